@@ -0,0 +1,117 @@
+package audio
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWAVRoundTrip(t *testing.T) {
+	pcm := Int16ToBytes([]int16{16384, 0, -16384, -16384})
+	wav, err := ParseWAV(EncodeWAV(pcm, 8000, 2))
+	require.NoError(t, err)
+	assert.Equal(t, Format{Encoding: EncodingWAV, SampleRate: 8000, Channels: 2, BitsPerSample: 16}, wav.Format)
+	assert.True(t, wav.PCM16())
+	assert.Equal(t, pcm, wav.Data)
+
+	// 双声道取平均
+	samples, rate, err := DecodeWAV(EncodeWAV(pcm, 8000, 2))
+	require.NoError(t, err)
+	assert.Equal(t, 8000, rate)
+	require.Len(t, samples, 2)
+	assert.InDelta(t, 0.25, samples[0], 1e-4)
+	assert.InDelta(t, -0.5, samples[1], 1e-4)
+
+	// 截断的文件按实际长度读取
+	wav, err = ParseWAV(EncodeWAV(make([]byte, 100), 16000, 1)[:94])
+	require.NoError(t, err)
+	assert.Len(t, wav.Data, 50)
+
+	_, err = ParseWAV([]byte("not a wav file"))
+	assert.Error(t, err)
+	_, err = ParseWAV(EncodeWAV(nil, 16000, 1)[:36])
+	assert.Error(t, err)
+}
+
+func TestPCMConversion(t *testing.T) {
+	samples := BytesToFloat32(Float32ToBytes([]float32{0.5, -0.5, 2}))
+	assert.InDelta(t, 0.5, samples[0], 1e-3)
+	assert.InDelta(t, -0.5, samples[1], 1e-3)
+	assert.InDelta(t, 1, samples[2], 1e-3)
+
+	assert.Equal(t, []int16{150, -50}, BytesToInt16(Int16ToBytes([]int16{100, 200, -100, 0}), 2))
+	assert.Equal(t, []float32{0.5}, Downmix([]float32{1, 0}, 2))
+
+	assert.Len(t, Resample(make([]float32, 160), 8000, 16000), 320)
+	assert.Len(t, ResampleInt16(make([]int16, 441), 44100, 16000), 160)
+	assert.Len(t, ConvertPCM(make([]byte, 24000*2*2), Format{SampleRate: 24000, Channels: 2}, 16000), 16000*2)
+
+	format := Format{Encoding: EncodingPCM, SampleRate: 16000}
+	assert.Equal(t, time.Second, format.Duration(32000))
+	assert.Equal(t, "pcm 16000Hz 1声道", format.String())
+}
+
+func TestParseMP3(t *testing.T) {
+	// ID3v2标签后接MPEG-1 Layer III、128kbps、44.1kHz、单声道的帧头
+	tag := []byte{'I', 'D', '3', 4, 0, 0, 0, 0, 0, 4, 0, 0, 0, 0}
+	frame := []byte{0xFF, 0xFB, 0x90, 0xC0}
+	data := append(tag, frame...)
+	assert.Equal(t, EncodingMP3, Detect(data))
+
+	format, err := ParseMP3(data)
+	require.NoError(t, err)
+	assert.Equal(t, Format{Encoding: EncodingMP3, SampleRate: 44100, Channels: 1, Bitrate: 128000}, format)
+
+	// MPEG-2、24kHz、立体声
+	format, err = ParseMP3([]byte{0xFF, 0xF3, 0x84, 0x00})
+	require.NoError(t, err)
+	assert.Equal(t, 24000, format.SampleRate)
+	assert.Equal(t, 2, format.Channels)
+
+	_, err = ParseMP3(make([]byte, 16))
+	assert.Error(t, err)
+}
+
+func TestDetect(t *testing.T) {
+	assert.Equal(t, EncodingWAV, Detect(EncodeWAV(nil, 16000, 1)))
+	pcm := make([]byte, 4)
+	binary.LittleEndian.PutUint16(pcm, 1000)
+	assert.Equal(t, EncodingPCM, Detect(pcm))
+}
+
+func TestG711(t *testing.T) {
+	for _, sample := range []int16{0, 100, -100, 1000, -1000, 32767, -32768} {
+		decoded := MulawToLinear(LinearToMulaw(sample))
+		tolerance := float64(sample) * 0.07
+		if tolerance < 0 {
+			tolerance = -tolerance
+		}
+		assert.InDelta(t, sample, decoded, tolerance+8, "sample %d", sample)
+	}
+
+	// A-law 最小和最大幅度的码字
+	assert.Equal(t, int16(8), AlawToLinear(0xD5))
+	assert.Equal(t, int16(-8), AlawToLinear(0x55))
+	assert.Equal(t, int16(32256), AlawToLinear(0xAA))
+	assert.Equal(t, int16(-32256), AlawToLinear(0x2A))
+}
+
+func TestPool(t *testing.T) {
+	buf := GetBytes(300)
+	assert.Len(t, buf, 300)
+	assert.Equal(t, 512, cap(buf))
+	PutBytes(buf)
+	// 外部分配的切片和超过最大档的切片不回收
+	PutBytes(make([]byte, 300))
+	assert.Len(t, GetBytes(1<<20), 1<<20)
+
+	samples := GetFloat32(3)
+	copy(samples, []float32{0.5, -0.5, 2})
+	data := Float32ToBytesBuffer(samples)
+	assert.Equal(t, Float32ToBytes(samples), data)
+	PutBytes(data)
+	PutFloat32(samples)
+}
@@ -0,0 +1,81 @@
+// Package audio 客户端和服务端共用的音频编解码工具：PCM采样转换、重采样、WAV/MP3/Opus编解码和格式描述
+package audio
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// 编码格式
+const (
+	EncodingPCM  = "pcm"  // 16位小端裸PCM
+	EncodingWAV  = "wav"  // WAV文件
+	EncodingMP3  = "mp3"  // MP3
+	EncodingOpus = "opus" // Opus数据包
+)
+
+// ErrCodecUnavailable 当前构建或运行环境不支持该编解码（如未启用opus构建标签、未安装ffmpeg）
+var ErrCodecUnavailable = errors.New("音频编解码不可用")
+
+// Format 音频格式描述
+type Format struct {
+	Encoding      string `json:"encoding" yaml:"encoding"`           // 编码格式: pcm|wav|mp3|opus
+	SampleRate    int    `json:"sample_rate" yaml:"sample_rate"`     // 采样率
+	Channels      int    `json:"channels,omitempty" yaml:"channels"` // 声道数（0表示单声道）
+	BitsPerSample int    `json:"bits_per_sample,omitempty" yaml:"-"` // 采样位数（PCM/WAV，0表示16位）
+	Bitrate       int    `json:"bitrate,omitempty" yaml:"bitrate"`   // 码率（bps，压缩格式）
+}
+
+// String 格式的可读描述（如“wav 16000Hz 1声道”）
+func (f Format) String() string {
+	return fmt.Sprintf("%s %dHz %d声道", f.Encoding, f.SampleRate, f.channels())
+}
+
+// channels 声道数（未设置时为单声道）
+func (f Format) channels() int {
+	if f.Channels <= 0 {
+		return 1
+	}
+	return f.Channels
+}
+
+// BytesPerSecond 未压缩PCM每秒的字节数
+func (f Format) BytesPerSecond() int {
+	bits := f.BitsPerSample
+	if bits <= 0 {
+		bits = 16
+	}
+	return f.SampleRate * f.channels() * bits / 8
+}
+
+// Duration 未压缩PCM数据的时长
+func (f Format) Duration(size int) time.Duration {
+	rate := f.BytesPerSecond()
+	if rate <= 0 {
+		return 0
+	}
+	return time.Duration(int64(size) * int64(time.Second) / int64(rate))
+}
+
+// Detect 按文件头识别编码格式，无法识别的视为裸PCM
+func Detect(data []byte) string {
+	switch {
+	case IsWAV(data):
+		return EncodingWAV
+	case IsMP3(data):
+		return EncodingMP3
+	default:
+		return EncodingPCM
+	}
+}
+
+// IsWAV 判断数据是否为WAV文件
+func IsWAV(data []byte) bool {
+	return len(data) >= 12 && string(data[0:4]) == "RIFF" && string(data[8:12]) == "WAVE"
+}
+
+// IsMP3 判断数据是否为MP3（ID3标签或帧同步字）
+func IsMP3(data []byte) bool {
+	return len(data) >= 3 && (string(data[0:3]) == "ID3" || (data[0] == 0xFF && data[1]&0xE0 == 0xE0))
+}
@@ -0,0 +1,55 @@
+package audio
+
+// G.711 μ-law 参数
+const (
+	mulawBias = 0x84
+	mulawClip = 32635
+)
+
+// LinearToMulaw 16bit线性采样转换为G.711 μ-law
+func LinearToMulaw(sample int16) byte {
+	s := int(sample)
+	sign := 0
+	if s < 0 {
+		s = -s
+		sign = 0x80
+	}
+	if s > mulawClip {
+		s = mulawClip
+	}
+	s += mulawBias
+
+	exponent := 7
+	for mask := 0x4000; s&mask == 0 && exponent > 0; mask >>= 1 {
+		exponent--
+	}
+	mantissa := (s >> (exponent + 3)) & 0x0F
+	return ^byte(sign | exponent<<4 | mantissa)
+}
+
+// MulawToLinear G.711 μ-law转换为16bit线性采样
+func MulawToLinear(u byte) int16 {
+	u = ^u
+	exponent := int(u>>4) & 0x07
+	mantissa := int(u & 0x0F)
+	s := ((mantissa << 3) + mulawBias) << exponent
+	s -= mulawBias
+	if u&0x80 != 0 {
+		return int16(-s)
+	}
+	return int16(s)
+}
+
+// AlawToLinear G.711 A-law转换为16bit线性采样
+func AlawToLinear(a byte) int16 {
+	a ^= 0x55
+	exponent := int(a>>4) & 0x07
+	s := int(a&0x0F)<<4 + 8
+	if exponent > 0 {
+		s = (s + 0x100) << (exponent - 1)
+	}
+	if a&0x80 == 0 {
+		return int16(-s)
+	}
+	return int16(s)
+}
@@ -0,0 +1,98 @@
+package audio
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+)
+
+// MP3帧头中的采样率（按MPEG版本）和码率（kbps，MPEG-1 Layer III / MPEG-2/2.5 Layer III）
+var (
+	mp3SampleRates = map[int][3]int{
+		3: {44100, 48000, 32000}, // MPEG-1
+		2: {22050, 24000, 16000}, // MPEG-2
+		0: {11025, 12000, 8000},  // MPEG-2.5
+	}
+	mp3BitratesV1 = [16]int{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0}
+	mp3BitratesV2 = [16]int{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0}
+)
+
+// ParseMP3 读取第一个MP3帧头中的格式（跳过ID3v2标签）
+func ParseMP3(data []byte) (Format, error) {
+	if len(data) >= 10 && string(data[0:3]) == "ID3" {
+		size := int(data[6]&0x7F)<<21 | int(data[7]&0x7F)<<14 | int(data[8]&0x7F)<<7 | int(data[9]&0x7F)
+		if data[5]&0x10 != 0 {
+			size += 10 // 标签尾
+		}
+		if 10+size > len(data) {
+			return Format{}, errors.New("MP3数据不完整")
+		}
+		data = data[10+size:]
+	}
+
+	for i := 0; i+4 <= len(data); i++ {
+		if data[i] != 0xFF || data[i+1]&0xE0 != 0xE0 {
+			continue
+		}
+		version := int(data[i+1]>>3) & 0x03
+		layer := int(data[i+1]>>1) & 0x03
+		bitrateIndex := int(data[i+2] >> 4)
+		rateIndex := int(data[i+2]>>2) & 0x03
+		rates, ok := mp3SampleRates[version]
+		if !ok || layer != 1 || rateIndex == 3 || bitrateIndex == 0 || bitrateIndex == 15 {
+			continue // 不是Layer III帧头
+		}
+
+		bitrate := mp3BitratesV2[bitrateIndex]
+		if version == 3 {
+			bitrate = mp3BitratesV1[bitrateIndex]
+		}
+		channels := 2
+		if data[i+3]>>6 == 3 {
+			channels = 1
+		}
+		return Format{
+			Encoding:   EncodingMP3,
+			SampleRate: rates[rateIndex],
+			Channels:   channels,
+			Bitrate:    bitrate * 1000,
+		}, nil
+	}
+	return Format{}, errors.New("未找到MP3帧头")
+}
+
+// DecodeMP3 用ffmpeg把MP3解码为指定采样率的16位单声道PCM（未安装ffmpeg时返回ErrCodecUnavailable）
+func DecodeMP3(ctx context.Context, data []byte, sampleRate int) ([]byte, error) {
+	return ffmpeg(ctx, data, "-f", "mp3", "-i", "pipe:0",
+		"-f", "s16le", "-acodec", "pcm_s16le", "-ac", "1", "-ar", strconv.Itoa(sampleRate), "pipe:1")
+}
+
+// EncodeMP3 用ffmpeg把16位PCM编码为MP3（bitrate为0时按64kbps，未安装ffmpeg时返回ErrCodecUnavailable）
+func EncodeMP3(ctx context.Context, pcm []byte, format Format) ([]byte, error) {
+	bitrate := format.Bitrate
+	if bitrate <= 0 {
+		bitrate = 64000
+	}
+	return ffmpeg(ctx, pcm, "-f", "s16le", "-ar", strconv.Itoa(format.SampleRate), "-ac", strconv.Itoa(format.channels()), "-i", "pipe:0",
+		"-f", "mp3", "-b:a", strconv.Itoa(bitrate), "pipe:1")
+}
+
+// ffmpeg 通过标准输入输出调用ffmpeg转换音频
+func ffmpeg(ctx context.Context, input []byte, args ...string) ([]byte, error) {
+	path, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		return nil, fmt.Errorf("%w: 未找到ffmpeg", ErrCodecUnavailable)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, path, append([]string{"-hide_banner", "-loglevel", "error"}, args...)...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("ffmpeg转换失败: %w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return stdout.Bytes(), nil
+}
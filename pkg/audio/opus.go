@@ -0,0 +1,67 @@
+//go:build opus
+
+package audio
+
+import (
+	"fmt"
+
+	"github.com/hraban/opus"
+)
+
+// opusMaxPacketSize 单个Opus数据包的最大字节数
+const opusMaxPacketSize = 1275
+
+// OpusEncoder Opus编码器（需要cgo和libopus，使用opus构建标签）
+type OpusEncoder struct {
+	encoder *opus.Encoder
+}
+
+// NewOpusEncoder 创建语音场景的Opus编码器
+func NewOpusEncoder(sampleRate, channels int) (*OpusEncoder, error) {
+	encoder, err := opus.NewEncoder(sampleRate, channels, opus.AppVoIP)
+	if err != nil {
+		return nil, fmt.Errorf("创建Opus编码器失败: %w", err)
+	}
+	return &OpusEncoder{encoder: encoder}, nil
+}
+
+// Encode 编码一帧采样（帧长须为2.5/5/10/20/40/60毫秒）
+func (e *OpusEncoder) Encode(samples []int16) ([]byte, error) {
+	packet := make([]byte, opusMaxPacketSize)
+	n, err := e.encoder.Encode(samples, packet)
+	if err != nil {
+		return nil, err
+	}
+	return packet[:n], nil
+}
+
+// OpusDecoder Opus解码器（需要cgo和libopus，使用opus构建标签）
+type OpusDecoder struct {
+	decoder    *opus.Decoder
+	sampleRate int
+	channels   int
+}
+
+// NewOpusDecoder 创建Opus解码器
+func NewOpusDecoder(sampleRate, channels int) (*OpusDecoder, error) {
+	decoder, err := opus.NewDecoder(sampleRate, channels)
+	if err != nil {
+		return nil, fmt.Errorf("创建Opus解码器失败: %w", err)
+	}
+	return &OpusDecoder{decoder: decoder, sampleRate: sampleRate, channels: channels}, nil
+}
+
+// Decode 解码一个数据包（单包最长120毫秒）
+func (d *OpusDecoder) Decode(packet []byte) ([]int16, error) {
+	samples := make([]int16, d.sampleRate*120/1000*d.channels)
+	n, err := d.decoder.Decode(packet, samples)
+	if err != nil {
+		return nil, err
+	}
+	return samples[:n*d.channels], nil
+}
+
+// OpusAvailable 是否支持Opus编解码
+func OpusAvailable() bool {
+	return true
+}
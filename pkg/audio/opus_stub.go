@@ -0,0 +1,36 @@
+//go:build !opus
+
+package audio
+
+import "fmt"
+
+// OpusEncoder Opus编码器（未启用opus构建标签时不可用）
+type OpusEncoder struct{}
+
+// NewOpusEncoder 未启用opus构建标签，返回ErrCodecUnavailable
+func NewOpusEncoder(sampleRate, channels int) (*OpusEncoder, error) {
+	return nil, fmt.Errorf("%w: 未启用opus构建标签", ErrCodecUnavailable)
+}
+
+// Encode 编码一帧采样
+func (e *OpusEncoder) Encode(samples []int16) ([]byte, error) {
+	return nil, ErrCodecUnavailable
+}
+
+// OpusDecoder Opus解码器（未启用opus构建标签时不可用）
+type OpusDecoder struct{}
+
+// NewOpusDecoder 未启用opus构建标签，返回ErrCodecUnavailable
+func NewOpusDecoder(sampleRate, channels int) (*OpusDecoder, error) {
+	return nil, fmt.Errorf("%w: 未启用opus构建标签", ErrCodecUnavailable)
+}
+
+// Decode 解码一个数据包
+func (d *OpusDecoder) Decode(packet []byte) ([]int16, error) {
+	return nil, ErrCodecUnavailable
+}
+
+// OpusAvailable 是否支持Opus编解码
+func OpusAvailable() bool {
+	return false
+}
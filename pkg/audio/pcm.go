@@ -0,0 +1,126 @@
+package audio
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// BytesToFloat32 16位小端PCM转换为[-1,1)的float32采样
+func BytesToFloat32(data []byte) []float32 {
+	samples := make([]float32, len(data)/2)
+	for i := range samples {
+		samples[i] = float32(int16(binary.LittleEndian.Uint16(data[i*2:]))) / 32768
+	}
+	return samples
+}
+
+// Float32ToBytes float32采样转换为16位小端PCM（超出[-1,1]的采样截断）
+func Float32ToBytes(samples []float32) []byte {
+	data := make([]byte, len(samples)*2)
+	float32ToBytes(data, samples)
+	return data
+}
+
+// float32ToBytes 把采样转换到 data（长度至少为采样数的2倍）
+func float32ToBytes(data []byte, samples []float32) {
+	for i, sample := range samples {
+		sample = float32(math.Max(-1, math.Min(1, float64(sample))))
+		binary.LittleEndian.PutUint16(data[i*2:], uint16(int16(sample*32767)))
+	}
+}
+
+// BytesToInt16 16位小端PCM转换为采样（多声道时取平均混为单声道）
+func BytesToInt16(data []byte, channels int) []int16 {
+	if channels <= 0 {
+		channels = 1
+	}
+
+	frames := len(data) / (2 * channels)
+	samples := make([]int16, frames)
+	for i := 0; i < frames; i++ {
+		var sum int
+		for ch := 0; ch < channels; ch++ {
+			sum += int(int16(binary.LittleEndian.Uint16(data[(i*channels+ch)*2:])))
+		}
+		samples[i] = int16(sum / channels)
+	}
+	return samples
+}
+
+// Int16ToBytes 采样转换为16位小端PCM
+func Int16ToBytes(samples []int16) []byte {
+	data := make([]byte, len(samples)*2)
+	for i, s := range samples {
+		binary.LittleEndian.PutUint16(data[i*2:], uint16(s))
+	}
+	return data
+}
+
+// Downmix 多声道交错的float32采样取平均混为单声道
+func Downmix(samples []float32, channels int) []float32 {
+	if channels <= 1 {
+		return samples
+	}
+
+	mono := make([]float32, len(samples)/channels)
+	for i := range mono {
+		var sum float32
+		for ch := 0; ch < channels; ch++ {
+			sum += samples[i*channels+ch]
+		}
+		mono[i] = sum / float32(channels)
+	}
+	return mono
+}
+
+// Resample 线性插值重采样单声道float32采样
+func Resample(samples []float32, from, to int) []float32 {
+	if from == to || from <= 0 || to <= 0 || len(samples) == 0 {
+		return samples
+	}
+
+	n := int(int64(len(samples)) * int64(to) / int64(from))
+	result := make([]float32, n)
+	step := float64(from) / float64(to)
+	for i := range result {
+		pos := float64(i) * step
+		idx := int(pos)
+		if idx+1 >= len(samples) {
+			result[i] = samples[len(samples)-1]
+			continue
+		}
+		frac := float32(pos - float64(idx))
+		result[i] = samples[idx]*(1-frac) + samples[idx+1]*frac
+	}
+	return result
+}
+
+// ResampleInt16 线性插值重采样单声道16位采样
+func ResampleInt16(samples []int16, from, to int) []int16 {
+	if from == to || from <= 0 || to <= 0 || len(samples) == 0 {
+		return samples
+	}
+
+	n := int(int64(len(samples)) * int64(to) / int64(from))
+	result := make([]int16, n)
+	step := float64(from) / float64(to)
+	for i := range result {
+		pos := float64(i) * step
+		idx := int(pos)
+		if idx+1 >= len(samples) {
+			result[i] = samples[len(samples)-1]
+			continue
+		}
+		frac := pos - float64(idx)
+		result[i] = int16(float64(samples[idx])*(1-frac) + float64(samples[idx+1])*frac)
+	}
+	return result
+}
+
+// ConvertPCM 转换16位PCM的采样率，多声道混为单声道（格式相同时原样返回）
+func ConvertPCM(data []byte, from Format, sampleRate int) []byte {
+	if from.SampleRate == sampleRate && from.channels() == 1 {
+		return data
+	}
+	return Int16ToBytes(ResampleInt16(BytesToInt16(data, from.channels()), from.SampleRate, sampleRate))
+}
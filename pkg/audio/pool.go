@@ -0,0 +1,84 @@
+package audio
+
+import (
+	"math/bits"
+	"sync"
+)
+
+// 缓冲池按2的幂分档，超过最大档的缓冲区不回收（避免偶发的大块音频长期占用内存）
+const (
+	minPoolClass = 8  // 256个元素
+	maxPoolClass = 18 // 256K个元素
+)
+
+// bufferPool 按容量分档的切片池
+type bufferPool[T any] struct {
+	classes [maxPoolClass + 1]sync.Pool
+}
+
+// get 取出长度为n的切片（内容未清零）
+func (p *bufferPool[T]) get(n int) []T {
+	class := poolClass(n)
+	if class > maxPoolClass {
+		return make([]T, n)
+	}
+	if buf, ok := p.classes[class].Get().(*[]T); ok {
+		return (*buf)[:n]
+	}
+	return make([]T, n, 1<<class)
+}
+
+// put 归还切片（容量不是某一档的大小时丢弃，如外部分配的切片）
+func (p *bufferPool[T]) put(buf []T) {
+	c := cap(buf)
+	if c == 0 || c&(c-1) != 0 {
+		return
+	}
+	class := bits.Len(uint(c)) - 1
+	if class < minPoolClass || class > maxPoolClass {
+		return
+	}
+	buf = buf[:0]
+	p.classes[class].Put(&buf)
+}
+
+// poolClass 容纳n个元素的最小档
+func poolClass(n int) int {
+	if n <= 1<<minPoolClass {
+		return minPoolClass
+	}
+	return bits.Len(uint(n - 1))
+}
+
+var (
+	bytePool    bufferPool[byte]
+	float32Pool bufferPool[float32]
+)
+
+// GetBytes 从缓冲池取出长度为n的字节切片（内容未清零），用完后调用 PutBytes 归还
+func GetBytes(n int) []byte {
+	return bytePool.get(n)
+}
+
+// PutBytes 归还 GetBytes 取出的切片，归还后调用方不得再使用
+// 不是取自缓冲池的切片直接丢弃；未归还的切片由GC回收，只是失去复用。
+func PutBytes(buf []byte) {
+	bytePool.put(buf)
+}
+
+// GetFloat32 从缓冲池取出长度为n的float32切片（内容未清零），用完后调用 PutFloat32 归还
+func GetFloat32(n int) []float32 {
+	return float32Pool.get(n)
+}
+
+// PutFloat32 归还 GetFloat32 取出的切片，归还后调用方不得再使用
+func PutFloat32(buf []float32) {
+	float32Pool.put(buf)
+}
+
+// Float32ToBytesBuffer 与 Float32ToBytes 相同，结果取自缓冲池（用完后调用 PutBytes 归还）
+func Float32ToBytesBuffer(samples []float32) []byte {
+	data := GetBytes(len(samples) * 2)
+	float32ToBytes(data, samples)
+	return data
+}
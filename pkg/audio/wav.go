@@ -0,0 +1,158 @@
+package audio
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// WAV格式编码
+const (
+	wavFormatPCM        = 1
+	wavFormatFloat      = 3
+	wavFormatExtensible = 0xFFFE
+)
+
+// wavHeaderSize 标准PCM WAV文件头长度
+const wavHeaderSize = 44
+
+// WAV 解析后的WAV文件
+type WAV struct {
+	Format
+	Tag  int    // 格式编码（1为整型PCM，3为浮点）
+	Data []byte // 数据块（截断的文件按实际长度）
+}
+
+// ParseWAV 解析WAV文件的格式块和数据块，不复制采样数据
+func ParseWAV(data []byte) (*WAV, error) {
+	if !IsWAV(data) {
+		return nil, errors.New("不是WAV文件")
+	}
+
+	var wav *WAV
+	var body []byte
+	for pos := 12; pos+8 <= len(data); {
+		id := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		start := pos + 8
+		end := start + size
+		if end > len(data) || end < start {
+			end = len(data) // 截断的文件按实际长度读取
+		}
+
+		switch id {
+		case "fmt ":
+			if end-start < 16 {
+				return nil, errors.New("WAV格式块不完整")
+			}
+			wav = &WAV{
+				Format: Format{
+					Encoding:      EncodingWAV,
+					Channels:      int(binary.LittleEndian.Uint16(data[start+2:])),
+					SampleRate:    int(binary.LittleEndian.Uint32(data[start+4:])),
+					BitsPerSample: int(binary.LittleEndian.Uint16(data[start+14:])),
+				},
+				Tag: int(binary.LittleEndian.Uint16(data[start:])),
+			}
+			if wav.Tag == wavFormatExtensible && end-start >= 26 {
+				wav.Tag = int(binary.LittleEndian.Uint16(data[start+24:]))
+			}
+		case "data":
+			body = data[start:end]
+		}
+		if wav != nil && body != nil {
+			wav.Data = body
+			return wav, nil
+		}
+
+		pos = start + size + size&1 // 块按偶数字节对齐
+	}
+
+	if wav == nil {
+		return nil, errors.New("WAV缺少格式块")
+	}
+	return nil, errors.New("WAV缺少数据块")
+}
+
+// PCM16 是否为16位整型PCM（数据块可直接作为裸PCM使用）
+func (w *WAV) PCM16() bool {
+	return w.Tag == wavFormatPCM && w.BitsPerSample == 16
+}
+
+// DecodeWAV 解码WAV数据为单声道float32采样，返回采样和采样率
+// 支持8/16/24/32位整型和32位浮点，多声道取平均。
+func DecodeWAV(data []byte) ([]float32, int, error) {
+	wav, err := ParseWAV(data)
+	if err != nil {
+		return nil, 0, err
+	}
+	if wav.Channels <= 0 || wav.SampleRate <= 0 {
+		return nil, 0, errors.New("WAV声道数或采样率无效")
+	}
+
+	var decode func([]byte) float32
+	switch {
+	case wav.Tag == wavFormatPCM && wav.BitsPerSample == 8:
+		decode = func(b []byte) float32 { return (float32(b[0]) - 128) / 128 }
+	case wav.Tag == wavFormatPCM && wav.BitsPerSample == 16:
+		decode = func(b []byte) float32 { return float32(int16(binary.LittleEndian.Uint16(b))) / 32768 }
+	case wav.Tag == wavFormatPCM && wav.BitsPerSample == 24:
+		decode = func(b []byte) float32 {
+			return float32(int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24)>>8) / 8388608
+		}
+	case wav.Tag == wavFormatPCM && wav.BitsPerSample == 32:
+		decode = func(b []byte) float32 { return float32(int32(binary.LittleEndian.Uint32(b))) / 2147483648 }
+	case wav.Tag == wavFormatFloat && wav.BitsPerSample == 32:
+		decode = func(b []byte) float32 {
+			// 浮点采样可能超出[-1,1]，截断避免转换为整型时溢出
+			return float32(math.Max(-1, math.Min(1, float64(math.Float32frombits(binary.LittleEndian.Uint32(b))))))
+		}
+	default:
+		return nil, 0, fmt.Errorf("不支持的WAV编码: 格式%d, %d位", wav.Tag, wav.BitsPerSample)
+	}
+
+	width := wav.BitsPerSample / 8
+	frame := width * wav.Channels
+	samples := make([]float32, len(wav.Data)/frame)
+	for i := range samples {
+		var sum float32
+		for ch := 0; ch < wav.Channels; ch++ {
+			sum += decode(wav.Data[i*frame+ch*width:])
+		}
+		samples[i] = sum / float32(wav.Channels)
+	}
+	return samples, wav.SampleRate, nil
+}
+
+// WAVHeader 16位PCM WAV文件头（dataSize为数据块字节数，采样率和声道数为0时按16kHz单声道）
+func WAVHeader(dataSize, sampleRate, channels int) []byte {
+	if sampleRate <= 0 {
+		sampleRate = 16000
+	}
+	if channels <= 0 {
+		channels = 1
+	}
+
+	header := make([]byte, wavHeaderSize)
+	copy(header[0:], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:], uint32(36+dataSize))
+	copy(header[8:], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(header[16:], 16)
+	binary.LittleEndian.PutUint16(header[20:], wavFormatPCM)
+	binary.LittleEndian.PutUint16(header[22:], uint16(channels))
+	binary.LittleEndian.PutUint32(header[24:], uint32(sampleRate))
+	binary.LittleEndian.PutUint32(header[28:], uint32(sampleRate*channels*2))
+	binary.LittleEndian.PutUint16(header[32:], uint16(channels*2))
+	binary.LittleEndian.PutUint16(header[34:], 16)
+	copy(header[36:], "data")
+	binary.LittleEndian.PutUint32(header[40:], uint32(dataSize))
+	return header
+}
+
+// EncodeWAV 为16位PCM数据添加WAV文件头
+func EncodeWAV(pcm []byte, sampleRate, channels int) []byte {
+	wav := make([]byte, 0, wavHeaderSize+len(pcm))
+	wav = append(wav, WAVHeader(len(pcm), sampleRate, channels)...)
+	return append(wav, pcm...)
+}
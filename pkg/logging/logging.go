@@ -0,0 +1,110 @@
+// Package logging 客户端和服务端共用的日志输出设置：按 logging 配置把标准库log输出到标准输出或文件，
+// 写入文件时按大小轮转并清理旧文件，format为json时每行输出一个JSON对象。
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 日志输出位置
+const (
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
+	OutputFile   = "file"
+)
+
+// 日志格式
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Config 日志输出配置
+type Config struct {
+	Format     string // text|json
+	Output     string // stdout|stderr|file
+	FilePath   string // Output为file时的日志文件
+	MaxSize    int    // 单个文件的最大大小（MB，0表示默认100MB）
+	MaxBackups int    // 保留的轮转文件数（0表示不按数量清理）
+	MaxAge     int    // 轮转文件保留天数（0表示不按时间清理）
+	Compress   bool   // 轮转后gzip压缩
+}
+
+// Setup 按配置设置标准库log的输出，返回的Closer在程序退出时关闭日志文件
+func Setup(config Config) (io.Closer, error) {
+	var out io.Writer
+	var closer io.Closer = nopCloser{}
+
+	switch config.Output {
+	case "", OutputStdout:
+		out = os.Stdout
+	case OutputStderr:
+		out = os.Stderr
+	case OutputFile:
+		if config.FilePath == "" {
+			return nil, fmt.Errorf("日志输出为file时需配置日志文件路径")
+		}
+		file, err := OpenRotatingFile(config.FilePath, config.MaxSize, config.MaxBackups, config.MaxAge, config.Compress)
+		if err != nil {
+			return nil, err
+		}
+		out, closer = file, file
+	default:
+		return nil, fmt.Errorf("未知的日志输出: %s（可选 stdout、stderr、file）", config.Output)
+	}
+
+	switch config.Format {
+	case "", FormatText:
+		log.SetFlags(log.LstdFlags)
+	case FormatJSON:
+		log.SetFlags(0)
+		out = &jsonWriter{out: out}
+	default:
+		closer.Close()
+		return nil, fmt.Errorf("未知的日志格式: %s（可选 text、json）", config.Format)
+	}
+
+	log.SetOutput(out)
+	return closer, nil
+}
+
+// jsonWriter 把每行日志包装为 {"time": ..., "message": ...}
+type jsonWriter struct {
+	out io.Writer
+	mu  sync.Mutex
+}
+
+// jsonLine JSON格式的一行日志
+type jsonLine struct {
+	Time    string `json:"time"`
+	Message string `json:"message"`
+}
+
+// Write 实现 io.Writer（log包每条日志调用一次）
+func (w *jsonWriter) Write(p []byte) (int, error) {
+	data, err := json.Marshal(jsonLine{
+		Time:    time.Now().Format(time.RFC3339Nano),
+		Message: strings.TrimSuffix(string(p), "\n"),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, err := w.out.Write(append(data, '\n')); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
@@ -0,0 +1,59 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotatingFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "server.log")
+	f, err := OpenRotatingFile(path, 1, 2, 0, true)
+	require.NoError(t, err)
+	defer f.Close()
+
+	// 每次写入半个上限，共轮转3次，只保留最新的2个压缩文件
+	chunk := bytes.Repeat([]byte("x"), 512*1024)
+	for i := 0; i < 7; i++ {
+		_, err := f.Write(chunk)
+		require.NoError(t, err)
+	}
+
+	backups := f.backups()
+	require.Len(t, backups, 2)
+	for _, name := range backups {
+		assert.True(t, strings.HasSuffix(name, ".gz"), name)
+	}
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(chunk)), info.Size())
+}
+
+func TestSetupJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "client.log")
+	closer, err := Setup(Config{Format: FormatJSON, Output: OutputFile, FilePath: path})
+	require.NoError(t, err)
+	defer log.SetOutput(os.Stderr)
+	defer log.SetFlags(log.LstdFlags)
+
+	log.Printf("连接成功: %s", "ws://localhost")
+	require.NoError(t, closer.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var line jsonLine
+	require.NoError(t, json.Unmarshal(data, &line))
+	assert.Equal(t, "连接成功: ws://localhost", line.Message)
+	assert.NotEmpty(t, line.Time)
+
+	_, err = Setup(Config{Output: "syslog"})
+	assert.Error(t, err)
+}
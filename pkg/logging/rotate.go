@@ -0,0 +1,206 @@
+package logging
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultMaxSize 未配置大小时的单个日志文件上限（MB）
+const defaultMaxSize = 100
+
+// backupTimeFormat 轮转文件名中的时间格式（文件名中不能有冒号）
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// RotatingFile 按大小轮转的日志文件：超过上限时把当前文件改名为 <名称>-<时间><扩展名>，
+// 再按配置压缩并清理超出数量或保留天数的旧文件
+type RotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+	maxAge     time.Duration
+	compress   bool
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// OpenRotatingFile 打开（追加写入）日志文件，目录不存在时创建
+func OpenRotatingFile(path string, maxSizeMB, maxBackups, maxAgeDays int, compress bool) (*RotatingFile, error) {
+	if maxSizeMB <= 0 {
+		maxSizeMB = defaultMaxSize
+	}
+	f := &RotatingFile{
+		path:       path,
+		maxSize:    int64(maxSizeMB) * 1024 * 1024,
+		maxBackups: maxBackups,
+		maxAge:     time.Duration(maxAgeDays) * 24 * time.Hour,
+		compress:   compress,
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// open 打开当前日志文件
+func (f *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+		return fmt.Errorf("创建日志目录失败: %w", err)
+	}
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("打开日志文件失败: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("读取日志文件信息失败: %w", err)
+	}
+	f.file, f.size = file, info.Size()
+	return nil
+}
+
+// Write 实现 io.Writer，写入后超过上限的内容写到新文件
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+	if f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Close 关闭日志文件
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+// rotate 轮转当前文件并清理旧文件（调用方持有 f.mu）
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("关闭日志文件失败: %w", err)
+	}
+	f.file = nil
+
+	backup := f.backupName(time.Now())
+	if err := os.Rename(f.path, backup); err != nil {
+		return fmt.Errorf("轮转日志文件失败: %w", err)
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	// 旧文件的压缩和清理失败不影响继续写日志（持有锁时不能写log，错误输出到标准错误）
+	if f.compress {
+		if err := compressFile(backup); err != nil {
+			fmt.Fprintf(os.Stderr, "压缩日志文件失败: %v\n", err)
+		}
+	}
+	f.prune()
+	return nil
+}
+
+// backupName 轮转文件名
+func (f *RotatingFile) backupName(t time.Time) string {
+	ext := filepath.Ext(f.path)
+	return strings.TrimSuffix(f.path, ext) + "-" + t.Format(backupTimeFormat) + ext
+}
+
+// backups 已轮转的文件（按时间从新到旧）
+func (f *RotatingFile) backups() []string {
+	ext := filepath.Ext(f.path)
+	prefix := filepath.Base(strings.TrimSuffix(f.path, ext)) + "-"
+	entries, err := os.ReadDir(filepath.Dir(f.path))
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		stamp := strings.TrimSuffix(strings.TrimSuffix(name, ".gz"), ext)
+		if entry.IsDir() || !strings.HasPrefix(stamp, prefix) {
+			continue
+		}
+		if _, err := time.Parse(backupTimeFormat, strings.TrimPrefix(stamp, prefix)); err != nil {
+			continue
+		}
+		names = append(names, name)
+	}
+	// 时间格式按字典序即按时间排序
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+	for i, name := range names {
+		names[i] = filepath.Join(filepath.Dir(f.path), name)
+	}
+	return names
+}
+
+// prune 删除超出保留数量或保留天数的轮转文件
+func (f *RotatingFile) prune() {
+	cutoff := time.Now().Add(-f.maxAge)
+	for i, name := range f.backups() {
+		expired := f.maxAge > 0
+		if expired {
+			info, err := os.Stat(name)
+			expired = err == nil && info.ModTime().Before(cutoff)
+		}
+		if (f.maxBackups > 0 && i >= f.maxBackups) || expired {
+			if err := os.Remove(name); err != nil {
+				fmt.Fprintf(os.Stderr, "删除旧日志文件失败: %v\n", err)
+			}
+		}
+	}
+}
+
+// compressFile gzip压缩文件并删除原文件
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(path + ".gz")
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		dst.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		dst.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	src.Close()
+	return os.Remove(path)
+}
@@ -0,0 +1,188 @@
+package protocol
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+)
+
+// 消息校验的错误码
+const (
+	ErrInvalidMessage  = "INVALID_MESSAGE"   // 消息格式不符合协议（未知字段、字段类型错误等）
+	ErrMessageTooLarge = "MESSAGE_TOO_LARGE" // 消息或音频超过大小限制
+)
+
+// Limits 接收消息时的校验限制（各项为0表示不限制）
+type Limits struct {
+	MaxMessageSize        int  // 整条消息的字节数上限
+	MaxAudioSize          int  // 单块音频解码后的字节数上限（按base64长度预先检查，超限的音频不会解码）
+	MaxSessionIDLength    int  // 会话ID的字节数上限
+	MaxParameters         int  // 命令参数的个数上限
+	DisallowUnknownFields bool // 拒绝协议未定义的字段（严格模式）
+}
+
+// DefaultLimits 默认校验限制：单块音频1MB（16kHz单声道约30秒），整条消息按base64膨胀留出余量，不拒绝未知字段
+func DefaultLimits() Limits {
+	return Limits{
+		MaxMessageSize:     2 << 20,
+		MaxAudioSize:       1 << 20,
+		MaxSessionIDLength: 128,
+		MaxParameters:      64,
+	}
+}
+
+// envelope 消息外层结构（数据部分按消息类型再解析）
+type envelope struct {
+	Type      MessageType     `json:"type"`
+	SessionID string          `json:"session_id"`
+	Timestamp int64           `json:"timestamp"`
+	Seq       int64           `json:"seq,omitempty"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// rawAudioStreamData 音频流数据（音频保留base64原文，检查长度后再解码）
+type rawAudioStreamData struct {
+	Format    string          `json:"format"`
+	ChunkID   int             `json:"chunk_id"`
+	IsFinal   bool            `json:"is_final"`
+	AudioData json.RawMessage `json:"audio_data"`
+}
+
+// Decode 按校验限制解析一条收到的消息
+// 与 FromJSON 不同，数据与消息类型不符、超过大小限制或（严格模式下）含有未知字段时返回 *ErrorData。
+// 校验时解析出的数据随消息保存，之后取数据不再解析。
+func Decode(data []byte, limits Limits) (*Message, error) {
+	if limits.MaxMessageSize > 0 && len(data) > limits.MaxMessageSize {
+		return nil, tooLarge("消息大小 %d 字节超过上限 %d 字节", len(data), limits.MaxMessageSize)
+	}
+
+	var env envelope
+	if err := decodeStrict(data, &env, limits.DisallowUnknownFields); err != nil {
+		return nil, invalid("消息格式错误: %v", err)
+	}
+	if env.Type == "" {
+		return nil, invalid("消息类型不能为空")
+	}
+	if limits.MaxSessionIDLength > 0 && len(env.SessionID) > limits.MaxSessionIDLength {
+		return nil, invalid("会话ID长度 %d 超过上限 %d", len(env.SessionID), limits.MaxSessionIDLength)
+	}
+	if !utf8.ValidString(env.SessionID) {
+		return nil, invalid("会话ID不是有效的UTF-8")
+	}
+
+	msg := &Message{Type: env.Type, SessionID: env.SessionID, Timestamp: env.Timestamp, Seq: env.Seq, Data: env.Data}
+	if isNull(env.Data) {
+		return msg, nil
+	}
+
+	switch env.Type {
+	case AudioStream:
+		audio, err := decodeAudioStream(env.Data, limits)
+		if err != nil {
+			return nil, err
+		}
+		msg.decoded = audio
+	default:
+		typed := newData(env.Type)
+		if typed == nil {
+			if limits.DisallowUnknownFields {
+				return nil, invalid("未知的消息类型: %s", env.Type)
+			}
+			return msg, nil
+		}
+		if err := decodeStrict(env.Data, typed, limits.DisallowUnknownFields); err != nil {
+			return nil, invalid("%s消息数据格式错误: %v", env.Type, err)
+		}
+		msg.decoded = typed
+	}
+
+	if cmd, ok := msg.decoded.(*CommandData); ok && limits.MaxParameters > 0 && len(cmd.Parameters) > limits.MaxParameters {
+		return nil, invalid("命令参数个数 %d 超过上限 %d", len(cmd.Parameters), limits.MaxParameters)
+	}
+	return msg, nil
+}
+
+// decodeAudioStream 解析音频流数据：先按base64长度检查音频大小，再解码
+func decodeAudioStream(data json.RawMessage, limits Limits) (*AudioStreamData, error) {
+	var raw rawAudioStreamData
+	if err := decodeStrict(data, &raw, limits.DisallowUnknownFields); err != nil {
+		return nil, invalid("音频流数据格式错误: %v", err)
+	}
+
+	audio := &AudioStreamData{Format: raw.Format, ChunkID: raw.ChunkID, IsFinal: raw.IsFinal}
+	if isNull(raw.AudioData) {
+		return audio, nil
+	}
+
+	var encoded string
+	if err := json.Unmarshal(raw.AudioData, &encoded); err != nil {
+		return nil, invalid("音频数据必须是base64字符串")
+	}
+	if size := base64.StdEncoding.DecodedLen(len(encoded)); limits.MaxAudioSize > 0 && size > limits.MaxAudioSize {
+		return nil, tooLarge("音频块约 %d 字节超过上限 %d 字节", size, limits.MaxAudioSize)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, invalid("音频数据不是有效的base64: %v", err)
+	}
+	audio.AudioData = decoded
+	return audio, nil
+}
+
+// newData 消息类型对应的数据结构（未知类型返回nil）
+func newData(msgType MessageType) interface{} {
+	switch msgType {
+	case AudioStream:
+		return &AudioStreamData{}
+	case Command:
+		return &CommandData{}
+	case Response:
+		return &ResponseData{}
+	case Status:
+		return &StatusData{}
+	case Error:
+		return &ErrorData{}
+	case TimeSync:
+		return &TimeSyncData{}
+	case VoiceList:
+		return &VoiceListData{}
+	case Notification:
+		return &NotificationData{}
+	default:
+		return nil
+	}
+}
+
+// decodeStrict 解析JSON，strict 时拒绝未定义的字段和多余的内容
+func decodeStrict(data []byte, v interface{}, strict bool) error {
+	if !strict {
+		return json.Unmarshal(data, v)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if decoder.More() {
+		return fmt.Errorf("JSON之后有多余的内容")
+	}
+	return nil
+}
+
+// isNull 数据为空或为JSON null
+func isNull(data json.RawMessage) bool {
+	trimmed := bytes.TrimSpace(data)
+	return len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null"))
+}
+
+// invalid 构造消息格式错误
+func invalid(format string, args ...interface{}) *ErrorData {
+	return &ErrorData{Code: ErrInvalidMessage, Message: fmt.Sprintf(format, args...), Recoverable: true}
+}
+
+// tooLarge 构造超过大小限制的错误
+func tooLarge(format string, args ...interface{}) *ErrorData {
+	return &ErrorData{Code: ErrMessageTooLarge, Message: fmt.Sprintf(format, args...), Recoverable: true}
+}
@@ -0,0 +1,167 @@
+package protocol
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecode(t *testing.T) {
+	audio := NewAudioStreamMessage("s1", AudioFormatPCM, 2, true, []byte{1, 2, 3, 4})
+	data, err := audio.ToJSON()
+	require.NoError(t, err)
+
+	// Decode 校验时已解析的数据直接取出，FromJSON 保留原文、取数据时解析
+	msg, err := Decode(data, DefaultLimits())
+	require.NoError(t, err)
+	parsed, err := msg.AudioStreamData()
+	require.NoError(t, err)
+	assert.Equal(t, audio.Payload, parsed)
+	again, err := msg.AudioStreamData()
+	require.NoError(t, err)
+	assert.Same(t, parsed, again)
+	msg, err = FromJSON(data)
+	require.NoError(t, err)
+	parsed, err = msg.AudioStreamData()
+	require.NoError(t, err)
+	assert.Equal(t, audio.Payload, parsed)
+
+	// 音频按base64长度检查，超限时不解码
+	limits := DefaultLimits()
+	limits.MaxAudioSize = 3
+	_, err = Decode(data, limits)
+	assert.Equal(t, ErrMessageTooLarge, err.(*ErrorData).Code)
+
+	limits.MaxMessageSize = 10
+	_, err = Decode(data, limits)
+	assert.Equal(t, ErrMessageTooLarge, err.(*ErrorData).Code)
+
+	// 数据与消息类型不符：FromJSON 保留原文，取数据时报错；Decode 直接报错
+	mismatched := []byte(`{"type":"audio_stream","session_id":"s1","data":{"chunk_id":"x"}}`)
+	msg, err = FromJSON(mismatched)
+	require.NoError(t, err)
+	_, err = msg.AudioStreamData()
+	assert.Error(t, err)
+	_, err = Decode(mismatched, DefaultLimits())
+	assert.Equal(t, ErrInvalidMessage, err.(*ErrorData).Code)
+
+	_, err = Decode([]byte(`{"type":"audio_stream","data":{"audio_data":"!!!"}}`), DefaultLimits())
+	assert.Equal(t, ErrInvalidMessage, err.(*ErrorData).Code)
+
+	_, err = Decode([]byte(`{"type":"status","session_id":"`+strings.Repeat("a", 200)+`"}`), DefaultLimits())
+	assert.Equal(t, ErrInvalidMessage, err.(*ErrorData).Code)
+
+	// 未知字段只在严格模式下拒绝
+	extra := []byte(`{"type":"command","session_id":"s1","data":{"command":"get_status","extra":1}}`)
+	msg, err = Decode(extra, DefaultLimits())
+	require.NoError(t, err)
+	cmd, err := msg.CommandData()
+	require.NoError(t, err)
+	assert.Equal(t, "get_status", cmd.Command)
+
+	limits = DefaultLimits()
+	limits.DisallowUnknownFields = true
+	_, err = Decode(extra, limits)
+	assert.Equal(t, ErrInvalidMessage, err.(*ErrorData).Code)
+	_, err = Decode([]byte(`{"type":"unknown","session_id":"s1","data":{}}`), limits)
+	assert.Equal(t, ErrInvalidMessage, err.(*ErrorData).Code)
+}
+
+// FuzzDecode 任意输入都不应使解析崩溃；解析成功的消息重新序列化后应得到相同的结果
+func FuzzDecode(f *testing.F) {
+	for _, msg := range []*Message{
+		NewAudioStreamMessage("s1", AudioFormatPCM, 1, false, []byte("audio")),
+		NewCommandMessage("s1", CmdStartSession, ModeContinuous, map[string]interface{}{"text_only": true, "speed": 1.5}),
+		NewStatusMessage("s1", StatePlaybackFinished, "", 0),
+		NewTimeSyncMessage("s1", 1000),
+		NewResponseMessage("s1", StageTTS, "你好", 0.9, true, []byte{0, 1}),
+	} {
+		data, err := msg.ToJSON()
+		require.NoError(f, err)
+		f.Add(data)
+	}
+	f.Add([]byte(`{"type":"audio_stream","data":{"audio_data":"` + base64.StdEncoding.EncodeToString(make([]byte, 64)) + `"}}`))
+	f.Add([]byte(`{"type":"command","data":null}`))
+	f.Add([]byte(`{"type":"","data":[]}`))
+
+	strict := DefaultLimits()
+	strict.DisallowUnknownFields = true
+	f.Fuzz(func(t *testing.T, data []byte) {
+		FromJSON(data)
+		Decode(data, strict)
+
+		msg, err := Decode(data, DefaultLimits())
+		if err != nil {
+			require.IsType(t, &ErrorData{}, err)
+			return
+		}
+		if audio, err := msg.AudioStreamData(); msg.Type == AudioStream && err == nil {
+			assert.LessOrEqual(t, len(audio.AudioData), DefaultLimits().MaxAudioSize)
+		}
+
+		encoded, err := json.Marshal(msg)
+		require.NoError(t, err)
+		again, err := Decode(encoded, DefaultLimits())
+		require.NoError(t, err)
+		assert.Equal(t, msg.Type, again.Type)
+		assert.Equal(t, msg.SessionID, again.SessionID)
+	})
+}
+
+func TestParseData(t *testing.T) {
+	// 进程内构造的数据直接返回；自由格式的参数不是JSON类型时统一转换为JSON类型
+	local := NewCommandMessage("s1", CmdSetParameter, "", map[string]interface{}{"speed": 1.5, "tags": []interface{}{"a"}})
+	cmd, err := local.CommandData()
+	require.NoError(t, err)
+	assert.Same(t, local.Payload, cmd)
+
+	local = NewCommandMessage("s1", CmdSetParameter, "", map[string]interface{}{"speed": 2})
+	cmd, err = local.CommandData()
+	require.NoError(t, err)
+	assert.NotSame(t, local.Payload, cmd)
+	assert.Equal(t, float64(2), cmd.Parameters["speed"])
+
+	// 进程内的数据序列化后与收到的原文一致
+	data, err := local.ToJSON()
+	require.NoError(t, err)
+	received, err := FromJSON(data)
+	require.NoError(t, err)
+	assert.Nil(t, received.Payload)
+	assert.JSONEq(t, `{"command":"set_parameter","mode":"","parameters":{"speed":2}}`, string(received.Data))
+	cmd, err = received.CommandData()
+	require.NoError(t, err)
+	assert.Equal(t, float64(2), cmd.Parameters["speed"])
+
+	// 原始JSON只解析一次
+	status, err := ParseStatusData(json.RawMessage(`{"state":"playback_finished"}`))
+	require.NoError(t, err)
+	assert.Equal(t, StatePlaybackFinished, status.State)
+}
+
+// BenchmarkAudioStream 收到一块100ms音频：解析消息并取出音频数据
+func BenchmarkAudioStream(b *testing.B) {
+	data, err := NewAudioStreamMessage("s1", AudioFormatPCM, 1, false, make([]byte, 3200)).ToJSON()
+	require.NoError(b, err)
+
+	b.Run("raw", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			msg, _ := FromJSON(data)
+			msg.AudioStreamData()
+		}
+	})
+	// 对照：数据按通用JSON对象解析后再经序列化转换（旧版的解析方式）
+	b.Run("generic", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var msg struct{ Data interface{} }
+			json.Unmarshal(data, &msg)
+			jsonData, _ := json.Marshal(msg.Data)
+			ParseAudioStreamData(jsonData)
+		}
+	})
+}
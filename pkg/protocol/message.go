@@ -14,14 +14,22 @@ const (
 	Response    MessageType = "response"
 	Status      MessageType = "status"
 	Error       MessageType = "error"
+	TimeSync    MessageType = "time_sync"
+	VoiceList   MessageType = "voice_list"
+
+	Notification MessageType = "notification" // 服务端主动推送的通知（提醒、系统告警、版本更新等）
 )
 
 // Message 基础消息结构
 type Message struct {
-	Type      MessageType `json:"type"`
-	SessionID string      `json:"session_id"`
-	Timestamp int64       `json:"timestamp"`
-	Data      interface{} `json:"data"`
+	Type      MessageType     `json:"type"`
+	SessionID string          `json:"session_id"`
+	Timestamp int64           `json:"timestamp"`
+	Seq       int64           `json:"seq,omitempty"` // 消息序号（服务端按连接递增，断线重连时据此补发遗漏的消息）
+	Data      json.RawMessage `json:"data"`          // 数据的JSON原文（收到的消息），按消息类型用同名方法取出
+	Payload   interface{}     `json:"-"`             // 进程内构造的数据（对应结构体的指针），不经JSON直接传递，序列化时写入 data
+
+	decoded interface{} // Decode 校验时已解析的数据，取数据时不再解析
 }
 
 // AudioStreamData 音频流数据
@@ -51,6 +59,25 @@ const (
 	CmdInterrupt    = "interrupt"
 	CmdClearContext = "clear_context"
 	CmdSetParameter = "set_parameter"
+	CmdSetLanguage  = "set_language"
+
+	CmdSetDataCollection = "set_data_collection"
+	CmdForgetMemory      = "forget_memory"
+	CmdListVoices        = "list_voices"
+	CmdSetPersona        = "set_persona"
+	CmdWake              = "wake"             // 唤醒事件（唤醒词模式下开始接受下一轮音频）
+	CmdAckNotification   = "ack_notification" // 确认收到通知（参数 id）
+	CmdInviteMember      = "invite_member"    // 会话所有者生成加入码，邀请其他客户端加入（参数 role，默认 listener）
+	CmdJoinSession       = "join_session"     // 用加入码加入其他客户端的会话（参数 code、role），之后的回复同时发给所有成员
+	CmdLeaveSession      = "leave_session"    // 退出加入的会话
+	CmdHandover          = "handover"         // 生成会话转移令牌，把对话转移到其他设备
+	CmdClaimHandover     = "claim_handover"   // 用转移令牌接管其他设备的对话（参数 token）
+)
+
+// 会话成员角色
+const (
+	RoleSpeaker  = "speaker"  // 可以发送音频
+	RoleListener = "listener" // 只接收回复
 )
 
 // 模式常量
@@ -59,6 +86,8 @@ const (
 	ModeWakeword   = "wakeword"
 	ModeInterrupt  = "interrupt"
 	ModeSingle     = "single"
+	ModePushToTalk = "push_to_talk"
+	ModeDuplex     = "duplex" // 全双工：播放回复时也保持录音，用户可直接插话
 )
 
 // ResponseData 服务端响应数据
@@ -68,7 +97,49 @@ type ResponseData struct {
 	Confidence float64                `json:"confidence"`           // 置信度
 	IsFinal    bool                   `json:"is_final"`             // 是否为最终结果
 	AudioData  []byte                 `json:"audio_data,omitempty"` // 音频数据（TTS结果）
+	Words      []WordTiming           `json:"words,omitempty"`      // 词级别时间戳（ASR结果）
+	PlayAt     int64                  `json:"play_at,omitempty"`    // 计划播放时间（服务端时钟，毫秒；0表示立即播放）
 	Metadata   map[string]interface{} `json:"metadata,omitempty"`   // 元数据
+
+	// TTS语音分片：较长的语音拆成多条响应依次下发（TotalChunks为0表示未分片）
+	ChunkIndex  int `json:"chunk_index,omitempty"`  // 分片序号（从0开始）
+	TotalChunks int `json:"total_chunks,omitempty"` // 分片总数
+
+	// 分句合成：较长的回复按句子并行合成、按顺序下发，每句再按上面的方式分片（TotalSegments为0表示未分句）
+	Segment       int `json:"segment,omitempty"`        // 句子序号（从0开始）
+	TotalSegments int `json:"total_segments,omitempty"` // 句子总数
+
+	AudioFormat *AudioFormat `json:"audio_format,omitempty"` // AudioData的音频格式（为空时按16kHz单声道PCM处理）
+}
+
+// AudioFormat 下发语音的音频格式
+type AudioFormat struct {
+	Format     string `json:"format"`             // 编码格式
+	SampleRate int    `json:"sample_rate"`        // 采样率
+	Channels   int    `json:"channels,omitempty"` // 声道数（0表示单声道）
+}
+
+// 音频编码格式常量
+const (
+	AudioFormatPCM = "pcm" // 16bit小端裸PCM
+	AudioFormatWAV = "wav"
+	AudioFormatMP3 = "mp3"
+)
+
+// WordTiming 词级别时间信息
+type WordTiming struct {
+	Text       string  `json:"text"`       // 词文本
+	StartTime  int64   `json:"start_time"` // 开始时间（毫秒，相对于音频起点）
+	EndTime    int64   `json:"end_time"`   // 结束时间（毫秒，相对于音频起点）
+	Confidence float64 `json:"confidence"` // 置信度
+}
+
+// TimeSyncData 时钟同步数据（毫秒时间戳）
+// 客户端根据四个时间戳估算与服务端的时钟偏差和往返延迟。
+type TimeSyncData struct {
+	ClientSendTime    int64 `json:"client_send_time"`    // 客户端发送时间（客户端时钟）
+	ServerReceiveTime int64 `json:"server_receive_time"` // 服务端接收时间（服务端时钟）
+	ServerSendTime    int64 `json:"server_send_time"`    // 服务端发送时间（服务端时钟）
 }
 
 // 处理阶段常量
@@ -80,10 +151,90 @@ const (
 
 // StatusData 状态数据
 type StatusData struct {
-	State             string       `json:"state"`                  // 当前状态
-	Mode              string       `json:"mode"`                   // 当前模式
-	ConcurrentStreams int          `json:"concurrent_streams"`     // 并发流数量
-	SessionInfo       *SessionInfo `json:"session_info,omitempty"` // 会话信息
+	State             string       `json:"state"`                   // 当前状态
+	Mode              string       `json:"mode"`                    // 当前模式
+	ConcurrentStreams int          `json:"concurrent_streams"`      // 并发流数量
+	SessionInfo       *SessionInfo `json:"session_info,omitempty"`  // 会话信息
+	Quota             *QuotaStatus `json:"quota,omitempty"`         // 资源配额使用情况（get_status返回）
+	Resumed           bool         `json:"resumed,omitempty"`       // 重连后恢复了原会话（连接确认时返回）
+	ResumeToken       string       `json:"resume_token,omitempty"`  // 恢复会话的令牌（启用会话恢复时在连接确认中返回，重连时作为 resume_token 参数）
+	Persona           string       `json:"persona,omitempty"`       // 当前人设ID
+	APIKeyUsage       *UsageTotals `json:"api_key_usage,omitempty"` // 所属API Key本计费周期的用量（get_status返回）
+	Members           []Member     `json:"members,omitempty"`       // 会话成员（有其他客户端加入时返回）
+	Invite            *Invite      `json:"invite,omitempty"`        // 加入码（invite_member返回）
+	Handover          *Handover    `json:"handover,omitempty"`      // 会话转移（handover和claim_handover返回，对话被接管时通知原设备）
+	History           []Turn       `json:"history,omitempty"`       // 接管的对话历史（claim_handover返回）
+	Scheduling        *Scheduling  `json:"scheduling,omitempty"`    // 本会话在处理工作池中的请求（与 concurrent_streams 一起反映服务端负载）
+	Close             *CloseInfo   `json:"close,omitempty"`         // 服务端主动关闭连接的原因（state为closing时）
+}
+
+// CloseInfo 服务端主动关闭连接的原因
+// 服务端发送state为closing的状态后等待客户端回复close_ack（或超时），再以Code发送WebSocket关闭帧。
+type CloseInfo struct {
+	Code      int    `json:"code"`             // 随后使用的WebSocket关闭码
+	Reason    string `json:"reason,omitempty"` // 关闭原因
+	Reconnect bool   `json:"reconnect"`        // 客户端是否应自动重连
+}
+
+// WebSocket关闭码（4000以上为本协议自定义）
+const (
+	CloseNormal            = 1000 // 正常关闭
+	CloseGoingAway         = 1001 // 服务端关闭或重启，稍后可重连
+	CloseSessionTerminated = 4001 // 会话被管理员终止，不应自动重连
+	CloseSlowConsumer      = 4002 // 客户端消费过慢，发送队列已满（可凭会话恢复重连）
+	CloseResumeRejected    = 4003 // 恢复会话的令牌或API Key不匹配，不应用同一会话ID重连
+	CloseSessionInUse      = 4004 // 会话ID已被其他连接使用，不应用同一会话ID重连
+)
+
+// CloseCodeAllowsReconnect 未收到关闭通知时按关闭码判断是否应重连（终止会话、拒绝恢复和会话ID冲突的关闭码不重连）
+func CloseCodeAllowsReconnect(code int) bool {
+	return code != CloseSessionTerminated && code != CloseResumeRejected && code != CloseSessionInUse
+}
+
+// Scheduling 会话在处理工作池中的请求数
+type Scheduling struct {
+	Active        int `json:"active"`                    // 正在处理的请求数
+	Queued        int `json:"queued"`                    // 排队等待的请求数（含超出会话并发上限的请求）
+	MaxPerSession int `json:"max_per_session,omitempty"` // 每个阶段的会话并发上限（0表示不限制）
+}
+
+// Handover 会话转移信息
+type Handover struct {
+	Token       string `json:"token,omitempty"`       // 转移令牌（一次有效）
+	ExpiresAt   int64  `json:"expires_at,omitempty"`  // 令牌过期时间（服务端时钟，毫秒）
+	From        string `json:"from,omitempty"`        // 原会话ID（接管成功时返回）
+	Transferred bool   `json:"transferred,omitempty"` // 对话已被其他设备接管，本会话已结束
+}
+
+// Turn 对话历史中的一条消息
+type Turn struct {
+	Role      string `json:"role"` // user|assistant
+	Content   string `json:"content"`
+	Timestamp int64  `json:"timestamp,omitempty"` // 毫秒
+}
+
+// Member 会话成员（每个连接一个）
+type Member struct {
+	ConnectionID string `json:"connection_id"`
+	Role         string `json:"role"` // speaker|listener
+}
+
+// Invite 会话所有者发出的加入邀请
+type Invite struct {
+	Code      string `json:"code"`       // 加入码（一次有效）
+	Role      string `json:"role"`       // 加入后的角色：speaker|listener
+	ExpiresAt int64  `json:"expires_at"` // 过期时间（服务端时钟，毫秒）
+}
+
+// QuotaStatus 资源配额使用情况（上限为0表示不限制）
+type QuotaStatus struct {
+	TurnsUsed         int     `json:"turns_used"`          // 最近一小时对话轮数
+	TurnsLimit        int     `json:"turns_limit"`         // 每小时轮数上限
+	AudioMinutesUsed  float64 `json:"audio_minutes_used"`  // 当日音频分钟数
+	AudioMinutesLimit float64 `json:"audio_minutes_limit"` // 每日音频分钟数上限
+	TokensUsed        int     `json:"tokens_used"`         // 当日Token用量
+	TokensLimit       int     `json:"tokens_limit"`        // 每日Token上限
+	Exceeded          string  `json:"exceeded,omitempty"`  // 已超出的配额: turns, audio, tokens, budget
 }
 
 // 状态常量
@@ -95,23 +246,90 @@ const (
 	StateError        = "error"
 	StateConnected    = "connected"
 	StateDisconnected = "disconnected"
+	StateClosing      = "closing" // 服务端即将关闭连接（见StatusData.Close）
+
+	// 客户端上报的状态
+	StatePlaybackFinished = "playback_finished" // 本轮TTS语音已播放完毕
+	StateCloseAck         = "close_ack"         // 已收到服务端的关闭通知
+	StateSpeechStart      = "speech_start"      // 客户端VAD检测到用户开始说话
+	StateSpeechEnd        = "speech_end"        // 客户端VAD检测到用户说完（之前的音频块均已发送）
 )
 
 // SessionInfo 会话信息
 type SessionInfo struct {
-	ID           string    `json:"id"`
-	StartTime    time.Time `json:"start_time"`
-	LastActivity time.Time `json:"last_activity"`
-	MessageCount int       `json:"message_count"`
-	Duration     int64     `json:"duration"` // 秒
+	ID           string       `json:"id"`
+	StartTime    time.Time    `json:"start_time"`
+	LastActivity time.Time    `json:"last_activity"`
+	MessageCount int          `json:"message_count"`
+	Duration     int64        `json:"duration"`        // 秒
+	Usage        *UsageTotals `json:"usage,omitempty"` // 会话累计用量（启用用量统计时返回）
+}
+
+// UsageTotals 累计用量
+type UsageTotals struct {
+	Turns            int     `json:"turns"`             // 对话轮数
+	PromptTokens     int     `json:"prompt_tokens"`     // LLM提示Token数
+	CompletionTokens int     `json:"completion_tokens"` // LLM生成Token数
+	TotalTokens      int     `json:"total_tokens"`      // LLM总Token数
+	ASRSeconds       float64 `json:"asr_seconds"`       // 送入识别的音频秒数
+	TTSSeconds       float64 `json:"tts_seconds"`       // 合成的音频秒数
+	Cost             float64 `json:"cost"`              // 按配置单价估算的费用
+}
+
+// VoiceListData 声音列表（list_voices命令的返回）
+type VoiceListData struct {
+	Provider string      `json:"provider"` // TTS提供商
+	Voices   []VoiceInfo `json:"voices"`   // 可用声音
+}
+
+// VoiceInfo 声音信息（id可用于start_session的voice参数）
+type VoiceInfo struct {
+	ID          string `json:"id"`                     // 声音ID
+	Name        string `json:"name,omitempty"`         // 声音名称
+	DisplayName string `json:"display_name,omitempty"` // 显示名称
+	Language    string `json:"language,omitempty"`     // 语言代码
+	Gender      string `json:"gender,omitempty"`       // 性别
+	Description string `json:"description,omitempty"`  // 描述
 }
 
+// NotificationData 服务端主动推送的通知
+type NotificationData struct {
+	ID          string                 `json:"id"`                     // 通知ID
+	Kind        string                 `json:"kind"`                   // 通知类型: message, alert, update, timer, reminder
+	Level       string                 `json:"level,omitempty"`        // 重要程度: info, warning, critical（为空时为info）
+	Title       string                 `json:"title,omitempty"`        // 标题
+	Content     string                 `json:"content"`                // 通知文本
+	AudioData   []byte                 `json:"audio_data,omitempty"`   // 播报语音（不播报或仅文本模式时为空）
+	AudioFormat *AudioFormat           `json:"audio_format,omitempty"` // 播报语音的音频格式
+	Data        map[string]interface{} `json:"data,omitempty"`         // 附加数据（如版本更新的 version、url）
+	RequireAck  bool                   `json:"require_ack,omitempty"`  // 客户端需回复 ack_notification 命令确认，未确认时重新开始会话后重发
+	DueAt       int64                  `json:"due_at,omitempty"`       // 计划时间（提醒和计时器，服务端时钟，毫秒）
+	Missed      bool                   `json:"missed,omitempty"`       // 到期时会话不在线，重新开始会话后补发
+}
+
+// 通知类型常量
+const (
+	NotifyMessage  = "message"
+	NotifyAlert    = "alert"
+	NotifyUpdate   = "update"
+	NotifyTimer    = "timer"
+	NotifyReminder = "reminder"
+)
+
+// 通知重要程度常量
+const (
+	NotifyInfo     = "info"
+	NotifyWarning  = "warning"
+	NotifyCritical = "critical"
+)
+
 // ErrorData 错误数据
 type ErrorData struct {
-	Code        string                 `json:"code"`              // 错误代码
-	Message     string                 `json:"message"`           // 错误消息
-	Recoverable bool                   `json:"recoverable"`       // 是否可恢复
-	Details     map[string]interface{} `json:"details,omitempty"` // 错误详情
+	Code        string                 `json:"code"`                // 错误代码
+	Message     string                 `json:"message"`             // 错误消息
+	Recoverable bool                   `json:"recoverable"`         // 是否可恢复
+	Retryable   bool                   `json:"retryable,omitempty"` // 稍后重试可能成功（限流、超时、服务暂时不可用）
+	Details     map[string]interface{} `json:"details,omitempty"`   // 错误详情
 }
 
 // 错误代码常量
@@ -126,19 +344,36 @@ const (
 	ErrTTSFailed               = "TTS_FAILED"
 	ErrSessionNotFound         = "SESSION_NOT_FOUND"
 	ErrSessionLimitExceeded    = "SESSION_LIMIT_EXCEEDED"
+	ErrSessionTerminated       = "SESSION_TERMINATED"
+	ErrSessionEnded            = "SESSION_ENDED"
+	ErrNotAwake                = "NOT_AWAKE"
 	ErrConnectionFailed        = "CONNECTION_FAILED"
 	ErrAuthenticationFailed    = "AUTHENTICATION_FAILED"
 	ErrRateLimitExceeded       = "RATE_LIMIT_EXCEEDED"
+	ErrQuotaExceeded           = "QUOTA_EXCEEDED"
+	ErrContentRefused          = "CONTENT_REFUSED"
+	ErrUtteranceTooLong        = "UTTERANCE_TOO_LONG"
+	ErrAudioBufferOverflow     = "AUDIO_BUFFER_OVERFLOW"
 	ErrInternalError           = "INTERNAL_ERROR"
+
+	// ASR/LLM/TTS服务调用失败的分类
+	ErrServiceUnavailable    = "SERVICE_UNAVAILABLE"     // 服务未初始化、连接失败或暂时不可用（可重试）
+	ErrServerBusy            = "SERVER_BUSY"             // 处理队列已满（可重试）
+	ErrTimeout               = "TIMEOUT"                 // 处理超时（可重试）
+	ErrProviderAuthFailed    = "PROVIDER_AUTH_FAILED"    // 服务API密钥缺失或无效
+	ErrProviderQuotaExceeded = "PROVIDER_QUOTA_EXCEEDED" // 服务额度已用尽
+	ErrProviderMisconfigured = "PROVIDER_MISCONFIGURED"  // 服务配置错误（模型不存在、加载失败等）
+	ErrUnsupportedLanguage   = "UNSUPPORTED_LANGUAGE"    // 服务不支持该语言
+	ErrInvalidInput          = "INVALID_INPUT"           // 请求内容无效（文本过长、声音不存在等）
 )
 
-// NewMessage 创建新消息
+// NewMessage 创建新消息（data 为进程内的数据，见 Message.Payload）
 func NewMessage(msgType MessageType, sessionID string, data interface{}) *Message {
 	return &Message{
 		Type:      msgType,
 		SessionID: sessionID,
 		Timestamp: time.Now().UnixNano() / int64(time.Millisecond),
-		Data:      data,
+		Payload:   data,
 	}
 }
 
@@ -195,11 +430,34 @@ func NewErrorMessage(sessionID string, code, message string, recoverable bool) *
 	return NewMessage(Error, sessionID, data)
 }
 
+// NewTimeSyncMessage 创建时钟同步请求消息
+func NewTimeSyncMessage(sessionID string, clientSendTime int64) *Message {
+	data := &TimeSyncData{
+		ClientSendTime: clientSendTime,
+	}
+	return NewMessage(TimeSync, sessionID, data)
+}
+
 // ToJSON 将消息转换为JSON
 func (m *Message) ToJSON() ([]byte, error) {
 	return json.Marshal(m)
 }
 
+// MarshalJSON 序列化消息，有进程内数据时以其作为 data
+func (m *Message) MarshalJSON() ([]byte, error) {
+	type wire Message
+	if m.Payload == nil {
+		return json.Marshal((*wire)(m))
+	}
+	data, err := json.Marshal(m.Payload)
+	if err != nil {
+		return nil, err
+	}
+	msg := wire(*m)
+	msg.Data = data
+	return json.Marshal(&msg)
+}
+
 // FromJSON 从JSON创建消息
 func FromJSON(data []byte) (*Message, error) {
 	var msg Message
@@ -209,79 +467,132 @@ func FromJSON(data []byte) (*Message, error) {
 	return &msg, nil
 }
 
-// ParseAudioStreamData 解析音频流数据
-func ParseAudioStreamData(data interface{}) (*AudioStreamData, error) {
-	jsonData, err := json.Marshal(data)
-	if err != nil {
-		return nil, err
-	}
+// AudioStreamData 取出消息中的音频流数据
+func (m *Message) AudioStreamData() (*AudioStreamData, error) {
+	return messageData[AudioStreamData](m, nil)
+}
 
-	var audioData AudioStreamData
-	if err := json.Unmarshal(jsonData, &audioData); err != nil {
-		return nil, err
-	}
+// CommandData 取出消息中的命令数据（参数统一为JSON类型，如数字为float64，进程内构造的命令也不例外）
+func (m *Message) CommandData() (*CommandData, error) {
+	return messageData(m, func(d *CommandData) bool { return jsonValue(d.Parameters) })
+}
 
-	return &audioData, nil
+// ResponseData 取出消息中的响应数据
+func (m *Message) ResponseData() (*ResponseData, error) {
+	return messageData(m, func(d *ResponseData) bool { return jsonValue(d.Metadata) })
 }
 
-// ParseCommandData 解析命令数据
-func ParseCommandData(data interface{}) (*CommandData, error) {
-	jsonData, err := json.Marshal(data)
-	if err != nil {
-		return nil, err
-	}
+// StatusData 取出消息中的状态数据
+func (m *Message) StatusData() (*StatusData, error) { return messageData[StatusData](m, nil) }
 
-	var cmdData CommandData
-	if err := json.Unmarshal(jsonData, &cmdData); err != nil {
-		return nil, err
-	}
+// ErrorData 取出消息中的错误数据
+func (m *Message) ErrorData() (*ErrorData, error) { return messageData[ErrorData](m, nil) }
 
-	return &cmdData, nil
+// TimeSyncData 取出消息中的时钟同步数据
+func (m *Message) TimeSyncData() (*TimeSyncData, error) { return messageData[TimeSyncData](m, nil) }
+
+// VoiceListData 取出消息中的声音列表数据
+func (m *Message) VoiceListData() (*VoiceListData, error) { return messageData[VoiceListData](m, nil) }
+
+// NotificationData 取出消息中的通知数据（附加数据统一为JSON类型）
+func (m *Message) NotificationData() (*NotificationData, error) {
+	return messageData(m, func(d *NotificationData) bool { return jsonValue(d.Data) })
 }
 
-// ParseResponseData 解析响应数据
-func ParseResponseData(data interface{}) (*ResponseData, error) {
-	jsonData, err := json.Marshal(data)
-	if err != nil {
-		return nil, err
-	}
+// ParseAudioStreamData 解析音频流数据
+func ParseAudioStreamData(data json.RawMessage) (*AudioStreamData, error) {
+	return unmarshalData[AudioStreamData](data)
+}
 
-	var respData ResponseData
-	if err := json.Unmarshal(jsonData, &respData); err != nil {
-		return nil, err
-	}
+// ParseCommandData 解析命令数据
+func ParseCommandData(data json.RawMessage) (*CommandData, error) {
+	return unmarshalData[CommandData](data)
+}
 
-	return &respData, nil
+// ParseResponseData 解析响应数据
+func ParseResponseData(data json.RawMessage) (*ResponseData, error) {
+	return unmarshalData[ResponseData](data)
 }
 
 // ParseStatusData 解析状态数据
-func ParseStatusData(data interface{}) (*StatusData, error) {
-	jsonData, err := json.Marshal(data)
-	if err != nil {
-		return nil, err
-	}
+func ParseStatusData(data json.RawMessage) (*StatusData, error) {
+	return unmarshalData[StatusData](data)
+}
 
-	var statusData StatusData
-	if err := json.Unmarshal(jsonData, &statusData); err != nil {
-		return nil, err
-	}
+// ParseErrorData 解析错误数据
+func ParseErrorData(data json.RawMessage) (*ErrorData, error) {
+	return unmarshalData[ErrorData](data)
+}
 
-	return &statusData, nil
+// ParseTimeSyncData 解析时钟同步数据
+func ParseTimeSyncData(data json.RawMessage) (*TimeSyncData, error) {
+	return unmarshalData[TimeSyncData](data)
 }
 
-// ParseErrorData 解析错误数据
-func ParseErrorData(data interface{}) (*ErrorData, error) {
-	jsonData, err := json.Marshal(data)
-	if err != nil {
-		return nil, err
+// ParseVoiceListData 解析声音列表数据
+func ParseVoiceListData(data json.RawMessage) (*VoiceListData, error) {
+	return unmarshalData[VoiceListData](data)
+}
+
+// ParseNotificationData 解析通知数据
+func ParseNotificationData(data json.RawMessage) (*NotificationData, error) {
+	return unmarshalData[NotificationData](data)
+}
+
+// messageData 取出消息数据并解析为T
+// 依次使用进程内的数据、Decode 已解析的数据和JSON原文；原文只解析一次，不再经过序列化转换。
+// 进程内的数据类型不符，或其中的自由格式字段不是JSON类型（normalized 返回false）时，经序列化转换。
+func messageData[T any](m *Message, normalized func(*T) bool) (*T, error) {
+	if m.Payload != nil {
+		if d, ok := m.Payload.(*T); ok && d != nil && (normalized == nil || normalized(d)) {
+			return d, nil
+		}
+		data, err := json.Marshal(m.Payload)
+		if err != nil {
+			return nil, err
+		}
+		return unmarshalData[T](data)
+	}
+	if d, ok := m.decoded.(*T); ok {
+		return d, nil
 	}
+	return unmarshalData[T](m.Data)
+}
 
-	var errorData ErrorData
-	if err := json.Unmarshal(jsonData, &errorData); err != nil {
+// unmarshalData 从JSON解析T（数据为空时为零值）
+func unmarshalData[T any](data []byte) (*T, error) {
+	var v T
+	if isNull(data) {
+		return &v, nil
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
 		return nil, err
 	}
+	return &v, nil
+}
 
-	return &errorData, nil
+// jsonValue 值是否只由JSON解码得到的类型组成（nil、bool、float64、string及其切片和映射）
+func jsonValue(v interface{}) bool {
+	switch v := v.(type) {
+	case nil, bool, float64, string:
+		return true
+	case []interface{}:
+		for _, item := range v {
+			if !jsonValue(item) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		for _, item := range v {
+			if !jsonValue(item) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
 }
 
 // IsRecoverable 检查错误是否可恢复
@@ -319,7 +630,7 @@ func ValidateMessage(msg *Message) error {
 		}
 	}
 
-	if msg.Data == nil {
+	if msg.Payload == nil && isNull(msg.Data) {
 		return &ErrorData{
 			Code:    ErrInvalidCommandData,
 			Message: "消息数据不能为空",
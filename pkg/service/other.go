@@ -0,0 +1,22 @@
+//go:build !linux && !windows
+
+package service
+
+import "context"
+
+func install(config Config) error { return ErrUnsupported }
+
+func uninstall(name string) error { return ErrUnsupported }
+
+func start(name string) error { return ErrUnsupported }
+
+func stop(name string) error { return ErrUnsupported }
+
+func queryStatus(name string) (Status, error) { return StatusUnknown, ErrUnsupported }
+
+// runService 前台运行
+func runService(name string, run func(ctx context.Context) error) error {
+	return runForeground(run)
+}
+
+func notify(state string) {}
@@ -0,0 +1,120 @@
+// Package service 客户端和服务端共用的系统服务集成：Linux下安装为systemd服务，Windows下安装为Windows服务，
+// 以服务方式运行时响应服务管理器的停止请求并报告运行状态，异常退出后由服务管理器自动重启。
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrUnsupported 当前操作系统不支持安装系统服务
+var ErrUnsupported = errors.New("当前系统不支持安装为系统服务")
+
+// ErrNotInstalled 服务尚未安装
+var ErrNotInstalled = errors.New("服务未安装")
+
+// defaultRestartDelay 异常退出后重启前的默认等待时间
+const defaultRestartDelay = 5 * time.Second
+
+// Config 服务安装配置
+type Config struct {
+	Name         string        // 服务名（systemd单元名、Windows服务名）
+	DisplayName  string        // 显示名称
+	Description  string        // 描述
+	Executable   string        // 可执行文件（为空时使用当前程序）
+	Arguments    []string      // 启动参数（通常为 -config <配置文件绝对路径> run）
+	WorkingDir   string        // 工作目录（为空时使用可执行文件所在目录，配置中的相对路径按此解析）
+	User         string        // 运行用户（仅systemd，为空时以root运行）
+	After        []string      // 在这些单元之后启动（仅systemd，默认 network-online.target）
+	RestartDelay time.Duration // 异常退出后重启前的等待时间（默认5秒）
+}
+
+// Status 服务状态
+type Status string
+
+const (
+	StatusRunning      Status = "running"
+	StatusStopped      Status = "stopped"
+	StatusStarting     Status = "starting"
+	StatusStopping     Status = "stopping"
+	StatusNotInstalled Status = "not_installed"
+	StatusUnknown      Status = "unknown"
+)
+
+// normalize 补全可执行文件、工作目录和重启等待时间
+func (c Config) normalize() (Config, error) {
+	if c.Name == "" {
+		return c, errors.New("服务名不能为空")
+	}
+	if c.Executable == "" {
+		exe, err := os.Executable()
+		if err != nil {
+			return c, fmt.Errorf("获取可执行文件路径失败: %w", err)
+		}
+		c.Executable = exe
+	}
+	exe, err := filepath.Abs(c.Executable)
+	if err != nil {
+		return c, fmt.Errorf("解析可执行文件路径失败: %w", err)
+	}
+	c.Executable = exe
+	if c.WorkingDir == "" {
+		c.WorkingDir = filepath.Dir(exe)
+	}
+	if c.DisplayName == "" {
+		c.DisplayName = c.Name
+	}
+	if c.RestartDelay <= 0 {
+		c.RestartDelay = defaultRestartDelay
+	}
+	return c, nil
+}
+
+// Install 安装服务并设置为开机自启动（需要管理员/root权限）
+func Install(config Config) error {
+	config, err := config.normalize()
+	if err != nil {
+		return err
+	}
+	return install(config)
+}
+
+// Uninstall 停止并删除服务（需要管理员/root权限）
+func Uninstall(name string) error {
+	return uninstall(name)
+}
+
+// Start 启动已安装的服务
+func Start(name string) error {
+	return start(name)
+}
+
+// Stop 停止服务
+func Stop(name string) error {
+	return stop(name)
+}
+
+// QueryStatus 查询服务状态（未安装时返回 StatusNotInstalled）
+func QueryStatus(name string) (Status, error) {
+	return queryStatus(name)
+}
+
+// Run 运行程序主体：由Windows服务管理器启动时按服务协议运行，否则在前台运行。
+// 收到停止请求（服务停止、SIGINT、SIGTERM）时取消ctx，run返回后结束；run返回错误时以失败状态退出，服务管理器据此自动重启。
+func Run(name string, run func(ctx context.Context) error) error {
+	return runService(name, run)
+}
+
+// Ready 通知服务管理器启动完成（systemd Type=notify；其他情况下无操作）
+func Ready() {
+	notify("READY=1")
+}
+
+// Stopping 通知服务管理器正在停止
+func Stopping() {
+	notify("STOPPING=1")
+}
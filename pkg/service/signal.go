@@ -0,0 +1,30 @@
+package service
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// runForeground 前台运行，收到SIGINT、SIGTERM时取消ctx
+func runForeground(run func(ctx context.Context) error) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	go func() {
+		select {
+		case sig := <-signals:
+			log.Printf("收到信号: %v", sig)
+			Stopping()
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return run(ctx)
+}
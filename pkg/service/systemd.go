@@ -0,0 +1,175 @@
+//go:build linux
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// unitDir systemd单元文件目录（测试时可替换）
+var unitDir = "/etc/systemd/system"
+
+// unitPath 服务的单元文件路径
+func unitPath(name string) string {
+	return filepath.Join(unitDir, name+".service")
+}
+
+// systemdUnit 生成单元文件：Type=notify 由程序启动完成后通知就绪，异常退出后按 RestartDelay 自动重启
+func systemdUnit(config Config) string {
+	after := config.After
+	if len(after) == 0 {
+		after = []string{"network-online.target"}
+	}
+
+	var b strings.Builder
+	b.WriteString("[Unit]\n")
+	fmt.Fprintf(&b, "Description=%s\n", firstNonEmpty(config.Description, config.DisplayName))
+	fmt.Fprintf(&b, "After=%s\n", strings.Join(after, " "))
+	fmt.Fprintf(&b, "Wants=%s\n", strings.Join(after, " "))
+	b.WriteString("StartLimitIntervalSec=0\n")
+	b.WriteString("\n[Service]\n")
+	b.WriteString("Type=notify\n")
+	b.WriteString("NotifyAccess=main\n")
+	fmt.Fprintf(&b, "ExecStart=%s\n", execLine(config.Executable, config.Arguments))
+	fmt.Fprintf(&b, "WorkingDirectory=%s\n", config.WorkingDir)
+	if config.User != "" {
+		fmt.Fprintf(&b, "User=%s\n", config.User)
+	}
+	b.WriteString("Restart=on-failure\n")
+	fmt.Fprintf(&b, "RestartSec=%d\n", int(config.RestartDelay.Seconds()+0.5))
+	b.WriteString("TimeoutStopSec=30\n")
+	b.WriteString("\n[Install]\n")
+	b.WriteString("WantedBy=multi-user.target\n")
+	return b.String()
+}
+
+// execLine 拼接ExecStart命令行（含空格或引号的参数加引号）
+func execLine(executable string, args []string) string {
+	parts := make([]string, 0, len(args)+1)
+	for _, arg := range append([]string{executable}, args...) {
+		if arg == "" || strings.ContainsAny(arg, " \t\"'\\") {
+			arg = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`
+		}
+		parts = append(parts, arg)
+	}
+	return strings.Join(parts, " ")
+}
+
+// firstNonEmpty 返回第一个非空字符串
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// install 写入单元文件并设置开机自启动
+func install(config Config) error {
+	path := unitPath(config.Name)
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("服务已安装: %s", path)
+	}
+	if err := os.WriteFile(path, []byte(systemdUnit(config)), 0644); err != nil {
+		return fmt.Errorf("写入单元文件失败: %w", err)
+	}
+	if err := systemctl("daemon-reload"); err != nil {
+		return err
+	}
+	return systemctl("enable", config.Name)
+}
+
+// uninstall 停止服务、取消自启动并删除单元文件
+func uninstall(name string) error {
+	path := unitPath(name)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return ErrNotInstalled
+	}
+	if err := systemctl("disable", "--now", name); err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("删除单元文件失败: %w", err)
+	}
+	return systemctl("daemon-reload")
+}
+
+// start 启动服务
+func start(name string) error {
+	if _, err := os.Stat(unitPath(name)); os.IsNotExist(err) {
+		return ErrNotInstalled
+	}
+	return systemctl("start", name)
+}
+
+// stop 停止服务
+func stop(name string) error {
+	if _, err := os.Stat(unitPath(name)); os.IsNotExist(err) {
+		return ErrNotInstalled
+	}
+	return systemctl("stop", name)
+}
+
+// queryStatus 按 systemctl is-active 的输出查询状态
+func queryStatus(name string) (Status, error) {
+	if _, err := os.Stat(unitPath(name)); os.IsNotExist(err) {
+		return StatusNotInstalled, nil
+	}
+	// 服务未运行时 is-active 以非0退出码返回，只看输出
+	out, _ := exec.Command("systemctl", "is-active", name).Output()
+	switch strings.TrimSpace(string(out)) {
+	case "active", "reloading":
+		return StatusRunning, nil
+	case "activating":
+		return StatusStarting, nil
+	case "deactivating":
+		return StatusStopping, nil
+	case "inactive", "failed":
+		return StatusStopped, nil
+	default:
+		return StatusUnknown, nil
+	}
+}
+
+// systemctl 执行systemctl命令，失败时带上命令输出
+func systemctl(args ...string) error {
+	var output bytes.Buffer
+	cmd := exec.Command("systemctl", args...)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("systemctl %s 失败: %v %s", strings.Join(args, " "), err, strings.TrimSpace(output.String()))
+	}
+	return nil
+}
+
+// runService systemd以前台进程方式运行服务，停止时发送SIGTERM
+func runService(name string, run func(ctx context.Context) error) error {
+	return runForeground(run)
+}
+
+// notify 向systemd发送状态通知（未由systemd以Type=notify启动时无操作）
+func notify(state string) {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return
+	}
+	// 以@开头的是抽象命名空间套接字
+	if socket[0] == '@' {
+		socket = "\x00" + socket[1:]
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	conn.Write([]byte(state))
+}
@@ -0,0 +1,42 @@
+//go:build linux
+
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSystemdUnit(t *testing.T) {
+	config, err := Config{
+		Name:         "voice-assistant-server",
+		Description:  "语音助手服务端",
+		Executable:   "/opt/voice assistant/server",
+		Arguments:    []string{"-config", "/etc/voice_assistant/server.yaml", "run"},
+		User:         "pi",
+		RestartDelay: 3 * time.Second,
+	}.normalize()
+	require.NoError(t, err)
+
+	unit := systemdUnit(config)
+	assert.Contains(t, unit, "Description=语音助手服务端\n")
+	assert.Contains(t, unit, "After=network-online.target\n")
+	assert.Contains(t, unit, "Type=notify\n")
+	assert.Contains(t, unit, `ExecStart="/opt/voice assistant/server" -config /etc/voice_assistant/server.yaml run`+"\n")
+	assert.Contains(t, unit, "WorkingDirectory=/opt/voice assistant\n")
+	assert.Contains(t, unit, "User=pi\n")
+	assert.Contains(t, unit, "Restart=on-failure\nRestartSec=3\n")
+}
+
+func TestQueryStatusNotInstalled(t *testing.T) {
+	unitDir = t.TempDir()
+	defer func() { unitDir = "/etc/systemd/system" }()
+
+	status, err := QueryStatus("voice-assistant-server")
+	require.NoError(t, err)
+	assert.Equal(t, StatusNotInstalled, status)
+	assert.ErrorIs(t, Uninstall("voice-assistant-server"), ErrNotInstalled)
+}
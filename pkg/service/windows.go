@@ -0,0 +1,227 @@
+//go:build windows
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// install 注册Windows服务：开机自动启动，异常退出后由服务管理器按 RestartDelay 重启
+func install(config Config) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("连接服务管理器失败: %w", err)
+	}
+	defer m.Disconnect()
+
+	if s, err := m.OpenService(config.Name); err == nil {
+		s.Close()
+		return fmt.Errorf("服务已安装: %s", config.Name)
+	}
+
+	s, err := m.CreateService(config.Name, config.Executable, mgr.Config{
+		DisplayName: config.DisplayName,
+		Description: config.Description,
+		StartType:   mgr.StartAutomatic,
+	}, config.Arguments...)
+	if err != nil {
+		return fmt.Errorf("创建服务失败: %w", err)
+	}
+	defer s.Close()
+
+	// 连续失败时每次都重启，一天内没有失败则重新计数
+	actions := []mgr.RecoveryAction{
+		{Type: mgr.ServiceRestart, Delay: config.RestartDelay},
+		{Type: mgr.ServiceRestart, Delay: config.RestartDelay},
+		{Type: mgr.ServiceRestart, Delay: config.RestartDelay},
+	}
+	if err := s.SetRecoveryActions(actions, uint32((24 * time.Hour).Seconds())); err != nil {
+		return fmt.Errorf("设置服务重启策略失败: %w", err)
+	}
+	// 以失败的退出码停止时也执行重启策略
+	if err := s.SetRecoveryActionsOnNonCrashFailures(true); err != nil {
+		return fmt.Errorf("设置服务重启策略失败: %w", err)
+	}
+	return nil
+}
+
+// openService 打开已安装的服务（未安装时返回 ErrNotInstalled）
+func openService(name string) (*mgr.Mgr, *mgr.Service, error) {
+	m, err := mgr.Connect()
+	if err != nil {
+		return nil, nil, fmt.Errorf("连接服务管理器失败: %w", err)
+	}
+	s, err := m.OpenService(name)
+	if err != nil {
+		m.Disconnect()
+		if errors.Is(err, windows.ERROR_SERVICE_DOES_NOT_EXIST) {
+			return nil, nil, ErrNotInstalled
+		}
+		return nil, nil, fmt.Errorf("打开服务失败: %w", err)
+	}
+	return m, s, nil
+}
+
+// uninstall 停止并删除服务
+func uninstall(name string) error {
+	m, s, err := openService(name)
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+	defer s.Close()
+
+	if status, err := s.Query(); err == nil && status.State != svc.Stopped {
+		if err := stopAndWait(s); err != nil {
+			return err
+		}
+	}
+	if err := s.Delete(); err != nil {
+		return fmt.Errorf("删除服务失败: %w", err)
+	}
+	return nil
+}
+
+// start 启动服务
+func start(name string) error {
+	m, s, err := openService(name)
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+	defer s.Close()
+
+	if err := s.Start(); err != nil {
+		return fmt.Errorf("启动服务失败: %w", err)
+	}
+	return nil
+}
+
+// stop 停止服务并等待停止完成
+func stop(name string) error {
+	m, s, err := openService(name)
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+	defer s.Close()
+	return stopAndWait(s)
+}
+
+// stopAndWait 发送停止请求并等待服务停止（最多30秒）
+func stopAndWait(s *mgr.Service) error {
+	status, err := s.Control(svc.Stop)
+	if err != nil {
+		return fmt.Errorf("停止服务失败: %w", err)
+	}
+	deadline := time.Now().Add(30 * time.Second)
+	for status.State != svc.Stopped {
+		if time.Now().After(deadline) {
+			return errors.New("等待服务停止超时")
+		}
+		time.Sleep(300 * time.Millisecond)
+		if status, err = s.Query(); err != nil {
+			return fmt.Errorf("查询服务状态失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// queryStatus 查询服务状态
+func queryStatus(name string) (Status, error) {
+	m, s, err := openService(name)
+	if errors.Is(err, ErrNotInstalled) {
+		return StatusNotInstalled, nil
+	}
+	if err != nil {
+		return StatusUnknown, err
+	}
+	defer m.Disconnect()
+	defer s.Close()
+
+	status, err := s.Query()
+	if err != nil {
+		return StatusUnknown, fmt.Errorf("查询服务状态失败: %w", err)
+	}
+	switch status.State {
+	case svc.Running:
+		return StatusRunning, nil
+	case svc.StartPending:
+		return StatusStarting, nil
+	case svc.StopPending:
+		return StatusStopping, nil
+	case svc.Stopped:
+		return StatusStopped, nil
+	default:
+		return StatusUnknown, nil
+	}
+}
+
+// runService 由服务管理器启动时按服务协议运行，否则在前台运行
+func runService(name string, run func(ctx context.Context) error) error {
+	isService, err := svc.IsWindowsService()
+	if err != nil {
+		return fmt.Errorf("检测运行方式失败: %w", err)
+	}
+	if !isService {
+		return runForeground(run)
+	}
+
+	handler := &serviceHandler{run: run}
+	if err := svc.Run(name, handler); err != nil {
+		return fmt.Errorf("运行服务失败: %w", err)
+	}
+	return handler.err
+}
+
+// serviceHandler 把服务管理器的停止请求转换为取消ctx
+type serviceHandler struct {
+	run func(ctx context.Context) error
+	err error
+}
+
+// Execute 实现 svc.Handler
+func (h *serviceHandler) Execute(args []string, requests <-chan svc.ChangeRequest, changes chan<- svc.Status) (bool, uint32) {
+	changes <- svc.Status{State: svc.StartPending}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- h.run(ctx) }()
+
+	changes <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+	for {
+		select {
+		case err := <-done:
+			// 程序自行退出：返回错误时以失败退出码结束，服务管理器按重启策略重启
+			h.err = err
+			if err != nil {
+				log.Printf("服务异常退出: %v", err)
+				return true, 1
+			}
+			return false, 0
+		case req := <-requests:
+			switch req.Cmd {
+			case svc.Interrogate:
+				changes <- req.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				log.Printf("收到服务停止请求")
+				changes <- svc.Status{State: svc.StopPending}
+				cancel()
+				h.err = <-done
+				return false, 0
+			}
+		}
+	}
+}
+
+// notify Windows服务管理器在 Execute 中报告状态，无需额外通知
+func notify(state string) {}
@@ -0,0 +1,348 @@
+package vagrpc
+
+import (
+	"fmt"
+	"time"
+
+	"voice_assistant/pkg/protocol"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// FromClientMessage 将gRPC客户端消息转换为协议消息
+func FromClientMessage(m *ClientMessage) (*protocol.Message, error) {
+	msg := &protocol.Message{
+		SessionID: m.GetSessionId(),
+		Timestamp: m.GetTimestamp(),
+	}
+
+	switch payload := m.GetPayload().(type) {
+	case *ClientMessage_Audio:
+		msg.Type = protocol.AudioStream
+		msg.Payload = &protocol.AudioStreamData{
+			Format:    payload.Audio.GetFormat(),
+			ChunkID:   int(payload.Audio.GetChunkId()),
+			IsFinal:   payload.Audio.GetIsFinal(),
+			AudioData: payload.Audio.GetAudioData(),
+		}
+	case *ClientMessage_Command:
+		msg.Type = protocol.Command
+		msg.Payload = &protocol.CommandData{
+			Command:    payload.Command.GetCommand(),
+			Mode:       payload.Command.GetMode(),
+			Parameters: payload.Command.GetParameters().AsMap(),
+		}
+	case *ClientMessage_TimeSync:
+		msg.Type = protocol.TimeSync
+		msg.Payload = fromTimeSync(payload.TimeSync)
+	default:
+		return nil, fmt.Errorf("未知的客户端消息类型: %T", payload)
+	}
+
+	return msg, nil
+}
+
+// ToClientMessage 将协议消息转换为gRPC客户端消息
+func ToClientMessage(msg *protocol.Message) (*ClientMessage, error) {
+	m := &ClientMessage{
+		SessionId: msg.SessionID,
+		Timestamp: msg.Timestamp,
+	}
+
+	switch msg.Type {
+	case protocol.AudioStream:
+		data, err := msg.AudioStreamData()
+		if err != nil {
+			return nil, err
+		}
+		m.Payload = &ClientMessage_Audio{Audio: &AudioChunk{
+			Format:    data.Format,
+			ChunkId:   int32(data.ChunkID),
+			IsFinal:   data.IsFinal,
+			AudioData: data.AudioData,
+		}}
+	case protocol.Command:
+		data, err := msg.CommandData()
+		if err != nil {
+			return nil, err
+		}
+		parameters, err := toStruct(data.Parameters)
+		if err != nil {
+			return nil, fmt.Errorf("转换命令参数失败: %w", err)
+		}
+		m.Payload = &ClientMessage_Command{Command: &Command{
+			Command:    data.Command,
+			Mode:       data.Mode,
+			Parameters: parameters,
+		}}
+	case protocol.TimeSync:
+		data, err := msg.TimeSyncData()
+		if err != nil {
+			return nil, err
+		}
+		m.Payload = &ClientMessage_TimeSync{TimeSync: toTimeSync(data)}
+	default:
+		return nil, fmt.Errorf("不支持的客户端消息类型: %s", msg.Type)
+	}
+
+	return m, nil
+}
+
+// FromServerMessage 将gRPC服务端消息转换为协议消息
+func FromServerMessage(m *ServerMessage) (*protocol.Message, error) {
+	msg := &protocol.Message{
+		SessionID: m.GetSessionId(),
+		Timestamp: m.GetTimestamp(),
+	}
+
+	switch payload := m.GetPayload().(type) {
+	case *ServerMessage_Response:
+		r := payload.Response
+		msg.Type = protocol.Response
+		msg.Payload = &protocol.ResponseData{
+			Stage:      r.GetStage(),
+			Content:    r.GetContent(),
+			Confidence: r.GetConfidence(),
+			IsFinal:    r.GetIsFinal(),
+			AudioData:  r.GetAudioData(),
+			Words:      fromWordTimings(r.GetWords()),
+			PlayAt:     r.GetPlayAt(),
+			Metadata:   fromStruct(r.GetMetadata()),
+
+			ChunkIndex:  int(r.GetChunkIndex()),
+			TotalChunks: int(r.GetTotalChunks()),
+
+			Segment:       int(r.GetSegment()),
+			TotalSegments: int(r.GetTotalSegments()),
+		}
+	case *ServerMessage_Status:
+		s := payload.Status
+		msg.Type = protocol.Status
+		msg.Payload = &protocol.StatusData{
+			State:             s.GetState(),
+			Mode:              s.GetMode(),
+			ConcurrentStreams: int(s.GetConcurrentStreams()),
+			SessionInfo:       fromSessionInfo(s.GetSessionInfo()),
+			Quota:             fromQuotaStatus(s.GetQuota()),
+		}
+	case *ServerMessage_Error:
+		e := payload.Error
+		msg.Type = protocol.Error
+		msg.Payload = &protocol.ErrorData{
+			Code:        e.GetCode(),
+			Message:     e.GetMessage(),
+			Recoverable: e.GetRecoverable(),
+			Details:     fromStruct(e.GetDetails()),
+		}
+	case *ServerMessage_TimeSync:
+		msg.Type = protocol.TimeSync
+		msg.Payload = fromTimeSync(payload.TimeSync)
+	default:
+		return nil, fmt.Errorf("未知的服务端消息类型: %T", payload)
+	}
+
+	return msg, nil
+}
+
+// ToServerMessage 将协议消息转换为gRPC服务端消息
+func ToServerMessage(msg *protocol.Message) (*ServerMessage, error) {
+	m := &ServerMessage{
+		SessionId: msg.SessionID,
+		Timestamp: msg.Timestamp,
+	}
+
+	switch msg.Type {
+	case protocol.Response:
+		data, err := msg.ResponseData()
+		if err != nil {
+			return nil, err
+		}
+		metadata, err := toStruct(data.Metadata)
+		if err != nil {
+			return nil, fmt.Errorf("转换响应元数据失败: %w", err)
+		}
+		m.Payload = &ServerMessage_Response{Response: &Response{
+			Stage:      data.Stage,
+			Content:    data.Content,
+			Confidence: data.Confidence,
+			IsFinal:    data.IsFinal,
+			AudioData:  data.AudioData,
+			Words:      toWordTimings(data.Words),
+			PlayAt:     data.PlayAt,
+			Metadata:   metadata,
+
+			ChunkIndex:  int32(data.ChunkIndex),
+			TotalChunks: int32(data.TotalChunks),
+
+			Segment:       int32(data.Segment),
+			TotalSegments: int32(data.TotalSegments),
+		}}
+	case protocol.Status:
+		data, err := msg.StatusData()
+		if err != nil {
+			return nil, err
+		}
+		m.Payload = &ServerMessage_Status{Status: &Status{
+			State:             data.State,
+			Mode:              data.Mode,
+			ConcurrentStreams: int32(data.ConcurrentStreams),
+			SessionInfo:       toSessionInfo(data.SessionInfo),
+			Quota:             toQuotaStatus(data.Quota),
+		}}
+	case protocol.Error:
+		data, err := msg.ErrorData()
+		if err != nil {
+			return nil, err
+		}
+		details, err := toStruct(data.Details)
+		if err != nil {
+			return nil, fmt.Errorf("转换错误详情失败: %w", err)
+		}
+		m.Payload = &ServerMessage_Error{Error: &Error{
+			Code:        data.Code,
+			Message:     data.Message,
+			Recoverable: data.Recoverable,
+			Details:     details,
+		}}
+	case protocol.TimeSync:
+		data, err := msg.TimeSyncData()
+		if err != nil {
+			return nil, err
+		}
+		m.Payload = &ServerMessage_TimeSync{TimeSync: toTimeSync(data)}
+	default:
+		return nil, fmt.Errorf("不支持的服务端消息类型: %s", msg.Type)
+	}
+
+	return m, nil
+}
+
+// toTimeSync 转换时钟同步数据
+func toTimeSync(data *protocol.TimeSyncData) *TimeSync {
+	return &TimeSync{
+		ClientSendTime:    data.ClientSendTime,
+		ServerReceiveTime: data.ServerReceiveTime,
+		ServerSendTime:    data.ServerSendTime,
+	}
+}
+
+// fromTimeSync 转换时钟同步数据
+func fromTimeSync(t *TimeSync) *protocol.TimeSyncData {
+	return &protocol.TimeSyncData{
+		ClientSendTime:    t.GetClientSendTime(),
+		ServerReceiveTime: t.GetServerReceiveTime(),
+		ServerSendTime:    t.GetServerSendTime(),
+	}
+}
+
+// toWordTimings 转换词级别时间信息
+func toWordTimings(words []protocol.WordTiming) []*WordTiming {
+	if len(words) == 0 {
+		return nil
+	}
+
+	converted := make([]*WordTiming, 0, len(words))
+	for _, w := range words {
+		converted = append(converted, &WordTiming{
+			Text:       w.Text,
+			StartTime:  w.StartTime,
+			EndTime:    w.EndTime,
+			Confidence: w.Confidence,
+		})
+	}
+	return converted
+}
+
+// fromWordTimings 转换词级别时间信息
+func fromWordTimings(words []*WordTiming) []protocol.WordTiming {
+	if len(words) == 0 {
+		return nil
+	}
+
+	converted := make([]protocol.WordTiming, 0, len(words))
+	for _, w := range words {
+		converted = append(converted, protocol.WordTiming{
+			Text:       w.GetText(),
+			StartTime:  w.GetStartTime(),
+			EndTime:    w.GetEndTime(),
+			Confidence: w.GetConfidence(),
+		})
+	}
+	return converted
+}
+
+// toSessionInfo 转换会话信息
+func toSessionInfo(info *protocol.SessionInfo) *SessionInfo {
+	if info == nil {
+		return nil
+	}
+	return &SessionInfo{
+		Id:           info.ID,
+		StartTime:    info.StartTime.UnixMilli(),
+		LastActivity: info.LastActivity.UnixMilli(),
+		MessageCount: int32(info.MessageCount),
+		Duration:     info.Duration,
+	}
+}
+
+// fromSessionInfo 转换会话信息
+func fromSessionInfo(info *SessionInfo) *protocol.SessionInfo {
+	if info == nil {
+		return nil
+	}
+	return &protocol.SessionInfo{
+		ID:           info.GetId(),
+		StartTime:    time.UnixMilli(info.GetStartTime()),
+		LastActivity: time.UnixMilli(info.GetLastActivity()),
+		MessageCount: int(info.GetMessageCount()),
+		Duration:     info.GetDuration(),
+	}
+}
+
+// toQuotaStatus 转换配额使用情况
+func toQuotaStatus(quota *protocol.QuotaStatus) *QuotaStatus {
+	if quota == nil {
+		return nil
+	}
+	return &QuotaStatus{
+		TurnsUsed:         int32(quota.TurnsUsed),
+		TurnsLimit:        int32(quota.TurnsLimit),
+		AudioMinutesUsed:  quota.AudioMinutesUsed,
+		AudioMinutesLimit: quota.AudioMinutesLimit,
+		TokensUsed:        int32(quota.TokensUsed),
+		TokensLimit:       int32(quota.TokensLimit),
+		Exceeded:          quota.Exceeded,
+	}
+}
+
+// fromQuotaStatus 转换配额使用情况
+func fromQuotaStatus(quota *QuotaStatus) *protocol.QuotaStatus {
+	if quota == nil {
+		return nil
+	}
+	return &protocol.QuotaStatus{
+		TurnsUsed:         int(quota.GetTurnsUsed()),
+		TurnsLimit:        int(quota.GetTurnsLimit()),
+		AudioMinutesUsed:  quota.GetAudioMinutesUsed(),
+		AudioMinutesLimit: quota.GetAudioMinutesLimit(),
+		TokensUsed:        int(quota.GetTokensUsed()),
+		TokensLimit:       int(quota.GetTokensLimit()),
+		Exceeded:          quota.GetExceeded(),
+	}
+}
+
+// toStruct 转换键值参数（nil保持为nil）
+func toStruct(values map[string]interface{}) (*structpb.Struct, error) {
+	if values == nil {
+		return nil, nil
+	}
+	return structpb.NewStruct(values)
+}
+
+// fromStruct 转换键值参数（nil保持为nil）
+func fromStruct(s *structpb.Struct) map[string]interface{} {
+	if s == nil {
+		return nil
+	}
+	return s.AsMap()
+}
@@ -0,0 +1,151 @@
+// 语音助手gRPC服务定义
+// 与WebSocket协议（pkg/protocol）一一对应：客户端上行音频流和控制命令，服务端下行分阶段响应。
+//
+// 重新生成Go代码：
+//   protoc --go_out=. --go_opt=paths=source_relative \
+//          --go-grpc_out=. --go-grpc_opt=paths=source_relative \
+//          pkg/vagrpc/voice_assistant.proto
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v4.25.3
+// source: pkg/vagrpc/voice_assistant.proto
+
+package vagrpc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	VoiceAssistant_Converse_FullMethodName = "/voice_assistant.v1.VoiceAssistant/Converse"
+)
+
+// VoiceAssistantClient is the client API for VoiceAssistant service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type VoiceAssistantClient interface {
+	// Converse 双向流式会话：会话ID通过 session-id 元数据或首条消息的 session_id 指定
+	Converse(ctx context.Context, opts ...grpc.CallOption) (VoiceAssistant_ConverseClient, error)
+}
+
+type voiceAssistantClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewVoiceAssistantClient(cc grpc.ClientConnInterface) VoiceAssistantClient {
+	return &voiceAssistantClient{cc}
+}
+
+func (c *voiceAssistantClient) Converse(ctx context.Context, opts ...grpc.CallOption) (VoiceAssistant_ConverseClient, error) {
+	stream, err := c.cc.NewStream(ctx, &VoiceAssistant_ServiceDesc.Streams[0], VoiceAssistant_Converse_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &voiceAssistantConverseClient{stream}
+	return x, nil
+}
+
+type VoiceAssistant_ConverseClient interface {
+	Send(*ClientMessage) error
+	Recv() (*ServerMessage, error)
+	grpc.ClientStream
+}
+
+type voiceAssistantConverseClient struct {
+	grpc.ClientStream
+}
+
+func (x *voiceAssistantConverseClient) Send(m *ClientMessage) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *voiceAssistantConverseClient) Recv() (*ServerMessage, error) {
+	m := new(ServerMessage)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// VoiceAssistantServer is the server API for VoiceAssistant service.
+// All implementations must embed UnimplementedVoiceAssistantServer
+// for forward compatibility
+type VoiceAssistantServer interface {
+	// Converse 双向流式会话：会话ID通过 session-id 元数据或首条消息的 session_id 指定
+	Converse(VoiceAssistant_ConverseServer) error
+	mustEmbedUnimplementedVoiceAssistantServer()
+}
+
+// UnimplementedVoiceAssistantServer must be embedded to have forward compatible implementations.
+type UnimplementedVoiceAssistantServer struct {
+}
+
+func (UnimplementedVoiceAssistantServer) Converse(VoiceAssistant_ConverseServer) error {
+	return status.Errorf(codes.Unimplemented, "method Converse not implemented")
+}
+func (UnimplementedVoiceAssistantServer) mustEmbedUnimplementedVoiceAssistantServer() {}
+
+// UnsafeVoiceAssistantServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to VoiceAssistantServer will
+// result in compilation errors.
+type UnsafeVoiceAssistantServer interface {
+	mustEmbedUnimplementedVoiceAssistantServer()
+}
+
+func RegisterVoiceAssistantServer(s grpc.ServiceRegistrar, srv VoiceAssistantServer) {
+	s.RegisterService(&VoiceAssistant_ServiceDesc, srv)
+}
+
+func _VoiceAssistant_Converse_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(VoiceAssistantServer).Converse(&voiceAssistantConverseServer{stream})
+}
+
+type VoiceAssistant_ConverseServer interface {
+	Send(*ServerMessage) error
+	Recv() (*ClientMessage, error)
+	grpc.ServerStream
+}
+
+type voiceAssistantConverseServer struct {
+	grpc.ServerStream
+}
+
+func (x *voiceAssistantConverseServer) Send(m *ServerMessage) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *voiceAssistantConverseServer) Recv() (*ClientMessage, error) {
+	m := new(ClientMessage)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// VoiceAssistant_ServiceDesc is the grpc.ServiceDesc for VoiceAssistant service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var VoiceAssistant_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "voice_assistant.v1.VoiceAssistant",
+	HandlerType: (*VoiceAssistantServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Converse",
+			Handler:       _VoiceAssistant_Converse_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "pkg/vagrpc/voice_assistant.proto",
+}
@@ -92,7 +92,7 @@ func (s *MockWebSocketServer) generateResponse(msg *protocol.Message) *protocol.
 			nil,
 		)
 	case protocol.Command:
-		if cmdData, err := protocol.ParseCommandData(msg.Data); err == nil {
+		if cmdData, err := msg.CommandData(); err == nil {
 			switch cmdData.Command {
 			case protocol.CmdStartSession:
 				return protocol.NewStatusMessage(
@@ -225,7 +225,7 @@ func TestClientAudioStreamProcessing(t *testing.T) {
 		assert.Equal(t, protocol.Response, response.Type)
 
 		// 验证响应数据
-		respData, err := protocol.ParseResponseData(response.Data)
+		respData, err := response.ResponseData()
 		require.NoError(t, err)
 		assert.Equal(t, protocol.StageASR, respData.Stage)
 		assert.Equal(t, "测试识别结果", respData.Content)
@@ -270,7 +270,7 @@ func TestClientCommandProcessing(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, protocol.Status, startResponse.Type)
 
-	statusData, err := protocol.ParseStatusData(startResponse.Data)
+	statusData, err := startResponse.StatusData()
 	require.NoError(t, err)
 	assert.Equal(t, protocol.StateConnected, statusData.State)
 
@@ -306,7 +306,7 @@ func TestClientCommandProcessing(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, protocol.Status, endResponse.Type)
 
-	endStatusData, err := protocol.ParseStatusData(endResponse.Data)
+	endStatusData, err := endResponse.StatusData()
 	require.NoError(t, err)
 	assert.Equal(t, protocol.StateDisconnected, endStatusData.State)
 }
@@ -473,7 +473,7 @@ func TestClientErrorHandling(t *testing.T) {
 		Type:      "invalid_type",
 		SessionID: "test_session",
 		Timestamp: time.Now().UnixNano() / int64(time.Millisecond),
-		Data:      "invalid data",
+		Payload:   "invalid data",
 	}
 
 	err = conn.WriteJSON(invalidMsg)
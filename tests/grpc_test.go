@@ -0,0 +1,88 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"voice_assistant/pkg/protocol"
+	"voice_assistant/pkg/vagrpc"
+)
+
+// TestGRPCClientMessageConversion 测试客户端消息与协议消息互转
+func TestGRPCClientMessageConversion(t *testing.T) {
+	audio := protocol.NewAudioStreamMessage("session_1", "pcm_16khz_16bit", 3, true, []byte{1, 2, 3})
+	m, err := vagrpc.ToClientMessage(audio)
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), m.GetAudio().GetChunkId())
+
+	back, err := vagrpc.FromClientMessage(m)
+	require.NoError(t, err)
+	assert.Equal(t, protocol.AudioStream, back.Type)
+	assert.Equal(t, audio.Payload, back.Payload)
+
+	command := protocol.NewCommandMessage("session_1", protocol.CmdStartSession, protocol.ModeContinuous, map[string]interface{}{
+		"text_only": true,
+		"user_id":   "alice",
+	})
+	m, err = vagrpc.ToClientMessage(command)
+	require.NoError(t, err)
+
+	back, err = vagrpc.FromClientMessage(m)
+	require.NoError(t, err)
+	cmdData := back.Payload.(*protocol.CommandData)
+	assert.Equal(t, protocol.CmdStartSession, cmdData.Command)
+	assert.Equal(t, true, cmdData.Parameters["text_only"])
+	assert.Equal(t, "alice", cmdData.Parameters["user_id"])
+}
+
+// TestGRPCServerMessageConversion 测试服务端消息与协议消息互转
+func TestGRPCServerMessageConversion(t *testing.T) {
+	response := protocol.NewMessage(protocol.Response, "session_1", &protocol.ResponseData{
+		Stage:      protocol.StageASR,
+		Content:    "你好",
+		Confidence: 0.9,
+		IsFinal:    true,
+		Words:      []protocol.WordTiming{{Text: "你好", StartTime: 0, EndTime: 400, Confidence: 0.9}},
+		Metadata:   map[string]interface{}{"suppressed": "echo"},
+	})
+
+	m, err := vagrpc.ToServerMessage(response)
+	require.NoError(t, err)
+	back, err := vagrpc.FromServerMessage(m)
+	require.NoError(t, err)
+	assert.Equal(t, response.Payload, back.Payload)
+
+	// TTS语音分片
+	chunk := protocol.NewMessage(protocol.Response, "session_1", &protocol.ResponseData{
+		Stage:       protocol.StageTTS,
+		Confidence:  1.0,
+		AudioData:   []byte{1, 2, 3, 4},
+		ChunkIndex:  1,
+		TotalChunks: 3,
+	})
+	m, err = vagrpc.ToServerMessage(chunk)
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), m.GetResponse().GetTotalChunks())
+	back, err = vagrpc.FromServerMessage(m)
+	require.NoError(t, err)
+	assert.Equal(t, chunk.Payload, back.Payload)
+
+	// 收到的JSON原文形式的数据同样可以转换
+	decoded, err := protocol.FromJSON(mustJSON(t, protocol.NewErrorMessage("session_1", protocol.ErrASRFailed, "语音识别失败", true)))
+	require.NoError(t, err)
+	m, err = vagrpc.ToServerMessage(decoded)
+	require.NoError(t, err)
+	assert.Equal(t, protocol.ErrASRFailed, m.GetError().GetCode())
+	assert.True(t, m.GetError().GetRecoverable())
+
+	_, err = vagrpc.ToServerMessage(protocol.NewAudioStreamMessage("session_1", "pcm_16khz_16bit", 0, false, nil))
+	assert.Error(t, err)
+}
+
+func mustJSON(t *testing.T, msg *protocol.Message) []byte {
+	data, err := msg.ToJSON()
+	require.NoError(t, err)
+	return data
+}
@@ -150,7 +150,7 @@ func TestProtocolDataParsing(t *testing.T) {
 		[]byte("test audio"),
 	)
 
-	audioData, err := protocol.ParseAudioStreamData(audioMsg.Data)
+	audioData, err := audioMsg.AudioStreamData()
 	require.NoError(t, err)
 	assert.Equal(t, "pcm_16khz_16bit", audioData.Format)
 	assert.Equal(t, 1, audioData.ChunkID)
@@ -165,7 +165,7 @@ func TestProtocolDataParsing(t *testing.T) {
 		map[string]interface{}{"timeout": 30},
 	)
 
-	cmdData, err := protocol.ParseCommandData(cmdMsg.Data)
+	cmdData, err := cmdMsg.CommandData()
 	require.NoError(t, err)
 	assert.Equal(t, protocol.CmdStartSession, cmdData.Command)
 	assert.Equal(t, protocol.ModeContinuous, cmdData.Mode)
@@ -181,7 +181,7 @@ func TestProtocolDataParsing(t *testing.T) {
 		[]byte("audio data"),
 	)
 
-	respData, err := protocol.ParseResponseData(respMsg.Data)
+	respData, err := respMsg.ResponseData()
 	require.NoError(t, err)
 	assert.Equal(t, protocol.StageASR, respData.Stage)
 	assert.Equal(t, "测试内容", respData.Content)
@@ -189,6 +189,25 @@ func TestProtocolDataParsing(t *testing.T) {
 	assert.True(t, respData.IsFinal)
 	assert.Equal(t, []byte("audio data"), respData.AudioData)
 
+	// 测试词级别时间戳解析
+	wordsMsg := protocol.NewMessage(protocol.Response, "test_session", &protocol.ResponseData{
+		Stage:   protocol.StageASR,
+		Content: "你好 世界",
+		IsFinal: false,
+		Words: []protocol.WordTiming{
+			{Text: "你好", StartTime: 0, EndTime: 420, Confidence: 0.9},
+			{Text: "世界", StartTime: 420, EndTime: 900, Confidence: 0.8},
+		},
+	})
+
+	wordsData, err := wordsMsg.ResponseData()
+	require.NoError(t, err)
+	assert.False(t, wordsData.IsFinal)
+	require.Len(t, wordsData.Words, 2)
+	assert.Equal(t, "世界", wordsData.Words[1].Text)
+	assert.Equal(t, int64(420), wordsData.Words[1].StartTime)
+	assert.Equal(t, int64(900), wordsData.Words[1].EndTime)
+
 	// 测试状态数据解析
 	statusMsg := protocol.NewStatusMessage(
 		"test_session",
@@ -197,7 +216,7 @@ func TestProtocolDataParsing(t *testing.T) {
 		1,
 	)
 
-	statusData, err := protocol.ParseStatusData(statusMsg.Data)
+	statusData, err := statusMsg.StatusData()
 	require.NoError(t, err)
 	assert.Equal(t, protocol.StateConnected, statusData.State)
 	assert.Equal(t, protocol.ModeContinuous, statusData.Mode)
@@ -211,7 +230,7 @@ func TestProtocolDataParsing(t *testing.T) {
 		true,
 	)
 
-	errorData, err := protocol.ParseErrorData(errorMsg.Data)
+	errorData, err := errorMsg.ErrorData()
 	require.NoError(t, err)
 	assert.Equal(t, protocol.ErrConnectionFailed, errorData.Code)
 	assert.Equal(t, "连接失败", errorData.Message)
@@ -0,0 +1,715 @@
+// Package assistant 语音助手客户端的嵌入式接口
+// 其他Go程序导入本包即可连接服务端、采集麦克风音频并播放回复语音，识别结果、回复文本和会话状态通过 Handler 回调获得，
+// 无需启动命令行客户端。命令行客户端本身也基于本包实现。
+package assistant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	vaaudio "voice_assistant/pkg/audio"
+	"voice_assistant/pkg/protocol"
+	"voice_assistant/voice_assistant_client/internal/audio"
+	"voice_assistant/voice_assistant_client/internal/client"
+	"voice_assistant/voice_assistant_client/internal/config"
+)
+
+// Config 客户端配置（与命令行客户端的 config/client.yaml 相同）
+type Config = config.Config
+
+// ConnectionStatus 连接状态快照
+type ConnectionStatus = client.ConnectionStatus
+
+// ConnectionState 连接状态
+type ConnectionState = client.ConnectionState
+
+// 连接状态
+const (
+	ConnectionDisconnected = client.StateDisconnected
+	ConnectionConnecting   = client.StateConnecting
+	ConnectionConnected    = client.StateConnected
+	ConnectionClosing      = client.StateClosing
+)
+
+// ErrMicrophoneMuted 麦克风已关闭时不能开始录音
+var ErrMicrophoneMuted = errors.New("麦克风已关闭")
+
+// DefaultConfig 默认配置
+func DefaultConfig() *Config {
+	return config.GetDefaultConfig()
+}
+
+// LoadConfig 从YAML文件加载配置
+func LoadConfig(path string) (*Config, error) {
+	return config.LoadConfig(path)
+}
+
+// Transcript 语音识别结果
+type Transcript struct {
+	Text       string
+	Confidence float64
+	Final      bool                  // 是否为本句的最终结果
+	Words      []protocol.WordTiming // 词级时间戳（服务端开启时提供）
+}
+
+// Speech 一轮回复的语音已全部收到
+type Speech struct {
+	Bytes  int   // 语音数据的总字节数
+	PlayAt int64 // 多设备同步播报的计划播放时间（服务端时钟，毫秒；0表示收到即播放）
+}
+
+// Handler 事件回调，未设置的回调不调用
+// 回调在消息处理协程或音频协程中执行，耗时操作应另起协程。
+type Handler struct {
+	OnTranscript    func(Transcript)                 // 语音识别结果（中间结果和最终结果）
+	OnReply         func(text string, final bool)    // 助手的文字回复（流式片段，final表示本轮回复结束）
+	OnSpeech        func(Speech)                     // 本轮回复的语音已收齐并开始（或已安排）播放
+	OnStatus        func(*protocol.StatusData)       // 服务端会话状态变化（聆听、处理、播报等）
+	OnVoiceActivity func(speaking bool)              // 本地VAD检测到说话开始或结束
+	OnRecording     func(recording bool)             // 开始或停止向服务端发送录音
+	OnAudioLevel    func(average, peak float64)      // 录音电平（每发送一块录音调用一次）
+	OnAudio         func(pcm []byte)                 // 发送到服务端的录音（16位小端PCM，仅在回调期间有效）
+	OnNotification  func(*protocol.NotificationData) // 服务端推送的通知（提醒、计时器到期），附带的语音自动播放
+	OnVoiceList     func(*protocol.VoiceListData)    // ListVoices 的结果
+	OnError         func(*protocol.ErrorData)        // 服务端报告的错误；不可恢复的错误随后停止助手
+	OnConnection    func(ConnectionStatus)           // 连接状态变化（连接中、已连接、重连进度、离线）
+	OnInfo          func(message string)             // 可直接显示给用户的提示（设备回退、重连、插话等）
+}
+
+// Assistant 语音助手：管理与服务端的连接、录音上传和回复语音的播放
+type Assistant struct {
+	config      *Config
+	handler     Handler
+	wsClient    *client.WebSocketClient
+	audioInput  *audio.AudioInput
+	audioOutput *audio.AudioOutput
+
+	// 状态
+	isRunning   bool
+	isRecording bool
+	pushToTalk  bool   // 按键说话模式：由按键而非服务端状态控制录音
+	wakeword    bool   // 唤醒词模式：按键发送唤醒事件后服务端才接受音频
+	duplex      bool   // 双工模式：播放回复时也保持录音，由回声门限屏蔽助手自己的声音
+	mode        string // 会话模式
+
+	// 本轮回复的最后一段语音已送入播放队列，播放完毕时需要通知服务端
+	playbackPending atomic.Bool
+
+	// 麦克风开关：关闭时不录音，服务端处于聆听状态也不开始
+	micMuted    atomic.Bool
+	serverState string // 服务端最近通知的会话状态
+
+	// 分片语音的接收进度
+	nextSpeechChunk int
+	speechBytes     int
+
+	// 分句语音的播放顺序：下一句应播放的序号，先到的后续句子暂存
+	nextSpeechSegment int
+	pendingSpeech     map[int][]*protocol.ResponseData
+
+	// 音频缓冲
+	chunkID   int
+	flushChan chan struct{} // 停止录音后冲刷剩余音频并发送最终块
+	vadEvents chan bool     // 本地VAD检测到的说话开始（true）和结束（false），与音频按顺序发送
+}
+
+// New 按配置创建语音助手（打开音频设备，尚未连接服务端）
+func New(cfg *Config, handler Handler) (*Assistant, error) {
+	// 创建WebSocket客户端
+	wsClient := client.NewWebSocketClient(cfg.ToClientConfig())
+
+	// 创建音频输入
+	audioInput, err := audio.NewAudioInput(cfg.ToAudioInputConfig())
+	if err != nil {
+		return nil, fmt.Errorf("创建音频输入失败: %w", err)
+	}
+
+	// 创建音频输出
+	audioOutput, err := audio.NewAudioOutput(cfg.ToAudioOutputConfig())
+	if err != nil {
+		return nil, fmt.Errorf("创建音频输出失败: %w", err)
+	}
+
+	a := &Assistant{
+		config:      cfg,
+		handler:     handler,
+		wsClient:    wsClient,
+		audioInput:  audioInput,
+		audioOutput: audioOutput,
+		flushChan:   make(chan struct{}, 1),
+		vadEvents:   make(chan bool, 8),
+	}
+
+	// 注册消息处理器
+	a.registerMessageHandlers()
+
+	return a, nil
+}
+
+// Connection 底层的WebSocket客户端（会话转移、声音列表、统计等本包未封装的操作）
+func (a *Assistant) Connection() *client.WebSocketClient {
+	return a.wsClient
+}
+
+// Input 底层的音频输入（切换设备、统计）
+func (a *Assistant) Input() *audio.AudioInput {
+	return a.audioInput
+}
+
+// Output 底层的音频输出（切换设备、播放速度、统计）
+func (a *Assistant) Output() *audio.AudioOutput {
+	return a.audioOutput
+}
+
+// Start 连接服务端、启动音频设备并按配置的会话模式开始会话
+func (a *Assistant) Start(ctx context.Context) error {
+	// 连接到服务器
+	if err := a.wsClient.Connect(ctx); err != nil {
+		return fmt.Errorf("连接服务器失败: %w", err)
+	}
+
+	// 音频设备断开后自动回退到默认设备时提示
+	a.audioInput.SetDeviceChangeHandler(func(name string, fallback bool) {
+		a.info("🎤 输入设备已断开，改用默认设备: " + name)
+	})
+	a.audioOutput.SetDeviceChangeHandler(func(name string, fallback bool) {
+		a.info("🔈 输出设备已断开，改用默认设备: " + name)
+	})
+	a.audioOutput.SetPlaybackHandler(a.handlePlayback)
+	a.audioInput.SetVoiceActivityHandler(func(speaking bool) {
+		select {
+		case a.vadEvents <- speaking:
+		default:
+		}
+	})
+
+	// 会话模式
+	a.applyMode(a.config.Session.Mode)
+
+	// 启动音频输入
+	if err := a.audioInput.Start(ctx); err != nil {
+		return fmt.Errorf("启动音频输入失败: %w", err)
+	}
+
+	// 启动音频输出
+	if err := a.audioOutput.Start(ctx); err != nil {
+		return fmt.Errorf("启动音频输出失败: %w", err)
+	}
+
+	// 启动音频处理协程
+	go a.audioProcessingLoop(ctx)
+
+	// 启动会话
+	if err := a.startSession(); err != nil {
+		return err
+	}
+
+	a.isRunning = true
+	return nil
+}
+
+// Stop 结束会话、关闭音频设备并断开连接
+func (a *Assistant) Stop() error {
+	if !a.isRunning {
+		return nil
+	}
+
+	log.Println("正在停止客户端...")
+
+	a.isRunning = false
+
+	// 停止会话
+	if a.wsClient.IsConnected() {
+		a.wsClient.StopSession()
+	}
+
+	// 停止音频输入
+	a.audioInput.Stop()
+
+	// 停止音频输出
+	a.audioOutput.Stop()
+
+	// 断开WebSocket连接
+	a.wsClient.Disconnect()
+
+	return nil
+}
+
+// Mode 当前的会话模式
+func (a *Assistant) Mode() string {
+	return a.mode
+}
+
+// SetMode 切换会话模式：通知服务端并调整本地的录音控制方式
+func (a *Assistant) SetMode(mode string) error {
+	if err := a.wsClient.SetMode(mode); err != nil {
+		return err
+	}
+	// 切换到按键说话时结束当前录音，之后由按键控制；其他模式由服务端状态控制
+	if mode == protocol.ModePushToTalk && a.isRecording {
+		a.stopRecording()
+	}
+	a.applyMode(mode)
+	return nil
+}
+
+// applyMode 按会话模式设置本地的录音控制方式
+func (a *Assistant) applyMode(mode string) {
+	a.pushToTalk = mode == protocol.ModePushToTalk
+	a.wakeword = mode == protocol.ModeWakeword
+	a.duplex = mode == protocol.ModeDuplex
+	a.mode = mode
+	// 离开双工模式后回声门限保留：非双工模式播放期间不录音，门限不起作用
+	if a.duplex {
+		a.audioInput.SetEchoReference(a.audioOutput, a.config.ToEchoGateConfig(), a.handleBargeIn)
+	}
+}
+
+// Talk 说话键：唤醒词模式下唤醒助手，按键说话模式下开始或结束录音，其他模式下不做处理
+func (a *Assistant) Talk() error {
+	if a.wakeword {
+		return a.Wake()
+	}
+	if !a.pushToTalk {
+		return nil
+	}
+	if a.isRecording {
+		a.stopRecording()
+		return nil
+	}
+	return a.startRecording()
+}
+
+// Wake 发送唤醒事件，服务端进入聆听状态后开始录音
+func (a *Assistant) Wake() error {
+	keyword := ""
+	if len(a.config.Session.Wakeword.Keywords) > 0 {
+		keyword = a.config.Session.Wakeword.Keywords[0]
+	}
+	if err := a.wsClient.Wake(keyword); err != nil {
+		return fmt.Errorf("唤醒失败: %w", err)
+	}
+	return nil
+}
+
+// StartListening 开始向服务端发送录音（按键说话模式下按下说话键时调用）
+func (a *Assistant) StartListening() error {
+	return a.startRecording()
+}
+
+// StopListening 停止录音，已采集的音频发送完后发送最终块
+func (a *Assistant) StopListening() {
+	a.stopRecording()
+}
+
+// Recording 是否正在向服务端发送录音
+func (a *Assistant) Recording() bool {
+	return a.isRecording
+}
+
+// Interrupt 打断当前回复，已收到但未播放的语音一并丢弃
+func (a *Assistant) Interrupt() error {
+	if err := a.wsClient.InterruptSession(); err != nil {
+		return err
+	}
+	a.playbackPending.Store(false)
+	return a.audioOutput.ClearQueue()
+}
+
+// ClearContext 清除服务端保存的对话上下文
+func (a *Assistant) ClearContext() error {
+	return a.wsClient.ClearContext()
+}
+
+// ListVoices 请求服务端TTS支持的声音列表，结果通过 Handler.OnVoiceList 返回
+func (a *Assistant) ListVoices(language string) error {
+	return a.wsClient.ListVoices(language)
+}
+
+// MicrophoneEnabled 麦克风是否开启
+func (a *Assistant) MicrophoneEnabled() bool {
+	return !a.micMuted.Load()
+}
+
+// SetMicrophone 开关麦克风：关闭时结束当前录音，开启后服务端处于聆听状态则立即恢复录音
+func (a *Assistant) SetMicrophone(enabled bool) {
+	a.micMuted.Store(!enabled)
+	if !enabled {
+		a.stopRecording()
+		return
+	}
+	if !a.pushToTalk && a.serverState == protocol.StateListening {
+		a.startRecording()
+	}
+}
+
+// startSession 向服务端开始会话并告知数据采集授权（启动时和服务端未能恢复会话的重连后调用）
+// 配置了 session.join 时改为加入其他客户端的会话，会话的模式和授权由对方决定。
+func (a *Assistant) startSession() error {
+	if join := a.config.Session.Join; join != "" {
+		if err := a.wsClient.JoinSession(join, a.config.Session.JoinCode, a.joinRole()); err != nil {
+			return fmt.Errorf("加入会话失败: %w", err)
+		}
+		a.info(fmt.Sprintf("👥 已加入会话 %s（%s）", join, a.joinRole()))
+		return nil
+	}
+
+	sessionParams := map[string]interface{}{
+		"text_only":       a.config.Session.TextOnly,
+		"report_playback": true,
+	}
+	if a.config.Session.Persona != "" {
+		sessionParams["persona"] = a.config.Session.Persona
+	}
+	if err := a.wsClient.StartSessionWithParameters(a.mode, sessionParams); err != nil {
+		return fmt.Errorf("启动会话失败: %w", err)
+	}
+
+	// 告知服务端数据采集授权（未允许时明确退出）
+	if err := a.wsClient.SetDataCollection(a.config.Session.AllowDataCollection); err != nil {
+		log.Printf("设置数据采集授权失败: %v", err)
+	}
+	return nil
+}
+
+// joinRole 加入其他客户端的会话时的成员角色
+func (a *Assistant) joinRole() string {
+	if a.config.Session.JoinRole == "" {
+		return protocol.RoleListener
+	}
+	return a.config.Session.JoinRole
+}
+
+// listenOnly 以listener身份加入了其他客户端的会话：只接收回复，不录音
+func (a *Assistant) listenOnly() bool {
+	return a.config.Session.Join != "" && a.joinRole() == protocol.RoleListener
+}
+
+// info 通过 Handler.OnInfo 给出提示
+func (a *Assistant) info(message string) {
+	if a.handler.OnInfo != nil {
+		a.handler.OnInfo(message)
+	}
+}
+
+// registerMessageHandlers 注册消息处理器
+func (a *Assistant) registerMessageHandlers() {
+	// 响应消息处理器
+	a.wsClient.RegisterHandler(protocol.Response, a.handleResponseMessage)
+
+	// 状态消息处理器
+	a.wsClient.RegisterHandler(protocol.Status, a.handleStatusMessage)
+
+	// 错误消息处理器
+	a.wsClient.RegisterHandler(protocol.Error, a.handleErrorMessage)
+
+	// 声音列表（ListVoices 的结果）
+	a.wsClient.RegisterHandler(protocol.VoiceList, a.handleVoiceListMessage)
+
+	// 服务端推送的通知（提醒、计时器到期）
+	a.wsClient.RegisterHandler(protocol.Notification, a.handleNotificationMessage)
+
+	// 断线重连
+	a.wsClient.SetReconnectHandler(a.handleReconnect)
+
+	// 连接状态
+	if a.handler.OnConnection != nil {
+		a.wsClient.SetStatusHandler(a.handler.OnConnection)
+	}
+}
+
+// handleReconnect 断线重连后：服务端恢复了会话则继续，否则重新开始会话
+func (a *Assistant) handleReconnect(resumed bool) {
+	if resumed {
+		a.info("🔄 已重新连接，会话继续")
+		return
+	}
+
+	a.info("🔄 已重新连接，重新开始会话")
+	if err := a.startSession(); err != nil {
+		log.Printf("重连后%v", err)
+	}
+}
+
+// handleResponseMessage 处理响应消息
+func (a *Assistant) handleResponseMessage(msg *protocol.Message) error {
+	respData, err := msg.ResponseData()
+	if err != nil {
+		return fmt.Errorf("解析响应数据失败: %w", err)
+	}
+
+	switch respData.Stage {
+	case protocol.StageASR:
+		// ASR识别结果
+		if a.handler.OnTranscript != nil {
+			a.handler.OnTranscript(Transcript{
+				Text:       respData.Content,
+				Confidence: respData.Confidence,
+				Final:      respData.IsFinal,
+				Words:      respData.Words,
+			})
+		}
+
+	case protocol.StageLLM:
+		// LLM回复结果
+		if a.handler.OnReply != nil {
+			a.handler.OnReply(respData.Content, respData.IsFinal)
+		}
+
+	case protocol.StageTTS:
+		// TTS音频数据（分句合成的语音按句子顺序播放）
+		if len(respData.AudioData) > 0 {
+			for _, part := range a.orderSpeech(respData) {
+				a.playSpeech(part)
+			}
+		}
+	}
+
+	return nil
+}
+
+// handleNotificationMessage 转交并播报服务端推送的通知（不属于对话轮次，不上报播放进度）
+func (a *Assistant) handleNotificationMessage(msg *protocol.Message) error {
+	data, err := msg.NotificationData()
+	if err != nil {
+		return fmt.Errorf("解析通知失败: %w", err)
+	}
+
+	if a.handler.OnNotification != nil {
+		a.handler.OnNotification(data)
+	}
+
+	if data.RequireAck && data.ID != "" {
+		if err := a.wsClient.AckNotification(data.ID); err != nil {
+			log.Printf("%v", err)
+		}
+	}
+
+	if len(data.AudioData) > 0 {
+		if err := a.playAudio(data.AudioData, data.AudioFormat); err != nil {
+			log.Printf("播放通知语音失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// handleVoiceListMessage 转交 ListVoices 返回的声音列表
+func (a *Assistant) handleVoiceListMessage(msg *protocol.Message) error {
+	data, err := msg.VoiceListData()
+	if err != nil {
+		return fmt.Errorf("解析声音列表失败: %w", err)
+	}
+
+	if a.handler.OnVoiceList != nil {
+		a.handler.OnVoiceList(data)
+	}
+	return nil
+}
+
+// handleStatusMessage 处理状态消息
+func (a *Assistant) handleStatusMessage(msg *protocol.Message) error {
+	statusData, err := msg.StatusData()
+	if err != nil {
+		return fmt.Errorf("解析状态数据失败: %w", err)
+	}
+
+	a.serverState = statusData.State
+	if handover := statusData.Handover; handover != nil {
+		switch {
+		case handover.Transferred:
+			// 对话已转移到其他设备
+			if a.isRecording {
+				a.stopRecording()
+			}
+		case handover.From != "":
+			// 接管了其他设备的对话，沿用对方的会话模式
+			if statusData.Mode != "" && statusData.Mode != a.mode {
+				a.applyMode(statusData.Mode)
+			}
+		}
+	}
+
+	if a.handler.OnStatus != nil {
+		a.handler.OnStatus(statusData)
+	}
+
+	// 根据状态调整录音状态
+	switch statusData.State {
+	case protocol.StateListening:
+		// 按键说话模式下由按键控制录音，麦克风关闭时不录音
+		if !a.isRecording && !a.pushToTalk && !a.micMuted.Load() && !a.listenOnly() {
+			a.startRecording()
+		}
+	case protocol.StateProcessing, protocol.StateSpeaking:
+		// 双工模式下处理和播放期间继续录音，用户可直接插话
+		if a.isRecording && !a.duplex {
+			a.stopRecording()
+		}
+	}
+
+	return nil
+}
+
+// handleErrorMessage 处理错误消息
+func (a *Assistant) handleErrorMessage(msg *protocol.Message) error {
+	errorData, err := msg.ErrorData()
+	if err != nil {
+		return fmt.Errorf("解析错误数据失败: %w", err)
+	}
+
+	if a.handler.OnError != nil {
+		a.handler.OnError(errorData)
+	}
+
+	// 如果是不可恢复的错误，停止客户端
+	if !errorData.Recoverable {
+		log.Printf("收到不可恢复错误，停止客户端: %s", errorData.Message)
+		go func() {
+			time.Sleep(time.Second)
+			a.Stop()
+		}()
+	}
+
+	return nil
+}
+
+// audioProcessingLoop 音频处理循环
+func (a *Assistant) audioProcessingLoop(ctx context.Context) {
+	audioChan := a.audioInput.GetAudioChannel()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-a.flushChan:
+			a.flushAudio(audioChan)
+		case speaking := <-a.vadEvents:
+			if !a.isRunning || !a.isRecording {
+				continue
+			}
+			a.reportVoiceActivity(audioChan, speaking)
+		case audioData, ok := <-audioChan:
+			if !ok {
+				return
+			}
+			if !a.isRunning || !a.isRecording {
+				vaaudio.PutFloat32(audioData)
+				continue
+			}
+
+			// 发送音频流
+			a.sendAudioChunk(audioData)
+
+			// 录音电平
+			if a.handler.OnAudioLevel != nil {
+				stats := a.audioInput.GetStats()
+				a.handler.OnAudioLevel(stats.AverageLevel, stats.PeakLevel)
+			}
+		}
+	}
+}
+
+// flushAudio 发送录音停止前已采集但尚未发送的音频，然后发送最终块
+func (a *Assistant) flushAudio(audioChan <-chan []float32) {
+	if !a.sendPendingAudio(audioChan) {
+		return
+	}
+
+	// 发送最终音频块
+	a.chunkID++
+	if err := a.wsClient.SendAudioStream([]byte{}, a.chunkID, true); err != nil {
+		log.Printf("发送最终音频块失败: %v", err)
+	}
+}
+
+// sendPendingAudio 发送已采集但尚未发送的音频，音频通道已关闭时返回false
+func (a *Assistant) sendPendingAudio(audioChan <-chan []float32) bool {
+	for {
+		select {
+		case audioData, ok := <-audioChan:
+			if !ok {
+				return false
+			}
+			a.sendAudioChunk(audioData)
+		default:
+			return true
+		}
+	}
+}
+
+// sendAudioChunk 发送一块录音，发送后音频块和转换出的PCM都归还缓冲池
+func (a *Assistant) sendAudioChunk(audioData []float32) {
+	audioBytes := vaaudio.Float32ToBytesBuffer(audioData)
+	vaaudio.PutFloat32(audioData)
+	if a.handler.OnAudio != nil {
+		a.handler.OnAudio(audioBytes)
+	}
+
+	a.chunkID++
+	if err := a.wsClient.SendAudioBuffer(audioBytes, a.chunkID, false); err != nil {
+		log.Printf("发送音频流失败: %v", err)
+	}
+}
+
+// reportVoiceActivity 上报本地VAD检测到的说话状态；说完时先发送之前的音频，服务端收到speech_end时本句音频已完整
+func (a *Assistant) reportVoiceActivity(audioChan <-chan []float32, speaking bool) {
+	if !speaking && !a.sendPendingAudio(audioChan) {
+		return
+	}
+	if err := a.wsClient.ReportVoiceActivity(speaking); err != nil {
+		log.Printf("%v", err)
+	}
+	if a.handler.OnVoiceActivity != nil {
+		a.handler.OnVoiceActivity(speaking)
+	}
+}
+
+// startRecording 开始录音
+func (a *Assistant) startRecording() error {
+	if a.isRecording {
+		return nil
+	}
+	if a.micMuted.Load() {
+		return ErrMicrophoneMuted
+	}
+
+	if err := a.audioInput.StartRecording(); err != nil {
+		log.Printf("开始录音失败: %v", err)
+		return fmt.Errorf("开始录音失败: %w", err)
+	}
+
+	a.isRecording = true
+	a.chunkID = 0
+	if a.handler.OnRecording != nil {
+		a.handler.OnRecording(true)
+	}
+	return nil
+}
+
+// stopRecording 停止录音
+func (a *Assistant) stopRecording() {
+	if !a.isRecording {
+		return
+	}
+
+	if err := a.audioInput.StopRecording(); err != nil {
+		log.Printf("停止录音失败: %v", err)
+		return
+	}
+
+	a.isRecording = false
+
+	// 由音频处理协程冲刷剩余音频后发送最终块，保证最终块在所有音频之后
+	select {
+	case a.flushChan <- struct{}{}:
+	default:
+	}
+
+	if a.handler.OnRecording != nil {
+		a.handler.OnRecording(false)
+	}
+}
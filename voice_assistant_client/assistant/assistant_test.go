@@ -0,0 +1,62 @@
+package assistant
+
+import (
+	"testing"
+
+	"voice_assistant/pkg/protocol"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleResponseMessage(t *testing.T) {
+	var transcripts []Transcript
+	var replies []string
+	a := &Assistant{handler: Handler{
+		OnTranscript: func(tr Transcript) { transcripts = append(transcripts, tr) },
+		OnReply: func(text string, final bool) {
+			if final {
+				replies = append(replies, text)
+			}
+		},
+	}}
+
+	require.NoError(t, a.handleResponseMessage(protocol.NewResponseMessage("s1", protocol.StageASR, "今天天气", 0.9, false, nil)))
+	require.NoError(t, a.handleResponseMessage(protocol.NewResponseMessage("s1", protocol.StageASR, "今天天气怎么样", 0.95, true, nil)))
+	require.NoError(t, a.handleResponseMessage(protocol.NewResponseMessage("s1", protocol.StageLLM, "晴天", 0, false, nil)))
+	require.NoError(t, a.handleResponseMessage(protocol.NewResponseMessage("s1", protocol.StageLLM, "晴天，25度", 0, true, nil)))
+
+	assert.Equal(t, []Transcript{
+		{Text: "今天天气", Confidence: 0.9},
+		{Text: "今天天气怎么样", Confidence: 0.95, Final: true},
+	}, transcripts)
+	assert.Equal(t, []string{"晴天，25度"}, replies)
+}
+
+func TestOrderSpeech(t *testing.T) {
+	speech := func(segment, chunk, chunks int, final bool) *protocol.ResponseData {
+		return &protocol.ResponseData{
+			Stage: protocol.StageTTS, AudioData: []byte{byte(segment)}, IsFinal: final,
+			Segment: segment, TotalSegments: 3, ChunkIndex: chunk, TotalChunks: chunks,
+		}
+	}
+	segments := func(parts []*protocol.ResponseData) []int {
+		var s []int
+		for _, part := range parts {
+			s = append(s, part.Segment)
+		}
+		return s
+	}
+
+	a := &Assistant{}
+	// 第二句先于第一句的最后一片到达，暂存到第一句收齐后播放
+	assert.Equal(t, []int{0}, segments(a.orderSpeech(speech(0, 0, 2, false))))
+	assert.Empty(t, a.orderSpeech(speech(1, 0, 1, false)))
+	assert.Equal(t, []int{0, 1}, segments(a.orderSpeech(speech(0, 1, 2, false))))
+	assert.Equal(t, []int{2}, segments(a.orderSpeech(speech(2, 0, 1, true))))
+
+	// 新一轮回复缺少第二句：收到最后一句时不再等待
+	assert.Equal(t, []int{0}, segments(a.orderSpeech(speech(0, 0, 1, false))))
+	assert.Equal(t, []int{2}, segments(a.orderSpeech(speech(2, 0, 1, true))))
+	assert.Nil(t, a.pendingSpeech)
+}
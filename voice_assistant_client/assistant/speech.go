@@ -0,0 +1,173 @@
+package assistant
+
+import (
+	"log"
+	"sort"
+	"time"
+
+	"voice_assistant/pkg/protocol"
+	"voice_assistant/voice_assistant_client/internal/audio"
+)
+
+// playSpeech 播放一段TTS语音
+func (a *Assistant) playSpeech(respData *protocol.ResponseData) {
+	if total, complete := a.trackSpeechChunk(respData); complete && a.handler.OnSpeech != nil {
+		a.handler.OnSpeech(Speech{Bytes: total, PlayAt: respData.PlayAt})
+	}
+	if respData.PlayAt > 0 {
+		a.schedulePlayback(respData.AudioData, respData.AudioFormat, respData.PlayAt)
+	} else if err := a.playAudio(respData.AudioData, respData.AudioFormat); err != nil {
+		log.Printf("播放音频失败: %v", err)
+	} else if respData.IsFinal {
+		// 先送入播放队列再标记，避免上一段的播放完毕事件被当作本轮结束
+		a.playbackPending.Store(true)
+	}
+}
+
+// playAudio 按服务端标明的音频格式播放语音（未标明格式时按输出采样率的PCM或WAV处理）
+func (a *Assistant) playAudio(audioData []byte, format *protocol.AudioFormat) error {
+	if format == nil {
+		return a.audioOutput.PlaySpeech(audioData, "", 0, 0)
+	}
+	return a.audioOutput.PlaySpeech(audioData, format.Format, format.SampleRate, format.Channels)
+}
+
+// orderSpeech 按句子序号排列分句合成的语音，返回现在可以播放的部分
+// 先到的后续句子暂存到前面的句子播放后再播放；收到最后一句的最后一片时前面仍有缺失的句子则不再等待，按序号播放已收到的部分。
+func (a *Assistant) orderSpeech(respData *protocol.ResponseData) []*protocol.ResponseData {
+	if respData.TotalSegments == 0 {
+		return []*protocol.ResponseData{respData}
+	}
+	if respData.Segment == 0 && respData.ChunkIndex == 0 {
+		// 新一轮回复
+		a.nextSpeechSegment = 0
+		a.pendingSpeech = nil
+	}
+
+	if respData.Segment > a.nextSpeechSegment {
+		if a.pendingSpeech == nil {
+			a.pendingSpeech = make(map[int][]*protocol.ResponseData)
+		}
+		a.pendingSpeech[respData.Segment] = append(a.pendingSpeech[respData.Segment], respData)
+		if respData.IsFinal {
+			return a.flushPendingSpeech()
+		}
+		return nil
+	}
+
+	ready := []*protocol.ResponseData{respData}
+	if respData.Segment < a.nextSpeechSegment || !lastSpeechChunk(respData) {
+		return ready
+	}
+	for a.nextSpeechSegment++; ; a.nextSpeechSegment++ {
+		parts, ok := a.pendingSpeech[a.nextSpeechSegment]
+		if !ok {
+			break
+		}
+		delete(a.pendingSpeech, a.nextSpeechSegment)
+		ready = append(ready, parts...)
+		if !lastSpeechChunk(parts[len(parts)-1]) {
+			break
+		}
+	}
+	return ready
+}
+
+// flushPendingSpeech 放弃等待缺失的句子，按序号返回暂存的全部语音
+func (a *Assistant) flushPendingSpeech() []*protocol.ResponseData {
+	segments := make([]int, 0, len(a.pendingSpeech))
+	for segment := range a.pendingSpeech {
+		segments = append(segments, segment)
+	}
+	sort.Ints(segments)
+	log.Printf("TTS语音缺少第%d句，跳过缺失的部分", a.nextSpeechSegment+1)
+
+	var ready []*protocol.ResponseData
+	for _, segment := range segments {
+		ready = append(ready, a.pendingSpeech[segment]...)
+	}
+	a.pendingSpeech = nil
+	a.nextSpeechSegment = 0
+	return ready
+}
+
+// lastSpeechChunk 是否为一句语音的最后一片
+func lastSpeechChunk(respData *protocol.ResponseData) bool {
+	return respData.TotalChunks <= 1 || respData.ChunkIndex >= respData.TotalChunks-1
+}
+
+// trackSpeechChunk 记录分片语音的接收进度，返回累计字节数及整段语音是否已收齐
+// 分片按顺序到达时逐片播放；服务端发送队列溢出等原因导致分片缺失时记录日志，已收到的部分照常播放。
+func (a *Assistant) trackSpeechChunk(respData *protocol.ResponseData) (int, bool) {
+	if respData.TotalChunks <= 1 && respData.TotalSegments == 0 {
+		return len(respData.AudioData), true
+	}
+
+	if respData.ChunkIndex == 0 && respData.Segment == 0 {
+		a.speechBytes = 0
+	}
+	a.speechBytes += len(respData.AudioData)
+
+	lastChunk := true
+	if respData.TotalChunks > 1 {
+		if respData.ChunkIndex != a.nextSpeechChunk {
+			log.Printf("TTS语音分片不连续: 期望第%d片，收到第%d/%d片，部分语音可能丢失",
+				a.nextSpeechChunk+1, respData.ChunkIndex+1, respData.TotalChunks)
+		}
+		a.nextSpeechChunk = respData.ChunkIndex + 1
+		if a.nextSpeechChunk >= respData.TotalChunks {
+			a.nextSpeechChunk = 0
+		} else {
+			lastChunk = false
+		}
+	}
+
+	// 分句合成时最后一句的最后一片才算收齐
+	if respData.IsFinal || lastChunk && respData.Segment >= respData.TotalSegments-1 {
+		a.nextSpeechChunk = 0
+		return a.speechBytes, true
+	}
+	return a.speechBytes, false
+}
+
+// handlePlayback 本轮语音播放完毕后通知服务端，连续模式下服务端随后恢复聆听
+func (a *Assistant) handlePlayback(event audio.PlaybackEvent) {
+	if !event.Finished || !a.playbackPending.CompareAndSwap(true, false) {
+		return
+	}
+	if err := a.wsClient.ReportPlaybackFinished(); err != nil {
+		log.Printf("%v", err)
+	}
+}
+
+// handleBargeIn 双工模式下用户在播放期间插话：停止播放本轮回复，用户的话照常发往服务端
+func (a *Assistant) handleBargeIn() {
+	a.playbackPending.Store(false)
+	if err := a.audioOutput.ClearQueue(); err != nil {
+		log.Printf("停止播放失败: %v", err)
+		return
+	}
+	a.info("✋ 检测到插话，停止播放")
+}
+
+// schedulePlayback 按服务端计划时间播放音频（多设备同步播报）
+func (a *Assistant) schedulePlayback(audioData []byte, format *protocol.AudioFormat, playAt int64) {
+	localAt := a.wsClient.ServerTimeToLocal(playAt)
+	if _, synced := a.wsClient.ClockOffset(); !synced {
+		log.Printf("时钟尚未同步，按本地时钟安排播放")
+	}
+
+	play := func() {
+		if err := a.playAudio(audioData, format); err != nil {
+			log.Printf("播放音频失败: %v", err)
+		}
+	}
+
+	delay := time.Until(localAt)
+	if delay <= 0 {
+		log.Printf("音频到达晚于计划播放时间 %v，立即播放", -delay)
+		play()
+		return
+	}
+	time.AfterFunc(delay, play)
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"voice_assistant/voice_assistant_client/internal/audio"
+	"voice_assistant/voice_assistant_client/internal/config"
+)
+
+// runCalibrate 测量环境噪声和说话电平，给出输入增益和VAD阈值建议并写回配置文件，返回进程退出码；
+// allDevices 为true时逐个测试所有输入设备，选择信噪比最高的设备
+func runCalibrate(cfg *config.Config, configPath string, allDevices bool, seconds int) int {
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	devices := []audio.InputDevice{{ID: cfg.Audio.Input.DeviceID}}
+	if allDevices {
+		var err error
+		devices, err = audio.ListInputDevices()
+		if err != nil {
+			log.Printf("获取输入设备失败: %v", err)
+			return 1
+		}
+		if len(devices) == 0 {
+			log.Printf("没有可用的输入设备")
+			return 1
+		}
+	}
+
+	inputConfig := cfg.ToAudioInputConfig()
+	duration := time.Duration(seconds) * time.Second
+	reader := bufio.NewReader(os.Stdin)
+
+	var results []audio.CalibrationResult
+	for _, device := range devices {
+		result, err := calibrateDevice(ctx, reader, device, inputConfig, duration)
+		if ctx.Err() != nil {
+			log.Printf("校准已取消")
+			return 1
+		}
+		if err != nil {
+			log.Printf("校准设备失败: %s, %v", device.Name, err)
+			continue
+		}
+		results = append(results, result)
+	}
+	if len(results) == 0 {
+		return 1
+	}
+
+	best := results[0]
+	for _, result := range results[1:] {
+		if result.SNR > best.SNR {
+			best = result
+		}
+	}
+	printCalibration(results, best)
+
+	if !best.Good() {
+		fmt.Println("\n⚠️ 信噪比偏低或出现削波，建议靠近麦克风、降低环境噪声或更换设备后重新校准")
+	}
+
+	fmt.Printf("\n将设备 %d、增益 %.1f、VAD阈值 %.1f dBFS 写入 %s? [Y/n] ", best.DeviceID, best.Gain, best.VADThreshold, configPath)
+	if answer, _ := reader.ReadString('\n'); strings.HasPrefix(strings.ToLower(strings.TrimSpace(answer)), "n") {
+		fmt.Println("未修改配置文件")
+		return 0
+	}
+
+	if allDevices {
+		cfg.Audio.Input.DeviceID = best.DeviceID
+	}
+	cfg.Audio.Input.Gain = best.Gain
+	cfg.Audio.VAD.Threshold = best.VADThreshold
+	if err := config.SaveConfig(cfg, configPath); err != nil {
+		log.Printf("保存配置失败: %v", err)
+		return 1
+	}
+	fmt.Printf("已保存到 %s\n", configPath)
+	return 0
+}
+
+// calibrateDevice 在一个设备上依次录制安静和说话时的音频
+func calibrateDevice(ctx context.Context, reader *bufio.Reader, device audio.InputDevice, inputConfig audio.InputConfig, duration time.Duration) (audio.CalibrationResult, error) {
+	fmt.Printf("\n=== 设备 %d %s ===\n", device.ID, device.Name)
+
+	fmt.Printf("请保持安静，按回车后录制 %.0f 秒环境噪声...", duration.Seconds())
+	reader.ReadString('\n')
+	name, noiseFrames, err := audio.RecordFrames(ctx, device.ID, inputConfig, duration)
+	if err != nil {
+		return audio.CalibrationResult{}, err
+	}
+
+	fmt.Printf("请用平时的音量和距离说话，按回车后录制 %.0f 秒...", duration.Seconds())
+	reader.ReadString('\n')
+	_, speechFrames, err := audio.RecordFrames(ctx, device.ID, inputConfig, duration)
+	if err != nil {
+		return audio.CalibrationResult{}, err
+	}
+
+	result := audio.Recommend(audio.AnalyzeLevels(noiseFrames), audio.AnalyzeLevels(speechFrames))
+	result.DeviceID, result.DeviceName = device.ID, name
+	return result, nil
+}
+
+// printCalibration 以表格打印校准结果
+func printCalibration(results []audio.CalibrationResult, best audio.CalibrationResult) {
+	fmt.Println("\n校准结果（电平为未加增益的原始测量值，* 为推荐设备）:")
+	fmt.Println()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\t设备\t噪声(dBFS)\t说话(dBFS)\t信噪比(dB)\t建议增益\tVAD阈值(dBFS)")
+	for _, result := range results {
+		mark := ""
+		if result.DeviceID == best.DeviceID {
+			mark = "*"
+		}
+		clipping := ""
+		if result.Clipping {
+			clipping = " 削波"
+		}
+		fmt.Fprintf(w, "%s\t%d %s\t%.1f\t%.1f%s\t%.1f\t%.1f\t%.1f\n", mark, result.DeviceID, result.DeviceName,
+			result.NoiseLevel, result.SpeechLevel, clipping, result.SNR, result.Gain, result.VADThreshold)
+	}
+	w.Flush()
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"voice_assistant/pkg/protocol"
+)
+
+// 命令模式支持的会话模式
+var commandModes = []string{
+	protocol.ModeContinuous,
+	protocol.ModeSingle,
+	protocol.ModeWakeword,
+	protocol.ModePushToTalk,
+	protocol.ModeDuplex,
+	protocol.ModeInterrupt,
+}
+
+// commandHelp 命令模式的帮助信息
+const commandHelp = `可用命令：
+  :mode <模式>     切换会话模式（continuous/single/wakeword/push_to_talk/duplex/interrupt）
+  :interrupt       打断当前回复
+  :clear           清除对话上下文
+  :voices [语言]   显示服务端TTS支持的声音列表
+  :speed [倍速]    查看或设置回复语音的播放速度（0.5-2.0，如 1.25）
+  :stats           显示连接和音频统计
+  :handover        生成转移令牌，把当前对话转移到其他设备
+  :claim <令牌>    用转移令牌接管其他设备的对话
+  :invite [角色]   生成加入码，邀请其他设备加入本会话（listener/speaker，默认 listener）
+  :help            显示本帮助`
+
+// runCommand 执行命令模式输入的命令（不含开头的冒号）
+func (c *VoiceAssistantClient) runCommand(line string) {
+	name, args := parseCommand(line)
+	if name == "" {
+		return
+	}
+
+	var err error
+	switch name {
+	case "mode":
+		err = c.switchMode(args)
+	case "interrupt":
+		if err = c.assistant.Interrupt(); err == nil {
+			c.uiManager.ShowMessage("⏹️ 已打断回复")
+		}
+	case "clear":
+		if err = c.assistant.ClearContext(); err == nil {
+			c.uiManager.ShowMessage("🧹 已清除对话上下文")
+		}
+	case "voices":
+		language := ""
+		if len(args) > 0 {
+			language = args[0]
+		}
+		err = c.assistant.ListVoices(language)
+	case "speed":
+		err = c.setSpeed(args)
+	case "stats":
+		c.showStats()
+	case "handover":
+		err = c.wsClient.Handover()
+	case "claim":
+		if len(args) != 1 {
+			err = fmt.Errorf("用法: :claim <令牌>")
+			break
+		}
+		err = c.wsClient.ClaimHandover(args[0], map[string]interface{}{
+			"text_only":       c.config.Session.TextOnly,
+			"report_playback": true,
+		})
+	case "invite":
+		err = c.invite(args)
+	case "help", "h", "?":
+		c.uiManager.ShowMessage(commandHelp)
+	default:
+		err = fmt.Errorf("未知命令: %s（输入 :help 查看可用命令）", name)
+	}
+	if err != nil {
+		c.uiManager.ShowMessage(fmt.Sprintf("命令 :%s 执行失败: %v", name, err))
+	}
+}
+
+// parseCommand 拆分命令名（不区分大小写）和参数，空行返回空命令名
+func parseCommand(line string) (string, []string) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return "", nil
+	}
+	return strings.ToLower(fields[0]), fields[1:]
+}
+
+// parseModeArgs 解析 :mode 的参数
+func parseModeArgs(args []string) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("用法: :mode <%s>", strings.Join(commandModes, "|"))
+	}
+	mode := strings.ToLower(args[0])
+	for _, m := range commandModes {
+		if m == mode {
+			return mode, nil
+		}
+	}
+	return "", fmt.Errorf("不支持的会话模式: %s（可选: %s）", mode, strings.Join(commandModes, "|"))
+}
+
+// parseSpeedArg 解析 :speed 的倍速参数（可带x后缀，如 1.5x）
+func parseSpeedArg(arg string) (float64, error) {
+	speed, err := strconv.ParseFloat(strings.TrimSuffix(strings.ToLower(arg), "x"), 64)
+	if err != nil {
+		return 0, fmt.Errorf("用法: :speed [倍速]（如 :speed 1.5）")
+	}
+	return speed, nil
+}
+
+// parseInviteArgs 解析 :invite 的角色参数（默认listener）
+func parseInviteArgs(args []string) (string, error) {
+	role := protocol.RoleListener
+	if len(args) > 0 {
+		role = strings.ToLower(args[0])
+	}
+	if len(args) > 1 || (role != protocol.RoleListener && role != protocol.RoleSpeaker) {
+		return "", fmt.Errorf("用法: :invite [listener|speaker]")
+	}
+	return role, nil
+}
+
+// switchMode 切换会话模式：通知服务端并调整本地的录音控制方式
+func (c *VoiceAssistantClient) switchMode(args []string) error {
+	mode, err := parseModeArgs(args)
+	if err != nil {
+		return err
+	}
+
+	if err := c.assistant.SetMode(mode); err != nil {
+		return err
+	}
+
+	switch mode {
+	case protocol.ModePushToTalk:
+		c.uiManager.ShowMessage("⌨️ 已切换到按键说话模式，按空格或回车开始说话，再按一次结束")
+	case protocol.ModeWakeword:
+		c.uiManager.ShowMessage("⌨️ 已切换到唤醒词模式，按空格或回车唤醒助手")
+	default:
+		c.uiManager.ShowMessage("🔀 已切换到会话模式: " + mode)
+	}
+	return nil
+}
+
+// setSpeed 查看或设置回复语音的播放速度（客户端变速不变调，与服务端TTS语速无关）
+func (c *VoiceAssistantClient) setSpeed(args []string) error {
+	if len(args) == 0 {
+		c.uiManager.ShowMessage(fmt.Sprintf("🔊 当前播放速度: %gx", c.audioOutput.Speed()))
+		return nil
+	}
+	speed, err := parseSpeedArg(args[0])
+	if err != nil {
+		return err
+	}
+	if err := c.audioOutput.SetSpeed(speed); err != nil {
+		return err
+	}
+	c.uiManager.ShowMessage(fmt.Sprintf("🔊 播放速度已设置为 %gx", speed))
+	return nil
+}
+
+// invite 生成加入码，邀请其他设备以指定角色加入本会话
+func (c *VoiceAssistantClient) invite(args []string) error {
+	role, err := parseInviteArgs(args)
+	if err != nil {
+		return err
+	}
+	return c.wsClient.InviteMember(role)
+}
+
+// showStats 显示连接和音频统计
+func (c *VoiceAssistantClient) showStats() {
+	conn := c.wsClient.GetStats()
+	input := c.audioInput.GetStats()
+	output := c.audioOutput.GetStats()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "连接: %s，会话 %s，模式 %s\n", c.wsClient.State(), c.wsClient.GetSessionID(), c.assistant.Mode())
+	if !conn.ConnectTime.IsZero() {
+		fmt.Fprintf(&b, "  已连接 %v，重连 %d 次\n", time.Since(conn.ConnectTime).Round(time.Second), conn.ReconnectCount)
+	}
+	fmt.Fprintf(&b, "  发送 %d 条消息 / %s，接收 %d 条消息 / %s\n",
+		conn.MessagesSent, formatBytes(conn.BytesSent), conn.MessagesReceived, formatBytes(conn.BytesReceived))
+	if offset, ok := c.wsClient.ClockOffset(); ok {
+		fmt.Fprintf(&b, "  与服务端时钟偏差 %v\n", offset.Round(time.Millisecond))
+	}
+	fmt.Fprintf(&b, "录音: %d 帧（有声 %d，静音 %d，回声屏蔽 %d），平均电平 %.3f\n",
+		input.TotalFrames, input.ActiveFrames, input.SilentFrames, input.EchoGated, input.AverageLevel)
+	fmt.Fprintf(&b, "播放: %d 帧（丢弃 %d），队列 %d，累计 %v",
+		output.PlayedFrames, output.DroppedFrames, output.QueueSize, output.PlayDuration.Round(time.Second))
+	c.uiManager.ShowMessage(b.String())
+}
+
+// showInvite 显示会话加入码
+func (c *VoiceAssistantClient) showInvite(invite *protocol.Invite) {
+	ttl := time.Until(c.wsClient.ServerTimeToLocal(invite.ExpiresAt)).Round(time.Second)
+	c.uiManager.ShowMessage(fmt.Sprintf("👥 加入码: %s（%s，%v 内有效，在其他设备配置 session.join: %s 和 session.join_code: %s）",
+		invite.Code, invite.Role, ttl, c.wsClient.GetSessionID(), invite.Code))
+}
+
+// showHandover 显示会话转移的结果：生成的令牌、接管的对话历史，或本设备的对话已被接管
+func (c *VoiceAssistantClient) showHandover(status *protocol.StatusData) {
+	handover := status.Handover
+	switch {
+	case handover.Token != "":
+		ttl := time.Until(c.wsClient.ServerTimeToLocal(handover.ExpiresAt)).Round(time.Second)
+		c.uiManager.ShowMessage(fmt.Sprintf("📲 转移令牌: %s（%v 内在新设备输入 :claim %s）", handover.Token, ttl, handover.Token))
+	case handover.Transferred:
+		c.uiManager.ShowMessage("📲 对话已转移到其他设备，本设备的会话已停止")
+	case handover.From != "":
+		var b strings.Builder
+		fmt.Fprintf(&b, "📲 已接管对话（%d 条历史）", len(status.History))
+		for _, turn := range status.History {
+			role := "你"
+			if turn.Role == "assistant" {
+				role = "助手"
+			}
+			fmt.Fprintf(&b, "\n  %s: %s", role, turn.Content)
+		}
+		c.uiManager.ShowMessage(b.String())
+	}
+}
+
+// showVoiceList 显示 :voices 命令返回的声音列表
+func (c *VoiceAssistantClient) showVoiceList(data *protocol.VoiceListData) {
+	var buf bytes.Buffer
+	printVoices(&buf, data)
+	c.uiManager.ShowMessage(strings.TrimRight(buf.String(), "\n"))
+}
+
+// formatBytes 可读的字节数
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1fMB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1fKB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%dB", n)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+
+	"voice_assistant/pkg/protocol"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCommand(t *testing.T) {
+	name, args := parseCommand("  Mode   push_to_talk ")
+	assert.Equal(t, "mode", name)
+	assert.Equal(t, []string{"push_to_talk"}, args)
+
+	name, args = parseCommand("claim K7M2Q9XR4T")
+	assert.Equal(t, "claim", name)
+	assert.Equal(t, []string{"K7M2Q9XR4T"}, args)
+
+	name, _ = parseCommand("   ")
+	assert.Empty(t, name)
+}
+
+func TestParseCommandArgs(t *testing.T) {
+	mode, err := parseModeArgs([]string{"DUPLEX"})
+	require.NoError(t, err)
+	assert.Equal(t, protocol.ModeDuplex, mode)
+	for _, args := range [][]string{nil, {"always_on"}, {"single", "duplex"}} {
+		_, err = parseModeArgs(args)
+		assert.Error(t, err, args)
+	}
+
+	// 倍速可带x后缀，范围由播放端校验
+	speed, err := parseSpeedArg("1.25X")
+	require.NoError(t, err)
+	assert.Equal(t, 1.25, speed)
+	_, err = parseSpeedArg("fast")
+	assert.Error(t, err)
+
+	// 邀请角色默认listener
+	role, err := parseInviteArgs(nil)
+	require.NoError(t, err)
+	assert.Equal(t, protocol.RoleListener, role)
+	role, err = parseInviteArgs([]string{"Speaker"})
+	require.NoError(t, err)
+	assert.Equal(t, protocol.RoleSpeaker, role)
+	for _, args := range [][]string{{"owner"}, {"speaker", "listener"}} {
+		_, err = parseInviteArgs(args)
+		assert.Error(t, err, args)
+	}
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"voice_assistant/voice_assistant_client/internal/audio"
+	"voice_assistant/voice_assistant_client/internal/client"
+	"voice_assistant/voice_assistant_client/internal/config"
+)
+
+// debugServer 本地调试HTTP服务（advanced.debug.enabled 时启动）
+// /debug/stats 返回统计快照，/debug/dump 查看和切换调试转储，profile_cpu、profile_memory 开启对应的 /debug/pprof/ 接口。
+type debugServer struct {
+	client  *VoiceAssistantClient
+	config  config.DebugConfig
+	server  *http.Server
+	started time.Time
+
+	// 麦克风音频转储：开启后上传的音频追加写入 dump_dir 下的PCM文件，关闭时结束当前文件
+	dumpAudio atomic.Bool
+	audioMu   sync.Mutex
+	audioFile *os.File
+}
+
+// DebugStats 调试统计快照
+type DebugStats struct {
+	Uptime      string             `json:"uptime"`
+	Connection  ConnectionSnapshot `json:"connection"`
+	AudioInput  audio.AudioStats   `json:"audio_input"`
+	AudioOutput audio.OutputStats  `json:"audio_output"`
+	Queues      QueueSnapshot      `json:"queues"`
+	Runtime     RuntimeSnapshot    `json:"runtime"`
+}
+
+// ConnectionSnapshot 连接状态和统计
+type ConnectionSnapshot struct {
+	State            string                 `json:"state"`
+	SessionID        string                 `json:"session_id"`
+	Stats            client.ConnectionStats `json:"stats"`
+	ReconnectAttempt int                    `json:"reconnect_attempt,omitempty"`
+}
+
+// QueueSnapshot 各队列中等待处理的数量
+type QueueSnapshot struct {
+	client.QueueDepths
+	AudioInput int `json:"audio_input"` // 已采集尚未发送的音频块
+}
+
+// RuntimeSnapshot Go运行时统计
+type RuntimeSnapshot struct {
+	Goroutines   int    `json:"goroutines"`
+	HeapAlloc    uint64 `json:"heap_alloc"`
+	HeapInuse    uint64 `json:"heap_inuse"`
+	Sys          uint64 `json:"sys"`
+	NumGC        uint32 `json:"num_gc"`
+	PauseTotalNs uint64 `json:"pause_total_ns"`
+}
+
+// DumpSettings 调试转储开关
+type DumpSettings struct {
+	DumpAudio    *bool `json:"dump_audio,omitempty"`
+	DumpMessages *bool `json:"dump_messages,omitempty"`
+}
+
+// newDebugServer 创建调试服务，按配置设置转储的初始状态
+func newDebugServer(c *VoiceAssistantClient, cfg config.DebugConfig) *debugServer {
+	d := &debugServer{client: c, config: cfg, started: time.Now()}
+	d.dumpAudio.Store(cfg.DumpAudio)
+	c.wsClient.SetMessageDump(cfg.DumpMessages)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/stats", d.handleStats)
+	mux.HandleFunc("/debug/dump", d.handleDump)
+	if cfg.ProfileCPU || cfg.ProfileMemory {
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.Handle("/debug/pprof/goroutine", pprof.Handler("goroutine"))
+	}
+	if cfg.ProfileCPU {
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	} else {
+		mux.HandleFunc("/debug/pprof/profile", profileDisabled("profile_cpu"))
+		mux.HandleFunc("/debug/pprof/trace", profileDisabled("profile_cpu"))
+	}
+	if cfg.ProfileMemory {
+		mux.Handle("/debug/pprof/heap", pprof.Handler("heap"))
+		mux.Handle("/debug/pprof/allocs", pprof.Handler("allocs"))
+	} else {
+		mux.HandleFunc("/debug/pprof/heap", profileDisabled("profile_memory"))
+		mux.HandleFunc("/debug/pprof/allocs", profileDisabled("profile_memory"))
+	}
+	d.server = &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	return d
+}
+
+// Start 在配置的地址上开始监听（监听失败时返回错误，不影响客户端其他功能）
+func (d *debugServer) Start() error {
+	lis, err := net.Listen("tcp", d.config.Listen)
+	if err != nil {
+		return fmt.Errorf("调试服务监听失败: %w", err)
+	}
+	log.Printf("调试服务启动在 http://%s/debug/stats", lis.Addr())
+	go func() {
+		if err := d.server.Serve(lis); err != nil && err != http.ErrServerClosed {
+			log.Printf("调试服务异常退出: %v", err)
+		}
+	}()
+	return nil
+}
+
+// Stop 关闭调试服务并结束音频转储文件
+func (d *debugServer) Stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := d.server.Shutdown(ctx); err != nil {
+		log.Printf("关闭调试服务失败: %v", err)
+	}
+	d.closeAudioDump()
+}
+
+// Snapshot 当前的统计快照
+func (d *debugServer) Snapshot() DebugStats {
+	c := d.client
+	status := c.wsClient.Status()
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	return DebugStats{
+		Uptime: time.Since(d.started).Round(time.Second).String(),
+		Connection: ConnectionSnapshot{
+			State:            status.State.String(),
+			SessionID:        c.wsClient.GetSessionID(),
+			Stats:            c.wsClient.GetStats(),
+			ReconnectAttempt: status.ReconnectAttempt,
+		},
+		AudioInput:  c.audioInput.GetStats(),
+		AudioOutput: c.audioOutput.GetStats(),
+		Queues: QueueSnapshot{
+			QueueDepths: c.wsClient.QueueDepths(),
+			AudioInput:  len(c.audioInput.GetAudioChannel()),
+		},
+		Runtime: RuntimeSnapshot{
+			Goroutines:   runtime.NumGoroutine(),
+			HeapAlloc:    mem.HeapAlloc,
+			HeapInuse:    mem.HeapInuse,
+			Sys:          mem.Sys,
+			NumGC:        mem.NumGC,
+			PauseTotalNs: mem.PauseTotalNs,
+		},
+	}
+}
+
+// handleStats GET /debug/stats
+func (d *debugServer) handleStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "只支持GET", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, d.Snapshot())
+}
+
+// handleDump GET /debug/dump 查看转储开关，POST 按请求体中给出的字段切换
+func (d *debugServer) handleDump(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var settings DumpSettings
+		if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+			http.Error(w, "请求格式错误: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if settings.DumpAudio != nil {
+			d.SetAudioDump(*settings.DumpAudio)
+		}
+		if settings.DumpMessages != nil {
+			d.client.wsClient.SetMessageDump(*settings.DumpMessages)
+		}
+		log.Printf("调试转储: dump_audio=%t, dump_messages=%t", d.dumpAudio.Load(), d.client.wsClient.MessageDump())
+	default:
+		http.Error(w, "只支持GET和POST", http.StatusMethodNotAllowed)
+		return
+	}
+
+	audioDump, messageDump := d.dumpAudio.Load(), d.client.wsClient.MessageDump()
+	writeJSON(w, DumpSettings{DumpAudio: &audioDump, DumpMessages: &messageDump})
+}
+
+// SetAudioDump 开启或关闭麦克风音频转储（关闭时结束当前文件，再次开启写入新文件）
+func (d *debugServer) SetAudioDump(enabled bool) {
+	d.dumpAudio.Store(enabled)
+	if !enabled {
+		d.closeAudioDump()
+	}
+}
+
+// writeAudio 转储开启时追加写入上传的音频（首次写入时创建文件，出错后关闭转储）
+func (d *debugServer) writeAudio(data []byte) {
+	if d == nil || !d.dumpAudio.Load() || len(data) == 0 {
+		return
+	}
+
+	d.audioMu.Lock()
+	defer d.audioMu.Unlock()
+
+	if d.audioFile == nil {
+		if err := os.MkdirAll(d.config.DumpDir, 0755); err != nil {
+			log.Printf("创建转储目录失败: %v", err)
+			d.dumpAudio.Store(false)
+			return
+		}
+		path := filepath.Join(d.config.DumpDir, fmt.Sprintf("mic_%s.pcm", time.Now().Format("20060102_150405")))
+		file, err := os.Create(path)
+		if err != nil {
+			log.Printf("创建音频转储文件失败: %v", err)
+			d.dumpAudio.Store(false)
+			return
+		}
+		log.Printf("麦克风音频转储到 %s", path)
+		d.audioFile = file
+	}
+
+	if _, err := d.audioFile.Write(data); err != nil {
+		log.Printf("写入音频转储失败: %v", err)
+		d.dumpAudio.Store(false)
+		d.audioFile.Close()
+		d.audioFile = nil
+	}
+}
+
+// closeAudioDump 结束当前的音频转储文件
+func (d *debugServer) closeAudioDump() {
+	d.audioMu.Lock()
+	defer d.audioMu.Unlock()
+	if d.audioFile != nil {
+		d.audioFile.Close()
+		d.audioFile = nil
+	}
+}
+
+// profileDisabled 未开启对应配置的pprof接口返回404并提示
+func profileDisabled(option string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, fmt.Sprintf("未开启 advanced.debug.%s", option), http.StatusNotFound)
+	}
+}
+
+// writeJSON 输出JSON响应
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		log.Printf("输出调试信息失败: %v", err)
+	}
+}
@@ -2,18 +2,21 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
-	"os/signal"
-	"syscall"
 	"time"
 
+	"voice_assistant/pkg/logging"
 	"voice_assistant/pkg/protocol"
+	"voice_assistant/pkg/service"
+	"voice_assistant/voice_assistant_client/assistant"
 	"voice_assistant/voice_assistant_client/internal/audio"
 	"voice_assistant/voice_assistant_client/internal/client"
 	"voice_assistant/voice_assistant_client/internal/config"
+	"voice_assistant/voice_assistant_client/internal/hotkey"
 	"voice_assistant/voice_assistant_client/internal/ui"
 )
 
@@ -30,25 +33,27 @@ var (
 	showDevices = flag.Bool("devices", false, "显示音频设备列表")
 	debugMode   = flag.Bool("debug", false, "启用调试模式")
 	serverURL   = flag.String("server", "", "服务器URL (覆盖配置文件)")
-	sessionMode = flag.String("mode", "", "会话模式 (continuous/single/wakeword)")
+	sessionMode = flag.String("mode", "", "会话模式 (continuous/single/wakeword/push_to_talk/duplex)")
+	transcribe  = flag.String("transcribe", "", "转写音频文件（WAV/PCM，可在参数后追加更多文件），不使用音频设备")
+	outputFile  = flag.String("output", "", "转写结果保存路径（JSON Lines）")
+	listVoices  = flag.Bool("voices", false, "显示服务端TTS支持的声音列表（可在参数后指定语言过滤，如 -voices zh）")
+	calibrate   = flag.Bool("calibrate", false, "校准麦克风：测量环境噪声和说话电平，设置输入增益和VAD阈值")
+	calibAll    = flag.Bool("calibrate-all", false, "校准时逐个测试所有输入设备，选择信噪比最高的设备")
+	calibSecs   = flag.Int("calibrate-seconds", 3, "校准时每段录音的秒数")
+	trayMode    = flag.Bool("tray", false, "以系统托盘模式运行（需以 -tags tray 编译）")
 )
 
-// VoiceAssistantClient 语音助手客户端
+// VoiceAssistantClient 语音助手客户端：在 assistant 包的基础上提供终端界面、按键、系统托盘和调试服务
 type VoiceAssistantClient struct {
 	config      *config.Config
+	assistant   *assistant.Assistant
 	wsClient    *client.WebSocketClient
 	audioInput  *audio.AudioInput
 	audioOutput *audio.AudioOutput
 	uiManager   *ui.Manager
+	debug       *debugServer // 本地调试服务（未启用时为nil）
 
-	// 状态管理
-	isRunning   bool
-	isRecording bool
-	isPlaying   bool
-
-	// 音频处理
-	chunkID     int
-	audioBuffer [][]byte
+	members int // 多客户端会话的成员数（没有其他客户端加入时为0）
 }
 
 func main() {
@@ -72,22 +77,84 @@ func main() {
 		log.Fatalf("加载配置失败: %v", err)
 	}
 
+	// 显示服务端声音列表
+	if *listVoices {
+		os.Exit(runListVoices(cfg, flag.Arg(0)))
+	}
+
+	// 麦克风校准
+	if *calibrate || *calibAll {
+		os.Exit(runCalibrate(cfg, *configFile, *calibAll, *calibSecs))
+	}
+
+	// 文件转写模式
+	if *transcribe != "" {
+		os.Exit(runTranscribe(cfg, append([]string{*transcribe}, flag.Args()...), *outputFile))
+	}
+
+	// 系统服务管理子命令
+	if isServiceCommand(flag.Arg(0)) {
+		if err := runServiceCommand(flag.Arg(0), *configFile, flag.Args()[1:]); err != nil {
+			log.Fatalf("%v", err)
+		}
+		return
+	}
+
+	// 按 logging 配置输出日志（以系统服务运行时没有控制台，需输出到文件）
+	logCloser, err := logging.Setup(cfg.ToLoggingConfig())
+	if err != nil {
+		log.Fatalf("设置日志输出失败: %v", err)
+	}
+	defer logCloser.Close()
+
+	// 前台运行或由服务管理器启动（client run）
+	if err := service.Run(serviceName, func(ctx context.Context) error {
+		return runClient(ctx, cfg)
+	}); err != nil {
+		log.Fatalf("%v", err)
+	}
+}
+
+// runClient 创建并启动客户端，ctx取消（收到退出信号或服务停止请求）或从托盘菜单退出后停止
+func runClient(ctx context.Context, cfg *config.Config) error {
 	// 创建客户端
 	client, err := NewVoiceAssistantClient(cfg)
 	if err != nil {
-		log.Fatalf("创建客户端失败: %v", err)
+		return fmt.Errorf("创建客户端失败: %w", err)
 	}
+	// 无论以何种方式返回（含启动失败和panic）都恢复终端输入模式
+	defer client.uiManager.RestoreTerminal()
 
 	// 启动客户端
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
 	if err := client.Start(ctx); err != nil {
-		log.Fatalf("启动客户端失败: %v", err)
+		return fmt.Errorf("启动客户端失败: %w", err)
 	}
 
-	// 等待信号
-	waitForSignal(cancel)
+	// 通知服务管理器启动完成（systemd Type=notify）
+	service.Ready()
+
+	// 等待退出；托盘模式下托盘事件循环占用主协程，收到信号或从托盘菜单退出后返回
+	if cfg.UI.Tray.Enabled {
+		trayDone := make(chan struct{})
+		go func() {
+			select {
+			case <-ctx.Done():
+				client.uiManager.QuitTray()
+			case <-trayDone:
+			}
+		}()
+		err := client.runTray()
+		close(trayDone)
+		if err != nil {
+			log.Printf("启动系统托盘失败: %v", err)
+			<-ctx.Done()
+		}
+	} else {
+		<-ctx.Done()
+	}
 
 	// 停止客户端
 	if err := client.Stop(); err != nil {
@@ -95,41 +162,61 @@ func main() {
 	}
 
 	log.Println("客户端已退出")
+	return nil
 }
 
 // NewVoiceAssistantClient 创建语音助手客户端
 func NewVoiceAssistantClient(cfg *config.Config) (*VoiceAssistantClient, error) {
-	// 创建WebSocket客户端
-	wsClient := client.NewWebSocketClient(cfg.ToClientConfig())
-
-	// 创建音频输入
-	audioInput, err := audio.NewAudioInput(cfg.ToAudioInputConfig())
-	if err != nil {
-		return nil, fmt.Errorf("创建音频输入失败: %w", err)
+	c := &VoiceAssistantClient{
+		config:    cfg,
+		uiManager: ui.NewManager(cfg.UI),
 	}
 
-	// 创建音频输出
-	audioOutput, err := audio.NewAudioOutput(cfg.ToAudioOutputConfig())
+	a, err := assistant.New(cfg, c.handler())
 	if err != nil {
-		return nil, fmt.Errorf("创建音频输出失败: %w", err)
-	}
-
-	// 创建UI管理器
-	uiManager := ui.NewManager(cfg.UI)
-
-	client := &VoiceAssistantClient{
-		config:      cfg,
-		wsClient:    wsClient,
-		audioInput:  audioInput,
-		audioOutput: audioOutput,
-		uiManager:   uiManager,
-		audioBuffer: make([][]byte, 0),
+		return nil, err
 	}
+	c.assistant = a
+	c.wsClient = a.Connection()
+	c.audioInput = a.Input()
+	c.audioOutput = a.Output()
 
-	// 注册消息处理器
-	client.registerMessageHandlers()
+	return c, nil
+}
 
-	return client, nil
+// handler 把语音助手的事件显示到界面
+func (c *VoiceAssistantClient) handler() assistant.Handler {
+	h := assistant.Handler{
+		OnTranscript: func(t assistant.Transcript) {
+			c.uiManager.ShowASRResult(t.Text, t.Confidence, t.Final, t.Words)
+		},
+		OnReply: c.uiManager.ShowLLMResponse,
+		OnSpeech: func(s assistant.Speech) {
+			c.uiManager.ShowTTSAudio(s.Bytes, s.PlayAt)
+		},
+		OnStatus:        c.handleStatus,
+		OnVoiceActivity: c.uiManager.ShowVoiceActivity,
+		OnRecording: func(recording bool) {
+			if recording {
+				c.uiManager.ShowMessage("🎤 开始录音...")
+			} else {
+				c.uiManager.ShowMessage("⏹️ 停止录音")
+			}
+		},
+		// 开启调试音频转储时同时写入转储文件
+		OnAudio:        func(pcm []byte) { c.debug.writeAudio(pcm) },
+		OnNotification: c.showNotification,
+		OnVoiceList:    c.showVoiceList,
+		OnError: func(data *protocol.ErrorData) {
+			c.uiManager.ShowError(data.Code, data.Message)
+		},
+		OnConnection: c.handleConnectionStatus,
+		OnInfo:       c.uiManager.ShowMessage,
+	}
+	if c.config.UI.ShowAudioLevel {
+		h.OnAudioLevel = c.uiManager.UpdateAudioLevel
+	}
+	return h
 }
 
 // Start 启动客户端
@@ -141,35 +228,40 @@ func (c *VoiceAssistantClient) Start(ctx context.Context) error {
 		return fmt.Errorf("启动UI失败: %w", err)
 	}
 
-	// 连接到服务器
-	if err := c.wsClient.Connect(ctx); err != nil {
-		return fmt.Errorf("连接服务器失败: %w", err)
-	}
-
-	// 启动音频输入
-	if err := c.audioInput.Start(ctx); err != nil {
-		return fmt.Errorf("启动音频输入失败: %w", err)
+	// 本地调试服务（统计快照、运行时转储开关、pprof）；监听失败时配置的转储仍然生效
+	if c.config.Advanced.Debug.Enabled {
+		c.debug = newDebugServer(c, c.config.Advanced.Debug)
+		if err := c.debug.Start(); err != nil {
+			log.Printf("%v", err)
+		}
 	}
 
-	// 启动音频输出
-	if err := c.audioOutput.Start(ctx); err != nil {
-		return fmt.Errorf("启动音频输出失败: %w", err)
+	// 连接服务器、启动音频设备并开始会话
+	if err := c.assistant.Start(ctx); err != nil {
+		return err
 	}
 
-	// 启动音频处理协程
-	go c.audioProcessingLoop(ctx)
+	// 全局快捷键（终端不在前台时也能按键说话）
+	hotkeyOK := c.startHotkey(ctx)
 
-	// 启动会话
-	mode := c.config.Session.Mode
-	if *sessionMode != "" {
-		mode = *sessionMode
-	}
-
-	if err := c.wsClient.StartSession(mode); err != nil {
-		return fmt.Errorf("启动会话失败: %w", err)
+	// 启动键盘事件循环（按键说话、切换音频设备）；非控制台UI只有按键说话模式需要键盘（已注册全局快捷键时除外）
+	mode := c.assistant.Mode()
+	keyEvents, err := c.uiManager.StartKeyboard(ctx)
+	if err != nil {
+		if mode == protocol.ModePushToTalk && !hotkeyOK {
+			return fmt.Errorf("启动按键说话失败: %w", err)
+		}
+	} else {
+		go c.keyboardLoop(ctx, keyEvents)
+		if mode == protocol.ModePushToTalk {
+			c.uiManager.ShowMessage("⌨️ 按空格或回车开始说话，再按一次结束")
+		}
+		if mode == protocol.ModeWakeword {
+			c.uiManager.ShowMessage("⌨️ 按空格或回车唤醒助手")
+		}
+		c.uiManager.ShowMessage("⌨️ 按 I 切换输入设备，按 O 切换输出设备，输入 :help 查看命令")
 	}
 
-	c.isRunning = true
 	log.Printf("客户端启动成功，会话模式: %s", mode)
 
 	return nil
@@ -177,194 +269,172 @@ func (c *VoiceAssistantClient) Start(ctx context.Context) error {
 
 // Stop 停止客户端
 func (c *VoiceAssistantClient) Stop() error {
-	if !c.isRunning {
-		return nil
-	}
-
-	log.Println("正在停止客户端...")
-
-	c.isRunning = false
-
-	// 停止会话
-	if c.wsClient.IsConnected() {
-		c.wsClient.StopSession()
+	if err := c.assistant.Stop(); err != nil {
+		return err
 	}
 
-	// 停止音频输入
-	if c.audioInput != nil {
-		c.audioInput.Stop()
-	}
-
-	// 停止音频输出
-	if c.audioOutput != nil {
-		c.audioOutput.Stop()
-	}
-
-	// 断开WebSocket连接
-	if c.wsClient != nil {
-		c.wsClient.Disconnect()
+	// 停止调试服务
+	if c.debug != nil {
+		c.debug.Stop()
 	}
 
 	// 停止UI
-	if c.uiManager != nil {
-		c.uiManager.Stop()
-	}
+	c.uiManager.Stop()
 
 	return nil
 }
 
-// registerMessageHandlers 注册消息处理器
-func (c *VoiceAssistantClient) registerMessageHandlers() {
-	// 响应消息处理器
-	c.wsClient.RegisterHandler(protocol.Response, c.handleResponseMessage)
-
-	// 状态消息处理器
-	c.wsClient.RegisterHandler(protocol.Status, c.handleStatusMessage)
-
-	// 错误消息处理器
-	c.wsClient.RegisterHandler(protocol.Error, c.handleErrorMessage)
-}
-
-// handleResponseMessage 处理响应消息
-func (c *VoiceAssistantClient) handleResponseMessage(msg *protocol.Message) error {
-	respData, err := protocol.ParseResponseData(msg.Data)
-	if err != nil {
-		return fmt.Errorf("解析响应数据失败: %w", err)
-	}
-
-	switch respData.Stage {
-	case protocol.StageASR:
-		// ASR识别结果
-		c.uiManager.ShowASRResult(respData.Content, respData.Confidence, respData.IsFinal)
-
-	case protocol.StageLLM:
-		// LLM回复结果
-		c.uiManager.ShowLLMResponse(respData.Content, respData.IsFinal)
-
-	case protocol.StageTTS:
-		// TTS音频数据
-		if len(respData.AudioData) > 0 {
-			if err := c.audioOutput.PlayBytes(respData.AudioData); err != nil {
-				log.Printf("播放音频失败: %v", err)
-			}
+// handleConnectionStatus 把连接状态显示到状态行（连接中、已连接及延迟、重连进度、离线）
+func (c *VoiceAssistantClient) handleConnectionStatus(status assistant.ConnectionStatus) {
+	switch {
+	case status.State == assistant.ConnectionConnected:
+		c.uiManager.UpdateConnectionStatus(ui.ConnectionConnected, status.Latency, "")
+	case status.State == assistant.ConnectionConnecting && status.ReconnectAttempt > 0:
+		detail := fmt.Sprintf("第%d/%d次", status.ReconnectAttempt, status.MaxReconnectAttempts)
+		if status.RetryIn > 0 {
+			detail += fmt.Sprintf("，%d秒后", int(status.RetryIn.Round(time.Second)/time.Second))
 		}
+		c.uiManager.UpdateConnectionStatus(ui.ConnectionReconnecting, 0, detail)
+	case status.State == assistant.ConnectionConnecting:
+		c.uiManager.UpdateConnectionStatus(ui.ConnectionConnecting, 0, "")
+	case status.GiveUpReason != "":
+		c.uiManager.ShowError("RECONNECT_GAVE_UP", fmt.Sprintf("已放弃重连（%s），请检查服务器后重新启动客户端", status.GiveUpReason))
+		c.uiManager.UpdateConnectionStatus(ui.ConnectionDisconnected, 0, status.GiveUpReason)
+	default:
+		c.uiManager.UpdateConnectionStatus(ui.ConnectionDisconnected, 0, "")
 	}
-
-	return nil
 }
 
-// handleStatusMessage 处理状态消息
-func (c *VoiceAssistantClient) handleStatusMessage(msg *protocol.Message) error {
-	statusData, err := protocol.ParseStatusData(msg.Data)
-	if err != nil {
-		return fmt.Errorf("解析状态数据失败: %w", err)
+// handleStatus 显示服务端通知的会话状态、成员数变化、加入码和会话转移结果
+func (c *VoiceAssistantClient) handleStatus(status *protocol.StatusData) {
+	c.uiManager.UpdateStatus(status.State, status.Mode)
+	if n := len(status.Members); n > 0 && n != c.members {
+		c.uiManager.ShowMessage(fmt.Sprintf("👥 会话成员: %d", n))
 	}
-
-	// 更新UI状态显示
-	c.uiManager.UpdateStatus(statusData.State, statusData.Mode)
-
-	// 根据状态调整录音状态
-	switch statusData.State {
-	case protocol.StateListening:
-		if !c.isRecording {
-			c.startRecording()
-		}
-	case protocol.StateProcessing, protocol.StateSpeaking:
-		if c.isRecording {
-			c.stopRecording()
-		}
+	c.members = len(status.Members)
+	if status.Handover != nil {
+		c.showHandover(status)
 	}
-
-	return nil
-}
-
-// handleErrorMessage 处理错误消息
-func (c *VoiceAssistantClient) handleErrorMessage(msg *protocol.Message) error {
-	errorData, err := protocol.ParseErrorData(msg.Data)
-	if err != nil {
-		return fmt.Errorf("解析错误数据失败: %w", err)
+	if status.Invite != nil {
+		c.showInvite(status.Invite)
 	}
+}
 
-	// 显示错误信息
-	c.uiManager.ShowError(errorData.Code, errorData.Message)
-
-	// 如果是不可恢复的错误，停止客户端
-	if !errorData.Recoverable {
-		log.Printf("收到不可恢复错误，停止客户端: %s", errorData.Message)
-		go func() {
-			time.Sleep(time.Second)
-			c.Stop()
-		}()
+// showNotification 显示服务端推送的通知（附带链接时一并显示）
+func (c *VoiceAssistantClient) showNotification(data *protocol.NotificationData) {
+	content := data.Content
+	if url, ok := data.Data["url"].(string); ok && url != "" {
+		content = fmt.Sprintf("%s（%s）", content, url)
 	}
-
-	return nil
+	c.uiManager.ShowNotification(data.Kind, data.Level, data.Title, content)
 }
 
-// audioProcessingLoop 音频处理循环
-func (c *VoiceAssistantClient) audioProcessingLoop(ctx context.Context) {
-	audioChan := c.audioInput.GetAudioChannel()
-
+// keyboardLoop 键盘事件循环：空格/回车在按键说话模式下开始或结束录音，I/O 切换到下一个输入/输出设备，冒号开头的输入作为命令执行
+func (c *VoiceAssistantClient) keyboardLoop(ctx context.Context, keyEvents <-chan ui.KeyEvent) {
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case audioData := <-audioChan:
-			if !c.isRunning || !c.isRecording {
-				continue
+		case event, ok := <-keyEvents:
+			if !ok {
+				return
 			}
-
-			// 转换音频数据为字节
-			audioBytes := audio.Float32ToBytes(audioData)
-
-			// 发送音频流
-			c.chunkID++
-			if err := c.wsClient.SendAudioStream(audioBytes, c.chunkID, false); err != nil {
-				log.Printf("发送音频流失败: %v", err)
+			if event.Command != "" {
+				c.runCommand(event.Command)
+				continue
 			}
 
-			// 更新UI音频级别显示
-			if c.config.UI.ShowAudioLevel {
-				stats := c.audioInput.GetStats()
-				c.uiManager.UpdateAudioLevel(stats.AverageLevel, stats.PeakLevel)
+			switch event.Key {
+			case ui.KeySpace, ui.KeyEnter:
+				c.handleTalkKey()
+			case 'i', 'I':
+				c.switchInputDevice()
+			case 'o', 'O':
+				c.switchOutputDevice()
 			}
 		}
 	}
 }
 
-// startRecording 开始录音
-func (c *VoiceAssistantClient) startRecording() {
-	if c.isRecording {
-		return
+// handleTalkKey 说话键（空格、回车或全局快捷键）：唤醒词模式下唤醒，按键说话模式下开始或结束录音
+func (c *VoiceAssistantClient) handleTalkKey() {
+	err := c.assistant.Talk()
+	switch {
+	case errors.Is(err, assistant.ErrMicrophoneMuted):
+		c.uiManager.ShowMessage("🔇 麦克风已关闭，请先在系统托盘中开启")
+	case err != nil:
+		c.uiManager.ShowMessage(err.Error())
 	}
+}
 
-	if err := c.audioInput.StartRecording(); err != nil {
-		log.Printf("开始录音失败: %v", err)
-		return
+// startHotkey 注册配置的全局快捷键，按下时与说话键相同；注册失败时提示并继续使用终端按键
+func (c *VoiceAssistantClient) startHotkey(ctx context.Context) bool {
+	if c.config.Session.Hotkey == "" {
+		return false
+	}
+	combo, err := hotkey.Parse(c.config.Session.Hotkey)
+	if err == nil {
+		var hk *hotkey.Hotkey
+		if hk, err = hotkey.Register(combo); err == nil {
+			go func() {
+				<-ctx.Done()
+				hk.Close()
+			}()
+			go func() {
+				for range hk.Events() {
+					c.handleTalkKey()
+				}
+			}()
+			if mode := c.assistant.Mode(); mode == protocol.ModePushToTalk || mode == protocol.ModeWakeword {
+				c.uiManager.ShowMessage(fmt.Sprintf("⌨️ 全局快捷键 %s 已注册，终端不在前台时也可使用", combo))
+			}
+			return true
+		}
 	}
-
-	c.isRecording = true
-	c.chunkID = 0
-	c.uiManager.ShowMessage("🎤 开始录音...")
+	c.uiManager.ShowMessage(fmt.Sprintf("全局快捷键不可用: %v", err))
+	return false
 }
 
-// stopRecording 停止录音
-func (c *VoiceAssistantClient) stopRecording() {
-	if !c.isRecording {
+// switchInputDevice 切换到下一个输入设备（录音状态不变）
+func (c *VoiceAssistantClient) switchInputDevice() {
+	name, err := c.audioInput.NextDevice()
+	if err != nil {
+		c.uiManager.ShowMessage(fmt.Sprintf("切换输入设备失败: %v", err))
 		return
 	}
+	c.uiManager.ShowMessage("🎤 输入设备: " + name)
+}
 
-	if err := c.audioInput.StopRecording(); err != nil {
-		log.Printf("停止录音失败: %v", err)
+// switchOutputDevice 切换到下一个输出设备（未播放完的音频在新设备上继续）
+func (c *VoiceAssistantClient) switchOutputDevice() {
+	name, err := c.audioOutput.NextDevice()
+	if err != nil {
+		c.uiManager.ShowMessage(fmt.Sprintf("切换输出设备失败: %v", err))
 		return
 	}
+	c.uiManager.ShowMessage("🔈 输出设备: " + name)
+}
 
-	// 发送最终音频块
-	if err := c.wsClient.SendAudioStream([]byte{}, c.chunkID+1, true); err != nil {
-		log.Printf("发送最终音频块失败: %v", err)
-	}
+// runTray 运行系统托盘：菜单开关麦克风，点击退出时结束
+func (c *VoiceAssistantClient) runTray() error {
+	c.uiManager.SetMicrophone(c.assistant.MicrophoneEnabled())
+	return c.uiManager.RunTray(ui.TrayActions{
+		Microphone: c.setMicrophone,
+		Quit: func() {
+			log.Printf("从系统托盘退出")
+		},
+	})
+}
 
-	c.isRecording = false
-	c.uiManager.ShowMessage("⏹️ 停止录音")
+// setMicrophone 开关麦克风：关闭时结束当前录音，开启后服务端处于聆听状态则立即恢复录音
+func (c *VoiceAssistantClient) setMicrophone(enabled bool) {
+	if enabled {
+		c.uiManager.ShowMessage("🎤 麦克风已开启")
+	}
+	c.assistant.SetMicrophone(enabled)
+	if !enabled {
+		c.uiManager.ShowMessage("🔇 麦克风已关闭")
+	}
 }
 
 // loadConfig 加载配置
@@ -389,11 +459,19 @@ func loadConfig() (*config.Config, error) {
 		cfg.Server.Host = *serverURL
 	}
 
+	if *sessionMode != "" {
+		cfg.Session.Mode = *sessionMode
+	}
+
 	if *debugMode {
 		cfg.UI.LogLevel = "debug"
 		cfg.Advanced.Debug.Enabled = true
 	}
 
+	if *trayMode {
+		cfg.UI.Tray.Enabled = true
+	}
+
 	return cfg, nil
 }
 
@@ -409,13 +487,3 @@ func showAudioDevices() {
 		log.Printf("获取输出设备列表失败: %v", err)
 	}
 }
-
-// waitForSignal 等待信号
-func waitForSignal(cancel context.CancelFunc) {
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-
-	sig := <-sigChan
-	log.Printf("收到信号: %v", sig)
-	cancel()
-}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"voice_assistant/pkg/protocol"
+	"voice_assistant/voice_assistant_client/internal/audio"
+	"voice_assistant/voice_assistant_client/internal/client"
+	"voice_assistant/voice_assistant_client/internal/config"
+)
+
+// 文件转写参数
+const (
+	transcribeSampleRate = 16000           // 服务端要求的采样率
+	transcribeChunk      = 100             // 每个音频块的时长（毫秒）
+	transcribeTimeout    = 2 * time.Minute // 单个文件的处理超时
+)
+
+// TranscribeResult 单个文件的转写结果
+type TranscribeResult struct {
+	File       string  `json:"file"`
+	Transcript string  `json:"transcript"`
+	Confidence float64 `json:"confidence"`
+	Response   string  `json:"response,omitempty"`
+	DurationMs int64   `json:"duration_ms"` // 音频时长
+	Error      string  `json:"error,omitempty"`
+}
+
+// transcriber 文件转写：不依赖音频设备，把音频文件按块发送给服务端并收集识别文本和回复
+type transcriber struct {
+	wsClient *client.WebSocketClient
+
+	// 当前文件的处理状态（由消息处理协程更新）
+	result   *TranscribeResult
+	gotFinal bool
+	done     chan struct{}
+	mu       sync.Mutex
+}
+
+// runTranscribe 依次转写文件，结果打印到标准输出并可保存为JSON Lines，返回进程退出码
+func runTranscribe(cfg *config.Config, files []string, outputPath string) int {
+	var output *os.File
+	if outputPath != "" {
+		f, err := os.Create(outputPath)
+		if err != nil {
+			log.Printf("创建结果文件失败: %v", err)
+			return 1
+		}
+		defer f.Close()
+		output = f
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	t := &transcriber{wsClient: client.NewWebSocketClient(cfg.ToClientConfig())}
+	t.wsClient.RegisterHandler(protocol.Response, t.handleResponse)
+	t.wsClient.RegisterHandler(protocol.Status, t.handleStatus)
+	t.wsClient.RegisterHandler(protocol.Error, t.handleError)
+
+	if err := t.wsClient.Connect(ctx); err != nil {
+		log.Printf("连接服务器失败: %v", err)
+		return 1
+	}
+	defer t.wsClient.Disconnect()
+
+	if err := t.wsClient.SetDataCollection(cfg.Session.AllowDataCollection); err != nil {
+		log.Printf("设置数据采集授权失败: %v", err)
+	}
+
+	failed := 0
+	for _, file := range files {
+		result := t.transcribe(file)
+		if result.Error != "" {
+			failed++
+		}
+
+		fmt.Printf("[%s]\n", result.File)
+		if result.Error != "" {
+			fmt.Printf("  错误: %s\n", result.Error)
+		} else {
+			fmt.Printf("  识别: %s (置信度: %.2f)\n", result.Transcript, result.Confidence)
+			fmt.Printf("  回复: %s\n", result.Response)
+		}
+
+		if output != nil {
+			line, _ := json.Marshal(result)
+			if _, err := fmt.Fprintf(output, "%s\n", line); err != nil {
+				log.Printf("写入结果文件失败: %v", err)
+				return 1
+			}
+		}
+	}
+
+	t.wsClient.StopSession()
+
+	if failed > 0 {
+		log.Printf("转写完成: %d个文件，失败%d个", len(files), failed)
+		return 1
+	}
+	return 0
+}
+
+// transcribe 转写单个文件（每个文件单独一轮单次会话，文件之间不共享对话上下文）
+func (t *transcriber) transcribe(file string) *TranscribeResult {
+	result := &TranscribeResult{File: file}
+
+	pcm, err := audio.ReadAudioFile(file, transcribeSampleRate)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.DurationMs = int64(len(pcm)) * 1000 / (transcribeSampleRate * 2)
+
+	done := make(chan struct{})
+	t.mu.Lock()
+	t.result, t.gotFinal, t.done = result, false, done
+	t.mu.Unlock()
+
+	defer func() {
+		t.mu.Lock()
+		t.result, t.done = nil, nil
+		t.mu.Unlock()
+	}()
+
+	// 文件转写不是实时对话，服务端繁忙时让位于交互会话
+	params := map[string]interface{}{"text_only": true, "priority": "batch"}
+	if err := t.wsClient.StartSessionWithParameters(protocol.ModeSingle, params); err != nil {
+		result.Error = fmt.Sprintf("启动会话失败: %v", err)
+		return result
+	}
+
+	chunkSize := transcribeSampleRate * 2 * transcribeChunk / 1000
+	chunkID := 0
+	for start := 0; start < len(pcm); start += chunkSize {
+		end := start + chunkSize
+		if end > len(pcm) {
+			end = len(pcm)
+		}
+		chunkID++
+		if err := t.wsClient.SendAudioStream(pcm[start:end], chunkID, false); err != nil {
+			result.Error = fmt.Sprintf("发送音频失败: %v", err)
+			return result
+		}
+	}
+	chunkID++
+	if err := t.wsClient.SendAudioStream([]byte{}, chunkID, true); err != nil {
+		result.Error = fmt.Sprintf("发送最终音频块失败: %v", err)
+		return result
+	}
+
+	select {
+	case <-done:
+	case <-time.After(transcribeTimeout):
+		t.mu.Lock()
+		result.Error = "等待服务端结果超时"
+		t.mu.Unlock()
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return result
+}
+
+// finish 结束当前文件的处理（调用方需持有锁）
+func (t *transcriber) finish() {
+	if t.done != nil {
+		close(t.done)
+		t.done = nil
+	}
+}
+
+// handleResponse 收集最终识别结果和回复
+func (t *transcriber) handleResponse(msg *protocol.Message) error {
+	respData, err := msg.ResponseData()
+	if err != nil {
+		return fmt.Errorf("解析响应数据失败: %w", err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.result == nil || !respData.IsFinal {
+		return nil
+	}
+
+	switch respData.Stage {
+	case protocol.StageASR:
+		t.result.Transcript = respData.Content
+		t.result.Confidence = respData.Confidence
+		t.gotFinal = true
+	case protocol.StageLLM:
+		t.result.Response = respData.Content
+	}
+	return nil
+}
+
+// handleStatus 最终识别结果之后会话回到空闲或聆听状态，本轮处理结束
+func (t *transcriber) handleStatus(msg *protocol.Message) error {
+	statusData, err := msg.StatusData()
+	if err != nil {
+		return fmt.Errorf("解析状态数据失败: %w", err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.gotFinal && (statusData.State == protocol.StateIdle || statusData.State == protocol.StateListening) {
+		t.finish()
+	}
+	return nil
+}
+
+// handleError 服务端处理失败时结束当前文件
+func (t *transcriber) handleError(msg *protocol.Message) error {
+	errorData, err := msg.ErrorData()
+	if err != nil {
+		return fmt.Errorf("解析错误数据失败: %w", err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.result != nil {
+		t.result.Error = fmt.Sprintf("%s: %s", errorData.Code, errorData.Message)
+		t.finish()
+	}
+	return nil
+}
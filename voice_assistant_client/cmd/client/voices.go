@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"voice_assistant/pkg/protocol"
+	"voice_assistant/voice_assistant_client/internal/client"
+	"voice_assistant/voice_assistant_client/internal/config"
+)
+
+// voicesTimeout 等待声音列表的超时
+const voicesTimeout = 10 * time.Second
+
+// runListVoices 连接服务端获取TTS声音列表并打印，返回进程退出码
+func runListVoices(cfg *config.Config, language string) int {
+	ctx, cancel := context.WithTimeout(context.Background(), voicesTimeout)
+	defer cancel()
+
+	voices := make(chan *protocol.VoiceListData, 1)
+	failures := make(chan string, 1)
+
+	wsClient := client.NewWebSocketClient(cfg.ToClientConfig())
+	wsClient.RegisterHandler(protocol.VoiceList, func(msg *protocol.Message) error {
+		data, err := msg.VoiceListData()
+		if err != nil {
+			return fmt.Errorf("解析声音列表失败: %w", err)
+		}
+		select {
+		case voices <- data:
+		default:
+		}
+		return nil
+	})
+	wsClient.RegisterHandler(protocol.Error, func(msg *protocol.Message) error {
+		errorData, err := msg.ErrorData()
+		if err != nil {
+			return fmt.Errorf("解析错误数据失败: %w", err)
+		}
+		select {
+		case failures <- fmt.Sprintf("%s: %s", errorData.Code, errorData.Message):
+		default:
+		}
+		return nil
+	})
+
+	if err := wsClient.Connect(ctx); err != nil {
+		log.Printf("连接服务器失败: %v", err)
+		return 1
+	}
+	defer wsClient.Disconnect()
+
+	if err := wsClient.ListVoices(language); err != nil {
+		log.Printf("请求声音列表失败: %v", err)
+		return 1
+	}
+
+	select {
+	case data := <-voices:
+		printVoices(os.Stdout, data)
+		return 0
+	case failure := <-failures:
+		log.Printf("获取声音列表失败: %s", failure)
+	case <-ctx.Done():
+		log.Printf("获取声音列表超时")
+	}
+	return 1
+}
+
+// printVoices 以表格打印声音列表
+func printVoices(out io.Writer, data *protocol.VoiceListData) {
+	fmt.Fprintf(out, "TTS提供商: %s, 共%d个声音\n\n", data.Provider, len(data.Voices))
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\t名称\t语言\t性别\t描述")
+	for _, voice := range data.Voices {
+		name := voice.DisplayName
+		if name == "" {
+			name = voice.Name
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", voice.ID, name, voice.Language, voice.Gender, voice.Description)
+	}
+	w.Flush()
+}
@@ -0,0 +1,225 @@
+package audio
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gordonklaus/portaudio"
+)
+
+// 校准参数
+const (
+	targetSpeechLevel = -20.0 // 增益后的说话电平目标（dBFS）
+	minInputGain      = 0.5
+	maxInputGain      = 8.0
+	minVADMargin      = 3.0  // 阈值高于噪声的最小余量（dB）
+	maxVADMargin      = 15.0 // 阈值高于噪声的最大余量（dB）
+	minGoodSNR        = 10.0 // 低于该信噪比时提示更换设备或环境
+)
+
+// InputDevice 可用的输入设备（ID与配置中的 device_id 一致）
+type InputDevice struct {
+	ID                int
+	Name              string
+	DefaultSampleRate float64
+}
+
+// LevelStats 一段录音的电平统计（dBFS）
+type LevelStats struct {
+	Median float64 // 帧电平中位数（环境噪声）
+	Loud   float64 // 帧电平90分位（说话电平）
+	Peak   float64 // 采样峰值
+}
+
+// CalibrationResult 输入校准结果
+type CalibrationResult struct {
+	DeviceID     int
+	DeviceName   string
+	NoiseLevel   float64 // 环境噪声（dBFS）
+	SpeechLevel  float64 // 说话电平（dBFS）
+	SNR          float64 // 信噪比（dB）
+	Gain         float64 // 建议输入增益（倍率）
+	VADThreshold float64 // 建议VAD阈值（dBFS，已计入增益）
+	Clipping     bool    // 说话时出现削波
+}
+
+// Good 信噪比是否足以可靠地检测语音
+func (r CalibrationResult) Good() bool {
+	return r.SNR >= minGoodSNR && !r.Clipping
+}
+
+// FrameLevel 一帧音频的RMS电平（dBFS，静音为-100）
+func FrameLevel(samples []float32) float64 {
+	if len(samples) == 0 {
+		return -100.0
+	}
+
+	var sum float64
+	for _, sample := range samples {
+		sum += float64(sample) * float64(sample)
+	}
+	rms := math.Sqrt(sum / float64(len(samples)))
+	if rms > 0 {
+		return 20 * math.Log10(rms)
+	}
+	return -100.0
+}
+
+// AnalyzeLevels 统计多帧音频的电平
+func AnalyzeLevels(frames [][]float32) LevelStats {
+	if len(frames) == 0 {
+		return LevelStats{Median: -100, Loud: -100, Peak: -100}
+	}
+
+	levels := make([]float64, len(frames))
+	var peak float64
+	for i, frame := range frames {
+		levels[i] = FrameLevel(frame)
+		for _, sample := range frame {
+			if abs := math.Abs(float64(sample)); abs > peak {
+				peak = abs
+			}
+		}
+	}
+	sort.Float64s(levels)
+
+	peakLevel := -100.0
+	if peak > 0 {
+		peakLevel = 20 * math.Log10(peak)
+	}
+	return LevelStats{
+		Median: levels[len(levels)/2],
+		Loud:   levels[len(levels)*9/10],
+		Peak:   peakLevel,
+	}
+}
+
+// Recommend 根据安静时和说话时的电平计算建议的增益和VAD阈值
+func Recommend(noise, speech LevelStats) CalibrationResult {
+	snr := speech.Loud - noise.Median
+
+	// 增益把说话电平调整到目标值，说话时已接近削波则不再放大
+	gainDB := targetSpeechLevel - speech.Loud
+	if speech.Peak+gainDB > -1 {
+		gainDB = math.Min(gainDB, -1-speech.Peak)
+	}
+	gain := math.Pow(10, gainDB/20)
+	gain = math.Max(minInputGain, math.Min(maxInputGain, gain))
+	gain = math.Round(gain*10) / 10
+	gainDB = 20 * math.Log10(gain)
+
+	// 阈值取噪声与说话电平之间，余量随信噪比变化
+	margin := math.Max(minVADMargin, math.Min(maxVADMargin, snr/2))
+	threshold := noise.Median + gainDB + margin
+
+	return CalibrationResult{
+		NoiseLevel:   round1(noise.Median),
+		SpeechLevel:  round1(speech.Loud),
+		SNR:          round1(snr),
+		Gain:         gain,
+		VADThreshold: round1(threshold),
+		Clipping:     speech.Peak > -0.5,
+	}
+}
+
+// ListInputDevices 获取可用的音频输入设备
+func ListInputDevices() ([]InputDevice, error) {
+	if err := acquirePortAudio(); err != nil {
+		return nil, err
+	}
+	defer releasePortAudio()
+
+	devices, err := portaudio.Devices()
+	if err != nil {
+		return nil, fmt.Errorf("获取设备列表失败: %w", err)
+	}
+
+	var inputs []InputDevice
+	for i, device := range devices {
+		if device.MaxInputChannels > 0 {
+			inputs = append(inputs, InputDevice{ID: i, Name: device.Name, DefaultSampleRate: device.DefaultSampleRate})
+		}
+	}
+	return inputs, nil
+}
+
+// RecordFrames 从输入设备录制一段音频（deviceID为-1时使用默认设备），返回设备名称和各帧音频
+func RecordFrames(ctx context.Context, deviceID int, config InputConfig, duration time.Duration) (string, [][]float32, error) {
+	if err := acquirePortAudio(); err != nil {
+		return "", nil, err
+	}
+	defer releasePortAudio()
+
+	device, err := resolveInputDevice(deviceID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var frames [][]float32
+	var mu sync.Mutex
+	params := portaudio.StreamParameters{
+		Input: portaudio.StreamDeviceParameters{
+			Device:   device,
+			Channels: config.Channels,
+			Latency:  device.DefaultLowInputLatency,
+		},
+		SampleRate:      float64(config.SampleRate),
+		FramesPerBuffer: config.BufferSize,
+	}
+	stream, err := portaudio.OpenStream(params, func(in []float32) {
+		frame := make([]float32, len(in))
+		copy(frame, in)
+		mu.Lock()
+		frames = append(frames, frame)
+		mu.Unlock()
+	})
+	if err != nil {
+		return device.Name, nil, fmt.Errorf("打开音频流失败: %w", err)
+	}
+	defer stream.Close()
+
+	if err := stream.Start(); err != nil {
+		return device.Name, nil, fmt.Errorf("启动音频流失败: %w", err)
+	}
+
+	select {
+	case <-time.After(duration):
+	case <-ctx.Done():
+	}
+	if err := stream.Stop(); err != nil {
+		return device.Name, nil, fmt.Errorf("停止音频流失败: %w", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	return device.Name, frames, ctx.Err()
+}
+
+// resolveInputDevice 按配置中的 device_id 查找输入设备
+func resolveInputDevice(deviceID int) (*portaudio.DeviceInfo, error) {
+	if deviceID == -1 {
+		device, err := portaudio.DefaultInputDevice()
+		if err != nil {
+			return nil, fmt.Errorf("获取默认输入设备失败: %w", err)
+		}
+		return device, nil
+	}
+
+	devices, err := portaudio.Devices()
+	if err != nil {
+		return nil, fmt.Errorf("获取设备列表失败: %w", err)
+	}
+	if deviceID < 0 || deviceID >= len(devices) {
+		return nil, fmt.Errorf("设备ID %d 超出范围", deviceID)
+	}
+	return devices[deviceID], nil
+}
+
+// round1 保留一位小数
+func round1(value float64) float64 {
+	return math.Round(value*10) / 10
+}
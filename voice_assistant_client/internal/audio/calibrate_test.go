@@ -0,0 +1,61 @@
+package audio
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// toneFrames 生成指定幅度的正弦波帧，叠加白噪声
+func toneFrames(amplitude, noise float64, count int) [][]float32 {
+	rng := rand.New(rand.NewSource(1))
+	frames := make([][]float32, count)
+	for i := range frames {
+		frame := make([]float32, 1024)
+		for j := range frame {
+			sample := amplitude*math.Sin(float64(i*1024+j)*2*math.Pi*440/16000) + noise*(rng.Float64()*2-1)
+			frame[j] = float32(sample)
+		}
+		frames[i] = frame
+	}
+	return frames
+}
+
+func TestFrameLevel(t *testing.T) {
+	assert.Equal(t, -100.0, FrameLevel(nil))
+	assert.Equal(t, -100.0, FrameLevel(make([]float32, 10)))
+	assert.InDelta(t, 0.0, FrameLevel([]float32{1, -1, 1, -1}), 0.001)
+	assert.InDelta(t, -6.02, FrameLevel([]float32{0.5, -0.5}), 0.01)
+}
+
+func TestRecommend(t *testing.T) {
+	noise := AnalyzeLevels(toneFrames(0, 0.002, 30))
+	speech := AnalyzeLevels(toneFrames(0.02, 0.002, 30))
+
+	result := Recommend(noise, speech)
+	assert.Greater(t, result.SNR, 10.0)
+	assert.True(t, result.Good())
+	// 说话电平偏低，建议放大
+	assert.Greater(t, result.Gain, 1.0)
+	// 阈值位于增益后的噪声和说话电平之间
+	gainDB := 20 * math.Log10(result.Gain)
+	assert.Greater(t, result.VADThreshold, result.NoiseLevel+gainDB)
+	assert.Less(t, result.VADThreshold, result.SpeechLevel+gainDB)
+
+	// 接近满幅的说话不再放大，并提示削波
+	loud := Recommend(noise, AnalyzeLevels(toneFrames(1.0, 0, 30)))
+	assert.LessOrEqual(t, loud.Gain, 1.0)
+	assert.True(t, loud.Clipping)
+	assert.False(t, loud.Good())
+
+	// 说话和噪声电平接近时信噪比不足
+	assert.False(t, Recommend(noise, AnalyzeLevels(toneFrames(0.001, 0.002, 30))).Good())
+}
+
+func TestApplyGain(t *testing.T) {
+	samples := []float32{0.1, -0.4, 0.8}
+	applyGain(samples, 2)
+	assert.Equal(t, []float32{0.2, -0.8, 1}, samples)
+}
@@ -0,0 +1,79 @@
+package audio
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/gordonklaus/portaudio"
+)
+
+// 设备热插拔检测参数
+const (
+	deviceCheckInterval = 2 * time.Second // 设备检测间隔
+	deviceStallTimeout  = 3 * time.Second // 音频回调停止超过该时长视为设备已断开
+)
+
+// DeviceChangeHandler 音频设备切换回调（fallback为true表示原设备断开后自动回退到了默认设备）
+type DeviceChangeHandler func(name string, fallback bool)
+
+// streamHeartbeat 记录音频回调的最近调用时间（设备拔出后音频流不再回调）
+type streamHeartbeat struct {
+	last atomic.Int64
+}
+
+// beat 记录一次回调
+func (h *streamHeartbeat) beat() {
+	h.last.Store(time.Now().UnixNano())
+}
+
+// stalled 音频回调是否已停止超过 deviceStallTimeout
+func (h *streamHeartbeat) stalled(now time.Time) bool {
+	last := h.last.Load()
+	return last != 0 && now.Sub(time.Unix(0, last)) > deviceStallTimeout
+}
+
+// resolveOutputDevice 按配置中的 device_id 查找输出设备
+func resolveOutputDevice(deviceID int) (*portaudio.DeviceInfo, error) {
+	if deviceID == -1 {
+		device, err := portaudio.DefaultOutputDevice()
+		if err != nil {
+			return nil, fmt.Errorf("获取默认输出设备失败: %w", err)
+		}
+		return device, nil
+	}
+
+	devices, err := portaudio.Devices()
+	if err != nil {
+		return nil, fmt.Errorf("获取设备列表失败: %w", err)
+	}
+	if deviceID < 0 || deviceID >= len(devices) {
+		return nil, fmt.Errorf("设备ID %d 超出范围", deviceID)
+	}
+	return devices[deviceID], nil
+}
+
+// nextDeviceID 按设备列表顺序查找当前设备之后的下一个输入（input为true）或输出设备，到末尾后从头开始
+func nextDeviceID(devices []*portaudio.DeviceInfo, currentName string, input bool) (int, bool) {
+	current := findDeviceByName(devices, currentName)
+	for step := 1; step <= len(devices); step++ {
+		i := (current + step + len(devices)) % len(devices)
+		if i == current {
+			continue
+		}
+		if input && devices[i].MaxInputChannels > 0 || !input && devices[i].MaxOutputChannels > 0 {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// findDeviceByName 按名称查找设备序号，找不到时返回-1
+func findDeviceByName(devices []*portaudio.DeviceInfo, name string) int {
+	for i, device := range devices {
+		if device.Name == name {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,48 @@
+package audio
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gordonklaus/portaudio"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextDeviceID(t *testing.T) {
+	devices := []*portaudio.DeviceInfo{
+		{Name: "内置麦克风", MaxInputChannels: 1},
+		{Name: "内置扬声器", MaxOutputChannels: 2},
+		{Name: "USB耳机", MaxInputChannels: 1, MaxOutputChannels: 2},
+	}
+
+	id, ok := nextDeviceID(devices, "内置麦克风", true)
+	assert.True(t, ok)
+	assert.Equal(t, 2, id)
+
+	// 到末尾后从头开始
+	id, ok = nextDeviceID(devices, "USB耳机", true)
+	assert.True(t, ok)
+	assert.Equal(t, 0, id)
+
+	id, ok = nextDeviceID(devices, "USB耳机", false)
+	assert.True(t, ok)
+	assert.Equal(t, 1, id)
+
+	// 当前设备已不在列表中时从第一个开始
+	id, ok = nextDeviceID(devices, "已拔出的设备", false)
+	assert.True(t, ok)
+	assert.Equal(t, 1, id)
+
+	// 没有其他可选设备
+	_, ok = nextDeviceID(devices[:1], "内置麦克风", true)
+	assert.False(t, ok)
+}
+
+func TestStreamHeartbeat(t *testing.T) {
+	var heartbeat streamHeartbeat
+	assert.False(t, heartbeat.stalled(time.Now()))
+
+	heartbeat.beat()
+	assert.False(t, heartbeat.stalled(time.Now()))
+	assert.True(t, heartbeat.stalled(time.Now().Add(deviceStallTimeout+time.Second)))
+}
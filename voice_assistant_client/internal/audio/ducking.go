@@ -0,0 +1,104 @@
+package audio
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// ErrDuckingUnsupported 当前系统不支持调低其他程序的音量
+var ErrDuckingUnsupported = errors.New("当前系统不支持播放时调低其他音频")
+
+// DuckingConfig 播放回复时调低其他程序音量的配置
+type DuckingConfig struct {
+	Enabled bool
+	Level   float64       // 调低后保留的音量比例（0-1）
+	Release time.Duration // 播放结束后等待该时长再恢复音量，避免句子之间的空隙里音量来回跳动
+}
+
+// VolumeController 各平台调节其他程序音量的实现
+type VolumeController interface {
+	// Duck 将其他程序的音量调低到原音量的level倍，并记录原音量
+	Duck(level float64) error
+	// Restore 恢复Duck记录的原音量
+	Restore() error
+}
+
+// ducker 播放期间调低其他程序音量，播放结束并经过Release后恢复
+type ducker struct {
+	ctrl   VolumeController
+	config DuckingConfig
+
+	mu     sync.Mutex // 串行化音量调节
+	ducked bool
+	timer  *time.Timer
+	gen    int // 恢复定时器的代数，开始新的播放后旧定时器失效
+}
+
+// newDucker 创建音量闪避器
+func newDucker(ctrl VolumeController, config DuckingConfig) *ducker {
+	return &ducker{ctrl: ctrl, config: config}
+}
+
+// start 开始播放：取消待执行的恢复，尚未调低时调低其他程序音量
+func (d *ducker) start() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.gen++
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	if d.ducked {
+		return
+	}
+	if err := d.ctrl.Duck(d.config.Level); err != nil {
+		log.Printf("调低其他音频失败: %v", err)
+	}
+	// 部分程序调节失败时也记为已调低，以便恢复已调低的那些
+	d.ducked = true
+}
+
+// finish 播放完毕：Release后恢复音量
+func (d *ducker) finish() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !d.ducked || d.timer != nil {
+		return
+	}
+	gen := d.gen
+	d.timer = time.AfterFunc(d.config.Release, func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if gen == d.gen {
+			d.restoreLocked()
+		}
+	})
+}
+
+// restore 立即恢复音量（停止音频输出时调用）
+func (d *ducker) restore() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.gen++
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+	d.restoreLocked()
+}
+
+// restoreLocked 恢复音量（调用方持有 mu）
+func (d *ducker) restoreLocked() {
+	d.timer = nil
+	if !d.ducked {
+		return
+	}
+	d.ducked = false
+	if err := d.ctrl.Restore(); err != nil {
+		log.Printf("恢复其他音频音量失败: %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package audio
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// duckedApps macOS 没有按程序调节音量的系统接口，通过AppleScript调低常见播放器自身的音量
+var duckedApps = []string{"Music", "Spotify"}
+
+// appleScriptVolumeController 通过 osascript 调低正在运行的播放器音量
+type appleScriptVolumeController struct {
+	saved map[string]int // 程序名 -> 原音量（0-100）
+}
+
+// NewVolumeController 创建当前系统的音量控制（macOS 调低 Music 和 Spotify 的音量）
+func NewVolumeController() (VolumeController, error) {
+	return &appleScriptVolumeController{}, nil
+}
+
+// Duck 调低正在运行的播放器的音量
+func (a *appleScriptVolumeController) Duck(level float64) error {
+	a.saved = make(map[string]int)
+	var firstErr error
+	for _, app := range duckedApps {
+		output, err := osascript(fmt.Sprintf(`if application %q is running then tell application %q to get sound volume`, app, app))
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		volume, err := strconv.Atoi(output)
+		if err != nil {
+			continue // 程序未运行时输出为空
+		}
+		if _, err := osascript(fmt.Sprintf(`tell application %q to set sound volume to %d`, app, int(float64(volume)*level))); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		a.saved[app] = volume
+	}
+	return firstErr
+}
+
+// Restore 恢复调低前的音量（期间已退出的程序跳过，避免将其重新启动）
+func (a *appleScriptVolumeController) Restore() error {
+	for app, volume := range a.saved {
+		osascript(fmt.Sprintf(`if application %q is running then tell application %q to set sound volume to %d`, app, app, volume))
+	}
+	a.saved = nil
+	return nil
+}
+
+// osascript 执行一行AppleScript，返回去除空白的输出
+func osascript(script string) (string, error) {
+	output, err := exec.Command("osascript", "-e", script).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("执行AppleScript失败: %w %s", err, strings.TrimSpace(string(output)))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
@@ -0,0 +1,123 @@
+package audio
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// pulseVolumeController 通过 pactl 调低 PulseAudio/PipeWire 中其他程序的播放流（sink input）音量
+type pulseVolumeController struct {
+	saved map[string][]int // sink input序号 -> 各声道原音量
+}
+
+// NewVolumeController 创建当前系统的音量控制（Linux 需要 pactl，PulseAudio 或 PipeWire 均可）
+func NewVolumeController() (VolumeController, error) {
+	if _, err := exec.LookPath("pactl"); err != nil {
+		return nil, fmt.Errorf("%w: 未找到 pactl", ErrDuckingUnsupported)
+	}
+	return &pulseVolumeController{}, nil
+}
+
+// Duck 调低除本进程外所有播放流的音量
+func (p *pulseVolumeController) Duck(level float64) error {
+	output, err := pactl("list", "sink-inputs").Output()
+	if err != nil {
+		return fmt.Errorf("列出播放流失败: %w", err)
+	}
+
+	p.saved = make(map[string][]int)
+	var firstErr error
+	for _, input := range parseSinkInputs(string(output)) {
+		if input.pid == os.Getpid() || len(input.volumes) == 0 {
+			continue
+		}
+		ducked := make([]int, len(input.volumes))
+		for i, volume := range input.volumes {
+			ducked[i] = int(math.Round(float64(volume) * level))
+		}
+		if err := setSinkInputVolume(input.index, ducked); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		p.saved[input.index] = input.volumes
+	}
+	return firstErr
+}
+
+// Restore 恢复调低前的音量（期间已结束的播放流忽略）
+func (p *pulseVolumeController) Restore() error {
+	for index, volumes := range p.saved {
+		setSinkInputVolume(index, volumes)
+	}
+	p.saved = nil
+	return nil
+}
+
+// pactl 以C语言环境运行pactl，输出不随系统语言翻译
+func pactl(args ...string) *exec.Cmd {
+	cmd := exec.Command("pactl", args...)
+	cmd.Env = append(os.Environ(), "LC_ALL=C")
+	return cmd
+}
+
+// setSinkInputVolume 设置播放流各声道的音量
+func setSinkInputVolume(index string, volumes []int) error {
+	args := []string{"set-sink-input-volume", index}
+	for _, volume := range volumes {
+		args = append(args, strconv.Itoa(volume))
+	}
+	if output, err := pactl(args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("设置播放流 #%s 音量失败: %w %s", index, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// sinkInput pactl list sink-inputs 中的一个播放流
+type sinkInput struct {
+	index   string
+	pid     int
+	volumes []int // 各声道音量（原始值，65536为100%）
+}
+
+var (
+	sinkInputHeader = regexp.MustCompile(`^Sink Input #(\d+)`)
+	rawVolume       = regexp.MustCompile(`(\d+) /`)
+	processID       = regexp.MustCompile(`application\.process\.id = "(\d+)"`)
+)
+
+// parseSinkInputs 解析 pactl list sink-inputs 的输出
+func parseSinkInputs(output string) []sinkInput {
+	var inputs []sinkInput
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if m := sinkInputHeader.FindStringSubmatch(line); m != nil {
+			inputs = append(inputs, sinkInput{index: m[1]})
+			continue
+		}
+		if len(inputs) == 0 {
+			continue
+		}
+		current := &inputs[len(inputs)-1]
+		if m := processID.FindStringSubmatch(line); m != nil {
+			current.pid, _ = strconv.Atoi(m[1])
+			continue
+		}
+		// 音量行形如 "Volume: front-left: 65536 / 100% / 0.00 dB,   front-right: 65536 / 100% / 0.00 dB"
+		if current.volumes == nil && strings.HasPrefix(line, "Volume:") {
+			for _, m := range rawVolume.FindAllStringSubmatch(line, -1) {
+				volume, _ := strconv.Atoi(m[1])
+				current.volumes = append(current.volumes, volume)
+			}
+		}
+	}
+	return inputs
+}
@@ -0,0 +1,29 @@
+package audio
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSinkInputs(t *testing.T) {
+	output := `Sink Input #42
+	Driver: protocol-native.c
+	Sink: 0
+	Volume: front-left: 65536 / 100% / 0.00 dB,   front-right: 32768 /  50% / -18.06 dB
+	        balance -0.50
+	Properties:
+		application.name = "Firefox"
+		application.process.id = "1234"
+
+Sink Input #57
+	Volume: mono: 45875 /  70% / -9.29 dB
+	Properties:
+		application.process.id = "5678"
+`
+	inputs := parseSinkInputs(output)
+	assert.Equal(t, []sinkInput{
+		{index: "42", pid: 1234, volumes: []int{65536, 32768}},
+		{index: "57", pid: 5678, volumes: []int{45875}},
+	}, inputs)
+}
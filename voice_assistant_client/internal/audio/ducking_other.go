@@ -0,0 +1,13 @@
+//go:build !linux && !darwin
+
+package audio
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// NewVolumeController 当前系统不支持调节其他程序的音量（Linux和macOS之外）
+func NewVolumeController() (VolumeController, error) {
+	return nil, fmt.Errorf("%w: %s", ErrDuckingUnsupported, runtime.GOOS)
+}
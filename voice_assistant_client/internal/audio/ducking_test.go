@@ -0,0 +1,70 @@
+package audio
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeVolume 记录调用次数的音量控制
+type fakeVolume struct {
+	mu              sync.Mutex
+	ducks, restores int
+	level           float64
+}
+
+func (f *fakeVolume) Duck(level float64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ducks++
+	f.level = level
+	return nil
+}
+
+func (f *fakeVolume) Restore() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.restores++
+	return nil
+}
+
+func (f *fakeVolume) counts() (int, int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.ducks, f.restores
+}
+
+func TestDucker(t *testing.T) {
+	ctrl := &fakeVolume{}
+	d := newDucker(ctrl, DuckingConfig{Enabled: true, Level: 0.3, Release: 50 * time.Millisecond})
+
+	// 同一段播放中的多个片段只调低一次
+	d.start()
+	d.start()
+	ducks, restores := ctrl.counts()
+	assert.Equal(t, 1, ducks)
+	assert.Zero(t, restores)
+	assert.Equal(t, 0.3, ctrl.level)
+
+	// 释放时间内开始新的播放不恢复音量
+	d.finish()
+	d.start()
+	time.Sleep(100 * time.Millisecond)
+	ducks, restores = ctrl.counts()
+	assert.Equal(t, 1, ducks)
+	assert.Zero(t, restores)
+
+	// 播放完毕经过释放时间后恢复
+	d.finish()
+	assert.Eventually(t, func() bool {
+		_, restores := ctrl.counts()
+		return restores == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// 未调低时停止不重复恢复
+	d.restore()
+	_, restores = ctrl.counts()
+	assert.Equal(t, 1, restores)
+}
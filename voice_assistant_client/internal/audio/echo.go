@@ -0,0 +1,73 @@
+package audio
+
+import "time"
+
+// EchoReference 回声参考：扬声器当前输出的电平，双工模式据此区分麦克风中的用户说话和助手自己的声音
+type EchoReference interface {
+	// PlaybackLevel 最近输出的电平（dBFS，未播放时为-100），按释放速度衰减以覆盖声学延迟和混响
+	PlaybackLevel() float64
+}
+
+// EchoGateConfig 双工模式的回声门限配置
+type EchoGateConfig struct {
+	Margin          float64       // 麦克风电平需高出估计回声的余量（dB）
+	BargeInDuration time.Duration // 播放期间持续检测到用户说话超过该时长视为插话（0表示不检测）
+}
+
+// 回声门限参数
+const (
+	playbackSilenceLevel = -60.0 // 播放电平低于该值视为没有播放
+	initialEchoCoupling  = 0.0   // 回声路径增益的初始估计（偏保守：麦克风中的回声与输出电平相当）
+	minEchoCoupling      = -40.0 // 回声路径增益估计的下限
+	echoCouplingFall     = 0.2   // 估计值下降的跟踪速度
+	echoCouplingRise     = 0.01  // 估计值上升的跟踪速度（慢，避免被接近门限的说话拉高）
+)
+
+// echoGate 回声门限
+// 播放期间麦克风电平未明显高于“播放电平+回声路径增益”的音频视为回声被屏蔽；
+// 回声路径增益从被屏蔽的音频中自适应估计。只在音频回调协程中使用。
+type echoGate struct {
+	ref      EchoReference
+	config   EchoGateConfig
+	coupling float64       // 估计的回声路径增益（麦克风电平 - 播放电平，dB）
+	open     time.Duration // 播放期间连续通过门限的时长
+	fired    bool          // 本次播放已报告过插话
+}
+
+// newEchoGate 创建回声门限
+func newEchoGate(ref EchoReference, config EchoGateConfig) *echoGate {
+	return &echoGate{ref: ref, config: config, coupling: initialEchoCoupling}
+}
+
+// process 判断一帧麦克风音频（电平level，时长frame）是否主要是助手自己的声音，返回是否屏蔽及是否检测到插话
+func (g *echoGate) process(level float64, frame time.Duration) (suppress, bargeIn bool) {
+	ref := g.ref.PlaybackLevel()
+	if ref <= playbackSilenceLevel {
+		g.open = 0
+		g.fired = false
+		return false, false
+	}
+
+	diff := level - ref
+	if diff < g.coupling+g.config.Margin {
+		if level > playbackSilenceLevel {
+			rate := echoCouplingRise
+			if diff < g.coupling {
+				rate = echoCouplingFall
+			}
+			g.coupling += (diff - g.coupling) * rate
+			if g.coupling < minEchoCoupling {
+				g.coupling = minEchoCoupling
+			}
+		}
+		g.open = 0
+		return true, false
+	}
+
+	g.open += frame
+	if !g.fired && g.config.BargeInDuration > 0 && g.open >= g.config.BargeInDuration {
+		g.fired = true
+		return false, true
+	}
+	return false, false
+}
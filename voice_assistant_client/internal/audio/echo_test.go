@@ -0,0 +1,51 @@
+package audio
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeEchoReference struct {
+	level float64
+}
+
+func (f *fakeEchoReference) PlaybackLevel() float64 {
+	return f.level
+}
+
+func TestEchoGate(t *testing.T) {
+	ref := &fakeEchoReference{level: -100}
+	gate := newEchoGate(ref, EchoGateConfig{Margin: 10, BargeInDuration: 60 * time.Millisecond})
+	frame := 20 * time.Millisecond
+
+	// 没有播放时不屏蔽
+	suppress, bargeIn := gate.process(-30, frame)
+	assert.False(t, suppress)
+	assert.False(t, bargeIn)
+
+	// 播放期间与输出电平相当的回声被屏蔽，回声路径增益随之下降
+	ref.level = -20
+	for i := 0; i < 50; i++ {
+		suppress, _ = gate.process(-40, frame)
+		assert.True(t, suppress)
+	}
+	assert.Less(t, gate.coupling, -15.0)
+
+	// 明显高于估计回声的说话通过门限，持续超过插话时长时只报告一次插话
+	var fired int
+	for i := 0; i < 10; i++ {
+		suppress, bargeIn = gate.process(-15, frame)
+		assert.False(t, suppress)
+		if bargeIn {
+			fired++
+		}
+	}
+	assert.Equal(t, 1, fired)
+
+	// 播放结束后重新计时
+	ref.level = -100
+	gate.process(-15, frame)
+	assert.False(t, gate.fired)
+}
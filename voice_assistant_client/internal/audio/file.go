@@ -0,0 +1,67 @@
+package audio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	vaaudio "voice_assistant/pkg/audio"
+)
+
+// ReadAudioFile 读取音频文件并转换为指定采样率的16位单声道PCM
+// 支持WAV（8/16/24/32位整型或32位浮点，多声道取平均）和裸PCM（.pcm/.raw，视为已是目标格式）。
+func ReadAudioFile(path string, sampleRate int) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取音频文件失败: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".pcm", ".raw":
+		return data[:len(data)&^1], nil
+	}
+
+	samples, rate, err := vaaudio.DecodeWAV(data)
+	if err != nil {
+		return nil, err
+	}
+	return vaaudio.Float32ToBytes(vaaudio.Resample(samples, rate, sampleRate)), nil
+}
+
+// DecodeSpeech 按服务端标明的格式把TTS语音解码为指定采样率的单声道float32采样
+// format为pcm时按sourceRate和channels解释16bit PCM；format为空时（旧版服务端未标明格式）识别WAV文件头，其余视为已是目标采样率的PCM。
+// MP3通过ffmpeg解码，未安装ffmpeg时需在服务端把 tts.settings.format 设为 wav 或 pcm。
+func DecodeSpeech(data []byte, format string, sourceRate, channels, sampleRate int) ([]float32, error) {
+	if format == "" {
+		format, sourceRate, channels = vaaudio.EncodingPCM, sampleRate, 1
+		if vaaudio.IsWAV(data) {
+			format = vaaudio.EncodingWAV
+		}
+	}
+
+	var samples []float32
+	switch format {
+	case vaaudio.EncodingWAV:
+		var err error
+		if samples, sourceRate, err = vaaudio.DecodeWAV(data); err != nil {
+			return nil, err
+		}
+	case vaaudio.EncodingPCM:
+		samples = vaaudio.Downmix(vaaudio.BytesToFloat32(data), channels)
+	case vaaudio.EncodingMP3:
+		pcm, err := vaaudio.DecodeMP3(context.Background(), data, sampleRate)
+		if errors.Is(err, vaaudio.ErrCodecUnavailable) {
+			return nil, fmt.Errorf("%w（请安装ffmpeg，或在服务端把 tts.settings.format 设为 wav 或 pcm）", err)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return vaaudio.BytesToFloat32(pcm), nil
+	default:
+		return nil, fmt.Errorf("不支持的语音格式: %s（请在服务端把 tts.settings.format 设为 wav 或 pcm）", format)
+	}
+	return vaaudio.Resample(samples, sourceRate, sampleRate), nil
+}
@@ -0,0 +1,73 @@
+package audio
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// buildWAV 构造16位PCM的WAV数据
+func buildWAV(rate, channels int, samples []int16) []byte {
+	data := make([]byte, 44+len(samples)*2)
+	copy(data[0:], "RIFF")
+	binary.LittleEndian.PutUint32(data[4:], uint32(36+len(samples)*2))
+	copy(data[8:], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(data[16:], 16)
+	binary.LittleEndian.PutUint16(data[20:], 1)
+	binary.LittleEndian.PutUint16(data[22:], uint16(channels))
+	binary.LittleEndian.PutUint32(data[24:], uint32(rate))
+	binary.LittleEndian.PutUint32(data[28:], uint32(rate*channels*2))
+	binary.LittleEndian.PutUint16(data[32:], uint16(channels*2))
+	binary.LittleEndian.PutUint16(data[34:], 16)
+	copy(data[36:], "data")
+	binary.LittleEndian.PutUint32(data[40:], uint32(len(samples)*2))
+	for i, s := range samples {
+		binary.LittleEndian.PutUint16(data[44+i*2:], uint16(s))
+	}
+	return data
+}
+
+func TestReadAudioFileResamples(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "in.wav")
+	require.NoError(t, os.WriteFile(path, buildWAV(8000, 1, make([]int16, 800)), 0644))
+
+	// 8kHz的0.1秒音频转换为16kHz后为1600个采样
+	pcm, err := ReadAudioFile(path, 16000)
+	require.NoError(t, err)
+	assert.Len(t, pcm, 3200)
+
+	// 裸PCM原样读取
+	raw := filepath.Join(dir, "in.pcm")
+	require.NoError(t, os.WriteFile(raw, []byte{1, 2, 3}, 0644))
+	pcm, err = ReadAudioFile(raw, 16000)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{1, 2}, pcm)
+}
+
+func TestDecodeSpeech(t *testing.T) {
+	// 24kHz的WAV转换为16kHz
+	samples, err := DecodeSpeech(buildWAV(24000, 1, make([]int16, 2400)), "wav", 24000, 1, 16000)
+	require.NoError(t, err)
+	assert.Len(t, samples, 1600)
+
+	// 双声道PCM混为单声道并重采样
+	samples, err = DecodeSpeech(make([]byte, 8000*2*2), "pcm", 8000, 2, 16000)
+	require.NoError(t, err)
+	assert.Len(t, samples, 16000)
+
+	// 未标明格式时识别WAV文件头，其余按输出采样率的PCM处理
+	samples, err = DecodeSpeech(buildWAV(8000, 1, make([]int16, 800)), "", 0, 0, 16000)
+	require.NoError(t, err)
+	assert.Len(t, samples, 1600)
+	samples, err = DecodeSpeech(make([]byte, 320), "", 0, 0, 16000)
+	require.NoError(t, err)
+	assert.Len(t, samples, 160)
+
+	_, err = DecodeSpeech([]byte("ID3"), "mp3", 24000, 1, 16000)
+	assert.Error(t, err)
+}
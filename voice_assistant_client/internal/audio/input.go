@@ -8,21 +8,26 @@ import (
 	"sync"
 	"time"
 
+	vaaudio "voice_assistant/pkg/audio"
+
 	"github.com/gordonklaus/portaudio"
 )
 
 // InputConfig 音频输入配置
 type InputConfig struct {
-	DeviceID           int     `yaml:"device_id"`
-	SampleRate         int     `yaml:"sample_rate"`
-	Channels           int     `yaml:"channels"`
-	Format             string  `yaml:"format"`
-	BufferSize         int     `yaml:"buffer_size"`
-	ChunkDuration      int     `yaml:"chunk_duration"` // 毫秒
-	VADEnabled         bool    `yaml:"vad_enabled"`
-	VADThreshold       float64 `yaml:"vad_threshold"`
-	MinSpeechDuration  int     `yaml:"min_speech_duration"`  // 毫秒
-	MinSilenceDuration int     `yaml:"min_silence_duration"` // 毫秒
+	Source             string        `yaml:"source"`  // portaudio|network
+	Network            NetworkConfig `yaml:"network"` // source为network时的网络音频流
+	DeviceID           int           `yaml:"device_id"`
+	SampleRate         int           `yaml:"sample_rate"`
+	Channels           int           `yaml:"channels"`
+	Format             string        `yaml:"format"`
+	BufferSize         int           `yaml:"buffer_size"`
+	ChunkDuration      int           `yaml:"chunk_duration"` // 毫秒
+	Gain               float64       `yaml:"gain"`           // 输入增益（倍率，0或1表示不调整）
+	VADEnabled         bool          `yaml:"vad_enabled"`
+	VADThreshold       float64       `yaml:"vad_threshold"`        // dBFS
+	MinSpeechDuration  int           `yaml:"min_speech_duration"`  // 毫秒
+	MinSilenceDuration int           `yaml:"min_silence_duration"` // 毫秒
 }
 
 // AudioInput 音频输入管理器
@@ -31,17 +36,38 @@ type AudioInput struct {
 	stream *portaudio.Stream
 	device *portaudio.DeviceInfo
 
+	// 网络音频输入（source为network时使用，不打开录音设备）
+	network     networkStream
+	networkName string
+
 	// 状态管理
 	isRunning   bool
 	isRecording bool
 	mu          sync.RWMutex
 
+	// 设备热插拔（streamMu 保护音频流的打开、关闭和设备切换）
+	streamMu       sync.Mutex
+	heartbeat      streamHeartbeat
+	onDeviceChange DeviceChangeHandler
+
+	// 运行期间的后台协程（Stop时关闭done并等待退出，之后可再次Start）
+	done chan struct{}
+	wg   sync.WaitGroup
+
 	// 音频数据通道
 	audioChan   chan []float32
 	controlChan chan controlSignal
 
-	// VAD检测
-	vadDetector *VADDetector
+	// VAD检测：说话开始和结束时经voiceActivity通知控制协程（voiceActive只在音频回调中访问）
+	vadDetector     *VADDetector
+	voiceActive     bool
+	onVoiceActivity func(speaking bool)
+	voiceActivity   chan bool
+
+	// 双工模式的回声门限（未设置回声参考时为nil），检测到插话时经bargeIn通知控制协程
+	echoGate  *echoGate
+	onBargeIn func()
+	bargeIn   chan struct{}
 
 	// 统计信息
 	stats AudioStats
@@ -65,20 +91,40 @@ type AudioStats struct {
 	LastActivity time.Time
 	AverageLevel float64
 	PeakLevel    float64
+	EchoGated    int64 // 双工模式下作为回声被屏蔽的采样数
 }
 
 // NewAudioInput 创建音频输入管理器
 func NewAudioInput(config InputConfig) (*AudioInput, error) {
-	if err := portaudio.Initialize(); err != nil {
-		return nil, fmt.Errorf("初始化PortAudio失败: %w", err)
+	ai := &AudioInput{
+		config:        config,
+		audioChan:     make(chan []float32, 100),
+		controlChan:   make(chan controlSignal, 10),
+		bargeIn:       make(chan struct{}, 1),
+		voiceActivity: make(chan bool, 8),
+		vadDetector:   NewVADDetector(config.VADThreshold, config.MinSpeechDuration, config.MinSilenceDuration),
 	}
 
-	ai := &AudioInput{
-		config:      config,
-		audioChan:   make(chan []float32, 100),
-		controlChan: make(chan controlSignal, 10),
-		vadDetector: NewVADDetector(config.VADThreshold, config.MinSpeechDuration, config.MinSilenceDuration),
+	switch config.Source {
+	case "", SourcePortAudio:
+	case SourceNetwork:
+		stream, err := newNetworkStream(config.Network, config.SampleRate)
+		if err != nil {
+			return nil, fmt.Errorf("设置网络音频输入失败: %w", err)
+		}
+		// 网络音频统一转为单声道
+		ai.network, ai.networkName = stream, redactURL(config.Network.URL)
+		ai.config.Channels = 1
+		log.Printf("使用网络音频输入: %s", ai.networkName)
+		return ai, nil
+	default:
+		return nil, fmt.Errorf("未知的音频输入来源: %s（可选 portaudio、network）", config.Source)
+	}
+
+	if err := acquirePortAudio(); err != nil {
+		return nil, err
 	}
+	defer releasePortAudio()
 
 	// 获取音频设备信息
 	if err := ai.setupDevice(); err != nil {
@@ -88,29 +134,31 @@ func NewAudioInput(config InputConfig) (*AudioInput, error) {
 	return ai, nil
 }
 
-// setupDevice 设置音频设备
-func (ai *AudioInput) setupDevice() error {
-	var device *portaudio.DeviceInfo
-	var err error
-
-	if ai.config.DeviceID == -1 {
-		// 使用默认输入设备
-		device, err = portaudio.DefaultInputDevice()
-		if err != nil {
-			return fmt.Errorf("获取默认输入设备失败: %w", err)
-		}
-	} else {
-		// 使用指定设备
-		devices, err := portaudio.Devices()
-		if err != nil {
-			return fmt.Errorf("获取设备列表失败: %w", err)
+// reloadDevice 重新获取当前设备（PortAudio可能已重新初始化，设备序号会变化），设备已不存在时改用默认设备（调用方持有 streamMu）
+func (ai *AudioInput) reloadDevice() error {
+	if ai.config.DeviceID != -1 {
+		if devices, err := portaudio.Devices(); err == nil {
+			if id := findDeviceByName(devices, ai.device.Name); id >= 0 {
+				ai.device, ai.config.DeviceID = devices[id], id
+				return nil
+			}
 		}
+		log.Printf("音频输入设备已不存在: %s，改用默认设备", ai.device.Name)
+	}
 
-		if ai.config.DeviceID >= len(devices) {
-			return fmt.Errorf("设备ID %d 超出范围", ai.config.DeviceID)
-		}
+	device, err := resolveInputDevice(-1)
+	if err != nil {
+		return err
+	}
+	ai.device, ai.config.DeviceID = device, -1
+	return nil
+}
 
-		device = devices[ai.config.DeviceID]
+// setupDevice 设置音频设备
+func (ai *AudioInput) setupDevice() error {
+	device, err := resolveInputDevice(ai.config.DeviceID)
+	if err != nil {
+		return err
 	}
 
 	ai.device = device
@@ -127,9 +175,52 @@ func (ai *AudioInput) Start(ctx context.Context) error {
 		return fmt.Errorf("音频输入已经在运行")
 	}
 	ai.isRunning = true
+	done := make(chan struct{})
+	ai.done = done
 	ai.mu.Unlock()
 
-	// 创建音频流
+	// 网络音频输入：读取协程断线后自动重连，不需要设备检测
+	if ai.network != nil {
+		ai.wg.Add(2)
+		go ai.controlLoop(ctx, done)
+		go ai.runNetwork(ctx, done)
+		log.Printf("音频输入已启动: %s, %dHz", ai.networkName, ai.config.SampleRate)
+		return nil
+	}
+
+	err := acquirePortAudio()
+	if err == nil {
+		ai.streamMu.Lock()
+		if err = ai.reloadDevice(); err == nil {
+			err = ai.openStream()
+		}
+		ai.streamMu.Unlock()
+		if err != nil {
+			releasePortAudio()
+		}
+	}
+	if err != nil {
+		ai.mu.Lock()
+		ai.isRunning = false
+		ai.mu.Unlock()
+		return err
+	}
+
+	log.Printf("音频输入已启动: %dHz, %d通道, 缓冲区%d",
+		ai.config.SampleRate, ai.config.Channels, ai.config.BufferSize)
+
+	// 启动控制协程
+	ai.wg.Add(2)
+	go ai.controlLoop(ctx, done)
+
+	// 启动设备检测协程
+	go ai.monitorDevice(ctx, done)
+
+	return nil
+}
+
+// openStream 在当前设备上打开并启动音频流（调用方持有 streamMu）
+func (ai *AudioInput) openStream() error {
 	inputParams := portaudio.StreamParameters{
 		Input: portaudio.StreamDeviceParameters{
 			Device:   ai.device,
@@ -140,31 +231,38 @@ func (ai *AudioInput) Start(ctx context.Context) error {
 		FramesPerBuffer: ai.config.BufferSize,
 	}
 
-	var err error
-	ai.stream, err = portaudio.OpenStream(inputParams, ai.audioCallback)
+	stream, err := portaudio.OpenStream(inputParams, ai.audioCallback)
 	if err != nil {
-		ai.mu.Lock()
-		ai.isRunning = false
-		ai.mu.Unlock()
 		return fmt.Errorf("打开音频流失败: %w", err)
 	}
 
-	// 启动音频流
-	if err := ai.stream.Start(); err != nil {
-		ai.stream.Close()
-		ai.mu.Lock()
-		ai.isRunning = false
-		ai.mu.Unlock()
+	ai.heartbeat.beat()
+	if err := stream.Start(); err != nil {
+		stream.Close()
 		return fmt.Errorf("启动音频流失败: %w", err)
 	}
 
-	log.Printf("音频输入已启动: %dHz, %d通道, 缓冲区%d",
-		ai.config.SampleRate, ai.config.Channels, ai.config.BufferSize)
+	ai.stream = stream
+	return nil
+}
 
-	// 启动控制协程
-	go ai.controlLoop(ctx)
+// closeStream 停止并关闭音频流（调用方持有 streamMu）；设备已断开时用abort避免等待缓冲区排空
+func (ai *AudioInput) closeStream(abort bool) {
+	if ai.stream == nil {
+		return
+	}
 
-	return nil
+	stop := ai.stream.Stop
+	if abort {
+		stop = ai.stream.Abort
+	}
+	if err := stop(); err != nil {
+		log.Printf("停止音频流失败: %v", err)
+	}
+	if err := ai.stream.Close(); err != nil {
+		log.Printf("关闭音频流失败: %v", err)
+	}
+	ai.stream = nil
 }
 
 // Stop 停止音频输入
@@ -175,32 +273,31 @@ func (ai *AudioInput) Stop() error {
 		return nil
 	}
 	ai.isRunning = false
+	ai.isRecording = false
+	done := ai.done
 	ai.mu.Unlock()
 
-	// 发送停止信号
-	select {
-	case ai.controlChan <- signalStop:
-	default:
-	}
+	// 等待后台协程退出
+	close(done)
+	ai.wg.Wait()
 
 	// 停止音频流
-	if ai.stream != nil {
-		if err := ai.stream.Stop(); err != nil {
-			log.Printf("停止音频流失败: %v", err)
-		}
-		if err := ai.stream.Close(); err != nil {
-			log.Printf("关闭音频流失败: %v", err)
+	if ai.network == nil {
+		ai.streamMu.Lock()
+		ai.closeStream(false)
+		ai.streamMu.Unlock()
+
+		if err := releasePortAudio(); err != nil {
+			log.Printf("%v", err)
 		}
 	}
 
-	// 关闭通道
-	close(ai.audioChan)
-	close(ai.controlChan)
-
-	// 清理PortAudio
-	if err := portaudio.Terminate(); err != nil {
-		log.Printf("清理PortAudio失败: %v", err)
-	}
+	// 丢弃未处理的数据和信号，避免再次启动后收到本次运行的残留
+	drain(ai.audioChan)
+	drain(ai.controlChan)
+	drain(ai.bargeIn)
+	drain(ai.voiceActivity)
+	ai.voiceActive = false
 
 	log.Println("音频输入已停止")
 	return nil
@@ -269,7 +366,162 @@ func (ai *AudioInput) ResumeRecording() error {
 	}
 }
 
+// SetEchoReference 启用双工模式的回声门限：以扬声器输出电平为参考，屏蔽录到的助手自己的声音
+// 播放期间持续检测到用户说话时调用onBargeIn（在后台协程中调用，可为nil）。
+func (ai *AudioInput) SetEchoReference(ref EchoReference, config EchoGateConfig, onBargeIn func()) {
+	ai.mu.Lock()
+	defer ai.mu.Unlock()
+	ai.echoGate = newEchoGate(ref, config)
+	ai.onBargeIn = onBargeIn
+}
+
+// SetVoiceActivityHandler 设置VAD事件回调：启用VAD时用户开始说话（true）和说完（false）时调用，在后台协程中调用
+func (ai *AudioInput) SetVoiceActivityHandler(handler func(speaking bool)) {
+	ai.mu.Lock()
+	defer ai.mu.Unlock()
+	ai.onVoiceActivity = handler
+}
+
+// SetDeviceChangeHandler 设置音频设备切换回调
+func (ai *AudioInput) SetDeviceChangeHandler(handler DeviceChangeHandler) {
+	ai.mu.Lock()
+	defer ai.mu.Unlock()
+	ai.onDeviceChange = handler
+}
+
+// SwitchDevice 运行中切换输入设备（deviceID为-1时使用默认设备），新设备打开失败时保留原设备
+func (ai *AudioInput) SwitchDevice(deviceID int) error {
+	if ai.network != nil {
+		return errNetworkInput
+	}
+	ai.streamMu.Lock()
+	defer ai.streamMu.Unlock()
+
+	device, err := resolveInputDevice(deviceID)
+	if err != nil {
+		return err
+	}
+	return ai.switchDevice(deviceID, device)
+}
+
+// NextDevice 切换到设备列表中的下一个输入设备，返回新设备名称
+func (ai *AudioInput) NextDevice() (string, error) {
+	if ai.network != nil {
+		return "", errNetworkInput
+	}
+	ai.streamMu.Lock()
+	defer ai.streamMu.Unlock()
+
+	devices, err := portaudio.Devices()
+	if err != nil {
+		return "", fmt.Errorf("获取设备列表失败: %w", err)
+	}
+	deviceID, ok := nextDeviceID(devices, ai.device.Name, true)
+	if !ok {
+		return "", fmt.Errorf("没有其他可用的输入设备")
+	}
+	if err := ai.switchDevice(deviceID, devices[deviceID]); err != nil {
+		return "", err
+	}
+	return devices[deviceID].Name, nil
+}
+
+// DeviceName 当前输入设备名称
+func (ai *AudioInput) DeviceName() string {
+	if ai.network != nil {
+		return ai.networkName
+	}
+	ai.streamMu.Lock()
+	defer ai.streamMu.Unlock()
+	return ai.device.Name
+}
+
+// switchDevice 关闭当前音频流并在新设备上重新打开（调用方持有 streamMu）
+func (ai *AudioInput) switchDevice(deviceID int, device *portaudio.DeviceInfo) error {
+	if device.MaxInputChannels == 0 {
+		return fmt.Errorf("设备 %s 不支持音频输入", device.Name)
+	}
+
+	previous, previousID := ai.device, ai.config.DeviceID
+	ai.device, ai.config.DeviceID = device, deviceID
+	if !ai.IsRunning() {
+		log.Printf("使用音频输入设备: %s", device.Name)
+		return nil
+	}
+
+	ai.closeStream(false)
+	if err := ai.openStream(); err != nil {
+		ai.device, ai.config.DeviceID = previous, previousID
+		if reopenErr := ai.openStream(); reopenErr != nil {
+			log.Printf("恢复原音频输入设备失败: %v", reopenErr)
+		}
+		return fmt.Errorf("切换到设备 %s 失败: %w", device.Name, err)
+	}
+
+	log.Printf("音频输入设备已切换: %s", device.Name)
+	return nil
+}
+
+// monitorDevice 定期检测输入设备，设备断开（音频回调停止）后回退到默认设备
+func (ai *AudioInput) monitorDevice(ctx context.Context, done <-chan struct{}) {
+	defer ai.wg.Done()
+
+	ticker := time.NewTicker(deviceCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-done:
+			return
+		case <-ticker.C:
+			name, ok := ai.fallbackIfLost()
+			if !ok {
+				continue
+			}
+
+			ai.mu.RLock()
+			handler := ai.onDeviceChange
+			ai.mu.RUnlock()
+			if handler != nil {
+				handler(name, true)
+			}
+		}
+	}
+}
+
+// fallbackIfLost 设备无响应或上次回退未成功时改用默认输入设备，返回是否已切换
+func (ai *AudioInput) fallbackIfLost() (string, bool) {
+	ai.streamMu.Lock()
+	defer ai.streamMu.Unlock()
+
+	if !ai.IsRunning() || ai.stream != nil && !ai.heartbeat.stalled(time.Now()) {
+		return "", false
+	}
+
+	if ai.stream != nil {
+		log.Printf("音频输入设备无响应，可能已断开: %s，切换到默认设备", ai.device.Name)
+		ai.closeStream(true)
+	}
+
+	device, err := portaudio.DefaultInputDevice()
+	if err != nil {
+		log.Printf("获取默认输入设备失败: %v，稍后重试", err)
+		return "", false
+	}
+	ai.device, ai.config.DeviceID = device, -1
+	if err := ai.openStream(); err != nil {
+		log.Printf("打开默认输入设备失败: %v，稍后重试", err)
+		return "", false
+	}
+
+	log.Printf("音频输入设备已切换: %s", device.Name)
+	return device.Name, true
+}
+
 // GetAudioChannel 获取音频数据通道
+// 每块音频取自缓冲池，消费方处理完后可调用 vaaudio.PutFloat32 归还（不归还只是失去复用）。
 func (ai *AudioInput) GetAudioChannel() <-chan []float32 {
 	return ai.audioChan
 }
@@ -297,43 +549,96 @@ func (ai *AudioInput) IsRecording() bool {
 
 // audioCallback 音频回调函数
 func (ai *AudioInput) audioCallback(in []float32) {
+	ai.heartbeat.beat()
+
 	ai.mu.RLock()
 	isRecording := ai.isRecording
+	gate := ai.echoGate
 	ai.mu.RUnlock()
 
 	if !isRecording {
+		// 停止录音后由最终音频块结束本句，下次录音重新上报说话开始
+		ai.voiceActive = false
 		return
 	}
 
+	// 输入增益
+	if ai.config.Gain > 0 && ai.config.Gain != 1 {
+		applyGain(in, float32(ai.config.Gain))
+	}
+
 	// 更新统计信息
 	ai.updateStats(in)
 
-	// VAD检测
+	// 双工模式：屏蔽主要是助手自己声音的音频，播放期间用户持续说话时通知插话
+	if gate != nil {
+		suppress, bargeIn := gate.process(FrameLevel(in), ai.frameDuration(len(in)))
+		if bargeIn {
+			select {
+			case ai.bargeIn <- struct{}{}:
+			default:
+			}
+		}
+		if suppress {
+			ai.mu.Lock()
+			ai.stats.EchoGated += int64(len(in))
+			ai.mu.Unlock()
+			return
+		}
+	}
+
+	// VAD检测（说话状态变化时通知控制协程，之前的音频已在audioChan中）
 	if ai.config.VADEnabled {
 		isVoice := ai.vadDetector.Detect(in)
+		if isVoice != ai.voiceActive {
+			ai.voiceActive = isVoice
+			select {
+			case ai.voiceActivity <- isVoice:
+			default:
+			}
+		}
 		if !isVoice {
 			return
 		}
 	}
 
-	// 复制音频数据
-	audioData := make([]float32, len(in))
+	// 复制音频数据（取自缓冲池，由消费方处理完后归还）
+	audioData := vaaudio.GetFloat32(len(in))
 	copy(audioData, in)
 
 	// 发送音频数据
 	select {
 	case ai.audioChan <- audioData:
 	default:
+		vaaudio.PutFloat32(audioData)
 		log.Printf("音频缓冲区已满，丢弃数据")
 	}
 }
 
 // controlLoop 控制循环
-func (ai *AudioInput) controlLoop(ctx context.Context) {
+func (ai *AudioInput) controlLoop(ctx context.Context, done <-chan struct{}) {
+	defer ai.wg.Done()
+
 	for {
 		select {
 		case <-ctx.Done():
 			return
+		case <-done:
+			return
+		case <-ai.bargeIn:
+			ai.mu.RLock()
+			handler := ai.onBargeIn
+			ai.mu.RUnlock()
+			if handler != nil {
+				handler()
+			}
+		case speaking := <-ai.voiceActivity:
+			ai.mu.RLock()
+			handler := ai.onVoiceActivity
+			ai.mu.RUnlock()
+			if handler != nil {
+				handler(speaking)
+			}
 		case signal := <-ai.controlChan:
 			switch signal {
 			case signalStart:
@@ -390,12 +695,34 @@ func (ai *AudioInput) updateStats(data []float32) {
 	}
 }
 
+// frameDuration 采样数对应的时长
+func (ai *AudioInput) frameDuration(samples int) time.Duration {
+	rate := ai.config.SampleRate * ai.config.Channels
+	if rate <= 0 {
+		return 0
+	}
+	return time.Duration(samples) * time.Second / time.Duration(rate)
+}
+
+// applyGain 放大或衰减音频（超出范围的采样削波）
+func applyGain(samples []float32, gain float32) {
+	for i, sample := range samples {
+		sample *= gain
+		if sample > 1 {
+			sample = 1
+		} else if sample < -1 {
+			sample = -1
+		}
+		samples[i] = sample
+	}
+}
+
 // GetDeviceList 获取可用的音频输入设备列表
 func GetDeviceList() ([]*portaudio.DeviceInfo, error) {
-	if err := portaudio.Initialize(); err != nil {
-		return nil, fmt.Errorf("初始化PortAudio失败: %w", err)
+	if err := acquirePortAudio(); err != nil {
+		return nil, err
 	}
-	defer portaudio.Terminate()
+	defer releasePortAudio()
 
 	devices, err := portaudio.Devices()
 	if err != nil {
@@ -0,0 +1,124 @@
+package audio
+
+import "time"
+
+// jitterBuffer 播放抖动缓冲
+// 累积到预缓冲量后才开始输出，网络慢导致片段到达不连续时不会每来一片就播一片造成卡顿；
+// 缓冲耗尽时对最后的采样淡出，重新预缓冲后淡入，片段之间的空隙不会出现爆音。
+// 非并发安全，由调用方加锁。
+type jitterBuffer struct {
+	prebuffer     int           // 开始输出前需累积的采样数
+	prebufferWait time.Duration // 数据不足预缓冲量时的最长等待时间（短句不会一直等下去）
+	fade          int           // 淡入淡出的采样数
+
+	chunks   [][]float32
+	buffered int
+	played   int       // 本段（从缓冲为空开始）已输出的采样数
+	primed   bool      // 已达到预缓冲量，正在输出
+	faded    int       // 本轮输出已淡入的采样数
+	waitFrom time.Time // 开始等待预缓冲的时间
+}
+
+// newJitterBuffer 按输出格式创建抖动缓冲（prebuffer和fade为0表示不预缓冲、不淡入淡出）
+func newJitterBuffer(config OutputConfig) *jitterBuffer {
+	samples := func(d time.Duration) int {
+		if d <= 0 {
+			return 0
+		}
+		channels := config.Channels
+		if channels <= 0 {
+			channels = 1
+		}
+		return int(d.Seconds()*float64(config.SampleRate)) * channels
+	}
+
+	return &jitterBuffer{
+		prebuffer:     samples(config.Prebuffer),
+		prebufferWait: config.Prebuffer,
+		fade:          samples(config.Crossfade),
+	}
+}
+
+// push 追加音频片段
+func (jb *jitterBuffer) push(samples []float32, now time.Time) {
+	if len(samples) == 0 {
+		return
+	}
+	if !jb.primed && jb.buffered == 0 {
+		jb.waitFrom = now
+		jb.played = 0
+	}
+	jb.chunks = append(jb.chunks, samples)
+	jb.buffered += len(samples)
+}
+
+// read 填充输出缓冲区，返回写入的音频采样数（其余部分为静音）
+// 跨片段连续填充，片段边界不会插入静音。
+func (jb *jitterBuffer) read(out []float32, now time.Time) int {
+	if !jb.primed {
+		if jb.buffered == 0 || jb.buffered < jb.prebuffer && now.Sub(jb.waitFrom) < jb.prebufferWait {
+			silence(out)
+			return 0
+		}
+		jb.primed = true
+		jb.faded = 0
+	}
+
+	n := 0
+	for n < len(out) && len(jb.chunks) > 0 {
+		copied := copy(out[n:], jb.chunks[0])
+		if copied == len(jb.chunks[0]) {
+			jb.chunks[0] = nil
+			jb.chunks = jb.chunks[1:]
+		} else {
+			jb.chunks[0] = jb.chunks[0][copied:]
+		}
+		n += copied
+	}
+	jb.buffered -= n
+	jb.played += n
+	silence(out[n:])
+
+	// 开始输出时淡入
+	for i := 0; i < n && jb.faded < jb.fade; i++ {
+		jb.faded++
+		out[i] *= float32(jb.faded) / float32(jb.fade+1)
+	}
+
+	// 缓冲耗尽：尾部淡出，等待下一片数据重新预缓冲
+	if jb.buffered == 0 {
+		fadeLen := jb.fade
+		if fadeLen > n {
+			fadeLen = n
+		}
+		for i := 0; i < fadeLen; i++ {
+			out[n-fadeLen+i] *= float32(fadeLen-i) / float32(fadeLen+1)
+		}
+		jb.primed = false
+	}
+	return n
+}
+
+// reset 清空缓冲
+func (jb *jitterBuffer) reset() {
+	jb.chunks = nil
+	jb.buffered = 0
+	jb.primed = false
+}
+
+// pending 待播放的片段数
+func (jb *jitterBuffer) pending() int {
+	return len(jb.chunks)
+}
+
+// drained 缓冲中的音频是否已全部输出
+func (jb *jitterBuffer) drained() bool {
+	return jb.buffered == 0
+}
+
+// silence 输出静音
+func silence(out []float32) {
+	for i := range out {
+		out[i] = 0
+	}
+}
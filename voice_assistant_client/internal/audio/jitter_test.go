@@ -0,0 +1,62 @@
+package audio
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func ones(n int) []float32 {
+	samples := make([]float32, n)
+	for i := range samples {
+		samples[i] = 1
+	}
+	return samples
+}
+
+func TestJitterBufferPrebuffer(t *testing.T) {
+	jb := newJitterBuffer(OutputConfig{SampleRate: 1000, Channels: 1, Prebuffer: 100 * time.Millisecond})
+	start := time.Now()
+	out := make([]float32, 40)
+
+	// 未达到预缓冲量时输出静音
+	jb.push(ones(60), start)
+	assert.Zero(t, jb.read(out, start))
+	assert.Equal(t, float32(0), out[0])
+
+	// 达到预缓冲量后跨片段连续输出
+	jb.push(ones(60), start)
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, 40, jb.read(out, start))
+		assert.Equal(t, float32(1), out[39])
+	}
+
+	// 缓冲耗尽后重新预缓冲；数据不足时等待超过预缓冲时长后照常输出
+	jb.push(ones(10), start)
+	assert.Zero(t, jb.read(out, start.Add(50*time.Millisecond)))
+	assert.Equal(t, 10, jb.read(out, start.Add(150*time.Millisecond)))
+	assert.Equal(t, float32(0), out[10])
+}
+
+func TestJitterBufferCrossfade(t *testing.T) {
+	jb := newJitterBuffer(OutputConfig{SampleRate: 1000, Channels: 1, Crossfade: 4 * time.Millisecond})
+	now := time.Now()
+	out := make([]float32, 10)
+
+	jb.push(ones(16), now)
+	assert.Equal(t, 10, jb.read(out, now))
+	assert.InDelta(t, 0.2, out[0], 1e-6) // 淡入
+	assert.Equal(t, float32(1), out[4])
+
+	assert.Equal(t, 6, jb.read(out, now))
+	assert.Equal(t, float32(1), out[1])
+	assert.InDelta(t, 0.2, out[5], 1e-6) // 缓冲耗尽时淡出
+	assert.True(t, jb.drained())
+	assert.Equal(t, 16, jb.played)
+
+	jb.push(ones(2), now)
+	jb.reset()
+	assert.Zero(t, jb.read(out, now))
+	assert.Zero(t, jb.pending())
+}
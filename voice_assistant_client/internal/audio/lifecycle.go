@@ -0,0 +1,58 @@
+package audio
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/gordonklaus/portaudio"
+)
+
+// PortAudio 初始化引用计数：输入、输出、设备列表和校准共用一次初始化，最后一个使用者释放时才清理，
+// 之后再次获取会重新初始化（同时刷新设备列表）
+var (
+	portaudioMu   sync.Mutex
+	portaudioRefs int
+)
+
+// acquirePortAudio 获取PortAudio（首次获取时初始化），使用完毕后须调用 releasePortAudio
+func acquirePortAudio() error {
+	portaudioMu.Lock()
+	defer portaudioMu.Unlock()
+
+	if portaudioRefs == 0 {
+		if err := portaudio.Initialize(); err != nil {
+			return fmt.Errorf("初始化PortAudio失败: %w", err)
+		}
+	}
+	portaudioRefs++
+	return nil
+}
+
+// releasePortAudio 释放PortAudio（最后一个使用者释放时清理）
+func releasePortAudio() error {
+	portaudioMu.Lock()
+	defer portaudioMu.Unlock()
+
+	if portaudioRefs == 0 {
+		return nil
+	}
+	portaudioRefs--
+	if portaudioRefs > 0 {
+		return nil
+	}
+	if err := portaudio.Terminate(); err != nil {
+		return fmt.Errorf("清理PortAudio失败: %w", err)
+	}
+	return nil
+}
+
+// drain 丢弃通道中尚未读取的数据
+func drain[T any](ch chan T) {
+	for {
+		select {
+		case <-ch:
+		default:
+			return
+		}
+	}
+}
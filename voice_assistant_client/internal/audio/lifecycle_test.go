@@ -0,0 +1,32 @@
+package audio
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPortAudioRefs(t *testing.T) {
+	if err := acquirePortAudio(); err != nil {
+		t.Skipf("PortAudio不可用: %v", err)
+	}
+	assert.NoError(t, acquirePortAudio())
+	assert.Equal(t, 2, portaudioRefs)
+
+	assert.NoError(t, releasePortAudio())
+	assert.Equal(t, 1, portaudioRefs)
+	assert.NoError(t, releasePortAudio())
+	assert.Equal(t, 0, portaudioRefs)
+
+	// 多余的释放不会使计数变为负数
+	assert.NoError(t, releasePortAudio())
+	assert.Equal(t, 0, portaudioRefs)
+}
+
+func TestDrain(t *testing.T) {
+	ch := make(chan int, 3)
+	ch <- 1
+	ch <- 2
+	drain(ch)
+	assert.Len(t, ch, 0)
+}
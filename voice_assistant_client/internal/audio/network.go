@@ -0,0 +1,360 @@
+package audio
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/url"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	vaaudio "voice_assistant/pkg/audio"
+
+	"github.com/pion/rtp"
+)
+
+// 音频输入来源
+const (
+	SourcePortAudio = "portaudio" // 本机录音设备（默认）
+	SourceNetwork   = "network"   // 网络音频流（RTSP、RTP或TCP裸PCM，如IP对讲机）
+)
+
+// 网络音频流的编码
+const (
+	CodecS16LE = "s16le" // 16位小端PCM（TCP流默认）
+	CodecL16   = "l16"   // 16位大端PCM（RTP的L16负载，RTP动态负载类型默认）
+	CodecPCMU  = "pcmu"  // G.711 μ-law
+	CodecPCMA  = "pcma"  // G.711 A-law
+)
+
+// defaultReconnectDelay 网络音频流断开后重新连接前的等待时间
+const defaultReconnectDelay = 3 * time.Second
+
+// errNetworkInput 使用网络音频输入时不能切换录音设备
+var errNetworkInput = errors.New("当前使用网络音频输入，不能切换录音设备")
+
+// NetworkConfig 网络音频输入配置
+type NetworkConfig struct {
+	URL            string        `yaml:"url"`             // rtsp://摄像头/流、rtp://监听地址:端口、tcp://主机:端口
+	Codec          string        `yaml:"codec"`           // TCP流和RTP动态负载的编码: s16le|l16|pcmu|pcma（RTSP由ffmpeg识别）
+	SampleRate     int           `yaml:"sample_rate"`     // TCP流和RTP动态负载的采样率（0表示与输入采样率相同）
+	Channels       int           `yaml:"channels"`        // TCP流和RTP动态负载的声道数（多声道取平均）
+	ReconnectDelay time.Duration `yaml:"reconnect_delay"` // 断开后重新连接前的等待时间
+}
+
+// networkStream 一种网络音频来源：连接后持续读取，解码为单声道采样交给deliver，连接断开或出错时返回
+type networkStream interface {
+	run(ctx context.Context, deliver func(samples []float32, sampleRate int)) error
+}
+
+// newNetworkStream 按URL的协议创建网络音频来源（sampleRate为输入采样率）
+func newNetworkStream(config NetworkConfig, sampleRate int) (networkStream, error) {
+	u, err := url.Parse(config.URL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("无效的网络音频地址: %q（示例: rtsp://192.168.1.20/audio、rtp://0.0.0.0:5004、tcp://192.168.1.20:9000）", config.URL)
+	}
+
+	format := pcmFormat{codec: config.Codec, sampleRate: config.SampleRate, channels: config.Channels}
+	if format.sampleRate <= 0 {
+		format.sampleRate = sampleRate
+	}
+	if format.channels <= 0 {
+		format.channels = 1
+	}
+
+	switch strings.ToLower(u.Scheme) {
+	case "rtsp", "rtsps":
+		return &rtspStream{url: config.URL, sampleRate: sampleRate}, nil
+	case "rtp":
+		if format.codec == "" {
+			format.codec = CodecL16
+		}
+		if err := format.validate(); err != nil {
+			return nil, err
+		}
+		return &rtpStream{addr: u.Host, format: format}, nil
+	case "tcp":
+		if format.codec == "" {
+			format.codec = CodecS16LE
+		}
+		if err := format.validate(); err != nil {
+			return nil, err
+		}
+		return &tcpStream{addr: u.Host, format: format}, nil
+	default:
+		return nil, fmt.Errorf("不支持的网络音频协议: %s（可选 rtsp、rtp、tcp）", u.Scheme)
+	}
+}
+
+// ValidateNetworkInput 检查网络音频输入的地址和编码
+func ValidateNetworkInput(config NetworkConfig) error {
+	_, err := newNetworkStream(config, 16000)
+	return err
+}
+
+// redactURL 隐去地址中的密码（用于日志和设备名）
+func redactURL(raw string) string {
+	if u, err := url.Parse(raw); err == nil {
+		return u.Redacted()
+	}
+	return raw
+}
+
+// pcmFormat 未压缩或G.711编码的音频格式
+type pcmFormat struct {
+	codec      string
+	sampleRate int
+	channels   int
+}
+
+// validate 检查编码
+func (f pcmFormat) validate() error {
+	switch f.codec {
+	case CodecS16LE, CodecL16, CodecPCMU, CodecPCMA:
+		return nil
+	}
+	return fmt.Errorf("不支持的网络音频编码: %s（可选 s16le、l16、pcmu、pcma）", f.codec)
+}
+
+// frameSize 一帧（所有声道各一个采样）的字节数
+func (f pcmFormat) frameSize() int {
+	if f.codec == CodecPCMU || f.codec == CodecPCMA {
+		return f.channels
+	}
+	return 2 * f.channels
+}
+
+// decode 解码为单声道float32采样（末尾不足一帧的字节忽略）
+func (f pcmFormat) decode(data []byte) []float32 {
+	data = data[:len(data)-len(data)%f.frameSize()]
+	var samples []float32
+	switch f.codec {
+	case CodecS16LE:
+		samples = vaaudio.BytesToFloat32(data)
+	case CodecL16:
+		samples = make([]float32, len(data)/2)
+		for i := range samples {
+			samples[i] = float32(int16(binary.BigEndian.Uint16(data[i*2:]))) / 32768
+		}
+	case CodecPCMU, CodecPCMA:
+		decode := vaaudio.MulawToLinear
+		if f.codec == CodecPCMA {
+			decode = vaaudio.AlawToLinear
+		}
+		samples = make([]float32, len(data))
+		for i, b := range data {
+			samples[i] = float32(decode(b)) / 32768
+		}
+	}
+	return vaaudio.Downmix(samples, f.channels)
+}
+
+// rtspStream 通过ffmpeg拉取RTSP流并转为16位单声道PCM（ffmpeg负责RTSP会话和各种音频编码）
+type rtspStream struct {
+	url        string
+	sampleRate int
+}
+
+func (s *rtspStream) run(ctx context.Context, deliver func([]float32, int)) error {
+	path, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		return fmt.Errorf("%w: 拉取RTSP流需要安装ffmpeg", vaaudio.ErrCodecUnavailable)
+	}
+
+	cmd := exec.CommandContext(ctx, path, "-hide_banner", "-loglevel", "error",
+		"-rtsp_transport", "tcp", "-i", s.url,
+		"-vn", "-f", "s16le", "-ac", "1", "-ar", strconv.Itoa(s.sampleRate), "pipe:1")
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("启动ffmpeg失败: %w", err)
+	}
+
+	format := pcmFormat{codec: CodecS16LE, sampleRate: s.sampleRate, channels: 1}
+	readErr := readPCM(bufio.NewReader(stdout), format, deliver)
+	waitErr := cmd.Wait()
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if waitErr != nil {
+		return fmt.Errorf("ffmpeg退出: %w: %s", waitErr, strings.TrimSpace(stderr.String()))
+	}
+	return readErr
+}
+
+// tcpStream 连接到TCP端口读取裸PCM或G.711流
+type tcpStream struct {
+	addr   string
+	format pcmFormat
+}
+
+func (s *tcpStream) run(ctx context.Context, deliver func([]float32, int)) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("连接网络音频流失败: %w", err)
+	}
+	defer conn.Close()
+
+	// 取消时关闭连接，结束阻塞的读取
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	err = readPCM(conn, s.format, deliver)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// readPCM 持续读取音频流直到出错，跨读取边界的不完整帧留到下次解码
+func readPCM(r io.Reader, format pcmFormat, deliver func([]float32, int)) error {
+	buf := make([]byte, 4096)
+	pending := 0
+	for {
+		n, err := r.Read(buf[pending:])
+		if n > 0 {
+			total := pending + n
+			usable := total - total%format.frameSize()
+			if usable > 0 {
+				deliver(format.decode(buf[:usable]), format.sampleRate)
+			}
+			pending = copy(buf, buf[usable:total])
+		}
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("网络音频流已结束")
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// rtpStream 在UDP端口接收RTP音频包（静态负载类型按RFC 3551解码，动态负载类型按配置的编码）
+type rtpStream struct {
+	addr   string
+	format pcmFormat
+}
+
+// payloadFormat 静态负载类型对应的格式，动态负载类型使用配置的格式
+func (s *rtpStream) payloadFormat(payloadType uint8) pcmFormat {
+	switch payloadType {
+	case 0:
+		return pcmFormat{codec: CodecPCMU, sampleRate: 8000, channels: 1}
+	case 8:
+		return pcmFormat{codec: CodecPCMA, sampleRate: 8000, channels: 1}
+	case 10:
+		return pcmFormat{codec: CodecL16, sampleRate: 44100, channels: 2}
+	case 11:
+		return pcmFormat{codec: CodecL16, sampleRate: 44100, channels: 1}
+	default:
+		return s.format
+	}
+}
+
+func (s *rtpStream) run(ctx context.Context, deliver func([]float32, int)) error {
+	var lc net.ListenConfig
+	conn, err := lc.ListenPacket(ctx, "udp", s.addr)
+	if err != nil {
+		return fmt.Errorf("监听RTP端口失败: %w", err)
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	var (
+		packet  rtp.Packet
+		lastSeq uint16
+		started bool
+		warned  = map[uint8]bool{}
+	)
+	buf := make([]byte, 1500)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("接收RTP包失败: %w", err)
+		}
+		if err := packet.Unmarshal(buf[:n]); err != nil {
+			continue
+		}
+
+		// 迟到或重复的包丢弃（不做重排，局域网对讲场景乱序很少）
+		if started && int16(packet.SequenceNumber-lastSeq) <= 0 {
+			continue
+		}
+		lastSeq, started = packet.SequenceNumber, true
+
+		format := s.payloadFormat(packet.PayloadType)
+		if packet.PayloadType >= 96 && !warned[packet.PayloadType] {
+			warned[packet.PayloadType] = true
+			log.Printf("RTP动态负载类型%d按配置解码: %s %dHz %d声道", packet.PayloadType, format.codec, format.sampleRate, format.channels)
+		}
+		if len(packet.Payload) > 0 {
+			deliver(format.decode(packet.Payload), format.sampleRate)
+		}
+	}
+}
+
+// runNetwork 持续从网络音频流读取，断开后按配置的间隔重新连接；
+// 音频重采样到输入采样率、按缓冲区大小分帧后交给audioCallback，与录音设备的音频走同样的增益、VAD和发送流程
+func (ai *AudioInput) runNetwork(ctx context.Context, done <-chan struct{}) {
+	defer ai.wg.Done()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	delay := ai.config.Network.ReconnectDelay
+	if delay <= 0 {
+		delay = defaultReconnectDelay
+	}
+	frameSize := ai.config.BufferSize
+	if frameSize <= 0 {
+		frameSize = ai.config.SampleRate / 50
+	}
+
+	var frame []float32
+	deliver := func(samples []float32, sampleRate int) {
+		frame = append(frame, vaaudio.Resample(samples, sampleRate, ai.config.SampleRate)...)
+		for len(frame) >= frameSize {
+			ai.audioCallback(frame[:frameSize:frameSize])
+			frame = frame[frameSize:]
+		}
+	}
+
+	for {
+		log.Printf("连接网络音频输入: %s", ai.networkName)
+		err := ai.network.run(ctx, deliver)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("网络音频输入中断: %v，%v后重新连接", err, delay)
+		frame = frame[:0]
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
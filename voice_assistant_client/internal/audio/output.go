@@ -4,19 +4,35 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"math"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	vaaudio "voice_assistant/pkg/audio"
+
 	"github.com/gordonklaus/portaudio"
 )
 
 // OutputConfig 音频输出配置
 type OutputConfig struct {
-	DeviceID   int    `yaml:"device_id"`
-	SampleRate int    `yaml:"sample_rate"`
-	Channels   int    `yaml:"channels"`
-	Format     string `yaml:"format"`
-	BufferSize int    `yaml:"buffer_size"`
+	Sink       string            `yaml:"sink"`    // portaudio|network
+	Network    NetworkSinkConfig `yaml:"network"` // sink为network时的网络输出目标
+	DeviceID   int               `yaml:"device_id"`
+	SampleRate int               `yaml:"sample_rate"`
+	Channels   int               `yaml:"channels"`
+	Format     string            `yaml:"format"`
+	BufferSize int               `yaml:"buffer_size"`
+
+	// 抖动缓冲：开始播放前累积的音频时长，以及缓冲耗尽和恢复播放时的淡入淡出时长（0表示不启用）
+	Prebuffer time.Duration `yaml:"prebuffer"`
+	Crossfade time.Duration `yaml:"crossfade"`
+
+	// 播放期间调低其他程序的音量
+	Ducking DuckingConfig `yaml:"ducking"`
+
+	// 播放速度（变速不变调，0或1表示原速）
+	Speed float64 `yaml:"speed"`
 }
 
 // AudioOutput 音频输出管理器
@@ -25,24 +41,64 @@ type AudioOutput struct {
 	stream *portaudio.Stream
 	device *portaudio.DeviceInfo
 
+	// 网络音频输出（sink为network时使用，不打开播放设备）
+	sink     networkSink
+	sinkName string
+
 	// 状态管理
 	isRunning bool
 	isPlaying bool
 	mu        sync.RWMutex
 
+	// 设备热插拔（streamMu 保护音频流的打开、关闭和设备切换）
+	streamMu       sync.Mutex
+	heartbeat      streamHeartbeat
+	onDeviceChange DeviceChangeHandler
+
+	// 运行期间的后台协程（Stop时关闭done并等待退出，之后可再次Start）
+	done chan struct{}
+	wg   sync.WaitGroup
+
 	// 音频数据通道
 	audioChan   chan []float32
 	controlChan chan outputControlSignal
 
-	// 播放队列
-	playQueue    [][]float32
-	playQueueMu  sync.Mutex
-	currentIndex int
+	// 播放进度（音频回调在播放队列耗尽时经drained通知后台协程）
+	onPlayback PlaybackHandler
+	drained    chan struct{}
+
+	// 播放期间调低其他程序音量（未启用或当前系统不支持时为nil）
+	ducker *ducker
+
+	// 输出电平包络（双工模式的回声参考）
+	level   atomic.Uint64 // 最近一次更新时的电平（dBFS，float64位模式）
+	levelAt atomic.Int64  // 更新时间（UnixNano）
+
+	// 播放队列（抖动缓冲），经变速器输出（playQueueMu 同时保护两者）
+	playQueue   *jitterBuffer
+	stretcher   *timeStretcher
+	playQueueMu sync.Mutex
 
 	// 统计信息
 	stats OutputStats
 }
 
+// PlaybackEvent 播放进度事件
+type PlaybackEvent struct {
+	Played    time.Duration // 本段语音（从播放队列为空时开始计）已播放的时长
+	Remaining time.Duration // 播放队列中待播放的时长
+	Finished  bool          // 播放队列已播放完毕（或被清空）
+}
+
+// PlaybackHandler 播放进度回调（在后台协程中调用，不阻塞音频回调）
+type PlaybackHandler func(event PlaybackEvent)
+
+// 播放进度事件的上报间隔
+const playbackProgressInterval = 200 * time.Millisecond
+
+// 输出电平包络的释放速度（dB/秒），播放停止后电平逐渐回落，覆盖扬声器到麦克风的延迟和混响
+const playbackLevelRelease = 60.0
+
 // outputControlSignal 输出控制信号
 type outputControlSignal int
 
@@ -66,48 +122,84 @@ type OutputStats struct {
 
 // NewAudioOutput 创建音频输出管理器
 func NewAudioOutput(config OutputConfig) (*AudioOutput, error) {
-	if err := portaudio.Initialize(); err != nil {
-		return nil, fmt.Errorf("初始化PortAudio失败: %w", err)
+	var sink networkSink
+	switch config.Sink {
+	case "", SinkPortAudio:
+		if err := acquirePortAudio(); err != nil {
+			return nil, err
+		}
+		defer releasePortAudio()
+	case SinkNetwork:
+		var err error
+		if sink, err = newNetworkSink(config.Network.URL); err != nil {
+			return nil, fmt.Errorf("设置网络音频输出失败: %w", err)
+		}
+		if config.Network.SampleRate <= 0 {
+			config.Network.SampleRate = defaultSinkSampleRate
+		}
+		if config.Network.Channels <= 0 {
+			config.Network.Channels = defaultSinkChannels
+		}
+		// 播放队列按单声道处理，发送时再转换为目标的声道数
+		config.Channels = 1
+	default:
+		return nil, fmt.Errorf("未知的音频输出目标: %s（可选 portaudio、network）", config.Sink)
 	}
 
 	ao := &AudioOutput{
 		config:      config,
 		audioChan:   make(chan []float32, 100),
 		controlChan: make(chan outputControlSignal, 10),
-		playQueue:   make([][]float32, 0),
+		drained:     make(chan struct{}, 1),
+		playQueue:   newJitterBuffer(config),
 	}
+	ao.stretcher = newTimeStretcher(config, func(out []float32) int {
+		return ao.playQueue.read(out, time.Now())
+	})
 
-	// 获取音频设备信息
-	if err := ao.setupDevice(); err != nil {
+	if sink != nil {
+		ao.sink, ao.sinkName = sink, redactURL(config.Network.URL)
+		log.Printf("使用网络音频输出: %s（%dHz, %d通道）", ao.sinkName, config.Network.SampleRate, config.Network.Channels)
+	} else if err := ao.setupDevice(); err != nil {
 		return nil, fmt.Errorf("设置音频设备失败: %w", err)
 	}
 
+	if config.Ducking.Enabled {
+		if ctrl, err := NewVolumeController(); err != nil {
+			log.Printf("播放时调低其他音频不可用: %v", err)
+		} else {
+			ao.ducker = newDucker(ctrl, config.Ducking)
+		}
+	}
+
 	return ao, nil
 }
 
-// setupDevice 设置音频设备
-func (ao *AudioOutput) setupDevice() error {
-	var device *portaudio.DeviceInfo
-	var err error
-
-	if ao.config.DeviceID == -1 {
-		// 使用默认输出设备
-		device, err = portaudio.DefaultOutputDevice()
-		if err != nil {
-			return fmt.Errorf("获取默认输出设备失败: %w", err)
-		}
-	} else {
-		// 使用指定设备
-		devices, err := portaudio.Devices()
-		if err != nil {
-			return fmt.Errorf("获取设备列表失败: %w", err)
+// reloadDevice 重新获取当前设备（PortAudio可能已重新初始化，设备序号会变化），设备已不存在时改用默认设备（调用方持有 streamMu）
+func (ao *AudioOutput) reloadDevice() error {
+	if ao.config.DeviceID != -1 {
+		if devices, err := portaudio.Devices(); err == nil {
+			if id := findDeviceByName(devices, ao.device.Name); id >= 0 {
+				ao.device, ao.config.DeviceID = devices[id], id
+				return nil
+			}
 		}
+		log.Printf("音频输出设备已不存在: %s，改用默认设备", ao.device.Name)
+	}
 
-		if ao.config.DeviceID >= len(devices) {
-			return fmt.Errorf("设备ID %d 超出范围", ao.config.DeviceID)
-		}
+	device, err := resolveOutputDevice(-1)
+	if err != nil {
+		return err
+	}
+	ao.device, ao.config.DeviceID = device, -1
+	return nil
+}
 
-		device = devices[ao.config.DeviceID]
+// setupDevice 设置音频设备
+func (ao *AudioOutput) setupDevice() error {
+	device, err := resolveOutputDevice(ao.config.DeviceID)
+	if err != nil {
+		return err
 	}
 
 	ao.device = device
@@ -124,9 +216,58 @@ func (ao *AudioOutput) Start(ctx context.Context) error {
 		return fmt.Errorf("音频输出已经在运行")
 	}
 	ao.isRunning = true
+	done := make(chan struct{})
+	ao.done = done
 	ao.mu.Unlock()
 
-	// 创建音频流
+	// 网络音频输出：由定时协程代替播放设备的音频回调，写入协程断线后自动重连
+	if ao.sink != nil {
+		frames := make(chan []float32, sinkQueueFrames)
+		ao.wg.Add(4)
+		go ao.controlLoop(ctx, done)
+		go ao.playbackLoop(ctx, done)
+		go ao.runSinkClock(ctx, done, frames)
+		go ao.runSinkWriter(ctx, done, frames)
+		log.Printf("音频输出已启动: %s, %dHz", ao.sinkName, ao.config.SampleRate)
+		return nil
+	}
+
+	err := acquirePortAudio()
+	if err == nil {
+		ao.streamMu.Lock()
+		if err = ao.reloadDevice(); err == nil {
+			err = ao.openStream()
+		}
+		ao.streamMu.Unlock()
+		if err != nil {
+			releasePortAudio()
+		}
+	}
+	if err != nil {
+		ao.mu.Lock()
+		ao.isRunning = false
+		ao.mu.Unlock()
+		return err
+	}
+
+	log.Printf("音频输出已启动: %dHz, %d通道, 缓冲区%d",
+		ao.config.SampleRate, ao.config.Channels, ao.config.BufferSize)
+
+	// 启动控制协程
+	ao.wg.Add(3)
+	go ao.controlLoop(ctx, done)
+
+	// 启动播放进度协程
+	go ao.playbackLoop(ctx, done)
+
+	// 启动设备检测协程
+	go ao.monitorDevice(ctx, done)
+
+	return nil
+}
+
+// openStream 在当前设备上打开并启动音频流（调用方持有 streamMu）
+func (ao *AudioOutput) openStream() error {
 	outputParams := portaudio.StreamParameters{
 		Output: portaudio.StreamDeviceParameters{
 			Device:   ao.device,
@@ -137,31 +278,38 @@ func (ao *AudioOutput) Start(ctx context.Context) error {
 		FramesPerBuffer: ao.config.BufferSize,
 	}
 
-	var err error
-	ao.stream, err = portaudio.OpenStream(outputParams, ao.audioCallback)
+	stream, err := portaudio.OpenStream(outputParams, ao.audioCallback)
 	if err != nil {
-		ao.mu.Lock()
-		ao.isRunning = false
-		ao.mu.Unlock()
 		return fmt.Errorf("打开音频流失败: %w", err)
 	}
 
-	// 启动音频流
-	if err := ao.stream.Start(); err != nil {
-		ao.stream.Close()
-		ao.mu.Lock()
-		ao.isRunning = false
-		ao.mu.Unlock()
+	ao.heartbeat.beat()
+	if err := stream.Start(); err != nil {
+		stream.Close()
 		return fmt.Errorf("启动音频流失败: %w", err)
 	}
 
-	log.Printf("音频输出已启动: %dHz, %d通道, 缓冲区%d",
-		ao.config.SampleRate, ao.config.Channels, ao.config.BufferSize)
+	ao.stream = stream
+	return nil
+}
 
-	// 启动控制协程
-	go ao.controlLoop(ctx)
+// closeStream 停止并关闭音频流（调用方持有 streamMu）；设备已断开时用abort避免等待缓冲区排空
+func (ao *AudioOutput) closeStream(abort bool) {
+	if ao.stream == nil {
+		return
+	}
 
-	return nil
+	stop := ao.stream.Stop
+	if abort {
+		stop = ao.stream.Abort
+	}
+	if err := stop(); err != nil {
+		log.Printf("停止音频流失败: %v", err)
+	}
+	if err := ao.stream.Close(); err != nil {
+		log.Printf("关闭音频流失败: %v", err)
+	}
+	ao.stream = nil
 }
 
 // Stop 停止音频输出
@@ -172,33 +320,34 @@ func (ao *AudioOutput) Stop() error {
 		return nil
 	}
 	ao.isRunning = false
+	ao.isPlaying = false
+	done := ao.done
 	ao.mu.Unlock()
 
-	// 发送停止信号
-	select {
-	case ao.controlChan <- outputSignalStop:
-	default:
-	}
+	// 等待后台协程退出
+	close(done)
+	ao.wg.Wait()
 
 	// 停止音频流
-	if ao.stream != nil {
-		if err := ao.stream.Stop(); err != nil {
-			log.Printf("停止音频流失败: %v", err)
-		}
-		if err := ao.stream.Close(); err != nil {
-			log.Printf("关闭音频流失败: %v", err)
+	if ao.sink == nil {
+		ao.streamMu.Lock()
+		ao.closeStream(false)
+		ao.streamMu.Unlock()
+
+		if err := releasePortAudio(); err != nil {
+			log.Printf("%v", err)
 		}
 	}
 
-	// 关闭通道
-	close(ao.audioChan)
-	close(ao.controlChan)
-
-	// 清理PortAudio
-	if err := portaudio.Terminate(); err != nil {
-		log.Printf("清理PortAudio失败: %v", err)
+	if ao.ducker != nil {
+		ao.ducker.restore()
 	}
 
+	// 丢弃未处理的数据和信号，避免再次启动后收到本次运行的残留
+	drain(ao.audioChan)
+	drain(ao.controlChan)
+	drain(ao.drained)
+
 	log.Println("音频输出已停止")
 	return nil
 }
@@ -214,9 +363,13 @@ func (ao *AudioOutput) Play(audioData []float32) error {
 
 	// 添加到播放队列
 	ao.playQueueMu.Lock()
-	ao.playQueue = append(ao.playQueue, audioData)
+	ao.playQueue.push(audioData, time.Now())
 	ao.playQueueMu.Unlock()
 
+	if ao.ducker != nil {
+		ao.ducker.start()
+	}
+
 	// 发送播放信号
 	select {
 	case ao.controlChan <- outputSignalStart:
@@ -228,9 +381,16 @@ func (ao *AudioOutput) Play(audioData []float32) error {
 
 // PlayBytes 播放字节数据
 func (ao *AudioOutput) PlayBytes(audioData []byte) error {
-	// 转换字节数据为float32
-	floatData := BytesToFloat32(audioData)
-	return ao.Play(floatData)
+	return ao.Play(vaaudio.BytesToFloat32(audioData))
+}
+
+// PlaySpeech 播放服务端下发的TTS语音，按其格式和采样率转换为输出设备的采样率
+func (ao *AudioOutput) PlaySpeech(data []byte, format string, sampleRate, channels int) error {
+	samples, err := DecodeSpeech(data, format, sampleRate, channels, ao.config.SampleRate)
+	if err != nil {
+		return err
+	}
+	return ao.Play(samples)
 }
 
 // StartPlaying 开始播放
@@ -307,13 +467,176 @@ func (ao *AudioOutput) ClearQueue() error {
 	}
 }
 
+// SetSpeed 设置播放速度（变速不变调），对已在播放队列中的音频立即生效
+func (ao *AudioOutput) SetSpeed(speed float64) error {
+	if err := ValidatePlaybackSpeed(speed); err != nil {
+		return err
+	}
+	ao.playQueueMu.Lock()
+	defer ao.playQueueMu.Unlock()
+	ao.stretcher.speed = speed
+	return nil
+}
+
+// Speed 当前播放速度
+func (ao *AudioOutput) Speed() float64 {
+	ao.playQueueMu.Lock()
+	defer ao.playQueueMu.Unlock()
+	return ao.stretcher.speed
+}
+
+// SetPlaybackHandler 设置播放进度回调：播放期间定期上报进度，播放队列播放完毕时上报Finished
+func (ao *AudioOutput) SetPlaybackHandler(handler PlaybackHandler) {
+	ao.mu.Lock()
+	defer ao.mu.Unlock()
+	ao.onPlayback = handler
+}
+
+// SetDeviceChangeHandler 设置音频设备切换回调
+func (ao *AudioOutput) SetDeviceChangeHandler(handler DeviceChangeHandler) {
+	ao.mu.Lock()
+	defer ao.mu.Unlock()
+	ao.onDeviceChange = handler
+}
+
+// SwitchDevice 运行中切换输出设备（deviceID为-1时使用默认设备），播放队列保留，新设备打开失败时保留原设备
+func (ao *AudioOutput) SwitchDevice(deviceID int) error {
+	if ao.sink != nil {
+		return errNetworkOutput
+	}
+	ao.streamMu.Lock()
+	defer ao.streamMu.Unlock()
+
+	device, err := resolveOutputDevice(deviceID)
+	if err != nil {
+		return err
+	}
+	return ao.switchDevice(deviceID, device)
+}
+
+// NextDevice 切换到设备列表中的下一个输出设备，返回新设备名称
+func (ao *AudioOutput) NextDevice() (string, error) {
+	if ao.sink != nil {
+		return "", errNetworkOutput
+	}
+	ao.streamMu.Lock()
+	defer ao.streamMu.Unlock()
+
+	devices, err := portaudio.Devices()
+	if err != nil {
+		return "", fmt.Errorf("获取设备列表失败: %w", err)
+	}
+	deviceID, ok := nextDeviceID(devices, ao.device.Name, false)
+	if !ok {
+		return "", fmt.Errorf("没有其他可用的输出设备")
+	}
+	if err := ao.switchDevice(deviceID, devices[deviceID]); err != nil {
+		return "", err
+	}
+	return devices[deviceID].Name, nil
+}
+
+// DeviceName 当前输出设备名称
+func (ao *AudioOutput) DeviceName() string {
+	if ao.sink != nil {
+		return ao.sinkName
+	}
+	ao.streamMu.Lock()
+	defer ao.streamMu.Unlock()
+	return ao.device.Name
+}
+
+// switchDevice 关闭当前音频流并在新设备上重新打开（调用方持有 streamMu）
+func (ao *AudioOutput) switchDevice(deviceID int, device *portaudio.DeviceInfo) error {
+	if device.MaxOutputChannels == 0 {
+		return fmt.Errorf("设备 %s 不支持音频输出", device.Name)
+	}
+
+	previous, previousID := ao.device, ao.config.DeviceID
+	ao.device, ao.config.DeviceID = device, deviceID
+	if !ao.IsRunning() {
+		log.Printf("使用音频输出设备: %s", device.Name)
+		return nil
+	}
+
+	ao.closeStream(false)
+	if err := ao.openStream(); err != nil {
+		ao.device, ao.config.DeviceID = previous, previousID
+		if reopenErr := ao.openStream(); reopenErr != nil {
+			log.Printf("恢复原音频输出设备失败: %v", reopenErr)
+		}
+		return fmt.Errorf("切换到设备 %s 失败: %w", device.Name, err)
+	}
+
+	log.Printf("音频输出设备已切换: %s", device.Name)
+	return nil
+}
+
+// monitorDevice 定期检测输出设备，设备断开（音频回调停止）后回退到默认设备
+func (ao *AudioOutput) monitorDevice(ctx context.Context, done <-chan struct{}) {
+	defer ao.wg.Done()
+
+	ticker := time.NewTicker(deviceCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-done:
+			return
+		case <-ticker.C:
+			name, ok := ao.fallbackIfLost()
+			if !ok {
+				continue
+			}
+
+			ao.mu.RLock()
+			handler := ao.onDeviceChange
+			ao.mu.RUnlock()
+			if handler != nil {
+				handler(name, true)
+			}
+		}
+	}
+}
+
+// fallbackIfLost 设备无响应或上次回退未成功时改用默认输出设备，返回是否已切换
+func (ao *AudioOutput) fallbackIfLost() (string, bool) {
+	ao.streamMu.Lock()
+	defer ao.streamMu.Unlock()
+
+	if !ao.IsRunning() || ao.stream != nil && !ao.heartbeat.stalled(time.Now()) {
+		return "", false
+	}
+
+	if ao.stream != nil {
+		log.Printf("音频输出设备无响应，可能已断开: %s，切换到默认设备", ao.device.Name)
+		ao.closeStream(true)
+	}
+
+	device, err := portaudio.DefaultOutputDevice()
+	if err != nil {
+		log.Printf("获取默认输出设备失败: %v，稍后重试", err)
+		return "", false
+	}
+	ao.device, ao.config.DeviceID = device, -1
+	if err := ao.openStream(); err != nil {
+		log.Printf("打开默认输出设备失败: %v，稍后重试", err)
+		return "", false
+	}
+
+	log.Printf("音频输出设备已切换: %s", device.Name)
+	return device.Name, true
+}
+
 // GetStats 获取统计信息
 func (ao *AudioOutput) GetStats() OutputStats {
 	ao.mu.RLock()
 	defer ao.mu.RUnlock()
 
 	ao.playQueueMu.Lock()
-	queueSize := len(ao.playQueue)
+	queueSize := ao.playQueue.pending()
 	ao.playQueueMu.Unlock()
 
 	stats := ao.stats
@@ -337,66 +660,132 @@ func (ao *AudioOutput) IsPlaying() bool {
 
 // audioCallback 音频回调函数
 func (ao *AudioOutput) audioCallback(out []float32) {
+	ao.heartbeat.beat()
+	ao.fill(out)
+}
+
+// fill 从播放队列取出音频填充out（不足部分为静音），返回取出的采样数
+func (ao *AudioOutput) fill(out []float32) int {
 	ao.mu.RLock()
 	isPlaying := ao.isPlaying
 	ao.mu.RUnlock()
 
 	if !isPlaying {
-		// 输出静音
-		for i := range out {
-			out[i] = 0
-		}
-		return
+		silence(out)
+		return 0
 	}
 
-	// 从播放队列获取数据
+	// 从抖动缓冲获取数据（未达到预缓冲量或没有数据时输出静音）
 	ao.playQueueMu.Lock()
-	if len(ao.playQueue) == 0 {
-		ao.playQueueMu.Unlock()
-		// 没有数据，输出静音
-		for i := range out {
-			out[i] = 0
-		}
-		return
-	}
+	played := ao.stretcher.read(out)
+	drained := played > 0 && ao.playQueue.drained() && ao.stretcher.empty()
+	ao.playQueueMu.Unlock()
 
-	// 获取当前音频数据
-	currentData := ao.playQueue[0]
+	if drained {
+		ao.notifyDrained()
+	}
+	if played > 0 {
+		ao.updateLevel(FrameLevel(out[:played]))
+	}
 
-	// 复制数据到输出缓冲区
-	copyLen := len(out)
-	if len(currentData) < copyLen {
-		copyLen = len(currentData)
+	// 更新统计信息
+	if played > 0 {
+		ao.updateStats(played)
 	}
+	return played
+}
 
-	copy(out[:copyLen], currentData[:copyLen])
+// PlaybackLevel 输出电平包络（dBFS，未播放时为-100），实现EchoReference
+func (ao *AudioOutput) PlaybackLevel() float64 {
+	at := ao.levelAt.Load()
+	if at == 0 {
+		return -100.0
+	}
+	level := math.Float64frombits(ao.level.Load()) - time.Since(time.Unix(0, at)).Seconds()*playbackLevelRelease
+	return math.Max(level, -100.0)
+}
 
-	// 如果输出缓冲区更大，用静音填充
-	for i := copyLen; i < len(out); i++ {
-		out[i] = 0
+// updateLevel 以峰值保持更新输出电平包络
+func (ao *AudioOutput) updateLevel(level float64) {
+	if current := ao.PlaybackLevel(); current > level {
+		level = current
 	}
+	ao.level.Store(math.Float64bits(level))
+	ao.levelAt.Store(time.Now().UnixNano())
+}
 
-	// 更新播放队列
-	if len(currentData) <= len(out) {
-		// 当前数据已播放完，移除
-		ao.playQueue = ao.playQueue[1:]
-	} else {
-		// 当前数据还有剩余
-		ao.playQueue[0] = currentData[len(out):]
+// notifyDrained 通知播放进度协程播放队列已耗尽
+func (ao *AudioOutput) notifyDrained() {
+	select {
+	case ao.drained <- struct{}{}:
+	default:
 	}
+}
 
-	ao.playQueueMu.Unlock()
+// playbackLoop 播放期间定期上报进度，播放队列耗尽时上报播放完毕
+// 耗尽通知处理时若已有新的音频到达（片段之间的空隙），不视为播放完毕。
+func (ao *AudioOutput) playbackLoop(ctx context.Context, done <-chan struct{}) {
+	defer ao.wg.Done()
 
-	// 更新统计信息
-	ao.updateStats(len(out))
+	ticker := time.NewTicker(playbackProgressInterval)
+	defer ticker.Stop()
+
+	for {
+		var finished bool
+		select {
+		case <-ctx.Done():
+			return
+		case <-done:
+			return
+		case <-ticker.C:
+		case <-ao.drained:
+			finished = true
+		}
+
+		ao.playQueueMu.Lock()
+		event := PlaybackEvent{
+			Played:    ao.samplesDuration(ao.playQueue.played),
+			Remaining: ao.samplesDuration(ao.playQueue.buffered),
+			Finished:  ao.playQueue.drained(),
+		}
+		ao.playQueueMu.Unlock()
+
+		if finished && event.Finished && ao.ducker != nil {
+			ao.ducker.finish()
+		}
+
+		ao.mu.RLock()
+		handler := ao.onPlayback
+		ao.mu.RUnlock()
+		if handler == nil {
+			continue
+		}
+
+		if finished && event.Finished || !event.Finished && event.Played > 0 {
+			handler(event)
+		}
+	}
+}
+
+// samplesDuration 采样数对应的播放时长
+func (ao *AudioOutput) samplesDuration(samples int) time.Duration {
+	rate := ao.config.SampleRate * ao.config.Channels
+	if rate <= 0 {
+		return 0
+	}
+	return time.Duration(samples) * time.Second / time.Duration(rate)
 }
 
 // controlLoop 控制循环
-func (ao *AudioOutput) controlLoop(ctx context.Context) {
+func (ao *AudioOutput) controlLoop(ctx context.Context, done <-chan struct{}) {
+	defer ao.wg.Done()
+
 	for {
 		select {
 		case <-ctx.Done():
 			return
+		case <-done:
+			return
 		case signal := <-ao.controlChan:
 			switch signal {
 			case outputSignalStart:
@@ -419,9 +808,10 @@ func (ao *AudioOutput) controlLoop(ctx context.Context) {
 				ao.mu.Unlock()
 			case outputSignalClear:
 				ao.playQueueMu.Lock()
-				ao.playQueue = ao.playQueue[:0]
-				ao.currentIndex = 0
+				ao.playQueue.reset()
+				ao.stretcher.reset()
 				ao.playQueueMu.Unlock()
+				ao.notifyDrained()
 			}
 		}
 	}
@@ -441,40 +831,12 @@ func (ao *AudioOutput) updateStats(framesPlayed int) {
 	}
 }
 
-// BytesToFloat32 将字节数据转换为float32
-func BytesToFloat32(data []byte) []float32 {
-	if len(data)%2 != 0 {
-		// 确保字节数为偶数
-		data = data[:len(data)-1]
-	}
-
-	result := make([]float32, len(data)/2)
-	for i := 0; i < len(result); i++ {
-		// 16位PCM转float32
-		sample := int16(data[i*2]) | int16(data[i*2+1])<<8
-		result[i] = float32(sample) / 32768.0
-	}
-	return result
-}
-
-// Float32ToBytes 将float32数据转换为字节
-func Float32ToBytes(data []float32) []byte {
-	result := make([]byte, len(data)*2)
-	for i, sample := range data {
-		// float32转16位PCM
-		pcm := int16(sample * 32767)
-		result[i*2] = byte(pcm)
-		result[i*2+1] = byte(pcm >> 8)
-	}
-	return result
-}
-
 // GetOutputDeviceList 获取可用的音频输出设备列表
 func GetOutputDeviceList() ([]*portaudio.DeviceInfo, error) {
-	if err := portaudio.Initialize(); err != nil {
-		return nil, fmt.Errorf("初始化PortAudio失败: %w", err)
+	if err := acquirePortAudio(); err != nil {
+		return nil, err
 	}
-	defer portaudio.Terminate()
+	defer releasePortAudio()
 
 	devices, err := portaudio.Devices()
 	if err != nil {
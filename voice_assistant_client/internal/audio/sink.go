@@ -0,0 +1,289 @@
+package audio
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	vaaudio "voice_assistant/pkg/audio"
+)
+
+// 音频输出目标
+const (
+	SinkPortAudio = "portaudio" // 本机播放设备（默认）
+	SinkNetwork   = "network"   // 网络目标（Snapcast、AirPlay发送程序或UDP PCM接收端）
+)
+
+// 网络输出的参数
+const (
+	defaultSinkSampleRate = 48000 // Snapcast默认的采样格式为 48000:16:2
+	defaultSinkChannels   = 2
+	maxSinkDatagram       = 1400 // UDP数据报的最大负载（避免IP分片）
+	sinkQueueFrames       = 64   // 等待写入网络的音频帧数（网络阻塞时丢弃更新的音频）
+)
+
+// errNetworkOutput 使用网络输出时不能切换播放设备
+var errNetworkOutput = errors.New("当前使用网络音频输出，不能切换播放设备")
+
+// NetworkSinkConfig 网络音频输出配置
+type NetworkSinkConfig struct {
+	URL            string        `yaml:"url"`             // tcp://主机:端口、udp://主机:端口、pipe:///tmp/snapfifo、exec:命令 参数...
+	SampleRate     int           `yaml:"sample_rate"`     // 发送的采样率（默认48000）
+	Channels       int           `yaml:"channels"`        // 发送的声道数（默认2，单声道语音复制到各声道）
+	ReconnectDelay time.Duration `yaml:"reconnect_delay"` // 连接断开后重新连接前的等待时间
+}
+
+// networkSink 一种网络输出目标：打开后以16位小端PCM持续写入，写入失败时关闭并重新打开
+type networkSink interface {
+	open(ctx context.Context) (io.WriteCloser, error)
+}
+
+// ValidateNetworkSink 检查网络音频输出的地址
+func ValidateNetworkSink(config NetworkSinkConfig) error {
+	_, err := newNetworkSink(config.URL)
+	return err
+}
+
+// newNetworkSink 按地址创建网络输出目标
+func newNetworkSink(raw string) (networkSink, error) {
+	if command, ok := strings.CutPrefix(raw, "exec:"); ok {
+		args := strings.Fields(command)
+		if len(args) == 0 {
+			return nil, fmt.Errorf("exec输出需要指定命令，如 exec:raop_play 192.168.1.30 -")
+		}
+		return execSink{args: args}, nil
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("无效的网络音频输出地址: %q", raw)
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "tcp", "udp":
+		if u.Host == "" {
+			break
+		}
+		return dialSink{network: strings.ToLower(u.Scheme), addr: u.Host}, nil
+	case "pipe":
+		if u.Path == "" {
+			break
+		}
+		return pipeSink{path: u.Path}, nil
+	}
+	return nil, fmt.Errorf("无效的网络音频输出地址: %q（示例: tcp://snapserver:4953、udp://192.168.1.30:5555、pipe:///tmp/snapfifo、exec:raop_play 192.168.1.30 -）", raw)
+}
+
+// dialSink 连接TCP端口（如Snapcast的tcp客户端模式源）或向UDP端口发送PCM数据报
+type dialSink struct {
+	network string
+	addr    string
+}
+
+func (s dialSink) open(ctx context.Context) (io.WriteCloser, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, s.network, s.addr)
+	if err != nil {
+		return nil, err
+	}
+	if s.network == "udp" {
+		return datagramWriter{conn}, nil
+	}
+	return conn, nil
+}
+
+// datagramWriter 把一次写入拆成不超过 maxSinkDatagram 的数据报（长度为4的倍数，不拆开立体声帧）
+type datagramWriter struct {
+	net.Conn
+}
+
+func (w datagramWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		n := min(len(p), maxSinkDatagram)
+		if _, err := w.Conn.Write(p[:n]); err != nil {
+			return written, err
+		}
+		written += n
+		p = p[n:]
+	}
+	return written, nil
+}
+
+// pipeSink 写入命名管道（如Snapcast的pipe源 /tmp/snapfifo）；没有读取方时打开失败，稍后重试
+type pipeSink struct {
+	path string
+}
+
+func (s pipeSink) open(ctx context.Context) (io.WriteCloser, error) {
+	return os.OpenFile(s.path, os.O_WRONLY|syscall.O_NONBLOCK, 0)
+}
+
+// execSink 启动外部程序并写入其标准输入（如AirPlay发送程序 raop_play）
+type execSink struct {
+	args []string
+}
+
+func (s execSink) open(ctx context.Context) (io.WriteCloser, error) {
+	cmd := exec.CommandContext(ctx, s.args[0], s.args[1:]...)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &execWriter{WriteCloser: stdin, cmd: cmd}, nil
+}
+
+// execWriter 关闭时结束标准输入并等待程序退出
+type execWriter struct {
+	io.WriteCloser
+	cmd *exec.Cmd
+}
+
+func (w *execWriter) Close() error {
+	w.WriteCloser.Close()
+	return w.cmd.Wait()
+}
+
+// onceCloser 只关闭一次（停止时可能与写入出错同时关闭）
+type onceCloser struct {
+	io.WriteCloser
+	once sync.Once
+	err  error
+}
+
+func (c *onceCloser) Close() error {
+	c.once.Do(func() { c.err = c.WriteCloser.Close() })
+	return c.err
+}
+
+// sinkFrames 把单声道采样转换为网络输出的格式（重采样、复制到各声道、16位小端）
+func sinkFrames(samples []float32, fromRate, toRate, channels int) []byte {
+	samples = vaaudio.Resample(samples, fromRate, toRate)
+	data := make([]byte, len(samples)*channels*2)
+	for i, sample := range samples {
+		value := uint16(int16(math.Max(-1, math.Min(1, float64(sample))) * 32767))
+		for ch := 0; ch < channels; ch++ {
+			binary.LittleEndian.PutUint16(data[(i*channels+ch)*2:], value)
+		}
+	}
+	return data
+}
+
+// runSinkClock 按采样率定时从播放队列取出音频（代替播放设备的音频回调），有声音时交给写入协程
+// 播放进度、耗尽通知和回声参考电平与本机播放设备相同。
+func (ao *AudioOutput) runSinkClock(ctx context.Context, done <-chan struct{}, frames chan<- []float32) {
+	defer ao.wg.Done()
+	defer close(frames)
+
+	frameSize := ao.config.BufferSize
+	if frameSize <= 0 {
+		frameSize = ao.config.SampleRate / 50
+	}
+	period := time.Duration(frameSize) * time.Second / time.Duration(ao.config.SampleRate)
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		out := make([]float32, frameSize)
+		if ao.fill(out) == 0 {
+			continue
+		}
+		select {
+		case frames <- out:
+		default:
+			ao.mu.Lock()
+			ao.stats.DroppedFrames += int64(len(out))
+			ao.mu.Unlock()
+		}
+	}
+}
+
+// runSinkWriter 把音频写入网络目标，连接失败或写入出错时按配置的间隔重新连接（期间的音频丢弃）
+func (ao *AudioOutput) runSinkWriter(ctx context.Context, done <-chan struct{}, frames <-chan []float32) {
+	defer ao.wg.Done()
+
+	// 停止时关闭连接，结束阻塞的写入
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	config := ao.config.Network
+	delay := config.ReconnectDelay
+	if delay <= 0 {
+		delay = defaultReconnectDelay
+	}
+
+	var (
+		w         io.WriteCloser
+		stopClose func() bool
+		retryAt   time.Time
+	)
+	disconnect := func() {
+		stopClose()
+		w.Close()
+		w = nil
+		retryAt = time.Now().Add(delay)
+	}
+	defer func() {
+		if w != nil {
+			disconnect()
+		}
+	}()
+
+	for frame := range frames {
+		if w == nil {
+			if time.Now().Before(retryAt) {
+				continue
+			}
+			conn, err := ao.sink.open(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Printf("连接网络音频输出失败: %s: %v，%v后重试", ao.sinkName, err, delay)
+				}
+				retryAt = time.Now().Add(delay)
+				continue
+			}
+			closer := &onceCloser{WriteCloser: conn}
+			w, stopClose = closer, context.AfterFunc(ctx, func() { closer.Close() })
+			log.Printf("已连接网络音频输出: %s", ao.sinkName)
+		}
+
+		if _, err := w.Write(sinkFrames(frame, ao.config.SampleRate, config.SampleRate, config.Channels)); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("写入网络音频输出失败: %v，%v后重新连接", err, delay)
+			disconnect()
+		}
+	}
+}
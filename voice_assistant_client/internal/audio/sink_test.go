@@ -0,0 +1,96 @@
+package audio
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	vaaudio "voice_assistant/pkg/audio"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewNetworkSink(t *testing.T) {
+	sink, err := newNetworkSink("tcp://snapserver:4953")
+	require.NoError(t, err)
+	assert.Equal(t, dialSink{network: "tcp", addr: "snapserver:4953"}, sink)
+
+	sink, err = newNetworkSink("pipe:///tmp/snapfifo")
+	require.NoError(t, err)
+	assert.Equal(t, pipeSink{path: "/tmp/snapfifo"}, sink)
+
+	sink, err = newNetworkSink("exec:raop_play 192.168.1.30 -")
+	require.NoError(t, err)
+	assert.Equal(t, execSink{args: []string{"raop_play", "192.168.1.30", "-"}}, sink)
+
+	for _, raw := range []string{"", "exec:", "udp://", "http://speaker/play", "pipe://"} {
+		_, err := newNetworkSink(raw)
+		assert.Error(t, err, raw)
+	}
+}
+
+func TestSinkFrames(t *testing.T) {
+	// 单声道复制到两个声道
+	data := sinkFrames([]float32{0.5, -0.5}, 16000, 16000, 2)
+	assert.Equal(t, vaaudio.Int16ToBytes([]int16{16383, 16383, -16383, -16383}), data)
+
+	// 重采样到目标采样率
+	assert.Len(t, sinkFrames(make([]float32, 160), 16000, 48000, 2), 480*2*2)
+}
+
+func TestNetworkSinkPlayback(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	ao, err := NewAudioOutput(OutputConfig{
+		Sink:       SinkNetwork,
+		Network:    NetworkSinkConfig{URL: "tcp://" + listener.Addr().String(), SampleRate: 16000, Channels: 2},
+		SampleRate: 16000,
+		Channels:   2,
+		BufferSize: 160,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, ao.config.Channels)
+	assert.Equal(t, "tcp://"+listener.Addr().String(), ao.DeviceName())
+	assert.ErrorIs(t, ao.SwitchDevice(0), errNetworkOutput)
+
+	finished := make(chan struct{}, 1)
+	ao.SetPlaybackHandler(func(event PlaybackEvent) {
+		if event.Finished {
+			select {
+			case finished <- struct{}{}:
+			default:
+			}
+		}
+	})
+
+	require.NoError(t, ao.Start(context.Background()))
+	defer ao.Stop()
+
+	samples := make([]float32, 320)
+	for i := range samples {
+		samples[i] = 0.5
+	}
+	require.NoError(t, ao.Play(samples))
+
+	conn, err := listener.Accept()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// 两帧单声道采样以双声道16位发送
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	data := make([]byte, 320*2*2)
+	_, err = io.ReadFull(conn, data)
+	require.NoError(t, err)
+	assert.Equal(t, vaaudio.Int16ToBytes([]int16{16383, 16383}), data[:4])
+
+	select {
+	case <-finished:
+	case <-time.After(5 * time.Second):
+		t.Fatal("未收到播放完毕事件")
+	}
+}
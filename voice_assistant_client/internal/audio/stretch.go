@@ -0,0 +1,194 @@
+package audio
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// 播放速度范围
+const (
+	MinPlaybackSpeed = 0.5
+	MaxPlaybackSpeed = 2.0
+)
+
+// WSOLA参数：每次拼接的片段长度、寻找最佳拼接位置的范围、片段之间交叉淡化的长度
+const (
+	stretchSequence = 40 * time.Millisecond
+	stretchSeek     = 15 * time.Millisecond
+	stretchOverlap  = 8 * time.Millisecond
+)
+
+// ValidatePlaybackSpeed 检查播放速度是否在支持范围内
+func ValidatePlaybackSpeed(speed float64) error {
+	if speed < MinPlaybackSpeed || speed > MaxPlaybackSpeed {
+		return fmt.Errorf("播放速度无效: %v（应在%v到%v之间）", speed, MinPlaybackSpeed, MaxPlaybackSpeed)
+	}
+	return nil
+}
+
+// timeStretcher WSOLA变速不变调
+// 从输入中按速度等间隔取片段，在附近的搜索范围内选择与上一片段末尾最相似的位置拼接并交叉淡化，
+// 语音变快或变慢而音高不变。从source按需拉取输入，source返回不足时视为本段音频结束，输出剩余部分。
+// 速度为1且没有待输出数据时直接透传。非并发安全，由调用方加锁。
+type timeStretcher struct {
+	source   func(out []float32) int
+	channels int
+	speed    float64
+
+	sequence, seek, overlap int // 帧数
+
+	in   []float32 // 待处理的输入（交错采样）
+	out  []float32 // 已生成待输出的采样
+	tail []float32 // 上一片段末尾待交叉淡化的部分
+	skip float64   // 输入位置累计的小数帧
+}
+
+// newTimeStretcher 按输出格式创建变速器
+func newTimeStretcher(config OutputConfig, source func(out []float32) int) *timeStretcher {
+	channels := config.Channels
+	if channels <= 0 {
+		channels = 1
+	}
+	frames := func(d time.Duration) int {
+		return int(d.Seconds() * float64(config.SampleRate))
+	}
+	speed := config.Speed
+	if speed == 0 {
+		speed = 1
+	}
+	return &timeStretcher{
+		source:   source,
+		channels: channels,
+		speed:    speed,
+		sequence: frames(stretchSequence),
+		seek:     frames(stretchSeek),
+		overlap:  max(frames(stretchOverlap), 1),
+	}
+}
+
+// read 填充输出缓冲区，返回写入的音频采样数（其余部分为静音）
+func (s *timeStretcher) read(out []float32) int {
+	if s.speed == 1 && s.empty() {
+		return s.source(out)
+	}
+
+	for len(s.out) < len(out) {
+		if !s.fill() {
+			s.flush()
+			break
+		}
+		s.process()
+	}
+
+	n := copy(out, s.out)
+	s.out = s.out[:copy(s.out, s.out[n:])]
+	silence(out[n:])
+	return n
+}
+
+// empty 是否没有待处理或待输出的数据
+func (s *timeStretcher) empty() bool {
+	return len(s.in) == 0 && len(s.out) == 0 && len(s.tail) == 0
+}
+
+// reset 丢弃全部数据
+func (s *timeStretcher) reset() {
+	s.in = s.in[:0]
+	s.out = s.out[:0]
+	s.tail = s.tail[:0]
+	s.skip = 0
+}
+
+// needFrames 处理一个片段需要的输入帧数
+func (s *timeStretcher) needFrames() int {
+	need := s.seek + s.sequence
+	if advance := int(float64(s.sequence-s.overlap)*s.speed) + 1; advance > need {
+		need = advance
+	}
+	return need
+}
+
+// fill 从source补足一个片段所需的输入，返回是否足够
+func (s *timeStretcher) fill() bool {
+	need := s.needFrames() * s.channels
+	if have := len(s.in); have < need {
+		if cap(s.in) < need {
+			s.in = append(make([]float32, 0, need), s.in...)
+		}
+		s.in = s.in[:need]
+		s.in = s.in[:have+s.source(s.in[have:])]
+	}
+	return len(s.in) >= need
+}
+
+// process 在搜索范围内找到最佳拼接位置，输出一个片段，输入按速度前进
+func (s *timeStretcher) process() {
+	ch := s.channels
+	offset := 0
+	if len(s.tail) > 0 {
+		offset = s.bestOffset()
+	}
+	segment := s.in[offset*ch : (offset+s.sequence)*ch]
+	overlap := s.overlap * ch
+
+	s.crossfade(segment[:overlap])
+	s.out = append(s.out, segment[overlap:len(segment)-overlap]...)
+	s.tail = append(s.tail[:0], segment[len(segment)-overlap:]...)
+
+	// 输出 sequence-overlap 帧，输入前进 (sequence-overlap)*speed 帧
+	s.skip += float64(s.sequence-s.overlap) * s.speed
+	advance := int(s.skip)
+	s.skip -= float64(advance)
+	s.in = s.in[:copy(s.in, s.in[advance*ch:])]
+}
+
+// bestOffset 搜索范围内与上一片段末尾相关性最高的位置（帧）
+func (s *timeStretcher) bestOffset() int {
+	best, bestScore := 0, math.Inf(-1)
+	for offset := 0; offset < s.seek; offset++ {
+		candidate := s.in[offset*s.channels : offset*s.channels+len(s.tail)]
+		var corr, energy float64
+		for i, v := range s.tail {
+			corr += float64(v * candidate[i])
+			energy += float64(candidate[i] * candidate[i])
+		}
+		if score := corr / math.Sqrt(energy+1e-9); score > bestScore {
+			best, bestScore = offset, score
+		}
+	}
+	return best
+}
+
+// crossfade 上一片段末尾淡出、新片段开头淡入后输出（没有上一片段时直接输出）
+func (s *timeStretcher) crossfade(head []float32) {
+	if len(s.tail) == 0 {
+		s.out = append(s.out, head...)
+		return
+	}
+	frames := len(head) / s.channels
+	for f := 0; f < frames; f++ {
+		w := float32(f+1) / float32(frames+1)
+		for c := 0; c < s.channels; c++ {
+			i := f*s.channels + c
+			s.out = append(s.out, s.tail[i]*(1-w)+head[i]*w)
+		}
+	}
+}
+
+// flush 本段音频结束：不足一个片段的剩余输入按原速接在最后输出
+func (s *timeStretcher) flush() {
+	if len(s.tail) > 0 {
+		if len(s.in) >= len(s.tail) {
+			head := s.in[:len(s.tail)]
+			s.crossfade(head)
+			s.in = s.in[len(head):]
+		} else {
+			s.out = append(s.out, s.tail...)
+		}
+	}
+	s.out = append(s.out, s.in...)
+	s.in = s.in[:0]
+	s.tail = s.tail[:0]
+	s.skip = 0
+}
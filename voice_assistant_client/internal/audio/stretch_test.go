@@ -0,0 +1,60 @@
+package audio
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// sliceSource 从切片按需读取的输入
+func sliceSource(samples []float32) func(out []float32) int {
+	return func(out []float32) int {
+		n := copy(out, samples)
+		samples = samples[n:]
+		return n
+	}
+}
+
+// zeroCrossings 过零次数（用于估计音高）
+func zeroCrossings(samples []float32) int {
+	count := 0
+	for i := 1; i < len(samples); i++ {
+		if (samples[i-1] < 0) != (samples[i] < 0) {
+			count++
+		}
+	}
+	return count
+}
+
+// readAll 读取变速器的全部输出
+func readAll(s *timeStretcher) []float32 {
+	var result []float32
+	buf := make([]float32, 256)
+	for {
+		n := s.read(buf)
+		if n == 0 {
+			return result
+		}
+		result = append(result, buf[:n]...)
+	}
+}
+
+func TestTimeStretcherSpeed(t *testing.T) {
+	const rate = 16000
+	input := make([]float32, rate)
+	for i := range input {
+		input[i] = float32(math.Sin(2 * math.Pi * 440 * float64(i) / rate))
+	}
+
+	// 原速直接透传
+	s := newTimeStretcher(OutputConfig{SampleRate: rate, Channels: 1}, sliceSource(input))
+	assert.Equal(t, input, readAll(s))
+
+	// 1.5倍速：时长缩短为2/3，音高（单位时间的过零次数）不变
+	s = newTimeStretcher(OutputConfig{SampleRate: rate, Channels: 1, Speed: 1.5}, sliceSource(input))
+	output := readAll(s)
+	assert.InDelta(t, float64(len(input))/1.5, float64(len(output)), rate*0.05)
+	assert.InDelta(t, float64(zeroCrossings(input))/float64(len(input)), float64(zeroCrossings(output))/float64(len(output)), 0.003)
+	assert.True(t, s.empty())
+}
@@ -90,22 +90,9 @@ func (v *VADDetector) Detect(audioData []float32) bool {
 	return v.isInSpeech
 }
 
-// calculateEnergy 计算音频能量
+// calculateEnergy 计算音频能量（RMS电平，dBFS）
 func (v *VADDetector) calculateEnergy(audioData []float32) float64 {
-	var sum float64
-	for _, sample := range audioData {
-		sum += float64(sample) * float64(sample)
-	}
-
-	// 计算RMS（均方根）
-	rms := math.Sqrt(sum / float64(len(audioData)))
-
-	// 转换为dB
-	if rms > 0 {
-		return 20 * math.Log10(rms)
-	}
-
-	return -100.0 // 静音时的dB值
+	return FrameLevel(audioData)
 }
 
 // updateEnergyHistory 更新能量历史
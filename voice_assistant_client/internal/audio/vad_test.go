@@ -0,0 +1,33 @@
+package audio
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVoiceActivityEvents(t *testing.T) {
+	ai := &AudioInput{
+		config:        InputConfig{VADEnabled: true, VADThreshold: -40},
+		audioChan:     make(chan []float32, 10),
+		voiceActivity: make(chan bool, 8),
+		vadDetector:   NewVADDetector(-40, 0, 0),
+		isRecording:   true,
+	}
+	loud := make([]float32, 160)
+	for i := range loud {
+		loud[i] = 0.5
+	}
+	silent := make([]float32, 160)
+
+	// 只在说话状态变化时通知，静音帧不发送
+	ai.audioCallback(loud)
+	ai.audioCallback(loud)
+	ai.audioCallback(silent)
+	ai.audioCallback(silent)
+	require.Len(t, ai.voiceActivity, 2)
+	assert.True(t, <-ai.voiceActivity)
+	assert.False(t, <-ai.voiceActivity)
+	assert.Len(t, ai.audioChan, 2)
+}
@@ -0,0 +1,169 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 认证方式
+const (
+	AuthTypeToken = "token" // Authorization: Bearer <token>
+	AuthTypeBasic = "basic" // Authorization: Basic <username:password>
+)
+
+// defaultRefreshBefore 令牌到期前多久刷新
+const defaultRefreshBefore = time.Minute
+
+// AuthConfig 连接握手的认证配置
+type AuthConfig struct {
+	Enabled  bool   `yaml:"enabled"`
+	Type     string `yaml:"type"` // token|basic
+	Token    string `yaml:"token"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+
+	// 令牌刷新（token方式）：令牌到期前RefreshBefore向RefreshURL换取新令牌，握手返回401时也会刷新后重试。
+	// 请求为 POST {"refresh_token"}（未配置RefreshToken时以当前令牌作为Bearer），响应为 {"access_token","refresh_token","expires_in"}。
+	RefreshURL    string        `yaml:"refresh_url"`
+	RefreshToken  string        `yaml:"refresh_token"`
+	RefreshBefore time.Duration `yaml:"refresh_before"`
+}
+
+// authenticator 生成握手的认证请求头，按需刷新令牌（并发安全）
+type authenticator struct {
+	config AuthConfig
+	client *http.Client
+
+	mu           sync.Mutex
+	token        string
+	refreshToken string
+	expiresAt    time.Time // 令牌到期时间（未知时为零值）
+}
+
+// newAuthenticator 创建认证（未启用时返回nil）
+func newAuthenticator(config AuthConfig) *authenticator {
+	if !config.Enabled {
+		return nil
+	}
+	if config.RefreshBefore <= 0 {
+		config.RefreshBefore = defaultRefreshBefore
+	}
+	return &authenticator{
+		config:       config,
+		client:       &http.Client{Timeout: 10 * time.Second},
+		token:        config.Token,
+		refreshToken: config.RefreshToken,
+		expiresAt:    tokenExpiry(config.Token),
+	}
+}
+
+// header 握手请求头，令牌即将到期时先刷新
+func (a *authenticator) header(ctx context.Context) (http.Header, error) {
+	header := http.Header{}
+	if a.config.Type == AuthTypeBasic {
+		credentials := base64.StdEncoding.EncodeToString([]byte(a.config.Username + ":" + a.config.Password))
+		header.Set("Authorization", "Basic "+credentials)
+		return header, nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.config.RefreshURL != "" && (a.token == "" || !a.expiresAt.IsZero() && time.Until(a.expiresAt) < a.config.RefreshBefore) {
+		if err := a.refreshLocked(ctx); err != nil {
+			if a.token == "" || time.Now().After(a.expiresAt) {
+				return nil, err
+			}
+			// 令牌尚未过期时继续使用，下次连接再刷新
+		}
+	}
+	if a.token != "" {
+		header.Set("Authorization", "Bearer "+a.token)
+	}
+	return header, nil
+}
+
+// reauthenticate 握手被拒绝（401）后刷新令牌，返回是否值得重试
+func (a *authenticator) reauthenticate(ctx context.Context) bool {
+	if a.config.Type == AuthTypeBasic || a.config.RefreshURL == "" {
+		return false
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.refreshLocked(ctx) == nil
+}
+
+// refreshLocked 向刷新接口换取新令牌（调用方持有 mu）
+func (a *authenticator) refreshLocked(ctx context.Context) error {
+	body, err := json.Marshal(map[string]string{"refresh_token": a.refreshToken})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.config.RefreshURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("创建令牌刷新请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if a.refreshToken == "" && a.token != "" {
+		req.Header.Set("Authorization", "Bearer "+a.token)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("刷新令牌失败: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("刷新令牌失败: HTTP %d: %s", resp.StatusCode, bytes.TrimSpace(message))
+	}
+
+	var result struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int64  `json:"expires_in"` // 秒
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("解析令牌刷新结果失败: %w", err)
+	}
+	if result.AccessToken == "" {
+		return fmt.Errorf("令牌刷新结果缺少 access_token")
+	}
+
+	a.token = result.AccessToken
+	if result.RefreshToken != "" {
+		a.refreshToken = result.RefreshToken
+	}
+	if result.ExpiresIn > 0 {
+		a.expiresAt = time.Now().Add(time.Duration(result.ExpiresIn) * time.Second)
+	} else {
+		a.expiresAt = tokenExpiry(a.token)
+	}
+	return nil
+}
+
+// tokenExpiry 读取JWT令牌的exp声明（不校验签名），不是JWT时返回零值
+func tokenExpiry(token string) time.Time {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}
+	}
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == 0 {
+		return time.Time{}
+	}
+	return time.Unix(claims.Exp, 0)
+}
@@ -0,0 +1,112 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenExpiry(t *testing.T) {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"exp":1700000000}`))
+	assert.Equal(t, time.Unix(1700000000, 0), tokenExpiry("header."+payload+".signature"))
+	assert.True(t, tokenExpiry("plain-token").IsZero())
+}
+
+func TestWebSocketClientAuth(t *testing.T) {
+	var mu sync.Mutex
+	valid := "token-1"
+	var refreshes int
+	var seen []string
+
+	upgrader := websocket.Upgrader{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/refresh", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			RefreshToken string `json:"refresh_token"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		defer mu.Unlock()
+		if req.RefreshToken != "refresh-secret" {
+			http.Error(w, "bad refresh token", http.StatusUnauthorized)
+			return
+		}
+		refreshes++
+		valid = fmt.Sprintf("token-%d", refreshes+1)
+		json.NewEncoder(w).Encode(map[string]interface{}{"access_token": valid, "expires_in": 3600})
+	})
+	mux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		auth := r.Header.Get("Authorization")
+		seen = append(seen, auth)
+		ok := auth == "Bearer "+valid
+		mu.Unlock()
+		if !ok {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c := NewWebSocketClient(ClientConfig{
+		ServerURL:            "ws" + strings.TrimPrefix(server.URL, "http") + "/ws",
+		ReconnectInterval:    10 * time.Millisecond,
+		MaxReconnectAttempts: 1,
+		ConnectionTimeout:    time.Second,
+		PingInterval:         time.Second,
+		PongTimeout:          5 * time.Second,
+		Auth: AuthConfig{
+			Enabled:      true,
+			Type:         AuthTypeToken,
+			Token:        "token-1",
+			RefreshURL:   server.URL + "/refresh",
+			RefreshToken: "refresh-secret",
+		},
+	})
+
+	require.NoError(t, c.Connect(context.Background()))
+	require.NoError(t, c.Disconnect())
+
+	// 服务端吊销令牌后，下次连接收到401时刷新令牌并重试
+	mu.Lock()
+	valid = "revoked"
+	refreshes = 0
+	mu.Unlock()
+	require.NoError(t, c.Connect(context.Background()))
+	require.NoError(t, c.Disconnect())
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 1, refreshes)
+	assert.Equal(t, []string{"Bearer token-1", "Bearer token-1", "Bearer token-2"}, seen)
+}
+
+func TestAuthenticatorBasic(t *testing.T) {
+	a := newAuthenticator(AuthConfig{Enabled: true, Type: AuthTypeBasic, Username: "user", Password: "pass"})
+	header, err := a.header(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "Basic dXNlcjpwYXNz", header.Get("Authorization"))
+	assert.Nil(t, newAuthenticator(AuthConfig{}))
+}
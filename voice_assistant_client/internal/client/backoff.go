@@ -0,0 +1,54 @@
+package client
+
+import (
+	"math/rand"
+	"time"
+)
+
+// 重连退避的默认值
+const (
+	defaultReconnectMultiplier = 2.0
+	defaultReconnectJitter     = 0.5
+)
+
+// backoff 重连等待时间：从初始间隔起按倍数指数增长，不超过上限，并按抖动比例随机缩短，
+// 避免服务端重启后大量客户端同时重连
+type backoff struct {
+	initial    time.Duration
+	max        time.Duration
+	multiplier float64
+	jitter     float64 // 0-1，等待时间在 [d*(1-jitter), d] 内随机
+	random     func() float64
+}
+
+// newBackoff 创建重连退避（multiplier小于1时使用默认值；max为0时不设上限）
+func newBackoff(initial, max time.Duration, multiplier, jitter float64) backoff {
+	if multiplier < 1 {
+		multiplier = defaultReconnectMultiplier
+	}
+	if jitter < 0 {
+		jitter = 0
+	}
+	if jitter > 1 {
+		jitter = 1
+	}
+	return backoff{initial: initial, max: max, multiplier: multiplier, jitter: jitter, random: rand.Float64}
+}
+
+// delay 第attempt次重连（从0开始）前的等待时间
+func (b backoff) delay(attempt int) time.Duration {
+	d := float64(b.initial)
+	for i := 0; i < attempt; i++ {
+		d *= b.multiplier
+		if b.max > 0 && d >= float64(b.max) {
+			break
+		}
+	}
+	if b.max > 0 && d > float64(b.max) {
+		d = float64(b.max)
+	}
+	if b.jitter > 0 {
+		d -= d * b.jitter * b.random()
+	}
+	return time.Duration(d)
+}
@@ -0,0 +1,86 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	b := newBackoff(time.Second, 10*time.Second, 2, 0)
+	assert.Equal(t, time.Second, b.delay(0))
+	assert.Equal(t, 2*time.Second, b.delay(1))
+	assert.Equal(t, 8*time.Second, b.delay(3))
+	assert.Equal(t, 10*time.Second, b.delay(4), "不超过上限")
+	assert.Equal(t, 10*time.Second, b.delay(100))
+
+	// 抖动在 [d*(1-jitter), d] 内
+	b = newBackoff(time.Second, 10*time.Second, 2, 0.5)
+	b.random = func() float64 { return 1 }
+	assert.Equal(t, 2*time.Second, b.delay(2))
+	b.random = func() float64 { return 0 }
+	assert.Equal(t, 4*time.Second, b.delay(2))
+}
+
+func TestReconnectGiveUpAfterMaxElapsed(t *testing.T) {
+	// 服务端在stop关闭后断开连接并停止服务
+	stop := make(chan struct{})
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		<-stop
+		conn.Close()
+	}))
+	defer server.Close()
+	c := NewWebSocketClient(ClientConfig{
+		ServerURL:            "ws" + strings.TrimPrefix(server.URL, "http"),
+		ReconnectInterval:    20 * time.Millisecond,
+		MaxReconnectAttempts: 100,
+		ReconnectMaxInterval: 40 * time.Millisecond,
+		ReconnectMaxElapsed:  150 * time.Millisecond,
+		ConnectionTimeout:    time.Second,
+		PingInterval:         time.Second,
+		PongTimeout:          5 * time.Second,
+	})
+
+	statuses := make(chan ConnectionStatus, 100)
+	c.SetStatusHandler(func(status ConnectionStatus) {
+		select {
+		case statuses <- status:
+		default:
+		}
+	})
+	require.NoError(t, c.Connect(context.Background()))
+	server.Listener.Close()
+	close(stop)
+
+	var sawRetry bool
+	timeout := time.After(3 * time.Second)
+	for {
+		select {
+		case status := <-statuses:
+			if status.RetryIn > 0 {
+				sawRetry = true
+			}
+			if status.State != StateDisconnected {
+				continue
+			}
+			assert.True(t, sawRetry)
+			assert.Contains(t, status.GiveUpReason, "断线超过")
+			assert.Less(t, c.GetStats().ReconnectCount, 100)
+			return
+		case <-timeout:
+			t.Fatal("未放弃重连")
+		}
+	}
+}
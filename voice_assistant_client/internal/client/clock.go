@@ -0,0 +1,86 @@
+package client
+
+import (
+	"sync"
+	"time"
+)
+
+// 时钟同步参数
+const (
+	clockSyncBurst         = 5                      // 连接建立后连续采样次数
+	clockSyncBurstInterval = 200 * time.Millisecond // 连续采样间隔
+	clockSyncInterval      = 30 * time.Second       // 常规采样间隔
+	clockSyncMaxSamples    = 8                      // 保留的样本数量
+)
+
+// clockSample 单次时钟同步样本（毫秒）
+type clockSample struct {
+	offset int64 // 服务端时钟减客户端时钟
+	rtt    int64 // 往返网络延迟（不含服务端处理时间）
+}
+
+// ClockSync 客户端与服务端时钟偏差估计
+// 采用NTP式四时间戳估算偏差，并在最近若干样本中取往返延迟最小的一个：
+// 往返延迟越小，上下行不对称带来的误差上限越小。
+type ClockSync struct {
+	mu         sync.RWMutex
+	samples    []clockSample
+	maxSamples int
+}
+
+// NewClockSync 创建时钟偏差估计器
+func NewClockSync(maxSamples int) *ClockSync {
+	if maxSamples <= 0 {
+		maxSamples = clockSyncMaxSamples
+	}
+	return &ClockSync{
+		samples:    make([]clockSample, 0, maxSamples),
+		maxSamples: maxSamples,
+	}
+}
+
+// AddSample 添加同步样本
+// clientSend/clientReceive 为客户端时钟，serverReceive/serverSend 为服务端时钟。
+func (cs *ClockSync) AddSample(clientSend, serverReceive, serverSend, clientReceive int64) {
+	rtt := (clientReceive - clientSend) - (serverSend - serverReceive)
+	if rtt < 0 || clientSend <= 0 || serverReceive <= 0 || serverSend <= 0 {
+		return
+	}
+
+	sample := clockSample{
+		offset: ((serverReceive - clientSend) + (serverSend - clientReceive)) / 2,
+		rtt:    rtt,
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	cs.samples = append(cs.samples, sample)
+	if len(cs.samples) > cs.maxSamples {
+		cs.samples = cs.samples[len(cs.samples)-cs.maxSamples:]
+	}
+}
+
+// Offset 获取当前时钟偏差（服务端减客户端）和对应样本的往返延迟
+func (cs *ClockSync) Offset() (offset, rtt time.Duration, ok bool) {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+
+	if len(cs.samples) == 0 {
+		return 0, 0, false
+	}
+
+	best := cs.samples[0]
+	for _, sample := range cs.samples[1:] {
+		if sample.rtt < best.rtt {
+			best = sample
+		}
+	}
+	return time.Duration(best.offset) * time.Millisecond, time.Duration(best.rtt) * time.Millisecond, true
+}
+
+// ServerToLocal 将服务端时间（毫秒）换算为本地时间，尚未同步时按零偏差处理
+func (cs *ClockSync) ServerToLocal(serverMs int64) time.Time {
+	offset, _, _ := cs.Offset()
+	return time.UnixMilli(serverMs).Add(-offset)
+}
@@ -0,0 +1,45 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClockSyncPrefersLowestRTT(t *testing.T) {
+	cs := NewClockSync(4)
+
+	_, _, ok := cs.Offset()
+	assert.False(t, ok)
+
+	// 服务端快500ms，对称延迟20ms，服务端处理5ms
+	cs.AddSample(1000, 1520, 1525, 1045)
+	// 上行拥塞导致的不对称样本，往返延迟更大
+	cs.AddSample(2000, 2700, 2705, 2225)
+
+	offset, rtt, ok := cs.Offset()
+	require.True(t, ok)
+	assert.Equal(t, 500*time.Millisecond, offset)
+	assert.Equal(t, 40*time.Millisecond, rtt)
+
+	assert.Equal(t, time.UnixMilli(9500), cs.ServerToLocal(10000))
+}
+
+func TestClockSyncDiscardsInvalidAndOldSamples(t *testing.T) {
+	cs := NewClockSync(2)
+
+	// 往返延迟为负（时间戳错乱）的样本被丢弃
+	cs.AddSample(1000, 1500, 1600, 1050)
+	_, _, ok := cs.Offset()
+	assert.False(t, ok)
+
+	cs.AddSample(1000, 1100, 1100, 1010) // 偏差95ms，往返10ms
+	cs.AddSample(2000, 2300, 2300, 2100) // 偏差250ms，往返100ms
+	cs.AddSample(3000, 3300, 3300, 3100) // 第一个样本被挤出
+
+	offset, _, ok := cs.Offset()
+	require.True(t, ok)
+	assert.Equal(t, 250*time.Millisecond, offset)
+}
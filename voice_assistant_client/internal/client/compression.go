@@ -0,0 +1,36 @@
+package client
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/websocket"
+)
+
+// 压缩默认值：音频块等大消息才值得压缩，小消息压缩的开销大于收益
+const defaultCompressionThreshold = 1024
+
+// compressionNegotiated 服务端握手响应是否同意了permessage-deflate
+func compressionNegotiated(resp *http.Response) bool {
+	if resp == nil {
+		return false
+	}
+	for _, ext := range resp.Header.Values("Sec-WebSocket-Extensions") {
+		if strings.Contains(ext, "permessage-deflate") {
+			return true
+		}
+	}
+	return false
+}
+
+// writeMessage 写出文本消息，超过阈值的消息按permessage-deflate压缩（未协商时压缩设置不生效）
+func (c *WebSocketClient) writeMessage(conn *websocket.Conn, data []byte) error {
+	if c.compression {
+		threshold := c.compressionThreshold
+		if threshold <= 0 {
+			threshold = defaultCompressionThreshold
+		}
+		conn.EnableWriteCompression(len(data) >= threshold)
+	}
+	return conn.WriteMessage(websocket.TextMessage, data)
+}
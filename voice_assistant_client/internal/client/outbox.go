@@ -0,0 +1,79 @@
+package client
+
+import (
+	"voice_assistant/pkg/protocol"
+)
+
+// 离线缓冲策略
+const (
+	OfflinePolicyDropOldest = "drop_oldest" // 缓冲区满时丢弃最早的消息（保留话语结尾）
+	OfflinePolicyDropNewest = "drop_newest" // 缓冲区满时拒绝新消息
+)
+
+// outbox 断线期间待发送的消息（有界），重连后按原顺序发出
+// 非并发安全，由WebSocketClient的锁保护。
+type outbox struct {
+	limit    int
+	policy   string
+	messages []*protocol.Message
+	requeued int // 队首来自发送队列的消息数（早于断线后新产生的消息）
+	dropped  int // 累计丢弃的消息数
+}
+
+// newOutbox 创建离线缓冲
+func newOutbox(limit int, policy string) *outbox {
+	if policy != OfflinePolicyDropNewest {
+		policy = OfflinePolicyDropOldest
+	}
+	return &outbox{limit: limit, policy: policy}
+}
+
+// push 追加消息，返回消息是否被接受
+func (o *outbox) push(msg *protocol.Message) bool {
+	if len(o.messages) >= o.limit {
+		if o.policy == OfflinePolicyDropNewest {
+			o.dropped++
+			return false
+		}
+		o.dropOldest()
+	}
+	o.messages = append(o.messages, msg)
+	return true
+}
+
+// requeue 放回已离开发送队列但未能写出的消息
+// 这些消息早于断线后产生的消息，按取出顺序排在缓冲区前部。
+func (o *outbox) requeue(msg *protocol.Message) {
+	if len(o.messages) >= o.limit {
+		o.dropOldest()
+	}
+	o.messages = append(o.messages, nil)
+	copy(o.messages[o.requeued+1:], o.messages[o.requeued:])
+	o.messages[o.requeued] = msg
+	o.requeued++
+}
+
+// pop 取出最早的消息
+func (o *outbox) pop() (*protocol.Message, bool) {
+	if len(o.messages) == 0 {
+		return nil, false
+	}
+	msg := o.messages[0]
+	o.messages[0] = nil
+	o.messages = o.messages[1:]
+	if o.requeued > 0 {
+		o.requeued--
+	}
+	return msg, true
+}
+
+// dropOldest 丢弃最早的消息
+func (o *outbox) dropOldest() {
+	o.pop()
+	o.dropped++
+}
+
+// len 缓冲的消息数
+func (o *outbox) len() int {
+	return len(o.messages)
+}
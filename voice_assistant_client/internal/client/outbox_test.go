@@ -0,0 +1,66 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"voice_assistant/pkg/protocol"
+)
+
+// outboxIDs 按顺序取出缓冲区中所有消息的会话ID
+func outboxIDs(o *outbox) []string {
+	var ids []string
+	for {
+		msg, ok := o.pop()
+		if !ok {
+			return ids
+		}
+		ids = append(ids, msg.SessionID)
+	}
+}
+
+func TestOutboxDropOldest(t *testing.T) {
+	o := newOutbox(3, OfflinePolicyDropOldest)
+	for _, id := range []string{"1", "2", "3", "4"} {
+		assert.True(t, o.push(protocol.NewMessage(protocol.AudioStream, id, nil)))
+	}
+	assert.Equal(t, 1, o.dropped)
+	assert.Equal(t, []string{"2", "3", "4"}, outboxIDs(o))
+}
+
+func TestOutboxDropNewest(t *testing.T) {
+	o := newOutbox(2, OfflinePolicyDropNewest)
+	assert.True(t, o.push(protocol.NewMessage(protocol.AudioStream, "1", nil)))
+	assert.True(t, o.push(protocol.NewMessage(protocol.AudioStream, "2", nil)))
+	assert.False(t, o.push(protocol.NewMessage(protocol.AudioStream, "3", nil)))
+	assert.Equal(t, 1, o.dropped)
+	assert.Equal(t, []string{"1", "2"}, outboxIDs(o))
+}
+
+func TestOutboxRequeueKeepsOrder(t *testing.T) {
+	o := newOutbox(10, OfflinePolicyDropOldest)
+
+	// 断线后产生的消息先进入缓冲，随后写循环放回发送队列中更早的消息
+	o.push(protocol.NewMessage(protocol.AudioStream, "c", nil))
+	o.requeue(protocol.NewMessage(protocol.AudioStream, "a", nil))
+	o.requeue(protocol.NewMessage(protocol.AudioStream, "b", nil))
+	o.push(protocol.NewMessage(protocol.AudioStream, "d", nil))
+
+	require.Equal(t, 4, o.len())
+	assert.Equal(t, []string{"a", "b", "c", "d"}, outboxIDs(o))
+	assert.Equal(t, 0, o.requeued)
+}
+
+func TestQueueDepths(t *testing.T) {
+	c := NewWebSocketClient(ClientConfig{OfflineBufferSize: 10})
+	c.sendChan <- protocol.NewMessage(protocol.AudioStream, "1", nil)
+
+	// 重连期间发送的消息进入离线缓冲
+	c.reconnecting = true
+	require.NoError(t, c.SendCommand(protocol.CmdGetStatus, "", nil))
+	require.NoError(t, c.SendCommand(protocol.CmdGetStatus, "", nil))
+
+	assert.Equal(t, QueueDepths{Send: 1, Offline: 2}, c.QueueDepths())
+}
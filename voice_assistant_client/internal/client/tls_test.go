@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebSocketClientTLS(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer server.Close()
+
+	// 测试服务端的自签名证书作为CA
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	require.NoError(t, os.WriteFile(caFile, caPEM, 0o600))
+
+	newClient := func(tlsConfig TLSConfig) *WebSocketClient {
+		return NewWebSocketClient(ClientConfig{
+			ServerURL:            "wss" + strings.TrimPrefix(server.URL, "https"),
+			ReconnectInterval:    10 * time.Millisecond,
+			MaxReconnectAttempts: 1,
+			ConnectionTimeout:    time.Second,
+			PingInterval:         time.Second,
+			PongTimeout:          5 * time.Second,
+			TLS:                  tlsConfig,
+		})
+	}
+
+	// 未信任自签名证书时握手失败
+	assert.Error(t, newClient(TLSConfig{}).Connect(context.Background()))
+
+	c := newClient(TLSConfig{Enabled: true, CAFile: caFile})
+	require.NoError(t, c.Connect(context.Background()))
+	assert.Equal(t, StateConnected, c.State())
+	require.NoError(t, c.Disconnect())
+
+	// 证书配置无效时连接直接报错
+	err := newClient(TLSConfig{Enabled: true, CertFile: caFile}).Connect(context.Background())
+	assert.ErrorContains(t, err, "TLS配置无效")
+}
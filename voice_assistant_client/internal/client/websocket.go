@@ -2,47 +2,129 @@ package client
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"net/url"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	vaaudio "voice_assistant/pkg/audio"
 	"voice_assistant/pkg/protocol"
 
 	"github.com/gorilla/websocket"
 )
 
+// 发送相关错误定义
+var (
+	ErrNotConnected      = errors.New("未连接到服务器")
+	ErrOfflineBufferFull = errors.New("离线缓冲区已满")
+	ErrSendTimeout       = errors.New("发送队列超时")
+	ErrConnectCanceled   = errors.New("连接已取消")
+)
+
+// ConnectionState 连接状态
+// 状态转换：未连接 -Connect-> 连接中 -> 已连接 -断线-> 连接中（重连）-> 已连接；
+// 任意状态 -Disconnect-> 断开中 -> 未连接；重连次数用尽后回到未连接。未连接时可再次Connect。
+type ConnectionState int
+
+const (
+	StateDisconnected ConnectionState = iota // 未连接
+	StateConnecting                          // 正在连接或重连
+	StateConnected                           // 已连接
+	StateClosing                             // 正在断开
+)
+
+// String 状态名称
+func (s ConnectionState) String() string {
+	switch s {
+	case StateDisconnected:
+		return "未连接"
+	case StateConnecting:
+		return "连接中"
+	case StateConnected:
+		return "已连接"
+	case StateClosing:
+		return "断开中"
+	default:
+		return fmt.Sprintf("未知状态(%d)", int(s))
+	}
+}
+
 // WebSocketClient WebSocket客户端
 type WebSocketClient struct {
 	// 连接配置
 	serverURL            string
 	sessionID            string
-	reconnectInterval    time.Duration
+	joined               string // 加入的其他客户端的会话（为空时消息发往自己的会话）
+	reconnectBackoff     backoff
 	maxReconnectAttempts int
+	maxReconnectElapsed  time.Duration
 	connectionTimeout    time.Duration
 	pingInterval         time.Duration
 	pongTimeout          time.Duration
+	compression          bool
+	compressionThreshold int
+	tlsConfig            *tls.Config    // wss连接的TLS配置（未启用时为nil）
+	tlsErr               error          // 加载TLS证书的错误，连接时返回
+	auth                 *authenticator // 握手认证（未启用时为nil）
 
 	// 连接状态
-	conn        *websocket.Conn
-	isConnected bool
-	mu          sync.RWMutex
+	conn  *websocket.Conn
+	state ConnectionState
+	mu    sync.RWMutex
 
-	// 消息处理
+	// 消息处理（由 mu 保护，可在运行中注册）
 	messageHandlers map[protocol.MessageType]MessageHandler
 
 	// 通道
 	sendChan    chan *protocol.Message
 	receiveChan chan *protocol.Message
-	closeChan   chan struct{}
+
+	// 本次运行（Connect 到 Disconnect 或重连次数用尽）：关闭 closeChan 并取消 runCtx 结束后台协程，
+	// wg 跟踪这些协程，下次 Connect 前等待它们全部退出
+	closeChan chan struct{}
+	runCancel context.CancelFunc
+	wg        sync.WaitGroup
 
 	// 重连控制
 	reconnectCount  int
 	lastConnectTime time.Time
+	retryIn         time.Duration // 本次重连前的等待时间（等待中时有效）
+	giveUpReason    string        // 本次运行放弃重连的原因
+
+	// 时钟同步
+	clock *ClockSync
+
+	// 会话恢复
+	runCtx      context.Context    // 本次运行的上下文（重连沿用）
+	lastSeq     int64              // 最后收到的服务端消息序号
+	resumeToken string             // 服务端在连接确认中下发的会话恢复令牌
+	reconnected bool               // 当前连接由重连建立，等待服务端连接确认
+	onReconnect func(resumed bool) // 重连回调
+
+	// 连接状态通知
+	pingSentAt time.Time              // 最近一次未收到Pong的Ping发送时间
+	onStatus   func(ConnectionStatus) // 状态变化和测得新的往返时延时回调
+
+	// 服务端主动关闭：当前连接上收到的关闭通知和关闭码，决定断开后是否重连
+	closeNotice *protocol.CloseInfo
+	closeCode   int
+
+	// 离线缓冲（未启用时为nil）：断线重连期间的音频和命令暂存，重连后按序发出
+	outbox       *outbox
+	reconnecting bool // 正在重连
+	flushing     bool // 正在发出缓冲的消息，新消息排在缓冲之后
 
 	// 统计信息
 	stats ConnectionStats
+
+	// 调试：记录收发的消息内容（可在运行中切换）
+	dumpMessages atomic.Bool
 }
 
 // MessageHandler 消息处理器函数类型
@@ -57,6 +139,24 @@ type ConnectionStats struct {
 	ReconnectCount   int
 	BytesSent        int64
 	BytesReceived    int64
+	Latency          time.Duration // 最近一次Ping往返时延（当前连接尚未测得时为0）
+}
+
+// QueueDepths 消息队列深度快照
+type QueueDepths struct {
+	Send    int `json:"send"`    // 发送队列
+	Receive int `json:"receive"` // 接收队列
+	Offline int `json:"offline"` // 离线缓冲
+}
+
+// ConnectionStatus 连接状态快照
+type ConnectionStatus struct {
+	State                ConnectionState
+	Latency              time.Duration // 最近一次Ping往返时延（尚未测得时为0）
+	ReconnectAttempt     int           // 正在进行第几次重连（未在重连时为0）
+	MaxReconnectAttempts int
+	RetryIn              time.Duration // 距下次重连尝试的等待时间（重连等待中时设置）
+	GiveUpReason         string        // 放弃重连的原因（重连次数用尽、超过最长重连时间或服务端结束会话时设置）
 }
 
 // ClientConfig 客户端配置
@@ -68,6 +168,27 @@ type ClientConfig struct {
 	ConnectionTimeout    time.Duration `yaml:"connection_timeout"`
 	PingInterval         time.Duration `yaml:"ping_interval"`
 	PongTimeout          time.Duration `yaml:"pong_timeout"`
+
+	// 重连退避：等待时间从ReconnectInterval起按ReconnectMultiplier倍增长，不超过ReconnectMaxInterval，
+	// 并按ReconnectJitter比例随机缩短；断线超过ReconnectMaxElapsed（0表示不限）后放弃重连
+	ReconnectMaxInterval time.Duration `yaml:"reconnect_max_interval"`
+	ReconnectMultiplier  float64       `yaml:"reconnect_multiplier"`
+	ReconnectJitter      float64       `yaml:"reconnect_jitter"`
+	ReconnectMaxElapsed  time.Duration `yaml:"reconnect_max_elapsed"`
+
+	// 离线缓冲：断线重连期间最多暂存的消息数（0表示不缓冲，断线时发送直接报错）及缓冲区满时的策略
+	OfflineBufferSize   int    `yaml:"offline_buffer_size"`
+	OfflineBufferPolicy string `yaml:"offline_buffer_policy"` // drop_oldest|drop_newest
+
+	// 压缩：握手时请求permessage-deflate，服务端同意后只压缩不小于CompressionThreshold字节的消息
+	EnableCompression    bool `yaml:"enable_compression"`
+	CompressionThreshold int  `yaml:"compression_threshold"`
+
+	// TLS：连接wss地址时使用的CA、客户端证书（双向TLS）和证书验证设置
+	TLS TLSConfig `yaml:"tls"`
+
+	// 认证：握手时携带的Bearer令牌或Basic凭据，每次连接和重连时按需刷新令牌
+	Auth AuthConfig `yaml:"auth"`
 }
 
 // NewWebSocketClient 创建WebSocket客户端
@@ -76,141 +197,479 @@ func NewWebSocketClient(config ClientConfig) *WebSocketClient {
 		config.SessionID = generateSessionID()
 	}
 
-	return &WebSocketClient{
+	c := &WebSocketClient{
 		serverURL:            config.ServerURL,
 		sessionID:            config.SessionID,
-		reconnectInterval:    config.ReconnectInterval,
+		reconnectBackoff:     newBackoff(config.ReconnectInterval, config.ReconnectMaxInterval, config.ReconnectMultiplier, config.ReconnectJitter),
 		maxReconnectAttempts: config.MaxReconnectAttempts,
+		maxReconnectElapsed:  config.ReconnectMaxElapsed,
 		connectionTimeout:    config.ConnectionTimeout,
 		pingInterval:         config.PingInterval,
 		pongTimeout:          config.PongTimeout,
+		compression:          config.EnableCompression,
+		compressionThreshold: config.CompressionThreshold,
 
 		messageHandlers: make(map[protocol.MessageType]MessageHandler),
 		sendChan:        make(chan *protocol.Message, 100),
 		receiveChan:     make(chan *protocol.Message, 100),
-		closeChan:       make(chan struct{}),
+		clock:           NewClockSync(clockSyncMaxSamples),
+	}
+	if config.OfflineBufferSize > 0 {
+		c.outbox = newOutbox(config.OfflineBufferSize, config.OfflineBufferPolicy)
 	}
+	c.tlsConfig, c.tlsErr = newTLSConfig(config.TLS)
+	c.auth = newAuthenticator(config.Auth)
+	return c
 }
 
 // Connect 连接到服务器
+// ctx 控制本次运行的生命周期，断线重连沿用该上下文；Disconnect 或重连次数用尽后可再次调用。
 func (c *WebSocketClient) Connect(ctx context.Context) error {
 	c.mu.Lock()
-	if c.isConnected {
+	if c.state != StateDisconnected {
+		state := c.state
 		c.mu.Unlock()
-		return fmt.Errorf("已经连接到服务器")
+		return fmt.Errorf("无法连接服务器，当前状态: %s", state)
 	}
+	c.state = StateConnecting
 	c.mu.Unlock()
+	c.notifyStatus()
 
+	// 等待上次运行的协程全部退出（重连次数用尽后协程可能仍在退出中）
+	c.wg.Wait()
+
+	runCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	c.mu.Lock()
+	if c.state != StateConnecting {
+		// 等待期间调用了 Disconnect
+		c.mu.Unlock()
+		cancel()
+		return ErrConnectCanceled
+	}
+	c.runCtx, c.runCancel, c.closeChan = runCtx, cancel, done
+	c.reconnectCount = 0
+	c.giveUpReason = ""
+	c.mu.Unlock()
+
+	conn, err := c.dial(runCtx)
+	if err != nil {
+		c.mu.Lock()
+		if c.state == StateConnecting {
+			c.state = StateDisconnected
+			c.stopRunLocked()
+		}
+		c.mu.Unlock()
+		c.notifyStatus()
+		return err
+	}
+
+	c.mu.Lock()
+	if c.state != StateConnecting {
+		c.mu.Unlock()
+		conn.Close()
+		return ErrConnectCanceled
+	}
+	c.setConnectedLocked(conn)
+
+	// 启动消息处理协程（读循环随连接重建，其余协程贯穿重连）
+	c.wg.Add(5)
+	c.mu.Unlock()
+	c.notifyStatus()
+
+	go c.readLoop(runCtx, conn, done)
+	go c.writeLoop(runCtx, done)
+	go c.messageProcessor(runCtx, done)
+	go c.pingLoop(runCtx, done)
+	go c.clockSyncLoop(runCtx, done)
+
+	return nil
+}
+
+// setConnectedLocked 记录新建立的连接（调用方持有 mu）
+func (c *WebSocketClient) setConnectedLocked(conn *websocket.Conn) {
+	c.conn = conn
+	c.state = StateConnected
+	c.lastConnectTime = time.Now()
+	c.stats.ConnectTime = time.Now()
+	c.stats.Latency = 0
+	c.pingSentAt = time.Time{}
+	c.closeNotice = nil
+	c.closeCode = 0
+	if c.reconnectCount > 0 {
+		c.stats.ReconnectCount = c.reconnectCount
+	}
+	log.Printf("WebSocket连接已建立: %s (会话ID: %s)", c.serverURL, c.sessionID)
+}
+
+// stopRunLocked 结束本次运行的后台协程（调用方持有 mu）
+func (c *WebSocketClient) stopRunLocked() {
+	if c.closeChan != nil {
+		close(c.closeChan)
+		c.closeChan = nil
+	}
+	if c.runCancel != nil {
+		c.runCancel()
+		c.runCancel = nil
+	}
+}
+
+// dial 建立WebSocket连接
+// 重连时携带最后收到的消息序号，服务端据此补发断线期间遗漏的消息。
+func (c *WebSocketClient) dial(ctx context.Context) (*websocket.Conn, error) {
 	// 解析URL
 	u, err := url.Parse(c.serverURL)
 	if err != nil {
-		return fmt.Errorf("解析服务器URL失败: %w", err)
+		return nil, fmt.Errorf("解析服务器URL失败: %w", err)
 	}
 
+	c.mu.RLock()
+	lastSeq := c.lastSeq
+	resumeToken := c.resumeToken
+	c.mu.RUnlock()
+
 	// 添加会话ID参数
 	q := u.Query()
 	q.Set("session_id", c.sessionID)
+	if lastSeq > 0 {
+		q.Set("last_seq", strconv.FormatInt(lastSeq, 10))
+	}
+	if resumeToken != "" {
+		q.Set("resume_token", resumeToken)
+	}
 	u.RawQuery = q.Encode()
 
 	// 设置连接超时
-	dialer := websocket.DefaultDialer
+	dialer := *websocket.DefaultDialer
 	dialer.HandshakeTimeout = c.connectionTimeout
+	dialer.EnableCompression = c.compression
+	if u.Scheme == "wss" {
+		if c.tlsErr != nil {
+			return nil, fmt.Errorf("TLS配置无效: %w", c.tlsErr)
+		}
+		dialer.TLSClientConfig = c.tlsConfig
+	}
 
-	// 建立连接
-	conn, _, err := dialer.DialContext(ctx, u.String(), nil)
+	// 建立连接（携带认证请求头，令牌被拒绝时刷新后重试一次）
+	var header http.Header
+	if c.auth != nil {
+		if header, err = c.auth.header(ctx); err != nil {
+			c.mu.Lock()
+			c.reconnectCount++
+			c.mu.Unlock()
+			return nil, fmt.Errorf("认证失败: %w", err)
+		}
+	}
+	conn, resp, err := dialer.DialContext(ctx, u.String(), header)
+	if err != nil && resp != nil && resp.StatusCode == http.StatusUnauthorized && c.auth != nil && c.auth.reauthenticate(ctx) {
+		log.Printf("握手认证被拒绝，已刷新令牌，重试连接")
+		if header, err = c.auth.header(ctx); err == nil {
+			conn, resp, err = dialer.DialContext(ctx, u.String(), header)
+		}
+	}
 	if err != nil {
+		c.mu.Lock()
 		c.reconnectCount++
-		return fmt.Errorf("连接服务器失败: %w", err)
+		c.mu.Unlock()
+		return nil, fmt.Errorf("连接服务器失败: %w", err)
 	}
+	if c.compression && !compressionNegotiated(resp) {
+		log.Printf("服务端未同意permessage-deflate压缩，消息不压缩发送")
+	}
+
+	// 设置连接参数
+	c.setupConnection(conn)
+
+	return conn, nil
+}
+
+// currentConn 获取当前连接
+func (c *WebSocketClient) currentConn() *websocket.Conn {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.conn
+}
 
+// SetReconnectHandler 设置重连回调（resumed 表示服务端恢复了原会话，否则需要重新开始会话）
+func (c *WebSocketClient) SetReconnectHandler(handler func(resumed bool)) {
 	c.mu.Lock()
-	c.conn = conn
-	c.isConnected = true
-	c.lastConnectTime = time.Now()
-	c.stats.ConnectTime = time.Now()
-	if c.reconnectCount > 0 {
-		c.stats.ReconnectCount = c.reconnectCount
-	}
+	c.onReconnect = handler
 	c.mu.Unlock()
+}
 
-	// 设置连接参数
-	c.setupConnection()
+// SetStatusHandler 设置连接状态回调：状态变化、每次重连尝试和测得新的Ping往返时延时调用
+// 回调在客户端内部协程中同步调用，应尽快返回且不能调用 Disconnect。
+func (c *WebSocketClient) SetStatusHandler(handler func(status ConnectionStatus)) {
+	c.mu.Lock()
+	c.onStatus = handler
+	c.mu.Unlock()
+}
 
-	// 启动消息处理协程
-	go c.readLoop(ctx)
-	go c.writeLoop(ctx)
-	go c.messageProcessor(ctx)
-	go c.pingLoop(ctx)
+// Status 当前连接状态快照
+func (c *WebSocketClient) Status() ConnectionStatus {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.statusLocked()
+}
 
-	log.Printf("WebSocket连接已建立: %s (会话ID: %s)", c.serverURL, c.sessionID)
-	return nil
+// statusLocked 连接状态快照（调用方持有 mu）
+func (c *WebSocketClient) statusLocked() ConnectionStatus {
+	status := ConnectionStatus{
+		State:                c.state,
+		Latency:              c.stats.Latency,
+		MaxReconnectAttempts: c.maxReconnectAttempts,
+	}
+	if c.state == StateConnecting && c.reconnecting {
+		status.ReconnectAttempt = c.reconnectCount + 1
+		status.RetryIn = c.retryIn
+	}
+	if c.state == StateDisconnected {
+		status.GiveUpReason = c.giveUpReason
+	}
+	return status
 }
 
-// Disconnect 断开连接
+// notifyStatus 通知当前连接状态（调用方不能持有 mu）
+func (c *WebSocketClient) notifyStatus() {
+	c.mu.RLock()
+	handler := c.onStatus
+	status := c.statusLocked()
+	c.mu.RUnlock()
+
+	if handler != nil {
+		handler(status)
+	}
+}
+
+// Disconnect 断开连接并等待后台协程退出，之后可再次 Connect
+// 会等待消息处理协程退出，不能在消息处理器中同步调用。
 func (c *WebSocketClient) Disconnect() error {
 	c.mu.Lock()
-	if !c.isConnected {
+	if c.state == StateDisconnected || c.state == StateClosing {
 		c.mu.Unlock()
 		return nil
 	}
-	c.isConnected = false
+	c.state = StateClosing
+	conn := c.conn
+	c.stopRunLocked()
 	c.mu.Unlock()
 
-	// 发送关闭信号
-	close(c.closeChan)
-
-	// 关闭WebSocket连接
-	if c.conn != nil {
-		c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
-		c.conn.Close()
+	// 关闭WebSocket连接（重连期间为已断开的旧连接，写入失败无影响）
+	if conn != nil {
+		conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+		conn.Close()
 	}
+	c.wg.Wait()
+
+	// 丢弃未发出和未处理的消息，避免再次连接后发出本次运行的残留
+	drainMessages(c.sendChan)
+	drainMessages(c.receiveChan)
+
+	c.mu.Lock()
+	c.conn = nil
+	c.state = StateDisconnected
+	c.reconnecting = false
+	c.flushing = false
+	c.mu.Unlock()
+	c.notifyStatus()
 
 	log.Printf("WebSocket连接已断开")
 	return nil
 }
 
+// drainMessages 丢弃通道中尚未读取的消息
+func drainMessages(ch chan *protocol.Message) {
+	for {
+		select {
+		case <-ch:
+		default:
+			return
+		}
+	}
+}
+
 // SendAudioStream 发送音频流
 func (c *WebSocketClient) SendAudioStream(audioData []byte, chunkID int, isFinal bool) error {
-	if !c.IsConnected() {
-		return fmt.Errorf("未连接到服务器")
+	msg := protocol.NewAudioStreamMessage(c.targetSession(), "pcm_16khz_16bit", chunkID, isFinal, audioData)
+	if err := c.enqueue(msg); err != nil {
+		return fmt.Errorf("发送音频流失败: %w", err)
 	}
+	return nil
+}
 
-	msg := protocol.NewAudioStreamMessage(c.sessionID, "pcm_16khz_16bit", chunkID, isFinal, audioData)
+// SendAudioBuffer 与 SendAudioStream 相同，audioData 取自 vaaudio.GetBytes，写出后由写循环归还缓冲池
+// 调用后不得再使用 audioData。
+func (c *WebSocketClient) SendAudioBuffer(audioData []byte, chunkID int, isFinal bool) error {
+	msg := protocol.NewAudioStreamMessage(c.targetSession(), "pcm_16khz_16bit", chunkID, isFinal, audioData)
+	msg.Payload = pooledAudio{msg.Payload.(*protocol.AudioStreamData)}
+	if err := c.enqueue(msg); err != nil {
+		vaaudio.PutBytes(audioData)
+		return fmt.Errorf("发送音频流失败: %w", err)
+	}
+	return nil
+}
 
-	select {
-	case c.sendChan <- msg:
-		return nil
-	case <-time.After(time.Second):
-		return fmt.Errorf("发送音频流超时")
+// pooledAudio 音频取自缓冲池的音频流数据（序列化结果与 AudioStreamData 相同）
+type pooledAudio struct {
+	*protocol.AudioStreamData
+}
+
+// release 消息写出后归还音频缓冲（断线放回离线缓冲的消息不归还）
+func release(msg *protocol.Message) {
+	if audio, ok := msg.Payload.(pooledAudio); ok {
+		vaaudio.PutBytes(audio.AudioData)
 	}
 }
 
 // SendCommand 发送命令
 func (c *WebSocketClient) SendCommand(command, mode string, parameters map[string]interface{}) error {
-	if !c.IsConnected() {
-		return fmt.Errorf("未连接到服务器")
+	msg := protocol.NewCommandMessage(c.targetSession(), command, mode, parameters)
+	if err := c.enqueue(msg); err != nil {
+		return fmt.Errorf("发送命令失败: %w", err)
+	}
+	return nil
+}
+
+// ReportPlaybackFinished 上报本轮TTS语音已播放完毕（连续模式下服务端据此恢复聆听）
+func (c *WebSocketClient) ReportPlaybackFinished() error {
+	msg := protocol.NewMessage(protocol.Status, c.targetSession(), &protocol.StatusData{State: protocol.StatePlaybackFinished})
+	if err := c.enqueue(msg); err != nil {
+		return fmt.Errorf("上报播放状态失败: %w", err)
+	}
+	return nil
+}
+
+// ReportVoiceActivity 上报VAD检测到的说话开始或结束（服务端据此断句）
+func (c *WebSocketClient) ReportVoiceActivity(speaking bool) error {
+	state := protocol.StateSpeechEnd
+	if speaking {
+		state = protocol.StateSpeechStart
 	}
+	msg := protocol.NewMessage(protocol.Status, c.targetSession(), &protocol.StatusData{State: state})
+	if err := c.enqueue(msg); err != nil {
+		return fmt.Errorf("上报说话状态失败: %w", err)
+	}
+	return nil
+}
 
-	msg := protocol.NewCommandMessage(c.sessionID, command, mode, parameters)
+// AckNotification 确认收到服务端推送的通知
+func (c *WebSocketClient) AckNotification(id string) error {
+	msg := protocol.NewCommandMessage(c.targetSession(), protocol.CmdAckNotification, "", map[string]interface{}{"id": id})
+	if err := c.enqueue(msg); err != nil {
+		return fmt.Errorf("确认通知失败: %w", err)
+	}
+	return nil
+}
+
+// enqueue 消息进入发送队列
+// 重连期间（以及重连后发出缓冲消息期间）消息进入离线缓冲，保证重连后按产生顺序发出。
+func (c *WebSocketClient) enqueue(msg *protocol.Message) error {
+	c.mu.Lock()
+	if c.outbox != nil && (c.reconnecting || c.flushing) {
+		accepted := c.outbox.push(msg)
+		c.mu.Unlock()
+		if !accepted {
+			return ErrOfflineBufferFull
+		}
+		return nil
+	}
+	connected := c.state == StateConnected
+	c.mu.Unlock()
+
+	if !connected {
+		return ErrNotConnected
+	}
 
 	select {
 	case c.sendChan <- msg:
 		return nil
 	case <-time.After(time.Second):
-		return fmt.Errorf("发送命令超时")
+		return ErrSendTimeout
+	}
+}
+
+// requeue 断线时已取出但未写出的消息放回离线缓冲（未启用缓冲时丢弃）
+func (c *WebSocketClient) requeue(msg *protocol.Message) {
+	// 时钟同步请求过时后没有意义
+	if msg.Type == protocol.TimeSync {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.outbox != nil && (c.reconnecting || c.flushing) {
+		c.outbox.requeue(msg)
+	}
+}
+
+// flushOutbox 重连后按序发出离线缓冲的消息
+// 发出期间新产生的消息继续排在缓冲末尾，缓冲清空后恢复直接发送；期间再次断线则留待下次重连。
+func (c *WebSocketClient) flushOutbox(done <-chan struct{}) {
+	c.mu.Lock()
+	if c.outbox == nil {
+		c.flushing = false
+		c.mu.Unlock()
+		return
+	}
+	pending, dropped := c.outbox.len(), c.outbox.dropped
+	c.outbox.requeued, c.outbox.dropped = 0, 0
+	c.mu.Unlock()
+
+	if pending > 0 || dropped > 0 {
+		log.Printf("重连后发送离线缓冲的消息: %d条 (缓冲区满丢弃%d条)", pending, dropped)
+	}
+
+	for {
+		c.mu.Lock()
+		if c.state != StateConnected {
+			c.flushing = false
+			c.mu.Unlock()
+			return
+		}
+		msg, ok := c.outbox.pop()
+		if !ok {
+			c.flushing = false
+			c.mu.Unlock()
+			return
+		}
+		c.mu.Unlock()
+
+		select {
+		case c.sendChan <- msg:
+		case <-done:
+			return
+		}
 	}
 }
 
 // RegisterHandler 注册消息处理器
 func (c *WebSocketClient) RegisterHandler(msgType protocol.MessageType, handler MessageHandler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	c.messageHandlers[msgType] = handler
 }
 
+// ClockOffset 获取与服务端的时钟偏差（服务端减客户端），尚未完成同步时ok为false
+func (c *WebSocketClient) ClockOffset() (offset time.Duration, ok bool) {
+	offset, _, ok = c.clock.Offset()
+	return offset, ok
+}
+
+// ServerTimeToLocal 将服务端时间戳（毫秒）换算为本地时间
+func (c *WebSocketClient) ServerTimeToLocal(serverMs int64) time.Time {
+	return c.clock.ServerToLocal(serverMs)
+}
+
 // IsConnected 检查是否已连接
 func (c *WebSocketClient) IsConnected() bool {
+	return c.State() == StateConnected
+}
+
+// State 获取连接状态
+func (c *WebSocketClient) State() ConnectionState {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	return c.isConnected
+	return c.state
 }
 
 // GetStats 获取连接统计信息
@@ -220,44 +679,86 @@ func (c *WebSocketClient) GetStats() ConnectionStats {
 	return c.stats
 }
 
+// QueueDepths 获取发送、接收队列和离线缓冲中的消息数
+func (c *WebSocketClient) QueueDepths() QueueDepths {
+	depths := QueueDepths{Send: len(c.sendChan), Receive: len(c.receiveChan)}
+	c.mu.RLock()
+	if c.outbox != nil {
+		depths.Offline = c.outbox.len()
+	}
+	c.mu.RUnlock()
+	return depths
+}
+
+// SetMessageDump 设置是否在日志中记录收发的消息内容（调试用）
+func (c *WebSocketClient) SetMessageDump(enabled bool) {
+	c.dumpMessages.Store(enabled)
+}
+
+// MessageDump 是否在日志中记录收发的消息内容
+func (c *WebSocketClient) MessageDump() bool {
+	return c.dumpMessages.Load()
+}
+
+// dumpMessage 调试开启时记录消息内容（过长时截断）
+func (c *WebSocketClient) dumpMessage(direction string, data []byte) {
+	if !c.dumpMessages.Load() {
+		return
+	}
+	const maxDumpLength = 512
+	if len(data) > maxDumpLength {
+		log.Printf("%s: %s...（共%d字节）", direction, data[:maxDumpLength], len(data))
+		return
+	}
+	log.Printf("%s: %s", direction, data)
+}
+
 // GetSessionID 获取会话ID
 func (c *WebSocketClient) GetSessionID() string {
 	return c.sessionID
 }
 
 // setupConnection 设置连接参数
-func (c *WebSocketClient) setupConnection() {
+func (c *WebSocketClient) setupConnection(conn *websocket.Conn) {
 	// 设置读取超时
-	c.conn.SetReadDeadline(time.Now().Add(c.pongTimeout))
+	conn.SetReadDeadline(time.Now().Add(c.pongTimeout))
 
 	// 设置Pong处理器
-	c.conn.SetPongHandler(func(string) error {
-		c.conn.SetReadDeadline(time.Now().Add(c.pongTimeout))
+	conn.SetPongHandler(func(string) error {
+		conn.SetReadDeadline(time.Now().Add(c.pongTimeout))
+		c.recordPong(conn)
 		return nil
 	})
 
 	// 设置关闭处理器
-	c.conn.SetCloseHandler(func(code int, text string) error {
+	conn.SetCloseHandler(func(code int, text string) error {
 		log.Printf("WebSocket连接关闭: code=%d, text=%s", code, text)
-		c.handleDisconnection()
+		c.mu.Lock()
+		if conn == c.conn {
+			c.closeCode = code
+		}
+		c.mu.Unlock()
+		// 回应关闭帧，完成关闭握手
+		conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, ""), time.Now().Add(time.Second))
+		c.handleDisconnection(conn)
 		return nil
 	})
 }
 
-// readLoop 读取消息循环
-func (c *WebSocketClient) readLoop(ctx context.Context) {
-	defer func() {
-		c.handleDisconnection()
-	}()
+// readLoop 读取消息循环（每个连接一个，连接断开后退出并触发重连）
+func (c *WebSocketClient) readLoop(ctx context.Context, conn *websocket.Conn, done <-chan struct{}) {
+	defer c.wg.Done()
+	defer c.handleDisconnection(conn)
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case <-c.closeChan:
+		case <-done:
 			return
 		default:
-			_, messageData, err := c.conn.ReadMessage()
+			_, messageData, err := conn.ReadMessage()
+			receivedAt := time.Now()
 			if err != nil {
 				if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 					log.Printf("WebSocket读取错误: %v", err)
@@ -271,6 +772,7 @@ func (c *WebSocketClient) readLoop(ctx context.Context) {
 			c.stats.BytesReceived += int64(len(messageData))
 			c.stats.LastMessageTime = time.Now()
 			c.mu.Unlock()
+			c.dumpMessage("收到消息", messageData)
 
 			// 解析消息
 			msg, err := protocol.FromJSON(messageData)
@@ -279,6 +781,21 @@ func (c *WebSocketClient) readLoop(ctx context.Context) {
 				continue
 			}
 
+			// 时钟同步应答直接处理，避免排队延迟影响接收时间戳
+			if msg.Type == protocol.TimeSync {
+				c.handleTimeSync(msg, receivedAt)
+				continue
+			}
+
+			if msg.Type == protocol.Status {
+				c.handleCloseNotice(conn, msg)
+			}
+
+			// 补发的消息可能与断线前收到的重复，按序号去重
+			if !c.trackSequence(msg) {
+				continue
+			}
+
 			// 发送到处理通道
 			select {
 			case c.receiveChan <- msg:
@@ -289,55 +806,125 @@ func (c *WebSocketClient) readLoop(ctx context.Context) {
 	}
 }
 
+// trackSequence 记录消息序号，返回消息是否需要处理
+// 连接确认中服务端未恢复原会话时（会话已过期或服务端重启）序号重新开始。
+func (c *WebSocketClient) trackSequence(msg *protocol.Message) bool {
+	if msg.Seq == 0 {
+		if msg.Type == protocol.Status {
+			if status, err := msg.StatusData(); err == nil && status.State == protocol.StateConnected {
+				c.handleConnected(status.Resumed, status.ResumeToken)
+			}
+		}
+		return true
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if msg.Seq <= c.lastSeq {
+		return false
+	}
+	c.lastSeq = msg.Seq
+	return true
+}
+
+// handleConnected 处理服务端连接确认，保存恢复令牌供重连时出示
+func (c *WebSocketClient) handleConnected(resumed bool, resumeToken string) {
+	c.mu.Lock()
+	c.resumeToken = resumeToken
+	if !resumed {
+		c.lastSeq = 0
+	}
+	reconnected := c.reconnected
+	c.reconnected = false
+	handler := c.onReconnect
+	done := c.closeChan
+	c.mu.Unlock()
+
+	if !reconnected || done == nil {
+		return
+	}
+	if resumed {
+		log.Printf("会话已恢复: %s", c.sessionID)
+	} else {
+		log.Printf("服务端未保留原会话，需要重新开始: %s", c.sessionID)
+	}
+
+	// 先按序发出断线期间缓冲的消息，再交给应用处理重连（如重新开始会话）
+	go func() {
+		c.flushOutbox(done)
+		if handler != nil {
+			handler(resumed)
+		}
+	}()
+}
+
 // writeLoop 写入消息循环
-func (c *WebSocketClient) writeLoop(ctx context.Context) {
+func (c *WebSocketClient) writeLoop(ctx context.Context, done <-chan struct{}) {
+	defer c.wg.Done()
+
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case <-c.closeChan:
+		case <-done:
 			return
 		case msg := <-c.sendChan:
 			if !c.IsConnected() {
+				c.requeue(msg)
 				continue
 			}
 
+			// 时钟同步请求在实际写出前记录发送时间
+			if syncData, ok := msg.Payload.(*protocol.TimeSyncData); ok {
+				syncData.ClientSendTime = time.Now().UnixMilli()
+			}
+
 			// 序列化消息
 			data, err := msg.ToJSON()
 			if err != nil {
 				log.Printf("序列化消息失败: %v", err)
+				release(msg)
 				continue
 			}
 
 			// 设置写入超时
-			c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			conn := c.currentConn()
+			conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
 
-			// 发送消息
-			if err := c.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+			// 发送消息（失败时触发重连，写循环继续服务新连接）
+			if err := c.writeMessage(conn, data); err != nil {
 				log.Printf("发送消息失败: %v", err)
-				c.handleDisconnection()
-				return
+				c.handleDisconnection(conn)
+				c.requeue(msg)
+				continue
 			}
+			release(msg)
 
 			// 更新统计信息
 			c.mu.Lock()
 			c.stats.MessagesSent++
 			c.stats.BytesSent += int64(len(data))
 			c.mu.Unlock()
+			c.dumpMessage("发送消息", data)
 		}
 	}
 }
 
 // messageProcessor 消息处理器
-func (c *WebSocketClient) messageProcessor(ctx context.Context) {
+func (c *WebSocketClient) messageProcessor(ctx context.Context, done <-chan struct{}) {
+	defer c.wg.Done()
+
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case <-c.closeChan:
+		case <-done:
 			return
 		case msg := <-c.receiveChan:
-			if handler, exists := c.messageHandlers[msg.Type]; exists {
+			c.mu.RLock()
+			handler, exists := c.messageHandlers[msg.Type]
+			c.mu.RUnlock()
+			if exists {
 				if err := handler(msg); err != nil {
 					log.Printf("处理消息失败: %v", err)
 				}
@@ -349,7 +936,9 @@ func (c *WebSocketClient) messageProcessor(ctx context.Context) {
 }
 
 // pingLoop Ping循环
-func (c *WebSocketClient) pingLoop(ctx context.Context) {
+func (c *WebSocketClient) pingLoop(ctx context.Context, done <-chan struct{}) {
+	defer c.wg.Done()
+
 	ticker := time.NewTicker(c.pingInterval)
 	defer ticker.Stop()
 
@@ -357,62 +946,255 @@ func (c *WebSocketClient) pingLoop(ctx context.Context) {
 		select {
 		case <-ctx.Done():
 			return
-		case <-c.closeChan:
+		case <-done:
 			return
 		case <-ticker.C:
 			if !c.IsConnected() {
 				continue
 			}
 
-			c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
-			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+			conn := c.currentConn()
+			c.mu.Lock()
+			c.pingSentAt = time.Now()
+			c.mu.Unlock()
+			conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
 				log.Printf("发送Ping失败: %v", err)
-				c.handleDisconnection()
-				return
+				c.handleDisconnection(conn)
 			}
 		}
 	}
 }
 
-// handleDisconnection 处理断开连接
-func (c *WebSocketClient) handleDisconnection() {
+// recordPong 收到Pong时以对应Ping的发送时间计算往返时延
+func (c *WebSocketClient) recordPong(conn *websocket.Conn) {
 	c.mu.Lock()
-	wasConnected := c.isConnected
-	c.isConnected = false
+	if conn != c.conn || c.pingSentAt.IsZero() {
+		c.mu.Unlock()
+		return
+	}
+	c.stats.Latency = time.Since(c.pingSentAt)
+	c.pingSentAt = time.Time{}
 	c.mu.Unlock()
+	c.notifyStatus()
+}
+
+// clockSyncLoop 时钟同步循环：连接建立后连续采样，之后定期采样以跟踪时钟漂移
+func (c *WebSocketClient) clockSyncLoop(ctx context.Context, done <-chan struct{}) {
+	defer c.wg.Done()
+
+	for i := 0; i < clockSyncBurst; i++ {
+		c.sendTimeSync()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-done:
+			return
+		case <-time.After(clockSyncBurstInterval):
+		}
+	}
+
+	ticker := time.NewTicker(clockSyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-done:
+			return
+		case <-ticker.C:
+			c.sendTimeSync()
+		}
+	}
+}
+
+// sendTimeSync 发送时钟同步请求
+func (c *WebSocketClient) sendTimeSync() {
+	if !c.IsConnected() {
+		return
+	}
+
+	select {
+	case c.sendChan <- protocol.NewTimeSyncMessage(c.sessionID, 0):
+	default:
+		// 发送队列繁忙时跳过本次采样，排队延迟会使样本失真
+	}
+}
+
+// handleTimeSync 处理时钟同步应答
+func (c *WebSocketClient) handleTimeSync(msg *protocol.Message, receivedAt time.Time) {
+	syncData, err := msg.TimeSyncData()
+	if err != nil {
+		log.Printf("解析时钟同步数据失败: %v", err)
+		return
+	}
 
-	if !wasConnected {
+	c.clock.AddSample(syncData.ClientSendTime, syncData.ServerReceiveTime, syncData.ServerSendTime, receivedAt.UnixMilli())
+}
+
+// handleCloseNotice 处理服务端的关闭通知：记录关闭原因并回复close_ack，服务端随后以对应关闭码断开
+func (c *WebSocketClient) handleCloseNotice(conn *websocket.Conn, msg *protocol.Message) {
+	status, err := msg.StatusData()
+	if err != nil || status.State != protocol.StateClosing || status.Close == nil {
 		return
 	}
 
+	c.mu.Lock()
+	if conn != c.conn {
+		c.mu.Unlock()
+		return
+	}
+	c.closeNotice = status.Close
+	c.mu.Unlock()
+	log.Printf("服务端即将关闭连接: code=%d, %s", status.Close.Code, status.Close.Reason)
+
+	ack := protocol.NewMessage(protocol.Status, c.sessionID, &protocol.StatusData{State: protocol.StateCloseAck})
+	select {
+	case c.sendChan <- ack:
+	default:
+	}
+}
+
+// shouldReconnectLocked 断开后是否重连：服务端通知不要重连（如会话被终止）时不再重连（调用方持有 mu）
+func (c *WebSocketClient) shouldReconnectLocked() bool {
+	if c.closeNotice != nil {
+		return c.closeNotice.Reconnect
+	}
+	return c.closeCode == 0 || protocol.CloseCodeAllowsReconnect(c.closeCode)
+}
+
+// handleDisconnection 处理断开连接（同一连接的多次断线通知只触发一次重连）
+func (c *WebSocketClient) handleDisconnection(conn *websocket.Conn) {
+	c.mu.Lock()
+	if conn != c.conn || c.state != StateConnected {
+		c.mu.Unlock()
+		return
+	}
+	if !c.shouldReconnectLocked() {
+		reason := ""
+		if c.closeNotice != nil {
+			reason = c.closeNotice.Reason
+		}
+		c.state = StateDisconnected
+		c.giveUpReason = "服务端已结束会话"
+		if reason != "" {
+			c.giveUpReason += "：" + reason
+		}
+		c.stopRunLocked()
+		c.mu.Unlock()
+
+		conn.Close()
+		log.Printf("服务端已结束会话，不再重连: %s", reason)
+		c.notifyStatus()
+		return
+	}
+	c.state = StateConnecting
+	c.reconnecting = true
+	runCtx, done := c.runCtx, c.closeChan
+	c.wg.Add(1)
+	c.mu.Unlock()
+
+	conn.Close()
 	log.Printf("连接断开，准备重连...")
+	c.notifyStatus()
 
 	// 尝试重连
-	go c.attemptReconnect()
+	go c.attemptReconnect(runCtx, done)
 }
 
-// attemptReconnect 尝试重连
-func (c *WebSocketClient) attemptReconnect() {
-	for c.reconnectCount < c.maxReconnectAttempts {
-		// 等待重连间隔
-		time.Sleep(c.reconnectInterval)
+// attemptReconnect 尝试重连（沿用原会话ID，服务端在保留期内恢复会话）
+// 每次重连前按指数退避等待，重连次数用尽或断线时长超过 maxReconnectElapsed 后放弃。
+func (c *WebSocketClient) attemptReconnect(runCtx context.Context, done <-chan struct{}) {
+	defer c.wg.Done()
+	defer func() {
+		c.mu.Lock()
+		c.reconnecting = false
+		c.retryIn = 0
+		c.mu.Unlock()
+	}()
+
+	start := time.Now()
+	var reason string
+	for {
+		c.mu.RLock()
+		attempts := c.reconnectCount
+		c.mu.RUnlock()
+		if attempts >= c.maxReconnectAttempts {
+			reason = fmt.Sprintf("已重连%d次", attempts)
+			break
+		}
+		wait := c.reconnectBackoff.delay(attempts)
+		if c.maxReconnectElapsed > 0 && time.Since(start)+wait > c.maxReconnectElapsed {
+			reason = fmt.Sprintf("断线超过%s", c.maxReconnectElapsed)
+			break
+		}
+
+		// 按退避时间等待
+		c.mu.Lock()
+		c.retryIn = wait
+		c.mu.Unlock()
+		c.notifyStatus()
+		select {
+		case <-runCtx.Done():
+			return
+		case <-done:
+			return
+		case <-time.After(wait):
+		}
+		c.mu.Lock()
+		c.retryIn = 0
+		c.mu.Unlock()
 
-		log.Printf("尝试重连 (%d/%d)...", c.reconnectCount+1, c.maxReconnectAttempts)
+		log.Printf("尝试重连 (%d/%d)...", attempts+1, c.maxReconnectAttempts)
 
 		// 尝试连接
-		ctx, cancel := context.WithTimeout(context.Background(), c.connectionTimeout)
-		if err := c.Connect(ctx); err != nil {
+		ctx, cancel := context.WithTimeout(runCtx, c.connectionTimeout)
+		conn, err := c.dial(ctx)
+		cancel()
+		if err != nil {
 			log.Printf("重连失败: %v", err)
-			cancel()
 			continue
 		}
-		cancel()
 
+		c.mu.Lock()
+		if c.state != StateConnecting {
+			// 重连期间调用了 Disconnect
+			c.mu.Unlock()
+			conn.Close()
+			return
+		}
+		c.setConnectedLocked(conn)
+
+		// 收到服务端连接确认后再发出离线缓冲的消息，此前的新消息继续缓冲
+		c.reconnectCount = 0
+		c.reconnected = true
+		c.flushing = c.outbox != nil
+		c.wg.Add(1)
+		c.mu.Unlock()
+
+		go c.readLoop(runCtx, conn, done)
 		log.Printf("重连成功")
+		c.notifyStatus()
 		return
 	}
 
-	log.Printf("重连失败，已达到最大尝试次数")
+	log.Printf("放弃重连: %s", reason)
+
+	// 不再重连：丢弃离线缓冲并结束本次运行，之后可重新 Connect
+	c.mu.Lock()
+	if c.outbox != nil && c.outbox.len() > 0 {
+		log.Printf("丢弃离线缓冲的消息: %d条", c.outbox.len())
+		c.outbox = newOutbox(c.outbox.limit, c.outbox.policy)
+	}
+	if c.state == StateConnecting {
+		c.state = StateDisconnected
+		c.giveUpReason = reason
+		c.stopRunLocked()
+	}
+	c.mu.Unlock()
+	c.notifyStatus()
 }
 
 // generateSessionID 生成会话ID
@@ -422,7 +1204,46 @@ func generateSessionID() string {
 
 // StartSession 启动会话
 func (c *WebSocketClient) StartSession(mode string) error {
-	return c.SendCommand(protocol.CmdStartSession, mode, nil)
+	return c.StartSessionWithParameters(mode, nil)
+}
+
+// StartSessionWithParameters 携带会话参数启动会话（如 text_only）
+func (c *WebSocketClient) StartSessionWithParameters(mode string, params map[string]interface{}) error {
+	return c.SendCommand(protocol.CmdStartSession, mode, params)
+}
+
+// JoinSession 用会话所有者发出的加入码加入其他客户端的会话（role 为 speaker 或 listener），之后的音频和命令都发往该会话
+func (c *WebSocketClient) JoinSession(sessionID, code, role string) error {
+	c.mu.Lock()
+	c.joined = sessionID
+	c.mu.Unlock()
+	return c.SendCommand(protocol.CmdJoinSession, "", map[string]interface{}{"code": code, "role": role})
+}
+
+// InviteMember 生成加入码，邀请其他客户端以指定角色加入当前会话
+func (c *WebSocketClient) InviteMember(role string) error {
+	return c.SendCommand(protocol.CmdInviteMember, "", map[string]interface{}{"role": role})
+}
+
+// LeaveSession 退出加入的会话，之后的消息发往自己的会话
+func (c *WebSocketClient) LeaveSession() error {
+	if err := c.SendCommand(protocol.CmdLeaveSession, "", nil); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.joined = ""
+	c.mu.Unlock()
+	return nil
+}
+
+// targetSession 消息所属的会话：加入了其他客户端的会话时为该会话，否则为自己的会话
+func (c *WebSocketClient) targetSession() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.joined != "" {
+		return c.joined
+	}
+	return c.sessionID
 }
 
 // StopSession 停止会话
@@ -448,6 +1269,22 @@ func (c *WebSocketClient) SetMode(mode string) error {
 	return c.SendCommand(protocol.CmdSetMode, mode, params)
 }
 
+// Wake 发送唤醒事件（唤醒词模式下服务端收到后才接受下一轮音频）
+func (c *WebSocketClient) Wake(keyword string) error {
+	params := map[string]interface{}{
+		"keyword": keyword,
+	}
+	return c.SendCommand(protocol.CmdWake, "", params)
+}
+
+// SetTextOnly 设置仅文本模式（服务端不再返回TTS音频）
+func (c *WebSocketClient) SetTextOnly(enabled bool) error {
+	params := map[string]interface{}{
+		"text_only": enabled,
+	}
+	return c.SendCommand(protocol.CmdSetMode, "", params)
+}
+
 // GetStatus 获取状态
 func (c *WebSocketClient) GetStatus() error {
 	return c.SendCommand(protocol.CmdGetStatus, "", nil)
@@ -462,3 +1299,50 @@ func (c *WebSocketClient) InterruptSession() error {
 func (c *WebSocketClient) ClearContext() error {
 	return c.SendCommand(protocol.CmdClearContext, "", nil)
 }
+
+// Handover 请求会话转移令牌，在其他设备上用令牌接管当前对话
+func (c *WebSocketClient) Handover() error {
+	return c.SendCommand(protocol.CmdHandover, "", nil)
+}
+
+// ClaimHandover 用转移令牌接管其他设备的对话
+func (c *WebSocketClient) ClaimHandover(token string, params map[string]interface{}) error {
+	if params == nil {
+		params = make(map[string]interface{})
+	}
+	params["token"] = token
+	return c.SendCommand(protocol.CmdClaimHandover, "", params)
+}
+
+// SetLanguage 切换会话语言
+func (c *WebSocketClient) SetLanguage(language string) error {
+	params := map[string]interface{}{
+		"language": language,
+	}
+	return c.SendCommand(protocol.CmdSetLanguage, "", params)
+}
+
+// SetPersona 切换服务端人设（系统提示），对之后的回复生效
+func (c *WebSocketClient) SetPersona(persona string) error {
+	params := map[string]interface{}{
+		"persona": persona,
+	}
+	return c.SendCommand(protocol.CmdSetPersona, "", params)
+}
+
+// ListVoices 请求服务端TTS支持的声音列表（language为空时返回全部），结果以voice_list消息返回
+func (c *WebSocketClient) ListVoices(language string) error {
+	var params map[string]interface{}
+	if language != "" {
+		params = map[string]interface{}{"language": language}
+	}
+	return c.SendCommand(protocol.CmdListVoices, "", params)
+}
+
+// SetDataCollection 设置是否允许服务端采集对话数据
+func (c *WebSocketClient) SetDataCollection(enabled bool) error {
+	params := map[string]interface{}{
+		"enabled": enabled,
+	}
+	return c.SendCommand(protocol.CmdSetDataCollection, "", params)
+}
@@ -0,0 +1,164 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	vaaudio "voice_assistant/pkg/audio"
+	"voice_assistant/pkg/protocol"
+)
+
+// newEchoServer 启动只读取消息、保持连接的WebSocket测试服务端
+func newEchoServer(t *testing.T) *httptest.Server {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestClient(server *httptest.Server) *WebSocketClient {
+	return NewWebSocketClient(ClientConfig{
+		ServerURL:            "ws" + strings.TrimPrefix(server.URL, "http"),
+		ReconnectInterval:    10 * time.Millisecond,
+		MaxReconnectAttempts: 1,
+		ConnectionTimeout:    time.Second,
+		PingInterval:         time.Second,
+		PongTimeout:          5 * time.Second,
+	})
+}
+
+func TestWebSocketClientReconnectAfterDisconnect(t *testing.T) {
+	c := newTestClient(newEchoServer(t))
+	ctx := context.Background()
+	assert.Equal(t, StateDisconnected, c.State())
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, c.Connect(ctx))
+		assert.Equal(t, StateConnected, c.State())
+		assert.Error(t, c.Connect(ctx), "已连接时不能重复连接")
+		assert.NoError(t, c.SendCommand(protocol.CmdGetStatus, "", nil))
+
+		require.NoError(t, c.Disconnect())
+		assert.Equal(t, StateDisconnected, c.State())
+		assert.ErrorIs(t, c.SendCommand(protocol.CmdGetStatus, "", nil), ErrNotConnected)
+	}
+	assert.NoError(t, c.Disconnect())
+}
+
+func TestWebSocketClientConcurrentRegisterHandler(t *testing.T) {
+	c := newTestClient(newEchoServer(t))
+	require.NoError(t, c.Connect(context.Background()))
+	defer c.Disconnect()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.RegisterHandler(protocol.Response, func(*protocol.Message) error { return nil })
+			c.receiveChan <- protocol.NewMessage(protocol.Response, c.GetSessionID(), nil)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestWebSocketClientConnectFailure(t *testing.T) {
+	server := newEchoServer(t)
+	c := newTestClient(server)
+	server.Close()
+
+	assert.Error(t, c.Connect(context.Background()))
+	assert.Equal(t, StateDisconnected, c.State())
+}
+
+func TestWebSocketClientStatusHandler(t *testing.T) {
+	c := newTestClient(newEchoServer(t))
+	c.pingInterval = 20 * time.Millisecond
+
+	var mu sync.Mutex
+	var states []ConnectionState
+	var latency time.Duration
+	c.SetStatusHandler(func(status ConnectionStatus) {
+		mu.Lock()
+		defer mu.Unlock()
+		if len(states) == 0 || states[len(states)-1] != status.State {
+			states = append(states, status.State)
+		}
+		latency = status.Latency
+	})
+
+	require.NoError(t, c.Connect(context.Background()))
+	// 测试服务端读取消息时自动应答Ping
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return latency > 0
+	}, time.Second, 10*time.Millisecond)
+	assert.Greater(t, c.GetStats().Latency, time.Duration(0))
+
+	require.NoError(t, c.Disconnect())
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []ConnectionState{StateConnecting, StateConnected, StateDisconnected}, states)
+}
+
+func TestWebSocketClientSendAudioBuffer(t *testing.T) {
+	received := make(chan *protocol.Message, 1)
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if msg, err := protocol.FromJSON(data); err == nil && msg.Type == protocol.AudioStream {
+				received <- msg
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	c := newTestClient(server)
+	require.NoError(t, c.Connect(context.Background()))
+	defer c.Disconnect()
+
+	// 缓冲池中的音频与普通音频序列化结果相同
+	pcm := vaaudio.GetBytes(4)
+	copy(pcm, []byte{1, 2, 3, 4})
+	require.NoError(t, c.SendAudioBuffer(pcm, 7, false))
+
+	select {
+	case msg := <-received:
+		audio, err := msg.AudioStreamData()
+		require.NoError(t, err)
+		assert.Equal(t, 7, audio.ChunkID)
+		assert.Equal(t, []byte{1, 2, 3, 4}, audio.AudioData)
+	case <-time.After(time.Second):
+		t.Fatal("服务端未收到音频")
+	}
+}
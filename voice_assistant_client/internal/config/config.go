@@ -5,8 +5,12 @@ import (
 	"os"
 	"time"
 
+	"voice_assistant/pkg/logging"
+	"voice_assistant/pkg/protocol"
 	"voice_assistant/voice_assistant_client/internal/audio"
 	"voice_assistant/voice_assistant_client/internal/client"
+	"voice_assistant/voice_assistant_client/internal/hotkey"
+	"voice_assistant/voice_assistant_client/internal/transcript"
 
 	"gopkg.in/yaml.v3"
 )
@@ -34,6 +38,17 @@ type ServerConfig struct {
 	ConnectionTimeout    time.Duration `yaml:"connection_timeout"`
 	PingInterval         time.Duration `yaml:"ping_interval"`
 	PongTimeout          time.Duration `yaml:"pong_timeout"`
+
+	// 重连退避：等待时间从reconnect_interval起按倍数增长到reconnect_max_interval，按抖动比例随机缩短，
+	// 断线超过reconnect_max_elapsed后放弃重连
+	ReconnectMaxInterval time.Duration `yaml:"reconnect_max_interval"`
+	ReconnectMultiplier  float64       `yaml:"reconnect_multiplier"`
+	ReconnectJitter      float64       `yaml:"reconnect_jitter"` // 0-1
+	ReconnectMaxElapsed  time.Duration `yaml:"reconnect_max_elapsed"`
+
+	// 断线重连期间缓冲待发送的音频和命令，重连后按序发出（0表示不缓冲）
+	OfflineBufferSize   int    `yaml:"offline_buffer_size"`
+	OfflineBufferPolicy string `yaml:"offline_buffer_policy"` // drop_oldest|drop_newest
 }
 
 // AudioConfig 音频配置
@@ -42,36 +57,76 @@ type AudioConfig struct {
 	Output     AudioOutputConfig `yaml:"output"`
 	VAD        VADConfig         `yaml:"vad"`
 	Processing ProcessingConfig  `yaml:"processing"`
+	Duplex     DuplexConfig      `yaml:"duplex"`
 }
 
 // AudioInputConfig 音频输入配置
 type AudioInputConfig struct {
-	DeviceID      int    `yaml:"device_id"`
-	SampleRate    int    `yaml:"sample_rate"`
-	Channels      int    `yaml:"channels"`
-	Format        string `yaml:"format"`
-	BufferSize    int    `yaml:"buffer_size"`
-	ChunkDuration int    `yaml:"chunk_duration"`
+	Source        string             `yaml:"source"`  // portaudio（本机录音设备）|network（网络音频流）
+	Network       NetworkInputConfig `yaml:"network"` // source为network时的网络音频流
+	DeviceID      int                `yaml:"device_id"`
+	SampleRate    int                `yaml:"sample_rate"`
+	Channels      int                `yaml:"channels"`
+	Format        string             `yaml:"format"`
+	BufferSize    int                `yaml:"buffer_size"`
+	ChunkDuration int                `yaml:"chunk_duration"`
+	Gain          float64            `yaml:"gain"` // 输入增益（倍率）
+}
+
+// NetworkInputConfig 网络音频输入配置（如IP对讲机的RTSP/RTP流或TCP裸PCM流）
+type NetworkInputConfig struct {
+	URL            string        `yaml:"url"`             // rtsp://、rtp://监听地址:端口、tcp://主机:端口
+	Codec          string        `yaml:"codec"`           // TCP流和RTP动态负载的编码: s16le|l16|pcmu|pcma
+	SampleRate     int           `yaml:"sample_rate"`     // TCP流和RTP动态负载的采样率（0表示与输入采样率相同）
+	Channels       int           `yaml:"channels"`        // TCP流和RTP动态负载的声道数
+	ReconnectDelay time.Duration `yaml:"reconnect_delay"` // 断开后重新连接前的等待时间
 }
 
 // AudioOutputConfig 音频输出配置
 type AudioOutputConfig struct {
-	DeviceID   int    `yaml:"device_id"`
-	SampleRate int    `yaml:"sample_rate"`
-	Channels   int    `yaml:"channels"`
-	Format     string `yaml:"format"`
-	BufferSize int    `yaml:"buffer_size"`
+	Sink       string              `yaml:"sink"`    // portaudio（本机播放设备）|network（网络音频输出）
+	Network    NetworkOutputConfig `yaml:"network"` // sink为network时的输出目标
+	DeviceID   int                 `yaml:"device_id"`
+	SampleRate int                 `yaml:"sample_rate"`
+	Channels   int                 `yaml:"channels"`
+	Format     string              `yaml:"format"`
+	BufferSize int                 `yaml:"buffer_size"`
+	Prebuffer  time.Duration       `yaml:"prebuffer"` // 开始播放前累积的音频时长（0表示收到即播）
+	Crossfade  time.Duration       `yaml:"crossfade"` // 缓冲耗尽和恢复播放时的淡入淡出时长
+	Ducking    DuckingConfig       `yaml:"ducking"`
+	Speed      float64             `yaml:"speed"` // 回复语音的播放速度（变速不变调，与服务端TTS语速无关）
+}
+
+// NetworkOutputConfig 网络音频输出配置（Snapcast、AirPlay发送程序或UDP PCM接收端，用于全屋音箱播放）
+type NetworkOutputConfig struct {
+	URL            string        `yaml:"url"`             // tcp://主机:端口、udp://主机:端口、pipe:///tmp/snapfifo、exec:命令 参数...
+	SampleRate     int           `yaml:"sample_rate"`     // 发送的采样率（默认48000）
+	Channels       int           `yaml:"channels"`        // 发送的声道数（默认2）
+	ReconnectDelay time.Duration `yaml:"reconnect_delay"` // 断开后重新连接前的等待时间
+}
+
+// DuckingConfig 播放回复时调低其他程序（音乐、视频等）的音量，播放结束后恢复
+type DuckingConfig struct {
+	Enabled bool          `yaml:"enabled"`
+	Level   float64       `yaml:"level"`   // 调低后保留的音量比例（0-1）
+	Release time.Duration `yaml:"release"` // 播放结束后等待该时长再恢复音量
 }
 
 // VADConfig VAD配置
 type VADConfig struct {
 	Enabled            bool    `yaml:"enabled"`
-	Threshold          float64 `yaml:"threshold"`
+	Threshold          float64 `yaml:"threshold"` // 能量阈值（dBFS）
 	MinSpeechDuration  int     `yaml:"min_speech_duration"`
 	MinSilenceDuration int     `yaml:"min_silence_duration"`
 	PreEmphasis        float64 `yaml:"pre_emphasis"`
 }
 
+// DuplexConfig 双工模式配置（session.mode为duplex时播放回复期间也保持录音）
+type DuplexConfig struct {
+	EchoMargin      float64       `yaml:"echo_margin"`       // 麦克风电平需高出估计回声的余量（dB），低于该余量的音频视为助手自己的声音
+	BargeInDuration time.Duration `yaml:"barge_in_duration"` // 播放期间用户持续说话超过该时长时停止播放（0表示不打断）
+}
+
 // ProcessingConfig 音频处理配置
 type ProcessingConfig struct {
 	NoiseReduction      bool `yaml:"noise_reduction"`
@@ -88,6 +143,25 @@ type SessionConfig struct {
 	KeepAliveInterval time.Duration  `yaml:"keep_alive_interval"`
 	MaxMessageSize    int            `yaml:"max_message_size"`
 	Wakeword          WakewordConfig `yaml:"wakeword"`
+
+	// AllowDataCollection 是否允许服务端记录对话用于模型微调（默认不允许）
+	AllowDataCollection bool `yaml:"allow_data_collection"`
+
+	// TextOnly 仅文本模式：服务端跳过TTS，只返回识别和回复文本
+	TextOnly bool `yaml:"text_only"`
+
+	// Persona 服务端人设ID（为空时使用服务端默认人设）
+	Persona string `yaml:"persona"`
+
+	// Join 加入其他客户端的会话（目标会话ID），不再开始自己的会话；JoinCode 为会话所有者发出的加入码；
+	// JoinRole 为 speaker（可以说话，需所有者邀请时授予）或 listener（只接收回复，默认）
+	Join     string `yaml:"join"`
+	JoinCode string `yaml:"join_code"`
+	JoinRole string `yaml:"join_role"`
+
+	// Hotkey 全局快捷键（如 ctrl+alt+space），终端不在前台时也生效，作用与空格键相同：
+	// 按键说话模式下开始/结束录音，唤醒词模式下唤醒助手。为空时不注册。
+	Hotkey string `yaml:"hotkey"`
 }
 
 // WakewordConfig 唤醒词配置
@@ -99,19 +173,36 @@ type WakewordConfig struct {
 
 // UIConfig 用户界面配置
 type UIConfig struct {
-	Type                 string        `yaml:"type"`
-	LogLevel             string        `yaml:"log_level"`
-	ShowAudioLevel       bool          `yaml:"show_audio_level"`
-	ShowConnectionStatus bool          `yaml:"show_connection_status"`
-	Console              ConsoleConfig `yaml:"console"`
-	GUI                  GUIConfig     `yaml:"gui"`
+	Type                 string           `yaml:"type"`
+	LogLevel             string           `yaml:"log_level"`
+	ShowAudioLevel       bool             `yaml:"show_audio_level"`
+	ShowConnectionStatus bool             `yaml:"show_connection_status"`
+	Console              ConsoleConfig    `yaml:"console"`
+	GUI                  GUIConfig        `yaml:"gui"`
+	Tray                 TrayConfig       `yaml:"tray"`
+	Transcript           TranscriptConfig `yaml:"transcript"`
+}
+
+// TranscriptConfig 对话文字记录配置：识别结果和助手回复随会话进行写入文件
+type TranscriptConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Format  string `yaml:"format"` // txt|srt|json（json为每行一条记录）
+	File    string `yaml:"file"`   // 文件路径，为空时写入 transcripts/transcript-<启动时间>.<格式>
+}
+
+// TrayConfig 系统托盘配置（需以 -tags tray 编译）
+type TrayConfig struct {
+	Enabled       bool `yaml:"enabled"`        // 在系统托盘显示连接状态图标和麦克风开关
+	NotifyErrors  bool `yaml:"notify_errors"`  // 错误时发送桌面通知
+	NotifyReplies bool `yaml:"notify_replies"` // 助手回复时发送桌面通知
 }
 
 // ConsoleConfig 控制台配置
 type ConsoleConfig struct {
-	ColoredOutput  bool   `yaml:"colored_output"`
-	ShowTimestamps bool   `yaml:"show_timestamps"`
-	Prompt         string `yaml:"prompt"`
+	ColoredOutput   bool   `yaml:"colored_output"`
+	ShowTimestamps  bool   `yaml:"show_timestamps"`
+	ShowWordTimings bool   `yaml:"show_word_timings"`
+	Prompt          string `yaml:"prompt"`
 }
 
 // GUIConfig GUI配置
@@ -125,13 +216,13 @@ type GUIConfig struct {
 // LoggingConfig 日志配置
 type LoggingConfig struct {
 	Level      string `yaml:"level"`
-	Format     string `yaml:"format"`
-	Output     string `yaml:"output"`
-	FilePath   string `yaml:"file_path"`
-	MaxSize    int    `yaml:"max_size"`
-	MaxBackups int    `yaml:"max_backups"`
-	MaxAge     int    `yaml:"max_age"`
-	Compress   bool   `yaml:"compress"`
+	Format     string `yaml:"format"`      // text|json
+	Output     string `yaml:"output"`      // stdout|stderr|file
+	FilePath   string `yaml:"file_path"`   // output为file时的日志文件
+	MaxSize    int    `yaml:"max_size"`    // 单个日志文件的最大大小（MB），超过后轮转
+	MaxBackups int    `yaml:"max_backups"` // 保留的轮转文件数
+	MaxAge     int    `yaml:"max_age"`     // 轮转文件保留天数
+	Compress   bool   `yaml:"compress"`    // 轮转后gzip压缩
 }
 
 // PerformanceConfig 性能配置
@@ -150,22 +241,27 @@ type SecurityConfig struct {
 	Auth AuthConfig `yaml:"auth"`
 }
 
-// TLSConfig TLS配置
+// TLSConfig TLS配置（启用时以wss连接服务器）
 type TLSConfig struct {
 	Enabled            bool   `yaml:"enabled"`
-	CertFile           string `yaml:"cert_file"`
-	KeyFile            string `yaml:"key_file"`
-	CAFile             string `yaml:"ca_file"`
-	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+	CertFile           string `yaml:"cert_file"`            // 客户端证书，双向TLS时与key_file一起配置
+	KeyFile            string `yaml:"key_file"`             // 客户端私钥
+	CAFile             string `yaml:"ca_file"`              // 自签名或私有CA证书，为空时使用系统根证书
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"` // 不验证服务端证书（仅用于测试）
 }
 
 // AuthConfig 认证配置
 type AuthConfig struct {
 	Enabled  bool   `yaml:"enabled"`
-	Type     string `yaml:"type"`
+	Type     string `yaml:"type"` // token|basic
 	Token    string `yaml:"token"`
 	Username string `yaml:"username"`
 	Password string `yaml:"password"`
+
+	// 令牌刷新（token方式）：到期前refresh_before向refresh_url换取新令牌
+	RefreshURL    string        `yaml:"refresh_url"`
+	RefreshToken  string        `yaml:"refresh_token"`
+	RefreshBefore time.Duration `yaml:"refresh_before"`
 }
 
 // AdvancedConfig 高级配置
@@ -176,12 +272,15 @@ type AdvancedConfig struct {
 }
 
 // DebugConfig 调试配置
+// 启用时在Listen地址提供本地调试HTTP服务：/debug/stats 统计快照，/debug/dump 运行时开关转储，ProfileCPU/ProfileMemory 开启对应的pprof接口。
 type DebugConfig struct {
-	Enabled       bool `yaml:"enabled"`
-	DumpAudio     bool `yaml:"dump_audio"`
-	DumpMessages  bool `yaml:"dump_messages"`
-	ProfileCPU    bool `yaml:"profile_cpu"`
-	ProfileMemory bool `yaml:"profile_memory"`
+	Enabled       bool   `yaml:"enabled"`
+	Listen        string `yaml:"listen"`         // 调试HTTP服务监听地址（只应监听本机地址）
+	DumpAudio     bool   `yaml:"dump_audio"`     // 把上传的麦克风音频写入DumpDir下的PCM文件
+	DumpMessages  bool   `yaml:"dump_messages"`  // 日志输出收发的协议消息（长消息截断）
+	DumpDir       string `yaml:"dump_dir"`       // 音频转储目录
+	ProfileCPU    bool   `yaml:"profile_cpu"`    // 提供CPU采样和执行跟踪接口（/debug/pprof/profile、trace）
+	ProfileMemory bool   `yaml:"profile_memory"` // 提供内存分析接口（/debug/pprof/heap、allocs）
 }
 
 // ExperimentalConfig 实验性配置
@@ -189,6 +288,9 @@ type ExperimentalConfig struct {
 	UseBinaryProtocol bool `yaml:"use_binary_protocol"`
 	EnableCompression bool `yaml:"enable_compression"`
 	AdaptiveBitrate   bool `yaml:"adaptive_bitrate"`
+
+	// CompressionThreshold 开启压缩时只压缩不小于该字节数的消息
+	CompressionThreshold int `yaml:"compression_threshold"`
 }
 
 // CompatibilityConfig 兼容性配置
@@ -239,6 +341,34 @@ func validateConfig(config *Config) error {
 	if config.Server.Port <= 0 || config.Server.Port > 65535 {
 		return fmt.Errorf("服务器端口无效: %d", config.Server.Port)
 	}
+	switch config.Server.OfflineBufferPolicy {
+	case "", "drop_oldest", "drop_newest":
+	default:
+		return fmt.Errorf("无效的离线缓冲策略: %s", config.Server.OfflineBufferPolicy)
+	}
+	if config.Server.ReconnectMultiplier != 0 && config.Server.ReconnectMultiplier < 1 {
+		return fmt.Errorf("重连退避倍数不能小于1: %g", config.Server.ReconnectMultiplier)
+	}
+	if config.Server.ReconnectJitter < 0 || config.Server.ReconnectJitter > 1 {
+		return fmt.Errorf("重连抖动比例须在0到1之间: %g", config.Server.ReconnectJitter)
+	}
+	if tlsConfig := config.Security.TLS; tlsConfig.Enabled && (tlsConfig.CertFile == "") != (tlsConfig.KeyFile == "") {
+		return fmt.Errorf("TLS客户端证书和私钥须同时配置")
+	}
+	if auth := config.Security.Auth; auth.Enabled {
+		switch auth.Type {
+		case "", "token":
+			if auth.Token == "" && auth.RefreshURL == "" {
+				return fmt.Errorf("token认证需要配置token或refresh_url")
+			}
+		case "basic":
+			if auth.Username == "" {
+				return fmt.Errorf("basic认证需要配置username")
+			}
+		default:
+			return fmt.Errorf("无效的认证方式: %s", auth.Type)
+		}
+	}
 
 	// 验证音频配置
 	if config.Audio.Input.SampleRate <= 0 {
@@ -247,6 +377,32 @@ func validateConfig(config *Config) error {
 	if config.Audio.Output.SampleRate <= 0 {
 		return fmt.Errorf("输出采样率无效: %d", config.Audio.Output.SampleRate)
 	}
+	switch config.Audio.Input.Source {
+	case "", audio.SourcePortAudio:
+	case audio.SourceNetwork:
+		if err := audio.ValidateNetworkInput(config.ToAudioInputConfig().Network); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("无效的音频输入来源: %s（可选 portaudio、network）", config.Audio.Input.Source)
+	}
+	switch config.Audio.Output.Sink {
+	case "", audio.SinkPortAudio:
+	case audio.SinkNetwork:
+		if err := audio.ValidateNetworkSink(config.ToAudioOutputConfig().Network); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("无效的音频输出目标: %s（可选 portaudio、network）", config.Audio.Output.Sink)
+	}
+	if speed := config.Audio.Output.Speed; speed != 0 {
+		if err := audio.ValidatePlaybackSpeed(speed); err != nil {
+			return err
+		}
+	}
+	if level := config.Audio.Output.Ducking.Level; level < 0 || level > 1 {
+		return fmt.Errorf("音量闪避比例无效: %v（应在0到1之间）", level)
+	}
 
 	// 验证UI配置
 	validUITypes := map[string]bool{"console": true, "gui": true, "headless": true}
@@ -254,6 +410,25 @@ func validateConfig(config *Config) error {
 		return fmt.Errorf("无效的UI类型: %s", config.UI.Type)
 	}
 
+	if format := config.UI.Transcript.Format; format != "" {
+		if err := transcript.ValidateFormat(format); err != nil {
+			return err
+		}
+	}
+
+	if config.Session.Hotkey != "" {
+		if _, err := hotkey.Parse(config.Session.Hotkey); err != nil {
+			return err
+		}
+	}
+
+	if role := config.Session.JoinRole; role != "" && role != protocol.RoleSpeaker && role != protocol.RoleListener {
+		return fmt.Errorf("无效的会话成员角色: %s（应为 speaker 或 listener）", role)
+	}
+	if config.Session.Join != "" && config.Session.JoinCode == "" {
+		return fmt.Errorf("加入会话需要配置 session.join_code（在目标客户端输入 :invite 获取）")
+	}
+
 	return nil
 }
 
@@ -269,9 +444,24 @@ func setDefaults(config *Config) {
 	if config.Server.MaxReconnectAttempts == 0 {
 		config.Server.MaxReconnectAttempts = 10
 	}
+	if config.Server.ReconnectMaxInterval == 0 {
+		config.Server.ReconnectMaxInterval = time.Minute
+	}
+	if config.Server.ReconnectMultiplier == 0 {
+		config.Server.ReconnectMultiplier = 2
+	}
+	if config.Server.ReconnectJitter == 0 {
+		config.Server.ReconnectJitter = 0.5
+	}
+	if config.Server.ReconnectMaxElapsed == 0 {
+		config.Server.ReconnectMaxElapsed = 10 * time.Minute
+	}
 	if config.Server.ConnectionTimeout == 0 {
 		config.Server.ConnectionTimeout = 10 * time.Second
 	}
+	if config.Server.OfflineBufferPolicy == "" {
+		config.Server.OfflineBufferPolicy = "drop_oldest"
+	}
 	if config.Server.PingInterval == 0 {
 		config.Server.PingInterval = 30 * time.Second
 	}
@@ -289,6 +479,9 @@ func setDefaults(config *Config) {
 	if config.Audio.Input.BufferSize == 0 {
 		config.Audio.Input.BufferSize = 1024
 	}
+	if config.Audio.Input.Gain == 0 {
+		config.Audio.Input.Gain = 1.0
+	}
 	if config.Audio.Output.SampleRate == 0 {
 		config.Audio.Output.SampleRate = 16000
 	}
@@ -298,6 +491,19 @@ func setDefaults(config *Config) {
 	if config.Audio.Output.BufferSize == 0 {
 		config.Audio.Output.BufferSize = 1024
 	}
+	if config.Audio.Output.Speed == 0 {
+		config.Audio.Output.Speed = 1.0
+	}
+	if config.Audio.Output.Ducking.Level == 0 {
+		config.Audio.Output.Ducking.Level = 0.3
+	}
+	if config.Audio.Output.Ducking.Release == 0 {
+		config.Audio.Output.Ducking.Release = 500 * time.Millisecond
+	}
+
+	if config.Audio.Duplex.EchoMargin == 0 {
+		config.Audio.Duplex.EchoMargin = 10
+	}
 
 	// VAD默认值
 	if config.Audio.VAD.Threshold == 0 {
@@ -328,6 +534,9 @@ func setDefaults(config *Config) {
 	if config.UI.Console.Prompt == "" {
 		config.UI.Console.Prompt = "语音助手> "
 	}
+	if config.UI.Transcript.Format == "" {
+		config.UI.Transcript.Format = transcript.FormatText
+	}
 
 	// 性能默认值
 	if config.Performance.AudioBufferSize == 0 {
@@ -339,12 +548,20 @@ func setDefaults(config *Config) {
 	if config.Performance.WorkerThreads == 0 {
 		config.Performance.WorkerThreads = 2
 	}
+
+	// 调试默认值
+	if config.Advanced.Debug.Listen == "" {
+		config.Advanced.Debug.Listen = "127.0.0.1:6060"
+	}
+	if config.Advanced.Debug.DumpDir == "" {
+		config.Advanced.Debug.DumpDir = "./debug"
+	}
 }
 
 // GetServerURL 获取服务器URL
 func (c *Config) GetServerURL() string {
 	scheme := "ws"
-	if c.Server.UseTLS {
+	if c.Server.UseTLS || c.Security.TLS.Enabled {
 		scheme = "wss"
 	}
 	return fmt.Sprintf("%s://%s:%d%s", scheme, c.Server.Host, c.Server.Port, c.Server.WebSocketPath)
@@ -357,21 +574,55 @@ func (c *Config) ToClientConfig() client.ClientConfig {
 		SessionID:            "", // 将由客户端生成
 		ReconnectInterval:    c.Server.ReconnectInterval,
 		MaxReconnectAttempts: c.Server.MaxReconnectAttempts,
+		ReconnectMaxInterval: c.Server.ReconnectMaxInterval,
+		ReconnectMultiplier:  c.Server.ReconnectMultiplier,
+		ReconnectJitter:      c.Server.ReconnectJitter,
+		ReconnectMaxElapsed:  c.Server.ReconnectMaxElapsed,
 		ConnectionTimeout:    c.Server.ConnectionTimeout,
 		PingInterval:         c.Server.PingInterval,
 		PongTimeout:          c.Server.PongTimeout,
+		OfflineBufferSize:    c.Server.OfflineBufferSize,
+		OfflineBufferPolicy:  c.Server.OfflineBufferPolicy,
+		EnableCompression:    c.Advanced.Experimental.EnableCompression,
+		CompressionThreshold: c.Advanced.Experimental.CompressionThreshold,
+		TLS: client.TLSConfig{
+			Enabled:            c.Security.TLS.Enabled,
+			CertFile:           c.Security.TLS.CertFile,
+			KeyFile:            c.Security.TLS.KeyFile,
+			CAFile:             c.Security.TLS.CAFile,
+			InsecureSkipVerify: c.Security.TLS.InsecureSkipVerify,
+		},
+		Auth: client.AuthConfig{
+			Enabled:       c.Security.Auth.Enabled,
+			Type:          c.Security.Auth.Type,
+			Token:         c.Security.Auth.Token,
+			Username:      c.Security.Auth.Username,
+			Password:      c.Security.Auth.Password,
+			RefreshURL:    c.Security.Auth.RefreshURL,
+			RefreshToken:  c.Security.Auth.RefreshToken,
+			RefreshBefore: c.Security.Auth.RefreshBefore,
+		},
 	}
 }
 
 // ToAudioInputConfig 转换为音频输入配置
 func (c *Config) ToAudioInputConfig() audio.InputConfig {
 	return audio.InputConfig{
+		Source: c.Audio.Input.Source,
+		Network: audio.NetworkConfig{
+			URL:            c.Audio.Input.Network.URL,
+			Codec:          c.Audio.Input.Network.Codec,
+			SampleRate:     c.Audio.Input.Network.SampleRate,
+			Channels:       c.Audio.Input.Network.Channels,
+			ReconnectDelay: c.Audio.Input.Network.ReconnectDelay,
+		},
 		DeviceID:           c.Audio.Input.DeviceID,
 		SampleRate:         c.Audio.Input.SampleRate,
 		Channels:           c.Audio.Input.Channels,
 		Format:             c.Audio.Input.Format,
 		BufferSize:         c.Audio.Input.BufferSize,
 		ChunkDuration:      c.Audio.Input.ChunkDuration,
+		Gain:               c.Audio.Input.Gain,
 		VADEnabled:         c.Audio.VAD.Enabled,
 		VADThreshold:       c.Audio.VAD.Threshold,
 		MinSpeechDuration:  c.Audio.VAD.MinSpeechDuration,
@@ -382,11 +633,47 @@ func (c *Config) ToAudioInputConfig() audio.InputConfig {
 // ToAudioOutputConfig 转换为音频输出配置
 func (c *Config) ToAudioOutputConfig() audio.OutputConfig {
 	return audio.OutputConfig{
+		Sink: c.Audio.Output.Sink,
+		Network: audio.NetworkSinkConfig{
+			URL:            c.Audio.Output.Network.URL,
+			SampleRate:     c.Audio.Output.Network.SampleRate,
+			Channels:       c.Audio.Output.Network.Channels,
+			ReconnectDelay: c.Audio.Output.Network.ReconnectDelay,
+		},
 		DeviceID:   c.Audio.Output.DeviceID,
 		SampleRate: c.Audio.Output.SampleRate,
 		Channels:   c.Audio.Output.Channels,
 		Format:     c.Audio.Output.Format,
 		BufferSize: c.Audio.Output.BufferSize,
+		Prebuffer:  c.Audio.Output.Prebuffer,
+		Crossfade:  c.Audio.Output.Crossfade,
+		Speed:      c.Audio.Output.Speed,
+		Ducking: audio.DuckingConfig{
+			Enabled: c.Audio.Output.Ducking.Enabled,
+			Level:   c.Audio.Output.Ducking.Level,
+			Release: c.Audio.Output.Ducking.Release,
+		},
+	}
+}
+
+// ToEchoGateConfig 转换为双工模式的回声门限配置
+func (c *Config) ToEchoGateConfig() audio.EchoGateConfig {
+	return audio.EchoGateConfig{
+		Margin:          c.Audio.Duplex.EchoMargin,
+		BargeInDuration: c.Audio.Duplex.BargeInDuration,
+	}
+}
+
+// ToLoggingConfig 转换为日志输出配置
+func (c *Config) ToLoggingConfig() logging.Config {
+	return logging.Config{
+		Format:     c.Logging.Format,
+		Output:     c.Logging.Output,
+		FilePath:   c.Logging.FilePath,
+		MaxSize:    c.Logging.MaxSize,
+		MaxBackups: c.Logging.MaxBackups,
+		MaxAge:     c.Logging.MaxAge,
+		Compress:   c.Logging.Compress,
 	}
 }
 
@@ -414,25 +701,41 @@ func GetDefaultConfig() *Config {
 			WebSocketPath:        "/ws",
 			ReconnectInterval:    5 * time.Second,
 			MaxReconnectAttempts: 10,
+			ReconnectMaxInterval: time.Minute,
+			ReconnectMultiplier:  2,
+			ReconnectJitter:      0.5,
+			ReconnectMaxElapsed:  10 * time.Minute,
 			ConnectionTimeout:    10 * time.Second,
 			PingInterval:         30 * time.Second,
 			PongTimeout:          10 * time.Second,
+			OfflineBufferSize:    300,
+			OfflineBufferPolicy:  "drop_oldest",
 		},
 		Audio: AudioConfig{
 			Input: AudioInputConfig{
+				Source:        "portaudio",
 				DeviceID:      -1,
 				SampleRate:    16000,
 				Channels:      1,
 				Format:        "pcm_16bit",
 				BufferSize:    1024,
 				ChunkDuration: 100,
+				Gain:          1.0,
 			},
 			Output: AudioOutputConfig{
+				Sink:       "portaudio",
 				DeviceID:   -1,
 				SampleRate: 16000,
 				Channels:   1,
 				Format:     "pcm_16bit",
 				BufferSize: 1024,
+				Prebuffer:  200 * time.Millisecond,
+				Crossfade:  10 * time.Millisecond,
+				Speed:      1.0,
+				Ducking: DuckingConfig{
+					Level:   0.3,
+					Release: 500 * time.Millisecond,
+				},
 			},
 			VAD: VADConfig{
 				Enabled:            true,
@@ -441,6 +744,10 @@ func GetDefaultConfig() *Config {
 				MinSilenceDuration: 500,
 				PreEmphasis:        0.97,
 			},
+			Duplex: DuplexConfig{
+				EchoMargin:      10,
+				BargeInDuration: 300 * time.Millisecond,
+			},
 			Processing: ProcessingConfig{
 				NoiseReduction:      true,
 				AutoGainControl:     true,
@@ -465,6 +772,13 @@ func GetDefaultConfig() *Config {
 				ShowTimestamps: true,
 				Prompt:         "语音助手> ",
 			},
+			Tray: TrayConfig{
+				NotifyErrors:  true,
+				NotifyReplies: true,
+			},
+			Transcript: TranscriptConfig{
+				Format: transcript.FormatText,
+			},
 		},
 		Performance: PerformanceConfig{
 			AudioBufferSize:      8192,
@@ -474,6 +788,12 @@ func GetDefaultConfig() *Config {
 			MaxMemoryUsage:       128,
 			GCPercent:            100,
 		},
+		Advanced: AdvancedConfig{
+			Debug: DebugConfig{
+				Listen:  "127.0.0.1:6060",
+				DumpDir: "./debug",
+			},
+		},
 	}
 
 	return config
@@ -0,0 +1,134 @@
+package hotkey
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnsupported 当前系统不支持全局快捷键
+var ErrUnsupported = errors.New("当前系统不支持全局快捷键")
+
+// Modifier 修饰键
+type Modifier uint8
+
+const (
+	ModCtrl Modifier = 1 << iota
+	ModAlt
+	ModShift
+	ModSuper // Windows键 / Super键
+)
+
+// modifierNames 修饰键名称（含常用别名）
+var modifierNames = map[string]Modifier{
+	"ctrl":    ModCtrl,
+	"control": ModCtrl,
+	"alt":     ModAlt,
+	"option":  ModAlt,
+	"shift":   ModShift,
+	"super":   ModSuper,
+	"win":     ModSuper,
+	"cmd":     ModSuper,
+}
+
+// key 按键在各平台的编码
+type key struct {
+	vk     uint16 // Windows虚拟键码
+	keysym uint32 // X11 keysym
+}
+
+// keys 支持的按键：字母、数字、F1-F12及常用功能键
+var keys = func() map[string]key {
+	m := map[string]key{
+		"space":  {0x20, 0x0020},
+		"enter":  {0x0d, 0xff0d},
+		"tab":    {0x09, 0xff09},
+		"escape": {0x1b, 0xff1b},
+		"pause":  {0x13, 0xff13},
+		"insert": {0x2d, 0xff63},
+		"home":   {0x24, 0xff50},
+		"end":    {0x23, 0xff57},
+	}
+	for c := 'a'; c <= 'z'; c++ {
+		m[string(c)] = key{uint16(c - 'a' + 'A'), uint32(c)}
+	}
+	for c := '0'; c <= '9'; c++ {
+		m[string(c)] = key{uint16(c), uint32(c)}
+	}
+	for i := 1; i <= 12; i++ {
+		m[fmt.Sprintf("f%d", i)] = key{uint16(0x70 + i - 1), uint32(0xffbe + i - 1)}
+	}
+	return m
+}()
+
+// Combo 快捷键组合
+type Combo struct {
+	Modifiers Modifier
+	Key       string // 按键名称（小写）
+}
+
+// String 组合的规范写法，如 ctrl+alt+space
+func (c Combo) String() string {
+	var parts []string
+	for _, mod := range []struct {
+		mod  Modifier
+		name string
+	}{{ModCtrl, "ctrl"}, {ModAlt, "alt"}, {ModShift, "shift"}, {ModSuper, "super"}} {
+		if c.Modifiers&mod.mod != 0 {
+			parts = append(parts, mod.name)
+		}
+	}
+	return strings.Join(append(parts, c.Key), "+")
+}
+
+// Parse 解析快捷键组合，如 "ctrl+alt+space"、"Ctrl+Shift+F9"（不区分大小写）
+// 全局快捷键至少需要一个修饰键，F1-F12 和 pause 除外，以免占用正常输入。
+func Parse(s string) (Combo, error) {
+	var combo Combo
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(s)), "+")
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if i < len(parts)-1 {
+			mod, ok := modifierNames[part]
+			if !ok {
+				return Combo{}, fmt.Errorf("快捷键 %q 中的修饰键无效: %q（可用 ctrl、alt、shift、super）", s, part)
+			}
+			combo.Modifiers |= mod
+			continue
+		}
+		if _, ok := keys[part]; !ok {
+			return Combo{}, fmt.Errorf("快捷键 %q 中的按键无效: %q", s, part)
+		}
+		combo.Key = part
+	}
+
+	functionKey := len(combo.Key) > 1 && combo.Key[0] == 'f' || combo.Key == "pause"
+	if combo.Modifiers == 0 && !functionKey {
+		return Combo{}, fmt.Errorf("快捷键 %q 需要至少一个修饰键（如 ctrl+alt+%s）", s, combo.Key)
+	}
+	return combo, nil
+}
+
+// Hotkey 已注册的全局快捷键，按下时从Events收到一个事件（按住不放不会重复触发）
+type Hotkey struct {
+	events chan struct{}
+	stop   func()
+}
+
+// Events 快捷键按下事件（Close后关闭）
+func (h *Hotkey) Events() <-chan struct{} {
+	return h.events
+}
+
+// Close 注销快捷键
+func (h *Hotkey) Close() {
+	h.stop()
+}
+
+// emit 发出按下事件（上一个事件尚未处理时丢弃）
+func (h *Hotkey) emit() {
+	select {
+	case h.events <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,96 @@
+package hotkey
+
+import (
+	"fmt"
+
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/xproto"
+)
+
+// lockMasks 注册时同时抓取CapsLock、NumLock的组合，否则开启这些锁定键后快捷键失效
+var lockMasks = []uint16{0, xproto.ModMaskLock, xproto.ModMask2, xproto.ModMaskLock | xproto.ModMask2}
+
+// Register 注册全局快捷键（X11的XGrabKey，需要DISPLAY；Wayland会话中只对XWayland窗口生效）
+func Register(combo Combo) (*Hotkey, error) {
+	k, ok := keys[combo.Key]
+	if !ok {
+		return nil, fmt.Errorf("不支持的按键: %s", combo.Key)
+	}
+
+	conn, err := xgb.NewConn()
+	if err != nil {
+		return nil, fmt.Errorf("%w: 无法连接X11显示服务（%v）", ErrUnsupported, err)
+	}
+	setup := xproto.Setup(conn)
+	root := setup.DefaultScreen(conn).Root
+
+	keycode, err := lookupKeycode(conn, setup, xproto.Keysym(k.keysym))
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	var mods uint16
+	for mod, mask := range map[Modifier]uint16{ModCtrl: xproto.ModMaskControl, ModAlt: xproto.ModMask1, ModShift: xproto.ModMaskShift, ModSuper: xproto.ModMask4} {
+		if combo.Modifiers&mod != 0 {
+			mods |= mask
+		}
+	}
+	for _, lock := range lockMasks {
+		if err := xproto.GrabKeyChecked(conn, true, root, mods|lock, keycode, xproto.GrabModeAsync, xproto.GrabModeAsync).Check(); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("注册快捷键 %s 失败（可能已被其他程序占用）: %v", combo, err)
+		}
+	}
+
+	h := &Hotkey{events: make(chan struct{}, 1)}
+	h.stop = func() {
+		for _, lock := range lockMasks {
+			xproto.UngrabKey(conn, keycode, root, mods|lock)
+		}
+		conn.Close()
+	}
+
+	go func() {
+		defer close(h.events)
+		// 按住不放时X11以相同时间戳的释放/按下事件对自动重复，据此忽略重复的按下
+		var lastRelease xproto.Timestamp
+		for {
+			ev, xerr := conn.WaitForEvent()
+			if ev == nil && xerr == nil {
+				return // 连接已关闭
+			}
+			switch e := ev.(type) {
+			case xproto.KeyPressEvent:
+				if e.Detail == keycode && e.Time != lastRelease {
+					h.emit()
+				}
+			case xproto.KeyReleaseEvent:
+				if e.Detail == keycode {
+					lastRelease = e.Time
+				}
+			}
+		}
+	}()
+	return h, nil
+}
+
+// lookupKeycode 在当前键盘映射中查找keysym对应的键码
+func lookupKeycode(conn *xgb.Conn, setup *xproto.SetupInfo, keysym xproto.Keysym) (xproto.Keycode, error) {
+	first := setup.MinKeycode
+	count := int(setup.MaxKeycode) - int(first) + 1
+	mapping, err := xproto.GetKeyboardMapping(conn, first, byte(count)).Reply()
+	if err != nil {
+		return 0, fmt.Errorf("读取键盘映射失败: %w", err)
+	}
+
+	perKeycode := int(mapping.KeysymsPerKeycode)
+	for i := 0; i < count; i++ {
+		for j := 0; j < perKeycode; j++ {
+			if mapping.Keysyms[i*perKeycode+j] == keysym {
+				return xproto.Keycode(int(first) + i), nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("当前键盘布局中没有按键 0x%x", uint32(keysym))
+}
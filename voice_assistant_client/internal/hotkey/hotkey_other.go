@@ -0,0 +1,13 @@
+//go:build !windows && !linux
+
+package hotkey
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// Register 当前系统不支持全局快捷键（Windows和Linux X11之外）
+func Register(combo Combo) (*Hotkey, error) {
+	return nil, fmt.Errorf("%w: %s", ErrUnsupported, runtime.GOOS)
+}
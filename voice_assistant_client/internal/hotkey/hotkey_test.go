@@ -0,0 +1,28 @@
+package hotkey
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	combo, err := Parse(" Ctrl + Alt + Space ")
+	require.NoError(t, err)
+	assert.Equal(t, Combo{Modifiers: ModCtrl | ModAlt, Key: "space"}, combo)
+	assert.Equal(t, "ctrl+alt+space", combo.String())
+
+	combo, err = Parse("win+shift+f9")
+	require.NoError(t, err)
+	assert.Equal(t, "shift+super+f9", combo.String())
+
+	// 功能键可以不带修饰键
+	_, err = Parse("f8")
+	assert.NoError(t, err)
+
+	for _, invalid := range []string{"", "space", "ctrl+", "hyper+a", "ctrl+alt+ß", "a+b", "f"} {
+		_, err := Parse(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
@@ -0,0 +1,92 @@
+package hotkey
+
+import (
+	"fmt"
+	"runtime"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	user32                 = windows.NewLazySystemDLL("user32.dll")
+	procRegisterHotKey     = user32.NewProc("RegisterHotKey")
+	procUnregisterHotKey   = user32.NewProc("UnregisterHotKey")
+	procGetMessageW        = user32.NewProc("GetMessageW")
+	procPostThreadMessageW = user32.NewProc("PostThreadMessageW")
+)
+
+// Windows消息和修饰键常量
+const (
+	wmQuit      = 0x0012
+	wmHotkey    = 0x0312
+	modAlt      = 0x0001
+	modControl  = 0x0002
+	modShift    = 0x0004
+	modWin      = 0x0008
+	modNoRepeat = 0x4000
+	hotkeyID    = 1
+)
+
+// winMsg Windows MSG结构
+type winMsg struct {
+	hwnd    uintptr
+	message uint32
+	wParam  uintptr
+	lParam  uintptr
+	time    uint32
+	pt      struct{ x, y int32 }
+}
+
+// Register 注册全局快捷键（RegisterHotKey，消息循环运行在独占的系统线程上）
+func Register(combo Combo) (*Hotkey, error) {
+	k, ok := keys[combo.Key]
+	if !ok {
+		return nil, fmt.Errorf("不支持的按键: %s", combo.Key)
+	}
+	mods := uintptr(modNoRepeat)
+	for mod, flag := range map[Modifier]uintptr{ModCtrl: modControl, ModAlt: modAlt, ModShift: modShift, ModSuper: modWin} {
+		if combo.Modifiers&mod != 0 {
+			mods |= flag
+		}
+	}
+
+	h := &Hotkey{events: make(chan struct{}, 1)}
+	registered := make(chan error, 1)
+	threadID := make(chan uint32, 1)
+	go func() {
+		// 快捷键消息发往注册它的线程
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+		defer close(h.events)
+
+		if ok, _, err := procRegisterHotKey.Call(0, hotkeyID, mods, uintptr(k.vk)); ok == 0 {
+			registered <- fmt.Errorf("注册快捷键 %s 失败（可能已被其他程序占用）: %w", combo, err)
+			return
+		}
+		defer procUnregisterHotKey.Call(0, hotkeyID)
+		threadID <- windows.GetCurrentThreadId()
+		registered <- nil
+
+		var m winMsg
+		for {
+			// GetMessageW 收到 WM_QUIT 返回0，出错返回-1
+			ret, _, _ := procGetMessageW.Call(uintptr(unsafe.Pointer(&m)), 0, 0, 0)
+			if int32(ret) <= 0 {
+				return
+			}
+			if m.message == wmHotkey && m.wParam == hotkeyID {
+				h.emit()
+			}
+		}
+	}()
+
+	if err := <-registered; err != nil {
+		return nil, err
+	}
+	tid := <-threadID
+	h.stop = func() {
+		procPostThreadMessageW.Call(uintptr(tid), wmQuit, 0, 0)
+	}
+	return h, nil
+}
@@ -0,0 +1,173 @@
+package transcript
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// 文字记录格式
+const (
+	FormatText = "txt"
+	FormatSRT  = "srt"
+	FormatJSON = "json" // 每行一个JSON对象（JSON Lines），会话进行中也是完整可读的文件
+)
+
+// 说话人
+const (
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// 字幕显示时长：按字数估算阅读时间，限制在上下限之间
+const (
+	subtitlePerRune = 200 * time.Millisecond
+	subtitleMin     = 1500 * time.Millisecond
+	subtitleMax     = 10 * time.Second
+)
+
+// Entry 一条对话记录
+type Entry struct {
+	Time       time.Time `json:"time"`
+	OffsetMs   int64     `json:"offset_ms"` // 相对记录开始的毫秒数
+	Role       string    `json:"role"`
+	Text       string    `json:"text"`
+	Confidence float64   `json:"confidence,omitempty"` // 识别置信度（用户）
+}
+
+// Writer 会话文字记录：识别的最终结果和助手回复随会话进行逐条写入文件
+type Writer struct {
+	mu     sync.Mutex
+	file   *os.File
+	format string
+	start  time.Time
+	cues   int // 已写入的字幕序号（srt）
+}
+
+// ValidateFormat 检查文字记录格式
+func ValidateFormat(format string) error {
+	switch format {
+	case FormatText, FormatSRT, FormatJSON:
+		return nil
+	}
+	return fmt.Errorf("无效的文字记录格式: %s（可选 txt、srt、json）", format)
+}
+
+// DefaultPath 默认文件路径：transcripts/transcript-<启动时间>.<格式>
+func DefaultPath(format string, now time.Time) string {
+	return filepath.Join("transcripts", fmt.Sprintf("transcript-%s.%s", now.Format("20060102-150405"), format))
+}
+
+// Open 打开文字记录文件（path为空时使用默认路径）
+// txt 和 json 格式追加到已有文件末尾；srt 的字幕时间相对本次记录开始，总是重新创建文件。
+func Open(path, format string) (*Writer, error) {
+	if err := ValidateFormat(format); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	if path == "" {
+		path = DefaultPath(format, start)
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("创建文字记录目录失败: %w", err)
+		}
+	}
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if format == FormatSRT {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	}
+	file, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("打开文字记录文件失败: %w", err)
+	}
+	return &Writer{file: file, format: format, start: start}, nil
+}
+
+// Path 文件路径
+func (w *Writer) Path() string {
+	return w.file.Name()
+}
+
+// Write 写入一条记录（空文本忽略）
+func (w *Writer) Write(role, text string, confidence float64) error {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+
+	now := time.Now()
+	entry := Entry{Time: now, OffsetMs: now.Sub(w.start).Milliseconds(), Role: role, Text: text, Confidence: confidence}
+
+	var record string
+	switch w.format {
+	case FormatText:
+		record = fmt.Sprintf("[%s] %s: %s\n", now.Format("2006-01-02 15:04:05"), roleName(role), text)
+	case FormatSRT:
+		w.cues++
+		offset := now.Sub(w.start)
+		record = fmt.Sprintf("%d\n%s --> %s\n%s: %s\n\n", w.cues,
+			srtTime(offset), srtTime(offset+subtitleDuration(text)), roleName(role), text)
+	case FormatJSON:
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		record = string(data) + "\n"
+	}
+
+	if _, err := w.file.WriteString(record); err != nil {
+		return fmt.Errorf("写入文字记录失败: %w", err)
+	}
+	return nil
+}
+
+// Close 关闭文件
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// roleName 说话人的显示名称
+func roleName(role string) string {
+	if role == RoleUser {
+		return "用户"
+	}
+	return "助手"
+}
+
+// subtitleDuration 按字数估算字幕显示时长
+func subtitleDuration(text string) time.Duration {
+	d := time.Duration(utf8.RuneCountInString(text)) * subtitlePerRune
+	if d < subtitleMin {
+		return subtitleMin
+	}
+	if d > subtitleMax {
+		return subtitleMax
+	}
+	return d
+}
+
+// srtTime SRT时间格式 HH:MM:SS,mmm
+func srtTime(d time.Duration) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d,%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
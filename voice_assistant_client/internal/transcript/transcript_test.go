@@ -0,0 +1,56 @@
+package transcript
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriterFormats(t *testing.T) {
+	dir := t.TempDir()
+
+	// srt：序号递增，时间相对记录开始
+	w, err := Open(filepath.Join(dir, "a.srt"), FormatSRT)
+	require.NoError(t, err)
+	require.NoError(t, w.Write(RoleUser, "今天天气怎么样", 0.95))
+	require.NoError(t, w.Write(RoleAssistant, "  ", 0))
+	require.NoError(t, w.Write(RoleAssistant, "今天晴，最高气温二十五度。", 0))
+	require.NoError(t, w.Close())
+	data, err := os.ReadFile(filepath.Join(dir, "a.srt"))
+	require.NoError(t, err)
+	lines := strings.Split(string(data), "\n")
+	assert.Equal(t, "1", lines[0])
+	assert.Regexp(t, `^00:00:00,\d{3} --> 00:00:01,\d{3}$`, lines[1])
+	assert.Equal(t, "用户: 今天天气怎么样", lines[2])
+	assert.Equal(t, "2", lines[4])
+	assert.Equal(t, "助手: 今天晴，最高气温二十五度。", lines[6])
+
+	// json：每行一条记录，再次打开时追加
+	path := filepath.Join(dir, "sub", "a.json")
+	for i := 0; i < 2; i++ {
+		w, err = Open(path, FormatJSON)
+		require.NoError(t, err)
+		require.NoError(t, w.Write(RoleUser, "你好", 0.9))
+		require.NoError(t, w.Close())
+	}
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	records := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, records, 2)
+	var entry Entry
+	require.NoError(t, json.Unmarshal([]byte(records[1]), &entry))
+	assert.Equal(t, RoleUser, entry.Role)
+	assert.Equal(t, "你好", entry.Text)
+
+	assert.Error(t, ValidateFormat("docx"))
+}
+
+func TestSRTTime(t *testing.T) {
+	assert.Equal(t, "01:02:03,045", srtTime(time.Hour+2*time.Minute+3*time.Second+45*time.Millisecond))
+}
@@ -0,0 +1,161 @@
+package ui
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"voice_assistant/pkg/protocol"
+)
+
+// 无界面模式事件类型
+const (
+	EventASR        = "asr"
+	EventLLM        = "llm"
+	EventTTS        = "tts"
+	EventStatus     = "status"
+	EventError      = "error"
+	EventMessage    = "message"
+	EventConnection = "connection"
+	EventNotify     = "notification"
+	EventVAD        = "vad" // 本地VAD检测到的说话状态（state为speech_start或speech_end）
+)
+
+// Event 无界面模式输出的事件（每行一个JSON对象）
+type Event struct {
+	Type       string                `json:"type"`                  // 事件类型
+	Timestamp  int64                 `json:"timestamp"`             // 时间戳（毫秒）
+	Content    string                `json:"content,omitempty"`     // 文本内容
+	Confidence float64               `json:"confidence,omitempty"`  // 置信度
+	IsFinal    *bool                 `json:"is_final,omitempty"`    // 是否为最终结果（asr/llm）
+	Words      []protocol.WordTiming `json:"words,omitempty"`       // 词级别时间戳
+	State      string                `json:"state,omitempty"`       // 会话状态
+	Mode       string                `json:"mode,omitempty"`        // 会话模式
+	Code       string                `json:"code,omitempty"`        // 错误代码
+	AudioBytes int                   `json:"audio_bytes,omitempty"` // 音频数据大小（tts）
+	PlayAt     int64                 `json:"play_at,omitempty"`     // 计划播放时间（tts，服务端时钟毫秒）
+	LatencyMs  int64                 `json:"latency_ms,omitempty"`  // Ping往返时延（connection）
+	Kind       string                `json:"kind,omitempty"`        // 通知类型（notification）
+	Level      string                `json:"level,omitempty"`       // 通知级别（notification）
+	Title      string                `json:"title,omitempty"`       // 通知标题（notification）
+}
+
+// HeadlessUI 无界面模式：向标准输出写入换行分隔的JSON事件，便于脚本嵌入或管道处理
+// 日志仍写入标准错误，不会混入事件流。
+type HeadlessUI struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+}
+
+// NewHeadlessUI 创建无界面模式输出
+func NewHeadlessUI(w io.Writer) *HeadlessUI {
+	if w == nil {
+		w = os.Stdout
+	}
+	return &HeadlessUI{
+		encoder: json.NewEncoder(w),
+	}
+}
+
+// ShowASRResult 输出ASR识别事件
+func (h *HeadlessUI) ShowASRResult(content string, confidence float64, isFinal bool, words []protocol.WordTiming) {
+	h.emit(Event{
+		Type:       EventASR,
+		Content:    content,
+		Confidence: confidence,
+		IsFinal:    &isFinal,
+		Words:      words,
+	})
+}
+
+// ShowLLMResponse 输出LLM回复事件
+func (h *HeadlessUI) ShowLLMResponse(content string, isFinal bool) {
+	h.emit(Event{
+		Type:    EventLLM,
+		Content: content,
+		IsFinal: &isFinal,
+	})
+}
+
+// ShowTTSAudio 输出TTS音频事件
+func (h *HeadlessUI) ShowTTSAudio(audioBytes int, playAt int64) {
+	h.emit(Event{
+		Type:       EventTTS,
+		AudioBytes: audioBytes,
+		PlayAt:     playAt,
+	})
+}
+
+// UpdateStatus 输出状态事件
+func (h *HeadlessUI) UpdateStatus(state, mode string) {
+	h.emit(Event{
+		Type:  EventStatus,
+		State: state,
+		Mode:  mode,
+	})
+}
+
+// ShowVoiceActivity 输出说话状态事件
+func (h *HeadlessUI) ShowVoiceActivity(speaking bool) {
+	state := protocol.StateSpeechEnd
+	if speaking {
+		state = protocol.StateSpeechStart
+	}
+	h.emit(Event{
+		Type:  EventVAD,
+		State: state,
+	})
+}
+
+// UpdateConnectionStatus 输出连接状态事件
+func (h *HeadlessUI) UpdateConnectionStatus(state string, latency time.Duration, detail string) {
+	h.emit(Event{
+		Type:      EventConnection,
+		State:     state,
+		Content:   detail,
+		LatencyMs: latency.Milliseconds(),
+	})
+}
+
+// ShowError 输出错误事件
+func (h *HeadlessUI) ShowError(code, message string) {
+	h.emit(Event{
+		Type:    EventError,
+		Code:    code,
+		Content: message,
+	})
+}
+
+// ShowMessage 输出提示消息事件
+func (h *HeadlessUI) ShowMessage(message string) {
+	h.emit(Event{
+		Type:    EventMessage,
+		Content: message,
+	})
+}
+
+// ShowNotification 输出服务端推送的通知事件
+func (h *HeadlessUI) ShowNotification(kind, level, title, content string) {
+	h.emit(Event{
+		Type:    EventNotify,
+		Kind:    kind,
+		Level:   level,
+		Title:   title,
+		Content: content,
+	})
+}
+
+// emit 写入一行事件
+func (h *HeadlessUI) emit(event Event) {
+	event.Timestamp = time.Now().UnixMilli()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err := h.encoder.Encode(event); err != nil {
+		log.Printf("输出事件失败: %v", err)
+	}
+}
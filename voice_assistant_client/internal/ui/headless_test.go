@@ -0,0 +1,47 @@
+package ui
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"voice_assistant/pkg/protocol"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeadlessEventStream(t *testing.T) {
+	var out bytes.Buffer
+	h := NewHeadlessUI(&out)
+	h.ShowASRResult("你好", 0.9, false, nil)
+	h.ShowLLMResponse("你好，有什么可以帮你？", true)
+	h.ShowTTSAudio(3200, 0)
+	h.ShowVoiceActivity(true)
+	h.ShowError("ASR_FAILED", "识别失败")
+
+	// 每行一个完整的JSON对象
+	var lines []map[string]interface{}
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		var line map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line), scanner.Text())
+		assert.Greater(t, line["timestamp"], 0.0)
+		lines = append(lines, line)
+	}
+	require.Len(t, lines, 5)
+
+	// 中间结果也输出is_final，未设置的字段省略
+	assert.Equal(t, EventASR, lines[0]["type"])
+	assert.Equal(t, false, lines[0]["is_final"])
+	assert.NotContains(t, lines[0], "words")
+	assert.Equal(t, EventLLM, lines[1]["type"])
+	assert.Equal(t, true, lines[1]["is_final"])
+	assert.Equal(t, 3200.0, lines[2]["audio_bytes"])
+	assert.NotContains(t, lines[2], "play_at")
+	assert.Equal(t, protocol.StateSpeechStart, lines[3]["state"])
+	assert.Equal(t, map[string]interface{}{
+		"type": EventError, "timestamp": lines[4]["timestamp"], "code": "ASR_FAILED", "content": "识别失败",
+	}, lines[4])
+}
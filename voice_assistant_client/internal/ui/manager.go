@@ -1,11 +1,43 @@
 package ui
 
 import (
+	"bufio"
 	"context"
 	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"voice_assistant/pkg/protocol"
 	"voice_assistant/voice_assistant_client/internal/config"
+	"voice_assistant/voice_assistant_client/internal/transcript"
+)
+
+// KeyEvent 键盘事件
+type KeyEvent struct {
+	Key     rune   // 按键字符（回车统一为KeyEnter）
+	Command string // 命令模式输入的命令（不含开头的冒号），非空时Key为0
+}
+
+// 连接状态（UpdateConnectionStatus）
+const (
+	ConnectionConnecting   = "connecting"
+	ConnectionConnected    = "connected"
+	ConnectionReconnecting = "reconnecting"
+	ConnectionDisconnected = "disconnected"
+)
+
+// 常用按键
+const (
+	KeySpace     = ' '
+	KeyEnter     = '\n'
+	KeyCommand   = ':' // 进入命令模式
+	keyEscape    = 0x1b
+	keyBackspace = 0x7f
 )
 
 // Manager UI管理器
@@ -16,7 +48,12 @@ type Manager struct {
 	isRunning bool
 
 	// 显示组件
-	console *ConsoleUI
+	console  *ConsoleUI
+	headless *HeadlessUI
+	tray     *Tray // 系统托盘（未启用时为nil），与控制台或无界面模式同时使用
+
+	// 对话文字记录（未启用时为nil）
+	transcript *transcript.Writer
 }
 
 // NewManager 创建UI管理器
@@ -28,11 +65,25 @@ func NewManager(config config.UIConfig) *Manager {
 
 // Start 启动UI
 func (m *Manager) Start(ctx context.Context) error {
-	if m.config.Type == "console" {
+	switch m.config.Type {
+	case "console":
 		m.console = NewConsoleUI(m.config.Console)
 		if err := m.console.Start(ctx); err != nil {
 			return fmt.Errorf("启动控制台UI失败: %w", err)
 		}
+	case "headless":
+		m.headless = NewHeadlessUI(os.Stdout)
+	}
+	if m.config.Tray.Enabled {
+		m.tray = NewTray()
+	}
+	if m.config.Transcript.Enabled {
+		writer, err := transcript.Open(m.config.Transcript.File, m.config.Transcript.Format)
+		if err != nil {
+			return fmt.Errorf("启动对话记录失败: %w", err)
+		}
+		m.transcript = writer
+		log.Printf("对话记录写入: %s", writer.Path())
 	}
 
 	m.isRunning = true
@@ -48,15 +99,67 @@ func (m *Manager) Stop() error {
 	if m.console != nil {
 		m.console.Stop()
 	}
+	if m.transcript != nil {
+		if err := m.transcript.Close(); err != nil {
+			log.Printf("关闭对话记录失败: %v", err)
+		}
+	}
 
 	m.isRunning = false
 	return nil
 }
 
-// ShowASRResult 显示ASR识别结果
-func (m *Manager) ShowASRResult(content string, confidence float64, isFinal bool) {
+// RunTray 运行系统托盘事件循环，直到调用QuitTray或从托盘菜单退出（macOS要求在主协程中调用）
+func (m *Manager) RunTray(actions TrayActions) error {
+	if m.tray == nil {
+		return fmt.Errorf("未启用系统托盘")
+	}
+	return m.tray.Run(actions)
+}
+
+// QuitTray 结束系统托盘事件循环
+func (m *Manager) QuitTray() {
+	if m.tray != nil {
+		m.tray.Quit()
+	}
+}
+
+// SetMicrophone 同步托盘菜单中的麦克风开关状态
+func (m *Manager) SetMicrophone(enabled bool) {
+	if m.tray != nil {
+		m.tray.SetMicrophone(enabled)
+	}
+}
+
+// notify 启用系统托盘时在后台发送桌面通知
+func (m *Manager) notify(title, message string) {
+	go func() {
+		if err := Notify(title, message); err != nil {
+			log.Printf("%v", err)
+		}
+	}()
+}
+
+// ShowASRResult 显示ASR识别结果（中间结果会被最终结果替换）
+func (m *Manager) ShowASRResult(content string, confidence float64, isFinal bool, words []protocol.WordTiming) {
 	if m.console != nil {
-		m.console.ShowASRResult(content, confidence, isFinal)
+		m.console.ShowASRResult(content, confidence, isFinal, words)
+	}
+	if m.headless != nil {
+		m.headless.ShowASRResult(content, confidence, isFinal, words)
+	}
+	if isFinal {
+		m.record(transcript.RoleUser, content, confidence)
+	}
+}
+
+// record 向对话记录写入一条最终结果
+func (m *Manager) record(role, text string, confidence float64) {
+	if m.transcript == nil {
+		return
+	}
+	if err := m.transcript.Write(role, text, confidence); err != nil {
+		log.Printf("%v", err)
 	}
 }
 
@@ -65,6 +168,22 @@ func (m *Manager) ShowLLMResponse(content string, isFinal bool) {
 	if m.console != nil {
 		m.console.ShowLLMResponse(content, isFinal)
 	}
+	if m.headless != nil {
+		m.headless.ShowLLMResponse(content, isFinal)
+	}
+	if m.tray != nil && m.config.Tray.NotifyReplies && isFinal && content != "" {
+		m.notify("语音助手", content)
+	}
+	if isFinal {
+		m.record(transcript.RoleAssistant, content, 0)
+	}
+}
+
+// ShowTTSAudio 通知收到TTS音频（仅无界面模式输出事件）
+func (m *Manager) ShowTTSAudio(audioBytes int, playAt int64) {
+	if m.headless != nil {
+		m.headless.ShowTTSAudio(audioBytes, playAt)
+	}
 }
 
 // UpdateStatus 更新状态
@@ -72,6 +191,22 @@ func (m *Manager) UpdateStatus(state, mode string) {
 	if m.console != nil {
 		m.console.UpdateStatus(state, mode)
 	}
+	if m.headless != nil {
+		m.headless.UpdateStatus(state, mode)
+	}
+	if m.tray != nil {
+		m.tray.UpdateStatus(state, mode)
+	}
+}
+
+// ShowVoiceActivity 显示本地VAD检测到的说话状态（不等识别结果，说话时显示正在听，说完时显示已听到）
+func (m *Manager) ShowVoiceActivity(speaking bool) {
+	if m.console != nil {
+		m.console.ShowVoiceActivity(speaking)
+	}
+	if m.headless != nil {
+		m.headless.ShowVoiceActivity(speaking)
+	}
 }
 
 // ShowError 显示错误
@@ -79,6 +214,12 @@ func (m *Manager) ShowError(code, message string) {
 	if m.console != nil {
 		m.console.ShowError(code, message)
 	}
+	if m.headless != nil {
+		m.headless.ShowError(code, message)
+	}
+	if m.tray != nil && m.config.Tray.NotifyErrors {
+		m.notify("语音助手出错", fmt.Sprintf("%s: %s", code, message))
+	}
 }
 
 // ShowMessage 显示消息
@@ -86,6 +227,79 @@ func (m *Manager) ShowMessage(message string) {
 	if m.console != nil {
 		m.console.ShowMessage(message)
 	}
+	if m.headless != nil {
+		m.headless.ShowMessage(message)
+	}
+}
+
+// ShowNotification 显示服务端推送的通知（托盘模式同时发送桌面通知）
+func (m *Manager) ShowNotification(kind, level, title, content string) {
+	if m.console != nil {
+		message := content
+		if title != "" {
+			message = fmt.Sprintf("%s：%s", title, content)
+		}
+		m.console.ShowMessage(fmt.Sprintf("%s %s", notificationIcon(kind, level), message))
+	}
+	if m.headless != nil {
+		m.headless.ShowNotification(kind, level, title, content)
+	}
+	if m.tray != nil {
+		if title == "" {
+			title = "语音助手"
+		}
+		m.notify(title, content)
+	}
+}
+
+// notificationIcon 通知类型对应的图标
+func notificationIcon(kind, level string) string {
+	switch {
+	case level == protocol.NotifyCritical:
+		return "🚨"
+	case kind == protocol.NotifyAlert || level == protocol.NotifyWarning:
+		return "⚠️"
+	case kind == protocol.NotifyUpdate:
+		return "⬆️"
+	case kind == protocol.NotifyTimer:
+		return "⏰"
+	case kind == protocol.NotifyReminder:
+		return "🔔"
+	default:
+		return "📢"
+	}
+}
+
+// StartKeyboard 启动键盘事件循环（仅控制台UI支持）
+func (m *Manager) StartKeyboard(ctx context.Context) (<-chan KeyEvent, error) {
+	if m.console == nil {
+		return nil, fmt.Errorf("当前UI类型不支持键盘输入: %s", m.config.Type)
+	}
+	return m.console.StartKeyboard(ctx), nil
+}
+
+// RestoreTerminal 恢复终端输入模式（退出前的兜底清理，可重复调用）
+func (m *Manager) RestoreTerminal() {
+	if m.console != nil {
+		m.console.restoreTerminal()
+	}
+}
+
+// UpdateConnectionStatus 更新连接状态（托盘图标总是更新，控制台和事件流在 show_connection_status 关闭时不显示）
+// latency 为最近一次Ping往返时延（未测得时为0），detail 为附加说明（如重连进度）。
+func (m *Manager) UpdateConnectionStatus(state string, latency time.Duration, detail string) {
+	if m.tray != nil {
+		m.tray.UpdateConnectionStatus(state, latency, detail)
+	}
+	if !m.config.ShowConnectionStatus {
+		return
+	}
+	if m.console != nil {
+		m.console.UpdateConnectionStatus(state, latency, detail)
+	}
+	if m.headless != nil {
+		m.headless.UpdateConnectionStatus(state, latency, detail)
+	}
 }
 
 // UpdateAudioLevel 更新音频级别
@@ -106,6 +320,18 @@ type ConsoleUI struct {
 	currentState string
 	currentMode  string
 	lastUpdate   time.Time
+
+	// 当前行是否为未完成的识别中间结果
+	partialActive bool
+
+	// 终端原始状态（单键输入模式下用于恢复）
+	ttyMu         sync.Mutex
+	savedTTYState string
+
+	// 连接状态行：statusRows 为终端行数（已在底部保留状态行时大于0）
+	statusMu        sync.Mutex
+	connectionState string
+	statusRows      int
 }
 
 // NewConsoleUI 创建控制台UI
@@ -132,29 +358,189 @@ func (c *ConsoleUI) Stop() error {
 	}
 
 	c.isRunning = false
+	c.releaseStatusLine()
+	c.restoreTerminal()
 	fmt.Println("\n再见！👋")
 	return nil
 }
 
+// StartKeyboard 启动键盘事件循环
+// 终端支持时切换为单键输入（空格、回车立即生效），否则退化为按行读取（仅回车生效）。
+// 输入冒号进入命令模式，输入命令后按回车作为一个命令事件发出（单键模式下按Esc取消）。
+func (c *ConsoleUI) StartKeyboard(ctx context.Context) <-chan KeyEvent {
+	events := make(chan KeyEvent, 10)
+	rawMode := c.enableSingleKeyInput()
+	if rawMode {
+		// 开始退出时立即恢复终端，不依赖停止流程走完（停止卡住或中途出错时终端也不会停留在无回显状态）
+		go func() {
+			<-ctx.Done()
+			c.restoreTerminal()
+		}()
+	}
+
+	go c.keyboardLoop(ctx, events, rawMode)
+	return events
+}
+
+// keyboardLoop 键盘读取循环
+func (c *ConsoleUI) keyboardLoop(ctx context.Context, events chan<- KeyEvent, rawMode bool) {
+	defer close(events)
+
+	reader := bufio.NewReader(os.Stdin)
+	var command []rune
+	inCommand := false
+	for {
+		var event KeyEvent
+		if rawMode {
+			r, _, err := reader.ReadRune()
+			if err != nil {
+				return
+			}
+			if r == '\r' {
+				r = KeyEnter
+			}
+
+			switch {
+			case !inCommand && r == KeyCommand:
+				inCommand, command = true, command[:0]
+				fmt.Print("\r\033[K:")
+				continue
+			case !inCommand:
+				event = KeyEvent{Key: r}
+			case r == KeyEnter:
+				inCommand = false
+				fmt.Println()
+				event = KeyEvent{Command: strings.TrimSpace(string(command))}
+				if event.Command == "" {
+					continue
+				}
+			case r == keyEscape:
+				inCommand = false
+				fmt.Print("\r\033[K")
+				continue
+			case r == keyBackspace || r == '\b':
+				if len(command) > 0 {
+					command = command[:len(command)-1]
+					fmt.Print("\b \b")
+				}
+				continue
+			default:
+				command = append(command, r)
+				fmt.Print(string(r))
+				continue
+			}
+		} else {
+			// 按行读取时每行代表一次回车，以冒号开头的行为命令
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimSpace(line)
+			if strings.HasPrefix(line, string(KeyCommand)) {
+				event = KeyEvent{Command: strings.TrimSpace(line[1:])}
+				if event.Command == "" {
+					continue
+				}
+			} else {
+				event = KeyEvent{Key: KeyEnter}
+			}
+		}
+
+		select {
+		case events <- event:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// enableSingleKeyInput 将终端切换为单键输入模式（关闭行缓冲和回显）
+func (c *ConsoleUI) enableSingleKeyInput() bool {
+	saveCmd := exec.Command("stty", "-g")
+	saveCmd.Stdin = os.Stdin
+	state, err := saveCmd.Output()
+	if err != nil {
+		return false
+	}
+
+	rawCmd := exec.Command("stty", "cbreak", "-echo")
+	rawCmd.Stdin = os.Stdin
+	if err := rawCmd.Run(); err != nil {
+		return false
+	}
+
+	c.ttyMu.Lock()
+	c.savedTTYState = strings.TrimSpace(string(state))
+	c.ttyMu.Unlock()
+	return true
+}
+
+// restoreTerminal 恢复终端原始状态（只执行一次）
+func (c *ConsoleUI) restoreTerminal() {
+	c.ttyMu.Lock()
+	defer c.ttyMu.Unlock()
+	if c.savedTTYState == "" {
+		return
+	}
+
+	cmd := exec.Command("stty", c.savedTTYState)
+	cmd.Stdin = os.Stdin
+	cmd.Run()
+	c.savedTTYState = ""
+}
+
 // ShowASRResult 显示ASR识别结果
-func (c *ConsoleUI) ShowASRResult(content string, confidence float64, isFinal bool) {
-	timestamp := c.getTimestamp()
-	status := "🎤"
-	if isFinal {
-		status = "✅"
+// 中间结果在同一行原地刷新，最终结果到达时替换该行并换行
+func (c *ConsoleUI) ShowASRResult(content string, confidence float64, isFinal bool, words []protocol.WordTiming) {
+	if !isFinal {
+		if content == "" {
+			return
+		}
+
+		c.clearPartial()
+		timestamp := c.getTimestamp()
+		if c.config.ColoredOutput {
+			fmt.Printf("%s 🎤 \033[36m[ASR]\033[0m \033[90m%s...\033[0m", timestamp, content)
+		} else {
+			fmt.Printf("%s 🎤 [ASR] %s...", timestamp, content)
+		}
+		c.partialActive = true
+		return
 	}
 
+	c.clearPartial()
+	if content == "" {
+		return
+	}
+	timestamp := c.getTimestamp()
+
 	if c.config.ColoredOutput {
-		fmt.Printf("%s %s \033[36m[ASR]\033[0m %s (置信度: %.2f)\n",
-			timestamp, status, content, confidence)
+		fmt.Printf("%s ✅ \033[36m[ASR]\033[0m %s (置信度: %.2f)\n",
+			timestamp, content, confidence)
 	} else {
-		fmt.Printf("%s %s [ASR] %s (置信度: %.2f)\n",
-			timestamp, status, content, confidence)
+		fmt.Printf("%s ✅ [ASR] %s (置信度: %.2f)\n",
+			timestamp, content, confidence)
+	}
+
+	if c.config.ShowWordTimings && len(words) > 0 {
+		for _, w := range words {
+			fmt.Printf("    %6.2fs - %6.2fs  %s\n",
+				float64(w.StartTime)/1000, float64(w.EndTime)/1000, w.Text)
+		}
+	}
+}
+
+// clearPartial 清除当前行的中间识别结果
+func (c *ConsoleUI) clearPartial() {
+	if c.partialActive {
+		fmt.Print("\r\033[K")
+		c.partialActive = false
 	}
 }
 
 // ShowLLMResponse 显示LLM回复
 func (c *ConsoleUI) ShowLLMResponse(content string, isFinal bool) {
+	c.clearPartial()
 	timestamp := c.getTimestamp()
 	status := "💭"
 	if isFinal {
@@ -175,6 +561,7 @@ func (c *ConsoleUI) UpdateStatus(state, mode string) {
 		c.currentMode = mode
 		c.lastUpdate = time.Now()
 
+		c.clearPartial()
 		timestamp := c.getTimestamp()
 		statusIcon := c.getStatusIcon(state)
 
@@ -188,8 +575,24 @@ func (c *ConsoleUI) UpdateStatus(state, mode string) {
 	}
 }
 
+// ShowVoiceActivity 在当前行显示说话状态（与中间识别结果一样会被下一条输出覆盖）
+func (c *ConsoleUI) ShowVoiceActivity(speaking bool) {
+	// 已有中间识别结果时不覆盖
+	if c.partialActive && speaking {
+		return
+	}
+	c.clearPartial()
+	if speaking {
+		fmt.Printf("%s 🎙️ 正在听…", c.getTimestamp())
+	} else {
+		fmt.Printf("%s 👂 听到了，识别中…", c.getTimestamp())
+	}
+	c.partialActive = true
+}
+
 // ShowError 显示错误
 func (c *ConsoleUI) ShowError(code, message string) {
+	c.clearPartial()
 	timestamp := c.getTimestamp()
 
 	if c.config.ColoredOutput {
@@ -203,6 +606,7 @@ func (c *ConsoleUI) ShowError(code, message string) {
 
 // ShowMessage 显示消息
 func (c *ConsoleUI) ShowMessage(message string) {
+	c.clearPartial()
 	timestamp := c.getTimestamp()
 
 	if c.config.ColoredOutput {
@@ -214,6 +618,11 @@ func (c *ConsoleUI) ShowMessage(message string) {
 
 // UpdateAudioLevel 更新音频级别
 func (c *ConsoleUI) UpdateAudioLevel(average, peak float64) {
+	// 中间识别结果占用当前行时不覆盖
+	if c.partialActive {
+		return
+	}
+
 	// 简单的音频级别显示（可以优化为进度条）
 	if peak > 0.1 {
 		level := int(peak * 10)
@@ -234,6 +643,97 @@ func (c *ConsoleUI) UpdateAudioLevel(average, peak float64) {
 	}
 }
 
+// UpdateConnectionStatus 更新连接状态
+// 彩色输出且能获取终端大小时在终端底部保留一行常驻显示（离线时以醒目颜色提示），否则只在状态变化时打印一行。
+func (c *ConsoleUI) UpdateConnectionStatus(state string, latency time.Duration, detail string) {
+	c.statusMu.Lock()
+	defer c.statusMu.Unlock()
+
+	text := connectionText(state, latency, detail)
+	if !c.config.ColoredOutput || !c.reserveStatusLine() {
+		if state != c.connectionState {
+			c.connectionState = state
+			c.ShowMessage(text)
+		}
+		return
+	}
+	c.connectionState = state
+
+	color := "\033[30;42m" // 已连接：绿底
+	switch state {
+	case ConnectionConnecting, ConnectionReconnecting:
+		color = "\033[30;43m" // 连接中：黄底
+	case ConnectionDisconnected:
+		color = "\033[97;41m" // 离线：红底
+	}
+	// 保存光标，移到最后一行重绘后恢复，不影响正在输出的内容
+	fmt.Printf("\0337\033[%d;1H\033[2K%s %s \033[0m\0338", c.statusRows, color, text)
+}
+
+// connectionText 连接状态文字
+func connectionText(state string, latency time.Duration, detail string) string {
+	var text string
+	switch state {
+	case ConnectionConnected:
+		text = "🔗 已连接"
+		if latency > 0 {
+			text += fmt.Sprintf("，延迟 %dms", latency.Milliseconds())
+		}
+	case ConnectionConnecting:
+		text = "⏳ 正在连接服务器..."
+	case ConnectionReconnecting:
+		text = "⚠️ 连接已断开，正在重连"
+	case ConnectionDisconnected:
+		text = "🔌 离线：未连接到服务器"
+	default:
+		text = state
+	}
+	if detail != "" {
+		text += "（" + detail + "）"
+	}
+	return text
+}
+
+// reserveStatusLine 把滚动区域限制在最后一行之上，为状态行留出位置（终端大小变化时重新设置）
+func (c *ConsoleUI) reserveStatusLine() bool {
+	cmd := exec.Command("stty", "size")
+	cmd.Stdin = os.Stdin
+	output, err := cmd.Output()
+	if err != nil {
+		return false
+	}
+	fields := strings.Fields(string(output))
+	if len(fields) != 2 {
+		return false
+	}
+	rows, err := strconv.Atoi(fields[0])
+	if err != nil || rows < 3 {
+		return false
+	}
+
+	if rows != c.statusRows {
+		if c.statusRows == 0 {
+			// 先换行再上移，保证光标不在被保留的最后一行
+			fmt.Print("\n\033[1A")
+		}
+		fmt.Printf("\0337\033[1;%dr\0338", rows-1)
+		c.statusRows = rows
+	}
+	return true
+}
+
+// releaseStatusLine 恢复完整的滚动区域并清除状态行
+func (c *ConsoleUI) releaseStatusLine() {
+	c.statusMu.Lock()
+	defer c.statusMu.Unlock()
+
+	if c.statusRows == 0 {
+		return
+	}
+	fmt.Printf("\0337\033[r\033[%d;1H\033[2K\0338", c.statusRows)
+	c.statusRows = 0
+}
+
 // printWelcome 打印欢迎信息
 func (c *ConsoleUI) printWelcome() {
 	if c.config.ColoredOutput {
@@ -244,11 +744,12 @@ func (c *ConsoleUI) printWelcome() {
 ╚══════════════════════════════════════╝
 ` + "\033[0m")
 	} else {
-		fmt.Println(`
+		fmt.Print(`
 ╔══════════════════════════════════════╗
 ║           语音助手客户端             ║
 ║        Voice Assistant Client       ║
 ╚══════════════════════════════════════╝
+
 `)
 	}
 
@@ -0,0 +1,61 @@
+package ui
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"unicode/utf8"
+)
+
+// notificationMaxRunes 通知正文的最大字数（超出部分截断）
+const notificationMaxRunes = 120
+
+// Notify 发送桌面通知：Linux 使用 notify-send，macOS 使用 osascript，Windows 使用 PowerShell 的 Toast 通知
+func Notify(title, message string) error {
+	if utf8.RuneCountInString(message) > notificationMaxRunes {
+		message = string([]rune(message)[:notificationMaxRunes]) + "…"
+	}
+
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "linux", "freebsd", "openbsd", "netbsd":
+		cmd = exec.Command("notify-send", "--app-name", title, title, message)
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptString(message), appleScriptString(title))
+		cmd = exec.Command("osascript", "-e", script)
+	case "windows":
+		cmd = exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", windowsToastScript(title, message))
+	default:
+		return fmt.Errorf("当前系统不支持桌面通知: %s", runtime.GOOS)
+	}
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("发送桌面通知失败: %w %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// appleScriptString AppleScript字符串字面量
+func appleScriptString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+// windowsToastScript 显示Toast通知的PowerShell脚本
+func windowsToastScript(title, message string) string {
+	xmlEscape := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "'", "&apos;")
+	// PowerShell单引号字符串中单引号写作两个
+	psQuote := func(s string) string { return "'" + strings.ReplaceAll(s, "'", "''") + "'" }
+	toast := fmt.Sprintf(`<toast><visual><binding template="ToastGeneric"><text>%s</text><text>%s</text></binding></visual></toast>`,
+		xmlEscape.Replace(title), xmlEscape.Replace(message))
+
+	return strings.Join([]string{
+		`[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] | Out-Null`,
+		`[Windows.Data.Xml.Dom.XmlDocument, Windows.Data.Xml.Dom.XmlDocument, ContentType = WindowsRuntime] | Out-Null`,
+		`$xml = New-Object Windows.Data.Xml.Dom.XmlDocument`,
+		`$xml.LoadXml(` + psQuote(toast) + `)`,
+		`$toast = New-Object Windows.UI.Notifications.ToastNotification $xml`,
+		`[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier(` + psQuote(title) + `).Show($toast)`,
+	}, "; ")
+}
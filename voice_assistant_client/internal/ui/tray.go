@@ -0,0 +1,48 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrTrayUnsupported 编译时未启用系统托盘支持
+var ErrTrayUnsupported = errors.New("未编译系统托盘支持，请使用 -tags tray 重新编译")
+
+// TrayActions 托盘菜单操作的回调（在托盘事件协程中调用）
+type TrayActions struct {
+	Microphone func(enabled bool) // 开关麦克风
+	Quit       func()             // 点击“退出”（托盘事件循环随后结束）
+}
+
+// trayTooltip 托盘图标的提示文字：连接状态和会话状态
+func trayTooltip(connection, state, mode string) string {
+	tooltip := "语音助手: " + connection
+	if state != "" {
+		tooltip += fmt.Sprintf("\n会话: %s（%s）", state, mode)
+	}
+	return tooltip
+}
+
+// trayConnectionText 托盘菜单中的连接状态文字（不含图标）
+func trayConnectionText(state string, latency time.Duration, detail string) string {
+	switch state {
+	case ConnectionConnected:
+		if latency > 0 {
+			return fmt.Sprintf("已连接（延迟 %dms）", latency.Milliseconds())
+		}
+		return "已连接"
+	case ConnectionConnecting:
+		return "正在连接..."
+	case ConnectionReconnecting:
+		if detail != "" {
+			return "正在重连（" + detail + "）"
+		}
+		return "正在重连"
+	default:
+		if detail != "" {
+			return "离线（" + detail + "）"
+		}
+		return "离线"
+	}
+}
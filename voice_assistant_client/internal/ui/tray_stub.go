@@ -0,0 +1,30 @@
+//go:build !tray
+
+package ui
+
+import "time"
+
+// Tray 未编译系统托盘支持时的占位实现（Run返回ErrTrayUnsupported）
+type Tray struct{}
+
+// NewTray 创建系统托盘
+func NewTray() *Tray {
+	return &Tray{}
+}
+
+// Run 未编译系统托盘支持
+func (t *Tray) Run(actions TrayActions) error {
+	return ErrTrayUnsupported
+}
+
+// Quit 无操作
+func (t *Tray) Quit() {}
+
+// UpdateConnectionStatus 无操作
+func (t *Tray) UpdateConnectionStatus(state string, latency time.Duration, detail string) {}
+
+// UpdateStatus 无操作
+func (t *Tray) UpdateStatus(state, mode string) {}
+
+// SetMicrophone 无操作
+func (t *Tray) SetMicrophone(enabled bool) {}
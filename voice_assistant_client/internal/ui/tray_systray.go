@@ -0,0 +1,188 @@
+//go:build tray
+
+package ui
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"runtime"
+	"sync"
+	"time"
+
+	"fyne.io/systray"
+)
+
+// trayIconSize 托盘图标边长（像素）
+const trayIconSize = 32
+
+// 托盘图标颜色：已连接绿色，连接中黄色，离线红色，麦克风关闭灰色
+var (
+	trayColorConnected    = color.RGBA{0x2e, 0xa0, 0x43, 0xff}
+	trayColorConnecting   = color.RGBA{0xe0, 0xa8, 0x00, 0xff}
+	trayColorDisconnected = color.RGBA{0xd0, 0x30, 0x30, 0xff}
+	trayColorMuted        = color.RGBA{0x80, 0x80, 0x80, 0xff}
+)
+
+// Tray 系统托盘：图标颜色表示连接状态，菜单显示连接详情并提供麦克风开关和退出
+// 托盘就绪前的状态更新会暂存，就绪后一并显示。
+type Tray struct {
+	mu         sync.Mutex
+	ready      bool
+	connection string // 连接状态（Connection*）
+	statusText string // 连接状态文字
+	state      string // 会话状态
+	mode       string // 会话模式
+	microphone bool
+
+	statusItem *systray.MenuItem
+	micItem    *systray.MenuItem
+	quitItem   *systray.MenuItem
+}
+
+// NewTray 创建系统托盘（Run之前不显示）
+func NewTray() *Tray {
+	return &Tray{
+		connection: ConnectionConnecting,
+		statusText: trayConnectionText(ConnectionConnecting, 0, ""),
+		microphone: true,
+	}
+}
+
+// Run 显示托盘图标并运行托盘事件循环，直到调用Quit或点击“退出”
+// macOS要求在主协程中调用。
+func (t *Tray) Run(actions TrayActions) error {
+	systray.Run(func() { t.setup(actions) }, nil)
+	return nil
+}
+
+// Quit 结束托盘事件循环
+func (t *Tray) Quit() {
+	systray.Quit()
+}
+
+// setup 创建托盘菜单并处理菜单点击
+func (t *Tray) setup(actions TrayActions) {
+	systray.SetTitle("语音助手")
+
+	t.mu.Lock()
+	t.statusItem = systray.AddMenuItem(t.statusText, "连接状态")
+	t.statusItem.Disable()
+	systray.AddSeparator()
+	t.micItem = systray.AddMenuItemCheckbox("麦克风", "开启或关闭麦克风", t.microphone)
+	systray.AddSeparator()
+	t.quitItem = systray.AddMenuItem("退出", "退出语音助手")
+	t.ready = true
+	t.refreshLocked()
+	t.mu.Unlock()
+
+	go func() {
+		for {
+			select {
+			case <-t.micItem.ClickedCh:
+				t.mu.Lock()
+				t.microphone = !t.microphone
+				enabled := t.microphone
+				t.refreshLocked()
+				t.mu.Unlock()
+				if actions.Microphone != nil {
+					actions.Microphone(enabled)
+				}
+			case <-t.quitItem.ClickedCh:
+				if actions.Quit != nil {
+					actions.Quit()
+				}
+				systray.Quit()
+				return
+			}
+		}
+	}()
+}
+
+// UpdateConnectionStatus 更新连接状态图标和菜单中的连接详情
+func (t *Tray) UpdateConnectionStatus(state string, latency time.Duration, detail string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.connection = state
+	t.statusText = trayConnectionText(state, latency, detail)
+	t.refreshLocked()
+}
+
+// UpdateStatus 更新提示文字中的会话状态
+func (t *Tray) UpdateStatus(state, mode string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.state, t.mode = state, mode
+	t.refreshLocked()
+}
+
+// SetMicrophone 同步麦克风开关状态（由其他途径切换时调用）
+func (t *Tray) SetMicrophone(enabled bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.microphone = enabled
+	t.refreshLocked()
+}
+
+// refreshLocked 按当前状态刷新图标、提示文字和菜单（调用方持有 mu）
+func (t *Tray) refreshLocked() {
+	if !t.ready {
+		return
+	}
+
+	iconColor := trayColorConnected
+	switch {
+	case t.connection == ConnectionConnecting || t.connection == ConnectionReconnecting:
+		iconColor = trayColorConnecting
+	case t.connection == ConnectionDisconnected:
+		iconColor = trayColorDisconnected
+	case !t.microphone:
+		iconColor = trayColorMuted
+	}
+	systray.SetIcon(trayIcon(iconColor))
+	systray.SetTooltip(trayTooltip(t.statusText, t.state, t.mode))
+
+	t.statusItem.SetTitle(t.statusText)
+	if t.microphone {
+		t.micItem.Check()
+	} else {
+		t.micItem.Uncheck()
+	}
+}
+
+// trayIcon 绘制指定颜色的圆形图标（Windows为ICO格式，其余为PNG）
+func trayIcon(c color.RGBA) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, trayIconSize, trayIconSize))
+	center := float64(trayIconSize-1) / 2
+	radius := float64(trayIconSize)/2 - 1
+	for y := 0; y < trayIconSize; y++ {
+		for x := 0; x < trayIconSize; x++ {
+			dx, dy := float64(x)-center, float64(y)-center
+			if dx*dx+dy*dy <= radius*radius {
+				img.SetRGBA(x, y, c)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	if runtime.GOOS == "windows" {
+		return pngToICO(buf.Bytes(), trayIconSize)
+	}
+	return buf.Bytes()
+}
+
+// pngToICO 把PNG包装为只含一张图片的ICO文件（ICO允许直接内嵌PNG数据）
+func pngToICO(data []byte, size int) []byte {
+	var buf bytes.Buffer
+	// ICONDIR：保留字段、类型（1为图标）、图片数
+	binary.Write(&buf, binary.LittleEndian, []uint16{0, 1, 1})
+	// ICONDIRENTRY：宽、高、调色板颜色数、保留、色彩平面数、位深、数据大小、数据偏移
+	buf.Write([]byte{byte(size), byte(size), 0, 0})
+	binary.Write(&buf, binary.LittleEndian, []uint16{1, 32})
+	binary.Write(&buf, binary.LittleEndian, []uint32{uint32(len(data)), 6 + 16})
+	buf.Write(data)
+	return buf.Bytes()
+}
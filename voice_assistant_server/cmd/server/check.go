@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"voice_assistant/voice_assistant_server/engine"
+	"voice_assistant/voice_assistant_server/internal/config"
+	"voice_assistant/voice_assistant_server/internal/pipeline"
+	"voice_assistant/voice_assistant_server/internal/server"
+)
+
+// runCheckCommand 执行提供商自检子命令：server check [-audio 音频文件] [-timeout 时长] [asr|llm|tts...]
+func runCheckCommand(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("check", flag.ContinueOnError)
+	audioPath := flags.String("audio", "", "ASR自检使用的WAV文件（默认使用TTS合成的测试句）")
+	timeout := flags.Duration("timeout", time.Minute, "每个提供商的调用超时")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "用法: server [-config 配置文件] check [-audio 音频文件] [-timeout 时长] [asr|llm|tts...]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	var stages []pipeline.Stage
+	for _, name := range flags.Args() {
+		stage := pipeline.Stage(name)
+		if stage != pipeline.StageASR && stage != pipeline.StageLLM && stage != pipeline.StageTTS {
+			return fmt.Errorf("未知的自检项: %s（可选 asr、llm、tts）", name)
+		}
+		stages = append(stages, stage)
+	}
+
+	var audio []byte
+	if *audioPath != "" {
+		data, err := os.ReadFile(*audioPath)
+		if err != nil {
+			return fmt.Errorf("读取音频文件失败: %w", err)
+		}
+		audio = data
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	processorConfig := engine.FromServerConfig(cfg)
+	results := server.SelfTest(ctx, server.SelfTestConfig{
+		ASRConfig: processorConfig.ASRConfig,
+		LLMConfig: processorConfig.LLMConfig,
+		TTSConfig: processorConfig.TTSConfig,
+		Audio:     audio,
+		Timeout:   *timeout,
+	}, stages)
+
+	var failed []string
+	for _, result := range results {
+		status := "通过"
+		if !result.OK {
+			status = "失败"
+			failed = append(failed, string(result.Stage))
+		}
+		fmt.Printf("[%s] %-4s %-10s 初始化 %v，调用 %v\n", status, result.Stage, result.Provider,
+			result.InitLatency.Round(time.Millisecond), result.Latency.Round(time.Millisecond))
+		if result.Detail != "" {
+			fmt.Printf("       %s\n", result.Detail)
+		}
+		if result.Error != "" {
+			fmt.Printf("       错误: %s\n", result.Error)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("自检未通过: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
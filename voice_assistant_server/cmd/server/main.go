@@ -1,17 +1,28 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
+	"strings"
+	"time"
 
+	"voice_assistant/pkg/logging"
 	"voice_assistant/pkg/protocol"
+	"voice_assistant/pkg/service"
+	"voice_assistant/voice_assistant_server/engine"
 	"voice_assistant/voice_assistant_server/internal/asr"
 	"voice_assistant/voice_assistant_server/internal/config"
+	"voice_assistant/voice_assistant_server/internal/hooks"
 	"voice_assistant/voice_assistant_server/internal/llm"
+	"voice_assistant/voice_assistant_server/internal/moderation"
+	"voice_assistant/voice_assistant_server/internal/plugins"
+	"voice_assistant/voice_assistant_server/internal/rag"
 	"voice_assistant/voice_assistant_server/internal/server"
+	"voice_assistant/voice_assistant_server/internal/speaker"
 	"voice_assistant/voice_assistant_server/internal/tts"
 
 	"github.com/gin-gonic/gin"
@@ -20,9 +31,18 @@ import (
 func main() {
 	// 解析命令行参数
 	var configPath string
+	var listProviders bool
+	var validateOnly bool
 	flag.StringVar(&configPath, "config", "config/server.yaml", "配置文件路径")
+	flag.BoolVar(&listProviders, "providers", false, "列出编译进当前二进制的提供商")
+	flag.BoolVar(&validateOnly, "validate-config", false, "只检查配置文件，检查完成后退出")
 	flag.Parse()
 
+	if listProviders {
+		printProviders()
+		return
+	}
+
 	// 加载配置
 	configData, err := ioutil.ReadFile(configPath)
 	if err != nil {
@@ -33,6 +53,62 @@ func main() {
 	if err != nil {
 		log.Fatalf("解析配置文件失败: %v", err)
 	}
+	if validateOnly {
+		fmt.Printf("配置文件检查通过: %s\n", configPath)
+		return
+	}
+
+	// 系统服务管理子命令
+	if isServiceCommand(flag.Arg(0)) {
+		if err := runServiceCommand(flag.Arg(0), configPath, flag.Args()[1:]); err != nil {
+			log.Fatalf("%v", err)
+		}
+		return
+	}
+
+	// 运行服务时按 logging 配置输出日志（以系统服务运行时没有控制台，需输出到文件）
+	if command := flag.Arg(0); command == "" || command == "run" {
+		logCloser, err := logging.Setup(toLoggingConfig(cfg))
+		if err != nil {
+			log.Fatalf("设置日志输出失败: %v", err)
+		}
+		defer logCloser.Close()
+	}
+
+	// 加载外部插件（插件提供的提供商类型需在创建服务之前注册）
+	pluginManager, err := plugins.Load(toPluginsConfig(cfg))
+	if err != nil {
+		log.Fatalf("加载插件失败: %v", err)
+	}
+
+	// 出错时先停止插件进程再退出（log.Fatalf 不执行延迟调用）
+	err = runCommand(cfg)
+	pluginManager.Close()
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+}
+
+// runCommand 执行模型管理、提供商自检子命令，或前台运行服务（由服务管理器启动时为 server run）
+func runCommand(cfg *config.Config) error {
+	switch flag.Arg(0) {
+	case "models":
+		return runModelsCommand(cfg, flag.Args()[1:])
+	case "check":
+		return runCheckCommand(cfg, flag.Args()[1:])
+	default:
+		return service.Run(serviceName, func(ctx context.Context) error {
+			return runServer(ctx, cfg)
+		})
+	}
+}
+
+// runServer 启动各项服务，ctx取消（收到退出信号或服务停止请求）或服务异常退出后依次关闭
+// 启动失败或服务异常退出时返回错误，由调用方清理插件等资源后退出。
+func runServer(ctx context.Context, cfg *config.Config) error {
+	if cfg.Models.AutoDownload {
+		downloadRequiredModels(cfg)
+	}
 
 	// 创建WebSocket配置
 	wsConfig := server.WebSocketConfig{
@@ -42,71 +118,51 @@ func main() {
 		PingPeriod:      cfg.WebSocket.PingPeriod,
 		PongWait:        cfg.WebSocket.PongWait,
 		WriteWait:       cfg.WebSocket.WriteWait,
-	}
 
-	// 创建WebSocket服务器
-	wsServer := server.NewWebSocketServer(wsConfig)
+		ResumeWindow:     cfg.WebSocket.ResumeWindow,
+		ResumeBufferSize: cfg.WebSocket.ResumeBufferSize,
+		StaleTimeout:     cfg.WebSocket.StaleTimeout,
+		SweepInterval:    cfg.WebSocket.SweepInterval,
 
-	// 转换配置类型
-	asrConfig := asr.ASRConfig{
-		Type:       cfg.ASR.Provider,
-		ModelPath:  cfg.ASR.Whisper.ModelPath,
-		Language:   cfg.ASR.Whisper.Language,
-		SampleRate: 16000,
-		Channels:   1,
-		APIKey:     cfg.ASR.OpenAI.APIKey,
-		Timeout:    30,
-	}
-
-	llmConfig := llm.LLMConfig{
-		Type:        cfg.LLM.Provider,
-		Model:       cfg.LLM.OpenAI.Model,
-		APIKey:      cfg.LLM.OpenAI.APIKey,
-		Temperature: float32(cfg.LLM.OpenAI.Temperature),
-		MaxTokens:   cfg.LLM.OpenAI.MaxTokens,
-		Timeout:     30,
-		OpenAIConfig: llm.OpenAIConfig{
-			BaseURL: "https://api.openai.com/v1",
-			Stream:  true,
-		},
-		OllamaConfig: llm.OllamaConfig{
-			Host: cfg.LLM.Ollama.BaseURL,
-			Port: 11434,
-		},
-		WebSocketConfig: llm.WebSocketConfig{
-			URL: cfg.LLM.WebSocket.URL,
-		},
+		SendQueueSize:  cfg.WebSocket.SendQueueSize,
+		OverflowPolicy: cfg.WebSocket.OverflowPolicy,
+		BlockTimeout:   cfg.WebSocket.BlockTimeout,
+
+		EnableCompression:    cfg.WebSocket.EnableCompression,
+		CompressionLevel:     cfg.WebSocket.CompressionLevel,
+		CompressionThreshold: cfg.WebSocket.CompressionThreshold,
+
+		CloseTimeout: cfg.WebSocket.CloseTimeout,
+
+		MaxMessageSize: cfg.WebSocket.MaxMessageSize,
+		MaxAudioSize:   cfg.WebSocket.MaxAudioSize,
+		StrictProtocol: cfg.WebSocket.StrictProtocol,
 	}
 
-	ttsConfig := tts.TTSConfig{
-		Type:     cfg.TTS.Provider,
-		Voice:    cfg.TTS.EdgeTTS.Voice,
-		Language: "zh-CN",
-		Speed:    1.0,
-		Pitch:    1.0,
-		Volume:   1.0,
-		Format:   "wav",
-		Timeout:  30,
-		EdgeConfig: tts.EdgeConfig{
-			UseWebSocket: true,
-		},
+	// 来源检查：生产模式下未配置允许来源时只允许同源的浏览器请求
+	production := cfg.Server.Mode == "production"
+	origins := server.NewOriginChecker(server.CORSConfig{
+		AllowedOrigins:   cfg.Server.CORS.AllowedOrigins,
+		AllowCredentials: cfg.Server.CORS.AllowCredentials,
+		MaxAge:           cfg.Server.CORS.MaxAge,
+		Strict:           production,
+	})
+	if origins.AllowsAll() {
+		log.Printf("警告: 允许任意来源的浏览器跨域请求和WebSocket连接，生产环境请配置 server.cors.allowed_origins 或 server.mode: production")
 	}
 
+	// 创建WebSocket服务器
+	wsServer := server.NewWebSocketServer(wsConfig)
+	wsServer.SetOriginChecker(origins)
+
 	// 创建处理器配置
-	processorConfig := server.ProcessorConfig{
-		ASRConfig:             asrConfig,
-		LLMConfig:             llmConfig,
-		TTSConfig:             ttsConfig,
-		EnableContinuousMode:  true,
-		MaxConcurrentSessions: 10,
-		SessionTimeout:        300,
-		AudioBufferSize:       4096,
-	}
+	processorConfig := engine.FromServerConfig(cfg)
 
 	// 创建消息处理器
 	processor := server.NewMessageProcessor(processorConfig)
 	if err := processor.Initialize(); err != nil {
-		log.Fatalf("初始化消息处理器失败: %v", err)
+		processor.Close()
+		return fmt.Errorf("初始化消息处理器失败: %w", err)
 	}
 
 	// 设置处理器
@@ -119,9 +175,52 @@ func main() {
 	wsServer.RegisterHandler("command", func(client *server.Client, msg *protocol.Message) error {
 		return processor.ProcessMessage(client, msg)
 	})
+	wsServer.RegisterHandler("status", func(client *server.Client, msg *protocol.Message) error {
+		return processor.ProcessMessage(client, msg)
+	})
+
+	// 各服务异常退出时通知主流程关闭
+	serveErr := make(chan error, 2)
+
+	// gRPC传输
+	if cfg.GRPC.Enabled {
+		grpcServer, err := server.NewGRPCServer(server.GRPCConfig{
+			Enabled:        cfg.GRPC.Enabled,
+			Host:           cfg.GRPC.Host,
+			Port:           cfg.GRPC.Port,
+			MaxConnections: cfg.GRPC.MaxConnections,
+			TLS: server.GRPCTLSConfig{
+				CertFile:     cfg.GRPC.TLS.CertFile,
+				KeyFile:      cfg.GRPC.TLS.KeyFile,
+				ClientCAFile: cfg.GRPC.TLS.ClientCAFile,
+			},
+		}, processor)
+		if err != nil {
+			processor.Close()
+			return fmt.Errorf("创建gRPC服务失败: %w", err)
+		}
+		if cfg.GRPC.TLS.CertFile == "" {
+			log.Printf("警告: gRPC未配置 grpc.tls 证书，音频与API密钥将以明文传输")
+		}
+		go func() {
+			if err := grpcServer.ListenAndServe(); err != nil {
+				serveErr <- fmt.Errorf("gRPC服务异常退出: %w", err)
+			}
+		}()
+	}
+
+	// MQTT桥接（智能家居集成）
+	if cfg.MQTT.Enabled {
+		bridge := server.NewMQTTBridge(toMQTTConfig(cfg), wsServer)
+		go bridge.Run(ctx)
+	}
 
 	// 创建HTTP服务器
+	if production {
+		gin.SetMode(gin.ReleaseMode)
+	}
 	router := gin.Default()
+	router.Use(origins.Middleware())
 
 	// WebSocket端点
 	router.GET("/ws", func(c *gin.Context) {
@@ -130,15 +229,213 @@ func main() {
 
 	// 健康检查端点
 	router.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{
+		health := gin.H{
 			"status":    "ok",
 			"clients":   wsServer.GetClientCount(),
 			"timestamp": fmt.Sprintf("%d", cfg.Server.Port),
-		})
+		}
+		if stats, ok := processor.ConversationStats(); ok {
+			health["conversations"] = stats
+		}
+		connections, staleClosed := wsServer.ConnectionStats()
+		health["connections"] = connections
+		health["stale_closed"] = staleClosed
+		health["send_queue"] = wsServer.SendQueueStats()
+		health["pipeline"] = processor.PipelineStats()
+		if stats := processor.ComputeStats(); len(stats) > 0 {
+			health["compute"] = stats
+		}
+		c.JSON(http.StatusOK, health)
+	})
+
+	// 存活检查：探测ASR/LLM/TTS后端，有组件不可用时 status 为 degraded，但仍返回200
+	router.GET("/healthz", func(c *gin.Context) {
+		c.JSON(http.StatusOK, processor.CheckHealth(c.Request.Context()))
+	})
+
+	// 就绪检查：任一后端组件不可用时返回503
+	router.GET("/readyz", func(c *gin.Context) {
+		report := processor.CheckHealth(c.Request.Context())
+		status := http.StatusOK
+		if !report.Ready {
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(status, report)
+	})
+
+	// 多房间同步播报端点（需管理令牌）
+	router.POST("/announce", server.AdminAuthorize(cfg.Admin.Token), func(c *gin.Context) {
+		var req struct {
+			Text   string `json:"text"`
+			LeadMs int64  `json:"lead_ms"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		result, err := wsServer.Announce(c.Request.Context(), req.Text, time.Duration(req.LeadMs)*time.Millisecond)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, result)
 	})
 
+	// 一次性调用的REST接口（ASR、对话、TTS）
+	server.NewRESTHandler(processor).Register(router.Group("/api"))
+
+	// 运维管理接口（查看和终止会话，需携带管理令牌）
+	adminConfig := server.AdminConfig{
+		Token:       cfg.Admin.Token,
+		DebugListen: cfg.Admin.DebugListen,
+	}
+	server.NewAdminHandler(processor, adminConfig).Register(router.Group("/api/admin"))
+
+	// 独立的调试端口：pprof和运行时调优（需管理令牌）
+	var debugSrv *http.Server
+	if adminConfig.DebugListen != "" {
+		if adminConfig.Token == "" {
+			log.Printf("警告: 未配置 admin.token，调试端口的接口均返回404")
+		}
+		debugRouter := gin.New()
+		debugRouter.Use(gin.Recovery())
+		server.NewDebugHandler(wsServer, adminConfig).Register(debugRouter)
+		debugSrv = &http.Server{Addr: adminConfig.DebugListen, Handler: debugRouter}
+		log.Printf("调试端口启动在 %s", adminConfig.DebugListen)
+		go func() {
+			if err := debugSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Printf("调试端口异常退出: %v", err)
+			}
+		}()
+	}
+
+	// 浏览器WebRTC网关
+	if cfg.WebRTC.Enabled {
+		gateway, err := server.NewWebRTCGateway(server.WebRTCConfig{
+			Enabled:        cfg.WebRTC.Enabled,
+			ICEServers:     cfg.WebRTC.ICEServers,
+			UDPPortMin:     cfg.WebRTC.UDPPortMin,
+			UDPPortMax:     cfg.WebRTC.UDPPortMax,
+			JitterPackets:  cfg.WebRTC.JitterPackets,
+			MaxConnections: cfg.WebRTC.MaxConnections,
+		}, processor)
+		if err != nil {
+			if debugSrv != nil {
+				debugSrv.Close()
+			}
+			processor.Close()
+			return fmt.Errorf("创建WebRTC网关失败: %w", err)
+		}
+		router.POST("/webrtc/offer", gateway.HandleOffer)
+	}
+
+	// 内置网页客户端（容器部署时直接在浏览器中使用）
+	if cfg.WebClient.Enabled {
+		path := server.RegisterWebClient(router, server.WebClientConfig{
+			Enabled: cfg.WebClient.Enabled,
+			Path:    cfg.WebClient.Path,
+		})
+		log.Printf("网页客户端已启用: %s/", path)
+	}
+
 	// 启动服务器
 	addr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
 	log.Printf("服务器启动在 %s", addr)
-	log.Fatal(http.ListenAndServe(addr, router))
+	srv := &http.Server{Addr: addr, Handler: router}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			serveErr <- fmt.Errorf("服务器异常退出: %w", err)
+		}
+	}()
+
+	// 通知服务管理器启动完成（systemd Type=notify）
+	service.Ready()
+
+	// 收到退出信号或服务停止请求后先通知客户端（关闭码1001，可稍后重连）再停止服务
+	var err error
+	select {
+	case <-ctx.Done():
+	case err = <-serveErr:
+		log.Printf("%v", err)
+	}
+	log.Printf("正在关闭服务器...")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	wsServer.Shutdown(shutdownCtx)
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("关闭HTTP服务失败: %v", err)
+	}
+	if debugSrv != nil {
+		debugSrv.Shutdown(shutdownCtx)
+	}
+	processor.Close()
+	log.Printf("服务器已关闭")
+	return err
+}
+
+// printProviders 打印编译进当前二进制的提供商（可通过 no_<类型>_<名称> 构建标签排除）
+func printProviders() {
+	fmt.Printf("ASR: %s\n", strings.Join(asr.GetAvailableASRTypes(), ", "))
+	fmt.Printf("LLM: %s\n", strings.Join(llm.GetAvailableLLMTypes(), ", "))
+	fmt.Printf("TTS: %s\n", strings.Join(tts.GetAvailableTTSTypes(), ", "))
+	fmt.Printf("知识库存储: %s\n", strings.Join(rag.GetAvailableStoreTypes(), ", "))
+	fmt.Printf("声纹提取: %s\n", strings.Join(speaker.GetAvailableEmbedderTypes(), ", "))
+	fmt.Printf("内容审核: %s\n", strings.Join(moderation.GetAvailableClassifierTypes(), ", "))
+	fmt.Printf("处理流程钩子: %s\n", strings.Join(hooks.GetAvailableHookTypes(), ", "))
+}
+
+// toPluginsConfig 转换外部插件配置
+func toPluginsConfig(cfg *config.Config) plugins.Config {
+	entries := make([]plugins.PluginConfig, 0, len(cfg.Plugins))
+	for _, plugin := range cfg.Plugins {
+		entries = append(entries, plugins.PluginConfig{
+			Name:    plugin.Name,
+			Command: plugin.Command,
+			Args:    plugin.Args,
+			Env:     plugin.Env,
+			Dir:     plugin.Dir,
+			Timeout: plugin.Timeout,
+			Options: plugin.Options,
+		})
+	}
+	return plugins.Config{Plugins: entries}
+}
+
+// toLoggingConfig 转换日志输出配置
+func toLoggingConfig(cfg *config.Config) logging.Config {
+	return logging.Config{
+		Format:     cfg.Logging.Format,
+		Output:     cfg.Logging.Output,
+		FilePath:   cfg.Logging.FilePath,
+		MaxSize:    cfg.Logging.MaxSize,
+		MaxBackups: cfg.Logging.MaxBackups,
+		MaxAge:     cfg.Logging.MaxAge,
+		Compress:   cfg.Logging.Compress,
+	}
+}
+
+// toMQTTConfig 转换MQTT桥接配置
+func toMQTTConfig(cfg *config.Config) server.MQTTConfig {
+	return server.MQTTConfig{
+		Enabled:     cfg.MQTT.Enabled,
+		Broker:      cfg.MQTT.Broker,
+		ClientID:    cfg.MQTT.ClientID,
+		Username:    cfg.MQTT.Username,
+		Password:    cfg.MQTT.Password,
+		KeepAlive:   cfg.MQTT.KeepAlive,
+		QoS:         byte(cfg.MQTT.QoS),
+		TopicPrefix: cfg.MQTT.TopicPrefix,
+		Topics: server.MQTTTopics{
+			Transcript: cfg.MQTT.Topics.Transcript,
+			Reply:      cfg.MQTT.Topics.Reply,
+			Turn:       cfg.MQTT.Topics.Turn,
+			Announce:   cfg.MQTT.Topics.Announce,
+			Status:     cfg.MQTT.Topics.Status,
+		},
+		RequireConsent:  cfg.MQTT.RequireConsent,
+		Discovery:       cfg.MQTT.Discovery,
+		DiscoveryPrefix: cfg.MQTT.DiscoveryPrefix,
+	}
 }
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+
+	"voice_assistant/voice_assistant_server/internal/config"
+	"voice_assistant/voice_assistant_server/internal/models"
+)
+
+// modelsUsage 模型管理子命令的用法
+const modelsUsage = `用法: server [-config 配置文件] models <命令> [模型名称...]
+
+命令:
+  list      列出配置的模型及安装状态
+  download  下载模型（已安装的跳过，未下载完的续传），不指定名称时下载全部
+  verify    按sha256校验已下载的模型`
+
+// newModelManager 按配置创建模型文件管理
+func newModelManager(cfg *config.Config) (*models.Manager, error) {
+	files := make([]models.File, 0, len(cfg.Models.Files))
+	for _, file := range cfg.Models.Files {
+		files = append(files, models.File{
+			Name:     file.Name,
+			Provider: file.Provider,
+			URL:      file.URL,
+			Path:     file.Path,
+			SHA256:   file.SHA256,
+		})
+	}
+	return models.NewManager(models.Config{
+		Dir:          cfg.Models.Dir,
+		AutoDownload: cfg.Models.AutoDownload,
+		Files:        files,
+	}, nil)
+}
+
+// activeProviders 当前配置使用的提供商
+func activeProviders(cfg *config.Config) []string {
+	providers := []string{cfg.ASR.Provider, cfg.LLM.Provider, cfg.TTS.Provider}
+	if cfg.Speaker.Enabled {
+		providers = append(providers, cfg.Speaker.Provider)
+	}
+	return providers
+}
+
+// downloadRequiredModels 启动时下载当前提供商缺失的模型文件（失败时只记录日志，由提供商初始化报告具体错误）
+func downloadRequiredModels(cfg *config.Config) {
+	manager, err := newModelManager(cfg)
+	if err != nil {
+		log.Printf("模型配置错误: %v", err)
+		return
+	}
+	if err := manager.EnsureAll(context.Background(), manager.Required(activeProviders(cfg))); err != nil {
+		log.Printf("自动下载模型失败: %v", err)
+	}
+}
+
+// runModelsCommand 执行模型管理子命令
+func runModelsCommand(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		fmt.Println(modelsUsage)
+		return nil
+	}
+	manager, err := newModelManager(cfg)
+	if err != nil {
+		return err
+	}
+	files, err := manager.Select(args[1:])
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "list":
+		for _, status := range manager.Status(files) {
+			state := "未安装"
+			if status.Installed {
+				state = "已安装"
+			} else if status.Partial > 0 {
+				state = fmt.Sprintf("未下载完（%d字节）", status.Partial)
+			}
+			fmt.Printf("%-20s %-10s %s\n", status.Name, state, status.Path)
+		}
+		return nil
+	case "download":
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		return manager.EnsureAll(ctx, files)
+	case "verify":
+		var failed []string
+		statuses := manager.Status(files)
+		for i, file := range files {
+			name := statuses[i].Name
+			if err := manager.Verify(file); err != nil {
+				fmt.Printf("%-20s 失败: %v\n", name, err)
+				failed = append(failed, name)
+				continue
+			}
+			fmt.Printf("%-20s 通过\n", name)
+		}
+		if len(failed) > 0 {
+			return fmt.Errorf("模型校验失败: %s", strings.Join(failed, ", "))
+		}
+		return nil
+	default:
+		return fmt.Errorf("未知的models命令: %s\n%s", args[0], modelsUsage)
+	}
+}
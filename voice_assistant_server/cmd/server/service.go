@@ -0,0 +1,86 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"voice_assistant/pkg/service"
+)
+
+// serviceName 默认的系统服务名
+const serviceName = "voice-assistant-server"
+
+// isServiceCommand 是否为系统服务管理子命令
+func isServiceCommand(command string) bool {
+	switch command {
+	case "install", "uninstall", "start", "stop", "status":
+		return true
+	}
+	return false
+}
+
+// runServiceCommand 执行系统服务管理子命令：
+// server [-config 配置文件] install [-name 服务名] [-user 用户] [-workdir 目录] [-restart-delay 时长]、uninstall、start、stop、status
+// 安装后由systemd（Linux）或Windows服务管理器以 server -config <配置文件绝对路径> run 启动，开机自启动，异常退出后自动重启。
+func runServiceCommand(command, configPath string, args []string) error {
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	name := flags.String("name", serviceName, "服务名")
+	user := flags.String("user", "", "运行服务的用户（仅Linux，默认root）")
+	workDir := flags.String("workdir", "", "服务的工作目录（默认为当前目录，配置中的相对路径按此解析）")
+	restartDelay := flags.Duration("restart-delay", 5*time.Second, "异常退出后重启前的等待时间")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "用法: server [-config 配置文件] install|uninstall|start|stop|status [-name 服务名]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	switch command {
+	case "install":
+		config, err := filepath.Abs(configPath)
+		if err != nil {
+			return fmt.Errorf("解析配置文件路径失败: %w", err)
+		}
+		dir, err := filepath.Abs(*workDir)
+		if err != nil {
+			return fmt.Errorf("解析工作目录失败: %w", err)
+		}
+		if err := service.Install(service.Config{
+			Name:         *name,
+			DisplayName:  "语音助手服务端",
+			Description:  "语音助手服务端（ASR、LLM、TTS）",
+			Arguments:    []string{"-config", config, "run"},
+			WorkingDir:   dir,
+			User:         *user,
+			RestartDelay: *restartDelay,
+		}); err != nil {
+			return fmt.Errorf("安装服务失败: %w", err)
+		}
+		fmt.Printf("服务已安装: %s（配置文件: %s），执行 server start 或重启系统后运行\n", *name, config)
+	case "uninstall":
+		if err := service.Uninstall(*name); err != nil {
+			return fmt.Errorf("卸载服务失败: %w", err)
+		}
+		fmt.Printf("服务已卸载: %s\n", *name)
+	case "start":
+		if err := service.Start(*name); err != nil {
+			return err
+		}
+		fmt.Printf("服务已启动: %s\n", *name)
+	case "stop":
+		if err := service.Stop(*name); err != nil {
+			return err
+		}
+		fmt.Printf("服务已停止: %s\n", *name)
+	case "status":
+		status, err := service.QueryStatus(*name)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s: %s\n", *name, status)
+	}
+	return nil
+}
@@ -0,0 +1,370 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+
+	"voice_assistant/voice_assistant_server/internal/archive"
+	"voice_assistant/voice_assistant_server/internal/asr"
+	"voice_assistant/voice_assistant_server/internal/compute"
+	"voice_assistant/voice_assistant_server/internal/config"
+	"voice_assistant/voice_assistant_server/internal/dataset"
+	"voice_assistant/voice_assistant_server/internal/hooks"
+	"voice_assistant/voice_assistant_server/internal/llm"
+	"voice_assistant/voice_assistant_server/internal/memory"
+	"voice_assistant/voice_assistant_server/internal/moderation"
+	"voice_assistant/voice_assistant_server/internal/pipeline"
+	"voice_assistant/voice_assistant_server/internal/rag"
+	"voice_assistant/voice_assistant_server/internal/reminder"
+	"voice_assistant/voice_assistant_server/internal/server"
+	"voice_assistant/voice_assistant_server/internal/speaker"
+	"voice_assistant/voice_assistant_server/internal/textnorm"
+	"voice_assistant/voice_assistant_server/internal/tts"
+	"voice_assistant/voice_assistant_server/internal/webhook"
+)
+
+// 处理器的默认参数（配置文件中没有对应的配置项）
+const (
+	defaultMaxSessions     = 10
+	defaultSessionTimeout  = 300 // 秒
+	defaultAudioBufferSize = 4096
+)
+
+// Config 处理器配置：各阶段选用的提供商及其参数，以及数据采集、记忆、审核等处理流程功能
+type Config = server.ProcessorConfig
+
+// ASRConfig 语音识别提供商配置（Type 为提供商名称，如 openai、whisper、funasr）
+type ASRConfig = asr.ASRConfig
+
+// LLMConfig 大模型提供商配置（Type 为提供商名称，如 openai、ollama、websocket）
+type LLMConfig = llm.LLMConfig
+
+// TTSConfig 语音合成提供商配置（Type 为提供商名称，如 edge、sherpa、cosyvoice）
+type TTSConfig = tts.TTSConfig
+
+// LoadConfig 读取服务端配置文件（config/server.yaml 格式）并转换为处理器配置
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("读取配置文件失败: %w", err)
+	}
+	cfg, err := config.LoadConfig(data)
+	if err != nil {
+		return Config{}, fmt.Errorf("解析配置文件失败: %w", err)
+	}
+	return FromServerConfig(cfg), nil
+}
+
+// FromServerConfig 把服务端配置转换为处理器配置（服务端启动时与 LoadConfig 使用同一转换）
+func FromServerConfig(cfg *config.Config) Config {
+	processorConfig := server.ProcessorConfig{
+		ASRConfig:             toASRConfig(cfg),
+		LLMConfig:             toLLMConfig(cfg),
+		TTSConfig:             toTTSConfig(cfg),
+		EnableContinuousMode:  true,
+		MaxConcurrentSessions: defaultMaxSessions,
+		SessionTimeout:        defaultSessionTimeout,
+		AudioBufferSize:       defaultAudioBufferSize,
+		DataCollection: dataset.Config{
+			Enabled:             cfg.DataCollection.Enabled,
+			Sink:                cfg.DataCollection.Sink,
+			Path:                cfg.DataCollection.Path,
+			RequireConsent:      cfg.DataCollection.RequireConsent,
+			Redact:              cfg.DataCollection.Redact,
+			IncludeSystemPrompt: cfg.DataCollection.IncludeSystemPrompt,
+		},
+		EchoSuppression: server.EchoSuppressionConfig{
+			Enabled:   cfg.EchoSuppression.Enabled,
+			Window:    cfg.EchoSuppression.Window,
+			Threshold: cfg.EchoSuppression.Threshold,
+			MinLength: cfg.EchoSuppression.MinLength,
+		},
+		Endpointing: server.EndpointingConfig{
+			Silence:    cfg.Endpointing.Silence,
+			MinSilence: cfg.Endpointing.MinSilence,
+			MaxSilence: cfg.Endpointing.MaxSilence,
+		},
+		Limits: server.LimitsConfig{
+			MaxUtteranceSeconds: cfg.Limits.MaxUtteranceSeconds,
+			MaxResponseTokens:   cfg.Limits.MaxResponseTokens,
+			MaxBufferedSeconds:  cfg.Limits.MaxBufferedSeconds,
+		},
+		SegmentedSpeech: server.SegmentedSpeechConfig{
+			Enabled:     cfg.SegmentedSpeech.Enabled,
+			MaxParallel: cfg.SegmentedSpeech.MaxParallel,
+			MinLength:   cfg.SegmentedSpeech.MinLength,
+		},
+		Quota: server.QuotaConfig{
+			Enabled: cfg.Quota.Enabled,
+			Default: toQuotaLimits(cfg.Quota.Default),
+			APIKeys: toQuotaLimitsMap(cfg.Quota.APIKeys),
+		},
+		Usage: server.UsageConfig{
+			Enabled: cfg.Usage.Enabled,
+			Period:  cfg.Usage.Period,
+			Pricing: server.UsagePricing{
+				PromptTokens:     cfg.Usage.Pricing.PromptTokens,
+				CompletionTokens: cfg.Usage.Pricing.CompletionTokens,
+				ASRMinute:        cfg.Usage.Pricing.ASRMinute,
+				TTSMinute:        cfg.Usage.Pricing.TTSMinute,
+			},
+			Session: toUsageBudget(cfg.Usage.Session),
+			Default: toUsageBudget(cfg.Usage.Default),
+			APIKeys: make(map[string]server.APIKeyBudget, len(cfg.Usage.APIKeys)),
+		},
+		Archive: archive.Config{
+			Enabled: cfg.Archive.Enabled,
+			Store:   cfg.Archive.Store,
+			Path:    cfg.Archive.Path,
+			S3: archive.S3Config{
+				Endpoint:  cfg.Archive.S3.Endpoint,
+				Region:    cfg.Archive.S3.Region,
+				Bucket:    cfg.Archive.S3.Bucket,
+				Prefix:    cfg.Archive.S3.Prefix,
+				AccessKey: cfg.Archive.S3.AccessKey,
+				SecretKey: cfg.Archive.S3.SecretKey,
+				PathStyle: cfg.Archive.S3.PathStyle,
+			},
+			RetentionDays:   cfg.Archive.RetentionDays,
+			CleanupInterval: cfg.Archive.CleanupInterval,
+			RequireConsent:  cfg.Archive.RequireConsent,
+		},
+		Webhook: toWebhookConfig(cfg),
+		Reminders: reminder.Config{
+			Enabled:     cfg.Reminders.Enabled,
+			Path:        cfg.Reminders.Path,
+			MaxPerOwner: cfg.Reminders.MaxPerOwner,
+			MissedTTL:   cfg.Reminders.MissedTTL,
+			Timezone:    cfg.Reminders.Timezone,
+		},
+		Notifications: server.NotificationConfig{
+			RetainFor:  cfg.Notifications.RetainFor,
+			MaxTracked: cfg.Notifications.MaxTracked,
+		},
+		Handover: server.HandoverConfig{
+			Enabled:    cfg.Handover.Enabled,
+			TokenTTL:   cfg.Handover.TokenTTL,
+			MaxHistory: cfg.Handover.MaxHistory,
+		},
+		MaxSessionsPerConnection: cfg.Multiplex.MaxSessionsPerConnection,
+		MaxTurnsPerConnection:    cfg.Multiplex.MaxTurnsPerConnection,
+		MaxMembersPerSession:     cfg.Multiplex.MaxMembersPerSession,
+		AudioChunkSize:           cfg.WebSocket.AudioChunkSize,
+		Pipeline: pipeline.Config{
+			ASRWorkers:    cfg.Pipeline.ASRWorkers,
+			LLMWorkers:    cfg.Pipeline.LLMWorkers,
+			TTSWorkers:    cfg.Pipeline.TTSWorkers,
+			QueueSize:     cfg.Pipeline.QueueSize,
+			Fairness:      cfg.Pipeline.Fairness,
+			MaxPerSession: cfg.Pipeline.MaxPerSession,
+			Weights:       cfg.Pipeline.Weights,
+			ASRTimeout:    cfg.Pipeline.ASRTimeout,
+			LLMTimeout:    cfg.Pipeline.LLMTimeout,
+			TTSTimeout:    cfg.Pipeline.TTSTimeout,
+			TurnTimeout:   cfg.Pipeline.TurnTimeout,
+		},
+		Compute: toComputeConfig(cfg),
+		Memory: memory.Config{
+			Enabled:   cfg.Memory.Enabled,
+			Store:     cfg.Memory.Store,
+			Path:      cfg.Memory.Path,
+			Extractor: cfg.Memory.Extractor,
+			MaxFacts:  cfg.Memory.MaxFacts,
+			Users:     cfg.Memory.Users,
+
+			SpeakerFallback: cfg.Memory.SpeakerFallback,
+		},
+		Knowledge: rag.Config{
+			Enabled: cfg.Knowledge.Enabled,
+			Store:   cfg.Knowledge.Store,
+			Path:    cfg.Knowledge.Path,
+			Qdrant: rag.QdrantConfig{
+				URL:        cfg.Knowledge.Qdrant.URL,
+				Collection: cfg.Knowledge.Qdrant.Collection,
+				APIKey:     cfg.Knowledge.Qdrant.APIKey,
+			},
+			Embedding: rag.EmbeddingConfig{
+				Provider:  cfg.Knowledge.Embedding.Provider,
+				Model:     cfg.Knowledge.Embedding.Model,
+				BaseURL:   cfg.Knowledge.Embedding.BaseURL,
+				APIKey:    cfg.Knowledge.Embedding.APIKey,
+				BatchSize: cfg.Knowledge.Embedding.BatchSize,
+			},
+			Documents:    cfg.Knowledge.Documents,
+			ChunkSize:    cfg.Knowledge.ChunkSize,
+			ChunkOverlap: cfg.Knowledge.ChunkOverlap,
+			TopK:         cfg.Knowledge.TopK,
+			MinScore:     cfg.Knowledge.MinScore,
+		},
+		Speaker: speaker.Config{
+			Enabled:      cfg.Speaker.Enabled,
+			Provider:     cfg.Speaker.Provider,
+			ModelPath:    cfg.Speaker.ModelPath,
+			NumThreads:   cfg.Speaker.NumThreads,
+			URL:          cfg.Speaker.URL,
+			ProfilesFile: cfg.Speaker.ProfilesFile,
+			Threshold:    cfg.Speaker.Threshold,
+			MinDuration:  cfg.Speaker.MinDuration,
+		},
+		Persona: server.PersonaConfig{
+			Default:  cfg.Persona.Default,
+			File:     cfg.Persona.File,
+			Personas: make(map[string]server.Persona, len(cfg.Persona.Personas)),
+		},
+		Moderation: moderation.Config{
+			Enabled:      cfg.Moderation.Enabled,
+			InputAction:  moderation.Action(cfg.Moderation.InputAction),
+			OutputAction: moderation.Action(cfg.Moderation.OutputAction),
+			Keywords:     cfg.Moderation.Keywords,
+			KeywordsFile: cfg.Moderation.KeywordsFile,
+			Provider:     cfg.Moderation.Provider,
+			OpenAI: moderation.OpenAIConfig{
+				APIKey:  cfg.Moderation.OpenAI.APIKey,
+				Model:   cfg.Moderation.OpenAI.Model,
+				BaseURL: cfg.Moderation.OpenAI.BaseURL,
+			},
+			Categories: cfg.Moderation.Categories,
+			FailClosed: cfg.Moderation.FailClosed,
+		},
+		Hooks: toHooksConfig(cfg),
+		Correction: server.CorrectionConfig{
+			Enabled:   cfg.Correction.Enabled,
+			Window:    cfg.Correction.Window,
+			MaxLength: cfg.Correction.MaxLength,
+			Cues:      cfg.Correction.Cues,
+		},
+		TextNorm: textnorm.Config{
+			Enabled:       cfg.TextNorm.Enabled,
+			Lexicon:       cfg.TextNorm.Lexicon,
+			LexiconFile:   cfg.TextNorm.LexiconFile,
+			ExpandNumbers: cfg.TextNorm.ExpandNumbers,
+			StripEmoji:    cfg.TextNorm.StripEmoji,
+			StripMarkdown: cfg.TextNorm.StripMarkdown,
+			CodeBlocks:    cfg.TextNorm.CodeBlocks,
+			URLs:          cfg.TextNorm.URLs,
+		},
+		TranscriptNorm: textnorm.TranscriptConfig{
+			Enabled:        cfg.TranscriptNorm.Enabled,
+			InverseNumbers: cfg.TranscriptNorm.InverseNumbers,
+			Punctuation:    cfg.TranscriptNorm.Punctuation,
+			PunctuationModel: textnorm.PunctuationModelConfig{
+				URL:     cfg.TranscriptNorm.PunctuationModel.URL,
+				APIKey:  cfg.TranscriptNorm.PunctuationModel.APIKey,
+				Timeout: cfg.TranscriptNorm.PunctuationModel.Timeout,
+			},
+		},
+	}
+	for apiKey, key := range cfg.Usage.APIKeys {
+		processorConfig.Usage.APIKeys[apiKey] = server.APIKeyBudget{Name: key.Name, Budget: toUsageBudget(key.Budget)}
+	}
+	for id, persona := range cfg.Persona.Personas {
+		processorConfig.Persona.Personas[id] = server.Persona{Name: persona.Name, SystemPrompt: persona.SystemPrompt}
+	}
+	return processorConfig
+}
+
+// localProviders 在本机运行模型的提供商（受计算资源管理），其余为在线服务
+var localProviders = map[pipeline.Stage]map[string]bool{
+	pipeline.StageASR: {"funasr": true, "whisper": true},
+	pipeline.StageLLM: {"ollama": true},
+	pipeline.StageTTS: {"chattts": true, "sherpa": true, "cosyvoice": true},
+}
+
+// toComputeConfig 转换计算资源配置（只管理使用本地模型的阶段）
+func toComputeConfig(cfg *config.Config) compute.Config {
+	providers := map[pipeline.Stage]string{
+		pipeline.StageASR: cfg.ASR.Provider,
+		pipeline.StageLLM: cfg.LLM.Provider,
+		pipeline.StageTTS: cfg.TTS.Provider,
+	}
+	workers := make(map[pipeline.Stage]compute.WorkerConfig)
+	for name, worker := range cfg.Compute.Workers {
+		stage := pipeline.Stage(name)
+		if !localProviders[stage][providers[stage]] {
+			continue
+		}
+		workers[stage] = compute.WorkerConfig{
+			Device:   worker.Device,
+			MemoryMB: worker.MemoryMB,
+			Threads:  worker.Threads,
+		}
+	}
+	return compute.Config{
+		Enabled:     cfg.Compute.Enabled,
+		GPUSlots:    cfg.Compute.GPUSlots,
+		GPUMemoryMB: cfg.Compute.GPUMemoryMB,
+		CPUThreads:  cfg.Compute.CPUThreads,
+		Workers:     workers,
+	}
+}
+
+// toWebhookConfig 转换Webhook推送配置
+func toWebhookConfig(cfg *config.Config) webhook.Config {
+	endpoints := make([]webhook.EndpointConfig, 0, len(cfg.Webhook.Endpoints))
+	for _, endpoint := range cfg.Webhook.Endpoints {
+		endpoints = append(endpoints, webhook.EndpointConfig{
+			URL:     endpoint.URL,
+			Secret:  endpoint.Secret,
+			Headers: endpoint.Headers,
+		})
+	}
+	return webhook.Config{
+		Enabled:        cfg.Webhook.Enabled,
+		Endpoints:      endpoints,
+		Timeout:        cfg.Webhook.Timeout,
+		MaxRetries:     cfg.Webhook.MaxRetries,
+		RetryInterval:  cfg.Webhook.RetryInterval,
+		QueueSize:      cfg.Webhook.QueueSize,
+		RequireConsent: cfg.Webhook.RequireConsent,
+	}
+}
+
+// toHooksConfig 转换处理流程钩子配置
+func toHooksConfig(cfg *config.Config) hooks.Config {
+	entries := make([]hooks.HookConfig, 0, len(cfg.Hooks.Hooks))
+	for _, hook := range cfg.Hooks.Hooks {
+		stages := make([]hooks.Stage, 0, len(hook.Stages))
+		for _, stage := range hook.Stages {
+			stages = append(stages, hooks.Stage(stage))
+		}
+		entries = append(entries, hooks.HookConfig{
+			Name:    hook.Name,
+			Type:    hook.Type,
+			Stages:  stages,
+			Timeout: hook.Timeout,
+			Command: hook.Command,
+			Args:    hook.Args,
+			Path:    hook.Path,
+			Options: hook.Options,
+		})
+	}
+	return hooks.Config{Enabled: cfg.Hooks.Enabled, Hooks: entries}
+}
+
+// toQuotaLimits 转换配额上限配置
+func toQuotaLimits(limits config.QuotaLimits) server.QuotaLimits {
+	return server.QuotaLimits{
+		MaxTurnsPerHour:       limits.MaxTurnsPerHour,
+		MaxAudioMinutesPerDay: limits.MaxAudioMinutesPerDay,
+		MaxTokensPerDay:       limits.MaxTokensPerDay,
+	}
+}
+
+// toUsageBudget 转换用量预算配置
+func toUsageBudget(budget config.UsageBudgetConfig) server.UsageBudget {
+	return server.UsageBudget{
+		MaxTokens:     budget.MaxTokens,
+		MaxASRSeconds: budget.MaxASRSeconds,
+		MaxTTSSeconds: budget.MaxTTSSeconds,
+		MaxCost:       budget.MaxCost,
+	}
+}
+
+// toQuotaLimitsMap 转换按租户/用户覆盖的配额配置
+func toQuotaLimitsMap(limits map[string]config.QuotaLimits) map[string]server.QuotaLimits {
+	converted := make(map[string]server.QuotaLimits, len(limits))
+	for key, value := range limits {
+		converted[key] = toQuotaLimits(value)
+	}
+	return converted
+}
@@ -0,0 +1,301 @@
+// Package engine 嵌入式语音处理引擎：在其他Go程序中直接使用服务端的ASR→LLM→TTS处理流程
+// 不经过WebSocket等传输层，音频直接送入消息处理器，各阶段结果通过通道返回，适合批量处理和嵌入其他服务。
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"voice_assistant/pkg/protocol"
+	"voice_assistant/voice_assistant_server/internal/server"
+)
+
+// 处理阶段（与协议中的响应阶段一致）
+const (
+	StageASR   = protocol.StageASR
+	StageLLM   = protocol.StageLLM
+	StageTTS   = protocol.StageTTS
+	StageError = "error"
+)
+
+// 会话参数默认值
+const (
+	resultBufferSize = 100
+	idleCheckPeriod  = 50 * time.Millisecond
+)
+
+// ErrSessionClosed 会话已关闭
+var ErrSessionClosed = errors.New("会话已关闭")
+
+// Result 一条阶段结果
+type Result struct {
+	Stage      string                // 处理阶段: asr, llm, tts, error
+	Text       string                // 识别文本或回复文本（TTS结果为合成的文本，可能为空）
+	Confidence float64               // 识别置信度
+	Final      bool                  // 是否为该阶段的最终结果
+	Words      []protocol.WordTiming // 词级别时间戳（ASR结果）
+	Audio      []byte                // 合成的语音（TTS结果）
+	Format     *protocol.AudioFormat // 语音的音频格式（为空时按16kHz单声道PCM处理）
+	Err        *protocol.ErrorData   // 错误信息（Stage 为 error 时）
+}
+
+// Engine 嵌入式处理引擎
+type Engine struct {
+	processor *server.MessageProcessor
+	sessionID atomic.Int64
+}
+
+// New 创建处理引擎并初始化配置中选用的提供商
+// 未设置的会话数上限、会话超时和音频缓冲区大小使用服务端的默认值。
+func New(config Config) (*Engine, error) {
+	if config.MaxConcurrentSessions <= 0 {
+		config.MaxConcurrentSessions = defaultMaxSessions
+	}
+	if config.SessionTimeout <= 0 {
+		config.SessionTimeout = defaultSessionTimeout
+	}
+	if config.AudioBufferSize <= 0 {
+		config.AudioBufferSize = defaultAudioBufferSize
+	}
+
+	processor := server.NewMessageProcessor(config)
+	if err := processor.Initialize(); err != nil {
+		return nil, fmt.Errorf("初始化处理器失败: %w", err)
+	}
+	return &Engine{processor: processor}, nil
+}
+
+// Processor 底层消息处理器（用于同时挂接REST等接口）
+func (e *Engine) Processor() *server.MessageProcessor {
+	return e.processor
+}
+
+// Close 关闭引擎及其全部会话
+func (e *Engine) Close() error {
+	return e.processor.Close()
+}
+
+// SessionOptions 会话参数
+type SessionOptions struct {
+	ID         string                 // 会话ID（为空时自动生成）
+	Mode       string                 // 会话模式（为空时使用服务端默认模式）
+	TextOnly   bool                   // 仅返回文本，不合成语音
+	Persona    string                 // 人设ID
+	APIKey     string                 // 用量统计使用的API Key（为空表示匿名）
+	Parameters map[string]interface{} // 其他 start_session 参数（voice、speed、tenant、user_id 等）
+}
+
+// Session 一个处理会话：音频按顺序送入，各阶段结果从 Results 读取
+// 结果通道需要持续读取，长时间不读取时处理器发送队列溢出，后续结果会被丢弃。
+type Session struct {
+	engine  *Engine
+	client  *server.Client
+	results chan Result
+
+	chunkID   int
+	submitted atomic.Int64 // 已提交的最终音频块数
+	started   atomic.Int64 // 已开始处理（或被拒绝）的轮数
+
+	done      chan struct{}
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+	closed    atomic.Bool
+	mu        sync.Mutex
+}
+
+// NewSession 创建并启动会话
+func (e *Engine) NewSession(opts SessionOptions) (*Session, error) {
+	id := opts.ID
+	if id == "" {
+		id = fmt.Sprintf("engine_%d_%d", time.Now().UnixNano(), e.sessionID.Add(1))
+	}
+
+	s := &Session{
+		engine: e,
+		client: &server.Client{
+			ID:       id,
+			SendChan: make(chan *protocol.Message, resultBufferSize),
+			APIKey:   opts.APIKey,
+		},
+		results: make(chan Result, resultBufferSize),
+		done:    make(chan struct{}),
+	}
+	s.wg.Add(1)
+	go s.dispatch()
+
+	parameters := make(map[string]interface{}, len(opts.Parameters)+2)
+	for k, v := range opts.Parameters {
+		parameters[k] = v
+	}
+	if opts.TextOnly {
+		parameters["text_only"] = true
+	}
+	if opts.Persona != "" {
+		parameters["persona"] = opts.Persona
+	}
+	if err := e.processor.ProcessMessage(s.client, protocol.NewCommandMessage(id, "start_session", opts.Mode, parameters)); err != nil {
+		s.Close()
+		return nil, fmt.Errorf("启动会话失败: %w", err)
+	}
+	return s, nil
+}
+
+// ID 会话ID
+func (s *Session) ID() string {
+	return s.client.ID
+}
+
+// Results 阶段结果通道（会话关闭后关闭）
+func (s *Session) Results() <-chan Result {
+	return s.results
+}
+
+// SendAudio 送入一块16kHz 16bit单声道PCM音频，final 表示一句话结束并开始处理
+func (s *Session) SendAudio(pcm []byte, final bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed.Load() {
+		return ErrSessionClosed
+	}
+	msg := protocol.NewAudioStreamMessage(s.client.ID, protocol.AudioFormatPCM, s.chunkID, final, pcm)
+	s.chunkID++
+	if final {
+		s.submitted.Add(1)
+	}
+	return s.engine.processor.ProcessMessage(s.client, msg)
+}
+
+// Wait 等待已提交的各轮处理完成（上下文取消时提前返回）
+// 最终音频块在调度器中异步处理，以收到本轮开始处理的状态为准，避免刚提交时误判为空闲。
+func (s *Session) Wait(ctx context.Context) error {
+	ticker := time.NewTicker(idleCheckPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.done:
+			return ErrSessionClosed
+		case <-ticker.C:
+		}
+
+		if s.started.Load() >= s.submitted.Load() && !s.engine.processor.IsSessionProcessing(s.client.ID) {
+			return nil
+		}
+	}
+}
+
+// Close 停止并释放会话，关闭结果通道
+func (s *Session) Close() error {
+	s.closeOnce.Do(func() {
+		s.mu.Lock()
+		s.closed.Store(true)
+		s.mu.Unlock()
+
+		s.engine.processor.ProcessMessage(s.client, protocol.NewCommandMessage(s.client.ID, "stop_session", "", nil))
+		s.engine.processor.ReleaseSession(s.client.ID)
+		close(s.done)
+		s.wg.Wait()
+		close(s.results)
+	})
+	return nil
+}
+
+// dispatch 把处理器下发的消息转换为阶段结果
+func (s *Session) dispatch() {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case <-s.done:
+			// 转发队列中剩余的结果
+			for {
+				select {
+				case msg := <-s.client.SendChan:
+					s.forward(msg)
+				default:
+					return
+				}
+			}
+		case msg := <-s.client.SendChan:
+			s.forward(msg)
+		}
+	}
+}
+
+// forward 转发单条消息（状态消息只用于判断处理进度）
+func (s *Session) forward(msg *protocol.Message) {
+	var result Result
+	switch msg.Type {
+	case protocol.Response:
+		data, err := msg.ResponseData()
+		if err != nil {
+			return
+		}
+		result = Result{
+			Stage:      data.Stage,
+			Text:       data.Content,
+			Confidence: data.Confidence,
+			Final:      data.IsFinal,
+			Words:      data.Words,
+			Audio:      data.AudioData,
+			Format:     data.AudioFormat,
+		}
+	case protocol.Error:
+		data, err := msg.ErrorData()
+		if err != nil {
+			return
+		}
+		// 音频被拒绝等情况下本轮不会开始处理
+		if s.started.Load() < s.submitted.Load() {
+			s.started.Add(1)
+		}
+		result = Result{Stage: StageError, Text: data.Message, Final: true, Err: data}
+	case protocol.Status:
+		data, err := msg.StatusData()
+		if err != nil {
+			return
+		}
+		if data.State == string(server.StateProcessing) {
+			s.started.Add(1)
+		}
+		return
+	default:
+		return
+	}
+
+	select {
+	case s.results <- result:
+	case <-s.done:
+		// 关闭时读取方可能已经停止读取，剩余结果尽量放入缓冲区
+		select {
+		case s.results <- result:
+		default:
+		}
+	}
+}
+
+// ProcessAudio 一次性处理一段完整的音频：创建会话、送入音频、等待处理完成后关闭会话
+// 返回的通道在处理完成（或上下文取消）后关闭。
+func (e *Engine) ProcessAudio(ctx context.Context, pcm []byte, opts SessionOptions) (<-chan Result, error) {
+	session, err := e.NewSession(opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := session.SendAudio(pcm, true); err != nil {
+		session.Close()
+		return nil, err
+	}
+
+	go func() {
+		session.Wait(ctx)
+		session.Close()
+	}()
+	return session.Results(), nil
+}
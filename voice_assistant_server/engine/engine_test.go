@@ -0,0 +1,86 @@
+package engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"voice_assistant/voice_assistant_server/internal/asr"
+	"voice_assistant/voice_assistant_server/internal/llm"
+	"voice_assistant/voice_assistant_server/internal/tts"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// engineTestASR 识别出固定文本
+type engineTestASR struct{ asr.ASRService }
+
+func (s *engineTestASR) Initialize(config asr.ASRConfig) error { return nil }
+func (s *engineTestASR) Close() error                          { return nil }
+func (s *engineTestASR) ProcessAudio(ctx context.Context, audio []byte) (asr.ASRResult, error) {
+	return asr.ASRResult{Text: "今天天气怎么样", Confidence: 0.9, IsFinal: true}, nil
+}
+
+// engineTestLLM 回复固定文本
+type engineTestLLM struct{ llm.LLMService }
+
+func (s *engineTestLLM) Initialize(config llm.LLMConfig) error { return nil }
+func (s *engineTestLLM) Close() error                          { return nil }
+func (s *engineTestLLM) Chat(ctx context.Context, input, conversationID string) (llm.LLMResponse, error) {
+	return llm.LLMResponse{Content: "晴天"}, nil
+}
+func (s *engineTestLLM) GenerateResponse(ctx context.Context, messages []llm.Message) (llm.LLMResponse, error) {
+	return llm.LLMResponse{Content: "晴天"}, nil
+}
+
+// engineTestTTS 合成一段静音
+type engineTestTTS struct{ tts.TTSService }
+
+func (s *engineTestTTS) Initialize(config tts.TTSConfig) error { return nil }
+func (s *engineTestTTS) Close() error                          { return nil }
+func (s *engineTestTTS) SynthesizeText(ctx context.Context, text string) (tts.TTSResult, error) {
+	return tts.TTSResult{AudioData: make([]byte, 3200), Format: "pcm", SampleRate: 16000, Duration: 100}, nil
+}
+
+func TestProcessAudio(t *testing.T) {
+	asr.RegisterASR("enginetest", func(config asr.ASRConfig) (asr.ASRService, error) { return &engineTestASR{}, nil })
+	llm.RegisterLLM("enginetest", func(config llm.LLMConfig) (llm.LLMService, error) { return &engineTestLLM{}, nil })
+	tts.RegisterTTS("enginetest", func(config tts.TTSConfig) (tts.TTSService, error) { return &engineTestTTS{}, nil })
+
+	e, err := New(Config{
+		ASRConfig: ASRConfig{Type: "enginetest"},
+		LLMConfig: LLMConfig{Type: "enginetest"},
+		TTSConfig: TTSConfig{Type: "enginetest"},
+	})
+	require.NoError(t, err)
+	defer e.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	results, err := e.ProcessAudio(ctx, make([]byte, 16000), SessionOptions{})
+	require.NoError(t, err)
+
+	finals := make(map[string]Result)
+	for result := range results {
+		if result.Final {
+			finals[result.Stage] = result
+		}
+	}
+	require.NoError(t, ctx.Err())
+
+	assert.Equal(t, "今天天气怎么样", finals[StageASR].Text)
+	assert.Equal(t, "晴天", finals[StageLLM].Text)
+	assert.NotEmpty(t, finals[StageTTS].Audio)
+	assert.NotContains(t, finals, StageError)
+
+	// 仅文本会话不合成语音
+	results, err = e.ProcessAudio(ctx, make([]byte, 16000), SessionOptions{TextOnly: true})
+	require.NoError(t, err)
+	var stages []string
+	for result := range results {
+		stages = append(stages, result.Stage)
+	}
+	assert.Contains(t, stages, StageLLM)
+	assert.NotContains(t, stages, StageTTS)
+}
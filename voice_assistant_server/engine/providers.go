@@ -0,0 +1,203 @@
+package engine
+
+import (
+	"log"
+	"strconv"
+	"strings"
+
+	"voice_assistant/voice_assistant_server/internal/asr"
+	"voice_assistant/voice_assistant_server/internal/config"
+	"voice_assistant/voice_assistant_server/internal/llm"
+	"voice_assistant/voice_assistant_server/internal/tts"
+)
+
+// toASRConfig 转换ASR配置（各提供商的配置节都映射到对应字段，只有provider选中的提供商会使用）
+func toASRConfig(cfg *config.Config) asr.ASRConfig {
+	c := cfg.ASR
+	language := c.Settings.Language
+	if language == "" {
+		language = c.Whisper.Language
+	}
+	return asr.ASRConfig{
+		Type:       c.Provider,
+		ModelPath:  c.Whisper.ModelPath,
+		Language:   language,
+		SampleRate: orDefault(c.Settings.SampleRate, 16000),
+		Channels:   orDefault(c.Settings.Channels, 1),
+		APIKey:     c.OpenAI.APIKey,
+		APIUrl:     c.OpenAI.APIURL,
+		Model:      c.OpenAI.Model,
+		Timeout:    orDefault(c.Settings.Timeout, 30),
+		OpenAIConfig: asr.OpenAIConfig{
+			PartialInterval: c.OpenAI.PartialInterval,
+		},
+		WhisperConfig: asr.WhisperConfig{
+			BeamSize:    c.Whisper.BeamSize,
+			Temperature: c.Whisper.Temperature,
+		},
+		FunASRConfig: asr.FunASRConfig{
+			ModelDir:          c.FunASR.ModelDir,
+			ModelRevision:     c.FunASR.ModelRevision,
+			DeviceID:          c.FunASR.DeviceID,
+			IntraOpNumThreads: c.FunASR.IntraOpNumThreads,
+			BatchSize:         c.FunASR.BatchSize,
+		},
+	}
+}
+
+// toLLMConfig 转换LLM配置（模型、地址和采样参数取自provider对应的配置节，插件提供商使用openai配置节）
+func toLLMConfig(cfg *config.Config) llm.LLMConfig {
+	c := cfg.LLM
+	llmConfig := llm.LLMConfig{
+		Type:              c.Provider,
+		SystemPrompt:      c.SystemPrompt,
+		Timeout:           orDefault(c.Settings.Timeout, 30),
+		TopP:              c.Settings.TopP,
+		TopK:              c.Settings.TopK,
+		MaxContextLength:  c.Settings.MaxContextLength,
+		EnableContextTrim: c.Settings.EnableContextTrim,
+		KeepSystemPrompt:  c.Settings.KeepSystemPrompt,
+		OpenAIConfig: llm.OpenAIConfig{
+			Organization: c.OpenAI.Organization,
+			Stream:       true,
+		},
+		OllamaConfig: llm.OllamaConfig{
+			NumCtx:    c.Ollama.NumCtx,
+			NumGPU:    c.Ollama.NumGPU,
+			NumThread: c.Ollama.NumThread,
+		},
+		WebSocketConfig: llm.WebSocketConfig{
+			URL:               c.WebSocket.URL,
+			Headers:           c.WebSocket.Headers,
+			ReconnectInterval: c.WebSocket.ReconnectInterval,
+			MaxReconnects:     c.WebSocket.MaxReconnects,
+			PingInterval:      c.WebSocket.PingInterval,
+		},
+		Conversation: llm.ConversationConfig{
+			MaxConversations: c.Conversation.MaxConversations,
+			EvictionPolicy:   c.Conversation.EvictionPolicy,
+			TTL:              c.Conversation.TTL,
+			MaxMessages:      c.Conversation.MaxMessages,
+			Tokenizer:        c.Conversation.Tokenizer,
+			TrimStrategy:     c.Conversation.TrimStrategy,
+			SummaryMaxTokens: c.Conversation.SummaryMaxTokens,
+		},
+	}
+
+	switch c.Provider {
+	case "ollama":
+		llmConfig.Model = c.Ollama.Model
+		llmConfig.APIUrl = strings.TrimSuffix(c.Ollama.BaseURL, "/")
+		llmConfig.Temperature = float32(c.Ollama.Temperature)
+		llmConfig.MaxTokens = c.Ollama.MaxTokens
+	case "websocket":
+		llmConfig.Model = c.WebSocket.Model
+		llmConfig.Temperature = float32(c.WebSocket.Temperature)
+		llmConfig.MaxTokens = c.WebSocket.MaxTokens
+	default:
+		llmConfig.Model = c.OpenAI.Model
+		llmConfig.APIKey = c.OpenAI.APIKey
+		llmConfig.APIUrl = c.OpenAI.APIURL
+		llmConfig.Temperature = float32(c.OpenAI.Temperature)
+		llmConfig.MaxTokens = c.OpenAI.MaxTokens
+	}
+	return llmConfig
+}
+
+// toTTSConfig 转换TTS配置（声音取自provider对应的配置节，Edge TTS的语速、音量和音调换算为倍率）
+func toTTSConfig(cfg *config.Config) tts.TTSConfig {
+	c := cfg.TTS
+	ttsConfig := tts.TTSConfig{
+		Type:       ttsType(c.Provider),
+		Voice:      c.EdgeTTS.Voice,
+		Language:   orDefaultString(c.Settings.Language, "zh-CN"),
+		SampleRate: c.Settings.SampleRate,
+		Channels:   1,
+		Format:     orDefaultString(c.Settings.Format, "wav"),
+		Quality:    c.Settings.Quality,
+		Speed:      1.0,
+		Pitch:      1.0,
+		Volume:     1.0,
+		Timeout:    orDefault(c.Settings.Timeout, 30),
+		EdgeConfig: tts.EdgeConfig{
+			UseWebSocket: true,
+		},
+		SherpaConfig: tts.SherpaConfig{
+			ModelPath:   c.Sherpa.ModelPath,
+			LexiconPath: c.Sherpa.LexiconPath,
+			TokensPath:  c.Sherpa.TokensPath,
+			DataDir:     c.Sherpa.DataDir,
+			NumThreads:  c.Sherpa.NumThreads,
+		},
+		ChatTTSConfig: tts.ChatTTSConfig{
+			ModelPath:   c.ChatTTS.ModelPath,
+			Device:      c.ChatTTS.Device,
+			Temperature: c.ChatTTS.Temperature,
+			TopP:        c.ChatTTS.TopP,
+			TopK:        c.ChatTTS.TopK,
+			SpeakerID:   c.ChatTTS.SpeakerID,
+			NumThreads:  c.ChatTTS.NumThreads,
+		},
+		CosyVoiceConfig: tts.CosyVoiceConfig{
+			URL:        c.CosyVoice.URL,
+			SampleRate: c.CosyVoice.SampleRate,
+			Speakers:   c.CosyVoice.Speakers,
+			VoicesDir:  c.CosyVoice.VoicesDir,
+		},
+	}
+
+	switch c.Provider {
+	case "edge_tts":
+		ttsConfig.Speed = edgeProsody("rate", c.EdgeTTS.Rate)
+		ttsConfig.Volume = edgeProsody("volume", c.EdgeTTS.Volume)
+		ttsConfig.Pitch = edgeProsody("pitch", c.EdgeTTS.Pitch)
+	case "cosyvoice":
+		ttsConfig.Voice = c.CosyVoice.Voice
+	case "chattts":
+		ttsConfig.Voice = strconv.Itoa(c.ChatTTS.SpeakerID)
+	case "sherpa":
+		ttsConfig.Voice = c.Sherpa.Voice
+	}
+	return ttsConfig
+}
+
+// ttsType 配置中的TTS提供商名称对应的注册名（edge_tts 与配置节名称一致，注册名为 edge）
+func ttsType(provider string) string {
+	if provider == "edge_tts" {
+		return "edge"
+	}
+	return provider
+}
+
+// edgeProsody 把Edge TTS的相对百分比（如 "+10%"、"-20%"）换算为倍率，无法换算的取值（如 "+0Hz"）按原值1.0处理
+func edgeProsody(name, value string) float32 {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 1.0
+	}
+	if percent, ok := strings.CutSuffix(value, "%"); ok {
+		if n, err := strconv.ParseFloat(percent, 32); err == nil && n > -100 {
+			return float32(1 + n/100)
+		}
+	}
+	if value != "+0Hz" && value != "0Hz" {
+		log.Printf("Edge TTS的%s只支持相对百分比（如 +10%%），忽略: %s", name, value)
+	}
+	return 1.0
+}
+
+// orDefault 未配置（为0）时使用默认值
+func orDefault(value, fallback int) int {
+	if value == 0 {
+		return fallback
+	}
+	return value
+}
+
+// orDefaultString 未配置（为空）时使用默认值
+func orDefaultString(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
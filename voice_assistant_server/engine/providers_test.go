@@ -0,0 +1,159 @@
+package engine
+
+import (
+	"os"
+	"testing"
+
+	"voice_assistant/voice_assistant_server/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToASRConfig(t *testing.T) {
+	cfg, err := config.LoadConfig([]byte(`
+llm:
+  provider: "ollama"
+asr:
+  provider: "funasr"
+  funasr:
+    model_dir: "./models/funasr"
+    model_revision: "v2.0.4"
+    device_id: "cuda:0"
+    intra_op_num_threads: 8
+    batch_size: 4
+  whisper:
+    model_path: "./models/whisper.bin"
+    language: "en"
+    beam_size: 5
+  openai:
+    api_key: "sk-test"
+    model: "gpt-4o-transcribe"
+    api_url: "http://localhost:9000/v1/audio/transcriptions"
+  settings:
+    sample_rate: 8000
+    timeout: 10
+`))
+	require.NoError(t, err)
+
+	asrConfig := toASRConfig(cfg)
+	assert.Equal(t, "funasr", asrConfig.Type)
+	assert.Equal(t, "en", asrConfig.Language, "未配置settings.language时沿用whisper.language")
+	assert.Equal(t, 8000, asrConfig.SampleRate)
+	assert.Equal(t, 1, asrConfig.Channels)
+	assert.Equal(t, 10, asrConfig.Timeout)
+	assert.Equal(t, "./models/funasr", asrConfig.FunASRConfig.ModelDir)
+	assert.Equal(t, "v2.0.4", asrConfig.FunASRConfig.ModelRevision)
+	assert.Equal(t, "cuda:0", asrConfig.FunASRConfig.DeviceID)
+	assert.Equal(t, 8, asrConfig.FunASRConfig.IntraOpNumThreads)
+	assert.Equal(t, 4, asrConfig.FunASRConfig.BatchSize)
+	assert.Equal(t, "./models/whisper.bin", asrConfig.ModelPath)
+	assert.Equal(t, 5, asrConfig.WhisperConfig.BeamSize)
+	assert.Equal(t, "sk-test", asrConfig.APIKey)
+	assert.Equal(t, "gpt-4o-transcribe", asrConfig.Model)
+	assert.Equal(t, "http://localhost:9000/v1/audio/transcriptions", asrConfig.APIUrl)
+}
+
+func TestToLLMConfig(t *testing.T) {
+	cfg, err := config.LoadConfig([]byte(`
+llm:
+  provider: "ollama"
+  system_prompt: "你是语音助手"
+  ollama:
+    base_url: "http://gpu-host:11434/"
+    model: "qwen:7b"
+    temperature: 0.5
+    max_tokens: 512
+    num_ctx: 8192
+  openai:
+    api_key: "sk-test"
+    model: "gpt-4o"
+    temperature: 0.7
+  settings:
+    max_context_length: 6000
+    enable_context_trim: true
+    top_p: 0.9
+`))
+	require.NoError(t, err)
+
+	llmConfig := toLLMConfig(cfg)
+	assert.Equal(t, "ollama", llmConfig.Type)
+	assert.Equal(t, "qwen:7b", llmConfig.Model)
+	assert.Equal(t, "http://gpu-host:11434", llmConfig.APIUrl)
+	assert.Empty(t, llmConfig.APIKey)
+	assert.InDelta(t, 0.5, llmConfig.Temperature, 1e-6)
+	assert.Equal(t, 512, llmConfig.MaxTokens)
+	assert.Equal(t, 8192, llmConfig.OllamaConfig.NumCtx)
+	assert.Equal(t, 6000, llmConfig.MaxContextLength)
+	assert.True(t, llmConfig.EnableContextTrim)
+	assert.InDelta(t, 0.9, llmConfig.TopP, 1e-6)
+	assert.Equal(t, 30, llmConfig.Timeout)
+	assert.Equal(t, "你是语音助手", llmConfig.SystemPrompt)
+
+	cfg.LLM.Provider = "openai"
+	llmConfig = toLLMConfig(cfg)
+	assert.Equal(t, "gpt-4o", llmConfig.Model)
+	assert.Equal(t, "sk-test", llmConfig.APIKey)
+	assert.InDelta(t, 0.7, llmConfig.Temperature, 1e-6)
+}
+
+func TestToTTSConfig(t *testing.T) {
+	cfg, err := config.LoadConfig([]byte(`
+llm:
+  provider: "ollama"
+tts:
+  provider: "edge_tts"
+  edge_tts:
+    voice: "zh-CN-YunxiNeural"
+    rate: "+20%"
+    volume: "-50%"
+    pitch: "+0Hz"
+  chattts:
+    temperature: 0.2
+    top_k: 10
+    speaker_id: 7
+  sherpa:
+    model_path: "./models/sherpa"
+    num_threads: 2
+  settings:
+    sample_rate: 16000
+    format: "mp3"
+`))
+	require.NoError(t, err)
+
+	ttsConfig := toTTSConfig(cfg)
+	assert.Equal(t, "edge", ttsConfig.Type)
+	assert.Equal(t, "zh-CN-YunxiNeural", ttsConfig.Voice)
+	assert.InDelta(t, 1.2, ttsConfig.Speed, 1e-6)
+	assert.InDelta(t, 0.5, ttsConfig.Volume, 1e-6)
+	assert.InDelta(t, 1.0, ttsConfig.Pitch, 1e-6)
+	assert.Equal(t, 16000, ttsConfig.SampleRate)
+	assert.Equal(t, "mp3", ttsConfig.Format)
+	assert.Equal(t, "zh-CN", ttsConfig.Language)
+	assert.Equal(t, "./models/sherpa", ttsConfig.SherpaConfig.ModelPath)
+	assert.Equal(t, 2, ttsConfig.SherpaConfig.NumThreads)
+
+	cfg.TTS.Provider = "chattts"
+	ttsConfig = toTTSConfig(cfg)
+	assert.Equal(t, "7", ttsConfig.Voice)
+	assert.InDelta(t, 0.2, ttsConfig.ChatTTSConfig.Temperature, 1e-6)
+	assert.Equal(t, 10, ttsConfig.ChatTTSConfig.TopK)
+	assert.InDelta(t, 1.0, ttsConfig.Speed, 1e-6)
+}
+
+// 随仓库提供的配置文件可以完整加载并转换
+func TestProviderConfigsFromServerYAML(t *testing.T) {
+	data, err := os.ReadFile("../config/server.yaml")
+	require.NoError(t, err)
+	cfg, err := config.LoadConfig(data)
+	require.NoError(t, err)
+
+	assert.Equal(t, "./models/funasr/paraformer-zh", toASRConfig(cfg).FunASRConfig.ModelDir)
+	llmConfig := toLLMConfig(cfg)
+	assert.Equal(t, "qwen:7b", llmConfig.Model)
+	assert.Equal(t, "http://localhost:11434", llmConfig.APIUrl)
+	assert.Equal(t, 4000, llmConfig.MaxContextLength)
+	ttsConfig := toTTSConfig(cfg)
+	assert.Equal(t, 24000, ttsConfig.SampleRate)
+	assert.InDelta(t, 0.3, ttsConfig.ChatTTSConfig.Temperature, 1e-6)
+}
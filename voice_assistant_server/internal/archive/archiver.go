@@ -0,0 +1,197 @@
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"path"
+	"sync"
+	"time"
+)
+
+// Transcript 归档的文本记录（transcript.json）
+type Transcript struct {
+	SessionKey  string  `json:"session_key"`            // 会话标识（哈希）
+	Language    string  `json:"language,omitempty"`     // 会话语言
+	Transcript  string  `json:"transcript"`             // 识别文本
+	Confidence  float64 `json:"confidence"`             // 识别置信度
+	Reply       string  `json:"reply,omitempty"`        // LLM回复
+	Model       string  `json:"model,omitempty"`        // 生成回复的模型
+	Tokens      int     `json:"tokens,omitempty"`       // Token用量
+	Error       string  `json:"error,omitempty"`        // 处理失败原因
+	InputAudio  string  `json:"input_audio,omitempty"`  // 输入音频文件名
+	OutputAudio string  `json:"output_audio,omitempty"` // 输出音频文件名
+	Timestamp   int64   `json:"timestamp"`              // 时间戳（毫秒）
+}
+
+// Archiver 逐句归档输入音频、识别文本、回复和合成音频，并按保留策略清理过期数据
+// 对象键布局: <日期>/<会话标识>/<时间>/{input.wav,transcript.json,output.wav|mp3}
+type Archiver struct {
+	store  Store
+	config Config
+
+	wg     sync.WaitGroup
+	stop   chan struct{}
+	closed bool
+	mu     sync.RWMutex
+}
+
+// NewArchiver 创建归档器（配置了保留天数时启动后台清理）
+func NewArchiver(config Config) (*Archiver, error) {
+	if config.Store == "" {
+		config.Store = "local"
+	}
+	if config.CleanupInterval <= 0 {
+		config.CleanupInterval = time.Hour
+	}
+
+	store, err := CreateStore(config)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Archiver{
+		store:  store,
+		config: config,
+		stop:   make(chan struct{}),
+	}
+	if config.RetentionDays > 0 {
+		a.wg.Add(1)
+		go a.retentionLoop()
+	}
+	return a, nil
+}
+
+// Archive 归档一句话的处理记录
+func (a *Archiver) Archive(ctx context.Context, utt Utterance) error {
+	a.mu.RLock()
+	if a.closed {
+		a.mu.RUnlock()
+		return ErrArchiverClosed
+	}
+	a.wg.Add(1)
+	a.mu.RUnlock()
+	defer a.wg.Done()
+
+	if utt.Time.IsZero() {
+		utt.Time = time.Now()
+	}
+	t := utt.Time.UTC()
+	dir := path.Join(t.Format("2006-01-02"), utt.SessionKey, t.Format("150405.000000"))
+
+	record := Transcript{
+		SessionKey: utt.SessionKey,
+		Language:   utt.Language,
+		Transcript: utt.Transcript,
+		Confidence: utt.Confidence,
+		Reply:      utt.Reply,
+		Model:      utt.Model,
+		Tokens:     utt.Tokens,
+		Error:      utt.Error,
+		Timestamp:  utt.Time.UnixMilli(),
+	}
+
+	if len(utt.InputAudio) > 0 {
+		record.InputAudio = "input.wav"
+		if err := a.store.Put(ctx, path.Join(dir, record.InputAudio), utt.InputAudio, "audio/wav"); err != nil {
+			return fmt.Errorf("归档输入音频失败: %w", err)
+		}
+	}
+
+	if len(utt.OutputAudio) > 0 {
+		format := utt.OutputFormat
+		if format == "" {
+			format = "wav"
+		}
+		contentType := "audio/" + format
+		if format == "mp3" {
+			contentType = "audio/mpeg"
+		}
+		record.OutputAudio = "output." + format
+		if err := a.store.Put(ctx, path.Join(dir, record.OutputAudio), utt.OutputAudio, contentType); err != nil {
+			return fmt.Errorf("归档输出音频失败: %w", err)
+		}
+	}
+
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := a.store.Put(ctx, path.Join(dir, "transcript.json"), data, "application/json"); err != nil {
+		return fmt.Errorf("归档文本记录失败: %w", err)
+	}
+	return nil
+}
+
+// Prune 删除超过保留天数的对象，返回删除的数量
+func (a *Archiver) Prune(ctx context.Context, now time.Time) (int, error) {
+	if a.config.RetentionDays <= 0 {
+		return 0, nil
+	}
+
+	objects, err := a.store.List(ctx, "")
+	if err != nil {
+		return 0, fmt.Errorf("列出归档对象失败: %w", err)
+	}
+
+	cutoff := now.AddDate(0, 0, -a.config.RetentionDays)
+	deleted := 0
+	for _, obj := range objects {
+		if !obj.ModTime.Before(cutoff) {
+			continue
+		}
+		if err := a.store.Delete(ctx, obj.Key); err != nil {
+			return deleted, fmt.Errorf("删除过期归档失败: %s: %w", obj.Key, err)
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// retentionLoop 定期清理过期归档
+func (a *Archiver) retentionLoop() {
+	defer a.wg.Done()
+
+	ticker := time.NewTicker(a.config.CleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		a.prune()
+		select {
+		case <-ticker.C:
+		case <-a.stop:
+			return
+		}
+	}
+}
+
+// prune 执行一次过期清理并记录结果
+func (a *Archiver) prune() {
+	ctx, cancel := context.WithTimeout(context.Background(), a.config.CleanupInterval)
+	defer cancel()
+
+	deleted, err := a.Prune(ctx, time.Now())
+	if err != nil {
+		log.Printf("清理过期归档失败: %v", err)
+		return
+	}
+	if deleted > 0 {
+		log.Printf("已清理过期归档对象: %d", deleted)
+	}
+}
+
+// Close 停止后台清理并等待进行中的归档写完
+func (a *Archiver) Close() error {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return nil
+	}
+	a.closed = true
+	close(a.stop)
+	a.mu.Unlock()
+
+	a.wg.Wait()
+	return nil
+}
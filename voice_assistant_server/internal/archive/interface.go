@@ -0,0 +1,94 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// 归档相关错误定义
+var (
+	ErrUnsupportedStoreType = errors.New("unsupported archive store type")
+	ErrArchiverClosed       = errors.New("archiver closed")
+)
+
+// Store 归档存储接口（对象按键存放，键以"/"分隔层级）
+type Store interface {
+	// Put 写入对象
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+
+	// List 列出指定前缀下的对象
+	List(ctx context.Context, prefix string) ([]Object, error)
+
+	// Delete 删除对象
+	Delete(ctx context.Context, key string) error
+}
+
+// Object 存储中的对象
+type Object struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
+// Config 归档配置
+type Config struct {
+	Enabled         bool          `yaml:"enabled"`          // 是否启用（默认关闭）
+	Store           string        `yaml:"store"`            // 存储类型: local|s3
+	Path            string        `yaml:"path"`             // 本地存储目录
+	S3              S3Config      `yaml:"s3"`               // S3兼容存储配置
+	RetentionDays   int           `yaml:"retention_days"`   // 保留天数（0表示永久保留）
+	CleanupInterval time.Duration `yaml:"cleanup_interval"` // 过期清理间隔
+	RequireConsent  bool          `yaml:"require_consent"`  // 仅归档明确同意数据采集的会话
+}
+
+// S3Config S3兼容存储配置（AWS S3、MinIO等）
+type S3Config struct {
+	Endpoint  string `yaml:"endpoint"`   // 服务地址，如 https://s3.amazonaws.com、http://minio:9000
+	Region    string `yaml:"region"`     // 区域
+	Bucket    string `yaml:"bucket"`     // 存储桶
+	Prefix    string `yaml:"prefix"`     // 对象键前缀
+	AccessKey string `yaml:"access_key"` // 访问密钥ID
+	SecretKey string `yaml:"secret_key"` // 访问密钥
+	PathStyle bool   `yaml:"path_style"` // 使用路径风格地址（MinIO等自建服务通常需要）
+}
+
+// Utterance 一句话的完整处理记录
+type Utterance struct {
+	SessionKey string    // 会话标识（哈希）
+	Time       time.Time // 开始处理时间
+	Language   string    // 会话语言
+
+	InputAudio []byte  // 输入音频（WAV）
+	Transcript string  // 识别文本
+	Confidence float64 // 识别置信度
+
+	Reply  string // LLM回复
+	Model  string // 生成回复的模型
+	Tokens int    // Token用量
+
+	OutputAudio  []byte // TTS输出音频
+	OutputFormat string // TTS输出格式（即文件扩展名，默认wav）
+
+	Error string // 处理失败时的错误阶段和原因
+}
+
+// StoreFactory 存储工厂函数类型
+type StoreFactory func(config Config) (Store, error)
+
+// 注册的存储实现
+var storeFactories = make(map[string]StoreFactory)
+
+// RegisterStore 注册存储实现
+func RegisterStore(name string, factory StoreFactory) {
+	storeFactories[name] = factory
+}
+
+// CreateStore 创建存储
+func CreateStore(config Config) (Store, error) {
+	factory, exists := storeFactories[config.Store]
+	if !exists {
+		return nil, ErrUnsupportedStoreType
+	}
+	return factory(config)
+}
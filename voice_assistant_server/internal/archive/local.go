@@ -0,0 +1,99 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStore 本地目录存储
+type LocalStore struct {
+	root string
+}
+
+// NewLocalStore 创建本地目录存储
+func NewLocalStore(config Config) (*LocalStore, error) {
+	root := config.Path
+	if root == "" {
+		root = "data/archive"
+	}
+
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, fmt.Errorf("创建归档目录失败: %w", err)
+	}
+	return &LocalStore{root: root}, nil
+}
+
+// Put 写入文件
+func (s *LocalStore) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	path := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建归档目录失败: %w", err)
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// List 列出前缀下的文件
+func (s *LocalStore) List(ctx context.Context, prefix string) ([]Object, error) {
+	var objects []Object
+	err := filepath.WalkDir(s.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return ctx.Err()
+		}
+
+		rel, err := filepath.Rel(s.root, path)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, Object{
+			Key:     key,
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		})
+		return nil
+	})
+	return objects, err
+}
+
+// Delete 删除文件，并清理随之变空的目录
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	path := s.path(key)
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	root := filepath.Clean(s.root)
+	for dir := filepath.Dir(path); dir != root && strings.HasPrefix(dir, root); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break // 目录非空
+		}
+	}
+	return nil
+}
+
+// path 对象键对应的文件路径（键中的".."不能越出根目录）
+func (s *LocalStore) path(key string) string {
+	return filepath.Join(s.root, filepath.FromSlash(filepath.Clean("/"+key)))
+}
+
+// 注册本地存储
+func init() {
+	RegisterStore("local", func(config Config) (Store, error) {
+		return NewLocalStore(config)
+	})
+}
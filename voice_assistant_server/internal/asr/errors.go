@@ -1,19 +1,24 @@
 package asr
 
-import "errors"
+import (
+	"errors"
 
-// ASR相关错误定义
+	"voice_assistant/voice_assistant_server/internal/errs"
+)
+
+// ASR相关错误定义（与LLM、TTS共有的错误为errs中的同一值，按errs的类别对应协议错误代码）
 var (
-	ErrUnsupportedASRType   = errors.New("unsupported ASR type")
-	ErrASRNotInitialized    = errors.New("ASR service not initialized")
-	ErrInvalidAudioFormat   = errors.New("invalid audio format")
-	ErrModelNotFound        = errors.New("ASR model not found")
-	ErrModelLoadFailed      = errors.New("failed to load ASR model")
+	ErrUnsupportedASRType   = errs.New(errs.KindMisconfigured, "unsupported ASR type")
+	ErrASRNotInitialized    = errs.New(errs.KindUnavailable, "ASR service not initialized")
+	ErrInvalidAudioFormat   = errs.New(errs.KindInvalidInput, "invalid audio format")
+	ErrModelNotFound        = errs.ErrModelNotFound
+	ErrModelLoadFailed      = errs.ErrModelLoadFailed
 	ErrProcessingFailed     = errors.New("audio processing failed")
-	ErrLanguageNotSupported = errors.New("language not supported")
-	ErrInvalidConfig        = errors.New("invalid ASR configuration")
-	ErrConnectionFailed     = errors.New("failed to connect to ASR service")
-	ErrTimeout              = errors.New("ASR processing timeout")
-	ErrInsufficientMemory   = errors.New("insufficient memory for ASR model")
-	ErrGPUNotAvailable      = errors.New("GPU not available for ASR")
+	ErrStreamingUnsupported = errs.New(errs.KindUnsupported, "streaming recognition not supported")
+	ErrLanguageNotSupported = errs.ErrLanguageNotSupported
+	ErrInvalidConfig        = errs.ErrInvalidConfig
+	ErrConnectionFailed     = errs.ErrConnectionFailed
+	ErrTimeout              = errs.ErrTimeout
+	ErrInsufficientMemory   = errs.ErrInsufficientMemory
+	ErrGPUNotAvailable      = errs.ErrGPUNotAvailable
 )
@@ -1,3 +1,5 @@
+//go:build !no_asr_funasr
+
 package asr
 
 import (
@@ -173,6 +175,11 @@ func (f *FunASR) checkEnvironment() error {
 	return nil
 }
 
+// CheckHealth 检查模型目录是否仍然存在
+func (f *FunASR) CheckHealth(ctx context.Context) error {
+	return f.validateModelFiles()
+}
+
 // validateModelFiles 验证模型文件
 func (f *FunASR) validateModelFiles() error {
 	if f.config.FunASRConfig.ModelDir == "" {
@@ -245,7 +252,7 @@ try:
     )
     
     # 识别音频
-    result = model.generate(input="%s")
+    result = model.generate(input="%s", batch_size=%d)
     
     # 输出结果
     if result and len(result) > 0:
@@ -276,6 +283,7 @@ except Exception as e:
 		f.config.FunASRConfig.DeviceID,
 		f.config.FunASRConfig.IntraOpNumThreads,
 		audioFile,
+		max(f.config.FunASRConfig.BatchSize, 1),
 		f.config.Language,
 		f.config.Language,
 		f.config.Language,
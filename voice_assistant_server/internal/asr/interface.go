@@ -2,7 +2,10 @@ package asr
 
 import (
 	"context"
+	"fmt"
 	"io"
+	"sort"
+	"time"
 )
 
 // ASRService ASR服务接口
@@ -32,6 +35,12 @@ type ASRService interface {
 	GetModelInfo() ModelInfo
 }
 
+// HealthChecker 可主动探测后端状态的ASR服务（可选实现，供就绪检查使用）
+type HealthChecker interface {
+	// CheckHealth 检查模型文件或在线服务是否可用
+	CheckHealth(ctx context.Context) error
+}
+
 // ASRConfig ASR配置
 type ASRConfig struct {
 	Type       string `yaml:"type"`        // whisper|sherpa|funasr|openai
@@ -41,8 +50,12 @@ type ASRConfig struct {
 	Channels   int    `yaml:"channels"`    // 声道数
 	APIKey     string `yaml:"api_key"`     // API密钥（在线服务）
 	APIUrl     string `yaml:"api_url"`     // API地址
+	Model      string `yaml:"model"`       // 模型名称（在线服务）
 	Timeout    int    `yaml:"timeout"`     // 超时时间（秒）
 
+	// OpenAI特定配置
+	OpenAIConfig OpenAIConfig `yaml:"openai"`
+
 	// Whisper特定配置
 	WhisperConfig WhisperConfig `yaml:"whisper"`
 
@@ -53,6 +66,11 @@ type ASRConfig struct {
 	FunASRConfig FunASRConfig `yaml:"funasr"`
 }
 
+// OpenAIConfig OpenAI配置
+type OpenAIConfig struct {
+	PartialInterval time.Duration `yaml:"partial_interval"` // 伪流式识别重新识别已收到音频的间隔（0表示不支持流式识别）
+}
+
 // WhisperConfig Whisper配置
 type WhisperConfig struct {
 	ModelSize   string  `yaml:"model_size"`   // tiny|base|small|medium|large
@@ -85,6 +103,7 @@ type FunASRConfig struct {
 	QuantType         string `yaml:"quant_type"`           // 量化类型
 	IntraOpNumThreads int    `yaml:"intra_op_num_threads"` // 线程数
 	CacheSize         int    `yaml:"cache_size"`           // 缓存大小
+	BatchSize         int    `yaml:"batch_size"`           // 批处理大小
 }
 
 // ASRResult ASR识别结果
@@ -93,16 +112,26 @@ type ASRResult struct {
 	Confidence float64 `json:"confidence"` // 置信度
 	Language   string  `json:"language"`   // 语言
 	IsFinal    bool    `json:"is_final"`   // 是否为最终结果
-	StartTime  int64   `json:"start_time"` // 开始时间（毫秒）
-	EndTime    int64   `json:"end_time"`   // 结束时间（毫秒）
+	StartTime  int64   `json:"start_time"` // 开始时间（毫秒；whisper为语音相对音频起点的偏移，与Words一致）
+	EndTime    int64   `json:"end_time"`   // 结束时间（毫秒；同上）
 	Words      []Word  `json:"words"`      // 词级别信息
 
+	// 说话人（启用说话人识别且匹配到已登记的说话人时）
+	Speaker *Speaker `json:"speaker,omitempty"`
+
 	// 元数据
 	ProcessTime int64  `json:"process_time"` // 处理耗时（毫秒）
 	ModelInfo   string `json:"model_info"`   // 模型信息
 	Error       error  `json:"error"`        // 错误信息
 }
 
+// Speaker 识别到的说话人
+type Speaker struct {
+	ID    string  `json:"id"`    // 说话人ID
+	Name  string  `json:"name"`  // 称呼
+	Score float64 `json:"score"` // 声纹相似度
+}
+
 // Word 词级别信息
 type Word struct {
 	Text       string  `json:"text"`       // 词文本
@@ -124,6 +153,25 @@ type ModelInfo struct {
 	MemoryUsage int64    `json:"memory_usage"` // 内存使用（字节）
 }
 
+// RequestOptions 单次请求选项（通过context传递，覆盖服务级配置）
+type RequestOptions struct {
+	Language string // 识别语言提示
+}
+
+// requestOptionsKey context键
+type requestOptionsKey struct{}
+
+// WithRequestOptions 将请求选项附加到context
+func WithRequestOptions(ctx context.Context, opts RequestOptions) context.Context {
+	return context.WithValue(ctx, requestOptionsKey{}, opts)
+}
+
+// RequestOptionsFromContext 从context获取请求选项
+func RequestOptionsFromContext(ctx context.Context) RequestOptions {
+	opts, _ := ctx.Value(requestOptionsKey{}).(RequestOptions)
+	return opts
+}
+
 // ASRFactory ASR工厂函数类型
 type ASRFactory func(config ASRConfig) (ASRService, error)
 
@@ -139,16 +187,17 @@ func RegisterASR(name string, factory ASRFactory) {
 func CreateASR(config ASRConfig) (ASRService, error) {
 	factory, exists := asrFactories[config.Type]
 	if !exists {
-		return nil, ErrUnsupportedASRType
+		return nil, fmt.Errorf("%w: %s（当前二进制已编译: %v）", ErrUnsupportedASRType, config.Type, GetAvailableASRTypes())
 	}
 	return factory(config)
 }
 
-// GetAvailableASRTypes 获取可用的ASR类型
+// GetAvailableASRTypes 获取可用（已编译进当前二进制）的ASR类型
 func GetAvailableASRTypes() []string {
 	types := make([]string, 0, len(asrFactories))
 	for t := range asrFactories {
 		types = append(types, t)
 	}
+	sort.Strings(types)
 	return types
 }
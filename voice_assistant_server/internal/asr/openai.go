@@ -1,3 +1,5 @@
+//go:build !no_asr_openai
+
 package asr
 
 import (
@@ -9,8 +11,13 @@ import (
 	"log"
 	"mime/multipart"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
+
+	"voice_assistant/voice_assistant_server/internal/errs"
+
+	vaaudio "voice_assistant/pkg/audio"
 )
 
 // OpenAIASR OpenAI Whisper API实现
@@ -25,9 +32,17 @@ type OpenAIASR struct {
 	supportedLangs []string
 }
 
-// OpenAIResponse OpenAI API响应
+// OpenAIResponse OpenAI API响应（verbose_json格式）
 type OpenAIResponse struct {
-	Text string `json:"text"`
+	Text  string       `json:"text"`
+	Words []OpenAIWord `json:"words"`
+}
+
+// OpenAIWord OpenAI词级别时间戳（秒）
+type OpenAIWord struct {
+	Word  string  `json:"word"`
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
 }
 
 // NewOpenAIASR 创建OpenAI ASR实例
@@ -101,17 +116,28 @@ func (o *OpenAIASR) ProcessAudio(ctx context.Context, audioData []byte) (ASRResu
 	startTime := time.Now()
 
 	// 调用OpenAI API
-	text, err := o.callOpenAIAPI(ctx, audioData)
+	response, err := o.callOpenAIAPI(ctx, audioData)
 	if err != nil {
 		return ASRResult{}, fmt.Errorf("OpenAI API调用失败: %w", err)
 	}
 
 	processTime := time.Since(startTime)
 
+	words := make([]Word, 0, len(response.Words))
+	for _, w := range response.Words {
+		words = append(words, Word{
+			Text:       w.Word,
+			StartTime:  int64(w.Start * 1000),
+			EndTime:    int64(w.End * 1000),
+			Confidence: 0.9,
+		})
+	}
+
 	result := ASRResult{
-		Text:        text,
+		Text:        response.Text,
+		Words:       words,
 		Confidence:  0.9, // OpenAI API通常有较高的准确率
-		Language:    o.config.Language,
+		Language:    o.resolveLanguage(ctx),
 		IsFinal:     true,
 		StartTime:   startTime.UnixMilli(),
 		EndTime:     time.Now().UnixMilli(),
@@ -122,9 +148,126 @@ func (o *OpenAIASR) ProcessAudio(ctx context.Context, audioData []byte) (ASRResu
 	return result, nil
 }
 
-// ProcessAudioStream 处理音频流
+// 伪流式识别：每次读取的音频块大小，以及输出中间结果所需的最少音频时长（过短的音频转写接口会拒绝）
+const (
+	openAIStreamChunkSize      = 4096
+	openAIMinPartialDurationMs = 500
+)
+
+// ProcessAudioStream 伪流式识别：持续读入音频流，每隔partial_interval重新识别已收到的全部音频，文本有变化时输出中间结果，
+// 流结束后识别整段音频输出最终结果。同一时间只有一个识别请求，识别耗时超过间隔时顺延到下一次；中间结果识别失败不影响最终结果。
 func (o *OpenAIASR) ProcessAudioStream(ctx context.Context, audioStream io.Reader) (<-chan ASRResult, error) {
-	return nil, fmt.Errorf("OpenAI ASR不支持流式处理")
+	o.mu.RLock()
+	initialized, interval := o.isInitialized, o.config.OpenAIConfig.PartialInterval
+	minPartial := o.config.SampleRate * o.config.Channels * 2 * openAIMinPartialDurationMs / 1000
+	o.mu.RUnlock()
+
+	if !initialized {
+		return nil, ErrASRNotInitialized
+	}
+	if interval <= 0 {
+		return nil, fmt.Errorf("OpenAI ASR未设置partial_interval: %w", ErrStreamingUnsupported)
+	}
+
+	chunks, readErr := readAudioChunks(ctx, audioStream, openAIStreamChunkSize)
+	results := make(chan ASRResult, 1)
+
+	go func() {
+		defer close(results)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var audio []byte
+		transcribed, lastText := 0, ""
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case chunk, ok := <-chunks:
+				if ok {
+					audio = append(audio, chunk...)
+					continue
+				}
+				if err := <-readErr; err != nil {
+					sendASRResult(ctx, results, ASRResult{Error: fmt.Errorf("读取音频流失败: %w", err)})
+					return
+				}
+				result, err := o.ProcessAudio(ctx, audio)
+				if err != nil {
+					result.Error = err
+				}
+				sendASRResult(ctx, results, result)
+				return
+
+			case <-ticker.C:
+				if len(audio) == transcribed || len(audio) < minPartial {
+					continue
+				}
+				transcribed = len(audio)
+				result, err := o.ProcessAudio(ctx, audio)
+				if err != nil {
+					if ctx.Err() == nil {
+						log.Printf("OpenAIASR: 中间结果识别失败: %v", err)
+					}
+					continue
+				}
+				if result.Text == lastText {
+					continue
+				}
+				lastText = result.Text
+				result.IsFinal = false
+				if !sendASRResult(ctx, results, result) {
+					return
+				}
+			}
+		}
+	}()
+
+	return results, nil
+}
+
+// readAudioChunks 在后台按块读取音频流，读完后关闭块通道并给出读取错误（正常结束时为nil）
+func readAudioChunks(ctx context.Context, r io.Reader, size int) (<-chan []byte, <-chan error) {
+	chunks := make(chan []byte, 16)
+	readErr := make(chan error, 1)
+
+	go func() {
+		defer close(chunks)
+		for {
+			buf := make([]byte, size)
+			n, err := r.Read(buf)
+			if n > 0 {
+				select {
+				case chunks <- buf[:n]:
+				case <-ctx.Done():
+					readErr <- ctx.Err()
+					return
+				}
+			}
+			if err == io.EOF {
+				readErr <- nil
+				return
+			}
+			if err != nil {
+				readErr <- err
+				return
+			}
+		}
+	}()
+
+	return chunks, readErr
+}
+
+// sendASRResult 输出识别结果，调用方取消时返回false
+func sendASRResult(ctx context.Context, results chan<- ASRResult, result ASRResult) bool {
+	select {
+	case results <- result:
+		return true
+	case <-ctx.Done():
+		return false
+	}
 }
 
 // ProcessAudioBytes 处理音频字节流
@@ -174,8 +317,44 @@ func (o *OpenAIASR) Close() error {
 	return nil
 }
 
+// CheckHealth 请求模型列表接口，检查服务是否可达、API密钥是否有效
+func (o *OpenAIASR) CheckHealth(ctx context.Context) error {
+	o.mu.RLock()
+	apiURL, apiKey := o.apiURL, o.apiKey
+	o.mu.RUnlock()
+
+	modelsURL := strings.TrimSuffix(apiURL, "/audio/transcriptions") + "/models"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, modelsURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("OpenAI服务不可达: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("OpenAI API密钥无效: 状态码 %d", resp.StatusCode)
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return fmt.Errorf("OpenAI服务异常: 状态码 %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// resolveLanguage 获取本次请求的识别语言（请求级语言提示优先）
+func (o *OpenAIASR) resolveLanguage(ctx context.Context) string {
+	if opts := RequestOptionsFromContext(ctx); opts.Language != "" {
+		return opts.Language
+	}
+	return o.config.Language
+}
+
 // callOpenAIAPI 调用OpenAI API
-func (o *OpenAIASR) callOpenAIAPI(ctx context.Context, audioData []byte) (string, error) {
+func (o *OpenAIASR) callOpenAIAPI(ctx context.Context, audioData []byte) (OpenAIResponse, error) {
 	// 创建multipart form
 	var body bytes.Buffer
 	writer := multipart.NewWriter(&body)
@@ -183,34 +362,37 @@ func (o *OpenAIASR) callOpenAIAPI(ctx context.Context, audioData []byte) (string
 	// 添加音频文件
 	audioWriter, err := writer.CreateFormFile("file", "audio.wav")
 	if err != nil {
-		return "", err
+		return OpenAIResponse{}, err
 	}
 
 	// 转换音频数据为WAV格式
-	wavData, err := o.convertToWAV(audioData)
-	if err != nil {
-		return "", err
-	}
-
+	wavData := vaaudio.EncodeWAV(audioData, o.config.SampleRate, o.config.Channels)
 	if _, err := audioWriter.Write(wavData); err != nil {
-		return "", err
+		return OpenAIResponse{}, err
 	}
 
 	// 添加模型参数
-	if err := writer.WriteField("model", "whisper-1"); err != nil {
-		return "", err
+	model := o.config.Model
+	if model == "" {
+		model = "whisper-1"
+	}
+	if err := writer.WriteField("model", model); err != nil {
+		return OpenAIResponse{}, err
 	}
 
 	// 添加语言参数
-	if o.config.Language != "" {
-		if err := writer.WriteField("language", o.config.Language); err != nil {
-			return "", err
+	if language := o.resolveLanguage(ctx); language != "" {
+		if err := writer.WriteField("language", language); err != nil {
+			return OpenAIResponse{}, err
 		}
 	}
 
-	// 添加响应格式
-	if err := writer.WriteField("response_format", "json"); err != nil {
-		return "", err
+	// 添加响应格式（verbose_json才会返回词级别时间戳）
+	if err := writer.WriteField("response_format", "verbose_json"); err != nil {
+		return OpenAIResponse{}, err
+	}
+	if err := writer.WriteField("timestamp_granularities[]", "word"); err != nil {
+		return OpenAIResponse{}, err
 	}
 
 	writer.Close()
@@ -218,7 +400,7 @@ func (o *OpenAIASR) callOpenAIAPI(ctx context.Context, audioData []byte) (string
 	// 创建请求
 	req, err := http.NewRequestWithContext(ctx, "POST", o.apiURL, &body)
 	if err != nil {
-		return "", err
+		return OpenAIResponse{}, err
 	}
 
 	// 设置请求头
@@ -228,104 +410,23 @@ func (o *OpenAIASR) callOpenAIAPI(ctx context.Context, audioData []byte) (string
 	// 发送请求
 	resp, err := o.client.Do(req)
 	if err != nil {
-		return "", err
+		return OpenAIResponse{}, fmt.Errorf("%w: %w", ErrConnectionFailed, err)
 	}
 	defer resp.Body.Close()
 
 	// 检查响应状态
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("API请求失败: %d, %s", resp.StatusCode, string(bodyBytes))
+		return OpenAIResponse{}, fmt.Errorf("API请求失败: %w", errs.FromHTTPStatus(resp.StatusCode, string(bodyBytes)))
 	}
 
 	// 解析响应
 	var response OpenAIResponse
 	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return "", err
-	}
-
-	return response.Text, nil
-}
-
-// convertToWAV 将音频数据转换为WAV格式
-func (o *OpenAIASR) convertToWAV(audioData []byte) ([]byte, error) {
-	// 简单的WAV头生成
-	sampleRate := o.config.SampleRate
-	if sampleRate == 0 {
-		sampleRate = 16000
-	}
-
-	channels := o.config.Channels
-	if channels == 0 {
-		channels = 1
+		return OpenAIResponse{}, err
 	}
 
-	// WAV文件头
-	header := make([]byte, 44)
-	copy(header[0:4], "RIFF")
-
-	dataSize := len(audioData)
-	fileSize := dataSize + 36
-
-	// 文件大小
-	header[4] = byte(fileSize)
-	header[5] = byte(fileSize >> 8)
-	header[6] = byte(fileSize >> 16)
-	header[7] = byte(fileSize >> 24)
-
-	copy(header[8:12], "WAVE")
-	copy(header[12:16], "fmt ")
-
-	// fmt chunk size (16)
-	header[16] = 16
-	header[17] = 0
-	header[18] = 0
-	header[19] = 0
-
-	// PCM format (1)
-	header[20] = 1
-	header[21] = 0
-
-	// Channels
-	header[22] = byte(channels)
-	header[23] = byte(channels >> 8)
-
-	// Sample rate
-	header[24] = byte(sampleRate)
-	header[25] = byte(sampleRate >> 8)
-	header[26] = byte(sampleRate >> 16)
-	header[27] = byte(sampleRate >> 24)
-
-	// Byte rate
-	byteRate := sampleRate * channels * 2
-	header[28] = byte(byteRate)
-	header[29] = byte(byteRate >> 8)
-	header[30] = byte(byteRate >> 16)
-	header[31] = byte(byteRate >> 24)
-
-	// Block align
-	blockAlign := channels * 2
-	header[32] = byte(blockAlign)
-	header[33] = byte(blockAlign >> 8)
-
-	// Bits per sample (16)
-	header[34] = 16
-	header[35] = 0
-
-	copy(header[36:40], "data")
-
-	// Data size
-	header[40] = byte(dataSize)
-	header[41] = byte(dataSize >> 8)
-	header[42] = byte(dataSize >> 16)
-	header[43] = byte(dataSize >> 24)
-
-	// 组合头部和数据
-	wavData := make([]byte, len(header)+len(audioData))
-	copy(wavData, header)
-	copy(wavData[len(header):], audioData)
-
-	return wavData, nil
+	return response, nil
 }
 
 // 注册OpenAI ASR
@@ -0,0 +1,71 @@
+//go:build !no_asr_openai
+
+package asr
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAIStreamPartialResults(t *testing.T) {
+	// 转写接口按收到的音频时长返回文本
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, _, err := r.FormFile("file")
+		require.NoError(t, err)
+		data, _ := io.ReadAll(file)
+		fmt.Fprintf(w, `{"text": "%dms"}`, (len(data)-44)/32)
+	}))
+	defer api.Close()
+
+	config := ASRConfig{SampleRate: 16000, Channels: 1, APIKey: "test", APIUrl: api.URL,
+		OpenAIConfig: OpenAIConfig{PartialInterval: 20 * time.Millisecond}}
+	service, err := NewOpenAIASR(config)
+	require.NoError(t, err)
+	require.NoError(t, service.Initialize(config))
+
+	reader, writer := io.Pipe()
+	results, err := service.ProcessAudioStream(context.Background(), reader)
+	require.NoError(t, err)
+
+	go func() {
+		for i := 0; i < 3; i++ {
+			writer.Write(make([]byte, 16000))
+			time.Sleep(60 * time.Millisecond)
+		}
+		writer.Close()
+	}()
+
+	var partials []string
+	var final ASRResult
+	for result := range results {
+		require.NoError(t, result.Error)
+		if result.IsFinal {
+			final = result
+			continue
+		}
+		partials = append(partials, result.Text)
+	}
+
+	// 中间结果随音频增长而更新，最终结果识别整段音频
+	require.NotEmpty(t, partials)
+	assert.Equal(t, "500ms", partials[0])
+	assert.Equal(t, "1500ms", final.Text)
+}
+
+func TestOpenAIStreamRequiresInterval(t *testing.T) {
+	config := ASRConfig{SampleRate: 16000, Channels: 1, APIKey: "test"}
+	service, err := NewOpenAIASR(config)
+	require.NoError(t, err)
+	require.NoError(t, service.Initialize(config))
+
+	_, err = service.ProcessAudioStream(context.Background(), nil)
+	assert.ErrorIs(t, err, ErrStreamingUnsupported)
+}
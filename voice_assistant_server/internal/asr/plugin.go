@@ -0,0 +1,216 @@
+package asr
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+)
+
+// PluginCaller 外部插件进程的调用接口（由 plugins 包实现）
+type PluginCaller interface {
+	Call(ctx context.Context, method string, params, result interface{}) error
+}
+
+// PluginASR 由外部插件进程提供的ASR服务（如单独发布的 sherpa、whisper.cpp 识别程序）
+type PluginASR struct {
+	caller         PluginCaller
+	provider       string
+	config         ASRConfig
+	isInitialized  bool
+	mu             sync.RWMutex
+	modelInfo      ModelInfo
+	supportedLangs []string
+}
+
+// pluginASRSettings 初始化时传给插件的配置
+type pluginASRSettings struct {
+	Provider   string `json:"provider"`
+	ModelPath  string `json:"model_path,omitempty"`
+	Language   string `json:"language,omitempty"`
+	SampleRate int    `json:"sample_rate"`
+	Channels   int    `json:"channels"`
+	APIKey     string `json:"api_key,omitempty"`
+	APIUrl     string `json:"api_url,omitempty"`
+}
+
+// pluginASRInfo 插件返回的模型信息
+type pluginASRInfo struct {
+	Model     string   `json:"model"`
+	Version   string   `json:"version"`
+	Languages []string `json:"languages"`
+}
+
+// pluginRecognizeRequest 识别请求
+type pluginRecognizeRequest struct {
+	Provider   string `json:"provider"`
+	Audio      []byte `json:"audio"` // 16位PCM（base64）
+	SampleRate int    `json:"sample_rate"`
+	Channels   int    `json:"channels"`
+	Language   string `json:"language,omitempty"`
+}
+
+// pluginRecognizeResult 识别结果
+type pluginRecognizeResult struct {
+	Text       string  `json:"text"`
+	Confidence float64 `json:"confidence"`
+	Language   string  `json:"language"`
+	Words      []Word  `json:"words"`
+}
+
+// pluginHealthRequest 健康检查请求
+type pluginHealthRequest struct {
+	Provider string `json:"provider"`
+}
+
+// NewPluginASR 创建插件ASR实例
+func NewPluginASR(caller PluginCaller, provider string, config ASRConfig) *PluginASR {
+	return &PluginASR{caller: caller, provider: provider, config: config}
+}
+
+// Initialize 初始化插件ASR（插件加载模型）
+func (p *PluginASR) Initialize(config ASRConfig) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	log.Printf("PluginASR: 初始化中 (%s)...", p.provider)
+
+	var info pluginASRInfo
+	err := p.caller.Call(context.Background(), "asr.initialize", pluginASRSettings{
+		Provider:   p.provider,
+		ModelPath:  config.ModelPath,
+		Language:   config.Language,
+		SampleRate: config.SampleRate,
+		Channels:   config.Channels,
+		APIKey:     config.APIKey,
+		APIUrl:     config.APIUrl,
+	}, &info)
+	if err != nil {
+		return fmt.Errorf("插件ASR初始化失败: %w", err)
+	}
+
+	p.supportedLangs = info.Languages
+	if len(p.supportedLangs) == 0 && config.Language != "" {
+		p.supportedLangs = []string{config.Language}
+	}
+	name := info.Model
+	if name == "" {
+		name = p.provider
+	}
+	p.modelInfo = ModelInfo{
+		Name:       name,
+		Version:    info.Version,
+		Type:       "speech-to-text",
+		Languages:  p.supportedLangs,
+		SampleRate: config.SampleRate,
+		Channels:   config.Channels,
+		LoadTime:   time.Now().UnixMilli(),
+	}
+
+	p.config = config
+	p.isInitialized = true
+
+	log.Printf("PluginASR: 初始化成功 (%s)", name)
+	return nil
+}
+
+// ProcessAudio 处理音频数据
+func (p *PluginASR) ProcessAudio(ctx context.Context, audioData []byte) (ASRResult, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if !p.isInitialized {
+		return ASRResult{}, ErrASRNotInitialized
+	}
+
+	startTime := time.Now()
+	language := p.config.Language
+	if opts := RequestOptionsFromContext(ctx); opts.Language != "" {
+		language = opts.Language
+	}
+
+	var response pluginRecognizeResult
+	err := p.caller.Call(ctx, "asr.recognize", pluginRecognizeRequest{
+		Provider:   p.provider,
+		Audio:      audioData,
+		SampleRate: p.config.SampleRate,
+		Channels:   p.config.Channels,
+		Language:   language,
+	}, &response)
+	if err != nil {
+		return ASRResult{}, fmt.Errorf("%w: %v", ErrProcessingFailed, err)
+	}
+	if response.Language == "" {
+		response.Language = language
+	}
+
+	return ASRResult{
+		Text:        response.Text,
+		Confidence:  response.Confidence,
+		Language:    response.Language,
+		IsFinal:     true,
+		Words:       response.Words,
+		StartTime:   startTime.UnixMilli(),
+		EndTime:     time.Now().UnixMilli(),
+		ProcessTime: time.Since(startTime).Milliseconds(),
+		ModelInfo:   p.modelInfo.Name,
+	}, nil
+}
+
+// ProcessAudioStream 处理音频流（插件按整段音频识别）
+func (p *PluginASR) ProcessAudioStream(ctx context.Context, audioStream io.Reader) (<-chan ASRResult, error) {
+	return nil, fmt.Errorf("插件ASR不支持流式处理")
+}
+
+// ProcessAudioBytes 处理音频字节流（中间结果不调用插件）
+func (p *PluginASR) ProcessAudioBytes(ctx context.Context, audioBytes []byte, isFinal bool) (ASRResult, error) {
+	if !isFinal {
+		return ASRResult{IsFinal: false}, nil
+	}
+	return p.ProcessAudio(ctx, audioBytes)
+}
+
+// GetSupportedLanguages 获取支持的语言列表
+func (p *PluginASR) GetSupportedLanguages() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.supportedLangs
+}
+
+// SetLanguage 设置识别语言
+func (p *PluginASR) SetLanguage(language string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, lang := range p.supportedLangs {
+		if lang == language {
+			p.config.Language = language
+			return nil
+		}
+	}
+	return ErrLanguageNotSupported
+}
+
+// GetModelInfo 获取模型信息
+func (p *PluginASR) GetModelInfo() ModelInfo {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.modelInfo
+}
+
+// Close 关闭插件ASR（插件进程由插件管理器停止）
+func (p *PluginASR) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.isInitialized = false
+	log.Printf("PluginASR: 已关闭 (%s)", p.provider)
+	return nil
+}
+
+// CheckHealth 检查插件进程是否可用
+func (p *PluginASR) CheckHealth(ctx context.Context) error {
+	return p.caller.Call(ctx, "health", pluginHealthRequest{Provider: p.provider}, nil)
+}
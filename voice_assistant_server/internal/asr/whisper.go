@@ -1,3 +1,5 @@
+//go:build !no_asr_whisper
+
 package asr
 
 import (
@@ -11,6 +13,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	vaaudio "voice_assistant/pkg/audio"
 )
 
 // WhisperASR Whisper ASR实现
@@ -64,7 +68,7 @@ func (w *WhisperASR) Initialize(config ASRConfig) error {
 
 	// 检查模型文件是否存在
 	if _, err := os.Stat(modelPath); os.IsNotExist(err) {
-		return fmt.Errorf("未找到whisper模型文件: %s", modelPath)
+		return fmt.Errorf("未找到whisper模型文件: %s（可执行 server models download 下载，或设置 models.auto_download）", modelPath)
 	}
 
 	w.modelPath = modelPath
@@ -117,34 +121,43 @@ func (w *WhisperASR) ProcessAudio(ctx context.Context, audioData []byte) (ASRRes
 
 	startTime := time.Now()
 
-	// 将音频数据转换为float32
-	audioFloat, err := w.bytesToFloat32(audioData)
-	if err != nil {
-		return ASRResult{}, fmt.Errorf("音频数据转换失败: %w", err)
+	if len(audioData)%2 != 0 {
+		return ASRResult{}, fmt.Errorf("音频数据转换失败: 音频数据长度必须是偶数")
 	}
 
 	// 创建临时WAV文件
-	wavFile, err := w.createTempWavFile(audioFloat)
+	wavFile, err := w.createTempWavFile(audioData)
 	if err != nil {
 		return ASRResult{}, fmt.Errorf("创建临时文件失败: %w", err)
 	}
 	defer os.Remove(wavFile)
 
 	// 运行Whisper识别
-	text, err := w.runWhisperCommand(ctx, wavFile)
+	transcript, err := w.runWhisperCommand(ctx, wavFile)
 	if err != nil {
 		return ASRResult{}, fmt.Errorf("Whisper识别失败: %w", err)
 	}
 
 	processTime := time.Since(startTime)
 
+	// 置信度取各词概率的平均值（没有词时使用默认值）
+	confidence := transcript.Confidence
+	if confidence == 0 {
+		confidence = 0.8
+	}
+	language := w.resolveLanguage(ctx)
+	if language == "auto" && transcript.Language != "" {
+		language = transcript.Language
+	}
+
 	result := ASRResult{
-		Text:        strings.TrimSpace(text),
-		Confidence:  0.8, // Whisper不提供置信度，使用默认值
-		Language:    w.language,
+		Text:        transcript.Text,
+		Confidence:  confidence,
+		Language:    language,
 		IsFinal:     true,
-		StartTime:   startTime.UnixMilli(),
-		EndTime:     time.Now().UnixMilli(),
+		StartTime:   transcript.Start,
+		EndTime:     transcript.End,
+		Words:       transcript.Words,
 		ProcessTime: processTime.Milliseconds(),
 		ModelInfo:   "Whisper",
 	}
@@ -208,6 +221,26 @@ func (w *WhisperASR) Close() error {
 	return nil
 }
 
+// resolveLanguage 获取本次请求的识别语言（请求级语言提示优先）
+func (w *WhisperASR) resolveLanguage(ctx context.Context) string {
+	if opts := RequestOptionsFromContext(ctx); opts.Language != "" {
+		return opts.Language
+	}
+	return w.language
+}
+
+// CheckHealth 检查模型文件是否仍然存在
+func (w *WhisperASR) CheckHealth(ctx context.Context) error {
+	w.mu.RLock()
+	modelPath := w.modelPath
+	w.mu.RUnlock()
+
+	if _, err := os.Stat(modelPath); err != nil {
+		return fmt.Errorf("whisper模型文件不可用: %w", err)
+	}
+	return nil
+}
+
 // checkWhisperInstallation 检查whisper-cpp是否安装
 func (w *WhisperASR) checkWhisperInstallation() error {
 	cmd := exec.Command("whisper-cli", "--help")
@@ -217,117 +250,28 @@ func (w *WhisperASR) checkWhisperInstallation() error {
 	return nil
 }
 
-// bytesToFloat32 将字节数组转换为float32数组
-func (w *WhisperASR) bytesToFloat32(data []byte) ([]float32, error) {
-	if len(data)%2 != 0 {
-		return nil, fmt.Errorf("音频数据长度必须是偶数")
-	}
-
-	samples := make([]float32, len(data)/2)
-	for i := 0; i < len(samples); i++ {
-		// 16位PCM转float32
-		sample := int16(data[i*2]) | int16(data[i*2+1])<<8
-		samples[i] = float32(sample) / 32768.0
-	}
-	return samples, nil
-}
-
-// createTempWavFile 创建临时WAV文件
-func (w *WhisperASR) createTempWavFile(audioData []float32) (string, error) {
+// createTempWavFile 把16位PCM写入临时WAV文件
+func (w *WhisperASR) createTempWavFile(audioData []byte) (string, error) {
 	wavFile := filepath.Join(w.tempDir, fmt.Sprintf("audio_%d.wav", time.Now().UnixNano()))
-
-	file, err := os.Create(wavFile)
-	if err != nil {
-		return "", err
-	}
-	defer file.Close()
-
-	// 写入WAV头
-	sampleRate := w.config.SampleRate
-	if sampleRate == 0 {
-		sampleRate = 16000
-	}
-
-	channels := w.config.Channels
-	if channels == 0 {
-		channels = 1
-	}
-
-	if err := w.writeWAVHeader(file, len(audioData), sampleRate, channels); err != nil {
+	if err := os.WriteFile(wavFile, vaaudio.EncodeWAV(audioData, w.config.SampleRate, w.config.Channels), 0644); err != nil {
 		return "", err
 	}
-
-	// 写入音频数据
-	for _, sample := range audioData {
-		// 转换为16位PCM
-		pcmSample := int16(sample * 32767)
-		if err := w.writeInt16(file, pcmSample); err != nil {
-			return "", err
-		}
-	}
-
 	return wavFile, nil
 }
 
-// writeWAVHeader 写入WAV文件头
-func (w *WhisperASR) writeWAVHeader(file *os.File, numSamples, sampleRate, channels int) error {
-	bitsPerSample := 16
-	byteRate := sampleRate * channels * bitsPerSample / 8
-	blockAlign := channels * bitsPerSample / 8
-	dataSize := numSamples * bitsPerSample / 8
-
-	// RIFF头
-	file.WriteString("RIFF")
-	w.writeInt32(file, int32(36+dataSize))
-	file.WriteString("WAVE")
-
-	// fmt子块
-	file.WriteString("fmt ")
-	w.writeInt32(file, 16) // PCM格式大小
-	w.writeInt16(file, 1)  // PCM格式
-	w.writeInt16(file, int16(channels))
-	w.writeInt32(file, int32(sampleRate))
-	w.writeInt32(file, int32(byteRate))
-	w.writeInt16(file, int16(blockAlign))
-	w.writeInt16(file, int16(bitsPerSample))
-
-	// data子块
-	file.WriteString("data")
-	w.writeInt32(file, int32(dataSize))
-
-	return nil
-}
-
-// writeInt16 写入16位整数
-func (w *WhisperASR) writeInt16(file *os.File, value int16) error {
-	return w.writeBytes(file, []byte{byte(value), byte(value >> 8)})
-}
-
-// writeInt32 写入32位整数
-func (w *WhisperASR) writeInt32(file *os.File, value int32) error {
-	return w.writeBytes(file, []byte{
-		byte(value), byte(value >> 8), byte(value >> 16), byte(value >> 24),
-	})
-}
-
-// writeBytes 写入字节
-func (w *WhisperASR) writeBytes(file *os.File, data []byte) error {
-	_, err := file.Write(data)
-	return err
-}
-
-// runWhisperCommand 运行Whisper命令
-func (w *WhisperASR) runWhisperCommand(ctx context.Context, wavFile string) (string, error) {
+// runWhisperCommand 运行Whisper命令，读取带分段和Token时间戳的JSON结果
+func (w *WhisperASR) runWhisperCommand(ctx context.Context, wavFile string) (whisperTranscript, error) {
 	// 创建带超时的上下文
 	ctx, cancel := context.WithTimeout(ctx, w.processTimeout)
 	defer cancel()
 
+	outputBase := strings.TrimSuffix(wavFile, ".wav")
 	args := []string{
 		"-m", w.modelPath,
 		"-f", wavFile,
-		"-l", w.language,
-		"--output-txt",
-		"--no-timestamps",
+		"-l", w.resolveLanguage(ctx),
+		"--output-json-full",
+		"--output-file", outputBase,
 	}
 
 	// 应用Whisper特定配置
@@ -344,20 +288,24 @@ func (w *WhisperASR) runWhisperCommand(ctx context.Context, wavFile string) (str
 
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		return "", fmt.Errorf("whisper命令执行失败: %v, 输出: %s", err, string(output))
+		return whisperTranscript{}, fmt.Errorf("whisper命令执行失败: %v, 输出: %s", err, string(output))
 	}
 
 	// 读取输出文件
-	outputFile := strings.TrimSuffix(wavFile, ".wav") + ".txt"
-	textBytes, err := os.ReadFile(outputFile)
+	outputFile := outputBase + ".json"
+	data, err := os.ReadFile(outputFile)
 	if err != nil {
-		return "", fmt.Errorf("读取输出文件失败: %v", err)
+		return whisperTranscript{}, fmt.Errorf("读取输出文件失败: %v", err)
 	}
 
 	// 清理输出文件
 	os.Remove(outputFile)
 
-	return string(textBytes), nil
+	transcript, err := parseWhisperJSON(data)
+	if err != nil {
+		return whisperTranscript{}, fmt.Errorf("解析输出文件失败: %w", err)
+	}
+	return transcript, nil
 }
 
 // 注册Whisper ASR
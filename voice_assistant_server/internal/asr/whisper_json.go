@@ -0,0 +1,186 @@
+//go:build !no_asr_whisper
+
+package asr
+
+import (
+	"encoding/json"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// whisperOutput whisper-cli --output-json-full 的输出
+type whisperOutput struct {
+	Result struct {
+		Language string `json:"language"`
+	} `json:"result"`
+	Transcription []whisperSegment `json:"transcription"`
+}
+
+// whisperSegment 识别分段
+type whisperSegment struct {
+	Offsets whisperOffsets `json:"offsets"`
+	Text    string         `json:"text"`
+	Tokens  []whisperToken `json:"tokens"`
+}
+
+// whisperToken 分段中的Token（中文等多字节字符可能被拆在相邻的Token中）
+type whisperToken struct {
+	Text    string         `json:"text"`
+	Offsets whisperOffsets `json:"offsets"`
+	P       float64        `json:"p"` // 概率
+}
+
+// whisperOffsets 相对音频起点的时间（毫秒）
+type whisperOffsets struct {
+	From int64 `json:"from"`
+	To   int64 `json:"to"`
+}
+
+// whisperTranscript 解析后的识别结果
+type whisperTranscript struct {
+	Text       string
+	Language   string // whisper识别出的语言（-l auto 时有意义）
+	Words      []Word
+	Start      int64   // 第一个词的开始时间（毫秒）
+	End        int64   // 最后一个词的结束时间（毫秒）
+	Confidence float64 // 各词概率的平均值（没有词时为0）
+}
+
+// wordBuilder 合并中的词
+type wordBuilder struct {
+	text  []byte
+	start int64
+	end   int64
+	pSum  float64
+	count int
+}
+
+// parseWhisperJSON 解析分段和Token时间戳，把Token合并为词
+func parseWhisperJSON(data []byte) (whisperTranscript, error) {
+	var output whisperOutput
+	if err := json.Unmarshal(data, &output); err != nil {
+		return whisperTranscript{}, err
+	}
+
+	transcript := whisperTranscript{Language: output.Result.Language}
+	var text strings.Builder
+	var current *wordBuilder
+	flush := func() {
+		if current == nil {
+			return
+		}
+		if word := strings.TrimSpace(string(current.text)); word != "" {
+			transcript.Words = append(transcript.Words, Word{
+				Text:       word,
+				StartTime:  current.start,
+				EndTime:    current.end,
+				Confidence: current.pSum / float64(current.count),
+			})
+		}
+		current = nil
+	}
+
+	for _, segment := range output.Transcription {
+		text.WriteString(segment.Text)
+		for _, piece := range segmentPieces(segment) {
+			if current != nil && !startsNewWord(current.text, piece.text) {
+				current.text = append(current.text, piece.text...)
+				current.end = piece.offsets.To
+				current.pSum += piece.p
+				current.count++
+				continue
+			}
+			flush()
+			current = &wordBuilder{text: piece.text, start: piece.offsets.From, end: piece.offsets.To, pSum: piece.p, count: 1}
+		}
+		// 分段之间总是断开
+		flush()
+	}
+
+	transcript.Text = strings.TrimSpace(text.String())
+	if n := len(transcript.Words); n > 0 {
+		transcript.Start = transcript.Words[0].StartTime
+		transcript.End = transcript.Words[n-1].EndTime
+		var sum float64
+		for _, word := range transcript.Words {
+			sum += word.Confidence
+		}
+		transcript.Confidence = sum / float64(n)
+	} else if n := len(output.Transcription); n > 0 {
+		transcript.Start = output.Transcription[0].Offsets.From
+		transcript.End = output.Transcription[n-1].Offsets.To
+	}
+	return transcript, nil
+}
+
+// tokenPiece 还原出原始字节的Token
+type tokenPiece struct {
+	text    []byte
+	offsets whisperOffsets
+	p       float64
+}
+
+// segmentPieces 分段中的文本Token（跳过 [_BEG_]、[_TT_150] 等特殊Token）
+// 被拆开的多字节字符在JSON解码时变成了U+FFFD，按字节数从分段文本中取回原始字节；分段文本与Token对不上时使用解码后的Token文本。
+func segmentPieces(segment whisperSegment) []tokenPiece {
+	pieces := make([]tokenPiece, 0, len(segment.Tokens))
+	total := 0
+	for _, token := range segment.Tokens {
+		if strings.HasPrefix(token.Text, "[_") && strings.HasSuffix(token.Text, "]") {
+			continue
+		}
+		pieces = append(pieces, tokenPiece{text: []byte(token.Text), offsets: token.Offsets, p: token.P})
+		total += rawTokenLength(token.Text)
+	}
+
+	raw := []byte(segment.Text)
+	if total != len(raw) || strings.ContainsRune(segment.Text, utf8.RuneError) {
+		return pieces
+	}
+	pos := 0
+	for i := range pieces {
+		n := rawTokenLength(string(pieces[i].text))
+		pieces[i].text = raw[pos : pos+n]
+		pos += n
+	}
+	return pieces
+}
+
+// rawTokenLength Token的原始字节数（每个U+FFFD对应一个无效字节）
+func rawTokenLength(text string) int {
+	n := 0
+	for _, r := range text {
+		if r == utf8.RuneError {
+			n++
+			continue
+		}
+		n += utf8.RuneLen(r)
+	}
+	return n
+}
+
+// startsNewWord Token是否开始一个新词
+// 多字节字符未拼完整、英文单词的后续部分和标点并入前一个词；以空白开头的Token和中文等字符各自成词。
+func startsNewWord(previous, token []byte) bool {
+	if len(token) == 0 || !utf8.Valid(previous) {
+		return false
+	}
+	r, _ := utf8.DecodeRune(token)
+	if r == utf8.RuneError {
+		return false
+	}
+	if unicode.IsSpace(r) {
+		return true
+	}
+	if unicode.IsPunct(r) || unicode.IsSymbol(r) {
+		return false
+	}
+	last, _ := utf8.DecodeLastRune(previous)
+	return !(isWordRune(last) && isWordRune(r))
+}
+
+// isWordRune 用空格分词的文字中的字母和数字，相邻时属于同一个词（汉字和假名逐字成词）
+func isWordRune(r rune) bool {
+	return (unicode.IsLetter(r) || unicode.IsDigit(r)) && !unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana)
+}
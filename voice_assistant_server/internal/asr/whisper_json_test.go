@@ -0,0 +1,75 @@
+//go:build !no_asr_whisper
+
+package asr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWhisperJSON(t *testing.T) {
+	// “你”被拆在两个Token中（原始输出中是不完整的UTF-8字节）
+	data := []byte(`{
+  "result": {"language": "zh"},
+  "transcription": [
+    {
+      "offsets": {"from": 0, "to": 900},
+      "text": " Hello, world",
+      "tokens": [
+        {"text": "[_BEG_]", "offsets": {"from": 0, "to": 0}, "p": 1.0},
+        {"text": " Hello", "offsets": {"from": 0, "to": 400}, "p": 0.9},
+        {"text": ",", "offsets": {"from": 400, "to": 450}, "p": 0.8},
+        {"text": " wor", "offsets": {"from": 500, "to": 700}, "p": 0.7},
+        {"text": "ld", "offsets": {"from": 700, "to": 900}, "p": 0.9},
+        {"text": "[_TT_45]", "offsets": {"from": 900, "to": 900}, "p": 1.0}
+      ]
+    },
+    {
+      "offsets": {"from": 1000, "to": 1450},
+      "text": "你好。",
+      "tokens": [
+        {"text": "` + "\xe4\xbd" + `", "offsets": {"from": 1000, "to": 1100}, "p": 0.6},
+        {"text": "` + "\xa0" + `", "offsets": {"from": 1100, "to": 1200}, "p": 0.8},
+        {"text": "好", "offsets": {"from": 1200, "to": 1400}, "p": 1.0},
+        {"text": "。", "offsets": {"from": 1400, "to": 1450}, "p": 1.0}
+      ]
+    }
+  ]
+}`)
+
+	transcript, err := parseWhisperJSON(data)
+	require.NoError(t, err)
+	assert.Equal(t, "Hello, world你好。", transcript.Text)
+	assert.Equal(t, "zh", transcript.Language)
+
+	require.Len(t, transcript.Words, 4)
+	assert.Equal(t, Word{Text: "Hello,", StartTime: 0, EndTime: 450, Confidence: 0.85}, roundWord(transcript.Words[0]))
+	assert.Equal(t, Word{Text: "world", StartTime: 500, EndTime: 900, Confidence: 0.8}, roundWord(transcript.Words[1]))
+	assert.Equal(t, Word{Text: "你", StartTime: 1000, EndTime: 1200, Confidence: 0.7}, roundWord(transcript.Words[2]))
+	assert.Equal(t, Word{Text: "好。", StartTime: 1200, EndTime: 1450, Confidence: 1.0}, roundWord(transcript.Words[3]))
+
+	assert.Equal(t, int64(0), transcript.Start)
+	assert.Equal(t, int64(1450), transcript.End)
+	assert.InDelta(t, 0.8375, transcript.Confidence, 1e-9)
+}
+
+func TestParseWhisperJSONWithoutTokens(t *testing.T) {
+	transcript, err := parseWhisperJSON([]byte(`{"transcription": [{"offsets": {"from": 200, "to": 1800}, "text": " 今天天气怎么样"}]}`))
+	require.NoError(t, err)
+	assert.Equal(t, "今天天气怎么样", transcript.Text)
+	assert.Empty(t, transcript.Words)
+	assert.Equal(t, int64(200), transcript.Start)
+	assert.Equal(t, int64(1800), transcript.End)
+	assert.Zero(t, transcript.Confidence)
+
+	_, err = parseWhisperJSON([]byte("not json"))
+	assert.Error(t, err)
+}
+
+// roundWord 置信度保留两位小数，便于比较
+func roundWord(w Word) Word {
+	w.Confidence = float64(int(w.Confidence*100+0.5)) / 100
+	return w
+}
@@ -0,0 +1,339 @@
+package compute
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	"voice_assistant/voice_assistant_server/internal/pipeline"
+)
+
+// ErrManagerClosed 资源管理器已关闭
+var ErrManagerClosed = errors.New("计算资源管理器已关闭")
+
+// 设备名称
+const (
+	DeviceGPU = "gpu"
+	DeviceCPU = "cpu"
+)
+
+// Config 本地模型计算资源配置
+type Config struct {
+	Enabled     bool                            `yaml:"enabled"`       // 是否启用
+	GPUSlots    int                             `yaml:"gpu_slots"`     // 同时使用GPU的任务数（默认1，即串行使用GPU）
+	GPUMemoryMB int                             `yaml:"gpu_memory_mb"` // 可用显存（MB），0表示不按显存限制
+	CPUThreads  int                             `yaml:"cpu_threads"`   // 本地模型可用的CPU线程数，0表示CPU核数
+	Workers     map[pipeline.Stage]WorkerConfig `yaml:"workers"`       // 各阶段本地模型的资源占用（未列出的阶段不受管理）
+}
+
+// WorkerConfig 一个阶段的本地模型每次推理占用的资源
+type WorkerConfig struct {
+	Device   string `yaml:"device"`    // gpu|cpu
+	MemoryMB int    `yaml:"memory_mb"` // 占用显存（MB，仅gpu）
+	Threads  int    `yaml:"threads"`   // 占用CPU线程数（仅cpu，默认1）
+}
+
+// DeviceStats 设备的占用和排队统计（/health返回）
+type DeviceStats struct {
+	Stages       []pipeline.Stage `json:"stages"`                   // 使用该设备的阶段
+	Slots        int              `json:"slots"`                    // GPU为同时运行的任务数，CPU为线程数
+	InUse        int              `json:"in_use"`                   // 正在占用的槽位
+	MemoryMB     int              `json:"memory_mb,omitempty"`      // 显存预算
+	MemoryUsedMB int              `json:"memory_used_mb,omitempty"` // 正在占用的显存
+	Running      int              `json:"running"`                  // 正在运行的任务数
+	Queued       int              `json:"queued"`                   // 等待资源的任务数
+	Completed    int64            `json:"completed"`
+	AvgWaitMs    float64          `json:"avg_wait_ms"`
+	Utilization  float64          `json:"utilization"` // 启动以来有任务运行的时间占比
+}
+
+// demand 一次推理占用的资源
+type demand struct {
+	slots    int
+	memoryMB int
+}
+
+// waiter 等待资源的任务
+type waiter struct {
+	stage    pipeline.Stage
+	priority pipeline.Priority
+	demand   demand
+	granted  chan struct{}
+	queuedAt time.Time
+}
+
+// device 一个计算设备的容量、占用和等待队列
+type device struct {
+	slots    int
+	memoryMB int
+	stages   []pipeline.Stage
+
+	usedSlots  int
+	usedMemory int
+	running    int
+	waiting    []*waiter
+	turn       int // 轮转到的阶段，同一优先级下各阶段轮流获得资源
+
+	completed   int64
+	waitNanos   int64
+	busyNanos   int64
+	activeSince time.Time
+}
+
+// Manager 本地ASR/LLM/TTS模型的计算资源管理
+// 每个阶段的推理在运行前按配置申请GPU槽位和显存或CPU线程，资源不足时排队：交互任务优先，
+// 同一优先级下各阶段轮流获得资源，同一阶段先到先得，避免各提供方同时启动进程抢占同一块GPU。
+type Manager struct {
+	mu      sync.Mutex
+	devices map[string]*device
+	stages  map[pipeline.Stage]WorkerConfig
+	started time.Time
+	closed  bool
+}
+
+// NewManager 创建计算资源管理器（未启用时返回nil，Acquire直接放行）
+func NewManager(config Config) (*Manager, error) {
+	if !config.Enabled {
+		return nil, nil
+	}
+
+	gpuSlots := config.GPUSlots
+	if gpuSlots <= 0 {
+		gpuSlots = 1
+	}
+	cpuThreads := config.CPUThreads
+	if cpuThreads <= 0 {
+		cpuThreads = runtime.NumCPU()
+	}
+
+	m := &Manager{
+		devices: map[string]*device{
+			DeviceGPU: {slots: gpuSlots, memoryMB: config.GPUMemoryMB},
+			DeviceCPU: {slots: cpuThreads},
+		},
+		stages:  make(map[pipeline.Stage]WorkerConfig),
+		started: time.Now(),
+	}
+	for _, stage := range []pipeline.Stage{pipeline.StageASR, pipeline.StageLLM, pipeline.StageTTS} {
+		worker, ok := config.Workers[stage]
+		if !ok || worker.Device == "" {
+			continue
+		}
+		d, ok := m.devices[worker.Device]
+		if !ok {
+			return nil, fmt.Errorf("%s 的计算设备不支持: %s", stage, worker.Device)
+		}
+		if worker.Device == DeviceGPU && d.memoryMB > 0 && worker.MemoryMB > d.memoryMB {
+			return nil, fmt.Errorf("%s 占用的显存 %dMB 超过可用显存 %dMB", stage, worker.MemoryMB, d.memoryMB)
+		}
+		if worker.Device == DeviceCPU && worker.Threads > d.slots {
+			return nil, fmt.Errorf("%s 占用的CPU线程数 %d 超过可用线程数 %d", stage, worker.Threads, d.slots)
+		}
+		m.stages[stage] = worker
+		d.stages = append(d.stages, stage)
+	}
+	return m, nil
+}
+
+// demandOf 阶段每次推理占用的资源
+func demandOf(worker WorkerConfig) demand {
+	if worker.Device == DeviceGPU {
+		return demand{slots: 1, memoryMB: worker.MemoryMB}
+	}
+	threads := worker.Threads
+	if threads <= 0 {
+		threads = 1
+	}
+	return demand{slots: threads}
+}
+
+// Acquire 为阶段的一次推理申请资源，返回释放函数（不受管理的阶段直接返回）
+// ctx在等待期间结束时返回ctx.Err()。
+func (m *Manager) Acquire(ctx context.Context, stage pipeline.Stage, priority pipeline.Priority) (func(), error) {
+	if m == nil {
+		return func() {}, nil
+	}
+	worker, ok := m.stages[stage]
+	if !ok {
+		return func() {}, nil
+	}
+
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil, ErrManagerClosed
+	}
+	d := m.devices[worker.Device]
+	w := &waiter{
+		stage:    stage,
+		priority: priority,
+		demand:   demandOf(worker),
+		granted:  make(chan struct{}),
+		queuedAt: time.Now(),
+	}
+	d.waiting = append(d.waiting, w)
+	d.schedule()
+	m.mu.Unlock()
+
+	select {
+	case <-w.granted:
+		return m.releaseFunc(d, w), nil
+	case <-ctx.Done():
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		select {
+		case <-w.granted:
+			// 取消的同时已分配到资源，归还
+			d.release(w.demand)
+		default:
+			d.remove(w)
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// releaseFunc 归还资源的函数（多次调用只归还一次）
+func (m *Manager) releaseFunc(d *device, w *waiter) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			m.mu.Lock()
+			d.completed++
+			d.release(w.demand)
+			m.mu.Unlock()
+		})
+	}
+}
+
+// schedule 按优先级和阶段轮转依次分配资源，排在最前的任务放不下时停止（不让小任务插队，避免大任务一直等待）
+func (d *device) schedule() {
+	for {
+		w := d.next()
+		if w == nil || !d.fits(w.demand) {
+			return
+		}
+		d.remove(w)
+		for i, stage := range d.stages {
+			if stage == w.stage {
+				d.turn = i + 1
+			}
+		}
+
+		now := time.Now()
+		if d.running == 0 {
+			d.activeSince = now
+		}
+		d.usedSlots += w.demand.slots
+		d.usedMemory += w.demand.memoryMB
+		d.running++
+		d.waitNanos += int64(now.Sub(w.queuedAt))
+		close(w.granted)
+	}
+}
+
+// next 下一个应分配资源的任务：交互任务优先，同一优先级下从轮到的阶段开始找
+func (d *device) next() *waiter {
+	for _, priority := range []pipeline.Priority{pipeline.PriorityInteractive, pipeline.PriorityBatch} {
+		for i := range d.stages {
+			stage := d.stages[(d.turn+i)%len(d.stages)]
+			for _, w := range d.waiting {
+				if w.stage == stage && effectivePriority(w.priority) == priority {
+					return w
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// effectivePriority 未设置优先级时按交互任务处理
+func effectivePriority(priority pipeline.Priority) pipeline.Priority {
+	if priority == pipeline.PriorityBatch {
+		return priority
+	}
+	return pipeline.PriorityInteractive
+}
+
+// fits 设备当前是否放得下（设备空闲时总是放行）
+func (d *device) fits(dm demand) bool {
+	if d.running == 0 {
+		return true
+	}
+	if d.usedSlots+dm.slots > d.slots {
+		return false
+	}
+	return d.memoryMB <= 0 || d.usedMemory+dm.memoryMB <= d.memoryMB
+}
+
+// release 归还资源并继续分配
+func (d *device) release(dm demand) {
+	d.usedSlots -= dm.slots
+	d.usedMemory -= dm.memoryMB
+	d.running--
+	if d.running == 0 {
+		d.busyNanos += int64(time.Since(d.activeSince))
+	}
+	d.schedule()
+}
+
+// remove 从等待队列中移除
+func (d *device) remove(w *waiter) {
+	for i, queued := range d.waiting {
+		if queued == w {
+			d.waiting = append(d.waiting[:i], d.waiting[i+1:]...)
+			return
+		}
+	}
+}
+
+// Stats 获取各设备的占用和排队统计（未启用时返回空）
+func (m *Manager) Stats() map[string]DeviceStats {
+	stats := make(map[string]DeviceStats)
+	if m == nil {
+		return stats
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	for name, d := range m.devices {
+		if len(d.stages) == 0 {
+			continue
+		}
+		s := DeviceStats{
+			Stages:       d.stages,
+			Slots:        d.slots,
+			InUse:        d.usedSlots,
+			MemoryMB:     d.memoryMB,
+			MemoryUsedMB: d.usedMemory,
+			Running:      d.running,
+			Queued:       len(d.waiting),
+			Completed:    d.completed,
+		}
+		if granted := d.completed + int64(d.running); granted > 0 {
+			s.AvgWaitMs = float64(d.waitNanos) / float64(granted) / float64(time.Millisecond)
+		}
+		busy := d.busyNanos
+		if d.running > 0 {
+			busy += int64(now.Sub(d.activeSince))
+		}
+		if elapsed := now.Sub(m.started); elapsed > 0 {
+			s.Utilization = float64(busy) / float64(elapsed)
+		}
+		stats[name] = s
+	}
+	return stats
+}
+
+// Close 关闭管理器，之后的申请返回ErrManagerClosed（已分配的资源照常归还）
+func (m *Manager) Close() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.closed = true
+	m.mu.Unlock()
+}
@@ -0,0 +1,140 @@
+package compute
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"voice_assistant/voice_assistant_server/internal/pipeline"
+)
+
+func newTestManager(t *testing.T, config Config) *Manager {
+	config.Enabled = true
+	m, err := NewManager(config)
+	require.NoError(t, err)
+	return m
+}
+
+func TestManagerSerializesGPU(t *testing.T) {
+	m := newTestManager(t, Config{Workers: map[pipeline.Stage]WorkerConfig{
+		pipeline.StageASR: {Device: DeviceGPU, MemoryMB: 1000},
+		pipeline.StageTTS: {Device: DeviceGPU, MemoryMB: 1000},
+	}})
+
+	release, err := m.Acquire(context.Background(), pipeline.StageASR, pipeline.PriorityInteractive)
+	require.NoError(t, err)
+
+	// GPU只有一个槽位，TTS须等ASR释放
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = m.Acquire(ctx, pipeline.StageTTS, pipeline.PriorityInteractive)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	stats := m.Stats()[DeviceGPU]
+	assert.Equal(t, 1, stats.Running)
+	assert.Equal(t, 1000, stats.MemoryUsedMB)
+	assert.Equal(t, 0, stats.Queued)
+
+	release()
+	release() // 重复释放无影响
+	release, err = m.Acquire(context.Background(), pipeline.StageTTS, pipeline.PriorityInteractive)
+	require.NoError(t, err)
+	release()
+
+	stats = m.Stats()[DeviceGPU]
+	assert.Equal(t, 0, stats.InUse)
+	assert.Equal(t, int64(2), stats.Completed)
+	assert.Greater(t, stats.Utilization, 0.0)
+
+	// 不受管理的阶段直接放行
+	release, err = m.Acquire(context.Background(), pipeline.StageLLM, pipeline.PriorityInteractive)
+	require.NoError(t, err)
+	release()
+}
+
+func TestManagerMemoryBudget(t *testing.T) {
+	m := newTestManager(t, Config{GPUSlots: 3, GPUMemoryMB: 4000, Workers: map[pipeline.Stage]WorkerConfig{
+		pipeline.StageASR: {Device: DeviceGPU, MemoryMB: 1000},
+		pipeline.StageLLM: {Device: DeviceGPU, MemoryMB: 3000},
+	}})
+
+	releaseASR, err := m.Acquire(context.Background(), pipeline.StageASR, pipeline.PriorityInteractive)
+	require.NoError(t, err)
+	releaseLLM, err := m.Acquire(context.Background(), pipeline.StageLLM, pipeline.PriorityInteractive)
+	require.NoError(t, err)
+
+	// 显存已满，即使还有槽位也要等待
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = m.Acquire(ctx, pipeline.StageASR, pipeline.PriorityInteractive)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	releaseASR()
+	releaseLLM()
+
+	_, err = NewManager(Config{Enabled: true, GPUMemoryMB: 2000, Workers: map[pipeline.Stage]WorkerConfig{
+		pipeline.StageLLM: {Device: DeviceGPU, MemoryMB: 3000},
+	}})
+	assert.Error(t, err)
+	_, err = NewManager(Config{Enabled: true, Workers: map[pipeline.Stage]WorkerConfig{
+		pipeline.StageLLM: {Device: "tpu"},
+	}})
+	assert.Error(t, err)
+}
+
+func TestManagerFairness(t *testing.T) {
+	m := newTestManager(t, Config{Workers: map[pipeline.Stage]WorkerConfig{
+		pipeline.StageASR: {Device: DeviceGPU},
+		pipeline.StageLLM: {Device: DeviceGPU},
+		pipeline.StageTTS: {Device: DeviceGPU},
+	}})
+	release, err := m.Acquire(context.Background(), pipeline.StageASR, pipeline.PriorityInteractive)
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	var order []string
+	var wg sync.WaitGroup
+	queued := 0
+	submit := func(stage pipeline.Stage, priority pipeline.Priority) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := m.Acquire(context.Background(), stage, priority)
+			require.NoError(t, err)
+			mu.Lock()
+			order = append(order, string(stage)+"/"+string(priority))
+			mu.Unlock()
+			release()
+		}()
+		// 等任务进入队列，保证排队顺序
+		queued++
+		require.Eventually(t, func() bool {
+			return m.Stats()[DeviceGPU].Queued == queued
+		}, time.Second, time.Millisecond)
+	}
+	// ASR连续排了三个任务，LLM、TTS各一个，另有一个批量任务
+	submit(pipeline.StageASR, pipeline.PriorityBatch)
+	submit(pipeline.StageASR, pipeline.PriorityInteractive)
+	submit(pipeline.StageASR, pipeline.PriorityInteractive)
+	submit(pipeline.StageLLM, pipeline.PriorityInteractive)
+	submit(pipeline.StageTTS, pipeline.PriorityInteractive)
+
+	release()
+	wg.Wait()
+	// 上一次运行的是ASR，从LLM开始轮转；交互任务都完成后才轮到批量任务
+	assert.Equal(t, []string{"llm/interactive", "tts/interactive", "asr/interactive", "asr/interactive", "asr/batch"}, order)
+}
+
+func TestManagerDisabled(t *testing.T) {
+	m, err := NewManager(Config{})
+	require.NoError(t, err)
+	assert.Nil(t, m)
+
+	release, err := m.Acquire(context.Background(), pipeline.StageASR, pipeline.PriorityInteractive)
+	require.NoError(t, err)
+	release()
+	assert.Empty(t, m.Stats())
+}
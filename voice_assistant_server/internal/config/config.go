@@ -1,8 +1,15 @@
 package config
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"time"
 
+	"voice_assistant/voice_assistant_server/internal/secrets"
+
 	"gopkg.in/yaml.v3"
 )
 
@@ -10,16 +17,57 @@ import (
 type Config struct {
 	Server    ServerConfig    `yaml:"server"`
 	WebSocket WebSocketConfig `yaml:"websocket"`
+	GRPC      GRPCConfig      `yaml:"grpc"`
+	WebRTC    WebRTCConfig    `yaml:"webrtc"`
+	WebClient WebClientConfig `yaml:"web_client"`
+	Multiplex MultiplexConfig `yaml:"multiplex"`
+	Pipeline  PipelineConfig  `yaml:"pipeline"`
+	Compute   ComputeConfig   `yaml:"compute"`
+	Models    ModelsConfig    `yaml:"models"`
 	ASR       ASRConfig       `yaml:"asr"`
 	LLM       LLMConfig       `yaml:"llm"`
 	TTS       TTSConfig       `yaml:"tts"`
 	Logging   LoggingConfig   `yaml:"logging"`
+
+	DataCollection  DataCollectionConfig  `yaml:"data_collection"`
+	EchoSuppression EchoSuppressionConfig `yaml:"echo_suppression"`
+	Endpointing     EndpointingConfig     `yaml:"endpointing"`
+	Limits          LimitsConfig          `yaml:"limits"`
+	SegmentedSpeech SegmentedSpeechConfig `yaml:"segmented_speech"`
+	Quota           QuotaConfig           `yaml:"quota"`
+	Archive         ArchiveConfig         `yaml:"archive"`
+	Webhook         WebhookConfig         `yaml:"webhook"`
+	MQTT            MQTTConfig            `yaml:"mqtt"`
+	Reminders       RemindersConfig       `yaml:"reminders"`
+	Notifications   NotificationsConfig   `yaml:"notifications"`
+	Handover        HandoverConfig        `yaml:"handover"`
+	Memory          MemoryConfig          `yaml:"memory"`
+	Knowledge       KnowledgeConfig       `yaml:"knowledge"`
+	Speaker         SpeakerConfig         `yaml:"speaker"`
+	Persona         PersonaConfig         `yaml:"persona"`
+	Moderation      ModerationConfig      `yaml:"moderation"`
+	Hooks           HooksConfig           `yaml:"hooks"`
+	Plugins         []PluginConfig        `yaml:"plugins"`
+	Correction      CorrectionConfig      `yaml:"correction"`
+	TextNorm        TextNormConfig        `yaml:"text_normalization"`
+	TranscriptNorm  TranscriptNormConfig  `yaml:"transcript_normalization"`
+	Usage           UsageConfig           `yaml:"usage"`
+	Admin           AdminConfig           `yaml:"admin"`
 }
 
 // ServerConfig 服务器配置
 type ServerConfig struct {
-	Host string `yaml:"host"`
-	Port int    `yaml:"port"`
+	Host string     `yaml:"host"`
+	Port int        `yaml:"port"`
+	Mode string     `yaml:"mode"` // development|production
+	CORS CORSConfig `yaml:"cors"`
+}
+
+// CORSConfig 跨域访问与WebSocket来源检查配置
+type CORSConfig struct {
+	AllowedOrigins   []string      `yaml:"allowed_origins"` // 支持通配符，为空时开发模式允许任意来源、生产模式只允许同源
+	AllowCredentials bool          `yaml:"allow_credentials"`
+	MaxAge           time.Duration `yaml:"max_age"`
 }
 
 // WebSocketConfig WebSocket配置
@@ -30,6 +78,115 @@ type WebSocketConfig struct {
 	PingPeriod      time.Duration `yaml:"ping_period"`
 	PongWait        time.Duration `yaml:"pong_wait"`
 	WriteWait       time.Duration `yaml:"write_wait"`
+
+	ResumeWindow     time.Duration `yaml:"resume_window"`      // 断线后保留会话的时长（0表示不启用会话恢复）
+	ResumeBufferSize int           `yaml:"resume_buffer_size"` // 保留的已发消息数
+
+	StaleTimeout  time.Duration `yaml:"stale_timeout"`  // 连接无任何活动超过该时长时强制关闭（0表示不检测）
+	SweepInterval time.Duration `yaml:"sweep_interval"` // 失效连接巡检间隔
+
+	SendQueueSize  int           `yaml:"send_queue_size"`  // 每个连接的发送队列长度
+	OverflowPolicy string        `yaml:"overflow_policy"`  // 发送队列已满时的处理策略: block, drop_oldest, disconnect
+	BlockTimeout   time.Duration `yaml:"block_timeout"`    // block策略等待队列腾出空间的最长时间
+	AudioChunkSize int           `yaml:"audio_chunk_size"` // 单条消息携带的最大TTS音频字节数（0表示不分片）
+
+	EnableCompression    bool `yaml:"enable_compression"`    // 客户端支持时协商permessage-deflate压缩
+	CompressionLevel     int  `yaml:"compression_level"`     // 压缩级别（1-9，0表示默认级别）
+	CompressionThreshold int  `yaml:"compression_threshold"` // 只压缩不小于该字节数的消息
+
+	CloseTimeout time.Duration `yaml:"close_timeout"` // 主动关闭连接时等待客户端确认和回应关闭帧的时长
+
+	MaxMessageSize int  `yaml:"max_message_size"` // 单条客户端消息的字节数上限（0表示不限制）
+	MaxAudioSize   int  `yaml:"max_audio_size"`   // 单块上传音频解码后的字节数上限（0表示不限制）
+	StrictProtocol bool `yaml:"strict_protocol"`  // 拒绝含有协议未定义字段的消息
+}
+
+// GRPCConfig gRPC传输配置
+type GRPCConfig struct {
+	Enabled        bool          `yaml:"enabled"`
+	Host           string        `yaml:"host"`
+	Port           int           `yaml:"port"`
+	MaxConnections int           `yaml:"max_connections"`
+	TLS            GRPCTLSConfig `yaml:"tls"`
+}
+
+// GRPCTLSConfig gRPC传输层安全配置（未配置证书时以明文提供服务）
+type GRPCTLSConfig struct {
+	CertFile     string `yaml:"cert_file"`
+	KeyFile      string `yaml:"key_file"`
+	ClientCAFile string `yaml:"client_ca_file"` // 配置后启用双向TLS
+}
+
+// WebRTCConfig 浏览器WebRTC网关配置
+type WebRTCConfig struct {
+	Enabled        bool     `yaml:"enabled"`
+	ICEServers     []string `yaml:"ice_servers"`
+	UDPPortMin     uint16   `yaml:"udp_port_min"`
+	UDPPortMax     uint16   `yaml:"udp_port_max"`
+	JitterPackets  int      `yaml:"jitter_packets"`
+	MaxConnections int      `yaml:"max_connections"`
+}
+
+// WebClientConfig 内置网页客户端配置
+type WebClientConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Path    string `yaml:"path"` // 挂载路径
+}
+
+// MultiplexConfig 连接复用配置
+type MultiplexConfig struct {
+	MaxSessionsPerConnection int `yaml:"max_sessions_per_connection"`
+	MaxTurnsPerConnection    int `yaml:"max_turns_per_connection"`
+	MaxMembersPerSession     int `yaml:"max_members_per_session"`
+}
+
+// PipelineConfig 处理工作池配置
+type PipelineConfig struct {
+	ASRWorkers    int            `yaml:"asr_workers"` // 各阶段同时处理的任务数（0表示不限制）
+	LLMWorkers    int            `yaml:"llm_workers"`
+	TTSWorkers    int            `yaml:"tts_workers"`
+	QueueSize     int            `yaml:"queue_size"`      // 每个阶段每种优先级的排队上限
+	Fairness      string         `yaml:"fairness"`        // 会话间的调度方式: fifo|round_robin|weighted
+	MaxPerSession int            `yaml:"max_per_session"` // 每个会话在一个阶段同时处理的请求数（0表示不限制）
+	Weights       map[string]int `yaml:"weights"`         // weighted调度时各API Key的权重
+
+	// 超时：各阶段单次调用（含排队）和一轮语音对话的最长耗时（0表示不限制）
+	ASRTimeout  time.Duration `yaml:"asr_timeout"`
+	LLMTimeout  time.Duration `yaml:"llm_timeout"`
+	TTSTimeout  time.Duration `yaml:"tts_timeout"`
+	TurnTimeout time.Duration `yaml:"turn_timeout"`
+}
+
+// ComputeConfig 本地模型的GPU/CPU资源管理配置
+type ComputeConfig struct {
+	Enabled     bool                           `yaml:"enabled"`
+	GPUSlots    int                            `yaml:"gpu_slots"`     // 同时使用GPU的任务数（1表示串行）
+	GPUMemoryMB int                            `yaml:"gpu_memory_mb"` // 可用显存（MB），0表示不按显存限制
+	CPUThreads  int                            `yaml:"cpu_threads"`   // 本地模型可用的CPU线程数，0表示CPU核数
+	Workers     map[string]ComputeWorkerConfig `yaml:"workers"`       // asr|llm|tts -> 每次推理占用的资源
+}
+
+// ComputeWorkerConfig 一个阶段的本地模型每次推理占用的资源
+type ComputeWorkerConfig struct {
+	Device   string `yaml:"device"`    // gpu|cpu
+	MemoryMB int    `yaml:"memory_mb"` // 占用显存（MB）
+	Threads  int    `yaml:"threads"`   // 占用CPU线程数
+}
+
+// ModelsConfig 模型文件下载配置
+type ModelsConfig struct {
+	Dir          string            `yaml:"dir"`           // 模型目录
+	AutoDownload bool              `yaml:"auto_download"` // 启动时下载当前提供商缺失的模型文件
+	Files        []ModelFileConfig `yaml:"files"`
+}
+
+// ModelFileConfig 可下载的模型文件
+type ModelFileConfig struct {
+	Name     string `yaml:"name"`
+	Provider string `yaml:"provider"` // 使用该模型的提供商，为空表示总是需要
+	URL      string `yaml:"url"`
+	Path     string `yaml:"path"`   // 保存位置（压缩包为解压后的目录）
+	SHA256   string `yaml:"sha256"` // 为空时不校验
 }
 
 // ASRConfig ASR配置
@@ -43,14 +200,19 @@ type ASRConfig struct {
 
 // WhisperConfig Whisper配置
 type WhisperConfig struct {
-	ModelPath string `yaml:"model_path"`
-	Language  string `yaml:"language"`
+	ModelPath   string  `yaml:"model_path"`
+	Language    string  `yaml:"language"`
+	BeamSize    int     `yaml:"beam_size"`   // 束搜索大小（0表示使用whisper默认值）
+	Temperature float32 `yaml:"temperature"` // 解码温度
 }
 
 // OpenAIASRConfig OpenAI ASR配置
 type OpenAIASRConfig struct {
 	APIKey string `yaml:"api_key"`
 	Model  string `yaml:"model"`
+	APIURL string `yaml:"api_url"` // 转写接口地址（兼容OpenAI的服务），为空时使用OpenAI官方地址
+
+	PartialInterval time.Duration `yaml:"partial_interval"` // 流式识别时重新识别已收到音频的间隔（0表示不支持流式识别）
 }
 
 // FunASRConfig FunASR配置
@@ -60,56 +222,100 @@ type FunASRConfig struct {
 	DeviceID          string `yaml:"device_id"`            // 设备ID (cpu|cuda:0)
 	IntraOpNumThreads int    `yaml:"intra_op_num_threads"` // 线程数
 	BatchSize         int    `yaml:"batch_size"`           // 批处理大小
-	MaxSentenceLength int    `yaml:"max_sentence_length"`  // 最大句子长度
+	MaxSentenceLength int    `yaml:"max_sentence_length"`  // 最大句子长度（保留，暂未使用）
 }
 
 // LLMConfig LLM配置
 type LLMConfig struct {
-	Provider  string                 `yaml:"provider"`
-	OpenAI    OpenAILLMConfig        `yaml:"openai"`
-	Ollama    OllamaConfig           `yaml:"ollama"`
-	WebSocket WebSocketLLMConfig     `yaml:"websocket"`
-	Settings  map[string]interface{} `yaml:"settings"`
+	Provider     string             `yaml:"provider"`
+	SystemPrompt string             `yaml:"system_prompt"` // 未配置人设时使用的系统提示
+	OpenAI       OpenAILLMConfig    `yaml:"openai"`
+	Ollama       OllamaConfig       `yaml:"ollama"`
+	WebSocket    WebSocketLLMConfig `yaml:"websocket"`
+	Conversation ConversationConfig `yaml:"conversation"`
+	Settings     LLMSettings        `yaml:"settings"`
+}
+
+// LLMSettings LLM通用设置
+type LLMSettings struct {
+	MaxContextLength  int     `yaml:"max_context_length"`  // 上下文Token上限
+	EnableContextTrim bool    `yaml:"enable_context_trim"` // 超出上限时修剪对话历史
+	KeepSystemPrompt  bool    `yaml:"keep_system_prompt"`  // 修剪时保留系统提示
+	TopP              float32 `yaml:"top_p"`
+	TopK              int     `yaml:"top_k"`
+	Timeout           int     `yaml:"timeout"` // 请求超时（秒）
+}
+
+// ConversationConfig 对话管理配置
+type ConversationConfig struct {
+	MaxConversations int    `yaml:"max_conversations"`  // 最大对话数
+	EvictionPolicy   string `yaml:"eviction_policy"`    // lru|ttl|size
+	TTL              int    `yaml:"ttl"`                // 对话空闲超时（秒）
+	MaxMessages      int    `yaml:"max_messages"`       // 修剪后保留的最大消息数
+	Tokenizer        string `yaml:"tokenizer"`          // Token计数：estimate|tiktoken|编码名
+	TrimStrategy     string `yaml:"trim_strategy"`      // sliding_window|summary
+	SummaryMaxTokens int    `yaml:"summary_max_tokens"` // 摘要的Token预算
 }
 
 // OpenAILLMConfig OpenAI LLM配置
 type OpenAILLMConfig struct {
-	APIKey      string  `yaml:"api_key"`
-	Model       string  `yaml:"model"`
-	Temperature float64 `yaml:"temperature"`
-	MaxTokens   int     `yaml:"max_tokens"`
+	APIKey       string  `yaml:"api_key"`
+	Model        string  `yaml:"model"`
+	Temperature  float64 `yaml:"temperature"`
+	MaxTokens    int     `yaml:"max_tokens"`
+	APIURL       string  `yaml:"api_url"`      // 对话接口地址（兼容OpenAI的服务），为空时使用OpenAI官方地址
+	Organization string  `yaml:"organization"` // 组织ID
 }
 
 // OllamaConfig Ollama配置
 type OllamaConfig struct {
-	BaseURL string `yaml:"base_url"`
-	Model   string `yaml:"model"`
+	BaseURL     string  `yaml:"base_url"`
+	Model       string  `yaml:"model"`
+	Temperature float64 `yaml:"temperature"`
+	MaxTokens   int     `yaml:"max_tokens"`
+	NumCtx      int     `yaml:"num_ctx"`    // 上下文长度（0表示使用模型默认值）
+	NumGPU      int     `yaml:"num_gpu"`    // 加载到GPU的层数
+	NumThread   int     `yaml:"num_thread"` // 线程数
 }
 
 // WebSocketLLMConfig WebSocket LLM配置
 type WebSocketLLMConfig struct {
-	URL string `yaml:"url"`
+	URL               string            `yaml:"url"`
+	Model             string            `yaml:"model"`
+	Headers           map[string]string `yaml:"headers"`            // 握手请求头（如认证信息）
+	ReconnectInterval int               `yaml:"reconnect_interval"` // 重连间隔（秒）
+	MaxReconnects     int               `yaml:"max_reconnects"`     // 最大重连次数
+	PingInterval      int               `yaml:"ping_interval"`      // 心跳间隔（秒）
+	Temperature       float64           `yaml:"temperature"`
+	MaxTokens         int               `yaml:"max_tokens"`
 }
 
 // TTSConfig TTS配置
 type TTSConfig struct {
-	Provider string        `yaml:"provider"` // edge_tts|sherpa|chattts
-	EdgeTTS  EdgeTTSConfig `yaml:"edge_tts"`
-	Sherpa   SherpaConfig  `yaml:"sherpa"`
-	ChatTTS  ChatTTSConfig `yaml:"chattts"` // 新增ChatTTS配置
-	Settings TTSSettings   `yaml:"settings"`
+	Provider  string          `yaml:"provider"` // edge_tts|sherpa|chattts|cosyvoice
+	EdgeTTS   EdgeTTSConfig   `yaml:"edge_tts"`
+	Sherpa    SherpaConfig    `yaml:"sherpa"`
+	ChatTTS   ChatTTSConfig   `yaml:"chattts"` // 新增ChatTTS配置
+	CosyVoice CosyVoiceConfig `yaml:"cosyvoice"`
+	Settings  TTSSettings     `yaml:"settings"`
 }
 
 // EdgeTTSConfig Edge TTS配置
 type EdgeTTSConfig struct {
-	Voice string `yaml:"voice"`
-	Rate  string `yaml:"rate"`
-	Pitch string `yaml:"pitch"`
+	Voice  string `yaml:"voice"`
+	Rate   string `yaml:"rate"`
+	Volume string `yaml:"volume"`
+	Pitch  string `yaml:"pitch"`
 }
 
 // SherpaConfig Sherpa配置
 type SherpaConfig struct {
-	ModelPath string `yaml:"model_path"`
+	ModelPath   string `yaml:"model_path"`
+	LexiconPath string `yaml:"lexicon_path"` // 词典（为空时使用模型目录下的lexicon.txt）
+	TokensPath  string `yaml:"tokens_path"`  // 词汇表（为空时使用模型目录下的tokens.txt）
+	DataDir     string `yaml:"data_dir"`     // espeak-ng数据目录（部分英文模型需要）
+	NumThreads  int    `yaml:"num_threads"`
+	Voice       string `yaml:"voice"` // 说话人ID（多说话人模型）
 }
 
 // ChatTTSConfig ChatTTS配置
@@ -123,17 +329,362 @@ type ChatTTSConfig struct {
 	NumThreads  int     `yaml:"num_threads"` // 线程数
 }
 
+// CosyVoiceConfig CosyVoice配置（支持参考音频克隆声音）
+type CosyVoiceConfig struct {
+	URL        string   `yaml:"url"`         // CosyVoice FastAPI服务地址
+	Voice      string   `yaml:"voice"`       // 默认声音（内置说话人或克隆声音ID）
+	SampleRate int      `yaml:"sample_rate"` // 服务输出采样率
+	Speakers   []string `yaml:"speakers"`    // 内置说话人
+	VoicesDir  string   `yaml:"voices_dir"`  // 克隆声音参考音频目录
+}
+
 // LoggingConfig 日志配置
 type LoggingConfig struct {
-	Level  string `yaml:"level"`
-	Format string `yaml:"format"`
-	Output string `yaml:"output"`
+	Level      string `yaml:"level"`
+	Format     string `yaml:"format"`      // text|json
+	Output     string `yaml:"output"`      // stdout|stderr|file
+	FilePath   string `yaml:"file_path"`   // output为file时的日志文件
+	MaxSize    int    `yaml:"max_size"`    // 单个日志文件的最大大小（MB），超过后轮转
+	MaxBackups int    `yaml:"max_backups"` // 保留的轮转文件数（0表示不按数量清理）
+	MaxAge     int    `yaml:"max_age"`     // 轮转文件保留天数（0表示不按时间清理）
+	Compress   bool   `yaml:"compress"`    // 轮转后gzip压缩
+}
+
+// DataCollectionConfig 对话数据采集配置（用于微调数据集）
+type DataCollectionConfig struct {
+	Enabled             bool   `yaml:"enabled"`
+	Sink                string `yaml:"sink"` // jsonl
+	Path                string `yaml:"path"`
+	RequireConsent      bool   `yaml:"require_consent"`
+	Redact              bool   `yaml:"redact"`
+	IncludeSystemPrompt bool   `yaml:"include_system_prompt"`
+}
+
+// EchoSuppressionConfig 连续模式下的TTS回声抑制配置
+type EchoSuppressionConfig struct {
+	Enabled   bool    `yaml:"enabled"`
+	Window    int     `yaml:"window"`    // 秒
+	Threshold float64 `yaml:"threshold"` // 相似度阈值
+	MinLength int     `yaml:"min_length"`
+}
+
+// EndpointingConfig 服务端断句配置（最后一个音频块之后静默超过设定时长即结束本句）
+type EndpointingConfig struct {
+	Silence    time.Duration `yaml:"silence"`     // 默认静默时长，0表示只依赖客户端的is_final
+	MinSilence time.Duration `yaml:"min_silence"` // 会话参数endpoint_silence_ms的下限
+	MaxSilence time.Duration `yaml:"max_silence"` // 会话参数endpoint_silence_ms的上限
+}
+
+// LimitsConfig 语音轮次长度限制（0表示不限制）
+type LimitsConfig struct {
+	MaxUtteranceSeconds int `yaml:"max_utterance_seconds"` // 单句音频最长秒数
+	MaxResponseTokens   int `yaml:"max_response_tokens"`   // 语音回复的最大Token数
+	MaxBufferedSeconds  int `yaml:"max_buffered_seconds"`  // 会话音频缓冲区最多保留的秒数（0时为120秒）
+}
+
+// SegmentedSpeechConfig 分句合成配置
+type SegmentedSpeechConfig struct {
+	Enabled     bool `yaml:"enabled"`      // 是否启用
+	MaxParallel int  `yaml:"max_parallel"` // 同时合成的句子数
+	MinLength   int  `yaml:"min_length"`   // 短于该字符数的句子与下一句合并
+}
+
+// QuotaConfig 会话资源配额配置（可按租户/用户覆盖）
+type QuotaConfig struct {
+	Enabled bool                   `yaml:"enabled"`
+	Default QuotaLimits            `yaml:"default"`
+	APIKeys map[string]QuotaLimits `yaml:"api_keys"` // 按API Key覆盖默认配额
+}
+
+// ArchiveConfig 语音与文本归档配置（用于排查质量问题和构建训练数据）
+type ArchiveConfig struct {
+	Enabled         bool            `yaml:"enabled"`
+	Store           string          `yaml:"store"` // local|s3
+	Path            string          `yaml:"path"`
+	S3              ArchiveS3Config `yaml:"s3"`
+	RetentionDays   int             `yaml:"retention_days"` // 0表示永久保留
+	CleanupInterval time.Duration   `yaml:"cleanup_interval"`
+	RequireConsent  bool            `yaml:"require_consent"`
+}
+
+// ArchiveS3Config S3兼容存储配置
+type ArchiveS3Config struct {
+	Endpoint  string `yaml:"endpoint"`
+	Region    string `yaml:"region"`
+	Bucket    string `yaml:"bucket"`
+	Prefix    string `yaml:"prefix"`
+	AccessKey string `yaml:"access_key"`
+	SecretKey string `yaml:"secret_key"`
+	PathStyle bool   `yaml:"path_style"`
+}
+
+// WebhookConfig 对话事件推送配置（一轮对话完成后向外部系统POST JSON）
+type WebhookConfig struct {
+	Enabled        bool                    `yaml:"enabled"`
+	Endpoints      []WebhookEndpointConfig `yaml:"endpoints"`
+	Timeout        time.Duration           `yaml:"timeout"`
+	MaxRetries     int                     `yaml:"max_retries"`
+	RetryInterval  time.Duration           `yaml:"retry_interval"` // 首次重试的等待时间，之后每次翻倍
+	QueueSize      int                     `yaml:"queue_size"`
+	RequireConsent bool                    `yaml:"require_consent"`
+}
+
+// WebhookEndpointConfig 接收事件的地址
+type WebhookEndpointConfig struct {
+	URL     string            `yaml:"url"`
+	Secret  string            `yaml:"secret"` // HMAC-SHA256签名密钥
+	Headers map[string]string `yaml:"headers"`
+}
+
+// MQTTConfig MQTT桥接配置（发布对话结果、订阅播报命令，可接入Home Assistant）
+type MQTTConfig struct {
+	Enabled         bool             `yaml:"enabled"`
+	Broker          string           `yaml:"broker"` // tcp://host:1883 或 ssl://host:8883
+	ClientID        string           `yaml:"client_id"`
+	Username        string           `yaml:"username"`
+	Password        string           `yaml:"password"`
+	KeepAlive       time.Duration    `yaml:"keep_alive"`
+	QoS             int              `yaml:"qos"` // 0或1
+	TopicPrefix     string           `yaml:"topic_prefix"`
+	Topics          MQTTTopicsConfig `yaml:"topics"`
+	RequireConsent  bool             `yaml:"require_consent"`
+	Discovery       bool             `yaml:"discovery"` // 发布Home Assistant自动发现配置
+	DiscoveryPrefix string           `yaml:"discovery_prefix"`
+}
+
+// MQTTTopicsConfig 单独指定的MQTT主题（为空时使用 topic_prefix/名称）
+type MQTTTopicsConfig struct {
+	Transcript string `yaml:"transcript"`
+	Reply      string `yaml:"reply"`
+	Turn       string `yaml:"turn"`
+	Announce   string `yaml:"announce"`
+	Status     string `yaml:"status"`
+}
+
+// RemindersConfig 定时提醒和计时器配置（识别“十分钟后提醒我…”等指令，到期时主动推送播报）
+type RemindersConfig struct {
+	Enabled     bool          `yaml:"enabled"`
+	Path        string        `yaml:"path"`          // 持久化文件（为空时只保存在内存）
+	MaxPerOwner int           `yaml:"max_per_owner"` // 每个用户（或会话）待触发的提醒上限（0表示不限制）
+	MissedTTL   time.Duration `yaml:"missed_ttl"`    // 到期时会话不在线的提醒保留多久等待补发
+	Timezone    string        `yaml:"timezone"`      // IANA时区名称（为空时使用服务器本地时区）
+}
+
+// NotificationsConfig 主动通知配置（管理接口推送的通知和到期提醒的送达确认）
+type NotificationsConfig struct {
+	RetainFor  time.Duration `yaml:"retain_for"`  // 送达状态保留多久，期间未确认的通知在会话重新开始时重发
+	MaxTracked int           `yaml:"max_tracked"` // 最多跟踪的通知数量，超出时丢弃最早的
+}
+
+// HandoverConfig 会话转移配置（把进行中的对话转移到其他设备）
+type HandoverConfig struct {
+	Enabled    bool          `yaml:"enabled"`
+	TokenTTL   time.Duration `yaml:"token_ttl"`   // 转移令牌有效期（令牌一次有效）
+	MaxHistory int           `yaml:"max_history"` // 接管时返回给新设备的历史消息数上限
+}
+
+// MemoryConfig 用户长期记忆配置（仅对能确定用户身份的会话生效）
+type MemoryConfig struct {
+	Enabled   bool              `yaml:"enabled"`
+	Store     string            `yaml:"store"`     // file|memory
+	Path      string            `yaml:"path"`      // file存储目录
+	Extractor string            `yaml:"extractor"` // rules|llm
+	MaxFacts  int               `yaml:"max_facts"`
+	Users     map[string]string `yaml:"users"` // API Key -> 用户ID
+
+	SpeakerFallback bool `yaml:"speaker_fallback"` // 未映射用户的会话按声纹识别的说话人区分记忆（声纹可被冒充，默认关闭）
+}
+
+// KnowledgeConfig 知识库检索配置
+type KnowledgeConfig struct {
+	Enabled      bool                     `yaml:"enabled"`
+	Store        string                   `yaml:"store"` // sqlite|qdrant|memory
+	Path         string                   `yaml:"path"`  // sqlite数据库文件
+	Qdrant       KnowledgeQdrantConfig    `yaml:"qdrant"`
+	Embedding    KnowledgeEmbeddingConfig `yaml:"embedding"`
+	Documents    []string                 `yaml:"documents"` // 启动时索引的文件或目录
+	ChunkSize    int                      `yaml:"chunk_size"`
+	ChunkOverlap int                      `yaml:"chunk_overlap"`
+	TopK         int                      `yaml:"top_k"`
+	MinScore     float64                  `yaml:"min_score"`
+}
+
+// KnowledgeQdrantConfig Qdrant向量数据库配置
+type KnowledgeQdrantConfig struct {
+	URL        string `yaml:"url"`
+	Collection string `yaml:"collection"`
+	APIKey     string `yaml:"api_key"`
+}
+
+// KnowledgeEmbeddingConfig 向量化模型配置
+type KnowledgeEmbeddingConfig struct {
+	Provider  string `yaml:"provider"` // openai|ollama
+	Model     string `yaml:"model"`
+	BaseURL   string `yaml:"base_url"`
+	APIKey    string `yaml:"api_key"`
+	BatchSize int    `yaml:"batch_size"`
+}
+
+// SpeakerConfig 说话人识别配置
+type SpeakerConfig struct {
+	Enabled      bool    `yaml:"enabled"`
+	Provider     string  `yaml:"provider"`   // sherpa|http
+	ModelPath    string  `yaml:"model_path"` // 声纹ONNX模型（sherpa）
+	NumThreads   int     `yaml:"num_threads"`
+	URL          string  `yaml:"url"` // 声纹服务地址（http）
+	ProfilesFile string  `yaml:"profiles_file"`
+	Threshold    float64 `yaml:"threshold"`
+	MinDuration  int     `yaml:"min_duration"` // 毫秒
+}
+
+// PersonaConfig 人设配置
+type PersonaConfig struct {
+	Default  string                   `yaml:"default"` // 默认人设ID（为空时使用 llm.system_prompt）
+	File     string                   `yaml:"file"`    // 通过管理接口添加或修改的人设保存文件
+	Personas map[string]PersonaPrompt `yaml:"personas"`
+}
+
+// PersonaPrompt 命名的系统提示
+type PersonaPrompt struct {
+	Name         string `yaml:"name"`
+	SystemPrompt string `yaml:"system_prompt"`
+}
+
+// ModerationConfig 内容审核配置
+type ModerationConfig struct {
+	Enabled      bool                   `yaml:"enabled"`
+	InputAction  string                 `yaml:"input_action"`  // block|redact|rephrase
+	OutputAction string                 `yaml:"output_action"` // block|redact|rephrase
+	Keywords     []string               `yaml:"keywords"`
+	KeywordsFile string                 `yaml:"keywords_file"`
+	Provider     string                 `yaml:"provider"` // 为空时只使用屏蔽词|openai
+	OpenAI       ModerationOpenAIConfig `yaml:"openai"`
+	Categories   []string               `yaml:"categories"`
+	FailClosed   bool                   `yaml:"fail_closed"`
+}
+
+// ModerationOpenAIConfig OpenAI审核接口配置
+type ModerationOpenAIConfig struct {
+	APIKey  string `yaml:"api_key"`
+	Model   string `yaml:"model"`
+	BaseURL string `yaml:"base_url"`
+}
+
+// HooksConfig 处理流程钩子配置
+type HooksConfig struct {
+	Enabled bool         `yaml:"enabled"`
+	Hooks   []HookConfig `yaml:"hooks"`
+}
+
+// HookConfig 单个钩子配置
+type HookConfig struct {
+	Name    string                 `yaml:"name"`
+	Type    string                 `yaml:"type"`   // exec|plugin
+	Stages  []string               `yaml:"stages"` // pre_asr|post_asr|pre_llm|post_llm|pre_tts，为空时全部
+	Timeout time.Duration          `yaml:"timeout"`
+	Command string                 `yaml:"command"`
+	Args    []string               `yaml:"args"`
+	Path    string                 `yaml:"path"`
+	Options map[string]interface{} `yaml:"options"`
+}
+
+// PluginConfig 外部进程插件配置
+type PluginConfig struct {
+	Name    string                 `yaml:"name"`
+	Command string                 `yaml:"command"`
+	Args    []string               `yaml:"args"`
+	Env     []string               `yaml:"env"` // KEY=VALUE
+	Dir     string                 `yaml:"dir"`
+	Timeout time.Duration          `yaml:"timeout"`
+	Options map[string]interface{} `yaml:"options"`
+}
+
+// CorrectionConfig 插话更正配置（“不对，我是说……”与上一轮的提问合并后重新回答）
+type CorrectionConfig struct {
+	Enabled   bool     `yaml:"enabled"`
+	Window    int      `yaml:"window"`     // 秒
+	MaxLength int      `yaml:"max_length"` // 字符数
+	Cues      []string `yaml:"cues"`
+}
+
+// TextNormConfig 合成前文本规范化配置（朗读化、发音词典、数字展开、去除表情符号）
+type TextNormConfig struct {
+	Enabled       bool              `yaml:"enabled"`
+	Lexicon       map[string]string `yaml:"lexicon"`
+	LexiconFile   string            `yaml:"lexicon_file"`
+	ExpandNumbers bool              `yaml:"expand_numbers"`
+	StripEmoji    bool              `yaml:"strip_emoji"`
+	StripMarkdown bool              `yaml:"strip_markdown"`
+	CodeBlocks    string            `yaml:"code_blocks"` // notice|omit|read
+	URLs          string            `yaml:"urls"`        // domain|omit|read
+}
+
+// TranscriptNormConfig 识别结果规范化配置（标点恢复、中文数字转阿拉伯数字）
+type TranscriptNormConfig struct {
+	Enabled          bool                   `yaml:"enabled"`
+	InverseNumbers   bool                   `yaml:"inverse_numbers"`
+	Punctuation      string                 `yaml:"punctuation"` // rule|model|none
+	PunctuationModel PunctuationModelConfig `yaml:"punctuation_model"`
+}
+
+// PunctuationModelConfig 标点模型服务配置
+type PunctuationModelConfig struct {
+	URL     string        `yaml:"url"`
+	APIKey  string        `yaml:"api_key"`
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// AdminConfig 管理接口配置
+type AdminConfig struct {
+	Token       string `yaml:"token"`        // 管理令牌（为空时不开放管理接口）
+	DebugListen string `yaml:"debug_listen"` // 调试端口监听地址（pprof和运行时调优，为空时不开启）
+}
+
+// UsageConfig 用量统计与预算配置
+type UsageConfig struct {
+	Enabled bool                      `yaml:"enabled"`
+	Period  string                    `yaml:"period"` // API Key预算周期: day|month
+	Pricing UsagePricingConfig        `yaml:"pricing"`
+	Session UsageBudgetConfig         `yaml:"session"` // 单个会话的预算
+	Default UsageBudgetConfig         `yaml:"default"` // 每个API Key每周期的默认预算
+	APIKeys map[string]UsageKeyConfig `yaml:"api_keys"`
+}
+
+// UsagePricingConfig 单价配置
+type UsagePricingConfig struct {
+	PromptTokens     float64 `yaml:"prompt_tokens"`     // 每千提示Token
+	CompletionTokens float64 `yaml:"completion_tokens"` // 每千生成Token
+	ASRMinute        float64 `yaml:"asr_minute"`
+	TTSMinute        float64 `yaml:"tts_minute"`
+}
+
+// UsageBudgetConfig 预算上限（0表示不限制）
+type UsageBudgetConfig struct {
+	MaxTokens     int     `yaml:"max_tokens"`
+	MaxASRSeconds float64 `yaml:"max_asr_seconds"`
+	MaxTTSSeconds float64 `yaml:"max_tts_seconds"`
+	MaxCost       float64 `yaml:"max_cost"`
+}
+
+// UsageKeyConfig 单个API Key的名称和预算
+type UsageKeyConfig struct {
+	Name   string            `yaml:"name"`
+	Budget UsageBudgetConfig `yaml:"budget"`
+}
+
+// QuotaLimits 配额上限（0表示不限制）
+type QuotaLimits struct {
+	MaxTurnsPerHour       int     `yaml:"max_turns_per_hour"`
+	MaxAudioMinutesPerDay float64 `yaml:"max_audio_minutes_per_day"`
+	MaxTokensPerDay       int     `yaml:"max_tokens_per_day"`
 }
 
 // ASRSettings ASR通用设置
 type ASRSettings struct {
-	SampleRate int `yaml:"sample_rate"`
-	Channels   int `yaml:"channels"`
+	SampleRate int    `yaml:"sample_rate"`
+	Channels   int    `yaml:"channels"`
+	Language   string `yaml:"language"` // 识别语言（为空时使用whisper.language）
+	Timeout    int    `yaml:"timeout"`  // 识别超时（秒）
 }
 
 // TTSSettings TTS通用设置
@@ -141,6 +692,8 @@ type TTSSettings struct {
 	SampleRate int    `yaml:"sample_rate"`
 	Format     string `yaml:"format"`
 	Quality    string `yaml:"quality"`
+	Language   string `yaml:"language"` // 合成语言
+	Timeout    int    `yaml:"timeout"`  // 合成超时（秒）
 }
 
 // DefaultConfig 默认配置
@@ -149,6 +702,10 @@ func DefaultConfig() *Config {
 		Server: ServerConfig{
 			Host: "0.0.0.0",
 			Port: 8080,
+			Mode: "development",
+			CORS: CORSConfig{
+				MaxAge: 12 * time.Hour,
+			},
 		},
 		WebSocket: WebSocketConfig{
 			ReadBufferSize:  1024,
@@ -157,6 +714,83 @@ func DefaultConfig() *Config {
 			PingPeriod:      54 * time.Second,
 			PongWait:        60 * time.Second,
 			WriteWait:       10 * time.Second,
+
+			ResumeWindow:     60 * time.Second,
+			ResumeBufferSize: 200,
+			StaleTimeout:     120 * time.Second,
+			SweepInterval:    30 * time.Second,
+
+			SendQueueSize:  100,
+			OverflowPolicy: "block",
+			BlockTimeout:   2 * time.Second,
+			AudioChunkSize: 32 * 1024,
+
+			EnableCompression:    true,
+			CompressionThreshold: 1024,
+
+			CloseTimeout: 2 * time.Second,
+
+			MaxMessageSize: 2 << 20,
+			MaxAudioSize:   1 << 20,
+		},
+		GRPC: GRPCConfig{
+			Enabled:        false,
+			Host:           "0.0.0.0",
+			Port:           9090,
+			MaxConnections: 100,
+		},
+		WebRTC: WebRTCConfig{
+			Enabled:        false,
+			JitterPackets:  5,
+			MaxConnections: 100,
+		},
+		WebClient: WebClientConfig{
+			Enabled: false,
+			Path:    "/app",
+		},
+		Multiplex: MultiplexConfig{
+			MaxSessionsPerConnection: 4,
+			MaxTurnsPerConnection:    2,
+			MaxMembersPerSession:     8,
+		},
+		Pipeline: PipelineConfig{
+			ASRWorkers: 4,
+			LLMWorkers: 8,
+			TTSWorkers: 4,
+			QueueSize:  100,
+			Fairness:   "round_robin",
+
+			ASRTimeout:  30 * time.Second,
+			LLMTimeout:  60 * time.Second,
+			TTSTimeout:  30 * time.Second,
+			TurnTimeout: 2 * time.Minute,
+		},
+		Compute: ComputeConfig{
+			Enabled:  false,
+			GPUSlots: 1,
+			Workers: map[string]ComputeWorkerConfig{
+				"asr": {Device: "gpu", MemoryMB: 2048},
+				"llm": {Device: "gpu", MemoryMB: 6144},
+				"tts": {Device: "gpu", MemoryMB: 2048},
+			},
+		},
+		Models: ModelsConfig{
+			Dir:          "./models",
+			AutoDownload: false,
+			Files: []ModelFileConfig{
+				{
+					Name:     "whisper-base",
+					Provider: "whisper",
+					URL:      "https://huggingface.co/ggerganov/whisper.cpp/resolve/main/ggml-base.bin",
+					Path:     "./models/whisper/ggml-base.bin",
+				},
+				{
+					Name:     "sherpa-vits-zh",
+					Provider: "sherpa",
+					URL:      "https://github.com/k2-fsa/sherpa-onnx/releases/download/tts-models/vits-zh-hf-fanchen-C.tar.bz2",
+					Path:     "./models/sherpa/vits-zh-hf-fanchen-C",
+				},
+			},
 		},
 		ASR: ASRConfig{
 			Provider: "whisper",
@@ -165,8 +799,14 @@ func DefaultConfig() *Config {
 				Language:  "zh",
 			},
 			OpenAI: OpenAIASRConfig{
-				APIKey: "",
-				Model:  "whisper-1",
+				APIKey:          "",
+				Model:           "whisper-1",
+				PartialInterval: 2 * time.Second,
+			},
+			Settings: ASRSettings{
+				SampleRate: 16000,
+				Channels:   1,
+				Timeout:    30,
 			},
 		},
 		LLM: LLMConfig{
@@ -184,6 +824,20 @@ func DefaultConfig() *Config {
 			WebSocket: WebSocketLLMConfig{
 				URL: "ws://localhost:8081/llm",
 			},
+			Settings: LLMSettings{
+				MaxContextLength:  4000,
+				EnableContextTrim: true,
+				KeepSystemPrompt:  true,
+				Timeout:           30,
+			},
+			Conversation: ConversationConfig{
+				MaxConversations: 100,
+				EvictionPolicy:   "lru",
+				TTL:              1800,
+				Tokenizer:        "tiktoken",
+				TrimStrategy:     "sliding_window",
+				SummaryMaxTokens: 200,
+			},
 		},
 		TTS: TTSConfig{
 			Provider: "edge_tts",
@@ -192,19 +846,181 @@ func DefaultConfig() *Config {
 				Rate:  "0%",
 				Pitch: "0%",
 			},
+			CosyVoice: CosyVoiceConfig{
+				URL:        "http://localhost:50000",
+				Voice:      "中文女",
+				SampleRate: 22050,
+				VoicesDir:  "data/voices",
+			},
+			Settings: TTSSettings{
+				SampleRate: 24000,
+				Format:     "wav",
+				Language:   "zh-CN",
+				Timeout:    30,
+			},
 		},
 		Logging: LoggingConfig{
-			Level:  "info",
-			Format: "json",
-			Output: "stdout",
+			Level:      "info",
+			Format:     "text",
+			Output:     "stdout",
+			FilePath:   "logs/server.log",
+			MaxSize:    100,
+			MaxBackups: 5,
+			MaxAge:     30,
+		},
+		DataCollection: DataCollectionConfig{
+			Enabled:             false,
+			Sink:                "jsonl",
+			Path:                "data/finetune.jsonl",
+			RequireConsent:      true,
+			Redact:              true,
+			IncludeSystemPrompt: true,
+		},
+		EchoSuppression: EchoSuppressionConfig{
+			Enabled:   true,
+			Window:    30,
+			Threshold: 0.8,
+			MinLength: 4,
+		},
+		Endpointing: EndpointingConfig{
+			Silence:    0,
+			MinSilence: 200 * time.Millisecond,
+			MaxSilence: 10 * time.Second,
+		},
+		Limits: LimitsConfig{
+			MaxUtteranceSeconds: 60,
+			MaxResponseTokens:   300,
+			MaxBufferedSeconds:  120,
+		},
+		SegmentedSpeech: SegmentedSpeechConfig{
+			Enabled:     true,
+			MaxParallel: 3,
+			MinLength:   10,
+		},
+		Archive: ArchiveConfig{
+			Enabled:         false,
+			Store:           "local",
+			Path:            "data/archive",
+			RetentionDays:   30,
+			CleanupInterval: time.Hour,
+			RequireConsent:  true,
+		},
+		Webhook: WebhookConfig{
+			Enabled:        false,
+			Timeout:        10 * time.Second,
+			MaxRetries:     3,
+			RetryInterval:  time.Second,
+			QueueSize:      100,
+			RequireConsent: false,
+		},
+		MQTT: MQTTConfig{
+			Enabled:         false,
+			ClientID:        "voice_assistant",
+			KeepAlive:       60 * time.Second,
+			QoS:             0,
+			TopicPrefix:     "voice_assistant",
+			Discovery:       true,
+			DiscoveryPrefix: "homeassistant",
+		},
+		Reminders: RemindersConfig{
+			Enabled:     false,
+			Path:        "data/reminders.json",
+			MaxPerOwner: 20,
+			MissedTTL:   time.Hour,
+		},
+		Notifications: NotificationsConfig{
+			RetainFor:  24 * time.Hour,
+			MaxTracked: 1000,
+		},
+		Handover: HandoverConfig{
+			Enabled:    true,
+			TokenTTL:   2 * time.Minute,
+			MaxHistory: 50,
+		},
+		Memory: MemoryConfig{
+			Enabled:   true,
+			Store:     "file",
+			Path:      "data/memory",
+			Extractor: "rules",
+			MaxFacts:  50,
+		},
+		Knowledge: KnowledgeConfig{
+			Enabled: false,
+			Store:   "sqlite",
+			Path:    "data/knowledge.db",
+			Qdrant: KnowledgeQdrantConfig{
+				URL:        "http://localhost:6333",
+				Collection: "voice_assistant",
+			},
+			Embedding: KnowledgeEmbeddingConfig{
+				Provider:  "ollama",
+				Model:     "nomic-embed-text",
+				BaseURL:   "http://localhost:11434",
+				BatchSize: 32,
+			},
+			ChunkSize:    500,
+			ChunkOverlap: 50,
+			TopK:         3,
+			MinScore:     0.3,
+		},
+		Speaker: SpeakerConfig{
+			Enabled:      false,
+			Provider:     "sherpa",
+			ModelPath:    "./models/speaker/3dspeaker_speech_eres2net_base_sv_zh-cn_3dspeaker_16k.onnx",
+			NumThreads:   1,
+			ProfilesFile: "data/speakers.json",
+			Threshold:    0.6,
+			MinDuration:  1000,
+		},
+		Persona: PersonaConfig{
+			File: "data/personas.json",
+		},
+		Moderation: ModerationConfig{
+			Enabled:      false,
+			InputAction:  "block",
+			OutputAction: "block",
+			OpenAI: ModerationOpenAIConfig{
+				Model: "omni-moderation-latest",
+			},
+		},
+		Correction: CorrectionConfig{
+			Enabled:   true,
+			Window:    30,
+			MaxLength: 30,
+		},
+		TextNorm: TextNormConfig{
+			Enabled:       true,
+			LexiconFile:   "data/lexicon.yaml",
+			ExpandNumbers: true,
+			StripEmoji:    true,
+			StripMarkdown: true,
+			CodeBlocks:    "notice",
+			URLs:          "domain",
+		},
+		TranscriptNorm: TranscriptNormConfig{
+			Enabled:        true,
+			InverseNumbers: true,
+			Punctuation:    "rule",
+		},
+		Usage: UsageConfig{
+			Enabled: false,
+			Period:  "month",
 		},
 	}
 }
 
-// LoadConfig 加载配置
+// LoadConfig 加载配置：替换环境变量并读取密钥引用；未知的配置项（通常是拼写错误）、格式错误和检查未通过的取值都返回错误
 func LoadConfig(data []byte) (*Config, error) {
 	config := DefaultConfig()
-	if err := yaml.Unmarshal(data, config); err != nil {
+	decoder := yaml.NewDecoder(bytes.NewReader([]byte(secrets.ExpandEnv(string(data)))))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(config); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w（请检查配置项的拼写、缩进和取值格式，时长需带单位，如 30s）", err)
+	}
+	if err := config.ResolveSecrets(context.Background()); err != nil {
+		return nil, err
+	}
+	if err := config.Validate(); err != nil {
 		return nil, err
 	}
 	return config, nil
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"voice_assistant/voice_assistant_server/internal/secrets"
+)
+
+// secretTimeout 读取全部密钥的超时（Vault等远程后端）
+const secretTimeout = 10 * time.Second
+
+// secretFields 可以使用密钥引用（file:、vault: 或注册的其他后端）的配置项
+func (c *Config) secretFields() map[string]*string {
+	fields := map[string]*string{
+		"asr.openai.api_key":                                 &c.ASR.OpenAI.APIKey,
+		"llm.openai.api_key":                                 &c.LLM.OpenAI.APIKey,
+		"knowledge.embedding.api_key":                        &c.Knowledge.Embedding.APIKey,
+		"knowledge.qdrant.api_key":                           &c.Knowledge.Qdrant.APIKey,
+		"moderation.openai.api_key":                          &c.Moderation.OpenAI.APIKey,
+		"archive.s3.access_key":                              &c.Archive.S3.AccessKey,
+		"archive.s3.secret_key":                              &c.Archive.S3.SecretKey,
+		"admin.token":                                        &c.Admin.Token,
+		"mqtt.password":                                      &c.MQTT.Password,
+		"transcript_normalization.punctuation_model.api_key": &c.TranscriptNorm.PunctuationModel.APIKey,
+	}
+	for i := range c.Webhook.Endpoints {
+		fields[fmt.Sprintf("webhook.endpoints[%d].secret", i)] = &c.Webhook.Endpoints[i].Secret
+	}
+	return fields
+}
+
+// ResolveSecrets 把密钥配置项中的引用替换为实际的密钥
+func (c *Config) ResolveSecrets(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, secretTimeout)
+	defer cancel()
+
+	for key, field := range c.secretFields() {
+		if *field == "" {
+			continue
+		}
+		value, err := secrets.Resolve(ctx, *field)
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		*field = value
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// secretTags 视为密钥的配置项名称
+var secretTags = map[string]bool{
+	"api_key": true, "access_key": true, "secret_key": true,
+	"secret": true, "password": true, "token": true,
+}
+
+// collectSecretFields 递归收集配置中名称为密钥的字符串配置项
+func collectSecretFields(v reflect.Value, path string, fields map[*string]string) {
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			name := v.Type().Field(i).Tag.Get("yaml")
+			field := v.Field(i)
+			if field.Kind() == reflect.String && secretTags[name] {
+				fields[field.Addr().Interface().(*string)] = path + name
+				continue
+			}
+			collectSecretFields(field, path+name+".", fields)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			collectSecretFields(v.Index(i), path, fields)
+		}
+	}
+}
+
+func TestSecretFieldsCoverAllSecrets(t *testing.T) {
+	c := DefaultConfig()
+	c.Webhook.Endpoints = []WebhookEndpointConfig{{URL: "https://example.com"}}
+
+	covered := make(map[*string]bool)
+	for _, field := range c.secretFields() {
+		covered[field] = true
+	}
+	found := make(map[*string]string)
+	collectSecretFields(reflect.ValueOf(c).Elem(), "", found)
+	assert.NotEmpty(t, found)
+	for field, key := range found {
+		assert.True(t, covered[field], "%s 未加入 secretFields，不能使用密钥引用", key)
+	}
+}
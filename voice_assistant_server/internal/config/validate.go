@@ -0,0 +1,281 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"voice_assistant/voice_assistant_server/internal/mqtt"
+	"voice_assistant/voice_assistant_server/internal/reminder"
+	"voice_assistant/voice_assistant_server/internal/webhook"
+)
+
+// 各配置项的可选值
+var (
+	asrProviders        = []string{"funasr", "whisper", "openai"}
+	llmProviders        = []string{"ollama", "openai", "websocket"}
+	ttsProviders        = []string{"edge_tts", "edge", "sherpa", "chattts", "cosyvoice"}
+	serverModes         = []string{"development", "production"}
+	overflowPolicies    = []string{"block", "drop_oldest", "disconnect"}
+	logFormats          = []string{"text", "json"}
+	logOutputs          = []string{"stdout", "stderr", "file"}
+	pipelineFairness    = []string{"", "fifo", "round_robin", "weighted"}
+	archiveStores       = []string{"local", "s3"}
+	memoryStores        = []string{"file", "memory"}
+	memoryExtractors    = []string{"rules", "llm"}
+	knowledgeStores     = []string{"sqlite", "qdrant", "memory"}
+	embeddingProviders  = []string{"openai", "ollama"}
+	speakerProviders    = []string{"sherpa", "http"}
+	moderationProviders = []string{"", "openai"}
+	moderationActions   = []string{"block", "redact", "rephrase"}
+	computeDevices      = []string{"gpu", "cpu"}
+	computeStages       = []string{"asr", "llm", "tts"}
+)
+
+// ValidationError 配置检查发现的全部问题
+type ValidationError struct {
+	Problems []string
+}
+
+// Error 每行一个问题
+func (e *ValidationError) Error() string {
+	return "配置检查未通过:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// validator 收集配置问题
+type validator struct {
+	problems []string
+}
+
+// addf 记录一个问题
+func (v *validator) addf(format string, args ...interface{}) {
+	v.problems = append(v.problems, fmt.Sprintf(format, args...))
+}
+
+// oneOf 检查取值是否为可选值之一（不区分大小写的拼写错误给出提示）
+func (v *validator) oneOf(key, value string, options []string) {
+	for _, option := range options {
+		if value == option {
+			return
+		}
+	}
+	hint := ""
+	if suggestion := closest(value, options); suggestion != "" {
+		hint = fmt.Sprintf("，是否应为 %q？", suggestion)
+	}
+	v.addf("%s 不支持 %q（可选: %s）%s", key, value, strings.Join(nonEmpty(options), "|"), hint)
+}
+
+// required 检查必填项
+func (v *validator) required(key, value, reason string) {
+	if strings.TrimSpace(value) == "" {
+		v.addf("%s 不能为空（%s）", key, reason)
+	}
+}
+
+// Validate 检查配置：提供商名称、所选提供商需要的API密钥和地址、枚举取值、端口和时长
+func (c *Config) Validate() error {
+	v := &validator{}
+
+	if c.Server.Port <= 0 || c.Server.Port > 65535 {
+		v.addf("server.port 超出范围: %d", c.Server.Port)
+	}
+	v.oneOf("server.mode", c.Server.Mode, serverModes)
+	v.oneOf("websocket.overflow_policy", c.WebSocket.OverflowPolicy, overflowPolicies)
+	v.oneOf("pipeline.fairness", c.Pipeline.Fairness, pipelineFairness)
+	v.oneOf("logging.format", c.Logging.Format, logFormats)
+	v.oneOf("logging.output", c.Logging.Output, logOutputs)
+	if c.Logging.Output == "file" {
+		v.required("logging.file_path", c.Logging.FilePath, "logging.output 为 file")
+	}
+	if c.GRPC.Enabled && (c.GRPC.Port <= 0 || c.GRPC.Port > 65535) {
+		v.addf("grpc.port 超出范围: %d", c.GRPC.Port)
+	}
+	if (c.GRPC.TLS.CertFile == "") != (c.GRPC.TLS.KeyFile == "") {
+		v.addf("grpc.tls.cert_file 和 grpc.tls.key_file 需同时配置")
+	}
+	if c.GRPC.TLS.ClientCAFile != "" && c.GRPC.TLS.CertFile == "" {
+		v.addf("grpc.tls.client_ca_file 需要同时配置服务端证书")
+	}
+
+	// 提供商
+	v.oneOf("asr.provider", c.ASR.Provider, asrProviders)
+	if c.ASR.Provider == "openai" {
+		v.required("asr.openai.api_key", c.ASR.OpenAI.APIKey, "asr.provider 为 openai")
+	}
+	v.oneOf("llm.provider", c.LLM.Provider, llmProviders)
+	switch c.LLM.Provider {
+	case "openai":
+		v.required("llm.openai.api_key", c.LLM.OpenAI.APIKey, "llm.provider 为 openai")
+	case "ollama":
+		v.required("llm.ollama.base_url", c.LLM.Ollama.BaseURL, "llm.provider 为 ollama")
+	case "websocket":
+		v.required("llm.websocket.url", c.LLM.WebSocket.URL, "llm.provider 为 websocket")
+	}
+	v.oneOf("tts.provider", c.TTS.Provider, ttsProviders)
+	if c.TTS.Provider == "cosyvoice" {
+		v.required("tts.cosyvoice.url", c.TTS.CosyVoice.URL, "tts.provider 为 cosyvoice")
+	}
+
+	// 可选功能（只检查已启用的）
+	if c.Archive.Enabled {
+		v.oneOf("archive.store", c.Archive.Store, archiveStores)
+		if c.Archive.Store == "s3" {
+			v.required("archive.s3.bucket", c.Archive.S3.Bucket, "archive.store 为 s3")
+		}
+	}
+	if c.Webhook.Enabled {
+		if len(c.Webhook.Endpoints) == 0 {
+			v.addf("webhook.endpoints 不能为空（webhook.enabled 为 true）")
+		}
+		for i, endpoint := range c.Webhook.Endpoints {
+			if err := webhook.ValidateURL(endpoint.URL); err != nil {
+				v.addf("webhook.endpoints[%d].url: %v", i, err)
+			}
+		}
+	}
+	if c.MQTT.Enabled {
+		if err := mqtt.ValidateBroker(c.MQTT.Broker); err != nil {
+			v.addf("mqtt.broker: %v", err)
+		}
+		if c.MQTT.QoS != 0 && c.MQTT.QoS != 1 {
+			v.addf("mqtt.qos 只支持 0 或 1，当前为 %d", c.MQTT.QoS)
+		}
+	}
+	if c.Reminders.Enabled {
+		if _, err := reminder.LoadLocation(c.Reminders.Timezone); err != nil {
+			v.addf("reminders.timezone: %v", err)
+		}
+	}
+	if c.Memory.Enabled {
+		v.oneOf("memory.store", c.Memory.Store, memoryStores)
+		v.oneOf("memory.extractor", c.Memory.Extractor, memoryExtractors)
+	}
+	if c.Knowledge.Enabled {
+		v.oneOf("knowledge.store", c.Knowledge.Store, knowledgeStores)
+		v.oneOf("knowledge.embedding.provider", c.Knowledge.Embedding.Provider, embeddingProviders)
+		if c.Knowledge.Embedding.Provider == "openai" {
+			v.required("knowledge.embedding.api_key", c.Knowledge.Embedding.APIKey, "knowledge.embedding.provider 为 openai")
+		}
+		if c.Knowledge.Store == "qdrant" {
+			v.required("knowledge.qdrant.url", c.Knowledge.Qdrant.URL, "knowledge.store 为 qdrant")
+		}
+	}
+	if c.Speaker.Enabled {
+		v.oneOf("speaker.provider", c.Speaker.Provider, speakerProviders)
+		if c.Speaker.Provider == "http" {
+			v.required("speaker.url", c.Speaker.URL, "speaker.provider 为 http")
+		}
+	}
+	if c.Moderation.Enabled {
+		v.oneOf("moderation.provider", c.Moderation.Provider, moderationProviders)
+		v.oneOf("moderation.input_action", c.Moderation.InputAction, moderationActions)
+		v.oneOf("moderation.output_action", c.Moderation.OutputAction, moderationActions)
+		if c.Moderation.Provider == "openai" {
+			v.required("moderation.openai.api_key", c.Moderation.OpenAI.APIKey, "moderation.provider 为 openai")
+		}
+	}
+	if c.Compute.Enabled {
+		for stage, worker := range c.Compute.Workers {
+			v.oneOf("compute.workers 的阶段", stage, computeStages)
+			v.oneOf(fmt.Sprintf("compute.workers.%s.device", stage), worker.Device, computeDevices)
+		}
+	}
+	if c.Endpointing.MaxSilence > 0 && c.Endpointing.MinSilence > c.Endpointing.MaxSilence {
+		v.addf("endpointing.min_silence (%v) 大于 max_silence (%v)", c.Endpointing.MinSilence, c.Endpointing.MaxSilence)
+	}
+
+	validateDurations(v, reflect.ValueOf(c).Elem(), "")
+
+	if len(v.problems) == 0 {
+		return nil
+	}
+	sort.Strings(v.problems)
+	return &ValidationError{Problems: v.problems}
+}
+
+// validateDurations 检查所有时长配置：不能为负数；小于1毫秒的通常是漏写了单位（如把 30s 写成 30）
+func validateDurations(v *validator, value reflect.Value, prefix string) {
+	durationType := reflect.TypeOf(time.Duration(0))
+	switch value.Kind() {
+	case reflect.Struct:
+		for i := 0; i < value.NumField(); i++ {
+			field := value.Type().Field(i)
+			name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+			if name == "" || name == "-" {
+				continue
+			}
+			key := name
+			if prefix != "" {
+				key = prefix + "." + name
+			}
+			validateDurations(v, value.Field(i), key)
+		}
+	case reflect.Map:
+		keys := value.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+		for _, key := range keys {
+			validateDurations(v, value.MapIndex(key), fmt.Sprintf("%s.%v", prefix, key))
+		}
+	case reflect.Int64:
+		if value.Type() != durationType {
+			return
+		}
+		d := time.Duration(value.Int())
+		switch {
+		case d < 0:
+			v.addf("%s 不能为负数: %v", prefix, d)
+		case d > 0 && d < time.Millisecond:
+			v.addf("%s 为 %v，是否漏写了单位（如 30s、500ms）？", prefix, d)
+		}
+	}
+}
+
+// closest 与取值最接近的可选值（编辑距离不超过2，否则返回空）
+func closest(value string, options []string) string {
+	best, bestDistance := "", 3
+	for _, option := range options {
+		if option == "" {
+			continue
+		}
+		if d := editDistance(strings.ToLower(value), option); d < bestDistance {
+			best, bestDistance = option, d
+		}
+	}
+	return best
+}
+
+// editDistance 两个字符串的编辑距离
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(rb)]
+}
+
+// nonEmpty 去掉空的可选值（用于提示）
+func nonEmpty(options []string) []string {
+	var result []string
+	for _, option := range options {
+		if option != "" {
+			result = append(result, option)
+		}
+	}
+	return result
+}
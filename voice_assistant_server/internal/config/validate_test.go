@@ -0,0 +1,76 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfigShippedFile(t *testing.T) {
+	data, err := os.ReadFile("../../config/server.yaml")
+	require.NoError(t, err)
+	_, err = LoadConfig(data)
+	assert.NoError(t, err)
+}
+
+func TestLoadConfigUnknownField(t *testing.T) {
+	_, err := LoadConfig([]byte("pipeline:\n  asr_worker: 3\n"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "asr_worker")
+
+	_, err = LoadConfig([]byte("websocket:\n  ping_period: 30\n"))
+	assert.Error(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	_, err := LoadConfig([]byte(`
+server:
+  port: 70000
+asr:
+  provider: whsiper
+llm:
+  provider: openai
+tts:
+  provider: edge_tts
+websocket:
+  ping_period: 30ns
+endpointing:
+  min_silence: 2s
+  max_silence: 1s
+`))
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []string{
+		`asr.provider 不支持 "whsiper"（可选: funasr|whisper|openai），是否应为 "whisper"？`,
+		"endpointing.min_silence (2s) 大于 max_silence (1s)",
+		"llm.openai.api_key 不能为空（llm.provider 为 openai）",
+		"server.port 超出范围: 70000",
+		"websocket.ping_period 为 30ns，是否漏写了单位（如 30s、500ms）？",
+	}, validationErr.Problems)
+
+	// 未启用的功能不检查
+	config := DefaultConfig()
+	config.LLM.OpenAI.APIKey = "sk-test"
+	config.Knowledge.Store = "redis"
+	assert.NoError(t, config.Validate())
+	config.Knowledge.Enabled = true
+	assert.Error(t, config.Validate())
+}
+
+func TestLoadConfigSecrets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "admin_token")
+	require.NoError(t, os.WriteFile(path, []byte("token-from-file\n"), 0600))
+	t.Setenv("VA_TEST_OPENAI_KEY", "sk-env")
+
+	config, err := LoadConfig([]byte("llm:\n  provider: openai\n  openai:\n    api_key: \"${VA_TEST_OPENAI_KEY}\"\nadmin:\n  token: \"file:" + path + "\"\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "sk-env", config.LLM.OpenAI.APIKey)
+	assert.Equal(t, "token-from-file", config.Admin.Token)
+
+	// 密钥读取失败时指出配置项
+	_, err = LoadConfig([]byte("admin:\n  token: \"file:" + path + ".missing\"\nllm:\n  openai:\n    api_key: sk\n"))
+	assert.ErrorContains(t, err, "admin.token")
+}
@@ -0,0 +1,86 @@
+package dataset
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigAllows(t *testing.T) {
+	disabled := Config{Enabled: false}
+	assert.False(t, disabled.Allows(ConsentGranted))
+
+	optOut := Config{Enabled: true}
+	assert.True(t, optOut.Allows(ConsentUnset))
+	assert.True(t, optOut.Allows(ConsentGranted))
+	assert.False(t, optOut.Allows(ConsentDenied))
+
+	optIn := Config{Enabled: true, RequireConsent: true}
+	assert.False(t, optIn.Allows(ConsentUnset))
+	assert.True(t, optIn.Allows(ConsentGranted))
+	assert.False(t, optIn.Allows(ConsentDenied))
+}
+
+func TestRedactor(t *testing.T) {
+	r := DefaultRedactor()
+
+	cases := map[string]string{
+		"我的邮箱是 zhang.san@example.com":     "我的邮箱是 [EMAIL]",
+		"电话13812345678，有事打给我":             "电话[PHONE]，有事打给我",
+		"call +86 13812345678 now":        "call [PHONE] now",
+		"身份证号11010519491231002X":          "身份证号[ID_NUMBER]",
+		"card 4111 1111 1111 1111 please": "card [CARD_NUMBER] please",
+		"key sk-abcdefghijklmnopqrstuvwx": "key [SECRET]",
+		"今天天气怎么样":                         "今天天气怎么样",
+	}
+
+	for input, expected := range cases {
+		assert.Equal(t, expected, r.Redact(input), input)
+	}
+}
+
+func TestJSONLSinkWithRedaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "samples.jsonl")
+
+	sink, err := CreateSink(Config{Enabled: true, Sink: "jsonl", Path: path, Redact: true})
+	require.NoError(t, err)
+
+	record := NewRecord("你是语音助手", "给 test@example.com 发邮件", "好的")
+	record.Metadata.Consent = true
+	require.NoError(t, sink.Write(record))
+	require.NoError(t, sink.Write(NewRecord("", "你好", "你好！")))
+	require.NoError(t, sink.Close())
+	assert.ErrorIs(t, sink.Write(record), ErrSinkClosed)
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var rec Record
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &rec))
+		records = append(records, rec)
+	}
+	require.Len(t, records, 2)
+
+	assert.Equal(t, []Message{
+		{Role: "system", Content: "你是语音助手"},
+		{Role: "user", Content: "给 [EMAIL] 发邮件"},
+		{Role: "assistant", Content: "好的"},
+	}, records[0].Messages)
+	assert.True(t, records[0].Metadata.Redacted)
+	assert.True(t, records[0].Metadata.Consent)
+	assert.Len(t, records[1].Messages, 2)
+}
+
+func TestCreateSinkUnsupported(t *testing.T) {
+	_, err := CreateSink(Config{Sink: "unknown"})
+	assert.ErrorIs(t, err, ErrUnsupportedSinkType)
+}
@@ -0,0 +1,122 @@
+package dataset
+
+import (
+	"errors"
+	"time"
+)
+
+// 数据采集相关错误定义
+var (
+	ErrUnsupportedSinkType = errors.New("unsupported dataset sink type")
+	ErrSinkClosed          = errors.New("dataset sink closed")
+)
+
+// Sink 数据采集输出接口
+type Sink interface {
+	// Write 写入一条对话样本
+	Write(record Record) error
+
+	// Close 关闭输出
+	Close() error
+}
+
+// Config 数据采集配置
+type Config struct {
+	Enabled             bool   `yaml:"enabled"`               // 是否启用（默认关闭）
+	Sink                string `yaml:"sink"`                  // 输出类型: jsonl
+	Path                string `yaml:"path"`                  // 输出文件路径
+	RequireConsent      bool   `yaml:"require_consent"`       // 仅记录明确同意的会话
+	Redact              bool   `yaml:"redact"`                // 写入前脱敏
+	IncludeSystemPrompt bool   `yaml:"include_system_prompt"` // 样本中包含系统提示
+}
+
+// Consent 会话数据采集授权状态
+type Consent int
+
+const (
+	ConsentUnset   Consent = iota // 未表态
+	ConsentGranted                // 同意采集
+	ConsentDenied                 // 拒绝采集（退出）
+)
+
+// Allows 判断在当前配置下是否允许记录该会话
+// 明确拒绝的会话永远不记录；要求授权时仅记录明确同意的会话。
+func (c Config) Allows(consent Consent) bool {
+	if !c.Enabled || consent == ConsentDenied {
+		return false
+	}
+	if c.RequireConsent {
+		return consent == ConsentGranted
+	}
+	return true
+}
+
+// Message 样本消息（与微调数据集的chat格式一致）
+type Message struct {
+	Role    string `json:"role"`    // system|user|assistant
+	Content string `json:"content"` // 消息内容
+}
+
+// Record 对话样本
+type Record struct {
+	Messages []Message `json:"messages"` // 消息列表
+	Metadata Metadata  `json:"metadata"` // 元数据
+}
+
+// Metadata 样本元数据
+type Metadata struct {
+	SessionKey string `json:"session_key"`        // 会话标识（哈希，不可还原）
+	Language   string `json:"language,omitempty"` // 会话语言
+	Model      string `json:"model,omitempty"`    // 生成回复的模型
+	Consent    bool   `json:"consent"`            // 是否明确同意采集
+	Redacted   bool   `json:"redacted"`           // 是否已脱敏
+	Timestamp  int64  `json:"timestamp"`          // 时间戳（毫秒）
+}
+
+// NewRecord 创建单轮对话样本
+func NewRecord(systemPrompt, prompt, response string) Record {
+	messages := make([]Message, 0, 3)
+	if systemPrompt != "" {
+		messages = append(messages, Message{Role: "system", Content: systemPrompt})
+	}
+	messages = append(messages,
+		Message{Role: "user", Content: prompt},
+		Message{Role: "assistant", Content: response},
+	)
+
+	return Record{
+		Messages: messages,
+		Metadata: Metadata{
+			Timestamp: time.Now().UnixMilli(),
+		},
+	}
+}
+
+// SinkFactory 输出工厂函数类型
+type SinkFactory func(config Config) (Sink, error)
+
+// 注册的输出实现
+var sinkFactories = make(map[string]SinkFactory)
+
+// RegisterSink 注册输出实现
+func RegisterSink(name string, factory SinkFactory) {
+	sinkFactories[name] = factory
+}
+
+// CreateSink 创建输出（启用脱敏时自动包装）
+func CreateSink(config Config) (Sink, error) {
+	factory, exists := sinkFactories[config.Sink]
+	if !exists {
+		return nil, ErrUnsupportedSinkType
+	}
+
+	sink, err := factory(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.Redact {
+		sink = NewRedactingSink(sink, DefaultRedactor())
+	}
+	return sink, nil
+}
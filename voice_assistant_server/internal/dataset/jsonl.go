@@ -0,0 +1,85 @@
+package dataset
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// JSONLSink 以JSON Lines格式追加写入文件
+type JSONLSink struct {
+	file   *os.File
+	writer *bufio.Writer
+	mu     sync.Mutex
+	closed bool
+}
+
+// NewJSONLSink 创建JSONL输出
+func NewJSONLSink(config Config) (*JSONLSink, error) {
+	path := config.Path
+	if path == "" {
+		path = "data/finetune.jsonl"
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("创建数据目录失败: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("打开数据文件失败: %w", err)
+	}
+
+	return &JSONLSink{
+		file:   file,
+		writer: bufio.NewWriter(file),
+	}, nil
+}
+
+// Write 写入一行样本
+func (s *JSONLSink) Write(record Record) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("序列化样本失败: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrSinkClosed
+	}
+
+	if _, err := s.writer.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	// 每条样本立即落盘，进程异常退出时不丢失已确认的数据
+	return s.writer.Flush()
+}
+
+// Close 关闭文件
+func (s *JSONLSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	if err := s.writer.Flush(); err != nil {
+		s.file.Close()
+		return err
+	}
+	return s.file.Close()
+}
+
+// 注册JSONL输出
+func init() {
+	RegisterSink("jsonl", func(config Config) (Sink, error) {
+		return NewJSONLSink(config)
+	})
+}
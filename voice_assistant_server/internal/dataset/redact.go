@@ -0,0 +1,70 @@
+package dataset
+
+import "regexp"
+
+// redactionRule 脱敏规则
+type redactionRule struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// Redactor 文本脱敏器
+type Redactor struct {
+	rules []redactionRule
+}
+
+// DefaultRedactor 创建默认脱敏器（邮箱、API密钥、身份证号、银行卡号、电话号码）
+func DefaultRedactor() *Redactor {
+	return &Redactor{
+		rules: []redactionRule{
+			{regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`), "[EMAIL]"},
+			{regexp.MustCompile(`\b(?:sk|pk|api|key|token)[-_][A-Za-z0-9\-_]{16,}\b`), "[SECRET]"},
+			{regexp.MustCompile(`\b\d{17}[\dXx]\b`), "[ID_NUMBER]"},
+			{regexp.MustCompile(`(?:\+?86[ \-]?)?\b1[3-9]\d{9}\b`), "[PHONE]"},
+			{regexp.MustCompile(`\b\d(?:[ \-]?\d){12,18}\b`), "[CARD_NUMBER]"},
+			{regexp.MustCompile(`\+?\d{1,3}[ \-]?\(?\d{3}\)?[ \-]?\d{3}[ \-]?\d{4}\b`), "[PHONE]"},
+		},
+	}
+}
+
+// Redact 对文本脱敏
+func (r *Redactor) Redact(text string) string {
+	for _, rule := range r.rules {
+		text = rule.pattern.ReplaceAllString(text, rule.replacement)
+	}
+	return text
+}
+
+// redactingSink 写入前脱敏的输出包装
+type redactingSink struct {
+	inner    Sink
+	redactor *Redactor
+}
+
+// NewRedactingSink 创建脱敏输出包装
+func NewRedactingSink(inner Sink, redactor *Redactor) Sink {
+	return &redactingSink{
+		inner:    inner,
+		redactor: redactor,
+	}
+}
+
+// Write 脱敏后写入
+func (s *redactingSink) Write(record Record) error {
+	messages := make([]Message, len(record.Messages))
+	for i, msg := range record.Messages {
+		messages[i] = Message{
+			Role:    msg.Role,
+			Content: s.redactor.Redact(msg.Content),
+		}
+	}
+	record.Messages = messages
+	record.Metadata.Redacted = true
+
+	return s.inner.Write(record)
+}
+
+// Close 关闭输出
+func (s *redactingSink) Close() error {
+	return s.inner.Close()
+}
@@ -0,0 +1,169 @@
+// Package errs ASR、LLM、TTS服务共用的错误分类：每类错误对应协议错误代码、是否可重试和面向用户的说明
+package errs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"voice_assistant/pkg/protocol"
+)
+
+// Kind 错误类别
+type Kind struct {
+	Code      string // 协议错误代码（protocol.Err*）
+	Retryable bool   // 稍后重试可能成功
+	Message   string // 面向用户的说明
+}
+
+// 错误类别
+var (
+	KindUnavailable   = Kind{protocol.ErrServiceUnavailable, true, "服务暂时不可用，请稍后再试"}
+	KindBusy          = Kind{protocol.ErrServerBusy, true, "服务繁忙，请稍后再试"}
+	KindTimeout       = Kind{protocol.ErrTimeout, true, "处理超时，请稍后再试"}
+	KindRateLimited   = Kind{protocol.ErrRateLimitExceeded, true, "请求过于频繁，请稍后再试"}
+	KindQuota         = Kind{protocol.ErrProviderQuotaExceeded, false, "服务额度已用尽"}
+	KindAuth          = Kind{protocol.ErrProviderAuthFailed, false, "服务认证失败，请检查API密钥"}
+	KindMisconfigured = Kind{protocol.ErrProviderMisconfigured, false, "服务配置错误"}
+	KindUnsupported   = Kind{protocol.ErrUnsupportedLanguage, false, "不支持该语言"}
+	KindInvalidInput  = Kind{protocol.ErrInvalidInput, false, "请求内容无效"}
+)
+
+// 各服务共用的错误（asr、llm、tts包中的同名错误即为这些值）
+var (
+	ErrConnectionFailed     = New(KindUnavailable, "failed to connect to service")
+	ErrServiceUnavailable   = New(KindUnavailable, "service unavailable")
+	ErrTimeout              = New(KindTimeout, "processing timeout")
+	ErrAPIKeyMissing        = New(KindAuth, "API key is missing")
+	ErrAPIKeyInvalid        = New(KindAuth, "API key is invalid")
+	ErrRateLimitExceeded    = New(KindRateLimited, "rate limit exceeded")
+	ErrQuotaExceeded        = New(KindQuota, "quota exceeded")
+	ErrModelNotFound        = New(KindMisconfigured, "model not found")
+	ErrModelLoadFailed      = New(KindMisconfigured, "failed to load model")
+	ErrInvalidConfig        = New(KindMisconfigured, "invalid configuration")
+	ErrInsufficientMemory   = New(KindMisconfigured, "insufficient memory for model")
+	ErrGPUNotAvailable      = New(KindMisconfigured, "GPU not available")
+	ErrLanguageNotSupported = New(KindUnsupported, "language not supported")
+)
+
+// sentinel 带错误类别的哨兵错误
+type sentinel struct {
+	kind Kind
+	text string
+}
+
+func (s *sentinel) Error() string { return s.text }
+
+// New 创建带错误类别的哨兵错误（用errors.Is比较）
+func New(kind Kind, text string) error {
+	return &sentinel{kind: kind, text: text}
+}
+
+// KindOf 错误链上的错误类别（未分类的错误返回false；上下文超时和网络超时视为超时）
+func KindOf(err error) (Kind, bool) {
+	var timeout interface{ Timeout() bool }
+	if errors.Is(err, context.DeadlineExceeded) || errors.As(err, &timeout) && timeout.Timeout() {
+		return KindTimeout, true
+	}
+	var s *sentinel
+	if errors.As(err, &s) {
+		return s.kind, true
+	}
+	return Kind{}, false
+}
+
+// Error 带协议错误代码的处理错误，处理器据此向客户端发送错误并记录统计
+type Error struct {
+	Kind
+	Stage    string // 处理阶段: asr|llm|tts
+	Provider string // 服务提供方
+	Err      error  // 原始错误
+}
+
+func (e *Error) Error() string {
+	if e.Provider != "" {
+		return fmt.Sprintf("%s(%s): %v", e.Stage, e.Provider, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", e.Stage, e.Err)
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// stageFailures 未分类错误按处理阶段使用的错误代码和说明
+var stageFailures = map[string]Kind{
+	"asr": {protocol.ErrASRFailed, true, "语音识别失败"},
+	"llm": {protocol.ErrLLMFailed, true, "文本生成失败"},
+	"tts": {protocol.ErrTTSFailed, true, "语音合成失败"},
+}
+
+// Wrap 把服务返回的错误包装为Error（已是Error时补上缺少的阶段和提供方）；未分类的错误使用该阶段的失败代码
+func Wrap(err error, stage, provider string) *Error {
+	if err == nil {
+		return nil
+	}
+
+	var wrapped *Error
+	if errors.As(err, &wrapped) {
+		e := *wrapped
+		if e.Stage == "" {
+			e.Stage = stage
+		}
+		if e.Provider == "" {
+			e.Provider = provider
+		}
+		return &e
+	}
+
+	kind, ok := KindOf(err)
+	if !ok {
+		kind, ok = stageFailures[stage]
+		if !ok {
+			kind = Kind{protocol.ErrInternalError, false, "处理失败"}
+		}
+	}
+	return &Error{Kind: kind, Stage: stage, Provider: provider, Err: err}
+}
+
+// FromHTTPStatus 按服务返回的HTTP状态码分类错误（detail为响应内容摘要）
+func FromHTTPStatus(status int, detail string) error {
+	var base error
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		base = ErrAPIKeyInvalid
+	case status == http.StatusTooManyRequests:
+		base = ErrRateLimitExceeded
+	case status == http.StatusPaymentRequired:
+		base = ErrQuotaExceeded
+	case status == http.StatusNotFound:
+		base = ErrModelNotFound
+	case status == http.StatusRequestTimeout || status == http.StatusGatewayTimeout:
+		base = ErrTimeout
+	case status >= http.StatusInternalServerError:
+		base = ErrServiceUnavailable
+	default:
+		return fmt.Errorf("HTTP %d: %s", status, detail)
+	}
+	if detail == "" {
+		return fmt.Errorf("%w: HTTP %d", base, status)
+	}
+	return fmt.Errorf("%w: HTTP %d: %s", base, status, detail)
+}
+
+// HTTPStatus 错误代码对应的HTTP状态码（REST接口使用）
+func HTTPStatus(code string) int {
+	switch code {
+	case protocol.ErrServiceUnavailable, protocol.ErrServerBusy:
+		return http.StatusServiceUnavailable
+	case protocol.ErrTimeout:
+		return http.StatusGatewayTimeout
+	case protocol.ErrRateLimitExceeded:
+		return http.StatusTooManyRequests
+	case protocol.ErrUnsupportedLanguage, protocol.ErrInvalidInput:
+		return http.StatusBadRequest
+	case protocol.ErrProviderAuthFailed, protocol.ErrProviderQuotaExceeded, protocol.ErrProviderMisconfigured:
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
+}
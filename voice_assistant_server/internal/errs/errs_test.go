@@ -0,0 +1,60 @@
+package errs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"voice_assistant/pkg/protocol"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrap(t *testing.T) {
+	// 包装后的哨兵错误按类别对应错误代码
+	e := Wrap(fmt.Errorf("请求失败: %w", ErrRateLimitExceeded), "llm", "openai")
+	assert.Equal(t, protocol.ErrRateLimitExceeded, e.Code)
+	assert.True(t, e.Retryable)
+	assert.Equal(t, "llm", e.Stage)
+	assert.Equal(t, "openai", e.Provider)
+	assert.ErrorIs(t, e, ErrRateLimitExceeded)
+
+	// 未分类的错误使用阶段的失败代码
+	e = Wrap(errors.New("boom"), "tts", "edge")
+	assert.Equal(t, protocol.ErrTTSFailed, e.Code)
+
+	// 超时
+	e = Wrap(fmt.Errorf("%w: %w", ErrConnectionFailed, context.DeadlineExceeded), "asr", "")
+	assert.Equal(t, protocol.ErrTimeout, e.Code)
+
+	// 已包装的错误只补上缺少的字段
+	again := Wrap(fmt.Errorf("重试失败: %w", e), "llm", "ollama")
+	assert.Equal(t, "asr", again.Stage)
+	assert.Equal(t, "ollama", again.Provider)
+
+	assert.Nil(t, Wrap(nil, "asr", ""))
+}
+
+func TestFromHTTPStatus(t *testing.T) {
+	tests := []struct {
+		status int
+		code   string
+	}{
+		{http.StatusUnauthorized, protocol.ErrProviderAuthFailed},
+		{http.StatusTooManyRequests, protocol.ErrRateLimitExceeded},
+		{http.StatusPaymentRequired, protocol.ErrProviderQuotaExceeded},
+		{http.StatusNotFound, protocol.ErrProviderMisconfigured},
+		{http.StatusBadGateway, protocol.ErrServiceUnavailable},
+		{http.StatusBadRequest, protocol.ErrLLMFailed},
+	}
+	for _, tt := range tests {
+		err := FromHTTPStatus(tt.status, "detail")
+		require.Error(t, err)
+		assert.Equal(t, tt.code, Wrap(err, "llm", "").Code, "HTTP %d", tt.status)
+	}
+	assert.Equal(t, http.StatusTooManyRequests, HTTPStatus(protocol.ErrRateLimitExceeded))
+	assert.Equal(t, http.StatusServiceUnavailable, HTTPStatus(protocol.ErrServerBusy))
+}
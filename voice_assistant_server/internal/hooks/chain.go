@@ -0,0 +1,132 @@
+package hooks
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"time"
+)
+
+// entry 已创建的钩子
+type entry struct {
+	name    string
+	hook    Hook
+	stages  map[Stage]bool // 为nil时全部位置
+	timeout time.Duration
+}
+
+// Chain 按配置顺序执行的钩子链
+type Chain struct {
+	entries []entry
+}
+
+// NewChain 按配置创建钩子链
+func NewChain(config Config) (*Chain, error) {
+	chain := &Chain{}
+	for i, hc := range config.Hooks {
+		name := hc.Name
+		if name == "" {
+			name = fmt.Sprintf("%s#%d", hc.Type, i)
+		}
+		if hc.Timeout <= 0 {
+			hc.Timeout = defaultTimeout
+		}
+
+		var stages map[Stage]bool
+		if len(hc.Stages) > 0 {
+			stages = make(map[Stage]bool, len(hc.Stages))
+			for _, stage := range hc.Stages {
+				if !ValidStage(stage) {
+					return nil, fmt.Errorf("钩子 %s: %w: %s", name, ErrInvalidStage, stage)
+				}
+				stages[stage] = true
+			}
+		}
+
+		hook, err := CreateHook(hc)
+		if err != nil {
+			return nil, fmt.Errorf("创建钩子 %s 失败: %w", name, err)
+		}
+		chain.entries = append(chain.entries, entry{name: name, hook: hook, stages: stages, timeout: hc.Timeout})
+	}
+	return chain, nil
+}
+
+// Use 在链尾追加钩子（stages为空时全部位置生效）
+func (c *Chain) Use(name string, hook Hook, stages ...Stage) {
+	var set map[Stage]bool
+	if len(stages) > 0 {
+		set = make(map[Stage]bool, len(stages))
+		for _, stage := range stages {
+			set[stage] = true
+		}
+	}
+	c.entries = append(c.entries, entry{name: name, hook: hook, stages: set, timeout: defaultTimeout})
+}
+
+// Len 钩子数
+func (c *Chain) Len() int {
+	return len(c.entries)
+}
+
+// Close 关闭实现了 io.Closer 的钩子（如持有连接或子进程的自定义钩子），返回遇到的第一个错误
+func (c *Chain) Close() error {
+	var first error
+	for _, e := range c.entries {
+		closer, ok := e.hook.(io.Closer)
+		if !ok {
+			continue
+		}
+		if err := closer.Close(); err != nil {
+			log.Printf("关闭钩子失败: %s, %v", e.name, err)
+			if first == nil {
+				first = err
+			}
+		}
+	}
+	return first
+}
+
+// Run 在指定位置依次执行钩子，返回处理后的数据
+// 钩子出错或超时时记录日志并忽略它的修改（不影响对话）；某个钩子设置 Abort 后不再执行后续钩子。
+func (c *Chain) Run(ctx context.Context, stage Stage, payload Payload) Payload {
+	payload.Stage = stage
+	for _, e := range c.entries {
+		if e.stages != nil && !e.stages[stage] {
+			continue
+		}
+
+		result := payload.clone()
+		hookCtx, cancel := context.WithTimeout(ctx, e.timeout)
+		err := e.hook.Handle(hookCtx, &result)
+		cancel()
+		if err != nil {
+			log.Printf("钩子执行失败: %s, 阶段: %s, %v", e.name, stage, err)
+			continue
+		}
+
+		result.Stage = stage
+		payload = result
+		if payload.Abort {
+			log.Printf("钩子中止本轮处理: %s, 阶段: %s, 会话: %s", e.name, stage, payload.SessionID)
+			break
+		}
+	}
+	return payload
+}
+
+// clone 复制数据（钩子失败时原数据不受影响）
+func (p Payload) clone() Payload {
+	if p.Audio != nil {
+		p.Audio = append([]byte(nil), p.Audio...)
+	}
+	if p.Metadata != nil {
+		metadata := make(map[string]interface{}, len(p.Metadata))
+		for k, v := range p.Metadata {
+			metadata[k] = v
+		}
+		p.Metadata = metadata
+	}
+	return p
+}
@@ -0,0 +1,67 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// execHook 外部命令钩子
+// 数据以JSON写入命令的标准输入，命令把修改后的JSON写到标准输出（输出为空时不做修改）；退出码非0视为失败。
+// 音频字段按JSON惯例使用base64编码。
+type execHook struct {
+	command string
+	args    []string
+}
+
+// NewExecHook 创建外部命令钩子
+func NewExecHook(config HookConfig) (Hook, error) {
+	if config.Command == "" {
+		return nil, errors.New("exec钩子缺少 command")
+	}
+	return &execHook{command: config.Command, args: config.Args}, nil
+}
+
+// Handle 执行命令并读取修改后的数据
+func (h *execHook) Handle(ctx context.Context, payload *Payload) error {
+	input, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, h.command, h.args...)
+	cmd.Stdin = bytes.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// 超时后命令启动的子进程可能仍占用输出管道，不再等待
+	cmd.WaitDelay = time.Second
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+
+	output := bytes.TrimSpace(stdout.Bytes())
+	if len(output) == 0 {
+		return nil
+	}
+	var result Payload
+	if err := json.Unmarshal(output, &result); err != nil {
+		return fmt.Errorf("解析钩子输出失败: %w", err)
+	}
+	// 会话信息以服务端为准
+	result.SessionID, result.UserID = payload.SessionID, payload.UserID
+	*payload = result
+	return nil
+}
+
+func init() {
+	RegisterHook("exec", NewExecHook)
+}
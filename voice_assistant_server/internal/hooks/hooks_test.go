@@ -0,0 +1,103 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainRun(t *testing.T) {
+	chain := &Chain{}
+	chain.Use("upper", HookFunc(func(ctx context.Context, payload *Payload) error {
+		payload.Text = strings.ToUpper(payload.Text)
+		return nil
+	}), StagePostASR)
+	chain.Use("broken", HookFunc(func(ctx context.Context, payload *Payload) error {
+		payload.Text = "ignored"
+		return errors.New("boom")
+	}))
+	chain.Use("block", HookFunc(func(ctx context.Context, payload *Payload) error {
+		if strings.Contains(payload.Text, "SECRET") {
+			payload.Abort, payload.Reply = true, "不能说"
+		}
+		return nil
+	}))
+	chain.Use("never", HookFunc(func(ctx context.Context, payload *Payload) error {
+		payload.Text = "never"
+		return nil
+	}))
+
+	// 失败的钩子不影响数据，只在配置的位置执行
+	result := chain.Run(context.Background(), StagePreLLM, Payload{Text: "hello"})
+	assert.Equal(t, "never", result.Text)
+	assert.Equal(t, StagePreLLM, result.Stage)
+
+	result = chain.Run(context.Background(), StagePostASR, Payload{Text: "hello"})
+	assert.Equal(t, "never", result.Text)
+
+	// 中止后不再执行后续钩子
+	result = chain.Run(context.Background(), StagePostASR, Payload{Text: "a secret"})
+	assert.True(t, result.Abort)
+	assert.Equal(t, "不能说", result.Reply)
+	assert.Equal(t, "A SECRET", result.Text)
+}
+
+// closingHook 记录是否已关闭的钩子
+type closingHook struct {
+	HookFunc
+	closed bool
+}
+
+func (h *closingHook) Close() error {
+	h.closed = true
+	return nil
+}
+
+func TestChainClose(t *testing.T) {
+	hook := &closingHook{HookFunc: func(ctx context.Context, payload *Payload) error { return nil }}
+	chain := &Chain{}
+	chain.Use("plain", HookFunc(func(ctx context.Context, payload *Payload) error { return nil }))
+	chain.Use("closing", hook)
+
+	require.NoError(t, chain.Close())
+	assert.True(t, hook.closed)
+}
+
+func TestNewChainRejectsInvalidStage(t *testing.T) {
+	_, err := NewChain(Config{Hooks: []HookConfig{{Type: "exec", Command: "cat", Stages: []Stage{"before_everything"}}}})
+	assert.ErrorIs(t, err, ErrInvalidStage)
+
+	_, err = NewChain(Config{Hooks: []HookConfig{{Type: "lua"}}})
+	assert.ErrorIs(t, err, ErrUnsupportedHookType)
+}
+
+func TestExecHook(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "hook.sh")
+	require.NoError(t, os.WriteFile(script, []byte("#!/bin/sh\nsed 's/天气/weather/'\n"), 0755))
+	silent := filepath.Join(dir, "silent.sh")
+	require.NoError(t, os.WriteFile(silent, []byte("#!/bin/sh\ncat >/dev/null\n"), 0755))
+	slow := filepath.Join(dir, "slow.sh")
+	require.NoError(t, os.WriteFile(slow, []byte("#!/bin/sh\nsleep 5\n"), 0755))
+
+	chain, err := NewChain(Config{Hooks: []HookConfig{
+		{Name: "rewrite", Type: "exec", Command: script},
+		{Name: "silent", Type: "exec", Command: silent},
+		{Name: "slow", Type: "exec", Command: slow, Timeout: 100 * time.Millisecond},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, 3, chain.Len())
+
+	start := time.Now()
+	result := chain.Run(context.Background(), StagePostASR, Payload{SessionID: "s1", Text: "今天天气怎么样"})
+	assert.Equal(t, "今天weather怎么样", result.Text)
+	assert.Equal(t, "s1", result.SessionID)
+	assert.Less(t, time.Since(start), 3*time.Second)
+}
@@ -0,0 +1,119 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+)
+
+// 钩子相关错误定义
+var (
+	ErrUnsupportedHookType = errors.New("unsupported hook type")
+	ErrInvalidStage        = errors.New("invalid hook stage")
+)
+
+// Stage 钩子在处理流程中的位置
+type Stage string
+
+const (
+	StagePreASR  Stage = "pre_asr"  // 整句音频识别前（可替换音频）
+	StagePostASR Stage = "post_asr" // 识别完成后（可改写识别文本）
+	StagePreLLM  Stage = "pre_llm"  // 交给LLM前（可改写输入，或直接给出回复）
+	StagePostLLM Stage = "post_llm" // LLM回复后（可改写回复）
+	StagePreTTS  Stage = "pre_tts"  // 语音合成前（可改写要播放的文本）
+)
+
+// Stages 全部钩子位置（按处理顺序）
+var Stages = []Stage{StagePreASR, StagePostASR, StagePreLLM, StagePostLLM, StagePreTTS}
+
+// 钩子默认值
+const (
+	defaultTimeout = 5 * time.Second
+)
+
+// Config 处理流程钩子配置
+type Config struct {
+	Enabled bool         `yaml:"enabled"`
+	Hooks   []HookConfig `yaml:"hooks"` // 按配置顺序依次执行
+}
+
+// HookConfig 单个钩子配置
+type HookConfig struct {
+	Name    string                 `yaml:"name"`    // 名称（用于日志）
+	Type    string                 `yaml:"type"`    // 实现类型: exec|plugin，或代码中注册的类型
+	Stages  []Stage                `yaml:"stages"`  // 生效位置（为空时全部位置）
+	Timeout time.Duration          `yaml:"timeout"` // 单次执行超时
+	Command string                 `yaml:"command"` // exec: 可执行文件
+	Args    []string               `yaml:"args"`    // exec: 命令参数
+	Path    string                 `yaml:"path"`    // plugin: Go插件（.so）路径
+	Options map[string]interface{} `yaml:"options"` // 传给钩子实现的自定义参数
+}
+
+// Payload 钩子处理的数据，钩子直接修改字段
+// Abort 为true时本轮不再继续处理：Reply 不为空时把它作为回复播放，为空时静默结束本轮。
+type Payload struct {
+	Stage     Stage                  `json:"stage"`
+	SessionID string                 `json:"session_id"`
+	UserID    string                 `json:"user_id,omitempty"`
+	Language  string                 `json:"language,omitempty"`
+	Audio     []byte                 `json:"audio,omitempty"` // pre_asr: 整句PCM音频
+	Text      string                 `json:"text,omitempty"`  // 识别文本、LLM输入、回复或要合成的文本
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+	Abort     bool                   `json:"abort,omitempty"`
+	Reply     string                 `json:"reply,omitempty"`
+}
+
+// Hook 处理流程钩子接口
+type Hook interface {
+	// Handle 处理数据（返回错误时忽略本钩子的修改，继续执行后续钩子）
+	Handle(ctx context.Context, payload *Payload) error
+}
+
+// HookFunc 函数形式的钩子
+type HookFunc func(ctx context.Context, payload *Payload) error
+
+// Handle 调用函数本身
+func (f HookFunc) Handle(ctx context.Context, payload *Payload) error {
+	return f(ctx, payload)
+}
+
+// HookFactory 钩子工厂函数类型
+type HookFactory func(config HookConfig) (Hook, error)
+
+// 注册的钩子实现
+var hookFactories = make(map[string]HookFactory)
+
+// RegisterHook 注册钩子实现（编译进服务端的第三方代码在init中注册）
+func RegisterHook(name string, factory HookFactory) {
+	hookFactories[name] = factory
+}
+
+// CreateHook 创建钩子
+func CreateHook(config HookConfig) (Hook, error) {
+	factory, exists := hookFactories[config.Type]
+	if !exists {
+		return nil, ErrUnsupportedHookType
+	}
+	return factory(config)
+}
+
+// GetAvailableHookTypes 获取可用（已编译进当前二进制）的钩子类型
+func GetAvailableHookTypes() []string {
+	types := make([]string, 0, len(hookFactories))
+	for t := range hookFactories {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// ValidStage 是否为支持的钩子位置
+func ValidStage(stage Stage) bool {
+	for _, s := range Stages {
+		if s == stage {
+			return true
+		}
+	}
+	return false
+}
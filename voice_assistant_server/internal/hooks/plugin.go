@@ -0,0 +1,39 @@
+package hooks
+
+import (
+	"errors"
+	"fmt"
+	"plugin"
+)
+
+// PluginSymbol Go插件需要导出的构造函数名，类型为 func(options map[string]interface{}) (hooks.Hook, error)
+const PluginSymbol = "NewHook"
+
+// NewPluginHook 加载Go插件（go build -buildmode=plugin 编译，需与服务端使用相同的Go版本和依赖版本）
+func NewPluginHook(config HookConfig) (Hook, error) {
+	if config.Path == "" {
+		return nil, errors.New("plugin钩子缺少 path")
+	}
+
+	p, err := plugin.Open(config.Path)
+	if err != nil {
+		return nil, fmt.Errorf("加载插件失败: %w", err)
+	}
+	symbol, err := p.Lookup(PluginSymbol)
+	if err != nil {
+		return nil, fmt.Errorf("插件未导出 %s: %w", PluginSymbol, err)
+	}
+
+	switch constructor := symbol.(type) {
+	case func(map[string]interface{}) (Hook, error):
+		return constructor(config.Options)
+	case *func(map[string]interface{}) (Hook, error):
+		return (*constructor)(config.Options)
+	default:
+		return nil, fmt.Errorf("插件导出的 %s 类型不正确: %T", PluginSymbol, symbol)
+	}
+}
+
+func init() {
+	RegisterHook("plugin", NewPluginHook)
+}
@@ -0,0 +1,444 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 对话淘汰策略
+const (
+	EvictionPolicyLRU  = "lru"  // 淘汰最久未使用的对话
+	EvictionPolicyTTL  = "ttl"  // 淘汰空闲超时的对话（超出数量上限时按LRU兜底）
+	EvictionPolicySize = "size" // 淘汰占用Token最多的对话
+)
+
+// 上下文修剪策略
+const (
+	TrimStrategySlidingWindow = "sliding_window" // 丢弃最早的对话消息
+	TrimStrategySummary       = "summary"        // 把超出预算的早期对话压缩为摘要
+)
+
+// 对话管理默认值
+const (
+	defaultMaxConversations = 100
+	defaultConversationTTL  = 30 * 60 // 秒
+	defaultMaxMessages      = 10
+	defaultSummaryMaxTokens = 200
+)
+
+// 摘要消息的名称（区别于配置的系统提示）和内容前缀
+const (
+	summaryMessageName   = "conversation_summary"
+	summaryMessagePrefix = "之前对话的摘要："
+)
+
+// ConversationStats 对话管理统计
+type ConversationStats struct {
+	Active          int   `json:"active"`           // 当前对话数
+	Created         int64 `json:"created"`          // 累计创建数
+	Evicted         int64 `json:"evicted"`          // 因数量上限被淘汰的对话数
+	Expired         int64 `json:"expired"`          // 因空闲超时被清理的对话数
+	Trimmed         int64 `json:"trimmed"`          // 发生上下文修剪的次数
+	TrimmedMessages int64 `json:"trimmed_messages"` // 累计修剪掉的消息数
+	Summarized      int64 `json:"summarized"`       // 压缩为摘要的次数
+}
+
+// ConversationManager 对话管理器
+type ConversationManager struct {
+	conversations map[string]*ConversationContext
+	mu            sync.RWMutex
+	config        ConversationConfig
+	stats         ConversationStats
+	now           func() time.Time
+
+	// Token计数和摘要生成（摘要策略使用所属LLM服务生成）
+	tokenizer Tokenizer
+	generate  func(ctx context.Context, messages []Message) (LLMResponse, error)
+}
+
+// NewConversationManager 创建对话管理器
+func NewConversationManager(config ConversationConfig) *ConversationManager {
+	if config.MaxConversations <= 0 {
+		config.MaxConversations = defaultMaxConversations
+	}
+	switch config.EvictionPolicy {
+	case EvictionPolicyLRU, EvictionPolicyTTL, EvictionPolicySize:
+	default:
+		config.EvictionPolicy = EvictionPolicyLRU
+	}
+	if config.EvictionPolicy == EvictionPolicyTTL && config.TTL <= 0 {
+		config.TTL = defaultConversationTTL
+	}
+	if config.TrimStrategy != TrimStrategySummary {
+		config.TrimStrategy = TrimStrategySlidingWindow
+	}
+	if config.SummaryMaxTokens <= 0 {
+		config.SummaryMaxTokens = defaultSummaryMaxTokens
+	}
+
+	return &ConversationManager{
+		conversations: make(map[string]*ConversationContext),
+		config:        config,
+		now:           time.Now,
+		tokenizer:     estimateTokenizer{},
+	}
+}
+
+// newServiceConversationManager 为LLM服务创建对话管理器：按配置选择Token计数方式，摘要由服务自身生成
+func newServiceConversationManager(config LLMConfig, conversation ConversationConfig, generate func(ctx context.Context, messages []Message) (LLMResponse, error)) (*ConversationManager, error) {
+	tokenizer, err := NewTokenizer(conversation.Tokenizer, config.Model)
+	if err != nil {
+		return nil, err
+	}
+	cm := NewConversationManager(conversation)
+	cm.tokenizer = tokenizer
+	cm.generate = generate
+	return cm, nil
+}
+
+// conversationConfigWithMessageLimit 未配置消息数上限时使用默认值
+// （适用于无法获知模型上下文窗口、只按消息数修剪的服务）
+func conversationConfigWithMessageLimit(config ConversationConfig) ConversationConfig {
+	if config.MaxMessages <= 0 {
+		config.MaxMessages = defaultMaxMessages
+	}
+	return config
+}
+
+// GetOrCreateConversation 获取或创建对话
+func (cm *ConversationManager) GetOrCreateConversation(id string, systemPrompt string, maxTokens int) *ConversationContext {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	now := cm.now().UnixMilli()
+
+	if cm.config.EvictionPolicy == EvictionPolicyTTL {
+		cm.expireLocked(now)
+	}
+
+	if conv, exists := cm.conversations[id]; exists {
+		conv.UpdatedAt = now
+		return conv
+	}
+
+	// 超过最大数量时按策略淘汰
+	for len(cm.conversations) >= cm.config.MaxConversations {
+		if !cm.evictLocked() {
+			break
+		}
+	}
+
+	conv := &ConversationContext{
+		ID:           id,
+		Messages:     make([]Message, 0),
+		SystemPrompt: systemPrompt,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		TokenCount:   0,
+		MaxTokens:    maxTokens,
+		Metadata:     make(map[string]interface{}),
+	}
+
+	// 添加系统提示
+	if systemPrompt != "" {
+		conv.Messages = append(conv.Messages, Message{
+			Role:      "system",
+			Content:   systemPrompt,
+			Timestamp: now,
+		})
+	}
+
+	cm.conversations[id] = conv
+	cm.stats.Created++
+	return conv
+}
+
+// RemoveConversation 删除对话
+func (cm *ConversationManager) RemoveConversation(id string) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	delete(cm.conversations, id)
+}
+
+// Trim 修剪对话上下文，使其满足Token预算和消息数量上限
+// 摘要策略下被修剪的早期对话与已有摘要合并为新的摘要，摘要生成失败时退化为直接丢弃。
+func (cm *ConversationManager) Trim(ctx context.Context, conv *ConversationContext) {
+	before := len(conv.Messages)
+	if cm.config.TrimStrategy == TrimStrategySummary && cm.generate != nil {
+		cm.trimWithSummary(ctx, conv)
+	} else {
+		conv.Messages = trimMessages(conv.Messages, conv.MaxTokens, cm.config.MaxMessages, cm.tokenizer)
+	}
+
+	if removed := before - len(conv.Messages); removed > 0 {
+		cm.mu.Lock()
+		cm.stats.Trimmed++
+		cm.stats.TrimmedMessages += int64(removed)
+		cm.mu.Unlock()
+	}
+}
+
+// trimWithSummary 按摘要策略修剪：为摘要预留Token预算，超出部分压缩进摘要
+func (cm *ConversationManager) trimWithSummary(ctx context.Context, conv *ConversationContext) {
+	// 取出已有摘要，修剪只针对系统提示和对话消息
+	summary := ""
+	messages := make([]Message, 0, len(conv.Messages))
+	for _, msg := range conv.Messages {
+		if msg.Role == "system" && msg.Name == summaryMessageName {
+			summary = strings.TrimPrefix(msg.Content, summaryMessagePrefix)
+			continue
+		}
+		messages = append(messages, msg)
+	}
+
+	budget := conv.MaxTokens
+	if budget > 0 {
+		budget -= cm.config.SummaryMaxTokens
+		if budget <= 0 {
+			budget = 1 // 预算不足时只保留最新一条消息
+		}
+	}
+	keep := selectMessages(messages, budget, cm.config.MaxMessages, cm.tokenizer)
+
+	var kept, dropped []Message
+	for i, msg := range messages {
+		if keep[i] {
+			kept = append(kept, msg)
+		} else if msg.Role != "system" {
+			dropped = append(dropped, msg)
+		}
+	}
+
+	if len(dropped) > 0 {
+		newSummary, err := cm.summarize(ctx, summary, dropped)
+		if err != nil {
+			log.Printf("对话摘要生成失败，直接丢弃早期消息: %s, %v", conv.ID, err)
+		} else {
+			summary = newSummary
+			cm.mu.Lock()
+			cm.stats.Summarized++
+			cm.mu.Unlock()
+		}
+	}
+
+	if summary == "" {
+		conv.Messages = kept
+		return
+	}
+
+	// 摘要紧跟在系统提示之后
+	result := make([]Message, 0, len(kept)+1)
+	inserted := false
+	for _, msg := range kept {
+		if !inserted && msg.Role != "system" {
+			result = append(result, summaryMessage(summary))
+			inserted = true
+		}
+		result = append(result, msg)
+	}
+	if !inserted {
+		result = append(result, summaryMessage(summary))
+	}
+	conv.Messages = result
+}
+
+// summarize 把已有摘要和被修剪的对话合并为新的摘要
+func (cm *ConversationManager) summarize(ctx context.Context, previous string, dropped []Message) (string, error) {
+	var transcript strings.Builder
+	if previous != "" {
+		transcript.WriteString("之前的摘要：" + previous + "\n")
+	}
+	for _, msg := range dropped {
+		role := "用户"
+		if msg.Role == "assistant" {
+			role = "助手"
+		}
+		transcript.WriteString(role + "：" + msg.Content + "\n")
+	}
+
+	response, err := cm.generate(ctx, []Message{
+		{
+			Role: "system",
+			Content: fmt.Sprintf("把下面的对话压缩成一段摘要，保留用户的关键信息、偏好和未完成的事项，使用对话的语言，不超过%d个Token，只输出摘要本身。",
+				cm.config.SummaryMaxTokens),
+		},
+		{Role: "user", Content: transcript.String()},
+	})
+	if err != nil {
+		return "", err
+	}
+	content := strings.TrimSpace(response.Content)
+	if content == "" {
+		return "", fmt.Errorf("摘要内容为空")
+	}
+	return content, nil
+}
+
+// summaryMessage 构造摘要消息
+func summaryMessage(summary string) Message {
+	return Message{
+		Role:      "system",
+		Name:      summaryMessageName,
+		Content:   summaryMessagePrefix + summary,
+		Timestamp: time.Now().UnixMilli(),
+	}
+}
+
+// History 对话中的用户和助手消息（不含系统提示和摘要，对话不存在时返回nil）
+func (cm *ConversationManager) History(id string) []Message {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	conv, exists := cm.conversations[id]
+	if !exists {
+		return nil
+	}
+	history := make([]Message, 0, len(conv.Messages))
+	for _, msg := range conv.Messages {
+		if msg.Role == "user" || msg.Role == "assistant" {
+			history = append(history, msg)
+		}
+	}
+	return history
+}
+
+// Stats 获取统计信息
+func (cm *ConversationManager) Stats() ConversationStats {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	stats := cm.stats
+	stats.Active = len(cm.conversations)
+	return stats
+}
+
+// expireLocked 清理空闲超时的对话（调用方需持有锁）
+func (cm *ConversationManager) expireLocked(now int64) {
+	ttl := int64(cm.config.TTL) * 1000
+	for id, conv := range cm.conversations {
+		if now-conv.UpdatedAt > ttl {
+			delete(cm.conversations, id)
+			cm.stats.Expired++
+			log.Printf("对话已过期清理: %s", id)
+		}
+	}
+}
+
+// evictLocked 按策略淘汰一个对话（调用方需持有锁）
+func (cm *ConversationManager) evictLocked() bool {
+	var victimID string
+	var victim *ConversationContext
+
+	for id, conv := range cm.conversations {
+		if victim == nil || cm.shouldEvictBefore(conv, victim) {
+			victimID = id
+			victim = conv
+		}
+	}
+
+	if victim == nil {
+		return false
+	}
+
+	delete(cm.conversations, victimID)
+	cm.stats.Evicted++
+	log.Printf("对话已淘汰(%s): %s", cm.config.EvictionPolicy, victimID)
+	return true
+}
+
+// shouldEvictBefore 判断a是否应先于b被淘汰
+func (cm *ConversationManager) shouldEvictBefore(a, b *ConversationContext) bool {
+	if cm.config.EvictionPolicy == EvictionPolicySize {
+		sizeA, sizeB := countMessagesTokens(a.Messages, cm.tokenizer), countMessagesTokens(b.Messages, cm.tokenizer)
+		if sizeA != sizeB {
+			return sizeA > sizeB
+		}
+	}
+
+	// LRU（同时作为其他策略的次序依据），时间相同时按ID保证确定性
+	if a.UpdatedAt != b.UpdatedAt {
+		return a.UpdatedAt < b.UpdatedAt
+	}
+	return a.ID < b.ID
+}
+
+// trimMessages 修剪消息列表
+// 保留全部系统消息和最近的对话消息，保持原有顺序；
+// 被保留的对话历史从用户消息开始，避免出现孤立的助手回复。
+// maxTokens或maxMessages小于等于0时表示不限制该项。
+func trimMessages(messages []Message, maxTokens, maxMessages int, tokenizer Tokenizer) []Message {
+	keep := selectMessages(messages, maxTokens, maxMessages, tokenizer)
+	result := make([]Message, 0, len(messages))
+	for i, msg := range messages {
+		if keep[i] {
+			result = append(result, msg)
+		}
+	}
+	return result
+}
+
+// selectMessages 标记修剪后保留的消息
+func selectMessages(messages []Message, maxTokens, maxMessages int, tokenizer Tokenizer) []bool {
+	keep := make([]bool, len(messages))
+	budget := 0
+	for i, msg := range messages {
+		if msg.Role == "system" {
+			keep[i] = true
+			budget += countMessageTokens(msg, tokenizer)
+		}
+	}
+
+	// 从最新消息向前保留，至少保留最后一条
+	kept := 0
+	first := len(messages)
+	for i := len(messages) - 1; i >= 0; i-- {
+		if keep[i] {
+			continue
+		}
+
+		tokens := countMessageTokens(messages[i], tokenizer)
+		if kept > 0 {
+			if maxMessages > 0 && kept >= maxMessages {
+				break
+			}
+			if maxTokens > 0 && budget+tokens > maxTokens {
+				break
+			}
+		}
+
+		keep[i] = true
+		budget += tokens
+		kept++
+		first = i
+	}
+
+	// 丢弃保留窗口开头的非用户消息
+	for i := first; i < len(messages)-1; i++ {
+		if messages[i].Role == "system" {
+			continue
+		}
+		if messages[i].Role == "user" {
+			break
+		}
+		keep[i] = false
+	}
+	return keep
+}
+
+// countMessagesTokens 计算消息列表的Token数
+func countMessagesTokens(messages []Message, tokenizer Tokenizer) int {
+	total := 0
+	for _, msg := range messages {
+		total += countMessageTokens(msg, tokenizer)
+	}
+	return total
+}
+
+// countMessageTokens 计算单条消息的Token数（含角色等固定开销）
+func countMessageTokens(msg Message, tokenizer Tokenizer) int {
+	return tokenizer.CountTokens(msg.Content) + 4
+}
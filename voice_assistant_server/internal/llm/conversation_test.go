@@ -0,0 +1,330 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock 可控时钟
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.t }
+
+func (c *fakeClock) Advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestManager(config ConversationConfig) (*ConversationManager, *fakeClock) {
+	clock := &fakeClock{t: time.Unix(1700000000, 0)}
+	cm := NewConversationManager(config)
+	cm.now = clock.Now
+	return cm, clock
+}
+
+func TestConversationManagerLRUEviction(t *testing.T) {
+	cm, clock := newTestManager(ConversationConfig{MaxConversations: 2})
+
+	cm.GetOrCreateConversation("a", "", 0)
+	clock.Advance(time.Second)
+	cm.GetOrCreateConversation("b", "", 0)
+	clock.Advance(time.Second)
+	cm.GetOrCreateConversation("a", "", 0) // 访问a，b成为最久未使用
+	clock.Advance(time.Second)
+	cm.GetOrCreateConversation("c", "", 0)
+
+	_, hasA := cm.conversations["a"]
+	_, hasB := cm.conversations["b"]
+	assert.True(t, hasA)
+	assert.False(t, hasB)
+
+	stats := cm.Stats()
+	assert.Equal(t, 2, stats.Active)
+	assert.Equal(t, int64(3), stats.Created)
+	assert.Equal(t, int64(1), stats.Evicted)
+}
+
+func TestConversationManagerEvictsWithinSameMillisecond(t *testing.T) {
+	// 时间戳相同的对话也必须能被淘汰，否则数量会超过上限
+	cm, _ := newTestManager(ConversationConfig{MaxConversations: 3})
+
+	for i := 0; i < 10; i++ {
+		cm.GetOrCreateConversation(fmt.Sprintf("conv_%d", i), "", 0)
+	}
+
+	assert.Equal(t, 3, cm.Stats().Active)
+	assert.Equal(t, int64(7), cm.Stats().Evicted)
+}
+
+func TestConversationManagerTTLExpiry(t *testing.T) {
+	cm, clock := newTestManager(ConversationConfig{
+		MaxConversations: 10,
+		EvictionPolicy:   EvictionPolicyTTL,
+		TTL:              60,
+	})
+
+	cm.GetOrCreateConversation("old", "", 0)
+	clock.Advance(30 * time.Second)
+	cm.GetOrCreateConversation("recent", "", 0)
+	clock.Advance(45 * time.Second)
+	cm.GetOrCreateConversation("new", "", 0)
+
+	_, hasOld := cm.conversations["old"]
+	_, hasRecent := cm.conversations["recent"]
+	assert.False(t, hasOld)
+	assert.True(t, hasRecent)
+	assert.Equal(t, int64(1), cm.Stats().Expired)
+	assert.Equal(t, int64(0), cm.Stats().Evicted)
+}
+
+func TestConversationManagerSizeEviction(t *testing.T) {
+	cm, clock := newTestManager(ConversationConfig{
+		MaxConversations: 2,
+		EvictionPolicy:   EvictionPolicySize,
+	})
+
+	big := cm.GetOrCreateConversation("big", "", 0)
+	big.Messages = append(big.Messages, Message{Role: "user", Content: strings.Repeat("很长的消息", 100)})
+	clock.Advance(time.Second)
+	small := cm.GetOrCreateConversation("small", "", 0)
+	small.Messages = append(small.Messages, Message{Role: "user", Content: "短"})
+	clock.Advance(time.Second)
+	cm.GetOrCreateConversation("big", "", 0) // 最近使用过也会因体积被淘汰
+	cm.GetOrCreateConversation("next", "", 0)
+
+	_, hasBig := cm.conversations["big"]
+	_, hasSmall := cm.conversations["small"]
+	assert.False(t, hasBig)
+	assert.True(t, hasSmall)
+}
+
+func TestConversationManagerInvalidConfigDefaults(t *testing.T) {
+	cm := NewConversationManager(ConversationConfig{EvictionPolicy: "unknown"})
+	assert.Equal(t, defaultMaxConversations, cm.config.MaxConversations)
+	assert.Equal(t, EvictionPolicyLRU, cm.config.EvictionPolicy)
+
+	cm = NewConversationManager(ConversationConfig{EvictionPolicy: EvictionPolicyTTL})
+	assert.Equal(t, defaultConversationTTL, cm.config.TTL)
+}
+
+// TestConversationManagerProperties 随机操作序列下对话数量始终不超过上限
+func TestConversationManagerProperties(t *testing.T) {
+	policies := []string{EvictionPolicyLRU, EvictionPolicyTTL, EvictionPolicySize}
+
+	for seed := int64(1); seed <= 50; seed++ {
+		rng := rand.New(rand.NewSource(seed))
+		policy := policies[rng.Intn(len(policies))]
+		maxConversations := rng.Intn(5) + 1
+
+		cm, clock := newTestManager(ConversationConfig{
+			MaxConversations: maxConversations,
+			EvictionPolicy:   policy,
+			TTL:              rng.Intn(10) + 1,
+		})
+
+		for op := 0; op < 200; op++ {
+			id := fmt.Sprintf("conv_%d", rng.Intn(10))
+			conv := cm.GetOrCreateConversation(id, "system", 0)
+			require.Equal(t, id, conv.ID)
+			conv.Messages = append(conv.Messages, Message{Role: "user", Content: strings.Repeat("x", rng.Intn(50))})
+
+			clock.Advance(time.Duration(rng.Intn(3000)) * time.Millisecond)
+
+			stats := cm.Stats()
+			require.LessOrEqual(t, stats.Active, maxConversations, "seed=%d policy=%s", seed, policy)
+			require.Equal(t, stats.Created, int64(stats.Active)+stats.Evicted+stats.Expired, "seed=%d policy=%s", seed, policy)
+		}
+	}
+}
+
+func TestConversationManagerHistory(t *testing.T) {
+	cm, _ := newTestManager(ConversationConfig{})
+	assert.Nil(t, cm.History("missing"))
+
+	conv := cm.GetOrCreateConversation("conv", "system", 0)
+	conv.Messages = append(conv.Messages,
+		summaryMessage("之前聊了天气"),
+		Message{Role: "user", Content: "你好"},
+		Message{Role: "assistant", Content: "你好，有什么可以帮你？"},
+	)
+
+	history := cm.History("conv")
+	require.Len(t, history, 2)
+	assert.Equal(t, "user", history[0].Role)
+	assert.Equal(t, "assistant", history[1].Role)
+}
+
+func TestTrimMessagesKeepsSystemAndOrder(t *testing.T) {
+	messages := []Message{
+		{Role: "system", Content: "你是语音助手"},
+		{Role: "user", Content: "第一个问题"},
+		{Role: "assistant", Content: "第一个回答"},
+		{Role: "user", Content: "第二个问题"},
+		{Role: "assistant", Content: "第二个回答"},
+		{Role: "user", Content: "第三个问题"},
+	}
+
+	trimmed := trimMessages(messages, 0, 4, estimateTokenizer{})
+
+	require.Len(t, trimmed, 4)
+	assert.Equal(t, "system", trimmed[0].Role)
+	// 窗口开头的助手回复被丢弃，历史从用户消息开始
+	assert.Equal(t, "第二个问题", trimmed[1].Content)
+	assert.Equal(t, "第二个回答", trimmed[2].Content)
+	assert.Equal(t, "第三个问题", trimmed[3].Content)
+}
+
+func TestTrimMessagesAlwaysKeepsLatest(t *testing.T) {
+	messages := []Message{
+		{Role: "system", Content: "system"},
+		{Role: "user", Content: strings.Repeat("很长", 500)},
+	}
+
+	trimmed := trimMessages(messages, 10, 0, estimateTokenizer{})
+	assert.Equal(t, messages, trimmed)
+}
+
+// TestTrimMessagesProperties 随机消息序列下修剪结果满足不变式
+func TestTrimMessagesProperties(t *testing.T) {
+	roles := []string{"user", "assistant"}
+
+	for seed := int64(1); seed <= 200; seed++ {
+		rng := rand.New(rand.NewSource(seed))
+
+		messages := []Message{{Role: "system", Content: "system prompt", Timestamp: 0}}
+		n := rng.Intn(30)
+		for i := 0; i < n; i++ {
+			messages = append(messages, Message{
+				Role:      roles[i%2],
+				Content:   strings.Repeat("词", rng.Intn(40)+1),
+				Timestamp: int64(i + 1),
+			})
+		}
+		maxTokens := rng.Intn(300)
+		maxMessages := rng.Intn(8)
+
+		trimmed := trimMessages(messages, maxTokens, maxMessages, estimateTokenizer{})
+
+		// 系统消息始终保留
+		require.Equal(t, "system", trimmed[0].Role, "seed=%d", seed)
+		// 最新消息始终保留
+		require.Equal(t, messages[len(messages)-1], trimmed[len(trimmed)-1], "seed=%d", seed)
+		// 保持原有顺序
+		for i := 1; i < len(trimmed); i++ {
+			require.Less(t, trimmed[i-1].Timestamp, trimmed[i].Timestamp, "seed=%d", seed)
+		}
+		// 多于一条对话消息时，满足预算且从用户消息开始
+		if len(trimmed) > 2 {
+			require.Equal(t, "user", trimmed[1].Role, "seed=%d", seed)
+			if maxTokens > 0 {
+				require.LessOrEqual(t, countMessagesTokens(trimmed, estimateTokenizer{}), maxTokens, "seed=%d", seed)
+			}
+			if maxMessages > 0 {
+				require.LessOrEqual(t, len(trimmed)-1, maxMessages, "seed=%d", seed)
+			}
+		}
+	}
+}
+
+func TestEstimateTokens(t *testing.T) {
+	assert.Equal(t, 0, estimateTokens(""))
+	assert.Equal(t, 4, estimateTokens("你好世界"))
+	assert.Equal(t, 3, estimateTokens("hello world"))
+	assert.Equal(t, 3, estimateTokens("你好 hi"))
+}
+
+func TestTiktokenTokenizer(t *testing.T) {
+	tokenizer, err := NewTokenizer(TokenizerTiktoken, "gpt-4o")
+	require.NoError(t, err)
+	assert.Equal(t, 2, tokenizer.CountTokens("hello world"))
+	assert.Equal(t, 2, tokenizer.CountTokens("你好世界"))
+
+	// 未知模型使用cl100k_base
+	tokenizer, err = NewTokenizer(TokenizerTiktoken, "qwen2:7b")
+	require.NoError(t, err)
+	assert.Equal(t, 5, tokenizer.CountTokens("你好世界"))
+
+	_, err = NewTokenizer("unknown_base", "")
+	assert.Error(t, err)
+}
+
+func TestTrimWithSummary(t *testing.T) {
+	var prompts []string
+	cm := NewConversationManager(ConversationConfig{TrimStrategy: TrimStrategySummary, MaxMessages: 2})
+	cm.generate = func(ctx context.Context, messages []Message) (LLMResponse, error) {
+		prompts = append(prompts, messages[len(messages)-1].Content)
+		return LLMResponse{Content: fmt.Sprintf("摘要%d", len(prompts))}, nil
+	}
+
+	conv := cm.GetOrCreateConversation("c1", "你是语音助手", 0)
+	conv.Messages = append(conv.Messages,
+		Message{Role: "user", Content: "我叫小明"},
+		Message{Role: "assistant", Content: "你好小明"},
+		Message{Role: "user", Content: "明天提醒我开会"},
+	)
+	cm.Trim(context.Background(), conv)
+
+	// 早期对话压缩为摘要，摘要紧跟系统提示
+	require.Len(t, conv.Messages, 3)
+	assert.Equal(t, "你是语音助手", conv.Messages[0].Content)
+	assert.Equal(t, summaryMessageName, conv.Messages[1].Name)
+	assert.Equal(t, "之前对话的摘要：摘要1", conv.Messages[1].Content)
+	assert.Equal(t, "明天提醒我开会", conv.Messages[2].Content)
+	assert.Contains(t, prompts[0], "用户：我叫小明")
+
+	// 再次修剪时已有摘要与新修剪的消息合并
+	conv.Messages = append(conv.Messages,
+		Message{Role: "assistant", Content: "好的"},
+		Message{Role: "user", Content: "谢谢"},
+	)
+	cm.Trim(context.Background(), conv)
+	require.Len(t, conv.Messages, 3)
+	assert.Equal(t, "之前对话的摘要：摘要2", conv.Messages[1].Content)
+	assert.Contains(t, prompts[1], "之前的摘要：摘要1")
+	assert.EqualValues(t, 2, cm.Stats().Summarized)
+
+	// 摘要生成失败时退化为直接丢弃，保留原有摘要
+	cm.generate = func(ctx context.Context, messages []Message) (LLMResponse, error) {
+		return LLMResponse{}, errors.New("unavailable")
+	}
+	conv.Messages = append(conv.Messages,
+		Message{Role: "assistant", Content: "不客气"},
+		Message{Role: "user", Content: "再见"},
+	)
+	cm.Trim(context.Background(), conv)
+	require.Len(t, conv.Messages, 3)
+	assert.Equal(t, "之前对话的摘要：摘要2", conv.Messages[1].Content)
+	assert.Equal(t, "再见", conv.Messages[2].Content)
+	assert.EqualValues(t, 2, cm.Stats().Summarized)
+}
+
+func TestApplyRequestOptionsSystemPrompt(t *testing.T) {
+	messages := []Message{
+		{Role: "system", Content: "默认提示"},
+		{Role: "system", Name: summaryMessageName, Content: "摘要"},
+		{Role: "user", Content: "你好"},
+	}
+
+	ctx := WithRequestOptions(context.Background(), RequestOptions{SystemPrompt: "你是海盗", Background: "背景"})
+	result := applyRequestOptions(ctx, messages)
+	require.Len(t, result, 4)
+	assert.Equal(t, "你是海盗", result[0].Content)
+	assert.Equal(t, "摘要", result[1].Content)
+	assert.Equal(t, "背景", result[2].Content)
+	assert.Equal(t, "你好", result[3].Content)
+	// 不修改对话历史
+	assert.Equal(t, "默认提示", messages[0].Content)
+
+	// 没有系统提示时插入到最前面
+	result = applyRequestOptions(ctx, messages[2:])
+	require.Len(t, result, 3)
+	assert.Equal(t, "你是海盗", result[0].Content)
+	assert.Equal(t, "背景", result[1].Content)
+}
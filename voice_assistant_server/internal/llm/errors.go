@@ -1,29 +1,33 @@
 package llm
 
-import "errors"
+import (
+	"errors"
 
-// LLM相关错误定义
+	"voice_assistant/voice_assistant_server/internal/errs"
+)
+
+// LLM相关错误定义（与ASR、TTS共有的错误为errs中的同一值，按errs的类别对应协议错误代码）
 var (
-	ErrUnsupportedLLMType    = errors.New("unsupported LLM type")
-	ErrLLMNotInitialized     = errors.New("LLM service not initialized")
-	ErrInvalidModel          = errors.New("invalid model name")
-	ErrModelNotFound         = errors.New("LLM model not found")
-	ErrModelLoadFailed       = errors.New("failed to load LLM model")
+	ErrUnsupportedLLMType    = errs.New(errs.KindMisconfigured, "unsupported LLM type")
+	ErrLLMNotInitialized     = errs.New(errs.KindUnavailable, "LLM service not initialized")
+	ErrInvalidModel          = errs.New(errs.KindMisconfigured, "invalid model name")
+	ErrModelNotFound         = errs.ErrModelNotFound
+	ErrModelLoadFailed       = errs.ErrModelLoadFailed
 	ErrGenerationFailed      = errors.New("text generation failed")
-	ErrInvalidPrompt         = errors.New("invalid prompt")
-	ErrInvalidConfig         = errors.New("invalid LLM configuration")
-	ErrConnectionFailed      = errors.New("failed to connect to LLM service")
-	ErrTimeout               = errors.New("LLM processing timeout")
-	ErrAPIKeyMissing         = errors.New("API key is missing")
-	ErrAPIKeyInvalid         = errors.New("API key is invalid")
-	ErrRateLimitExceeded     = errors.New("rate limit exceeded")
-	ErrQuotaExceeded         = errors.New("quota exceeded")
-	ErrTokenLimitExceeded    = errors.New("token limit exceeded")
-	ErrContextTooLong        = errors.New("context too long")
-	ErrInsufficientMemory    = errors.New("insufficient memory for LLM model")
-	ErrGPUNotAvailable       = errors.New("GPU not available for LLM")
-	ErrConversationNotFound  = errors.New("conversation not found")
-	ErrInvalidConversationID = errors.New("invalid conversation ID")
-	ErrStreamingNotSupported = errors.New("streaming not supported")
+	ErrInvalidPrompt         = errs.New(errs.KindInvalidInput, "invalid prompt")
+	ErrInvalidConfig         = errs.ErrInvalidConfig
+	ErrConnectionFailed      = errs.ErrConnectionFailed
+	ErrTimeout               = errs.ErrTimeout
+	ErrAPIKeyMissing         = errs.ErrAPIKeyMissing
+	ErrAPIKeyInvalid         = errs.ErrAPIKeyInvalid
+	ErrRateLimitExceeded     = errs.ErrRateLimitExceeded
+	ErrQuotaExceeded         = errs.ErrQuotaExceeded
+	ErrTokenLimitExceeded    = errs.New(errs.KindInvalidInput, "token limit exceeded")
+	ErrContextTooLong        = errs.New(errs.KindInvalidInput, "context too long")
+	ErrInsufficientMemory    = errs.ErrInsufficientMemory
+	ErrGPUNotAvailable       = errs.ErrGPUNotAvailable
+	ErrConversationNotFound  = errs.New(errs.KindInvalidInput, "conversation not found")
+	ErrInvalidConversationID = errs.New(errs.KindInvalidInput, "invalid conversation ID")
+	ErrStreamingNotSupported = errs.New(errs.KindMisconfigured, "streaming not supported")
 	ErrFunctionCallFailed    = errors.New("function call failed")
 )
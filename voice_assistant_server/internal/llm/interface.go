@@ -2,6 +2,9 @@ package llm
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"time"
 )
 
 // LLMService LLM服务接口
@@ -34,6 +37,23 @@ type LLMService interface {
 	Close() error
 }
 
+// ConversationStatsProvider 可提供对话管理统计的LLM服务
+type ConversationStatsProvider interface {
+	ConversationStats() ConversationStats
+}
+
+// ConversationHistoryProvider 可导出对话历史的LLM服务（用于会话转移到其他设备）
+type ConversationHistoryProvider interface {
+	// ConversationHistory 对话中的用户和助手消息（按时间顺序，不含系统提示；对话不存在时返回nil）
+	ConversationHistory(conversationID string) []Message
+}
+
+// HealthChecker 可主动探测后端状态的LLM服务（可选实现，供就绪检查使用）
+type HealthChecker interface {
+	// CheckHealth 检查后端服务是否可达、凭据是否有效
+	CheckHealth(ctx context.Context) error
+}
+
 // LLMConfig LLM配置
 type LLMConfig struct {
 	Type      string `yaml:"type"`       // openai|ollama|websocket|anthropic|gemini
@@ -60,6 +80,9 @@ type LLMConfig struct {
 	EnableContextTrim bool `yaml:"enable_context_trim"` // 启用上下文修剪
 	KeepSystemPrompt  bool `yaml:"keep_system_prompt"`  // 保留系统提示
 
+	// 对话管理
+	Conversation ConversationConfig `yaml:"conversation"`
+
 	// OpenAI特定配置
 	OpenAIConfig OpenAIConfig `yaml:"openai"`
 
@@ -70,6 +93,19 @@ type LLMConfig struct {
 	WebSocketConfig WebSocketConfig `yaml:"websocket"`
 }
 
+// ConversationConfig 对话管理配置
+type ConversationConfig struct {
+	MaxConversations int    `yaml:"max_conversations"` // 最大对话数
+	EvictionPolicy   string `yaml:"eviction_policy"`   // lru|ttl|size
+	TTL              int    `yaml:"ttl"`               // 对话空闲超时（秒，ttl策略）
+	MaxMessages      int    `yaml:"max_messages"`      // 修剪后保留的最大对话消息数（0表示仅按Token修剪）
+
+	// 上下文修剪
+	Tokenizer        string `yaml:"tokenizer"`          // Token计数：estimate|tiktoken|编码名（如cl100k_base、o200k_base）
+	TrimStrategy     string `yaml:"trim_strategy"`      // sliding_window|summary
+	SummaryMaxTokens int    `yaml:"summary_max_tokens"` // 摘要占用的Token预算（summary策略）
+}
+
 // OpenAIConfig OpenAI配置
 type OpenAIConfig struct {
 	Organization string     `yaml:"organization"` // 组织ID
@@ -188,6 +224,88 @@ type ConversationContext struct {
 	Metadata     map[string]interface{} `json:"metadata"`      // 元数据
 }
 
+// RequestOptions 单次请求选项（通过context传递，覆盖服务级配置）
+type RequestOptions struct {
+	Instruction  string // 附加系统指令（如回复语言），不写入对话历史
+	Background   string // 用户背景信息（如长期记忆），紧跟系统提示，不写入对话历史
+	SystemPrompt string // 替换服务配置的系统提示（如会话人设），不写入对话历史
+}
+
+// requestOptionsKey context键
+type requestOptionsKey struct{}
+
+// WithRequestOptions 将请求选项附加到context
+func WithRequestOptions(ctx context.Context, opts RequestOptions) context.Context {
+	return context.WithValue(ctx, requestOptionsKey{}, opts)
+}
+
+// RequestOptionsFromContext 从context获取请求选项
+func RequestOptionsFromContext(ctx context.Context) RequestOptions {
+	opts, _ := ctx.Value(requestOptionsKey{}).(RequestOptions)
+	return opts
+}
+
+// applyRequestOptions 根据请求选项生成实际发送的消息列表（不修改对话历史）
+func applyRequestOptions(ctx context.Context, messages []Message) []Message {
+	opts := RequestOptionsFromContext(ctx)
+	if opts.Instruction == "" && opts.Background == "" && opts.SystemPrompt == "" {
+		return messages
+	}
+	if opts.SystemPrompt != "" {
+		messages = replaceSystemPrompt(messages, opts.SystemPrompt)
+	}
+
+	result := make([]Message, 0, len(messages)+2)
+	if opts.Background != "" {
+		// 背景信息放在开头的系统消息之后、对话历史之前
+		leading := 0
+		for leading < len(messages) && messages[leading].Role == "system" {
+			leading++
+		}
+		result = append(result, messages[:leading]...)
+		result = append(result, Message{
+			Role:      "system",
+			Content:   opts.Background,
+			Timestamp: time.Now().UnixMilli(),
+		})
+		messages = messages[leading:]
+	}
+	result = append(result, messages...)
+	if opts.Instruction != "" {
+		result = append(result, Message{
+			Role:      "system",
+			Content:   opts.Instruction,
+			Timestamp: time.Now().UnixMilli(),
+		})
+	}
+	return result
+}
+
+// replaceSystemPrompt 替换开头的系统提示（没有时插入到最前面，对话摘要保持不变）
+func replaceSystemPrompt(messages []Message, prompt string) []Message {
+	result := make([]Message, 0, len(messages)+1)
+	replaced := false
+	for i, msg := range messages {
+		if msg.Role != "system" {
+			result = append(result, messages[i:]...)
+			break
+		}
+		if !replaced && msg.Name != summaryMessageName {
+			msg.Content = prompt
+			replaced = true
+		}
+		result = append(result, msg)
+	}
+	if !replaced {
+		result = append([]Message{{
+			Role:      "system",
+			Content:   prompt,
+			Timestamp: time.Now().UnixMilli(),
+		}}, result...)
+	}
+	return result
+}
+
 // LLMFactory LLM工厂函数类型
 type LLMFactory func(config LLMConfig) (LLMService, error)
 
@@ -203,16 +321,17 @@ func RegisterLLM(name string, factory LLMFactory) {
 func CreateLLM(config LLMConfig) (LLMService, error) {
 	factory, exists := llmFactories[config.Type]
 	if !exists {
-		return nil, ErrUnsupportedLLMType
+		return nil, fmt.Errorf("%w: %s（当前二进制已编译: %v）", ErrUnsupportedLLMType, config.Type, GetAvailableLLMTypes())
 	}
 	return factory(config)
 }
 
-// GetAvailableLLMTypes 获取可用的LLM类型
+// GetAvailableLLMTypes 获取可用（已编译进当前二进制）的LLM类型
 func GetAvailableLLMTypes() []string {
 	types := make([]string, 0, len(llmFactories))
 	for t := range llmFactories {
 		types = append(types, t)
 	}
+	sort.Strings(types)
 	return types
 }
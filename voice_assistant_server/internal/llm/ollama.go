@@ -1,3 +1,5 @@
+//go:build !no_llm_ollama
+
 package llm
 
 import (
@@ -12,6 +14,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"voice_assistant/voice_assistant_server/internal/errs"
 )
 
 // OllamaLLM Ollama LLM实现
@@ -93,9 +97,14 @@ func NewOllamaLLM(config LLMConfig) (*OllamaLLM, error) {
 		client: &http.Client{
 			Timeout: time.Duration(config.Timeout) * time.Second,
 		},
-		conversationManager: NewConversationManager(100),
 	}
 
+	conversationManager, err := newServiceConversationManager(config, conversationConfigWithMessageLimit(config.Conversation), o.GenerateResponse)
+	if err != nil {
+		return nil, err
+	}
+	o.conversationManager = conversationManager
+
 	if o.client.Timeout == 0 {
 		o.client.Timeout = 120 * time.Second // Ollama可能需要更长时间
 	}
@@ -125,7 +134,9 @@ func (o *OllamaLLM) Initialize(config LLMConfig) error {
 	}
 
 	// 检查连接
-	if err := o.checkConnection(); err != nil {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := o.checkConnection(ctx); err != nil {
 		return fmt.Errorf("连接Ollama服务失败: %w", err)
 	}
 
@@ -267,11 +278,11 @@ func (o *OllamaLLM) Chat(ctx context.Context, userInput string, conversationID s
 
 	// 修剪上下文（如果需要）
 	if o.config.EnableContextTrim {
-		o.trimContext(conv)
+		o.conversationManager.Trim(ctx, conv)
 	}
 
 	// 生成响应
-	response, err := o.GenerateResponse(ctx, conv.Messages)
+	response, err := o.GenerateResponse(ctx, applyRequestOptions(ctx, conv.Messages))
 	if err != nil {
 		return response, err
 	}
@@ -308,11 +319,11 @@ func (o *OllamaLLM) ChatStream(ctx context.Context, userInput string, conversati
 
 	// 修剪上下文（如果需要）
 	if o.config.EnableContextTrim {
-		o.trimContext(conv)
+		o.conversationManager.Trim(ctx, conv)
 	}
 
 	// 生成流式响应
-	responseChan, err := o.GenerateResponseStream(ctx, conv.Messages)
+	responseChan, err := o.GenerateResponseStream(ctx, applyRequestOptions(ctx, conv.Messages))
 	if err != nil {
 		return nil, err
 	}
@@ -387,11 +398,16 @@ func (o *OllamaLLM) Close() error {
 	return nil
 }
 
-// checkConnection 检查连接
-func (o *OllamaLLM) checkConnection() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+// CheckHealth 检查Ollama服务是否可达
+func (o *OllamaLLM) CheckHealth(ctx context.Context) error {
+	if err := o.checkConnection(ctx); err != nil {
+		return fmt.Errorf("Ollama服务不可达: %w", err)
+	}
+	return nil
+}
 
+// checkConnection 检查连接
+func (o *OllamaLLM) checkConnection(ctx context.Context) error {
 	req, err := http.NewRequestWithContext(ctx, "GET", o.baseURL+"/api/tags", nil)
 	if err != nil {
 		return err
@@ -494,14 +510,14 @@ func (o *OllamaLLM) callOllamaAPI(ctx context.Context, request OllamaRequest) (*
 	// 发送请求
 	resp, err := o.client.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %w", ErrConnectionFailed, err)
 	}
 	defer resp.Body.Close()
 
 	// 检查响应状态
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API请求失败: %d, %s", resp.StatusCode, string(bodyBytes))
+		return nil, fmt.Errorf("API请求失败: %w", errs.FromHTTPStatus(resp.StatusCode, string(bodyBytes)))
 	}
 
 	// 解析响应
@@ -533,14 +549,14 @@ func (o *OllamaLLM) callOllamaStreamAPI(ctx context.Context, request OllamaReque
 	// 发送请求
 	resp, err := o.client.Do(req)
 	if err != nil {
-		return err
+		return fmt.Errorf("%w: %w", ErrConnectionFailed, err)
 	}
 	defer resp.Body.Close()
 
 	// 检查响应状态
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API请求失败: %d, %s", resp.StatusCode, string(bodyBytes))
+		return fmt.Errorf("API请求失败: %w", errs.FromHTTPStatus(resp.StatusCode, string(bodyBytes)))
 	}
 
 	// 处理流式响应
@@ -586,28 +602,14 @@ func (o *OllamaLLM) callOllamaStreamAPI(ctx context.Context, request OllamaReque
 	return scanner.Err()
 }
 
-// trimContext 修剪对话上下文
-func (o *OllamaLLM) trimContext(conv *ConversationContext) {
-	if len(conv.Messages) <= 2 {
-		return
-	}
-
-	// 简单的修剪策略：保留系统提示和最近的消息
-	maxMessages := 10
-	if len(conv.Messages) > maxMessages {
-		systemMessages := make([]Message, 0)
-		recentMessages := make([]Message, 0)
-
-		for i, msg := range conv.Messages {
-			if msg.Role == "system" {
-				systemMessages = append(systemMessages, msg)
-			} else if i >= len(conv.Messages)-maxMessages+len(systemMessages) {
-				recentMessages = append(recentMessages, msg)
-			}
-		}
+// ConversationStats 获取对话管理统计
+func (o *OllamaLLM) ConversationStats() ConversationStats {
+	return o.conversationManager.Stats()
+}
 
-		conv.Messages = append(systemMessages, recentMessages...)
-	}
+// ConversationHistory 获取对话历史
+func (o *OllamaLLM) ConversationHistory(conversationID string) []Message {
+	return o.conversationManager.History(conversationID)
 }
 
 // 注册Ollama LLM
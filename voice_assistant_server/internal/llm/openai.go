@@ -1,3 +1,5 @@
+//go:build !no_llm_openai
+
 package llm
 
 import (
@@ -12,6 +14,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"voice_assistant/voice_assistant_server/internal/errs"
 )
 
 // OpenAILLM OpenAI LLM实现
@@ -94,72 +98,6 @@ type OpenAIUsage struct {
 	TotalTokens      int `json:"total_tokens"`
 }
 
-// ConversationManager 对话管理器
-type ConversationManager struct {
-	conversations map[string]*ConversationContext
-	mu            sync.RWMutex
-	maxContexts   int
-}
-
-// NewConversationManager 创建对话管理器
-func NewConversationManager(maxContexts int) *ConversationManager {
-	return &ConversationManager{
-		conversations: make(map[string]*ConversationContext),
-		maxContexts:   maxContexts,
-	}
-}
-
-// GetOrCreateConversation 获取或创建对话
-func (cm *ConversationManager) GetOrCreateConversation(id string, systemPrompt string, maxTokens int) *ConversationContext {
-	cm.mu.Lock()
-	defer cm.mu.Unlock()
-
-	if conv, exists := cm.conversations[id]; exists {
-		conv.UpdatedAt = time.Now().UnixMilli()
-		return conv
-	}
-
-	// 如果超过最大数量，删除最旧的对话
-	if len(cm.conversations) >= cm.maxContexts {
-		var oldestID string
-		var oldestTime int64 = time.Now().UnixMilli()
-
-		for cid, conv := range cm.conversations {
-			if conv.UpdatedAt < oldestTime {
-				oldestTime = conv.UpdatedAt
-				oldestID = cid
-			}
-		}
-
-		if oldestID != "" {
-			delete(cm.conversations, oldestID)
-		}
-	}
-
-	conv := &ConversationContext{
-		ID:           id,
-		Messages:     make([]Message, 0),
-		SystemPrompt: systemPrompt,
-		CreatedAt:    time.Now().UnixMilli(),
-		UpdatedAt:    time.Now().UnixMilli(),
-		TokenCount:   0,
-		MaxTokens:    maxTokens,
-		Metadata:     make(map[string]interface{}),
-	}
-
-	// 添加系统提示
-	if systemPrompt != "" {
-		conv.Messages = append(conv.Messages, Message{
-			Role:      "system",
-			Content:   systemPrompt,
-			Timestamp: time.Now().UnixMilli(),
-		})
-	}
-
-	cm.conversations[id] = conv
-	return conv
-}
-
 // NewOpenAILLM 创建OpenAI LLM实例
 func NewOpenAILLM(config LLMConfig) (*OpenAILLM, error) {
 	o := &OpenAILLM{
@@ -167,9 +105,14 @@ func NewOpenAILLM(config LLMConfig) (*OpenAILLM, error) {
 		client: &http.Client{
 			Timeout: time.Duration(config.Timeout) * time.Second,
 		},
-		conversationManager: NewConversationManager(100), // 最多100个对话上下文
 	}
 
+	conversationManager, err := newServiceConversationManager(config, config.Conversation, o.GenerateResponse)
+	if err != nil {
+		return nil, err
+	}
+	o.conversationManager = conversationManager
+
 	if o.client.Timeout == 0 {
 		o.client.Timeout = 60 * time.Second
 	}
@@ -362,11 +305,11 @@ func (o *OpenAILLM) Chat(ctx context.Context, userInput string, conversationID s
 
 	// 修剪上下文（如果需要）
 	if o.config.EnableContextTrim {
-		o.trimContext(conv)
+		o.conversationManager.Trim(ctx, conv)
 	}
 
 	// 生成响应
-	response, err := o.GenerateResponse(ctx, conv.Messages)
+	response, err := o.GenerateResponse(ctx, applyRequestOptions(ctx, conv.Messages))
 	if err != nil {
 		return response, err
 	}
@@ -404,11 +347,11 @@ func (o *OpenAILLM) ChatStream(ctx context.Context, userInput string, conversati
 
 	// 修剪上下文（如果需要）
 	if o.config.EnableContextTrim {
-		o.trimContext(conv)
+		o.conversationManager.Trim(ctx, conv)
 	}
 
 	// 生成流式响应
-	responseChan, err := o.GenerateResponseStream(ctx, conv.Messages)
+	responseChan, err := o.GenerateResponseStream(ctx, applyRequestOptions(ctx, conv.Messages))
 	if err != nil {
 		return nil, err
 	}
@@ -485,6 +428,34 @@ func (o *OpenAILLM) Close() error {
 	return nil
 }
 
+// CheckHealth 请求模型列表接口，检查服务是否可达、API密钥是否有效
+func (o *OpenAILLM) CheckHealth(ctx context.Context) error {
+	o.mu.RLock()
+	apiURL, apiKey := o.apiURL, o.apiKey
+	o.mu.RUnlock()
+
+	modelsURL := strings.TrimSuffix(apiURL, "/chat/completions") + "/models"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, modelsURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("OpenAI服务不可达: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("OpenAI API密钥无效: 状态码 %d", resp.StatusCode)
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return fmt.Errorf("OpenAI服务异常: 状态码 %d", resp.StatusCode)
+	}
+	return nil
+}
+
 // convertMessages 转换消息格式
 func (o *OpenAILLM) convertMessages(messages []Message) []OpenAIMessage {
 	openaiMessages := make([]OpenAIMessage, len(messages))
@@ -546,14 +517,14 @@ func (o *OpenAILLM) callOpenAIAPI(ctx context.Context, request OpenAIRequest) (*
 	// 发送请求
 	resp, err := o.client.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %w", ErrConnectionFailed, err)
 	}
 	defer resp.Body.Close()
 
 	// 检查响应状态
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API请求失败: %d, %s", resp.StatusCode, string(bodyBytes))
+		return nil, fmt.Errorf("API请求失败: %w", errs.FromHTTPStatus(resp.StatusCode, string(bodyBytes)))
 	}
 
 	// 解析响应
@@ -592,14 +563,14 @@ func (o *OpenAILLM) callOpenAIStreamAPI(ctx context.Context, request OpenAIReque
 	// 发送请求
 	resp, err := o.client.Do(req)
 	if err != nil {
-		return err
+		return fmt.Errorf("%w: %w", ErrConnectionFailed, err)
 	}
 	defer resp.Body.Close()
 
 	// 检查响应状态
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API请求失败: %d, %s", resp.StatusCode, string(bodyBytes))
+		return fmt.Errorf("API请求失败: %w", errs.FromHTTPStatus(resp.StatusCode, string(bodyBytes)))
 	}
 
 	// 处理流式响应
@@ -677,34 +648,14 @@ func (o *OpenAILLM) getContextWindow(model string) int {
 	}
 }
 
-// trimContext 修剪对话上下文
-func (o *OpenAILLM) trimContext(conv *ConversationContext) {
-	if len(conv.Messages) <= 2 {
-		return // 保留系统提示和至少一条消息
-	}
-
-	// 计算大概的token数（简单估算）
-	totalTokens := 0
-	for _, msg := range conv.Messages {
-		totalTokens += len(msg.Content) / 4 // 粗略估算：4个字符≈1个token
-	}
-
-	// 如果超过最大token数，删除中间的消息
-	if totalTokens > conv.MaxTokens {
-		// 保留系统提示（如果有）和最近的几条消息
-		systemMessages := make([]Message, 0)
-		recentMessages := make([]Message, 0)
-
-		for i, msg := range conv.Messages {
-			if msg.Role == "system" {
-				systemMessages = append(systemMessages, msg)
-			} else if i >= len(conv.Messages)-4 { // 保留最近4条消息
-				recentMessages = append(recentMessages, msg)
-			}
-		}
+// ConversationStats 获取对话管理统计
+func (o *OpenAILLM) ConversationStats() ConversationStats {
+	return o.conversationManager.Stats()
+}
 
-		conv.Messages = append(systemMessages, recentMessages...)
-	}
+// ConversationHistory 获取对话历史
+func (o *OpenAILLM) ConversationHistory(conversationID string) []Message {
+	return o.conversationManager.History(conversationID)
 }
 
 // 注册OpenAI LLM
@@ -0,0 +1,36 @@
+//go:build !no_llm_openai
+
+package llm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAICheckHealth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/models", r.URL.Path)
+		if r.Header.Get("Authorization") != "Bearer valid" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"data": []}`))
+	}))
+	defer server.Close()
+
+	service, err := NewOpenAILLM(LLMConfig{})
+	require.NoError(t, err)
+	require.NoError(t, service.Initialize(LLMConfig{APIKey: "valid", APIUrl: server.URL + "/v1/chat/completions"}))
+	assert.NoError(t, service.CheckHealth(context.Background()))
+
+	require.NoError(t, service.Initialize(LLMConfig{APIKey: "expired", APIUrl: server.URL + "/v1/chat/completions"}))
+	assert.ErrorContains(t, service.CheckHealth(context.Background()), "API密钥无效")
+
+	server.Close()
+	assert.ErrorContains(t, service.CheckHealth(context.Background()), "不可达")
+}
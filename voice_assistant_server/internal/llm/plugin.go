@@ -0,0 +1,279 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// PluginCaller 外部插件进程的调用接口（由 plugins 包实现）
+type PluginCaller interface {
+	Call(ctx context.Context, method string, params, result interface{}) error
+}
+
+// PluginLLM 由外部插件进程提供的LLM服务（如单独发布的 llama.cpp 推理程序）
+// 对话上下文由服务端维护，插件只负责按完整的消息列表生成回复。
+type PluginLLM struct {
+	caller              PluginCaller
+	provider            string
+	config              LLMConfig
+	conversationManager *ConversationManager
+	isInitialized       bool
+	mu                  sync.RWMutex
+	modelInfo           ModelInfo
+}
+
+// pluginLLMSettings 初始化时传给插件的配置
+type pluginLLMSettings struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model,omitempty"`
+	APIKey   string `json:"api_key,omitempty"`
+	APIUrl   string `json:"api_url,omitempty"`
+}
+
+// pluginLLMInfo 插件返回的模型信息
+type pluginLLMInfo struct {
+	Model         string   `json:"model"`
+	Version       string   `json:"version"`
+	ContextWindow int      `json:"context_window"`
+	Languages     []string `json:"languages"`
+}
+
+// pluginGenerateRequest 生成请求
+type pluginGenerateRequest struct {
+	Provider    string    `json:"provider"`
+	Model       string    `json:"model,omitempty"`
+	Messages    []Message `json:"messages"`
+	MaxTokens   int       `json:"max_tokens,omitempty"`
+	Temperature float32   `json:"temperature,omitempty"`
+	TopP        float32   `json:"top_p,omitempty"`
+}
+
+// pluginGenerateResult 生成结果
+type pluginGenerateResult struct {
+	Content      string     `json:"content"`
+	Model        string     `json:"model"`
+	FinishReason string     `json:"finish_reason"`
+	Usage        TokenUsage `json:"usage"`
+}
+
+// pluginHealthRequest 健康检查请求
+type pluginHealthRequest struct {
+	Provider string `json:"provider"`
+}
+
+// NewPluginLLM 创建插件LLM实例
+func NewPluginLLM(caller PluginCaller, provider string, config LLMConfig) (*PluginLLM, error) {
+	p := &PluginLLM{caller: caller, provider: provider, config: config}
+
+	conversationManager, err := newServiceConversationManager(config, conversationConfigWithMessageLimit(config.Conversation), p.GenerateResponse)
+	if err != nil {
+		return nil, err
+	}
+	p.conversationManager = conversationManager
+	return p, nil
+}
+
+// Initialize 初始化插件LLM（插件加载模型）
+func (p *PluginLLM) Initialize(config LLMConfig) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	log.Printf("PluginLLM: 初始化中 (%s)...", p.provider)
+
+	var info pluginLLMInfo
+	err := p.caller.Call(context.Background(), "llm.initialize", pluginLLMSettings{
+		Provider: p.provider,
+		Model:    config.Model,
+		APIKey:   config.APIKey,
+		APIUrl:   config.APIUrl,
+	}, &info)
+	if err != nil {
+		return fmt.Errorf("插件LLM初始化失败: %w", err)
+	}
+
+	name := info.Model
+	if name == "" {
+		name = config.Model
+	}
+	contextWindow := info.ContextWindow
+	if contextWindow == 0 {
+		contextWindow = config.ContextWindow
+	}
+	p.modelInfo = ModelInfo{
+		Name:          name,
+		Version:       info.Version,
+		Type:          "text-generation",
+		Provider:      p.provider,
+		MaxTokens:     config.MaxTokens,
+		ContextWindow: contextWindow,
+		Languages:     info.Languages,
+		Capabilities:  []string{"chat", "completion"},
+		LoadTime:      time.Now().UnixMilli(),
+	}
+
+	p.config = config
+	p.isInitialized = true
+
+	log.Printf("PluginLLM: 初始化成功 (%s)", name)
+	return nil
+}
+
+// GenerateResponse 生成回复
+func (p *PluginLLM) GenerateResponse(ctx context.Context, messages []Message) (LLMResponse, error) {
+	p.mu.RLock()
+	initialized, config := p.isInitialized, p.config
+	p.mu.RUnlock()
+
+	if !initialized {
+		return LLMResponse{}, ErrLLMNotInitialized
+	}
+
+	startTime := time.Now()
+	var result pluginGenerateResult
+	err := p.caller.Call(ctx, "llm.generate", pluginGenerateRequest{
+		Provider:    p.provider,
+		Model:       config.Model,
+		Messages:    messages,
+		MaxTokens:   config.MaxTokens,
+		Temperature: config.Temperature,
+		TopP:        config.TopP,
+	}, &result)
+	if err != nil {
+		return LLMResponse{}, fmt.Errorf("%w: %v", ErrGenerationFailed, err)
+	}
+	if result.Model == "" {
+		result.Model = config.Model
+	}
+	if result.FinishReason == "" {
+		result.FinishReason = "stop"
+	}
+
+	return LLMResponse{
+		Content:      result.Content,
+		Role:         "assistant",
+		Model:        result.Model,
+		FinishReason: result.FinishReason,
+		TokenUsage:   result.Usage,
+		IsComplete:   true,
+		ProcessTime:  time.Since(startTime).Milliseconds(),
+		Timestamp:    time.Now().UnixMilli(),
+	}, nil
+}
+
+// GenerateResponseStream 生成流式回复（插件一次返回完整回复，作为单个增量发出）
+func (p *PluginLLM) GenerateResponseStream(ctx context.Context, messages []Message) (<-chan LLMResponse, error) {
+	responseChan := make(chan LLMResponse, 1)
+	go func() {
+		defer close(responseChan)
+		response, err := p.GenerateResponse(ctx, messages)
+		if err != nil {
+			responseChan <- LLMResponse{Error: err, IsComplete: true}
+			return
+		}
+		response.IsDelta = true
+		responseChan <- response
+	}()
+	return responseChan, nil
+}
+
+// Chat 聊天对话
+func (p *PluginLLM) Chat(ctx context.Context, userInput string, conversationID string) (LLMResponse, error) {
+	conv := p.conversationManager.GetOrCreateConversation(
+		conversationID,
+		p.config.SystemPrompt,
+		p.config.MaxContextLength,
+	)
+
+	conv.Messages = append(conv.Messages, Message{
+		Role:      "user",
+		Content:   userInput,
+		Timestamp: time.Now().UnixMilli(),
+	})
+
+	if p.config.EnableContextTrim {
+		p.conversationManager.Trim(ctx, conv)
+	}
+
+	response, err := p.GenerateResponse(ctx, applyRequestOptions(ctx, conv.Messages))
+	if err != nil {
+		return response, err
+	}
+
+	conv.Messages = append(conv.Messages, Message{
+		Role:      "assistant",
+		Content:   response.Content,
+		Timestamp: time.Now().UnixMilli(),
+	})
+	conv.UpdatedAt = time.Now().UnixMilli()
+
+	response.ConversationID = conversationID
+	return response, nil
+}
+
+// ChatStream 流式聊天对话（插件一次返回完整回复）
+func (p *PluginLLM) ChatStream(ctx context.Context, userInput string, conversationID string) (<-chan LLMResponse, error) {
+	responseChan := make(chan LLMResponse, 1)
+	go func() {
+		defer close(responseChan)
+		response, err := p.Chat(ctx, userInput, conversationID)
+		if err != nil {
+			responseChan <- LLMResponse{Error: err, IsComplete: true, ConversationID: conversationID}
+			return
+		}
+		response.IsDelta = true
+		responseChan <- response
+	}()
+	return responseChan, nil
+}
+
+// GetSupportedModels 获取支持的模型列表
+func (p *PluginLLM) GetSupportedModels() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return []string{p.config.Model}
+}
+
+// SetModel 设置使用的模型
+func (p *PluginLLM) SetModel(model string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.config.Model = model
+	p.modelInfo.Name = model
+	return nil
+}
+
+// GetModelInfo 获取模型信息
+func (p *PluginLLM) GetModelInfo() ModelInfo {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.modelInfo
+}
+
+// Close 关闭插件LLM（插件进程由插件管理器停止）
+func (p *PluginLLM) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.isInitialized = false
+	log.Printf("PluginLLM: 已关闭 (%s)", p.provider)
+	return nil
+}
+
+// CheckHealth 检查插件进程是否可用
+func (p *PluginLLM) CheckHealth(ctx context.Context) error {
+	return p.caller.Call(ctx, "health", pluginHealthRequest{Provider: p.provider}, nil)
+}
+
+// ConversationStats 获取对话管理统计
+func (p *PluginLLM) ConversationStats() ConversationStats {
+	return p.conversationManager.Stats()
+}
+
+// ConversationHistory 获取对话历史
+func (p *PluginLLM) ConversationHistory(conversationID string) []Message {
+	return p.conversationManager.History(conversationID)
+}
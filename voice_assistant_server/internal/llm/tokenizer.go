@@ -0,0 +1,109 @@
+package llm
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/pkoukk/tiktoken-go"
+	tiktoken_loader "github.com/pkoukk/tiktoken-go-loader"
+)
+
+// Token计数方式
+const (
+	TokenizerEstimate = "estimate" // 按字符估算（中日韩字符每字1个Token，其余约4个字符1个Token）
+	TokenizerTiktoken = "tiktoken" // 按模型对应的BPE编码精确计数（未知模型使用cl100k_base）
+)
+
+// defaultTiktokenEncoding 无法按模型名确定编码时使用的编码（与多数开源模型的分词粒度接近）
+const defaultTiktokenEncoding = "cl100k_base"
+
+// Tokenizer 文本Token计数
+type Tokenizer interface {
+	CountTokens(text string) int
+}
+
+// NewTokenizer 按配置创建Token计数器
+// name为estimate、tiktoken或tiktoken编码名（如cl100k_base、o200k_base），为空时使用估算。
+func NewTokenizer(name, model string) (Tokenizer, error) {
+	switch name {
+	case "", TokenizerEstimate:
+		return estimateTokenizer{}, nil
+	case TokenizerTiktoken:
+		encoding := defaultTiktokenEncoding
+		if enc, ok := tiktoken.MODEL_TO_ENCODING[model]; ok {
+			encoding = enc
+		} else {
+			for prefix, enc := range tiktoken.MODEL_PREFIX_TO_ENCODING {
+				if strings.HasPrefix(model, prefix) {
+					encoding = enc
+					break
+				}
+			}
+		}
+		return getTiktokenTokenizer(encoding)
+	default:
+		return getTiktokenTokenizer(name)
+	}
+}
+
+// estimateTokenizer 按字符类别估算Token数
+type estimateTokenizer struct{}
+
+// CountTokens 估算文本Token数
+func (estimateTokenizer) CountTokens(text string) int {
+	return estimateTokens(text)
+}
+
+// tiktokenTokenizer 基于tiktoken的BPE计数
+type tiktokenTokenizer struct {
+	encoding *tiktoken.Tiktoken
+}
+
+// CountTokens 计算文本Token数（不解析特殊Token）
+func (t *tiktokenTokenizer) CountTokens(text string) int {
+	return len(t.encoding.EncodeOrdinary(text))
+}
+
+// tiktoken编码构建开销较大，同一编码在进程内共享
+var (
+	tiktokenLoaderOnce sync.Once
+	tiktokenCache      = make(map[string]*tiktokenTokenizer)
+	tiktokenMu         sync.Mutex
+)
+
+// getTiktokenTokenizer 获取指定编码的计数器（编码表随程序内置，无需联网下载）
+func getTiktokenTokenizer(encoding string) (*tiktokenTokenizer, error) {
+	tiktokenLoaderOnce.Do(func() {
+		tiktoken.SetBpeLoader(tiktoken_loader.NewOfflineLoader())
+	})
+
+	tiktokenMu.Lock()
+	defer tiktokenMu.Unlock()
+
+	if t, ok := tiktokenCache[encoding]; ok {
+		return t, nil
+	}
+	enc, err := tiktoken.GetEncoding(encoding)
+	if err != nil {
+		return nil, fmt.Errorf("加载Token编码失败(%s): %w", encoding, err)
+	}
+	t := &tiktokenTokenizer{encoding: enc}
+	tiktokenCache[encoding] = t
+	return t, nil
+}
+
+// estimateTokens 估算文本Token数
+// 中日韩字符按每字1个Token计算，其余字符按约4个字符1个Token计算。
+func estimateTokens(text string) int {
+	cjk, other := 0, 0
+	for _, r := range text {
+		if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) {
+			cjk++
+		} else {
+			other++
+		}
+	}
+	return cjk + (other+3)/4
+}
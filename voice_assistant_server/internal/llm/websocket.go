@@ -1,11 +1,15 @@
+//go:build !no_llm_websocket
+
 package llm
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -26,8 +30,139 @@ type WebSocketLLM struct {
 	pingTicker          *time.Ticker
 	stopChan            chan struct{}
 	responseChan        chan WebSocketResponse
-	requestID           int64
-	pendingRequests     map[int64]chan LLMResponse
+	requests            *wsRequestTracker
+	writeMu             sync.Mutex // 连接同一时间只允许一个写入者
+}
+
+// WebSocket请求跟踪参数
+const (
+	defaultWSRequestTimeout = 60 * time.Second // 未配置 timeout 时每个请求的期限
+	wsStreamBuffer          = 100              // 流式请求响应通道缓冲
+	wsExpireInterval        = time.Second      // 过期请求检查间隔
+)
+
+// wsPendingRequest 等待响应的请求
+type wsPendingRequest struct {
+	id        int64
+	responses chan LLMResponse
+	done      chan struct{} // 请求结束（完成、超时、取消或连接断开）时关闭
+	timeout   time.Duration
+	deadline  time.Time // 流式请求每收到一段响应顺延
+}
+
+// wsRequestTracker 跟踪等待响应的请求：请求ID原子递增，请求结束时从表中移除并关闭响应通道（只关闭一次）
+type wsRequestTracker struct {
+	nextID  atomic.Int64
+	mu      sync.Mutex
+	pending map[int64]*wsPendingRequest
+}
+
+// newWSRequestTracker 创建请求跟踪器
+func newWSRequestTracker() *wsRequestTracker {
+	return &wsRequestTracker{pending: make(map[int64]*wsPendingRequest)}
+}
+
+// add 登记请求
+func (t *wsRequestTracker) add(buffer int, timeout time.Duration) *wsPendingRequest {
+	if timeout <= 0 {
+		timeout = defaultWSRequestTimeout
+	}
+	req := &wsPendingRequest{
+		id:        t.nextID.Add(1),
+		responses: make(chan LLMResponse, buffer),
+		done:      make(chan struct{}),
+		timeout:   timeout,
+		deadline:  time.Now().Add(timeout),
+	}
+
+	t.mu.Lock()
+	t.pending[req.id] = req
+	t.mu.Unlock()
+	return req
+}
+
+// deliver 转发响应，final为true时结束请求；请求已结束（超时或取消）时忽略
+func (t *wsRequestTracker) deliver(id int64, response LLMResponse, final bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	req, ok := t.pending[id]
+	if !ok {
+		return
+	}
+	req.deadline = time.Now().Add(req.timeout)
+
+	select {
+	case req.responses <- response:
+	default:
+		log.Printf("WebSocketLLM: 请求 %d 的响应通道已满，丢弃响应", id)
+	}
+	if final {
+		t.removeLocked(req)
+	}
+}
+
+// fail 以错误结束请求，返回请求是否仍在等待
+func (t *wsRequestTracker) fail(id int64, err error) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	req, ok := t.pending[id]
+	if !ok {
+		return false
+	}
+	t.failLocked(req, err)
+	return true
+}
+
+// failAll 以错误结束所有请求（连接断开或服务关闭），返回结束的请求数
+func (t *wsRequestTracker) failAll(err error) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	count := len(t.pending)
+	for _, req := range t.pending {
+		t.failLocked(req, err)
+	}
+	return count
+}
+
+// expire 以超时结束已过期的请求，返回结束的请求数
+func (t *wsRequestTracker) expire(now time.Time) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	count := 0
+	for _, req := range t.pending {
+		if now.After(req.deadline) {
+			t.failLocked(req, ErrTimeout)
+			count++
+		}
+	}
+	return count
+}
+
+// len 等待响应的请求数
+func (t *wsRequestTracker) len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.pending)
+}
+
+// failLocked 发送错误响应并结束请求（调用方持有 mu）
+func (t *wsRequestTracker) failLocked(req *wsPendingRequest, err error) {
+	select {
+	case req.responses <- LLMResponse{Error: err, IsComplete: true}:
+	default:
+	}
+	t.removeLocked(req)
+}
+
+// removeLocked 移除请求并关闭响应通道（调用方持有 mu）
+func (t *wsRequestTracker) removeLocked(req *wsPendingRequest) {
+	delete(t.pending, req.id)
+	close(req.responses)
+	close(req.done)
 }
 
 // WebSocketRequest WebSocket请求
@@ -64,13 +199,18 @@ type WebSocketResponse struct {
 // NewWebSocketLLM 创建WebSocket LLM实例
 func NewWebSocketLLM(config LLMConfig) (*WebSocketLLM, error) {
 	w := &WebSocketLLM{
-		config:              config,
-		headers:             config.WebSocketConfig.Headers,
-		stopChan:            make(chan struct{}),
-		responseChan:        make(chan WebSocketResponse, 100),
-		pendingRequests:     make(map[int64]chan LLMResponse),
-		conversationManager: NewConversationManager(100),
+		config:       config,
+		headers:      config.WebSocketConfig.Headers,
+		stopChan:     make(chan struct{}),
+		responseChan: make(chan WebSocketResponse, 100),
+		requests:     newWSRequestTracker(),
+	}
+
+	conversationManager, err := newServiceConversationManager(config, conversationConfigWithMessageLimit(config.Conversation), w.GenerateResponse)
+	if err != nil {
+		return nil, err
 	}
+	w.conversationManager = conversationManager
 
 	return w, nil
 }
@@ -104,12 +244,18 @@ func (w *WebSocketLLM) Initialize(config LLMConfig) error {
 	}
 
 	// 建立连接
-	if err := w.connect(); err != nil {
+	conn, err := w.dial()
+	if err != nil {
 		return fmt.Errorf("连接WebSocket失败: %w", err)
 	}
+	w.conn = conn
+	w.isConnected = true
 
 	// 启动消息处理
-	go w.handleMessages()
+	go w.handleMessages(conn)
+
+	// 启动过期请求清理
+	go w.expireRequests()
 
 	// 启动心跳
 	w.startPing()
@@ -125,101 +271,90 @@ func (w *WebSocketLLM) Initialize(config LLMConfig) error {
 
 // GenerateResponse 生成回复
 func (w *WebSocketLLM) GenerateResponse(ctx context.Context, messages []Message) (LLMResponse, error) {
-	w.mu.RLock()
-	defer w.mu.RUnlock()
-
-	if !w.isInitialized {
-		return LLMResponse{}, ErrLLMNotInitialized
-	}
-
-	if !w.isConnected {
-		return LLMResponse{}, ErrConnectionFailed
-	}
-
 	startTime := time.Now()
 
-	// 生成请求ID
-	w.requestID++
-	requestID := w.requestID
-
-	// 创建请求
-	request := WebSocketRequest{
-		ID:          requestID,
-		Type:        "generate",
-		Model:       w.config.Model,
-		Messages:    messages,
-		Stream:      false,
-		Temperature: w.config.Temperature,
-		TopP:        w.config.TopP,
-		MaxTokens:   w.config.MaxTokens,
-	}
-
-	// 创建响应通道
-	responseChan := make(chan LLMResponse, 1)
-	w.pendingRequests[requestID] = responseChan
-
-	// 发送请求
-	if err := w.conn.WriteJSON(request); err != nil {
-		delete(w.pendingRequests, requestID)
+	req, err := w.sendRequest("generate", false, messages, 1)
+	if err != nil {
 		return LLMResponse{}, err
 	}
 
-	// 等待响应
+	// 等待响应（超时由过期清理处理）
 	select {
-	case response := <-responseChan:
-		delete(w.pendingRequests, requestID)
+	case response, ok := <-req.responses:
+		if !ok {
+			return LLMResponse{}, ErrConnectionFailed
+		}
+		if response.Error != nil {
+			return LLMResponse{}, response.Error
+		}
 		response.ProcessTime = time.Since(startTime).Milliseconds()
 		return response, nil
 	case <-ctx.Done():
-		delete(w.pendingRequests, requestID)
+		w.requests.fail(req.id, ctx.Err())
 		return LLMResponse{}, ctx.Err()
-	case <-time.After(time.Duration(w.config.Timeout) * time.Second):
-		delete(w.pendingRequests, requestID)
-		return LLMResponse{}, ErrTimeout
 	}
 }
 
-// GenerateResponseStream 生成流式回复
+// GenerateResponseStream 生成流式回复（ctx取消、超时或连接断开时以错误响应结束并关闭通道）
 func (w *WebSocketLLM) GenerateResponseStream(ctx context.Context, messages []Message) (<-chan LLMResponse, error) {
+	req, err := w.sendRequest("generate_stream", true, messages, wsStreamBuffer)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			w.requests.fail(req.id, ctx.Err())
+		case <-req.done:
+		}
+	}()
+
+	// 返回响应通道
+	return req.responses, nil
+}
+
+// sendRequest 登记并发送请求
+func (w *WebSocketLLM) sendRequest(requestType string, stream bool, messages []Message, buffer int) (*wsPendingRequest, error) {
 	w.mu.RLock()
-	defer w.mu.RUnlock()
+	initialized, connected, conn, config := w.isInitialized, w.isConnected, w.conn, w.config
+	w.mu.RUnlock()
 
-	if !w.isInitialized {
+	if !initialized {
 		return nil, ErrLLMNotInitialized
 	}
-
-	if !w.isConnected {
+	if !connected {
 		return nil, ErrConnectionFailed
 	}
 
-	// 生成请求ID
-	w.requestID++
-	requestID := w.requestID
-
-	// 创建请求
+	req := w.requests.add(buffer, time.Duration(config.Timeout)*time.Second)
 	request := WebSocketRequest{
-		ID:          requestID,
-		Type:        "generate_stream",
-		Model:       w.config.Model,
+		ID:          req.id,
+		Type:        requestType,
+		Model:       config.Model,
 		Messages:    messages,
-		Stream:      true,
-		Temperature: w.config.Temperature,
-		TopP:        w.config.TopP,
-		MaxTokens:   w.config.MaxTokens,
+		Stream:      stream,
+		Temperature: config.Temperature,
+		TopP:        config.TopP,
+		MaxTokens:   config.MaxTokens,
 	}
 
-	// 创建响应通道
-	responseChan := make(chan LLMResponse, 10)
-	w.pendingRequests[requestID] = responseChan
-
-	// 发送请求
-	if err := w.conn.WriteJSON(request); err != nil {
-		delete(w.pendingRequests, requestID)
+	if err := w.write(conn, func(conn *websocket.Conn) error { return conn.WriteJSON(request) }); err != nil {
+		w.requests.fail(req.id, err)
 		return nil, err
 	}
+	return req, nil
+}
 
-	// 返回响应通道
-	return responseChan, nil
+// write 串行写入连接
+func (w *WebSocketLLM) write(conn *websocket.Conn, fn func(conn *websocket.Conn) error) error {
+	w.writeMu.Lock()
+	defer w.writeMu.Unlock()
+
+	if w.config.WebSocketConfig.WriteTimeout > 0 {
+		conn.SetWriteDeadline(time.Now().Add(time.Duration(w.config.WebSocketConfig.WriteTimeout) * time.Second))
+	}
+	return fn(conn)
 }
 
 // Chat 聊天对话
@@ -241,11 +376,11 @@ func (w *WebSocketLLM) Chat(ctx context.Context, userInput string, conversationI
 
 	// 修剪上下文（如果需要）
 	if w.config.EnableContextTrim {
-		w.trimContext(conv)
+		w.conversationManager.Trim(ctx, conv)
 	}
 
 	// 生成响应
-	response, err := w.GenerateResponse(ctx, conv.Messages)
+	response, err := w.GenerateResponse(ctx, applyRequestOptions(ctx, conv.Messages))
 	if err != nil {
 		return response, err
 	}
@@ -282,11 +417,11 @@ func (w *WebSocketLLM) ChatStream(ctx context.Context, userInput string, convers
 
 	// 修剪上下文（如果需要）
 	if w.config.EnableContextTrim {
-		w.trimContext(conv)
+		w.conversationManager.Trim(ctx, conv)
 	}
 
 	// 生成流式响应
-	responseChan, err := w.GenerateResponseStream(ctx, conv.Messages)
+	responseChan, err := w.GenerateResponseStream(ctx, applyRequestOptions(ctx, conv.Messages))
 	if err != nil {
 		return nil, err
 	}
@@ -348,6 +483,10 @@ func (w *WebSocketLLM) Close() error {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
+	if !w.isInitialized {
+		return nil
+	}
+
 	// 停止定时器
 	if w.pingTicker != nil {
 		w.pingTicker.Stop()
@@ -367,12 +506,26 @@ func (w *WebSocketLLM) Close() error {
 	w.isInitialized = false
 	w.isConnected = false
 
+	// 结束等待中的请求
+	w.requests.failAll(ErrLLMNotInitialized)
+
 	log.Println("WebSocketLLM: 已关闭")
 	return nil
 }
 
-// connect 建立连接
-func (w *WebSocketLLM) connect() error {
+// CheckHealth 检查与LLM服务的WebSocket连接（断线重连期间返回错误）
+func (w *WebSocketLLM) CheckHealth(ctx context.Context) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if !w.isConnected {
+		return fmt.Errorf("未连接到LLM服务: %s", w.url)
+	}
+	return nil
+}
+
+// dial 建立连接
+func (w *WebSocketLLM) dial() (*websocket.Conn, error) {
 	dialer := websocket.Dialer{
 		HandshakeTimeout: 10 * time.Second,
 	}
@@ -385,56 +538,60 @@ func (w *WebSocketLLM) connect() error {
 
 	conn, _, err := dialer.Dial(w.url, header)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	w.conn = conn
-	w.isConnected = true
-
-	// 设置读写超时
+	// 设置读超时
 	if w.config.WebSocketConfig.ReadTimeout > 0 {
-		w.conn.SetReadDeadline(time.Now().Add(time.Duration(w.config.WebSocketConfig.ReadTimeout) * time.Second))
-	}
-	if w.config.WebSocketConfig.WriteTimeout > 0 {
-		w.conn.SetWriteDeadline(time.Now().Add(time.Duration(w.config.WebSocketConfig.WriteTimeout) * time.Second))
+		conn.SetReadDeadline(time.Now().Add(time.Duration(w.config.WebSocketConfig.ReadTimeout) * time.Second))
 	}
 
 	log.Println("WebSocketLLM: 连接成功")
-	return nil
+	return conn, nil
 }
 
-// handleMessages 处理消息
-func (w *WebSocketLLM) handleMessages() {
+// handleMessages 处理消息（每个连接一个），连接断开时以错误结束所有等待中的请求
+func (w *WebSocketLLM) handleMessages(conn *websocket.Conn) {
 	for {
-		select {
-		case <-w.stopChan:
-			return
-		default:
-			var response WebSocketResponse
-			if err := w.conn.ReadJSON(&response); err != nil {
-				if !websocket.IsCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-					log.Printf("WebSocketLLM: 读取消息失败: %v", err)
-				}
-				w.isConnected = false
+		var response WebSocketResponse
+		if err := conn.ReadJSON(&response); err != nil {
+			select {
+			case <-w.stopChan:
 				return
+			default:
 			}
-
-			// 处理响应
-			w.processResponse(response)
+			if !websocket.IsCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
+				log.Printf("WebSocketLLM: 读取消息失败: %v", err)
+			}
+			w.markDisconnected(conn)
+			return
 		}
+
+		// 处理响应
+		w.processResponse(response)
 	}
 }
 
-// processResponse 处理响应
-func (w *WebSocketLLM) processResponse(wsResponse WebSocketResponse) {
-	w.mu.RLock()
-	responseChan, exists := w.pendingRequests[wsResponse.ID]
-	w.mu.RUnlock()
+// markDisconnected 标记连接断开（仅当前连接），等待中的请求不会再收到响应
+func (w *WebSocketLLM) markDisconnected(conn *websocket.Conn) {
+	w.mu.Lock()
+	current := w.conn == conn && w.isConnected
+	if current {
+		w.isConnected = false
+	}
+	w.mu.Unlock()
 
-	if !exists {
+	if !current {
 		return
 	}
+	conn.Close()
+	if count := w.requests.failAll(ErrConnectionFailed); count > 0 {
+		log.Printf("WebSocketLLM: 连接断开，取消等待中的请求: %d个", count)
+	}
+}
 
+// processResponse 处理响应
+func (w *WebSocketLLM) processResponse(wsResponse WebSocketResponse) {
 	// 转换响应格式
 	response := LLMResponse{
 		Content:      wsResponse.Content,
@@ -449,22 +606,27 @@ func (w *WebSocketLLM) processResponse(wsResponse WebSocketResponse) {
 
 	// 处理错误
 	if wsResponse.Error != "" {
-		response.Error = fmt.Errorf(wsResponse.Error)
+		response.Error = errors.New(wsResponse.Error)
 	}
 
-	// 发送响应
-	select {
-	case responseChan <- response:
-	default:
-		// 通道已满，跳过
-	}
+	// 响应完成或出错时结束请求
+	w.requests.deliver(wsResponse.ID, response, wsResponse.IsComplete || wsResponse.Error != "")
+}
+
+// expireRequests 定期以超时结束长时间没有响应的请求（调用方已放弃的流式请求也会被回收）
+func (w *WebSocketLLM) expireRequests() {
+	ticker := time.NewTicker(wsExpireInterval)
+	defer ticker.Stop()
 
-	// 如果响应完成，清理请求
-	if wsResponse.IsComplete || wsResponse.Error != "" {
-		w.mu.Lock()
-		delete(w.pendingRequests, wsResponse.ID)
-		close(responseChan)
-		w.mu.Unlock()
+	for {
+		select {
+		case <-ticker.C:
+			if count := w.requests.expire(time.Now()); count > 0 {
+				log.Printf("WebSocketLLM: 请求超时: %d个", count)
+			}
+		case <-w.stopChan:
+			return
+		}
 	}
 }
 
@@ -479,11 +641,15 @@ func (w *WebSocketLLM) startPing() {
 		for {
 			select {
 			case <-w.pingTicker.C:
-				if w.isConnected {
-					if err := w.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
-						log.Printf("WebSocketLLM: 心跳失败: %v", err)
-						w.isConnected = false
-					}
+				w.mu.RLock()
+				connected, conn := w.isConnected, w.conn
+				w.mu.RUnlock()
+				if !connected {
+					continue
+				}
+				if err := w.write(conn, func(conn *websocket.Conn) error { return conn.WriteMessage(websocket.PingMessage, nil) }); err != nil {
+					log.Printf("WebSocketLLM: 心跳失败: %v", err)
+					w.markDisconnected(conn)
 				}
 			case <-w.stopChan:
 				return
@@ -504,17 +670,35 @@ func (w *WebSocketLLM) startReconnect() {
 		for {
 			select {
 			case <-w.reconnectTicker.C:
-				if !w.isConnected && reconnectCount < w.config.WebSocketConfig.MaxReconnects {
-					log.Printf("WebSocketLLM: 尝试重连 (%d/%d)", reconnectCount+1, w.config.WebSocketConfig.MaxReconnects)
-					if err := w.connect(); err != nil {
-						log.Printf("WebSocketLLM: 重连失败: %v", err)
-						reconnectCount++
-					} else {
-						log.Println("WebSocketLLM: 重连成功")
-						reconnectCount = 0
-						go w.handleMessages()
-					}
+				w.mu.RLock()
+				connected := w.isConnected
+				w.mu.RUnlock()
+				if connected || reconnectCount >= w.config.WebSocketConfig.MaxReconnects {
+					continue
+				}
+
+				log.Printf("WebSocketLLM: 尝试重连 (%d/%d)", reconnectCount+1, w.config.WebSocketConfig.MaxReconnects)
+				conn, err := w.dial()
+				if err != nil {
+					log.Printf("WebSocketLLM: 重连失败: %v", err)
+					reconnectCount++
+					continue
+				}
+
+				w.mu.Lock()
+				if !w.isInitialized {
+					// 重连期间服务已关闭
+					w.mu.Unlock()
+					conn.Close()
+					return
 				}
+				w.conn = conn
+				w.isConnected = true
+				w.mu.Unlock()
+
+				log.Println("WebSocketLLM: 重连成功")
+				reconnectCount = 0
+				go w.handleMessages(conn)
 			case <-w.stopChan:
 				return
 			}
@@ -522,28 +706,14 @@ func (w *WebSocketLLM) startReconnect() {
 	}()
 }
 
-// trimContext 修剪对话上下文
-func (w *WebSocketLLM) trimContext(conv *ConversationContext) {
-	if len(conv.Messages) <= 2 {
-		return
-	}
-
-	// 简单的修剪策略：保留系统提示和最近的消息
-	maxMessages := 10
-	if len(conv.Messages) > maxMessages {
-		systemMessages := make([]Message, 0)
-		recentMessages := make([]Message, 0)
-
-		for i, msg := range conv.Messages {
-			if msg.Role == "system" {
-				systemMessages = append(systemMessages, msg)
-			} else if i >= len(conv.Messages)-maxMessages+len(systemMessages) {
-				recentMessages = append(recentMessages, msg)
-			}
-		}
+// ConversationStats 获取对话管理统计
+func (w *WebSocketLLM) ConversationStats() ConversationStats {
+	return w.conversationManager.Stats()
+}
 
-		conv.Messages = append(systemMessages, recentMessages...)
-	}
+// ConversationHistory 获取对话历史
+func (w *WebSocketLLM) ConversationHistory(conversationID string) []Message {
+	return w.conversationManager.History(conversationID)
 }
 
 // 注册WebSocket LLM
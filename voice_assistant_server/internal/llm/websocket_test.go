@@ -0,0 +1,117 @@
+//go:build !no_llm_websocket
+
+package llm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWSRequestTrackerExpire(t *testing.T) {
+	tracker := newWSRequestTracker()
+	first := tracker.add(1, time.Second)
+	second := tracker.add(1, time.Hour)
+	assert.Equal(t, first.id+1, second.id)
+
+	assert.Equal(t, 1, tracker.expire(time.Now().Add(2*time.Second)))
+	response, ok := <-first.responses
+	require.True(t, ok)
+	assert.ErrorIs(t, response.Error, ErrTimeout)
+	_, ok = <-first.responses
+	assert.False(t, ok, "过期的请求应关闭响应通道")
+
+	// 已结束的请求收到的响应被忽略
+	tracker.deliver(first.id, LLMResponse{Content: "迟到"}, true)
+	assert.Equal(t, 1, tracker.len())
+
+	assert.Equal(t, 1, tracker.failAll(ErrConnectionFailed))
+	<-second.done
+	assert.Equal(t, 0, tracker.len())
+}
+
+// newLLMTestServer 启动WebSocket LLM测试服务端，handle 处理每个请求
+func newLLMTestServer(t *testing.T, handle func(conn *websocket.Conn, request WebSocketRequest)) *httptest.Server {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			var request WebSocketRequest
+			if err := conn.ReadJSON(&request); err != nil {
+				return
+			}
+			handle(conn, request)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestWebSocketLLM(t *testing.T, server *httptest.Server) *WebSocketLLM {
+	config := LLMConfig{Model: "test", Timeout: 5}
+	config.WebSocketConfig.URL = "ws" + strings.TrimPrefix(server.URL, "http")
+
+	w, err := NewWebSocketLLM(config)
+	require.NoError(t, err)
+	require.NoError(t, w.Initialize(config))
+	t.Cleanup(func() { w.Close() })
+	return w
+}
+
+func TestWebSocketLLMStream(t *testing.T) {
+	server := newLLMTestServer(t, func(conn *websocket.Conn, request WebSocketRequest) {
+		conn.WriteJSON(WebSocketResponse{ID: request.ID, Content: "你", IsDelta: true})
+		conn.WriteJSON(WebSocketResponse{ID: request.ID, Content: "好", IsDelta: true, IsComplete: true})
+	})
+	w := newTestWebSocketLLM(t, server)
+
+	responses, err := w.GenerateResponseStream(context.Background(), []Message{{Role: "user", Content: "hi"}})
+	require.NoError(t, err)
+
+	var content string
+	for response := range responses {
+		require.NoError(t, response.Error)
+		content += response.Content
+	}
+	assert.Equal(t, "你好", content)
+	assert.Equal(t, 0, w.requests.len())
+}
+
+func TestWebSocketLLMCancelOnDisconnect(t *testing.T) {
+	server := newLLMTestServer(t, func(conn *websocket.Conn, request WebSocketRequest) {
+		conn.Close()
+	})
+	w := newTestWebSocketLLM(t, server)
+
+	responses, err := w.GenerateResponseStream(context.Background(), []Message{{Role: "user", Content: "hi"}})
+	require.NoError(t, err)
+
+	var last LLMResponse
+	for response := range responses {
+		last = response
+	}
+	assert.ErrorIs(t, last.Error, ErrConnectionFailed)
+	assert.Equal(t, 0, w.requests.len())
+}
+
+func TestWebSocketLLMContextCancel(t *testing.T) {
+	server := newLLMTestServer(t, func(*websocket.Conn, WebSocketRequest) {})
+	w := newTestWebSocketLLM(t, server)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := w.GenerateResponse(ctx, []Message{{Role: "user", Content: "hi"}})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 0, w.requests.len())
+}
@@ -0,0 +1,144 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxFactRunes 单条记忆的最大长度（字符）
+const maxFactRunes = 40
+
+// extractRule 规则提取：匹配的第一个分组为记忆内容
+type extractRule struct {
+	kind    string
+	pattern *regexp.Regexp
+}
+
+// 记忆内容在标点处结束
+const valuePattern = `([^，。！？、；,.!?;\n]{1,20})`
+
+// 规则按顺序匹配（"不喜欢"需在"喜欢"之前）
+var extractRules = []extractRule{
+	{KindName, regexp.MustCompile(`(?:我叫|我的名字(?:是|叫)|叫我)` + valuePattern)},
+	{KindName, regexp.MustCompile(`(?i)\b(?:my name is|call me)\s+([a-z][a-z' -]{0,30}[a-z])`)},
+	{KindLocation, regexp.MustCompile(`我(?:住在|家在|生活在)` + valuePattern)},
+	{KindLocation, regexp.MustCompile(`(?i)\bI live in\s+([a-z][a-z .'-]{0,40}[a-z])`)},
+	{KindDislike, regexp.MustCompile(`我(?:很|最|特别|非常)?(?:不喜欢|讨厌|不爱)` + valuePattern)},
+	{KindDislike, regexp.MustCompile(`(?i)\bI (?:don't like|do not like|hate|dislike)\s+([^,.!?;\n]{1,40})`)},
+	{KindLike, regexp.MustCompile(`我(?:很|最|特别|非常)?喜欢` + valuePattern)},
+	{KindLike, regexp.MustCompile(`(?i)\bI (?:really )?(?:like|love)\s+([^,.!?;\n]{1,40})`)},
+	{KindNote, regexp.MustCompile(`(?:记住|请记得)` + valuePattern)},
+	{KindNote, regexp.MustCompile(`(?i)\bremember (?:that\s+)?([^.!?;\n]{1,60})`)},
+}
+
+// RuleExtractor 基于规则的提取（无需额外调用LLM，覆盖常见的中英文表达）
+type RuleExtractor struct{}
+
+// Extract 提取记忆
+func (RuleExtractor) Extract(ctx context.Context, text string) ([]Fact, error) {
+	var facts []Fact
+	matched := make([]bool, len(text))
+
+	for _, rule := range extractRules {
+		for _, loc := range rule.pattern.FindAllStringSubmatchIndex(text, -1) {
+			// 同一段文字只按最先匹配的规则提取（避免"不喜欢"再被识别为"喜欢"）
+			if overlaps(matched, loc[0], loc[1]) {
+				continue
+			}
+			value := cleanValue(text[loc[2]:loc[3]])
+			if value == "" {
+				continue
+			}
+			for i := loc[0]; i < loc[1]; i++ {
+				matched[i] = true
+			}
+			facts = append(facts, Fact{Kind: rule.kind, Value: value})
+		}
+	}
+	return facts, nil
+}
+
+// overlaps 判断区间内是否有已匹配的文字
+func overlaps(matched []bool, start, end int) bool {
+	for i := start; i < end; i++ {
+		if matched[i] {
+			return true
+		}
+	}
+	return false
+}
+
+// questionMarkers 疑问表达（如"你知道我喜欢什么吗"不是陈述）
+var questionMarkers = []string{"什么", "吗", "哪", "谁", "怎么", "多少", "?"}
+
+// cleanValue 去掉记忆内容首尾的空白和语气词，并限制长度（疑问或不完整的内容返回空）
+func cleanValue(value string) string {
+	value = strings.TrimSpace(value)
+	if value == "" || strings.HasPrefix(value, "的") {
+		return ""
+	}
+	for _, marker := range questionMarkers {
+		if strings.Contains(value, marker) {
+			return ""
+		}
+	}
+	for _, suffix := range []string{"了", "的", "啊", "呢", "哦", "吧", "呀"} {
+		value = strings.TrimSuffix(value, suffix)
+	}
+	value = strings.TrimSpace(value)
+	if utf8.RuneCountInString(value) > maxFactRunes {
+		value = string([]rune(value)[:maxFactRunes])
+	}
+	return value
+}
+
+// llmExtractInstruction LLM提取指令
+const llmExtractInstruction = `你负责维护语音助手的用户长期记忆。从用户的这句话中提取值得长期记住的个人信息：
+称呼(name)、居住地(location)、喜欢的事物(like)、不喜欢的事物(dislike)、其他需要记住的事项(note)。
+只提取用户明确陈述的关于自己的信息，不要推测。按JSON数组输出，例如 [{"kind":"name","value":"小明"}]，没有可提取的信息时输出 []，不要输出其他内容。`
+
+// LLMExtractor 由LLM提取记忆（理解能力更强，每轮对话额外调用一次LLM）
+type LLMExtractor struct {
+	generate GenerateFunc
+}
+
+// NewLLMExtractor 创建LLM提取器
+func NewLLMExtractor(generate GenerateFunc) *LLMExtractor {
+	return &LLMExtractor{generate: generate}
+}
+
+// Extract 提取记忆
+func (e *LLMExtractor) Extract(ctx context.Context, text string) ([]Fact, error) {
+	output, err := e.generate(ctx, llmExtractInstruction, text)
+	if err != nil {
+		return nil, err
+	}
+
+	// 模型可能在JSON前后附带说明文字或代码块标记
+	start, end := strings.Index(output, "["), strings.LastIndex(output, "]")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("记忆提取结果不是JSON数组: %q", output)
+	}
+
+	var items []Fact
+	if err := json.Unmarshal([]byte(output[start:end+1]), &items); err != nil {
+		return nil, fmt.Errorf("解析记忆提取结果失败: %w", err)
+	}
+
+	facts := make([]Fact, 0, len(items))
+	for _, item := range items {
+		switch item.Kind {
+		case KindName, KindLocation, KindLike, KindDislike, KindNote:
+		default:
+			continue
+		}
+		if value := cleanValue(item.Value); value != "" {
+			facts = append(facts, Fact{Kind: item.Kind, Value: value})
+		}
+	}
+	return facts, nil
+}
@@ -0,0 +1,93 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// 长期记忆相关错误定义
+var (
+	ErrUnsupportedStoreType     = errors.New("unsupported memory store type")
+	ErrUnsupportedExtractorType = errors.New("unsupported memory extractor type")
+)
+
+// 记忆类别
+const (
+	KindName     = "name"     // 称呼（单值）
+	KindLocation = "location" // 居住地（单值）
+	KindLike     = "like"     // 喜好
+	KindDislike  = "dislike"  // 不喜欢的事物
+	KindNote     = "note"     // 其他需要记住的信息
+)
+
+// Fact 关于用户的一条长期记忆
+type Fact struct {
+	Kind      string    `json:"kind"`
+	Value     string    `json:"value"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Profile 用户画像（按用户ID保存的全部记忆）
+type Profile struct {
+	UserID    string    `json:"user_id"`
+	Facts     []Fact    `json:"facts"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Store 用户画像存储接口
+type Store interface {
+	// Load 读取用户画像（不存在时返回nil）
+	Load(ctx context.Context, userID string) (*Profile, error)
+
+	// Save 保存用户画像
+	Save(ctx context.Context, profile *Profile) error
+
+	// Delete 删除用户画像
+	Delete(ctx context.Context, userID string) error
+
+	// Close 关闭存储
+	Close() error
+}
+
+// Extractor 从用户的话中提取需要长期记住的信息
+type Extractor interface {
+	Extract(ctx context.Context, text string) ([]Fact, error)
+}
+
+// GenerateFunc 调用LLM生成文本（llm提取方式使用）
+type GenerateFunc func(ctx context.Context, instruction, text string) (string, error)
+
+// Config 长期记忆配置
+type Config struct {
+	Enabled   bool              `yaml:"enabled"`   // 是否启用（仅对能确定用户身份的会话生效）
+	Store     string            `yaml:"store"`     // 存储类型: file|memory
+	Path      string            `yaml:"path"`      // file存储目录
+	Extractor string            `yaml:"extractor"` // 提取方式: rules（规则匹配）|llm（每轮额外调用一次LLM）
+	MaxFacts  int               `yaml:"max_facts"` // 每个用户保留的记忆条数上限
+	Users     map[string]string `yaml:"users"`     // API Key到用户ID的映射，连接携带的API Key决定记忆归属
+
+	// SpeakerFallback API Key未映射用户时按声纹识别的说话人区分记忆
+	// 声纹可被录音或合成语音冒充，误识别也会把他人的记忆带入对话，默认关闭。
+	SpeakerFallback bool `yaml:"speaker_fallback"`
+}
+
+// StoreFactory 存储工厂函数类型
+type StoreFactory func(config Config) (Store, error)
+
+// 注册的存储实现
+var storeFactories = make(map[string]StoreFactory)
+
+// RegisterStore 注册存储实现
+func RegisterStore(name string, factory StoreFactory) {
+	storeFactories[name] = factory
+}
+
+// CreateStore 创建存储
+func CreateStore(config Config) (Store, error) {
+	factory, exists := storeFactories[config.Store]
+	if !exists {
+		return nil, ErrUnsupportedStoreType
+	}
+	return factory(config)
+}
@@ -0,0 +1,210 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultMaxFacts 每个用户默认保留的记忆条数
+const defaultMaxFacts = 50
+
+// kindLabels 记忆类别在系统提示中的名称（按输出顺序）
+var kindLabels = []struct {
+	kind  string
+	label string
+}{
+	{KindName, "称呼"},
+	{KindLocation, "居住地"},
+	{KindLike, "喜欢"},
+	{KindDislike, "不喜欢"},
+	{KindNote, "其他"},
+}
+
+// Manager 长期记忆管理：从对话中提取用户信息并合并到用户画像，生成注入系统提示的记忆摘要
+type Manager struct {
+	store     Store
+	extractor Extractor
+	maxFacts  int
+	now       func() time.Time
+
+	// 同一用户的读改写串行执行
+	locks map[string]*sync.Mutex
+	mu    sync.Mutex
+}
+
+// NewManager 创建记忆管理器（extractor为llm时generate不能为空）
+func NewManager(config Config, generate GenerateFunc) (*Manager, error) {
+	if config.Store == "" {
+		config.Store = "file"
+	}
+	if config.MaxFacts <= 0 {
+		config.MaxFacts = defaultMaxFacts
+	}
+
+	store, err := CreateStore(config)
+	if err != nil {
+		return nil, fmt.Errorf("创建记忆存储失败(%s): %w", config.Store, err)
+	}
+
+	var extractor Extractor
+	switch config.Extractor {
+	case "", "rules":
+		extractor = RuleExtractor{}
+	case "llm":
+		if generate == nil {
+			return nil, fmt.Errorf("llm记忆提取需要可用的LLM服务")
+		}
+		extractor = NewLLMExtractor(generate)
+	default:
+		return nil, ErrUnsupportedExtractorType
+	}
+
+	return &Manager{
+		store:     store,
+		extractor: extractor,
+		maxFacts:  config.MaxFacts,
+		now:       time.Now,
+		locks:     make(map[string]*sync.Mutex),
+	}, nil
+}
+
+// Remember 从用户的话中提取记忆并保存，返回新增或更新的条数
+func (m *Manager) Remember(ctx context.Context, userID, text string) (int, error) {
+	facts, err := m.extractor.Extract(ctx, text)
+	if err != nil {
+		return 0, fmt.Errorf("提取记忆失败: %w", err)
+	}
+	if len(facts) == 0 {
+		return 0, nil
+	}
+
+	lock := m.userLock(userID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	profile, err := m.store.Load(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	if profile == nil {
+		profile = &Profile{UserID: userID}
+	}
+
+	now := m.now()
+	changed := 0
+	for _, fact := range facts {
+		fact.UpdatedAt = now
+		if mergeFact(profile, fact) {
+			changed++
+		}
+	}
+	if changed == 0 {
+		return 0, nil
+	}
+
+	// 超出上限时丢弃最早更新的记忆
+	for len(profile.Facts) > m.maxFacts {
+		oldest := 0
+		for i, fact := range profile.Facts {
+			if fact.UpdatedAt.Before(profile.Facts[oldest].UpdatedAt) {
+				oldest = i
+			}
+		}
+		profile.Facts = append(profile.Facts[:oldest], profile.Facts[oldest+1:]...)
+	}
+
+	profile.UpdatedAt = now
+	if err := m.store.Save(ctx, profile); err != nil {
+		return 0, err
+	}
+	return changed, nil
+}
+
+// mergeFact 合并一条记忆，返回画像是否变化
+// 称呼和居住地只保留最新值；喜欢与不喜欢互斥，后说的为准。
+func mergeFact(profile *Profile, fact Fact) bool {
+	opposite := ""
+	switch fact.Kind {
+	case KindLike:
+		opposite = KindDislike
+	case KindDislike:
+		opposite = KindLike
+	}
+
+	for _, existing := range profile.Facts {
+		if existing.Kind == fact.Kind && existing.Value == fact.Value {
+			return false // 已记住，不改变顺序和时间
+		}
+	}
+
+	single := fact.Kind == KindName || fact.Kind == KindLocation
+	facts := profile.Facts[:0]
+	for _, existing := range profile.Facts {
+		if single && existing.Kind == fact.Kind {
+			continue
+		}
+		if existing.Kind == opposite && existing.Value == fact.Value {
+			continue
+		}
+		facts = append(facts, existing)
+	}
+	profile.Facts = append(facts, fact)
+	return true
+}
+
+// Prompt 生成注入系统提示的用户记忆（没有记忆时返回空）
+func (m *Manager) Prompt(ctx context.Context, userID string) (string, error) {
+	profile, err := m.store.Load(ctx, userID)
+	if err != nil || profile == nil || len(profile.Facts) == 0 {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString("以下是之前对话中记住的用户信息，回答时自然地参考，不要逐条复述：")
+	for _, kl := range kindLabels {
+		var values []string
+		for _, fact := range profile.Facts {
+			if fact.Kind == kl.kind {
+				values = append(values, fact.Value)
+			}
+		}
+		if len(values) > 0 {
+			b.WriteString("\n- " + kl.label + "：" + strings.Join(values, "、"))
+		}
+	}
+	return b.String(), nil
+}
+
+// Profile 获取用户画像（不存在时返回nil）
+func (m *Manager) Profile(ctx context.Context, userID string) (*Profile, error) {
+	return m.store.Load(ctx, userID)
+}
+
+// Forget 删除用户的全部记忆
+func (m *Manager) Forget(ctx context.Context, userID string) error {
+	lock := m.userLock(userID)
+	lock.Lock()
+	defer lock.Unlock()
+	return m.store.Delete(ctx, userID)
+}
+
+// Close 关闭存储
+func (m *Manager) Close() error {
+	return m.store.Close()
+}
+
+// userLock 获取用户的读改写锁
+func (m *Manager) userLock(userID string) *sync.Mutex {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	lock, exists := m.locks[userID]
+	if !exists {
+		lock = &sync.Mutex{}
+		m.locks[userID] = lock
+	}
+	return lock
+}
@@ -0,0 +1,115 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuleExtractor(t *testing.T) {
+	facts, err := RuleExtractor{}.Extract(context.Background(), "我叫小明，我住在杭州。我不喜欢香菜，我最喜欢爵士乐")
+	require.NoError(t, err)
+	assert.Equal(t, []Fact{
+		{Kind: KindName, Value: "小明"},
+		{Kind: KindLocation, Value: "杭州"},
+		{Kind: KindDislike, Value: "香菜"},
+		{Kind: KindLike, Value: "爵士乐"},
+	}, facts)
+
+	facts, err = RuleExtractor{}.Extract(context.Background(), "My name is Alice. I love hiking")
+	require.NoError(t, err)
+	assert.Equal(t, []Fact{
+		{Kind: KindName, Value: "Alice"},
+		{Kind: KindLike, Value: "hiking"},
+	}, facts)
+
+	// 疑问句不是陈述
+	facts, err = RuleExtractor{}.Extract(context.Background(), "你知道我喜欢什么吗")
+	require.NoError(t, err)
+	assert.Empty(t, facts)
+}
+
+func TestManagerRememberAndPrompt(t *testing.T) {
+	ctx := context.Background()
+	m, err := NewManager(Config{Store: "memory"}, nil)
+	require.NoError(t, err)
+
+	n, err := m.Remember(ctx, "u1", "我叫小明，我喜欢香菜")
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	// 重复的信息不计入变化；称呼只保留最新值；喜欢和不喜欢互斥
+	n, err = m.Remember(ctx, "u1", "我叫小明")
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+	_, err = m.Remember(ctx, "u1", "叫我明明。我讨厌香菜")
+	require.NoError(t, err)
+
+	prompt, err := m.Prompt(ctx, "u1")
+	require.NoError(t, err)
+	assert.Contains(t, prompt, "称呼：明明")
+	assert.Contains(t, prompt, "不喜欢：香菜")
+	assert.NotContains(t, prompt, "小明")
+	assert.NotContains(t, prompt, "- 喜欢")
+
+	// 其他用户互不影响
+	prompt, err = m.Prompt(ctx, "u2")
+	require.NoError(t, err)
+	assert.Empty(t, prompt)
+
+	require.NoError(t, m.Forget(ctx, "u1"))
+	profile, err := m.Profile(ctx, "u1")
+	require.NoError(t, err)
+	assert.Nil(t, profile)
+}
+
+func TestManagerMaxFacts(t *testing.T) {
+	ctx := context.Background()
+	m, err := NewManager(Config{Store: "memory", MaxFacts: 2}, nil)
+	require.NoError(t, err)
+
+	for _, text := range []string{"我喜欢猫", "我喜欢狗", "我喜欢鱼"} {
+		_, err := m.Remember(ctx, "u1", text)
+		require.NoError(t, err)
+	}
+
+	profile, err := m.Profile(ctx, "u1")
+	require.NoError(t, err)
+	require.Len(t, profile.Facts, 2)
+	assert.Equal(t, "狗", profile.Facts[0].Value)
+	assert.Equal(t, "鱼", profile.Facts[1].Value)
+}
+
+func TestLLMExtractor(t *testing.T) {
+	e := NewLLMExtractor(func(ctx context.Context, instruction, text string) (string, error) {
+		return "```json\n[{\"kind\":\"location\",\"value\":\"上海\"},{\"kind\":\"age\",\"value\":\"30\"}]\n```", nil
+	})
+
+	facts, err := e.Extract(context.Background(), "我刚搬到上海")
+	require.NoError(t, err)
+	assert.Equal(t, []Fact{{Kind: KindLocation, Value: "上海"}}, facts)
+}
+
+func TestFileStore(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewFileStore(Config{Path: t.TempDir()})
+	require.NoError(t, err)
+
+	profile, err := store.Load(ctx, "user/1")
+	require.NoError(t, err)
+	assert.Nil(t, profile)
+
+	require.NoError(t, store.Save(ctx, &Profile{UserID: "user/1", Facts: []Fact{{Kind: KindName, Value: "小明"}}}))
+	profile, err = store.Load(ctx, "user/1")
+	require.NoError(t, err)
+	require.NotNil(t, profile)
+	assert.Equal(t, "小明", profile.Facts[0].Value)
+
+	require.NoError(t, store.Delete(ctx, "user/1"))
+	require.NoError(t, store.Delete(ctx, "user/1"))
+	profile, err = store.Load(ctx, "user/1")
+	require.NoError(t, err)
+	assert.Nil(t, profile)
+}
@@ -0,0 +1,144 @@
+package memory
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FileStore 本地目录存储：每个用户一个JSON文件，文件名为用户ID的哈希
+type FileStore struct {
+	root string
+}
+
+// NewFileStore 创建本地目录存储
+func NewFileStore(config Config) (*FileStore, error) {
+	root := config.Path
+	if root == "" {
+		root = "data/memory"
+	}
+
+	if err := os.MkdirAll(root, 0700); err != nil {
+		return nil, fmt.Errorf("创建记忆目录失败: %w", err)
+	}
+	return &FileStore{root: root}, nil
+}
+
+// Load 读取用户画像
+func (s *FileStore) Load(ctx context.Context, userID string) (*Profile, error) {
+	data, err := os.ReadFile(s.path(userID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取用户记忆失败: %w", err)
+	}
+
+	var profile Profile
+	if err := json.Unmarshal(data, &profile); err != nil {
+		return nil, fmt.Errorf("解析用户记忆失败: %w", err)
+	}
+	return &profile, nil
+}
+
+// Save 保存用户画像（先写临时文件再替换，避免写入中断损坏原文件）
+func (s *FileStore) Save(ctx context.Context, profile *Profile) error {
+	data, err := json.MarshalIndent(profile, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化用户记忆失败: %w", err)
+	}
+
+	path := s.path(profile.UserID)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("写入用户记忆失败: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("写入用户记忆失败: %w", err)
+	}
+	return nil
+}
+
+// Delete 删除用户画像
+func (s *FileStore) Delete(ctx context.Context, userID string) error {
+	if err := os.Remove(s.path(userID)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("删除用户记忆失败: %w", err)
+	}
+	return nil
+}
+
+// Close 关闭存储
+func (s *FileStore) Close() error {
+	return nil
+}
+
+// path 用户画像文件路径（用户ID可能包含任意字符，使用哈希作为文件名）
+func (s *FileStore) path(userID string) string {
+	sum := sha256.Sum256([]byte(userID))
+	return filepath.Join(s.root, hex.EncodeToString(sum[:16])+".json")
+}
+
+// MemoryStore 进程内存储（重启后丢失，用于测试和临时部署）
+type MemoryStore struct {
+	profiles map[string]Profile
+	mu       sync.RWMutex
+}
+
+// NewMemoryStore 创建进程内存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{profiles: make(map[string]Profile)}
+}
+
+// Load 读取用户画像
+func (s *MemoryStore) Load(ctx context.Context, userID string) (*Profile, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	profile, exists := s.profiles[userID]
+	if !exists {
+		return nil, nil
+	}
+	profile.Facts = append([]Fact(nil), profile.Facts...)
+	return &profile, nil
+}
+
+// Save 保存用户画像
+func (s *MemoryStore) Save(ctx context.Context, profile *Profile) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := *profile
+	stored.Facts = append([]Fact(nil), profile.Facts...)
+	s.profiles[profile.UserID] = stored
+	return nil
+}
+
+// Delete 删除用户画像
+func (s *MemoryStore) Delete(ctx context.Context, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.profiles, userID)
+	return nil
+}
+
+// Close 关闭存储
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+// 注册存储实现
+func init() {
+	RegisterStore("file", func(config Config) (Store, error) {
+		return NewFileStore(config)
+	})
+	RegisterStore("memory", func(config Config) (Store, error) {
+		return NewMemoryStore(), nil
+	})
+}
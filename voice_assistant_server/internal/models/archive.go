@@ -0,0 +1,93 @@
+package models
+
+import (
+	"archive/tar"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 支持的压缩包格式
+var archiveFormats = []string{".tar.gz", ".tgz", ".tar.bz2"}
+
+// archiveFormat 下载地址对应的压缩包格式（不是压缩包时为空）
+func archiveFormat(url string) string {
+	url = strings.SplitN(url, "?", 2)[0]
+	for _, format := range archiveFormats {
+		if strings.HasSuffix(url, format) {
+			return format
+		}
+	}
+	return ""
+}
+
+// extractArchive 把压缩包解压到目录（拒绝指向目录外的条目）
+func extractArchive(file, format, dir string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return fmt.Errorf("打开压缩包失败: %w", err)
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if format == ".tar.bz2" {
+		r = bzip2.NewReader(f)
+	} else {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("解压失败: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("解压失败: %w", err)
+	}
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("解压失败: %w", err)
+		}
+
+		target := filepath.Join(root, header.Name)
+		if target != root && !strings.HasPrefix(target, root+string(os.PathSeparator)) {
+			return fmt.Errorf("压缩包中的路径不合法: %s", header.Name)
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return fmt.Errorf("解压失败: %w", err)
+			}
+		case tar.TypeReg:
+			if err := extractFile(tr, target, header.FileInfo().Mode()); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// extractFile 写出压缩包中的一个文件
+func extractFile(r io.Reader, target string, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("解压失败: %w", err)
+	}
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode.Perm()|0600)
+	if err != nil {
+		return fmt.Errorf("解压失败: %w", err)
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		return fmt.Errorf("解压失败: %w", err)
+	}
+	return out.Close()
+}
@@ -0,0 +1,333 @@
+package models
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// progressInterval 下载进度日志的间隔
+const progressInterval = 5 * time.Second
+
+// ErrChecksumMismatch 下载或已有的模型文件校验失败
+var ErrChecksumMismatch = errors.New("模型文件sha256校验失败")
+
+// Config 模型文件管理配置
+type Config struct {
+	Dir          string `yaml:"dir"`           // 模型目录（模型项未指定path时下载到该目录）
+	AutoDownload bool   `yaml:"auto_download"` // 启动时下载当前使用的提供商缺失的模型文件
+	Files        []File `yaml:"files"`         // 可下载的模型文件
+}
+
+// File 一个可下载的模型文件
+// URL以.tar.gz、.tgz或.tar.bz2结尾时按压缩包处理：解压到path的上级目录，解压后path须存在。
+type File struct {
+	Name     string `yaml:"name"`     // 名称（命令行中指定要下载的模型）
+	Provider string `yaml:"provider"` // 使用该模型的提供商（如whisper、sherpa），为空表示总是需要
+	URL      string `yaml:"url"`      // 下载地址
+	Path     string `yaml:"path"`     // 保存位置（压缩包为解压后的目录），为空时保存到模型目录下
+	SHA256   string `yaml:"sha256"`   // 下载文件的sha256，为空时不校验
+}
+
+// FileStatus 模型文件的安装状态
+type FileStatus struct {
+	Name      string `json:"name"`
+	Path      string `json:"path"`
+	Installed bool   `json:"installed"`
+	Partial   int64  `json:"partial,omitempty"` // 未下载完的字节数（可续传）
+}
+
+// Manager 模型文件管理：下载（支持断点续传）、sha256校验和解压
+type Manager struct {
+	config Config
+	client *http.Client
+}
+
+// NewManager 创建模型文件管理（client为nil时使用http.DefaultClient）
+func NewManager(config Config, client *http.Client) (*Manager, error) {
+	seen := make(map[string]bool)
+	for _, file := range config.Files {
+		if file.URL == "" {
+			return nil, fmt.Errorf("模型 %s 缺少下载地址", file.Name)
+		}
+		name := fileName(file)
+		if seen[name] {
+			return nil, fmt.Errorf("模型名称重复: %s", name)
+		}
+		seen[name] = true
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Manager{config: config, client: client}, nil
+}
+
+// fileName 模型名称（未配置时取下载地址中的文件名）
+func fileName(file File) string {
+	if file.Name != "" {
+		return file.Name
+	}
+	return path.Base(strings.SplitN(file.URL, "?", 2)[0])
+}
+
+// isArchive 下载的是否为需要解压的压缩包
+func isArchive(file File) bool {
+	return archiveFormat(file.URL) != ""
+}
+
+// targetPath 模型文件（或解压后的目录）的保存位置
+func (m *Manager) targetPath(file File) string {
+	if file.Path != "" {
+		return file.Path
+	}
+	name := path.Base(strings.SplitN(file.URL, "?", 2)[0])
+	if format := archiveFormat(name); format != "" {
+		name = strings.TrimSuffix(name, format)
+	}
+	return filepath.Join(m.config.Dir, name)
+}
+
+// partPath 下载中的临时文件
+func (m *Manager) partPath(file File) string {
+	target := m.targetPath(file)
+	if isArchive(file) {
+		return target + archiveFormat(file.URL) + ".part"
+	}
+	return target + ".part"
+}
+
+// Select 按名称选择模型（names为空时返回全部）
+func (m *Manager) Select(names []string) ([]File, error) {
+	if len(names) == 0 {
+		return m.config.Files, nil
+	}
+	var files []File
+	for _, name := range names {
+		found := false
+		for _, file := range m.config.Files {
+			if fileName(file) == name {
+				files = append(files, file)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("未配置模型: %s", name)
+		}
+	}
+	return files, nil
+}
+
+// Required 当前使用的提供商需要的模型（未指定提供商的模型总是需要）
+func (m *Manager) Required(providers []string) []File {
+	var files []File
+	for _, file := range m.config.Files {
+		if file.Provider == "" {
+			files = append(files, file)
+			continue
+		}
+		for _, provider := range providers {
+			if file.Provider == provider {
+				files = append(files, file)
+				break
+			}
+		}
+	}
+	return files
+}
+
+// Status 各模型文件的安装状态
+func (m *Manager) Status(files []File) []FileStatus {
+	statuses := make([]FileStatus, 0, len(files))
+	for _, file := range files {
+		status := FileStatus{Name: fileName(file), Path: m.targetPath(file)}
+		if _, err := os.Stat(status.Path); err == nil {
+			status.Installed = true
+		} else if info, err := os.Stat(m.partPath(file)); err == nil {
+			status.Partial = info.Size()
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// EnsureAll 下载缺失的模型文件（已存在的跳过），返回第一个失败的错误
+func (m *Manager) EnsureAll(ctx context.Context, files []File) error {
+	for _, file := range files {
+		if _, err := os.Stat(m.targetPath(file)); err == nil {
+			continue
+		}
+		if err := m.Download(ctx, file); err != nil {
+			return fmt.Errorf("下载模型 %s 失败: %w", fileName(file), err)
+		}
+	}
+	return nil
+}
+
+// Verify 校验已下载的模型文件（压缩包解压后无法校验，只检查目录是否存在）
+func (m *Manager) Verify(file File) error {
+	target := m.targetPath(file)
+	if _, err := os.Stat(target); err != nil {
+		return fmt.Errorf("模型文件不存在: %s", target)
+	}
+	if isArchive(file) || file.SHA256 == "" {
+		return nil
+	}
+	return checkSHA256(target, file.SHA256)
+}
+
+// Download 下载模型文件：已有未完成的临时文件时断点续传，完成后校验sha256，压缩包解压到目标目录
+func (m *Manager) Download(ctx context.Context, file File) error {
+	target := m.targetPath(file)
+	part := m.partPath(file)
+	if err := os.MkdirAll(filepath.Dir(part), 0755); err != nil {
+		return fmt.Errorf("创建模型目录失败: %w", err)
+	}
+
+	if err := m.fetch(ctx, file, part); err != nil {
+		return err
+	}
+	if file.SHA256 != "" {
+		if err := checkSHA256(part, file.SHA256); err != nil {
+			// 校验失败的文件不能用于续传
+			os.Remove(part)
+			return err
+		}
+	} else {
+		log.Printf("模型 %s 未配置sha256，跳过校验", fileName(file))
+	}
+
+	if isArchive(file) {
+		if err := extractArchive(part, archiveFormat(file.URL), filepath.Dir(target)); err != nil {
+			return err
+		}
+		os.Remove(part)
+		if _, err := os.Stat(target); err != nil {
+			return fmt.Errorf("解压后未找到 %s，请检查path配置", target)
+		}
+	} else if err := os.Rename(part, target); err != nil {
+		return fmt.Errorf("保存模型文件失败: %w", err)
+	}
+	log.Printf("模型 %s 已就绪: %s", fileName(file), target)
+	return nil
+}
+
+// fetch 把下载内容写入临时文件（已有内容时请求剩余部分）
+func (m *Manager) fetch(ctx context.Context, file File, part string) error {
+	var offset int64
+	if info, err := os.Stat(part); err == nil {
+		offset = info.Size()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, file.URL, nil)
+	if err != nil {
+		return fmt.Errorf("创建下载请求失败: %w", err)
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("下载失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	switch {
+	case resp.StatusCode == http.StatusPartialContent:
+		flags |= os.O_APPEND
+		log.Printf("模型 %s 从 %s 处继续下载", fileName(file), formatBytes(offset))
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// 临时文件已完整
+		return nil
+	case resp.StatusCode == http.StatusOK:
+		// 服务器不支持续传，重新下载
+		flags |= os.O_TRUNC
+		offset = 0
+	default:
+		return fmt.Errorf("下载失败: HTTP %d", resp.StatusCode)
+	}
+
+	out, err := os.OpenFile(part, flags, 0644)
+	if err != nil {
+		return fmt.Errorf("创建临时文件失败: %w", err)
+	}
+	defer out.Close()
+
+	total := int64(-1)
+	if resp.ContentLength >= 0 {
+		total = offset + resp.ContentLength
+	}
+	progress := &progressWriter{name: fileName(file), written: offset, total: total, logged: time.Now()}
+	log.Printf("开始下载模型 %s: %s (%s)", progress.name, file.URL, formatBytes(total))
+	if _, err := io.Copy(out, io.TeeReader(resp.Body, progress)); err != nil {
+		return fmt.Errorf("下载中断（已保存 %s，可重新执行以续传）: %w", formatBytes(progress.written), err)
+	}
+	return out.Close()
+}
+
+// progressWriter 定期输出下载进度
+type progressWriter struct {
+	name    string
+	written int64
+	total   int64
+	logged  time.Time
+}
+
+// Write 累计已下载字节数
+func (p *progressWriter) Write(b []byte) (int, error) {
+	p.written += int64(len(b))
+	if time.Since(p.logged) >= progressInterval {
+		p.logged = time.Now()
+		if p.total > 0 {
+			log.Printf("下载模型 %s: %s / %s (%.1f%%)", p.name, formatBytes(p.written), formatBytes(p.total), float64(p.written)*100/float64(p.total))
+		} else {
+			log.Printf("下载模型 %s: %s", p.name, formatBytes(p.written))
+		}
+	}
+	return len(b), nil
+}
+
+// checkSHA256 校验文件的sha256
+func checkSHA256(file, expected string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return fmt.Errorf("读取模型文件失败: %w", err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return fmt.Errorf("读取模型文件失败: %w", err)
+	}
+	if actual := hex.EncodeToString(h.Sum(nil)); !strings.EqualFold(actual, expected) {
+		return fmt.Errorf("%w: %s (期望 %s，实际 %s)", ErrChecksumMismatch, file, expected, actual)
+	}
+	return nil
+}
+
+// formatBytes 可读的字节数（未知时为“未知大小”）
+func formatBytes(n int64) string {
+	switch {
+	case n < 0:
+		return "未知大小"
+	case n >= 1<<30:
+		return fmt.Sprintf("%.2fGB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1fMB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1fKB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%dB", n)
+	}
+}
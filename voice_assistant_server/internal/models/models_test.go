@@ -0,0 +1,138 @@
+package models
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serveFiles 提供模型文件下载（支持Range请求），记录每次请求的Range头
+func serveFiles(t *testing.T, files map[string][]byte) (*httptest.Server, *[]string) {
+	var ranges []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		ranges = append(ranges, r.Header.Get("Range"))
+		http.ServeContent(w, r, r.URL.Path, time.Time{}, bytes.NewReader(data))
+	}))
+	t.Cleanup(server.Close)
+	return server, &ranges
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestDownloadResume(t *testing.T) {
+	model := bytes.Repeat([]byte("ggml"), 1000)
+	server, ranges := serveFiles(t, map[string][]byte{"/ggml-base.bin": model})
+	dir := t.TempDir()
+
+	m, err := NewManager(Config{Dir: dir, Files: []File{
+		{Name: "whisper-base", Provider: "whisper", URL: server.URL + "/ggml-base.bin", SHA256: sha256Hex(model)},
+	}}, nil)
+	require.NoError(t, err)
+	file := m.config.Files[0]
+
+	// 上次下载到一半
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ggml-base.bin.part"), model[:1500], 0644))
+	assert.Equal(t, int64(1500), m.Status(m.config.Files)[0].Partial)
+
+	// 只下载当前提供商需要的模型
+	assert.Empty(t, m.Required([]string{"funasr"}))
+	require.NoError(t, m.EnsureAll(context.Background(), m.Required([]string{"whisper"})))
+	assert.Equal(t, []string{"bytes=1500-"}, *ranges)
+	data, err := os.ReadFile(filepath.Join(dir, "ggml-base.bin"))
+	require.NoError(t, err)
+	assert.Equal(t, model, data)
+	assert.True(t, m.Status(m.config.Files)[0].Installed)
+	assert.NoError(t, m.Verify(file))
+
+	// 已安装的不再下载
+	require.NoError(t, m.EnsureAll(context.Background(), m.config.Files))
+	assert.Len(t, *ranges, 1)
+
+	// 文件损坏后校验失败
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ggml-base.bin"), []byte("broken"), 0644))
+	assert.ErrorIs(t, m.Verify(file), ErrChecksumMismatch)
+}
+
+func TestDownloadChecksumMismatch(t *testing.T) {
+	server, _ := serveFiles(t, map[string][]byte{"/model.onnx": []byte("model")})
+	dir := t.TempDir()
+
+	m, err := NewManager(Config{Dir: dir, Files: []File{{URL: server.URL + "/model.onnx", SHA256: sha256Hex([]byte("other"))}}}, nil)
+	require.NoError(t, err)
+	err = m.Download(context.Background(), m.config.Files[0])
+	assert.ErrorIs(t, err, ErrChecksumMismatch)
+
+	// 校验失败的文件不保留，也不能续传
+	_, err = os.Stat(filepath.Join(dir, "model.onnx"))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(dir, "model.onnx.part"))
+	assert.True(t, os.IsNotExist(err))
+
+	_, err = m.Select([]string{"missing"})
+	assert.Error(t, err)
+}
+
+func TestDownloadArchive(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range map[string]string{"vits-zh/model.onnx": "onnx", "vits-zh/tokens.txt": "tokens"} {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+
+	server, _ := serveFiles(t, map[string][]byte{"/vits-zh.tar.gz": buf.Bytes()})
+	dir := t.TempDir()
+	m, err := NewManager(Config{Dir: dir, Files: []File{{Name: "sherpa", URL: server.URL + "/vits-zh.tar.gz", SHA256: sha256Hex(buf.Bytes())}}}, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, m.EnsureAll(context.Background(), m.config.Files))
+	data, err := os.ReadFile(filepath.Join(dir, "vits-zh", "tokens.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "tokens", string(data))
+	assert.True(t, m.Status(m.config.Files)[0].Installed)
+	_, err = os.Stat(filepath.Join(dir, "vits-zh.tar.gz.part"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestExtractArchiveRejectsTraversal(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "../evil.txt", Mode: 0644, Size: 1, Typeflag: tar.TypeReg}))
+	_, err := tw.Write([]byte("x"))
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "evil.tar.gz")
+	require.NoError(t, os.WriteFile(archive, buf.Bytes(), 0644))
+	err = extractArchive(archive, ".tar.gz", filepath.Join(dir, "out"))
+	require.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), "不合法"))
+}
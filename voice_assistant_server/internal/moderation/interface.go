@@ -0,0 +1,107 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"sort"
+)
+
+// 内容审核相关错误定义
+var (
+	ErrUnsupportedClassifierType = errors.New("unsupported moderation classifier type")
+	ErrInvalidAction             = errors.New("invalid moderation action")
+)
+
+// Action 命中审核规则时的处理方式
+type Action string
+
+const (
+	ActionAllow    Action = "allow"    // 未命中，原样通过
+	ActionBlock    Action = "block"    // 拒绝：输入不交给LLM，回复不播放
+	ActionRedact   Action = "redact"   // 用*替换命中的关键词（分类器命中时没有位置信息，按拒绝处理）
+	ActionRephrase Action = "rephrase" // 由LLM改写为不含违规内容的文本（改写失败或仍然违规时按拒绝处理）
+)
+
+// Stage 审核位置
+type Stage string
+
+const (
+	StageInput  Stage = "input"  // ASR之后、LLM之前（用户输入）
+	StageOutput Stage = "output" // LLM之后、TTS之前（助手回复）
+)
+
+// Config 内容审核配置
+type Config struct {
+	Enabled      bool         `yaml:"enabled"`       // 是否启用
+	InputAction  Action       `yaml:"input_action"`  // 用户输入命中时的处理方式: block|redact|rephrase
+	OutputAction Action       `yaml:"output_action"` // 助手回复命中时的处理方式: block|redact|rephrase
+	Keywords     []string     `yaml:"keywords"`      // 屏蔽词（不区分大小写）
+	KeywordsFile string       `yaml:"keywords_file"` // 屏蔽词文件（每行一个，#开头为注释）
+	Provider     string       `yaml:"provider"`      // 分类器（为空时只使用屏蔽词）: openai
+	OpenAI       OpenAIConfig `yaml:"openai"`        // OpenAI兼容的审核接口
+	Categories   []string     `yaml:"categories"`    // 只拦截这些类别（为空时拦截分类器标记的所有类别）
+	FailClosed   bool         `yaml:"fail_closed"`   // 分类器调用失败时拒绝（默认放行，只按屏蔽词审核）
+}
+
+// OpenAIConfig OpenAI审核接口配置
+type OpenAIConfig struct {
+	APIKey  string `yaml:"api_key"`
+	Model   string `yaml:"model"`
+	BaseURL string `yaml:"base_url"`
+}
+
+// Result 分类结果
+type Result struct {
+	Flagged    bool     // 是否违规
+	Categories []string // 命中的类别
+	Matches    []Match  // 命中的屏蔽词位置（分类器结果为空）
+}
+
+// Match 命中的屏蔽词
+type Match struct {
+	Start int    // 字节偏移
+	End   int    // 字节偏移（不含）
+	Text  string // 原文
+}
+
+// Classifier 内容分类接口
+type Classifier interface {
+	// Classify 判断文本是否违规
+	Classify(ctx context.Context, text string) (Result, error)
+
+	// Close 释放资源
+	Close() error
+}
+
+// GenerateFunc 调用LLM按指令处理文本（rephrase方式使用）
+type GenerateFunc func(ctx context.Context, instruction, text string) (string, error)
+
+// ClassifierFactory 分类器工厂函数类型
+type ClassifierFactory func(config Config) (Classifier, error)
+
+// 注册的分类器实现
+var classifierFactories = make(map[string]ClassifierFactory)
+
+// RegisterClassifier 注册分类器实现
+func RegisterClassifier(name string, factory ClassifierFactory) {
+	classifierFactories[name] = factory
+}
+
+// CreateClassifier 创建分类器
+func CreateClassifier(config Config) (Classifier, error) {
+	factory, exists := classifierFactories[config.Provider]
+	if !exists {
+		return nil, ErrUnsupportedClassifierType
+	}
+	return factory(config)
+}
+
+// GetAvailableClassifierTypes 获取可用（已编译进当前二进制）的分类器类型
+func GetAvailableClassifierTypes() []string {
+	types := make([]string, 0, len(classifierFactories))
+	for t := range classifierFactories {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
@@ -0,0 +1,122 @@
+package moderation
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// CategoryKeyword 屏蔽词命中的类别
+const CategoryKeyword = "keyword"
+
+// KeywordFilter 屏蔽词匹配（不区分大小写，按最长词优先匹配，不检查词边界以支持中文）
+type KeywordFilter struct {
+	keywords []string // 小写，按长度从长到短
+}
+
+// NewKeywordFilter 创建屏蔽词匹配（path为空时只使用keywords）
+func NewKeywordFilter(keywords []string, path string) (*KeywordFilter, error) {
+	words := append([]string(nil), keywords...)
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("读取屏蔽词文件失败: %w", err)
+		}
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line != "" && !strings.HasPrefix(line, "#") {
+				words = append(words, line)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("读取屏蔽词文件失败: %w", err)
+		}
+	}
+
+	filter := &KeywordFilter{}
+	seen := make(map[string]bool, len(words))
+	for _, word := range words {
+		word = lowerAligned(strings.TrimSpace(word))
+		if word == "" || seen[word] {
+			continue
+		}
+		seen[word] = true
+		filter.keywords = append(filter.keywords, word)
+	}
+	sort.SliceStable(filter.keywords, func(i, j int) bool {
+		return len(filter.keywords[i]) > len(filter.keywords[j])
+	})
+	return filter, nil
+}
+
+// Len 屏蔽词数量
+func (f *KeywordFilter) Len() int {
+	return len(f.keywords)
+}
+
+// Find 查找文本中的屏蔽词（互不重叠，按出现顺序）
+func (f *KeywordFilter) Find(text string) []Match {
+	if len(f.keywords) == 0 {
+		return nil
+	}
+
+	lower := lowerAligned(text)
+	var matches []Match
+	for i := 0; i < len(lower); {
+		matched := ""
+		for _, word := range f.keywords {
+			if strings.HasPrefix(lower[i:], word) {
+				matched = word
+				break
+			}
+		}
+		if matched == "" {
+			_, size := utf8.DecodeRuneInString(lower[i:])
+			i += size
+			continue
+		}
+		matches = append(matches, Match{Start: i, End: i + len(matched), Text: text[i : i+len(matched)]})
+		i += len(matched)
+	}
+	return matches
+}
+
+// Redact 把命中的屏蔽词替换为等长的*（按字符计）
+func Redact(text string, matches []Match) string {
+	if len(matches) == 0 {
+		return text
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, match := range matches {
+		b.WriteString(text[last:match.Start])
+		b.WriteString(strings.Repeat("*", utf8.RuneCountInString(match.Text)))
+		last = match.End
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// lowerAligned 转为小写，转换后字节长度变化的字符保持原样，使结果与原文的字节偏移一致
+func lowerAligned(text string) string {
+	var b strings.Builder
+	b.Grow(len(text))
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		lower := unicode.ToLower(r)
+		if r == utf8.RuneError || utf8.RuneLen(lower) != size {
+			b.WriteString(text[i : i+size])
+		} else {
+			b.WriteRune(lower)
+		}
+		i += size
+	}
+	return b.String()
+}
@@ -0,0 +1,149 @@
+package moderation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixedClassifier 固定结果的分类器
+type fixedClassifier struct {
+	result Result
+	err    error
+}
+
+func (c fixedClassifier) Classify(ctx context.Context, text string) (Result, error) {
+	return c.result, c.err
+}
+
+func (c fixedClassifier) Close() error { return nil }
+
+func TestKeywordFilter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "words.txt")
+	require.NoError(t, os.WriteFile(path, []byte("# 注释\n笨蛋\n\nidiot\n"), 0600))
+
+	filter, err := NewKeywordFilter([]string{"大笨蛋", "IDIOT"}, path)
+	require.NoError(t, err)
+	assert.Equal(t, 3, filter.Len())
+
+	text := "你这个大笨蛋，Idiot！笨蛋"
+	matches := filter.Find(text)
+	require.Len(t, matches, 3)
+	// 最长词优先
+	assert.Equal(t, "大笨蛋", matches[0].Text)
+	assert.Equal(t, "Idiot", matches[1].Text)
+	assert.Equal(t, "你这个***，*****！**", Redact(text, matches))
+
+	assert.Empty(t, filter.Find("今天天气不错"))
+}
+
+func TestModeratorActions(t *testing.T) {
+	ctx := context.Background()
+	rephrase := func(ctx context.Context, instruction, text string) (string, error) {
+		return "请你走开", nil
+	}
+
+	moderator, err := NewModerator(Config{
+		Keywords:     []string{"滚"},
+		InputAction:  ActionRedact,
+		OutputAction: ActionRephrase,
+	}, rephrase)
+	require.NoError(t, err)
+
+	decision, err := moderator.Check(ctx, StageInput, "你好")
+	require.NoError(t, err)
+	assert.Equal(t, ActionAllow, decision.Action)
+	assert.Equal(t, "你好", decision.Text)
+
+	decision, err = moderator.Check(ctx, StageInput, "你滚")
+	require.NoError(t, err)
+	assert.Equal(t, ActionRedact, decision.Action)
+	assert.Equal(t, "你*", decision.Text)
+	assert.Equal(t, []string{CategoryKeyword}, decision.Categories)
+
+	decision, err = moderator.Check(ctx, StageOutput, "你滚")
+	require.NoError(t, err)
+	assert.Equal(t, ActionRephrase, decision.Action)
+	assert.Equal(t, "请你走开", decision.Text)
+
+	// 改写后仍然违规时拒绝
+	moderator.generate = func(ctx context.Context, instruction, text string) (string, error) {
+		return "滚吧", nil
+	}
+	decision, err = moderator.Check(ctx, StageOutput, "你滚")
+	require.NoError(t, err)
+	assert.True(t, decision.Blocked())
+	assert.Empty(t, decision.Text)
+
+	_, err = NewModerator(Config{InputAction: "ignore"}, nil)
+	assert.ErrorIs(t, err, ErrInvalidAction)
+	_, err = NewModerator(Config{OutputAction: ActionRephrase}, nil)
+	assert.ErrorIs(t, err, ErrInvalidAction)
+}
+
+func TestModeratorClassifier(t *testing.T) {
+	ctx := context.Background()
+	flagged := fixedClassifier{result: Result{Flagged: true, Categories: []string{"harassment"}}}
+
+	// 分类器命中没有位置信息，redact按拒绝处理
+	moderator, err := NewModeratorWith(Config{InputAction: ActionRedact}, flagged, nil)
+	require.NoError(t, err)
+	decision, err := moderator.Check(ctx, StageInput, "some text")
+	require.NoError(t, err)
+	assert.True(t, decision.Blocked())
+	assert.Equal(t, []string{"harassment"}, decision.Categories)
+
+	failing, err := NewModeratorWith(Config{}, fixedClassifier{err: errors.New("timeout")}, nil)
+	require.NoError(t, err)
+	_, err = failing.Check(ctx, StageOutput, "text")
+	assert.Error(t, err)
+	assert.Equal(t, ActionAllow, failing.FailDecision("text").Action)
+
+	failing.config.FailClosed = true
+	assert.True(t, failing.FailDecision("text").Blocked())
+}
+
+func TestOpenAIClassifier(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/moderations", r.URL.Path)
+		assert.Equal(t, "Bearer key", r.Header.Get("Authorization"))
+
+		var body struct {
+			Input string `json:"input"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		flagged := strings.Contains(body.Input, "kill")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"results": []map[string]interface{}{{
+				"flagged":    flagged,
+				"categories": map[string]bool{"violence": flagged, "harassment": flagged, "sexual": false},
+			}},
+		})
+	}))
+	defer server.Close()
+
+	classifier, err := CreateClassifier(Config{
+		Provider:   "openai",
+		OpenAI:     OpenAIConfig{APIKey: "key", BaseURL: server.URL + "/v1"},
+		Categories: []string{"violence"},
+	})
+	require.NoError(t, err)
+
+	result, err := classifier.Classify(context.Background(), "I will kill you")
+	require.NoError(t, err)
+	assert.True(t, result.Flagged)
+	assert.Equal(t, []string{"violence"}, result.Categories)
+
+	result, err = classifier.Classify(context.Background(), "hello")
+	require.NoError(t, err)
+	assert.False(t, result.Flagged)
+}
@@ -0,0 +1,145 @@
+package moderation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// rephraseInstruction 改写违规内容的指令
+const rephraseInstruction = "下面的文本包含冒犯、辱骂或其他不当内容。请改写为礼貌、得体的表达，去掉不当内容，尽量保留原意和原文语言。只输出改写后的文本，不要解释。"
+
+// Decision 审核结果
+type Decision struct {
+	Action     Action   // 实际采取的处理方式（未命中时为allow）
+	Text       string   // 处理后的文本（拒绝时为空）
+	Categories []string // 命中的类别
+}
+
+// Blocked 是否被拒绝
+func (d Decision) Blocked() bool {
+	return d.Action == ActionBlock
+}
+
+// Moderator 内容审核：先匹配屏蔽词，未命中时再调用分类器（如已配置）
+type Moderator struct {
+	config     Config
+	keywords   *KeywordFilter
+	classifier Classifier
+	generate   GenerateFunc
+}
+
+// NewModerator 创建内容审核（generate用于rephrase方式，可为nil）
+func NewModerator(config Config, generate GenerateFunc) (*Moderator, error) {
+	for _, action := range []*Action{&config.InputAction, &config.OutputAction} {
+		switch *action {
+		case "":
+			*action = ActionBlock
+		case ActionBlock, ActionRedact:
+		case ActionRephrase:
+			if generate == nil {
+				return nil, fmt.Errorf("%w: rephrase 需要LLM", ErrInvalidAction)
+			}
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrInvalidAction, *action)
+		}
+	}
+
+	keywords, err := NewKeywordFilter(config.Keywords, config.KeywordsFile)
+	if err != nil {
+		return nil, err
+	}
+
+	moderator := &Moderator{config: config, keywords: keywords, generate: generate}
+	if config.Provider != "" {
+		classifier, err := CreateClassifier(config)
+		if err != nil {
+			return nil, fmt.Errorf("创建内容审核分类器失败: %w", err)
+		}
+		moderator.classifier = classifier
+	}
+	return moderator, nil
+}
+
+// NewModeratorWith 使用指定分类器创建内容审核（测试和自定义分类器使用）
+func NewModeratorWith(config Config, classifier Classifier, generate GenerateFunc) (*Moderator, error) {
+	config.Provider = ""
+	moderator, err := NewModerator(config, generate)
+	if err != nil {
+		return nil, err
+	}
+	moderator.classifier = classifier
+	return moderator, nil
+}
+
+// KeywordCount 屏蔽词数量
+func (m *Moderator) KeywordCount() int {
+	return m.keywords.Len()
+}
+
+// Check 审核一段文本并按该位置的策略处理；分类器调用失败时返回错误，由调用方决定是否放行
+func (m *Moderator) Check(ctx context.Context, stage Stage, text string) (Decision, error) {
+	action := m.config.InputAction
+	if stage == StageOutput {
+		action = m.config.OutputAction
+	}
+
+	result, err := m.classify(ctx, text)
+	if err != nil {
+		return Decision{}, err
+	}
+	if !result.Flagged {
+		return Decision{Action: ActionAllow, Text: text}, nil
+	}
+
+	blocked := Decision{Action: ActionBlock, Categories: result.Categories}
+	switch action {
+	case ActionRedact:
+		// 分类器没有命中位置，无法只替换违规部分
+		if len(result.Matches) == 0 {
+			return blocked, nil
+		}
+		return Decision{Action: ActionRedact, Text: Redact(text, result.Matches), Categories: result.Categories}, nil
+	case ActionRephrase:
+		rephrased, err := m.generate(ctx, rephraseInstruction, text)
+		rephrased = strings.TrimSpace(rephrased)
+		if err != nil || rephrased == "" {
+			return blocked, nil
+		}
+		// 改写后的文本仍需通过审核
+		recheck, err := m.classify(ctx, rephrased)
+		if err != nil || recheck.Flagged {
+			return blocked, nil
+		}
+		return Decision{Action: ActionRephrase, Text: rephrased, Categories: result.Categories}, nil
+	default:
+		return blocked, nil
+	}
+}
+
+// FailDecision 分类器调用失败时的处理（fail_closed时拒绝，否则放行原文）
+func (m *Moderator) FailDecision(text string) Decision {
+	if m.config.FailClosed {
+		return Decision{Action: ActionBlock}
+	}
+	return Decision{Action: ActionAllow, Text: text}
+}
+
+// Close 关闭分类器
+func (m *Moderator) Close() error {
+	if m.classifier == nil {
+		return nil
+	}
+	return m.classifier.Close()
+}
+
+// classify 屏蔽词命中时不再调用分类器
+func (m *Moderator) classify(ctx context.Context, text string) (Result, error) {
+	if matches := m.keywords.Find(text); len(matches) > 0 {
+		return Result{Flagged: true, Categories: []string{CategoryKeyword}, Matches: matches}, nil
+	}
+	if m.classifier == nil || strings.TrimSpace(text) == "" {
+		return Result{}, nil
+	}
+	return m.classifier.Classify(ctx, text)
+}
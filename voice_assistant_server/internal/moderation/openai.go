@@ -0,0 +1,125 @@
+//go:build !no_moderation_openai
+
+package moderation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// OpenAIClassifier OpenAI兼容的内容审核接口（/v1/moderations）
+type OpenAIClassifier struct {
+	baseURL    string
+	apiKey     string
+	model      string
+	categories map[string]bool // 需要拦截的类别（为空时拦截全部）
+	client     *http.Client
+}
+
+// NewOpenAIClassifier 创建OpenAI内容审核
+func NewOpenAIClassifier(config Config) (*OpenAIClassifier, error) {
+	baseURL := config.OpenAI.BaseURL
+	if baseURL == "" {
+		baseURL = "https://api.openai.com/v1"
+	}
+	model := config.OpenAI.Model
+	if model == "" {
+		model = "omni-moderation-latest"
+	}
+
+	classifier := &OpenAIClassifier{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  config.OpenAI.APIKey,
+		model:   model,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+	if len(config.Categories) > 0 {
+		classifier.categories = make(map[string]bool, len(config.Categories))
+		for _, category := range config.Categories {
+			classifier.categories[category] = true
+		}
+	}
+	return classifier, nil
+}
+
+// Classify 判断文本是否违规
+func (c *OpenAIClassifier) Classify(ctx context.Context, text string) (Result, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"model": c.model,
+		"input": text,
+	})
+	if err != nil {
+		return Result{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/moderations", bytes.NewReader(body))
+	if err != nil {
+		return Result{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return Result{}, fmt.Errorf("请求内容审核接口失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return Result{}, fmt.Errorf("内容审核接口返回错误: HTTP %d: %s", resp.StatusCode, bytes.TrimSpace(message))
+	}
+
+	var response struct {
+		Results []struct {
+			Flagged    bool            `json:"flagged"`
+			Categories map[string]bool `json:"categories"`
+		} `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return Result{}, fmt.Errorf("解析内容审核结果失败: %w", err)
+	}
+
+	var result Result
+	for _, item := range response.Results {
+		if !item.Flagged {
+			continue
+		}
+		matched := false
+		for category, flagged := range item.Categories {
+			if flagged && (c.categories == nil || c.categories[category]) {
+				result.Categories = append(result.Categories, category)
+				matched = true
+			}
+		}
+		// 未返回具体类别时按整体标记处理
+		if !matched && len(item.Categories) == 0 && c.categories == nil {
+			result.Categories = append(result.Categories, "flagged")
+		}
+	}
+	sort.Strings(result.Categories)
+	result.Flagged = len(result.Categories) > 0
+	return result, nil
+}
+
+// Close 关闭空闲连接
+func (c *OpenAIClassifier) Close() error {
+	c.client.CloseIdleConnections()
+	return nil
+}
+
+// 注册openai内容审核
+func init() {
+	RegisterClassifier("openai", func(config Config) (Classifier, error) {
+		return NewOpenAIClassifier(config)
+	})
+}
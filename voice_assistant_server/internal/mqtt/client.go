@@ -0,0 +1,350 @@
+package mqtt
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrNotConnected 与MQTT服务器的连接尚未建立或已断开
+var ErrNotConnected = errors.New("MQTT未连接")
+
+// 重连等待时间（每次失败翻倍，不超过上限）
+const (
+	reconnectMin = time.Second
+	reconnectMax = 30 * time.Second
+)
+
+// Message MQTT消息
+type Message struct {
+	Topic   string
+	Payload []byte
+	QoS     byte // 0或1
+	Retain  bool
+}
+
+// Handler 订阅消息回调（在独立的协程中调用）
+type Handler func(msg Message)
+
+// Options 客户端选项
+type Options struct {
+	Broker         string        // 服务器地址：tcp://host:1883、ssl://host:8883（也支持 mqtt://、mqtts://、tls://）
+	ClientID       string        // 客户端ID
+	Username       string        // 用户名（为空时不认证）
+	Password       string        // 密码
+	KeepAlive      time.Duration // 心跳间隔
+	ConnectTimeout time.Duration // 连接和握手超时
+	Will           *Message      // 遗嘱消息：异常断开时服务器代为发布（如离线状态）
+	OnConnect      func()        // 每次连接（含重连）成功后调用，可在其中发布在线状态
+}
+
+// subscription 订阅
+type subscription struct {
+	qos     byte
+	handler Handler
+}
+
+// Client 精简的MQTT 3.1.1客户端：QoS 0/1发布和订阅、心跳、断线自动重连并恢复订阅
+// QoS 1消息发出后不等待确认，断线期间的消息不会补发。
+type Client struct {
+	opts Options
+
+	mu     sync.Mutex
+	conn   net.Conn
+	subs   map[string]subscription
+	nextID uint16
+
+	writeMu      sync.Mutex
+	lastReceived atomic.Int64 // 最近收到报文的时间（UnixNano）
+}
+
+// NewClient 创建客户端（Run之后才连接）
+func NewClient(opts Options) *Client {
+	if opts.KeepAlive <= 0 {
+		opts.KeepAlive = 60 * time.Second
+	}
+	if opts.ConnectTimeout <= 0 {
+		opts.ConnectTimeout = 10 * time.Second
+	}
+	return &Client{opts: opts, subs: make(map[string]subscription)}
+}
+
+// Subscribe 订阅主题（支持+和#通配符），连接建立后生效，重连后自动恢复
+func (c *Client) Subscribe(filter string, qos byte, handler Handler) error {
+	c.mu.Lock()
+	c.subs[filter] = subscription{qos: qos, handler: handler}
+	conn := c.conn
+	c.mu.Unlock()
+
+	if conn == nil {
+		return nil
+	}
+	return c.write(conn, subscribePacket(c.packetID(), map[string]byte{filter: qos}))
+}
+
+// Publish 发布消息（未连接时返回ErrNotConnected）
+func (c *Client) Publish(msg Message) error {
+	c.mu.Lock()
+	conn := c.conn
+	c.mu.Unlock()
+	if conn == nil {
+		return ErrNotConnected
+	}
+
+	var id uint16
+	if msg.QoS > 0 {
+		id = c.packetID()
+	}
+	return c.write(conn, publishPacket(msg, id))
+}
+
+// Connected 是否已连接
+func (c *Client) Connected() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn != nil
+}
+
+// Run 连接服务器并保持连接，断开后按退避时间重连，直到ctx取消（取消时发送DISCONNECT正常断开）
+func (c *Client) Run(ctx context.Context) {
+	wait := reconnectMin
+	for {
+		conn, err := c.connect(ctx)
+		if err != nil {
+			log.Printf("连接MQTT服务器失败 (%s): %v，%v后重试", c.opts.Broker, err, wait)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(wait):
+			}
+			wait = min(wait*2, reconnectMax)
+			continue
+		}
+
+		wait = reconnectMin
+		log.Printf("已连接MQTT服务器: %s", c.opts.Broker)
+		err = c.serve(ctx, conn)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("MQTT连接断开: %v", err)
+	}
+}
+
+// connect 建立连接、完成握手并恢复订阅
+func (c *Client) connect(ctx context.Context) (net.Conn, error) {
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	conn.SetDeadline(time.Now().Add(c.opts.ConnectTimeout))
+	if _, err := conn.Write(connectPacket(c.opts).encode()); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	reply, err := readPacket(bufio.NewReader(conn))
+	if err == nil && (reply.kind() != packetConnack || len(reply.body) != 2) {
+		err = errMalformed
+	}
+	if err == nil {
+		err = connackError(reply.body[1])
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+	c.lastReceived.Store(time.Now().UnixNano())
+
+	c.mu.Lock()
+	c.conn = conn
+	filters := make(map[string]byte, len(c.subs))
+	for filter, sub := range c.subs {
+		filters[filter] = sub.qos
+	}
+	c.mu.Unlock()
+
+	if len(filters) > 0 {
+		if err := c.write(conn, subscribePacket(c.packetID(), filters)); err != nil {
+			c.disconnected(conn)
+			return nil, err
+		}
+	}
+	if c.opts.OnConnect != nil {
+		c.opts.OnConnect()
+	}
+	return conn, nil
+}
+
+// ValidateBroker 检查MQTT服务器地址
+func ValidateBroker(broker string) error {
+	_, _, err := parseBroker(broker)
+	return err
+}
+
+// parseBroker 解析服务器地址，返回 host:port 和是否使用TLS（未指定端口时为1883/8883）
+func parseBroker(broker string) (string, bool, error) {
+	u, err := url.Parse(broker)
+	if err != nil || u.Host == "" {
+		return "", false, fmt.Errorf("无效的MQTT服务器地址: %q", broker)
+	}
+	secure := false
+	switch u.Scheme {
+	case "tcp", "mqtt":
+	case "ssl", "tls", "mqtts":
+		secure = true
+	default:
+		return "", false, fmt.Errorf("不支持的MQTT协议: %s（可用 tcp、ssl）", u.Scheme)
+	}
+	if u.Port() != "" {
+		return u.Host, secure, nil
+	}
+	port := "1883"
+	if secure {
+		port = "8883"
+	}
+	return net.JoinHostPort(u.Hostname(), port), secure, nil
+}
+
+// dial 按地址协议建立TCP或TLS连接
+func (c *Client) dial(ctx context.Context) (net.Conn, error) {
+	host, secure, err := parseBroker(c.opts.Broker)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{Timeout: c.opts.ConnectTimeout}
+	if secure {
+		serverName, _, _ := net.SplitHostPort(host)
+		return (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: serverName}}).DialContext(ctx, "tcp", host)
+	}
+	return dialer.DialContext(ctx, "tcp", host)
+}
+
+// serve 接收报文并发送心跳，直到连接出错或ctx取消
+func (c *Client) serve(ctx context.Context, conn net.Conn) error {
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		ticker := time.NewTicker(c.opts.KeepAlive / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				c.write(conn, packet{header: packetDisconnect << 4})
+				conn.Close()
+				return
+			case <-ticker.C:
+				// 超过1.5倍心跳间隔没有收到任何报文（含PINGRESP）视为连接已失效
+				if time.Since(time.Unix(0, c.lastReceived.Load())) > c.opts.KeepAlive*3/2 {
+					conn.Close()
+					return
+				}
+				c.write(conn, packet{header: packetPingreq << 4})
+			}
+		}
+	}()
+
+	reader := bufio.NewReader(conn)
+	for {
+		p, err := readPacket(reader)
+		if err != nil {
+			c.disconnected(conn)
+			return err
+		}
+		c.lastReceived.Store(time.Now().UnixNano())
+
+		switch p.kind() {
+		case packetPublish:
+			msg, id, err := parsePublish(p)
+			if err != nil {
+				c.disconnected(conn)
+				return err
+			}
+			if msg.QoS > 0 {
+				c.write(conn, ackPacket(packetPuback, id))
+			}
+			c.dispatch(msg)
+		case packetSuback:
+			for _, code := range p.body[min(2, len(p.body)):] {
+				if code == 0x80 {
+					log.Printf("MQTT订阅被服务器拒绝")
+				}
+			}
+		}
+	}
+}
+
+// dispatch 把收到的消息交给匹配的订阅回调
+func (c *Client) dispatch(msg Message) {
+	c.mu.Lock()
+	var handlers []Handler
+	for filter, sub := range c.subs {
+		if topicMatch(filter, msg.Topic) {
+			handlers = append(handlers, sub.handler)
+		}
+	}
+	c.mu.Unlock()
+
+	for _, handler := range handlers {
+		go handler(msg)
+	}
+}
+
+// disconnected 关闭连接并清除当前连接
+func (c *Client) disconnected(conn net.Conn) {
+	conn.Close()
+	c.mu.Lock()
+	if c.conn == conn {
+		c.conn = nil
+	}
+	c.mu.Unlock()
+}
+
+// write 发送一个报文（串行写入）
+func (c *Client) write(conn net.Conn, p packet) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	conn.SetWriteDeadline(time.Now().Add(c.opts.ConnectTimeout))
+	_, err := conn.Write(p.encode())
+	return err
+}
+
+// packetID 下一个报文标识符（非0）
+func (c *Client) packetID() uint16 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.nextID++
+	if c.nextID == 0 {
+		c.nextID = 1
+	}
+	return c.nextID
+}
+
+// topicMatch 主题是否匹配订阅过滤器（+匹配一级，#匹配其余所有层级）
+func topicMatch(filter, topic string) bool {
+	filterParts := strings.Split(filter, "/")
+	topicParts := strings.Split(topic, "/")
+	for i, part := range filterParts {
+		if part == "#" {
+			return true
+		}
+		if i >= len(topicParts) || (part != "+" && part != topicParts[i]) {
+			return false
+		}
+	}
+	return len(filterParts) == len(topicParts)
+}
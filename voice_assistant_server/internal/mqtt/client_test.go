@@ -0,0 +1,126 @@
+package mqtt
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTopicMatch(t *testing.T) {
+	assert.True(t, topicMatch("a/b", "a/b"))
+	assert.True(t, topicMatch("a/+/c", "a/b/c"))
+	assert.True(t, topicMatch("a/#", "a/b/c"))
+	assert.True(t, topicMatch("#", "a"))
+	assert.False(t, topicMatch("a/+", "a/b/c"))
+	assert.False(t, topicMatch("a/b/c", "a/b"))
+	assert.False(t, topicMatch("a/b", "a/c"))
+}
+
+func TestValidateBroker(t *testing.T) {
+	host, secure, err := parseBroker("ssl://broker.local")
+	require.NoError(t, err)
+	assert.Equal(t, "broker.local:8883", host)
+	assert.True(t, secure)
+
+	host, secure, err = parseBroker("tcp://127.0.0.1:1884")
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.1:1884", host)
+	assert.False(t, secure)
+
+	assert.Error(t, ValidateBroker("http://broker.local"))
+	assert.Error(t, ValidateBroker("broker.local:1883"))
+}
+
+func TestPublishPacketRoundTrip(t *testing.T) {
+	msg := Message{Topic: "home/say", Payload: []byte("你好"), QoS: 1, Retain: true}
+	p, err := readPacket(bufio.NewReader(bytes.NewReader(publishPacket(msg, 7).encode())))
+	require.NoError(t, err)
+
+	parsed, id, err := parsePublish(p)
+	require.NoError(t, err)
+	assert.Equal(t, msg, parsed)
+	assert.Equal(t, uint16(7), id)
+}
+
+// TestClientWithBroker 用最简的模拟服务器验证握手、遗嘱、恢复订阅、收发消息
+func TestClientWithBroker(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	received := make(chan Message, 1)
+	connected := make(chan struct{}, 1)
+	client := NewClient(Options{
+		Broker:    "tcp://" + listener.Addr().String(),
+		ClientID:  "test",
+		Username:  "user",
+		Password:  "pass",
+		KeepAlive: 30 * time.Second,
+		Will:      &Message{Topic: "va/status", Payload: []byte("offline"), Retain: true},
+		OnConnect: func() { connected <- struct{}{} },
+	})
+	require.ErrorIs(t, client.Publish(Message{Topic: "va/reply"}), ErrNotConnected)
+	require.NoError(t, client.Subscribe("va/+", 1, func(msg Message) { received <- msg }))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go client.Run(ctx)
+
+	conn, err := listener.Accept()
+	require.NoError(t, err)
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+
+	p, err := readPacket(reader)
+	require.NoError(t, err)
+	require.Equal(t, byte(packetConnect), p.kind())
+	assert.Equal(t, byte(0x02|0x04|0x20|0x80|0x40), p.body[7]) // 清除会话 + 保留遗嘱 + 用户名密码
+	_, err = conn.Write(packet{header: packetConnack << 4, body: []byte{0, 0}}.encode())
+	require.NoError(t, err)
+
+	p, err = readPacket(reader)
+	require.NoError(t, err)
+	require.Equal(t, byte(packetSubscribe), p.kind())
+	filter, rest, err := readString(p.body[2:])
+	require.NoError(t, err)
+	assert.Equal(t, "va/+", filter)
+	assert.Equal(t, []byte{1}, rest)
+
+	select {
+	case <-connected:
+	case <-time.After(time.Second):
+		t.Fatal("OnConnect未调用")
+	}
+
+	// 服务器下发QoS 1消息，客户端应回复PUBACK并回调
+	_, err = conn.Write(publishPacket(Message{Topic: "va/announce", Payload: []byte("hi"), QoS: 1}, 42).encode())
+	require.NoError(t, err)
+	p, err = readPacket(reader)
+	require.NoError(t, err)
+	assert.Equal(t, ackPacket(packetPuback, 42), p)
+	select {
+	case msg := <-received:
+		assert.Equal(t, "hi", string(msg.Payload))
+	case <-time.After(time.Second):
+		t.Fatal("未收到订阅消息")
+	}
+
+	require.NoError(t, client.Publish(Message{Topic: "va/reply", Payload: []byte("ok")}))
+	p, err = readPacket(reader)
+	require.NoError(t, err)
+	msg, _, err := parsePublish(p)
+	require.NoError(t, err)
+	assert.Equal(t, "va/reply", msg.Topic)
+
+	// 取消后正常断开
+	cancel()
+	p, err = readPacket(reader)
+	require.NoError(t, err)
+	assert.Equal(t, byte(packetDisconnect), p.kind())
+}
@@ -0,0 +1,222 @@
+package mqtt
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// MQTT 3.1.1 控制报文类型
+const (
+	packetConnect    = 1
+	packetConnack    = 2
+	packetPublish    = 3
+	packetPuback     = 4
+	packetSubscribe  = 8
+	packetSuback     = 9
+	packetPingreq    = 12
+	packetPingresp   = 13
+	packetDisconnect = 14
+)
+
+// maxPacketSize 接收报文的大小上限（剩余长度）
+const maxPacketSize = 1 << 20
+
+// errMalformed 报文格式错误
+var errMalformed = errors.New("MQTT报文格式错误")
+
+// packet 一个控制报文（固定头的第一个字节和剩余部分）
+type packet struct {
+	header byte
+	body   []byte
+}
+
+// kind 报文类型
+func (p packet) kind() byte {
+	return p.header >> 4
+}
+
+// encode 编码为完整报文（固定头 + 剩余长度 + 剩余部分）
+func (p packet) encode() []byte {
+	var buf bytes.Buffer
+	buf.WriteByte(p.header)
+	n := len(p.body)
+	for {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		buf.WriteByte(b)
+		if n == 0 {
+			break
+		}
+	}
+	buf.Write(p.body)
+	return buf.Bytes()
+}
+
+// readPacket 读取一个报文
+func readPacket(r *bufio.Reader) (packet, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return packet{}, err
+	}
+	length, multiplier := 0, 1
+	for i := 0; ; i++ {
+		if i == 4 {
+			return packet{}, errMalformed
+		}
+		b, err := r.ReadByte()
+		if err != nil {
+			return packet{}, err
+		}
+		length += int(b&0x7f) * multiplier
+		multiplier *= 128
+		if b&0x80 == 0 {
+			break
+		}
+	}
+	if length > maxPacketSize {
+		return packet{}, fmt.Errorf("MQTT报文过大: %d字节", length)
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return packet{}, err
+	}
+	return packet{header: header, body: body}, nil
+}
+
+// appendString 追加长度前缀的UTF-8字符串
+func appendString(buf *bytes.Buffer, s string) {
+	appendBytes(buf, []byte(s))
+}
+
+// appendBytes 追加长度前缀的二进制数据
+func appendBytes(buf *bytes.Buffer, b []byte) {
+	binary.Write(buf, binary.BigEndian, uint16(len(b)))
+	buf.Write(b)
+}
+
+// readString 读取长度前缀的字符串，返回剩余部分
+func readString(b []byte) (string, []byte, error) {
+	if len(b) < 2 {
+		return "", nil, errMalformed
+	}
+	n := int(binary.BigEndian.Uint16(b))
+	if len(b) < 2+n {
+		return "", nil, errMalformed
+	}
+	return string(b[2 : 2+n]), b[2+n:], nil
+}
+
+// connectPacket CONNECT报文
+func connectPacket(opts Options) packet {
+	var buf bytes.Buffer
+	appendString(&buf, "MQTT")
+	buf.WriteByte(4) // 协议级别 3.1.1
+
+	flags := byte(0x02) // 清除会话
+	if opts.Will != nil {
+		flags |= 0x04 | opts.Will.QoS<<3
+		if opts.Will.Retain {
+			flags |= 0x20
+		}
+	}
+	if opts.Username != "" {
+		flags |= 0x80
+		if opts.Password != "" {
+			flags |= 0x40
+		}
+	}
+	buf.WriteByte(flags)
+	binary.Write(&buf, binary.BigEndian, uint16(opts.KeepAlive.Seconds()))
+
+	appendString(&buf, opts.ClientID)
+	if opts.Will != nil {
+		appendString(&buf, opts.Will.Topic)
+		appendBytes(&buf, opts.Will.Payload)
+	}
+	if opts.Username != "" {
+		appendString(&buf, opts.Username)
+		if opts.Password != "" {
+			appendString(&buf, opts.Password)
+		}
+	}
+	return packet{header: packetConnect << 4, body: buf.Bytes()}
+}
+
+// publishPacket PUBLISH报文（QoS 0时不带报文标识符）
+func publishPacket(msg Message, id uint16) packet {
+	header := byte(packetPublish<<4) | msg.QoS<<1
+	if msg.Retain {
+		header |= 0x01
+	}
+	var buf bytes.Buffer
+	appendString(&buf, msg.Topic)
+	if msg.QoS > 0 {
+		binary.Write(&buf, binary.BigEndian, id)
+	}
+	buf.Write(msg.Payload)
+	return packet{header: header, body: buf.Bytes()}
+}
+
+// parsePublish 解析PUBLISH报文，返回消息和报文标识符
+func parsePublish(p packet) (Message, uint16, error) {
+	msg := Message{QoS: (p.header >> 1) & 0x03, Retain: p.header&0x01 != 0}
+	topic, rest, err := readString(p.body)
+	if err != nil {
+		return Message{}, 0, err
+	}
+	msg.Topic = topic
+	var id uint16
+	if msg.QoS > 0 {
+		if len(rest) < 2 {
+			return Message{}, 0, errMalformed
+		}
+		id = binary.BigEndian.Uint16(rest)
+		rest = rest[2:]
+	}
+	msg.Payload = rest
+	return msg, id, nil
+}
+
+// subscribePacket SUBSCRIBE报文
+func subscribePacket(id uint16, filters map[string]byte) packet {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.BigEndian, id)
+	for filter, qos := range filters {
+		appendString(&buf, filter)
+		buf.WriteByte(qos)
+	}
+	return packet{header: packetSubscribe<<4 | 0x02, body: buf.Bytes()}
+}
+
+// ackPacket PUBACK等只带报文标识符的确认报文
+func ackPacket(kind byte, id uint16) packet {
+	body := make([]byte, 2)
+	binary.BigEndian.PutUint16(body, id)
+	return packet{header: kind << 4, body: body}
+}
+
+// connackError CONNACK返回码对应的错误
+func connackError(code byte) error {
+	switch code {
+	case 0:
+		return nil
+	case 1:
+		return errors.New("服务器不支持MQTT 3.1.1")
+	case 2:
+		return errors.New("客户端ID被拒绝")
+	case 3:
+		return errors.New("MQTT服务不可用")
+	case 4:
+		return errors.New("用户名或密码错误")
+	case 5:
+		return errors.New("未授权")
+	}
+	return fmt.Errorf("连接被拒绝（返回码%d）", code)
+}
@@ -0,0 +1,485 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"voice_assistant/voice_assistant_server/internal/errs"
+)
+
+// ErrQueueFull 阶段排队已满
+var ErrQueueFull = errs.New(errs.KindBusy, "处理队列已满")
+
+// ErrPoolClosed 工作池已关闭
+var ErrPoolClosed = errors.New("处理工作池已关闭")
+
+// Stage 处理阶段
+type Stage string
+
+const (
+	StageASR Stage = "asr"
+	StageLLM Stage = "llm"
+	StageTTS Stage = "tts"
+)
+
+// Priority 任务优先级
+type Priority string
+
+const (
+	PriorityInteractive Priority = "interactive" // 实时语音对话，优先处理
+	PriorityBatch       Priority = "batch"       // 文件转写、REST调用等可延后的任务
+)
+
+// ParsePriority 解析优先级（未知取值返回false）
+func ParsePriority(s string) (Priority, bool) {
+	switch Priority(s) {
+	case PriorityInteractive, PriorityBatch:
+		return Priority(s), true
+	default:
+		return "", false
+	}
+}
+
+// 会话间的调度方式（同一优先级内排队任务的取用顺序）
+const (
+	FairnessFIFO       = "fifo"        // 按到达顺序（默认）
+	FairnessRoundRobin = "round_robin" // 有排队任务的会话轮流
+	FairnessWeighted   = "weighted"    // 按API Key的权重轮流（平滑加权轮询）
+)
+
+// Config 工作池配置
+type Config struct {
+	ASRWorkers    int            `yaml:"asr_workers"` // 各阶段同时处理的任务数（0表示不限制）
+	LLMWorkers    int            `yaml:"llm_workers"`
+	TTSWorkers    int            `yaml:"tts_workers"`
+	QueueSize     int            `yaml:"queue_size"`      // 每个阶段每种优先级的排队上限
+	Fairness      string         `yaml:"fairness"`        // 会话间的调度方式: fifo|round_robin|weighted
+	MaxPerSession int            `yaml:"max_per_session"` // 每个会话在一个阶段同时处理的任务数（0表示不限制），超出的任务排队等待
+	Weights       map[string]int `yaml:"weights"`         // weighted调度时各API Key的权重（未列出的为1）
+
+	// 超时：各阶段单次调用（含排队）和一轮语音对话的最长耗时（0表示不限制）
+	ASRTimeout  time.Duration `yaml:"asr_timeout"`
+	LLMTimeout  time.Duration `yaml:"llm_timeout"`
+	TTSTimeout  time.Duration `yaml:"tts_timeout"`
+	TurnTimeout time.Duration `yaml:"turn_timeout"`
+}
+
+// Timeout 阶段单次调用的超时（0表示不限制）
+func (c Config) Timeout(stage Stage) time.Duration {
+	switch stage {
+	case StageASR:
+		return c.ASRTimeout
+	case StageLLM:
+		return c.LLMTimeout
+	case StageTTS:
+		return c.TTSTimeout
+	default:
+		return 0
+	}
+}
+
+// StageStats 阶段统计
+type StageStats struct {
+	Workers           int     `json:"workers"`
+	Busy              int64   `json:"busy"`
+	QueuedInteractive int     `json:"queued_interactive"`
+	QueuedBatch       int     `json:"queued_batch"`
+	Sessions          int     `json:"sessions"` // 有任务在处理或排队的会话数
+	Completed         int64   `json:"completed"`
+	Rejected          int64   `json:"rejected"`
+	AvgWaitMs         float64 `json:"avg_wait_ms"`
+}
+
+// SessionStats 单个会话在各阶段的任务数
+type SessionStats struct {
+	Active int // 正在处理
+	Queued int // 排队等待（含超出会话并发上限的任务）
+}
+
+// Owner 任务所属的会话，用于会话间公平调度
+type Owner struct {
+	Session string // 会话ID（为空时所有无归属的任务视为同一会话）
+	APIKey  string // 会话的API Key（weighted调度按Config.Weights取权重）
+}
+
+// ownerKey context中任务归属的键
+type ownerKey struct{}
+
+// WithOwner 指定任务所属的会话
+func WithOwner(ctx context.Context, owner Owner) context.Context {
+	return context.WithValue(ctx, ownerKey{}, owner)
+}
+
+// OwnerFromContext 获取任务所属的会话（未指定时为空）
+func OwnerFromContext(ctx context.Context) Owner {
+	owner, _ := ctx.Value(ownerKey{}).(Owner)
+	return owner
+}
+
+// Pool 按阶段划分的处理工作池
+// 每个阶段有固定数量的工作协程，交互任务排在批量任务之前，同一优先级内按Fairness在会话之间调度；同一会话的任务顺序由调用方保证。
+type Pool struct {
+	stages map[Stage]*stagePool
+	stop   chan struct{}
+	once   sync.Once
+	wg     sync.WaitGroup
+}
+
+// 排队的优先级（数值小的先取）
+const (
+	levelInteractive = iota
+	levelBatch
+	levelCount
+)
+
+// stagePool 单个阶段的工作协程和排队
+type stagePool struct {
+	workers       int
+	queueSize     int
+	fairness      string
+	maxPerSession int
+	weights       map[string]int
+
+	mu     sync.Mutex
+	cond   *sync.Cond
+	closed bool
+	levels [levelCount]level
+	owners map[string]*ownerState
+	seq    uint64
+
+	busy      atomic.Int64
+	completed atomic.Int64
+	rejected  atomic.Int64
+	waitNanos atomic.Int64
+}
+
+// level 一种优先级的排队
+type level struct {
+	order  []string // 有排队任务的会话（轮询顺序）
+	cursor int      // 轮询游标
+	length int      // 排队任务数
+}
+
+// ownerState 会话在阶段中的任务
+type ownerState struct {
+	weight  int
+	current int // 平滑加权轮询的当前值
+	active  int
+	queued  [levelCount][]*job
+}
+
+// job 排队的任务
+type job struct {
+	ctx      context.Context
+	run      func(ctx context.Context) error
+	done     chan error
+	owner    string
+	seq      uint64
+	queuedAt time.Time
+}
+
+// NewPool 创建工作池（worker数为0的阶段不经过工作池，直接在调用方协程执行）
+func NewPool(config Config) *Pool {
+	queueSize := config.QueueSize
+	if queueSize <= 0 {
+		queueSize = 100
+	}
+
+	p := &Pool{
+		stages: make(map[Stage]*stagePool),
+		stop:   make(chan struct{}),
+	}
+	for stage, workers := range map[Stage]int{
+		StageASR: config.ASRWorkers,
+		StageLLM: config.LLMWorkers,
+		StageTTS: config.TTSWorkers,
+	} {
+		if workers <= 0 {
+			continue
+		}
+		sp := &stagePool{
+			workers:       workers,
+			queueSize:     queueSize,
+			fairness:      config.Fairness,
+			maxPerSession: config.MaxPerSession,
+			weights:       config.Weights,
+			owners:        make(map[string]*ownerState),
+		}
+		sp.cond = sync.NewCond(&sp.mu)
+		p.stages[stage] = sp
+		for i := 0; i < workers; i++ {
+			p.wg.Add(1)
+			go p.work(sp)
+		}
+	}
+	return p
+}
+
+// Do 在指定阶段的工作协程上执行任务并等待结果
+// 排队已满时返回ErrQueueFull；ctx在排队期间结束时返回ctx.Err()，任务不再执行。
+func (p *Pool) Do(ctx context.Context, stage Stage, priority Priority, run func(ctx context.Context) error) error {
+	if p == nil {
+		return run(ctx)
+	}
+	sp, ok := p.stages[stage]
+	if !ok {
+		return run(ctx)
+	}
+
+	select {
+	case <-p.stop:
+		return ErrPoolClosed
+	default:
+	}
+
+	lv := levelInteractive
+	if priority == PriorityBatch {
+		lv = levelBatch
+	}
+
+	owner := OwnerFromContext(ctx)
+	j := &job{ctx: ctx, run: run, done: make(chan error, 1), owner: owner.Session, queuedAt: time.Now()}
+	if err := sp.enqueue(j, lv, owner.APIKey); err != nil {
+		return err
+	}
+
+	select {
+	case err := <-j.done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-p.stop:
+		return ErrPoolClosed
+	}
+}
+
+// enqueue 把任务排到会话的队列中
+func (sp *stagePool) enqueue(j *job, lv int, apiKey string) error {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+
+	if sp.closed {
+		return ErrPoolClosed
+	}
+	if sp.levels[lv].length >= sp.queueSize {
+		sp.rejected.Add(1)
+		return ErrQueueFull
+	}
+
+	o, ok := sp.owners[j.owner]
+	if !ok {
+		o = &ownerState{weight: 1}
+		if w := sp.weights[apiKey]; w > 0 {
+			o.weight = w
+		}
+		sp.owners[j.owner] = o
+	}
+	if len(o.queued[lv]) == 0 {
+		sp.levels[lv].order = append(sp.levels[lv].order, j.owner)
+	}
+	sp.seq++
+	j.seq = sp.seq
+	o.queued[lv] = append(o.queued[lv], j)
+	sp.levels[lv].length++
+	sp.cond.Signal()
+	return nil
+}
+
+// work 工作协程：优先取交互任务
+func (p *Pool) work(sp *stagePool) {
+	defer p.wg.Done()
+
+	for {
+		j := sp.next()
+		if j == nil {
+			return
+		}
+		sp.run(j)
+	}
+}
+
+// next 等待下一个可以执行的任务（工作池关闭时返回nil）
+func (sp *stagePool) next() *job {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+
+	for {
+		if sp.closed {
+			return nil
+		}
+		for lv := range sp.levels {
+			if j := sp.takeLocked(lv); j != nil {
+				return j
+			}
+		}
+		sp.cond.Wait()
+	}
+}
+
+// takeLocked 按调度方式从一种优先级中取出任务（会话已达并发上限时跳过该会话）
+func (sp *stagePool) takeLocked(lv int) *job {
+	l := &sp.levels[lv]
+	if l.length == 0 {
+		return nil
+	}
+
+	chosen := -1
+	switch sp.fairness {
+	case FairnessRoundRobin:
+		for i := range l.order {
+			index := (l.cursor + i) % len(l.order)
+			if sp.eligibleLocked(l.order[index]) {
+				chosen = index
+				break
+			}
+		}
+	case FairnessWeighted:
+		total := 0
+		for index, key := range l.order {
+			if !sp.eligibleLocked(key) {
+				continue
+			}
+			o := sp.owners[key]
+			o.current += o.weight
+			total += o.weight
+			if chosen < 0 || o.current > sp.owners[l.order[chosen]].current {
+				chosen = index
+			}
+		}
+		if chosen >= 0 {
+			sp.owners[l.order[chosen]].current -= total
+		}
+	default:
+		// fifo：各会话队首中最早排队的任务
+		for index, key := range l.order {
+			if sp.eligibleLocked(key) && (chosen < 0 || sp.owners[key].queued[lv][0].seq < sp.owners[l.order[chosen]].queued[lv][0].seq) {
+				chosen = index
+			}
+		}
+	}
+	if chosen < 0 {
+		return nil
+	}
+
+	key := l.order[chosen]
+	o := sp.owners[key]
+	j := o.queued[lv][0]
+	o.queued[lv] = o.queued[lv][1:]
+	l.length--
+	l.cursor = chosen + 1
+	if len(o.queued[lv]) == 0 {
+		l.order = append(l.order[:chosen], l.order[chosen+1:]...)
+		l.cursor = chosen
+	}
+	if len(l.order) > 0 {
+		l.cursor %= len(l.order)
+	} else {
+		l.cursor = 0
+	}
+	o.active++
+	return j
+}
+
+// eligibleLocked 会话是否未达到并发上限
+func (sp *stagePool) eligibleLocked(key string) bool {
+	return sp.maxPerSession <= 0 || sp.owners[key].active < sp.maxPerSession
+}
+
+// finish 任务结束，释放会话的并发名额
+func (sp *stagePool) finish(j *job) {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+
+	o := sp.owners[j.owner]
+	o.active--
+	if o.active == 0 && len(o.queued[levelInteractive]) == 0 && len(o.queued[levelBatch]) == 0 {
+		delete(sp.owners, j.owner)
+	}
+	// 会话名额释放后，其排队的任务可能可以执行
+	sp.cond.Signal()
+}
+
+// run 执行任务（排队期间已取消的任务直接跳过）
+func (sp *stagePool) run(j *job) {
+	defer sp.finish(j)
+
+	if err := j.ctx.Err(); err != nil {
+		j.done <- err
+		return
+	}
+
+	sp.waitNanos.Add(int64(time.Since(j.queuedAt)))
+	sp.busy.Add(1)
+	err := j.run(j.ctx)
+	sp.busy.Add(-1)
+	sp.completed.Add(1)
+	j.done <- err
+}
+
+// close 停止工作协程（进行中的任务继续执行，排队的任务不再执行）
+func (sp *stagePool) close() {
+	sp.mu.Lock()
+	sp.closed = true
+	sp.mu.Unlock()
+	sp.cond.Broadcast()
+}
+
+// SessionStats 会话在各阶段正在处理和排队的任务数
+func (p *Pool) SessionStats(session string) SessionStats {
+	var stats SessionStats
+	if p == nil {
+		return stats
+	}
+	for _, sp := range p.stages {
+		sp.mu.Lock()
+		if o, ok := sp.owners[session]; ok {
+			stats.Active += o.active
+			stats.Queued += len(o.queued[levelInteractive]) + len(o.queued[levelBatch])
+		}
+		sp.mu.Unlock()
+	}
+	return stats
+}
+
+// Stats 获取各阶段的队列深度和处理统计（未启用工作池的阶段不包含在内）
+func (p *Pool) Stats() map[Stage]StageStats {
+	stats := make(map[Stage]StageStats)
+	if p == nil {
+		return stats
+	}
+	for stage, sp := range p.stages {
+		sp.mu.Lock()
+		s := StageStats{
+			Workers:           sp.workers,
+			Busy:              sp.busy.Load(),
+			QueuedInteractive: sp.levels[levelInteractive].length,
+			QueuedBatch:       sp.levels[levelBatch].length,
+			Sessions:          len(sp.owners),
+			Completed:         sp.completed.Load(),
+			Rejected:          sp.rejected.Load(),
+		}
+		sp.mu.Unlock()
+		if s.Completed > 0 {
+			s.AvgWaitMs = float64(sp.waitNanos.Load()) / float64(s.Completed) / float64(time.Millisecond)
+		}
+		stats[stage] = s
+	}
+	return stats
+}
+
+// Close 停止工作协程，等待进行中的任务结束
+func (p *Pool) Close() {
+	if p == nil {
+		return
+	}
+	p.once.Do(func() {
+		close(p.stop)
+		for _, sp := range p.stages {
+			sp.close()
+		}
+	})
+	p.wg.Wait()
+}
@@ -0,0 +1,170 @@
+package pipeline
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockWorker 占住阶段唯一的工作协程，返回释放函数
+func blockWorker(t *testing.T, p *Pool, stage Stage) func() {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	go p.Do(context.Background(), stage, PriorityBatch, func(ctx context.Context) error {
+		close(started)
+		<-release
+		return nil
+	})
+	<-started
+	return func() { close(release) }
+}
+
+func TestPoolInteractiveFirst(t *testing.T) {
+	p := NewPool(Config{ASRWorkers: 1, QueueSize: 10})
+	defer p.Close()
+	release := blockWorker(t, p, StageASR)
+
+	var mu sync.Mutex
+	var order []Priority
+	var wg sync.WaitGroup
+	submit := func(priority Priority) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			require.NoError(t, p.Do(context.Background(), StageASR, priority, func(ctx context.Context) error {
+				mu.Lock()
+				order = append(order, priority)
+				mu.Unlock()
+				return nil
+			}))
+		}()
+	}
+
+	// 批量任务先排队，交互任务后到仍优先执行
+	submit(PriorityBatch)
+	require.Eventually(t, func() bool { return p.Stats()[StageASR].QueuedBatch == 1 }, time.Second, time.Millisecond)
+	submit(PriorityInteractive)
+	require.Eventually(t, func() bool { return p.Stats()[StageASR].QueuedInteractive == 1 }, time.Second, time.Millisecond)
+
+	release()
+	wg.Wait()
+	assert.Equal(t, []Priority{PriorityInteractive, PriorityBatch}, order)
+	assert.EqualValues(t, 3, p.Stats()[StageASR].Completed)
+}
+
+func TestPoolQueueFullAndCancel(t *testing.T) {
+	p := NewPool(Config{TTSWorkers: 1, QueueSize: 1})
+	defer p.Close()
+	release := blockWorker(t, p, StageTTS)
+	defer release()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	queued := make(chan error, 1)
+	go func() {
+		queued <- p.Do(ctx, StageTTS, PriorityInteractive, func(ctx context.Context) error {
+			t.Error("排队期间取消的任务不应执行")
+			return nil
+		})
+	}()
+	require.Eventually(t, func() bool { return p.Stats()[StageTTS].QueuedInteractive == 1 }, time.Second, time.Millisecond)
+
+	err := p.Do(context.Background(), StageTTS, PriorityInteractive, func(ctx context.Context) error { return nil })
+	assert.ErrorIs(t, err, ErrQueueFull)
+	assert.EqualValues(t, 1, p.Stats()[StageTTS].Rejected)
+
+	cancel()
+	assert.ErrorIs(t, <-queued, context.Canceled)
+}
+
+func TestPoolUnlimitedStage(t *testing.T) {
+	p := NewPool(Config{})
+	defer p.Close()
+
+	ran := false
+	require.NoError(t, p.Do(context.Background(), StageLLM, PriorityBatch, func(ctx context.Context) error {
+		ran = true
+		return nil
+	}))
+	assert.True(t, ran)
+	assert.Empty(t, p.Stats())
+}
+
+// runOrder 阶段唯一的工作协程被占住时依次排队各会话的任务，返回释放后的执行顺序
+func runOrder(t *testing.T, config Config, owners []Owner) []string {
+	config.ASRWorkers = 1
+	p := NewPool(config)
+	defer p.Close()
+	release := blockWorker(t, p, StageASR)
+
+	var mu sync.Mutex
+	var order []string
+	var wg sync.WaitGroup
+	for i, owner := range owners {
+		wg.Add(1)
+		ctx := WithOwner(context.Background(), owner)
+		go func() {
+			defer wg.Done()
+			require.NoError(t, p.Do(ctx, StageASR, PriorityInteractive, func(ctx context.Context) error {
+				mu.Lock()
+				order = append(order, OwnerFromContext(ctx).Session)
+				mu.Unlock()
+				return nil
+			}))
+		}()
+		require.Eventually(t, func() bool { return p.Stats()[StageASR].QueuedInteractive == i+1 }, time.Second, time.Millisecond)
+	}
+
+	release()
+	wg.Wait()
+	return order
+}
+
+func TestPoolFairness(t *testing.T) {
+	a, b := Owner{Session: "a", APIKey: "k1"}, Owner{Session: "b", APIKey: "k2"}
+	owners := []Owner{a, a, a, b, b, b}
+
+	assert.Equal(t, []string{"a", "a", "a", "b", "b", "b"}, runOrder(t, Config{}, owners))
+	assert.Equal(t, []string{"a", "b", "a", "b", "a", "b"}, runOrder(t, Config{Fairness: FairnessRoundRobin}, owners))
+	// 平滑加权轮询：b的权重为2
+	assert.Equal(t, []string{"b", "a", "b", "b", "a", "a"}, runOrder(t, Config{Fairness: FairnessWeighted, Weights: map[string]int{"k2": 2}}, owners))
+}
+
+func TestPoolMaxPerSession(t *testing.T) {
+	p := NewPool(Config{LLMWorkers: 2, MaxPerSession: 1})
+	defer p.Close()
+
+	ctx := WithOwner(context.Background(), Owner{Session: "a"})
+	release := make(chan struct{})
+	started := make(chan string, 3)
+	var wg sync.WaitGroup
+	submit := func(ctx context.Context, name string) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			require.NoError(t, p.Do(ctx, StageLLM, PriorityInteractive, func(ctx context.Context) error {
+				started <- name
+				<-release
+				return nil
+			}))
+		}()
+	}
+
+	// 同一会话的第二个任务超出上限排队，空闲的工作协程留给其他会话
+	submit(ctx, "a1")
+	assert.Equal(t, "a1", <-started)
+	submit(ctx, "a2")
+	require.Eventually(t, func() bool { return p.SessionStats("a") == SessionStats{Active: 1, Queued: 1} }, time.Second, time.Millisecond)
+	submit(WithOwner(context.Background(), Owner{Session: "b"}), "b1")
+	assert.Equal(t, "b1", <-started)
+	assert.EqualValues(t, 2, p.Stats()[StageLLM].Busy)
+	assert.Equal(t, 2, p.Stats()[StageLLM].Sessions)
+
+	close(release)
+	assert.Equal(t, "a2", <-started)
+	wg.Wait()
+	assert.Equal(t, SessionStats{}, p.SessionStats("a"))
+}
@@ -0,0 +1,132 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"voice_assistant/voice_assistant_server/internal/asr"
+	"voice_assistant/voice_assistant_server/internal/hooks"
+	"voice_assistant/voice_assistant_server/internal/llm"
+	"voice_assistant/voice_assistant_server/internal/tts"
+)
+
+// startTimeout 插件启动并返回能力声明的超时
+const startTimeout = 30 * time.Second
+
+// Manager 已加载的外部插件
+type Manager struct {
+	processes []*Process
+}
+
+// Load 启动配置的插件，把插件提供的ASR/LLM/TTS类型和钩子类型注册到对应的注册表
+// 插件提供的类型与编译进服务端的类型同名时替换内置实现（如使用单独发布的 sherpa 插件）。
+func Load(config Config) (*Manager, error) {
+	m := &Manager{}
+	for _, pc := range config.Plugins {
+		if pc.Command == "" {
+			m.Close()
+			return nil, fmt.Errorf("插件 %s 缺少 command", pc.Name)
+		}
+
+		process := NewProcess(pc)
+		ctx, cancel := context.WithTimeout(context.Background(), startTimeout)
+		info, err := process.Start(ctx)
+		cancel()
+		if err != nil {
+			process.Close()
+			m.Close()
+			return nil, err
+		}
+		m.processes = append(m.processes, process)
+
+		register(process, info)
+		log.Printf("插件已加载: %s %s (ASR: %v, LLM: %v, TTS: %v, 钩子: %v)",
+			info.Name, info.Version, info.ASR, info.LLM, info.TTS, info.Hooks)
+	}
+	return m, nil
+}
+
+// Close 停止全部插件进程
+func (m *Manager) Close() error {
+	for _, process := range m.processes {
+		process.Close()
+	}
+	return nil
+}
+
+// register 注册插件提供的类型
+func register(process *Process, info Info) {
+	for _, name := range info.ASR {
+		warnOverride("ASR", name, asr.GetAvailableASRTypes(), process)
+		provider := name
+		asr.RegisterASR(provider, func(config asr.ASRConfig) (asr.ASRService, error) {
+			return asr.NewPluginASR(process, provider, config), nil
+		})
+	}
+	for _, name := range info.LLM {
+		warnOverride("LLM", name, llm.GetAvailableLLMTypes(), process)
+		provider := name
+		llm.RegisterLLM(provider, func(config llm.LLMConfig) (llm.LLMService, error) {
+			return llm.NewPluginLLM(process, provider, config)
+		})
+	}
+	for _, name := range info.TTS {
+		warnOverride("TTS", name, tts.GetAvailableTTSTypes(), process)
+		provider := name
+		tts.RegisterTTS(provider, func(config tts.TTSConfig) (tts.TTSService, error) {
+			return tts.NewPluginTTS(process, provider, config), nil
+		})
+	}
+	for _, name := range info.Hooks {
+		warnOverride("钩子", name, hooks.GetAvailableHookTypes(), process)
+		hookType := name
+		hooks.RegisterHook(hookType, func(config hooks.HookConfig) (hooks.Hook, error) {
+			return &pluginHook{process: process, hookType: hookType, name: config.Name, options: config.Options}, nil
+		})
+	}
+}
+
+// warnOverride 插件类型替换内置实现时记录日志
+func warnOverride(kind, name string, available []string, process *Process) {
+	for _, t := range available {
+		if t == name {
+			log.Printf("插件 %s 提供的%s类型 %s 替换内置实现", process.Name(), kind, name)
+			return
+		}
+	}
+}
+
+// pluginHook 由插件进程处理的钩子
+type pluginHook struct {
+	process  *Process
+	hookType string
+	name     string
+	options  map[string]interface{}
+}
+
+// hookRequest 钩子请求
+type hookRequest struct {
+	Type    string                 `json:"type"`
+	Name    string                 `json:"name,omitempty"`
+	Options map[string]interface{} `json:"options,omitempty"`
+	Payload *hooks.Payload         `json:"payload"`
+}
+
+// Handle 把数据交给插件处理，插件返回修改后的数据（返回空结果时不修改）
+func (h *pluginHook) Handle(ctx context.Context, payload *hooks.Payload) error {
+	var result *hooks.Payload
+	err := h.process.Call(ctx, "hook.handle", hookRequest{
+		Type:    h.hookType,
+		Name:    h.name,
+		Options: h.options,
+		Payload: payload,
+	}, &result)
+	if err != nil || result == nil {
+		return err
+	}
+	result.SessionID, result.UserID = payload.SessionID, payload.UserID
+	*payload = *result
+	return nil
+}
@@ -0,0 +1,211 @@
+package plugins
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"voice_assistant/voice_assistant_server/internal/asr"
+	"voice_assistant/voice_assistant_server/internal/hooks"
+	"voice_assistant/voice_assistant_server/internal/llm"
+	"voice_assistant/voice_assistant_server/internal/tts"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试二进制以该环境变量启动时作为插件进程运行
+const helperEnv = "VA_TEST_PLUGIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(helperEnv) == "1" {
+		runHelperPlugin()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runHelperPlugin 测试插件：提供 test ASR/LLM/TTS 和 upper 钩子
+func runHelperPlugin() {
+	var writeMu sync.Mutex
+	reply := func(id int64, result interface{}, errMsg string) {
+		line, _ := json.Marshal(map[string]interface{}{"id": id, "result": result, "error": errMsg})
+		writeMu.Lock()
+		os.Stdout.Write(append(line, '\n'))
+		writeMu.Unlock()
+	}
+
+	// 未初始化的提供商拒绝处理请求（模拟需要先加载模型的插件）
+	initialized := make(map[string]bool)
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			return
+		}
+		var req struct {
+			ID     int64           `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		json.Unmarshal(line, &req)
+
+		if stage, method, _ := strings.Cut(req.Method, "."); method != "initialize" && (stage == "asr" || stage == "llm" || stage == "tts") && !initialized[stage] {
+			reply(req.ID, nil, stage+" not initialized")
+			continue
+		}
+
+		switch req.Method {
+		case "describe":
+			fmt.Fprintln(os.Stderr, "ready")
+			reply(req.ID, Info{Name: "test", Version: "1.0", ASR: []string{"test"}, LLM: []string{"test"}, TTS: []string{"test"}, Hooks: []string{"upper"}}, "")
+		case "asr.initialize", "llm.initialize":
+			initialized[strings.TrimSuffix(req.Method, ".initialize")] = true
+			reply(req.ID, map[string]interface{}{"model": "test-model", "languages": []string{"zh"}}, "")
+		case "tts.initialize":
+			initialized["tts"] = true
+			reply(req.ID, map[string]interface{}{"voices": []map[string]string{{"id": "v1"}}, "languages": []string{"zh"}}, "")
+		case "asr.recognize":
+			var params struct {
+				Audio []byte `json:"audio"`
+			}
+			json.Unmarshal(req.Params, &params)
+			reply(req.ID, map[string]interface{}{"text": fmt.Sprintf("%d bytes", len(params.Audio)), "confidence": 0.8}, "")
+		case "llm.generate":
+			var params struct {
+				Messages []llm.Message `json:"messages"`
+			}
+			json.Unmarshal(req.Params, &params)
+			reply(req.ID, map[string]interface{}{"content": "echo: " + params.Messages[len(params.Messages)-1].Content}, "")
+		case "tts.synthesize":
+			reply(req.ID, map[string]interface{}{"audio": make([]byte, 3200), "sample_rate": 16000}, "")
+		case "hook.handle":
+			var params struct {
+				Payload hooks.Payload `json:"payload"`
+			}
+			json.Unmarshal(req.Params, &params)
+			params.Payload.Text = strings.ToUpper(params.Payload.Text)
+			reply(req.ID, params.Payload, "")
+		case "crash":
+			os.Exit(3)
+		default:
+			reply(req.ID, nil, "unknown method "+req.Method)
+		}
+	}
+}
+
+// helperConfig 以测试二进制作为插件的配置
+func helperConfig(t *testing.T) PluginConfig {
+	executable, err := os.Executable()
+	require.NoError(t, err)
+	return PluginConfig{
+		Name:    "helper",
+		Command: executable,
+		Env:     []string{helperEnv + "=1"},
+		Timeout: 5 * time.Second,
+	}
+}
+
+func TestLoadRegistersProviders(t *testing.T) {
+	manager, err := Load(Config{Plugins: []PluginConfig{helperConfig(t)}})
+	require.NoError(t, err)
+	defer manager.Close()
+
+	ctx := context.Background()
+
+	asrService, err := asr.CreateASR(asr.ASRConfig{Type: "test", SampleRate: 16000, Channels: 1})
+	require.NoError(t, err)
+	require.NoError(t, asrService.Initialize(asr.ASRConfig{Type: "test", SampleRate: 16000, Channels: 1}))
+	result, err := asrService.ProcessAudio(ctx, make([]byte, 640))
+	require.NoError(t, err)
+	assert.Equal(t, "640 bytes", result.Text)
+	assert.Equal(t, "test-model", asrService.GetModelInfo().Name)
+
+	llmService, err := llm.CreateLLM(llm.LLMConfig{Type: "test", Model: "m"})
+	require.NoError(t, err)
+	require.NoError(t, llmService.Initialize(llm.LLMConfig{Type: "test", Model: "m"}))
+	response, err := llmService.Chat(ctx, "你好", "conv1")
+	require.NoError(t, err)
+	assert.Equal(t, "echo: 你好", response.Content)
+	assert.Len(t, llmService.(llm.ConversationHistoryProvider).ConversationHistory("conv1"), 2)
+
+	ttsService, err := tts.CreateTTS(tts.TTSConfig{Type: "test", SampleRate: 16000})
+	require.NoError(t, err)
+	require.NoError(t, ttsService.Initialize(tts.TTSConfig{Type: "test", SampleRate: 16000}))
+	speech, err := ttsService.SynthesizeText(ctx, "你好")
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), speech.Duration)
+	assert.Equal(t, "pcm", speech.Format)
+	assert.Len(t, ttsService.GetSupportedVoices(), 1)
+
+	chain, err := hooks.NewChain(hooks.Config{Hooks: []hooks.HookConfig{{Type: "upper"}}})
+	require.NoError(t, err)
+	payload := chain.Run(ctx, hooks.StagePostASR, hooks.Payload{SessionID: "s1", Text: "hello"})
+	assert.Equal(t, "HELLO", payload.Text)
+	assert.Equal(t, "s1", payload.SessionID)
+}
+
+func TestProcessRestartsAfterExit(t *testing.T) {
+	process := NewProcess(helperConfig(t))
+	defer process.Close()
+
+	ctx := context.Background()
+	info, err := process.Start(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "test", info.Name)
+
+	err = process.Call(ctx, "unknown", nil, nil)
+	assert.ErrorContains(t, err, "unknown method")
+
+	err = process.Call(ctx, "crash", nil, nil)
+	assert.ErrorIs(t, err, ErrPluginExited)
+
+	// 退出后下次调用时重启
+	time.Sleep(restartInterval)
+	var result map[string]interface{}
+	require.NoError(t, process.Call(ctx, "asr.initialize", nil, &result))
+	assert.Equal(t, "test-model", result["model"])
+}
+
+func TestProcessReinitializesAfterKill(t *testing.T) {
+	manager, err := Load(Config{Plugins: []PluginConfig{helperConfig(t)}})
+	require.NoError(t, err)
+	defer manager.Close()
+	process := manager.processes[0]
+
+	ctx := context.Background()
+	asrService, err := asr.CreateASR(asr.ASRConfig{Type: "test", SampleRate: 16000, Channels: 1})
+	require.NoError(t, err)
+	require.NoError(t, asrService.Initialize(asr.ASRConfig{Type: "test", SampleRate: 16000, Channels: 1}))
+	ttsService, err := tts.CreateTTS(tts.TTSConfig{Type: "test", SampleRate: 16000})
+	require.NoError(t, err)
+	require.NoError(t, ttsService.Initialize(tts.TTSConfig{Type: "test", SampleRate: 16000}))
+	_, err = asrService.ProcessAudio(ctx, make([]byte, 640))
+	require.NoError(t, err)
+
+	// 会话进行中插件进程被杀掉
+	process.mu.Lock()
+	cmd, exited := process.cmd, process.exited
+	process.mu.Unlock()
+	require.NoError(t, cmd.Process.Kill())
+	<-exited
+
+	// 重启后重放初始化，不需要重新调用 Initialize 即可继续识别和合成
+	time.Sleep(restartInterval)
+	result, err := asrService.ProcessAudio(ctx, make([]byte, 320))
+	require.NoError(t, err)
+	assert.Equal(t, "320 bytes", result.Text)
+	_, err = ttsService.SynthesizeText(ctx, "你好")
+	require.NoError(t, err)
+
+	process.mu.Lock()
+	assert.NotSame(t, cmd, process.cmd)
+	assert.Len(t, process.inits, 2)
+	process.mu.Unlock()
+}
@@ -0,0 +1,430 @@
+// Package plugins 外部进程插件：以子进程方式运行的ASR/LLM/TTS提供商和处理流程钩子
+//
+// 服务端通过插件进程的标准输入输出通信，每行一个JSON消息：
+//
+//	请求: {"id": 1, "method": "asr.recognize", "params": {...}}
+//	响应: {"id": 1, "result": {...}} 或 {"id": 1, "error": "错误信息"}
+//
+// 请求可以并发发出，插件按任意顺序返回响应；标准错误输出写入服务端日志。
+// 插件启动后服务端先调用 describe 获取插件提供的能力，插件进程退出后在下次调用时自动重启，
+// 重启后按原顺序重放已成功的初始化调用（asr.initialize 等），插件重新加载模型后再处理新的请求。
+package plugins
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 插件相关错误定义
+var (
+	ErrPluginExited = errors.New("plugin process exited")
+	ErrPluginClosed = errors.New("plugin closed")
+)
+
+// 插件默认值
+const (
+	defaultCallTimeout = 60 * time.Second
+	restartInterval    = time.Second     // 插件进程退出后两次重启之间的最短间隔
+	stopTimeout        = 5 * time.Second // 关闭标准输入后等待插件退出的时间
+	maxMessageSize     = 64 << 20        // 单行消息上限（音频以base64传输）
+)
+
+// Config 外部插件配置
+type Config struct {
+	Plugins []PluginConfig `yaml:"plugins"`
+}
+
+// PluginConfig 单个外部插件
+type PluginConfig struct {
+	Name    string                 `yaml:"name"`    // 名称（用于日志）
+	Command string                 `yaml:"command"` // 可执行文件
+	Args    []string               `yaml:"args"`    // 命令参数
+	Env     []string               `yaml:"env"`     // 追加的环境变量（KEY=VALUE）
+	Dir     string                 `yaml:"dir"`     // 工作目录
+	Timeout time.Duration          `yaml:"timeout"` // 单次调用超时
+	Options map[string]interface{} `yaml:"options"` // 随 describe 传给插件的自定义参数
+}
+
+// Info 插件声明的能力（describe 的结果）
+type Info struct {
+	Name    string   `json:"name"`
+	Version string   `json:"version"`
+	ASR     []string `json:"asr"`   // 提供的ASR类型
+	LLM     []string `json:"llm"`   // 提供的LLM类型
+	TTS     []string `json:"tts"`   // 提供的TTS类型
+	Hooks   []string `json:"hooks"` // 提供的钩子类型
+}
+
+// request 发给插件的请求
+type request struct {
+	ID     int64       `json:"id"`
+	Method string      `json:"method"`
+	Params interface{} `json:"params,omitempty"`
+}
+
+// response 插件返回的响应
+type response struct {
+	ID     int64           `json:"id"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// describeParams describe 请求参数
+type describeParams struct {
+	Options map[string]interface{} `json:"options,omitempty"`
+}
+
+// initializeSuffix 初始化方法的后缀（asr.initialize、llm.initialize、tts.initialize）
+const initializeSuffix = ".initialize"
+
+// initCall 插件进程重启后需要重放的初始化调用
+type initCall struct {
+	key    string // 方法和提供商，同一提供商重新初始化时替换之前的参数
+	method string
+	params json.RawMessage
+}
+
+// Process 插件进程
+type Process struct {
+	config PluginConfig
+
+	startMu sync.Mutex // 串行化启动
+
+	mu        sync.Mutex
+	cmd       *exec.Cmd
+	stdin     io.WriteCloser
+	running   bool
+	exited    chan struct{} // 当前进程退出时关闭
+	pending   map[int64]chan response
+	nextID    int64
+	info      Info
+	inits     []initCall // 已成功的初始化调用（按调用顺序）
+	startedAt time.Time
+	closed    bool
+
+	writeMu sync.Mutex
+}
+
+// NewProcess 创建插件进程（首次调用时启动）
+func NewProcess(config PluginConfig) *Process {
+	if config.Timeout <= 0 {
+		config.Timeout = defaultCallTimeout
+	}
+	if config.Name == "" {
+		config.Name = config.Command
+	}
+	return &Process{config: config, pending: make(map[int64]chan response)}
+}
+
+// Name 插件名称
+func (p *Process) Name() string {
+	return p.config.Name
+}
+
+// Start 启动插件进程并获取插件能力
+func (p *Process) Start(ctx context.Context) (Info, error) {
+	if err := p.ensure(ctx); err != nil {
+		return Info{}, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.info, nil
+}
+
+// Call 调用插件方法，result为nil时忽略返回值
+func (p *Process) Call(ctx context.Context, method string, params, result interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, p.config.Timeout)
+	defer cancel()
+
+	if err := p.ensure(ctx); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	if !p.running {
+		p.mu.Unlock()
+		return fmt.Errorf("%w: %s", ErrPluginExited, p.config.Name)
+	}
+	id, responses, exited := p.registerLocked()
+	stdin := p.stdin
+	p.mu.Unlock()
+
+	if err := p.roundTrip(ctx, stdin, id, responses, exited, method, params, result); err != nil {
+		return err
+	}
+	if strings.HasSuffix(method, initializeSuffix) {
+		p.recordInit(method, params)
+	}
+	return nil
+}
+
+// recordInit 记录成功的初始化调用，插件进程重启后重放
+func (p *Process) recordInit(method string, params interface{}) {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return
+	}
+	var target struct {
+		Provider string `json:"provider"`
+	}
+	json.Unmarshal(raw, &target)
+	call := initCall{key: method + "/" + target.Provider, method: method, params: raw}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i := range p.inits {
+		if p.inits[i].key == call.key {
+			p.inits[i] = call
+			return
+		}
+	}
+	p.inits = append(p.inits, call)
+}
+
+// Close 停止插件进程
+func (p *Process) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+	if !p.running {
+		return nil
+	}
+	// 关闭标准输入通知插件退出，超时未退出时结束进程
+	cmd, exited := p.cmd, p.exited
+	p.stdin.Close()
+	go func() {
+		select {
+		case <-exited:
+		case <-time.After(stopTimeout):
+			cmd.Process.Kill()
+		}
+	}()
+	return nil
+}
+
+// ensure 插件进程未运行时启动
+func (p *Process) ensure(ctx context.Context) error {
+	p.startMu.Lock()
+	defer p.startMu.Unlock()
+
+	p.mu.Lock()
+	closed, running, startedAt := p.closed, p.running, p.startedAt
+	p.mu.Unlock()
+	if closed {
+		return ErrPluginClosed
+	}
+	if running {
+		return nil
+	}
+	if !startedAt.IsZero() {
+		if wait := restartInterval - time.Since(startedAt); wait > 0 {
+			return fmt.Errorf("%w: %s（%v后重试）", ErrPluginExited, p.config.Name, wait.Round(time.Millisecond))
+		}
+		log.Printf("插件进程已退出，正在重启: %s", p.config.Name)
+	}
+
+	cmd := exec.Command(p.config.Command, p.config.Args...)
+	cmd.Dir = p.config.Dir
+	cmd.Env = append(os.Environ(), p.config.Env...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("启动插件 %s 失败: %w", p.config.Name, err)
+	}
+
+	exited := make(chan struct{})
+	p.mu.Lock()
+	p.cmd = cmd
+	p.stdin = stdin
+	p.running = true
+	p.exited = exited
+	p.startedAt = time.Now()
+	id, responses, _ := p.registerLocked()
+	p.mu.Unlock()
+	// 输出读完后再等待进程退出（Wait会关闭输出管道）
+	var readers sync.WaitGroup
+	readers.Add(2)
+	go func() {
+		defer readers.Done()
+		p.readResponses(cmd, stdout)
+	}()
+	go func() {
+		defer readers.Done()
+		p.logStderr(stderr)
+	}()
+	go p.wait(cmd, stdin, exited, &readers)
+
+	// 获取插件能力（重启后插件重新读取参数）
+	var info Info
+	if err := p.roundTrip(ctx, stdin, id, responses, exited, "describe", describeParams{Options: p.config.Options}, &info); err != nil {
+		cmd.Process.Kill()
+		return fmt.Errorf("插件 %s describe 失败: %w", p.config.Name, err)
+	}
+	if info.Name == "" {
+		info.Name = p.config.Name
+	}
+	p.mu.Lock()
+	p.info = info
+	inits := append([]initCall(nil), p.inits...)
+	p.mu.Unlock()
+
+	// 重启后重放初始化调用，恢复插件中各提供商的模型和参数
+	for _, call := range inits {
+		p.mu.Lock()
+		id, responses, _ := p.registerLocked()
+		p.mu.Unlock()
+		if err := p.roundTrip(ctx, stdin, id, responses, exited, call.method, call.params, nil); err != nil {
+			cmd.Process.Kill()
+			return fmt.Errorf("插件 %s 重启后重新调用 %s 失败: %w", p.config.Name, call.method, err)
+		}
+	}
+	if len(inits) > 0 {
+		log.Printf("插件已重启并重新初始化: %s (%d 个提供商)", p.config.Name, len(inits))
+	}
+	return nil
+}
+
+// registerLocked 分配请求ID（调用方持有mu）
+func (p *Process) registerLocked() (int64, chan response, chan struct{}) {
+	p.nextID++
+	responses := make(chan response, 1)
+	p.pending[p.nextID] = responses
+	return p.nextID, responses, p.exited
+}
+
+// roundTrip 发送请求并等待响应
+func (p *Process) roundTrip(ctx context.Context, stdin io.Writer, id int64, responses chan response, exited chan struct{}, method string, params, result interface{}) error {
+	defer func() {
+		p.mu.Lock()
+		delete(p.pending, id)
+		p.mu.Unlock()
+	}()
+
+	line, err := json.Marshal(request{ID: id, Method: method, Params: params})
+	if err != nil {
+		return err
+	}
+	p.writeMu.Lock()
+	_, err = stdin.Write(append(line, '\n'))
+	p.writeMu.Unlock()
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrPluginExited, p.config.Name, err)
+	}
+
+	select {
+	case resp := <-responses:
+		if resp.Error != "" {
+			return fmt.Errorf("插件 %s: %s", p.config.Name, resp.Error)
+		}
+		if result == nil || len(resp.Result) == 0 {
+			return nil
+		}
+		if err := json.Unmarshal(resp.Result, result); err != nil {
+			return fmt.Errorf("解析插件 %s 的 %s 响应失败: %w", p.config.Name, method, err)
+		}
+		return nil
+	case <-exited:
+		return fmt.Errorf("%w: %s", ErrPluginExited, p.config.Name)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// readResponses 读取插件输出的响应（输出无法继续读取时结束插件进程，下次调用时重启）
+func (p *Process) readResponses(cmd *exec.Cmd, stdout io.Reader) {
+	reader := bufio.NewReaderSize(stdout, 64*1024)
+	for {
+		line, err := readLine(reader)
+		if err != nil {
+			if err != io.EOF {
+				log.Printf("读取插件 %s 输出失败: %v", p.config.Name, err)
+				cmd.Process.Kill()
+				io.Copy(io.Discard, reader)
+			}
+			return
+		}
+		if len(line) == 0 {
+			continue
+		}
+
+		var resp response
+		if err := json.Unmarshal(line, &resp); err != nil {
+			log.Printf("插件 %s 输出了无法解析的消息: %v", p.config.Name, err)
+			continue
+		}
+		p.mu.Lock()
+		responses, exists := p.pending[resp.ID]
+		p.mu.Unlock()
+		if exists {
+			select {
+			case responses <- resp:
+			default: // 重复的响应
+			}
+		}
+	}
+}
+
+// readLine 读取一行（超过 maxMessageSize 时返回错误）
+func readLine(reader *bufio.Reader) ([]byte, error) {
+	var line []byte
+	for {
+		chunk, isPrefix, err := reader.ReadLine()
+		if err != nil {
+			return nil, err
+		}
+		line = append(line, chunk...)
+		if len(line) > maxMessageSize {
+			return nil, fmt.Errorf("消息超过 %d 字节", maxMessageSize)
+		}
+		if !isPrefix {
+			return line, nil
+		}
+	}
+}
+
+// logStderr 插件的标准错误输出写入日志
+func (p *Process) logStderr(stderr io.Reader) {
+	scanner := bufio.NewScanner(stderr)
+	for scanner.Scan() {
+		log.Printf("[插件 %s] %s", p.config.Name, scanner.Text())
+	}
+}
+
+// wait 等待插件进程退出，未完成的调用以错误结束
+func (p *Process) wait(cmd *exec.Cmd, stdin io.Closer, exited chan struct{}, readers *sync.WaitGroup) {
+	readers.Wait()
+	err := cmd.Wait()
+
+	p.mu.Lock()
+	if p.exited == exited {
+		p.running = false
+	}
+	closed := p.closed
+	p.mu.Unlock()
+	stdin.Close()
+	close(exited)
+
+	if !closed {
+		log.Printf("插件进程退出: %s, %v", p.config.Name, err)
+	}
+}
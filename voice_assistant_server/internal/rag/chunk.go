@@ -0,0 +1,92 @@
+package rag
+
+import (
+	"strings"
+)
+
+// defaultChunkSize 默认片段长度（字符）
+const defaultChunkSize = 500
+
+// sentenceEnds 句末标点（超长段落在句末处切分）
+const sentenceEnds = "。！？；.!?;\n"
+
+// SplitText 把文档切分为片段：优先按段落合并到约size字符，超长段落按句子切分，
+// 仍超长的句子直接按长度截断；相邻片段保留overlap字符的重叠，避免答案被切断在边界上
+func SplitText(text string, size, overlap int) []string {
+	if size <= 0 {
+		size = defaultChunkSize
+	}
+	if overlap < 0 || overlap >= size {
+		overlap = 0
+	}
+
+	var pieces []string
+	for _, paragraph := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+		if len([]rune(paragraph)) <= size {
+			pieces = append(pieces, paragraph)
+			continue
+		}
+		for _, sentence := range splitSentences(paragraph) {
+			runes := []rune(sentence)
+			for len(runes) > size {
+				pieces = append(pieces, string(runes[:size]))
+				runes = runes[size:]
+			}
+			if len(runes) > 0 {
+				pieces = append(pieces, string(runes))
+			}
+		}
+	}
+
+	var chunks []string
+	var current []rune
+	for _, piece := range pieces {
+		runes := []rune(piece)
+		if len(current) > 0 && len(current)+1+len(runes) > size {
+			chunks = append(chunks, strings.TrimSpace(string(current)))
+			current = tail(current, overlap)
+		}
+		if len(current) > 0 {
+			current = append(current, '\n')
+		}
+		current = append(current, runes...)
+	}
+	if text := strings.TrimSpace(string(current)); text != "" {
+		chunks = append(chunks, text)
+	}
+	return chunks
+}
+
+// splitSentences 按句末标点切分（标点保留在句子末尾）
+func splitSentences(text string) []string {
+	var sentences []string
+	start := 0
+	runes := []rune(text)
+	for i, r := range runes {
+		if strings.ContainsRune(sentenceEnds, r) {
+			if sentence := strings.TrimSpace(string(runes[start : i+1])); sentence != "" {
+				sentences = append(sentences, sentence)
+			}
+			start = i + 1
+		}
+	}
+	if sentence := strings.TrimSpace(string(runes[start:])); sentence != "" {
+		sentences = append(sentences, sentence)
+	}
+	return sentences
+}
+
+// tail 取末尾n个字符（作为下一片段的开头）
+func tail(runes []rune, n int) []rune {
+	if n <= 0 {
+		return nil
+	}
+	if len(runes) > n {
+		runes = runes[len(runes)-n:]
+	}
+	return append([]rune(nil), runes...)
+}
@@ -0,0 +1,195 @@
+package rag
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// defaultBatchSize 每次请求向量化的默认片段数
+const defaultBatchSize = 32
+
+// OpenAIEmbedder OpenAI兼容的向量化接口（/v1/embeddings）
+type OpenAIEmbedder struct {
+	baseURL string
+	apiKey  string
+	model   string
+	batch   int
+	client  *http.Client
+}
+
+// NewOpenAIEmbedder 创建OpenAI向量化服务
+func NewOpenAIEmbedder(config EmbeddingConfig) (*OpenAIEmbedder, error) {
+	baseURL := config.BaseURL
+	if baseURL == "" {
+		baseURL = "https://api.openai.com/v1"
+	}
+	model := config.Model
+	if model == "" {
+		model = "text-embedding-3-small"
+	}
+
+	return &OpenAIEmbedder{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  config.APIKey,
+		model:   model,
+		batch:   batchSize(config),
+		client:  &http.Client{Timeout: 60 * time.Second},
+	}, nil
+}
+
+// Embed 向量化
+func (e *OpenAIEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	return embedBatches(texts, e.batch, func(batch []string) ([][]float32, error) {
+		var response struct {
+			Data []struct {
+				Index     int       `json:"index"`
+				Embedding []float32 `json:"embedding"`
+			} `json:"data"`
+		}
+		headers := map[string]string{}
+		if e.apiKey != "" {
+			headers["Authorization"] = "Bearer " + e.apiKey
+		}
+		err := postJSON(ctx, e.client, e.baseURL+"/embeddings", headers, map[string]interface{}{
+			"model": e.model,
+			"input": batch,
+		}, &response)
+		if err != nil {
+			return nil, err
+		}
+
+		vectors := make([][]float32, len(batch))
+		for _, item := range response.Data {
+			if item.Index >= 0 && item.Index < len(vectors) {
+				vectors[item.Index] = item.Embedding
+			}
+		}
+		return vectors, nil
+	})
+}
+
+// OllamaEmbedder Ollama向量化接口（/api/embed）
+type OllamaEmbedder struct {
+	baseURL string
+	model   string
+	batch   int
+	client  *http.Client
+}
+
+// NewOllamaEmbedder 创建Ollama向量化服务
+func NewOllamaEmbedder(config EmbeddingConfig) (*OllamaEmbedder, error) {
+	baseURL := config.BaseURL
+	if baseURL == "" {
+		baseURL = "http://localhost:11434"
+	}
+	model := config.Model
+	if model == "" {
+		model = "nomic-embed-text"
+	}
+
+	return &OllamaEmbedder{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		model:   model,
+		batch:   batchSize(config),
+		client:  &http.Client{Timeout: 120 * time.Second},
+	}, nil
+}
+
+// Embed 向量化
+func (e *OllamaEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	return embedBatches(texts, e.batch, func(batch []string) ([][]float32, error) {
+		var response struct {
+			Embeddings [][]float32 `json:"embeddings"`
+		}
+		err := postJSON(ctx, e.client, e.baseURL+"/api/embed", nil, map[string]interface{}{
+			"model": e.model,
+			"input": batch,
+		}, &response)
+		if err != nil {
+			return nil, err
+		}
+		return response.Embeddings, nil
+	})
+}
+
+// batchSize 每次请求的片段数
+func batchSize(config EmbeddingConfig) int {
+	if config.BatchSize > 0 {
+		return config.BatchSize
+	}
+	return defaultBatchSize
+}
+
+// embedBatches 分批向量化并检查返回数量
+func embedBatches(texts []string, size int, embed func(batch []string) ([][]float32, error)) ([][]float32, error) {
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += size {
+		end := start + size
+		if end > len(texts) {
+			end = len(texts)
+		}
+
+		batch, err := embed(texts[start:end])
+		if err != nil {
+			return nil, fmt.Errorf("向量化失败: %w", err)
+		}
+		if len(batch) != end-start {
+			return nil, fmt.Errorf("向量化结果数量不符: 期望 %d, 实际 %d", end-start, len(batch))
+		}
+		for _, vector := range batch {
+			if len(vector) == 0 {
+				return nil, fmt.Errorf("向量化结果为空")
+			}
+		}
+		vectors = append(vectors, batch...)
+	}
+	return vectors, nil
+}
+
+// postJSON 发送JSON请求并解析响应
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, body interface{}, result interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respData, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(respData))
+	}
+	return json.Unmarshal(respData, result)
+}
+
+// 注册向量化实现
+func init() {
+	RegisterEmbedder("openai", func(config EmbeddingConfig) (Embedder, error) {
+		return NewOpenAIEmbedder(config)
+	})
+	RegisterEmbedder("ollama", func(config EmbeddingConfig) (Embedder, error) {
+		return NewOllamaEmbedder(config)
+	})
+}
@@ -0,0 +1,135 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"sort"
+)
+
+// 知识库检索相关错误定义
+var (
+	ErrUnsupportedStoreType    = errors.New("unsupported vector store type")
+	ErrUnsupportedEmbedderType = errors.New("unsupported embedding provider")
+	ErrUnsupportedDocument     = errors.New("unsupported document type")
+)
+
+// Chunk 文档切分后的一个片段
+type Chunk struct {
+	ID     string // 片段ID（来源+序号）
+	Source string // 来源文档（文件路径或上传时指定的名称）
+	Hash   string // 来源文档内容哈希（内容未变化时跳过重新索引）
+	Index  int    // 在来源文档中的序号
+	Text   string // 片段文本
+}
+
+// Result 检索结果
+type Result struct {
+	Chunk
+	Score float64 // 相似度（余弦，越大越相关）
+}
+
+// VectorStore 向量存储接口
+type VectorStore interface {
+	// Upsert 写入片段及其向量（ID相同的覆盖）
+	Upsert(ctx context.Context, chunks []Chunk, vectors [][]float32) error
+
+	// Search 返回与向量最相似的topK个片段（按相似度降序）
+	Search(ctx context.Context, vector []float32, topK int) ([]Result, error)
+
+	// DeleteSource 删除来源文档的全部片段
+	DeleteSource(ctx context.Context, source string) error
+
+	// SourceHash 来源文档已索引内容的哈希（未索引时返回空）
+	SourceHash(ctx context.Context, source string) (string, error)
+
+	// Count 片段总数
+	Count(ctx context.Context) (int, error)
+
+	// Close 关闭存储
+	Close() error
+}
+
+// Embedder 文本向量化接口
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// Config 知识库检索配置
+type Config struct {
+	Enabled      bool            `yaml:"enabled"`       // 是否启用（默认关闭）
+	Store        string          `yaml:"store"`         // 向量存储: sqlite|qdrant|memory
+	Path         string          `yaml:"path"`          // sqlite数据库文件
+	Qdrant       QdrantConfig    `yaml:"qdrant"`        // Qdrant配置
+	Embedding    EmbeddingConfig `yaml:"embedding"`     // 向量化模型
+	Documents    []string        `yaml:"documents"`     // 启动时索引的文件或目录（.txt/.md）
+	ChunkSize    int             `yaml:"chunk_size"`    // 片段长度（字符）
+	ChunkOverlap int             `yaml:"chunk_overlap"` // 相邻片段重叠的字符数
+	TopK         int             `yaml:"top_k"`         // 每轮注入的片段数
+	MinScore     float64         `yaml:"min_score"`     // 低于该相似度的片段不注入
+}
+
+// QdrantConfig Qdrant向量数据库配置
+type QdrantConfig struct {
+	URL        string `yaml:"url"`        // 服务地址，如 http://localhost:6333
+	Collection string `yaml:"collection"` // 集合名（不存在时按向量维度自动创建）
+	APIKey     string `yaml:"api_key"`    // API密钥（可选）
+}
+
+// EmbeddingConfig 向量化模型配置
+type EmbeddingConfig struct {
+	Provider  string `yaml:"provider"`   // openai|ollama
+	Model     string `yaml:"model"`      // 模型名
+	BaseURL   string `yaml:"base_url"`   // 服务地址（OpenAI兼容接口或Ollama）
+	APIKey    string `yaml:"api_key"`    // API密钥（openai）
+	BatchSize int    `yaml:"batch_size"` // 每次请求向量化的片段数
+}
+
+// StoreFactory 向量存储工厂函数类型
+type StoreFactory func(config Config) (VectorStore, error)
+
+// EmbedderFactory 向量化工厂函数类型
+type EmbedderFactory func(config EmbeddingConfig) (Embedder, error)
+
+// 注册的实现
+var (
+	storeFactories    = make(map[string]StoreFactory)
+	embedderFactories = make(map[string]EmbedderFactory)
+)
+
+// RegisterStore 注册向量存储实现
+func RegisterStore(name string, factory StoreFactory) {
+	storeFactories[name] = factory
+}
+
+// RegisterEmbedder 注册向量化实现
+func RegisterEmbedder(name string, factory EmbedderFactory) {
+	embedderFactories[name] = factory
+}
+
+// CreateStore 创建向量存储
+func CreateStore(config Config) (VectorStore, error) {
+	factory, exists := storeFactories[config.Store]
+	if !exists {
+		return nil, ErrUnsupportedStoreType
+	}
+	return factory(config)
+}
+
+// CreateEmbedder 创建向量化服务
+func CreateEmbedder(config EmbeddingConfig) (Embedder, error) {
+	factory, exists := embedderFactories[config.Provider]
+	if !exists {
+		return nil, ErrUnsupportedEmbedderType
+	}
+	return factory(config)
+}
+
+// GetAvailableStoreTypes 获取可用（已编译进当前二进制）的向量存储类型
+func GetAvailableStoreTypes() []string {
+	types := make([]string, 0, len(storeFactories))
+	for t := range storeFactories {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
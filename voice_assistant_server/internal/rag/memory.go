@@ -0,0 +1,130 @@
+package rag
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+)
+
+// MemoryStore 进程内向量存储（暴力检索，重启后丢失；也作为sqlite存储的检索索引）
+type MemoryStore struct {
+	entries map[string]memoryEntry
+	mu      sync.RWMutex
+}
+
+// memoryEntry 片段及其归一化向量
+type memoryEntry struct {
+	chunk  Chunk
+	vector []float32
+}
+
+// NewMemoryStore 创建进程内向量存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]memoryEntry)}
+}
+
+// Upsert 写入片段及其向量
+func (s *MemoryStore) Upsert(ctx context.Context, chunks []Chunk, vectors [][]float32) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, chunk := range chunks {
+		s.entries[chunk.ID] = memoryEntry{chunk: chunk, vector: normalize(vectors[i])}
+	}
+	return nil
+}
+
+// Search 余弦相似度检索
+func (s *MemoryStore) Search(ctx context.Context, vector []float32, topK int) ([]Result, error) {
+	query := normalize(vector)
+
+	s.mu.RLock()
+	results := make([]Result, 0, len(s.entries))
+	for _, entry := range s.entries {
+		if len(entry.vector) != len(query) {
+			continue // 更换向量化模型后残留的旧片段
+		}
+		results = append(results, Result{Chunk: entry.chunk, Score: dot(query, entry.vector)})
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if topK > 0 && len(results) > topK {
+		results = results[:topK]
+	}
+	return results, nil
+}
+
+// DeleteSource 删除来源文档的全部片段
+func (s *MemoryStore) DeleteSource(ctx context.Context, source string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, entry := range s.entries {
+		if entry.chunk.Source == source {
+			delete(s.entries, id)
+		}
+	}
+	return nil
+}
+
+// SourceHash 来源文档已索引内容的哈希
+func (s *MemoryStore) SourceHash(ctx context.Context, source string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, entry := range s.entries {
+		if entry.chunk.Source == source {
+			return entry.chunk.Hash, nil
+		}
+	}
+	return "", nil
+}
+
+// Count 片段总数
+func (s *MemoryStore) Count(ctx context.Context) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.entries), nil
+}
+
+// Close 关闭存储
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+// normalize 归一化向量（归一化后点积即余弦相似度）
+func normalize(vector []float32) []float32 {
+	var sum float64
+	for _, v := range vector {
+		sum += float64(v) * float64(v)
+	}
+	result := make([]float32, len(vector))
+	if sum == 0 {
+		return result
+	}
+	norm := math.Sqrt(sum)
+	for i, v := range vector {
+		result[i] = float32(float64(v) / norm)
+	}
+	return result
+}
+
+// dot 向量点积
+func dot(a, b []float32) float64 {
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
+
+// 注册向量存储实现
+func init() {
+	RegisterStore("memory", func(config Config) (VectorStore, error) {
+		return NewMemoryStore(), nil
+	})
+}
@@ -0,0 +1,286 @@
+//go:build !no_rag_qdrant
+
+package rag
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// QdrantStore Qdrant向量数据库存储（REST接口）
+type QdrantStore struct {
+	baseURL    string
+	collection string
+	apiKey     string
+	client     *http.Client
+
+	// 集合按首次写入的向量维度创建
+	ready bool
+	mu    sync.Mutex
+}
+
+// qdrantPoint Qdrant数据点
+type qdrantPoint struct {
+	ID      string        `json:"id"`
+	Vector  []float32     `json:"vector,omitempty"`
+	Payload qdrantPayload `json:"payload"`
+}
+
+// qdrantPayload 数据点附带的片段信息
+type qdrantPayload struct {
+	ChunkID string `json:"chunk_id"`
+	Source  string `json:"source"`
+	Hash    string `json:"hash"`
+	Index   int    `json:"index"`
+	Text    string `json:"text"`
+}
+
+// qdrantScoredPoint 检索结果
+type qdrantScoredPoint struct {
+	Score   float64       `json:"score"`
+	Payload qdrantPayload `json:"payload"`
+}
+
+// NewQdrantStore 创建Qdrant存储
+func NewQdrantStore(config Config) (*QdrantStore, error) {
+	baseURL := config.Qdrant.URL
+	if baseURL == "" {
+		baseURL = "http://localhost:6333"
+	}
+	collection := config.Qdrant.Collection
+	if collection == "" {
+		collection = "voice_assistant"
+	}
+
+	return &QdrantStore{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		collection: collection,
+		apiKey:     config.Qdrant.APIKey,
+		client:     &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// Upsert 写入片段及其向量
+func (s *QdrantStore) Upsert(ctx context.Context, chunks []Chunk, vectors [][]float32) error {
+	if len(chunks) == 0 {
+		return nil
+	}
+	if err := s.ensureCollection(ctx, len(vectors[0])); err != nil {
+		return err
+	}
+
+	points := make([]qdrantPoint, len(chunks))
+	for i, chunk := range chunks {
+		points[i] = qdrantPoint{
+			ID:     pointID(chunk.ID),
+			Vector: vectors[i],
+			Payload: qdrantPayload{
+				ChunkID: chunk.ID,
+				Source:  chunk.Source,
+				Hash:    chunk.Hash,
+				Index:   chunk.Index,
+				Text:    chunk.Text,
+			},
+		}
+	}
+	return s.do(ctx, http.MethodPut, "/points?wait=true", map[string]interface{}{"points": points}, nil)
+}
+
+// Search 检索
+func (s *QdrantStore) Search(ctx context.Context, vector []float32, topK int) ([]Result, error) {
+	var response struct {
+		Result []qdrantScoredPoint `json:"result"`
+	}
+	err := s.do(ctx, http.MethodPost, "/points/search", map[string]interface{}{
+		"vector":       vector,
+		"limit":        topK,
+		"with_payload": true,
+	}, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]Result, len(response.Result))
+	for i, point := range response.Result {
+		results[i] = Result{Chunk: point.Payload.chunk(), Score: point.Score}
+	}
+	return results, nil
+}
+
+// DeleteSource 删除来源文档的全部片段
+func (s *QdrantStore) DeleteSource(ctx context.Context, source string) error {
+	return s.do(ctx, http.MethodPost, "/points/delete?wait=true", map[string]interface{}{
+		"filter": sourceFilter(source),
+	}, nil)
+}
+
+// SourceHash 来源文档已索引内容的哈希
+func (s *QdrantStore) SourceHash(ctx context.Context, source string) (string, error) {
+	var response struct {
+		Result struct {
+			Points []qdrantScoredPoint `json:"points"`
+		} `json:"result"`
+	}
+	err := s.do(ctx, http.MethodPost, "/points/scroll", map[string]interface{}{
+		"filter":       sourceFilter(source),
+		"limit":        1,
+		"with_payload": true,
+	}, &response)
+	if err != nil || len(response.Result.Points) == 0 {
+		return "", err
+	}
+	return response.Result.Points[0].Payload.Hash, nil
+}
+
+// Count 片段总数
+func (s *QdrantStore) Count(ctx context.Context) (int, error) {
+	var response struct {
+		Result struct {
+			Count int `json:"count"`
+		} `json:"result"`
+	}
+	if err := s.do(ctx, http.MethodPost, "/points/count", map[string]interface{}{"exact": true}, &response); err != nil {
+		return 0, err
+	}
+	return response.Result.Count, nil
+}
+
+// Close 关闭存储
+func (s *QdrantStore) Close() error {
+	return nil
+}
+
+// ensureCollection 集合不存在时按向量维度创建
+func (s *QdrantStore) ensureCollection(ctx context.Context, size int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ready {
+		return nil
+	}
+
+	exists, err := s.collectionExists(ctx)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		err := s.do(ctx, http.MethodPut, "", map[string]interface{}{
+			"vectors": map[string]interface{}{"size": size, "distance": "Cosine"},
+		}, nil)
+		if err != nil {
+			return fmt.Errorf("创建Qdrant集合失败: %w", err)
+		}
+		if err := s.do(ctx, http.MethodPut, "/index?wait=true", map[string]interface{}{
+			"field_name":   "source",
+			"field_schema": "keyword",
+		}, nil); err != nil {
+			return fmt.Errorf("创建Qdrant索引失败: %w", err)
+		}
+	}
+	s.ready = true
+	return nil
+}
+
+// collectionExists 检查集合是否存在
+func (s *QdrantStore) collectionExists(ctx context.Context) (bool, error) {
+	req, err := s.newRequest(ctx, http.MethodGet, "", nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("连接Qdrant失败: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode == http.StatusOK, nil
+}
+
+// do 调用集合下的接口
+func (s *QdrantStore) do(ctx context.Context, method, path string, body interface{}, result interface{}) error {
+	req, err := s.newRequest(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("连接Qdrant失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("读取Qdrant响应失败: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		// 集合尚未创建时视为空
+		if resp.StatusCode == http.StatusNotFound && method != http.MethodPut {
+			return nil
+		}
+		return fmt.Errorf("Qdrant返回错误 %d: %s", resp.StatusCode, string(data))
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, result); err != nil {
+		return fmt.Errorf("解析Qdrant响应失败: %w", err)
+	}
+	return nil
+}
+
+// newRequest 创建集合下的请求
+func (s *QdrantStore) newRequest(ctx context.Context, method, path string, body interface{}) (*http.Request, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, s.baseURL+"/collections/"+s.collection+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.apiKey != "" {
+		req.Header.Set("api-key", s.apiKey)
+	}
+	return req, nil
+}
+
+// chunk 转换为片段
+func (p qdrantPayload) chunk() Chunk {
+	return Chunk{ID: p.ChunkID, Source: p.Source, Hash: p.Hash, Index: p.Index, Text: p.Text}
+}
+
+// sourceFilter 按来源文档过滤
+func sourceFilter(source string) map[string]interface{} {
+	return map[string]interface{}{
+		"must": []map[string]interface{}{
+			{"key": "source", "match": map[string]interface{}{"value": source}},
+		},
+	}
+}
+
+// pointID 片段ID转换为Qdrant要求的UUID格式
+func pointID(chunkID string) string {
+	sum := sha256.Sum256([]byte(chunkID))
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+// 注册向量存储实现
+func init() {
+	RegisterStore("qdrant", func(config Config) (VectorStore, error) {
+		return NewQdrantStore(config)
+	})
+}
@@ -0,0 +1,62 @@
+//go:build !no_rag_qdrant
+
+package rag
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQdrantStore(t *testing.T) {
+	var created bool
+	var upserted []qdrantPoint
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.Header.Get("api-key"))
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/collections/kb":
+			if !created {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+		case r.Method == http.MethodPut && r.URL.Path == "/collections/kb":
+			created = true
+		case r.Method == http.MethodPut && r.URL.Path == "/collections/kb/index":
+		case r.Method == http.MethodPut && r.URL.Path == "/collections/kb/points":
+			var body struct {
+				Points []qdrantPoint `json:"points"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			upserted = body.Points
+		case r.URL.Path == "/collections/kb/points/search":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"result": []qdrantScoredPoint{{Score: 0.9, Payload: upserted[0].Payload}},
+			})
+			return
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		w.Write([]byte(`{"result":true}`))
+	}))
+	defer server.Close()
+
+	store, err := NewQdrantStore(Config{Qdrant: QdrantConfig{URL: server.URL, Collection: "kb", APIKey: "secret"}})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, store.Upsert(ctx, []Chunk{{ID: "a#0", Source: "a", Text: "年假"}}, [][]float32{{1, 0}}))
+	assert.True(t, created)
+	require.Len(t, upserted, 1)
+	assert.Len(t, upserted[0].ID, 36)
+
+	results, err := store.Search(ctx, []float32{1, 0}, 3)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "a#0", results[0].ID)
+	assert.Equal(t, "年假", results[0].Text)
+}
@@ -0,0 +1,111 @@
+package rag
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// keywordEmbedder 按关键词出现次数生成向量的测试向量化服务
+type keywordEmbedder struct {
+	keywords []string
+	calls    int
+}
+
+func (e *keywordEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	e.calls++
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vector := make([]float32, len(e.keywords)+1)
+		for j, keyword := range e.keywords {
+			vector[j] = float32(strings.Count(text, keyword))
+		}
+		vector[len(e.keywords)] = 0.1 // 避免零向量
+		vectors[i] = vector
+	}
+	return vectors, nil
+}
+
+func TestSplitText(t *testing.T) {
+	text := "第一段。\n\n第二段。\n\n" + strings.Repeat("很长的句子。", 20)
+	chunks := SplitText(text, 30, 5)
+
+	require.Greater(t, len(chunks), 2)
+	assert.True(t, strings.HasPrefix(chunks[0], "第一段。\n第二段。\n很长的句子。"))
+	for _, chunk := range chunks {
+		assert.LessOrEqual(t, utf8.RuneCountInString(chunk), 30+5+1)
+	}
+	// 相邻片段有重叠
+	last := []rune(chunks[1])
+	assert.True(t, strings.HasPrefix(chunks[2], string(last[len(last)-5:])))
+
+	assert.Empty(t, SplitText("  \n\n ", 30, 5))
+}
+
+func TestRetrieverSearch(t *testing.T) {
+	ctx := context.Background()
+	embedder := &keywordEmbedder{keywords: []string{"年假", "报销", "WiFi"}}
+	r := NewRetrieverWith(Config{ChunkSize: 30, TopK: 2, MinScore: 0.5}, NewMemoryStore(), embedder)
+
+	n, err := r.IndexDocument(ctx, "handbook.md", "员工每年有15天年假，年假需提前一周申请。\n\n差旅报销在出差结束后30天内提交。")
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	results, err := r.Search(ctx, "我有几天年假")
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Contains(t, results[0].Text, "15天年假")
+
+	prompt, err := r.Prompt(ctx, "怎么报销")
+	require.NoError(t, err)
+	assert.Contains(t, prompt, "来源：handbook.md")
+	assert.Contains(t, prompt, "30天内")
+
+	// 无关问题不注入
+	prompt, err = r.Prompt(ctx, "今天天气怎么样")
+	require.NoError(t, err)
+	assert.Empty(t, prompt)
+
+	// 内容未变化时不重新向量化
+	calls := embedder.calls
+	n, err = r.IndexDocument(ctx, "handbook.md", "员工每年有15天年假，年假需提前一周申请。\n\n差旅报销在出差结束后30天内提交。")
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+	assert.Equal(t, calls, embedder.calls)
+
+	// 内容变化时替换旧片段
+	n, err = r.IndexDocument(ctx, "handbook.md", "访客WiFi密码在前台领取。")
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	stats, err := r.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Chunks)
+
+	require.NoError(t, r.DeleteDocument(ctx, "handbook.md"))
+	stats, err = r.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, stats.Chunks)
+}
+
+func TestIndexPaths(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.md"), []byte("年假说明"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "sub"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sub", "b.txt"), []byte("报销说明"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "image.png"), []byte{0x89}, 0644))
+
+	r := NewRetrieverWith(Config{ChunkSize: 50}, NewMemoryStore(), &keywordEmbedder{keywords: []string{"年假", "报销"}})
+	documents, chunks, err := r.IndexPaths(context.Background(), []string{dir})
+	require.NoError(t, err)
+	assert.Equal(t, 2, documents)
+	assert.Equal(t, 2, chunks)
+
+	_, err = r.IndexFile(context.Background(), filepath.Join(dir, "image.png"))
+	assert.ErrorIs(t, err, ErrUnsupportedDocument)
+}
@@ -0,0 +1,214 @@
+package rag
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// defaultTopK 默认每轮注入的片段数
+const defaultTopK = 3
+
+// documentExtensions 支持索引的文档类型
+var documentExtensions = map[string]bool{
+	".txt":      true,
+	".md":       true,
+	".markdown": true,
+}
+
+// Retriever 知识库检索：切分并索引文档，按用户问题检索相关片段生成注入提示
+type Retriever struct {
+	store    VectorStore
+	embedder Embedder
+	config   Config
+}
+
+// Stats 知识库统计
+type Stats struct {
+	Store  string `json:"store"`
+	Chunks int    `json:"chunks"`
+}
+
+// NewRetriever 创建知识库检索
+func NewRetriever(config Config) (*Retriever, error) {
+	if config.Store == "" {
+		config.Store = "sqlite"
+	}
+	if config.ChunkSize <= 0 {
+		config.ChunkSize = defaultChunkSize
+	}
+	if config.TopK <= 0 {
+		config.TopK = defaultTopK
+	}
+
+	embedder, err := CreateEmbedder(config.Embedding)
+	if err != nil {
+		return nil, fmt.Errorf("创建向量化服务失败(%s): %w", config.Embedding.Provider, err)
+	}
+	store, err := CreateStore(config)
+	if err != nil {
+		return nil, fmt.Errorf("创建向量存储失败(%s): %w", config.Store, err)
+	}
+	return NewRetrieverWith(config, store, embedder), nil
+}
+
+// NewRetrieverWith 使用指定的存储和向量化服务创建知识库检索
+func NewRetrieverWith(config Config, store VectorStore, embedder Embedder) *Retriever {
+	if config.TopK <= 0 {
+		config.TopK = defaultTopK
+	}
+	return &Retriever{store: store, embedder: embedder, config: config}
+}
+
+// IndexDocument 索引一篇文档（替换同一来源的旧片段；内容未变化时跳过），返回片段数
+func (r *Retriever) IndexDocument(ctx context.Context, source, text string) (int, error) {
+	// 哈希包含向量化模型，更换模型后文档会重新向量化
+	sum := sha256.Sum256([]byte(r.config.Embedding.Provider + "\x00" + r.config.Embedding.Model + "\x00" + text))
+	hash := hex.EncodeToString(sum[:])
+
+	indexed, err := r.store.SourceHash(ctx, source)
+	if err != nil {
+		return 0, err
+	}
+	if indexed == hash {
+		return 0, nil
+	}
+
+	texts := SplitText(text, r.config.ChunkSize, r.config.ChunkOverlap)
+	if len(texts) == 0 {
+		return 0, r.store.DeleteSource(ctx, source)
+	}
+
+	vectors, err := r.embedder.Embed(ctx, texts)
+	if err != nil {
+		return 0, err
+	}
+
+	chunks := make([]Chunk, len(texts))
+	for i, chunkText := range texts {
+		chunks[i] = Chunk{
+			ID:     fmt.Sprintf("%s#%d", source, i),
+			Source: source,
+			Hash:   hash,
+			Index:  i,
+			Text:   chunkText,
+		}
+	}
+
+	// 先向量化再替换，向量化失败时保留旧内容
+	if err := r.store.DeleteSource(ctx, source); err != nil {
+		return 0, err
+	}
+	if err := r.store.Upsert(ctx, chunks, vectors); err != nil {
+		return 0, err
+	}
+	return len(chunks), nil
+}
+
+// SupportedDocument 判断文件类型是否支持索引
+func SupportedDocument(name string) bool {
+	return documentExtensions[strings.ToLower(filepath.Ext(name))]
+}
+
+// IndexFile 索引文本文件（来源为文件路径）
+func (r *Retriever) IndexFile(ctx context.Context, path string) (int, error) {
+	if !SupportedDocument(path) {
+		return 0, ErrUnsupportedDocument
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("读取文档失败: %w", err)
+	}
+	return r.IndexDocument(ctx, filepath.Clean(path), string(data))
+}
+
+// IndexPaths 索引文件或目录（递归，跳过不支持的文件类型），返回新索引的文档数和片段数
+func (r *Retriever) IndexPaths(ctx context.Context, paths []string) (int, int, error) {
+	documents, chunks := 0, 0
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.IsDir() || !SupportedDocument(path) {
+				return nil
+			}
+
+			count, err := r.IndexFile(ctx, path)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			if count > 0 {
+				documents++
+				chunks += count
+			}
+			return nil
+		})
+		if err != nil {
+			return documents, chunks, err
+		}
+	}
+	return documents, chunks, nil
+}
+
+// DeleteDocument 删除文档
+func (r *Retriever) DeleteDocument(ctx context.Context, source string) error {
+	return r.store.DeleteSource(ctx, source)
+}
+
+// Search 检索与问题相关的片段（过滤低于min_score的结果）
+func (r *Retriever) Search(ctx context.Context, query string) ([]Result, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, nil
+	}
+
+	vectors, err := r.embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, err
+	}
+	results, err := r.store.Search(ctx, vectors[0], r.config.TopK)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := results[:0]
+	for _, result := range results {
+		if result.Score >= r.config.MinScore {
+			filtered = append(filtered, result)
+		}
+	}
+	return filtered, nil
+}
+
+// Prompt 生成注入系统提示的知识库资料（没有相关片段时返回空）
+func (r *Retriever) Prompt(ctx context.Context, query string) (string, error) {
+	results, err := r.Search(ctx, query)
+	if err != nil || len(results) == 0 {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString("以下是知识库中与用户问题相关的资料，回答时优先依据这些资料，资料没有涉及的细节不要编造：")
+	for i, result := range results {
+		fmt.Fprintf(&b, "\n[%d] 来源：%s\n%s", i+1, filepath.Base(result.Source), result.Text)
+	}
+	return b.String(), nil
+}
+
+// Stats 知识库统计
+func (r *Retriever) Stats(ctx context.Context) (Stats, error) {
+	count, err := r.store.Count(ctx)
+	return Stats{Store: r.config.Store, Chunks: count}, err
+}
+
+// Close 关闭存储
+func (r *Retriever) Close() error {
+	return r.store.Close()
+}
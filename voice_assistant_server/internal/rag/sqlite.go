@@ -0,0 +1,164 @@
+//go:build !no_rag_sqlite
+
+package rag
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteSchema 片段表
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS chunks (
+	id     TEXT PRIMARY KEY,
+	source TEXT NOT NULL,
+	hash   TEXT NOT NULL,
+	idx    INTEGER NOT NULL,
+	text   TEXT NOT NULL,
+	vector BLOB NOT NULL
+);
+CREATE INDEX IF NOT EXISTS chunks_source ON chunks(source);`
+
+// SQLiteStore 本地SQLite向量存储：片段和向量持久化到单个数据库文件，启动时载入内存检索
+type SQLiteStore struct {
+	db    *sql.DB
+	index *MemoryStore
+}
+
+// NewSQLiteStore 创建SQLite向量存储
+func NewSQLiteStore(config Config) (*SQLiteStore, error) {
+	path := config.Path
+	if path == "" {
+		path = "data/knowledge.db"
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("创建知识库目录失败: %w", err)
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("打开知识库失败: %w", err)
+	}
+	db.SetMaxOpenConns(1) // SQLite单写者，避免并发写入时的锁冲突
+
+	store := &SQLiteStore{db: db, index: NewMemoryStore()}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("初始化知识库失败: %w", err)
+	}
+	if err := store.load(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return store, nil
+}
+
+// load 载入全部片段到内存索引
+func (s *SQLiteStore) load() error {
+	rows, err := s.db.Query(`SELECT id, source, hash, idx, text, vector FROM chunks`)
+	if err != nil {
+		return fmt.Errorf("读取知识库失败: %w", err)
+	}
+	defer rows.Close()
+
+	var chunks []Chunk
+	var vectors [][]float32
+	for rows.Next() {
+		var chunk Chunk
+		var blob []byte
+		if err := rows.Scan(&chunk.ID, &chunk.Source, &chunk.Hash, &chunk.Index, &chunk.Text, &blob); err != nil {
+			return fmt.Errorf("读取知识库失败: %w", err)
+		}
+		chunks = append(chunks, chunk)
+		vectors = append(vectors, decodeVector(blob))
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("读取知识库失败: %w", err)
+	}
+	return s.index.Upsert(context.Background(), chunks, vectors)
+}
+
+// Upsert 写入片段及其向量
+func (s *SQLiteStore) Upsert(ctx context.Context, chunks []Chunk, vectors [][]float32) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("写入知识库失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT OR REPLACE INTO chunks (id, source, hash, idx, text, vector) VALUES (?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("写入知识库失败: %w", err)
+	}
+	defer stmt.Close()
+
+	for i, chunk := range chunks {
+		if _, err := stmt.ExecContext(ctx, chunk.ID, chunk.Source, chunk.Hash, chunk.Index, chunk.Text, encodeVector(vectors[i])); err != nil {
+			return fmt.Errorf("写入知识库失败: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("写入知识库失败: %w", err)
+	}
+	return s.index.Upsert(ctx, chunks, vectors)
+}
+
+// Search 检索（在内存索引中进行）
+func (s *SQLiteStore) Search(ctx context.Context, vector []float32, topK int) ([]Result, error) {
+	return s.index.Search(ctx, vector, topK)
+}
+
+// DeleteSource 删除来源文档的全部片段
+func (s *SQLiteStore) DeleteSource(ctx context.Context, source string) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM chunks WHERE source = ?`, source); err != nil {
+		return fmt.Errorf("删除知识库文档失败: %w", err)
+	}
+	return s.index.DeleteSource(ctx, source)
+}
+
+// SourceHash 来源文档已索引内容的哈希
+func (s *SQLiteStore) SourceHash(ctx context.Context, source string) (string, error) {
+	return s.index.SourceHash(ctx, source)
+}
+
+// Count 片段总数
+func (s *SQLiteStore) Count(ctx context.Context) (int, error) {
+	return s.index.Count(ctx)
+}
+
+// Close 关闭数据库
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+// encodeVector 向量编码为小端float32字节序列
+func encodeVector(vector []float32) []byte {
+	blob := make([]byte, 4*len(vector))
+	for i, v := range vector {
+		binary.LittleEndian.PutUint32(blob[4*i:], math.Float32bits(v))
+	}
+	return blob
+}
+
+// decodeVector 解码向量
+func decodeVector(blob []byte) []float32 {
+	vector := make([]float32, len(blob)/4)
+	for i := range vector {
+		vector[i] = math.Float32frombits(binary.LittleEndian.Uint32(blob[4*i:]))
+	}
+	return vector
+}
+
+// 注册向量存储实现
+func init() {
+	RegisterStore("sqlite", func(config Config) (VectorStore, error) {
+		return NewSQLiteStore(config)
+	})
+}
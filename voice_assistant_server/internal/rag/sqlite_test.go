@@ -0,0 +1,46 @@
+//go:build !no_rag_sqlite
+
+package rag
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLiteStorePersists(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "knowledge.db")
+
+	store, err := NewSQLiteStore(Config{Path: path})
+	require.NoError(t, err)
+	chunks := []Chunk{
+		{ID: "a#0", Source: "a", Hash: "h1", Text: "年假"},
+		{ID: "b#0", Source: "b", Hash: "h2", Text: "报销"},
+	}
+	require.NoError(t, store.Upsert(ctx, chunks, [][]float32{{1, 0}, {0, 1}}))
+	require.NoError(t, store.Close())
+
+	// 重新打开后数据仍在
+	store, err = NewSQLiteStore(Config{Path: path})
+	require.NoError(t, err)
+	defer store.Close()
+
+	results, err := store.Search(ctx, []float32{0.1, 1}, 1)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "报销", results[0].Text)
+	assert.InDelta(t, 0.995, results[0].Score, 0.01)
+
+	hash, err := store.SourceHash(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, "h1", hash)
+
+	require.NoError(t, store.DeleteSource(ctx, "a"))
+	count, err := store.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
@@ -0,0 +1,456 @@
+package reminder
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Action 提醒指令
+type Action string
+
+const (
+	ActionSet    Action = "set"    // 设置提醒或计时器
+	ActionCancel Action = "cancel" // 取消提醒或计时器
+	ActionList   Action = "list"   // 查询待触发的提醒
+)
+
+// Intent 从识别文本中解析出的提醒指令
+type Intent struct {
+	Action   Action
+	Kind     Kind          // 设置时为提醒类型；取消和查询时为空表示全部类型
+	Text     string        // 提醒内容
+	Duration time.Duration // 计时器时长（相对时间的提醒也会填写）
+	DueAt    time.Time     // 到期时间
+	Language string        // 指令使用的语言: zh, en
+}
+
+// ParseIntent 解析识别文本中的提醒指令（中文和英文，基于规则）
+// now 的时区决定“明天8点”等时间的含义。
+func ParseIntent(text string, now time.Time) (Intent, bool) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return Intent{}, false
+	}
+	if containsHan(text) {
+		return parseChinese(text, now)
+	}
+	return parseEnglish(strings.ToLower(text), now)
+}
+
+// containsHan 是否包含汉字
+func containsHan(text string) bool {
+	for _, r := range text {
+		if unicode.Is(unicode.Han, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// 中文指令
+var (
+	zhNumber   = `([0-9]+|[零〇一二两三四五六七八九十百千]+)`
+	zhDuration = regexp.MustCompile(`(半|[0-9]+|[零〇一二两三四五六七八九十百千]+)(个半|个|半)?(小时|钟头|分钟|分|秒钟|秒)`)
+	zhClock    = regexp.MustCompile(`(今天|明天|后天)?(早上|早晨|上午|中午|下午|傍晚|晚上|今晚)?` + zhNumber + `(?:点|[:：])(?:(半)|` + zhNumber + `分?|钟)?`)
+
+	zhReminderCues = []string{"提醒我", "提醒一下我", "叫我", "记得提醒"}
+	zhTimerCues    = []string{"倒计时", "计时器", "计时", "定时"}
+	zhCancelCues   = []string{"取消", "删除", "删掉", "关掉", "关闭", "停止", "不用提醒"}
+	zhListCues     = []string{"哪些提醒", "什么提醒", "几个提醒", "提醒列表", "还剩多久", "还有多久", "还剩多少时间", "还剩几分钟"}
+	zhFillers      = []string{"一下", "要", "去", "该", "我", "记得", "说"}
+)
+
+// parseChinese 解析中文指令
+func parseChinese(text string, now time.Time) (Intent, bool) {
+	compact := strings.Join(strings.Fields(text), "")
+	mentionsReminder := strings.Contains(compact, "提醒")
+	mentionsTimer := containsAny(compact, zhTimerCues)
+
+	if containsAny(compact, zhCancelCues) && (mentionsReminder || mentionsTimer || strings.Contains(compact, "闹钟")) {
+		return Intent{Action: ActionCancel, Kind: mentionedKind(mentionsReminder, mentionsTimer), Language: "zh"}, true
+	}
+	if containsAny(compact, zhListCues) {
+		return Intent{Action: ActionList, Kind: mentionedKind(mentionsReminder, mentionsTimer), Language: "zh"}, true
+	}
+
+	cue, cueAt := findCue(compact, zhReminderCues)
+	if cueAt >= 0 {
+		before, after := compact[:cueAt], compact[cueAt+len(cue):]
+
+		// 具体时间：“明天早上8点提醒我开会”“提醒我下午三点半取快递”“提醒我3:15开会”
+		// 只有“X点”时须紧挨着提示语，避免把“喝一点水”当作一点钟
+		if loc := zhClock.FindStringSubmatchIndex(compact); loc != nil && (loc[3] > loc[2] || loc[5] > loc[4] || loc[1] > loc[0]+len(submatch(compact, loc, 3))+len("点") || loc[11] > loc[10] || loc[1] == cueAt || loc[0] == cueAt+len(cue)) {
+			if due, ok := zhClockTime(compact, loc, now); ok {
+				content := after
+				if loc[0] >= cueAt {
+					content = compact[loc[1]:]
+				}
+				return Intent{Action: ActionSet, Kind: KindReminder, Text: cleanContent(content, zhFillers), DueAt: due, Language: "zh"}, true
+			}
+		}
+
+		// 相对时间：“十分钟后提醒我喝水”“过半小时提醒我”“提醒我一个半小时后出门”
+		if d, rest, ok := zhRelative(before); ok && (rest == "" || strings.HasSuffix(rest, "过")) {
+			return setIntent(KindReminder, cleanContent(after, zhFillers), d, now, "zh"), true
+		}
+		if d, rest, ok := zhRelative(after); ok {
+			return setIntent(KindReminder, cleanContent(rest, zhFillers), d, now, "zh"), true
+		}
+		return Intent{}, false
+	}
+
+	// 计时器：“设一个五分钟的倒计时”“计时三分钟”
+	if mentionsTimer {
+		if d, _, _, ok := zhFindDuration(compact); ok {
+			return setIntent(KindTimer, "", d, now, "zh"), true
+		}
+	}
+	return Intent{}, false
+}
+
+// zhRelative 文本中的相对时间（“X后”“过X”），返回时长和时间之后的剩余文本
+func zhRelative(text string) (time.Duration, string, bool) {
+	d, start, end, ok := zhFindDuration(text)
+	if !ok {
+		return 0, "", false
+	}
+	prefix, rest := text[:start], text[end:]
+	for _, suffix := range []string{"之后", "以后", "后"} {
+		if strings.HasPrefix(rest, suffix) {
+			return d, strings.TrimPrefix(rest, suffix), true
+		}
+	}
+	if strings.HasSuffix(prefix, "过") {
+		return d, rest, true
+	}
+	return 0, "", false
+}
+
+// zhFindDuration 查找第一个时长（连续的“一小时二十分钟”合并为一个），返回时长和在文本中的位置
+func zhFindDuration(text string) (time.Duration, int, int, bool) {
+	matches := zhDuration.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 {
+		return 0, 0, 0, false
+	}
+
+	var total time.Duration
+	start, end := matches[0][0], matches[0][0]
+	for _, m := range matches {
+		if m[0] != end {
+			break
+		}
+		d, ok := zhDurationPart(text[m[2]:m[3]], submatch(text, m, 2), text[m[6]:m[7]])
+		if !ok {
+			break
+		}
+		total += d
+		end = m[1]
+	}
+	if total <= 0 {
+		return 0, 0, 0, false
+	}
+	return total, start, end, true
+}
+
+// zhDurationPart 一段时长：数字、“个/半/个半”、单位
+func zhDurationPart(number, half, unit string) (time.Duration, bool) {
+	var value float64
+	if number == "半" {
+		value = 0.5
+	} else {
+		n, ok := parseChineseNumber(number)
+		if !ok {
+			return 0, false
+		}
+		value = float64(n)
+	}
+	if strings.HasSuffix(half, "半") {
+		value += 0.5
+	}
+
+	switch unit {
+	case "小时", "钟头":
+		return time.Duration(value * float64(time.Hour)), true
+	case "分钟", "分":
+		return time.Duration(value * float64(time.Minute)), true
+	default:
+		return time.Duration(value * float64(time.Second)), true
+	}
+}
+
+// zhClockTime 计算“明天早上8点半”对应的时间；未指明日期且今天已过时取明天
+func zhClockTime(text string, loc []int, now time.Time) (time.Time, bool) {
+	day, period := submatch(text, loc, 1), submatch(text, loc, 2)
+	hour, ok := parseChineseNumber(submatch(text, loc, 3))
+	if !ok || hour > 24 {
+		return time.Time{}, false
+	}
+	minute := 0
+	if submatch(text, loc, 4) != "" {
+		minute = 30
+	} else if m := submatch(text, loc, 5); m != "" {
+		if minute, ok = parseChineseNumber(m); !ok || minute > 59 {
+			return time.Time{}, false
+		}
+	}
+
+	switch period {
+	case "下午", "傍晚", "晚上", "今晚":
+		if hour < 12 {
+			hour += 12
+		}
+	case "中午":
+		if hour < 11 {
+			hour += 12
+		}
+	}
+
+	offset := map[string]int{"明天": 1, "后天": 2}[day]
+	due := time.Date(now.Year(), now.Month(), now.Day()+offset, hour, minute, 0, 0, now.Location())
+	if day == "" && !due.After(now) {
+		due = due.AddDate(0, 0, 1)
+	}
+	return due, true
+}
+
+// parseChineseNumber 解析阿拉伯数字或一万以内的中文数字
+func parseChineseNumber(s string) (int, bool) {
+	if n, err := strconv.Atoi(s); err == nil {
+		return n, true
+	}
+	digits := map[rune]int{'零': 0, '〇': 0, '一': 1, '二': 2, '两': 2, '三': 3, '四': 4, '五': 5, '六': 6, '七': 7, '八': 8, '九': 9}
+	units := map[rune]int{'十': 10, '百': 100, '千': 1000}
+
+	total, current := 0, -1
+	for _, r := range s {
+		if d, ok := digits[r]; ok {
+			current = d
+			continue
+		}
+		unit, ok := units[r]
+		if !ok {
+			return 0, false
+		}
+		if current < 0 {
+			current = 1 // “十五”
+		}
+		total += current * unit
+		current = -1
+	}
+	if current > 0 {
+		total += current
+	}
+	return total, s != ""
+}
+
+// 英文指令
+var (
+	enNumber   = `(\d+(?:\.\d+)?|an?|one|two|three|four|five|six|seven|eight|nine|ten|eleven|twelve|fifteen|twenty|thirty|forty|forty[- ]five|fifty|sixty|ninety)`
+	enDuration = regexp.MustCompile(`(?:` + enNumber + `[- ]?(seconds?|secs?|minutes?|mins?|hours?|hrs?)|half an hour)(?: and a half)?`)
+	enRelative = regexp.MustCompile(`\b(?:in|after|for)\s+`)
+	enClock    = regexp.MustCompile(`\b(?:(tomorrow|today)\s+)?at\s+(\d{1,2})(?::(\d{2}))?\s*(am|pm|a\.m\.|p\.m\.)?(?:\s+(tomorrow|today))?`)
+
+	enNumbers = map[string]float64{
+		"a": 1, "an": 1, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5, "six": 6, "seven": 7, "eight": 8,
+		"nine": 9, "ten": 10, "eleven": 11, "twelve": 12, "fifteen": 15, "twenty": 20, "thirty": 30, "forty": 40,
+		"forty-five": 45, "forty five": 45, "fifty": 50, "sixty": 60, "ninety": 90,
+	}
+	enCancelCues = []string{"cancel", "delete", "remove", "stop", "clear"}
+	enListCues   = []string{"what reminders", "which reminders", "any reminders", "my reminders", "list reminders", "list my reminders",
+		"how much time is left", "how long is left", "how much time left", "time left on"}
+	enFillers = []string{"to", "that", "about", "of", "i need to", "i have to", "i should"}
+)
+
+// parseEnglish 解析英文指令（text已转小写）
+func parseEnglish(text string, now time.Time) (Intent, bool) {
+	text = strings.Join(strings.Fields(strings.TrimRight(text, ".!?")), " ")
+	mentionsReminder := strings.Contains(text, "reminder")
+	mentionsTimer := strings.Contains(text, "timer")
+
+	if containsAny(text, enCancelCues) && (mentionsReminder || mentionsTimer) {
+		return Intent{Action: ActionCancel, Kind: mentionedKind(mentionsReminder, mentionsTimer), Language: "en"}, true
+	}
+	if containsAny(text, enListCues) {
+		return Intent{Action: ActionList, Kind: mentionedKind(mentionsReminder, mentionsTimer), Language: "en"}, true
+	}
+
+	if at := strings.Index(text, "remind me"); at >= 0 {
+		content := text[at+len("remind me"):]
+
+		// 具体时间：“remind me at 3 pm to call mom”“remind me to leave tomorrow at 8:30”
+		if m := enClock.FindStringSubmatchIndex(content); m != nil {
+			if due, ok := enClockTime(content, m, now); ok {
+				content = content[:m[0]] + content[m[1]:]
+				return Intent{Action: ActionSet, Kind: KindReminder, Text: cleanContent(content, enFillers), DueAt: due, Language: "en"}, true
+			}
+		}
+
+		// 相对时间：“remind me in 10 minutes to stretch”“remind me to stretch in half an hour”
+		for _, loc := range enRelative.FindAllStringIndex(content, -1) {
+			d, end, ok := enParseDuration(content[loc[1]:])
+			if !ok {
+				continue
+			}
+			content = content[:loc[0]] + content[loc[1]+end:]
+			return setIntent(KindReminder, cleanContent(content, enFillers), d, now, "en"), true
+		}
+		return Intent{}, false
+	}
+
+	// 计时器：“set a timer for 5 minutes”“start a 10 minute timer”
+	if mentionsTimer {
+		if m := enDuration.FindStringIndex(text); m != nil {
+			if d, _, ok := enParseDuration(text[m[0]:]); ok {
+				return setIntent(KindTimer, "", d, now, "en"), true
+			}
+		}
+	}
+	return Intent{}, false
+}
+
+// enParseDuration 解析文本开头的时长（“1 hour and 30 minutes”合并为一个），返回时长和结束位置
+func enParseDuration(text string) (time.Duration, int, bool) {
+	var total time.Duration
+	end := 0
+	for {
+		m := enDuration.FindStringSubmatchIndex(text[end:])
+		if m == nil || m[0] != 0 {
+			break
+		}
+		phrase := text[end : end+m[1]]
+		var d time.Duration
+		if strings.HasPrefix(phrase, "half an hour") {
+			d = 30 * time.Minute
+		} else {
+			value, ok := enNumbers[submatch(text[end:], m, 1)]
+			if !ok {
+				var err error
+				if value, err = strconv.ParseFloat(submatch(text[end:], m, 1), 64); err != nil {
+					break
+				}
+			}
+			if strings.HasSuffix(phrase, "and a half") {
+				value += 0.5
+			}
+			switch unit := submatch(text[end:], m, 2); {
+			case strings.HasPrefix(unit, "h"):
+				d = time.Duration(value * float64(time.Hour))
+			case strings.HasPrefix(unit, "m"):
+				d = time.Duration(value * float64(time.Minute))
+			default:
+				d = time.Duration(value * float64(time.Second))
+			}
+		}
+		total += d
+		end += m[1]
+
+		// “1 hour and 30 minutes”
+		rest := text[end:]
+		if next := strings.TrimPrefix(strings.TrimPrefix(rest, " and "), " "); next != rest && enDuration.MatchString(next) && enDuration.FindStringIndex(next)[0] == 0 {
+			end += len(rest) - len(next)
+			continue
+		}
+		break
+	}
+	return total, end, total > 0
+}
+
+// enClockTime 计算“tomorrow at 8:30 am”对应的时间；未指明日期且今天已过时取明天
+func enClockTime(text string, m []int, now time.Time) (time.Time, bool) {
+	day := submatch(text, m, 1) + submatch(text, m, 5)
+	hour, err := strconv.Atoi(submatch(text, m, 2))
+	if err != nil || hour > 23 {
+		return time.Time{}, false
+	}
+	minute := 0
+	if s := submatch(text, m, 3); s != "" {
+		if minute, err = strconv.Atoi(s); err != nil || minute > 59 {
+			return time.Time{}, false
+		}
+	}
+	switch strings.ReplaceAll(submatch(text, m, 4), ".", "") {
+	case "pm":
+		if hour < 12 {
+			hour += 12
+		}
+	case "am":
+		if hour == 12 {
+			hour = 0
+		}
+	}
+
+	offset := 0
+	if day == "tomorrow" {
+		offset = 1
+	}
+	due := time.Date(now.Year(), now.Month(), now.Day()+offset, hour, minute, 0, 0, now.Location())
+	if day == "" && !due.After(now) {
+		due = due.AddDate(0, 0, 1)
+	}
+	return due, true
+}
+
+// setIntent 相对时间的设置指令
+func setIntent(kind Kind, text string, d time.Duration, now time.Time, language string) Intent {
+	return Intent{Action: ActionSet, Kind: kind, Text: text, Duration: d, DueAt: now.Add(d), Language: language}
+}
+
+// mentionedKind 取消和查询指令提到的类型（都提到或都没提到时为全部类型）
+func mentionedKind(reminder, timer bool) Kind {
+	switch {
+	case reminder && !timer:
+		return KindReminder
+	case timer && !reminder:
+		return KindTimer
+	}
+	return ""
+}
+
+// cleanContent 去掉提醒内容首尾的标点和“一下”“to”等连接词
+func cleanContent(text string, fillers []string) string {
+	trim := func(s string) string {
+		return strings.TrimFunc(s, func(r rune) bool { return unicode.IsPunct(r) || unicode.IsSpace(r) })
+	}
+	text = trim(text)
+	for changed := true; changed; {
+		changed = false
+		for _, filler := range fillers {
+			if rest, ok := strings.CutPrefix(text, filler); ok && (containsHan(filler) || rest == "" || rest[0] == ' ') {
+				text, changed = trim(rest), true
+			}
+		}
+	}
+	return text
+}
+
+// findCue 查找最先出现的提示语，返回提示语和位置（未找到时位置为-1）
+func findCue(text string, cues []string) (string, int) {
+	found, at := "", -1
+	for _, cue := range cues {
+		if i := strings.Index(text, cue); i >= 0 && (at < 0 || i < at) {
+			found, at = cue, i
+		}
+	}
+	return found, at
+}
+
+// containsAny 是否包含任一短语
+func containsAny(text string, phrases []string) bool {
+	for _, phrase := range phrases {
+		if strings.Contains(text, phrase) {
+			return true
+		}
+	}
+	return false
+}
+
+// submatch 第n个子匹配（未匹配时为空）
+func submatch(text string, loc []int, n int) string {
+	if loc[2*n] < 0 {
+		return ""
+	}
+	return text[loc[2*n]:loc[2*n+1]]
+}
@@ -0,0 +1,150 @@
+package reminder
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIntentChinese(t *testing.T) {
+	now := time.Date(2024, 5, 1, 9, 0, 0, 0, time.Local)
+
+	intent, ok := ParseIntent("十分钟后提醒我喝水", now)
+	require.True(t, ok)
+	assert.Equal(t, Intent{Action: ActionSet, Kind: KindReminder, Text: "喝水", Duration: 10 * time.Minute, DueAt: now.Add(10 * time.Minute), Language: "zh"}, intent)
+
+	intent, ok = ParseIntent("提醒我一个半小时后出门。", now)
+	require.True(t, ok)
+	assert.Equal(t, "出门", intent.Text)
+	assert.Equal(t, 90*time.Minute, intent.Duration)
+
+	intent, ok = ParseIntent("明天早上八点半提醒我开会", now)
+	require.True(t, ok)
+	assert.Equal(t, time.Date(2024, 5, 2, 8, 30, 0, 0, time.Local), intent.DueAt)
+	assert.Equal(t, "开会", intent.Text)
+
+	intent, ok = ParseIntent("提醒我下午3点取快递", now)
+	require.True(t, ok)
+	assert.Equal(t, time.Date(2024, 5, 1, 15, 0, 0, 0, time.Local), intent.DueAt)
+	assert.Equal(t, "取快递", intent.Text)
+
+	// 识别结果规范化后的时间
+	intent, ok = ParseIntent("下午3:15提醒我开会。", now)
+	require.True(t, ok)
+	assert.Equal(t, time.Date(2024, 5, 1, 15, 15, 0, 0, time.Local), intent.DueAt)
+	assert.Equal(t, "开会", intent.Text)
+
+	intent, ok = ParseIntent("帮我设一个五分钟的倒计时", now)
+	require.True(t, ok)
+	assert.Equal(t, KindTimer, intent.Kind)
+	assert.Equal(t, 5*time.Minute, intent.Duration)
+
+	intent, ok = ParseIntent("取消计时器", now)
+	require.True(t, ok)
+	assert.Equal(t, Intent{Action: ActionCancel, Kind: KindTimer, Language: "zh"}, intent)
+
+	intent, ok = ParseIntent("我有哪些提醒", now)
+	require.True(t, ok)
+	assert.Equal(t, ActionList, intent.Action)
+
+	// 没有时间或不是提醒指令
+	for _, text := range []string{"提醒我多喝一点水", "今天天气怎么样", "十分钟能走到吗"} {
+		_, ok = ParseIntent(text, now)
+		assert.False(t, ok, text)
+	}
+}
+
+func TestParseIntentEnglish(t *testing.T) {
+	now := time.Date(2024, 5, 1, 17, 0, 0, 0, time.Local)
+
+	intent, ok := ParseIntent("Remind me in 10 minutes to take out the trash.", now)
+	require.True(t, ok)
+	assert.Equal(t, "take out the trash", intent.Text)
+	assert.Equal(t, 10*time.Minute, intent.Duration)
+
+	intent, ok = ParseIntent("remind me to stretch in an hour and 30 minutes", now)
+	require.True(t, ok)
+	assert.Equal(t, "stretch", intent.Text)
+	assert.Equal(t, 90*time.Minute, intent.Duration)
+
+	// 今天已过的时间取明天
+	intent, ok = ParseIntent("remind me at 8:30 am to call mom", now)
+	require.True(t, ok)
+	assert.Equal(t, time.Date(2024, 5, 2, 8, 30, 0, 0, time.Local), intent.DueAt)
+	assert.Equal(t, "call mom", intent.Text)
+
+	intent, ok = ParseIntent("set a timer for five minutes", now)
+	require.True(t, ok)
+	assert.Equal(t, KindTimer, intent.Kind)
+	assert.Equal(t, 5*time.Minute, intent.Duration)
+
+	intent, ok = ParseIntent("start a 3 minute timer", now)
+	require.True(t, ok)
+	assert.Equal(t, 3*time.Minute, intent.Duration)
+
+	intent, ok = ParseIntent("cancel all my reminders", now)
+	require.True(t, ok)
+	assert.Equal(t, Intent{Action: ActionCancel, Kind: KindReminder, Language: "en"}, intent)
+
+	_, ok = ParseIntent("what's the weather in 10 minutes", now)
+	assert.False(t, ok)
+}
+
+func TestParseChineseNumber(t *testing.T) {
+	for text, want := range map[string]int{"十": 10, "十五": 15, "二十": 20, "二十五": 25, "两": 2, "一百零五": 105, "45": 45} {
+		n, ok := parseChineseNumber(text)
+		assert.True(t, ok, text)
+		assert.Equal(t, want, n, text)
+	}
+}
+
+func TestSchedulerDeliverAndPersist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reminders.json")
+	var mu sync.Mutex
+	online := false
+	delivered := make(chan Reminder, 4)
+	deliver := func(r Reminder) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		if online {
+			delivered <- r
+		}
+		return online
+	}
+
+	s, err := NewScheduler(Config{Path: path, MaxPerOwner: 2, MissedTTL: time.Hour}, deliver)
+	require.NoError(t, err)
+
+	owner := Owner{SessionID: "s1", UserID: "u1"}
+	_, err = s.Add(Reminder{Kind: KindReminder, Text: "喝水", SessionID: "s1", UserID: "u1", DueAt: time.Now().Add(50 * time.Millisecond)})
+	require.NoError(t, err)
+	later, err := s.Add(Reminder{Kind: KindTimer, SessionID: "s1", UserID: "u1", DueAt: time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+	_, err = s.Add(Reminder{Kind: KindTimer, SessionID: "s1", UserID: "u1", DueAt: time.Now().Add(time.Hour)})
+	assert.ErrorIs(t, err, ErrTooMany)
+	_, err = s.Add(Reminder{Kind: KindTimer, SessionID: "s1", DueAt: time.Now().Add(-time.Second)})
+	assert.ErrorIs(t, err, ErrPast)
+
+	// 到期时离线，标记为错过，上线后补发
+	assert.Eventually(t, func() bool { return len(s.List(owner)) == 1 }, time.Second, 10*time.Millisecond)
+	mu.Lock()
+	online = true
+	mu.Unlock()
+	assert.Equal(t, 1, s.Redeliver(Owner{SessionID: "other", UserID: "u1"}))
+	assert.Equal(t, "喝水", (<-delivered).Text)
+	s.Close()
+
+	// 重启后恢复未触发的提醒
+	s, err = NewScheduler(Config{Path: path}, deliver)
+	require.NoError(t, err)
+	defer s.Close()
+	list := s.List(owner)
+	require.Len(t, list, 1)
+	assert.Equal(t, later.ID, list[0].ID)
+	assert.Equal(t, 1, s.Cancel(owner, KindTimer))
+	assert.Empty(t, s.List(owner))
+}
@@ -0,0 +1,378 @@
+package reminder
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrTooMany 同一用户（或会话）的待触发提醒已达上限
+var ErrTooMany = errors.New("待触发的提醒数量已达上限")
+
+// ErrPast 提醒时间已过
+var ErrPast = errors.New("提醒时间已过")
+
+// Config 定时提醒配置
+type Config struct {
+	Enabled     bool          `yaml:"enabled"`
+	Path        string        `yaml:"path"`          // 持久化文件（为空时只保存在内存，重启后丢失）
+	MaxPerOwner int           `yaml:"max_per_owner"` // 每个用户（或会话）待触发的提醒上限（0表示不限制）
+	MissedTTL   time.Duration `yaml:"missed_ttl"`    // 到期时会话不在线的提醒保留多久，期间会话重新开始时补发（0表示不补发）
+	Timezone    string        `yaml:"timezone"`      // 解析“明天8点”等时间使用的时区（IANA名称，为空时使用服务器本地时区）
+}
+
+// Kind 提醒类型
+type Kind string
+
+const (
+	KindTimer    Kind = "timer"    // 计时器：到时间只提示时间到
+	KindReminder Kind = "reminder" // 提醒：到时间播报提醒内容
+)
+
+// Reminder 一条定时提醒
+type Reminder struct {
+	ID        string        `json:"id"`
+	Kind      Kind          `json:"kind"`
+	Text      string        `json:"text,omitempty"`     // 提醒内容（计时器为空）
+	Duration  time.Duration `json:"duration,omitempty"` // 计时器时长
+	SessionID string        `json:"session_id"`
+	UserID    string        `json:"user_id,omitempty"`
+	Language  string        `json:"language,omitempty"` // 设置提醒时使用的语言（播报时会话未指定语言则使用该语言）
+	DueAt     time.Time     `json:"due_at"`
+	CreatedAt time.Time     `json:"created_at"`
+	Missed    bool          `json:"missed,omitempty"` // 到期时未能送达，等待会话重新开始时补发
+}
+
+// Owner 提醒的所有者：携带user_id的会话按用户归属（同一用户的其他设备也能收到），否则按会话归属
+type Owner struct {
+	SessionID string
+	UserID    string
+}
+
+// owns 是否为提醒的所有者
+func (o Owner) owns(r *Reminder) bool {
+	if r.UserID != "" {
+		return r.UserID == o.UserID
+	}
+	return r.SessionID == o.SessionID
+}
+
+// DeliverFunc 投递到期的提醒，返回是否送达（未送达且配置了missed_ttl时保留等待补发）
+type DeliverFunc func(r Reminder) bool
+
+// Scheduler 定时提醒调度器：按到期时间投递提醒，变更时写入持久化文件
+type Scheduler struct {
+	config   Config
+	location *time.Location
+	deliver  DeliverFunc
+
+	mu        sync.Mutex
+	reminders map[string]*Reminder
+
+	wake chan struct{}
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewScheduler 创建调度器并加载已保存的提醒（停机期间到期的提醒启动后立即投递）
+func NewScheduler(config Config, deliver DeliverFunc) (*Scheduler, error) {
+	location, err := LoadLocation(config.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Scheduler{
+		config:    config,
+		location:  location,
+		deliver:   deliver,
+		reminders: make(map[string]*Reminder),
+		wake:      make(chan struct{}, 1),
+		done:      make(chan struct{}),
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	s.wg.Add(1)
+	go s.loop()
+	return s, nil
+}
+
+// LoadLocation 加载时区（为空时为服务器本地时区）
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("无效的时区 %q: %w", name, err)
+	}
+	return location, nil
+}
+
+// Now 当前时间（调度器时区）
+func (s *Scheduler) Now() time.Time {
+	return time.Now().In(s.location)
+}
+
+// Location 调度器时区
+func (s *Scheduler) Location() *time.Location {
+	return s.location
+}
+
+// Add 添加提醒，返回补全ID和创建时间后的提醒
+func (s *Scheduler) Add(r Reminder) (Reminder, error) {
+	now := time.Now()
+	if !r.DueAt.After(now) {
+		return Reminder{}, ErrPast
+	}
+
+	s.mu.Lock()
+	if s.config.MaxPerOwner > 0 {
+		owner, count := Owner{SessionID: r.SessionID, UserID: r.UserID}, 0
+		for _, existing := range s.reminders {
+			if owner.owns(existing) && !existing.Missed {
+				count++
+			}
+		}
+		if count >= s.config.MaxPerOwner {
+			s.mu.Unlock()
+			return Reminder{}, ErrTooMany
+		}
+	}
+	r.ID, r.CreatedAt, r.Missed = newID(), now, false
+	stored := r
+	s.reminders[r.ID] = &stored
+	s.saveLocked()
+	s.mu.Unlock()
+
+	s.notify()
+	return r, nil
+}
+
+// List 所有者待触发的提醒（按到期时间排序）
+func (s *Scheduler) List(owner Owner) []Reminder {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var list []Reminder
+	for _, r := range s.reminders {
+		if owner.owns(r) && !r.Missed {
+			list = append(list, *r)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].DueAt.Before(list[j].DueAt) })
+	return list
+}
+
+// Cancel 取消所有者待触发的提醒（kind为空时取消全部类型），返回取消的数量
+func (s *Scheduler) Cancel(owner Owner, kind Kind) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	canceled := 0
+	for id, r := range s.reminders {
+		if owner.owns(r) && !r.Missed && (kind == "" || r.Kind == kind) {
+			delete(s.reminders, id)
+			canceled++
+		}
+	}
+	if canceled > 0 {
+		s.saveLocked()
+	}
+	return canceled
+}
+
+// Redeliver 补发所有者错过的提醒，返回送达的数量
+func (s *Scheduler) Redeliver(owner Owner) int {
+	s.mu.Lock()
+	var missed []Reminder
+	for _, r := range s.reminders {
+		if owner.owns(r) && r.Missed {
+			missed = append(missed, *r)
+		}
+	}
+	s.mu.Unlock()
+
+	sort.Slice(missed, func(i, j int) bool { return missed[i].DueAt.Before(missed[j].DueAt) })
+	delivered := 0
+	for _, r := range missed {
+		if !s.deliver(r) {
+			continue
+		}
+		delivered++
+		s.mu.Lock()
+		delete(s.reminders, r.ID)
+		s.saveLocked()
+		s.mu.Unlock()
+	}
+	return delivered
+}
+
+// Close 停止调度（未触发的提醒保留在持久化文件中）
+func (s *Scheduler) Close() {
+	close(s.done)
+	s.wg.Wait()
+}
+
+// notify 唤醒调度协程重新计算下一次触发时间
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// loop 调度协程
+func (s *Scheduler) loop() {
+	defer s.wg.Done()
+
+	for {
+		var timer *time.Timer
+		var fire <-chan time.Time
+		if next, ok := s.nextEvent(); ok {
+			timer = time.NewTimer(time.Until(next))
+			fire = timer.C
+		}
+
+		select {
+		case <-s.done:
+		case <-s.wake:
+		case <-fire:
+			s.fire(time.Now())
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		select {
+		case <-s.done:
+			return
+		default:
+		}
+	}
+}
+
+// nextEvent 下一次需要处理的时间：最早到期的提醒，或最早过期的错过提醒
+func (s *Scheduler) nextEvent() (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var next time.Time
+	for _, r := range s.reminders {
+		at := r.DueAt
+		if r.Missed {
+			at = r.DueAt.Add(s.config.MissedTTL)
+		}
+		if next.IsZero() || at.Before(next) {
+			next = at
+		}
+	}
+	return next, !next.IsZero()
+}
+
+// fire 投递到期的提醒，丢弃超过保留时长的错过提醒
+func (s *Scheduler) fire(now time.Time) {
+	s.mu.Lock()
+	var due []Reminder
+	for id, r := range s.reminders {
+		switch {
+		case r.Missed && !r.DueAt.Add(s.config.MissedTTL).After(now):
+			log.Printf("丢弃未能送达的提醒: %s", id)
+			delete(s.reminders, id)
+		case !r.Missed && !r.DueAt.After(now):
+			due = append(due, *r)
+		}
+	}
+	s.mu.Unlock()
+
+	sort.Slice(due, func(i, j int) bool { return due[i].DueAt.Before(due[j].DueAt) })
+	delivered := make(map[string]bool, len(due))
+	for _, r := range due {
+		delivered[r.ID] = s.deliver(r)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, ok := range delivered {
+		r, exists := s.reminders[id]
+		if !exists {
+			continue
+		}
+		if ok || s.config.MissedTTL <= 0 {
+			delete(s.reminders, id)
+		} else {
+			r.Missed = true
+		}
+	}
+	s.saveLocked()
+}
+
+// load 读取持久化文件
+func (s *Scheduler) load() error {
+	if s.config.Path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(s.config.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("读取提醒文件失败: %w", err)
+	}
+
+	var reminders []*Reminder
+	if err := json.Unmarshal(data, &reminders); err != nil {
+		return fmt.Errorf("解析提醒文件失败: %w", err)
+	}
+	for _, r := range reminders {
+		s.reminders[r.ID] = r
+	}
+	return nil
+}
+
+// saveLocked 写入持久化文件（先写临时文件再替换；调用方需持有锁）
+func (s *Scheduler) saveLocked() {
+	if s.config.Path == "" {
+		return
+	}
+
+	reminders := make([]*Reminder, 0, len(s.reminders))
+	for _, r := range s.reminders {
+		reminders = append(reminders, r)
+	}
+	sort.Slice(reminders, func(i, j int) bool { return reminders[i].DueAt.Before(reminders[j].DueAt) })
+	data, err := json.MarshalIndent(reminders, "", "  ")
+	if err != nil {
+		log.Printf("序列化提醒失败: %v", err)
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.config.Path), 0700); err != nil {
+		log.Printf("创建提醒目录失败: %v", err)
+		return
+	}
+	tmp := s.config.Path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		log.Printf("写入提醒文件失败: %v", err)
+		return
+	}
+	if err := os.Rename(tmp, s.config.Path); err != nil {
+		os.Remove(tmp)
+		log.Printf("写入提醒文件失败: %v", err)
+	}
+}
+
+// newID 随机提醒ID
+func newID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,103 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Provider 密钥后端：按引用读取密钥（如Vault路径、KMS密文）
+type Provider interface {
+	// Resolve 读取引用对应的密钥，ref为“scheme:”之后的部分
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// ProviderFunc 以函数实现的密钥后端
+type ProviderFunc func(ctx context.Context, ref string) (string, error)
+
+// Resolve 调用函数
+func (f ProviderFunc) Resolve(ctx context.Context, ref string) (string, error) {
+	return f(ctx, ref)
+}
+
+var (
+	providersMu sync.RWMutex
+	providers   = make(map[string]Provider)
+)
+
+// Register 注册密钥后端，配置中“scheme:引用”形式的取值交由该后端读取（重复注册时覆盖）
+func Register(scheme string, provider Provider) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	providers[scheme] = provider
+}
+
+// Schemes 已注册的密钥后端
+func Schemes() []string {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	schemes := make([]string, 0, len(providers))
+	for scheme := range providers {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// Resolve 解析配置中的密钥取值：“scheme:引用”且scheme已注册时从对应后端读取，其余原样返回
+func Resolve(ctx context.Context, value string) (string, error) {
+	scheme, ref, ok := strings.Cut(value, ":")
+	if !ok {
+		return value, nil
+	}
+	providersMu.RLock()
+	provider, exists := providers[scheme]
+	providersMu.RUnlock()
+	if !exists {
+		return value, nil
+	}
+
+	secret, err := provider.Resolve(ctx, ref)
+	if err != nil {
+		return "", fmt.Errorf("从 %s 读取密钥失败: %w", scheme, err)
+	}
+	return secret, nil
+}
+
+// envRe 环境变量名
+var envRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ExpandEnv 替换文本中的 $VAR、${VAR} 和 ${VAR:-默认值}；不是合法变量名的 $ 原样保留（如 $5）
+func ExpandEnv(text string) string {
+	return os.Expand(text, func(name string) string {
+		name, fallback, hasDefault := strings.Cut(name, ":-")
+		if !envRe.MatchString(name) {
+			if hasDefault {
+				return "${" + name + ":-" + fallback + "}"
+			}
+			return "$" + name
+		}
+		if value, ok := os.LookupEnv(name); ok && (value != "" || !hasDefault) {
+			return value
+		}
+		return fallback
+	})
+}
+
+func init() {
+	Register("file", ProviderFunc(readFile))
+	Register("vault", &VaultProvider{})
+}
+
+// readFile 读取挂载为文件的密钥（如 Docker/Kubernetes secrets），去掉首尾空白
+func readFile(ctx context.Context, path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
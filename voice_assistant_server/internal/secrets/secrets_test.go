@@ -0,0 +1,92 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("VA_TEST_KEY", "sk-123")
+	t.Setenv("VA_TEST_EMPTY", "")
+
+	assert.Equal(t, "key: sk-123 / sk-123", ExpandEnv("key: ${VA_TEST_KEY} / $VA_TEST_KEY"))
+	assert.Equal(t, "fallback", ExpandEnv("${VA_TEST_MISSING:-fallback}"))
+	assert.Equal(t, "fallback", ExpandEnv("${VA_TEST_EMPTY:-fallback}"))
+	assert.Equal(t, "", ExpandEnv("${VA_TEST_MISSING}"))
+	// 不是变量名的 $ 原样保留
+	assert.Equal(t, "价格 $5，${1:-x}", ExpandEnv("价格 $5，${1:-x}"))
+}
+
+func TestResolve(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "openai_key")
+	require.NoError(t, os.WriteFile(path, []byte("sk-file\n"), 0600))
+
+	value, err := Resolve(context.Background(), "file:"+path)
+	require.NoError(t, err)
+	assert.Equal(t, "sk-file", value)
+
+	_, err = Resolve(context.Background(), "file:"+path+".missing")
+	assert.Error(t, err)
+
+	// 未注册的scheme和普通取值原样返回
+	for _, plain := range []string{"sk-plain", "https://example.com"} {
+		value, err = Resolve(context.Background(), plain)
+		require.NoError(t, err)
+		assert.Equal(t, plain, value)
+	}
+
+	// 自定义后端（如KMS）
+	Register("kms-test", ProviderFunc(func(ctx context.Context, ref string) (string, error) {
+		if ref == "bad" {
+			return "", errors.New("解密失败")
+		}
+		return "decrypted-" + ref, nil
+	}))
+	value, err = Resolve(context.Background(), "kms-test:abc")
+	require.NoError(t, err)
+	assert.Equal(t, "decrypted-abc", value)
+	_, err = Resolve(context.Background(), "kms-test:bad")
+	assert.ErrorContains(t, err, "解密失败")
+	assert.Contains(t, Schemes(), "vault")
+}
+
+func TestVaultProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/voice":
+			w.Write([]byte(`{"data":{"data":{"openai_api_key":"sk-vault"},"metadata":{}}}`))
+		case "/v1/kv/voice":
+			w.Write([]byte(`{"data":{"openai_api_key":"sk-v1"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	vault := &VaultProvider{Address: server.URL, Token: "root"}
+	value, err := vault.Resolve(context.Background(), "secret/data/voice#openai_api_key")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-vault", value)
+	value, err = vault.Resolve(context.Background(), "kv/voice#openai_api_key")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-v1", value)
+
+	_, err = vault.Resolve(context.Background(), "secret/data/voice#missing")
+	assert.Error(t, err)
+	_, err = vault.Resolve(context.Background(), "secret/data/voice")
+	assert.Error(t, err)
+	_, err = (&VaultProvider{Address: server.URL, Token: "wrong"}).Resolve(context.Background(), "secret/data/voice#openai_api_key")
+	assert.ErrorContains(t, err, "403")
+}
@@ -0,0 +1,73 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// VaultProvider 从HashiCorp Vault的KV引擎读取密钥
+// 引用格式为“路径#字段”，如 vault:secret/data/voice_assistant#openai_api_key（KV v2的路径含data/）。
+// 地址和令牌未设置时读取环境变量 VAULT_ADDR 和 VAULT_TOKEN。
+type VaultProvider struct {
+	Address string
+	Token   string
+	Client  *http.Client
+}
+
+// Resolve 读取Vault中的密钥字段
+func (v *VaultProvider) Resolve(ctx context.Context, ref string) (string, error) {
+	path, field, ok := strings.Cut(ref, "#")
+	if !ok || path == "" || field == "" {
+		return "", fmt.Errorf("Vault引用格式应为 路径#字段: %s", ref)
+	}
+	address := v.Address
+	if address == "" {
+		address = os.Getenv("VAULT_ADDR")
+	}
+	token := v.Token
+	if token == "" {
+		token = os.Getenv("VAULT_TOKEN")
+	}
+	if address == "" || token == "" {
+		return "", fmt.Errorf("未配置Vault地址或令牌（VAULT_ADDR、VAULT_TOKEN）")
+	}
+	client := v.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(address, "/")+"/v1/"+strings.TrimLeft(path, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Vault返回 HTTP %d: %s", resp.StatusCode, path)
+	}
+
+	var body struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("解析Vault响应失败: %w", err)
+	}
+	data := body.Data
+	// KV v2 的字段位于 data.data 中
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		data = nested
+	}
+	value, ok := data[field].(string)
+	if !ok {
+		return "", fmt.Errorf("Vault路径 %s 中没有字段 %s", path, field)
+	}
+	return value, nil
+}
@@ -0,0 +1,257 @@
+package server
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"voice_assistant/pkg/protocol"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminConfig 管理接口配置
+type AdminConfig struct {
+	Token       string `yaml:"token"`        // 管理令牌（为空时不开放管理接口）
+	DebugListen string `yaml:"debug_listen"` // 调试端口监听地址（pprof和运行时调优，为空时不开启）
+}
+
+// SessionSnapshot 会话的实时状态（管理接口返回）
+type SessionSnapshot struct {
+	ID            string       `json:"id"`
+	ConnectionID  string       `json:"connection_id,omitempty"` // 所属连接（尚未收到消息时为空）
+	State         SessionState `json:"state"`
+	Mode          string       `json:"mode"` // single|continuous|duplex
+	TextOnly      bool         `json:"text_only"`
+	Language      string       `json:"language,omitempty"`
+	Persona       string       `json:"persona,omitempty"`
+	Tenant        string       `json:"tenant,omitempty"`
+	UserID        string       `json:"user_id,omitempty"`
+	Priority      string       `json:"priority,omitempty"`
+	IsProcessing  bool         `json:"is_processing"`
+	PendingFinal  bool         `json:"pending_final"`  // 处理中收到了最终音频块，处理完成后继续
+	BufferedBytes int          `json:"buffered_bytes"` // 尚未识别的音频
+	Turns         int          `json:"turns"`          // 已完成的对话轮数
+	CreatedAt     time.Time    `json:"created_at"`
+	LastActivity  time.Time    `json:"last_activity"`
+	IdleSeconds   float64      `json:"idle_seconds"`
+
+	Usage *protocol.UsageTotals `json:"usage,omitempty"` // 启用用量统计时返回
+
+	Members []protocol.Member `json:"members,omitempty"` // 有其他客户端加入时的会话成员
+}
+
+// snapshot 会话的实时状态
+func (p *MessageProcessor) snapshot(session *Session, now time.Time) SessionSnapshot {
+	session.mu.RLock()
+	defer session.mu.RUnlock()
+
+	snapshot := SessionSnapshot{
+		ID:            session.ID,
+		State:         session.State,
+		Mode:          session.modeLocked(),
+		TextOnly:      session.TextOnly,
+		Language:      session.Language,
+		Persona:       session.Persona,
+		Tenant:        session.Tenant,
+		UserID:        session.UserID,
+		Priority:      string(session.Priority),
+		IsProcessing:  session.IsProcessing,
+		PendingFinal:  session.pendingFinal,
+		BufferedBytes: session.AudioBuffer.Len(),
+		Turns:         session.Turns,
+		CreatedAt:     session.CreatedAt,
+		LastActivity:  session.LastActivity,
+		IdleSeconds:   now.Sub(session.LastActivity).Seconds(),
+	}
+	if session.client != nil {
+		snapshot.ConnectionID = session.client.Connection().ID
+	}
+	if p.usage != nil {
+		usage := session.Usage
+		snapshot.Usage = &usage
+	}
+	if session.group != nil {
+		snapshot.Members = session.group.list()
+	}
+	return snapshot
+}
+
+// SessionSnapshots 全部会话的实时状态（按创建时间排序）
+func (p *MessageProcessor) SessionSnapshots() []SessionSnapshot {
+	p.mu.RLock()
+	sessions := make([]*Session, 0, len(p.sessions))
+	for _, session := range p.sessions {
+		sessions = append(sessions, session)
+	}
+	p.mu.RUnlock()
+
+	now := time.Now()
+	snapshots := make([]SessionSnapshot, 0, len(sessions))
+	for _, session := range sessions {
+		snapshots = append(snapshots, p.snapshot(session, now))
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].CreatedAt.Before(snapshots[j].CreatedAt)
+	})
+	return snapshots
+}
+
+// TerminateSession 强制结束会话：取消进行中的处理并释放会话，通知客户端（错误码 SESSION_TERMINATED）；
+// 会话是WebSocket连接的主会话时同时关闭连接，不再保留会话等待恢复。会话不存在时返回false。
+func (p *MessageProcessor) TerminateSession(sessionID, reason string) bool {
+	p.mu.Lock()
+	session, exists := p.sessions[sessionID]
+	if exists {
+		delete(p.sessions, sessionID)
+	}
+	p.mu.Unlock()
+	if !exists {
+		return false
+	}
+
+	session.cancel()
+	session.mu.RLock()
+	client := session.client
+	session.mu.RUnlock()
+
+	log.Printf("会话已被管理员终止: %s, 原因: %s", sessionID, reason)
+	if client == nil {
+		return true
+	}
+
+	message := "会话已被管理员终止"
+	if reason != "" {
+		message += ": " + reason
+	}
+	client.SendMessage(protocol.NewErrorMessage(sessionID, protocol.ErrSessionTerminated, message, false))
+	client.ReleaseSession(sessionID)
+
+	conn := client.Connection()
+	if conn.ID == sessionID && conn.Conn != nil {
+		conn.Server.forgetResume(sessionID)
+		// 通知客户端不要重连，确认后关闭连接
+		go conn.shutdown(protocol.CloseInfo{Code: protocol.CloseSessionTerminated, Reason: message, Reconnect: false})
+	}
+	return true
+}
+
+// AdminHandler 运维管理接口（需携带管理令牌）
+type AdminHandler struct {
+	processor *MessageProcessor
+	config    AdminConfig
+}
+
+// NewAdminHandler 创建管理接口
+func NewAdminHandler(processor *MessageProcessor, config AdminConfig) *AdminHandler {
+	return &AdminHandler{
+		processor: processor,
+		config:    config,
+	}
+}
+
+// Register 注册路由
+func (h *AdminHandler) Register(router gin.IRouter) {
+	router.Use(AdminAuthorize(h.config.Token))
+	router.GET("/sessions", h.handleSessionList)
+	router.POST("/sessions/:id/terminate", h.handleSessionTerminate)
+	router.POST("/notifications", h.handleNotify)
+	router.GET("/notifications/:id", h.handleNotificationStatus)
+	router.GET("/usage", h.handleUsage)
+
+	// 修改服务端数据的接口（处理与REST接口共用）
+	rest := NewRESTHandler(h.processor)
+	router.POST("/knowledge", rest.handleKnowledgeUpload)
+	router.DELETE("/knowledge", rest.handleKnowledgeDelete)
+	router.PUT("/personas/:id", rest.handlePersonaPut)
+	router.DELETE("/personas/:id", rest.handlePersonaDelete)
+	router.POST("/voices", rest.handleVoiceEnroll)
+	router.DELETE("/voices/:id", rest.handleVoiceDelete)
+	router.POST("/speakers", rest.handleSpeakerEnroll)
+	router.DELETE("/speakers/:id", rest.handleSpeakerDelete)
+}
+
+// AdminAuthorize 校验管理令牌（Authorization: Bearer 或 X-Admin-Token 请求头），管理接口、调试端口和播报端点共用
+func AdminAuthorize(adminToken string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if adminToken == "" {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "未启用管理接口"})
+			return
+		}
+
+		token := c.GetHeader("X-Admin-Token")
+		if auth := c.GetHeader("Authorization"); token == "" && strings.HasPrefix(auth, "Bearer ") {
+			token = strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "管理令牌无效",
+				"code":  protocol.ErrAuthenticationFailed,
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// handleSessionList 列出全部会话的实时状态
+func (h *AdminHandler) handleSessionList(c *gin.Context) {
+	sessions := h.processor.SessionSnapshots()
+	c.JSON(http.StatusOK, gin.H{
+		"sessions": sessions,
+		"count":    len(sessions),
+	})
+}
+
+// handleSessionTerminate 强制结束会话（可选JSON请求体 {"reason": "..."}，原因会发给客户端）
+func (h *AdminHandler) handleSessionTerminate(c *gin.Context) {
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	sessionID := c.Param("id")
+	if !h.processor.TerminateSession(sessionID, req.Reason) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "会话不存在"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"session_id": sessionID, "terminated": true})
+}
+
+// handleNotify 向会话推送通知（请求体为 NotifyRequest）
+func (h *AdminHandler) handleNotify(c *gin.Context) {
+	var req NotifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := req.normalize(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.processor.Notify(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// handleNotificationStatus 查询通知的送达和确认状态
+func (h *AdminHandler) handleNotificationStatus(c *gin.Context) {
+	status, exists := h.processor.NotificationStatus(c.Param("id"))
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "通知不存在或已过期"})
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
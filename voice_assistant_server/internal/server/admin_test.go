@@ -0,0 +1,110 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"voice_assistant/pkg/protocol"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminSessions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	p := NewMessageProcessor(ProcessorConfig{MaxConcurrentSessions: 10})
+	router := gin.New()
+	NewAdminHandler(p, AdminConfig{Token: "secret"}).Register(router.Group("/api/admin"))
+
+	// 复用连接上的第二个会话
+	conn := &Client{ID: "conn", SendChan: make(chan *protocol.Message, 10)}
+	view, err := conn.Session("second", 2)
+	require.NoError(t, err)
+	session := p.getOrCreateSession("second", "")
+	session.client = view
+	session.ContinuousMode = true
+	session.Turns = 3
+	p.getOrCreateSession("idle", "")
+
+	serve := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, serve(http.MethodGet, "/api/admin/sessions", "", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, serve(http.MethodGet, "/api/admin/sessions", "wrong", "").Code)
+
+	w := serve(http.MethodGet, "/api/admin/sessions", "secret", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var list struct {
+		Sessions []SessionSnapshot `json:"sessions"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list.Sessions, 2)
+	assert.Equal(t, "second", list.Sessions[0].ID)
+	assert.Equal(t, "conn", list.Sessions[0].ConnectionID)
+	assert.Equal(t, "continuous", list.Sessions[0].Mode)
+	assert.Equal(t, 3, list.Sessions[0].Turns)
+
+	w = serve(http.MethodPost, "/api/admin/sessions/second/terminate", "secret", `{"reason": "维护"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Error(t, session.ctx.Err())
+	assert.Empty(t, conn.SessionIDs())
+
+	msg := <-conn.SendChan
+	assert.Equal(t, protocol.Error, msg.Type)
+	assert.Equal(t, "second", msg.SessionID)
+	assert.Equal(t, protocol.ErrSessionTerminated, msg.Payload.(*protocol.ErrorData).Code)
+
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodPost, "/api/admin/sessions/second/terminate", "secret", "").Code)
+	assert.Len(t, p.SessionSnapshots(), 1)
+}
+
+func TestAdminDataRoutesRequireToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	p := NewMessageProcessor(ProcessorConfig{MaxConcurrentSessions: 10})
+	router := gin.New()
+	NewRESTHandler(p).Register(router.Group("/api"))
+	NewAdminHandler(p, AdminConfig{Token: "secret"}).Register(router.Group("/api/admin"))
+
+	// 修改服务端数据的接口只在管理接口下提供，未携带管理令牌时拒绝
+	for _, route := range []struct{ method, path string }{
+		{http.MethodPost, "/knowledge"},
+		{http.MethodDelete, "/knowledge?source=faq"},
+		{http.MethodPut, "/personas/teacher"},
+		{http.MethodDelete, "/personas/teacher"},
+		{http.MethodPost, "/voices"},
+		{http.MethodDelete, "/voices/alice"},
+		{http.MethodPost, "/speakers"},
+		{http.MethodDelete, "/speakers/dad"},
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(route.method, "/api/admin"+route.path, strings.NewReader("{}")))
+		assert.Equal(t, http.StatusUnauthorized, w.Code, route)
+
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(route.method, "/api"+route.path, strings.NewReader("{}")))
+		assert.Equal(t, http.StatusNotFound, w.Code, route)
+	}
+}
+
+func TestAdminDisabledWithoutToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	NewAdminHandler(NewMessageProcessor(ProcessorConfig{MaxConcurrentSessions: 10}), AdminConfig{}).Register(router.Group("/api/admin"))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/sessions", nil)
+	req.Header.Set("Authorization", "Bearer ")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
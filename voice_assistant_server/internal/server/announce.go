@@ -0,0 +1,59 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"voice_assistant/pkg/protocol"
+	"voice_assistant/voice_assistant_server/internal/pipeline"
+)
+
+// DefaultAnnounceLead 默认播报提前量
+// 预留广播分发和客户端解码的时间，提前量不足时晚到的客户端会错过计划时间。
+const DefaultAnnounceLead = 1500 * time.Millisecond
+
+// AnnounceResult 播报结果
+type AnnounceResult struct {
+	PlayAt  int64 `json:"play_at"` // 计划播放时间（服务端时钟，毫秒）
+	Clients int   `json:"clients"` // 收到播报的客户端数量
+}
+
+// Announce 合成一次语音并广播给所有客户端，各客户端按同一服务端时间开始播放
+func (s *WebSocketServer) Announce(ctx context.Context, text string, lead time.Duration) (*AnnounceResult, error) {
+	if text == "" {
+		return nil, errors.New("播报内容不能为空")
+	}
+	if s.processor == nil || !s.processor.isInitialized {
+		return nil, errors.New("处理器未初始化")
+	}
+	if lead <= 0 {
+		lead = DefaultAnnounceLead
+	}
+
+	ttsResult, err := s.processor.synthesize(ctx, pipeline.PriorityInteractive, text)
+	if err != nil {
+		return nil, err
+	}
+
+	// 合成完成后再计算播放时间，避免合成耗时吃掉提前量
+	playAt := time.Now().Add(lead).UnixMilli()
+	sent := s.Broadcast(func(clientID string) *protocol.Message {
+		return protocol.NewMessage(protocol.Response, clientID, &protocol.ResponseData{
+			Stage:      protocol.StageTTS,
+			Content:    text,
+			Confidence: 1.0,
+			IsFinal:    true,
+			AudioData:  ttsResult.AudioData,
+			PlayAt:     playAt,
+
+			AudioFormat: speechFormat(ttsResult),
+			Metadata:    map[string]interface{}{"announcement": true},
+		})
+	})
+
+	return &AnnounceResult{
+		PlayAt:  playAt,
+		Clients: sent,
+	}, nil
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"context"
+	"log"
+	"time"
+
+	vaaudio "voice_assistant/pkg/audio"
+	"voice_assistant/voice_assistant_server/internal/archive"
+	"voice_assistant/voice_assistant_server/internal/dataset"
+	"voice_assistant/voice_assistant_server/internal/tts"
+)
+
+// archiveUtterance 异步归档一句话的处理记录（遵循会话授权状态）
+func (p *MessageProcessor) archiveUtterance(session *Session, audio []byte, utt *archive.Utterance) {
+	if p.archiver == nil {
+		return
+	}
+
+	session.mu.RLock()
+	consent := session.DataConsent
+	session.mu.RUnlock()
+
+	// 明确拒绝的会话不归档；要求授权时仅归档明确同意的会话
+	if consent == dataset.ConsentDenied || (p.config.Archive.RequireConsent && consent != dataset.ConsentGranted) {
+		return
+	}
+
+	record := *utt
+	record.SessionKey = hashSessionID(session.ID)
+	record.InputAudio = vaaudio.EncodeWAV(audio, 16000, 1)
+
+	archiver := p.archiver
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		if err := archiver.Archive(ctx, record); err != nil {
+			log.Printf("归档语音记录失败: %v", err)
+		}
+	}()
+}
+
+// setArchivedOutput 记录TTS输出音频（裸PCM补上WAV头，未启用归档时跳过）
+func (p *MessageProcessor) setArchivedOutput(utt *archive.Utterance, result *tts.TTSResult) {
+	if p.archiver == nil {
+		return
+	}
+
+	switch result.Format {
+	case "mp3":
+		utt.OutputAudio, utt.OutputFormat = result.AudioData, "mp3"
+	case "pcm", "":
+		utt.OutputAudio, utt.OutputFormat = vaaudio.EncodeWAV(result.AudioData, result.SampleRate, result.Channels), "wav"
+	default:
+		utt.OutputAudio, utt.OutputFormat = result.AudioData, result.Format
+	}
+}
@@ -0,0 +1,23 @@
+package server
+
+import (
+	"fmt"
+
+	vaaudio "voice_assistant/pkg/audio"
+)
+
+// extractPCM 从上传数据中提取16kHz 16bit单声道PCM（WAV文件校验格式后去掉文件头，其余视为裸PCM）
+func extractPCM(data []byte) ([]byte, error) {
+	if !vaaudio.IsWAV(data) {
+		return data, nil
+	}
+
+	audio, err := vaaudio.ParseWAV(data)
+	if err != nil {
+		return nil, err
+	}
+	if audio.Channels != 1 || audio.SampleRate != 16000 || audio.BitsPerSample != 16 {
+		return nil, fmt.Errorf("仅支持16kHz 16bit单声道音频，当前: %dHz %dbit %d声道", audio.SampleRate, audio.BitsPerSample, audio.Channels)
+	}
+	return audio.Data, nil
+}
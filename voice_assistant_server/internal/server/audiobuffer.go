@@ -0,0 +1,144 @@
+package server
+
+import (
+	"fmt"
+	"log"
+
+	"voice_assistant/pkg/protocol"
+)
+
+// defaultMaxBufferedSeconds 未配置 limits.max_buffered_seconds 时会话音频缓冲区最多保留的秒数
+const defaultMaxBufferedSeconds = 120
+
+// AudioRing 会话音频缓冲区：容量有上限的环形缓冲区，写满后丢弃最早的音频
+// 存储空间随写入增长到上限为止；上限为0时不限制。非并发安全，由会话锁保护。
+type AudioRing struct {
+	data  []byte
+	head  int // 最早的字节在 data 中的位置
+	size  int
+	limit int
+}
+
+// NewAudioRing 创建最多保留 limit 字节的音频缓冲区（按16位采样对齐）
+func NewAudioRing(limit int) AudioRing {
+	return AudioRing{limit: limit &^ 1}
+}
+
+// Len 已缓冲的字节数
+func (r *AudioRing) Len() int {
+	return r.size
+}
+
+// Limit 容量上限（0表示不限制）
+func (r *AudioRing) Limit() int {
+	return r.limit
+}
+
+// Write 追加音频，超过上限时丢弃最早的部分，返回丢弃的字节数
+func (r *AudioRing) Write(p []byte) int {
+	dropped := 0
+	if r.limit > 0 && len(p) > r.limit {
+		excess := (len(p) - r.limit + 1) &^ 1
+		dropped += excess
+		p = p[excess:]
+	}
+	if len(p) == 0 {
+		return dropped
+	}
+
+	need := r.size + len(p)
+	if r.limit > 0 && need > r.limit {
+		need = r.limit
+	}
+	if need > len(r.data) {
+		r.grow(need)
+	}
+
+	if overflow := r.size + len(p) - len(r.data); overflow > 0 {
+		if overflow = (overflow + 1) &^ 1; overflow > r.size {
+			overflow = r.size
+		}
+		r.head = (r.head + overflow) % len(r.data)
+		r.size -= overflow
+		dropped += overflow
+	}
+
+	tail := (r.head + r.size) % len(r.data)
+	n := copy(r.data[tail:], p)
+	copy(r.data, p[n:])
+	r.size += len(p)
+	return dropped
+}
+
+// grow 扩大存储空间（至少翻倍，不超过上限），已缓冲的音频移到开头
+func (r *AudioRing) grow(need int) {
+	capacity := 2 * len(r.data)
+	if capacity < need {
+		capacity = need
+	}
+	if r.limit > 0 && capacity > r.limit {
+		capacity = r.limit
+	}
+	data := make([]byte, capacity)
+	r.CopyTo(data)
+	r.data, r.head = data, 0
+}
+
+// CopyTo 按时间顺序把缓冲的音频复制到 dst，返回复制的字节数
+func (r *AudioRing) CopyTo(dst []byte) int {
+	if r.size == 0 {
+		return 0
+	}
+	end := r.head + r.size
+	if end <= len(r.data) {
+		return copy(dst, r.data[r.head:end])
+	}
+	n := copy(dst, r.data[r.head:])
+	return n + copy(dst[n:], r.data[:end-len(r.data)])
+}
+
+// Bytes 缓冲音频的副本
+func (r *AudioRing) Bytes() []byte {
+	data := make([]byte, r.size)
+	r.CopyTo(data)
+	return data
+}
+
+// Reset 清空缓冲区（保留已分配的存储空间）
+func (r *AudioRing) Reset() {
+	r.head, r.size = 0, 0
+}
+
+// audioBufferLimit 会话音频缓冲区的容量上限：不小于触发中间识别的 AudioBufferSize
+func (p *MessageProcessor) audioBufferLimit() int {
+	seconds := p.config.Limits.MaxBufferedSeconds
+	if seconds <= 0 {
+		seconds = defaultMaxBufferedSeconds
+	}
+	limit := seconds * 1000 * pcmBytesPerMillisecond
+	if limit < p.config.AudioBufferSize {
+		limit = p.config.AudioBufferSize
+	}
+	return limit
+}
+
+// bufferAudioLocked 把音频写入会话缓冲区，返回是否需要提示客户端缓冲区已满（每句只提示一次，调用方持有会话锁）
+func (p *MessageProcessor) bufferAudioLocked(session *Session, chunk []byte) bool {
+	if session.AudioBuffer.Len() == 0 {
+		session.bufferOverflowed = false
+	}
+	if session.AudioBuffer.Write(chunk) == 0 {
+		return false
+	}
+	notify := !session.bufferOverflowed
+	session.bufferOverflowed = true
+	return notify
+}
+
+// warnAudioBufferOverflow 提示客户端会话音频缓冲区已满，最早的音频已丢弃（如客户端一直未发送最终块）
+func (p *MessageProcessor) warnAudioBufferOverflow(client *Client, session *Session, limit int) error {
+	seconds := limit / (1000 * pcmBytesPerMillisecond)
+	log.Printf("会话音频缓冲区已满（%d 秒），丢弃最早的音频: %s", seconds, session.ID)
+	return p.sendError(client, protocol.ErrAudioBufferOverflow,
+		fmt.Sprintf("缓冲的语音超过 %d 秒仍未结束，最早的部分已丢弃", seconds), true)
+}
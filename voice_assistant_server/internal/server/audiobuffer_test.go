@@ -0,0 +1,58 @@
+package server
+
+import (
+	"testing"
+
+	"voice_assistant/pkg/protocol"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAudioRing(t *testing.T) {
+	ring := NewAudioRing(8)
+	assert.Equal(t, 0, ring.Write([]byte{1, 2, 3, 4}))
+	assert.Equal(t, []byte{1, 2, 3, 4}, ring.Bytes())
+
+	// 写满后丢弃最早的音频，按写入顺序读出
+	assert.Equal(t, 2, ring.Write([]byte{5, 6, 7, 8, 9, 10}))
+	assert.Equal(t, []byte{3, 4, 5, 6, 7, 8, 9, 10}, ring.Bytes())
+	assert.Equal(t, 4, ring.Write([]byte{11, 12, 13, 14}))
+	assert.Equal(t, []byte{7, 8, 9, 10, 11, 12, 13, 14}, ring.Bytes())
+
+	// 超过容量的一块只保留最后的部分
+	assert.Equal(t, 12, ring.Write([]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}))
+	assert.Equal(t, []byte{5, 6, 7, 8, 9, 10, 11, 12}, ring.Bytes())
+
+	ring.Reset()
+	assert.Equal(t, 0, ring.Len())
+	ring.Write([]byte{1, 2})
+	assert.Equal(t, []byte{1, 2}, ring.Bytes())
+
+	// 上限为0时不限制
+	var unbounded AudioRing
+	assert.Equal(t, 0, unbounded.Write(make([]byte, 1000)))
+	assert.Equal(t, 1000, unbounded.Len())
+}
+
+func TestAudioBufferOverflowWarning(t *testing.T) {
+	p, client := newModeTestProcessor()
+	p.config.AudioBufferSize = 4
+	session := p.getOrCreateSession(client.ID, "")
+	session.AudioBuffer = NewAudioRing(4)
+	session.AudioBuffer.Write([]byte{1, 2, 3, 4})
+	session.IsProcessing = true // 不触发识别
+
+	// 缓冲区已满：丢弃最早的音频，每句只提示一次
+	sendAudio(t, p, client)
+	assert.Equal(t, protocol.ErrAudioBufferOverflow, nextErrorCode(t, client))
+	sendAudio(t, p, client)
+	assert.Empty(t, client.SendChan)
+	assert.Equal(t, []byte{1, 2, 1, 2}, session.AudioBuffer.Bytes())
+
+	// 新的一句重新提示
+	session.AudioBuffer.Reset()
+	sendAudio(t, p, client)
+	sendAudio(t, p, client)
+	sendAudio(t, p, client)
+	assert.Equal(t, protocol.ErrAudioBufferOverflow, nextErrorCode(t, client))
+}
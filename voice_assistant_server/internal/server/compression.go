@@ -0,0 +1,38 @@
+package server
+
+import (
+	"compress/flate"
+	"log"
+
+	"github.com/gorilla/websocket"
+)
+
+// 压缩默认值：base64编码的TTS音频等大消息才值得压缩，小消息压缩的开销大于收益
+const defaultCompressionThreshold = 1024
+
+// compressionThreshold 启用压缩的最小消息字节数
+func (s *WebSocketServer) compressionThreshold() int {
+	if s.config.CompressionThreshold > 0 {
+		return s.config.CompressionThreshold
+	}
+	return defaultCompressionThreshold
+}
+
+// setupCompression 设置连接的压缩级别（客户端未协商permessage-deflate时压缩设置不生效）
+func (s *WebSocketServer) setupCompression(conn *websocket.Conn) {
+	if !s.config.EnableCompression || s.config.CompressionLevel == 0 {
+		return
+	}
+	if err := conn.SetCompressionLevel(s.config.CompressionLevel); err != nil {
+		log.Printf("设置压缩级别失败，使用默认级别: %v", err)
+		conn.SetCompressionLevel(flate.DefaultCompression)
+	}
+}
+
+// writeMessage 写出文本消息，超过阈值的消息按permessage-deflate压缩
+func (c *Client) writeMessage(data []byte) error {
+	if c.Server.config.EnableCompression {
+		c.Conn.EnableWriteCompression(len(data) >= c.Server.compressionThreshold())
+	}
+	return c.Conn.WriteMessage(websocket.TextMessage, data)
+}
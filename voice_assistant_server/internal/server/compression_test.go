@@ -0,0 +1,57 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"voice_assistant/pkg/protocol"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompressionNegotiation(t *testing.T) {
+	s := NewWebSocketServer(WebSocketConfig{
+		MaxConnections:    10,
+		PingPeriod:        time.Minute,
+		PongWait:          time.Minute,
+		WriteWait:         time.Second,
+		EnableCompression: true,
+		CompressionLevel:  5,
+	})
+	defer s.Close()
+	httpServer := httptest.NewServer(http.HandlerFunc(s.HandleConnection))
+	defer httpServer.Close()
+	url := "ws" + strings.TrimPrefix(httpServer.URL, "http")
+
+	dialer := *websocket.DefaultDialer
+	dialer.EnableCompression = true
+	conn, resp, err := dialer.Dial(url+"?session_id=compression-test", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	assert.Contains(t, resp.Header.Get("Sec-WebSocket-Extensions"), "permessage-deflate")
+	require.Eventually(t, func() bool { return s.GetClientCount() == 1 }, 2*time.Second, 10*time.Millisecond)
+
+	// 超过阈值的消息压缩后下发，客户端读到原始内容
+	content := strings.Repeat("语音助手", 300)
+	require.NoError(t, s.BroadcastToClient("compression-test", protocol.NewResponseMessage("compression-test", protocol.StageLLM, content, 0.9, true, nil)))
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		_, raw, err := conn.ReadMessage()
+		require.NoError(t, err)
+		msg, err := protocol.FromJSON(raw)
+		require.NoError(t, err)
+		if msg.Type != protocol.Response {
+			continue
+		}
+		data, err := msg.ResponseData()
+		require.NoError(t, err)
+		assert.True(t, data.Content == content, "压缩后内容不一致")
+		return
+	}
+}
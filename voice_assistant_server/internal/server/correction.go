@@ -0,0 +1,173 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"voice_assistant/voice_assistant_server/internal/llm"
+)
+
+// CorrectionConfig 插话更正配置
+// 用户在上一轮回复后不久以“不对，我是说……”等方式更正时，把更正内容与上一轮的提问一起交给LLM重新回答，
+// 而不是当作一句无关的新问题。
+type CorrectionConfig struct {
+	Enabled   bool     `yaml:"enabled"`    // 是否启用
+	Window    int      `yaml:"window"`     // 上一轮结束后多少秒内的更正有效
+	MaxLength int      `yaml:"max_length"` // 超过该字符数的话不视为更正
+	Cues      []string `yaml:"cues"`       // 追加的更正提示语（句首匹配，不区分大小写）
+}
+
+// 插话更正默认值
+const (
+	defaultCorrectionWindow    = 30
+	defaultCorrectionMaxLength = 30
+)
+
+// correctionCues 内置的更正提示语（按顺序匹配，同一前缀的较长提示语在前）
+var correctionCues = []string{
+	"不对不对", "不是不是", "我的意思是", "我说的是", "我是想问", "我是说", "说错了", "更正一下", "不对", "不是的", "错了",
+	"no no", "sorry i meant", "actually i meant", "no i meant", "no i mean", "i meant", "i mean", "correction",
+}
+
+// correction 识别到的更正
+type correction struct {
+	previous string // 上一轮用户的提问
+	revised  string // 去掉提示语后的更正内容
+}
+
+// instruction 交给LLM的更正说明（不写入对话历史）
+func (c *correction) instruction() string {
+	return fmt.Sprintf("用户正在更正上一轮的提问。上一轮的提问：“%s”；更正内容：“%s”。请按更正后的意思重新回答上一轮的问题，不要沿用被更正的内容，也不必为误解致歉。", c.previous, c.revised)
+}
+
+// merged 更正后的完整提问（作为下一次更正的“上一轮”）
+func (c *correction) merged() string {
+	return c.previous + "（更正：" + c.revised + "）"
+}
+
+// detectCorrection 判断用户输入是否为对上一轮提问的更正
+func (p *MessageProcessor) detectCorrection(session *Session, text string) *correction {
+	cfg := p.config.Correction
+	if !cfg.Enabled {
+		return nil
+	}
+	window := time.Duration(cfg.Window) * time.Second
+	if window <= 0 {
+		window = defaultCorrectionWindow * time.Second
+	}
+	maxLength := cfg.MaxLength
+	if maxLength <= 0 {
+		maxLength = defaultCorrectionMaxLength
+	}
+
+	session.mu.RLock()
+	previous, lastTurnAt := session.lastUserInput, session.lastTurnAt
+	session.mu.RUnlock()
+	if previous == "" || time.Since(lastTurnAt) > window || len([]rune(text)) > maxLength {
+		return nil
+	}
+
+	revised, ok := stripCorrectionCue(text, cfg.Cues)
+	if !ok {
+		return nil
+	}
+	return &correction{previous: previous, revised: revised}
+}
+
+// stripCorrectionCue 去掉句首的更正提示语（可连续多个，如“不对，我是说”），返回剩余内容；未以提示语开头或只有提示语时返回false
+func stripCorrectionCue(text string, extra []string) (string, bool) {
+	cues := make([]string, 0, len(extra)+len(correctionCues))
+	for _, cue := range extra {
+		if cue = normalizeIntentText(cue); cue != "" {
+			cues = append(cues, cue)
+		}
+	}
+	cues = append(cues, correctionCues...)
+
+	rest := normalizeIntentText(text)
+	matched := false
+	for {
+		stripped := false
+		for _, cue := range cues {
+			if !hasCuePrefix(rest, cue) {
+				continue
+			}
+			rest = strings.TrimSpace(rest[len(cue):])
+			matched, stripped = true, true
+			break
+		}
+		if !stripped {
+			break
+		}
+	}
+	if !matched || rest == "" {
+		return "", false
+	}
+	return rest, true
+}
+
+// hasCuePrefix 句首是否为提示语（英文提示语须以词边界结束，避免“now”匹配“no”）
+func hasCuePrefix(text, cue string) bool {
+	if !strings.HasPrefix(text, cue) {
+		return false
+	}
+	if len(text) == len(cue) {
+		return true
+	}
+	last, next := cue[len(cue)-1], text[len(cue)]
+	return !isASCIILetter(last) || !isASCIILetter(next)
+}
+
+// isASCIILetter 是否为英文字母
+func isASCIILetter(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+// withCorrection 把更正说明追加到LLM请求的附加指令（与语言等其他指令合并）
+func withCorrection(ctx context.Context, c *correction) context.Context {
+	if c == nil {
+		return ctx
+	}
+
+	opts := llm.RequestOptionsFromContext(ctx)
+	if opts.Instruction != "" {
+		opts.Instruction += "\n" + c.instruction()
+	} else {
+		opts.Instruction = c.instruction()
+	}
+	return llm.WithRequestOptions(ctx, opts)
+}
+
+// recordUserTurn 记录本轮用户的提问，供下一轮判断更正（更正轮记录合并后的提问）
+func (p *MessageProcessor) recordUserTurn(session *Session, text string, c *correction) {
+	if !p.config.Correction.Enabled {
+		return
+	}
+	if c != nil {
+		text = c.merged()
+		log.Printf("用户更正了上一轮的提问: %s, %q", session.ID, text)
+	}
+
+	session.mu.Lock()
+	session.lastUserInput = text
+	session.lastTurnAt = time.Now()
+	session.mu.Unlock()
+}
+
+// correctionMetadata 更正轮的LLM响应元数据（与审核等其他元数据合并）
+func correctionMetadata(metadata map[string]interface{}, c *correction) map[string]interface{} {
+	if c == nil {
+		return metadata
+	}
+	if metadata == nil {
+		metadata = make(map[string]interface{})
+	}
+	metadata["correction"] = map[string]interface{}{
+		"previous": c.previous,
+		"revised":  c.revised,
+	}
+	return metadata
+}
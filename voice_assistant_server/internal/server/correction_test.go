@@ -0,0 +1,61 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"voice_assistant/voice_assistant_server/internal/llm"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStripCorrectionCue(t *testing.T) {
+	rest, ok := stripCorrectionCue("不对，我是说上海", nil)
+	require.True(t, ok)
+	assert.Equal(t, "上海", strings.Trim(rest, "，, "))
+
+	rest, ok = stripCorrectionCue("I meant Shanghai", nil)
+	require.True(t, ok)
+	assert.Equal(t, "shanghai", strings.ToLower(rest))
+
+	for _, text := range []string{"now what", "上海天气怎么样", "不对", "I meant"} {
+		_, ok = stripCorrectionCue(text, nil)
+		assert.False(t, ok, text)
+	}
+
+	_, ok = stripCorrectionCue("换成 北京", []string{"换成"})
+	assert.True(t, ok)
+}
+
+func TestDetectCorrection(t *testing.T) {
+	p := NewMessageProcessor(ProcessorConfig{MaxConcurrentSessions: 10, Correction: CorrectionConfig{Enabled: true, Window: 30, MaxLength: 30}})
+	session := p.getOrCreateSession("correction-test", "")
+
+	// 没有上一轮时不视为更正
+	assert.Nil(t, p.detectCorrection(session, "不对，我是说上海"))
+
+	p.recordUserTurn(session, "北京明天天气怎么样", nil)
+	c := p.detectCorrection(session, "不对，我是说上海")
+	require.NotNil(t, c)
+	assert.Equal(t, "北京明天天气怎么样", c.previous)
+
+	// 更正说明附加在请求指令中
+	opts := llm.RequestOptionsFromContext(withCorrection(context.Background(), c))
+	assert.Contains(t, opts.Instruction, "北京明天天气怎么样")
+
+	// 连续更正以合并后的提问为上一轮
+	p.recordUserTurn(session, "不对，我是说上海", c)
+	next := p.detectCorrection(session, "我的意思是后天")
+	require.NotNil(t, next)
+	assert.Equal(t, c.merged(), next.previous)
+
+	// 过长或超过时间窗口的话按新问题处理
+	assert.Nil(t, p.detectCorrection(session, "不对，"+strings.Repeat("很长的一句话", 10)))
+	session.mu.Lock()
+	session.lastTurnAt = time.Now().Add(-time.Minute)
+	session.mu.Unlock()
+	assert.Nil(t, p.detectCorrection(session, "不对，我是说上海"))
+}
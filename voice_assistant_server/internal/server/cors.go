@@ -0,0 +1,128 @@
+package server
+
+import (
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CORSConfig 跨域访问与WebSocket来源检查配置
+type CORSConfig struct {
+	AllowedOrigins   []string      `yaml:"allowed_origins"`   // 允许的来源，支持通配符（如 https://*.example.com、http://localhost:*），"*" 允许任意来源
+	AllowCredentials bool          `yaml:"allow_credentials"` // 是否允许浏览器携带Cookie等凭据
+	MaxAge           time.Duration `yaml:"max_age"`           // 预检结果的缓存时长
+	Strict           bool          `yaml:"-"`                 // 未配置允许来源时只允许同源请求（生产模式）；否则允许任意来源
+}
+
+// corsMethods 预检响应中允许的方法
+const corsMethods = "GET, POST, PUT, DELETE, OPTIONS"
+
+// OriginChecker 按配置检查浏览器请求的来源（Origin请求头）
+// 不带Origin的请求（命令行客户端、服务间调用）和同源请求始终放行。
+type OriginChecker struct {
+	config    CORSConfig
+	exact     map[string]bool
+	wildcards []string
+	allowAll  bool
+}
+
+// NewOriginChecker 创建来源检查
+func NewOriginChecker(config CORSConfig) *OriginChecker {
+	checker := &OriginChecker{
+		config: config,
+		exact:  make(map[string]bool),
+	}
+	for _, origin := range config.AllowedOrigins {
+		origin = strings.ToLower(strings.TrimRight(strings.TrimSpace(origin), "/"))
+		switch {
+		case origin == "":
+		case origin == "*":
+			checker.allowAll = true
+		case strings.Contains(origin, "*"):
+			checker.wildcards = append(checker.wildcards, origin)
+		default:
+			checker.exact[origin] = true
+		}
+	}
+	if len(config.AllowedOrigins) == 0 && !config.Strict {
+		checker.allowAll = true
+	}
+	return checker
+}
+
+// AllowsAll 是否允许任意来源（开发模式未配置允许来源时）
+func (o *OriginChecker) AllowsAll() bool {
+	return o.allowAll
+}
+
+// Allowed 来源是否在允许列表中
+func (o *OriginChecker) Allowed(origin string) bool {
+	if o.allowAll {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	if o.exact[origin] {
+		return true
+	}
+	for _, pattern := range o.wildcards {
+		if matched, _ := path.Match(pattern, origin); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckRequest 检查请求来源（用于 websocket.Upgrader.CheckOrigin）
+func (o *OriginChecker) CheckRequest(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	return origin == "" || sameOrigin(origin, r) || o.Allowed(origin)
+}
+
+// Middleware HTTP路由的跨域处理：允许的来源附带CORS响应头并应答预检请求，不允许的来源返回403
+func (o *OriginChecker) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" || sameOrigin(origin, c.Request) {
+			c.Next()
+			return
+		}
+		if !o.Allowed(origin) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "来源不被允许: " + origin})
+			return
+		}
+
+		header := c.Writer.Header()
+		header.Set("Access-Control-Allow-Origin", origin)
+		header.Add("Vary", "Origin")
+		if o.config.AllowCredentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != "" {
+			header.Set("Access-Control-Allow-Methods", corsMethods)
+			if headers := c.GetHeader("Access-Control-Request-Headers"); headers != "" {
+				header.Set("Access-Control-Allow-Headers", headers)
+			}
+			if o.config.MaxAge > 0 {
+				header.Set("Access-Control-Max-Age", strconv.Itoa(int(o.config.MaxAge.Seconds())))
+			}
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		c.Next()
+	}
+}
+
+// sameOrigin 来源与请求的主机相同（同源的页面）
+func sameOrigin(origin string, r *http.Request) bool {
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOriginChecker(t *testing.T) {
+	checker := NewOriginChecker(CORSConfig{
+		AllowedOrigins: []string{"https://app.example.com/", "https://*.example.org", "http://localhost:*"},
+		Strict:         true,
+	})
+
+	assert.True(t, checker.Allowed("https://app.example.com"))
+	assert.True(t, checker.Allowed("https://A.example.org"))
+	assert.True(t, checker.Allowed("http://localhost:3000"))
+	assert.False(t, checker.Allowed("https://example.org"))
+	assert.False(t, checker.Allowed("https://evil.com"))
+	assert.False(t, checker.Allowed("http://localhost.evil.com"))
+
+	// 不带Origin和同源的请求始终放行
+	req := httptest.NewRequest(http.MethodGet, "http://voice.local:8080/ws", nil)
+	assert.True(t, checker.CheckRequest(req))
+	req.Header.Set("Origin", "http://voice.local:8080")
+	assert.True(t, checker.CheckRequest(req))
+	req.Header.Set("Origin", "https://evil.com")
+	assert.False(t, checker.CheckRequest(req))
+
+	// 生产模式未配置时只允许同源，开发模式允许任意来源
+	assert.False(t, NewOriginChecker(CORSConfig{Strict: true}).CheckRequest(req))
+	assert.True(t, NewOriginChecker(CORSConfig{}).CheckRequest(req))
+}
+
+func TestOriginMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(NewOriginChecker(CORSConfig{AllowedOrigins: []string{"https://app.example.com"}, Strict: true}).Middleware())
+	router.POST("/api/chat", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	serve := func(method, origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/chat", nil)
+		req.Header.Set("Origin", origin)
+		if method == http.MethodOptions {
+			req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			req.Header.Set("Access-Control-Request-Headers", "Content-Type")
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve(http.MethodOptions, "https://app.example.com")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "Content-Type", w.Header().Get("Access-Control-Allow-Headers"))
+
+	w = serve(http.MethodPost, "https://app.example.com")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+
+	assert.Equal(t, http.StatusForbidden, serve(http.MethodPost, "https://evil.com").Code)
+	assert.Equal(t, http.StatusForbidden, serve(http.MethodOptions, "https://evil.com").Code)
+}
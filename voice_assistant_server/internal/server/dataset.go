@@ -0,0 +1,69 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+
+	"voice_assistant/pkg/protocol"
+	"voice_assistant/voice_assistant_server/internal/dataset"
+	"voice_assistant/voice_assistant_server/internal/llm"
+)
+
+// handleSetDataCollection 处理设置数据采集授权
+func (p *MessageProcessor) handleSetDataCollection(client *Client, session *Session, cmdData protocol.CommandData) error {
+	enabled, ok := cmdData.Parameters["enabled"].(bool)
+	if !ok {
+		return p.sendError(client, "INVALID_COMMAND_DATA", "缺少数据采集授权参数: enabled", true)
+	}
+
+	session.mu.Lock()
+	if enabled {
+		session.DataConsent = dataset.ConsentGranted
+	} else {
+		session.DataConsent = dataset.ConsentDenied
+	}
+	session.mu.Unlock()
+
+	log.Printf("会话数据采集授权已更新: %s, 同意: %t", session.ID, enabled)
+	return p.sendStatus(client, session)
+}
+
+// recordExchange 记录一轮对话样本（遵循会话授权状态）
+func (p *MessageProcessor) recordExchange(session *Session, prompt string, response llm.LLMResponse) {
+	if p.datasetSink == nil || prompt == "" || response.Content == "" {
+		return
+	}
+
+	// 写入前读取最新授权状态，会话中途退出后立即停止记录
+	session.mu.RLock()
+	consent := session.DataConsent
+	language := session.Language
+	persona := session.Persona
+	session.mu.RUnlock()
+
+	if !p.config.DataCollection.Allows(consent) {
+		return
+	}
+
+	systemPrompt := ""
+	if p.config.DataCollection.IncludeSystemPrompt {
+		systemPrompt = p.systemPrompt(persona)
+	}
+
+	record := dataset.NewRecord(systemPrompt, prompt, response.Content)
+	record.Metadata.SessionKey = hashSessionID(session.ID)
+	record.Metadata.Language = language
+	record.Metadata.Model = response.Model
+	record.Metadata.Consent = consent == dataset.ConsentGranted
+
+	if err := p.datasetSink.Write(record); err != nil {
+		log.Printf("写入数据采集样本失败: %v", err)
+	}
+}
+
+// hashSessionID 对会话ID做不可逆哈希，样本中不保留原始标识
+func hashSessionID(sessionID string) string {
+	sum := sha256.Sum256([]byte(sessionID))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,203 @@
+package server
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"runtime/debug"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BufferSizes 新建WebSocket连接使用的缓冲区大小
+type BufferSizes struct {
+	ReadBufferSize  int `json:"read_buffer_size"`
+	WriteBufferSize int `json:"write_buffer_size"`
+	SendQueueSize   int `json:"send_queue_size"`
+}
+
+// BufferSizes 当前新建连接使用的缓冲区大小
+func (s *WebSocketServer) BufferSizes() BufferSizes {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	sizes := BufferSizes{
+		ReadBufferSize:  s.upgrader.ReadBufferSize,
+		WriteBufferSize: s.upgrader.WriteBufferSize,
+		SendQueueSize:   s.config.SendQueueSize,
+	}
+	if sizes.SendQueueSize <= 0 {
+		sizes.SendQueueSize = defaultSendQueueSize
+	}
+	return sizes
+}
+
+// SetBufferSizes 调整新建连接使用的缓冲区大小（为0的字段保持不变），已建立的连接不受影响
+func (s *WebSocketServer) SetBufferSizes(sizes BufferSizes) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sizes.ReadBufferSize > 0 {
+		s.upgrader.ReadBufferSize = sizes.ReadBufferSize
+	}
+	if sizes.WriteBufferSize > 0 {
+		s.upgrader.WriteBufferSize = sizes.WriteBufferSize
+	}
+	if sizes.SendQueueSize > 0 {
+		s.config.SendQueueSize = sizes.SendQueueSize
+	}
+}
+
+// RuntimeSettings 运行时调优参数和内存概况（调试端口返回）
+type RuntimeSettings struct {
+	GCPercent   int         `json:"gc_percent"`   // GOGC（负数表示关闭按比例触发的GC）
+	MemoryLimit int64       `json:"memory_limit"` // GOMEMLIMIT（字节，math.MaxInt64表示不限制）
+	Buffers     BufferSizes `json:"buffers"`
+
+	Goroutines int    `json:"goroutines"`
+	HeapAlloc  uint64 `json:"heap_alloc"`
+	HeapInuse  uint64 `json:"heap_inuse"`
+	HeapIdle   uint64 `json:"heap_idle"`
+	Sys        uint64 `json:"sys"`
+	NumGC      uint32 `json:"num_gc"`
+}
+
+// RuntimeUpdate 修改运行时调优参数的请求（只修改给出的字段）
+type RuntimeUpdate struct {
+	GCPercent       *int   `json:"gc_percent"`
+	MemoryLimit     *int64 `json:"memory_limit"`
+	ReadBufferSize  *int   `json:"read_buffer_size"`
+	WriteBufferSize *int   `json:"write_buffer_size"`
+	SendQueueSize   *int   `json:"send_queue_size"`
+}
+
+// validate 检查修改的取值
+func (u RuntimeUpdate) validate() error {
+	for _, size := range []*int{u.ReadBufferSize, u.WriteBufferSize, u.SendQueueSize} {
+		if size != nil && *size <= 0 {
+			return errors.New("缓冲区大小必须大于0")
+		}
+	}
+	if u.MemoryLimit != nil && *u.MemoryLimit <= 0 {
+		return errors.New("memory_limit必须大于0")
+	}
+	return nil
+}
+
+// DebugHandler 调试端口：pprof和运行时调优（与管理接口共用管理令牌，应只监听内网或本机地址）
+type DebugHandler struct {
+	ws     *WebSocketServer
+	config AdminConfig
+	mu     sync.Mutex // 串行化GC参数的读取和修改
+}
+
+// NewDebugHandler 创建调试端口的处理器
+func NewDebugHandler(ws *WebSocketServer, config AdminConfig) *DebugHandler {
+	return &DebugHandler{ws: ws, config: config}
+}
+
+// Register 注册路由（pprof要求挂在根路径下）
+func (h *DebugHandler) Register(router gin.IRouter) {
+	router.Use(AdminAuthorize(h.config.Token))
+	router.GET("/debug/pprof/*name", h.handlePprof)
+	router.POST("/debug/pprof/symbol", gin.WrapF(pprof.Symbol))
+	router.GET("/debug/runtime", h.handleRuntime)
+	router.PUT("/debug/runtime", h.handleRuntimeUpdate)
+	router.POST("/debug/gc", h.handleGC)
+}
+
+// handlePprof 分发pprof接口（Index同时提供heap、goroutine等命名的profile）
+func (h *DebugHandler) handlePprof(c *gin.Context) {
+	switch c.Param("name") {
+	case "/cmdline":
+		pprof.Cmdline(c.Writer, c.Request)
+	case "/profile":
+		pprof.Profile(c.Writer, c.Request)
+	case "/symbol":
+		pprof.Symbol(c.Writer, c.Request)
+	case "/trace":
+		pprof.Trace(c.Writer, c.Request)
+	default:
+		pprof.Index(c.Writer, c.Request)
+	}
+}
+
+// handleRuntime 查看运行时调优参数和内存概况
+func (h *DebugHandler) handleRuntime(c *gin.Context) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	c.JSON(http.StatusOK, h.settings())
+}
+
+// handleRuntimeUpdate 修改GC参数和新建连接的缓冲区大小（请求体为 RuntimeUpdate），返回修改后的设置
+func (h *DebugHandler) handleRuntimeUpdate(c *gin.Context) {
+	var req RuntimeUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := req.validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if req.GCPercent != nil {
+		previous := debug.SetGCPercent(*req.GCPercent)
+		log.Printf("调整GC比例: %d -> %d", previous, *req.GCPercent)
+	}
+	if req.MemoryLimit != nil {
+		previous := debug.SetMemoryLimit(*req.MemoryLimit)
+		log.Printf("调整内存上限: %d -> %d", previous, *req.MemoryLimit)
+	}
+
+	var sizes BufferSizes
+	if req.ReadBufferSize != nil {
+		sizes.ReadBufferSize = *req.ReadBufferSize
+	}
+	if req.WriteBufferSize != nil {
+		sizes.WriteBufferSize = *req.WriteBufferSize
+	}
+	if req.SendQueueSize != nil {
+		sizes.SendQueueSize = *req.SendQueueSize
+	}
+	if sizes != (BufferSizes{}) {
+		h.ws.SetBufferSizes(sizes)
+		log.Printf("调整新建连接的缓冲区大小: %+v", h.ws.BufferSizes())
+	}
+
+	c.JSON(http.StatusOK, h.settings())
+}
+
+// handleGC 立即执行GC并把空闲内存归还操作系统，返回执行后的内存概况
+func (h *DebugHandler) handleGC(c *gin.Context) {
+	debug.FreeOSMemory()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	c.JSON(http.StatusOK, h.settings())
+}
+
+// settings 当前的运行时设置（调用方持有 h.mu）
+func (h *DebugHandler) settings() RuntimeSettings {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	// SetGCPercent只能在设置时返回原值，读取后立即恢复
+	percent := debug.SetGCPercent(100)
+	debug.SetGCPercent(percent)
+
+	return RuntimeSettings{
+		GCPercent:   percent,
+		MemoryLimit: debug.SetMemoryLimit(-1),
+		Buffers:     h.ws.BufferSizes(),
+		Goroutines:  runtime.NumGoroutine(),
+		HeapAlloc:   mem.HeapAlloc,
+		HeapInuse:   mem.HeapInuse,
+		HeapIdle:    mem.HeapIdle,
+		Sys:         mem.Sys,
+		NumGC:       mem.NumGC,
+	}
+}
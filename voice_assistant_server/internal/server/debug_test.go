@@ -0,0 +1,47 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime/debug"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebugRuntimeTuning(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ws := NewWebSocketServer(WebSocketConfig{ReadBufferSize: 1024, WriteBufferSize: 1024})
+	router := gin.New()
+	NewDebugHandler(ws, AdminConfig{Token: "secret"}).Register(router)
+
+	serve := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("X-Admin-Token", token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, serve(http.MethodGet, "/debug/pprof/", "", "").Code)
+	assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/debug/pprof/", "secret", "").Code)
+	assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/debug/pprof/goroutine?debug=1", "secret", "").Code)
+
+	// 修改GC比例和新建连接的缓冲区大小，未给出的字段保持不变
+	defer debug.SetGCPercent(debug.SetGCPercent(100))
+	w := serve(http.MethodPut, "/debug/runtime", "secret", `{"gc_percent": 50, "read_buffer_size": 4096, "send_queue_size": 200}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	var settings RuntimeSettings
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &settings))
+	assert.Equal(t, 50, settings.GCPercent)
+	assert.Equal(t, BufferSizes{ReadBufferSize: 4096, WriteBufferSize: 1024, SendQueueSize: 200}, settings.Buffers)
+	assert.Equal(t, settings.Buffers, ws.BufferSizes())
+
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodPut, "/debug/runtime", "secret", `{"send_queue_size": 0}`).Code)
+}
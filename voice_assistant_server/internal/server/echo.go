@@ -0,0 +1,147 @@
+package server
+
+import (
+	"strings"
+	"time"
+)
+
+// EchoSuppressionConfig 回声抑制配置
+// 连续模式下没有回声消除时，ASR可能识别到助手自己播放的TTS语音，造成自问自答的循环。
+type EchoSuppressionConfig struct {
+	Enabled   bool    `yaml:"enabled"`    // 是否启用
+	Window    int     `yaml:"window"`     // 比对最近多少秒内播放的文本
+	Threshold float64 `yaml:"threshold"`  // 相似度阈值（0-1）
+	MinLength int     `yaml:"min_length"` // 参与比对的最少字符数（过短的识别结果不判定为回声）
+}
+
+// 回声抑制默认值
+const (
+	defaultEchoWindow    = 30
+	defaultEchoThreshold = 0.8
+	defaultEchoMinLength = 4
+	maxSpokenHistory     = 5
+)
+
+// spokenText 已播放的TTS文本
+type spokenText struct {
+	text     []rune // 规范化后的文本
+	spokenAt time.Time
+}
+
+// rememberSpoken 记录已发送给客户端播放的TTS文本
+func (p *MessageProcessor) rememberSpoken(session *Session, text string) {
+	if !p.config.EchoSuppression.Enabled {
+		return
+	}
+
+	normalized := []rune(normalizeEchoText(text))
+	if len(normalized) == 0 {
+		return
+	}
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	session.recentSpoken = append(session.recentSpoken, spokenText{
+		text:     normalized,
+		spokenAt: time.Now(),
+	})
+	if len(session.recentSpoken) > maxSpokenHistory {
+		session.recentSpoken = session.recentSpoken[len(session.recentSpoken)-maxSpokenHistory:]
+	}
+}
+
+// isSelfEcho 判断识别文本是否为最近播放的TTS回声（仅连续模式）
+func (p *MessageProcessor) isSelfEcho(session *Session, transcript string) bool {
+	cfg := p.config.EchoSuppression
+	if !cfg.Enabled {
+		return false
+	}
+
+	window := time.Duration(cfg.Window) * time.Second
+	if window <= 0 {
+		window = defaultEchoWindow * time.Second
+	}
+	threshold := cfg.Threshold
+	if threshold <= 0 || threshold > 1 {
+		threshold = defaultEchoThreshold
+	}
+	minLength := cfg.MinLength
+	if minLength <= 0 {
+		minLength = defaultEchoMinLength
+	}
+
+	heard := []rune(normalizeEchoText(transcript))
+	if len(heard) < minLength {
+		return false
+	}
+
+	session.mu.RLock()
+	defer session.mu.RUnlock()
+
+	if !session.ContinuousMode {
+		return false
+	}
+
+	now := time.Now()
+	for _, spoken := range session.recentSpoken {
+		if now.Sub(spoken.spokenAt) > window {
+			continue
+		}
+		if echoSimilarity(heard, spoken.text) >= threshold {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeEchoText 规范化比对文本：去除标点和空白，统一小写
+func normalizeEchoText(text string) string {
+	return strings.ReplaceAll(normalizeIntentText(text), " ", "")
+}
+
+// echoSimilarity 计算识别文本与播放文本的相似度
+// 识别结果通常只是播放内容的一部分，因此与播放文本中等长的最佳片段比较。
+func echoSimilarity(heard, spoken []rune) float64 {
+	if len(heard) == 0 || len(spoken) == 0 {
+		return 0
+	}
+
+	if len(heard) >= len(spoken) {
+		return 1 - float64(editDistance(heard, spoken))/float64(len(heard))
+	}
+
+	best := 0.0
+	for start := 0; start+len(heard) <= len(spoken); start++ {
+		similarity := 1 - float64(editDistance(heard, spoken[start:start+len(heard)]))/float64(len(heard))
+		if similarity > best {
+			best = similarity
+			if best == 1 {
+				break
+			}
+		}
+	}
+	return best
+}
+
+// editDistance 计算编辑距离
+func editDistance(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,40 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEchoSimilarity(t *testing.T) {
+	spoken := []rune(normalizeEchoText("今天北京天气晴朗，最高气温二十五度。"))
+
+	assert.Equal(t, 1.0, echoSimilarity([]rune(normalizeEchoText("北京天气晴朗")), spoken))
+	assert.GreaterOrEqual(t, echoSimilarity([]rune(normalizeEchoText("今天北京天汽晴朗")), spoken), 0.8)
+	assert.Less(t, echoSimilarity([]rune(normalizeEchoText("帮我订一张机票")), spoken), 0.8)
+	assert.Equal(t, 0.0, echoSimilarity(nil, spoken))
+}
+
+func TestIsSelfEcho(t *testing.T) {
+	p := &MessageProcessor{config: ProcessorConfig{
+		EchoSuppression: EchoSuppressionConfig{Enabled: true, Window: 30, Threshold: 0.8},
+	}}
+	session := &Session{ID: "test", ContinuousMode: true}
+
+	p.rememberSpoken(session, "Sure, I will speak English from now on.")
+
+	assert.True(t, p.isSelfEcho(session, "I will speak English from now on"))
+	assert.False(t, p.isSelfEcho(session, "What's the weather like today?"))
+	// 过短的识别结果不判定为回声
+	assert.False(t, p.isSelfEcho(session, "now"))
+
+	// 超出比对窗口
+	session.recentSpoken[0].spokenAt = time.Now().Add(-time.Minute)
+	assert.False(t, p.isSelfEcho(session, "I will speak English from now on"))
+
+	// 非连续模式不做判定
+	p.rememberSpoken(session, "Sure, I will speak English from now on.")
+	session.ContinuousMode = false
+	assert.False(t, p.isSelfEcho(session, "I will speak English from now on"))
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"voice_assistant/pkg/protocol"
+)
+
+// EndpointingConfig 服务端断句配置
+// 客户端没有（或来不及）发送is_final时，最后一个音频块之后静默超过设定时长，服务端即结束本句并开始识别。
+type EndpointingConfig struct {
+	Silence    time.Duration `yaml:"silence"`     // 默认静默时长（0表示只依赖客户端的is_final）
+	MinSilence time.Duration `yaml:"min_silence"` // 会话参数endpoint_silence_ms允许的最小值
+	MaxSilence time.Duration `yaml:"max_silence"` // 会话参数endpoint_silence_ms允许的最大值
+}
+
+// 断句默认值
+const (
+	defaultEndpointMinSilence = 200 * time.Millisecond
+	defaultEndpointMaxSilence = 10 * time.Second
+)
+
+// parseEndpointParameter 解析会话参数中的endpoint_silence_ms（0表示关闭服务端断句），返回是否携带该参数
+func (p *MessageProcessor) parseEndpointParameter(params map[string]interface{}) (time.Duration, bool, error) {
+	value, exists := params["endpoint_silence_ms"]
+	if !exists {
+		return 0, false, nil
+	}
+	ms, ok := value.(float64)
+	if !ok || ms < 0 {
+		return 0, false, fmt.Errorf("endpoint_silence_ms必须是非负数")
+	}
+	silence := time.Duration(ms) * time.Millisecond
+	if silence == 0 {
+		return 0, true, nil
+	}
+
+	minSilence, maxSilence := p.config.Endpointing.MinSilence, p.config.Endpointing.MaxSilence
+	if minSilence <= 0 {
+		minSilence = defaultEndpointMinSilence
+	}
+	if maxSilence <= 0 {
+		maxSilence = defaultEndpointMaxSilence
+	}
+	if silence < minSilence || silence > maxSilence {
+		return 0, false, fmt.Errorf("endpoint_silence_ms超出范围: %d-%d", minSilence.Milliseconds(), maxSilence.Milliseconds())
+	}
+	return silence, true, nil
+}
+
+// armEndpointLocked 收到非最终音频块后重新计时，静默超时时按整句处理缓冲的音频；收到最终块时取消计时（调用方持有会话锁）
+// 按键说话模式只由客户端的is_final结束本句，不计时。
+func (p *MessageProcessor) armEndpointLocked(client *Client, session *Session, isFinal bool) {
+	session.endpointWait++
+	if session.endpointTimer != nil {
+		session.endpointTimer.Stop()
+		session.endpointTimer = nil
+	}
+	if isFinal || session.EndpointSilence <= 0 || session.speechActive || session.Mode == protocol.ModePushToTalk {
+		return
+	}
+
+	wait := session.endpointWait
+	session.endpointTimer = time.AfterFunc(session.EndpointSilence, func() {
+		p.endpointTimeout(client, session, wait)
+	})
+}
+
+// endpointTimeout 静默超时：会话仍在聆听且有未处理的音频时结束本句
+func (p *MessageProcessor) endpointTimeout(client *Client, session *Session, wait uint64) {
+	session.mu.Lock()
+	released := session.ctx != nil && session.ctx.Err() != nil
+	if released || wait != session.endpointWait || session.State != StateListening || session.AudioBuffer.Len() == 0 {
+		session.mu.Unlock()
+		return
+	}
+	session.endpointTimer = nil
+	silence := session.EndpointSilence
+	session.mu.Unlock()
+
+	log.Printf("音频静默超过 %v，服务端结束本句: %s", silence, session.ID)
+	p.scheduleAudio(client, session, true)
+}
+
+// handleVoiceActivity 处理客户端上报的VAD事件：开始说话时暂停静默计时，说完时结束本句并开始识别
+// 按键说话模式由客户端的is_final结束本句，说话中的停顿不断句。
+func (p *MessageProcessor) handleVoiceActivity(client *Client, session *Session, speaking bool) {
+	session.mu.Lock()
+	session.LastActivity = time.Now()
+	session.speechActive = speaking
+	session.endpointWait++
+	if session.endpointTimer != nil {
+		session.endpointTimer.Stop()
+		session.endpointTimer = nil
+	}
+	released := session.ctx != nil && session.ctx.Err() != nil
+	endUtterance := !speaking && !released && session.Mode != protocol.ModePushToTalk &&
+		session.State == StateListening && session.AudioBuffer.Len() > 0
+	session.mu.Unlock()
+
+	if endUtterance {
+		log.Printf("客户端检测到说话结束，结束本句: %s", session.ID)
+		p.scheduleAudio(client, session, true)
+	}
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"voice_assistant/pkg/protocol"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEndpointParameter(t *testing.T) {
+	p := NewMessageProcessor(ProcessorConfig{MaxConcurrentSessions: 10})
+
+	_, ok, err := p.parseEndpointParameter(map[string]interface{}{})
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	silence, ok, err := p.parseEndpointParameter(map[string]interface{}{"endpoint_silence_ms": 600.0})
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 600*time.Millisecond, silence)
+
+	silence, ok, err = p.parseEndpointParameter(map[string]interface{}{"endpoint_silence_ms": 0.0})
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Zero(t, silence)
+
+	for _, value := range []interface{}{50.0, 60000.0, -1.0, "600"} {
+		_, _, err = p.parseEndpointParameter(map[string]interface{}{"endpoint_silence_ms": value})
+		assert.Error(t, err, value)
+	}
+}
+
+func TestEndpointTimer(t *testing.T) {
+	p, client := newModeTestProcessor()
+	start := protocol.NewCommandMessage(client.ID, protocol.CmdStartSession, protocol.ModeContinuous, map[string]interface{}{"endpoint_silence_ms": 10000.0})
+	require.NoError(t, p.ProcessMessage(client, start))
+	<-client.SendChan
+
+	// 每个音频块重新计时，最终块取消计时
+	sendAudio(t, p, client)
+	session := p.getOrCreateSession(client.ID, "")
+	session.mu.Lock()
+	wait := session.endpointWait
+	require.NotNil(t, session.endpointTimer)
+	session.mu.Unlock()
+
+	sendAudio(t, p, client)
+	session.mu.Lock()
+	assert.Equal(t, wait+1, session.endpointWait)
+	p.armEndpointLocked(client, session, true)
+	assert.Nil(t, session.endpointTimer)
+	session.mu.Unlock()
+
+	// 过期的计时不处理音频
+	p.endpointTimeout(client, session, wait)
+	assert.Equal(t, 4, session.AudioBuffer.Len())
+	assert.Equal(t, StateListening, session.State)
+}
+
+func TestVoiceActivityEndpointing(t *testing.T) {
+	p, client := newModeTestProcessor()
+	service := &blockingASR{started: make(chan struct{})}
+	p.asrService = service
+	start := protocol.NewCommandMessage(client.ID, protocol.CmdStartSession, protocol.ModeContinuous, map[string]interface{}{"endpoint_silence_ms": 10000.0})
+	require.NoError(t, p.ProcessMessage(client, start))
+	<-client.SendChan
+
+	reportVAD := func(state string) {
+		msg := protocol.NewMessage(protocol.Status, client.ID, &protocol.StatusData{State: state})
+		require.NoError(t, p.ProcessMessage(client, msg))
+	}
+
+	// 说话期间不按静默计时断句
+	reportVAD(protocol.StateSpeechStart)
+	sendAudio(t, p, client)
+	session := p.getOrCreateSession(client.ID, "")
+	session.mu.Lock()
+	assert.Nil(t, session.endpointTimer)
+	session.mu.Unlock()
+
+	// 说完后立即结束本句并开始识别
+	reportVAD(protocol.StateSpeechEnd)
+	assert.Equal(t, string(StateProcessing), nextStatus(t, client).State)
+	select {
+	case <-service.started:
+	case <-time.After(time.Second):
+		t.Fatal("说话结束后未开始识别")
+	}
+	p.ReleaseSession(client.ID)
+}
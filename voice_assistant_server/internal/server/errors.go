@@ -0,0 +1,75 @@
+package server
+
+import (
+	"voice_assistant/pkg/protocol"
+	"voice_assistant/voice_assistant_server/internal/errs"
+	"voice_assistant/voice_assistant_server/internal/pipeline"
+
+	"github.com/gin-gonic/gin"
+)
+
+// providerName 处理阶段使用的服务提供方
+func (p *MessageProcessor) providerName(stage pipeline.Stage) string {
+	switch stage {
+	case pipeline.StageASR:
+		return p.config.ASRConfig.Type
+	case pipeline.StageLLM:
+		return p.config.LLMConfig.Type
+	case pipeline.StageTTS:
+		return p.config.TTSConfig.Type
+	}
+	return ""
+}
+
+// stageError 把ASR/LLM/TTS调用失败包装为带协议错误代码的错误
+func (p *MessageProcessor) stageError(stage pipeline.Stage, err error) *errs.Error {
+	return errs.Wrap(err, string(stage), p.providerName(stage))
+}
+
+// stageErrorMessage 面向用户的错误说明：已分类的错误在message后附上原因（如“语音合成失败：服务繁忙，请稍后再试”）
+func stageErrorMessage(e *errs.Error, message string) string {
+	if _, classified := errs.KindOf(e.Err); classified {
+		return message + "：" + e.Message
+	}
+	return message
+}
+
+// sendStageError 向客户端发送服务调用失败，错误代码、可重试标志按错误分类（未分类的错误使用该阶段的失败代码），详情中带处理阶段和服务提供方
+func (p *MessageProcessor) sendStageError(client *Client, stage pipeline.Stage, err error, message string) error {
+	return p.sendPartialStageError(client, stage, err, message, nil)
+}
+
+// sendPartialStageError 与 sendStageError 相同，详情的 partial 中带本轮已下发的前面阶段的结果（如识别文本、文本回复）
+func (p *MessageProcessor) sendPartialStageError(client *Client, stage pipeline.Stage, err error, message string, partial map[string]interface{}) error {
+	e := p.stageError(stage, err)
+	details := map[string]interface{}{
+		"stage":    e.Stage,
+		"provider": e.Provider,
+	}
+	if len(partial) > 0 {
+		details["partial"] = partial
+	}
+	return client.SendMessage(protocol.NewMessage(protocol.Error, client.ID, &protocol.ErrorData{
+		Code:        e.Code,
+		Message:     stageErrorMessage(e, message),
+		Recoverable: true,
+		Retryable:   e.Retryable,
+		Details:     details,
+	}))
+}
+
+// stageTimedOut 阶段调用是否因超时失败（单次调用超过该阶段的超时或本轮超过总时长）
+func stageTimedOut(err error) bool {
+	kind, ok := errs.KindOf(err)
+	return ok && kind.Code == protocol.ErrTimeout
+}
+
+// serviceError REST接口返回服务调用失败，HTTP状态码按错误代码选择（如排队已满为503、限流为429）
+func (h *RESTHandler) serviceError(c *gin.Context, stage pipeline.Stage, err error, message string) {
+	e := h.processor.stageError(stage, err)
+	c.JSON(errs.HTTPStatus(e.Code), gin.H{
+		"error":     message + ": " + err.Error(),
+		"code":      e.Code,
+		"retryable": e.Retryable,
+	})
+}
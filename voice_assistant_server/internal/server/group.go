@@ -0,0 +1,419 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"voice_assistant/pkg/protocol"
+)
+
+// joinCodeTTL 加入码有效期
+const joinCodeTTL = 10 * time.Minute
+
+// joinInvite 会话所有者发出的加入邀请
+type joinInvite struct {
+	role      string
+	expiresAt time.Time
+}
+
+// sessionGroup 多个客户端共享的会话（如展示屏加控制平板）
+// 成员按连接区分，音频的识别结果、回复和服务端推送的通知通过广播视图发给全部成员，命令的应答只发给发出命令的成员；
+// 只有speaker角色的成员可以发送音频。
+type sessionGroup struct {
+	mu      sync.Mutex
+	members map[string]*groupMember // 按连接ID
+	warned  map[string]bool         // 已提示过音频被拒绝的连接
+}
+
+// groupMember 会话成员
+type groupMember struct {
+	view      *Client // 成员连接上的会话视图
+	broadcast *Client // 成员发来消息时使用的广播视图
+	role      string
+	joinedAt  time.Time
+}
+
+// newSessionGroup 创建会话成员组
+func newSessionGroup() *sessionGroup {
+	return &sessionGroup{
+		members: make(map[string]*groupMember),
+		warned:  make(map[string]bool),
+	}
+}
+
+// join 加入或更新成员角色，返回成员的广播视图
+func (g *sessionGroup) join(view *Client, role string) *Client {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	id := view.Connection().ID
+	delete(g.warned, id)
+	if member, exists := g.members[id]; exists {
+		member.view = view
+		member.role = role
+		return member.broadcast
+	}
+	member := &groupMember{
+		view:     view,
+		role:     role,
+		joinedAt: time.Now(),
+		broadcast: &Client{
+			ID:       view.ID,
+			Conn:     view.Conn,
+			SendChan: view.SendChan,
+			Server:   view.Server,
+			APIKey:   view.APIKey,
+			conn:     view.Connection(),
+			group:    g,
+		},
+	}
+	g.members[id] = member
+	return member.broadcast
+}
+
+// leave 移除成员，返回是否为成员
+func (g *sessionGroup) leave(view *Client) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	id := view.Connection().ID
+	_, exists := g.members[id]
+	delete(g.members, id)
+	delete(g.warned, id)
+	return exists
+}
+
+// member 发来消息的连接对应的成员（不是成员时返回nil）
+func (g *sessionGroup) member(view *Client) *groupMember {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.members[view.Connection().ID]
+}
+
+// size 成员数
+func (g *sessionGroup) size() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return len(g.members)
+}
+
+// any 任一成员的广播视图（没有成员时返回nil）
+func (g *sessionGroup) any() *Client {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var first *groupMember
+	for _, member := range g.members {
+		if first == nil || member.joinedAt.Before(first.joinedAt) {
+			first = member
+		}
+	}
+	if first == nil {
+		return nil
+	}
+	return first.broadcast
+}
+
+// list 成员列表（按加入时间排序）
+func (g *sessionGroup) list() []protocol.Member {
+	g.mu.Lock()
+	members := make([]*groupMember, 0, len(g.members))
+	for _, member := range g.members {
+		members = append(members, member)
+	}
+	g.mu.Unlock()
+
+	sort.Slice(members, func(i, j int) bool { return members[i].joinedAt.Before(members[j].joinedAt) })
+	list := make([]protocol.Member, 0, len(members))
+	for _, member := range members {
+		list = append(list, protocol.Member{ConnectionID: member.view.Connection().ID, Role: member.role})
+	}
+	return list
+}
+
+// warnOnce 连接第一次发送不被接受的音频时返回true（每个连接只提示一次，重新加入后重置）
+func (g *sessionGroup) warnOnce(view *Client) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	id := view.Connection().ID
+	if g.warned[id] {
+		return false
+	}
+	g.warned[id] = true
+	return true
+}
+
+// send 把消息发给全部在线成员，已断开的成员移出会话
+func (g *sessionGroup) send(msg *protocol.Message) error {
+	g.mu.Lock()
+	views := make(map[string]*Client, len(g.members))
+	for id, member := range g.members {
+		views[id] = member.view
+	}
+	g.mu.Unlock()
+
+	var lastErr error
+	delivered := 0
+	for id, view := range views {
+		if !memberConnected(view) {
+			g.mu.Lock()
+			if member, exists := g.members[id]; exists && member.view == view {
+				delete(g.members, id)
+				log.Printf("会话成员已断开，移出会话: %s, %s", view.ID, id)
+			}
+			g.mu.Unlock()
+			continue
+		}
+		if err := view.SendMessage(msg); err != nil {
+			lastErr = err
+			continue
+		}
+		delivered++
+	}
+	if delivered == 0 && lastErr != nil {
+		return lastErr
+	}
+	return nil
+}
+
+// memberConnected 成员连接是否仍可接收消息
+// 其他传输（gRPC、WebRTC）的连接状态由传输层维护，断开时释放会话。
+func memberConnected(view *Client) bool {
+	conn := view.Connection()
+	if conn.Conn == nil && conn.resume == nil {
+		return true
+	}
+	return connected(view)
+}
+
+// joinSession 当前连接加入会话
+// 会话第一次有其他客户端加入时，会话所有者的连接作为speaker成员加入，保持原有的发言权。
+func (p *MessageProcessor) joinSession(client *Client, session *Session, role string) (*Client, error) {
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	group := session.group
+	if group == nil {
+		group = newSessionGroup()
+		if server := client.Connection().Server; server != nil {
+			for _, view := range server.sessionViews(session.ID) {
+				if view.Connection() != client.Connection() && view.Connection().ID == session.owner {
+					group.join(view, protocol.RoleSpeaker)
+				}
+			}
+		}
+	}
+	if max := p.config.MaxMembersPerSession; max > 0 && group.member(client) == nil && group.size() >= max {
+		return nil, fmt.Errorf("会话成员数已达上限: %d", max)
+	}
+
+	broadcast := group.join(client, role)
+	session.group = group
+	session.client = broadcast
+	return broadcast, nil
+}
+
+// leaveSession 当前连接退出会话，返回剩余成员的广播视图（没有剩余成员时返回nil）
+func (p *MessageProcessor) leaveSession(client *Client, session *Session) (*Client, bool) {
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	group := session.group
+	if group == nil || !group.leave(client) {
+		return nil, false
+	}
+
+	remaining := group.any()
+	if remaining == nil {
+		session.group = nil
+	}
+	if session.client != nil && session.client.Connection() == client.Connection() {
+		session.client = remaining
+	}
+	return remaining, true
+}
+
+// memberRole 命令参数中的成员角色（默认 listener）
+func memberRole(cmdData protocol.CommandData) (string, error) {
+	role, _ := cmdData.Parameters["role"].(string)
+	if role == "" {
+		role = protocol.RoleListener
+	}
+	if role != protocol.RoleSpeaker && role != protocol.RoleListener {
+		return "", fmt.Errorf("不支持的成员角色: %s", role)
+	}
+	return role, nil
+}
+
+// handleInviteMember 处理邀请其他客户端加入会话（参数 role：加入后的角色，默认 listener）
+// 只有会话所有者可以邀请，返回带一次有效加入码的状态消息。
+func (p *MessageProcessor) handleInviteMember(client *Client, session *Session, cmdData protocol.CommandData) error {
+	role, err := memberRole(cmdData)
+	if err != nil {
+		return p.sendError(client, "INVALID_COMMAND_DATA", err.Error(), true)
+	}
+
+	session.mu.Lock()
+	if session.owner != client.Connection().ID {
+		session.mu.Unlock()
+		return p.sendError(client, "NOT_SESSION_OWNER", "只有会话所有者可以邀请其他客户端加入", true)
+	}
+	now := time.Now()
+	for code, invite := range session.invites {
+		if now.After(invite.expiresAt) {
+			delete(session.invites, code)
+		}
+	}
+	if session.invites == nil {
+		session.invites = make(map[string]joinInvite)
+	}
+	code := newHandoverToken()
+	expiresAt := now.Add(joinCodeTTL)
+	session.invites[code] = joinInvite{role: role, expiresAt: expiresAt}
+	session.mu.Unlock()
+	log.Printf("已生成会话加入码: %s, 角色: %s", session.ID, role)
+
+	status := p.buildStatusData(session)
+	status.Invite = &protocol.Invite{Code: code, Role: role, ExpiresAt: expiresAt.UnixMilli()}
+	return client.SendMessage(protocol.NewMessage(protocol.Status, client.ID, status))
+}
+
+// admitMember 确定加入会话的连接的角色：所有者和已有成员不需要加入码，其他连接必须出示所有者发出的有效加入码（使用后作废）
+// 请求的角色不能高于邀请或原有的角色，只能降为 listener。
+func (p *MessageProcessor) admitMember(client *Client, session *Session, code, requested string) (string, bool) {
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	granted := ""
+	switch {
+	case client.Connection().ID == session.owner:
+		granted = protocol.RoleSpeaker
+	case session.group != nil && session.group.member(client) != nil:
+		granted = session.group.member(client).role
+	default:
+		invite, exists := session.invites[code]
+		if !exists || time.Now().After(invite.expiresAt) {
+			return "", false
+		}
+		delete(session.invites, code)
+		granted = invite.role
+	}
+	if requested == protocol.RoleListener {
+		return protocol.RoleListener, true
+	}
+	return granted, true
+}
+
+// handleJoinSession 处理加入会话（参数 code：所有者发出的加入码；role：listener 或不高于邀请的角色，默认按邀请）
+func (p *MessageProcessor) handleJoinSession(client *Client, session *Session, cmdData protocol.CommandData) error {
+	requested, _ := cmdData.Parameters["role"].(string)
+	if requested != "" && requested != protocol.RoleSpeaker && requested != protocol.RoleListener {
+		return p.sendError(client, "INVALID_COMMAND_DATA", fmt.Sprintf("不支持的成员角色: %s", requested), true)
+	}
+	code, _ := cmdData.Parameters["code"].(string)
+	role, ok := p.admitMember(client, session, strings.ToUpper(strings.TrimSpace(code)), requested)
+	if !ok {
+		log.Printf("会话加入码无效: %s, 连接: %s", session.ID, client.Connection().ID)
+		return p.sendError(client, "JOIN_DENIED", "加入码无效或已过期，请向会话所有者获取加入码", true)
+	}
+
+	broadcast, err := p.joinSession(client, session, role)
+	if err != nil {
+		return p.sendError(client, "SESSION_MEMBER_LIMIT", err.Error(), true)
+	}
+	log.Printf("客户端加入会话: %s, 连接: %s, 角色: %s", session.ID, client.Connection().ID, role)
+
+	// 成员变化通知全部成员
+	return p.sendStatus(broadcast, session)
+}
+
+// handleLeaveSession 处理退出会话
+func (p *MessageProcessor) handleLeaveSession(client *Client, session *Session, cmdData protocol.CommandData) error {
+	remaining, ok := p.leaveSession(client, session)
+	if !ok {
+		return p.sendError(client, "NOT_SESSION_MEMBER", "当前连接未加入该会话", true)
+	}
+	log.Printf("客户端退出会话: %s, 连接: %s", session.ID, client.Connection().ID)
+
+	// 释放连接上的会话名额
+	client.ReleaseSession(session.ID)
+	if remaining != nil {
+		p.sendStatus(remaining, session)
+	}
+	return p.sendStatus(client, session)
+}
+
+// attachSender 记录发来消息的会话视图，返回处理音频时使用的视图（第一条消息所在的连接成为会话所有者）
+// 其他连接只能发送 join_session 加入会话，加入前的其他消息被拒绝（ok为false），防止按会话ID操作他人的会话。
+// 有其他客户端加入的会话中，成员的音频回复通过广播视图发给全部成员；listener成员的音频被拒绝（只提示一次）。
+func (p *MessageProcessor) attachSender(client *Client, session *Session, msg *protocol.Message) (*Client, bool) {
+	session.mu.Lock()
+	if session.owner == "" {
+		session.owner = client.Connection().ID
+	}
+	owner := session.owner == client.Connection().ID
+	group := session.group
+	var member *groupMember
+	if group != nil {
+		member = group.member(client)
+	}
+	switch {
+	case member != nil:
+		session.client = member.broadcast
+	case owner && group == nil:
+		session.client = client
+	}
+	session.mu.Unlock()
+
+	if !owner && member == nil && !isJoinCommand(msg) {
+		p.sendError(client, "SESSION_NOT_OWNED", "会话属于其他连接，需要先用加入码加入（join_session）", true)
+		return nil, false
+	}
+	if group == nil {
+		return client, true
+	}
+	if msg.Type == protocol.AudioStream && (member == nil || member.role != protocol.RoleSpeaker) {
+		if group.warnOnce(client) {
+			p.sendError(client, "AUDIO_NOT_ALLOWED", "只有speaker角色的会话成员可以发送音频", true)
+		}
+		return nil, false
+	}
+	if member == nil {
+		return client, true
+	}
+	return member.broadcast, true
+}
+
+// isJoinCommand 消息是否为加入会话命令
+func isJoinCommand(msg *protocol.Message) bool {
+	if msg.Type != protocol.Command {
+		return false
+	}
+	cmdData, err := msg.CommandData()
+	return err == nil && cmdData.Command == protocol.CmdJoinSession
+}
+
+// sessionViews 打开了指定会话的全部WebSocket连接上的会话视图
+func (s *WebSocketServer) sessionViews(sessionID string) []*Client {
+	s.mu.RLock()
+	conns := make([]*Client, 0, len(s.clients))
+	for _, conn := range s.clients {
+		conns = append(conns, conn)
+	}
+	s.mu.RUnlock()
+
+	var views []*Client
+	for _, conn := range conns {
+		conn.mu.Lock()
+		if view, exists := conn.sessions[sessionID]; exists {
+			views = append(views, view)
+		}
+		conn.mu.Unlock()
+	}
+	return views
+}
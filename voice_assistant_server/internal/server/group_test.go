@@ -0,0 +1,93 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"voice_assistant/pkg/protocol"
+)
+
+func TestSessionGroup(t *testing.T) {
+	s := NewWebSocketServer(WebSocketConfig{})
+	p := NewMessageProcessor(ProcessorConfig{MaxConcurrentSessions: 10, MaxMembersPerSession: 2})
+
+	// 展示屏开始会话，控制平板作为listener加入
+	kiosk := &Client{ID: "kiosk", SendChan: make(chan *protocol.Message, 10), Server: s}
+	tablet := &Client{ID: "tablet", SendChan: make(chan *protocol.Message, 10), Server: s}
+	s.clients[kiosk.ID] = kiosk
+	s.clients[tablet.ID] = tablet
+	kioskView, err := kiosk.Session("kiosk", 0)
+	require.NoError(t, err)
+	tabletView, err := tablet.Session("kiosk", 0)
+	require.NoError(t, err)
+	session := p.getOrCreateSession("kiosk", "")
+	_, ok := p.attachSender(kioskView, session, protocol.NewCommandMessage("kiosk", protocol.CmdGetStatus, "", nil))
+	require.True(t, ok)
+
+	// 未加入的连接凭会话ID只能发送加入命令
+	_, ok = p.attachSender(tabletView, session, protocol.NewCommandMessage("kiosk", protocol.CmdGetStatus, "", nil))
+	assert.False(t, ok)
+	assert.Equal(t, "SESSION_NOT_OWNED", (<-tablet.SendChan).Payload.(*protocol.ErrorData).Code)
+
+	// 没有加入码或加入码无效时拒绝，平板也不能自己生成加入码
+	join := protocol.CommandData{Command: protocol.CmdJoinSession, Parameters: map[string]interface{}{"role": protocol.RoleSpeaker}}
+	require.NoError(t, p.handleJoinSession(tabletView, session, join))
+	assert.Equal(t, "JOIN_DENIED", (<-tablet.SendChan).Payload.(*protocol.ErrorData).Code)
+	invite := protocol.CommandData{Command: protocol.CmdInviteMember}
+	require.NoError(t, p.handleInviteMember(tabletView, session, invite))
+	assert.Equal(t, "NOT_SESSION_OWNER", (<-tablet.SendChan).Payload.(*protocol.ErrorData).Code)
+
+	// 展示屏邀请，默认角色为listener，请求speaker也只能以listener加入
+	require.NoError(t, p.handleInviteMember(kioskView, session, invite))
+	issued := (<-kiosk.SendChan).Payload.(*protocol.StatusData).Invite
+	require.NotNil(t, issued)
+	assert.Equal(t, protocol.RoleListener, issued.Role)
+	assert.Len(t, issued.Code, handoverTokenLength)
+	join.Parameters["code"] = issued.Code
+	require.NoError(t, p.handleJoinSession(tabletView, session, join))
+	for _, conn := range []*Client{kiosk, tablet} {
+		status := (<-conn.SendChan).Payload.(*protocol.StatusData)
+		assert.Equal(t, []protocol.Member{
+			{ConnectionID: "kiosk", Role: protocol.RoleSpeaker},
+			{ConnectionID: "tablet", Role: protocol.RoleListener},
+		}, status.Members)
+	}
+
+	// listener不能发送音频，只提示一次
+	audio := protocol.NewAudioStreamMessage("kiosk", "pcm_16khz_16bit", 1, false, []byte{0, 0})
+	_, ok = p.attachSender(tabletView, session, audio)
+	assert.False(t, ok)
+	_, ok = p.attachSender(tabletView, session, audio)
+	assert.False(t, ok)
+	assert.Equal(t, "AUDIO_NOT_ALLOWED", (<-tablet.SendChan).Payload.(*protocol.ErrorData).Code)
+	assert.Empty(t, tablet.SendChan)
+
+	// speaker的回复发给全部成员
+	sender, ok := p.attachSender(kioskView, session, audio)
+	require.True(t, ok)
+	require.NoError(t, p.sendResponse(sender, protocol.StageLLM, "你好", 1, true, nil))
+	assert.Equal(t, "你好", (<-kiosk.SendChan).Payload.(*protocol.ResponseData).Content)
+	assert.Equal(t, "你好", (<-tablet.SendChan).Payload.(*protocol.ResponseData).Content)
+
+	// 加入码只能使用一次；所有者授予speaker时可以发言，成员数达到上限时拒绝
+	other := &Client{ID: "other", SendChan: make(chan *protocol.Message, 10)}
+	require.NoError(t, p.handleJoinSession(other, session, join))
+	assert.Equal(t, "JOIN_DENIED", (<-other.SendChan).Payload.(*protocol.ErrorData).Code)
+	require.NoError(t, p.handleInviteMember(kioskView, session, protocol.CommandData{
+		Command:    protocol.CmdInviteMember,
+		Parameters: map[string]interface{}{"role": protocol.RoleSpeaker},
+	}))
+	issued = (<-kiosk.SendChan).Payload.(*protocol.StatusData).Invite
+	assert.Equal(t, protocol.RoleSpeaker, issued.Role)
+	join.Parameters["code"] = issued.Code
+	require.NoError(t, p.handleJoinSession(other, session, join))
+	assert.Equal(t, "SESSION_MEMBER_LIMIT", (<-other.SendChan).Payload.(*protocol.ErrorData).Code)
+
+	// 退出后只剩展示屏
+	require.NoError(t, p.handleLeaveSession(tabletView, session, protocol.CommandData{Command: protocol.CmdLeaveSession}))
+	assert.Len(t, (<-kiosk.SendChan).Payload.(*protocol.StatusData).Members, 1)
+	assert.Len(t, (<-tablet.SendChan).Payload.(*protocol.StatusData).Members, 1)
+	assert.Empty(t, tablet.SessionIDs())
+}
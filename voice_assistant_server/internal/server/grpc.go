@@ -0,0 +1,340 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"voice_assistant/pkg/protocol"
+	"voice_assistant/pkg/vagrpc"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCConfig gRPC传输配置
+type GRPCConfig struct {
+	Enabled        bool          `yaml:"enabled"`
+	Host           string        `yaml:"host"`
+	Port           int           `yaml:"port"`
+	MaxConnections int           `yaml:"max_connections"`
+	TLS            GRPCTLSConfig `yaml:"tls"`
+}
+
+// GRPCTLSConfig gRPC传输层安全配置（未配置证书时以明文提供服务）
+type GRPCTLSConfig struct {
+	CertFile     string `yaml:"cert_file"`      // 服务端证书
+	KeyFile      string `yaml:"key_file"`       // 服务端私钥
+	ClientCAFile string `yaml:"client_ca_file"` // 配置后要求客户端出示由该CA签发的证书（双向TLS）
+}
+
+// Enabled 是否配置了证书
+func (c GRPCTLSConfig) Enabled() bool {
+	return c.CertFile != "" || c.KeyFile != ""
+}
+
+// credentials 根据配置创建TLS凭据
+func (c GRPCTLSConfig) credentials() (credentials.TransportCredentials, error) {
+	cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("加载gRPC证书失败: %w", err)
+	}
+	config := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+	if c.ClientCAFile != "" {
+		pem, err := os.ReadFile(c.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取gRPC客户端CA证书失败: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("gRPC客户端CA证书无效: %s", c.ClientCAFile)
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return credentials.NewTLS(config), nil
+}
+
+// GRPCServer gRPC传输：与WebSocket共用消息处理器，每个双向流对应一个客户端连接
+type GRPCServer struct {
+	vagrpc.UnimplementedVoiceAssistantServer
+
+	config    GRPCConfig
+	processor *MessageProcessor
+	server    *grpc.Server
+
+	streams int
+	mu      sync.Mutex
+}
+
+// NewGRPCServer 创建gRPC服务器（配置了证书时启用TLS）
+func NewGRPCServer(config GRPCConfig, processor *MessageProcessor) (*GRPCServer, error) {
+	var options []grpc.ServerOption
+	if config.TLS.Enabled() {
+		creds, err := config.TLS.credentials()
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, grpc.Creds(creds))
+	}
+
+	s := &GRPCServer{
+		config:    config,
+		processor: processor,
+		server:    grpc.NewServer(options...),
+	}
+	vagrpc.RegisterVoiceAssistantServer(s.server, s)
+	return s, nil
+}
+
+// ListenAndServe 监听配置的地址并提供服务
+func (s *GRPCServer) ListenAndServe() error {
+	addr := fmt.Sprintf("%s:%d", s.config.Host, s.config.Port)
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("gRPC监听失败: %w", err)
+	}
+
+	log.Printf("gRPC服务启动在 %s", addr)
+	return s.server.Serve(lis)
+}
+
+// Serve 在指定监听器上提供服务
+func (s *GRPCServer) Serve(lis net.Listener) error {
+	return s.server.Serve(lis)
+}
+
+// Stop 停止服务（等待进行中的流结束）
+func (s *GRPCServer) Stop() {
+	s.server.GracefulStop()
+}
+
+// Converse 双向流式会话
+func (s *GRPCServer) Converse(stream vagrpc.VoiceAssistant_ConverseServer) error {
+	if !s.acquireStream() {
+		return status.Error(codes.ResourceExhausted, "连接数已达上限")
+	}
+	defer s.releaseStream()
+
+	// 会话ID优先取元数据，未提供时取首条消息
+	var first *vagrpc.ClientMessage
+	sessionID := sessionIDFromMetadata(stream)
+	if sessionID == "" {
+		msg, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		first = msg
+		sessionID = msg.GetSessionId()
+	}
+	if sessionID == "" {
+		sessionID = "grpc_" + randomHex(16)
+	}
+
+	// 会话ID已被其他连接使用时拒绝，不能凭会话ID接入他人的会话；流结束时释放会话
+	apiKey := apiKeyFromMetadata(stream)
+	if !s.processor.createSession(sessionID, apiKey) {
+		log.Printf("拒绝gRPC连接，会话已存在: %s", sessionID)
+		return status.Errorf(codes.AlreadyExists, "会话已存在: %s", sessionID)
+	}
+	defer s.processor.ReleaseSession(sessionID)
+
+	client := &Client{
+		ID:       sessionID,
+		SendChan: make(chan *protocol.Message, 100),
+		APIKey:   apiKey,
+	}
+	log.Printf("gRPC客户端连接: %s", sessionID)
+	defer log.Printf("gRPC客户端断开: %s", sessionID)
+
+	// 发送连接确认
+	client.SendMessage(protocol.NewStatusMessage(sessionID, protocol.StateConnected, "idle", 0))
+
+	done := make(chan struct{})
+	sendErr := make(chan error, 1)
+	go func() {
+		sendErr <- s.sendLoop(stream, client, done)
+	}()
+
+	if err := s.recvLoop(stream, client, first); err != nil {
+		return err
+	}
+
+	// 客户端结束发送：等待进行中的处理完成，把剩余响应发送完再结束流
+	s.waitSessionIdle(stream, client)
+	close(done)
+	return <-sendErr
+}
+
+// recvLoop 接收客户端消息并交给处理器
+func (s *GRPCServer) recvLoop(stream vagrpc.VoiceAssistant_ConverseServer, client *Client, first *vagrpc.ClientMessage) error {
+	next := first
+	for {
+		if next == nil {
+			msg, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			next = msg
+		}
+		receivedAt := time.Now()
+
+		msg, err := vagrpc.FromClientMessage(next)
+		next = nil
+		if err != nil {
+			log.Printf("解析gRPC消息失败: %v", err)
+			continue
+		}
+		if msg.SessionID == "" {
+			msg.SessionID = client.ID
+		}
+
+		// 时钟同步在传输层直接应答，与WebSocket一致
+		if msg.Type == protocol.TimeSync {
+			syncData := msg.Payload.(*protocol.TimeSyncData)
+			syncData.ServerReceiveTime = receivedAt.UnixMilli()
+			client.SendMessage(protocol.NewMessage(protocol.TimeSync, client.ID, syncData))
+			continue
+		}
+
+		if err := s.processor.ProcessMessage(client, msg); err != nil {
+			log.Printf("处理消息失败: %v", err)
+			client.SendMessage(protocol.NewErrorMessage(client.ID, "PROCESSING_ERROR", err.Error(), true))
+		}
+	}
+}
+
+// sendLoop 将处理器输出的消息写入流
+func (s *GRPCServer) sendLoop(stream vagrpc.VoiceAssistant_ConverseServer, client *Client, done <-chan struct{}) error {
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-done:
+			// 发送队列中剩余的消息
+			for {
+				select {
+				case msg := <-client.SendChan:
+					if err := s.send(stream, msg); err != nil {
+						return err
+					}
+				default:
+					return nil
+				}
+			}
+		case msg := <-client.SendChan:
+			if err := s.send(stream, msg); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// send 写出单条消息
+func (s *GRPCServer) send(stream vagrpc.VoiceAssistant_ConverseServer, msg *protocol.Message) error {
+	// 时钟同步应答在实际写出前记录发送时间
+	if syncData, ok := msg.Payload.(*protocol.TimeSyncData); ok {
+		syncData.ServerSendTime = time.Now().UnixMilli()
+	}
+
+	out, err := vagrpc.ToServerMessage(msg)
+	if err != nil {
+		log.Printf("转换gRPC消息失败: %v", err)
+		return nil
+	}
+	if err := stream.Send(out); err != nil {
+		log.Printf("发送gRPC消息失败: %v", err)
+		return err
+	}
+	return nil
+}
+
+// waitSessionIdle 等待连接上各会话的进行中处理结束（流上下文取消时提前返回）
+// 音频处理在独立协程中启动，先等待一个检查周期再判断，避免最后一块音频刚到达时误判为空闲。
+func (s *GRPCServer) waitSessionIdle(stream grpc.ServerStream, client *Client) {
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return
+		case <-ticker.C:
+		}
+
+		busy := s.processor.IsSessionProcessing(client.ID)
+		for _, sessionID := range client.SessionIDs() {
+			busy = busy || s.processor.IsSessionProcessing(sessionID)
+		}
+		if !busy {
+			return
+		}
+	}
+}
+
+// acquireStream 占用一个连接名额
+func (s *GRPCServer) acquireStream() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.config.MaxConnections > 0 && s.streams >= s.config.MaxConnections {
+		return false
+	}
+	s.streams++
+	return true
+}
+
+// releaseStream 释放连接名额
+func (s *GRPCServer) releaseStream() {
+	s.mu.Lock()
+	s.streams--
+	s.mu.Unlock()
+}
+
+// GetStreamCount 获取当前活跃的流数量
+func (s *GRPCServer) GetStreamCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.streams
+}
+
+// apiKeyFromMetadata 从请求元数据（x-api-key 或 authorization: Bearer）读取API Key
+func apiKeyFromMetadata(stream grpc.ServerStream) string {
+	md, ok := metadata.FromIncomingContext(stream.Context())
+	if !ok {
+		return ""
+	}
+	if values := md.Get("x-api-key"); len(values) > 0 {
+		return values[0]
+	}
+	if values := md.Get("authorization"); len(values) > 0 && strings.HasPrefix(values[0], "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(values[0], "Bearer "))
+	}
+	return ""
+}
+
+// sessionIDFromMetadata 从请求元数据读取会话ID
+func sessionIDFromMetadata(stream grpc.ServerStream) string {
+	md, ok := metadata.FromIncomingContext(stream.Context())
+	if !ok {
+		return ""
+	}
+	if values := md.Get("session-id"); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"voice_assistant/pkg/vagrpc"
+)
+
+func TestGRPCConverse(t *testing.T) {
+	lis := bufconn.Listen(1 << 20)
+	grpcServer, err := NewGRPCServer(GRPCConfig{MaxConnections: 2}, NewMessageProcessor(ProcessorConfig{MaxConcurrentSessions: 10}))
+	require.NoError(t, err)
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, "session-id", "grpc_test")
+
+	stream, err := vagrpc.NewVoiceAssistantClient(conn).Converse(ctx)
+	require.NoError(t, err)
+
+	// 连接确认
+	msg, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, "grpc_test", msg.GetSessionId())
+	assert.Equal(t, "connected", msg.GetStatus().GetState())
+
+	// 时钟同步由传输层应答
+	require.NoError(t, stream.Send(&vagrpc.ClientMessage{
+		Payload: &vagrpc.ClientMessage_TimeSync{TimeSync: &vagrpc.TimeSync{ClientSendTime: 1000}},
+	}))
+	msg, err = stream.Recv()
+	require.NoError(t, err)
+	syncData := msg.GetTimeSync()
+	require.NotNil(t, syncData)
+	assert.Equal(t, int64(1000), syncData.GetClientSendTime())
+	assert.Greater(t, syncData.GetServerReceiveTime(), int64(0))
+	assert.GreaterOrEqual(t, syncData.GetServerSendTime(), syncData.GetServerReceiveTime())
+
+	// 其他消息交给处理器（未初始化时返回错误）
+	require.NoError(t, stream.Send(&vagrpc.ClientMessage{
+		Payload: &vagrpc.ClientMessage_Command{Command: &vagrpc.Command{Command: "get_status"}},
+	}))
+	msg, err = stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, "PROCESSOR_NOT_INITIALIZED", msg.GetError().GetCode())
+
+	// 会话ID已被使用时拒绝另一条流接入
+	other, err := vagrpc.NewVoiceAssistantClient(conn).Converse(ctx)
+	require.NoError(t, err)
+	_, err = other.Recv()
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	// 客户端结束发送后服务端关闭流
+	require.NoError(t, stream.CloseSend())
+	_, err = stream.Recv()
+	assert.ErrorIs(t, err, io.EOF)
+}
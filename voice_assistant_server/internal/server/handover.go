@@ -0,0 +1,239 @@
+package server
+
+import (
+	"crypto/rand"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"voice_assistant/pkg/protocol"
+	"voice_assistant/voice_assistant_server/internal/llm"
+)
+
+// 会话转移默认值
+const (
+	defaultHandoverTokenTTL   = 2 * time.Minute
+	defaultHandoverMaxHistory = 50
+)
+
+// handoverAlphabet 转移令牌字符集（去掉容易混淆的 0/O、1/I）
+const handoverAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// handoverTokenLength 转移令牌长度
+const handoverTokenLength = 10
+
+// HandoverConfig 会话转移配置
+type HandoverConfig struct {
+	Enabled    bool          `yaml:"enabled"`
+	TokenTTL   time.Duration `yaml:"token_ttl"`   // 转移令牌有效期
+	MaxHistory int           `yaml:"max_history"` // 接管时返回给新设备的历史消息数上限
+}
+
+// handoverTicket 待接管的会话
+type handoverTicket struct {
+	source    *Session
+	expiresAt time.Time
+}
+
+// handoverStore 会话转移令牌（一次有效，过期作废）
+type handoverStore struct {
+	config  HandoverConfig
+	mu      sync.Mutex
+	tickets map[string]*handoverTicket
+}
+
+// newHandoverStore 创建会话转移令牌存储
+func newHandoverStore(config HandoverConfig) *handoverStore {
+	if config.TokenTTL <= 0 {
+		config.TokenTTL = defaultHandoverTokenTTL
+	}
+	if config.MaxHistory <= 0 {
+		config.MaxHistory = defaultHandoverMaxHistory
+	}
+	return &handoverStore{config: config, tickets: make(map[string]*handoverTicket)}
+}
+
+// issue 为会话生成转移令牌（会话之前生成的令牌作废）
+func (s *handoverStore) issue(source *Session) (string, time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for token, ticket := range s.tickets {
+		if ticket.source == source || now.After(ticket.expiresAt) {
+			delete(s.tickets, token)
+		}
+	}
+
+	token := newHandoverToken()
+	expiresAt := now.Add(s.config.TokenTTL)
+	s.tickets[token] = &handoverTicket{source: source, expiresAt: expiresAt}
+	return token, expiresAt
+}
+
+// claim 使用转移令牌（无论成功与否令牌都作废），令牌无效或已过期时返回nil
+func (s *handoverStore) claim(token string) *Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ticket, exists := s.tickets[token]
+	if !exists {
+		return nil
+	}
+	delete(s.tickets, token)
+	if time.Now().After(ticket.expiresAt) {
+		return nil
+	}
+	return ticket.source
+}
+
+// handleHandover 处理会话转移：生成一次有效的转移令牌，新设备凭令牌接管对话
+func (p *MessageProcessor) handleHandover(client *Client, session *Session, cmdData protocol.CommandData) error {
+	if p.handovers == nil {
+		return p.sendError(client, "HANDOVER_DISABLED", "未启用会话转移", true)
+	}
+
+	token, expiresAt := p.handovers.issue(session)
+	log.Printf("已生成会话转移令牌: %s, 有效期至 %s", session.ID, expiresAt.Format("15:04:05"))
+
+	status := p.buildStatusData(session)
+	status.Handover = &protocol.Handover{Token: token, ExpiresAt: expiresAt.UnixMilli()}
+	return client.SendMessage(protocol.NewMessage(protocol.Status, client.ID, status))
+}
+
+// handleClaimHandover 处理接管对话（参数 token，可选 text_only、report_playback 按新设备覆盖）
+// 新会话沿用原会话的对话上下文、模式、语言、声音、人设和用户信息，原会话停止并改用新的对话ID。
+func (p *MessageProcessor) handleClaimHandover(client *Client, session *Session, cmdData protocol.CommandData) error {
+	if p.handovers == nil {
+		return p.sendError(client, "HANDOVER_DISABLED", "未启用会话转移", true)
+	}
+	token, _ := cmdData.Parameters["token"].(string)
+	token = strings.ToUpper(strings.TrimSpace(token))
+	if token == "" {
+		return p.sendError(client, "INVALID_COMMAND_DATA", "缺少 token 参数", true)
+	}
+
+	source := p.handovers.claim(token)
+	if source == session {
+		return p.sendError(client, "HANDOVER_INVALID", "不能接管本会话", true)
+	}
+	if source == nil || !p.handoverAllowed(source, session) {
+		log.Printf("会话转移令牌无效: %s", session.ID)
+		return p.sendError(client, "HANDOVER_INVALID", "转移令牌无效或已过期", true)
+	}
+
+	conversationID := p.transferSession(source, session)
+	session.mu.Lock()
+	applyTextOnlyParameter(session, cmdData.Parameters)
+	applyPlaybackParameter(session, cmdData.Parameters)
+	session.mu.Unlock()
+	log.Printf("对话已转移: %s -> %s", source.ID, session.ID)
+
+	// 通知原设备对话已被接管
+	source.mu.RLock()
+	sourceClient := source.client
+	source.mu.RUnlock()
+	if sourceClient != nil {
+		status := p.buildStatusData(source)
+		status.Handover = &protocol.Handover{Transferred: true}
+		sourceClient.SendMessage(protocol.NewMessage(protocol.Status, sourceClient.ID, status))
+	}
+
+	status := p.buildStatusData(session)
+	status.Handover = &protocol.Handover{From: source.ID}
+	status.History = p.conversationHistory(conversationID)
+	return client.SendMessage(protocol.NewMessage(protocol.Status, client.ID, status))
+}
+
+// handoverAllowed 原会话携带API Key时，只允许同一API Key的连接接管
+func (p *MessageProcessor) handoverAllowed(source, target *Session) bool {
+	source.mu.RLock()
+	apiKey := source.APIKey
+	source.mu.RUnlock()
+
+	target.mu.RLock()
+	defer target.mu.RUnlock()
+	return apiKey == "" || apiKey == target.APIKey
+}
+
+// transferSession 把原会话的对话和设置移交给新会话，原会话停止，返回移交的对话ID
+func (p *MessageProcessor) transferSession(source, target *Session) string {
+	source.mu.Lock()
+	conversationID := source.ConversationID
+	mode := source.modeLocked()
+	language, textOnly, voice, persona := source.Language, source.TextOnly, source.Voice, source.Persona
+	tenant, userID, priority, consent := source.Tenant, source.UserID, source.Priority, source.DataConsent
+	silence, turns := source.EndpointSilence, source.Turns
+	lastUserInput, lastTurnAt := source.lastUserInput, source.lastTurnAt
+
+	// 原会话停止，之后重新开始时使用新的对话
+	source.State = StateIdle
+	source.ContinuousMode = false
+	source.Duplex = false
+	source.Mode = ""
+	source.AudioBuffer.Reset()
+	source.ConversationID = newConversationID(source.ID)
+	source.lastUserInput = ""
+	source.mu.Unlock()
+
+	target.mu.Lock()
+	defer target.mu.Unlock()
+
+	target.ConversationID = conversationID
+	applySessionMode(target, mode)
+	target.Language = language
+	target.TextOnly = textOnly
+	target.Voice = voice
+	target.Persona = persona
+	target.Tenant = tenant
+	target.UserID = userID
+	target.Priority = priority
+	target.DataConsent = consent
+	target.EndpointSilence = silence
+	target.Turns = turns
+	target.lastUserInput = lastUserInput
+	target.lastTurnAt = lastTurnAt
+	target.ended = false
+	target.audioRejected = false
+	target.State = StateListening
+	if mode == protocol.ModeWakeword {
+		target.State = StateIdle
+	}
+	target.LastActivity = time.Now()
+	return conversationID
+}
+
+// conversationHistory 对话历史（LLM服务不支持导出时返回nil）
+func (p *MessageProcessor) conversationHistory(conversationID string) []protocol.Turn {
+	provider, ok := p.llmService.(llm.ConversationHistoryProvider)
+	if !ok {
+		return nil
+	}
+	messages := provider.ConversationHistory(conversationID)
+	if max := p.handovers.config.MaxHistory; len(messages) > max {
+		messages = messages[len(messages)-max:]
+	}
+
+	history := make([]protocol.Turn, 0, len(messages))
+	for _, msg := range messages {
+		history = append(history, protocol.Turn{Role: msg.Role, Content: msg.Content, Timestamp: msg.Timestamp})
+	}
+	return history
+}
+
+// newConversationID 为会话生成新的对话ID
+func newConversationID(sessionID string) string {
+	return fmt.Sprintf("conv_%s_%d", sessionID, time.Now().UnixNano())
+}
+
+// newHandoverToken 随机转移令牌
+func newHandoverToken() string {
+	b := make([]byte, handoverTokenLength)
+	rand.Read(b)
+	for i := range b {
+		b[i] = handoverAlphabet[int(b[i])%len(handoverAlphabet)]
+	}
+	return string(b)
+}
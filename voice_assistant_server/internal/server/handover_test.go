@@ -0,0 +1,66 @@
+package server
+
+import (
+	"testing"
+
+	"voice_assistant/pkg/protocol"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandover(t *testing.T) {
+	p := NewMessageProcessor(ProcessorConfig{MaxConcurrentSessions: 10, Handover: HandoverConfig{Enabled: true}})
+
+	phone := &Client{ID: "phone", SendChan: make(chan *protocol.Message, 10)}
+	source := p.getOrCreateSession("phone", "")
+	source.client = phone
+	source.Mode = protocol.ModeContinuous
+	source.ContinuousMode = true
+	source.Language = "en"
+	source.UserID = "alice"
+	source.Turns = 3
+	conversationID := source.ConversationID
+
+	require.NoError(t, p.handleHandover(phone, source, protocol.CommandData{Command: protocol.CmdHandover}))
+	handover := (<-phone.SendChan).Payload.(*protocol.StatusData).Handover
+	require.NotNil(t, handover)
+	assert.Len(t, handover.Token, handoverTokenLength)
+
+	// 新设备凭令牌接管，沿用对话和设置
+	speaker := &Client{ID: "speaker", SendChan: make(chan *protocol.Message, 10)}
+	target := p.getOrCreateSession("speaker", "")
+	target.client = speaker
+	claim := protocol.CommandData{Command: protocol.CmdClaimHandover, Parameters: map[string]interface{}{"token": handover.Token}}
+	require.NoError(t, p.handleClaimHandover(speaker, target, claim))
+
+	status := (<-speaker.SendChan).Payload.(*protocol.StatusData)
+	assert.Equal(t, "phone", status.Handover.From)
+	assert.Equal(t, protocol.ModeContinuous, status.Mode)
+	assert.Equal(t, conversationID, target.ConversationID)
+	assert.Equal(t, "en", target.Language)
+	assert.Equal(t, "alice", target.UserID)
+	assert.Equal(t, 3, target.Turns)
+
+	// 原设备收到通知，会话停止并改用新的对话
+	status = (<-phone.SendChan).Payload.(*protocol.StatusData)
+	assert.True(t, status.Handover.Transferred)
+	assert.Equal(t, string(StateIdle), status.State)
+	assert.NotEqual(t, conversationID, source.ConversationID)
+
+	// 令牌只能使用一次
+	require.NoError(t, p.handleClaimHandover(speaker, target, claim))
+	assert.Equal(t, "HANDOVER_INVALID", (<-speaker.SendChan).Payload.(*protocol.ErrorData).Code)
+}
+
+func TestHandoverRequiresSameAPIKey(t *testing.T) {
+	p := NewMessageProcessor(ProcessorConfig{MaxConcurrentSessions: 10, Handover: HandoverConfig{Enabled: true}})
+	source := p.getOrCreateSession("phone", "key-a")
+	token, _ := p.handovers.issue(source)
+
+	other := &Client{ID: "other", SendChan: make(chan *protocol.Message, 10)}
+	target := p.getOrCreateSession("other", "key-b")
+	claim := protocol.CommandData{Command: protocol.CmdClaimHandover, Parameters: map[string]interface{}{"token": token}}
+	require.NoError(t, p.handleClaimHandover(other, target, claim))
+	assert.Equal(t, "HANDOVER_INVALID", (<-other.SendChan).Payload.(*protocol.ErrorData).Code)
+}
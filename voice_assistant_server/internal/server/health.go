@@ -0,0 +1,215 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"voice_assistant/voice_assistant_server/internal/asr"
+	"voice_assistant/voice_assistant_server/internal/errs"
+	"voice_assistant/voice_assistant_server/internal/llm"
+	"voice_assistant/voice_assistant_server/internal/pipeline"
+	"voice_assistant/voice_assistant_server/internal/tts"
+)
+
+// 后端探测参数
+const (
+	healthProbeTimeout = 5 * time.Second  // 单个组件的探测超时
+	healthCacheTTL     = 10 * time.Second // 探测结果缓存时长，避免频繁调用在线服务
+)
+
+// 组件状态
+const (
+	ComponentOK   = "ok"
+	ComponentDown = "down"
+)
+
+// ComponentHealth 单个后端组件（ASR、LLM、TTS）的状态
+type ComponentHealth struct {
+	Component   pipeline.Stage `json:"component"`
+	Provider    string         `json:"provider"`
+	Status      string         `json:"status"`               // ok|down
+	Probed      bool           `json:"probed"`               // 提供方是否支持主动探测（不支持时只检查是否已初始化）
+	LatencyMs   int64          `json:"latency_ms"`           // 探测耗时
+	Error       string         `json:"error,omitempty"`      // 探测失败原因
+	LastError   string         `json:"last_error,omitempty"` // 最近一次调用失败的原因
+	LastErrorAt *time.Time     `json:"last_error_at,omitempty"`
+
+	LastErrorCode string           `json:"last_error_code,omitempty"` // 最近一次调用失败的错误代码
+	ErrorCounts   map[string]int64 `json:"error_counts,omitempty"`    // 启动以来按错误代码统计的调用失败次数（含超时和排队已满）
+}
+
+// HealthReport 后端探测结果（/healthz、/readyz返回）
+type HealthReport struct {
+	Status     string            `json:"status"` // ok|degraded
+	Ready      bool              `json:"ready"`
+	CheckedAt  time.Time         `json:"checked_at"`
+	Components []ComponentHealth `json:"components"`
+}
+
+// componentError 组件最近一次调用失败
+type componentError struct {
+	message string
+	code    string
+	at      time.Time
+}
+
+// healthMonitor 记录各组件最近的调用错误并缓存探测结果
+type healthMonitor struct {
+	mu         sync.Mutex
+	lastErrors map[pipeline.Stage]componentError
+	counts     map[pipeline.Stage]map[string]int64
+	report     HealthReport
+
+	probeMu sync.Mutex // 同一时间只进行一轮探测
+}
+
+// newHealthMonitor 创建健康状态记录
+func newHealthMonitor() *healthMonitor {
+	return &healthMonitor{
+		lastErrors: make(map[pipeline.Stage]componentError),
+		counts:     make(map[pipeline.Stage]map[string]int64),
+	}
+}
+
+// recordError 按错误代码统计组件调用失败并记录最近一次后端故障（取消不统计；超时和排队已满只计数，不是后端故障）
+func (h *healthMonitor) recordError(stage pipeline.Stage, err error) {
+	if h == nil || err == nil || errors.Is(err, context.Canceled) {
+		return
+	}
+	code := errs.Wrap(err, string(stage), "").Code
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.counts[stage] == nil {
+		h.counts[stage] = make(map[string]int64)
+	}
+	h.counts[stage][code]++
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, pipeline.ErrQueueFull) {
+		return
+	}
+	h.lastErrors[stage] = componentError{message: err.Error(), code: code, at: time.Now()}
+}
+
+// cached 未过期的探测结果
+func (h *healthMonitor) cached() (HealthReport, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.report.CheckedAt.IsZero() || time.Since(h.report.CheckedAt) > healthCacheTTL {
+		return HealthReport{}, false
+	}
+	return h.report, true
+}
+
+// store 缓存探测结果
+func (h *healthMonitor) store(report HealthReport) {
+	h.mu.Lock()
+	h.report = report
+	h.mu.Unlock()
+}
+
+// withLastErrors 附上各组件最近的调用错误
+func (h *healthMonitor) withLastErrors(report HealthReport) HealthReport {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	components := make([]ComponentHealth, len(report.Components))
+	copy(components, report.Components)
+	for i := range components {
+		if lastError, exists := h.lastErrors[components[i].Component]; exists {
+			at := lastError.at
+			components[i].LastError = lastError.message
+			components[i].LastErrorCode = lastError.code
+			components[i].LastErrorAt = &at
+		}
+		if counts := h.counts[components[i].Component]; len(counts) > 0 {
+			components[i].ErrorCounts = make(map[string]int64, len(counts))
+			for code, n := range counts {
+				components[i].ErrorCounts[code] = n
+			}
+		}
+	}
+	report.Components = components
+	return report
+}
+
+// CheckHealth 探测ASR、LLM、TTS后端（结果缓存一段时间），任一组件不可用时 Ready 为false
+func (p *MessageProcessor) CheckHealth(ctx context.Context) HealthReport {
+	if report, ok := p.health.cached(); ok {
+		return p.health.withLastErrors(report)
+	}
+
+	p.health.probeMu.Lock()
+	defer p.health.probeMu.Unlock()
+	// 等待期间其他请求可能已完成探测
+	if report, ok := p.health.cached(); ok {
+		return p.health.withLastErrors(report)
+	}
+
+	p.mu.RLock()
+	initialized := p.isInitialized
+	services := []struct {
+		stage    pipeline.Stage
+		provider string
+		present  bool
+		check    func(ctx context.Context) error // 提供方未实现主动探测时为nil
+	}{
+		{pipeline.StageASR, p.config.ASRConfig.Type, p.asrService != nil, nil},
+		{pipeline.StageLLM, p.config.LLMConfig.Type, p.llmService != nil, nil},
+		{pipeline.StageTTS, p.config.TTSConfig.Type, p.ttsService != nil, nil},
+	}
+	if checker, ok := p.asrService.(asr.HealthChecker); ok {
+		services[0].check = checker.CheckHealth
+	}
+	if checker, ok := p.llmService.(llm.HealthChecker); ok {
+		services[1].check = checker.CheckHealth
+	}
+	if checker, ok := p.ttsService.(tts.HealthChecker); ok {
+		services[2].check = checker.CheckHealth
+	}
+	p.mu.RUnlock()
+
+	components := make([]ComponentHealth, len(services))
+	var wg sync.WaitGroup
+	for i, service := range services {
+		components[i] = ComponentHealth{Component: service.stage, Provider: service.provider, Status: ComponentOK}
+		if !initialized || !service.present {
+			components[i].Status = ComponentDown
+			components[i].Error = "处理器未初始化"
+			continue
+		}
+		if service.check == nil {
+			continue
+		}
+		components[i].Probed = true
+		wg.Add(1)
+		go func(component *ComponentHealth, check func(ctx context.Context) error) {
+			defer wg.Done()
+			probeCtx, cancel := context.WithTimeout(ctx, healthProbeTimeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check(probeCtx)
+			component.LatencyMs = time.Since(start).Milliseconds()
+			if err != nil {
+				component.Status = ComponentDown
+				component.Error = err.Error()
+			}
+		}(&components[i], service.check)
+	}
+	wg.Wait()
+
+	report := HealthReport{Status: "ok", Ready: true, CheckedAt: time.Now(), Components: components}
+	for _, component := range components {
+		if component.Status != ComponentOK {
+			report.Status = "degraded"
+			report.Ready = false
+		}
+	}
+	// 请求被取消时探测结果不可信，不缓存
+	if ctx.Err() == nil {
+		p.health.store(report)
+	}
+	return p.health.withLastErrors(report)
+}
@@ -0,0 +1,42 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"voice_assistant/pkg/protocol"
+	"voice_assistant/voice_assistant_server/internal/pipeline"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckHealthNotInitialized(t *testing.T) {
+	p := &MessageProcessor{health: newHealthMonitor()}
+	p.health.recordError(pipeline.StageLLM, errors.New("连接被拒绝"))
+	p.health.recordError(pipeline.StageTTS, context.Canceled)
+	p.health.recordError(pipeline.StageTTS, pipeline.ErrQueueFull)
+
+	report := p.CheckHealth(context.Background())
+	assert.False(t, report.Ready)
+	assert.Equal(t, "degraded", report.Status)
+	require.Len(t, report.Components, 3)
+	for _, component := range report.Components {
+		assert.Equal(t, ComponentDown, component.Status)
+	}
+
+	// 只记录后端故障，取消和排队已满不计入
+	assert.Equal(t, "连接被拒绝", report.Components[1].LastError)
+	assert.NotNil(t, report.Components[1].LastErrorAt)
+	assert.Empty(t, report.Components[2].LastError)
+
+	// 按错误代码计数（排队已满计数，取消不计）
+	assert.Equal(t, protocol.ErrLLMFailed, report.Components[1].LastErrorCode)
+	assert.Equal(t, map[string]int64{protocol.ErrLLMFailed: 1}, report.Components[1].ErrorCounts)
+	assert.Equal(t, map[string]int64{protocol.ErrServerBusy: 1}, report.Components[2].ErrorCounts)
+
+	// 缓存的探测结果也带上最新的调用错误
+	p.health.recordError(pipeline.StageASR, errors.New("模型文件不存在"))
+	assert.Equal(t, "模型文件不存在", p.CheckHealth(context.Background()).Components[0].LastError)
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"context"
+
+	"voice_assistant/voice_assistant_server/internal/hooks"
+)
+
+// turnHooks 一轮对话中的钩子执行（各阶段共享Metadata，钩子可借此在阶段间传递数据）
+// 未启用钩子时为nil，run原样返回数据。
+type turnHooks struct {
+	chain    *hooks.Chain
+	session  *Session
+	metadata map[string]interface{}
+}
+
+// newTurnHooks 为本轮对话准备钩子执行（未启用时返回nil）
+func (p *MessageProcessor) newTurnHooks(session *Session) *turnHooks {
+	if p.hooks == nil || p.hooks.Len() == 0 {
+		return nil
+	}
+	return &turnHooks{chain: p.hooks, session: session}
+}
+
+// run 在指定位置执行钩子
+// 钩子返回的文本或音频为空时视为未修改（要丢弃本轮请设置 Abort）。
+func (t *turnHooks) run(ctx context.Context, stage hooks.Stage, payload hooks.Payload) hooks.Payload {
+	if t == nil {
+		return payload
+	}
+
+	t.session.mu.RLock()
+	payload.SessionID = t.session.ID
+	payload.UserID = t.session.UserID
+	payload.Language = t.session.Language
+	t.session.mu.RUnlock()
+	payload.Metadata = t.metadata
+
+	result := t.chain.Run(ctx, stage, payload)
+	if result.Text == "" {
+		result.Text = payload.Text
+	}
+	if len(result.Audio) == 0 {
+		result.Audio = payload.Audio
+	}
+	t.metadata = result.Metadata
+	return result
+}
+
+// abortForHook 钩子中止本轮：有回复时发送（语音会话同时播放）回复，之后结束本轮
+func (p *MessageProcessor) abortForHook(ctx context.Context, client *Client, session *Session, payload hooks.Payload, textOnly bool) {
+	if payload.Reply != "" {
+		session.mu.Lock()
+		session.State = StateResponding
+		session.mu.Unlock()
+
+		p.speakNotice(ctx, client, session, payload.Reply, map[string]interface{}{"hook": string(payload.Stage)}, textOnly)
+	}
+	p.finishTurn(client, session)
+}
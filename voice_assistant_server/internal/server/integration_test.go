@@ -151,7 +151,7 @@ func TestAudioStreamProcessing(t *testing.T) {
 	assert.Equal(t, protocol.Response, response.Type)
 
 	// 验证响应数据
-	responseData, err := protocol.ParseResponseData(response.Data)
+	responseData, err := response.ResponseData()
 	require.NoError(t, err)
 	assert.Equal(t, protocol.StageASR, responseData.Stage)
 	assert.Equal(t, "测试识别结果", responseData.Content)
@@ -184,7 +184,7 @@ func TestCommandProcessing(t *testing.T) {
 	assert.Equal(t, protocol.Status, response.Type)
 
 	// 验证状态数据
-	statusData, err := protocol.ParseStatusData(response.Data)
+	statusData, err := response.StatusData()
 	require.NoError(t, err)
 	assert.Equal(t, protocol.StateConnected, statusData.State)
 }
@@ -226,7 +226,7 @@ func TestErrorHandling(t *testing.T) {
 		Type:      "invalid_type",
 		SessionID: "test_session",
 		Timestamp: time.Now().UnixNano() / int64(time.Millisecond),
-		Data:      "invalid data",
+		Payload:   "invalid data",
 	}
 
 	err = conn.WriteJSON(invalidMsg)
@@ -0,0 +1,186 @@
+package server
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"voice_assistant/voice_assistant_server/internal/llm"
+	"voice_assistant/voice_assistant_server/internal/rag"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 知识库限制
+const (
+	knowledgeTimeout      = 3 * time.Second // 每轮检索超时（超时后不注入资料，不影响对话）
+	maxKnowledgeDocument  = 10 << 20        // 上传文档上限（字节）
+	knowledgeIndexTimeout = 10 * time.Minute
+)
+
+// initKnowledge 创建知识库检索并在后台索引配置的文档
+func (p *MessageProcessor) initKnowledge() error {
+	retriever, err := rag.NewRetriever(p.config.Knowledge)
+	if err != nil {
+		return err
+	}
+	p.knowledge = retriever
+
+	if len(p.config.Knowledge.Documents) > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), knowledgeIndexTimeout)
+		p.knowledgeCancel = cancel
+		go func() {
+			defer cancel()
+			documents, chunks, err := retriever.IndexPaths(ctx, p.config.Knowledge.Documents)
+			if err != nil {
+				log.Printf("索引知识库文档失败: %v", err)
+			}
+			log.Printf("知识库索引完成: 更新文档 %d 篇, 片段 %d 个", documents, chunks)
+		}()
+	}
+	return nil
+}
+
+// withKnowledge 检索与用户问题相关的知识库资料并附加到LLM请求
+func (p *MessageProcessor) withKnowledge(ctx context.Context, query string) context.Context {
+	if p.knowledge == nil {
+		return ctx
+	}
+
+	searchCtx, cancel := context.WithTimeout(ctx, knowledgeTimeout)
+	defer cancel()
+	prompt, err := p.knowledge.Prompt(searchCtx, query)
+	if err != nil {
+		log.Printf("知识库检索失败: %v", err)
+		return ctx
+	}
+	return withBackground(ctx, prompt)
+}
+
+// withBackground 把背景信息追加到LLM请求（与长期记忆、说话人等其他背景信息合并）
+func withBackground(ctx context.Context, background string) context.Context {
+	if background == "" {
+		return ctx
+	}
+
+	opts := llm.RequestOptionsFromContext(ctx)
+	if opts.Background != "" {
+		opts.Background += "\n\n" + background
+	} else {
+		opts.Background = background
+	}
+	return llm.WithRequestOptions(ctx, opts)
+}
+
+// KnowledgeDocumentRequest 文本形式上传的知识库文档
+type KnowledgeDocumentRequest struct {
+	Source string `json:"source"` // 文档名称（同名文档覆盖）
+	Text   string `json:"text"`   // 文档内容
+}
+
+// handleKnowledgeStats 知识库统计
+func (h *RESTHandler) handleKnowledgeStats(c *gin.Context) {
+	if !h.knowledgeReady(c) {
+		return
+	}
+
+	stats, err := h.processor.knowledge.Stats(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// handleKnowledgeUpload 上传文档（管理接口，multipart字段 file，或JSON {"source","text"}）并索引
+func (h *RESTHandler) handleKnowledgeUpload(c *gin.Context) {
+	if !h.knowledgeReady(c) {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxKnowledgeDocument)
+
+	var req KnowledgeDocumentRequest
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "缺少文档文件: " + err.Error()})
+			return
+		}
+		if !rag.SupportedDocument(fileHeader.Filename) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "仅支持 .txt 和 .md 文档"})
+			return
+		}
+
+		file, err := fileHeader.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		defer file.Close()
+
+		data, err := io.ReadAll(file)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		req.Source = c.DefaultPostForm("source", fileHeader.Filename)
+		req.Text = string(data)
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Source == "" || strings.TrimSpace(req.Text) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "source 和 text 不能为空"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), knowledgeIndexTimeout)
+	defer cancel()
+	chunks, err := h.processor.knowledge.IndexDocument(ctx, req.Source, req.Text)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "索引文档失败: " + err.Error()})
+		return
+	}
+
+	log.Printf("知识库文档已索引: %s, 片段 %d 个", req.Source, chunks)
+	c.JSON(http.StatusOK, gin.H{
+		"source": req.Source,
+		"chunks": chunks, // 0表示内容未变化
+	})
+}
+
+// handleKnowledgeDelete 删除文档（管理接口，查询参数 source）
+func (h *RESTHandler) handleKnowledgeDelete(c *gin.Context) {
+	if !h.knowledgeReady(c) {
+		return
+	}
+
+	source := c.Query("source")
+	if source == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "source 不能为空"})
+		return
+	}
+	if err := h.processor.knowledge.DeleteDocument(c.Request.Context(), source); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	log.Printf("知识库文档已删除: %s", source)
+	c.JSON(http.StatusOK, gin.H{"source": source})
+}
+
+// knowledgeReady 检查知识库是否启用
+func (h *RESTHandler) knowledgeReady(c *gin.Context) bool {
+	if !h.ready(c) {
+		return false
+	}
+	if h.processor.knowledge == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "未启用知识库"})
+		return false
+	}
+	return true
+}
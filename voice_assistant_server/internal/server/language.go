@@ -0,0 +1,206 @@
+package server
+
+import (
+	"context"
+	"log"
+	"strings"
+	"unicode"
+
+	"voice_assistant/pkg/protocol"
+	"voice_assistant/voice_assistant_server/internal/asr"
+	"voice_assistant/voice_assistant_server/internal/llm"
+	"voice_assistant/voice_assistant_server/internal/pipeline"
+	"voice_assistant/voice_assistant_server/internal/tts"
+)
+
+// LanguageProfile 会话语言配置
+type LanguageProfile struct {
+	Code         string   // 语言代码
+	Name         string   // 语言名称
+	ASRLanguage  string   // ASR语言提示
+	TTSLanguage  string   // TTS语言代码
+	TTSVoice     string   // TTS声音
+	Instruction  string   // LLM回复语言指令
+	Confirmation string   // 切换确认语（使用目标语言）
+	Aliases      []string // 语言别名（用于命令参数）
+	Triggers     []string // 切换触发短语（小写）
+}
+
+// languageProfiles 支持切换的语言
+var languageProfiles = map[string]LanguageProfile{
+	"zh": {
+		Code:         "zh",
+		Name:         "中文",
+		ASRLanguage:  "zh",
+		TTSLanguage:  "zh-CN",
+		TTSVoice:     "zh-CN-XiaoxiaoNeural",
+		Instruction:  "请从现在开始始终使用简体中文回复。",
+		Confirmation: "好的，接下来我会说中文。",
+		Aliases:      []string{"zh-cn", "chinese", "中文", "汉语"},
+		Triggers: []string{
+			"说中文", "讲中文", "用中文", "说汉语", "讲汉语", "用汉语", "切换到中文", "切换成中文",
+			"speak chinese", "switch to chinese", "talk in chinese", "reply in chinese",
+		},
+	},
+	"en": {
+		Code:         "en",
+		Name:         "English",
+		ASRLanguage:  "en",
+		TTSLanguage:  "en-US",
+		TTSVoice:     "en-US-AriaNeural",
+		Instruction:  "From now on, always reply in English.",
+		Confirmation: "Sure, I will speak English from now on.",
+		Aliases:      []string{"en-us", "english", "英文", "英语"},
+		Triggers: []string{
+			"说英语", "讲英语", "用英语", "说英文", "讲英文", "用英文", "切换到英语", "切换到英文", "切换成英文",
+			"speak english", "switch to english", "talk in english", "reply in english",
+		},
+	},
+}
+
+// lookupLanguageProfile 根据语言代码或别名查找语言配置
+func lookupLanguageProfile(language string) (LanguageProfile, bool) {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if profile, exists := languageProfiles[language]; exists {
+		return profile, true
+	}
+
+	for _, profile := range languageProfiles {
+		for _, alias := range profile.Aliases {
+			if alias == language {
+				return profile, true
+			}
+		}
+	}
+
+	return LanguageProfile{}, false
+}
+
+// languageSwitchPrefixes 切换命令前允许出现的礼貌用语和时间状语（小写）
+var languageSwitchPrefixes = []string{
+	"从现在开始", "从现在起", "接下来", "以后", "现在", "那就", "那", "好的", "好", "麻烦你", "麻烦", "请你", "请", "你",
+	"from now on", "please", "okay", "ok", "now",
+}
+
+// languageSwitchSuffixes 切换命令后允许出现的补充说明和语气词（小写）
+var languageSwitchSuffixes = []string{
+	"跟我说话", "和我说话", "跟我说", "和我说", "跟我聊", "和我聊", "聊天", "交流", "对话", "回复", "回答", "说话", "好了", "吧", "了", "啊", "呀",
+	"from now on", "with me", "to me", "instead", "please", "now",
+}
+
+// detectLanguageSwitch 检测识别文本中的语言切换命令
+// 只接受整句的祈使命令（如“请说英语”“用英语回答吧”），除允许的礼貌用语和语气词外不能有其他内容，
+// 因此“用英语怎么说苹果”“不要说英语”“你会说英语吗”等询问和否定不会切换语言。
+func detectLanguageSwitch(text string) (LanguageProfile, bool) {
+	if strings.ContainsAny(text, "?？") {
+		return LanguageProfile{}, false
+	}
+	command := trimAffixes(normalizeIntentText(text), languageSwitchPrefixes, languageSwitchSuffixes)
+	if command == "" {
+		return LanguageProfile{}, false
+	}
+
+	for _, profile := range languageProfiles {
+		for _, trigger := range profile.Triggers {
+			if command == trigger {
+				return profile, true
+			}
+		}
+	}
+
+	return LanguageProfile{}, false
+}
+
+// trimAffixes 反复去掉开头和结尾允许出现的词语
+func trimAffixes(text string, prefixes, suffixes []string) string {
+	for trimmed := true; trimmed; {
+		trimmed = false
+		for _, prefix := range prefixes {
+			if rest := strings.TrimPrefix(text, prefix); rest != text && rest != "" {
+				text, trimmed = strings.TrimSpace(rest), true
+				break
+			}
+		}
+	}
+	for trimmed := true; trimmed; {
+		trimmed = false
+		for _, suffix := range suffixes {
+			if rest := strings.TrimSuffix(text, suffix); rest != text && rest != "" {
+				text, trimmed = strings.TrimSpace(rest), true
+				break
+			}
+		}
+	}
+	return text
+}
+
+// normalizeIntentText 规范化文本：转小写、去除标点、合并空白
+func normalizeIntentText(text string) string {
+	cleaned := strings.Map(func(r rune) rune {
+		if unicode.IsPunct(r) || unicode.IsSymbol(r) {
+			return ' '
+		}
+		return unicode.ToLower(r)
+	}, text)
+
+	return strings.Join(strings.Fields(cleaned), " ")
+}
+
+// withLanguageOptions 将语言配置应用到各阶段的请求选项
+func withLanguageOptions(ctx context.Context, language string) context.Context {
+	profile, exists := languageProfiles[language]
+	if !exists {
+		return ctx
+	}
+
+	ctx = asr.WithRequestOptions(ctx, asr.RequestOptions{Language: profile.ASRLanguage})
+	ctx = llm.WithRequestOptions(ctx, llm.RequestOptions{Instruction: profile.Instruction})
+	ctx = tts.WithRequestOptions(ctx, tts.RequestOptions{
+		Voice:    profile.TTSVoice,
+		Language: profile.TTSLanguage,
+	})
+	return ctx
+}
+
+// switchLanguage 切换会话语言（ASR语言提示、LLM回复语言和TTS声音同时生效）
+func (p *MessageProcessor) switchLanguage(session *Session, profile LanguageProfile) {
+	session.mu.Lock()
+	session.Language = profile.Code
+	session.mu.Unlock()
+
+	log.Printf("会话语言已切换: %s -> %s", session.ID, profile.Code)
+}
+
+// confirmLanguageSwitch 使用新语言发送切换确认（文本和语音，仅文本模式不合成语音）
+func (p *MessageProcessor) confirmLanguageSwitch(ctx context.Context, client *Client, session *Session, profile LanguageProfile, textOnly bool) error {
+	p.sendResponseData(client, &protocol.ResponseData{
+		Stage:      protocol.StageLLM,
+		Content:    profile.Confirmation,
+		Confidence: 1.0,
+		IsFinal:    true,
+		Metadata: map[string]interface{}{
+			"language": profile.Code,
+		},
+	})
+
+	if textOnly {
+		return nil
+	}
+
+	session.mu.RLock()
+	voice := session.Voice
+	session.mu.RUnlock()
+
+	ctx = withVoiceOptions(withLanguageOptions(ctx, profile.Code), voice)
+	ttsResult, err := p.synthesize(ctx, sessionPriority(session), profile.Confirmation)
+	if err != nil {
+		log.Printf("TTS处理失败: %v", err)
+		return p.sendStageError(client, pipeline.StageTTS, err, "语音合成失败")
+	}
+
+	if err := p.sendSpeech(client, ttsResult); err != nil {
+		return err
+	}
+	p.rememberSpoken(session, profile.Confirmation)
+	return nil
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"voice_assistant/pkg/protocol"
+)
+
+func TestDetectLanguageSwitch(t *testing.T) {
+	tests := []struct {
+		text     string
+		language string // 为空表示不切换
+	}{
+		{"说英语", "en"},
+		{"请用英语回答吧。", "en"},
+		{"从现在开始说英文", "en"},
+		{"好的，切换到英语", "en"},
+		{"Please speak English.", "en"},
+		{"Switch to English from now on", "en"},
+		{"讲中文", "zh"},
+		{"Reply in Chinese, please", "zh"},
+
+		// 询问、否定和句中提到语言的情况不切换
+		{"用英语怎么说苹果", ""},
+		{"不要说英语", ""},
+		{"你会说英语吗", ""},
+		{"你能说英语？", ""},
+		{"我朋友只会说英语", ""},
+		{"Do you speak English", ""},
+		{"Don't speak English", ""},
+		{"How do I say thank you when I speak English", ""},
+		{"英语", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		profile, ok := detectLanguageSwitch(tt.text)
+		if tt.language == "" {
+			assert.False(t, ok, tt.text)
+			continue
+		}
+		if assert.True(t, ok, tt.text) {
+			assert.Equal(t, tt.language, profile.Code, tt.text)
+		}
+	}
+}
+
+func TestHandleSetLanguage(t *testing.T) {
+	tests := []struct {
+		language string
+		want     string // 为空表示拒绝
+	}{
+		{"en", "en"},
+		{"English", "en"},
+		{"英语", "en"},
+		{" zh-CN ", "zh"},
+		{"fr", ""},
+		{"", ""},
+	}
+
+	p := NewMessageProcessor(ProcessorConfig{MaxConcurrentSessions: 10})
+	for _, tt := range tests {
+		client := &Client{ID: "lang", SendChan: make(chan *protocol.Message, 10)}
+		session := &Session{ID: "lang", TextOnly: true}
+		cmd := protocol.CommandData{Command: protocol.CmdSetLanguage, Parameters: map[string]interface{}{"language": tt.language}}
+		require.NoError(t, p.handleSetLanguage(client, session, cmd))
+
+		var msg *protocol.Message
+		select {
+		case msg = <-client.SendChan:
+		case <-time.After(time.Second):
+			t.Fatalf("未收到回复: %q", tt.language)
+		}
+		if tt.want == "" {
+			assert.Equal(t, "INVALID_COMMAND_DATA", msg.Payload.(*protocol.ErrorData).Code, tt.language)
+			assert.Empty(t, session.Language, tt.language)
+			continue
+		}
+		// 仅文本模式只用新语言回复确认文本
+		assert.Equal(t, tt.want, session.Language, tt.language)
+		response := msg.Payload.(*protocol.ResponseData)
+		assert.Equal(t, languageProfiles[tt.want].Confirmation, response.Content)
+		assert.Equal(t, tt.want, response.Metadata["language"])
+	}
+}
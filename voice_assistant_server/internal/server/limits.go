@@ -0,0 +1,121 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"unicode/utf8"
+
+	"voice_assistant/pkg/protocol"
+	"voice_assistant/voice_assistant_server/internal/llm"
+)
+
+// LimitsConfig 语音轮次的长度限制（0表示不限制）
+type LimitsConfig struct {
+	MaxUtteranceSeconds int `yaml:"max_utterance_seconds"` // 单句音频最长秒数，超出部分丢弃并提示客户端
+	MaxResponseTokens   int `yaml:"max_response_tokens"`   // 语音回复的最大Token数：要求LLM简短回答，超出部分不合成并以提示语结尾
+	MaxBufferedSeconds  int `yaml:"max_buffered_seconds"`  // 会话音频缓冲区最多保留的秒数，写满后丢弃最早的音频并提示客户端（0时为120秒，始终有上限）
+}
+
+// responseTruncatedNotices 语音回复被截断时的提示（按会话语言）
+var responseTruncatedNotices = map[string]string{
+	"zh": "回答较长，后面的内容请查看文字回复。",
+	"en": "The answer was cut short. Please see the text reply for the rest.",
+}
+
+// sentenceEnds 截断语音回复时优先断开的位置（英文另外按“. ”断开）
+const sentenceEnds = "。！？；!?;\n"
+
+// clipUtteranceLocked 截掉超过单句时长上限的音频，返回保留的部分和是否需要提示客户端（每句只提示一次，调用方持有会话锁）
+func (p *MessageProcessor) clipUtteranceLocked(session *Session, chunk []byte) ([]byte, bool) {
+	if session.AudioBuffer.Len() == 0 {
+		session.utteranceClipped = false
+	}
+	if p.config.Limits.MaxUtteranceSeconds <= 0 {
+		return chunk, false
+	}
+
+	room := p.config.Limits.MaxUtteranceSeconds*1000*pcmBytesPerMillisecond - session.AudioBuffer.Len()
+	if room >= len(chunk) {
+		return chunk, false
+	}
+	if room < 0 {
+		room = 0
+	}
+	notify := !session.utteranceClipped
+	session.utteranceClipped = true
+	return chunk[:room&^1], notify
+}
+
+// warnUtteranceTooLong 提示客户端本句超过时长上限，之后的音频已丢弃（仍识别已收到的部分）
+func (p *MessageProcessor) warnUtteranceTooLong(client *Client, session *Session) error {
+	log.Printf("单句音频超过 %d 秒，丢弃之后的音频: %s", p.config.Limits.MaxUtteranceSeconds, session.ID)
+	return p.sendError(client, protocol.ErrUtteranceTooLong,
+		fmt.Sprintf("单句语音超过 %d 秒，之后的部分已忽略", p.config.Limits.MaxUtteranceSeconds), true)
+}
+
+// withResponseLimit 要求LLM按语音回复的长度上限简短回答（仅语音回复）
+func (p *MessageProcessor) withResponseLimit(ctx context.Context, textOnly bool) context.Context {
+	maxTokens := p.config.Limits.MaxResponseTokens
+	if textOnly || maxTokens <= 0 {
+		return ctx
+	}
+
+	instruction := fmt.Sprintf("这是语音对话，请用简短的口语回答，不要使用列表、表格或代码，长度控制在约%d个Token（约%d个汉字或%d个英文单词）以内。",
+		maxTokens, maxTokens, maxTokens*3/4)
+	opts := llm.RequestOptionsFromContext(ctx)
+	if opts.Instruction != "" {
+		opts.Instruction += "\n" + instruction
+	} else {
+		opts.Instruction = instruction
+	}
+	return llm.WithRequestOptions(ctx, opts)
+}
+
+// limitSpokenResponse 把超过长度上限的回复截断为要合成的语音文本（尽量在句末断开，并以提示语结尾），返回是否截断
+func (p *MessageProcessor) limitSpokenResponse(text, language string) (string, bool) {
+	maxTokens := p.config.Limits.MaxResponseTokens
+	if maxTokens <= 0 || p.responseTokenizer == nil || p.responseTokenizer.CountTokens(text) <= maxTokens {
+		return text, false
+	}
+
+	runes := []rune(text)
+	// 二分查找不超过上限的最长前缀
+	lo, hi := 0, len(runes)
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if p.responseTokenizer.CountTokens(string(runes[:mid])) <= maxTokens {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	spoken := string(runes[:lo])
+	cut := strings.LastIndexAny(spoken, sentenceEnds)
+	if i := strings.LastIndex(spoken, ". "); i > cut {
+		cut = i
+	}
+	if cut > 0 {
+		_, size := utf8.DecodeRuneInString(spoken[cut:])
+		spoken = spoken[:cut+size]
+	}
+
+	notice, exists := responseTruncatedNotices[language]
+	if !exists {
+		notice = responseTruncatedNotices["zh"]
+	}
+	return strings.TrimSpace(spoken) + "\n" + notice, true
+}
+
+// truncationMetadata 语音回复被截断时在LLM响应中注明（与其他元数据合并）
+func truncationMetadata(metadata map[string]interface{}, truncated bool) map[string]interface{} {
+	if !truncated {
+		return metadata
+	}
+	if metadata == nil {
+		metadata = make(map[string]interface{})
+	}
+	metadata["speech_truncated"] = true
+	return metadata
+}
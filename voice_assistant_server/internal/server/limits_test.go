@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"voice_assistant/pkg/protocol"
+	"voice_assistant/voice_assistant_server/internal/llm"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClipUtterance(t *testing.T) {
+	p := NewMessageProcessor(ProcessorConfig{MaxConcurrentSessions: 10, Limits: LimitsConfig{MaxUtteranceSeconds: 1}})
+	session := p.getOrCreateSession("limits-test", "")
+	limit := 1000 * pcmBytesPerMillisecond
+
+	chunk, notify := p.clipUtteranceLocked(session, make([]byte, limit-100))
+	assert.Len(t, chunk, limit-100)
+	assert.False(t, notify)
+	session.AudioBuffer.Write(chunk)
+
+	// 超过上限的部分丢弃，每句只提示一次
+	chunk, notify = p.clipUtteranceLocked(session, make([]byte, 300))
+	assert.Len(t, chunk, 100)
+	assert.True(t, notify)
+	session.AudioBuffer.Write(chunk)
+	chunk, notify = p.clipUtteranceLocked(session, make([]byte, 300))
+	assert.Empty(t, chunk)
+	assert.False(t, notify)
+
+	// 新的一句重新计算
+	session.AudioBuffer.Reset()
+	chunk, _ = p.clipUtteranceLocked(session, make([]byte, 300))
+	assert.Len(t, chunk, 300)
+	assert.False(t, session.utteranceClipped)
+}
+
+func TestUtteranceTooLongWarning(t *testing.T) {
+	p, client := newModeTestProcessor()
+	p.config.Limits.MaxUtteranceSeconds = 1
+	session := p.getOrCreateSession(client.ID, "")
+	session.AudioBuffer.Write(make([]byte, 1000*pcmBytesPerMillisecond))
+
+	sendAudio(t, p, client)
+	assert.Equal(t, protocol.ErrUtteranceTooLong, nextErrorCode(t, client))
+	sendAudio(t, p, client)
+	assert.Empty(t, client.SendChan)
+	assert.Equal(t, 1000*pcmBytesPerMillisecond, session.AudioBuffer.Len())
+}
+
+func TestLimitSpokenResponse(t *testing.T) {
+	p := NewMessageProcessor(ProcessorConfig{MaxConcurrentSessions: 10, Limits: LimitsConfig{MaxResponseTokens: 10}})
+	tokenizer, err := llm.NewTokenizer(llm.TokenizerEstimate, "")
+	require.NoError(t, err)
+	p.responseTokenizer = tokenizer
+
+	spoken, truncated := p.limitSpokenResponse("今天天气不错。", "zh")
+	assert.False(t, truncated)
+	assert.Equal(t, "今天天气不错。", spoken)
+
+	// 在上限以内的最后一个句末断开，并以提示语结尾
+	spoken, truncated = p.limitSpokenResponse("今天天气不错。明天有雨，记得带伞出门。", "zh")
+	require.True(t, truncated)
+	assert.Equal(t, "今天天气不错。\n"+responseTruncatedNotices["zh"], spoken)
+
+	// 没有句末标点时按上限截断
+	spoken, truncated = p.limitSpokenResponse(strings.Repeat("长", 30), "en")
+	require.True(t, truncated)
+	assert.Equal(t, strings.Repeat("长", 10)+"\n"+responseTruncatedNotices["en"], spoken)
+}
+
+func TestWithResponseLimit(t *testing.T) {
+	p := NewMessageProcessor(ProcessorConfig{MaxConcurrentSessions: 10, Limits: LimitsConfig{MaxResponseTokens: 200}})
+	ctx := llm.WithRequestOptions(context.Background(), llm.RequestOptions{Instruction: "请用中文回答。"})
+
+	opts := llm.RequestOptionsFromContext(p.withResponseLimit(ctx, false))
+	assert.True(t, strings.HasPrefix(opts.Instruction, "请用中文回答。\n"))
+	assert.Contains(t, opts.Instruction, "200")
+
+	// 仅文本模式不限制
+	assert.Equal(t, "请用中文回答。", llm.RequestOptionsFromContext(p.withResponseLimit(ctx, true)).Instruction)
+}
@@ -0,0 +1,149 @@
+package server
+
+import (
+	"log"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// 连接状态
+const (
+	ConnectionActive       = "active"       // 在心跳周期内有活动
+	ConnectionUnresponsive = "unresponsive" // 超过Pong等待时长没有活动
+)
+
+// ConnectionInfo 连接的活跃度信息（用于健康检查）
+type ConnectionInfo struct {
+	ID          string    `json:"id"`
+	RemoteAddr  string    `json:"remote_addr"`
+	State       string    `json:"state"`
+	ConnectedAt time.Time `json:"connected_at"`
+	AgeSeconds  float64   `json:"age_seconds"`
+	IdleSeconds float64   `json:"idle_seconds"`
+	Sessions    int       `json:"sessions"`
+	QueueLength int       `json:"queue_length"` // 发送队列中待写出的消息数
+	Dropped     int64     `json:"dropped"`      // 发送队列溢出丢弃的消息数
+}
+
+// touch 记录连接活动
+func (c *Client) touch() {
+	c.lastActivity.Store(time.Now().UnixNano())
+}
+
+// idle 连接自最近一次活动以来的时长
+func (c *Client) idle(now time.Time) time.Duration {
+	return now.Sub(time.Unix(0, c.lastActivity.Load()))
+}
+
+// close 关闭连接并清理登记（可重复调用）
+// 读协程可能阻塞在消息处理中，失效连接由巡检直接关闭，不依赖读协程退出。
+func (c *Client) close() {
+	c.closeOnce.Do(func() {
+		c.Server.mu.Lock()
+		if c.Server.clients[c.ID] == c {
+			delete(c.Server.clients, c.ID)
+		}
+		c.Server.mu.Unlock()
+		// 先通知写协程和阻塞在发送队列上的发送方退出，它们可能持有会话恢复的锁
+		close(c.done)
+		c.Server.detachConnection(c)
+		c.Conn.Close()
+		// 未启用会话恢复时释放连接自己的会话，会话ID随即可以重新使用
+		if c.resume == nil && c.Server.processor != nil {
+			c.Server.processor.releaseConnectionSessions(c.ID, append([]string{c.ID}, c.SessionIDs()...))
+		}
+		log.Printf("客户端断开: %s", c.ID)
+	})
+}
+
+// sweepLoop 定期巡检失效连接
+func (s *WebSocketServer) sweepLoop() {
+	interval := s.config.SweepInterval
+	if interval <= 0 {
+		interval = s.config.StaleTimeout / 2
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopSweep:
+			return
+		case now := <-ticker.C:
+			if closed := s.sweepStale(now); closed > 0 {
+				log.Printf("已清理失效连接: %d", closed)
+			}
+		}
+	}
+}
+
+// sweepStale 关闭超过StaleTimeout没有活动的连接，返回关闭的连接数
+// 未启用会话恢复时同时释放连接上的处理器会话；启用时会话保留到恢复窗口结束。
+func (s *WebSocketServer) sweepStale(now time.Time) int {
+	s.mu.RLock()
+	var stale []*Client
+	for _, client := range s.clients {
+		if client.idle(now) >= s.config.StaleTimeout {
+			stale = append(stale, client)
+		}
+	}
+	s.mu.RUnlock()
+
+	for _, client := range stale {
+		log.Printf("连接无活动超过 %v，强制关闭: %s", s.config.StaleTimeout, client.ID)
+		client.close()
+	}
+	atomic.AddInt64(&s.staleClosed, int64(len(stale)))
+	return len(stale)
+}
+
+// ConnectionStats 获取当前连接的活跃度信息（按连接时长从长到短排序）及累计清理的失效连接数
+func (s *WebSocketServer) ConnectionStats() ([]ConnectionInfo, int64) {
+	now := time.Now()
+
+	s.mu.RLock()
+	infos := make([]ConnectionInfo, 0, len(s.clients))
+	for _, client := range s.clients {
+		idle := client.idle(now)
+		state := ConnectionActive
+		if idle > s.config.PongWait {
+			state = ConnectionUnresponsive
+		}
+		infos = append(infos, ConnectionInfo{
+			ID:          client.ID,
+			RemoteAddr:  client.Conn.RemoteAddr().String(),
+			State:       state,
+			ConnectedAt: client.connectedAt,
+			AgeSeconds:  now.Sub(client.connectedAt).Seconds(),
+			IdleSeconds: idle.Seconds(),
+			Sessions:    len(client.SessionIDs()),
+			QueueLength: len(client.SendChan),
+			Dropped:     client.dropped.Load(),
+		})
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ConnectedAt.Before(infos[j].ConnectedAt)
+	})
+	return infos, atomic.LoadInt64(&s.staleClosed)
+}
+
+// Close 停止失效连接巡检并关闭所有连接
+func (s *WebSocketServer) Close() {
+	s.closeOnce.Do(func() {
+		close(s.stopSweep)
+	})
+
+	s.mu.RLock()
+	clients := make([]*Client, 0, len(s.clients))
+	for _, client := range s.clients {
+		clients = append(clients, client)
+	}
+	s.mu.RUnlock()
+
+	for _, client := range clients {
+		client.close()
+	}
+}
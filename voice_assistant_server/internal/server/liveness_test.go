@@ -0,0 +1,54 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSweepStaleConnections(t *testing.T) {
+	s := NewWebSocketServer(WebSocketConfig{
+		MaxConnections: 10,
+		PingPeriod:     time.Minute,
+		PongWait:       time.Minute,
+		WriteWait:      time.Second,
+	})
+	defer s.Close()
+	httpServer := httptest.NewServer(http.HandlerFunc(s.HandleConnection))
+	defer httpServer.Close()
+	url := "ws" + strings.TrimPrefix(httpServer.URL, "http")
+
+	conn, _, err := websocket.DefaultDialer.Dial(url+"?session_id=liveness-test", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	require.Eventually(t, func() bool { return s.GetClientCount() == 1 }, 2*time.Second, 10*time.Millisecond)
+
+	infos, staleClosed := s.ConnectionStats()
+	require.Len(t, infos, 1)
+	assert.Equal(t, "liveness-test", infos[0].ID)
+	assert.Equal(t, ConnectionActive, infos[0].State)
+	assert.Zero(t, staleClosed)
+
+	// 活动时间在阈值内的连接保留
+	s.config.StaleTimeout = time.Minute
+	assert.Zero(t, s.sweepStale(time.Now()))
+
+	// 超过阈值没有活动的连接被强制关闭，客户端读到连接断开
+	assert.Equal(t, 1, s.sweepStale(time.Now().Add(2*time.Minute)))
+	assert.Zero(t, s.GetClientCount())
+	_, staleClosed = s.ConnectionStats()
+	assert.EqualValues(t, 1, staleClosed)
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			break
+		}
+	}
+}
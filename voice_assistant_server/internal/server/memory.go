@@ -0,0 +1,117 @@
+package server
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"voice_assistant/pkg/protocol"
+	"voice_assistant/voice_assistant_server/internal/dataset"
+	"voice_assistant/voice_assistant_server/internal/llm"
+	"voice_assistant/voice_assistant_server/internal/pipeline"
+)
+
+// memoryTimeout 读取记忆时的超时（记忆不可用时不影响本轮对话）
+const memoryTimeout = 2 * time.Second
+
+// memoryGenerate 记忆提取调用LLM（经工作池按批量任务排队，不挤占实时对话）
+func (p *MessageProcessor) memoryGenerate(ctx context.Context, instruction, text string) (string, error) {
+	response, err := p.generate(ctx, pipeline.PriorityBatch, []llm.Message{
+		{Role: "system", Content: instruction},
+		{Role: "user", Content: text},
+	})
+	if err != nil {
+		return "", err
+	}
+	return response.Content, nil
+}
+
+// memoryUser 会话可使用长期记忆的用户ID（未启用、无法确定用户或拒绝数据采集时返回空）
+func (p *MessageProcessor) memoryUser(session *Session) string {
+	if p.memory == nil {
+		return ""
+	}
+
+	session.mu.RLock()
+	defer session.mu.RUnlock()
+	if session.DataConsent == dataset.ConsentDenied {
+		return ""
+	}
+	return p.memoryIdentityLocked(session)
+}
+
+// memoryIdentityLocked 按服务端可验证的身份确定记忆归属：连接的API Key在 memory.users 中映射的用户；
+// 开启 memory.speaker_fallback 时其次是声纹识别到的说话人。
+// 客户端在 start_session 中自报的 user_id 不可信，不用于记忆（调用方持有会话锁）
+func (p *MessageProcessor) memoryIdentityLocked(session *Session) string {
+	if session.APIKey != "" {
+		if userID := p.config.Memory.Users[session.APIKey]; userID != "" {
+			return userID
+		}
+	}
+	if p.config.Memory.SpeakerFallback && session.Speaker != nil {
+		return "speaker:" + session.Speaker.ID
+	}
+	return ""
+}
+
+// withMemory 把用户的长期记忆作为背景信息附加到LLM请求
+func (p *MessageProcessor) withMemory(ctx context.Context, session *Session) context.Context {
+	userID := p.memoryUser(session)
+	if userID == "" {
+		return ctx
+	}
+
+	loadCtx, cancel := context.WithTimeout(ctx, memoryTimeout)
+	defer cancel()
+	prompt, err := p.memory.Prompt(loadCtx, userID)
+	if err != nil {
+		log.Printf("读取用户记忆失败: %s, %v", userID, err)
+		return ctx
+	}
+	return withBackground(ctx, prompt)
+}
+
+// rememberUser 异步从用户的话中提取并保存长期记忆
+func (p *MessageProcessor) rememberUser(session *Session, text string) {
+	userID := p.memoryUser(session)
+	if userID == "" || text == "" {
+		return
+	}
+
+	manager := p.memory
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		changed, err := manager.Remember(ctx, userID, text)
+		if err != nil {
+			log.Printf("保存用户记忆失败: %s, %v", userID, err)
+			return
+		}
+		if changed > 0 {
+			log.Printf("用户记忆已更新: %s, 条数: %d", userID, changed)
+		}
+	}()
+}
+
+// handleForgetMemory 处理删除长期记忆（删除会话用户的全部记忆）
+func (p *MessageProcessor) handleForgetMemory(client *Client, session *Session, cmdData protocol.CommandData) error {
+	session.mu.RLock()
+	userID := p.memoryIdentityLocked(session)
+	session.mu.RUnlock()
+
+	if p.memory == nil || userID == "" {
+		return p.sendError(client, "MEMORY_UNAVAILABLE", "未启用长期记忆或无法确定会话的用户身份", true)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := p.memory.Forget(ctx, userID); err != nil {
+		log.Printf("删除用户记忆失败: %s, %v", userID, err)
+		return p.sendError(client, "MEMORY_FAILED", "删除长期记忆失败", true)
+	}
+
+	log.Printf("用户记忆已删除: %s", userID)
+	return p.sendStatus(client, session)
+}
@@ -0,0 +1,125 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"voice_assistant/pkg/protocol"
+)
+
+// 会话模式的语义：
+//   - continuous/push_to_talk/interrupt：一轮结束后恢复聆听
+//   - duplex：按连续模式处理，客户端播放回复时也在录音
+//   - single：一轮结束后会话自动结束，再次start_session前不接受音频（期间切换模式也不会恢复）
+//   - wakeword：收到wake命令后才接受音频，一轮结束后回到等待唤醒
+var sessionModes = map[string]bool{
+	protocol.ModeContinuous: true,
+	protocol.ModePushToTalk: true,
+	protocol.ModeInterrupt:  true,
+	protocol.ModeDuplex:     true,
+	protocol.ModeSingle:     true,
+	protocol.ModeWakeword:   true,
+}
+
+// resolveSessionMode 校验start_session/set_mode指定的模式，未指定时按配置使用连续或单轮模式
+func (p *MessageProcessor) resolveSessionMode(mode string) (string, error) {
+	if mode == "" {
+		if p.config.EnableContinuousMode {
+			return protocol.ModeContinuous, nil
+		}
+		return protocol.ModeSingle, nil
+	}
+	if !sessionModes[mode] {
+		return "", fmt.Errorf("不支持的会话模式: %s", mode)
+	}
+	return mode, nil
+}
+
+// applySessionMode 设置会话模式，切换到唤醒词模式后需重新唤醒（调用方持有会话锁）
+func applySessionMode(session *Session, mode string) {
+	session.Mode = mode
+	session.Duplex = mode == protocol.ModeDuplex
+	session.ContinuousMode = mode != protocol.ModeSingle && mode != protocol.ModeWakeword
+	session.awake = false
+}
+
+// modeLocked 会话模式名称（未通过start_session/set_mode指定时按连续模式标志推断，调用方持有会话锁）
+func (s *Session) modeLocked() string {
+	switch {
+	case s.Mode != "":
+		return s.Mode
+	case s.ContinuousMode:
+		return protocol.ModeContinuous
+	default:
+		return protocol.ModeSingle
+	}
+}
+
+// acceptsAudioLocked 会话当前是否接受音频，不接受时返回提示的错误码和信息（调用方持有会话锁）
+func (s *Session) acceptsAudioLocked() (bool, string, string) {
+	switch {
+	case s.ended:
+		return false, protocol.ErrSessionEnded, "单轮会话已结束，请重新开始会话"
+	case s.Mode == protocol.ModeWakeword && !s.awake:
+		return false, protocol.ErrNotAwake, "等待唤醒，收到wake命令后才接受音频"
+	default:
+		return true, "", ""
+	}
+}
+
+// endTurnLocked 一轮对话结束后按会话模式设置状态，返回单轮会话是否因此结束（调用方持有会话锁）
+func endTurnLocked(session *Session) bool {
+	switch {
+	case session.ContinuousMode:
+		session.State = StateListening
+	case session.Mode == protocol.ModeSingle:
+		session.State = StateIdle
+		session.ended = true
+		session.AudioBuffer.Reset()
+		return true
+	case session.Mode == protocol.ModeWakeword:
+		session.State = StateIdle
+		session.awake = false
+		session.AudioBuffer.Reset()
+	default:
+		session.State = StateIdle
+	}
+	return false
+}
+
+// finishTurn 一轮对话结束：按模式重置状态并通知客户端，单轮会话同时释放连接上的会话名额
+func (p *MessageProcessor) finishTurn(client *Client, session *Session) {
+	session.mu.Lock()
+	session.IsProcessing = false
+	ended := endTurnLocked(session)
+	session.mu.Unlock()
+
+	p.sendStatus(client, session)
+	if ended {
+		client.ReleaseSession(session.ID)
+		log.Printf("单轮会话已结束: %s", session.ID)
+	}
+}
+
+// handleWake 处理唤醒事件：唤醒词模式的会话开始接受下一轮音频
+func (p *MessageProcessor) handleWake(client *Client, session *Session, cmdData protocol.CommandData) error {
+	session.mu.Lock()
+	if session.Mode != protocol.ModeWakeword {
+		session.mu.Unlock()
+		return p.sendError(client, protocol.ErrInvalidCommandData, "当前会话不是唤醒词模式", true)
+	}
+	if !session.awake {
+		// 唤醒前缓冲的音频不属于本轮
+		session.awake = true
+		session.audioRejected = false
+		session.AudioBuffer.Reset()
+		session.State = StateListening
+	}
+	session.LastActivity = time.Now()
+	keyword, _ := cmdData.Parameters["keyword"].(string)
+	session.mu.Unlock()
+
+	log.Printf("会话已唤醒: %s, 唤醒词: %q", session.ID, keyword)
+	return p.sendStatus(client, session)
+}
@@ -0,0 +1,150 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"voice_assistant/pkg/protocol"
+	"voice_assistant/voice_assistant_server/internal/asr"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newModeTestProcessor() (*MessageProcessor, *Client) {
+	p := NewMessageProcessor(ProcessorConfig{MaxConcurrentSessions: 10, AudioBufferSize: 1 << 20})
+	p.isInitialized = true
+	return p, &Client{ID: "mode", SendChan: make(chan *protocol.Message, 10)}
+}
+
+func sendAudio(t *testing.T, p *MessageProcessor, client *Client) {
+	msg := protocol.NewMessage(protocol.AudioStream, client.ID, &protocol.AudioStreamData{AudioData: []byte{1, 2}})
+	require.NoError(t, p.ProcessMessage(client, msg))
+}
+
+func nextStatus(t *testing.T, client *Client) *protocol.StatusData {
+	msg := <-client.SendChan
+	require.Equal(t, protocol.Status, msg.Type)
+	return msg.Payload.(*protocol.StatusData)
+}
+
+func nextErrorCode(t *testing.T, client *Client) string {
+	msg := <-client.SendChan
+	require.Equal(t, protocol.Error, msg.Type)
+	return msg.Payload.(*protocol.ErrorData).Code
+}
+
+func TestWakewordMode(t *testing.T) {
+	p, client := newModeTestProcessor()
+	start := protocol.NewCommandMessage(client.ID, protocol.CmdStartSession, protocol.ModeWakeword, nil)
+	require.NoError(t, p.ProcessMessage(client, start))
+	status := nextStatus(t, client)
+	assert.Equal(t, string(StateIdle), status.State)
+	assert.Equal(t, protocol.ModeWakeword, status.Mode)
+
+	// 唤醒前的音频被丢弃，只提示一次
+	sendAudio(t, p, client)
+	assert.Equal(t, protocol.ErrNotAwake, nextErrorCode(t, client))
+	sendAudio(t, p, client)
+	assert.Empty(t, client.SendChan)
+
+	wake := protocol.NewCommandMessage(client.ID, protocol.CmdWake, "", map[string]interface{}{"keyword": "小助手"})
+	require.NoError(t, p.ProcessMessage(client, wake))
+	assert.Equal(t, string(StateListening), nextStatus(t, client).State)
+
+	sendAudio(t, p, client)
+	session := p.getOrCreateSession(client.ID, "")
+	assert.Equal(t, 2, session.AudioBuffer.Len())
+
+	// 一轮结束后回到等待唤醒
+	p.finishTurn(client, session)
+	assert.Equal(t, string(StateIdle), nextStatus(t, client).State)
+	sendAudio(t, p, client)
+	assert.Equal(t, protocol.ErrNotAwake, nextErrorCode(t, client))
+}
+
+func TestSingleModeEndsAfterTurn(t *testing.T) {
+	p, client := newModeTestProcessor()
+	start := protocol.NewCommandMessage(client.ID, protocol.CmdStartSession, protocol.ModeSingle, nil)
+	require.NoError(t, p.ProcessMessage(client, start))
+	assert.Equal(t, protocol.ModeSingle, nextStatus(t, client).Mode)
+
+	session := p.getOrCreateSession(client.ID, "")
+	p.finishTurn(client, session)
+	assert.Equal(t, string(StateIdle), nextStatus(t, client).State)
+
+	// 会话结束后切换模式也不再接受音频，重新开始会话后恢复
+	setMode := protocol.NewCommandMessage(client.ID, protocol.CmdSetMode, "", map[string]interface{}{"mode": protocol.ModeContinuous})
+	require.NoError(t, p.ProcessMessage(client, setMode))
+	assert.Equal(t, protocol.ModeContinuous, nextStatus(t, client).Mode)
+	sendAudio(t, p, client)
+	assert.Equal(t, protocol.ErrSessionEnded, nextErrorCode(t, client))
+
+	require.NoError(t, p.ProcessMessage(client, start))
+	assert.Equal(t, string(StateListening), nextStatus(t, client).State)
+	sendAudio(t, p, client)
+	assert.Empty(t, client.SendChan)
+}
+
+// capturingASR 记录收到的音频并一直等到调用被取消的ASR服务
+type capturingASR struct {
+	asr.ASRService
+	audio chan []byte
+}
+
+func (c *capturingASR) ProcessAudio(ctx context.Context, audio []byte) (asr.ASRResult, error) {
+	c.audio <- audio
+	<-ctx.Done()
+	return asr.ASRResult{}, ctx.Err()
+}
+
+func TestPushToTalkEndsOnFinalChunk(t *testing.T) {
+	p, client := newModeTestProcessor()
+	service := &capturingASR{audio: make(chan []byte, 1)}
+	p.asrService = service
+	start := protocol.NewCommandMessage(client.ID, protocol.CmdStartSession, protocol.ModePushToTalk, map[string]interface{}{"endpoint_silence_ms": 200.0})
+	require.NoError(t, p.ProcessMessage(client, start))
+	assert.Equal(t, protocol.ModePushToTalk, nextStatus(t, client).Mode)
+
+	// 按住期间的停顿既不按静默计时也不按VAD断句
+	sendAudio(t, p, client)
+	session := p.getOrCreateSession(client.ID, "")
+	session.mu.Lock()
+	assert.Nil(t, session.endpointTimer)
+	session.mu.Unlock()
+	end := protocol.NewMessage(protocol.Status, client.ID, &protocol.StatusData{State: protocol.StateSpeechEnd})
+	require.NoError(t, p.ProcessMessage(client, end))
+	sendAudio(t, p, client)
+	assert.Empty(t, client.SendChan)
+
+	// 松开后的最终块连同此前缓冲的全部音频一起识别
+	final := protocol.NewMessage(protocol.AudioStream, client.ID, &protocol.AudioStreamData{AudioData: []byte{3, 4}, IsFinal: true})
+	require.NoError(t, p.ProcessMessage(client, final))
+	assert.Equal(t, string(StateProcessing), nextStatus(t, client).State)
+	select {
+	case audio := <-service.audio:
+		assert.Equal(t, []byte{1, 2, 1, 2, 3, 4}, audio)
+	case <-time.After(time.Second):
+		t.Fatal("最终块后未开始识别")
+	}
+	p.ReleaseSession(client.ID)
+
+	// 一轮结束后恢复聆听，等待下一次按键
+	session.mu.Lock()
+	endTurnLocked(session)
+	assert.Equal(t, StateListening, session.State)
+	session.mu.Unlock()
+}
+
+func TestInvalidSessionMode(t *testing.T) {
+	p, client := newModeTestProcessor()
+	start := protocol.NewCommandMessage(client.ID, protocol.CmdStartSession, "always_on", nil)
+	require.NoError(t, p.ProcessMessage(client, start))
+	assert.Equal(t, protocol.ErrInvalidCommandData, nextErrorCode(t, client))
+
+	// 未指定模式时按配置使用单轮模式
+	start = protocol.NewCommandMessage(client.ID, protocol.CmdStartSession, "", nil)
+	require.NoError(t, p.ProcessMessage(client, start))
+	assert.Equal(t, protocol.ModeSingle, nextStatus(t, client).Mode)
+}
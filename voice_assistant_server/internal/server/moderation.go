@@ -0,0 +1,83 @@
+package server
+
+import (
+	"context"
+	"log"
+
+	"voice_assistant/pkg/protocol"
+	"voice_assistant/voice_assistant_server/internal/llm"
+	"voice_assistant/voice_assistant_server/internal/moderation"
+	"voice_assistant/voice_assistant_server/internal/pipeline"
+)
+
+// moderationRefusals 内容被拒绝时的提示（按会话语言）
+var moderationRefusals = map[string]map[moderation.Stage]string{
+	"zh": {
+		moderation.StageInput:  "抱歉，这个话题我不方便回答，我们聊点别的吧。",
+		moderation.StageOutput: "抱歉，这个问题我没法给出合适的回答，换个问题试试吧。",
+	},
+	"en": {
+		moderation.StageInput:  "Sorry, I can't help with that. Let's talk about something else.",
+		moderation.StageOutput: "Sorry, I couldn't come up with an appropriate answer. Please try asking something else.",
+	},
+}
+
+// moderationGenerate 改写违规内容调用LLM（处于实时对话中，按交互任务排队）
+func (p *MessageProcessor) moderationGenerate(ctx context.Context, instruction, text string) (string, error) {
+	response, err := p.generate(ctx, pipeline.PriorityInteractive, []llm.Message{
+		{Role: "system", Content: instruction},
+		{Role: "user", Content: text},
+	})
+	if err != nil {
+		return "", err
+	}
+	return response.Content, nil
+}
+
+// moderate 审核用户输入或助手回复（未启用时原样通过；分类器不可用时按 fail_closed 配置处理）
+func (p *MessageProcessor) moderate(ctx context.Context, stage moderation.Stage, text string) moderation.Decision {
+	if p.moderator == nil || text == "" {
+		return moderation.Decision{Action: moderation.ActionAllow, Text: text}
+	}
+
+	decision, err := p.moderator.Check(ctx, stage, text)
+	if err != nil {
+		log.Printf("内容审核失败: %v", err)
+		decision = p.moderator.FailDecision(text)
+	}
+	if decision.Action != moderation.ActionAllow {
+		log.Printf("内容审核: 阶段 %s, 处理方式: %s, 类别: %v", stage, decision.Action, decision.Categories)
+	}
+	return decision
+}
+
+// moderationMetadata 响应中附带的审核信息（未命中时为nil）
+func moderationMetadata(stage moderation.Stage, decision moderation.Decision) map[string]interface{} {
+	if decision.Action == moderation.ActionAllow {
+		return nil
+	}
+	return map[string]interface{}{
+		"moderation": map[string]interface{}{
+			"stage":      stage,
+			"action":     decision.Action,
+			"categories": decision.Categories,
+		},
+	}
+}
+
+// moderationRefusal 内容被拒绝时的提示
+func moderationRefusal(stage moderation.Stage, language string) string {
+	refusals, exists := moderationRefusals[language]
+	if !exists {
+		refusals = moderationRefusals["zh"]
+	}
+	return refusals[stage]
+}
+
+// refuseContent 以会话语言告知用户请求被拒绝（错误码 CONTENT_REFUSED，并以文本和语音回复提示）
+func (p *MessageProcessor) refuseContent(ctx context.Context, client *Client, session *Session, stage moderation.Stage, decision moderation.Decision, language string, textOnly bool) error {
+	log.Printf("会话内容被拒绝: %s, 阶段: %s", session.ID, stage)
+
+	p.sendError(client, protocol.ErrContentRefused, "内容未通过审核", true)
+	return p.speakNotice(ctx, client, session, moderationRefusal(stage, language), moderationMetadata(stage, decision), textOnly)
+}
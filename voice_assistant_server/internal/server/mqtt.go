@@ -0,0 +1,225 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"voice_assistant/voice_assistant_server/internal/mqtt"
+	"voice_assistant/voice_assistant_server/internal/webhook"
+)
+
+// MQTT状态主题的取值
+const (
+	mqttOnline  = "online"
+	mqttOffline = "offline"
+)
+
+// mqttAnnounceTimeout MQTT触发播报的合成超时
+const mqttAnnounceTimeout = 30 * time.Second
+
+// MQTTConfig MQTT桥接配置（智能家居集成）
+type MQTTConfig struct {
+	Enabled        bool          `yaml:"enabled"`
+	Broker         string        `yaml:"broker"`    // tcp://host:1883 或 ssl://host:8883
+	ClientID       string        `yaml:"client_id"` // 客户端ID
+	Username       string        `yaml:"username"`
+	Password       string        `yaml:"password"`
+	KeepAlive      time.Duration `yaml:"keep_alive"`
+	QoS            byte          `yaml:"qos"`          // 发布和订阅的QoS（0或1）
+	TopicPrefix    string        `yaml:"topic_prefix"` // 主题前缀
+	Topics         MQTTTopics    `yaml:"topics"`       // 单独指定的主题（为空时使用 前缀/名称）
+	RequireConsent bool          `yaml:"require_consent"`
+
+	// Home Assistant自动发现
+	Discovery       bool   `yaml:"discovery"`
+	DiscoveryPrefix string `yaml:"discovery_prefix"`
+}
+
+// MQTTTopics MQTT主题
+type MQTTTopics struct {
+	Transcript string `yaml:"transcript"` // 发布：用户说的话（纯文本）
+	Reply      string `yaml:"reply"`      // 发布：助手回复（纯文本）
+	Turn       string `yaml:"turn"`       // 发布：一轮对话的完整信息（JSON）
+	Announce   string `yaml:"announce"`   // 订阅：向所有客户端播报（纯文本或 {"text": "...", "lead_ms": 1500}）
+	Status     string `yaml:"status"`     // 发布：在线状态（online/offline，保留消息）
+}
+
+// topic 主题名（未单独指定时为 前缀/名称）
+func (c MQTTConfig) topic(override, name string) string {
+	if override != "" {
+		return override
+	}
+	return strings.TrimSuffix(c.TopicPrefix, "/") + "/" + name
+}
+
+// MQTTBridge 把对话结果发布到MQTT，并订阅播报命令
+type MQTTBridge struct {
+	config MQTTConfig
+	topics MQTTTopics
+	client *mqtt.Client
+	server *WebSocketServer
+}
+
+// NewMQTTBridge 创建MQTT桥接（对话完成监听注册到服务器的处理器上，Run之后连接）
+func NewMQTTBridge(config MQTTConfig, server *WebSocketServer) *MQTTBridge {
+	if config.ClientID == "" {
+		config.ClientID = "voice_assistant"
+	}
+	if config.TopicPrefix == "" {
+		config.TopicPrefix = "voice_assistant"
+	}
+	if config.DiscoveryPrefix == "" {
+		config.DiscoveryPrefix = "homeassistant"
+	}
+	b := &MQTTBridge{
+		config: config,
+		server: server,
+		topics: MQTTTopics{
+			Transcript: config.topic(config.Topics.Transcript, "transcript"),
+			Reply:      config.topic(config.Topics.Reply, "reply"),
+			Turn:       config.topic(config.Topics.Turn, "turn"),
+			Announce:   config.topic(config.Topics.Announce, "announce"),
+			Status:     config.topic(config.Topics.Status, "status"),
+		},
+	}
+	b.client = mqtt.NewClient(mqtt.Options{
+		Broker:    config.Broker,
+		ClientID:  config.ClientID,
+		Username:  config.Username,
+		Password:  config.Password,
+		KeepAlive: config.KeepAlive,
+		Will:      &mqtt.Message{Topic: b.topics.Status, Payload: []byte(mqttOffline), QoS: config.QoS, Retain: true},
+		OnConnect: b.onConnect,
+	})
+	b.client.Subscribe(b.topics.Announce, config.QoS, b.handleAnnounce)
+
+	if server.processor != nil {
+		server.processor.AddTurnListener(b.publishTurn)
+	}
+	return b
+}
+
+// Run 连接MQTT服务器并保持连接，直到ctx取消
+func (b *MQTTBridge) Run(ctx context.Context) {
+	log.Printf("MQTT桥接已启用 (%s, 主题前缀: %s)", b.config.Broker, b.config.TopicPrefix)
+	b.client.Run(ctx)
+}
+
+// onConnect 连接（含重连）后发布在线状态和自动发现配置
+func (b *MQTTBridge) onConnect() {
+	b.publish(b.topics.Status, []byte(mqttOnline), true)
+	if b.config.Discovery {
+		b.publishDiscovery()
+	}
+}
+
+// publishTurn 发布一轮对话
+func (b *MQTTBridge) publishTurn(turn webhook.Turn, consented bool) {
+	if b.config.RequireConsent && !consented {
+		return
+	}
+	payload, err := json.Marshal(turn)
+	if err != nil {
+		log.Printf("序列化MQTT消息失败: %v", err)
+		return
+	}
+	b.publish(b.topics.Transcript, []byte(turn.Transcript), false)
+	b.publish(b.topics.Reply, []byte(turn.Reply), false)
+	b.publish(b.topics.Turn, payload, false)
+}
+
+// publish 发布消息（未连接时丢弃）
+func (b *MQTTBridge) publish(topic string, payload []byte, retain bool) {
+	err := b.client.Publish(mqtt.Message{Topic: topic, Payload: payload, QoS: b.config.QoS, Retain: retain})
+	if err != nil {
+		log.Printf("发布MQTT消息失败 (%s): %v", topic, err)
+	}
+}
+
+// mqttAnnounce 播报命令
+type mqttAnnounce struct {
+	Text   string `json:"text"`
+	LeadMs int    `json:"lead_ms"`
+}
+
+// handleAnnounce 处理播报命令：纯文本直接播报，JSON可指定提前量
+func (b *MQTTBridge) handleAnnounce(msg mqtt.Message) {
+	payload := strings.TrimSpace(string(msg.Payload))
+	request := mqttAnnounce{Text: payload}
+	if strings.HasPrefix(payload, "{") {
+		if err := json.Unmarshal(msg.Payload, &request); err != nil {
+			log.Printf("MQTT播报命令格式错误: %v", err)
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), mqttAnnounceTimeout)
+	defer cancel()
+	result, err := b.server.Announce(ctx, request.Text, time.Duration(request.LeadMs)*time.Millisecond)
+	if err != nil {
+		log.Printf("MQTT播报失败: %v", err)
+		return
+	}
+	log.Printf("MQTT播报已发送给%d个客户端", result.Clients)
+}
+
+// haDevice Home Assistant设备信息
+type haDevice struct {
+	Identifiers []string `json:"identifiers"`
+	Name        string   `json:"name"`
+	Model       string   `json:"model"`
+}
+
+// haEntity Home Assistant自动发现配置
+type haEntity struct {
+	Name              string   `json:"name"`
+	UniqueID          string   `json:"unique_id"`
+	StateTopic        string   `json:"state_topic,omitempty"`
+	ValueTemplate     string   `json:"value_template,omitempty"`
+	CommandTopic      string   `json:"command_topic,omitempty"`
+	AvailabilityTopic string   `json:"availability_topic"`
+	Icon              string   `json:"icon,omitempty"`
+	Device            haDevice `json:"device"`
+}
+
+// publishDiscovery 发布Home Assistant自动发现配置：最近的识别文本和回复两个传感器，以及一个播报通知实体
+func (b *MQTTBridge) publishDiscovery() {
+	device := haDevice{Identifiers: []string{b.config.ClientID}, Name: "Voice Assistant", Model: "voice_assistant"}
+	// 传感器状态最长255个字符，从JSON主题截取
+	entities := map[string]haEntity{
+		"sensor/%s/transcript/config": {
+			Name:          "Last transcript",
+			StateTopic:    b.topics.Turn,
+			ValueTemplate: "{{ value_json.transcript[:255] }}",
+			Icon:          "mdi:account-voice",
+		},
+		"sensor/%s/reply/config": {
+			Name:          "Last reply",
+			StateTopic:    b.topics.Turn,
+			ValueTemplate: "{{ value_json.reply[:255] }}",
+			Icon:          "mdi:robot",
+		},
+		"notify/%s/announce/config": {
+			Name:         "Announce",
+			CommandTopic: b.topics.Announce,
+			Icon:         "mdi:bullhorn",
+		},
+	}
+
+	prefix := strings.TrimSuffix(b.config.DiscoveryPrefix, "/")
+	for path, entity := range entities {
+		path = fmt.Sprintf(path, b.config.ClientID)
+		entity.UniqueID = strings.ReplaceAll(strings.TrimSuffix(path, "/config"), "/", "_")
+		entity.AvailabilityTopic = b.topics.Status
+		entity.Device = device
+		payload, err := json.Marshal(entity)
+		if err != nil {
+			continue
+		}
+		b.publish(prefix+"/"+path, payload, true)
+	}
+}
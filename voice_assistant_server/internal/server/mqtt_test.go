@@ -0,0 +1,45 @@
+package server
+
+import (
+	"testing"
+
+	"voice_assistant/voice_assistant_server/internal/asr"
+	"voice_assistant/voice_assistant_server/internal/dataset"
+	"voice_assistant/voice_assistant_server/internal/llm"
+	"voice_assistant/voice_assistant_server/internal/webhook"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMQTTBridgeTopics(t *testing.T) {
+	bridge := NewMQTTBridge(MQTTConfig{
+		TopicPrefix: "home/assistant/",
+		Topics:      MQTTTopics{Announce: "home/say"},
+	}, &WebSocketServer{})
+
+	assert.Equal(t, "home/assistant/transcript", bridge.topics.Transcript)
+	assert.Equal(t, "home/assistant/turn", bridge.topics.Turn)
+	assert.Equal(t, "home/say", bridge.topics.Announce)
+}
+
+func TestTurnListenerConsent(t *testing.T) {
+	p := NewMessageProcessor(ProcessorConfig{MaxConcurrentSessions: 10})
+	var turns []webhook.Turn
+	var consents []bool
+	p.AddTurnListener(func(turn webhook.Turn, consented bool) {
+		turns = append(turns, turn)
+		consents = append(consents, consented)
+	})
+
+	response := llm.LLMResponse{Content: "晴天"}
+	p.notifyTurn(&Session{ID: "a", DataConsent: dataset.ConsentGranted}, asr.ASRResult{Confidence: 0.9}, "天气", response, false)
+	p.notifyTurn(&Session{ID: "b"}, asr.ASRResult{}, "天气", response, false)
+	// 明确拒绝的会话不通知
+	p.notifyTurn(&Session{ID: "c", DataConsent: dataset.ConsentDenied}, asr.ASRResult{}, "天气", response, false)
+
+	if assert.Len(t, turns, 2) {
+		assert.Equal(t, "天气", turns[0].Transcript)
+		assert.Equal(t, "晴天", turns[0].Reply)
+		assert.Equal(t, []bool{true, false}, consents)
+	}
+}
@@ -0,0 +1,164 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Session 获取连接上指定会话的客户端视图（不存在时创建）
+// 会话视图与连接共用发送队列，处理器通过视图发出的消息都带有对应的会话ID，客户端据此区分多路对话。
+// maxSessions 为单个连接允许同时打开的会话数（0表示不限制）。
+func (c *Client) Session(sessionID string, maxSessions int) (*Client, error) {
+	conn := c.Connection()
+	if sessionID == "" {
+		sessionID = conn.ID
+	}
+
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+
+	if view, exists := conn.sessions[sessionID]; exists {
+		return view, nil
+	}
+	if maxSessions > 0 && len(conn.sessions) >= maxSessions {
+		return nil, fmt.Errorf("连接上的会话数已达上限: %d", maxSessions)
+	}
+
+	view := conn
+	if sessionID != conn.ID {
+		view = &Client{
+			ID:       sessionID,
+			Conn:     conn.Conn,
+			SendChan: conn.SendChan,
+			Server:   conn.Server,
+			APIKey:   conn.APIKey,
+			conn:     conn,
+		}
+	}
+	if conn.sessions == nil {
+		conn.sessions = make(map[string]*Client)
+	}
+	conn.sessions[sessionID] = view
+	return view, nil
+}
+
+// Connection 获取会话视图所属的连接
+func (c *Client) Connection() *Client {
+	if c.conn != nil {
+		return c.conn
+	}
+	return c
+}
+
+// ReleaseSession 释放连接上的会话名额
+func (c *Client) ReleaseSession(sessionID string) {
+	conn := c.Connection()
+	conn.mu.Lock()
+	delete(conn.sessions, sessionID)
+	conn.mu.Unlock()
+}
+
+// SessionIDs 获取连接上打开的会话ID
+func (c *Client) SessionIDs() []string {
+	conn := c.Connection()
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+
+	ids := make([]string, 0, len(conn.sessions))
+	for id := range conn.sessions {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// turns 获取连接的处理调度器
+func (c *Client) turns(slots int) *turnScheduler {
+	conn := c.Connection()
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+
+	if conn.scheduler == nil {
+		conn.scheduler = newTurnScheduler(slots)
+	}
+	return conn.scheduler
+}
+
+// turnScheduler 连接内的处理调度：同一会话的处理串行执行，不同会话轮流占用有限的并发名额，
+// 避免某个会话连续送入音频时挤占同一连接上的其他会话。
+type turnScheduler struct {
+	slots   int
+	running map[string]bool
+	queues  map[string][]scheduledTurn
+	order   []string // 等待调度的会话（轮询顺序）
+	mu      sync.Mutex
+}
+
+// scheduledTurn 排队的处理任务
+type scheduledTurn struct {
+	run      func()
+	coalesce bool // 可合并：同一会话已有可合并任务排队时不再重复排队
+}
+
+// newTurnScheduler 创建调度器（slots为连接内同时处理的会话数）
+func newTurnScheduler(slots int) *turnScheduler {
+	if slots <= 0 {
+		slots = 1
+	}
+	return &turnScheduler{
+		slots:   slots,
+		running: make(map[string]bool),
+		queues:  make(map[string][]scheduledTurn),
+	}
+}
+
+// Submit 提交会话的处理任务
+func (s *turnScheduler) Submit(sessionID string, run func(), coalesce bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	queue := s.queues[sessionID]
+	if coalesce && len(queue) > 0 && queue[len(queue)-1].coalesce {
+		return
+	}
+	if len(queue) == 0 && !s.running[sessionID] {
+		s.order = append(s.order, sessionID)
+	}
+	s.queues[sessionID] = append(queue, scheduledTurn{run: run, coalesce: coalesce})
+	s.dispatchLocked()
+}
+
+// dispatchLocked 在有空闲名额时按轮询顺序启动任务（调用方需持有锁）
+func (s *turnScheduler) dispatchLocked() {
+	for len(s.running) < s.slots && len(s.order) > 0 {
+		sessionID := s.order[0]
+		s.order = s.order[1:]
+
+		queue := s.queues[sessionID]
+		turn := queue[0]
+		if len(queue) == 1 {
+			delete(s.queues, sessionID)
+		} else {
+			s.queues[sessionID] = queue[1:]
+		}
+
+		s.running[sessionID] = true
+		go func() {
+			defer s.finish(sessionID)
+			turn.run()
+		}()
+	}
+}
+
+// finish 任务结束：会话还有排队任务时排到轮询队尾
+func (s *turnScheduler) finish(sessionID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.running, sessionID)
+	if len(s.queues[sessionID]) > 0 {
+		s.order = append(s.order, sessionID)
+	}
+	s.dispatchLocked()
+}
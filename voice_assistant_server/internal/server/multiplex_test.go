@@ -0,0 +1,105 @@
+package server
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"voice_assistant/pkg/protocol"
+)
+
+func TestClientSessions(t *testing.T) {
+	conn := &Client{ID: "conn", SendChan: make(chan *protocol.Message, 10)}
+
+	// 空会话ID使用连接自身
+	view, err := conn.Session("", 2)
+	require.NoError(t, err)
+	assert.Same(t, conn, view)
+
+	// 其他会话共用发送队列，消息带各自的会话ID
+	view, err = conn.Session("second", 2)
+	require.NoError(t, err)
+	assert.Equal(t, "second", view.ID)
+	assert.Same(t, conn, view.Connection())
+	require.NoError(t, view.SendMessage(protocol.NewMessage(protocol.Status, view.ID, nil)))
+	assert.Equal(t, "second", (<-conn.SendChan).SessionID)
+
+	again, err := view.Session("second", 2)
+	require.NoError(t, err)
+	assert.Same(t, view, again)
+
+	// 超出上限
+	_, err = conn.Session("third", 2)
+	assert.Error(t, err)
+	assert.Equal(t, []string{"conn", "second"}, conn.SessionIDs())
+
+	// 释放后可以打开新会话
+	view.ReleaseSession("second")
+	_, err = conn.Session("third", 2)
+	assert.NoError(t, err)
+}
+
+func TestTurnSchedulerRoundRobin(t *testing.T) {
+	scheduler := newTurnScheduler(1)
+
+	var order []string
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	release := make(chan struct{})
+
+	submit := func(sessionID, name string, block bool) {
+		wg.Add(1)
+		scheduler.Submit(sessionID, func() {
+			defer wg.Done()
+			if block {
+				<-release
+			}
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+		}, false)
+	}
+
+	// a1 占用唯一名额期间，a会话继续排队，b会话随后到达
+	submit("a", "a1", true)
+	submit("a", "a2", false)
+	submit("a", "a3", false)
+	submit("b", "b1", false)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, []string{"a1", "b1", "a2", "a3"}, order)
+}
+
+func TestTurnSchedulerCoalesce(t *testing.T) {
+	scheduler := newTurnScheduler(1)
+
+	var count int
+	var mu sync.Mutex
+	release := make(chan struct{})
+	done := make(chan struct{})
+
+	scheduler.Submit("a", func() { <-release }, false)
+	for i := 0; i < 5; i++ {
+		scheduler.Submit("a", func() {
+			mu.Lock()
+			count++
+			mu.Unlock()
+		}, true)
+	}
+	scheduler.Submit("a", func() { close(done) }, false)
+	close(release)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("调度超时")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 1, count)
+}
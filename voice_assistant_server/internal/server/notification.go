@@ -0,0 +1,382 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"voice_assistant/pkg/protocol"
+	"voice_assistant/voice_assistant_server/internal/pipeline"
+	"voice_assistant/voice_assistant_server/internal/tts"
+)
+
+// 通知跟踪默认值
+const (
+	defaultNotificationRetain     = 24 * time.Hour
+	defaultNotificationMaxTracked = 1000
+)
+
+// NotificationConfig 主动通知配置
+type NotificationConfig struct {
+	RetainFor  time.Duration `yaml:"retain_for"`  // 通知的送达状态保留多久（需要确认的通知在此期间未确认时，会话重新开始后重发）
+	MaxTracked int           `yaml:"max_tracked"` // 最多跟踪的通知数量，超出时丢弃最早的
+}
+
+// NotifyRequest 主动通知请求
+// 指定 session_id 时只发给该会话，指定 user_id 时发给该用户的所有会话，都为空时发给所有会话。
+type NotifyRequest struct {
+	SessionID  string                 `json:"session_id,omitempty"`
+	UserID     string                 `json:"user_id,omitempty"`
+	Kind       string                 `json:"kind,omitempty"`  // message（默认）、alert、update
+	Level      string                 `json:"level,omitempty"` // info（默认）、warning、critical
+	Title      string                 `json:"title,omitempty"`
+	Content    string                 `json:"content"`
+	Data       map[string]interface{} `json:"data,omitempty"`
+	Speak      bool                   `json:"speak,omitempty"`       // 合成语音播报（仅文本模式的会话不播报）
+	RequireAck bool                   `json:"require_ack,omitempty"` // 要求客户端确认
+}
+
+// NotifyResult 主动通知结果
+type NotifyResult struct {
+	ID       string   `json:"id"`
+	Sessions []string `json:"sessions"` // 已发送的会话
+}
+
+// NotificationDelivery 通知在一个会话上的送达状态
+type NotificationDelivery struct {
+	SessionID string     `json:"session_id"`
+	SentAt    *time.Time `json:"sent_at,omitempty"`  // 最近一次发送时间（会话不在线时为空）
+	AckedAt   *time.Time `json:"acked_at,omitempty"` // 客户端确认时间
+}
+
+// NotificationStatus 通知的送达状态（管理接口返回）
+type NotificationStatus struct {
+	ID         string                 `json:"id"`
+	Kind       string                 `json:"kind"`
+	RequireAck bool                   `json:"require_ack"`
+	CreatedAt  time.Time              `json:"created_at"`
+	Deliveries []NotificationDelivery `json:"deliveries"`
+	Acked      int                    `json:"acked"`
+	Pending    int                    `json:"pending"` // 需要确认但尚未确认的会话数
+}
+
+// 通知类型和重要程度的可选值
+var (
+	notificationKinds  = []string{protocol.NotifyMessage, protocol.NotifyAlert, protocol.NotifyUpdate, protocol.NotifyTimer, protocol.NotifyReminder}
+	notificationLevels = []string{protocol.NotifyInfo, protocol.NotifyWarning, protocol.NotifyCritical}
+)
+
+// trackedNotification 跟踪中的通知
+type trackedNotification struct {
+	data       protocol.NotificationData
+	createdAt  time.Time
+	deliveries map[string]*NotificationDelivery
+}
+
+// notificationTracker 记录通知的发送和确认状态
+type notificationTracker struct {
+	config        NotificationConfig
+	mu            sync.Mutex
+	notifications map[string]*trackedNotification
+}
+
+// newNotificationTracker 创建通知跟踪
+func newNotificationTracker(config NotificationConfig) *notificationTracker {
+	if config.RetainFor <= 0 {
+		config.RetainFor = defaultNotificationRetain
+	}
+	if config.MaxTracked <= 0 {
+		config.MaxTracked = defaultNotificationMaxTracked
+	}
+	return &notificationTracker{config: config, notifications: make(map[string]*trackedNotification)}
+}
+
+// track 开始跟踪通知（同一ID再次发送时沿用原记录）
+func (t *notificationTracker) track(data protocol.NotificationData) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.pruneLocked(time.Now())
+	if _, exists := t.notifications[data.ID]; exists {
+		return
+	}
+	t.notifications[data.ID] = &trackedNotification{
+		data:       data,
+		createdAt:  time.Now(),
+		deliveries: make(map[string]*NotificationDelivery),
+	}
+}
+
+// sent 记录通知已发给会话（sentAt为零值表示会话不在线，等待重新开始后发送）
+func (t *notificationTracker) sent(id, sessionID string, sentAt time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	n, exists := t.notifications[id]
+	if !exists {
+		return
+	}
+	delivery, exists := n.deliveries[sessionID]
+	if !exists {
+		delivery = &NotificationDelivery{SessionID: sessionID}
+		n.deliveries[sessionID] = delivery
+	}
+	if !sentAt.IsZero() {
+		delivery.SentAt = &sentAt
+	}
+}
+
+// ack 记录会话确认收到通知，返回通知是否存在
+func (t *notificationTracker) ack(id, sessionID string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	n, exists := t.notifications[id]
+	if !exists {
+		return false
+	}
+	delivery, exists := n.deliveries[sessionID]
+	if !exists {
+		delivery = &NotificationDelivery{SessionID: sessionID}
+		n.deliveries[sessionID] = delivery
+	}
+	if delivery.AckedAt == nil {
+		now := time.Now()
+		delivery.AckedAt = &now
+	}
+	return true
+}
+
+// unacked 会话尚未确认的需要确认的通知（按创建时间排序）
+func (t *notificationTracker) unacked(sessionID string) []protocol.NotificationData {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.pruneLocked(time.Now())
+	var pending []*trackedNotification
+	for _, n := range t.notifications {
+		if delivery, exists := n.deliveries[sessionID]; exists && n.data.RequireAck && delivery.AckedAt == nil {
+			pending = append(pending, n)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].createdAt.Before(pending[j].createdAt) })
+
+	list := make([]protocol.NotificationData, 0, len(pending))
+	for _, n := range pending {
+		list = append(list, n.data)
+	}
+	return list
+}
+
+// status 通知的送达状态
+func (t *notificationTracker) status(id string) (NotificationStatus, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	n, exists := t.notifications[id]
+	if !exists {
+		return NotificationStatus{}, false
+	}
+	status := NotificationStatus{
+		ID:         id,
+		Kind:       n.data.Kind,
+		RequireAck: n.data.RequireAck,
+		CreatedAt:  n.createdAt,
+		Deliveries: make([]NotificationDelivery, 0, len(n.deliveries)),
+	}
+	for _, delivery := range n.deliveries {
+		status.Deliveries = append(status.Deliveries, *delivery)
+		if delivery.AckedAt != nil {
+			status.Acked++
+		} else if n.data.RequireAck {
+			status.Pending++
+		}
+	}
+	sort.Slice(status.Deliveries, func(i, j int) bool { return status.Deliveries[i].SessionID < status.Deliveries[j].SessionID })
+	return status, true
+}
+
+// pruneLocked 丢弃超过保留时长或数量上限的通知（调用方需持有锁）
+func (t *notificationTracker) pruneLocked(now time.Time) {
+	var oldest []*trackedNotification
+	for id, n := range t.notifications {
+		if now.Sub(n.createdAt) > t.config.RetainFor {
+			delete(t.notifications, id)
+			continue
+		}
+		oldest = append(oldest, n)
+	}
+	if len(oldest) < t.config.MaxTracked {
+		return
+	}
+	sort.Slice(oldest, func(i, j int) bool { return oldest[i].createdAt.Before(oldest[j].createdAt) })
+	for _, n := range oldest[:len(oldest)-t.config.MaxTracked+1] {
+		delete(t.notifications, n.data.ID)
+	}
+}
+
+// Notify 向会话推送一条通知
+// 指定的会话不在线且要求确认时，通知保留到会话重新开始后发送。
+func (p *MessageProcessor) Notify(ctx context.Context, req NotifyRequest) (*NotifyResult, error) {
+	if err := req.normalize(); err != nil {
+		return nil, err
+	}
+	if req.Speak && !p.isInitialized {
+		return nil, errors.New("处理器未初始化")
+	}
+
+	data := protocol.NotificationData{
+		ID:         newNotificationID(),
+		Kind:       req.Kind,
+		Level:      req.Level,
+		Title:      req.Title,
+		Content:    req.Content,
+		Data:       req.Data,
+		RequireAck: req.RequireAck,
+	}
+	p.notifications.track(data)
+
+	// 同一语言的会话共用一次合成
+	speech := make(map[string]tts.TTSResult)
+	result := &NotifyResult{ID: data.ID, Sessions: []string{}}
+	for _, session := range p.notifyTargets(req.SessionID, req.UserID) {
+		session.mu.RLock()
+		language, textOnly := session.Language, session.TextOnly
+		session.mu.RUnlock()
+
+		sessionData := data
+		if req.Speak && !textOnly {
+			ttsResult, exists := speech[language]
+			if !exists {
+				var err error
+				ttsResult, err = p.synthesize(withLanguageOptions(ctx, language), pipeline.PriorityInteractive, req.Content)
+				if err != nil {
+					return nil, fmt.Errorf("合成通知语音失败: %w", err)
+				}
+				speech[language] = ttsResult
+			}
+			sessionData.AudioData, sessionData.AudioFormat = ttsResult.AudioData, speechFormat(ttsResult)
+		}
+		if err := p.sendNotification(session, sessionData); err != nil {
+			log.Printf("推送通知失败: %s, %v", session.ID, err)
+			continue
+		}
+		result.Sessions = append(result.Sessions, session.ID)
+	}
+
+	// 指定的会话不在线：要求确认时等会话重新开始后发送
+	if len(result.Sessions) == 0 && req.SessionID != "" && req.RequireAck {
+		p.notifications.sent(data.ID, req.SessionID, time.Time{})
+	}
+	log.Printf("推送通知: %s, 类型: %s, 会话数: %d", data.ID, data.Kind, len(result.Sessions))
+	return result, nil
+}
+
+// normalize 校验通知请求并填充默认的类型和级别
+func (r *NotifyRequest) normalize() error {
+	if r.Content == "" {
+		return errors.New("通知内容不能为空")
+	}
+	if r.Kind == "" {
+		r.Kind = protocol.NotifyMessage
+	}
+	if r.Level == "" {
+		r.Level = protocol.NotifyInfo
+	}
+	if !contains(notificationKinds, r.Kind) {
+		return fmt.Errorf("不支持的通知类型: %s", r.Kind)
+	}
+	if !contains(notificationLevels, r.Level) {
+		return fmt.Errorf("不支持的通知级别: %s", r.Level)
+	}
+	return nil
+}
+
+// NotificationStatus 通知的送达和确认状态
+func (p *MessageProcessor) NotificationStatus(id string) (NotificationStatus, bool) {
+	return p.notifications.status(id)
+}
+
+// notifyTargets 在线的目标会话（sessionID和userID都为空时为全部在线会话）
+func (p *MessageProcessor) notifyTargets(sessionID, userID string) []*Session {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	var targets []*Session
+	for _, session := range p.sessions {
+		session.mu.RLock()
+		matched := (sessionID == "" && userID == "") ||
+			(sessionID != "" && session.ID == sessionID) ||
+			(userID != "" && session.UserID == userID)
+		if matched && session.client != nil && connected(session.client) {
+			targets = append(targets, session)
+		}
+		session.mu.RUnlock()
+	}
+	return targets
+}
+
+// sendNotification 向会话发送通知并记录送达状态
+func (p *MessageProcessor) sendNotification(session *Session, data protocol.NotificationData) error {
+	session.mu.RLock()
+	client := session.client
+	session.mu.RUnlock()
+	if client == nil {
+		return errors.New("会话不在线")
+	}
+
+	p.notifications.track(data)
+	if err := client.SendMessage(protocol.NewMessage(protocol.Notification, client.ID, &data)); err != nil {
+		return err
+	}
+	p.notifications.sent(data.ID, session.ID, time.Now())
+	if len(data.AudioData) > 0 {
+		p.rememberSpoken(session, data.Content)
+	}
+	return nil
+}
+
+// handleAckNotification 处理客户端对通知的确认（参数 id）
+func (p *MessageProcessor) handleAckNotification(client *Client, session *Session, cmdData protocol.CommandData) error {
+	id, _ := cmdData.Parameters["id"].(string)
+	if id == "" {
+		return p.sendError(client, "INVALID_COMMAND_DATA", "缺少 id 参数", true)
+	}
+	if !p.notifications.ack(id, session.ID) {
+		log.Printf("确认的通知不存在或已过期: %s, %s", session.ID, id)
+	}
+	return nil
+}
+
+// resendNotifications 会话开始时重发尚未确认的通知
+func (p *MessageProcessor) resendNotifications(session *Session) {
+	for _, data := range p.notifications.unacked(session.ID) {
+		if err := p.sendNotification(session, data); err != nil {
+			log.Printf("重发通知失败: %s, %v", session.ID, err)
+			return
+		}
+	}
+}
+
+// contains 字符串是否在列表中
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// newNotificationID 随机通知ID
+func newNotificationID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return "ntf_" + hex.EncodeToString(b)
+}
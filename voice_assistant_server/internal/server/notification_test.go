@@ -0,0 +1,71 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"voice_assistant/pkg/protocol"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationAck(t *testing.T) {
+	p := NewMessageProcessor(ProcessorConfig{MaxConcurrentSessions: 10})
+	client := &Client{ID: "s1", SendChan: make(chan *protocol.Message, 10)}
+	session := p.getOrCreateSession("s1", "")
+	session.client = client
+
+	data := protocol.NotificationData{ID: "n1", Kind: protocol.NotifyAlert, Content: "磁盘空间不足", RequireAck: true}
+	require.NoError(t, p.sendNotification(session, data))
+
+	msg := <-client.SendChan
+	assert.Equal(t, protocol.Notification, msg.Type)
+	assert.Equal(t, "n1", msg.Payload.(*protocol.NotificationData).ID)
+
+	// 未确认前会话重新开始时重发
+	assert.Len(t, p.notifications.unacked("s1"), 1)
+	status, exists := p.NotificationStatus("n1")
+	require.True(t, exists)
+	assert.Equal(t, 1, status.Pending)
+
+	ack := protocol.CommandData{Command: protocol.CmdAckNotification, Parameters: map[string]interface{}{"id": "n1"}}
+	require.NoError(t, p.handleAckNotification(client, session, ack))
+	assert.Empty(t, p.notifications.unacked("s1"))
+
+	status, _ = p.NotificationStatus("n1")
+	assert.Equal(t, 1, status.Acked)
+	assert.Equal(t, 0, status.Pending)
+	require.Len(t, status.Deliveries, 1)
+	assert.NotNil(t, status.Deliveries[0].SentAt)
+	assert.NotNil(t, status.Deliveries[0].AckedAt)
+}
+
+func TestNotifyOfflineSession(t *testing.T) {
+	p := NewMessageProcessor(ProcessorConfig{MaxConcurrentSessions: 10})
+
+	_, err := p.Notify(context.Background(), NotifyRequest{Content: "x", Kind: "unknown"})
+	assert.Error(t, err)
+
+	// 指定的会话不在线：要求确认的通知等会话开始后发送
+	result, err := p.Notify(context.Background(), NotifyRequest{SessionID: "s1", Content: "有新版本", Kind: protocol.NotifyUpdate, RequireAck: true})
+	require.NoError(t, err)
+	assert.Empty(t, result.Sessions)
+
+	pending := p.notifications.unacked("s1")
+	require.Len(t, pending, 1)
+	assert.Equal(t, result.ID, pending[0].ID)
+	assert.Equal(t, protocol.NotifyInfo, pending[0].Level)
+}
+
+func TestNotificationTrackerPrune(t *testing.T) {
+	tracker := newNotificationTracker(NotificationConfig{RetainFor: time.Hour, MaxTracked: 2})
+	for _, id := range []string{"a", "b", "c"} {
+		tracker.track(protocol.NotificationData{ID: id})
+	}
+	_, exists := tracker.status("a")
+	assert.False(t, exists)
+	_, exists = tracker.status("c")
+	assert.True(t, exists)
+}
@@ -0,0 +1,374 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"voice_assistant/pkg/protocol"
+	"voice_assistant/voice_assistant_server/internal/llm"
+
+	"github.com/gin-gonic/gin"
+)
+
+// personaIDPattern 人设ID
+var personaIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+var (
+	// ErrPersonaNotFound 人设不存在
+	ErrPersonaNotFound = errors.New("人设不存在")
+	// ErrInvalidPersona 人设ID或内容无效
+	ErrInvalidPersona = errors.New("无效的人设")
+)
+
+// PersonaConfig 人设配置
+type PersonaConfig struct {
+	Default  string             `yaml:"default"`  // 默认人设ID（为空时使用LLM配置的系统提示）
+	File     string             `yaml:"file"`     // 通过管理接口添加或修改的人设保存文件（为空时只保存在内存）
+	Personas map[string]Persona `yaml:"personas"` // 配置文件中定义的人设（按ID）
+}
+
+// Persona 命名的系统提示
+type Persona struct {
+	ID           string    `json:"id" yaml:"-"`
+	Name         string    `json:"name" yaml:"name"`
+	SystemPrompt string    `json:"system_prompt" yaml:"system_prompt"`
+	UpdatedAt    time.Time `json:"updated_at,omitempty" yaml:"-"`
+}
+
+// PersonaStore 人设库：配置文件中的人设加上运行时通过管理接口添加的人设（后者同ID时覆盖前者）
+type PersonaStore struct {
+	path       string
+	defaultID  string
+	personas   map[string]Persona
+	overrides  map[string]Persona // 保存到文件的人设
+	configured map[string]Persona // 配置文件中的人设（删除覆盖后恢复）
+	mu         sync.RWMutex
+}
+
+// NewPersonaStore 加载人设库（保存文件读取失败时仍返回只含配置人设的人设库）
+func NewPersonaStore(config PersonaConfig) (*PersonaStore, error) {
+	store := &PersonaStore{
+		path:       config.File,
+		defaultID:  config.Default,
+		personas:   make(map[string]Persona),
+		overrides:  make(map[string]Persona),
+		configured: make(map[string]Persona),
+	}
+	for id, persona := range config.Personas {
+		persona.ID = id
+		store.configured[id] = persona
+		store.personas[id] = persona
+	}
+	if store.path == "" {
+		return store, nil
+	}
+
+	data, err := os.ReadFile(store.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return store, fmt.Errorf("读取人设文件失败: %w", err)
+	}
+
+	var personas []Persona
+	if err := json.Unmarshal(data, &personas); err != nil {
+		return store, fmt.Errorf("解析人设文件失败: %w", err)
+	}
+	for _, persona := range personas {
+		store.overrides[persona.ID] = persona
+		store.personas[persona.ID] = persona
+	}
+	return store, nil
+}
+
+// Get 按ID查找人设
+func (s *PersonaStore) Get(id string) (Persona, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	persona, ok := s.personas[id]
+	return persona, ok
+}
+
+// Default 默认人设（未配置或已不存在时返回false）
+func (s *PersonaStore) Default() (Persona, bool) {
+	if s.defaultID == "" {
+		return Persona{}, false
+	}
+	return s.Get(s.defaultID)
+}
+
+// DefaultID 默认人设ID
+func (s *PersonaStore) DefaultID() string {
+	return s.defaultID
+}
+
+// List 全部人设（按ID排序）
+func (s *PersonaStore) List() []Persona {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	personas := make([]Persona, 0, len(s.personas))
+	for _, persona := range s.personas {
+		personas = append(personas, persona)
+	}
+	sort.Slice(personas, func(i, j int) bool {
+		return personas[i].ID < personas[j].ID
+	})
+	return personas
+}
+
+// Put 添加或修改人设并保存到文件，立即对之后的请求生效
+func (s *PersonaStore) Put(persona Persona) (Persona, error) {
+	if !personaIDPattern.MatchString(persona.ID) {
+		return Persona{}, fmt.Errorf("%w: 人设ID只能包含字母、数字、下划线和连字符", ErrInvalidPersona)
+	}
+	persona.SystemPrompt = strings.TrimSpace(persona.SystemPrompt)
+	if persona.SystemPrompt == "" {
+		return Persona{}, fmt.Errorf("%w: 系统提示不能为空", ErrInvalidPersona)
+	}
+	if persona.Name == "" {
+		persona.Name = persona.ID
+	}
+	persona.UpdatedAt = time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, existed := s.overrides[persona.ID]
+	s.overrides[persona.ID] = persona
+	if err := s.saveLocked(); err != nil {
+		if existed {
+			s.overrides[persona.ID] = previous
+		} else {
+			delete(s.overrides, persona.ID)
+		}
+		return Persona{}, err
+	}
+	s.personas[persona.ID] = persona
+	return persona, nil
+}
+
+// Delete 删除通过管理接口添加的人设（配置文件中的同名人设随之恢复）；默认人设和仅在配置文件中定义的人设不能删除
+func (s *PersonaStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	persona, exists := s.overrides[id]
+	if !exists {
+		if _, configured := s.configured[id]; configured {
+			return fmt.Errorf("%w: 配置文件中的人设需修改配置文件后删除", ErrInvalidPersona)
+		}
+		return ErrPersonaNotFound
+	}
+	configured, hasConfigured := s.configured[id]
+	if id == s.defaultID && !hasConfigured {
+		return fmt.Errorf("%w: 不能删除默认人设", ErrInvalidPersona)
+	}
+
+	delete(s.overrides, id)
+	if err := s.saveLocked(); err != nil {
+		s.overrides[id] = persona
+		return err
+	}
+	if hasConfigured {
+		s.personas[id] = configured
+	} else {
+		delete(s.personas, id)
+	}
+	return nil
+}
+
+// saveLocked 写入人设文件（先写临时文件再替换，调用方持有写锁）
+func (s *PersonaStore) saveLocked() error {
+	if s.path == "" {
+		return nil
+	}
+
+	personas := make([]Persona, 0, len(s.overrides))
+	for _, persona := range s.overrides {
+		personas = append(personas, persona)
+	}
+	sort.Slice(personas, func(i, j int) bool {
+		return personas[i].ID < personas[j].ID
+	})
+	data, err := json.MarshalIndent(personas, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化人设失败: %w", err)
+	}
+
+	if dir := filepath.Dir(s.path); dir != "" {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return fmt.Errorf("创建人设目录失败: %w", err)
+		}
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("写入人设文件失败: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("写入人设文件失败: %w", err)
+	}
+	return nil
+}
+
+// resolvePersona 会话使用的人设（会话未选择或所选人设已被删除时使用默认人设）
+func (p *MessageProcessor) resolvePersona(id string) (Persona, bool) {
+	if p.personas == nil {
+		return Persona{}, false
+	}
+	if id != "" {
+		if persona, ok := p.personas.Get(id); ok {
+			return persona, true
+		}
+	}
+	return p.personas.Default()
+}
+
+// withPersona 用会话人设的系统提示替换服务配置的系统提示
+func (p *MessageProcessor) withPersona(ctx context.Context, id string) context.Context {
+	persona, ok := p.resolvePersona(id)
+	if !ok {
+		return ctx
+	}
+
+	opts := llm.RequestOptionsFromContext(ctx)
+	opts.SystemPrompt = persona.SystemPrompt
+	return llm.WithRequestOptions(ctx, opts)
+}
+
+// systemPrompt 会话实际使用的系统提示
+func (p *MessageProcessor) systemPrompt(id string) string {
+	if persona, ok := p.resolvePersona(id); ok {
+		return persona.SystemPrompt
+	}
+	return p.config.LLMConfig.SystemPrompt
+}
+
+// parsePersonaParameter 从命令参数读取人设ID（未指定时返回空字符串和false）
+func (p *MessageProcessor) parsePersonaParameter(params map[string]interface{}) (string, bool, error) {
+	value, exists := params["persona"]
+	if !exists {
+		return "", false, nil
+	}
+	id, ok := value.(string)
+	if !ok {
+		return "", false, fmt.Errorf("persona 参数必须是字符串")
+	}
+	if id == "" {
+		return "", true, nil
+	}
+	if persona, found := p.resolvePersona(id); !found || persona.ID != id {
+		return "", false, fmt.Errorf("未知的人设: %s", id)
+	}
+	return id, true, nil
+}
+
+// handleSetPersona 处理切换人设（persona为空时恢复默认人设），对之后的回复生效
+func (p *MessageProcessor) handleSetPersona(client *Client, session *Session, cmdData protocol.CommandData) error {
+	id, ok, err := p.parsePersonaParameter(cmdData.Parameters)
+	if err != nil {
+		return p.sendError(client, "INVALID_COMMAND_DATA", err.Error(), true)
+	}
+	if !ok {
+		return p.sendError(client, "INVALID_COMMAND_DATA", "缺少 persona 参数", true)
+	}
+
+	session.mu.Lock()
+	session.Persona = id
+	session.mu.Unlock()
+
+	log.Printf("会话人设已更新: %s, 人设: %s", session.ID, p.sessionPersonaID(id))
+	return p.sendStatus(client, session)
+}
+
+// sessionPersonaID 会话实际使用的人设ID（未选择时为默认人设）
+func (p *MessageProcessor) sessionPersonaID(id string) string {
+	if persona, ok := p.resolvePersona(id); ok {
+		return persona.ID
+	}
+	return ""
+}
+
+// PersonaRequest 添加或修改人设的请求
+type PersonaRequest struct {
+	Name         string `json:"name"`
+	SystemPrompt string `json:"system_prompt"`
+}
+
+// handlePersonaList 全部人设
+func (h *RESTHandler) handlePersonaList(c *gin.Context) {
+	if !h.ready(c) {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"default":  h.processor.personas.DefaultID(),
+		"personas": h.processor.personas.List(),
+	})
+}
+
+// handlePersonaPut 添加或修改人设（管理接口，无需重启，对之后的回复生效）
+func (h *RESTHandler) handlePersonaPut(c *gin.Context) {
+	if !h.ready(c) {
+		return
+	}
+
+	var req PersonaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	persona, err := h.processor.personas.Put(Persona{
+		ID:           c.Param("id"),
+		Name:         req.Name,
+		SystemPrompt: req.SystemPrompt,
+	})
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrInvalidPersona) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": "保存人设失败: " + err.Error()})
+		return
+	}
+
+	log.Printf("已保存人设: %s (%s)", persona.ID, persona.Name)
+	c.JSON(http.StatusOK, persona)
+}
+
+// handlePersonaDelete 删除通过管理接口添加的人设
+func (h *RESTHandler) handlePersonaDelete(c *gin.Context) {
+	if !h.ready(c) {
+		return
+	}
+
+	id := c.Param("id")
+	if err := h.processor.personas.Delete(id); err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, ErrPersonaNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, ErrInvalidPersona):
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	log.Printf("已删除人设: %s", id)
+	c.JSON(http.StatusOK, gin.H{"id": id})
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"voice_assistant/voice_assistant_server/internal/llm"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPersonaStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "personas.json")
+	config := PersonaConfig{
+		Default: "assistant",
+		File:    path,
+		Personas: map[string]Persona{
+			"assistant": {Name: "助手", SystemPrompt: "你是语音助手"},
+		},
+	}
+
+	store, err := NewPersonaStore(config)
+	require.NoError(t, err)
+	persona, ok := store.Default()
+	require.True(t, ok)
+	assert.Equal(t, "assistant", persona.ID)
+
+	_, err = store.Put(Persona{ID: "bad id", SystemPrompt: "x"})
+	assert.ErrorIs(t, err, ErrInvalidPersona)
+	_, err = store.Put(Persona{ID: "pirate", SystemPrompt: " "})
+	assert.ErrorIs(t, err, ErrInvalidPersona)
+
+	_, err = store.Put(Persona{ID: "pirate", SystemPrompt: "你是海盗"})
+	require.NoError(t, err)
+	_, err = store.Put(Persona{ID: "assistant", SystemPrompt: "你是简洁的助手"})
+	require.NoError(t, err)
+
+	// 重新加载后保留运行时添加和修改的人设
+	reloaded, err := NewPersonaStore(config)
+	require.NoError(t, err)
+	assert.Len(t, reloaded.List(), 2)
+	persona, _ = reloaded.Get("assistant")
+	assert.Equal(t, "你是简洁的助手", persona.SystemPrompt)
+
+	// 删除覆盖后恢复配置文件中的人设，配置文件中的人设本身不能删除
+	require.NoError(t, reloaded.Delete("assistant"))
+	persona, _ = reloaded.Get("assistant")
+	assert.Equal(t, "你是语音助手", persona.SystemPrompt)
+	assert.ErrorIs(t, reloaded.Delete("assistant"), ErrInvalidPersona)
+	assert.ErrorIs(t, reloaded.Delete("missing"), ErrPersonaNotFound)
+}
+
+func TestWithPersona(t *testing.T) {
+	p := NewMessageProcessor(ProcessorConfig{
+		MaxConcurrentSessions: 10,
+		Persona: PersonaConfig{
+			Default: "assistant",
+			Personas: map[string]Persona{
+				"assistant": {SystemPrompt: "你是语音助手"},
+				"pirate":    {SystemPrompt: "你是海盗"},
+			},
+		},
+	})
+
+	prompt := func(id string) string {
+		return llm.RequestOptionsFromContext(p.withPersona(context.Background(), id)).SystemPrompt
+	}
+	assert.Equal(t, "你是海盗", prompt("pirate"))
+	// 未选择或所选人设不存在时使用默认人设
+	assert.Equal(t, "你是语音助手", prompt(""))
+	assert.Equal(t, "你是语音助手", prompt("deleted"))
+
+	_, _, err := p.parsePersonaParameter(map[string]interface{}{"persona": "unknown"})
+	assert.Error(t, err)
+	id, ok, err := p.parsePersonaParameter(map[string]interface{}{"persona": "pirate"})
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "pirate", id)
+}
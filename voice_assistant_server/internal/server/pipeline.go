@@ -0,0 +1,188 @@
+package server
+
+import (
+	"context"
+
+	"voice_assistant/pkg/protocol"
+	"voice_assistant/voice_assistant_server/internal/asr"
+	"voice_assistant/voice_assistant_server/internal/compute"
+	"voice_assistant/voice_assistant_server/internal/llm"
+	"voice_assistant/voice_assistant_server/internal/pipeline"
+	"voice_assistant/voice_assistant_server/internal/tts"
+)
+
+// sessionPriority 会话在工作池中的优先级（未设置时按交互会话处理）
+func sessionPriority(session *Session) pipeline.Priority {
+	session.mu.RLock()
+	defer session.mu.RUnlock()
+	if session.Priority == "" {
+		return pipeline.PriorityInteractive
+	}
+	return session.Priority
+}
+
+// applyPriorityParameter 应用命令参数中的priority设置，返回是否包含该参数
+func applyPriorityParameter(session *Session, params map[string]interface{}) bool {
+	value, ok := params["priority"].(string)
+	if !ok {
+		return false
+	}
+	priority, ok := pipeline.ParsePriority(value)
+	if !ok {
+		return false
+	}
+	session.Priority = priority
+	return true
+}
+
+// runStage 在阶段的工作池上执行任务，本地模型的阶段先申请计算资源；阶段超时包含排队等待的时间
+func (p *MessageProcessor) runStage(ctx context.Context, stage pipeline.Stage, priority pipeline.Priority, run func(ctx context.Context) error) error {
+	if timeout := p.config.Pipeline.Timeout(stage); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return p.workers.Do(ctx, stage, priority, func(ctx context.Context) error {
+		release, err := p.compute.Acquire(ctx, stage, priority)
+		if err != nil {
+			return err
+		}
+		defer release()
+		return run(ctx)
+	})
+}
+
+// withSessionOwner 工作池按会话和API Key调度本轮的任务
+func withSessionOwner(ctx context.Context, session *Session) context.Context {
+	session.mu.RLock()
+	owner := pipeline.Owner{Session: session.ID, APIKey: session.APIKey}
+	session.mu.RUnlock()
+	return pipeline.WithOwner(ctx, owner)
+}
+
+// sessionScheduling 会话在工作池中的请求数（未启用工作池时为nil）
+func (p *MessageProcessor) sessionScheduling(session *Session) *protocol.Scheduling {
+	if len(p.workers.Stats()) == 0 {
+		return nil
+	}
+	stats := p.workers.SessionStats(session.ID)
+	return &protocol.Scheduling{
+		Active:        stats.Active,
+		Queued:        stats.Queued,
+		MaxPerSession: p.config.Pipeline.MaxPerSession,
+	}
+}
+
+// recognize 经ASR工作池识别音频
+func (p *MessageProcessor) recognize(ctx context.Context, priority pipeline.Priority, audio []byte) (asr.ASRResult, error) {
+	var result asr.ASRResult
+	err := p.runStage(ctx, pipeline.StageASR, priority, func(ctx context.Context) error {
+		var err error
+		result, err = p.asrService.ProcessAudio(ctx, audio)
+		p.health.recordError(pipeline.StageASR, err)
+		return err
+	})
+	if err == nil {
+		p.recordUsage(ctx, protocol.UsageTotals{ASRSeconds: float64(len(audio)) / float64(pcmBytesPerMillisecond*1000)})
+	}
+	return result, err
+}
+
+// chat 经LLM工作池生成对话回复
+func (p *MessageProcessor) chat(ctx context.Context, priority pipeline.Priority, input, conversationID string) (llm.LLMResponse, error) {
+	var response llm.LLMResponse
+	err := p.runStage(ctx, pipeline.StageLLM, priority, func(ctx context.Context) error {
+		var err error
+		response, err = p.llmService.Chat(ctx, input, conversationID)
+		p.health.recordError(pipeline.StageLLM, err)
+		return err
+	})
+	if err == nil {
+		p.recordUsage(ctx, tokenUsage(response, 1))
+	}
+	return response, err
+}
+
+// generate 经LLM工作池按完整消息列表生成回复
+func (p *MessageProcessor) generate(ctx context.Context, priority pipeline.Priority, messages []llm.Message) (llm.LLMResponse, error) {
+	var response llm.LLMResponse
+	err := p.runStage(ctx, pipeline.StageLLM, priority, func(ctx context.Context) error {
+		var err error
+		response, err = p.llmService.GenerateResponse(ctx, messages)
+		p.health.recordError(pipeline.StageLLM, err)
+		return err
+	})
+	if err == nil {
+		p.recordUsage(ctx, tokenUsage(response, 0))
+	}
+	return response, err
+}
+
+// synthesize 经TTS工作池合成语音（先规范化文本）
+func (p *MessageProcessor) synthesize(ctx context.Context, priority pipeline.Priority, text string) (tts.TTSResult, error) {
+	return p.synthesizeSpeakable(ctx, priority, p.speakableText(ctx, text))
+}
+
+// synthesizeSpeakable 经TTS工作池合成已规范化的文本
+func (p *MessageProcessor) synthesizeSpeakable(ctx context.Context, priority pipeline.Priority, text string) (tts.TTSResult, error) {
+	var result tts.TTSResult
+	err := p.runStage(ctx, pipeline.StageTTS, priority, func(ctx context.Context) error {
+		var err error
+		result, err = p.ttsService.SynthesizeText(ctx, text)
+		p.health.recordError(pipeline.StageTTS, err)
+		return err
+	})
+	if err != nil {
+		return result, err
+	}
+	result = p.normalizeSpeech(result)
+	p.recordUsage(ctx, protocol.UsageTotals{TTSSeconds: float64(result.Duration) / 1000})
+	return result, nil
+}
+
+// speakableText 合成前规范化文本（按本次请求的TTS语言展开数字；规范化后为空时，如只有表情符号，保留原文）
+func (p *MessageProcessor) speakableText(ctx context.Context, text string) string {
+	if p.normalizer == nil {
+		return text
+	}
+
+	language := tts.RequestOptionsFromContext(ctx).Language
+	if language == "" {
+		language = p.config.TTSConfig.Language
+	}
+	if normalized := p.normalizer.Normalize(text, language); normalized != "" {
+		return normalized
+	}
+	return text
+}
+
+// transcriptText 规范化识别文本，用于显示和后续处理（LLM、钩子、对话历史）；归档记录保留原始识别文本
+func (p *MessageProcessor) transcriptText(ctx context.Context, result asr.ASRResult, language string) string {
+	if p.transcriptNormalizer == nil {
+		return result.Text
+	}
+	if result.Language != "" {
+		language = result.Language
+	}
+	return p.transcriptNormalizer.Normalize(ctx, result.Text, language, result.IsFinal)
+}
+
+// tokenUsage LLM回复的Token用量（turns为计入的对话轮数）
+func tokenUsage(response llm.LLMResponse, turns int) protocol.UsageTotals {
+	return protocol.UsageTotals{
+		Turns:            turns,
+		PromptTokens:     response.TokenUsage.PromptTokens,
+		CompletionTokens: response.TokenUsage.CompletionTokens,
+		TotalTokens:      response.TokenUsage.TotalTokens,
+	}
+}
+
+// PipelineStats 获取各处理阶段工作池的队列深度和处理统计
+func (p *MessageProcessor) PipelineStats() map[pipeline.Stage]pipeline.StageStats {
+	return p.workers.Stats()
+}
+
+// ComputeStats 获取本地模型所用GPU/CPU的占用、排队和利用率（未启用时为空）
+func (p *MessageProcessor) ComputeStats() map[string]compute.DeviceStats {
+	return p.compute.Stats()
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"log"
+	"time"
+
+	"voice_assistant/pkg/protocol"
+	"voice_assistant/voice_assistant_server/internal/tts"
+)
+
+// 客户端超过语音时长加该余量仍未上报播放完毕时，自动恢复聆听（避免客户端异常时会话一直停在应答状态）
+const playbackReportGrace = 5 * time.Second
+
+// applyPlaybackParameter 解析会话参数中的report_playback（客户端会在语音播放完毕后上报playback_finished）
+func applyPlaybackParameter(session *Session, params map[string]interface{}) {
+	if report, ok := params["report_playback"].(bool); ok {
+		session.ReportsPlayback = report
+	}
+}
+
+// speechDuration TTS语音时长（提供方未给出时按16kHz 16bit单声道估算）
+func speechDuration(result tts.TTSResult) time.Duration {
+	if result.Duration > 0 {
+		return time.Duration(result.Duration) * time.Millisecond
+	}
+	return time.Duration(len(result.AudioData)) * time.Second / 32000
+}
+
+// awaitPlaybackLocked 语音已下发时让连续模式会话保持应答状态，等客户端上报播放完毕后再恢复聆听，返回是否进入等待（调用方持有会话锁）
+// 仅对在start_session中声明report_playback的会话生效（双工模式客户端一直在录音，无需等待）；客户端超时未上报时自动恢复。
+func (p *MessageProcessor) awaitPlaybackLocked(client *Client, session *Session, speech time.Duration) bool {
+	if speech <= 0 || !session.ReportsPlayback || !session.ContinuousMode || session.Duplex {
+		return false
+	}
+
+	session.State = StateResponding
+	session.playbackWait++
+	wait := session.playbackWait
+	session.awaitingPlayback = true
+	if session.playbackTimer != nil {
+		session.playbackTimer.Stop()
+	}
+	session.playbackTimer = time.AfterFunc(speech+playbackReportGrace, func() {
+		if p.finishPlayback(client, session, wait) {
+			log.Printf("客户端未上报语音播放完毕，自动恢复聆听: %s", session.ID)
+		}
+	})
+	return true
+}
+
+// finishPlayback 结束播放等待并恢复聆听，返回是否恢复（wait为0时结束当前的等待）
+func (p *MessageProcessor) finishPlayback(client *Client, session *Session, wait uint64) bool {
+	session.mu.Lock()
+	if !session.awaitingPlayback || wait != 0 && wait != session.playbackWait {
+		session.mu.Unlock()
+		return false
+	}
+	session.awaitingPlayback = false
+	if session.playbackTimer != nil {
+		session.playbackTimer.Stop()
+		session.playbackTimer = nil
+	}
+	// 等待期间会话可能已被停止、切换模式或释放
+	released := session.ctx != nil && session.ctx.Err() != nil
+	resumed := !released && session.State == StateResponding && session.ContinuousMode
+	if resumed {
+		session.State = StateListening
+	}
+	session.mu.Unlock()
+
+	if resumed {
+		p.sendStatus(client, session)
+	}
+	return resumed
+}
+
+// handleClientStatus 处理客户端上报的状态
+func (p *MessageProcessor) handleClientStatus(client *Client, session *Session, msg *protocol.Message) error {
+	statusData, err := msg.StatusData()
+	if err != nil {
+		return p.sendError(client, "INVALID_STATUS_DATA", "无效的状态数据", true)
+	}
+
+	switch statusData.State {
+	case protocol.StatePlaybackFinished:
+		session.mu.Lock()
+		session.LastActivity = time.Now()
+		session.mu.Unlock()
+		p.finishPlayback(client, session, 0)
+	case protocol.StateSpeechStart, protocol.StateSpeechEnd:
+		p.handleVoiceActivity(client, session, statusData.State == protocol.StateSpeechStart)
+	default:
+		log.Printf("忽略客户端上报的状态: %s", statusData.State)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"voice_assistant/pkg/protocol"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAwaitPlayback(t *testing.T) {
+	p := NewMessageProcessor(ProcessorConfig{MaxConcurrentSessions: 10})
+	p.isInitialized = true
+	client := &Client{ID: "playback", SendChan: make(chan *protocol.Message, 10)}
+	session := p.getOrCreateSession(client.ID, "")
+	session.ContinuousMode = true
+
+	// 未声明上报播放进度的会话直接恢复聆听
+	session.mu.Lock()
+	assert.False(t, p.awaitPlaybackLocked(client, session, time.Second))
+	session.ReportsPlayback = true
+	// 双工模式的客户端一直在录音，不等待播放完毕
+	applySessionMode(session, protocol.ModeDuplex)
+	assert.False(t, p.awaitPlaybackLocked(client, session, time.Second))
+	assert.Equal(t, protocol.ModeDuplex, session.modeLocked())
+	applySessionMode(session, protocol.ModeContinuous)
+	assert.True(t, p.awaitPlaybackLocked(client, session, time.Second))
+	session.mu.Unlock()
+	assert.Equal(t, StateResponding, session.State)
+
+	finished := protocol.NewMessage(protocol.Status, client.ID, &protocol.StatusData{State: protocol.StatePlaybackFinished})
+	require.NoError(t, p.ProcessMessage(client, finished))
+	assert.Equal(t, StateListening, session.State)
+
+	status := <-client.SendChan
+	require.Equal(t, protocol.Status, status.Type)
+	assert.Equal(t, string(StateListening), status.Payload.(*protocol.StatusData).State)
+
+	// 重复上报不再发送状态
+	require.NoError(t, p.ProcessMessage(client, finished))
+	assert.Empty(t, client.SendChan)
+}
+
+func TestAwaitPlaybackTimeout(t *testing.T) {
+	p := NewMessageProcessor(ProcessorConfig{MaxConcurrentSessions: 10})
+	client := &Client{ID: "playback", SendChan: make(chan *protocol.Message, 10)}
+	session := p.getOrCreateSession(client.ID, "")
+	session.ContinuousMode = true
+	session.ReportsPlayback = true
+
+	session.mu.Lock()
+	require.True(t, p.awaitPlaybackLocked(client, session, time.Second))
+	wait := session.playbackWait
+	session.mu.Unlock()
+
+	// 过期的等待不影响新一轮
+	session.mu.Lock()
+	require.True(t, p.awaitPlaybackLocked(client, session, time.Second))
+	session.mu.Unlock()
+	assert.False(t, p.finishPlayback(client, session, wait))
+	assert.Equal(t, StateResponding, session.State)
+
+	// 超时未上报时自动恢复聆听
+	assert.True(t, p.finishPlayback(client, session, wait+1))
+	assert.Equal(t, StateListening, session.State)
+}
@@ -2,16 +2,28 @@ package server
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
 	"sync"
 	"time"
 
+	vaaudio "voice_assistant/pkg/audio"
 	"voice_assistant/pkg/protocol"
+	"voice_assistant/voice_assistant_server/internal/archive"
 	"voice_assistant/voice_assistant_server/internal/asr"
+	"voice_assistant/voice_assistant_server/internal/compute"
+	"voice_assistant/voice_assistant_server/internal/dataset"
+	"voice_assistant/voice_assistant_server/internal/hooks"
 	"voice_assistant/voice_assistant_server/internal/llm"
+	"voice_assistant/voice_assistant_server/internal/memory"
+	"voice_assistant/voice_assistant_server/internal/moderation"
+	"voice_assistant/voice_assistant_server/internal/pipeline"
+	"voice_assistant/voice_assistant_server/internal/rag"
+	"voice_assistant/voice_assistant_server/internal/reminder"
+	"voice_assistant/voice_assistant_server/internal/speaker"
+	"voice_assistant/voice_assistant_server/internal/textnorm"
 	"voice_assistant/voice_assistant_server/internal/tts"
+	"voice_assistant/voice_assistant_server/internal/webhook"
 )
 
 // MessageProcessor 消息处理器
@@ -21,6 +33,70 @@ type MessageProcessor struct {
 	llmService llm.LLMService
 	ttsService tts.TTSService
 
+	// 数据采集输出（未启用时为nil）
+	datasetSink dataset.Sink
+
+	// 语音归档（未启用时为nil）
+	archiver *archive.Archiver
+
+	// Webhook事件推送（未启用时为nil）
+	webhooks *webhook.Dispatcher
+
+	// 对话完成监听（MQTT桥接等）
+	turnListeners []TurnListener
+
+	// 定时提醒和计时器（未启用时为nil）
+	reminders *reminder.Scheduler
+
+	// 主动通知的送达和确认状态
+	notifications *notificationTracker
+
+	// 会话转移令牌（未启用时为nil）
+	handovers *handoverStore
+
+	// 资源配额统计（未启用时为nil）
+	quotas *QuotaTracker
+
+	// 各处理阶段的工作池
+	workers *pipeline.Pool
+
+	// 本地模型的计算资源管理（未启用时为nil）
+	compute *compute.Manager
+
+	// 用户长期记忆（未启用时为nil）
+	memory *memory.Manager
+
+	// 知识库检索（未启用时为nil）
+	knowledge       *rag.Retriever
+	knowledgeCancel context.CancelFunc
+
+	// 说话人识别（未启用时为nil）
+	speakers *speaker.Identifier
+
+	// 人设
+	personas *PersonaStore
+
+	// 内容审核（未启用时为nil）
+	moderator *moderation.Moderator
+
+	// 处理流程钩子（未启用时为nil）
+	hooks *hooks.Chain
+
+	// 合成前文本规范化（未启用时为nil）
+	normalizer *textnorm.Normalizer
+
+	// 识别结果规范化（未启用时为nil）
+	transcriptNormalizer *textnorm.TranscriptNormalizer
+
+	// 语音回复长度计数（未限制时为nil）
+	responseTokenizer llm.Tokenizer
+
+	// 用量统计（未启用时为nil）
+	usage *UsageTracker
+
+	// 后端组件健康状态
+	health *healthMonitor
+
 	// 配置
 	config ProcessorConfig
 
@@ -43,6 +119,85 @@ type ProcessorConfig struct {
 	MaxConcurrentSessions int  `yaml:"max_concurrent_sessions"`
 	SessionTimeout        int  `yaml:"session_timeout"` // 秒
 	AudioBufferSize       int  `yaml:"audio_buffer_size"`
+
+	// 数据采集
+	DataCollection dataset.Config `yaml:"data_collection"`
+
+	// 回声抑制
+	EchoSuppression EchoSuppressionConfig `yaml:"echo_suppression"`
+
+	// 服务端断句
+	Endpointing EndpointingConfig `yaml:"endpointing"`
+
+	// 资源配额
+	Quota QuotaConfig `yaml:"quota"`
+
+	// 语音与文本归档
+	Archive archive.Config `yaml:"archive"`
+
+	// Webhook事件推送
+	Webhook webhook.Config `yaml:"webhook"`
+
+	// 定时提醒和计时器
+	Reminders reminder.Config `yaml:"reminders"`
+
+	// 主动通知
+	Notifications NotificationConfig `yaml:"notifications"`
+
+	// 会话转移到其他设备
+	Handover HandoverConfig `yaml:"handover"`
+
+	// 连接复用：单个连接可同时打开的会话数（0表示不限制）和同时处理的会话数
+	MaxSessionsPerConnection int `yaml:"max_sessions_per_connection"`
+	MaxTurnsPerConnection    int `yaml:"max_turns_per_connection"`
+
+	// 多客户端会话：单个会话的成员数上限（0表示不限制）
+	MaxMembersPerSession int `yaml:"max_members_per_session"`
+
+	// TTS语音分片：单条消息携带的最大音频字节数（0表示不分片）
+	AudioChunkSize int `yaml:"audio_chunk_size"`
+
+	// 处理工作池：各阶段的并发数和排队上限
+	Pipeline pipeline.Config `yaml:"pipeline"`
+
+	// 本地模型的GPU/CPU资源管理
+	Compute compute.Config `yaml:"compute"`
+
+	// 用户长期记忆
+	Memory memory.Config `yaml:"memory"`
+
+	// 知识库检索
+	Knowledge rag.Config `yaml:"knowledge"`
+
+	// 说话人识别
+	Speaker speaker.Config `yaml:"speaker"`
+
+	// 人设（命名的系统提示）
+	Persona PersonaConfig `yaml:"persona"`
+
+	// 内容审核
+	Moderation moderation.Config `yaml:"moderation"`
+
+	// 处理流程钩子
+	Hooks hooks.Config `yaml:"hooks"`
+
+	// 插话更正
+	Correction CorrectionConfig `yaml:"correction"`
+
+	// 合成前文本规范化
+	TextNorm textnorm.Config `yaml:"text_normalization"`
+
+	// 识别结果规范化
+	TranscriptNorm textnorm.TranscriptConfig `yaml:"transcript_normalization"`
+
+	// 分句合成
+	SegmentedSpeech SegmentedSpeechConfig `yaml:"segmented_speech"`
+
+	// 语音轮次长度限制
+	Limits LimitsConfig `yaml:"limits"`
+
+	// 用量统计与预算
+	Usage UsageConfig `yaml:"usage"`
 }
 
 // Session 会话状态
@@ -50,19 +205,64 @@ type Session struct {
 	ID             string
 	State          SessionState
 	ConversationID string
-	AudioBuffer    []byte
+	AudioBuffer    AudioRing // 本句已收到的音频（有容量上限，写满后丢弃最早的音频）
 	LastActivity   time.Time
 	IsProcessing   bool
 	ContinuousMode bool
+	Mode           string               // 会话模式（start_session/set_mode指定，未启动时为空）
+	Duplex         bool                 // 全双工模式（按连续模式处理，客户端播放回复时也在录音）
+	Language       string               // 会话语言（为空时使用服务默认配置）
+	pendingFinal   bool                 // 处理中间结果时收到了最终音频块
+	DataConsent    dataset.Consent      // 数据采集授权状态
+	TextOnly       bool                 // 仅文本模式（不进行TTS合成）
+	recentSpoken   []spokenText         // 最近播放的TTS文本（用于回声抑制）
+	Tenant         string               // 租户（用于资源配额）
+	UserID         string               // 用户ID（用于资源配额，为空时按会话计）
+	Priority       pipeline.Priority    // 工作池优先级（为空时按交互会话处理）
+	Voice          SessionVoice         // 会话级声音和语速（为空时使用服务配置）
+	Speaker        *asr.Speaker         // 最近一句识别到的说话人（未识别时为nil）
+	Persona        string               // 会话人设ID（为空时使用默认人设）
+	APIKey         string               // 连接携带的API Key（用于用量统计）
+	Usage          protocol.UsageTotals // 会话累计用量（启用用量统计时记录）
+	Turns          int                  // 已完成的对话轮数
+	lastUserInput  string               // 上一轮用户的提问（用于识别插话更正）
+	lastTurnAt     time.Time            // 上一轮完成的时间
+	CreatedAt      time.Time
+	client         *Client               // 最近发来消息的会话视图（管理接口终止会话时用于通知客户端）
+	group          *sessionGroup         // 其他客户端加入后的会话成员（没有其他客户端加入时为nil）
+	owner          string                // 创建会话的连接ID（只有所有者可以邀请其他客户端加入）
+	invites        map[string]joinInvite // 所有者发出、尚未使用的加入码
+
+	// 模式约束：唤醒词模式在唤醒后才接受音频，单轮模式一轮结束后不再接受音频
+	awake         bool
+	ended         bool
+	audioRejected bool // 已提示过客户端音频被拒绝（每次等待只提示一次）
+
+	utteranceClipped bool // 本句音频已超过时长上限（每句只提示一次）
+	bufferOverflowed bool // 本句音频已超过缓冲区上限（每句只提示一次）
+
+	// 服务端断句：最后一个音频块之后静默超过该时长时结束本句（0表示只依赖客户端的is_final）
+	EndpointSilence time.Duration
+	endpointWait    uint64
+	endpointTimer   *time.Timer
+	speechActive    bool // 客户端上报speech_start后、speech_end前（期间不按静默计时断句）
+
+	// 语音播放上报：客户端在播放完毕后上报playback_finished，会话在此之前保持应答状态
+	ReportsPlayback  bool
+	awaitingPlayback bool
+	playbackWait     uint64
+	playbackTimer    *time.Timer
 
 	// 处理通道
 	audioStreamChan chan []byte
 	responseChan    chan *protocol.Message
 
-	// 上下文
-	ctx    context.Context
-	cancel context.CancelFunc
-	mu     sync.RWMutex
+	// 上下文：会话释放时取消ctx；turnCancel取消进行中的一轮处理（stop_session、interrupt）
+	ctx        context.Context
+	cancel     context.CancelFunc
+	turnCancel context.CancelFunc
+	turnSeq    uint64
+	mu         sync.RWMutex
 }
 
 // SessionState 会话状态
@@ -78,10 +278,29 @@ const (
 
 // NewMessageProcessor 创建消息处理器
 func NewMessageProcessor(config ProcessorConfig) *MessageProcessor {
-	return &MessageProcessor{
+	processor := &MessageProcessor{
 		config:   config,
 		sessions: make(map[string]*Session),
+		workers:  pipeline.NewPool(config.Pipeline),
+		health:   newHealthMonitor(),
+
+		notifications: newNotificationTracker(config.Notifications),
+	}
+	if config.Quota.Enabled {
+		processor.quotas = NewQuotaTracker(config.Quota)
+	}
+	if config.Usage.Enabled {
+		processor.usage = NewUsageTracker(config.Usage)
+	}
+	if config.Handover.Enabled {
+		processor.handovers = newHandoverStore(config.Handover)
 	}
+	personas, err := NewPersonaStore(config.Persona)
+	if err != nil {
+		log.Printf("MessageProcessor: 加载人设失败，仅使用配置文件中的人设: %v", err)
+	}
+	processor.personas = personas
+	return processor
 }
 
 // Initialize 初始化处理器
@@ -121,6 +340,140 @@ func (p *MessageProcessor) Initialize() error {
 	}
 	p.ttsService = ttsService
 
+	// 初始化数据采集输出
+	if p.config.DataCollection.Enabled {
+		sink, err := dataset.CreateSink(p.config.DataCollection)
+		if err != nil {
+			return fmt.Errorf("创建数据采集输出失败: %w", err)
+		}
+		p.datasetSink = sink
+		log.Printf("MessageProcessor: 数据采集已启用 (%s, 需授权: %t, 脱敏: %t)",
+			p.config.DataCollection.Sink, p.config.DataCollection.RequireConsent, p.config.DataCollection.Redact)
+	}
+
+	// 初始化语音归档
+	if p.config.Archive.Enabled {
+		archiver, err := archive.NewArchiver(p.config.Archive)
+		if err != nil {
+			return fmt.Errorf("创建语音归档失败: %w", err)
+		}
+		p.archiver = archiver
+		log.Printf("MessageProcessor: 语音归档已启用 (%s, 保留天数: %d, 需授权: %t)",
+			p.config.Archive.Store, p.config.Archive.RetentionDays, p.config.Archive.RequireConsent)
+	}
+
+	// 初始化Webhook事件推送
+	if p.config.Webhook.Enabled {
+		dispatcher, err := webhook.NewDispatcher(p.config.Webhook)
+		if err != nil {
+			return fmt.Errorf("创建Webhook推送失败: %w", err)
+		}
+		p.webhooks = dispatcher
+		log.Printf("MessageProcessor: Webhook推送已启用 (%d个地址, 需授权: %t)",
+			len(p.config.Webhook.Endpoints), p.config.Webhook.RequireConsent)
+	}
+
+	// 初始化定时提醒
+	if p.config.Reminders.Enabled {
+		scheduler, err := reminder.NewScheduler(p.config.Reminders, p.deliverReminder)
+		if err != nil {
+			return fmt.Errorf("创建定时提醒失败: %w", err)
+		}
+		p.reminders = scheduler
+		log.Printf("MessageProcessor: 定时提醒已启用 (时区: %s)", scheduler.Location())
+	}
+
+	// 初始化用户长期记忆
+	if p.config.Memory.Enabled {
+		manager, err := memory.NewManager(p.config.Memory, p.memoryGenerate)
+		if err != nil {
+			return fmt.Errorf("创建长期记忆失败: %w", err)
+		}
+		p.memory = manager
+		log.Printf("MessageProcessor: 长期记忆已启用 (%s, 提取方式: %s)", p.config.Memory.Store, p.config.Memory.Extractor)
+	}
+
+	// 初始化知识库检索
+	if p.config.Knowledge.Enabled {
+		if err := p.initKnowledge(); err != nil {
+			return fmt.Errorf("创建知识库检索失败: %w", err)
+		}
+		log.Printf("MessageProcessor: 知识库已启用 (%s, 向量化: %s)", p.config.Knowledge.Store, p.config.Knowledge.Embedding.Provider)
+	}
+
+	// 初始化说话人识别
+	if p.config.Speaker.Enabled {
+		identifier, err := speaker.NewIdentifier(p.config.Speaker)
+		if err != nil {
+			return fmt.Errorf("创建说话人识别失败: %w", err)
+		}
+		p.speakers = identifier
+		log.Printf("MessageProcessor: 说话人识别已启用 (%s, 已登记 %d 人)", p.config.Speaker.Provider, len(identifier.Profiles()))
+	}
+
+	// 初始化内容审核
+	if p.config.Moderation.Enabled {
+		moderator, err := moderation.NewModerator(p.config.Moderation, p.moderationGenerate)
+		if err != nil {
+			return fmt.Errorf("创建内容审核失败: %w", err)
+		}
+		p.moderator = moderator
+		log.Printf("MessageProcessor: 内容审核已启用 (屏蔽词: %d, 分类器: %s, 输入: %s, 回复: %s)",
+			moderator.KeywordCount(), p.config.Moderation.Provider, p.config.Moderation.InputAction, p.config.Moderation.OutputAction)
+	}
+
+	// 初始化处理流程钩子
+	if p.config.Hooks.Enabled {
+		chain, err := hooks.NewChain(p.config.Hooks)
+		if err != nil {
+			return fmt.Errorf("创建处理流程钩子失败: %w", err)
+		}
+		p.hooks = chain
+		log.Printf("MessageProcessor: 处理流程钩子已启用 (%d 个)", chain.Len())
+	}
+
+	// 初始化合成前文本规范化
+	if p.config.TextNorm.Enabled {
+		normalizer, err := textnorm.NewNormalizer(p.config.TextNorm)
+		if err != nil {
+			return fmt.Errorf("创建文本规范化失败: %w", err)
+		}
+		p.normalizer = normalizer
+		log.Printf("MessageProcessor: 合成前文本规范化已启用 (发音词典: %d 条)", normalizer.TermCount())
+	}
+
+	// 初始化识别结果规范化
+	if p.config.TranscriptNorm.Enabled {
+		normalizer, err := textnorm.NewTranscriptNormalizer(p.config.TranscriptNorm)
+		if err != nil {
+			return fmt.Errorf("创建识别结果规范化失败: %w", err)
+		}
+		p.transcriptNormalizer = normalizer
+		log.Printf("MessageProcessor: 识别结果规范化已启用 (标点: %s, 数字转换: %v)", normalizer.Punctuation(), p.config.TranscriptNorm.InverseNumbers)
+	}
+
+	// 初始化本地模型的计算资源管理
+	if p.config.Compute.Enabled {
+		manager, err := compute.NewManager(p.config.Compute)
+		if err != nil {
+			return fmt.Errorf("创建计算资源管理失败: %w", err)
+		}
+		p.compute = manager
+		for name, stats := range manager.Stats() {
+			log.Printf("MessageProcessor: 计算资源管理已启用 %s (阶段: %v, 槽位: %d, 显存: %dMB)", name, stats.Stages, stats.Slots, stats.MemoryMB)
+		}
+	}
+
+	// 语音回复长度限制（与对话上下文使用同一种Token计数）
+	if p.config.Limits.MaxResponseTokens > 0 {
+		tokenizer, err := llm.NewTokenizer(p.config.LLMConfig.Conversation.Tokenizer, p.config.LLMConfig.Model)
+		if err != nil {
+			log.Printf("MessageProcessor: 创建Token计数器失败，按字符估算: %v", err)
+			tokenizer, _ = llm.NewTokenizer(llm.TokenizerEstimate, "")
+		}
+		p.responseTokenizer = tokenizer
+	}
+
 	p.isInitialized = true
 
 	log.Println("MessageProcessor: 初始化成功")
@@ -133,14 +486,27 @@ func (p *MessageProcessor) ProcessMessage(client *Client, msg *protocol.Message)
 		return p.sendError(client, "PROCESSOR_NOT_INITIALIZED", "处理器未初始化", true)
 	}
 
+	// 同一连接可通过消息中的会话ID复用多个会话，响应通过会话视图带回对应的会话ID
+	view, err := client.Session(msg.SessionID, p.config.MaxSessionsPerConnection)
+	if err != nil {
+		return client.SendMessage(protocol.NewErrorMessage(msg.SessionID, protocol.ErrSessionLimitExceeded, err.Error(), true))
+	}
+	client = view
+
 	// 获取或创建会话
-	session := p.getOrCreateSession(msg.SessionID)
+	session := p.getOrCreateSession(client.ID, client.APIKey)
+	sender, ok := p.attachSender(client, session, msg)
+	if !ok {
+		return nil
+	}
 
 	switch msg.Type {
 	case protocol.AudioStream:
-		return p.handleAudioStream(client, session, msg)
+		return p.handleAudioStream(sender, session, msg)
 	case protocol.Command:
 		return p.handleCommand(client, session, msg)
+	case protocol.Status:
+		return p.handleClientStatus(client, session, msg)
 	default:
 		return p.sendError(client, "UNSUPPORTED_MESSAGE_TYPE", fmt.Sprintf("不支持的消息类型: %s", msg.Type), false)
 	}
@@ -148,23 +514,44 @@ func (p *MessageProcessor) ProcessMessage(client *Client, msg *protocol.Message)
 
 // handleAudioStream 处理音频流
 func (p *MessageProcessor) handleAudioStream(client *Client, session *Session, msg *protocol.Message) error {
-	var audioData protocol.AudioStreamData
-	if err := p.parseMessageData(msg.Data, &audioData); err != nil {
+	audioData, err := msg.AudioStreamData()
+	if err != nil {
 		return p.sendError(client, "INVALID_AUDIO_DATA", "无效的音频数据", false)
 	}
 
 	session.mu.Lock()
 	session.LastActivity = time.Now()
 
-	// 添加音频数据到缓冲区
-	session.AudioBuffer = append(session.AudioBuffer, audioData.AudioData...)
+	// 单轮会话结束后、唤醒词模式唤醒前的音频直接丢弃
+	if ok, code, message := session.acceptsAudioLocked(); !ok {
+		notify := !session.audioRejected
+		session.audioRejected = true
+		session.mu.Unlock()
+		if notify {
+			return p.sendError(client, code, message, true)
+		}
+		return nil
+	}
+
+	// 添加音频数据到缓冲区（超过单句时长上限的部分丢弃）
+	// 缓冲区写满时丢弃最早的音频（客户端一直未发送最终块时避免无限增长）
+	chunk, tooLong := p.clipUtteranceLocked(session, audioData.AudioData)
+	overflowed := p.bufferAudioLocked(session, chunk)
 
 	// 如果是最终数据或缓冲区足够大，处理音频
-	shouldProcess := audioData.IsFinal || len(session.AudioBuffer) >= p.config.AudioBufferSize
+	shouldProcess := audioData.IsFinal || session.AudioBuffer.Len() >= p.config.AudioBufferSize
+	p.armEndpointLocked(client, session, audioData.IsFinal)
+	limit := session.AudioBuffer.Limit()
 	session.mu.Unlock()
 
+	if tooLong {
+		p.warnUtteranceTooLong(client, session)
+	}
+	if overflowed {
+		p.warnAudioBufferOverflow(client, session, limit)
+	}
 	if shouldProcess {
-		go p.processAudioBuffer(client, session, audioData.IsFinal)
+		p.scheduleAudio(client, session, audioData.IsFinal)
 	}
 
 	return nil
@@ -172,10 +559,11 @@ func (p *MessageProcessor) handleAudioStream(client *Client, session *Session, m
 
 // handleCommand 处理命令
 func (p *MessageProcessor) handleCommand(client *Client, session *Session, msg *protocol.Message) error {
-	var cmdData protocol.CommandData
-	if err := p.parseMessageData(msg.Data, &cmdData); err != nil {
+	parsed, err := msg.CommandData()
+	if err != nil {
 		return p.sendError(client, "INVALID_COMMAND_DATA", "无效的命令数据", false)
 	}
+	cmdData := *parsed
 
 	switch cmdData.Command {
 	case "start_session":
@@ -184,68 +572,289 @@ func (p *MessageProcessor) handleCommand(client *Client, session *Session, msg *
 		return p.handleStopSession(client, session, cmdData)
 	case "set_mode":
 		return p.handleSetMode(client, session, cmdData)
+	case protocol.CmdInterrupt:
+		return p.handleInterrupt(client, session, cmdData)
 	case "get_status":
 		return p.handleGetStatus(client, session, cmdData)
+	case "list_voices":
+		return p.handleListVoices(client, session, cmdData)
+	case "set_language":
+		return p.handleSetLanguage(client, session, cmdData)
+	case "set_data_collection":
+		return p.handleSetDataCollection(client, session, cmdData)
+	case "forget_memory":
+		return p.handleForgetMemory(client, session, cmdData)
+	case "set_persona":
+		return p.handleSetPersona(client, session, cmdData)
+	case protocol.CmdWake:
+		return p.handleWake(client, session, cmdData)
+	case protocol.CmdAckNotification:
+		return p.handleAckNotification(client, session, cmdData)
+	case protocol.CmdInviteMember:
+		return p.handleInviteMember(client, session, cmdData)
+	case protocol.CmdJoinSession:
+		return p.handleJoinSession(client, session, cmdData)
+	case protocol.CmdLeaveSession:
+		return p.handleLeaveSession(client, session, cmdData)
+	case protocol.CmdHandover:
+		return p.handleHandover(client, session, cmdData)
+	case protocol.CmdClaimHandover:
+		return p.handleClaimHandover(client, session, cmdData)
 	default:
 		return p.sendError(client, "UNSUPPORTED_COMMAND", fmt.Sprintf("不支持的命令: %s", cmdData.Command), false)
 	}
 }
 
+// scheduleAudio 将音频处理交给连接的调度器（中间结果可合并，最终结果必定执行）
+func (p *MessageProcessor) scheduleAudio(client *Client, session *Session, isFinal bool) {
+	client.turns(p.config.MaxTurnsPerConnection).Submit(session.ID, func() {
+		p.processAudioBuffer(client, session, isFinal)
+	}, !isFinal)
+}
+
 // processAudioBuffer 处理音频缓冲区
 func (p *MessageProcessor) processAudioBuffer(client *Client, session *Session, isFinal bool) {
 	session.mu.Lock()
 	if session.IsProcessing {
+		// 正在识别中间结果时收到最终块，待当前处理结束后补处理，避免丢失整句
+		if isFinal {
+			session.pendingFinal = true
+		}
 		session.mu.Unlock()
 		return
 	}
 	session.IsProcessing = true
-	session.State = StateProcessing
-	audioBuffer := make([]byte, len(session.AudioBuffer))
-	copy(audioBuffer, session.AudioBuffer)
+	// 中间结果的音频副本只用于识别，取自缓冲池；整句音频还会交给归档、钩子等，不回收
+	var audioBuffer []byte
+	if isFinal {
+		audioBuffer = make([]byte, session.AudioBuffer.Len())
+	} else {
+		audioBuffer = vaaudio.GetBytes(session.AudioBuffer.Len())
+	}
+	session.AudioBuffer.CopyTo(audioBuffer)
 	if isFinal {
-		session.AudioBuffer = session.AudioBuffer[:0] // 清空缓冲区
+		// 识别中间结果时会话仍处于聆听状态，客户端继续录音
+		session.State = StateProcessing
+		session.AudioBuffer.Reset() // 清空缓冲区
+		session.speechActive = false
 	}
+	language := session.Language
+	textOnly := session.TextOnly
+	priority := session.Priority
+	voice := session.Voice
+	persona := session.Persona
 	session.mu.Unlock()
 
 	// 发送状态更新
-	p.sendStatus(client, session)
+	if isFinal {
+		p.sendStatus(client, session)
+	}
 
-	// ASR处理
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	// ASR处理（本轮各阶段使用同一语言配置；停止、打断或释放会话时取消进行中的调用）
+	ctx, cancel := p.beginTurn(session)
 	defer cancel()
+	ctx = withLanguageOptions(ctx, language)
+	ctx = withVoiceOptions(ctx, voice)
+	ctx = p.withPersona(ctx, persona)
+	ctx = withSessionUsage(ctx, session)
+	ctx = withSessionOwner(ctx, session)
+
+	// 资源配额：整句音频在识别前检查，超出时直接提示用户
+	if isFinal {
+		if exceeded := p.checkQuota(session); exceeded != "" {
+			session.mu.Lock()
+			session.State = StateResponding
+			session.mu.Unlock()
+
+			p.refuseForQuota(ctx, client, session, exceeded, language, textOnly)
 
-	asrResult, err := p.asrService.ProcessAudio(ctx, audioBuffer)
+			p.finishTurn(client, session)
+			return
+		}
+		p.recordQuotaAudio(session, len(audioBuffer))
+	}
+
+	// 处理流程钩子：整句音频识别前（可替换音频或中止本轮）
+	hook := p.newTurnHooks(session)
+	if isFinal {
+		hooked := hook.run(ctx, hooks.StagePreASR, hooks.Payload{Audio: audioBuffer})
+		if hooked.Abort {
+			p.abortForHook(ctx, client, session, hooked, textOnly)
+			return
+		}
+		audioBuffer = hooked.Audio
+	}
+
+	// 整句处理记录，本轮结束时归档（各阶段结果随处理进度补全）
+	utt := archive.Utterance{Time: time.Now(), Language: language}
+	if isFinal {
+		defer p.archiveUtterance(session, audioBuffer, &utt)
+	}
+
+	// 整句音频在识别文本的同时识别说话人
+	waitSpeaker := p.startSpeakerIdentification(ctx, audioBuffer, isFinal)
+
+	asrResult, err := p.recognize(ctx, priority, audioBuffer)
+	if !isFinal && err == nil {
+		// 识别失败时工作池中的任务可能仍在读取音频（取消或超时后不等待任务结束），不归还
+		vaaudio.PutBytes(audioBuffer)
+	}
+	if err != nil && turnCancelled(ctx) {
+		p.abandonTurn(session, err)
+		return
+	}
 	if err != nil {
 		log.Printf("ASR处理失败: %v", err)
-		p.sendError(client, "ASR_FAILED", "语音识别失败", true)
+		utt.Error = "asr: " + err.Error()
 		session.mu.Lock()
 		session.IsProcessing = false
-		session.State = StateError
+		if isFinal {
+			session.State = StateError
+		}
+		pendingFinal := session.pendingFinal && !isFinal
+		session.pendingFinal = false
 		session.mu.Unlock()
+
+		// 中间结果识别失败不影响整句，只在最终识别失败时通知客户端
+		if isFinal {
+			p.sendStageError(client, pipeline.StageASR, err, "语音识别失败")
+		}
+
+		if pendingFinal {
+			p.scheduleAudio(client, session, true)
+		}
 		return
 	}
 
-	// 发送ASR结果
-	p.sendResponse(client, "asr", asrResult.Text, asrResult.Confidence, asrResult.IsFinal, nil)
+	// 缓冲区未结束时的识别结果只是中间假设，不进入LLM
+	asrResult.IsFinal = asrResult.IsFinal && isFinal
+	utt.Transcript, utt.Confidence = asrResult.Text, asrResult.Confidence
+	if isFinal {
+		asrResult.Speaker = waitSpeaker()
+		p.setSessionSpeaker(session, asrResult.Speaker)
+	}
+
+	// 识别到的是助手自己播放的语音：中间结果直接丢弃，最终结果以空内容结束本轮
+	if p.isSelfEcho(session, asrResult.Text) {
+		log.Printf("丢弃疑似TTS回声的识别结果: %s, %q", session.ID, asrResult.Text)
+		if asrResult.IsFinal {
+			p.sendResponseData(client, &protocol.ResponseData{
+				Stage:   protocol.StageASR,
+				IsFinal: true,
+				Metadata: map[string]interface{}{
+					"suppressed": "echo",
+				},
+			})
+		}
+		asrResult.Text = ""
+	} else {
+		// 恢复标点、转换数字后发送ASR结果（中间假设与最终结果）
+		asrResult.Text = p.transcriptText(ctx, asrResult, language)
+		p.sendResponseData(client, &protocol.ResponseData{
+			Stage:      protocol.StageASR,
+			Content:    asrResult.Text,
+			Confidence: asrResult.Confidence,
+			IsFinal:    asrResult.IsFinal,
+			Words:      toWordTimings(asrResult.Words),
+			Metadata:   speakerMetadata(asrResult.Speaker),
+		})
+	}
 
 	if asrResult.Text == "" || !asrResult.IsFinal {
 		session.mu.Lock()
 		session.IsProcessing = false
 		session.State = StateListening
+		pendingFinal := session.pendingFinal
+		session.pendingFinal = false
+		session.mu.Unlock()
+
+		// 最终结果为空时本轮结束，通知客户端恢复聆听
+		if isFinal {
+			p.sendStatus(client, session)
+		}
+		if pendingFinal {
+			p.scheduleAudio(client, session, true)
+		}
+		return
+	}
+
+	// 处理流程钩子：识别完成后（可改写识别文本）
+	hooked := hook.run(ctx, hooks.StagePostASR, hooks.Payload{Text: asrResult.Text})
+	if hooked.Abort {
+		p.abortForHook(ctx, client, session, hooked, textOnly)
+		return
+	}
+	asrResult.Text = hooked.Text
+
+	// 语言切换指令：切换后直接使用新语言确认，不再调用LLM
+	if profile, ok := detectLanguageSwitch(asrResult.Text); ok {
+		p.switchLanguage(session, profile)
+
+		session.mu.Lock()
+		session.State = StateResponding
+		session.mu.Unlock()
+
+		p.confirmLanguageSwitch(ctx, client, session, profile, textOnly)
+
+		p.finishTurn(client, session)
+		return
+	}
+
+	// 内容审核：用户输入被拒绝时直接提示，不调用LLM
+	input := p.moderate(ctx, moderation.StageInput, asrResult.Text)
+	if input.Blocked() {
+		utt.Error = "moderation: input refused"
+
+		session.mu.Lock()
+		session.State = StateResponding
 		session.mu.Unlock()
+
+		p.refuseContent(ctx, client, session, moderation.StageInput, input, language, textOnly)
+
+		p.finishTurn(client, session)
+		return
+	}
+
+	// 提醒和计时器指令：直接设置并确认，不再调用LLM
+	if p.handleReminderIntent(ctx, client, session, input.Text, textOnly) {
+		p.finishTurn(client, session)
 		return
 	}
 
+	// 处理流程钩子：交给LLM前（可改写输入，或设置 Abort 和 Reply 直接回复）
+	hooked = hook.run(ctx, hooks.StagePreLLM, hooks.Payload{Text: input.Text})
+	if hooked.Abort {
+		p.abortForHook(ctx, client, session, hooked, textOnly)
+		return
+	}
+	input.Text = hooked.Text
+
 	// LLM处理
 	session.mu.Lock()
 	session.State = StateProcessing
 	conversationID := session.ConversationID
 	session.mu.Unlock()
 
-	llmResponse, err := p.llmService.Chat(ctx, asrResult.Text, conversationID)
+	// 插话更正：把更正内容与上一轮的提问一起交给LLM
+	correction := p.detectCorrection(session, input.Text)
+	llmCtx := p.withKnowledge(p.withMemory(withSpeaker(ctx, asrResult.Speaker), session), input.Text)
+	llmCtx = p.withResponseLimit(withCorrection(llmCtx, correction), textOnly)
+	llmResponse, err := p.chat(llmCtx, priority, input.Text, conversationID)
+	if err != nil && turnCancelled(ctx) {
+		p.abandonTurn(session, err)
+		return
+	}
 	if err != nil {
 		log.Printf("LLM处理失败: %v", err)
-		p.sendError(client, "LLM_FAILED", "文本生成失败", true)
+		utt.Error = "llm: " + err.Error()
+		p.sendPartialStageError(client, pipeline.StageLLM, err, "文本生成失败", map[string]interface{}{
+			"transcript": input.Text,
+		})
+		// 超时时已下发的识别结果作为本轮结果，会话照常结束本轮
+		if stageTimedOut(err) {
+			p.finishTurn(client, session)
+			return
+		}
 		session.mu.Lock()
 		session.IsProcessing = false
 		session.State = StateError
@@ -253,55 +862,175 @@ func (p *MessageProcessor) processAudioBuffer(client *Client, session *Session,
 		return
 	}
 
-	// 发送LLM结果
-	p.sendResponse(client, "llm", llmResponse.Content, 0.9, true, nil)
-
-	// TTS处理
+	p.recordQuotaTurn(session, llmResponse.TokenUsage.TotalTokens)
 	session.mu.Lock()
-	session.State = StateResponding
+	session.Turns++
 	session.mu.Unlock()
+	p.recordUserTurn(session, input.Text, correction)
+
+	// 内容审核：回复被拒绝时改为提示语，之后的记录、合成都使用审核后的文本
+	output := p.moderate(ctx, moderation.StageOutput, llmResponse.Content)
+	if output.Blocked() {
+		p.sendError(client, protocol.ErrContentRefused, "回复未通过内容审核", true)
+		output.Text = moderationRefusal(moderation.StageOutput, language)
+	}
+	llmResponse.Content = output.Text
+
+	// 处理流程钩子：LLM回复后（可改写回复）
+	hooked = hook.run(ctx, hooks.StagePostLLM, hooks.Payload{Text: llmResponse.Content})
+	if hooked.Abort {
+		p.abortForHook(ctx, client, session, hooked, textOnly)
+		return
+	}
+	llmResponse.Content = hooked.Text
+	utt.Reply, utt.Model, utt.Tokens = llmResponse.Content, llmResponse.Model, llmResponse.TokenUsage.TotalTokens
+
+	// 语音回复超过长度上限时只合成前面的部分（文本回复保持完整）
+	spoken, truncated := llmResponse.Content, false
+	if !textOnly {
+		spoken, truncated = p.limitSpokenResponse(llmResponse.Content, language)
+	}
+
+	// 发送LLM结果
+	p.sendResponseData(client, &protocol.ResponseData{
+		Stage:      protocol.StageLLM,
+		Content:    llmResponse.Content,
+		Confidence: 0.9,
+		IsFinal:    true,
+		Metadata:   truncationMetadata(correctionMetadata(moderationMetadata(moderation.StageOutput, output), correction), truncated),
+	})
+
+	// 记录对话样本，提取用户长期记忆（被拒绝的回复不作为样本）
+	if !output.Blocked() {
+		p.recordExchange(session, input.Text, llmResponse)
+	}
+	p.rememberUser(session, input.Text)
+	p.notifyTurn(session, asrResult, input.Text, llmResponse, output.Blocked())
+
+	// TTS处理（仅文本模式跳过）
+	var speech time.Duration
+	if !textOnly {
+		// 处理流程钩子：语音合成前（可改写要播放的文本，中止时不播放本轮回复）
+		hooked = hook.run(ctx, hooks.StagePreTTS, hooks.Payload{Text: spoken})
+		if hooked.Abort {
+			p.abortForHook(ctx, client, session, hooked, textOnly)
+			return
+		}
+		spoken = hooked.Text
 
-	ttsResult, err := p.ttsService.SynthesizeText(ctx, llmResponse.Content)
-	if err != nil {
-		log.Printf("TTS处理失败: %v", err)
-		p.sendError(client, "TTS_FAILED", "语音合成失败", true)
 		session.mu.Lock()
-		session.IsProcessing = false
-		session.State = StateError
+		session.State = StateResponding
 		session.mu.Unlock()
-		return
+
+		ttsResult, duration, err := p.speakReply(ctx, client, priority, spoken)
+		if err != nil && turnCancelled(ctx) {
+			p.abandonTurn(session, err)
+			return
+		}
+		if err != nil {
+			log.Printf("TTS处理失败: %v", err)
+			utt.Error = "tts: " + err.Error()
+			p.sendPartialStageError(client, pipeline.StageTTS, err, "语音合成失败", map[string]interface{}{
+				"transcript": input.Text,
+				"reply":      llmResponse.Content,
+			})
+			// 超时时已下发的文本回复作为本轮结果（按仅文本回复结束本轮）
+			if stageTimedOut(err) {
+				p.finishTurn(client, session)
+				return
+			}
+			session.mu.Lock()
+			session.IsProcessing = false
+			session.State = StateError
+			session.mu.Unlock()
+			return
+		}
+
+		speech = duration
+		p.setArchivedOutput(&utt, &ttsResult)
+		p.rememberSpoken(session, spoken)
 	}
 
-	// 发送TTS结果
-	p.sendResponse(client, "tts", "", 1.0, true, ttsResult.AudioData)
+	// 分句合成中途被打断时已下发的句子照常保留，会话状态由打断方设置
+	if turnCancelled(ctx) {
+		p.abandonTurn(session, ctx.Err())
+		return
+	}
 
-	// 重置会话状态
+	// 重置会话状态（客户端上报播放进度时，语音播放完毕后再恢复聆听）
 	session.mu.Lock()
-	session.IsProcessing = false
-	if session.ContinuousMode {
-		session.State = StateListening
-	} else {
-		session.State = StateIdle
+	if p.awaitPlaybackLocked(client, session, speech) {
+		session.IsProcessing = false
+		session.mu.Unlock()
+		p.sendStatus(client, session)
+		return
 	}
 	session.mu.Unlock()
 
-	// 发送状态更新
-	p.sendStatus(client, session)
+	p.finishTurn(client, session)
 }
 
 // handleStartSession 处理开始会话
 func (p *MessageProcessor) handleStartSession(client *Client, session *Session, cmdData protocol.CommandData) error {
+	session.mu.RLock()
+	voice, err := p.parseVoiceParameters(session.Voice, cmdData.Parameters)
+	session.mu.RUnlock()
+	if err != nil {
+		return p.sendError(client, "INVALID_COMMAND_DATA", err.Error(), true)
+	}
+	persona, hasPersona, err := p.parsePersonaParameter(cmdData.Parameters)
+	if err != nil {
+		return p.sendError(client, "INVALID_COMMAND_DATA", err.Error(), true)
+	}
+	mode, err := p.resolveSessionMode(cmdData.Mode)
+	if err != nil {
+		return p.sendError(client, "INVALID_COMMAND_DATA", err.Error(), true)
+	}
+	silence, hasSilence, err := p.parseEndpointParameter(cmdData.Parameters)
+	if err != nil {
+		return p.sendError(client, "INVALID_COMMAND_DATA", err.Error(), true)
+	}
+
 	session.mu.Lock()
-	defer session.mu.Unlock()
+	session.Voice = voice
+	if hasPersona {
+		session.Persona = persona
+	}
 
+	applySessionMode(session, mode)
+	if hasSilence {
+		session.EndpointSilence = silence
+	}
+	session.ended = false
+	session.audioRejected = false
+	// 唤醒词模式启动后先等待唤醒
 	session.State = StateListening
-	session.ContinuousMode = cmdData.Mode == "continuous"
+	if mode == protocol.ModeWakeword {
+		session.State = StateIdle
+	}
 	session.LastActivity = time.Now()
+	applyTextOnlyParameter(session, cmdData.Parameters)
+	applyPriorityParameter(session, cmdData.Parameters)
+	applyPlaybackParameter(session, cmdData.Parameters)
+	if tenant, ok := cmdData.Parameters["tenant"].(string); ok {
+		session.Tenant = tenant
+	}
+	if userID, ok := cmdData.Parameters["user_id"].(string); ok {
+		session.UserID = userID
+	}
 
 	// 创建新的对话ID
-	session.ConversationID = fmt.Sprintf("conv_%s_%d", session.ID, time.Now().UnixNano())
+	session.ConversationID = newConversationID(session.ID)
+
+	log.Printf("会话已启动: %s, 模式: %s, 仅文本: %t, 声音: %s", session.ID, session.Mode, session.TextOnly, session.Voice.Voice)
 
-	log.Printf("会话已启动: %s, 连续模式: %t", session.ID, session.ContinuousMode)
+	session.mu.Unlock()
+
+	// 补发会话不在线时到期的提醒，重发尚未确认的通知
+	go func() {
+		p.resendNotifications(session)
+		p.redeliverReminders(session)
+	}()
 
 	return p.sendStatus(client, session)
 }
@@ -309,46 +1038,136 @@ func (p *MessageProcessor) handleStartSession(client *Client, session *Session,
 // handleStopSession 处理停止会话
 func (p *MessageProcessor) handleStopSession(client *Client, session *Session, cmdData protocol.CommandData) error {
 	session.mu.Lock()
-	defer session.mu.Unlock()
 
+	// 取消进行中的识别、生成和合成
+	cancelTurnLocked(session)
+	session.pendingFinal = false
 	session.State = StateIdle
 	session.ContinuousMode = false
-	session.AudioBuffer = session.AudioBuffer[:0]
+	session.Duplex = false
+	session.Mode = ""
+	session.AudioBuffer.Reset()
 
 	log.Printf("会话已停止: %s", session.ID)
 
+	session.mu.Unlock()
+
+	// 释放连接上的会话名额
+	client.ReleaseSession(session.ID)
+
 	return p.sendStatus(client, session)
 }
 
 // handleSetMode 处理设置模式
 func (p *MessageProcessor) handleSetMode(client *Client, session *Session, cmdData protocol.CommandData) error {
+	mode, _ := cmdData.Parameters["mode"].(string)
+	if mode != "" && !sessionModes[mode] {
+		return p.sendError(client, "INVALID_COMMAND_DATA", fmt.Sprintf("不支持的会话模式: %s", mode), true)
+	}
+	silence, hasSilence, err := p.parseEndpointParameter(cmdData.Parameters)
+	if err != nil {
+		return p.sendError(client, "INVALID_COMMAND_DATA", err.Error(), true)
+	}
+
 	session.mu.Lock()
-	defer session.mu.Unlock()
 
-	if mode, exists := cmdData.Parameters["mode"]; exists {
-		if modeStr, ok := mode.(string); ok {
-			session.ContinuousMode = modeStr == "continuous"
-			log.Printf("会话模式已更新: %s, 连续模式: %t", session.ID, session.ContinuousMode)
+	if hasSilence {
+		session.EndpointSilence = silence
+		log.Printf("会话断句静默时长已更新: %s, %v", session.ID, silence)
+	}
+
+	if mode != "" {
+		previous := session.Mode
+		applySessionMode(session, mode)
+		// 切换到唤醒词模式后等待唤醒；从等待唤醒切换到其他模式时恢复聆听
+		switch {
+		case mode == protocol.ModeWakeword && session.State == StateListening:
+			session.State = StateIdle
+		case previous == protocol.ModeWakeword && mode != protocol.ModeWakeword && session.State == StateIdle && !session.ended:
+			session.State = StateListening
 		}
+		log.Printf("会话模式已更新: %s, 模式: %s", session.ID, session.Mode)
+	}
+
+	if applyTextOnlyParameter(session, cmdData.Parameters) {
+		log.Printf("会话仅文本模式已更新: %s, 仅文本: %t", session.ID, session.TextOnly)
 	}
 
+	session.mu.Unlock()
+
 	return p.sendStatus(client, session)
 }
 
+// handleSetLanguage 处理设置语言
+func (p *MessageProcessor) handleSetLanguage(client *Client, session *Session, cmdData protocol.CommandData) error {
+	language, _ := cmdData.Parameters["language"].(string)
+	profile, ok := lookupLanguageProfile(language)
+	if !ok {
+		return p.sendError(client, "INVALID_COMMAND_DATA", fmt.Sprintf("不支持的语言: %s", language), true)
+	}
+
+	p.switchLanguage(session, profile)
+
+	session.mu.RLock()
+	textOnly := session.TextOnly
+	session.mu.RUnlock()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(sessionContext(session), 30*time.Second)
+		defer cancel()
+		p.confirmLanguageSwitch(withSessionOwner(ctx, session), client, session, profile, textOnly)
+	}()
+
+	return nil
+}
+
+// applyTextOnlyParameter 从命令参数读取仅文本模式（调用方需持有会话锁），返回是否设置
+func applyTextOnlyParameter(session *Session, params map[string]interface{}) bool {
+	textOnly, ok := params["text_only"].(bool)
+	if !ok {
+		return false
+	}
+	session.TextOnly = textOnly
+	return true
+}
+
 // handleGetStatus 处理获取状态
 func (p *MessageProcessor) handleGetStatus(client *Client, session *Session, cmdData protocol.CommandData) error {
-	return p.sendStatus(client, session)
+	statusData := p.buildStatusData(session)
+	statusData.Quota = p.quotaStatus(session)
+	statusData.SessionInfo = buildSessionInfo(session)
+	statusData.SessionInfo.Usage, statusData.APIKeyUsage = p.usageStatus(session)
+
+	msg := protocol.NewMessage(protocol.Status, client.ID, statusData)
+	return client.SendMessage(msg)
 }
 
 // getOrCreateSession 获取或创建会话
-func (p *MessageProcessor) getOrCreateSession(sessionID string) *Session {
+func (p *MessageProcessor) getOrCreateSession(sessionID, apiKey string) *Session {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
 	if session, exists := p.sessions[sessionID]; exists {
 		return session
 	}
+	return p.newSessionLocked(sessionID, apiKey)
+}
+
+// createSession 创建新会话，会话ID已被使用时返回false
+// 由客户端指定会话ID的传输（WebRTC、gRPC）建立连接时使用，防止用已知的会话ID接入其他连接的会话。
+func (p *MessageProcessor) createSession(sessionID, apiKey string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, exists := p.sessions[sessionID]; exists {
+		return false
+	}
+	p.newSessionLocked(sessionID, apiKey)
+	return true
+}
 
+// newSessionLocked 创建并登记会话（调用方持有p.mu）
+func (p *MessageProcessor) newSessionLocked(sessionID, apiKey string) *Session {
 	// 检查会话数量限制
 	if len(p.sessions) >= p.config.MaxConcurrentSessions {
 		// 清理最旧的会话
@@ -359,11 +1178,14 @@ func (p *MessageProcessor) getOrCreateSession(sessionID string) *Session {
 	session := &Session{
 		ID:              sessionID,
 		State:           StateIdle,
-		ConversationID:  fmt.Sprintf("conv_%s_%d", sessionID, time.Now().UnixNano()),
-		AudioBuffer:     make([]byte, 0, p.config.AudioBufferSize),
+		ConversationID:  newConversationID(sessionID),
+		AudioBuffer:     NewAudioRing(p.audioBufferLimit()),
 		LastActivity:    time.Now(),
+		CreatedAt:       time.Now(),
+		APIKey:          apiKey,
 		IsProcessing:    false,
 		ContinuousMode:  false,
+		EndpointSilence: p.config.Endpointing.Silence,
 		audioStreamChan: make(chan []byte, 100),
 		responseChan:    make(chan *protocol.Message, 100),
 		ctx:             ctx,
@@ -376,6 +1198,34 @@ func (p *MessageProcessor) getOrCreateSession(sessionID string) *Session {
 	return session
 }
 
+// IsSessionProcessing 判断会话是否有进行中的处理
+func (p *MessageProcessor) IsSessionProcessing(sessionID string) bool {
+	p.mu.RLock()
+	session, exists := p.sessions[sessionID]
+	p.mu.RUnlock()
+	if !exists {
+		return false
+	}
+
+	session.mu.RLock()
+	defer session.mu.RUnlock()
+	return session.IsProcessing
+}
+
+// isSessionListening 判断会话是否处于聆听状态
+func (p *MessageProcessor) isSessionListening(sessionID string) bool {
+	p.mu.RLock()
+	session, exists := p.sessions[sessionID]
+	p.mu.RUnlock()
+	if !exists {
+		return false
+	}
+
+	session.mu.RLock()
+	defer session.mu.RUnlock()
+	return session.State == StateListening
+}
+
 // cleanupOldestSession 清理最旧的会话
 func (p *MessageProcessor) cleanupOldestSession() {
 	var oldestID string
@@ -397,37 +1247,110 @@ func (p *MessageProcessor) cleanupOldestSession() {
 	}
 }
 
+// ReleaseSession 释放会话（连接失效且无法恢复时调用）
+func (p *MessageProcessor) ReleaseSession(sessionID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if session, exists := p.sessions[sessionID]; exists {
+		session.cancel()
+		delete(p.sessions, sessionID)
+		log.Printf("已释放会话: %s", sessionID)
+	}
+}
+
+// releaseConnectionSessions 释放断开的连接自己的会话（主会话和以所有者身份打开的会话，加入的他人会话不受影响）
+func (p *MessageProcessor) releaseConnectionSessions(connID string, sessionIDs []string) {
+	for _, id := range sessionIDs {
+		p.mu.RLock()
+		session := p.sessions[id]
+		p.mu.RUnlock()
+		if session == nil {
+			continue
+		}
+
+		session.mu.RLock()
+		owned := id == connID || session.owner == connID
+		session.mu.RUnlock()
+		if owned {
+			p.ReleaseSession(id)
+		}
+	}
+}
+
 // sendResponse 发送响应
 func (p *MessageProcessor) sendResponse(client *Client, stage, content string, confidence float64, isFinal bool, audioData []byte) error {
-	responseData := &protocol.ResponseData{
+	return p.sendResponseData(client, &protocol.ResponseData{
 		Stage:      stage,
 		Content:    content,
 		Confidence: confidence,
 		IsFinal:    isFinal,
 		AudioData:  audioData,
-	}
+	})
+}
 
+// sendResponseData 发送完整响应数据（可携带元数据）
+func (p *MessageProcessor) sendResponseData(client *Client, responseData *protocol.ResponseData) error {
 	msg := protocol.NewMessage(protocol.Response, client.ID, responseData)
 	return client.SendMessage(msg)
 }
 
+// toWordTimings 转换ASR词级别信息为协议格式
+func toWordTimings(words []asr.Word) []protocol.WordTiming {
+	if len(words) == 0 {
+		return nil
+	}
+
+	timings := make([]protocol.WordTiming, 0, len(words))
+	for _, w := range words {
+		timings = append(timings, protocol.WordTiming{
+			Text:       w.Text,
+			StartTime:  w.StartTime,
+			EndTime:    w.EndTime,
+			Confidence: w.Confidence,
+		})
+	}
+	return timings
+}
+
 // sendStatus 发送状态
 func (p *MessageProcessor) sendStatus(client *Client, session *Session) error {
+	msg := protocol.NewMessage(protocol.Status, client.ID, p.buildStatusData(session))
+	return client.SendMessage(msg)
+}
+
+// buildStatusData 构造会话状态数据
+func (p *MessageProcessor) buildStatusData(session *Session) *protocol.StatusData {
 	session.mu.RLock()
 	statusData := &protocol.StatusData{
-		State: string(session.State),
-		Mode: func() string {
-			if session.ContinuousMode {
-				return "continuous"
-			}
-			return "single"
-		}(),
+		State:             string(session.State),
+		Mode:              session.modeLocked(),
 		ConcurrentStreams: len(p.sessions),
 	}
+	persona := session.Persona
+	group := session.group
 	session.mu.RUnlock()
+	statusData.Persona = p.sessionPersonaID(persona)
+	statusData.Scheduling = p.sessionScheduling(session)
+	if group != nil {
+		statusData.Members = group.list()
+	}
 
-	msg := protocol.NewMessage(protocol.Status, client.ID, statusData)
-	return client.SendMessage(msg)
+	return statusData
+}
+
+// buildSessionInfo 构造会话信息
+func buildSessionInfo(session *Session) *protocol.SessionInfo {
+	session.mu.RLock()
+	defer session.mu.RUnlock()
+
+	return &protocol.SessionInfo{
+		ID:           session.ID,
+		StartTime:    session.CreatedAt,
+		LastActivity: session.LastActivity,
+		MessageCount: session.Turns,
+		Duration:     int64(time.Since(session.CreatedAt).Seconds()),
+	}
 }
 
 // sendError 发送错误
@@ -442,13 +1365,13 @@ func (p *MessageProcessor) sendError(client *Client, code, message string, recov
 	return client.SendMessage(msg)
 }
 
-// parseMessageData 解析消息数据
-func (p *MessageProcessor) parseMessageData(data interface{}, target interface{}) error {
-	jsonData, err := json.Marshal(data)
-	if err != nil {
-		return err
+// ConversationStats 获取LLM对话管理统计（LLM服务不支持时返回false）
+func (p *MessageProcessor) ConversationStats() (llm.ConversationStats, bool) {
+	provider, ok := p.llmService.(llm.ConversationStatsProvider)
+	if !ok {
+		return llm.ConversationStats{}, false
 	}
-	return json.Unmarshal(jsonData, target)
+	return provider.ConversationStats(), true
 }
 
 // Close 关闭处理器
@@ -462,6 +1385,10 @@ func (p *MessageProcessor) Close() error {
 	}
 	p.sessions = make(map[string]*Session)
 
+	// 停止工作池（等待进行中的任务结束）
+	p.workers.Close()
+	p.compute.Close()
+
 	// 关闭服务
 	if p.asrService != nil {
 		p.asrService.Close()
@@ -472,6 +1399,53 @@ func (p *MessageProcessor) Close() error {
 	if p.ttsService != nil {
 		p.ttsService.Close()
 	}
+	if p.datasetSink != nil {
+		p.datasetSink.Close()
+		p.datasetSink = nil
+	}
+
+	if p.knowledge != nil {
+		if p.knowledgeCancel != nil {
+			p.knowledgeCancel()
+		}
+		p.knowledge.Close()
+		p.knowledge = nil
+	}
+
+	if p.speakers != nil {
+		p.speakers.Close()
+		p.speakers = nil
+	}
+
+	if p.archiver != nil {
+		p.archiver.Close()
+		p.archiver = nil
+	}
+
+	if p.webhooks != nil {
+		p.webhooks.Close()
+		p.webhooks = nil
+	}
+
+	if p.reminders != nil {
+		p.reminders.Close()
+		p.reminders = nil
+	}
+
+	if p.hooks != nil {
+		p.hooks.Close()
+		p.hooks = nil
+	}
+
+	if p.memory != nil {
+		p.memory.Close()
+		p.memory = nil
+	}
+
+	if p.moderator != nil {
+		p.moderator.Close()
+		p.moderator = nil
+	}
 
 	p.isInitialized = false
 
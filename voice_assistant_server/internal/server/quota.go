@@ -0,0 +1,301 @@
+package server
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"voice_assistant/pkg/protocol"
+	"voice_assistant/voice_assistant_server/internal/pipeline"
+)
+
+// QuotaConfig 会话资源配额配置
+// 配额按连接携带的、已在 api_keys 中配置的API Key累计，同一API Key的多个会话或重连后的新会话共享用量；
+// 未携带或未配置的API Key共用一份默认配额，换用随机API Key或重连都不能获得新的额度。
+type QuotaConfig struct {
+	Enabled bool                   `yaml:"enabled"`  // 是否启用
+	Default QuotaLimits            `yaml:"default"`  // 默认配额
+	APIKeys map[string]QuotaLimits `yaml:"api_keys"` // 按API Key覆盖默认配额
+}
+
+// QuotaLimits 配额上限（0表示不限制）
+type QuotaLimits struct {
+	MaxTurnsPerHour       int     `yaml:"max_turns_per_hour"`        // 每小时对话轮数
+	MaxAudioMinutesPerDay float64 `yaml:"max_audio_minutes_per_day"` // 每日上传音频分钟数
+	MaxTokensPerDay       int     `yaml:"max_tokens_per_day"`        // 每日LLM Token用量
+}
+
+// 配额类型
+const (
+	QuotaTurns  = "turns"
+	QuotaAudio  = "audio"
+	QuotaTokens = "tokens"
+	QuotaBudget = "budget" // 用量预算（见 UsageConfig）
+)
+
+// pcmBytesPerMillisecond 16kHz 16bit 单声道PCM每毫秒字节数
+const pcmBytesPerMillisecond = 32
+
+// quotaIdleExpiry 用量记录闲置超过该时间后清理
+const quotaIdleExpiry = 25 * time.Hour
+
+// quotaRefusals 超出配额时的语音提示（按会话语言）
+var quotaRefusals = map[string]map[string]string{
+	"zh": {
+		QuotaTurns:  "不好意思，这一小时里我们聊得有点多了，请稍后再来找我吧。",
+		QuotaAudio:  "不好意思，今天的语音时长已经用完了，明天再和我聊吧。",
+		QuotaTokens: "不好意思，今天的对话额度已经用完了，明天再和我聊吧。",
+		QuotaBudget: "不好意思，服务的使用额度已经用完了，请联系管理员。",
+	},
+	"en": {
+		QuotaTurns:  "Sorry, we've talked quite a lot this hour. Please come back a little later.",
+		QuotaAudio:  "Sorry, you've used up today's voice time. Let's talk again tomorrow.",
+		QuotaTokens: "Sorry, you've reached today's conversation limit. Let's talk again tomorrow.",
+		QuotaBudget: "Sorry, the usage budget for this service has run out. Please contact the administrator.",
+	},
+}
+
+// quotaUsage 单个用户的资源用量
+type quotaUsage struct {
+	turns    []time.Time // 最近一小时内的对话轮次
+	day      string      // 当日日期（用于按天重置）
+	audioMs  int64       // 当日音频毫秒数
+	tokens   int         // 当日Token用量
+	lastSeen time.Time
+}
+
+// QuotaTracker 资源配额统计
+type QuotaTracker struct {
+	config QuotaConfig
+	usage  map[string]*quotaUsage
+	mu     sync.Mutex
+
+	now func() time.Time
+}
+
+// NewQuotaTracker 创建配额统计
+func NewQuotaTracker(config QuotaConfig) *QuotaTracker {
+	return &QuotaTracker{
+		config: config,
+		usage:  make(map[string]*quotaUsage),
+		now:    time.Now,
+	}
+}
+
+// defaultQuotaKey 未携带或未配置API Key的连接共用的配额归属
+const defaultQuotaKey = "default"
+
+// Key 配额归属：已配置的API Key各自计数，其余连接共用默认归属
+// start_session 中客户端自报的 tenant 和 user_id 无法校验，不影响配额
+func (q *QuotaTracker) Key(apiKey string) string {
+	if _, exists := q.config.APIKeys[apiKey]; exists && apiKey != "" {
+		return "api_key/" + apiKey
+	}
+	return defaultQuotaKey
+}
+
+// Limits 获取API Key适用的配额
+func (q *QuotaTracker) Limits(apiKey string) QuotaLimits {
+	if limits, exists := q.config.APIKeys[apiKey]; exists && apiKey != "" {
+		return limits
+	}
+	return q.config.Default
+}
+
+// Check 检查是否已超出配额，返回超出的配额类型（未超出时为空）
+func (q *QuotaTracker) Check(key string, limits QuotaLimits) string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	usage := q.currentUsage(key)
+	switch {
+	case limits.MaxTurnsPerHour > 0 && len(usage.turns) >= limits.MaxTurnsPerHour:
+		return QuotaTurns
+	case limits.MaxAudioMinutesPerDay > 0 && float64(usage.audioMs) >= limits.MaxAudioMinutesPerDay*float64(time.Minute/time.Millisecond):
+		return QuotaAudio
+	case limits.MaxTokensPerDay > 0 && usage.tokens >= limits.MaxTokensPerDay:
+		return QuotaTokens
+	}
+	return ""
+}
+
+// RecordAudio 记录上传的音频
+func (q *QuotaTracker) RecordAudio(key string, audioBytes int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.currentUsage(key).audioMs += int64(audioBytes / pcmBytesPerMillisecond)
+}
+
+// RecordTurn 记录一轮对话及其Token用量
+func (q *QuotaTracker) RecordTurn(key string, tokens int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	usage := q.currentUsage(key)
+	usage.turns = append(usage.turns, q.now())
+	usage.tokens += tokens
+}
+
+// Status 获取配额使用情况
+func (q *QuotaTracker) Status(key string, limits QuotaLimits) *protocol.QuotaStatus {
+	exceeded := q.Check(key, limits)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	usage := q.currentUsage(key)
+	return &protocol.QuotaStatus{
+		TurnsUsed:         len(usage.turns),
+		TurnsLimit:        limits.MaxTurnsPerHour,
+		AudioMinutesUsed:  float64(usage.audioMs) / float64(time.Minute/time.Millisecond),
+		AudioMinutesLimit: limits.MaxAudioMinutesPerDay,
+		TokensUsed:        usage.tokens,
+		TokensLimit:       limits.MaxTokensPerDay,
+		Exceeded:          exceeded,
+	}
+}
+
+// currentUsage 获取用量记录并滚动时间窗口（调用方需持有锁）
+func (q *QuotaTracker) currentUsage(key string) *quotaUsage {
+	now := q.now()
+	day := now.Format("2006-01-02")
+
+	usage, exists := q.usage[key]
+	if !exists {
+		q.pruneIdle(now)
+		usage = &quotaUsage{day: day}
+		q.usage[key] = usage
+	}
+	usage.lastSeen = now
+
+	if usage.day != day {
+		usage.day = day
+		usage.audioMs = 0
+		usage.tokens = 0
+	}
+
+	cutoff := now.Add(-time.Hour)
+	kept := 0
+	for kept < len(usage.turns) && !usage.turns[kept].After(cutoff) {
+		kept++
+	}
+	usage.turns = usage.turns[kept:]
+
+	return usage
+}
+
+// pruneIdle 清理长时间未使用的用量记录（调用方需持有锁）
+func (q *QuotaTracker) pruneIdle(now time.Time) {
+	for key, usage := range q.usage {
+		if now.Sub(usage.lastSeen) > quotaIdleExpiry {
+			delete(q.usage, key)
+		}
+	}
+}
+
+// checkQuota 检查会话是否超出配额或用量预算（未启用时始终通过）
+func (p *MessageProcessor) checkQuota(session *Session) string {
+	if exceeded := p.checkBudget(session, ""); exceeded != "" {
+		log.Printf("会话超出用量预算: %s, 预算: %s", session.ID, exceeded)
+		return QuotaBudget
+	}
+	if p.quotas == nil {
+		return ""
+	}
+
+	session.mu.RLock()
+	key := p.quotas.Key(session.APIKey)
+	limits := p.quotas.Limits(session.APIKey)
+	session.mu.RUnlock()
+
+	return p.quotas.Check(key, limits)
+}
+
+// recordQuotaAudio 记录会话上传的音频时长
+func (p *MessageProcessor) recordQuotaAudio(session *Session, audioBytes int) {
+	if p.quotas == nil {
+		return
+	}
+
+	session.mu.RLock()
+	key := p.quotas.Key(session.APIKey)
+	session.mu.RUnlock()
+
+	p.quotas.RecordAudio(key, audioBytes)
+}
+
+// recordQuotaTurn 记录会话的一轮对话
+func (p *MessageProcessor) recordQuotaTurn(session *Session, tokens int) {
+	if p.quotas == nil {
+		return
+	}
+
+	session.mu.RLock()
+	key := p.quotas.Key(session.APIKey)
+	session.mu.RUnlock()
+
+	p.quotas.RecordTurn(key, tokens)
+}
+
+// quotaStatus 获取会话的配额使用情况（未启用时为nil）
+func (p *MessageProcessor) quotaStatus(session *Session) *protocol.QuotaStatus {
+	if p.quotas == nil {
+		return nil
+	}
+
+	session.mu.RLock()
+	key := p.quotas.Key(session.APIKey)
+	limits := p.quotas.Limits(session.APIKey)
+	session.mu.RUnlock()
+
+	return p.quotas.Status(key, limits)
+}
+
+// refuseForQuota 以会话语言告知用户已超出配额（文本和语音，仅文本模式不合成语音）
+func (p *MessageProcessor) refuseForQuota(ctx context.Context, client *Client, session *Session, exceeded, language string, textOnly bool) error {
+	refusals, exists := quotaRefusals[language]
+	if !exists {
+		refusals = quotaRefusals["zh"]
+	}
+
+	log.Printf("会话超出配额: %s, 类型: %s", session.ID, exceeded)
+
+	client.SendMessage(protocol.NewMessage(protocol.Error, client.ID, &protocol.ErrorData{
+		Code:        protocol.ErrQuotaExceeded,
+		Message:     refusals[exceeded],
+		Recoverable: true,
+		Details:     map[string]interface{}{"quota": exceeded},
+	}))
+	return p.speakNotice(ctx, client, session, refusals[exceeded], map[string]interface{}{
+		"quota_exceeded": exceeded,
+	}, textOnly)
+}
+
+// speakNotice 代替LLM回复一句提示（文本和语音，仅文本模式不合成语音）
+func (p *MessageProcessor) speakNotice(ctx context.Context, client *Client, session *Session, message string, metadata map[string]interface{}, textOnly bool) error {
+	p.sendResponseData(client, &protocol.ResponseData{
+		Stage:      protocol.StageLLM,
+		Content:    message,
+		Confidence: 1.0,
+		IsFinal:    true,
+		Metadata:   metadata,
+	})
+
+	if textOnly {
+		return nil
+	}
+
+	ttsResult, err := p.synthesize(ctx, sessionPriority(session), message)
+	if err != nil {
+		log.Printf("TTS处理失败: %v", err)
+		return p.sendStageError(client, pipeline.StageTTS, err, "语音合成失败")
+	}
+
+	if err := p.sendSpeech(client, ttsResult); err != nil {
+		return err
+	}
+	p.rememberSpoken(session, message)
+	return nil
+}
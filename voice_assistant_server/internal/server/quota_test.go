@@ -0,0 +1,76 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestQuotaTracker(config QuotaConfig) (*QuotaTracker, *time.Time) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.Local)
+	q := NewQuotaTracker(config)
+	q.now = func() time.Time { return now }
+	return q, &now
+}
+
+func TestQuotaTurnsSlidingHour(t *testing.T) {
+	q, now := newTestQuotaTracker(QuotaConfig{})
+	limits := QuotaLimits{MaxTurnsPerHour: 2}
+
+	q.RecordTurn("u", 0)
+	*now = now.Add(30 * time.Minute)
+	q.RecordTurn("u", 0)
+	assert.Equal(t, QuotaTurns, q.Check("u", limits))
+	assert.Equal(t, "", q.Check("other", limits))
+
+	// 第一轮滑出一小时窗口后恢复
+	*now = now.Add(31 * time.Minute)
+	assert.Equal(t, "", q.Check("u", limits))
+	assert.Equal(t, 1, q.Status("u", limits).TurnsUsed)
+}
+
+func TestQuotaDailyAudioAndTokensReset(t *testing.T) {
+	q, now := newTestQuotaTracker(QuotaConfig{})
+	limits := QuotaLimits{MaxAudioMinutesPerDay: 1, MaxTokensPerDay: 100}
+
+	q.RecordAudio("u", 60*1000*pcmBytesPerMillisecond)
+	assert.Equal(t, QuotaAudio, q.Check("u", limits))
+	assert.InDelta(t, 1.0, q.Status("u", limits).AudioMinutesUsed, 0.001)
+
+	q, now = newTestQuotaTracker(QuotaConfig{})
+	q.RecordTurn("u", 150)
+	assert.Equal(t, QuotaTokens, q.Check("u", limits))
+
+	// 次日重置
+	*now = now.Add(24 * time.Hour)
+	assert.Equal(t, "", q.Check("u", limits))
+	assert.Equal(t, 0, q.Status("u", limits).TokensUsed)
+}
+
+func TestQuotaLimitsByAPIKey(t *testing.T) {
+	q := NewQuotaTracker(QuotaConfig{
+		Default: QuotaLimits{MaxTurnsPerHour: 10},
+		APIKeys: map[string]QuotaLimits{"key-acme": {MaxTurnsPerHour: 50}, "key-vip": {MaxTurnsPerHour: 0}},
+	})
+
+	assert.Equal(t, 10, q.Limits("").MaxTurnsPerHour)
+	assert.Equal(t, 10, q.Limits("key-other").MaxTurnsPerHour)
+	assert.Equal(t, 50, q.Limits("key-acme").MaxTurnsPerHour)
+	assert.Equal(t, 0, q.Limits("key-vip").MaxTurnsPerHour)
+
+	// 只有配置的API Key单独计数
+	assert.Equal(t, "api_key/key-acme", q.Key("key-acme"))
+	assert.Equal(t, defaultQuotaKey, q.Key(""))
+	assert.Equal(t, defaultQuotaKey, q.Key("key-other"))
+}
+
+func TestQuotaUnknownKeysShareDefault(t *testing.T) {
+	p := &MessageProcessor{quotas: NewQuotaTracker(QuotaConfig{Default: QuotaLimits{MaxTurnsPerHour: 2}})}
+
+	// 换用随机API Key、不带API Key或重连都计入同一份默认配额，客户端自报的tenant和user_id不影响归属
+	p.recordQuotaTurn(&Session{ID: "s1", APIKey: "random-1"}, 0)
+	p.recordQuotaTurn(&Session{ID: "s2", APIKey: "random-2", Tenant: "acme", UserID: "vip"}, 0)
+	assert.Equal(t, QuotaTurns, p.checkQuota(&Session{ID: "s3", APIKey: "random-3"}))
+	assert.Equal(t, QuotaTurns, p.checkQuota(&Session{ID: "s4"}))
+}
@@ -0,0 +1,318 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"voice_assistant/pkg/protocol"
+	"voice_assistant/voice_assistant_server/internal/pipeline"
+	"voice_assistant/voice_assistant_server/internal/reminder"
+)
+
+// reminderDeliverTimeout 到期提醒的合成和发送超时
+const reminderDeliverTimeout = 30 * time.Second
+
+// reminderReplies 提醒指令的回复和到期通知（按语言）
+var reminderReplies = map[string]map[string]string{
+	"zh": {
+		"set_reminder":   "好的，%s提醒你%s。",
+		"set_timer":      "好的，%s的计时开始了。",
+		"too_many":       "待办的提醒太多了，先取消一些再设置吧。",
+		"past":           "这个时间已经过去了，换个时间吧。",
+		"canceled":       "已取消%d个提醒。",
+		"none":           "你现在没有待办的提醒。",
+		"list":           "你有%d个提醒：%s。",
+		"due_reminder":   "提醒时间到了：%s。",
+		"due_empty":      "你设置的提醒时间到了。",
+		"due_timer":      "%s的计时结束了。",
+		"missed_prefix":  "你有一条错过的提醒，",
+		"item_timer":     "%s的计时器还剩%s",
+		"item_reminder":  "%s%s",
+		"list_separator": "；",
+	},
+	"en": {
+		"set_reminder":   "OK, I'll remind you %s%s.",
+		"set_timer":      "OK, your %s timer has started.",
+		"too_many":       "You have too many pending reminders. Cancel some first.",
+		"past":           "That time has already passed. Try another time.",
+		"canceled":       "Canceled %d reminder(s).",
+		"none":           "You have no pending reminders.",
+		"list":           "You have %d reminder(s): %s.",
+		"due_reminder":   "Reminder: %s.",
+		"due_empty":      "It's time for your reminder.",
+		"due_timer":      "Your %s timer is done.",
+		"missed_prefix":  "You missed a reminder. ",
+		"item_timer":     "%s timer with %s left",
+		"item_reminder":  "%s%s",
+		"list_separator": "; ",
+	},
+}
+
+// reminderReply 指定语言的回复模板（不支持的语言使用中文）
+func reminderReply(language, key string) string {
+	replies, exists := reminderReplies[language]
+	if !exists {
+		replies = reminderReplies["zh"]
+	}
+	return replies[key]
+}
+
+// handleReminderIntent 处理设置、取消、查询提醒和计时器的指令，返回是否已处理（已处理时不再调用LLM）
+func (p *MessageProcessor) handleReminderIntent(ctx context.Context, client *Client, session *Session, text string, textOnly bool) bool {
+	if p.reminders == nil {
+		return false
+	}
+	now := p.reminders.Now()
+	intent, ok := reminder.ParseIntent(text, now)
+	if !ok {
+		return false
+	}
+
+	session.mu.Lock()
+	owner := reminder.Owner{SessionID: session.ID, UserID: session.UserID}
+	language := session.Language
+	session.State = StateResponding
+	session.mu.Unlock()
+	if language == "" {
+		language = intent.Language
+	}
+
+	var reply string
+	switch intent.Action {
+	case reminder.ActionSet:
+		r, err := p.reminders.Add(reminder.Reminder{
+			Kind:      intent.Kind,
+			Text:      intent.Text,
+			Duration:  intent.Duration,
+			SessionID: owner.SessionID,
+			UserID:    owner.UserID,
+			Language:  language,
+			DueAt:     intent.DueAt,
+		})
+		switch {
+		case errors.Is(err, reminder.ErrTooMany):
+			reply = reminderReply(language, "too_many")
+		case err != nil:
+			reply = reminderReply(language, "past")
+		case r.Kind == reminder.KindTimer:
+			reply = fmt.Sprintf(reminderReply(language, "set_timer"), formatReminderDuration(r.Duration, language))
+			log.Printf("已设置计时器: %s, %v", session.ID, r.Duration)
+		default:
+			reply = fmt.Sprintf(reminderReply(language, "set_reminder"), formatReminderTime(r, now, language), reminderContent(r.Text, language))
+			log.Printf("已设置提醒: %s, %s", session.ID, r.DueAt.Format(time.RFC3339))
+		}
+	case reminder.ActionCancel:
+		reply = fmt.Sprintf(reminderReply(language, "canceled"), p.reminders.Cancel(owner, intent.Kind))
+	case reminder.ActionList:
+		reply = p.listReminders(owner, intent.Kind, now, language)
+	}
+
+	p.speakNotice(ctx, client, session, reply, map[string]interface{}{"reminder": string(intent.Action)}, textOnly)
+	return true
+}
+
+// listReminders 待触发提醒的口语化列表
+func (p *MessageProcessor) listReminders(owner reminder.Owner, kind reminder.Kind, now time.Time, language string) string {
+	var items []string
+	for _, r := range p.reminders.List(owner) {
+		if kind != "" && r.Kind != kind {
+			continue
+		}
+		if r.Kind == reminder.KindTimer {
+			left := r.DueAt.Sub(now).Round(time.Second)
+			items = append(items, fmt.Sprintf(reminderReply(language, "item_timer"), formatReminderDuration(r.Duration, language), formatReminderDuration(left, language)))
+		} else {
+			items = append(items, fmt.Sprintf(reminderReply(language, "item_reminder"), formatReminderTime(r, now, language), reminderContent(r.Text, language)))
+		}
+	}
+	if len(items) == 0 {
+		return reminderReply(language, "none")
+	}
+	return fmt.Sprintf(reminderReply(language, "list"), len(items), strings.Join(items, reminderReply(language, "list_separator")))
+}
+
+// reminderContent 提醒内容在回复中的写法
+func reminderContent(text, language string) string {
+	if text == "" {
+		return ""
+	}
+	if language == "en" {
+		return " to " + text
+	}
+	return text
+}
+
+// formatReminderTime 提醒时间的口语化写法：一小时内用相对时间，之外用具体时间
+func formatReminderTime(r reminder.Reminder, now time.Time, language string) string {
+	due := r.DueAt.In(now.Location())
+	if left := due.Sub(now); left < time.Hour {
+		if language == "en" {
+			return "in " + formatReminderDuration(left.Round(time.Second), language)
+		}
+		return formatReminderDuration(left.Round(time.Second), language) + "后"
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	days := int(due.Sub(today).Hours() / 24)
+	if language == "en" {
+		switch days {
+		case 0:
+			return "at " + due.Format("3:04 PM")
+		case 1:
+			return "at " + due.Format("3:04 PM") + " tomorrow"
+		}
+		return "at " + due.Format("3:04 PM on Jan 2")
+	}
+	switch days {
+	case 0:
+		return "今天" + due.Format("15:04")
+	case 1:
+		return "明天" + due.Format("15:04")
+	}
+	return due.Format("1月2日15:04")
+}
+
+// formatReminderDuration 时长的口语化写法（如“1小时30分钟”“90 seconds”）
+func formatReminderDuration(d time.Duration, language string) string {
+	hours, minutes, seconds := int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60
+	var parts []string
+	if language == "en" {
+		for _, part := range []struct {
+			value int
+			unit  string
+		}{{hours, "hour"}, {minutes, "minute"}, {seconds, "second"}} {
+			if part.value == 1 {
+				parts = append(parts, "1 "+part.unit)
+			} else if part.value > 1 {
+				parts = append(parts, fmt.Sprintf("%d %ss", part.value, part.unit))
+			}
+		}
+		if len(parts) == 0 {
+			return "0 seconds"
+		}
+		return strings.Join(parts, " ")
+	}
+
+	if hours > 0 {
+		parts = append(parts, fmt.Sprintf("%d小时", hours))
+	}
+	if minutes > 0 {
+		parts = append(parts, fmt.Sprintf("%d分钟", minutes))
+	}
+	if seconds > 0 {
+		parts = append(parts, fmt.Sprintf("%d秒", seconds))
+	}
+	if len(parts) == 0 {
+		return "0秒"
+	}
+	return strings.Join(parts, "")
+}
+
+// deliverReminder 向提醒所有者在线的会话推送通知（带播报语音），返回是否送达
+func (p *MessageProcessor) deliverReminder(r reminder.Reminder) bool {
+	owner := reminder.Owner{SessionID: r.SessionID, UserID: r.UserID}
+	p.mu.RLock()
+	var targets []*Session
+	for _, session := range p.sessions {
+		session.mu.RLock()
+		if (r.UserID != "" && session.UserID == r.UserID) || (r.UserID == "" && session.ID == owner.SessionID) {
+			if session.client != nil && connected(session.client) {
+				targets = append(targets, session)
+			}
+		}
+		session.mu.RUnlock()
+	}
+	p.mu.RUnlock()
+
+	delivered := false
+	for _, session := range targets {
+		if err := p.sendReminder(session, r); err != nil {
+			log.Printf("推送提醒失败: %s, %v", session.ID, err)
+			continue
+		}
+		delivered = true
+	}
+	return delivered
+}
+
+// sendReminder 以会话语言合成提醒并推送通知（仅文本模式不合成语音）
+func (p *MessageProcessor) sendReminder(session *Session, r reminder.Reminder) error {
+	session.mu.RLock()
+	language, voice, textOnly := session.Language, session.Voice, session.TextOnly
+	session.mu.RUnlock()
+	if language == "" {
+		language = r.Language
+	}
+
+	var content string
+	switch {
+	case r.Kind == reminder.KindTimer:
+		content = fmt.Sprintf(reminderReply(language, "due_timer"), formatReminderDuration(r.Duration, language))
+	case r.Text == "":
+		content = reminderReply(language, "due_empty")
+	default:
+		content = fmt.Sprintf(reminderReply(language, "due_reminder"), r.Text)
+	}
+	if r.Missed {
+		content = reminderReply(language, "missed_prefix") + content
+	}
+
+	notification := protocol.NotificationData{
+		ID:         r.ID,
+		Kind:       string(r.Kind),
+		Level:      protocol.NotifyInfo,
+		Content:    content,
+		RequireAck: true,
+		DueAt:      r.DueAt.UnixMilli(),
+		Missed:     r.Missed,
+	}
+	if !textOnly {
+		ctx, cancel := context.WithTimeout(context.Background(), reminderDeliverTimeout)
+		defer cancel()
+		ctx = withVoiceOptions(withLanguageOptions(ctx, language), voice)
+		ttsResult, err := p.synthesize(ctx, pipeline.PriorityInteractive, content)
+		if err != nil {
+			return err
+		}
+		notification.AudioData, notification.AudioFormat = ttsResult.AudioData, speechFormat(ttsResult)
+	}
+
+	log.Printf("推送提醒: %s, %s", session.ID, r.ID)
+	return p.sendNotification(session, notification)
+}
+
+// redeliverReminders 会话开始时补发错过的提醒
+func (p *MessageProcessor) redeliverReminders(session *Session) {
+	if p.reminders == nil {
+		return
+	}
+	session.mu.RLock()
+	owner := reminder.Owner{SessionID: session.ID, UserID: session.UserID}
+	session.mu.RUnlock()
+
+	if n := p.reminders.Redeliver(owner); n > 0 {
+		log.Printf("已补发错过的提醒: %s, %d条", session.ID, n)
+	}
+}
+
+// connected 会话所在的WebSocket连接是否仍可发送（可恢复会话断线期间的消息会在重连后补发）
+// gRPC和WebRTC传输没有对应的通知消息，不作为投递目标。
+func connected(client *Client) bool {
+	conn := client.Connection()
+	if conn.resume != nil {
+		return true
+	}
+	if conn.Conn == nil {
+		return false
+	}
+	select {
+	case <-conn.done:
+		return false
+	default:
+		return true
+	}
+}
@@ -0,0 +1,37 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"voice_assistant/voice_assistant_server/internal/reminder"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatReminderDuration(t *testing.T) {
+	assert.Equal(t, "1小时30分钟", formatReminderDuration(90*time.Minute, "zh"))
+	assert.Equal(t, "45秒", formatReminderDuration(45*time.Second, "zh"))
+	assert.Equal(t, "1 hour 30 minutes", formatReminderDuration(90*time.Minute, "en"))
+	assert.Equal(t, "1 minute", formatReminderDuration(time.Minute, "en"))
+}
+
+func TestFormatReminderTime(t *testing.T) {
+	now := time.Date(2024, 5, 1, 9, 0, 0, 0, time.Local)
+
+	soon := reminder.Reminder{DueAt: now.Add(10 * time.Minute)}
+	assert.Equal(t, "10分钟后", formatReminderTime(soon, now, "zh"))
+	assert.Equal(t, "in 10 minutes", formatReminderTime(soon, now, "en"))
+
+	tomorrow := reminder.Reminder{DueAt: time.Date(2024, 5, 2, 8, 30, 0, 0, time.Local)}
+	assert.Equal(t, "明天08:30", formatReminderTime(tomorrow, now, "zh"))
+	assert.Equal(t, "at 8:30 AM tomorrow", formatReminderTime(tomorrow, now, "en"))
+}
+
+func TestDeliverReminderWithoutSession(t *testing.T) {
+	p := NewMessageProcessor(ProcessorConfig{MaxConcurrentSessions: 10})
+	p.getOrCreateSession("other", "")
+
+	// 所有者不在线时不算送达，由调度器保留等待补发
+	assert.False(t, p.deliverReminder(reminder.Reminder{ID: "r1", Kind: reminder.KindTimer, SessionID: "s1", Duration: time.Minute}))
+}
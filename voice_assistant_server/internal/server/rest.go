@@ -0,0 +1,273 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	vaaudio "voice_assistant/pkg/audio"
+	"voice_assistant/pkg/protocol"
+	"voice_assistant/voice_assistant_server/internal/llm"
+	"voice_assistant/voice_assistant_server/internal/moderation"
+	"voice_assistant/voice_assistant_server/internal/pipeline"
+	"voice_assistant/voice_assistant_server/internal/tts"
+
+	"github.com/gin-gonic/gin"
+)
+
+// REST接口限制
+const (
+	maxRESTAudioSize = 25 << 20 // 上传音频上限（字节）
+	restTimeout      = 60 * time.Second
+)
+
+// RESTHandler 一次性调用的HTTP接口：直接复用处理器中的ASR、LLM、TTS服务，无需实现WebSocket协议
+type RESTHandler struct {
+	processor *MessageProcessor
+}
+
+// NewRESTHandler 创建REST接口
+func NewRESTHandler(processor *MessageProcessor) *RESTHandler {
+	return &RESTHandler{
+		processor: processor,
+	}
+}
+
+// Register 注册路由
+func (h *RESTHandler) Register(router gin.IRouter) {
+	router.POST("/asr", h.handleASR)
+	router.POST("/chat", h.handleChat)
+	router.POST("/tts", h.handleTTS)
+	router.GET("/knowledge", h.handleKnowledgeStats)
+	router.GET("/voices", h.handleVoiceList)
+	router.GET("/speakers", h.handleSpeakerList)
+	router.GET("/personas", h.handlePersonaList)
+	router.GET("/usage", h.handleUsage)
+}
+
+// ChatRequest 对话请求（messages 与 message 二选一）
+type ChatRequest struct {
+	Messages       []llm.Message `json:"messages"`        // 完整消息列表（无状态调用）
+	Message        string        `json:"message"`         // 单条用户输入（配合 conversation_id 保持上下文）
+	ConversationID string        `json:"conversation_id"` // 对话ID
+	Language       string        `json:"language"`        // 回复语言
+	Persona        string        `json:"persona"`         // 人设ID（仅 message 方式生效，为空时使用默认人设）
+}
+
+// TTSRequest 语音合成请求
+type TTSRequest struct {
+	Text     string `json:"text"`     // 合成文本
+	Language string `json:"language"` // 语言
+	Voice    string `json:"voice"`    // 声音（覆盖语言默认声音）
+}
+
+// handleASR 上传音频（multipart字段 audio，16kHz 16bit 单声道PCM或WAV）返回识别文本
+func (h *RESTHandler) handleASR(c *gin.Context) {
+	if !h.ready(c) || !h.budgetReady(c) {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxRESTAudioSize)
+	fileHeader, err := c.FormFile("audio")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少音频文件: " + err.Error()})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pcm, err := extractPCM(data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), restTimeout)
+	defer cancel()
+	ctx = withUsageScope(ctx, nil, requestAPIKey(c.Request))
+	ctx = withLanguageOptions(ctx, c.PostForm("language"))
+
+	waitSpeaker := h.processor.startSpeakerIdentification(ctx, pcm, true)
+	result, err := h.processor.recognize(ctx, pipeline.PriorityBatch, pcm)
+	if err != nil {
+		h.serviceError(c, pipeline.StageASR, err, "语音识别失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"text":       result.Text,
+		"confidence": result.Confidence,
+		"language":   result.Language,
+		"words":      toWordTimings(result.Words),
+		"speaker":    waitSpeaker(),
+	})
+}
+
+// handleChat 文本对话
+func (h *RESTHandler) handleChat(c *gin.Context) {
+	if !h.ready(c) || !h.budgetReady(c) {
+		return
+	}
+
+	var req ChatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.Messages) == 0 && req.Message == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "messages 和 message 不能同时为空"})
+		return
+	}
+	if req.Persona != "" {
+		if _, ok := h.processor.personas.Get(req.Persona); !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "未知的人设: " + req.Persona})
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), restTimeout)
+	defer cancel()
+	ctx = withUsageScope(ctx, nil, requestAPIKey(c.Request))
+	ctx = withLanguageOptions(ctx, req.Language)
+
+	// 内容审核：只审核最新的一条用户输入
+	if !h.moderateChatInput(ctx, c, &req) {
+		return
+	}
+
+	var response llm.LLMResponse
+	var err error
+	if len(req.Messages) > 0 {
+		response, err = h.processor.generate(ctx, pipeline.PriorityBatch, req.Messages)
+	} else {
+		conversationID := req.ConversationID
+		if conversationID == "" {
+			conversationID = fmt.Sprintf("rest_%d", time.Now().UnixNano())
+		}
+		ctx = h.processor.withPersona(ctx, req.Persona)
+		ctx = h.processor.withKnowledge(ctx, req.Message)
+		response, err = h.processor.chat(ctx, pipeline.PriorityBatch, req.Message, conversationID)
+		req.ConversationID = conversationID
+	}
+	if err != nil {
+		h.serviceError(c, pipeline.StageLLM, err, "文本生成失败")
+		return
+	}
+
+	output := h.processor.moderate(ctx, moderation.StageOutput, response.Content)
+	if output.Blocked() {
+		refuseModeratedREST(c, moderation.StageOutput, output)
+		return
+	}
+	response.Content = output.Text
+
+	c.JSON(http.StatusOK, gin.H{
+		"content":         response.Content,
+		"model":           response.Model,
+		"finish_reason":   response.FinishReason,
+		"token_usage":     response.TokenUsage,
+		"conversation_id": req.ConversationID,
+	})
+}
+
+// moderateChatInput 审核对话请求中最新的用户输入（改写或替换后写回请求），被拒绝时返回403
+func (h *RESTHandler) moderateChatInput(ctx context.Context, c *gin.Context, req *ChatRequest) bool {
+	text := &req.Message
+	if len(req.Messages) > 0 {
+		text = nil
+		for i := len(req.Messages) - 1; i >= 0; i-- {
+			if req.Messages[i].Role == "user" {
+				text = &req.Messages[i].Content
+				break
+			}
+		}
+		if text == nil {
+			return true
+		}
+	}
+
+	input := h.processor.moderate(ctx, moderation.StageInput, *text)
+	if input.Blocked() {
+		refuseModeratedREST(c, moderation.StageInput, input)
+		return false
+	}
+	*text = input.Text
+	return true
+}
+
+// refuseModeratedREST 内容未通过审核时的响应
+func refuseModeratedREST(c *gin.Context, stage moderation.Stage, decision moderation.Decision) {
+	c.JSON(http.StatusForbidden, gin.H{
+		"error":      "内容未通过审核",
+		"code":       protocol.ErrContentRefused,
+		"stage":      stage,
+		"categories": decision.Categories,
+	})
+}
+
+// handleTTS 合成语音并以音频文件返回
+func (h *RESTHandler) handleTTS(c *gin.Context) {
+	if !h.ready(c) || !h.budgetReady(c) {
+		return
+	}
+
+	var req TTSRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Text == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "text 不能为空"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), restTimeout)
+	defer cancel()
+	ctx = withUsageScope(ctx, nil, requestAPIKey(c.Request))
+	ctx = withLanguageOptions(ctx, req.Language)
+	if req.Voice != "" {
+		opts := tts.RequestOptionsFromContext(ctx)
+		opts.Voice = req.Voice
+		ctx = tts.WithRequestOptions(ctx, opts)
+	}
+
+	result, err := h.processor.synthesize(ctx, pipeline.PriorityBatch, req.Text)
+	if err != nil {
+		h.serviceError(c, pipeline.StageTTS, err, "语音合成失败")
+		return
+	}
+
+	audio, contentType, ext := result.AudioData, "audio/wav", "wav"
+	switch result.Format {
+	case "mp3":
+		contentType, ext = "audio/mpeg", "mp3"
+	case "pcm", "":
+		// 裸PCM补上WAV头，便于直接播放
+		audio = vaaudio.EncodeWAV(result.AudioData, result.SampleRate, result.Channels)
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="speech.%s"`, ext))
+	c.Data(http.StatusOK, contentType, audio)
+}
+
+// ready 检查处理器是否已初始化
+func (h *RESTHandler) ready(c *gin.Context) bool {
+	if h.processor == nil || !h.processor.isInitialized {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "处理器未初始化"})
+		return false
+	}
+	return true
+}
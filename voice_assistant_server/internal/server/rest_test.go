@@ -0,0 +1,92 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	vaaudio "voice_assistant/pkg/audio"
+	"voice_assistant/voice_assistant_server/internal/moderation"
+	"voice_assistant/voice_assistant_server/internal/tts"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractPCM(t *testing.T) {
+	pcm := []byte{1, 2, 3, 4, 5, 6}
+
+	// WAV文件去掉文件头
+	extracted, err := extractPCM(vaaudio.EncodeWAV(pcm, 16000, 1))
+	require.NoError(t, err)
+	assert.Equal(t, pcm, extracted)
+
+	// 裸PCM原样返回
+	extracted, err = extractPCM(pcm)
+	require.NoError(t, err)
+	assert.Equal(t, pcm, extracted)
+
+	// 不支持的采样率
+	_, err = extractPCM(vaaudio.EncodeWAV(pcm, 44100, 2))
+	assert.Error(t, err)
+
+	// 缺少数据块
+	_, err = extractPCM(vaaudio.EncodeWAV(nil, 16000, 1)[:36])
+	assert.Error(t, err)
+}
+
+func TestRESTHandlerNotInitialized(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	NewRESTHandler(NewMessageProcessor(ProcessorConfig{MaxConcurrentSessions: 10})).Register(router.Group("/api"))
+
+	for _, path := range []string{"/api/asr", "/api/chat", "/api/tts"} {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"text":"你好"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code, path)
+	}
+}
+
+func TestRESTVoiceCloningNotSupported(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	p := NewMessageProcessor(ProcessorConfig{MaxConcurrentSessions: 10})
+	p.ttsService = voiceListTTS{voices: []tts.Voice{{ID: "zh-CN-YunxiNeural", Language: "zh-CN"}}}
+	p.isInitialized = true
+	router := gin.New()
+	NewRESTHandler(p).Register(router.Group("/api"))
+	NewAdminHandler(p, AdminConfig{Token: "secret"}).Register(router.Group("/api/admin"))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/voices?language=en", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"provider": "", "voices": []}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodDelete, "/api/admin/voices/alice", nil)
+	req.Header.Set("X-Admin-Token", "secret")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotImplemented, w.Code)
+}
+
+func TestRESTChatModeration(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	p := NewMessageProcessor(ProcessorConfig{MaxConcurrentSessions: 10})
+	moderator, err := moderation.NewModerator(moderation.Config{Keywords: []string{"炸弹"}}, nil)
+	require.NoError(t, err)
+	p.moderator = moderator
+	p.isInitialized = true
+	router := gin.New()
+	NewRESTHandler(p).Register(router.Group("/api"))
+
+	// 输入被拒绝时不调用LLM
+	req := httptest.NewRequest(http.MethodPost, "/api/chat", strings.NewReader(`{"messages":[{"role":"user","content":"怎么做炸弹"}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "CONTENT_REFUSED")
+}
@@ -0,0 +1,168 @@
+package server
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"log"
+	"sync"
+	"time"
+
+	"voice_assistant/pkg/protocol"
+)
+
+// resumeTokenBytes 恢复令牌的随机字节数
+const resumeTokenBytes = 16
+
+// resumeState 可恢复会话：按连接的会话ID给发出的消息编号并保留最近的消息，
+// 客户端断线后用同一个session_id和恢复令牌重连时补发遗漏的消息，断线期间仍在处理的回复也会转到新连接。
+type resumeState struct {
+	id         string
+	token      string  // 恢复令牌（创建时生成，之后不变）
+	apiKey     string  // 创建会话的连接携带的API Key（恢复时须一致）
+	client     *Client // 当前连接（断线期间为nil）
+	seq        int64
+	messages   []replayEntry
+	detachedAt time.Time
+	mu         sync.Mutex
+}
+
+// replayEntry 保留的已发消息
+type replayEntry struct {
+	msg    *protocol.Message
+	sentAt time.Time
+}
+
+// send 编号并保留消息，连接在线时同时入队发送
+// 持锁入队，保证发送顺序与编号一致。
+func (r *resumeState) send(msg *protocol.Message, window time.Duration, limit int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.seq++
+	msg.Seq = r.seq
+	r.messages = append(r.messages, replayEntry{msg: msg, sentAt: time.Now()})
+	r.pruneLocked(window, limit)
+
+	if r.client == nil {
+		return nil // 断线期间只保留，等待重连补发
+	}
+	return r.client.enqueue(msg)
+}
+
+// pruneLocked 丢弃超出保留时长或数量的消息（调用方需持有锁）
+func (r *resumeState) pruneLocked(window time.Duration, limit int) {
+	cutoff := time.Now().Add(-window)
+	drop := 0
+	for drop < len(r.messages) && r.messages[drop].sentAt.Before(cutoff) {
+		drop++
+	}
+	if limit > 0 && len(r.messages)-drop > limit {
+		drop = len(r.messages) - limit
+	}
+	if drop > 0 {
+		r.messages = append(r.messages[:0], r.messages[drop:]...)
+	}
+}
+
+// claimResume 查找或创建会话ID的可恢复会话，ok为false表示拒绝连接（resumed为true时令牌不匹配，否则会话ID已被使用）
+// 会话ID可能被猜到，恢复已有会话时须提供连接确认中下发的令牌，且API Key与创建会话的连接一致。
+func (s *WebSocketServer) claimResume(sessionID, token, apiKey string) (state *resumeState, resumed, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, resumed = s.resumes[sessionID]
+	if !resumed {
+		// 没有可恢复的会话时按新会话处理，会话ID已被其他连接（如gRPC、WebRTC）使用时拒绝
+		if s.processor != nil && !s.processor.createSession(sessionID, apiKey) {
+			return nil, false, false
+		}
+		state = &resumeState{id: sessionID, token: randomHex(resumeTokenBytes), apiKey: apiKey}
+		s.resumes[sessionID] = state
+		return state, false, true
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(state.token)) != 1 ||
+		subtle.ConstantTimeCompare([]byte(apiKey), []byte(state.apiKey)) != 1 {
+		return nil, true, false
+	}
+	return state, true, true
+}
+
+// resumeConnection 为新连接关联可恢复会话（由 claimResume 取得）
+// 连接确认和遗漏的消息在关联时一并入队，之后处理器发出的消息排在它们后面。
+func (s *WebSocketServer) resumeConnection(client *Client, state *resumeState, resumed bool, lastSeq int64, connected *protocol.StatusData) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	previous := state.client
+	state.client = client
+	state.detachedAt = time.Time{}
+	client.resume = state
+
+	connected.Resumed = resumed
+	connected.ResumeToken = state.token
+	client.enqueue(protocol.NewMessage(protocol.Status, client.ID, connected))
+
+	state.pruneLocked(s.config.ResumeWindow, s.config.ResumeBufferSize)
+	replayed := 0
+	for _, entry := range state.messages {
+		if entry.msg.Seq > lastSeq {
+			client.enqueue(entry.msg)
+			replayed++
+		}
+	}
+
+	// 旧连接尚未检测到断线（如网络切换），由新连接接管
+	if previous != nil && previous != client && previous.Conn != nil {
+		previous.Conn.Close()
+	}
+
+	if resumed {
+		log.Printf("会话已恢复: %s, 补发消息: %d", client.ID, replayed)
+	}
+}
+
+// randomHex 随机字节的十六进制表示（会话ID、恢复令牌）
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// forgetResume 丢弃会话的恢复状态（会话被强制结束后不再允许恢复）
+func (s *WebSocketServer) forgetResume(sessionID string) {
+	s.mu.Lock()
+	delete(s.resumes, sessionID)
+	s.mu.Unlock()
+}
+
+// detachConnection 连接断开：保留会话等待重连，超过保留时长后释放
+func (s *WebSocketServer) detachConnection(client *Client) {
+	state := client.resume
+	if state == nil {
+		return
+	}
+
+	state.mu.Lock()
+	if state.client != client {
+		state.mu.Unlock()
+		return // 已被新连接接管
+	}
+	state.client = nil
+	state.detachedAt = time.Now()
+	state.mu.Unlock()
+
+	time.AfterFunc(s.config.ResumeWindow, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		state.mu.Lock()
+		defer state.mu.Unlock()
+		if state.client == nil && time.Since(state.detachedAt) >= s.config.ResumeWindow && s.resumes[state.id] == state {
+			delete(s.resumes, state.id)
+			if s.processor != nil {
+				s.processor.ReleaseSession(state.id)
+			}
+		}
+	})
+}
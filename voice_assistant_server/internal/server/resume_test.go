@@ -0,0 +1,201 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"voice_assistant/pkg/protocol"
+)
+
+// dialResume 连接测试服务器并读取连接确认
+func dialResume(t *testing.T, url, token, lastSeq string) (*websocket.Conn, *protocol.StatusData) {
+	query := "?session_id=resume-test"
+	if token != "" {
+		query += "&resume_token=" + token
+	}
+	if lastSeq != "" {
+		query += "&last_seq=" + lastSeq
+	}
+	conn, _, err := websocket.DefaultDialer.Dial(url+query, nil)
+	require.NoError(t, err)
+
+	msg := readResumeMessage(t, conn)
+	require.Equal(t, protocol.Status, msg.Type)
+	status, err := msg.StatusData()
+	require.NoError(t, err)
+	require.Equal(t, "connected", status.State)
+	return conn, status
+}
+
+// readResumeMessage 读取一条消息
+func readResumeMessage(t *testing.T, conn *websocket.Conn) *protocol.Message {
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, data, err := conn.ReadMessage()
+	require.NoError(t, err)
+	msg, err := protocol.FromJSON(data)
+	require.NoError(t, err)
+	return msg
+}
+
+// currentClient 等待服务端登记指定会话的连接
+func currentClient(t *testing.T, s *WebSocketServer, previous *Client) *Client {
+	var client *Client
+	require.Eventually(t, func() bool {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		client = s.clients["resume-test"]
+		return client != nil && client != previous
+	}, 2*time.Second, 10*time.Millisecond)
+	return client
+}
+
+func TestWebSocketResume(t *testing.T) {
+	s := NewWebSocketServer(WebSocketConfig{
+		MaxConnections:   10,
+		PingPeriod:       time.Minute,
+		PongWait:         time.Minute,
+		WriteWait:        time.Second,
+		ResumeWindow:     time.Minute,
+		ResumeBufferSize: 10,
+	})
+	httpServer := httptest.NewServer(http.HandlerFunc(s.HandleConnection))
+	defer httpServer.Close()
+	url := "ws" + strings.TrimPrefix(httpServer.URL, "http")
+
+	conn, status := dialResume(t, url, "", "")
+	assert.False(t, status.Resumed)
+	token := status.ResumeToken
+	assert.Len(t, token, 2*resumeTokenBytes)
+	first := currentClient(t, s, nil)
+
+	require.NoError(t, first.SendMessage(protocol.NewResponseMessage(first.ID, protocol.StageASR, "一", 1, true, nil)))
+	msg := readResumeMessage(t, conn)
+	assert.EqualValues(t, 1, msg.Seq)
+
+	// 断线期间处理器仍通过旧连接发送回复
+	conn.Close()
+	require.Eventually(t, func() bool { return s.GetClientCount() == 0 }, 2*time.Second, 10*time.Millisecond)
+	require.NoError(t, first.SendMessage(protocol.NewResponseMessage(first.ID, protocol.StageLLM, "二", 1, true, nil)))
+
+	// 重连后补发遗漏的消息，之后旧连接发出的消息转到新连接
+	conn, status = dialResume(t, url, token, "1")
+	defer conn.Close()
+	assert.True(t, status.Resumed)
+	assert.Equal(t, token, status.ResumeToken)
+
+	msg = readResumeMessage(t, conn)
+	assert.EqualValues(t, 2, msg.Seq)
+	resp, err := msg.ResponseData()
+	require.NoError(t, err)
+	assert.Equal(t, "二", resp.Content)
+
+	currentClient(t, s, first)
+	require.NoError(t, first.SendMessage(protocol.NewResponseMessage(first.ID, protocol.StageTTS, "三", 1, true, nil)))
+	msg = readResumeMessage(t, conn)
+	assert.EqualValues(t, 3, msg.Seq)
+}
+
+func TestResumeRequiresToken(t *testing.T) {
+	s := NewWebSocketServer(WebSocketConfig{
+		MaxConnections:   10,
+		PingPeriod:       time.Minute,
+		PongWait:         time.Minute,
+		WriteWait:        time.Second,
+		ResumeWindow:     time.Minute,
+		ResumeBufferSize: 10,
+	})
+	httpServer := httptest.NewServer(http.HandlerFunc(s.HandleConnection))
+	defer httpServer.Close()
+	url := "ws" + strings.TrimPrefix(httpServer.URL, "http")
+
+	conn, status := dialResume(t, url, "", "")
+	defer conn.Close()
+	victim := currentClient(t, s, nil)
+	require.NoError(t, victim.SendMessage(protocol.NewResponseMessage(victim.ID, protocol.StageASR, "私密", 1, true, nil)))
+	readResumeMessage(t, conn)
+
+	// 只知道会话ID、令牌错误或API Key不同时都拒绝，原连接不受影响，也不补发消息
+	for _, query := range []string{"", "&resume_token=wrong", "&resume_token=" + status.ResumeToken + "&api_key=other"} {
+		attacker, _, err := websocket.DefaultDialer.Dial(url+"?session_id=resume-test&last_seq=0"+query, nil)
+		require.NoError(t, err)
+		attacker.SetReadDeadline(time.Now().Add(2 * time.Second))
+		_, _, err = attacker.ReadMessage()
+		assert.True(t, websocket.IsCloseError(err, protocol.CloseResumeRejected), "%v", err)
+		attacker.Close()
+	}
+	s.mu.RLock()
+	assert.Same(t, victim, s.clients["resume-test"])
+	s.mu.RUnlock()
+
+	// 未指定会话ID时生成不可猜测的ID
+	assert.Regexp(t, "^session_[0-9a-f]{32}$", s.generateSessionID())
+}
+
+func TestResumeStatePrune(t *testing.T) {
+	state := &resumeState{}
+	for i := 0; i < 5; i++ {
+		require.NoError(t, state.send(protocol.NewMessage(protocol.Status, "s", nil), time.Minute, 3))
+	}
+	require.Len(t, state.messages, 3)
+	assert.EqualValues(t, 3, state.messages[0].msg.Seq)
+
+	// 超出保留时长的消息被丢弃
+	state.messages[0].sentAt = time.Now().Add(-2 * time.Minute)
+	state.pruneLocked(time.Minute, 3)
+	require.Len(t, state.messages, 2)
+	assert.EqualValues(t, 4, state.messages[0].msg.Seq)
+}
+
+func TestSessionIDInUseRejected(t *testing.T) {
+	for _, window := range []time.Duration{0, time.Minute} {
+		s := NewWebSocketServer(WebSocketConfig{
+			MaxConnections:   10,
+			PingPeriod:       time.Minute,
+			PongWait:         time.Minute,
+			WriteWait:        time.Second,
+			ResumeWindow:     window,
+			ResumeBufferSize: 10,
+		})
+		p := NewMessageProcessor(ProcessorConfig{MaxConcurrentSessions: 10})
+		s.SetProcessor(p)
+		httpServer := httptest.NewServer(http.HandlerFunc(s.HandleConnection))
+		url := "ws" + strings.TrimPrefix(httpServer.URL, "http")
+
+		rejected := func(sessionID string) bool {
+			conn, _, err := websocket.DefaultDialer.Dial(url+"?session_id="+sessionID, nil)
+			require.NoError(t, err)
+			defer conn.Close()
+			conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+			_, _, err = conn.ReadMessage()
+			return websocket.IsCloseError(err, protocol.CloseSessionInUse)
+		}
+
+		// 其他传输（如gRPC）正在使用的会话ID
+		require.True(t, p.createSession("grpc-held", ""))
+		assert.True(t, rejected("grpc-held"), "resume window %v", window)
+		p.ReleaseSession("grpc-held")
+		assert.False(t, rejected("grpc-held"), "resume window %v", window)
+
+		if window == 0 {
+			// 未启用会话恢复时在线连接的会话ID不能被另一个连接占用，断开后会话随即释放
+			conn, _ := dialResume(t, url, "", "")
+			victim := currentClient(t, s, nil)
+			assert.True(t, rejected("resume-test"))
+			s.mu.RLock()
+			assert.Same(t, victim, s.clients["resume-test"])
+			s.mu.RUnlock()
+			conn.Close()
+			require.Eventually(t, func() bool { return s.GetClientCount() == 0 }, 2*time.Second, 10*time.Millisecond)
+			conn, _ = dialResume(t, url, "", "")
+			conn.Close()
+		}
+		httpServer.Close()
+	}
+}
@@ -0,0 +1,197 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	vaaudio "voice_assistant/pkg/audio"
+	"voice_assistant/voice_assistant_server/internal/pipeline"
+	"voice_assistant/voice_assistant_server/internal/tts"
+)
+
+// SegmentedSpeechConfig 分句合成配置
+// 较长的回复按句子拆开，同时合成若干句，合成好的句子按顺序立即下发，不必等整段合成完毕。
+type SegmentedSpeechConfig struct {
+	Enabled     bool `yaml:"enabled"`      // 是否启用
+	MaxParallel int  `yaml:"max_parallel"` // 同时合成的句子数
+	MinLength   int  `yaml:"min_length"`   // 短于该字符数的句子与下一句合并
+}
+
+// 分句合成默认值
+const (
+	defaultSegmentParallel  = 3
+	defaultSegmentMinLength = 10
+)
+
+// speechSentenceEnds 分句位置（英文句号、问号、感叹号须后跟空白）
+const speechSentenceEnds = "。！？；!?;\n"
+
+// segmentResult 一句的合成结果
+type segmentResult struct {
+	result tts.TTSResult
+	err    error
+}
+
+// splitSpeechSegments 把待合成的文本拆成句子，过短的句子与下一句合并
+func splitSpeechSegments(text string, minLength int) []string {
+	var sentences []string
+	start := 0
+	for i, r := range text {
+		end := i + utf8.RuneLen(r)
+		atEnd := strings.ContainsRune(speechSentenceEnds, r)
+		if !atEnd && strings.ContainsRune(".?!", r) {
+			next, _ := utf8.DecodeRuneInString(text[end:])
+			atEnd = end == len(text) || next == ' ' || next == '\n'
+		}
+		if atEnd {
+			sentences = append(sentences, text[start:end])
+			start = end
+		}
+	}
+	sentences = append(sentences, text[start:])
+
+	var segments []string
+	current := ""
+	for _, sentence := range sentences {
+		current += sentence
+		if utf8.RuneCountInString(strings.TrimSpace(current)) >= minLength {
+			segments = append(segments, strings.TrimSpace(current))
+			current = ""
+		}
+	}
+	if rest := strings.TrimSpace(current); rest != "" {
+		if len(segments) > 0 {
+			segments[len(segments)-1] += " " + rest
+		} else {
+			segments = append(segments, rest)
+		}
+	}
+	return segments
+}
+
+// speakReply 合成并下发回复语音，返回合成结果（分句合成时为各句拼接的结果）和已下发语音的时长
+// 分句合成时某一句失败，已下发的部分照常播放并提示客户端；第一句就失败时返回错误。
+func (p *MessageProcessor) speakReply(ctx context.Context, client *Client, priority pipeline.Priority, text string) (tts.TTSResult, time.Duration, error) {
+	text = p.speakableText(ctx, text)
+
+	minLength := p.config.SegmentedSpeech.MinLength
+	if minLength <= 0 {
+		minLength = defaultSegmentMinLength
+	}
+	var segments []string
+	if p.config.SegmentedSpeech.Enabled {
+		segments = splitSpeechSegments(text, minLength)
+	}
+	if len(segments) <= 1 {
+		result, err := p.synthesizeSpeakable(ctx, priority, text)
+		if err != nil {
+			return result, 0, err
+		}
+		if err := p.sendSpeech(client, result); err != nil {
+			return result, 0, nil
+		}
+		return result, speechDuration(result), nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	results := p.synthesizeSegments(ctx, priority, segments)
+
+	var spoken []tts.TTSResult
+	for i, ch := range results {
+		segment := <-ch
+		if segment.err != nil {
+			if i == 0 {
+				return tts.TTSResult{}, 0, segment.err
+			}
+			if turnCancelled(ctx) {
+				break
+			}
+			log.Printf("第%d/%d句语音合成失败，停止合成之后的句子: %v", i+1, len(segments), segment.err)
+			p.sendStageError(client, pipeline.StageTTS, segment.err, fmt.Sprintf("第%d句之后的语音合成失败", i+1))
+			break
+		}
+		if err := p.sendSpeechSegment(client, segment.result, i, len(segments)); err != nil {
+			break
+		}
+		spoken = append(spoken, segment.result)
+	}
+	if len(spoken) == 0 {
+		return tts.TTSResult{}, 0, nil
+	}
+	joined := joinSpeech(spoken)
+	return joined, speechDuration(joined), nil
+}
+
+// synthesizeSegments 按顺序启动各句的合成（同时最多MaxParallel句），每句的结果从对应的通道按顺序读取
+func (p *MessageProcessor) synthesizeSegments(ctx context.Context, priority pipeline.Priority, segments []string) []chan segmentResult {
+	parallel := p.config.SegmentedSpeech.MaxParallel
+	if parallel <= 0 {
+		parallel = defaultSegmentParallel
+	}
+
+	results := make([]chan segmentResult, len(segments))
+	for i := range results {
+		results[i] = make(chan segmentResult, 1)
+	}
+
+	go func() {
+		slots := make(chan struct{}, parallel)
+		for i, segment := range segments {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				for _, ch := range results[i:] {
+					ch <- segmentResult{err: ctx.Err()}
+				}
+				return
+			}
+			go func(ch chan segmentResult, segment string) {
+				defer func() { <-slots }()
+				result, err := p.synthesizeSpeakable(ctx, priority, segment)
+				ch <- segmentResult{result: result, err: err}
+			}(results[i], segment)
+		}
+	}()
+	return results
+}
+
+// joinSpeech 拼接已下发的各句合成结果（用于归档和计算时长）；WAV音频合并PCM数据后重新加上文件头
+func joinSpeech(results []tts.TTSResult) tts.TTSResult {
+	if len(results) == 0 {
+		return tts.TTSResult{}
+	}
+	joined := results[0]
+	joined.AudioData = nil
+
+	var pcm []byte
+	var duration time.Duration
+	allWAV := true
+	for _, result := range results {
+		duration += speechDuration(result)
+		if !allWAV || !vaaudio.IsWAV(result.AudioData) {
+			allWAV = false
+			continue
+		}
+		wav, err := vaaudio.ParseWAV(result.AudioData)
+		if err != nil {
+			allWAV = false
+			continue
+		}
+		joined.SampleRate, joined.Channels = wav.SampleRate, wav.Channels
+		pcm = append(pcm, wav.Data...)
+	}
+	joined.Duration = duration.Milliseconds()
+	if allWAV {
+		joined.AudioData, joined.Format = vaaudio.EncodeWAV(pcm, joined.SampleRate, joined.Channels), "wav"
+		return joined
+	}
+	for _, result := range results {
+		joined.AudioData = append(joined.AudioData, result.AudioData...)
+	}
+	return joined
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	vaaudio "voice_assistant/pkg/audio"
+	"voice_assistant/pkg/protocol"
+	"voice_assistant/voice_assistant_server/internal/pipeline"
+	"voice_assistant/voice_assistant_server/internal/tts"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// segmentTTS 按文本长度返回PCM的TTS服务，前面的句子合成得更慢，并记录同时合成的句子数
+type segmentTTS struct {
+	tts.TTSService
+	running, peak atomic.Int32
+}
+
+func (s *segmentTTS) SynthesizeText(ctx context.Context, text string) (tts.TTSResult, error) {
+	running := s.running.Add(1)
+	defer s.running.Add(-1)
+	for peak := s.peak.Load(); running > peak && !s.peak.CompareAndSwap(peak, running); peak = s.peak.Load() {
+	}
+
+	time.Sleep(time.Duration(100/len(text)) * time.Millisecond)
+	return tts.TTSResult{AudioData: make([]byte, len(text)*2), Format: "pcm", SampleRate: 16000, Channels: 1}, nil
+}
+
+func TestSplitSpeechSegments(t *testing.T) {
+	assert.Equal(t, []string{"今天天气不错，适合出门。", "明天有雨，记得带伞！", "好的。再见。"},
+		splitSpeechSegments("今天天气不错，适合出门。明天有雨，记得带伞！好的。再见。", 6))
+	assert.Equal(t, []string{"It is sunny today.", "Version 1.2 is out. Bye."},
+		splitSpeechSegments("It is sunny today. Version 1.2 is out. Bye.", 10))
+	assert.Equal(t, []string{"短句"}, splitSpeechSegments("短句", 10))
+}
+
+func TestSpeakReplySegments(t *testing.T) {
+	p := NewMessageProcessor(ProcessorConfig{
+		MaxConcurrentSessions: 10,
+		SegmentedSpeech:       SegmentedSpeechConfig{Enabled: true, MaxParallel: 2, MinLength: 2},
+	})
+	service := &segmentTTS{}
+	p.ttsService = service
+	client := &Client{ID: "segments", SendChan: make(chan *protocol.Message, 10)}
+	p.getOrCreateSession(client.ID, "")
+
+	result, speech, err := p.speakReply(context.Background(), client, pipeline.PriorityInteractive, "第一句话。第二句。第三句话很长很长。")
+	require.NoError(t, err)
+	assert.LessOrEqual(t, service.peak.Load(), int32(2))
+	assert.Equal(t, speechDuration(result), speech)
+
+	// 按句子顺序下发，只有最后一句标记为最终
+	require.Len(t, client.SendChan, 3)
+	total := 0
+	for i := 0; i < 3; i++ {
+		data := (<-client.SendChan).Payload.(*protocol.ResponseData)
+		assert.Equal(t, i, data.Segment)
+		assert.Equal(t, 3, data.TotalSegments)
+		assert.Equal(t, i == 2, data.IsFinal)
+		total += len(data.AudioData)
+	}
+	assert.Len(t, result.AudioData, total)
+}
+
+func TestJoinSpeech(t *testing.T) {
+	joined := joinSpeech([]tts.TTSResult{
+		{AudioData: vaaudio.EncodeWAV(make([]byte, 320), 16000, 1), Format: "wav"},
+		{AudioData: vaaudio.EncodeWAV(make([]byte, 640), 16000, 1), Format: "wav"},
+	})
+	wav, err := vaaudio.ParseWAV(joined.AudioData)
+	require.NoError(t, err)
+	assert.Len(t, wav.Data, 960)
+	assert.Equal(t, "wav", joined.Format)
+}
@@ -0,0 +1,209 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	vaaudio "voice_assistant/pkg/audio"
+	"voice_assistant/voice_assistant_server/internal/asr"
+	"voice_assistant/voice_assistant_server/internal/llm"
+	"voice_assistant/voice_assistant_server/internal/pipeline"
+	"voice_assistant/voice_assistant_server/internal/tts"
+)
+
+// 自检使用的测试内容
+const (
+	selfTestSentence = "你好，这是语音助手的自检。"
+	selfTestPrompt   = "这是连通性测试，请只回复“好的”。"
+)
+
+// SelfTestConfig 提供商自检配置
+type SelfTestConfig struct {
+	ASRConfig asr.ASRConfig
+	LLMConfig llm.LLMConfig
+	TTSConfig tts.TTSConfig
+
+	Audio   []byte        // ASR自检的音频（WAV或16kHz 16bit单声道PCM），为空时使用TTS自检合成的测试句
+	Timeout time.Duration // 每个提供商的超时
+}
+
+// CheckResult 单个提供商的自检结果
+type CheckResult struct {
+	Stage       pipeline.Stage `json:"stage"`
+	Provider    string         `json:"provider"`
+	OK          bool           `json:"ok"`
+	InitLatency time.Duration  `json:"init_latency"` // 创建和初始化耗时
+	Latency     time.Duration  `json:"latency"`      // 测试调用耗时
+	Detail      string         `json:"detail,omitempty"`
+	Error       string         `json:"error,omitempty"`
+}
+
+// SelfTest 依次创建各阶段配置的提供商并实际调用一次：TTS合成测试句，ASR识别测试音频，LLM回答一句简单的提示
+// stages为空时检查全部阶段；TTS先于ASR检查，以便ASR使用合成的测试句。
+func SelfTest(ctx context.Context, config SelfTestConfig, stages []pipeline.Stage) []CheckResult {
+	if len(stages) == 0 {
+		stages = []pipeline.Stage{pipeline.StageTTS, pipeline.StageASR, pipeline.StageLLM}
+	}
+	selected := make(map[pipeline.Stage]bool, len(stages))
+	for _, stage := range stages {
+		selected[stage] = true
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = time.Minute
+	}
+
+	var results []CheckResult
+	audio, source := config.Audio, "指定的音频"
+	if selected[pipeline.StageTTS] {
+		result, speech := checkTTS(ctx, config)
+		results = append(results, result)
+		if audio == nil && speech != nil {
+			audio, source = speech, "合成的测试句"
+		}
+	}
+	if selected[pipeline.StageASR] {
+		if audio == nil {
+			// 没有可用的语音时用1秒静音，只验证调用链路
+			audio, source = make([]byte, 1000*pcmBytesPerMillisecond), "1秒静音"
+		}
+		results = append(results, checkASR(ctx, config, audio, source))
+	}
+	if selected[pipeline.StageLLM] {
+		results = append(results, checkLLM(ctx, config))
+	}
+	return results
+}
+
+// timedCheck 记录初始化和调用耗时，调用失败时记录错误
+func timedCheck(result *CheckResult, initialize func() error, call func() error) {
+	start := time.Now()
+	err := initialize()
+	result.InitLatency = time.Since(start)
+	if err != nil {
+		result.Error = err.Error()
+		return
+	}
+
+	start = time.Now()
+	err = call()
+	result.Latency = time.Since(start)
+	if err != nil {
+		result.Error = err.Error()
+		return
+	}
+	result.OK = true
+}
+
+// checkTTS 合成测试句，返回可供ASR自检使用的16kHz单声道PCM（不是WAV时为nil）
+func checkTTS(ctx context.Context, config SelfTestConfig) (CheckResult, []byte) {
+	result := CheckResult{Stage: pipeline.StageTTS, Provider: config.TTSConfig.Type}
+	var service tts.TTSService
+	var speech []byte
+	timedCheck(&result, func() error {
+		var err error
+		if service, err = tts.CreateTTS(config.TTSConfig); err != nil {
+			return err
+		}
+		return service.Initialize(config.TTSConfig)
+	}, func() error {
+		ctx, cancel := context.WithTimeout(ctx, config.Timeout)
+		defer cancel()
+		synthesized, err := service.SynthesizeText(ctx, selfTestSentence)
+		if err != nil {
+			return err
+		}
+		if len(synthesized.AudioData) == 0 {
+			return errors.New("合成结果为空")
+		}
+		result.Detail = fmt.Sprintf("%s，%d字节，时长%v", synthesized.Format, len(synthesized.AudioData), speechDuration(synthesized).Round(time.Millisecond))
+		if vaaudio.IsWAV(synthesized.AudioData) {
+			speech, _ = recognitionPCM(synthesized.AudioData)
+		}
+		return nil
+	})
+	if service != nil {
+		service.Close()
+	}
+	return result, speech
+}
+
+// checkASR 识别测试音频
+func checkASR(ctx context.Context, config SelfTestConfig, audio []byte, source string) CheckResult {
+	result := CheckResult{Stage: pipeline.StageASR, Provider: config.ASRConfig.Type}
+	var service asr.ASRService
+	timedCheck(&result, func() error {
+		var err error
+		if service, err = asr.CreateASR(config.ASRConfig); err != nil {
+			return err
+		}
+		return service.Initialize(config.ASRConfig)
+	}, func() error {
+		pcm, err := recognitionPCM(audio)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(ctx, config.Timeout)
+		defer cancel()
+		recognized, err := service.ProcessAudio(ctx, pcm)
+		if err != nil {
+			return err
+		}
+		result.Detail = fmt.Sprintf("%s: %q", source, recognized.Text)
+		return nil
+	})
+	if service != nil {
+		service.Close()
+	}
+	return result
+}
+
+// checkLLM 发送一句简单的提示
+func checkLLM(ctx context.Context, config SelfTestConfig) CheckResult {
+	result := CheckResult{Stage: pipeline.StageLLM, Provider: config.LLMConfig.Type}
+	var service llm.LLMService
+	timedCheck(&result, func() error {
+		var err error
+		if service, err = llm.CreateLLM(config.LLMConfig); err != nil {
+			return err
+		}
+		return service.Initialize(config.LLMConfig)
+	}, func() error {
+		ctx, cancel := context.WithTimeout(ctx, config.Timeout)
+		defer cancel()
+		response, err := service.GenerateResponse(ctx, []llm.Message{{Role: "user", Content: selfTestPrompt}})
+		if err != nil {
+			return err
+		}
+		if response.Content == "" {
+			return errors.New("回复为空")
+		}
+		reply := response.Content
+		if utf8.RuneCountInString(reply) > 40 {
+			reply = string([]rune(reply)[:40]) + "…"
+		}
+		result.Detail = fmt.Sprintf("%q，%d tokens", reply, response.TokenUsage.TotalTokens)
+		return nil
+	})
+	if service != nil {
+		service.Close()
+	}
+	return result
+}
+
+// recognitionPCM 把WAV转换为ASR使用的16kHz 16bit单声道PCM（混为单声道并重采样），非WAV数据视为已是该格式
+func recognitionPCM(data []byte) ([]byte, error) {
+	if !vaaudio.IsWAV(data) {
+		return data, nil
+	}
+	audio, err := vaaudio.ParseWAV(data)
+	if err != nil {
+		return nil, err
+	}
+	if !audio.PCM16() {
+		return nil, fmt.Errorf("仅支持16bit音频，当前: %dbit", audio.BitsPerSample)
+	}
+	return vaaudio.Int16ToBytes(vaaudio.ResampleInt16(vaaudio.BytesToInt16(audio.Data, audio.Channels), audio.SampleRate, 16000)), nil
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	vaaudio "voice_assistant/pkg/audio"
+	"voice_assistant/voice_assistant_server/internal/asr"
+	"voice_assistant/voice_assistant_server/internal/llm"
+	"voice_assistant/voice_assistant_server/internal/pipeline"
+	"voice_assistant/voice_assistant_server/internal/tts"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// selfTestTTS 合成1秒8kHz双声道WAV
+type selfTestTTS struct{ tts.TTSService }
+
+func (s *selfTestTTS) Initialize(config tts.TTSConfig) error { return nil }
+func (s *selfTestTTS) Close() error                          { return nil }
+func (s *selfTestTTS) SynthesizeText(ctx context.Context, text string) (tts.TTSResult, error) {
+	return tts.TTSResult{AudioData: vaaudio.EncodeWAV(make([]byte, 8000*2*2), 8000, 2), Format: "wav", Duration: 1000}, nil
+}
+
+// selfTestASR 记录收到的音频长度
+type selfTestASR struct {
+	asr.ASRService
+	received *int
+}
+
+func (s *selfTestASR) Initialize(config asr.ASRConfig) error { return nil }
+func (s *selfTestASR) Close() error                          { return nil }
+func (s *selfTestASR) ProcessAudio(ctx context.Context, audio []byte) (asr.ASRResult, error) {
+	*s.received = len(audio)
+	return asr.ASRResult{Text: "你好"}, nil
+}
+
+// selfTestLLM 初始化失败
+type selfTestLLM struct{ llm.LLMService }
+
+func (s *selfTestLLM) Initialize(config llm.LLMConfig) error { return errors.New("服务不可达") }
+func (s *selfTestLLM) Close() error                          { return nil }
+
+func TestSelfTest(t *testing.T) {
+	received := 0
+	tts.RegisterTTS("selftest", func(config tts.TTSConfig) (tts.TTSService, error) { return &selfTestTTS{}, nil })
+	asr.RegisterASR("selftest", func(config asr.ASRConfig) (asr.ASRService, error) {
+		return &selfTestASR{received: &received}, nil
+	})
+	llm.RegisterLLM("selftest", func(config llm.LLMConfig) (llm.LLMService, error) { return &selfTestLLM{}, nil })
+
+	config := SelfTestConfig{
+		ASRConfig: asr.ASRConfig{Type: "selftest"},
+		LLMConfig: llm.LLMConfig{Type: "selftest"},
+		TTSConfig: tts.TTSConfig{Type: "selftest"},
+	}
+	results := SelfTest(context.Background(), config, nil)
+	require.Len(t, results, 3)
+
+	// ASR识别TTS合成的测试句（转为16kHz单声道）
+	assert.Equal(t, pipeline.StageTTS, results[0].Stage)
+	assert.True(t, results[0].OK)
+	assert.True(t, results[1].OK)
+	assert.Contains(t, results[1].Detail, "合成的测试句")
+	assert.Equal(t, 16000*2, received)
+
+	assert.Equal(t, pipeline.StageLLM, results[2].Stage)
+	assert.False(t, results[2].OK)
+	assert.Equal(t, "服务不可达", results[2].Error)
+
+	// 只检查ASR时使用静音
+	results = SelfTest(context.Background(), config, []pipeline.Stage{pipeline.StageASR})
+	require.Len(t, results, 1)
+	assert.Contains(t, results[0].Detail, "静音")
+
+	// 未编译的提供商
+	config.TTSConfig.Type = "missing"
+	results = SelfTest(context.Background(), config, []pipeline.Stage{pipeline.StageTTS})
+	assert.False(t, results[0].OK)
+	assert.Contains(t, results[0].Error, "missing")
+}
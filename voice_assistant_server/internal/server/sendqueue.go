@@ -0,0 +1,200 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	vaaudio "voice_assistant/pkg/audio"
+	"voice_assistant/pkg/protocol"
+	"voice_assistant/voice_assistant_server/internal/tts"
+)
+
+// 发送队列溢出策略
+const (
+	OverflowBlock      = "block"       // 等待队列腾出空间，超过BlockTimeout仍无空间时丢弃新消息
+	OverflowDropOldest = "drop_oldest" // 丢弃队列中最早的消息，为新消息腾出空间
+	OverflowDisconnect = "disconnect"  // 断开消费过慢的连接（启用会话恢复时客户端可重连补发）
+)
+
+// 发送队列默认参数
+const (
+	defaultSendQueueSize    = 100
+	defaultSendBlockTimeout = 2 * time.Second
+)
+
+// SendQueueStats 发送队列溢出统计（用于健康检查）
+type SendQueueStats struct {
+	Policy        string `json:"policy"`
+	Blocked       int64  `json:"blocked"`        // 等待后成功入队的消息数
+	Dropped       int64  `json:"dropped"`        // 丢弃的新消息数（等待超时或队列已满）
+	DroppedOldest int64  `json:"dropped_oldest"` // 为新消息腾出空间而丢弃的旧消息数
+	Disconnected  int64  `json:"disconnected"`   // 因消费过慢被断开的连接数
+}
+
+// sendQueueCounters 发送队列溢出计数
+type sendQueueCounters struct {
+	blocked       atomic.Int64
+	dropped       atomic.Int64
+	droppedOldest atomic.Int64
+	disconnected  atomic.Int64
+}
+
+// overflowPolicy 当前生效的溢出策略
+func (s *WebSocketServer) overflowPolicy() string {
+	switch s.config.OverflowPolicy {
+	case OverflowDropOldest, OverflowDisconnect:
+		return s.config.OverflowPolicy
+	default:
+		return OverflowBlock
+	}
+}
+
+// SendQueueStats 获取累计的发送队列溢出统计
+func (s *WebSocketServer) SendQueueStats() SendQueueStats {
+	return SendQueueStats{
+		Policy:        s.overflowPolicy(),
+		Blocked:       s.sendStats.blocked.Load(),
+		Dropped:       s.sendStats.dropped.Load(),
+		DroppedOldest: s.sendStats.droppedOldest.Load(),
+		Disconnected:  s.sendStats.disconnected.Load(),
+	}
+}
+
+// overflow 发送队列已满时按溢出策略处理消息
+// 调用方可能持有会话恢复的锁，断开连接需异步进行。
+func (s *WebSocketServer) overflow(conn *Client, msg *protocol.Message) error {
+	switch s.overflowPolicy() {
+	case OverflowDropOldest:
+		for {
+			select {
+			case <-conn.SendChan:
+				conn.dropped.Add(1)
+				s.sendStats.droppedOldest.Add(1)
+			default:
+			}
+			select {
+			case conn.SendChan <- msg:
+				return nil
+			default:
+				// 写协程之外的生产者抢先填满了队列，继续腾出空间
+			}
+		}
+
+	case OverflowDisconnect:
+		conn.dropped.Add(1)
+		s.sendStats.dropped.Add(1)
+		s.sendStats.disconnected.Add(1)
+		log.Printf("客户端消费过慢，发送队列已满，断开连接: %s", conn.ID)
+		go conn.closeWithCode(protocol.CloseSlowConsumer, "发送队列已满")
+		return fmt.Errorf("客户端发送队列已满，已断开连接")
+
+	default:
+		timeout := s.config.BlockTimeout
+		if timeout <= 0 {
+			timeout = defaultSendBlockTimeout
+		}
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+
+		select {
+		case conn.SendChan <- msg:
+			s.sendStats.blocked.Add(1)
+			return nil
+		case <-conn.done:
+			return fmt.Errorf("客户端连接已关闭")
+		case <-timer.C:
+			conn.dropped.Add(1)
+			s.sendStats.dropped.Add(1)
+			return fmt.Errorf("客户端发送队列已满，等待 %v 后仍无空间", timeout)
+		}
+	}
+}
+
+// 音频分片按4字节（16bit立体声一帧）对齐，保证每片都能独立播放
+const audioChunkAlign = 4
+
+// splitAudio 将语音按chunkSize字节拆分，每片都是可独立播放的音频
+// WAV音频拆分PCM数据后为每片重新加上文件头；MP3等无法按字节安全拆分的音频整体返回。
+func splitAudio(audio []byte, chunkSize int) [][]byte {
+	chunkSize -= chunkSize % audioChunkAlign
+	if chunkSize <= 0 || len(audio) <= chunkSize {
+		return [][]byte{audio}
+	}
+
+	if vaaudio.IsWAV(audio) {
+		wav, err := vaaudio.ParseWAV(audio)
+		if err != nil || !wav.PCM16() {
+			return [][]byte{audio}
+		}
+		pcmChunks := splitPCM(wav.Data, chunkSize)
+		chunks := make([][]byte, len(pcmChunks))
+		for i, pcm := range pcmChunks {
+			chunks[i] = vaaudio.EncodeWAV(pcm, wav.SampleRate, wav.Channels)
+		}
+		return chunks
+	}
+
+	if vaaudio.IsMP3(audio) {
+		return [][]byte{audio}
+	}
+	return splitPCM(audio, chunkSize)
+}
+
+// splitPCM 按字节数拆分PCM数据（chunkSize需已按帧对齐）
+func splitPCM(pcm []byte, chunkSize int) [][]byte {
+	chunks := make([][]byte, 0, (len(pcm)+chunkSize-1)/chunkSize)
+	for start := 0; start < len(pcm); start += chunkSize {
+		end := start + chunkSize
+		if end > len(pcm) {
+			end = len(pcm)
+		}
+		chunks = append(chunks, pcm[start:end])
+	}
+	return chunks
+}
+
+// sendSpeech 发送TTS语音，超过AudioChunkSize时拆成多条消息，避免单条大消息长时间占用写协程
+// 除最后一片外IsFinal为false，每片携带分片序号ChunkIndex和分片总数TotalChunks，以及音频格式AudioFormat。
+func (p *MessageProcessor) sendSpeech(client *Client, result tts.TTSResult) error {
+	return p.sendSpeechSegment(client, result, 0, 0)
+}
+
+// sendSpeechSegment 发送分句合成的第segment句语音（totalSegments为0表示未分句）
+// 每句按sendSpeech的方式分片，分片序号在句内编号；只有最后一句的最后一片IsFinal为true。
+func (p *MessageProcessor) sendSpeechSegment(client *Client, result tts.TTSResult, segment, totalSegments int) error {
+	format := speechFormat(result)
+	chunks := splitAudio(result.AudioData, p.config.AudioChunkSize)
+	if len(chunks) == 1 && totalSegments == 0 {
+		return p.sendResponseData(client, &protocol.ResponseData{
+			Stage:       protocol.StageTTS,
+			Confidence:  1.0,
+			IsFinal:     true,
+			AudioData:   result.AudioData,
+			AudioFormat: format,
+		})
+	}
+
+	lastSegment := segment >= totalSegments-1
+	for i, chunk := range chunks {
+		data := &protocol.ResponseData{
+			Stage:      protocol.StageTTS,
+			Confidence: 1.0,
+			IsFinal:    lastSegment && i == len(chunks)-1,
+			AudioData:  chunk,
+
+			AudioFormat:   format,
+			Segment:       segment,
+			TotalSegments: totalSegments,
+		}
+		if len(chunks) > 1 {
+			data.ChunkIndex, data.TotalChunks = i, len(chunks)
+		}
+		err := p.sendResponseData(client, data)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	vaaudio "voice_assistant/pkg/audio"
+	"voice_assistant/pkg/protocol"
+	"voice_assistant/voice_assistant_server/internal/tts"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newQueueTestClient(config WebSocketConfig) *Client {
+	return &Client{
+		ID:       "queue-test",
+		SendChan: make(chan *protocol.Message, 2),
+		Server:   &WebSocketServer{config: config},
+		done:     make(chan struct{}),
+	}
+}
+
+func TestSendQueueDropOldest(t *testing.T) {
+	client := newQueueTestClient(WebSocketConfig{OverflowPolicy: OverflowDropOldest})
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, client.enqueue(protocol.NewMessage(protocol.Status, client.ID, i)))
+	}
+
+	assert.Equal(t, 1, (<-client.SendChan).Payload)
+	assert.Equal(t, 2, (<-client.SendChan).Payload)
+	assert.Equal(t, int64(1), client.dropped.Load())
+	assert.Equal(t, int64(1), client.Server.SendQueueStats().DroppedOldest)
+}
+
+func TestSendQueueBlockTimeout(t *testing.T) {
+	client := newQueueTestClient(WebSocketConfig{BlockTimeout: 20 * time.Millisecond})
+	for i := 0; i < 2; i++ {
+		require.NoError(t, client.enqueue(protocol.NewMessage(protocol.Status, client.ID, i)))
+	}
+
+	// 队列一直没有空间：等待超时后丢弃新消息
+	assert.Error(t, client.enqueue(protocol.NewMessage(protocol.Status, client.ID, 2)))
+	assert.Equal(t, int64(1), client.dropped.Load())
+
+	// 写协程在等待期间腾出空间：消息入队
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		<-client.SendChan
+	}()
+	assert.NoError(t, client.enqueue(protocol.NewMessage(protocol.Status, client.ID, 3)))
+
+	stats := client.Server.SendQueueStats()
+	assert.Equal(t, OverflowBlock, stats.Policy)
+	assert.Equal(t, int64(1), stats.Blocked)
+	assert.Equal(t, int64(1), stats.Dropped)
+}
+
+func TestSplitAudio(t *testing.T) {
+	pcm := make([]byte, 1002)
+
+	chunks := splitAudio(pcm, 402)
+	require.Len(t, chunks, 3)
+	assert.Len(t, chunks[0], 400)
+	assert.Len(t, chunks[2], 202)
+
+	// WAV每片都带文件头
+	wavChunks := splitAudio(vaaudio.EncodeWAV(pcm, 24000, 1), 400)
+	require.Len(t, wavChunks, 3)
+	for _, chunk := range wavChunks {
+		wav, err := vaaudio.ParseWAV(chunk)
+		require.NoError(t, err)
+		assert.Equal(t, 24000, wav.SampleRate)
+	}
+
+	// MP3和未启用分片时整体发送
+	mp3 := append([]byte("ID3"), pcm...)
+	assert.Len(t, splitAudio(mp3, 400), 1)
+	assert.Len(t, splitAudio(pcm, 0), 1)
+}
+
+func TestSendSpeechChunks(t *testing.T) {
+	p := NewMessageProcessor(ProcessorConfig{MaxConcurrentSessions: 10, AudioChunkSize: 400})
+	client := &Client{ID: "speech", SendChan: make(chan *protocol.Message, 10)}
+	p.getOrCreateSession(client.ID, "")
+
+	require.NoError(t, p.sendSpeech(client, tts.TTSResult{AudioData: make([]byte, 1000)}))
+	require.Len(t, client.SendChan, 3)
+	for i := 0; i < 3; i++ {
+		data := (<-client.SendChan).Payload.(*protocol.ResponseData)
+		assert.Equal(t, i, data.ChunkIndex)
+		assert.Equal(t, 3, data.TotalChunks)
+		assert.Equal(t, i == 2, data.IsFinal)
+	}
+
+	// 未分片的语音不带分片字段
+	require.NoError(t, p.sendSpeech(client, tts.TTSResult{AudioData: make([]byte, 200)}))
+	data := (<-client.SendChan).Payload.(*protocol.ResponseData)
+	assert.Zero(t, data.TotalChunks)
+	assert.True(t, data.IsFinal)
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"voice_assistant/pkg/protocol"
+
+	"github.com/gorilla/websocket"
+)
+
+// defaultCloseTimeout 等待客户端确认关闭通知和回应关闭帧的默认时长
+const defaultCloseTimeout = 2 * time.Second
+
+// maxCloseReasonBytes WebSocket关闭帧中原因的最大字节数
+const maxCloseReasonBytes = 123
+
+// closeTimeout 关闭握手每一步的等待时长
+func (s *WebSocketServer) closeTimeout() time.Duration {
+	if s.config.CloseTimeout > 0 {
+		return s.config.CloseTimeout
+	}
+	return defaultCloseTimeout
+}
+
+// shutdown 服务端主动关闭连接：发送closing状态，等待客户端回复close_ack（最长CloseTimeout）后以info.Code发送关闭帧，
+// 客户端回应关闭帧（读协程随之退出）或超时后断开。客户端据此区分主动关闭与异常断线，决定是否重连。
+func (c *Client) shutdown(info protocol.CloseInfo) {
+	if !c.closing.CompareAndSwap(false, true) {
+		return
+	}
+	timeout := c.Server.closeTimeout()
+
+	// 关闭通知只对当前连接有意义，不参与重连补发
+	notice := protocol.NewMessage(protocol.Status, c.ID, &protocol.StatusData{State: protocol.StateClosing, Close: &info})
+	if err := c.enqueue(notice); err == nil {
+		select {
+		case <-c.closeAck:
+		case <-c.done:
+			return
+		case <-time.After(timeout):
+			log.Printf("客户端未确认关闭通知: %s", c.ID)
+		}
+	}
+
+	c.writeClose(info.Code, info.Reason)
+	select {
+	case <-c.done:
+	case <-time.After(timeout):
+		c.close()
+	}
+}
+
+// writeClose 发送WebSocket关闭帧（可与写协程并发调用）
+func (c *Client) writeClose(code int, reason string) {
+	if len(reason) > maxCloseReasonBytes {
+		reason = truncateUTF8(reason, maxCloseReasonBytes)
+	}
+	err := c.Conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(c.Server.config.WriteWait))
+	if err != nil && err != websocket.ErrCloseSent {
+		log.Printf("发送关闭帧失败: %s, %v", c.ID, err)
+	}
+}
+
+// closeWithCode 不经确认直接以关闭码断开连接（发送队列已满等无法发出关闭通知的情况）
+func (c *Client) closeWithCode(code int, reason string) {
+	if !c.closing.CompareAndSwap(false, true) {
+		return
+	}
+	c.writeClose(code, reason)
+	c.close()
+}
+
+// acknowledgeClose 收到客户端的close_ack
+func (c *Client) acknowledgeClose() {
+	c.ackOnce.Do(func() {
+		close(c.closeAck)
+	})
+}
+
+// isCloseAck 是否为客户端对关闭通知的确认
+func isCloseAck(msg *protocol.Message) bool {
+	if msg.Type != protocol.Status {
+		return false
+	}
+	status, err := msg.StatusData()
+	return err == nil && status.State == protocol.StateCloseAck
+}
+
+// truncateUTF8 按字节数截断，不拆开多字节字符
+func truncateUTF8(s string, n int) string {
+	for n > 0 && n < len(s) && s[n]&0xC0 == 0x80 {
+		n--
+	}
+	return s[:n]
+}
+
+// Shutdown 服务端关闭前通知所有连接（关闭码1001，客户端可稍后重连）并等待关闭握手完成，ctx结束时直接断开剩余连接
+func (s *WebSocketServer) Shutdown(ctx context.Context) {
+	s.mu.RLock()
+	clients := make([]*Client, 0, len(s.clients))
+	for _, client := range s.clients {
+		clients = append(clients, client)
+	}
+	s.mu.RUnlock()
+
+	info := protocol.CloseInfo{Code: protocol.CloseGoingAway, Reason: "服务器正在关闭", Reconnect: true}
+	var wg sync.WaitGroup
+	for _, client := range clients {
+		wg.Add(1)
+		go func(client *Client) {
+			defer wg.Done()
+			client.shutdown(info)
+		}(client)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+	s.Close()
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"voice_assistant/pkg/protocol"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShutdownCloseHandshake(t *testing.T) {
+	s := NewWebSocketServer(WebSocketConfig{
+		MaxConnections: 10,
+		PingPeriod:     time.Minute,
+		PongWait:       time.Minute,
+		WriteWait:      time.Second,
+		CloseTimeout:   5 * time.Second,
+	})
+	httpServer := httptest.NewServer(http.HandlerFunc(s.HandleConnection))
+	defer httpServer.Close()
+	url := "ws" + strings.TrimPrefix(httpServer.URL, "http")
+
+	conn, _, err := websocket.DefaultDialer.Dial(url+"?session_id=shutdown-test", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	require.Eventually(t, func() bool { return s.GetClientCount() == 1 }, 2*time.Second, 10*time.Millisecond)
+
+	shutdownDone := make(chan struct{})
+	start := time.Now()
+	go func() {
+		s.Shutdown(context.Background())
+		close(shutdownDone)
+	}()
+
+	// 先收到closing状态，带关闭码和可重连标记
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var info *protocol.CloseInfo
+	for info == nil {
+		_, data, err := conn.ReadMessage()
+		require.NoError(t, err)
+		msg, err := protocol.FromJSON(data)
+		require.NoError(t, err)
+		if msg.Type != protocol.Status {
+			continue
+		}
+		status, err := msg.StatusData()
+		require.NoError(t, err)
+		if status.State == protocol.StateClosing {
+			info = status.Close
+			require.NotNil(t, info)
+		}
+	}
+	assert.Equal(t, protocol.CloseGoingAway, info.Code)
+	assert.True(t, info.Reconnect)
+
+	// 确认后服务端以对应关闭码断开，无需等到超时
+	ack := protocol.NewMessage(protocol.Status, "shutdown-test", &protocol.StatusData{State: protocol.StateCloseAck})
+	require.NoError(t, conn.WriteJSON(ack))
+	_, _, err = conn.ReadMessage()
+	var closeErr *websocket.CloseError
+	require.ErrorAs(t, err, &closeErr)
+	assert.Equal(t, protocol.CloseGoingAway, closeErr.Code)
+
+	select {
+	case <-shutdownDone:
+	case <-time.After(3 * time.Second):
+		t.Fatal("Shutdown未返回")
+	}
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.Zero(t, s.GetClientCount())
+}
+
+func TestCloseCodeAllowsReconnect(t *testing.T) {
+	assert.True(t, protocol.CloseCodeAllowsReconnect(protocol.CloseGoingAway))
+	assert.True(t, protocol.CloseCodeAllowsReconnect(protocol.CloseSlowConsumer))
+	assert.False(t, protocol.CloseCodeAllowsReconnect(protocol.CloseSessionTerminated))
+	assert.False(t, protocol.CloseCodeAllowsReconnect(protocol.CloseResumeRejected))
+}
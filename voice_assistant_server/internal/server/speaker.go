@@ -0,0 +1,169 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"voice_assistant/voice_assistant_server/internal/asr"
+	"voice_assistant/voice_assistant_server/internal/speaker"
+
+	"github.com/gin-gonic/gin"
+)
+
+// speakerTimeout 每句说话人识别的超时（超时后按未识别处理，不影响对话）
+const speakerTimeout = 5 * time.Second
+
+// startSpeakerIdentification 与ASR并行识别说话人，返回等待识别结果的函数（未启用或非整句时结果为nil）
+func (p *MessageProcessor) startSpeakerIdentification(ctx context.Context, audio []byte, isFinal bool) func() *asr.Speaker {
+	if p.speakers == nil || !isFinal {
+		return func() *asr.Speaker { return nil }
+	}
+
+	identifier := p.speakers
+	done := make(chan *asr.Speaker, 1)
+	go func() {
+		identifyCtx, cancel := context.WithTimeout(ctx, speakerTimeout)
+		defer cancel()
+		done <- identifySpeaker(identifyCtx, identifier, audio)
+	}()
+	return func() *asr.Speaker { return <-done }
+}
+
+// identifySpeaker 识别说话人（失败时记录日志并按未识别处理）
+func identifySpeaker(ctx context.Context, identifier *speaker.Identifier, audio []byte) *asr.Speaker {
+	match, err := identifier.Identify(ctx, audio)
+	if err != nil {
+		log.Printf("说话人识别失败: %v", err)
+		return nil
+	}
+	if match == nil {
+		return nil
+	}
+	return &asr.Speaker{ID: match.ID, Name: match.Name, Score: match.Score}
+}
+
+// setSessionSpeaker 记录会话最近一句的说话人（开启 memory.speaker_fallback 时长期记忆按说话人区分）
+func (p *MessageProcessor) setSessionSpeaker(session *Session, current *asr.Speaker) {
+	if p.speakers == nil {
+		return
+	}
+
+	session.mu.Lock()
+	session.Speaker = current
+	session.mu.Unlock()
+}
+
+// speakerMetadata 响应中附带的说话人信息
+func speakerMetadata(current *asr.Speaker) map[string]interface{} {
+	if current == nil {
+		return nil
+	}
+	return map[string]interface{}{
+		"speaker": current,
+	}
+}
+
+// withSpeaker 告知LLM当前说话人，便于称呼对方并个性化回复
+func withSpeaker(ctx context.Context, current *asr.Speaker) context.Context {
+	if current == nil {
+		return ctx
+	}
+	return withBackground(ctx, fmt.Sprintf("当前说话人（声纹识别）：%s。可以用名字称呼对方，并根据对方的偏好回答。", current.Name))
+}
+
+// handleSpeakerList 已登记的说话人
+func (h *RESTHandler) handleSpeakerList(c *gin.Context) {
+	if !h.speakerReady(c) {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"speakers": h.processor.speakers.Profiles()})
+}
+
+// handleSpeakerEnroll 登记说话人（管理接口，multipart字段 id、name、audio，audio为16kHz 16bit单声道的WAV或裸PCM）；
+// ID已存在时拒绝，携带 append=true 时向已有的ID追加样本，提高识别稳定性
+func (h *RESTHandler) handleSpeakerEnroll(c *gin.Context) {
+	if !h.speakerReady(c) {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxRESTAudioSize)
+	fileHeader, err := c.FormFile("audio")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少音频文件: " + err.Error()})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pcm, err := extractPCM(data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), restTimeout)
+	defer cancel()
+	profile, err := h.processor.speakers.Enroll(ctx, c.PostForm("id"), c.PostForm("name"), pcm, c.PostForm("append") == "true")
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, speaker.ErrInvalidProfile) || errors.Is(err, speaker.ErrAudioTooShort):
+			status = http.StatusBadRequest
+		case errors.Is(err, speaker.ErrProfileExists):
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": "登记说话人失败: " + err.Error()})
+		return
+	}
+
+	log.Printf("已登记说话人: %s (%s), 样本 %d 段", profile.ID, profile.Name, profile.Samples)
+	c.JSON(http.StatusOK, profile)
+}
+
+// handleSpeakerDelete 删除说话人（管理接口）
+func (h *RESTHandler) handleSpeakerDelete(c *gin.Context) {
+	if !h.speakerReady(c) {
+		return
+	}
+
+	id := c.Param("id")
+	if err := h.processor.speakers.Delete(id); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, speaker.ErrProfileNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	log.Printf("已删除说话人: %s", id)
+	c.JSON(http.StatusOK, gin.H{"id": id})
+}
+
+// speakerReady 检查说话人识别是否启用
+func (h *RESTHandler) speakerReady(c *gin.Context) bool {
+	if !h.ready(c) {
+		return false
+	}
+	if h.processor.speakers == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "未启用说话人识别"})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"voice_assistant/voice_assistant_server/internal/asr"
+	"voice_assistant/voice_assistant_server/internal/llm"
+	"voice_assistant/voice_assistant_server/internal/memory"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithSpeaker(t *testing.T) {
+	dad := &asr.Speaker{ID: "dad", Name: "爸爸", Score: 0.9}
+
+	assert.Equal(t, context.Background(), withSpeaker(context.Background(), nil))
+	assert.Nil(t, speakerMetadata(nil))
+	assert.Equal(t, dad, speakerMetadata(dad)["speaker"])
+
+	// 与其他背景信息合并
+	ctx := withBackground(withSpeaker(context.Background(), dad), "用户喜欢爵士乐")
+	background := llm.RequestOptionsFromContext(ctx).Background
+	assert.Contains(t, background, "爸爸")
+	assert.Contains(t, background, "\n\n用户喜欢爵士乐")
+}
+
+func TestMemoryUserFallsBackToSpeaker(t *testing.T) {
+	p := NewMessageProcessor(ProcessorConfig{
+		MaxConcurrentSessions: 10,
+		Memory:                memory.Config{Users: map[string]string{"key-alice": "alice"}},
+	})
+	p.memory = &memory.Manager{}
+	session := &Session{Speaker: &asr.Speaker{ID: "dad"}}
+
+	// 默认不按说话人区分记忆
+	assert.Empty(t, p.memoryUser(session))
+
+	p.config.Memory.SpeakerFallback = true
+	assert.Equal(t, "speaker:dad", p.memoryUser(session))
+
+	// 客户端自报的user_id不决定记忆归属
+	session.UserID = "alice"
+	assert.Equal(t, "speaker:dad", p.memoryUser(session))
+	session.Speaker = nil
+	assert.Empty(t, p.memoryUser(session))
+
+	// 按连接的API Key映射用户
+	session.APIKey = "key-alice"
+	assert.Equal(t, "alice", p.memoryUser(session))
+	session.APIKey = "key-unknown"
+	assert.Empty(t, p.memoryUser(session))
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	vaaudio "voice_assistant/pkg/audio"
+	"voice_assistant/pkg/protocol"
+	"voice_assistant/voice_assistant_server/internal/tts"
+)
+
+// qualitySampleRates 未设置采样率时按音质选择的输出采样率
+var qualitySampleRates = map[string]int{
+	"low":    16000,
+	"medium": 22050,
+	"high":   24000,
+}
+
+// outputSampleRate 配置的TTS输出采样率（0表示保持服务输出的采样率）
+func (p *MessageProcessor) outputSampleRate() int {
+	if p.config.TTSConfig.SampleRate > 0 {
+		return p.config.TTSConfig.SampleRate
+	}
+	return qualitySampleRates[p.config.TTSConfig.Quality]
+}
+
+// normalizeSpeech 按tts.settings转换合成结果的采样率和格式，并标明实际的格式
+// 源数据按内容识别：WAV文件头、MP3帧同步字，其余视为结果采样率的16bit PCM（部分服务返回裸PCM但Format为配置值）。
+// 输出格式为wav或pcm时转换为该格式；MP3等压缩格式服务端无法解码，原样下发。
+func (p *MessageProcessor) normalizeSpeech(result tts.TTSResult) tts.TTSResult {
+	audio := result.AudioData
+	if len(audio) == 0 {
+		return result
+	}
+	if vaaudio.IsMP3(audio) {
+		result.Format = protocol.AudioFormatMP3
+		return result
+	}
+
+	pcm, rate, channels := audio, result.SampleRate, result.Channels
+	sourceFormat := protocol.AudioFormatPCM
+	if vaaudio.IsWAV(audio) {
+		wav, err := vaaudio.ParseWAV(audio)
+		if err != nil || !wav.PCM16() {
+			result.Format = protocol.AudioFormatWAV
+			return result
+		}
+		pcm, rate, channels = wav.Data, wav.SampleRate, wav.Channels
+		sourceFormat = protocol.AudioFormatWAV
+	}
+	if rate <= 0 {
+		rate = 16000
+	}
+	if channels <= 0 {
+		channels = 1
+	}
+
+	if target := p.outputSampleRate(); target > 0 && target != rate {
+		// 重采样时混为单声道
+		pcm = vaaudio.Int16ToBytes(vaaudio.ResampleInt16(vaaudio.BytesToInt16(pcm, channels), rate, target))
+		rate, channels = target, 1
+	}
+
+	format := p.config.TTSConfig.Format
+	if format != protocol.AudioFormatWAV && format != protocol.AudioFormatPCM {
+		format = sourceFormat
+	}
+	if format == protocol.AudioFormatWAV {
+		result.AudioData = vaaudio.EncodeWAV(pcm, rate, channels)
+	} else {
+		result.AudioData = pcm
+	}
+	result.Format, result.SampleRate, result.Channels = format, rate, channels
+	if result.Duration <= 0 {
+		result.Duration = int64(len(pcm)) * 1000 / int64(2*channels*rate)
+	}
+	return result
+}
+
+// speechFormat 下发语音的格式信息
+func speechFormat(result tts.TTSResult) *protocol.AudioFormat {
+	if len(result.AudioData) == 0 || result.Format == "" {
+		return nil
+	}
+	return &protocol.AudioFormat{
+		Format:     result.Format,
+		SampleRate: result.SampleRate,
+		Channels:   result.Channels,
+	}
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"testing"
+
+	vaaudio "voice_assistant/pkg/audio"
+	"voice_assistant/pkg/protocol"
+	"voice_assistant/voice_assistant_server/internal/tts"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeSpeech(t *testing.T) {
+	p := NewMessageProcessor(ProcessorConfig{
+		MaxConcurrentSessions: 10,
+		TTSConfig:             tts.TTSConfig{SampleRate: 16000, Format: "wav"},
+	})
+
+	// 22050Hz的WAV重采样为16kHz
+	source := vaaudio.EncodeWAV(make([]byte, 22050*2), 22050, 1)
+	result := p.normalizeSpeech(tts.TTSResult{AudioData: source, Format: "wav", SampleRate: 22050})
+	wav, err := vaaudio.ParseWAV(result.AudioData)
+	require.NoError(t, err)
+	assert.Equal(t, 16000, wav.SampleRate)
+	assert.Len(t, wav.Data, 16000*2)
+	assert.Equal(t, "wav", result.Format)
+	assert.Equal(t, int64(1000), result.Duration)
+
+	// 声明为wav但实际返回裸PCM的服务按结果采样率处理
+	result = p.normalizeSpeech(tts.TTSResult{AudioData: make([]byte, 32000), Format: "wav", SampleRate: 16000})
+	assert.True(t, vaaudio.IsWAV(result.AudioData))
+	assert.Equal(t, 16000, result.SampleRate)
+
+	// MP3原样下发并标明格式
+	mp3 := append([]byte("ID3"), make([]byte, 100)...)
+	result = p.normalizeSpeech(tts.TTSResult{AudioData: mp3, Format: "wav"})
+	assert.Equal(t, mp3, result.AudioData)
+	assert.Equal(t, &protocol.AudioFormat{Format: "mp3"}, speechFormat(result))
+
+	// 输出pcm时去掉文件头
+	p.config.TTSConfig = tts.TTSConfig{Format: "pcm", Quality: "medium"}
+	result = p.normalizeSpeech(tts.TTSResult{AudioData: vaaudio.EncodeWAV(make([]byte, 200), 22050, 1)})
+	assert.Len(t, result.AudioData, 200)
+	assert.Equal(t, &protocol.AudioFormat{Format: "pcm", SampleRate: 22050, Channels: 1}, speechFormat(result))
+}
+
+func TestSendSpeechFormat(t *testing.T) {
+	p := NewMessageProcessor(ProcessorConfig{MaxConcurrentSessions: 10, AudioChunkSize: 400})
+	client := &Client{ID: "format", SendChan: make(chan *protocol.Message, 10)}
+	p.getOrCreateSession(client.ID, "")
+
+	result := tts.TTSResult{AudioData: vaaudio.EncodeWAV(make([]byte, 1000), 24000, 1), Format: "wav", SampleRate: 24000, Channels: 1}
+	require.NoError(t, p.sendSpeech(client, result))
+	require.Len(t, client.SendChan, 3)
+	for i := 0; i < 3; i++ {
+		data := (<-client.SendChan).Payload.(*protocol.ResponseData)
+		require.NotNil(t, data.AudioFormat)
+		assert.Equal(t, "wav", data.AudioFormat.Format)
+		assert.Equal(t, 24000, data.AudioFormat.SampleRate)
+	}
+}
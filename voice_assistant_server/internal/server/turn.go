@@ -0,0 +1,90 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"voice_assistant/pkg/protocol"
+)
+
+// beginTurn 创建一轮处理的上下文：继承会话的上下文（会话释放时取消），受 pipeline.turn_timeout 限制，
+// stop_session、interrupt 可通过 cancelTurnLocked 取消进行中的ASR、LLM、TTS调用。返回的函数在本轮结束时调用。
+func (p *MessageProcessor) beginTurn(session *Session) (context.Context, context.CancelFunc) {
+	parent := sessionContext(session)
+	ctx, cancel := context.WithCancel(parent)
+	if timeout := p.config.Pipeline.TurnTimeout; timeout > 0 {
+		ctx, cancel = context.WithTimeout(parent, timeout)
+	}
+
+	session.mu.Lock()
+	session.turnSeq++
+	seq := session.turnSeq
+	session.turnCancel = cancel
+	session.mu.Unlock()
+
+	return ctx, func() {
+		cancel()
+		session.mu.Lock()
+		if session.turnSeq == seq {
+			session.turnCancel = nil
+		}
+		session.mu.Unlock()
+	}
+}
+
+// sessionContext 会话的上下文（会话释放时取消）
+func sessionContext(session *Session) context.Context {
+	if session.ctx == nil {
+		return context.Background()
+	}
+	return session.ctx
+}
+
+// cancelTurnLocked 取消会话进行中的一轮处理，返回是否有处理被取消（调用方持有会话锁）
+func cancelTurnLocked(session *Session) bool {
+	if session.turnCancel == nil {
+		return false
+	}
+	session.turnCancel()
+	session.turnCancel = nil
+	return true
+}
+
+// turnCancelled 本轮是否已被停止、打断或因会话释放而取消（超时不算，按错误处理）
+func turnCancelled(ctx context.Context) bool {
+	return errors.Is(ctx.Err(), context.Canceled)
+}
+
+// abandonTurn 本轮被取消后结束处理：不再发送错误，会话状态已由取消方设置
+func (p *MessageProcessor) abandonTurn(session *Session, err error) {
+	log.Printf("本轮处理已取消: %s, %v", session.ID, err)
+	session.mu.Lock()
+	session.IsProcessing = false
+	session.pendingFinal = false
+	session.mu.Unlock()
+}
+
+// handleInterrupt 处理打断：取消进行中的识别、生成和合成，丢弃已缓冲的音频，会话恢复聆听（非连续模式回到空闲）
+func (p *MessageProcessor) handleInterrupt(client *Client, session *Session, cmdData protocol.CommandData) error {
+	session.mu.Lock()
+	cancelled := cancelTurnLocked(session)
+	session.AudioBuffer.Reset()
+	session.pendingFinal = false
+	session.awaitingPlayback = false
+	if session.playbackTimer != nil {
+		session.playbackTimer.Stop()
+		session.playbackTimer = nil
+	}
+	if session.State != StateIdle {
+		if session.ContinuousMode {
+			session.State = StateListening
+		} else {
+			session.State = StateIdle
+		}
+	}
+	session.mu.Unlock()
+
+	log.Printf("会话已打断: %s, 取消进行中的处理: %t", session.ID, cancelled)
+	return p.sendStatus(client, session)
+}
@@ -0,0 +1,165 @@
+package server
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"voice_assistant/pkg/protocol"
+	"voice_assistant/voice_assistant_server/internal/asr"
+	"voice_assistant/voice_assistant_server/internal/llm"
+	"voice_assistant/voice_assistant_server/internal/pipeline"
+	"voice_assistant/voice_assistant_server/internal/tts"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingASR 一直等到调用被取消的ASR服务
+type blockingASR struct {
+	asr.ASRService
+	started chan struct{}
+}
+
+func (b *blockingASR) ProcessAudio(ctx context.Context, audio []byte) (asr.ASRResult, error) {
+	close(b.started)
+	<-ctx.Done()
+	return asr.ASRResult{}, ctx.Err()
+}
+
+func TestInterruptCancelsTurn(t *testing.T) {
+	p, client := newModeTestProcessor()
+	service := &blockingASR{started: make(chan struct{})}
+	p.asrService = service
+
+	start := protocol.NewCommandMessage(client.ID, protocol.CmdStartSession, protocol.ModeContinuous, nil)
+	require.NoError(t, p.ProcessMessage(client, start))
+	nextStatus(t, client)
+	sendAudio(t, p, client)
+	session := p.getOrCreateSession(client.ID, "")
+
+	done := make(chan struct{})
+	go func() {
+		p.processAudioBuffer(client, session, true)
+		close(done)
+	}()
+	assert.Equal(t, string(StateProcessing), nextStatus(t, client).State)
+	<-service.started
+
+	interrupt := protocol.NewCommandMessage(client.ID, protocol.CmdInterrupt, "", nil)
+	require.NoError(t, p.ProcessMessage(client, interrupt))
+	assert.Equal(t, string(StateListening), nextStatus(t, client).State)
+
+	// 进行中的识别被取消，本轮结束且不发送错误
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("打断后识别未取消")
+	}
+	assert.False(t, session.IsProcessing)
+	assert.Empty(t, client.SendChan)
+}
+
+func TestStageTimeout(t *testing.T) {
+	p := NewMessageProcessor(ProcessorConfig{
+		MaxConcurrentSessions: 10,
+		Pipeline:              pipeline.Config{ASRTimeout: 20 * time.Millisecond},
+	})
+	p.asrService = &blockingASR{started: make(chan struct{})}
+
+	_, err := p.recognize(context.Background(), pipeline.PriorityInteractive, []byte{1, 2})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+// transcriptASR 识别结果固定的ASR服务
+type transcriptASR struct{ asr.ASRService }
+
+func (s *transcriptASR) ProcessAudio(ctx context.Context, audio []byte) (asr.ASRResult, error) {
+	return asr.ASRResult{Text: "今天天气怎么样", Confidence: 1, IsFinal: true}, nil
+}
+
+// blockingLLM 一直等到调用被取消或超时的LLM服务
+type blockingLLM struct{ llm.LLMService }
+
+func (b *blockingLLM) Chat(ctx context.Context, userInput string, conversationID string) (llm.LLMResponse, error) {
+	<-ctx.Done()
+	return llm.LLMResponse{}, ctx.Err()
+}
+
+func TestLaterStageTimeoutKeepsPartialResults(t *testing.T) {
+	p, client := newModeTestProcessor()
+	p.config.Pipeline.LLMTimeout = 20 * time.Millisecond
+	p.asrService = &transcriptASR{}
+	p.llmService = &blockingLLM{}
+
+	start := protocol.NewCommandMessage(client.ID, protocol.CmdStartSession, protocol.ModeContinuous, nil)
+	require.NoError(t, p.ProcessMessage(client, start))
+	nextStatus(t, client)
+	sendAudio(t, p, client)
+	session := p.getOrCreateSession(client.ID, "")
+	p.processAudioBuffer(client, session, true)
+
+	// 识别结果照常下发，LLM超时的错误中带已下发的识别文本，本轮结束后恢复聆听
+	var transcript string
+	var timeout *protocol.ErrorData
+	for len(client.SendChan) > 0 {
+		msg := <-client.SendChan
+		switch data := msg.Payload.(type) {
+		case *protocol.ResponseData:
+			transcript = data.Content
+		case *protocol.ErrorData:
+			timeout = data
+		}
+	}
+	assert.Equal(t, "今天天气怎么样", transcript)
+	require.NotNil(t, timeout)
+	assert.Equal(t, protocol.ErrTimeout, timeout.Code)
+	assert.Equal(t, map[string]interface{}{"transcript": "今天天气怎么样"}, timeout.Details["partial"])
+	assert.Equal(t, StateListening, session.State)
+	assert.False(t, session.IsProcessing)
+}
+
+// replyLLM 回复固定的LLM服务
+type replyLLM struct{ llm.LLMService }
+
+func (r *replyLLM) Chat(ctx context.Context, userInput string, conversationID string) (llm.LLMResponse, error) {
+	return llm.LLMResponse{Content: "今天晴，最高气温25度"}, nil
+}
+
+// countingTTS 记录合成次数的TTS服务
+type countingTTS struct {
+	tts.TTSService
+	calls atomic.Int32
+}
+
+func (c *countingTTS) SynthesizeText(ctx context.Context, text string) (tts.TTSResult, error) {
+	c.calls.Add(1)
+	return tts.TTSResult{AudioData: make([]byte, 320), Format: "pcm", SampleRate: 16000, Channels: 1}, nil
+}
+
+func TestTextOnlySkipsTTS(t *testing.T) {
+	p, client := newModeTestProcessor()
+	p.asrService = &transcriptASR{}
+	p.llmService = &replyLLM{}
+	service := &countingTTS{}
+	p.ttsService = service
+
+	start := protocol.NewCommandMessage(client.ID, protocol.CmdStartSession, protocol.ModeContinuous, map[string]interface{}{"text_only": true})
+	require.NoError(t, p.ProcessMessage(client, start))
+	nextStatus(t, client)
+	sendAudio(t, p, client)
+	session := p.getOrCreateSession(client.ID, "")
+	p.processAudioBuffer(client, session, true)
+
+	// 只返回识别和回复文本，不合成语音
+	var stages []string
+	for len(client.SendChan) > 0 {
+		if data, ok := (<-client.SendChan).Payload.(*protocol.ResponseData); ok {
+			stages = append(stages, data.Stage)
+		}
+	}
+	assert.Equal(t, []string{protocol.StageASR, protocol.StageLLM}, stages)
+	assert.Zero(t, service.calls.Load())
+	assert.Equal(t, StateListening, session.State)
+}
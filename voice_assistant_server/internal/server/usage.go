@@ -0,0 +1,421 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"voice_assistant/pkg/protocol"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UsageConfig 用量统计与预算配置
+// 用量按会话和API Key累计（API Key取自连接握手的 X-API-Key 或 Authorization: Bearer 请求头，未携带时计入匿名用量）。
+type UsageConfig struct {
+	Enabled bool                    `yaml:"enabled"`  // 是否启用
+	Period  string                  `yaml:"period"`   // API Key预算周期: day|month
+	Pricing UsagePricing            `yaml:"pricing"`  // 单价（用于估算费用）
+	Session UsageBudget             `yaml:"session"`  // 单个会话的预算
+	Default UsageBudget             `yaml:"default"`  // 每个API Key每周期的默认预算
+	APIKeys map[string]APIKeyBudget `yaml:"api_keys"` // 按API Key覆盖默认预算
+}
+
+// UsagePricing 单价（单位自定，如美元）
+type UsagePricing struct {
+	PromptTokens     float64 `yaml:"prompt_tokens"`     // 每千提示Token
+	CompletionTokens float64 `yaml:"completion_tokens"` // 每千生成Token
+	ASRMinute        float64 `yaml:"asr_minute"`        // 每分钟识别音频
+	TTSMinute        float64 `yaml:"tts_minute"`        // 每分钟合成音频
+}
+
+// UsageBudget 预算上限（0表示不限制）
+type UsageBudget struct {
+	MaxTokens     int     `yaml:"max_tokens" json:"max_tokens"`
+	MaxASRSeconds float64 `yaml:"max_asr_seconds" json:"max_asr_seconds"`
+	MaxTTSSeconds float64 `yaml:"max_tts_seconds" json:"max_tts_seconds"`
+	MaxCost       float64 `yaml:"max_cost" json:"max_cost"`
+}
+
+// APIKeyBudget API Key的名称和预算
+type APIKeyBudget struct {
+	Name   string      `yaml:"name"` // 用量报告中显示的名称（不显示API Key本身）
+	Budget UsageBudget `yaml:"budget"`
+}
+
+// 预算周期
+const (
+	UsagePeriodDay   = "day"
+	UsagePeriodMonth = "month"
+)
+
+// anonymousAPIKey 未携带API Key的连接在用量报告中的名称
+const anonymousAPIKey = "anonymous"
+
+// APIKeyUsage 单个API Key的用量（/api/usage返回）
+type APIKeyUsage struct {
+	APIKey   string               `json:"api_key"` // 配置的名称或API Key的摘要
+	Period   string               `json:"period"`  // 当前计费周期，如 2026-10
+	Usage    protocol.UsageTotals `json:"usage"`
+	Budget   UsageBudget          `json:"budget"`
+	Exceeded string               `json:"exceeded,omitempty"`
+}
+
+// SessionUsage 单个会话的用量（/api/usage返回）
+type SessionUsage struct {
+	SessionID string               `json:"session_id"`
+	APIKey    string               `json:"api_key"`
+	Usage     protocol.UsageTotals `json:"usage"`
+	Exceeded  string               `json:"exceeded,omitempty"`
+}
+
+// keyUsage 单个API Key当前周期的用量
+type keyUsage struct {
+	period string
+	totals protocol.UsageTotals
+}
+
+// UsageTracker 按API Key累计用量（会话用量保存在会话中）
+type UsageTracker struct {
+	config UsageConfig
+	keys   map[string]*keyUsage
+	mu     sync.Mutex
+
+	now func() time.Time
+}
+
+// NewUsageTracker 创建用量统计
+func NewUsageTracker(config UsageConfig) *UsageTracker {
+	return &UsageTracker{
+		config: config,
+		keys:   make(map[string]*keyUsage),
+		now:    time.Now,
+	}
+}
+
+// Cost 按单价估算一次用量的费用
+func (t *UsageTracker) Cost(usage protocol.UsageTotals) float64 {
+	pricing := t.config.Pricing
+	return float64(usage.PromptTokens)/1000*pricing.PromptTokens +
+		float64(usage.CompletionTokens)/1000*pricing.CompletionTokens +
+		usage.ASRSeconds/60*pricing.ASRMinute +
+		usage.TTSSeconds/60*pricing.TTSMinute
+}
+
+// Record 累计API Key的用量
+func (t *UsageTracker) Record(apiKey string, delta protocol.UsageTotals) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	addUsage(&t.currentLocked(apiKey).totals, delta)
+}
+
+// Usage API Key当前周期的用量
+func (t *UsageTracker) Usage(apiKey string) protocol.UsageTotals {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.currentLocked(apiKey).totals
+}
+
+// Budget API Key适用的预算
+func (t *UsageTracker) Budget(apiKey string) UsageBudget {
+	if key, exists := t.config.APIKeys[apiKey]; exists && apiKey != "" {
+		return key.Budget
+	}
+	return t.config.Default
+}
+
+// Exceeded 检查会话或API Key是否已超出预算，返回超出的预算（如 session:tokens、api_key:cost，未超出时为空）
+func (t *UsageTracker) Exceeded(session protocol.UsageTotals, apiKey string) string {
+	if exceeded := exceededBudget(t.config.Session, session); exceeded != "" {
+		return "session:" + exceeded
+	}
+	if exceeded := exceededBudget(t.Budget(apiKey), t.Usage(apiKey)); exceeded != "" {
+		return "api_key:" + exceeded
+	}
+	return ""
+}
+
+// Label 用量报告中的API Key名称（不暴露API Key本身）
+func (t *UsageTracker) Label(apiKey string) string {
+	if apiKey == "" {
+		return anonymousAPIKey
+	}
+	if key, exists := t.config.APIKeys[apiKey]; exists && key.Name != "" {
+		return key.Name
+	}
+	sum := sha256.Sum256([]byte(apiKey))
+	return "key_" + hex.EncodeToString(sum[:4])
+}
+
+// Keys 全部API Key当前周期的用量（按名称排序）
+func (t *UsageTracker) Keys() []APIKeyUsage {
+	t.mu.Lock()
+	apiKeys := make([]string, 0, len(t.keys))
+	for apiKey := range t.keys {
+		apiKeys = append(apiKeys, apiKey)
+	}
+	t.mu.Unlock()
+
+	result := make([]APIKeyUsage, 0, len(apiKeys))
+	for _, apiKey := range apiKeys {
+		result = append(result, t.Key(apiKey))
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].APIKey < result[j].APIKey
+	})
+	return result
+}
+
+// Key 单个API Key当前周期的用量
+func (t *UsageTracker) Key(apiKey string) APIKeyUsage {
+	usage := t.Usage(apiKey)
+	budget := t.Budget(apiKey)
+	return APIKeyUsage{
+		APIKey:   t.Label(apiKey),
+		Period:   t.period(),
+		Usage:    usage,
+		Budget:   budget,
+		Exceeded: exceededBudget(budget, usage),
+	}
+}
+
+// currentLocked 获取API Key的用量记录，进入新周期时清零（调用方需持有锁）
+func (t *UsageTracker) currentLocked(apiKey string) *keyUsage {
+	period := t.period()
+	usage, exists := t.keys[apiKey]
+	if !exists {
+		usage = &keyUsage{period: period}
+		t.keys[apiKey] = usage
+	}
+	if usage.period != period {
+		usage.period = period
+		usage.totals = protocol.UsageTotals{}
+	}
+	return usage
+}
+
+// period 当前计费周期
+func (t *UsageTracker) period() string {
+	if t.config.Period == UsagePeriodDay {
+		return t.now().Format("2006-01-02")
+	}
+	return t.now().Format("2006-01")
+}
+
+// exceededBudget 已超出的预算项（未超出时为空）
+func exceededBudget(budget UsageBudget, usage protocol.UsageTotals) string {
+	switch {
+	case budget.MaxTokens > 0 && usage.TotalTokens >= budget.MaxTokens:
+		return "tokens"
+	case budget.MaxASRSeconds > 0 && usage.ASRSeconds >= budget.MaxASRSeconds:
+		return "asr_seconds"
+	case budget.MaxTTSSeconds > 0 && usage.TTSSeconds >= budget.MaxTTSSeconds:
+		return "tts_seconds"
+	case budget.MaxCost > 0 && usage.Cost >= budget.MaxCost:
+		return "cost"
+	}
+	return ""
+}
+
+// addUsage 累加用量
+func addUsage(total *protocol.UsageTotals, delta protocol.UsageTotals) {
+	total.Turns += delta.Turns
+	total.PromptTokens += delta.PromptTokens
+	total.CompletionTokens += delta.CompletionTokens
+	total.TotalTokens += delta.TotalTokens
+	total.ASRSeconds += delta.ASRSeconds
+	total.TTSSeconds += delta.TTSSeconds
+	total.Cost += delta.Cost
+}
+
+// requestAPIKey 从请求头（X-API-Key 或 Authorization: Bearer）或查询参数 api_key 读取API Key
+func requestAPIKey(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+	}
+	return r.URL.Query().Get("api_key")
+}
+
+// requestAPIKeyKey context键
+type requestAPIKeyKey struct{}
+
+// withRequestAPIKey 把信令请求携带的API Key传给连接建立过程
+func withRequestAPIKey(ctx context.Context, apiKey string) context.Context {
+	return context.WithValue(ctx, requestAPIKeyKey{}, apiKey)
+}
+
+// requestAPIKeyFromContext 获取信令请求携带的API Key
+func requestAPIKeyFromContext(ctx context.Context) string {
+	apiKey, _ := ctx.Value(requestAPIKeyKey{}).(string)
+	return apiKey
+}
+
+// usageScopeKey context键
+type usageScopeKey struct{}
+
+// usageScope 用量的归属（会话可为nil，如REST请求）
+type usageScope struct {
+	session *Session
+	apiKey  string
+}
+
+// withUsageScope 指定之后经工作池的识别、生成和合成用量计入的会话和API Key
+func withUsageScope(ctx context.Context, session *Session, apiKey string) context.Context {
+	return context.WithValue(ctx, usageScopeKey{}, usageScope{session: session, apiKey: apiKey})
+}
+
+// withSessionUsage 用量计入会话及其API Key
+func withSessionUsage(ctx context.Context, session *Session) context.Context {
+	session.mu.RLock()
+	apiKey := session.APIKey
+	session.mu.RUnlock()
+	return withUsageScope(ctx, session, apiKey)
+}
+
+// recordUsage 记录一次用量（未启用或context未指定归属时忽略）
+func (p *MessageProcessor) recordUsage(ctx context.Context, delta protocol.UsageTotals) {
+	if p.usage == nil {
+		return
+	}
+	scope, ok := ctx.Value(usageScopeKey{}).(usageScope)
+	if !ok {
+		return
+	}
+
+	delta.Cost = p.usage.Cost(delta)
+	if scope.session != nil {
+		scope.session.mu.Lock()
+		addUsage(&scope.session.Usage, delta)
+		scope.session.mu.Unlock()
+	}
+	p.usage.Record(scope.apiKey, delta)
+}
+
+// checkBudget 检查会话（可为nil）或API Key是否已超出预算（未启用时始终通过）
+func (p *MessageProcessor) checkBudget(session *Session, apiKey string) string {
+	if p.usage == nil {
+		return ""
+	}
+
+	var totals protocol.UsageTotals
+	if session != nil {
+		session.mu.RLock()
+		totals = session.Usage
+		apiKey = session.APIKey
+		session.mu.RUnlock()
+	}
+	return p.usage.Exceeded(totals, apiKey)
+}
+
+// usageStatus 会话及其API Key的累计用量（未启用时为nil）
+func (p *MessageProcessor) usageStatus(session *Session) (*protocol.UsageTotals, *protocol.UsageTotals) {
+	if p.usage == nil {
+		return nil, nil
+	}
+
+	session.mu.RLock()
+	sessionUsage := session.Usage
+	apiKey := session.APIKey
+	session.mu.RUnlock()
+
+	keyUsage := p.usage.Usage(apiKey)
+	return &sessionUsage, &keyUsage
+}
+
+// handleUsage 调用方API Key及其会话的累计用量（可用 session_id 参数只查询一个会话）
+func (h *RESTHandler) handleUsage(c *gin.Context) {
+	if h.processor.usage == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "未启用用量统计"})
+		return
+	}
+	apiKey := requestAPIKey(c.Request)
+	if apiKey == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "缺少API Key"})
+		return
+	}
+
+	sessions, ok := h.processor.usageSessions(c, func(session *Session) bool { return session.APIKey == apiKey })
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"sessions": sessions,
+		"api_keys": []APIKeyUsage{h.processor.usage.Key(apiKey)},
+	})
+}
+
+// handleUsage 全部会话和API Key的累计用量（可用 session_id 参数只查询一个会话）
+func (h *AdminHandler) handleUsage(c *gin.Context) {
+	if h.processor.usage == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "未启用用量统计"})
+		return
+	}
+
+	sessions, ok := h.processor.usageSessions(c, func(*Session) bool { return true })
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"sessions": sessions,
+		"api_keys": h.processor.usage.Keys(),
+	})
+}
+
+// usageSessions 按 session_id 参数和可见范围列出会话用量（指定的会话不存在或不可见时返回404）
+func (p *MessageProcessor) usageSessions(c *gin.Context, visible func(*Session) bool) ([]SessionUsage, bool) {
+	sessionID := c.Query("session_id")
+	p.mu.RLock()
+	sessions := make([]*Session, 0, len(p.sessions))
+	for id, session := range p.sessions {
+		if sessionID == "" || id == sessionID {
+			sessions = append(sessions, session)
+		}
+	}
+	p.mu.RUnlock()
+
+	report := make([]SessionUsage, 0, len(sessions))
+	for _, session := range sessions {
+		session.mu.RLock()
+		if visible(session) {
+			report = append(report, SessionUsage{
+				SessionID: session.ID,
+				APIKey:    p.usage.Label(session.APIKey),
+				Usage:     session.Usage,
+				Exceeded:  exceededBudget(p.config.Usage.Session, session.Usage),
+			})
+		}
+		session.mu.RUnlock()
+	}
+	if sessionID != "" && len(report) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "会话不存在"})
+		return nil, false
+	}
+	sort.Slice(report, func(i, j int) bool {
+		return report[i].SessionID < report[j].SessionID
+	})
+	return report, true
+}
+
+// budgetReady REST请求前检查API Key的预算，超出时返回429
+func (h *RESTHandler) budgetReady(c *gin.Context) bool {
+	exceeded := h.processor.checkBudget(nil, requestAPIKey(c.Request))
+	if exceeded == "" {
+		return true
+	}
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":    "已超出用量预算",
+		"code":     protocol.ErrQuotaExceeded,
+		"exceeded": exceeded,
+	})
+	return false
+}
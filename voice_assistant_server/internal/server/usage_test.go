@@ -0,0 +1,121 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"voice_assistant/pkg/protocol"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsageTrackerBudgetAndPeriod(t *testing.T) {
+	now := time.Date(2024, 5, 31, 12, 0, 0, 0, time.Local)
+	tracker := NewUsageTracker(UsageConfig{
+		Pricing: UsagePricing{PromptTokens: 1, CompletionTokens: 2, ASRMinute: 0.6},
+		Session: UsageBudget{MaxTokens: 1000},
+		Default: UsageBudget{MaxCost: 1},
+		APIKeys: map[string]APIKeyBudget{"vip": {Name: "team-vip"}},
+	})
+	tracker.now = func() time.Time { return now }
+
+	delta := protocol.UsageTotals{PromptTokens: 200, CompletionTokens: 300, ASRSeconds: 30}
+	assert.InDelta(t, 0.2+0.6+0.3, tracker.Cost(delta), 0.0001)
+
+	delta.Cost = tracker.Cost(delta)
+	tracker.Record("k", delta)
+	assert.Equal(t, "api_key:cost", tracker.Exceeded(protocol.UsageTotals{}, "k"))
+	assert.Equal(t, "session:tokens", tracker.Exceeded(protocol.UsageTotals{TotalTokens: 1000}, "k"))
+	// 配置了预算覆盖的API Key不受默认预算限制
+	tracker.Record("vip", delta)
+	assert.Equal(t, "", tracker.Exceeded(protocol.UsageTotals{}, "vip"))
+
+	assert.Equal(t, "team-vip", tracker.Label("vip"))
+	assert.Equal(t, anonymousAPIKey, tracker.Label(""))
+	assert.Regexp(t, `^key_[0-9a-f]{8}$`, tracker.Label("k"))
+
+	// 进入新的计费周期后清零
+	now = now.Add(24 * time.Hour)
+	assert.Equal(t, "", tracker.Exceeded(protocol.UsageTotals{}, "k"))
+	assert.Zero(t, tracker.Usage("k").Cost)
+}
+
+func TestRecordUsageScope(t *testing.T) {
+	p := &MessageProcessor{
+		config: ProcessorConfig{Usage: UsageConfig{Session: UsageBudget{MaxTTSSeconds: 5}}},
+		usage:  NewUsageTracker(UsageConfig{Session: UsageBudget{MaxTTSSeconds: 5}}),
+	}
+	session := &Session{ID: "s1", APIKey: "k"}
+
+	// 未指定归属时不计入
+	p.recordUsage(context.Background(), protocol.UsageTotals{TTSSeconds: 10})
+	assert.Empty(t, p.usage.Keys())
+
+	ctx := withSessionUsage(context.Background(), session)
+	p.recordUsage(ctx, protocol.UsageTotals{Turns: 1, TotalTokens: 30})
+	p.recordUsage(ctx, protocol.UsageTotals{TTSSeconds: 6})
+	assert.Equal(t, 30, session.Usage.TotalTokens)
+	assert.Equal(t, 1, p.usage.Usage("k").Turns)
+	assert.Equal(t, "session:tts_seconds", p.checkBudget(session, ""))
+	assert.Equal(t, "", p.checkBudget(nil, "k"))
+}
+
+func TestUsageEndpointScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	p := NewMessageProcessor(ProcessorConfig{MaxConcurrentSessions: 10, Usage: UsageConfig{Enabled: true}})
+	p.getOrCreateSession("mine", "k1")
+	p.getOrCreateSession("theirs", "k2")
+	p.usage.Record("k2", protocol.UsageTotals{Turns: 1})
+	router := gin.New()
+	NewRESTHandler(p).Register(router.Group("/api"))
+	NewAdminHandler(p, AdminConfig{Token: "secret"}).Register(router.Group("/api/admin"))
+
+	serve := func(path, header, value string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	var report struct {
+		Sessions []SessionUsage `json:"sessions"`
+		APIKeys  []APIKeyUsage  `json:"api_keys"`
+	}
+
+	// 未携带API Key时拒绝，携带时只返回自己的会话和用量
+	assert.Equal(t, http.StatusUnauthorized, serve("/api/usage", "", "").Code)
+	w := serve("/api/usage", "X-API-Key", "k1")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	require.Len(t, report.Sessions, 1)
+	assert.Equal(t, "mine", report.Sessions[0].SessionID)
+	require.Len(t, report.APIKeys, 1)
+	assert.Zero(t, report.APIKeys[0].Usage.Turns)
+	assert.Equal(t, http.StatusNotFound, serve("/api/usage?session_id=theirs", "X-API-Key", "k1").Code)
+
+	// 管理接口返回全部会话
+	assert.Equal(t, http.StatusUnauthorized, serve("/api/admin/usage", "X-API-Key", "k1").Code)
+	w = serve("/api/admin/usage", "Authorization", "Bearer secret")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.Len(t, report.Sessions, 2)
+}
+
+func TestRequestAPIKey(t *testing.T) {
+	r := httptest.NewRequest("GET", "/ws?api_key=query", nil)
+	assert.Equal(t, "query", requestAPIKey(r))
+
+	r.Header.Set("Authorization", "Bearer bearer")
+	assert.Equal(t, "bearer", requestAPIKey(r))
+
+	r.Header.Set("X-API-Key", "header")
+	assert.Equal(t, "header", requestAPIKey(r))
+}
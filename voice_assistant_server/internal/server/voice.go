@@ -0,0 +1,159 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"voice_assistant/pkg/protocol"
+	"voice_assistant/voice_assistant_server/internal/tts"
+)
+
+// 会话语音参数的取值范围（倍率，1.0为默认）
+const (
+	minSpeechSpeed  = 0.5
+	maxSpeechSpeed  = 2.0
+	minSpeechPitch  = 0.5
+	maxSpeechPitch  = 1.5
+	minSpeechVolume = 0.1
+	maxSpeechVolume = 2.0
+)
+
+// SessionVoice 会话级TTS设置（零值表示使用服务配置）
+type SessionVoice struct {
+	Voice         string  // 声音ID
+	VoiceLanguage string  // 声音的语言（切换到其他语言时不再使用该声音）
+	Speed         float32 // 语速倍率
+	Pitch         float32 // 音调倍率
+	Volume        float32 // 音量倍率
+}
+
+// parseVoiceParameters 从命令参数读取声音和语速等设置，声音需在TTS服务支持的列表中
+func (p *MessageProcessor) parseVoiceParameters(current SessionVoice, params map[string]interface{}) (SessionVoice, error) {
+	result := current
+
+	if value, exists := params["voice"]; exists {
+		voiceID, ok := value.(string)
+		if !ok {
+			return current, fmt.Errorf("voice 必须是字符串")
+		}
+		if voiceID == "" {
+			result.Voice, result.VoiceLanguage = "", ""
+		} else {
+			voice, err := p.lookupVoice(voiceID)
+			if err != nil {
+				return current, err
+			}
+			result.Voice, result.VoiceLanguage = voice.ID, voice.Language
+		}
+	}
+
+	ranges := []struct {
+		name     string
+		min, max float32
+		target   *float32
+	}{
+		{"speed", minSpeechSpeed, maxSpeechSpeed, &result.Speed},
+		{"pitch", minSpeechPitch, maxSpeechPitch, &result.Pitch},
+		{"volume", minSpeechVolume, maxSpeechVolume, &result.Volume},
+	}
+	for _, r := range ranges {
+		value, exists := params[r.name]
+		if !exists {
+			continue
+		}
+		number, ok := value.(float64)
+		if !ok {
+			return current, fmt.Errorf("%s 必须是数字", r.name)
+		}
+		if number < float64(r.min) || number > float64(r.max) {
+			return current, fmt.Errorf("%s 超出范围 %.1f-%.1f", r.name, r.min, r.max)
+		}
+		*r.target = float32(number)
+	}
+
+	return result, nil
+}
+
+// lookupVoice 在TTS服务支持的声音中查找（服务未提供声音列表时不做校验）
+func (p *MessageProcessor) lookupVoice(voiceID string) (tts.Voice, error) {
+	if p.ttsService == nil {
+		return tts.Voice{}, fmt.Errorf("TTS服务未初始化")
+	}
+
+	voices := p.ttsService.GetSupportedVoices()
+	if len(voices) == 0 {
+		return tts.Voice{ID: voiceID}, nil
+	}
+	for _, voice := range voices {
+		if strings.EqualFold(voice.ID, voiceID) {
+			return voice, nil
+		}
+	}
+	return tts.Voice{}, fmt.Errorf("不支持的声音: %s", voiceID)
+}
+
+// withVoiceOptions 将会话级声音和语速设置应用到TTS请求选项（在语言配置之后调用，优先于语言默认声音）
+func withVoiceOptions(ctx context.Context, voice SessionVoice) context.Context {
+	if voice == (SessionVoice{}) {
+		return ctx
+	}
+
+	opts := tts.RequestOptionsFromContext(ctx)
+	if voice.Voice != "" && voiceMatchesLanguage(voice.VoiceLanguage, opts.Language) {
+		opts.Voice = voice.Voice
+	}
+	if voice.Speed > 0 {
+		opts.Speed = voice.Speed
+	}
+	if voice.Pitch > 0 {
+		opts.Pitch = voice.Pitch
+	}
+	if voice.Volume > 0 {
+		opts.Volume = voice.Volume
+	}
+	return tts.WithRequestOptions(ctx, opts)
+}
+
+// voiceMatchesLanguage 判断声音能否用于当前语言（任一方未知时视为匹配）
+func voiceMatchesLanguage(voiceLanguage, language string) bool {
+	if voiceLanguage == "" || language == "" {
+		return true
+	}
+	primary := func(code string) string {
+		code = strings.ToLower(code)
+		if i := strings.IndexAny(code, "-_"); i >= 0 {
+			code = code[:i]
+		}
+		return code
+	}
+	return primary(voiceLanguage) == primary(language)
+}
+
+// handleListVoices 处理获取声音列表（可选参数language按语言过滤）
+func (p *MessageProcessor) handleListVoices(client *Client, session *Session, cmdData protocol.CommandData) error {
+	if p.ttsService == nil {
+		return p.sendError(client, protocol.ErrServiceUnavailable, "TTS服务未初始化", true)
+	}
+
+	language, _ := cmdData.Parameters["language"].(string)
+	data := &protocol.VoiceListData{
+		Provider: p.config.TTSConfig.Type,
+		Voices:   []protocol.VoiceInfo{},
+	}
+	for _, voice := range p.ttsService.GetSupportedVoices() {
+		if language != "" && !voiceMatchesLanguage(voice.Language, language) {
+			continue
+		}
+		data.Voices = append(data.Voices, protocol.VoiceInfo{
+			ID:          voice.ID,
+			Name:        voice.Name,
+			DisplayName: voice.DisplayName,
+			Language:    voice.Language,
+			Gender:      voice.Gender,
+			Description: voice.Description,
+		})
+	}
+
+	return client.SendMessage(protocol.NewMessage(protocol.VoiceList, client.ID, data))
+}
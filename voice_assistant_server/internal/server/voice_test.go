@@ -0,0 +1,78 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"voice_assistant/voice_assistant_server/internal/tts"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// voiceListTTS 只提供声音列表的TTS服务
+type voiceListTTS struct {
+	tts.TTSService
+	voices []tts.Voice
+}
+
+func (v voiceListTTS) GetSupportedVoices() []tts.Voice {
+	return v.voices
+}
+
+func TestParseVoiceParameters(t *testing.T) {
+	p := NewMessageProcessor(ProcessorConfig{MaxConcurrentSessions: 10})
+	p.ttsService = voiceListTTS{voices: []tts.Voice{
+		{ID: "zh-CN-YunxiNeural", Language: "zh-CN"},
+		{ID: "en-US-AriaNeural", Language: "en-US"},
+	}}
+
+	voice, err := p.parseVoiceParameters(SessionVoice{}, map[string]interface{}{
+		"voice": "zh-cn-yunxineural",
+		"speed": 1.25,
+		"pitch": 0.9,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, SessionVoice{Voice: "zh-CN-YunxiNeural", VoiceLanguage: "zh-CN", Speed: 1.25, Pitch: 0.9}, voice)
+
+	// 未提供的参数保持原值，空声音恢复默认
+	voice, err = p.parseVoiceParameters(voice, map[string]interface{}{"voice": "", "volume": 1.5})
+	require.NoError(t, err)
+	assert.Equal(t, SessionVoice{Speed: 1.25, Pitch: 0.9, Volume: 1.5}, voice)
+
+	for _, params := range []map[string]interface{}{
+		{"voice": "unknown"},
+		{"voice": 1},
+		{"speed": 3.0},
+		{"pitch": "high"},
+		{"volume": 0.0},
+	} {
+		_, err := p.parseVoiceParameters(voice, params)
+		assert.Error(t, err, params)
+	}
+}
+
+func TestWithVoiceOptions(t *testing.T) {
+	voice := SessionVoice{Voice: "zh-CN-YunxiNeural", VoiceLanguage: "zh-CN", Speed: 1.2}
+
+	// 会话声音优先于语言默认声音
+	opts := tts.RequestOptionsFromContext(withVoiceOptions(withLanguageOptions(context.Background(), "zh"), voice))
+	assert.Equal(t, "zh-CN-YunxiNeural", opts.Voice)
+	assert.Equal(t, float32(1.2), opts.Speed)
+
+	// 切换到其他语言时使用该语言的默认声音，语速仍然生效
+	opts = tts.RequestOptionsFromContext(withVoiceOptions(withLanguageOptions(context.Background(), "en"), voice))
+	assert.Equal(t, "en-US-AriaNeural", opts.Voice)
+	assert.Equal(t, float32(1.2), opts.Speed)
+
+	// 未使用语言配置时直接使用会话声音
+	opts = tts.RequestOptionsFromContext(withVoiceOptions(context.Background(), voice))
+	assert.Equal(t, "zh-CN-YunxiNeural", opts.Voice)
+}
+
+func TestVoiceMatchesLanguage(t *testing.T) {
+	assert.True(t, voiceMatchesLanguage("zh-CN", "zh"))
+	assert.True(t, voiceMatchesLanguage("en-US", "en_GB"))
+	assert.True(t, voiceMatchesLanguage("", "en"))
+	assert.False(t, voiceMatchesLanguage("zh-CN", "en-US"))
+}
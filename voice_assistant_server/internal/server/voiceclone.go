@@ -0,0 +1,145 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"voice_assistant/voice_assistant_server/internal/pipeline"
+	"voice_assistant/voice_assistant_server/internal/tts"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxVoiceSampleSize 参考音频上限（字节）
+const maxVoiceSampleSize = 10 << 20
+
+// handleVoiceList 声音列表（包括已登记的克隆声音）
+func (h *RESTHandler) handleVoiceList(c *gin.Context) {
+	if !h.ready(c) {
+		return
+	}
+
+	voices := h.processor.ttsService.GetSupportedVoices()
+	if language := c.Query("language"); language != "" {
+		filtered := make([]tts.Voice, 0, len(voices))
+		for _, voice := range voices {
+			if voiceMatchesLanguage(voice.Language, language) {
+				filtered = append(filtered, voice)
+			}
+		}
+		voices = filtered
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"provider": h.processor.config.TTSConfig.Type,
+		"voices":   voices,
+	})
+}
+
+// handleVoiceEnroll 登记克隆声音（管理接口，multipart字段 id、name、language、prompt_text、audio）；
+// 未提供 prompt_text 时用ASR识别参考音频（需为16kHz单声道WAV）
+func (h *RESTHandler) handleVoiceEnroll(c *gin.Context) {
+	cloner, ok := h.voiceCloner(c)
+	if !ok {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxVoiceSampleSize)
+	fileHeader, err := c.FormFile("audio")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少参考音频: " + err.Error()})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	audio, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sample := tts.VoiceSample{
+		ID:         c.PostForm("id"),
+		Name:       c.PostForm("name"),
+		Language:   c.PostForm("language"),
+		PromptText: strings.TrimSpace(c.PostForm("prompt_text")),
+		Audio:      audio,
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), restTimeout)
+	defer cancel()
+
+	if sample.PromptText == "" {
+		pcm, err := extractPCM(audio)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无法识别参考音频，请提供 prompt_text: " + err.Error()})
+			return
+		}
+		result, err := h.processor.recognize(withLanguageOptions(ctx, sample.Language), pipeline.PriorityBatch, pcm)
+		if err != nil {
+			h.serviceError(c, pipeline.StageASR, err, "识别参考音频失败")
+			return
+		}
+		sample.PromptText = strings.TrimSpace(result.Text)
+	}
+
+	voice, err := cloner.EnrollVoice(ctx, sample)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, tts.ErrInvalidVoice) || errors.Is(err, tts.ErrFormatNotSupported) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": "登记声音失败: " + err.Error()})
+		return
+	}
+
+	log.Printf("已登记克隆声音: %s (%s)", voice.ID, sample.PromptText)
+	c.JSON(http.StatusOK, gin.H{
+		"voice":       voice,
+		"prompt_text": sample.PromptText,
+	})
+}
+
+// handleVoiceDelete 删除克隆声音（管理接口）
+func (h *RESTHandler) handleVoiceDelete(c *gin.Context) {
+	cloner, ok := h.voiceCloner(c)
+	if !ok {
+		return
+	}
+
+	voiceID := c.Param("id")
+	if err := cloner.DeleteVoice(c.Request.Context(), voiceID); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, tts.ErrVoiceNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	log.Printf("已删除克隆声音: %s", voiceID)
+	c.JSON(http.StatusOK, gin.H{"id": voiceID})
+}
+
+// voiceCloner 检查当前TTS服务是否支持声音克隆
+func (h *RESTHandler) voiceCloner(c *gin.Context) (tts.VoiceCloner, bool) {
+	if !h.ready(c) {
+		return nil, false
+	}
+	cloner, ok := h.processor.ttsService.(tts.VoiceCloner)
+	if !ok {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "当前TTS服务不支持声音克隆: " + h.processor.config.TTSConfig.Type})
+		return nil, false
+	}
+	return cloner, true
+}
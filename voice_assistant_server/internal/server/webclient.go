@@ -0,0 +1,49 @@
+package server
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// webClientFiles 内置网页客户端的静态文件（页面通过同源的 /ws 接入，协议与桌面客户端相同）
+//
+//go:embed webclient
+var webClientFiles embed.FS
+
+// DefaultWebClientPath 内置网页客户端的默认挂载路径
+const DefaultWebClientPath = "/app"
+
+// WebClientConfig 内置网页客户端配置
+type WebClientConfig struct {
+	Enabled bool   `yaml:"enabled"` // 是否在HTTP端口提供网页客户端
+	Path    string `yaml:"path"`    // 挂载路径（默认 /app）
+}
+
+// RegisterWebClient 在配置的路径下提供内置网页客户端，容器部署时无需安装桌面客户端即可在浏览器中使用，返回实际挂载路径
+func RegisterWebClient(router gin.IRouter, config WebClientConfig) string {
+	path := "/" + strings.Trim(config.Path, "/")
+	if path == "/" {
+		path = DefaultWebClientPath
+	}
+
+	files, err := fs.Sub(webClientFiles, "webclient")
+	if err != nil {
+		panic(err) // 嵌入的目录固定存在
+	}
+	fileServer := http.StripPrefix(path, http.FileServer(http.FS(files)))
+
+	// 不带结尾斜杠时跳转，保证页面中的相对路径正确
+	router.GET(path, func(c *gin.Context) {
+		c.Redirect(http.StatusMovedPermanently, path+"/")
+	})
+	router.GET(path+"/*filepath", func(c *gin.Context) {
+		// 页面更新随服务端版本发布，避免浏览器使用旧版本脚本
+		c.Header("Cache-Control", "no-cache")
+		fileServer.ServeHTTP(c.Writer, c.Request)
+	})
+	return path
+}
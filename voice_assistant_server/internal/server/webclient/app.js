@@ -0,0 +1,528 @@
+// 内置网页客户端：通过同源的 /ws 使用与桌面客户端相同的WebSocket协议。
+// 麦克风音频降采样为16kHz 16bit PCM后以base64发送，TTS语音按 audio_format 播放。
+(function () {
+  "use strict";
+
+  var SAMPLE_RATE = 16000;
+  var CHUNK_SAMPLES = SAMPLE_RATE / 10; // 每100毫秒发送一块
+  var VAD_THRESHOLD = 0.015;            // 连续对话模式的语音能量阈值（RMS）
+  var VAD_SILENCE_MS = 800;             // 说话后静音多久视为说完
+  var PREROLL_CHUNKS = 3;               // 检测到说话前保留的音频块（避免吞掉句首）
+
+  var el = function (id) { return document.getElementById(id); };
+  var ui = {
+    state: el("state"),
+    conversation: el("conversation"),
+    partial: el("partial"),
+    talk: el("talk"),
+    toggle: el("toggle"),
+    interrupt: el("interrupt"),
+    apiKey: el("api-key"),
+    mode: el("mode"),
+    connect: el("connect"),
+    error: el("error")
+  };
+
+  var sessionId = localStorage.getItem("va_session_id") || newSessionId();
+  localStorage.setItem("va_session_id", sessionId);
+  ui.apiKey.value = localStorage.getItem("va_api_key") || "";
+  ui.mode.value = localStorage.getItem("va_mode") || "push_to_talk";
+
+  var ws = null;
+  var reconnectTimer = null;
+  var chunkId = 0;
+
+  // 录音状态
+  var audioCtx = null;
+  var micStream = null;
+  var pending = [];        // 尚未凑满一块的16kHz采样
+  var recording = false;   // 按住说话中
+  var listening = false;   // 连续对话已开启
+  var inSpeech = false;
+  var lastVoiceAt = 0;
+  var preroll = [];
+
+  // 播放状态
+  var playhead = 0;
+  var sources = [];
+  var speechParts = [];    // 等待拼接解码的WAV/MP3分片
+  var decoding = 0;        // 解码中的WAV/MP3语音数
+  var playbackPending = false;
+  var speechComplete = false; // 本轮最后一句的最后一片已收到
+
+  function newSessionId() {
+    if (window.crypto && crypto.randomUUID) {
+      return "web-" + crypto.randomUUID();
+    }
+    return "web-" + Date.now().toString(36) + Math.random().toString(36).slice(2);
+  }
+
+  // ---------- 连接 ----------
+
+  function connect() {
+    clearTimeout(reconnectTimer);
+    if (ws) {
+      ws.onclose = null;
+      ws.close();
+    }
+    var url = new URL("/ws", location.href);
+    url.protocol = location.protocol === "https:" ? "wss:" : "ws:";
+    url.searchParams.set("session_id", sessionId);
+    if (ui.apiKey.value) {
+      url.searchParams.set("api_key", ui.apiKey.value);
+    }
+
+    setState("connecting", "连接中");
+    ws = new WebSocket(url);
+    ws.onopen = function () {
+      showError("");
+      setControlsEnabled(true);
+      sendCommand("start_session", ui.mode.value);
+    };
+    ws.onmessage = function (event) {
+      try {
+        handleMessage(JSON.parse(event.data));
+      } catch (err) {
+        console.error("处理消息失败", err);
+      }
+    };
+    ws.onclose = function (event) {
+      setControlsEnabled(false);
+      stopListening();
+      setState("disconnected", "已断开");
+      // 会话被管理员终止（4001）时不再重连
+      if (event.code !== 4001) {
+        reconnectTimer = setTimeout(connect, 3000);
+      }
+    };
+  }
+
+  function send(type, data) {
+    if (!ws || ws.readyState !== WebSocket.OPEN) {
+      return;
+    }
+    ws.send(JSON.stringify({ type: type, session_id: sessionId, timestamp: Date.now(), data: data }));
+  }
+
+  function sendCommand(command, mode) {
+    send("command", { command: command, mode: mode || "", parameters: {} });
+  }
+
+  function sendStatus(state) {
+    send("status", { state: state });
+  }
+
+  function sendAudio(samples, isFinal) {
+    send("audio_stream", {
+      format: "pcm_16khz_16bit",
+      chunk_id: chunkId++,
+      is_final: isFinal,
+      audio_data: encodePCM(samples)
+    });
+  }
+
+  // ---------- 服务端消息 ----------
+
+  function handleMessage(msg) {
+    var data = msg.data || {};
+    switch (msg.type) {
+      case "response":
+        handleResponse(data);
+        break;
+      case "status":
+        if (data.state === "closing") {
+          sendStatus("close_ack");
+        }
+        setState(data.state, stateText(data.state));
+        break;
+      case "error":
+        showError(data.message || data.code);
+        break;
+      case "notification":
+        if (data.message) {
+          addMessage("assistant", data.message);
+        }
+        break;
+    }
+  }
+
+  function handleResponse(data) {
+    switch (data.stage) {
+      case "asr":
+        if (data.is_final) {
+          ui.partial.textContent = "";
+          if (data.content) {
+            addMessage("user", data.content);
+          }
+        } else {
+          ui.partial.textContent = data.content || "";
+        }
+        break;
+      case "llm":
+        if (data.content) {
+          addMessage("assistant", data.content);
+        }
+        break;
+      case "tts":
+        if (data.audio_data) {
+          handleSpeech(data);
+        }
+        break;
+    }
+  }
+
+  // ---------- 播放 ----------
+
+  function handleSpeech(data) {
+    ensureAudioContext();
+    playbackPending = true;
+    var format = data.audio_format || { format: "pcm", sample_rate: SAMPLE_RATE };
+    var bytes = decodeBase64(data.audio_data);
+    var lastChunk = !data.total_chunks || data.chunk_index >= data.total_chunks - 1;
+    // 分句合成时最后一句的最后一片才算收齐
+    speechComplete = data.is_final || (lastChunk && (data.segment || 0) >= (data.total_segments || 0) - 1);
+
+    if (format.format === "pcm") {
+      playPCM(bytes, format.sample_rate || SAMPLE_RATE, format.channels || 1);
+    } else {
+      // WAV/MP3分片不能单独解码，收齐一句后拼接解码
+      speechParts.push(bytes);
+      if (lastChunk) {
+        var joined = concatBytes(speechParts);
+        speechParts = [];
+        decoding++;
+        audioCtx.decodeAudioData(joined.buffer).then(function (buffer) {
+          decoding--;
+          schedule(buffer);
+        }, function (err) {
+          decoding--;
+          showError("语音解码失败: " + err);
+        });
+      }
+    }
+  }
+
+  function playPCM(bytes, sampleRate, channels) {
+    var view = new DataView(bytes.buffer, bytes.byteOffset, bytes.byteLength);
+    var frames = Math.floor(bytes.byteLength / 2 / channels);
+    if (frames === 0) {
+      return;
+    }
+    var buffer = audioCtx.createBuffer(channels, frames, sampleRate);
+    for (var ch = 0; ch < channels; ch++) {
+      var out = buffer.getChannelData(ch);
+      for (var i = 0; i < frames; i++) {
+        out[i] = view.getInt16((i * channels + ch) * 2, true) / 32768;
+      }
+    }
+    schedule(buffer);
+  }
+
+  function schedule(buffer) {
+    var source = audioCtx.createBufferSource();
+    source.buffer = buffer;
+    source.connect(audioCtx.destination);
+    playhead = Math.max(playhead, audioCtx.currentTime);
+    source.start(playhead);
+    playhead += buffer.duration;
+    sources.push(source);
+    source.onended = function () {
+      sources.splice(sources.indexOf(source), 1);
+      // 所有已排队的语音播完后通知服务端（连续对话模式下服务端据此恢复聆听）
+      if (sources.length === 0 && speechParts.length === 0 && decoding === 0 && playbackPending && speechComplete) {
+        playbackPending = false;
+        sendStatus("playback_finished");
+      }
+    };
+  }
+
+  function stopPlayback() {
+    sources.forEach(function (source) {
+      source.onended = null;
+      source.stop();
+    });
+    sources = [];
+    speechParts = [];
+    playhead = 0;
+    playbackPending = false;
+    speechComplete = false;
+  }
+
+  function isPlaying() {
+    return sources.length > 0;
+  }
+
+  // ---------- 录音 ----------
+
+  function ensureAudioContext() {
+    if (!audioCtx) {
+      audioCtx = new (window.AudioContext || window.webkitAudioContext)();
+    }
+    if (audioCtx.state === "suspended") {
+      audioCtx.resume();
+    }
+  }
+
+  function ensureMicrophone() {
+    ensureAudioContext();
+    if (micStream) {
+      return Promise.resolve();
+    }
+    if (!navigator.mediaDevices || !navigator.mediaDevices.getUserMedia) {
+      return Promise.reject(new Error("浏览器不支持录音（需通过HTTPS或localhost访问）"));
+    }
+    return navigator.mediaDevices.getUserMedia({
+      audio: { channelCount: 1, echoCancellation: true, noiseSuppression: true }
+    }).then(function (stream) {
+      micStream = stream;
+      var input = audioCtx.createMediaStreamSource(stream);
+      var processor = audioCtx.createScriptProcessor(4096, 1, 1);
+      processor.onaudioprocess = function (event) {
+        onSamples(downsample(event.inputBuffer.getChannelData(0), audioCtx.sampleRate));
+      };
+      input.connect(processor);
+      processor.connect(audioCtx.destination);
+    });
+  }
+
+  function onSamples(samples) {
+    if (recording) {
+      pushSamples(samples, sendAudio);
+      return;
+    }
+    if (!listening || isPlaying()) {
+      return;
+    }
+
+    // 连续对话：按能量检测说话的开始和结束
+    var now = Date.now();
+    if (rms(samples) > VAD_THRESHOLD) {
+      lastVoiceAt = now;
+      if (!inSpeech) {
+        inSpeech = true;
+        sendStatus("speech_start");
+        preroll.forEach(function (chunk) { sendAudio(chunk, false); });
+        preroll = [];
+      }
+    }
+    if (inSpeech) {
+      pushSamples(samples, sendAudio);
+      if (now - lastVoiceAt > VAD_SILENCE_MS) {
+        inSpeech = false;
+        flushAudio();
+        sendStatus("speech_end");
+      }
+    } else {
+      pushSamples(samples, function (chunk) {
+        preroll.push(chunk);
+        if (preroll.length > PREROLL_CHUNKS) {
+          preroll.shift();
+        }
+      });
+    }
+  }
+
+  // pushSamples 凑满一块后交给 emit
+  function pushSamples(samples, emit) {
+    for (var i = 0; i < samples.length; i++) {
+      pending.push(samples[i]);
+    }
+    while (pending.length >= CHUNK_SAMPLES) {
+      emit(pending.splice(0, CHUNK_SAMPLES), false);
+    }
+  }
+
+  // flushAudio 发送剩余采样并标记本句结束
+  function flushAudio() {
+    sendAudio(pending, true);
+    pending = [];
+  }
+
+  function startTalking() {
+    ensureMicrophone().then(function () {
+      if (isPlaying()) {
+        interrupt();
+      }
+      pending = [];
+      recording = true;
+      ui.talk.classList.add("active");
+      ui.talk.textContent = "松开发送";
+    }, function (err) {
+      showError("无法使用麦克风: " + err.message);
+    });
+  }
+
+  function stopTalking() {
+    if (!recording) {
+      return;
+    }
+    recording = false;
+    ui.talk.classList.remove("active");
+    ui.talk.textContent = "按住说话";
+    flushAudio();
+  }
+
+  function startListening() {
+    ensureMicrophone().then(function () {
+      pending = [];
+      preroll = [];
+      inSpeech = false;
+      listening = true;
+      ui.toggle.classList.add("active");
+      ui.toggle.textContent = "停止连续对话";
+    }, function (err) {
+      showError("无法使用麦克风: " + err.message);
+    });
+  }
+
+  function stopListening() {
+    if (inSpeech) {
+      inSpeech = false;
+      flushAudio();
+      sendStatus("speech_end");
+    }
+    listening = false;
+    ui.toggle.classList.remove("active");
+    ui.toggle.textContent = "开始连续对话";
+  }
+
+  function interrupt() {
+    stopPlayback();
+    sendCommand("interrupt");
+  }
+
+  // ---------- 编解码 ----------
+
+  function downsample(input, rate) {
+    if (rate === SAMPLE_RATE) {
+      return Array.prototype.slice.call(input);
+    }
+    var ratio = rate / SAMPLE_RATE;
+    var length = Math.floor(input.length / ratio);
+    var out = new Array(length);
+    for (var i = 0; i < length; i++) {
+      var start = Math.floor(i * ratio);
+      var end = Math.min(Math.floor((i + 1) * ratio), input.length);
+      var sum = 0;
+      for (var j = start; j < end; j++) {
+        sum += input[j];
+      }
+      out[i] = end > start ? sum / (end - start) : 0;
+    }
+    return out;
+  }
+
+  function rms(samples) {
+    var sum = 0;
+    for (var i = 0; i < samples.length; i++) {
+      sum += samples[i] * samples[i];
+    }
+    return samples.length ? Math.sqrt(sum / samples.length) : 0;
+  }
+
+  function encodePCM(samples) {
+    var bytes = new Uint8Array(samples.length * 2);
+    var view = new DataView(bytes.buffer);
+    for (var i = 0; i < samples.length; i++) {
+      var s = Math.max(-1, Math.min(1, samples[i]));
+      view.setInt16(i * 2, s < 0 ? s * 0x8000 : s * 0x7fff, true);
+    }
+    var binary = "";
+    for (var k = 0; k < bytes.length; k += 0x8000) {
+      binary += String.fromCharCode.apply(null, bytes.subarray(k, k + 0x8000));
+    }
+    return btoa(binary);
+  }
+
+  function decodeBase64(text) {
+    var binary = atob(text);
+    var bytes = new Uint8Array(binary.length);
+    for (var i = 0; i < binary.length; i++) {
+      bytes[i] = binary.charCodeAt(i);
+    }
+    return bytes;
+  }
+
+  function concatBytes(parts) {
+    var total = parts.reduce(function (n, part) { return n + part.length; }, 0);
+    var out = new Uint8Array(total);
+    var offset = 0;
+    parts.forEach(function (part) {
+      out.set(part, offset);
+      offset += part.length;
+    });
+    return out;
+  }
+
+  // ---------- 界面 ----------
+
+  function addMessage(role, text) {
+    var node = document.createElement("div");
+    node.className = "message " + role;
+    node.textContent = text;
+    ui.conversation.appendChild(node);
+    ui.conversation.parentNode.scrollTop = ui.conversation.parentNode.scrollHeight;
+  }
+
+  var STATE_TEXT = {
+    idle: "空闲",
+    listening: "聆听中",
+    processing: "思考中",
+    speaking: "回复中",
+    connected: "已连接",
+    error: "出错",
+    closing: "服务端关闭连接"
+  };
+
+  function stateText(state) {
+    return STATE_TEXT[state] || state;
+  }
+
+  function setState(state, text) {
+    ui.state.className = "state " + state;
+    ui.state.textContent = text;
+  }
+
+  function showError(text) {
+    ui.error.textContent = text || "";
+  }
+
+  function setControlsEnabled(enabled) {
+    var continuous = ui.mode.value === "continuous";
+    ui.talk.disabled = !enabled || continuous;
+    ui.toggle.disabled = !enabled || !continuous;
+    ui.interrupt.disabled = !enabled;
+  }
+
+  ui.talk.addEventListener("pointerdown", function (event) {
+    event.preventDefault();
+    ui.talk.setPointerCapture(event.pointerId);
+    startTalking();
+  });
+  ui.talk.addEventListener("pointerup", stopTalking);
+  ui.talk.addEventListener("pointercancel", stopTalking);
+
+  ui.toggle.addEventListener("click", function () {
+    if (listening) {
+      stopListening();
+    } else {
+      startListening();
+    }
+  });
+
+  ui.interrupt.addEventListener("click", interrupt);
+
+  ui.mode.addEventListener("change", function () {
+    localStorage.setItem("va_mode", ui.mode.value);
+    stopListening();
+    sendCommand("set_mode", ui.mode.value);
+    setControlsEnabled(ws && ws.readyState === WebSocket.OPEN);
+  });
+
+  ui.connect.addEventListener("click", function () {
+    localStorage.setItem("va_api_key", ui.apiKey.value);
+    connect();
+  });
+
+  connect();
+})();
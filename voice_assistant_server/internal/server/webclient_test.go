@@ -0,0 +1,41 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterWebClient(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/status", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	assert.Equal(t, "/app", RegisterWebClient(router, WebClientConfig{Enabled: true, Path: "/app/"}))
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	// 不带结尾斜杠时跳转到页面目录
+	w := get("/app")
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "/app/", w.Header().Get("Location"))
+
+	w = get("/app/")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `<script src="app.js">`)
+	assert.Equal(t, "no-cache", w.Header().Get("Cache-Control"))
+
+	w = get("/app/app.js")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "pcm_16khz_16bit")
+
+	assert.Equal(t, http.StatusNotFound, get("/app/missing.js").Code)
+	assert.Equal(t, http.StatusNoContent, get("/api/status").Code)
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"log"
+
+	"voice_assistant/voice_assistant_server/internal/asr"
+	"voice_assistant/voice_assistant_server/internal/dataset"
+	"voice_assistant/voice_assistant_server/internal/llm"
+	"voice_assistant/voice_assistant_server/internal/webhook"
+)
+
+// TurnListener 对话完成监听
+// consented 表示会话明确同意数据使用；明确拒绝的会话不会通知。
+type TurnListener func(turn webhook.Turn, consented bool)
+
+// AddTurnListener 注册对话完成监听（在Initialize之前注册；监听在处理协程中同步调用，不应阻塞）
+func (p *MessageProcessor) AddTurnListener(listener TurnListener) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.turnListeners = append(p.turnListeners, listener)
+}
+
+// notifyTurn 一轮对话完成后推送 turn.completed 事件并通知监听（遵循会话授权状态）
+// transcript 为交给LLM的用户输入（内容审核脱敏后的识别文本）。
+func (p *MessageProcessor) notifyTurn(session *Session, asrResult asr.ASRResult, transcript string, response llm.LLMResponse, moderated bool) {
+	p.mu.RLock()
+	listeners := p.turnListeners
+	p.mu.RUnlock()
+	if p.webhooks == nil && len(listeners) == 0 {
+		return
+	}
+
+	session.mu.RLock()
+	consent := session.DataConsent
+	turn := webhook.Turn{
+		SessionID: session.ID,
+		UserID:    session.UserID,
+		TenantID:  session.Tenant,
+		Language:  session.Language,
+		Persona:   session.Persona,
+	}
+	session.mu.RUnlock()
+
+	// 明确拒绝的会话不推送
+	if consent == dataset.ConsentDenied {
+		return
+	}
+
+	if asrResult.Speaker != nil {
+		turn.Speaker = asrResult.Speaker.Name
+	}
+	turn.Transcript, turn.Confidence = transcript, asrResult.Confidence
+	turn.Reply, turn.Model, turn.Tokens = response.Content, response.Model, response.TokenUsage.TotalTokens
+	turn.Moderated = moderated
+
+	consented := consent == dataset.ConsentGranted
+	for _, listener := range listeners {
+		listener(turn, consented)
+	}
+
+	// 要求授权时仅推送明确同意的会话
+	if p.webhooks == nil || (p.config.Webhook.RequireConsent && !consented) {
+		return
+	}
+	if err := p.webhooks.Send(webhook.EventTurnCompleted, turn); err != nil {
+		log.Printf("%v", err)
+	}
+}
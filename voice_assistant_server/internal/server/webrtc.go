@@ -0,0 +1,535 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	vaaudio "voice_assistant/pkg/audio"
+	"voice_assistant/pkg/protocol"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pion/interceptor"
+	"github.com/pion/webrtc/v4"
+	"github.com/pion/webrtc/v4/pkg/media"
+)
+
+// WebRTC音频参数
+const (
+	webrtcFrameDuration  = 20 * time.Millisecond // 下行音频帧时长
+	webrtcUploadChunk    = 3200                  // 上行音频块大小（16kHz 16bit 100毫秒）
+	webrtcGatherTimeout  = 10 * time.Second      // ICE候选收集超时
+	webrtcPlaybackQueue  = 16                    // 待播放语音片段队列长度
+	defaultJitterPackets = 5
+)
+
+// WebRTCConfig WebRTC网关配置
+type WebRTCConfig struct {
+	Enabled        bool     `yaml:"enabled"`
+	ICEServers     []string `yaml:"ice_servers"`     // STUN/TURN服务器地址
+	UDPPortMin     uint16   `yaml:"udp_port_min"`    // 媒体UDP端口范围下限（容器部署时需映射）
+	UDPPortMax     uint16   `yaml:"udp_port_max"`    // 媒体UDP端口范围上限
+	JitterPackets  int      `yaml:"jitter_packets"`  // 抖动缓冲深度（包数）
+	MaxConnections int      `yaml:"max_connections"` // 最大连接数
+}
+
+// WebRTCGateway 浏览器WebRTC网关：麦克风音频走RTP音轨，命令和文本响应走数据通道，TTS语音经音轨下发
+// 数据通道上收发的消息与WebSocket协议相同（音频除外），会话仍由消息处理器统一管理。
+type WebRTCGateway struct {
+	config    WebRTCConfig
+	processor *MessageProcessor
+	api       *webrtc.API
+
+	peers map[string]*webrtcPeer
+	mu    sync.Mutex
+}
+
+// webrtcOffer 信令请求
+type webrtcOffer struct {
+	SDP       string `json:"sdp"`
+	Type      string `json:"type"`
+	SessionID string `json:"session_id"`
+}
+
+// webrtcAnswer 信令应答
+type webrtcAnswer struct {
+	SDP       string `json:"sdp"`
+	Type      string `json:"type"`
+	SessionID string `json:"session_id"`
+}
+
+// NewWebRTCGateway 创建WebRTC网关
+func NewWebRTCGateway(config WebRTCConfig, processor *MessageProcessor) (*WebRTCGateway, error) {
+	if config.JitterPackets <= 0 {
+		config.JitterPackets = defaultJitterPackets
+	}
+
+	mediaEngine := &webrtc.MediaEngine{}
+	for _, codec := range webrtcCodecs {
+		if err := mediaEngine.RegisterCodec(webrtc.RTPCodecParameters{
+			RTPCodecCapability: codec.Capability,
+			PayloadType:        codec.PayloadType,
+		}, webrtc.RTPCodecTypeAudio); err != nil {
+			return nil, fmt.Errorf("注册音频编码失败: %w", err)
+		}
+	}
+
+	registry := &interceptor.Registry{}
+	if err := webrtc.RegisterDefaultInterceptors(mediaEngine, registry); err != nil {
+		return nil, fmt.Errorf("注册RTP拦截器失败: %w", err)
+	}
+
+	settings := webrtc.SettingEngine{}
+	if config.UDPPortMin > 0 && config.UDPPortMax > 0 {
+		if err := settings.SetEphemeralUDPPortRange(config.UDPPortMin, config.UDPPortMax); err != nil {
+			return nil, fmt.Errorf("设置UDP端口范围失败: %w", err)
+		}
+	}
+
+	return &WebRTCGateway{
+		config:    config,
+		processor: processor,
+		api: webrtc.NewAPI(
+			webrtc.WithMediaEngine(mediaEngine),
+			webrtc.WithInterceptorRegistry(registry),
+			webrtc.WithSettingEngine(settings),
+		),
+		peers: make(map[string]*webrtcPeer),
+	}, nil
+}
+
+// HandleOffer 信令端点：接收浏览器的SDP offer，返回包含全部ICE候选的answer
+func (g *WebRTCGateway) HandleOffer(c *gin.Context) {
+	var req webrtcOffer
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.SessionID == "" {
+		req.SessionID = "webrtc_" + randomHex(16)
+	}
+
+	ctx := withRequestAPIKey(c.Request.Context(), requestAPIKey(c.Request))
+	answer, err := g.Connect(ctx, req.SessionID, webrtc.SessionDescription{
+		Type: webrtc.SDPTypeOffer,
+		SDP:  req.SDP,
+	})
+	if err != nil {
+		log.Printf("WebRTC连接失败: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, webrtcAnswer{
+		SDP:       answer.SDP,
+		Type:      answer.Type.String(),
+		SessionID: req.SessionID,
+	})
+}
+
+// Connect 为会话创建对等连接并完成协商
+func (g *WebRTCGateway) Connect(ctx context.Context, sessionID string, offer webrtc.SessionDescription) (*webrtc.SessionDescription, error) {
+	codec := webrtcCodecs[0]
+	encoder, err := codec.New()
+	if err != nil {
+		return nil, fmt.Errorf("创建音频编码器失败: %w", err)
+	}
+
+	g.mu.Lock()
+	if _, exists := g.peers[sessionID]; exists {
+		g.mu.Unlock()
+		return nil, fmt.Errorf("会话已存在: %s", sessionID)
+	}
+	if g.config.MaxConnections > 0 && len(g.peers) >= g.config.MaxConnections {
+		g.mu.Unlock()
+		return nil, errors.New("连接数已达上限")
+	}
+	// 会话ID已被其他连接（如WebSocket）使用时拒绝，避免接入他人的会话
+	if !g.processor.createSession(sessionID, requestAPIKeyFromContext(ctx)) {
+		g.mu.Unlock()
+		return nil, fmt.Errorf("会话已存在: %s", sessionID)
+	}
+	peer := &webrtcPeer{
+		gateway: g,
+		client: &Client{
+			ID:       sessionID,
+			SendChan: make(chan *protocol.Message, 100),
+			APIKey:   requestAPIKeyFromContext(ctx),
+		},
+		encoder:  encoder,
+		playback: make(chan []int16, webrtcPlaybackQueue),
+		done:     make(chan struct{}),
+	}
+	g.peers[sessionID] = peer
+	g.mu.Unlock()
+
+	answer, err := peer.negotiate(ctx, codec, offer)
+	if err != nil {
+		peer.close()
+		return nil, err
+	}
+
+	log.Printf("WebRTC客户端连接: %s, 下行编码: %s", sessionID, codec.Capability.MimeType)
+	return answer, nil
+}
+
+// GetPeerCount 获取当前WebRTC连接数
+func (g *WebRTCGateway) GetPeerCount() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return len(g.peers)
+}
+
+// iceServers 转换ICE服务器配置
+func (g *WebRTCGateway) iceServers() []webrtc.ICEServer {
+	if len(g.config.ICEServers) == 0 {
+		return nil
+	}
+	return []webrtc.ICEServer{{URLs: g.config.ICEServers}}
+}
+
+// webrtcPeer 单个浏览器连接
+type webrtcPeer struct {
+	gateway *WebRTCGateway
+	client  *Client
+	pc      *webrtc.PeerConnection
+	track   *webrtc.TrackLocalStaticSample
+	encoder audioCodec
+
+	dataChannel *webrtc.DataChannel
+	playback    chan []int16
+
+	// 上行音频：累积到一定长度后交给处理器
+	upload  []byte
+	chunkID int
+	mu      sync.Mutex
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// negotiate 创建对等连接、添加下行音轨并生成answer
+func (p *webrtcPeer) negotiate(ctx context.Context, codec webrtcCodec, offer webrtc.SessionDescription) (*webrtc.SessionDescription, error) {
+	pc, err := p.gateway.api.NewPeerConnection(webrtc.Configuration{ICEServers: p.gateway.iceServers()})
+	if err != nil {
+		return nil, fmt.Errorf("创建对等连接失败: %w", err)
+	}
+	p.pc = pc
+
+	track, err := webrtc.NewTrackLocalStaticSample(codec.Capability, "audio", "voice_assistant")
+	if err != nil {
+		return nil, fmt.Errorf("创建音轨失败: %w", err)
+	}
+	sender, err := pc.AddTrack(track)
+	if err != nil {
+		return nil, fmt.Errorf("添加音轨失败: %w", err)
+	}
+	p.track = track
+
+	// 读取RTCP，驱动拦截器（NACK、接收报告等）
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			if _, _, err := sender.Read(buf); err != nil {
+				return
+			}
+		}
+	}()
+
+	pc.OnTrack(p.receiveAudio)
+	pc.OnDataChannel(p.attachDataChannel)
+	pc.OnConnectionStateChange(func(state webrtc.PeerConnectionState) {
+		log.Printf("WebRTC连接状态: %s, %s", p.client.ID, state)
+		if state == webrtc.PeerConnectionStateFailed || state == webrtc.PeerConnectionStateClosed {
+			p.close()
+		}
+	})
+
+	if err := pc.SetRemoteDescription(offer); err != nil {
+		return nil, fmt.Errorf("设置远端描述失败: %w", err)
+	}
+	answer, err := pc.CreateAnswer(nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建answer失败: %w", err)
+	}
+
+	// 不使用增量ICE：等待候选收集完成后一次性返回
+	gatherComplete := webrtc.GatheringCompletePromise(pc)
+	if err := pc.SetLocalDescription(answer); err != nil {
+		return nil, fmt.Errorf("设置本地描述失败: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, webrtcGatherTimeout)
+	defer cancel()
+	select {
+	case <-gatherComplete:
+	case <-ctx.Done():
+		return nil, errors.New("ICE候选收集超时")
+	}
+
+	go p.playbackLoop()
+	return pc.LocalDescription(), nil
+}
+
+// attachDataChannel 绑定浏览器创建的数据通道
+func (p *webrtcPeer) attachDataChannel(dc *webrtc.DataChannel) {
+	p.mu.Lock()
+	if p.dataChannel != nil {
+		p.mu.Unlock()
+		log.Printf("忽略多余的数据通道: %s, %s", p.client.ID, dc.Label())
+		return
+	}
+	p.dataChannel = dc
+	p.mu.Unlock()
+
+	dc.OnOpen(func() {
+		// 数据通道就绪后再开始下发消息，之前的输出在发送队列中等待
+		p.client.SendMessage(protocol.NewStatusMessage(p.client.ID, protocol.StateConnected, "idle", 0))
+		go p.sendLoop(dc)
+	})
+	dc.OnMessage(func(raw webrtc.DataChannelMessage) {
+		p.handleDataMessage(raw.Data, time.Now())
+	})
+	dc.OnClose(p.close)
+}
+
+// handleDataMessage 处理数据通道上的协议消息
+func (p *webrtcPeer) handleDataMessage(data []byte, receivedAt time.Time) {
+	msg, err := protocol.Decode(data, protocol.DefaultLimits())
+	if err != nil {
+		log.Printf("解析消息失败: %v", err)
+		return
+	}
+	if msg.SessionID == "" {
+		msg.SessionID = p.client.ID
+	}
+
+	switch msg.Type {
+	case protocol.TimeSync:
+		// 时钟同步在传输层直接应答，与WebSocket一致
+		syncData, err := msg.TimeSyncData()
+		if err != nil {
+			log.Printf("解析时钟同步数据失败: %v", err)
+			return
+		}
+		syncData.ServerReceiveTime = receivedAt.UnixMilli()
+		p.client.SendMessage(protocol.NewMessage(protocol.TimeSync, p.client.ID, syncData))
+		return
+	case protocol.AudioStream:
+		// 音频走音轨，数据通道上的 audio_stream 仅用于标记一句话结束
+		audioData, err := msg.AudioStreamData()
+		if err != nil {
+			log.Printf("解析音频数据失败: %v", err)
+			return
+		}
+		if audioData.IsFinal {
+			p.flushUpload(true)
+		}
+		return
+	}
+
+	if err := p.gateway.processor.ProcessMessage(p.client, msg); err != nil {
+		log.Printf("处理消息失败: %v", err)
+		p.client.SendMessage(protocol.NewErrorMessage(p.client.ID, "PROCESSING_ERROR", err.Error(), true))
+	}
+}
+
+// receiveAudio 读取浏览器麦克风音轨，经抖动缓冲解码后转为16kHz PCM交给处理器
+func (p *webrtcPeer) receiveAudio(track *webrtc.TrackRemote, _ *webrtc.RTPReceiver) {
+	codec, exists := findWebRTCCodec(track.Codec().MimeType)
+	if !exists {
+		log.Printf("不支持的上行音频编码: %s", track.Codec().MimeType)
+		return
+	}
+	decoder, err := codec.New()
+	if err != nil {
+		log.Printf("创建音频解码器失败: %v", err)
+		return
+	}
+
+	jitter := newJitterBuffer(p.gateway.config.JitterPackets)
+	for {
+		packet, _, err := track.ReadRTP()
+		if err != nil {
+			return
+		}
+
+		for _, ready := range jitter.Push(packet) {
+			samples, err := decoder.Decode(ready.Payload)
+			if err != nil {
+				log.Printf("音频解码失败: %v", err)
+				continue
+			}
+			p.appendUpload(vaaudio.Int16ToBytes(vaaudio.ResampleInt16(samples, decoder.SampleRate(), 16000)))
+		}
+	}
+}
+
+// appendUpload 累积上行音频（会话未处于聆听状态时丢弃）
+func (p *webrtcPeer) appendUpload(pcm []byte) {
+	if !p.gateway.processor.isSessionListening(p.client.ID) {
+		p.mu.Lock()
+		p.upload = p.upload[:0]
+		p.mu.Unlock()
+		return
+	}
+
+	p.mu.Lock()
+	p.upload = append(p.upload, pcm...)
+	full := len(p.upload) >= webrtcUploadChunk
+	p.mu.Unlock()
+
+	if full {
+		p.flushUpload(false)
+	}
+}
+
+// flushUpload 将累积的上行音频作为音频块交给处理器
+func (p *webrtcPeer) flushUpload(isFinal bool) {
+	p.mu.Lock()
+	pcm := make([]byte, len(p.upload))
+	copy(pcm, p.upload)
+	p.upload = p.upload[:0]
+	p.chunkID++
+	chunkID := p.chunkID
+	p.mu.Unlock()
+
+	if len(pcm) == 0 && !isFinal {
+		return
+	}
+
+	msg := protocol.NewAudioStreamMessage(p.client.ID, "pcm_16khz_16bit", chunkID, isFinal, pcm)
+	if err := p.gateway.processor.ProcessMessage(p.client, msg); err != nil {
+		log.Printf("处理音频失败: %v", err)
+	}
+}
+
+// sendLoop 下发处理器输出：TTS语音送入音轨，其余消息经数据通道发送
+func (p *webrtcPeer) sendLoop(dc *webrtc.DataChannel) {
+	for {
+		select {
+		case <-p.done:
+			return
+		case msg := <-p.client.SendChan:
+			if syncData, ok := msg.Payload.(*protocol.TimeSyncData); ok {
+				syncData.ServerSendTime = time.Now().UnixMilli()
+			}
+			if err := p.send(dc, p.routeAudio(msg)); err != nil {
+				log.Printf("发送消息失败: %v", err)
+				return
+			}
+		}
+	}
+}
+
+// routeAudio 将TTS响应中的语音转入音轨播放，返回去掉音频后的消息
+// 无法解码为PCM的音频（如MP3）保留在消息中经数据通道下发，由浏览器自行播放。
+func (p *webrtcPeer) routeAudio(msg *protocol.Message) *protocol.Message {
+	data, ok := msg.Payload.(*protocol.ResponseData)
+	if !ok || data.Stage != protocol.StageTTS || len(data.AudioData) == 0 {
+		return msg
+	}
+
+	samples, sampleRate, ok := decodeSpeech(data.AudioData)
+	if !ok {
+		return msg
+	}
+
+	select {
+	case p.playback <- vaaudio.ResampleInt16(samples, sampleRate, p.encoder.SampleRate()):
+	case <-p.done:
+		return msg
+	}
+
+	routed := *data
+	routed.AudioData = nil
+	routed.Metadata = make(map[string]interface{}, len(data.Metadata)+1)
+	for k, v := range data.Metadata {
+		routed.Metadata[k] = v
+	}
+	routed.Metadata["audio_track"] = true
+	return protocol.NewMessage(msg.Type, msg.SessionID, &routed)
+}
+
+// send 经数据通道发送消息
+func (p *webrtcPeer) send(dc *webrtc.DataChannel, msg *protocol.Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		log.Printf("序列化消息失败: %v", err)
+		return nil
+	}
+	return dc.SendText(string(data))
+}
+
+// playbackLoop 按帧时长节拍编码并写出下行语音
+func (p *webrtcPeer) playbackLoop() {
+	ticker := time.NewTicker(webrtcFrameDuration)
+	defer ticker.Stop()
+
+	frameSamples := p.encoder.SampleRate() * int(webrtcFrameDuration/time.Millisecond) / 1000
+	var pending []int16
+	for {
+		select {
+		case <-p.done:
+			return
+		case samples := <-p.playback:
+			pending = append(pending, samples...)
+		case <-ticker.C:
+			if len(pending) == 0 {
+				continue
+			}
+
+			frame := make([]int16, frameSamples)
+			n := copy(frame, pending)
+			pending = pending[n:]
+
+			payload, err := p.encoder.Encode(frame)
+			if err != nil {
+				log.Printf("音频编码失败: %v", err)
+				continue
+			}
+			if err := p.track.WriteSample(media.Sample{Data: payload, Duration: webrtcFrameDuration}); err != nil {
+				log.Printf("写入音轨失败: %v", err)
+			}
+		}
+	}
+}
+
+// close 关闭连接并释放会话名额
+func (p *webrtcPeer) close() {
+	p.closeOnce.Do(func() {
+		close(p.done)
+
+		g := p.gateway
+		g.mu.Lock()
+		delete(g.peers, p.client.ID)
+		g.mu.Unlock()
+		g.processor.ReleaseSession(p.client.ID)
+
+		if p.pc != nil {
+			p.pc.Close()
+		}
+		log.Printf("WebRTC客户端断开: %s", p.client.ID)
+	})
+}
+
+// decodeSpeech 将TTS输出转为单声道采样（支持WAV和16kHz 16bit裸PCM）
+func decodeSpeech(audio []byte) ([]int16, int, bool) {
+	if vaaudio.IsWAV(audio) {
+		wav, err := vaaudio.ParseWAV(audio)
+		if err != nil || !wav.PCM16() {
+			return nil, 0, false
+		}
+		return vaaudio.BytesToInt16(wav.Data, wav.Channels), wav.SampleRate, true
+	}
+
+	// MP3无法在服务端解码
+	if vaaudio.IsMP3(audio) {
+		return nil, 0, false
+	}
+	return vaaudio.BytesToInt16(audio, 1), 16000, true
+}
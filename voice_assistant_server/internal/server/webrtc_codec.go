@@ -0,0 +1,136 @@
+package server
+
+import (
+	"strings"
+
+	vaaudio "voice_assistant/pkg/audio"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v4"
+)
+
+// audioCodec WebRTC音频编解码器（实例持有编解码状态，每个连接独立创建）
+type audioCodec interface {
+	SampleRate() int                        // 编解码采样率
+	Decode(payload []byte) ([]int16, error) // 解码一个RTP负载为单声道采样
+	Encode(samples []int16) ([]byte, error) // 编码一帧单声道采样
+}
+
+// webrtcCodec 可协商的音频编码
+type webrtcCodec struct {
+	Capability  webrtc.RTPCodecCapability
+	PayloadType webrtc.PayloadType
+	New         func() (audioCodec, error)
+}
+
+// webrtcCodecs 按优先级排列的音频编码（Opus依赖libopus，需以 opus 构建标签编译）
+var webrtcCodecs = []webrtcCodec{
+	{
+		Capability:  webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypePCMU, ClockRate: 8000, Channels: 1},
+		PayloadType: 0,
+		New:         func() (audioCodec, error) { return pcmuCodec{}, nil },
+	},
+}
+
+// findWebRTCCodec 按MIME类型查找音频编码
+func findWebRTCCodec(mimeType string) (webrtcCodec, bool) {
+	for _, codec := range webrtcCodecs {
+		if strings.EqualFold(codec.Capability.MimeType, mimeType) {
+			return codec, true
+		}
+	}
+	return webrtcCodec{}, false
+}
+
+// pcmuCodec G.711 μ-law 编解码（纯Go实现，所有浏览器均支持）
+type pcmuCodec struct{}
+
+// SampleRate 采样率
+func (pcmuCodec) SampleRate() int {
+	return 8000
+}
+
+// Decode 解码
+func (pcmuCodec) Decode(payload []byte) ([]int16, error) {
+	samples := make([]int16, len(payload))
+	for i, b := range payload {
+		samples[i] = vaaudio.MulawToLinear(b)
+	}
+	return samples, nil
+}
+
+// Encode 编码
+func (pcmuCodec) Encode(samples []int16) ([]byte, error) {
+	payload := make([]byte, len(samples))
+	for i, s := range samples {
+		payload[i] = vaaudio.LinearToMulaw(s)
+	}
+	return payload, nil
+}
+
+// jitterBuffer RTP抖动缓冲：按序列号重排乱序到达的包，缺失的包等待超过缓冲深度后视为丢失
+type jitterBuffer struct {
+	depth   int
+	packets map[uint16]*rtp.Packet
+	next    uint16
+	started bool
+}
+
+// newJitterBuffer 创建抖动缓冲（depth为最多等待的包数）
+func newJitterBuffer(depth int) *jitterBuffer {
+	if depth <= 0 {
+		depth = 1
+	}
+	return &jitterBuffer{
+		depth:   depth,
+		packets: make(map[uint16]*rtp.Packet),
+	}
+}
+
+// Push 放入一个包，返回按序可以输出的包
+func (jb *jitterBuffer) Push(packet *rtp.Packet) []*rtp.Packet {
+	seq := packet.SequenceNumber
+	if !jb.started {
+		jb.next = seq
+		jb.started = true
+	}
+	// 迟到（已跳过）或重复的包直接丢弃
+	if seqBefore(seq, jb.next) {
+		return nil
+	}
+	jb.packets[seq] = packet
+
+	var ready []*rtp.Packet
+	for len(jb.packets) > 0 {
+		if p, exists := jb.packets[jb.next]; exists {
+			ready = append(ready, p)
+			delete(jb.packets, jb.next)
+			jb.next++
+			continue
+		}
+		if len(jb.packets) <= jb.depth {
+			break
+		}
+		// 缓冲已满仍未等到，跳过丢失的包
+		jb.next = jb.earliest()
+	}
+	return ready
+}
+
+// earliest 缓冲中最早的序列号
+func (jb *jitterBuffer) earliest() uint16 {
+	var earliest uint16
+	first := true
+	for seq := range jb.packets {
+		if first || seqBefore(seq, earliest) {
+			earliest = seq
+			first = false
+		}
+	}
+	return earliest
+}
+
+// seqBefore 判断序列号a是否在b之前（处理回绕）
+func seqBefore(a, b uint16) bool {
+	return int16(a-b) < 0
+}
@@ -0,0 +1,64 @@
+//go:build opus
+
+package server
+
+import (
+	vaaudio "voice_assistant/pkg/audio"
+
+	"github.com/pion/webrtc/v4"
+)
+
+// opusSampleRate Opus采样率
+const opusSampleRate = 48000
+
+// Opus优先于PCMU协商（需要cgo和libopus）
+func init() {
+	webrtcCodecs = append([]webrtcCodec{{
+		Capability: webrtc.RTPCodecCapability{
+			MimeType:    webrtc.MimeTypeOpus,
+			ClockRate:   opusSampleRate,
+			Channels:    2,
+			SDPFmtpLine: "minptime=10;useinbandfec=1",
+		},
+		PayloadType: 111,
+		New:         newOpusCodec,
+	}}, webrtcCodecs...)
+}
+
+// opusCodec Opus编解码（单声道，48kHz）
+type opusCodec struct {
+	encoder *vaaudio.OpusEncoder
+	decoder *vaaudio.OpusDecoder
+}
+
+// newOpusCodec 创建Opus编解码器
+func newOpusCodec() (audioCodec, error) {
+	encoder, err := vaaudio.NewOpusEncoder(opusSampleRate, 1)
+	if err != nil {
+		return nil, err
+	}
+	decoder, err := vaaudio.NewOpusDecoder(opusSampleRate, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	return &opusCodec{
+		encoder: encoder,
+		decoder: decoder,
+	}, nil
+}
+
+// SampleRate 采样率
+func (c *opusCodec) SampleRate() int {
+	return opusSampleRate
+}
+
+// Decode 解码
+func (c *opusCodec) Decode(payload []byte) ([]int16, error) {
+	return c.decoder.Decode(payload)
+}
+
+// Encode 编码
+func (c *opusCodec) Encode(samples []int16) ([]byte, error) {
+	return c.encoder.Encode(samples)
+}
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	vaaudio "voice_assistant/pkg/audio"
+	"voice_assistant/pkg/protocol"
+)
+
+func TestJitterBuffer(t *testing.T) {
+	jb := newJitterBuffer(2)
+	packet := func(seq uint16) *rtp.Packet {
+		return &rtp.Packet{Header: rtp.Header{SequenceNumber: seq}}
+	}
+	sequences := func(packets []*rtp.Packet) []uint16 {
+		var seqs []uint16
+		for _, p := range packets {
+			seqs = append(seqs, p.SequenceNumber)
+		}
+		return seqs
+	}
+
+	// 乱序到达时重排
+	assert.Equal(t, []uint16{65534}, sequences(jb.Push(packet(65534))))
+	assert.Empty(t, jb.Push(packet(0)))
+	assert.Equal(t, []uint16{65535, 0}, sequences(jb.Push(packet(65535))))
+
+	// 丢包超过缓冲深度后跳过
+	assert.Empty(t, jb.Push(packet(2)))
+	assert.Empty(t, jb.Push(packet(3)))
+	assert.Equal(t, []uint16{2, 3, 4}, sequences(jb.Push(packet(4))))
+
+	// 迟到的包丢弃
+	assert.Empty(t, jb.Push(packet(1)))
+}
+
+func TestDecodeSpeech(t *testing.T) {
+	pcm := vaaudio.Int16ToBytes([]int16{100, 200, 300, 400})
+
+	samples, rate, ok := decodeSpeech(vaaudio.EncodeWAV(pcm, 24000, 2))
+	require.True(t, ok)
+	assert.Equal(t, 24000, rate)
+	assert.Equal(t, []int16{150, 350}, samples)
+
+	samples, rate, ok = decodeSpeech(pcm)
+	require.True(t, ok)
+	assert.Equal(t, 16000, rate)
+	assert.Len(t, samples, 4)
+
+	_, _, ok = decodeSpeech([]byte("ID3\x03\x00"))
+	assert.False(t, ok)
+
+	assert.Len(t, vaaudio.ResampleInt16(make([]int16, 160), 8000, 16000), 320)
+}
+
+func TestWebRTCConnect(t *testing.T) {
+	gateway, err := NewWebRTCGateway(WebRTCConfig{MaxConnections: 1}, NewMessageProcessor(ProcessorConfig{MaxConcurrentSessions: 10}))
+	require.NoError(t, err)
+
+	// 浏览器端：麦克风音轨 + 控制数据通道
+	browser, err := webrtc.NewPeerConnection(webrtc.Configuration{})
+	require.NoError(t, err)
+	defer browser.Close()
+
+	mic, err := webrtc.NewTrackLocalStaticSample(webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypePCMU, ClockRate: 8000}, "mic", "browser")
+	require.NoError(t, err)
+	_, err = browser.AddTrack(mic)
+	require.NoError(t, err)
+
+	dc, err := browser.CreateDataChannel("control", nil)
+	require.NoError(t, err)
+	messages := make(chan protocol.Message, 10)
+	dc.OnMessage(func(raw webrtc.DataChannelMessage) {
+		var msg protocol.Message
+		if json.Unmarshal(raw.Data, &msg) == nil {
+			messages <- msg
+		}
+	})
+
+	offer, err := browser.CreateOffer(nil)
+	require.NoError(t, err)
+	gathered := webrtc.GatheringCompletePromise(browser)
+	require.NoError(t, browser.SetLocalDescription(offer))
+	<-gathered
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	answer, err := gateway.Connect(ctx, "webrtc_test", *browser.LocalDescription())
+	require.NoError(t, err)
+	assert.Equal(t, 1, gateway.GetPeerCount())
+
+	// 同一会话不能重复连接
+	_, err = gateway.Connect(ctx, "webrtc_test", *browser.LocalDescription())
+	assert.Error(t, err)
+
+	require.NoError(t, browser.SetRemoteDescription(*answer))
+
+	// 数据通道打开后收到连接确认
+	select {
+	case msg := <-messages:
+		assert.Equal(t, protocol.Status, msg.Type)
+		assert.Equal(t, "webrtc_test", msg.SessionID)
+	case <-ctx.Done():
+		t.Fatal("未收到连接确认")
+	}
+
+	// 时钟同步由传输层应答
+	request, err := json.Marshal(protocol.NewMessage(protocol.TimeSync, "webrtc_test", &protocol.TimeSyncData{ClientSendTime: 1000}))
+	require.NoError(t, err)
+	require.NoError(t, dc.SendText(string(request)))
+	select {
+	case msg := <-messages:
+		require.Equal(t, protocol.TimeSync, msg.Type)
+		syncData, err := msg.TimeSyncData()
+		require.NoError(t, err)
+		assert.Equal(t, int64(1000), syncData.ClientSendTime)
+		assert.GreaterOrEqual(t, syncData.ServerSendTime, syncData.ServerReceiveTime)
+	case <-ctx.Done():
+		t.Fatal("未收到时钟同步应答")
+	}
+
+	// 关闭后释放连接名额和会话
+	browser.Close()
+	assert.Eventually(t, func() bool { return gateway.GetPeerCount() == 0 }, 10*time.Second, 50*time.Millisecond)
+	assert.True(t, gateway.processor.createSession("webrtc_test", ""))
+}
+
+func TestWebRTCRejectsExistingSession(t *testing.T) {
+	processor := NewMessageProcessor(ProcessorConfig{MaxConcurrentSessions: 10})
+	gateway, err := NewWebRTCGateway(WebRTCConfig{}, processor)
+	require.NoError(t, err)
+
+	// 会话ID已被WebSocket连接使用时不能通过WebRTC接入
+	existing := processor.getOrCreateSession("ws_session", "")
+	_, err = gateway.Connect(context.Background(), "ws_session", webrtc.SessionDescription{Type: webrtc.SDPTypeOffer})
+	assert.ErrorContains(t, err, "会话已存在")
+	assert.Equal(t, 0, gateway.GetPeerCount())
+	assert.Same(t, existing, processor.getOrCreateSession("ws_session", ""))
+}
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"voice_assistant/pkg/protocol"
@@ -21,6 +23,32 @@ type WebSocketConfig struct {
 	PingPeriod      time.Duration `yaml:"ping_period"`
 	PongWait        time.Duration `yaml:"pong_wait"`
 	WriteWait       time.Duration `yaml:"write_wait"`
+
+	// 会话恢复：断线后保留会话和已发消息的时长（0表示不启用）及保留的消息数
+	ResumeWindow     time.Duration `yaml:"resume_window"`
+	ResumeBufferSize int           `yaml:"resume_buffer_size"`
+
+	// 失效连接清理：连接超过StaleTimeout没有任何活动（消息、Ping/Pong）时强制关闭（0表示不检测），按SweepInterval巡检
+	StaleTimeout  time.Duration `yaml:"stale_timeout"`
+	SweepInterval time.Duration `yaml:"sweep_interval"`
+
+	// 发送队列：队列长度，以及队列已满时的溢出策略（block|drop_oldest|disconnect）和block策略的最长等待时间
+	SendQueueSize  int           `yaml:"send_queue_size"`
+	OverflowPolicy string        `yaml:"overflow_policy"`
+	BlockTimeout   time.Duration `yaml:"block_timeout"`
+
+	// 压缩：与客户端协商permessage-deflate，只压缩不小于CompressionThreshold字节的消息（CompressionLevel为0时使用默认级别）
+	EnableCompression    bool `yaml:"enable_compression"`
+	CompressionLevel     int  `yaml:"compression_level"`
+	CompressionThreshold int  `yaml:"compression_threshold"`
+
+	// 主动关闭：等待客户端确认关闭通知和回应关闭帧的时长（默认2秒）
+	CloseTimeout time.Duration `yaml:"close_timeout"`
+
+	// 消息校验：单条消息和单块上传音频的字节数上限（0表示不限制），严格模式下拒绝协议未定义的字段
+	MaxMessageSize int  `yaml:"max_message_size"`
+	MaxAudioSize   int  `yaml:"max_audio_size"`
+	StrictProtocol bool `yaml:"strict_protocol"`
 }
 
 // WebSocketServer WebSocket服务器
@@ -28,6 +56,7 @@ type WebSocketServer struct {
 	config   WebSocketConfig
 	upgrader websocket.Upgrader
 	clients  map[string]*Client
+	resumes  map[string]*resumeState
 	mu       sync.RWMutex
 
 	// 消息处理器
@@ -35,14 +64,51 @@ type WebSocketServer struct {
 
 	// 处理器
 	processor *MessageProcessor
+
+	// 来源检查（未设置时允许任意来源）
+	origins *OriginChecker
+
+	// 失效连接巡检
+	staleClosed int64
+	stopSweep   chan struct{}
+	closeOnce   sync.Once
+
+	// 发送队列溢出统计
+	sendStats sendQueueCounters
 }
 
 // Client 客户端连接
+// 消息处理器只通过ID和SendChan与客户端交互；Conn和Server仅WebSocket连接使用，其他传输（如gRPC）为nil。
 type Client struct {
 	ID       string
 	Conn     *websocket.Conn
 	SendChan chan *protocol.Message
 	Server   *WebSocketServer
+	APIKey   string // 握手时携带的API Key（用于用量统计，为空表示匿名）
+
+	// 连接上复用的多个会话（会话视图指向所属连接）
+	conn      *Client
+	sessions  map[string]*Client
+	scheduler *turnScheduler
+	mu        sync.Mutex
+
+	// 可恢复会话（未启用会话恢复时为nil）
+	resume *resumeState
+
+	// 广播视图：消息发给会话的全部成员（仅多客户端会话的成员视图设置）
+	group *sessionGroup
+
+	// 连接活跃度（仅WebSocket连接使用）
+	connectedAt  time.Time
+	lastActivity atomic.Int64 // 最近一次收到消息或Ping/Pong的时间（UnixNano）
+	closeOnce    sync.Once
+	done         chan struct{}
+	dropped      atomic.Int64 // 发送队列溢出时丢弃的消息数
+
+	// 服务端主动关闭（仅WebSocket连接使用）
+	closing  atomic.Bool
+	closeAck chan struct{}
+	ackOnce  sync.Once
 }
 
 // MessageHandler 消息处理器函数类型
@@ -50,18 +116,33 @@ type MessageHandler func(client *Client, msg *protocol.Message) error
 
 // NewWebSocketServer 创建新的WebSocket服务器
 func NewWebSocketServer(config WebSocketConfig) *WebSocketServer {
-	return &WebSocketServer{
+	s := &WebSocketServer{
 		config: config,
 		upgrader: websocket.Upgrader{
-			CheckOrigin: func(r *http.Request) bool {
-				return true // 生产环境需要严格检查
-			},
-			ReadBufferSize:  config.ReadBufferSize,
-			WriteBufferSize: config.WriteBufferSize,
+			ReadBufferSize:    config.ReadBufferSize,
+			WriteBufferSize:   config.WriteBufferSize,
+			EnableCompression: config.EnableCompression,
 		},
 		clients:         make(map[string]*Client),
+		resumes:         make(map[string]*resumeState),
 		messageHandlers: make(map[protocol.MessageType]MessageHandler),
+		stopSweep:       make(chan struct{}),
 	}
+	s.upgrader.CheckOrigin = s.checkOrigin
+
+	if config.StaleTimeout > 0 {
+		go s.sweepLoop()
+	}
+	return s
+}
+
+// limits 接收消息的校验限制
+func (s *WebSocketServer) limits() protocol.Limits {
+	limits := protocol.DefaultLimits()
+	limits.MaxMessageSize = s.config.MaxMessageSize
+	limits.MaxAudioSize = s.config.MaxAudioSize
+	limits.DisallowUnknownFields = s.config.StrictProtocol
+	return limits
 }
 
 // SetProcessor 设置消息处理器
@@ -69,6 +150,23 @@ func (s *WebSocketServer) SetProcessor(processor *MessageProcessor) {
 	s.processor = processor
 }
 
+// SetOriginChecker 设置握手请求的来源检查（防止跨站WebSocket劫持）
+func (s *WebSocketServer) SetOriginChecker(origins *OriginChecker) {
+	s.origins = origins
+}
+
+// checkOrigin 检查握手请求的来源
+func (s *WebSocketServer) checkOrigin(r *http.Request) bool {
+	if s.origins == nil {
+		return true
+	}
+	if !s.origins.CheckRequest(r) {
+		log.Printf("拒绝来源不被允许的WebSocket连接: %s", r.Header.Get("Origin"))
+		return false
+	}
+	return true
+}
+
 // HandleConnection 处理WebSocket连接
 func (s *WebSocketServer) HandleConnection(w http.ResponseWriter, r *http.Request) {
 	// 检查连接数限制
@@ -81,23 +179,67 @@ func (s *WebSocketServer) HandleConnection(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	conn, err := s.upgrader.Upgrade(w, r, nil)
+	// 缓冲区大小可在运行中调整（调试端口），只影响之后建立的连接
+	s.mu.RLock()
+	upgrader := s.upgrader
+	queueSize := s.config.SendQueueSize
+	s.mu.RUnlock()
+
+	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Printf("WebSocket升级失败: %v", err)
 		return
 	}
+	s.setupCompression(conn)
 
 	sessionID := r.URL.Query().Get("session_id")
 	if sessionID == "" {
 		sessionID = s.generateSessionID()
 	}
 
+	// 恢复已有会话须凭令牌，校验通过前不登记连接，避免挤掉原连接；
+	// 不是恢复会话时会话ID不能已被使用，不能凭会话ID接入其他连接的会话
+	var resume *resumeState
+	var resumed bool
+	if s.config.ResumeWindow > 0 {
+		var ok bool
+		resume, resumed, ok = s.claimResume(sessionID, r.URL.Query().Get("resume_token"), requestAPIKey(r))
+		switch {
+		case !ok && resumed:
+			log.Printf("拒绝恢复会话（令牌或API Key不匹配）: %s", sessionID)
+			s.rejectConnection(conn, protocol.CloseResumeRejected, "resume rejected")
+			return
+		case !ok:
+			log.Printf("拒绝连接，会话已存在: %s", sessionID)
+			s.rejectConnection(conn, protocol.CloseSessionInUse, "session in use")
+			return
+		}
+	} else if s.processor != nil && !s.processor.createSession(sessionID, requestAPIKey(r)) {
+		log.Printf("拒绝连接，会话已存在: %s", sessionID)
+		s.rejectConnection(conn, protocol.CloseSessionInUse, "session in use")
+		return
+	}
+
+	// 发送队列需容纳重连时补发的消息
+	if queueSize <= 0 {
+		queueSize = defaultSendQueueSize
+	}
+	if s.config.ResumeWindow > 0 {
+		queueSize += s.config.ResumeBufferSize
+	}
+
 	client := &Client{
 		ID:       sessionID,
 		Conn:     conn,
-		SendChan: make(chan *protocol.Message, 100),
+		SendChan: make(chan *protocol.Message, queueSize),
 		Server:   s,
+		APIKey:   requestAPIKey(r),
+
+		connectedAt: time.Now(),
+		done:        make(chan struct{}),
+		closeAck:    make(chan struct{}),
 	}
+	client.touch()
 
 	s.mu.Lock()
 	s.clients[sessionID] = client
@@ -105,20 +247,31 @@ func (s *WebSocketServer) HandleConnection(w http.ResponseWriter, r *http.Reques
 
 	log.Printf("客户端连接: %s", sessionID)
 
-	// 发送连接确认
+	// 发送连接确认（启用会话恢复时随后补发断线期间遗漏的消息）
 	statusData := &protocol.StatusData{
 		State:             "connected",
 		Mode:              "idle",
 		ConcurrentStreams: 0,
 	}
-	statusMsg := protocol.NewMessage(protocol.Status, sessionID, statusData)
-	client.SendMessage(statusMsg)
+	if resume != nil {
+		lastSeq, _ := strconv.ParseInt(r.URL.Query().Get("last_seq"), 10, 64)
+		s.resumeConnection(client, resume, resumed, lastSeq, statusData)
+	} else {
+		client.SendMessage(protocol.NewMessage(protocol.Status, sessionID, statusData))
+	}
 
 	// 启动客户端处理协程
 	go client.readLoop()
 	go client.writeLoop()
 }
 
+// rejectConnection 以关闭码拒绝尚未登记的连接
+func (s *WebSocketServer) rejectConnection(conn *websocket.Conn, code int, reason string) {
+	conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(code, reason), time.Now().Add(s.config.WriteWait))
+	conn.Close()
+}
+
 // RegisterHandler 注册消息处理器
 func (s *WebSocketServer) RegisterHandler(msgType protocol.MessageType, handler MessageHandler) {
 	s.messageHandlers[msgType] = handler
@@ -144,51 +297,108 @@ func (s *WebSocketServer) GetClientCount() int {
 	return len(s.clients)
 }
 
-// SendMessage 发送消息给客户端
+// Broadcast 向所有已连接客户端发送消息，返回成功入队的客户端数量
+// newMessage 按客户端ID构造消息，保证每条消息携带各自的会话ID。
+func (s *WebSocketServer) Broadcast(newMessage func(clientID string) *protocol.Message) int {
+	s.mu.RLock()
+	clients := make([]*Client, 0, len(s.clients))
+	for _, client := range s.clients {
+		clients = append(clients, client)
+	}
+	s.mu.RUnlock()
+
+	sent := 0
+	for _, client := range clients {
+		if err := client.SendMessage(newMessage(client.ID)); err != nil {
+			log.Printf("广播到客户端 %s 失败: %v", client.ID, err)
+			continue
+		}
+		sent++
+	}
+	return sent
+}
+
+// SendMessage 发送消息给客户端（可恢复会话的消息经编号保留后发往当前连接）
 func (c *Client) SendMessage(msg *protocol.Message) error {
+	if c.group != nil {
+		return c.group.send(msg)
+	}
+	if conn := c.Connection(); conn.resume != nil {
+		return conn.resume.send(msg, conn.Server.config.ResumeWindow, conn.Server.config.ResumeBufferSize)
+	}
+	return c.enqueue(msg)
+}
+
+// enqueue 消息直接进入发送队列
+// 队列已满时WebSocket连接按服务器的溢出策略处理，其他传输直接丢弃新消息。
+func (c *Client) enqueue(msg *protocol.Message) error {
 	select {
 	case c.SendChan <- msg:
 		return nil
 	default:
+	}
+
+	conn := c.Connection()
+	if conn.Server == nil {
+		conn.dropped.Add(1)
 		return fmt.Errorf("客户端发送队列已满")
 	}
+	return conn.Server.overflow(conn, msg)
 }
 
 // readLoop 读取消息循环
 func (c *Client) readLoop() {
-	defer func() {
-		c.Server.mu.Lock()
-		delete(c.Server.clients, c.ID)
-		c.Server.mu.Unlock()
-		c.Conn.Close()
-		log.Printf("客户端断开: %s", c.ID)
-	}()
+	defer c.close()
 
 	// 设置读取超时
 	c.Conn.SetReadDeadline(time.Now().Add(c.Server.config.PongWait))
 	c.Conn.SetPongHandler(func(string) error {
+		c.touch()
 		c.Conn.SetReadDeadline(time.Now().Add(c.Server.config.PongWait))
 		return nil
 	})
+	c.Conn.SetPingHandler(func(data string) error {
+		c.touch()
+		err := c.Conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(c.Server.config.WriteWait))
+		if err == websocket.ErrCloseSent {
+			return nil
+		}
+		return err
+	})
 
 	for {
 		_, messageData, err := c.Conn.ReadMessage()
+		receivedAt := time.Now()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 				log.Printf("WebSocket错误: %v", err)
 			}
 			break
 		}
+		c.touch()
 
-		var msg protocol.Message
-		if err := json.Unmarshal(messageData, &msg); err != nil {
+		msg, err := protocol.Decode(messageData, c.Server.limits())
+		if err != nil {
 			log.Printf("解析消息失败: %v", err)
+			if errData, ok := err.(*protocol.ErrorData); ok {
+				c.SendMessage(protocol.NewMessage(protocol.Error, c.ID, errData))
+			}
+			continue
+		}
+
+		// 时钟同步在连接层直接应答，避免处理排队影响时间戳精度
+		if msg.Type == protocol.TimeSync {
+			c.replyTimeSync(msg, receivedAt)
+			continue
+		}
+		if isCloseAck(msg) {
+			c.acknowledgeClose()
 			continue
 		}
 
 		// 处理消息
 		if handler, exists := c.Server.messageHandlers[msg.Type]; exists {
-			if err := handler(c, &msg); err != nil {
+			if err := handler(c, msg); err != nil {
 				log.Printf("处理消息失败: %v", err)
 				// 发送错误响应
 				errorData := &protocol.ErrorData{
@@ -215,16 +425,24 @@ func (c *Client) writeLoop() {
 
 	for {
 		select {
+		case <-c.done:
+			return
+
 		case msg := <-c.SendChan:
 			c.Conn.SetWriteDeadline(time.Now().Add(c.Server.config.WriteWait))
 
+			// 时钟同步应答在实际写出前记录发送时间
+			if syncData, ok := msg.Payload.(*protocol.TimeSyncData); ok {
+				syncData.ServerSendTime = time.Now().UnixMilli()
+			}
+
 			data, err := json.Marshal(msg)
 			if err != nil {
 				log.Printf("序列化消息失败: %v", err)
 				continue
 			}
 
-			if err := c.Conn.WriteMessage(websocket.TextMessage, data); err != nil {
+			if err := c.writeMessage(data); err != nil {
 				log.Printf("发送消息失败: %v", err)
 				return
 			}
@@ -239,7 +457,22 @@ func (c *Client) writeLoop() {
 	}
 }
 
-// generateSessionID 生成会话ID
+// replyTimeSync 应答时钟同步请求
+func (c *Client) replyTimeSync(msg *protocol.Message, receivedAt time.Time) {
+	syncData, err := msg.TimeSyncData()
+	if err != nil {
+		log.Printf("解析时钟同步数据失败: %v", err)
+		return
+	}
+	syncData.ServerReceiveTime = receivedAt.UnixMilli()
+
+	// 时钟同步应答只对当前连接有意义，不参与重连补发
+	if err := c.enqueue(protocol.NewMessage(protocol.TimeSync, c.ID, syncData)); err != nil {
+		log.Printf("发送时钟同步应答失败: %v", err)
+	}
+}
+
+// generateSessionID 生成随机会话ID（不可猜测）
 func (s *WebSocketServer) generateSessionID() string {
-	return fmt.Sprintf("session_%d", time.Now().UnixNano())
+	return "session_" + randomHex(16)
 }
@@ -0,0 +1,71 @@
+//go:build !no_speaker_http
+
+package speaker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// HTTPEmbedder 外部声纹服务：POST 16kHz 16bit单声道PCM（application/octet-stream），返回 {"embedding": [...]}
+type HTTPEmbedder struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPEmbedder 创建外部声纹服务
+func NewHTTPEmbedder(config Config) (*HTTPEmbedder, error) {
+	if config.URL == "" {
+		return nil, fmt.Errorf("未配置声纹服务地址")
+	}
+	return &HTTPEmbedder{
+		url:    config.URL,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// Embed 提取声纹向量
+func (e *HTTPEmbedder) Embed(ctx context.Context, pcm []byte) ([]float32, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(pcm))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("X-Sample-Rate", fmt.Sprint(SampleRate))
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求声纹服务失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("声纹服务返回错误: HTTP %d: %s", resp.StatusCode, bytes.TrimSpace(message))
+	}
+
+	var result struct {
+		Embedding []float32 `json:"embedding"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("解析声纹结果失败: %w", err)
+	}
+	return result.Embedding, nil
+}
+
+// Close 释放资源
+func (e *HTTPEmbedder) Close() error {
+	return nil
+}
+
+// 注册http声纹提取
+func init() {
+	RegisterEmbedder("http", func(config Config) (Embedder, error) {
+		return NewHTTPEmbedder(config)
+	})
+}
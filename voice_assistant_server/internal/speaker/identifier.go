@@ -0,0 +1,100 @@
+package speaker
+
+import (
+	"context"
+	"fmt"
+)
+
+// Identifier 说话人识别：提取声纹并与已登记的说话人比对
+type Identifier struct {
+	config   Config
+	embedder Embedder
+	profiles *ProfileStore
+}
+
+// NewIdentifier 按配置创建说话人识别
+func NewIdentifier(config Config) (*Identifier, error) {
+	embedder, err := CreateEmbedder(config)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s（当前二进制已编译: %v）", err, config.Provider, GetAvailableEmbedderTypes())
+	}
+
+	profiles, err := NewProfileStore(config.ProfilesFile)
+	if err != nil {
+		embedder.Close()
+		return nil, err
+	}
+	return NewIdentifierWith(config, embedder, profiles), nil
+}
+
+// NewIdentifierWith 使用指定的声纹提取和声纹库创建说话人识别
+func NewIdentifierWith(config Config, embedder Embedder, profiles *ProfileStore) *Identifier {
+	if config.Threshold <= 0 {
+		config.Threshold = 0.6
+	}
+	if config.MinDuration <= 0 {
+		config.MinDuration = 1000
+	}
+	return &Identifier{
+		config:   config,
+		embedder: embedder,
+		profiles: profiles,
+	}
+}
+
+// Identify 识别说话人（音频过短、未登记说话人或相似度低于阈值时返回nil）
+func (i *Identifier) Identify(ctx context.Context, pcm []byte) (*Match, error) {
+	if len(i.profiles.List()) == 0 || !i.longEnough(pcm) {
+		return nil, nil
+	}
+
+	embedding, err := i.embedder.Embed(ctx, pcm)
+	if err != nil {
+		return nil, fmt.Errorf("提取声纹失败: %w", err)
+	}
+
+	match := i.profiles.Best(embedding)
+	if match == nil || match.Score < i.config.Threshold {
+		return nil, nil
+	}
+	return match, nil
+}
+
+// Enroll 用一段语音登记说话人（appendSample 为true时同ID多次登记累积样本，否则ID已存在时拒绝）
+func (i *Identifier) Enroll(ctx context.Context, id, name string, pcm []byte, appendSample bool) (Profile, error) {
+	if !i.longEnough(pcm) {
+		return Profile{}, fmt.Errorf("%w: 至少需要%d毫秒", ErrAudioTooShort, i.config.MinDuration)
+	}
+
+	embedding, err := i.embedder.Embed(ctx, pcm)
+	if err != nil {
+		return Profile{}, fmt.Errorf("提取声纹失败: %w", err)
+	}
+
+	profile, err := i.profiles.Enroll(id, name, embedding, appendSample)
+	if err != nil {
+		return Profile{}, err
+	}
+	profile.Embedding = nil
+	return profile, nil
+}
+
+// Delete 删除说话人
+func (i *Identifier) Delete(id string) error {
+	return i.profiles.Delete(id)
+}
+
+// Profiles 已登记的说话人
+func (i *Identifier) Profiles() []Profile {
+	return i.profiles.List()
+}
+
+// Close 释放资源
+func (i *Identifier) Close() error {
+	return i.embedder.Close()
+}
+
+// longEnough 音频是否达到最短识别时长
+func (i *Identifier) longEnough(pcm []byte) bool {
+	return len(pcm)*1000/(SampleRate*bytesPerSample) >= i.config.MinDuration
+}
@@ -0,0 +1,90 @@
+package speaker
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+)
+
+// 说话人识别相关错误定义
+var (
+	ErrUnsupportedEmbedderType = errors.New("unsupported speaker embedder type")
+	ErrProfileNotFound         = errors.New("speaker profile not found")
+	ErrProfileExists           = errors.New("speaker profile already exists")
+	ErrInvalidProfile          = errors.New("invalid speaker profile")
+	ErrAudioTooShort           = errors.New("audio too short for speaker embedding")
+)
+
+// 输入音频格式（与ASR一致）
+const (
+	SampleRate     = 16000
+	bytesPerSample = 2
+)
+
+// Embedder 说话人声纹向量提取接口
+type Embedder interface {
+	// Embed 从16kHz 16bit单声道PCM提取声纹向量
+	Embed(ctx context.Context, pcm []byte) ([]float32, error)
+
+	// Close 释放资源
+	Close() error
+}
+
+// Config 说话人识别配置
+type Config struct {
+	Enabled      bool    `yaml:"enabled"`       // 是否启用
+	Provider     string  `yaml:"provider"`      // 声纹提取: sherpa（本地ONNX模型）|http（外部服务）
+	ModelPath    string  `yaml:"model_path"`    // 声纹模型路径（sherpa）
+	NumThreads   int     `yaml:"num_threads"`   // 推理线程数（sherpa）
+	URL          string  `yaml:"url"`           // 声纹服务地址（http）
+	ProfilesFile string  `yaml:"profiles_file"` // 已登记声纹的保存文件（为空时只保存在内存）
+	Threshold    float64 `yaml:"threshold"`     // 判定为同一说话人的最低相似度
+	MinDuration  int     `yaml:"min_duration"`  // 参与识别的最短音频（毫秒）
+}
+
+// Profile 已登记的说话人
+type Profile struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Embedding []float32 `json:"embedding,omitempty"` // 登记样本的平均声纹（已归一化）
+	Samples   int       `json:"samples"`             // 登记样本数
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Match 识别结果
+type Match struct {
+	ID    string  `json:"id"`
+	Name  string  `json:"name"`
+	Score float64 `json:"score"` // 余弦相似度
+}
+
+// EmbedderFactory 声纹提取工厂函数类型
+type EmbedderFactory func(config Config) (Embedder, error)
+
+// 注册的声纹提取实现
+var embedderFactories = make(map[string]EmbedderFactory)
+
+// RegisterEmbedder 注册声纹提取实现
+func RegisterEmbedder(name string, factory EmbedderFactory) {
+	embedderFactories[name] = factory
+}
+
+// CreateEmbedder 创建声纹提取
+func CreateEmbedder(config Config) (Embedder, error) {
+	factory, exists := embedderFactories[config.Provider]
+	if !exists {
+		return nil, ErrUnsupportedEmbedderType
+	}
+	return factory(config)
+}
+
+// GetAvailableEmbedderTypes 获取可用（已编译进当前二进制）的声纹提取类型
+func GetAvailableEmbedderTypes() []string {
+	types := make([]string, 0, len(embedderFactories))
+	for t := range embedderFactories {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
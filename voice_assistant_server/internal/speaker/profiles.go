@@ -0,0 +1,208 @@
+package speaker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+// profileIDPattern 说话人ID
+var profileIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// ProfileStore 已登记的说话人声纹（保存为单个JSON文件）
+type ProfileStore struct {
+	path     string
+	profiles map[string]Profile
+	mu       sync.RWMutex
+}
+
+// NewProfileStore 打开声纹库（path为空时只保存在内存）
+func NewProfileStore(path string) (*ProfileStore, error) {
+	store := &ProfileStore{path: path, profiles: make(map[string]Profile)}
+	if path == "" {
+		return store, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取声纹库失败: %w", err)
+	}
+
+	var profiles []Profile
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return nil, fmt.Errorf("解析声纹库失败: %w", err)
+	}
+	for _, profile := range profiles {
+		store.profiles[profile.ID] = profile
+	}
+	return store, nil
+}
+
+// Enroll 登记一段样本的声纹：appendSample 为true时同一说话人多次登记取平均，提高识别稳定性；
+// 为false时ID已存在返回 ErrProfileExists，避免他人的声音混入已有的声纹
+func (s *ProfileStore) Enroll(id, name string, embedding []float32, appendSample bool) (Profile, error) {
+	if !profileIDPattern.MatchString(id) {
+		return Profile{}, fmt.Errorf("%w: 说话人ID只能包含字母、数字、下划线和连字符", ErrInvalidProfile)
+	}
+	embedding = normalize(embedding)
+	if embedding == nil {
+		return Profile{}, fmt.Errorf("%w: 声纹向量为空", ErrInvalidProfile)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	profile, exists := s.profiles[id]
+	switch {
+	case exists && !appendSample:
+		return Profile{}, fmt.Errorf("%w: %s", ErrProfileExists, id)
+	case !exists:
+		profile = Profile{ID: id, Embedding: embedding}
+	case len(profile.Embedding) != len(embedding):
+		return Profile{}, fmt.Errorf("%w: 声纹维度不一致（%d/%d），请删除后重新登记", ErrInvalidProfile, len(profile.Embedding), len(embedding))
+	default:
+		merged := make([]float32, len(embedding))
+		for i := range merged {
+			merged[i] = (profile.Embedding[i]*float32(profile.Samples) + embedding[i]) / float32(profile.Samples+1)
+		}
+		profile.Embedding = normalize(merged)
+	}
+	if name != "" {
+		profile.Name = name
+	}
+	if profile.Name == "" {
+		profile.Name = id
+	}
+	profile.Samples++
+	profile.UpdatedAt = time.Now()
+
+	previous, existed := s.profiles[id]
+	s.profiles[id] = profile
+	if err := s.saveLocked(); err != nil {
+		if existed {
+			s.profiles[id] = previous
+		} else {
+			delete(s.profiles, id)
+		}
+		return Profile{}, err
+	}
+	return profile, nil
+}
+
+// Delete 删除说话人
+func (s *ProfileStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	profile, exists := s.profiles[id]
+	if !exists {
+		return ErrProfileNotFound
+	}
+	delete(s.profiles, id)
+	if err := s.saveLocked(); err != nil {
+		s.profiles[id] = profile
+		return err
+	}
+	return nil
+}
+
+// List 已登记的说话人（按ID排序，不含声纹向量）
+func (s *ProfileStore) List() []Profile {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	profiles := make([]Profile, 0, len(s.profiles))
+	for _, profile := range s.profiles {
+		profile.Embedding = nil
+		profiles = append(profiles, profile)
+	}
+	sort.Slice(profiles, func(i, j int) bool {
+		return profiles[i].ID < profiles[j].ID
+	})
+	return profiles
+}
+
+// Best 与声纹最相似的说话人（未登记任何说话人时返回nil）
+func (s *ProfileStore) Best(embedding []float32) *Match {
+	embedding = normalize(embedding)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var best *Match
+	for _, profile := range s.profiles {
+		if len(profile.Embedding) != len(embedding) {
+			continue
+		}
+		var score float64
+		for i := range embedding {
+			score += float64(embedding[i]) * float64(profile.Embedding[i])
+		}
+		if best == nil || score > best.Score {
+			best = &Match{ID: profile.ID, Name: profile.Name, Score: score}
+		}
+	}
+	return best
+}
+
+// saveLocked 写入声纹文件（先写临时文件再替换，调用方持有写锁）
+func (s *ProfileStore) saveLocked() error {
+	if s.path == "" {
+		return nil
+	}
+
+	profiles := make([]Profile, 0, len(s.profiles))
+	for _, profile := range s.profiles {
+		profiles = append(profiles, profile)
+	}
+	sort.Slice(profiles, func(i, j int) bool {
+		return profiles[i].ID < profiles[j].ID
+	})
+	data, err := json.MarshalIndent(profiles, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化声纹库失败: %w", err)
+	}
+
+	if dir := filepath.Dir(s.path); dir != "" {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return fmt.Errorf("创建声纹库目录失败: %w", err)
+		}
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("写入声纹库失败: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("写入声纹库失败: %w", err)
+	}
+	return nil
+}
+
+// normalize 归一化为单位向量（零向量返回nil）
+func normalize(vector []float32) []float32 {
+	var norm float64
+	for _, v := range vector {
+		norm += float64(v) * float64(v)
+	}
+	if norm == 0 {
+		return nil
+	}
+	norm = math.Sqrt(norm)
+
+	normalized := make([]float32, len(vector))
+	for i, v := range vector {
+		normalized[i] = float32(float64(v) / norm)
+	}
+	return normalized
+}
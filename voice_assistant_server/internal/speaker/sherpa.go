@@ -0,0 +1,90 @@
+//go:build !no_speaker_sherpa
+
+package speaker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// sherpaEmbedScript 用sherpa-onnx提取声纹：argv为模型路径和线程数，stdin为16kHz 16bit单声道PCM，输出JSON
+const sherpaEmbedScript = `
+import json, sys
+import numpy as np
+import sherpa_onnx
+
+config = sherpa_onnx.SpeakerEmbeddingExtractorConfig(model=sys.argv[1], num_threads=int(sys.argv[2]))
+extractor = sherpa_onnx.SpeakerEmbeddingExtractor(config)
+samples = np.frombuffer(sys.stdin.buffer.read(), dtype=np.int16).astype(np.float32) / 32768
+stream = extractor.create_stream()
+stream.accept_waveform(sample_rate=16000, waveform=samples)
+stream.input_finished()
+print(json.dumps({"embedding": [float(v) for v in extractor.compute(stream)]}))
+`
+
+// SherpaEmbedder 基于sherpa-onnx的本地声纹提取（3D-Speaker、WeSpeaker等ONNX模型）
+type SherpaEmbedder struct {
+	modelPath  string
+	numThreads int
+}
+
+// NewSherpaEmbedder 创建sherpa-onnx声纹提取
+func NewSherpaEmbedder(config Config) (*SherpaEmbedder, error) {
+	if config.ModelPath == "" {
+		return nil, fmt.Errorf("未配置声纹模型路径")
+	}
+	if _, err := os.Stat(config.ModelPath); err != nil {
+		return nil, fmt.Errorf("声纹模型不存在: %w", err)
+	}
+	if err := exec.Command("python", "-c", "import sherpa_onnx").Run(); err != nil {
+		return nil, fmt.Errorf("sherpa_onnx未安装，请执行 pip install sherpa-onnx")
+	}
+
+	numThreads := config.NumThreads
+	if numThreads <= 0 {
+		numThreads = 1
+	}
+	return &SherpaEmbedder{
+		modelPath:  config.ModelPath,
+		numThreads: numThreads,
+	}, nil
+}
+
+// Embed 提取声纹向量
+func (e *SherpaEmbedder) Embed(ctx context.Context, pcm []byte) ([]float32, error) {
+	cmd := exec.CommandContext(ctx, "python", "-c", sherpaEmbedScript, e.modelPath, strconv.Itoa(e.numThreads))
+	cmd.Stdin = bytes.NewReader(pcm)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("执行声纹提取脚本失败: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var result struct {
+		Embedding []float32 `json:"embedding"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, fmt.Errorf("解析声纹结果失败: %w", err)
+	}
+	return result.Embedding, nil
+}
+
+// Close 释放资源
+func (e *SherpaEmbedder) Close() error {
+	return nil
+}
+
+// 注册sherpa声纹提取
+func init() {
+	RegisterEmbedder("sherpa", func(config Config) (Embedder, error) {
+		return NewSherpaEmbedder(config)
+	})
+}
@@ -0,0 +1,95 @@
+package speaker
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// firstSampleEmbedder 以第一个采样值区分说话人的测试声纹提取
+type firstSampleEmbedder struct {
+	voices map[byte][]float32
+}
+
+func (e firstSampleEmbedder) Embed(ctx context.Context, pcm []byte) ([]float32, error) {
+	return e.voices[pcm[0]], nil
+}
+
+func (e firstSampleEmbedder) Close() error {
+	return nil
+}
+
+// speech 生成指定时长的测试音频，第一个字节标识说话人
+func speech(voice byte, ms int) []byte {
+	pcm := make([]byte, SampleRate*bytesPerSample*ms/1000)
+	pcm[0] = voice
+	return pcm
+}
+
+func TestIdentifier(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "speakers.json")
+	profiles, err := NewProfileStore(path)
+	require.NoError(t, err)
+
+	embedder := firstSampleEmbedder{voices: map[byte][]float32{
+		1: {1, 0, 0},
+		2: {0, 1, 0},
+		3: {0.9, 0.1, 0},
+		4: {0, 0, 1},
+	}}
+	identifier := NewIdentifierWith(Config{Threshold: 0.8}, embedder, profiles)
+	ctx := context.Background()
+
+	// 未登记任何说话人
+	match, err := identifier.Identify(ctx, speech(1, 2000))
+	require.NoError(t, err)
+	assert.Nil(t, match)
+
+	_, err = identifier.Enroll(ctx, "dad", "爸爸", speech(1, 2000), false)
+	require.NoError(t, err)
+	_, err = identifier.Enroll(ctx, "mom", "妈妈", speech(2, 2000), false)
+	require.NoError(t, err)
+
+	// 未明确追加时不能向已有的ID混入样本，追加时累积样本
+	_, err = identifier.Enroll(ctx, "dad", "", speech(3, 2000), false)
+	assert.ErrorIs(t, err, ErrProfileExists)
+	profile, err := identifier.Enroll(ctx, "dad", "", speech(3, 2000), true)
+	require.NoError(t, err)
+	assert.Equal(t, 2, profile.Samples)
+	assert.Equal(t, "爸爸", profile.Name)
+
+	match, err = identifier.Identify(ctx, speech(2, 2000))
+	require.NoError(t, err)
+	require.NotNil(t, match)
+	assert.Equal(t, "mom", match.ID)
+	assert.Equal(t, "妈妈", match.Name)
+
+	// 未知说话人、音频过短
+	match, err = identifier.Identify(ctx, speech(4, 2000))
+	require.NoError(t, err)
+	assert.Nil(t, match)
+	match, err = identifier.Identify(ctx, speech(1, 500))
+	require.NoError(t, err)
+	assert.Nil(t, match)
+	_, err = identifier.Enroll(ctx, "kid", "", speech(4, 500), false)
+	assert.ErrorIs(t, err, ErrAudioTooShort)
+	_, err = identifier.Enroll(ctx, "../kid", "", speech(4, 2000), false)
+	assert.ErrorIs(t, err, ErrInvalidProfile)
+
+	// 重新打开后仍可识别
+	reopened, err := NewProfileStore(path)
+	require.NoError(t, err)
+	identifier = NewIdentifierWith(Config{Threshold: 0.8}, embedder, reopened)
+	match, err = identifier.Identify(ctx, speech(1, 2000))
+	require.NoError(t, err)
+	require.NotNil(t, match)
+	assert.Equal(t, "dad", match.ID)
+
+	require.NoError(t, identifier.Delete("dad"))
+	assert.ErrorIs(t, identifier.Delete("dad"), ErrProfileNotFound)
+	assert.Len(t, identifier.Profiles(), 1)
+	assert.Nil(t, identifier.Profiles()[0].Embedding)
+}
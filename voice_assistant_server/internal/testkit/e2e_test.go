@@ -0,0 +1,185 @@
+package testkit
+
+import (
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"voice_assistant/pkg/protocol"
+	"voice_assistant/voice_assistant_server/internal/asr"
+	"voice_assistant/voice_assistant_server/internal/llm"
+	"voice_assistant/voice_assistant_server/internal/server"
+	"voice_assistant/voice_assistant_server/internal/tts"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "用本次合成的语音更新标准音频")
+
+// 每块音频100ms（与客户端上传的块大小相近）
+const chunkSize = SampleRate / 10 * 2
+
+// turnResult 一轮对话中客户端收到的结果
+type turnResult struct {
+	transcript string
+	reply      string
+	speech     []*protocol.ResponseData
+	errors     []*protocol.ErrorData
+}
+
+// audio 按句子和分片序号拼接合成语音
+func (r *turnResult) audio() ([]byte, *protocol.AudioFormat) {
+	sort.SliceStable(r.speech, func(i, j int) bool {
+		a, b := r.speech[i], r.speech[j]
+		if a.Segment != b.Segment {
+			return a.Segment < b.Segment
+		}
+		return a.ChunkIndex < b.ChunkIndex
+	})
+
+	var pcm []byte
+	var format *protocol.AudioFormat
+	for _, part := range r.speech {
+		pcm = append(pcm, part.AudioData...)
+		if part.AudioFormat != nil {
+			format = part.AudioFormat
+		}
+	}
+	return pcm, format
+}
+
+func TestEndToEnd(t *testing.T) {
+	fixtures, err := LoadFixtures("testdata")
+	require.NoError(t, err)
+	require.NotEmpty(t, fixtures)
+
+	processor := server.NewMessageProcessor(server.ProcessorConfig{
+		ASRConfig:             asr.ASRConfig{Type: Provider},
+		LLMConfig:             llm.LLMConfig{Type: Provider},
+		TTSConfig:             tts.TTSConfig{Type: Provider},
+		EnableContinuousMode:  true,
+		MaxConcurrentSessions: 10,
+		SessionTimeout:        300,
+		AudioBufferSize:       4096,
+	})
+	require.NoError(t, processor.Initialize())
+	defer processor.Close()
+
+	wsServer := server.NewWebSocketServer(server.WebSocketConfig{
+		MaxConnections: 10,
+		PingPeriod:     time.Minute,
+		PongWait:       time.Minute,
+		WriteWait:      time.Second,
+	})
+	wsServer.SetProcessor(processor)
+	for _, msgType := range []protocol.MessageType{protocol.AudioStream, protocol.Command, protocol.Status} {
+		wsServer.RegisterHandler(msgType, processor.ProcessMessage)
+	}
+	httpServer := httptest.NewServer(http.HandlerFunc(wsServer.HandleConnection))
+	defer httpServer.Close()
+	url := "ws" + strings.TrimPrefix(httpServer.URL, "http")
+
+	for _, fixture := range fixtures {
+		fixture := fixture
+		t.Run(fixture.Name, func(t *testing.T) {
+			result := runTurn(t, url, fixture)
+			require.Empty(t, result.errors)
+			assert.Equal(t, fixture.Transcript, result.transcript)
+			assert.Equal(t, fixture.Reply, result.reply)
+
+			pcm, format := result.audio()
+			require.NotEmpty(t, pcm)
+			require.NotNil(t, format)
+			channels := format.Channels
+			if channels == 0 {
+				channels = 1
+			}
+			if *update {
+				require.NoError(t, WriteGolden(fixture.GoldenPath, pcm, format.SampleRate, channels))
+			}
+
+			golden, sampleRate, goldenChannels, err := ReadGolden(fixture.GoldenPath)
+			require.NoError(t, err, "缺少标准音频时用 go test -update 生成")
+			assert.Equal(t, sampleRate, format.SampleRate)
+			assert.Equal(t, goldenChannels, channels)
+			assert.True(t, len(golden) == len(pcm) && string(golden) == string(pcm),
+				"合成语音与标准音频 %s 不一致（%d 字节，期望 %d 字节）", fixture.GoldenPath, len(pcm), len(golden))
+		})
+	}
+}
+
+// runTurn 连接服务端，上传用例的输入音频，收集本轮结果直到服务端恢复聆听
+func runTurn(t *testing.T, url string, fixture Fixture) *turnResult {
+	sessionID := "e2e-" + fixture.Name
+	conn, _, err := websocket.DefaultDialer.Dial(url+"?session_id="+sessionID, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	send := func(msg *protocol.Message) {
+		data, err := msg.ToJSON()
+		require.NoError(t, err)
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, data))
+	}
+	read := func() *protocol.Message {
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		_, data, err := conn.ReadMessage()
+		require.NoError(t, err)
+		msg, err := protocol.FromJSON(data)
+		require.NoError(t, err)
+		return msg
+	}
+
+	// 连接确认
+	msg := read()
+	require.Equal(t, protocol.Status, msg.Type)
+
+	send(protocol.NewCommandMessage(sessionID, protocol.CmdStartSession, protocol.ModeContinuous, nil))
+	for chunkID, offset := 0, 0; offset < len(fixture.Audio); chunkID, offset = chunkID+1, offset+chunkSize {
+		end := offset + chunkSize
+		if end > len(fixture.Audio) {
+			end = len(fixture.Audio)
+		}
+		send(protocol.NewAudioStreamMessage(sessionID, protocol.AudioFormatPCM, chunkID, end == len(fixture.Audio), fixture.Audio[offset:end]))
+	}
+
+	result := &turnResult{}
+	processing := false
+	for {
+		msg := read()
+		switch msg.Type {
+		case protocol.Response:
+			data, err := msg.ResponseData()
+			require.NoError(t, err)
+			if !data.IsFinal && data.Stage != protocol.StageTTS {
+				continue
+			}
+			switch data.Stage {
+			case protocol.StageASR:
+				result.transcript = data.Content
+			case protocol.StageLLM:
+				result.reply = data.Content
+			case protocol.StageTTS:
+				result.speech = append(result.speech, data)
+			}
+		case protocol.Error:
+			data, err := msg.ErrorData()
+			require.NoError(t, err)
+			result.errors = append(result.errors, data)
+		case protocol.Status:
+			data, err := msg.StatusData()
+			require.NoError(t, err)
+			if data.State == protocol.StateProcessing {
+				processing = true
+			} else if processing {
+				// 本轮结束
+				return result
+			}
+		}
+	}
+}
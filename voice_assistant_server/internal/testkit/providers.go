@@ -0,0 +1,125 @@
+package testkit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"unicode/utf8"
+
+	"voice_assistant/voice_assistant_server/internal/asr"
+	"voice_assistant/voice_assistant_server/internal/llm"
+	"voice_assistant/voice_assistant_server/internal/tts"
+)
+
+// 模拟合成语音的参数：每个字一段方波，字与字之间留一段静音
+const (
+	SampleRate = 16000
+
+	toneSamples    = SampleRate / 10 // 每个字100ms
+	silenceSamples = SampleRate / 50 // 字间隔20ms
+	toneAmplitude  = 8000
+)
+
+func init() {
+	asr.RegisterASR(Provider, func(config asr.ASRConfig) (asr.ASRService, error) { return &ASR{}, nil })
+	llm.RegisterLLM(Provider, func(config llm.LLMConfig) (llm.LLMService, error) { return &LLM{}, nil })
+	tts.RegisterTTS(Provider, func(config tts.TTSConfig) (tts.TTSService, error) { return &TTS{}, nil })
+}
+
+// ASR 模拟语音识别：按已登记用例的输入音频识别，其他音频（如未说完一句时的中间结果）识别为空
+type ASR struct{ asr.ASRService }
+
+// Initialize 初始化
+func (s *ASR) Initialize(config asr.ASRConfig) error { return nil }
+
+// Close 关闭
+func (s *ASR) Close() error { return nil }
+
+// ProcessAudio 识别整段音频
+func (s *ASR) ProcessAudio(ctx context.Context, audio []byte) (asr.ASRResult, error) {
+	mu.RLock()
+	text, ok := transcripts[sha256.Sum256(audio)]
+	mu.RUnlock()
+	if !ok {
+		return asr.ASRResult{}, nil
+	}
+	return asr.ASRResult{
+		Text:       text,
+		Confidence: 1,
+		IsFinal:    true,
+		EndTime:    int64(len(audio) / 2 * 1000 / SampleRate),
+	}, nil
+}
+
+// LLM 模拟大模型：按已登记用例的识别文本回复，未登记的输入原样复述
+type LLM struct{ llm.LLMService }
+
+// Initialize 初始化
+func (s *LLM) Initialize(config llm.LLMConfig) error { return nil }
+
+// Close 关闭
+func (s *LLM) Close() error { return nil }
+
+// Chat 对话
+func (s *LLM) Chat(ctx context.Context, userInput string, conversationID string) (llm.LLMResponse, error) {
+	return llm.LLMResponse{Content: reply(userInput), Role: "assistant", FinishReason: "stop", IsComplete: true}, nil
+}
+
+// GenerateResponse 按消息列表回复最后一条消息
+func (s *LLM) GenerateResponse(ctx context.Context, messages []llm.Message) (llm.LLMResponse, error) {
+	input := ""
+	if len(messages) > 0 {
+		input = messages[len(messages)-1].Content
+	}
+	return s.Chat(ctx, input, "")
+}
+
+// reply 查找识别文本对应的回复
+func reply(input string) string {
+	mu.RLock()
+	defer mu.RUnlock()
+	if text, ok := replies[input]; ok {
+		return text
+	}
+	return input
+}
+
+// TTS 模拟语音合成：输出 Synthesize 生成的PCM
+type TTS struct{ tts.TTSService }
+
+// Initialize 初始化
+func (s *TTS) Initialize(config tts.TTSConfig) error { return nil }
+
+// Close 关闭
+func (s *TTS) Close() error { return nil }
+
+// SynthesizeText 合成文本
+func (s *TTS) SynthesizeText(ctx context.Context, text string) (tts.TTSResult, error) {
+	audio := Synthesize(text)
+	return tts.TTSResult{
+		AudioData:  audio,
+		Format:     "pcm",
+		SampleRate: SampleRate,
+		Channels:   1,
+		Duration:   int64(len(audio) / 2 * 1000 / SampleRate),
+	}, nil
+}
+
+// Synthesize 把文本合成为16kHz 16bit单声道PCM：每个字一段频率由字符编码决定的方波
+// 只用整数运算，各平台生成的音频逐字节一致，可以直接与标准音频比较。
+func Synthesize(text string) []byte {
+	pcm := make([]byte, 0, utf8.RuneCountInString(text)*(toneSamples+silenceSamples)*2)
+	for _, r := range text {
+		// 200Hz～1800Hz，方波半周期取整数个采样
+		halfPeriod := SampleRate / (2 * (200 + int(r)%64*25))
+		for i := 0; i < toneSamples; i++ {
+			sample := int16(toneAmplitude)
+			if i/halfPeriod%2 == 1 {
+				sample = -toneAmplitude
+			}
+			pcm = binary.LittleEndian.AppendUint16(pcm, uint16(sample))
+		}
+		pcm = append(pcm, make([]byte, silenceSamples*2)...)
+	}
+	return pcm
+}
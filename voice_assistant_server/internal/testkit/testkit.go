@@ -0,0 +1,113 @@
+// Package testkit 端到端回归测试工具：注册确定性的模拟ASR、LLM、TTS提供商，并加载带标准音频的测试用例
+// 模拟提供商按用例识别输入音频、给出固定回复、把文本合成为可逐字节比较的方波音频，
+// 处理流程重构（如流式识别、分句合成）后可以用同一组用例验证完整的 audio_stream → asr → llm → tts 链路。
+package testkit
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	vaaudio "voice_assistant/pkg/audio"
+)
+
+// Provider 模拟提供商的名称（ASR、LLM、TTS配置的 Type）
+const Provider = "mock"
+
+// 用例清单文件名
+const manifestFile = "fixtures.json"
+
+// Fixture 一个测试用例：输入一句话的音频，期望的识别文本、回复文本和合成语音
+type Fixture struct {
+	Name       string `json:"name"`       // 用例名称（输入音频为 <name>.wav，期望的合成语音为 <name>.golden.wav）
+	Transcript string `json:"transcript"` // 输入音频的识别文本
+	Reply      string `json:"reply"`      // 模拟LLM对识别文本的回复
+
+	Audio      []byte `json:"-"` // 输入音频（16kHz 16bit单声道PCM）
+	AudioPath  string `json:"-"` // 输入音频文件路径
+	GoldenPath string `json:"-"` // 期望的合成语音文件路径
+}
+
+// 已加载的用例（模拟ASR按输入音频的哈希查找识别文本，模拟LLM按识别文本查找回复）
+var (
+	transcripts = make(map[[sha256.Size]byte]string)
+	replies     = make(map[string]string)
+	mu          sync.RWMutex
+)
+
+// LoadFixtures 加载目录下的测试用例并交给模拟提供商使用
+// 输入音频文件不存在时用模拟TTS合成识别文本生成（可替换为真实录音，识别结果仍以清单为准）。
+func LoadFixtures(dir string) ([]Fixture, error) {
+	data, err := os.ReadFile(filepath.Join(dir, manifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("读取用例清单失败: %w", err)
+	}
+	var fixtures []Fixture
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		return nil, fmt.Errorf("解析用例清单失败: %w", err)
+	}
+
+	for i := range fixtures {
+		f := &fixtures[i]
+		f.AudioPath = filepath.Join(dir, f.Name+".wav")
+		f.GoldenPath = filepath.Join(dir, f.Name+".golden.wav")
+
+		audio, err := loadAudio(f.AudioPath)
+		if os.IsNotExist(err) {
+			audio = Synthesize(f.Transcript)
+			err = os.WriteFile(f.AudioPath, vaaudio.EncodeWAV(audio, SampleRate, 1), 0644)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("用例 %s: %w", f.Name, err)
+		}
+		f.Audio = audio
+		AddFixture(*f)
+	}
+	return fixtures, nil
+}
+
+// AddFixture 登记一个用例的识别文本和回复
+func AddFixture(f Fixture) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	transcripts[sha256.Sum256(f.Audio)] = f.Transcript
+	replies[f.Transcript] = f.Reply
+}
+
+// loadAudio 读取16kHz 16bit单声道WAV文件，返回PCM数据
+func loadAudio(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	wav, err := vaaudio.ParseWAV(data)
+	if err != nil {
+		return nil, err
+	}
+	if !wav.PCM16() || wav.SampleRate != SampleRate || wav.Channels != 1 {
+		return nil, fmt.Errorf("%s 不是16kHz 16bit单声道WAV", path)
+	}
+	return wav.Data, nil
+}
+
+// ReadGolden 读取期望的合成语音，返回PCM数据、采样率和声道数
+func ReadGolden(path string) ([]byte, int, int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	wav, err := vaaudio.ParseWAV(data)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	return wav.Data, wav.SampleRate, wav.Channels, nil
+}
+
+// WriteGolden 把合成语音写为期望的合成语音文件（更新标准音频时使用）
+func WriteGolden(path string, pcm []byte, sampleRate, channels int) error {
+	return os.WriteFile(path, vaaudio.EncodeWAV(pcm, sampleRate, channels), 0644)
+}
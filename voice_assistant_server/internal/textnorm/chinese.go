@@ -0,0 +1,179 @@
+package textnorm
+
+import (
+	"strings"
+)
+
+var (
+	chineseDigits       = []string{"零", "一", "二", "三", "四", "五", "六", "七", "八", "九"}
+	chineseSectionUnits = []string{"", "十", "百", "千"}
+	chineseGroupUnits   = []string{"", "万", "亿"}
+)
+
+// chineseUnits 单位的中文读法（区分大小写）
+var chineseUnits = map[string]string{
+	"km": "公里", "m": "米", "cm": "厘米", "mm": "毫米",
+	"kg": "千克", "g": "克", "mg": "毫克",
+	"L": "升", "l": "升", "ml": "毫升", "mL": "毫升",
+	"km/h": "公里每小时", "m/s": "米每秒",
+	"°C": "摄氏度", "℃": "摄氏度", "°F": "华氏度", "℉": "华氏度",
+	"kWh": "千瓦时", "kW": "千瓦", "W": "瓦",
+	"Hz": "赫兹", "kHz": "千赫兹", "MHz": "兆赫兹", "GHz": "吉赫兹",
+}
+
+// chineseCurrencies 货币符号的中文读法（读在数字之后）
+var chineseCurrencies = map[string]string{
+	"$": "美元", "¥": "元", "￥": "元", "€": "欧元",
+}
+
+// expandChinese 按中文读法展开文本中的数字
+func expandChinese(text string) string {
+	text = replaceFunc(dateRe, text, func(text string, m []int) (string, bool) {
+		month, day := atoi(group(text, m, 2)), atoi(group(text, m, 3))
+		if !validDate(month, day) {
+			return "", false
+		}
+		return chineseDigitString(group(text, m, 1)) + "年" + chineseInteger(group(text, m, 2)) + "月" + chineseInteger(group(text, m, 3)) + "日", true
+	})
+	text = replaceFunc(timeRe, text, func(text string, m []int) (string, bool) {
+		hour, minute, second := atoi(group(text, m, 1)), atoi(group(text, m, 2)), atoi(group(text, m, 3))
+		if !validTime(hour, minute, second) {
+			return "", false
+		}
+		reading := chineseInteger(group(text, m, 1)) + "点"
+		if hour == 2 {
+			reading = "两点"
+		}
+		if minute > 0 {
+			if minute < 10 {
+				reading += "零"
+			}
+			reading += chineseInteger(group(text, m, 2)) + "分"
+		}
+		if second > 0 {
+			reading += chineseInteger(group(text, m, 3)) + "秒"
+		}
+		return reading, true
+	})
+	text = replaceFunc(versionRe, text, func(text string, m []int) (string, bool) {
+		parts := strings.Split(text[m[0]:m[1]], ".")
+		for i, part := range parts {
+			parts[i] = chineseNumber(part)
+		}
+		return strings.Join(parts, "点"), true
+	})
+	text = replaceFunc(yearRe, text, func(text string, m []int) (string, bool) {
+		return chineseDigitString(group(text, m, 1)) + "年", true
+	})
+	return replaceFunc(numberRe, text, func(text string, m []int) (string, bool) {
+		n, prefix := parseNumber(text, m)
+		reading := chineseInteger(n.integer)
+		if n.readAsDigits() {
+			reading = chineseDigitString(n.integer)
+		}
+		if n.fraction != "" {
+			reading += "点" + chineseDigitString(n.fraction)
+		}
+
+		suffix := ""
+		switch unit := strings.TrimSpace(n.suffix); {
+		case unit == "%":
+			reading = "百分之" + reading
+		case unit == "‰":
+			reading = "千分之" + reading
+		case chineseUnits[unit] != "":
+			suffix = chineseUnits[unit]
+		default:
+			suffix = n.suffix
+		}
+		if n.negative {
+			if suffix == "摄氏度" || suffix == "华氏度" {
+				reading = "零下" + reading
+			} else {
+				reading = "负" + reading
+			}
+		}
+		return prefix + reading + chineseCurrencies[n.currency] + suffix, true
+	})
+}
+
+// chineseNumber 整数的中文读法（以0开头或位数过多时按数位逐个读）
+func chineseNumber(digits string) string {
+	if len(digits) > maxCardinalDigits || len(digits) > 1 && digits[0] == '0' {
+		return chineseDigitString(digits)
+	}
+	return chineseInteger(digits)
+}
+
+// chineseDigitString 按数位逐个读（如年份、电话号码、小数部分）
+func chineseDigitString(digits string) string {
+	var b strings.Builder
+	for _, d := range digits {
+		b.WriteString(chineseDigits[d-'0'])
+	}
+	return b.String()
+}
+
+// chineseInteger 整数的中文读法（最多12位，如“十万零五百”）
+func chineseInteger(digits string) string {
+	digits = strings.TrimLeft(digits, "0")
+	if digits == "" {
+		return chineseDigits[0]
+	}
+	if len(digits) > maxGroupedDigits {
+		return chineseDigitString(digits)
+	}
+
+	// 从低位起每4位一节
+	var sections []int
+	for end := len(digits); end > 0; end -= 4 {
+		start := end - 4
+		if start < 0 {
+			start = 0
+		}
+		sections = append(sections, atoi(digits[start:end]))
+	}
+
+	var b strings.Builder
+	needZero := false
+	for i := len(sections) - 1; i >= 0; i-- {
+		section := sections[i]
+		if section == 0 {
+			needZero = b.Len() > 0
+			continue
+		}
+		if b.Len() > 0 && (section < 1000 || needZero) {
+			b.WriteString(chineseDigits[0])
+		}
+		b.WriteString(chineseSection(section))
+		b.WriteString(chineseGroupUnits[i])
+		needZero = false
+	}
+
+	// 以10-19开头时读作“十”“十五”而不是“一十”“一十五”
+	reading := b.String()
+	if strings.HasPrefix(reading, "一十") {
+		reading = strings.TrimPrefix(reading, "一")
+	}
+	return reading
+}
+
+// chineseSection 0-9999的中文读法（中间的0读作“零”）
+func chineseSection(section int) string {
+	var b strings.Builder
+	zero := false
+	for pos, unit := 3, 1000; pos >= 0; pos, unit = pos-1, unit/10 {
+		d := section / unit % 10
+		if d == 0 {
+			zero = b.Len() > 0
+			continue
+		}
+		if zero {
+			b.WriteString(chineseDigits[0])
+			zero = false
+		}
+		b.WriteString(chineseDigits[d])
+		b.WriteString(chineseSectionUnits[pos])
+	}
+	return b.String()
+}
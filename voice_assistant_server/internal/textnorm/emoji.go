@@ -0,0 +1,39 @@
+package textnorm
+
+import (
+	"strings"
+	"unicode"
+)
+
+// emojiRanges 表情符号及其修饰符（变体选择符、零宽连接符、肤色、旗帜、标签）
+var emojiRanges = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x200d, Hi: 0x200d, Stride: 1},
+		{Lo: 0x2300, Hi: 0x23ff, Stride: 1},
+		{Lo: 0x2460, Hi: 0x24ff, Stride: 1},
+		{Lo: 0x25a0, Hi: 0x27bf, Stride: 1},
+		{Lo: 0x2900, Hi: 0x297f, Stride: 1},
+		{Lo: 0x2b00, Hi: 0x2bff, Stride: 1},
+		{Lo: 0xfe0e, Hi: 0xfe0f, Stride: 1},
+	},
+	R32: []unicode.Range32{
+		{Lo: 0x1f000, Hi: 0x1faff, Stride: 1},
+		{Lo: 0xe0020, Hi: 0xe007f, Stride: 1},
+	},
+}
+
+// StripEmoji 去除表情符号，并合并因此产生的多余空白
+func StripEmoji(text string) string {
+	stripped := false
+	cleaned := strings.Map(func(r rune) rune {
+		if unicode.Is(emojiRanges, r) {
+			stripped = true
+			return -1
+		}
+		return r
+	}, text)
+	if !stripped {
+		return text
+	}
+	return strings.Join(strings.Fields(cleaned), " ")
+}
@@ -0,0 +1,238 @@
+package textnorm
+
+import (
+	"strconv"
+	"strings"
+)
+
+var (
+	englishOnes = []string{"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine",
+		"ten", "eleven", "twelve", "thirteen", "fourteen", "fifteen", "sixteen", "seventeen", "eighteen", "nineteen"}
+	englishTens   = []string{"", "", "twenty", "thirty", "forty", "fifty", "sixty", "seventy", "eighty", "ninety"}
+	englishScales = []string{"", "thousand", "million", "billion"}
+	englishMonths = []string{"", "January", "February", "March", "April", "May", "June",
+		"July", "August", "September", "October", "November", "December"}
+)
+
+// englishUnits 单位的英文读法（单数、复数，区分大小写）
+var englishUnits = map[string][2]string{
+	"km": {"kilometer", "kilometers"}, "m": {"meter", "meters"}, "cm": {"centimeter", "centimeters"}, "mm": {"millimeter", "millimeters"},
+	"kg": {"kilogram", "kilograms"}, "g": {"gram", "grams"}, "mg": {"milligram", "milligrams"},
+	"L": {"liter", "liters"}, "l": {"liter", "liters"}, "ml": {"milliliter", "milliliters"}, "mL": {"milliliter", "milliliters"},
+	"km/h": {"kilometer per hour", "kilometers per hour"}, "m/s": {"meter per second", "meters per second"}, "mph": {"mile per hour", "miles per hour"},
+	"°C": {"degree Celsius", "degrees Celsius"}, "℃": {"degree Celsius", "degrees Celsius"},
+	"°F": {"degree Fahrenheit", "degrees Fahrenheit"}, "℉": {"degree Fahrenheit", "degrees Fahrenheit"},
+	"kWh": {"kilowatt hour", "kilowatt hours"}, "kW": {"kilowatt", "kilowatts"}, "W": {"watt", "watts"},
+	"Hz": {"hertz", "hertz"}, "kHz": {"kilohertz", "kilohertz"}, "MHz": {"megahertz", "megahertz"}, "GHz": {"gigahertz", "gigahertz"},
+}
+
+// englishCurrencies 货币符号的英文读法（单数、复数，读在数字之后）
+var englishCurrencies = map[string][2]string{
+	"$": {"dollar", "dollars"}, "¥": {"yuan", "yuan"}, "￥": {"yuan", "yuan"}, "€": {"euro", "euros"},
+}
+
+// englishOrdinalSuffixes 序数词后缀（1st、2nd、3rd、4th）
+var englishOrdinalSuffixes = map[string]bool{"st": true, "nd": true, "rd": true, "th": true}
+
+// expandEnglish 按英文读法展开文本中的数字
+func expandEnglish(text string) string {
+	text = replaceFunc(dateRe, text, func(text string, m []int) (string, bool) {
+		month, day := atoi(group(text, m, 2)), atoi(group(text, m, 3))
+		if !validDate(month, day) {
+			return "", false
+		}
+		return englishMonths[month] + " " + englishOrdinal(englishInteger(group(text, m, 3))) + ", " + englishYear(atoi(group(text, m, 1))), true
+	})
+	text = replaceFunc(timeRe, text, func(text string, m []int) (string, bool) {
+		hour, minute, second := atoi(group(text, m, 1)), atoi(group(text, m, 2)), atoi(group(text, m, 3))
+		if !validTime(hour, minute, second) {
+			return "", false
+		}
+		reading := englishInteger(group(text, m, 1))
+		switch {
+		case minute == 0:
+			reading += " o'clock"
+		case minute < 10:
+			reading += " oh " + englishOnes[minute]
+		default:
+			reading += " " + englishInteger(group(text, m, 2))
+		}
+		if second > 0 {
+			reading += " and " + englishPlural(englishInteger(group(text, m, 3)), second == 1, [2]string{"second", "seconds"})
+		}
+		return spaceAfterLetter(text, m[0]) + reading, true
+	})
+	text = replaceFunc(versionRe, text, func(text string, m []int) (string, bool) {
+		parts := strings.Split(text[m[0]:m[1]], ".")
+		for i, part := range parts {
+			parts[i] = englishNumber(part)
+		}
+		return spaceAfterLetter(text, m[0]) + strings.Join(parts, " point "), true
+	})
+	return replaceFunc(numberRe, text, func(text string, m []int) (string, bool) {
+		n, prefix := parseNumber(text, m)
+		reading := englishInteger(n.integer)
+		if n.readAsDigits() {
+			reading = englishDigitString(n.integer)
+		}
+		one := strings.TrimLeft(n.integer, "0") == "1"
+		singular := one && n.fraction == ""
+
+		unit := strings.TrimSpace(n.suffix)
+		if englishOrdinalSuffixes[strings.ToLower(unit)] && n.fraction == "" && unit == n.suffix {
+			return prefix + spaceAfterLetter(text, m[0]) + englishOrdinal(reading), true
+		}
+
+		if currency, ok := englishCurrencies[n.currency]; ok && len(n.fraction) == 2 && currency[0] != "yuan" {
+			// $3.50 读作 three dollars and fifty cents
+			reading = englishPlural(reading, one, currency)
+			if cents := atoi(n.fraction); cents > 0 {
+				reading += " and " + englishPlural(englishInteger(n.fraction), cents == 1, [2]string{"cent", "cents"})
+			}
+		} else {
+			if n.fraction != "" {
+				reading += " point " + englishDigitString(n.fraction)
+			}
+			if currency, ok := englishCurrencies[n.currency]; ok {
+				reading = englishPlural(reading, singular, currency)
+			}
+		}
+
+		switch {
+		case unit == "%":
+			reading += " percent"
+		case unit == "‰":
+			reading += " per mille"
+		case englishUnits[unit] != [2]string{}:
+			reading = englishPlural(reading, singular, englishUnits[unit])
+		case n.suffix != "" && n.suffix == unit:
+			// 紧挨着的未知后缀（如“4K”）与数字隔开
+			reading += " " + unit
+		default:
+			reading += n.suffix
+		}
+		if n.negative {
+			reading = "minus " + reading
+		}
+		return prefix + spaceAfterLetter(text, m[0]) + reading, true
+	})
+}
+
+// spaceAfterLetter 数字紧挨在英文字母之后时（如“GPT4”）补一个空格
+func spaceAfterLetter(text string, start int) string {
+	if precededByLetter(text, start) {
+		return " "
+	}
+	return ""
+}
+
+// englishPlural 数字读法加上单数或复数的单位
+func englishPlural(reading string, singular bool, unit [2]string) string {
+	if singular {
+		return reading + " " + unit[0]
+	}
+	return reading + " " + unit[1]
+}
+
+// englishNumber 整数的英文读法（以0开头或位数过多时按数位逐个读）
+func englishNumber(digits string) string {
+	if len(digits) > maxCardinalDigits || len(digits) > 1 && digits[0] == '0' {
+		return englishDigitString(digits)
+	}
+	return englishInteger(digits)
+}
+
+// englishDigitString 按数位逐个读
+func englishDigitString(digits string) string {
+	words := make([]string, 0, len(digits))
+	for _, d := range digits {
+		words = append(words, englishOnes[d-'0'])
+	}
+	return strings.Join(words, " ")
+}
+
+// englishInteger 整数的英文读法（最多12位，如“one hundred twenty-three”）
+func englishInteger(digits string) string {
+	digits = strings.TrimLeft(digits, "0")
+	if digits == "" {
+		return englishOnes[0]
+	}
+	if len(digits) > maxGroupedDigits {
+		return englishDigitString(digits)
+	}
+
+	// 从低位起每3位一组
+	var groups []int
+	for end := len(digits); end > 0; end -= 3 {
+		start := end - 3
+		if start < 0 {
+			start = 0
+		}
+		groups = append(groups, atoi(digits[start:end]))
+	}
+
+	var words []string
+	for i := len(groups) - 1; i >= 0; i-- {
+		if groups[i] == 0 {
+			continue
+		}
+		words = append(words, englishHundreds(groups[i]))
+		if englishScales[i] != "" {
+			words = append(words, englishScales[i])
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// englishHundreds 1-999的英文读法
+func englishHundreds(n int) string {
+	var words []string
+	if n >= 100 {
+		words = append(words, englishOnes[n/100], "hundred")
+		n %= 100
+	}
+	switch {
+	case n == 0:
+	case n < 20:
+		words = append(words, englishOnes[n])
+	case n%10 == 0:
+		words = append(words, englishTens[n/10])
+	default:
+		words = append(words, englishTens[n/10]+"-"+englishOnes[n%10])
+	}
+	return strings.Join(words, " ")
+}
+
+// englishYear 年份的读法（2024读作twenty twenty-four，2005读作two thousand five）
+func englishYear(year int) string {
+	switch {
+	case year >= 2000 && year < 2010, year < 1000:
+		return englishInteger(strconv.Itoa(year))
+	case year%100 == 0:
+		return englishHundreds(year/100) + " hundred"
+	case year%100 < 10:
+		return englishHundreds(year/100) + " oh " + englishOnes[year%100]
+	default:
+		return englishHundreds(year/100) + " " + englishHundreds(year%100)
+	}
+}
+
+// englishOrdinal 把基数词读法的最后一个词改为序数词（twenty-one -> twenty-first）
+func englishOrdinal(reading string) string {
+	irregular := map[string]string{
+		"one": "first", "two": "second", "three": "third", "five": "fifth",
+		"eight": "eighth", "nine": "ninth", "twelve": "twelfth",
+	}
+
+	cut := strings.LastIndexAny(reading, " -") + 1
+	head, last := reading[:cut], reading[cut:]
+	switch {
+	case irregular[last] != "":
+		last = irregular[last]
+	case strings.HasSuffix(last, "y"):
+		last = strings.TrimSuffix(last, "y") + "ieth"
+	default:
+		last += "th"
+	}
+	return head + last
+}
@@ -0,0 +1,213 @@
+package textnorm
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// 中文数字的值（“幺”为报号码时的一）
+var (
+	zhDigitValues = map[rune]int64{'零': 0, '〇': 0, '幺': 1, '一': 1, '二': 2, '两': 2, '三': 3, '四': 4, '五': 5, '六': 6, '七': 7, '八': 8, '九': 9}
+	zhUnitValues  = map[rune]int64{'十': 10, '百': 100, '千': 1000}
+	zhGroupValues = map[rune]int64{'万': 10000, '亿': 100000000}
+)
+
+// 反向规范化的匹配规则（按顺序转换：时间、百分数、小数、日期、数字串、带单位的数字）
+var (
+	zhClockRe   = regexp.MustCompile(`(早上|早晨|上午|中午|下午|傍晚|晚上|今晚|凌晨)?([零一二两三四五六七八九十]{1,3})点(?:(半|整|一刻|三刻)|(零[一二三四五六七八九]|[二三四五]?十[一二三四五六七八九]?|[一二三四五六七八九])(分)?|(钟))?`)
+	zhPercentRe = regexp.MustCompile(`百分之([零一二两三四五六七八九十百千]+)(?:点([零〇一二三四五六七八九]+))?`)
+	zhDecimalRe = regexp.MustCompile(`([零一二两三四五六七八九十百千万亿]+)点([零〇一二三四五六七八九]+)`)
+	zhYearRe    = regexp.MustCompile(`([零〇一二三四五六七八九]{2,4})年`)
+	zhMonthRe   = regexp.MustCompile(`(十[一二]?|[一二三四五六七八九])月`)
+	zhDayRe     = regexp.MustCompile(`(三十一?|二?十[一二三四五六七八九]?|[一二三四五六七八九])(日|号)`)
+	zhDigitsRe  = regexp.MustCompile(`[零〇幺一二三四五六七八九]{3,}`)
+	zhNumeralRe = regexp.MustCompile(`[零〇一二两三四五六七八九十百千万亿]+`)
+)
+
+// zhNumeralExceptions 含数字但不是数值的常用词
+var zhNumeralExceptions = []string{"一点一点", "一五一十"}
+
+// inverseChinese 把识别文本中的中文数字转为阿拉伯数字（“三点十五”->“3:15”，“百分之五十”->“50%”）
+func inverseChinese(text string) string {
+	for _, word := range zhNumeralExceptions {
+		if strings.Contains(text, word) {
+			return inverseAround(text, word)
+		}
+	}
+
+	text = replaceFunc(zhClockRe, text, func(text string, m []int) (string, bool) {
+		period, marker := group(text, m, 1), group(text, m, 3)
+		hour, ok := parseChineseNumeral(group(text, m, 2))
+		if !ok || hour > 24 {
+			return "", false
+		}
+		minute, hasMinute := int64(0), group(text, m, 4) != ""
+		if hasMinute {
+			minute, _ = parseChineseNumeral(group(text, m, 4))
+		}
+		switch {
+		case marker == "半":
+			minute = 30
+		case marker == "一刻":
+			minute = 15
+		case marker == "三刻":
+			minute = 45
+		case group(text, m, 6) != "":
+			return period + strconv.FormatInt(hour, 10) + "点", true
+		case marker == "整":
+		case hasMinute && (group(text, m, 5) != "" || strings.ContainsAny(group(text, m, 4), "零十")):
+		case period != "" && !hasMinute:
+			// “下午三点”：没有分钟时须有时段，避免把“快一点”当作一点钟
+			return period + strconv.FormatInt(hour, 10) + "点", true
+		default:
+			// “三点五”等按小数处理
+			return "", false
+		}
+		return period + strconv.FormatInt(hour, 10) + ":" + twoDigits(minute), true
+	})
+	text = replaceFunc(zhPercentRe, text, func(text string, m []int) (string, bool) {
+		n, ok := parseChineseNumeral(group(text, m, 1))
+		if !ok {
+			return "", false
+		}
+		reading := strconv.FormatInt(n, 10)
+		if fraction := group(text, m, 2); fraction != "" {
+			reading += "." + chineseDigitsToString(fraction)
+		}
+		return reading + "%", true
+	})
+	text = replaceFunc(zhDecimalRe, text, func(text string, m []int) (string, bool) {
+		n, ok := parseChineseNumeral(group(text, m, 1))
+		if !ok {
+			return "", false
+		}
+		return strconv.FormatInt(n, 10) + "." + chineseDigitsToString(group(text, m, 2)), true
+	})
+	text = replaceFunc(zhYearRe, text, func(text string, m []int) (string, bool) {
+		year := group(text, m, 1)
+		if len([]rune(year)) == 3 {
+			return "", false
+		}
+		return chineseDigitsToString(year) + "年", true
+	})
+	text = replaceFunc(zhMonthRe, text, func(text string, m []int) (string, bool) {
+		if precededByNumeral(text, m[0]) {
+			return "", false
+		}
+		n, _ := parseChineseNumeral(group(text, m, 1))
+		return strconv.FormatInt(n, 10) + "月", true
+	})
+	text = replaceFunc(zhDayRe, text, func(text string, m []int) (string, bool) {
+		n, ok := parseChineseNumeral(group(text, m, 1))
+		if !ok || n < 1 || n > 31 || precededByNumeral(text, m[0]) {
+			return "", false
+		}
+		return strconv.FormatInt(n, 10) + group(text, m, 2), true
+	})
+	// 电话号码、房间号等逐位读的数字串
+	text = zhDigitsRe.ReplaceAllStringFunc(text, chineseDigitsToString)
+	return replaceFunc(zhNumeralRe, text, func(text string, m []int) (string, bool) {
+		numeral := text[m[0]:m[1]]
+		// 只转换带单位且以数字或“十”开头的数（保留“十分”“千万”“万一”等）
+		first := []rune(numeral)[0]
+		if len([]rune(numeral)) < 2 || !strings.ContainsAny(numeral, "十百千万亿") {
+			return "", false
+		}
+		if _, ok := zhDigitValues[first]; !ok && first != '十' {
+			return "", false
+		}
+		n, ok := parseChineseNumeral(numeral)
+		if !ok {
+			return "", false
+		}
+		return strconv.FormatInt(n, 10), true
+	})
+}
+
+// inverseAround 分别转换例外词前后的文本，例外词保持原样
+func inverseAround(text, word string) string {
+	before, after, _ := strings.Cut(text, word)
+	return inverseChinese(before) + word + inverseChinese(after)
+}
+
+// parseChineseNumeral 解析中文数字（“一千零五”“两万三”“十五”），不是合法数值时返回false
+func parseChineseNumeral(s string) (int64, bool) {
+	var total, section int64
+	digit, lastUnit := int64(-1), int64(0)
+	zero := false
+	for _, r := range s {
+		if d, ok := zhDigitValues[r]; ok {
+			if digit > 0 {
+				return 0, false // “五六十”等概数
+			}
+			if d == 0 {
+				zero = true
+			}
+			digit = d
+			continue
+		}
+		if unit, ok := zhUnitValues[r]; ok {
+			switch {
+			case digit < 0 && unit == 10 && section == 0 && lastUnit == 0:
+				digit = 1 // “十五”
+			case digit <= 0:
+				return 0, false
+			}
+			section += digit * unit
+			digit, lastUnit, zero = -1, unit, false
+			continue
+		}
+		groupUnit, ok := zhGroupValues[r]
+		if !ok {
+			return 0, false
+		}
+		if digit > 0 {
+			section += digit
+		}
+		if section == 0 {
+			return 0, false
+		}
+		if groupUnit > 10000 {
+			total = (total + section) * groupUnit
+		} else {
+			total += section * groupUnit
+		}
+		section, digit, lastUnit, zero = 0, -1, groupUnit, false
+	}
+	if digit > 0 {
+		// “两百五”“三万五”省略了末位单位
+		if lastUnit >= 100 && !zero {
+			digit *= lastUnit / 10
+		}
+		section += digit
+	}
+	return total + section, s != ""
+}
+
+// precededByNumeral 匹配前紧挨着中文数字（如“一百二十号”中的“二十号”）
+func precededByNumeral(text string, start int) bool {
+	r, _ := utf8.DecodeLastRuneInString(text[:start])
+	_, digit := zhDigitValues[r]
+	_, unit := zhUnitValues[r]
+	_, groupUnit := zhGroupValues[r]
+	return digit || unit || groupUnit
+}
+
+// chineseDigitsToString 逐位转换中文数字串（“二零二四”->“2024”）
+func chineseDigitsToString(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		b.WriteByte(byte('0' + zhDigitValues[r]))
+	}
+	return b.String()
+}
+
+// twoDigits 两位数的分钟
+func twoDigits(n int64) string {
+	if n < 10 {
+		return "0" + strconv.FormatInt(n, 10)
+	}
+	return strconv.FormatInt(n, 10)
+}
@@ -0,0 +1,117 @@
+package textnorm
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Lexicon 发音词典（不区分大小写，按最长词条优先匹配；以英文字母或数字开头/结尾的词条须在词边界处）
+type Lexicon struct {
+	terms    []string          // 小写，按长度从长到短
+	readings map[string]string // 小写词条 -> 读法
+}
+
+// Segment 按词典切分后的文本片段
+type Segment struct {
+	Text     string
+	Replaced bool // 是否为词典读法
+}
+
+// NewLexicon 创建发音词典（忽略空词条）
+func NewLexicon(entries map[string]string) *Lexicon {
+	lexicon := &Lexicon{readings: make(map[string]string, len(entries))}
+	for term, reading := range entries {
+		term = strings.ToLower(strings.TrimSpace(term))
+		if term == "" {
+			continue
+		}
+		if _, exists := lexicon.readings[term]; !exists {
+			lexicon.terms = append(lexicon.terms, term)
+		}
+		lexicon.readings[term] = reading
+	}
+	sort.SliceStable(lexicon.terms, func(i, j int) bool {
+		if len(lexicon.terms[i]) != len(lexicon.terms[j]) {
+			return len(lexicon.terms[i]) > len(lexicon.terms[j])
+		}
+		return lexicon.terms[i] < lexicon.terms[j]
+	})
+	return lexicon
+}
+
+// ReadLexiconFile 读取YAML格式的发音词典文件（词条: 读法）
+func ReadLexiconFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取发音词典失败: %w", err)
+	}
+	entries := make(map[string]string)
+	if err := yaml.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("解析发音词典失败: %w", err)
+	}
+	return entries, nil
+}
+
+// Len 词条数量
+func (l *Lexicon) Len() int {
+	if l == nil {
+		return 0
+	}
+	return len(l.terms)
+}
+
+// Apply 按词典替换文本，返回原文与读法交替的片段
+func (l *Lexicon) Apply(text string) []Segment {
+	if l.Len() == 0 {
+		return []Segment{{Text: text}}
+	}
+
+	var segments []Segment
+	start := 0
+	for i := 0; i < len(text); {
+		term := l.match(text, i)
+		if term == "" {
+			_, size := utf8.DecodeRuneInString(text[i:])
+			i += size
+			continue
+		}
+		if start < i {
+			segments = append(segments, Segment{Text: text[start:i]})
+		}
+		segments = append(segments, Segment{Text: l.readings[term], Replaced: true})
+		i += len(term)
+		start = i
+	}
+	if start < len(text) {
+		segments = append(segments, Segment{Text: text[start:]})
+	}
+	return segments
+}
+
+// match 返回从text[i]开始匹配的最长词条（未匹配时为空）
+func (l *Lexicon) match(text string, i int) string {
+	for _, term := range l.terms {
+		end := i + len(term)
+		if end > len(text) || !strings.EqualFold(text[i:end], term) {
+			continue
+		}
+		if isWordByte(term[0]) && i > 0 && isWordByte(text[i-1]) {
+			continue
+		}
+		if isWordByte(term[len(term)-1]) && end < len(text) && isWordByte(text[end]) {
+			continue
+		}
+		return term
+	}
+	return ""
+}
+
+// isWordByte 是否为英文字母或数字
+func isWordByte(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9'
+}
@@ -0,0 +1,205 @@
+package textnorm
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 代码块的朗读方式
+const (
+	CodeBlocksNotice = "notice" // 以一句提示代替（默认）
+	CodeBlocksOmit   = "omit"   // 不读
+	CodeBlocksRead   = "read"   // 照读代码内容
+)
+
+// 链接的朗读方式
+const (
+	URLsDomain = "domain" // 只读域名（默认）
+	URLsOmit   = "omit"   // 不读
+	URLsRead   = "read"   // 照读完整链接
+)
+
+// codeBlockNotices 代码块的提示（按语言）
+var codeBlockNotices = map[bool]string{
+	false: "代码已显示在屏幕上。",
+	true:  "The code is shown on screen.",
+}
+
+// Markdown 相关的匹配规则
+var (
+	codeFenceRe    = regexp.MustCompile("(?s)(```|~~~)[^\n]*\n?(.*?)(?:```|~~~|$)")
+	inlineCodeRe   = regexp.MustCompile("`([^`\n]+)`")
+	imageRe        = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	linkRe         = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)(?:\s+"[^"]*")?\)`)
+	urlRe          = regexp.MustCompile(`https?://[A-Za-z0-9\-._~:/?#\[\]@!$&'()*+,;=%]+`)
+	headingRe      = regexp.MustCompile(`^#{1,6}\s+(.*?)\s*#*$`)
+	quoteRe        = regexp.MustCompile(`^(?:>\s?)+`)
+	ruleRe         = regexp.MustCompile(`^(?:[-*_]\s*){3,}$`)
+	tableDividerRe = regexp.MustCompile(`^\|?\s*:?-{3,}:?\s*(?:\|\s*:?-{3,}:?\s*)*\|?$`)
+	listItemRe     = regexp.MustCompile(`^\s*(?:([-*+])|(\d+)[.)])\s+(.*)$`)
+	emphasisRe     = regexp.MustCompile(`\*\*\*(.+?)\*\*\*|\*\*(.+?)\*\*|__(.+?)__|~~(.+?)~~|\*([^*\s](?:[^*]*[^*\s])?)\*`)
+)
+
+// listItem 列表项
+type listItem struct {
+	ordered bool
+	text    string
+}
+
+// speakable 把Markdown格式的回复转为适合朗读的文本：去掉格式标记，代码块和链接按配置处理，列表、标题和表格转为完整的句子
+func (n *Normalizer) speakable(text string, english bool) string {
+	text = codeFenceRe.ReplaceAllStringFunc(text, func(block string) string {
+		switch n.config.CodeBlocks {
+		case CodeBlocksOmit:
+			return "\n"
+		case CodeBlocksRead:
+			return "\n" + codeFenceRe.FindStringSubmatch(block)[2] + "\n"
+		default:
+			return "\n" + codeBlockNotices[english] + "\n"
+		}
+	})
+	text = inlineCodeRe.ReplaceAllString(text, "$1")
+	text = imageRe.ReplaceAllString(text, "$1")
+	text = linkRe.ReplaceAllString(text, "$1")
+	text = urlRe.ReplaceAllStringFunc(text, n.speakableURL)
+
+	var lines []string
+	var items []listItem
+	flush := func() {
+		if len(items) > 0 {
+			lines = append(lines, listProse(items, english))
+			items = nil
+		}
+	}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(quoteRe.ReplaceAllString(strings.TrimSpace(line), ""))
+		if m := listItemRe.FindStringSubmatch(line); m != nil {
+			item := listItem{ordered: m[2] != "", text: stripEmphasis(m[3])}
+			if len(items) > 0 && items[0].ordered != item.ordered {
+				flush()
+			}
+			items = append(items, item)
+			continue
+		}
+		flush()
+
+		switch {
+		case line == "", ruleRe.MatchString(line), tableDividerRe.MatchString(line):
+		case headingRe.MatchString(line):
+			lines = append(lines, endSentence(stripEmphasis(headingRe.FindStringSubmatch(line)[1]), english))
+		case strings.HasPrefix(line, "|"):
+			lines = append(lines, tableRowProse(stripEmphasis(line), english))
+		default:
+			lines = append(lines, stripEmphasis(line))
+		}
+	}
+	flush()
+
+	return strings.Join(lines, "\n")
+}
+
+// speakableURL 按配置朗读链接（只读域名时去掉www.前缀；链接末尾的标点留在原文中）
+func (n *Normalizer) speakableURL(raw string) string {
+	trimmed := strings.TrimRight(raw, ".,;:!?)'")
+	trailing := raw[len(trimmed):]
+
+	switch n.config.URLs {
+	case URLsRead:
+		return raw
+	case URLsOmit:
+		return trailing
+	default:
+		u, err := url.Parse(trimmed)
+		if err != nil || u.Hostname() == "" {
+			return trailing
+		}
+		return strings.TrimPrefix(u.Hostname(), "www.") + trailing
+	}
+}
+
+// stripEmphasis 去掉粗体、斜体和删除线标记
+func stripEmphasis(text string) string {
+	return emphasisRe.ReplaceAllStringFunc(text, func(s string) string {
+		for _, inner := range emphasisRe.FindStringSubmatch(s)[1:] {
+			if inner != "" {
+				return inner
+			}
+		}
+		return s
+	})
+}
+
+// listProse 把连续的同类列表项连成句子：有序列表逐项加“第一”“First”，无序列表用分号连接
+func listProse(items []listItem, english bool) string {
+	parts := make([]string, 0, len(items))
+	ordered := items[0].ordered
+	for i, item := range items {
+		text := trimSentenceEnd(item.text)
+		if text == "" {
+			continue
+		}
+		if ordered {
+			if english {
+				ordinal := englishOrdinal(englishInteger(strconv.Itoa(i + 1)))
+				text = strings.ToUpper(ordinal[:1]) + ordinal[1:] + ", " + text
+			} else {
+				text = "第" + chineseInteger(strconv.Itoa(i+1)) + "，" + text
+			}
+		}
+		parts = append(parts, text)
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+
+	if english {
+		if ordered {
+			return strings.Join(parts, ". ") + "."
+		}
+		return strings.Join(parts, "; ") + "."
+	}
+	if ordered {
+		return strings.Join(parts, "。") + "。"
+	}
+	return strings.Join(parts, "；") + "。"
+}
+
+// tableRowProse 表格的一行读作以逗号分隔的句子
+func tableRowProse(line string, english bool) string {
+	var cells []string
+	for _, cell := range strings.Split(strings.Trim(line, "|"), "|") {
+		if cell = strings.TrimSpace(cell); cell != "" {
+			cells = append(cells, cell)
+		}
+	}
+	if english {
+		return endSentence(strings.Join(cells, ", "), english)
+	}
+	return endSentence(strings.Join(cells, "，"), english)
+}
+
+// endSentence 句末没有标点时补上句号
+func endSentence(text string, english bool) string {
+	if text == "" {
+		return ""
+	}
+	last, _ := utf8.DecodeLastRuneInString(text)
+	if unicode.IsPunct(last) {
+		return text
+	}
+	if english {
+		return text + "."
+	}
+	return text + "。"
+}
+
+// trimSentenceEnd 去掉句末的句号、分号等
+func trimSentenceEnd(text string) string {
+	return strings.TrimRightFunc(strings.TrimSpace(text), func(r rune) bool {
+		return strings.ContainsRune(".;,。；，、", r)
+	})
+}
@@ -0,0 +1,154 @@
+package textnorm
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config 合成前文本规范化配置
+type Config struct {
+	Enabled       bool              `yaml:"enabled"`        // 是否启用
+	Lexicon       map[string]string `yaml:"lexicon"`        // 发音词典：词条 -> 读法（不区分大小写）
+	LexiconFile   string            `yaml:"lexicon_file"`   // 发音词典文件（YAML，词条: 读法），修改后自动重新加载
+	ExpandNumbers bool              `yaml:"expand_numbers"` // 把数字、日期、时间、百分比和单位展开为读法
+	StripEmoji    bool              `yaml:"strip_emoji"`    // 去除表情符号
+	StripMarkdown bool              `yaml:"strip_markdown"` // 去除Markdown标记，列表、标题和表格转为完整的句子
+	CodeBlocks    string            `yaml:"code_blocks"`    // 代码块的朗读方式: notice|omit|read
+	URLs          string            `yaml:"urls"`           // 链接的朗读方式: domain|omit|read
+}
+
+// lexiconCheckInterval 检查词典文件是否修改的最小间隔
+const lexiconCheckInterval = 5 * time.Second
+
+// Normalizer 合成前文本规范化：转为适合朗读的文本、去除表情符号、按发音词典替换、展开数字（对所有TTS提供商生效）
+type Normalizer struct {
+	config Config
+
+	mu        sync.RWMutex
+	lexicon   *Lexicon
+	modTime   time.Time // 已加载的词典文件修改时间
+	checkedAt time.Time // 上次检查词典文件的时间
+}
+
+// NewNormalizer 创建文本规范化（词典文件不存在时只使用配置中的词条）
+func NewNormalizer(config Config) (*Normalizer, error) {
+	switch config.CodeBlocks {
+	case "", CodeBlocksNotice, CodeBlocksOmit, CodeBlocksRead:
+	default:
+		return nil, fmt.Errorf("不支持的代码块朗读方式: %s", config.CodeBlocks)
+	}
+	switch config.URLs {
+	case "", URLsDomain, URLsOmit, URLsRead:
+	default:
+		return nil, fmt.Errorf("不支持的链接朗读方式: %s", config.URLs)
+	}
+
+	n := &Normalizer{config: config}
+	if err := n.load(); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// load 加载配置中的词条和词典文件（文件中的词条覆盖配置中的同名词条）
+func (n *Normalizer) load() error {
+	entries := make(map[string]string, len(n.config.Lexicon))
+	for term, reading := range n.config.Lexicon {
+		entries[term] = reading
+	}
+
+	var modTime time.Time
+	if n.config.LexiconFile != "" {
+		info, err := os.Stat(n.config.LexiconFile)
+		switch {
+		case os.IsNotExist(err):
+		case err != nil:
+			return fmt.Errorf("读取发音词典失败: %w", err)
+		default:
+			fileEntries, err := ReadLexiconFile(n.config.LexiconFile)
+			if err != nil {
+				return err
+			}
+			for term, reading := range fileEntries {
+				entries[term] = reading
+			}
+			modTime = info.ModTime()
+		}
+	}
+
+	lexicon := NewLexicon(entries)
+	n.mu.Lock()
+	n.lexicon = lexicon
+	n.modTime = modTime
+	n.checkedAt = time.Now()
+	n.mu.Unlock()
+	return nil
+}
+
+// reloadIfModified 词典文件修改后重新加载（加载失败时继续使用原词典）
+func (n *Normalizer) reloadIfModified() {
+	if n.config.LexiconFile == "" {
+		return
+	}
+
+	n.mu.Lock()
+	if time.Since(n.checkedAt) < lexiconCheckInterval {
+		n.mu.Unlock()
+		return
+	}
+	n.checkedAt = time.Now()
+	modTime := n.modTime
+	n.mu.Unlock()
+
+	info, err := os.Stat(n.config.LexiconFile)
+	if err != nil || info.ModTime().Equal(modTime) {
+		return
+	}
+	if err := n.load(); err != nil {
+		log.Printf("重新加载发音词典失败: %v", err)
+		return
+	}
+	log.Printf("发音词典已重新加载: %s (%d 条)", n.config.LexiconFile, n.TermCount())
+}
+
+// TermCount 发音词典词条数量
+func (n *Normalizer) TermCount() int {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.lexicon.Len()
+}
+
+// Normalize 规范化待合成的文本（language为TTS语言代码，如zh-CN、en-US，en开头按英文读法展开，其余按中文）
+func (n *Normalizer) Normalize(text, language string) string {
+	english := strings.HasPrefix(strings.ToLower(language), "en")
+	if n.config.StripMarkdown {
+		text = n.speakable(text, english)
+	}
+	if n.config.StripEmoji {
+		text = StripEmoji(text)
+	}
+
+	n.reloadIfModified()
+	n.mu.RLock()
+	lexicon := n.lexicon
+	n.mu.RUnlock()
+
+	var b strings.Builder
+	for _, segment := range lexicon.Apply(text) {
+		// 词典读法原样保留，不再展开其中的数字
+		if segment.Replaced || !n.config.ExpandNumbers {
+			b.WriteString(segment.Text)
+			continue
+		}
+		if english {
+			b.WriteString(expandEnglish(segment.Text))
+		} else {
+			b.WriteString(expandChinese(segment.Text))
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
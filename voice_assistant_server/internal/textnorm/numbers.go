@@ -0,0 +1,117 @@
+package textnorm
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// 数字相关的匹配规则（按顺序展开：日期、时间、版本号、数字及其前后的正负号、货币、百分号和单位）
+var (
+	dateRe    = regexp.MustCompile(`(\d{4})[-/.年](\d{1,2})[-/.月](\d{1,2})日?`)
+	timeRe    = regexp.MustCompile(`(\d{1,2}):(\d{2})(?::(\d{2}))?`)
+	versionRe = regexp.MustCompile(`\d+(?:\.\d+){2,}`)
+	yearRe    = regexp.MustCompile(`(\d{4})年`)
+	numberRe  = regexp.MustCompile(`(-)?([$¥￥€])?(\d{1,3}(?:,\d{3})+|\d+)(\.\d+)?(\s?(?:%|‰|[A-Za-z°℃℉/]+))?`)
+)
+
+// maxCardinalDigits 按数值读的最大整数位数（更长的数字串如电话号码、编号按数位逐个读）
+const (
+	maxCardinalDigits = 9
+	maxGroupedDigits  = 12 // 带千分位分隔符的数字明确是数值，可以更长
+)
+
+// number 匹配到的数字
+type number struct {
+	negative bool
+	currency string
+	integer  string // 去掉千分位分隔符
+	grouped  bool   // 带千分位分隔符
+	fraction string // 小数部分（不含小数点）
+	suffix   string // 紧随的百分号或单位（含前导空白）
+}
+
+// readAsDigits 是否按数位逐个读（以0开头或位数过多的整数）
+func (n number) readAsDigits() bool {
+	if n.grouped {
+		return len(n.integer) > maxGroupedDigits
+	}
+	return len(n.integer) > maxCardinalDigits || len(n.integer) > 1 && n.integer[0] == '0'
+}
+
+// replaceFunc 逐个替换正则匹配，fn返回false时保留原文
+func replaceFunc(re *regexp.Regexp, text string, fn func(text string, m []int) (string, bool)) string {
+	matches := re.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 {
+		return text
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		b.WriteString(text[last:m[0]])
+		if replaced, ok := fn(text, m); ok {
+			b.WriteString(replaced)
+		} else {
+			b.WriteString(text[m[0]:m[1]])
+		}
+		last = m[1]
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// group 第i个子匹配（未参与匹配时为空）
+func group(text string, m []int, i int) string {
+	if m[2*i] < 0 {
+		return ""
+	}
+	return text[m[2*i]:m[2*i+1]]
+}
+
+// atoi 解析已由正则保证为数字的子匹配
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// validDate 月、日是否有效
+func validDate(month, day int) bool {
+	return month >= 1 && month <= 12 && day >= 1 && day <= 31
+}
+
+// validTime 时、分、秒是否有效
+func validTime(hour, minute, second int) bool {
+	return hour <= 24 && minute < 60 && second < 60
+}
+
+// parseNumber 解析numberRe的匹配；负号紧跟在英文字母、数字或小数点之后时不按负数读（避免“3-5”“GPT-4”读成负数）
+func parseNumber(text string, m []int) (number, string) {
+	n := number{
+		currency: group(text, m, 2),
+		integer:  strings.ReplaceAll(group(text, m, 3), ",", ""),
+		grouped:  strings.Contains(group(text, m, 3), ","),
+		fraction: strings.TrimPrefix(group(text, m, 4), "."),
+		suffix:   group(text, m, 5),
+	}
+
+	prefix := ""
+	if group(text, m, 1) != "" {
+		if m[0] > 0 && (isWordByte(text[m[0]-1]) || text[m[0]-1] == '.') {
+			prefix = "-"
+		} else {
+			n.negative = true
+		}
+	}
+	return n, prefix
+}
+
+// precededByLetter 匹配前紧挨着英文字母（如“GPT4”）
+func precededByLetter(text string, start int) bool {
+	return start > 0 && isASCIILetter(text[start-1])
+}
+
+// isASCIILetter 是否为英文字母
+func isASCIILetter(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
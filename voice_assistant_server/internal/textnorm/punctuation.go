@@ -0,0 +1,153 @@
+package textnorm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 问句的判断依据
+var (
+	zhQuestionParticles = []string{"吗", "呢"}
+	zhQuestionWords     = []string{"什么", "怎么", "为什么", "为啥", "哪", "谁", "多少", "多久", "几点", "几号", "星期几",
+		"是不是", "能不能", "可不可以", "有没有", "要不要", "对不对", "好不好", "行不行", "会不会"}
+	enQuestionWords = map[string]bool{
+		"what": true, "who": true, "whom": true, "whose": true, "where": true, "when": true, "why": true, "which": true, "how": true,
+		"is": true, "are": true, "am": true, "was": true, "were": true, "do": true, "does": true, "did": true,
+		"can": true, "could": true, "will": true, "would": true, "should": true, "shall": true, "may": true, "might": true,
+		"have": true, "has": true, "had": true,
+	}
+)
+
+// punctuateRule 按规则恢复标点：中文词间的停顿（空白）改为逗号，句末没有标点时补句号或问号，英文句首字母大写
+func punctuateRule(text string) string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return text
+	}
+
+	if containsHan(text) {
+		text = pausesToCommas(text)
+		if last, _ := utf8.DecodeLastRuneInString(text); !unicode.IsPunct(last) {
+			if isChineseQuestion(text) {
+				text += "？"
+			} else {
+				text += "。"
+			}
+		}
+		return text
+	}
+
+	text = strings.Join(strings.Fields(text), " ")
+	first, size := utf8.DecodeRuneInString(text)
+	text = string(unicode.ToUpper(first)) + text[size:]
+	if last, _ := utf8.DecodeLastRuneInString(text); !unicode.IsPunct(last) {
+		word := strings.ToLower(strings.Fields(text)[0])
+		if enQuestionWords[word] {
+			text += "?"
+		} else {
+			text += "."
+		}
+	}
+	return text
+}
+
+// pausesToCommas 中文之间的空白（识别结果中的停顿）改为逗号，中英文之间的空白保留
+func pausesToCommas(text string) string {
+	fields := strings.Fields(text)
+	var b strings.Builder
+	for i, field := range fields {
+		if i > 0 {
+			previous, _ := utf8.DecodeLastRuneInString(fields[i-1])
+			next, _ := utf8.DecodeRuneInString(field)
+			switch {
+			case unicode.IsPunct(previous) || unicode.IsPunct(next):
+			case unicode.Is(unicode.Han, previous) && unicode.Is(unicode.Han, next):
+				b.WriteString("，")
+			default:
+				b.WriteString(" ")
+			}
+		}
+		b.WriteString(field)
+	}
+	return b.String()
+}
+
+// isChineseQuestion 是否为中文问句（以语气词结尾或含有疑问词）
+func isChineseQuestion(text string) bool {
+	for _, particle := range zhQuestionParticles {
+		if strings.HasSuffix(text, particle) {
+			return true
+		}
+	}
+	return containsAny(text, zhQuestionWords)
+}
+
+// containsHan 是否包含汉字
+func containsHan(text string) bool {
+	for _, r := range text {
+		if unicode.Is(unicode.Han, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsAny 是否包含任一子串
+func containsAny(text string, words []string) bool {
+	for _, word := range words {
+		if strings.Contains(text, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// punctuationModel 标点恢复模型服务（POST {"text","language"}，返回 {"text"}，如封装了 ct-punc 的HTTP服务）
+type punctuationModel struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+// Punctuate 请求模型为文本加标点
+func (m *punctuationModel) Punctuate(ctx context.Context, text, language string) (string, error) {
+	body, err := json.Marshal(map[string]string{"text": text, "language": language})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.url, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if m.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+m.apiKey)
+	}
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("请求标点模型失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("标点模型返回错误: HTTP %d: %s", resp.StatusCode, bytes.TrimSpace(message))
+	}
+
+	var response struct {
+		Text string `json:"text"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return "", fmt.Errorf("解析标点模型结果失败: %w", err)
+	}
+	return strings.TrimSpace(response.Text), nil
+}
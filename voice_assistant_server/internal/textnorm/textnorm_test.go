@@ -0,0 +1,173 @@
+package textnorm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandChinese(t *testing.T) {
+	cases := map[string]string{
+		"今天是2024-05-01":   "今天是二零二四年五月一日",
+		"会议10:05开始":       "会议十点零五分开始",
+		"气温-3℃，湿度45%":     "气温零下三摄氏度，湿度百分之四十五",
+		"票价¥120.5":        "票价一百二十点五元",
+		"共1,234,567人":     "共一百二十三万四千五百六十七人",
+		"增长了110个，100500次": "增长了一百一十个，十万零五百次",
+		"电话13812345678":   "电话一三八一二三四五六七八",
+		"版本1.2.3发布于2023年": "版本一点二点三发布于二零二三年",
+		"3-5天，GPT4":       "三-五天，GPT四",
+	}
+	for input, expected := range cases {
+		assert.Equal(t, expected, expandChinese(input), input)
+	}
+}
+
+func TestExpandEnglish(t *testing.T) {
+	cases := map[string]string{
+		"Meet at 10:05 on 2024-05-01": "Meet at ten oh five on May first, twenty twenty-four",
+		"It costs $3.50 or $1.00":     "It costs three dollars and fifty cents or one dollar",
+		"It is -3°C, 45% humid":       "It is minus three degrees Celsius, forty-five percent humid",
+		"1 km at 5 km/h":              "one kilometer at five kilometers per hour",
+		"21st place, 101 dogs":        "twenty-first place, one hundred one dogs",
+		"GPT4 and 4K video":           "GPT four and four K video",
+		"Call 13812345678":            "Call one three eight one two three four five six seven eight",
+		"On 1999/12/31":               "On December thirty-first, nineteen ninety-nine",
+	}
+	for input, expected := range cases {
+		assert.Equal(t, expected, expandEnglish(input), input)
+	}
+}
+
+func TestStripEmoji(t *testing.T) {
+	assert.Equal(t, "明天天气很好", StripEmoji("明天😀天气👍🏻很好"))
+	assert.Equal(t, "Great job", StripEmoji("Great 👨‍👩‍👧 job ❤️"))
+	assert.Equal(t, "气温3℃", StripEmoji("气温3℃"))
+}
+
+func TestLexicon(t *testing.T) {
+	n, err := NewNormalizer(Config{
+		Enabled:       true,
+		Lexicon:       map[string]string{"GPT-4": "G P T 4", "ai": "人工智能"},
+		ExpandNumbers: true,
+	})
+	require.NoError(t, err)
+
+	// 词典读法不再展开数字；英文词条按整词匹配
+	assert.Equal(t, "G P T 4真好，人工智能 said 三次", n.Normalize("gpt-4真好，AI said 3次", "zh-CN"))
+	assert.Equal(t, "G P T 4 said three times", n.Normalize("GPT-4 said 3 times", "en-US"))
+}
+
+func TestLexiconFileReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lexicon.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("SQL: sequel\n"), 0600))
+
+	n, err := NewNormalizer(Config{Enabled: true, Lexicon: map[string]string{"SQL": "S Q L", "API": "A P I"}, LexiconFile: path})
+	require.NoError(t, err)
+	assert.Equal(t, 2, n.TermCount())
+	assert.Equal(t, "sequel A P I", n.Normalize("SQL API", "en-US"))
+
+	// 文件修改后重新加载
+	require.NoError(t, os.WriteFile(path, []byte("SQL: S Q L\nCLI: C L I\n"), 0600))
+	require.NoError(t, os.Chtimes(path, time.Now().Add(time.Minute), time.Now().Add(time.Minute)))
+	n.mu.Lock()
+	n.checkedAt = time.Time{}
+	n.mu.Unlock()
+	assert.Equal(t, "S Q L C L I", n.Normalize("SQL CLI", "en-US"))
+
+	// 文件格式错误
+	require.NoError(t, os.WriteFile(path, []byte("- not a map"), 0600))
+	_, err = NewNormalizer(Config{Enabled: true, LexiconFile: path})
+	assert.Error(t, err)
+}
+
+func TestSpeakable(t *testing.T) {
+	n, err := NewNormalizer(Config{Enabled: true, StripMarkdown: true})
+	require.NoError(t, err)
+
+	markdown := "## 推荐步骤\n\n1. **安装** Go\n2. 运行 `go build`；\n3. 查看 [文档](https://go.dev/doc/)\n\n- 苹果\n- 香蕉\n\n```go\nfmt.Println(1)\n```\n\n| 名称 | 价格 |\n|---|---|\n| 苹果 | 5元 |\n\n> 详见 https://www.example.com/a?b=1。\n---\n*注意*：snake_case_name 不变"
+	assert.Equal(t, "推荐步骤。\n第一，安装 Go。第二，运行 go build。第三，查看 文档。\n苹果；香蕉。\n代码已显示在屏幕上。\n名称，价格。\n苹果，5元。\n详见 example.com。\n注意：snake_case_name 不变", n.Normalize(markdown, "zh-CN"))
+
+	markdown = "# Steps\n1. Install **Go**.\n2. Run `go build`\n* apples\n* pears"
+	assert.Equal(t, "Steps.\nFirst, Install Go. Second, Run go build.\napples; pears.", n.Normalize(markdown, "en-US"))
+
+	// 代码块和链接的朗读方式
+	n, err = NewNormalizer(Config{Enabled: true, StripMarkdown: true, CodeBlocks: CodeBlocksRead, URLs: URLsOmit})
+	require.NoError(t, err)
+	assert.Equal(t, "运行\nmake\n详见", n.Normalize("运行\n```\nmake\n```\n详见 https://example.com", "zh-CN"))
+
+	_, err = NewNormalizer(Config{Enabled: true, CodeBlocks: "skip"})
+	assert.Error(t, err)
+}
+
+func TestInverseChinese(t *testing.T) {
+	cases := map[string]string{
+		"帮我定三点十五的闹钟":     "帮我定3:15的闹钟",
+		"明天下午两点半开会":      "明天下午2:30开会",
+		"晚上八点提醒我":        "晚上8点提醒我",
+		"六点零五分出发，七点钟到":   "6:05出发，7点到",
+		"快一点，喝一点水":       "快一点，喝一点水",
+		"电量还剩百分之五十五点五":   "电量还剩55.5%",
+		"圆周率是三点一四":       "圆周率是3.14",
+		"二零二四年十二月二十五号":   "2024年12月25号",
+		"打幺三八零零一三八零零零":   "打13800138000",
+		"一共两千三百零五块，两百五个": "一共2305块，250个",
+		"一万五千人，三十多度":     "15000人，30多度",
+		"十分感谢，千万别忘，万一呢":  "十分感谢，千万别忘，万一呢",
+		"五六十个，一点一点来":     "五六十个，一点一点来",
+	}
+	for input, expected := range cases {
+		assert.Equal(t, expected, inverseChinese(input), input)
+	}
+}
+
+func TestPunctuateRule(t *testing.T) {
+	assert.Equal(t, "今天天气怎么样？", punctuateRule("今天天气怎么样"))
+	assert.Equal(t, "好的，那就这样吧。", punctuateRule("好的 那就这样吧"))
+	assert.Equal(t, "打开 WiFi 设置。", punctuateRule("打开 WiFi 设置"))
+	assert.Equal(t, "你好！", punctuateRule("你好！"))
+	assert.Equal(t, "What time is it?", punctuateRule("what time  is it"))
+	assert.Equal(t, "Turn on the lights.", punctuateRule("turn on the lights"))
+}
+
+func TestTranscriptNormalizer(t *testing.T) {
+	n, err := NewTranscriptNormalizer(TranscriptConfig{Enabled: true, InverseNumbers: true})
+	require.NoError(t, err)
+	assert.Equal(t, PunctuationRule, n.Punctuation())
+	assert.Equal(t, "帮我定3:15的闹钟。", n.Normalize(context.Background(), "帮我定三点十五的闹钟", "zh-CN", true))
+	// 中间结果不加标点
+	assert.Equal(t, "帮我定3:15", n.Normalize(context.Background(), "帮我定三点十五", "zh-CN", false))
+
+	// 标点模型，请求失败时按规则处理
+	failing := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		var req map[string]string
+		json.NewDecoder(r.Body).Decode(&req)
+		assert.Equal(t, "zh-CN", req["language"])
+		json.NewEncoder(w).Encode(map[string]string{"text": "嗯，" + req["text"] + "！"})
+	}))
+	defer server.Close()
+
+	n, err = NewTranscriptNormalizer(TranscriptConfig{Enabled: true, InverseNumbers: true, Punctuation: PunctuationModel, PunctuationModel: PunctuationModelConfig{URL: server.URL}})
+	require.NoError(t, err)
+	assert.Equal(t, "嗯，定3:30的闹钟！", n.Normalize(context.Background(), "定三点半的闹钟", "zh-CN", true))
+	failing = true
+	assert.Equal(t, "定3:30的闹钟。", n.Normalize(context.Background(), "定三点半的闹钟", "zh-CN", true))
+
+	_, err = NewTranscriptNormalizer(TranscriptConfig{Enabled: true, Punctuation: PunctuationModel})
+	assert.Error(t, err)
+	_, err = NewTranscriptNormalizer(TranscriptConfig{Enabled: true, Punctuation: "auto"})
+	assert.Error(t, err)
+}
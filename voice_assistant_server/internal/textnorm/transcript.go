@@ -0,0 +1,98 @@
+package textnorm
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// 识别结果的标点恢复方式
+const (
+	PunctuationRule  = "rule"  // 按规则补句末标点和停顿处的逗号（默认）
+	PunctuationModel = "model" // 使用标点模型服务，请求失败时按规则处理
+	PunctuationNone  = "none"  // 不加标点
+)
+
+// defaultPunctuationTimeout 标点模型请求的默认超时
+const defaultPunctuationTimeout = 2 * time.Second
+
+// TranscriptConfig 识别结果规范化配置
+type TranscriptConfig struct {
+	Enabled          bool                   `yaml:"enabled"`           // 是否启用
+	InverseNumbers   bool                   `yaml:"inverse_numbers"`   // 把中文数字、时间、日期和百分数转为阿拉伯数字（如“三点十五”->“3:15”）
+	Punctuation      string                 `yaml:"punctuation"`       // 标点恢复方式: rule|model|none
+	PunctuationModel PunctuationModelConfig `yaml:"punctuation_model"` // 标点模型服务（punctuation为model时）
+}
+
+// PunctuationModelConfig 标点模型服务配置
+type PunctuationModelConfig struct {
+	URL     string        `yaml:"url"`     // 服务地址（POST {"text","language"}，返回 {"text"}）
+	APIKey  string        `yaml:"api_key"` // 可选，作为Bearer令牌发送
+	Timeout time.Duration `yaml:"timeout"` // 请求超时（默认2秒）
+}
+
+// TranscriptNormalizer 识别结果规范化：恢复标点、把中文数字转为阿拉伯数字（反向文本规范化），在识别文本显示和进入LLM之前执行
+type TranscriptNormalizer struct {
+	config TranscriptConfig
+	model  *punctuationModel
+}
+
+// NewTranscriptNormalizer 创建识别结果规范化
+func NewTranscriptNormalizer(config TranscriptConfig) (*TranscriptNormalizer, error) {
+	n := &TranscriptNormalizer{config: config}
+	switch config.Punctuation {
+	case "", PunctuationRule, PunctuationNone:
+	case PunctuationModel:
+		if config.PunctuationModel.URL == "" {
+			return nil, fmt.Errorf("标点模型缺少 url")
+		}
+		timeout := config.PunctuationModel.Timeout
+		if timeout <= 0 {
+			timeout = defaultPunctuationTimeout
+		}
+		n.model = &punctuationModel{
+			url:    config.PunctuationModel.URL,
+			apiKey: config.PunctuationModel.APIKey,
+			client: &http.Client{Timeout: timeout},
+		}
+	default:
+		return nil, fmt.Errorf("不支持的标点恢复方式: %s", config.Punctuation)
+	}
+	return n, nil
+}
+
+// Punctuation 标点恢复方式
+func (n *TranscriptNormalizer) Punctuation() string {
+	if n.config.Punctuation == "" {
+		return PunctuationRule
+	}
+	return n.config.Punctuation
+}
+
+// Normalize 规范化识别文本；中间结果（final为false）只转换数字，不加标点
+// 标点在转换数字之前恢复，标点模型看到的是原始识别文本。
+func (n *TranscriptNormalizer) Normalize(ctx context.Context, text, language string, final bool) string {
+	if text == "" {
+		return text
+	}
+
+	if final {
+		switch {
+		case n.model != nil:
+			punctuated, err := n.model.Punctuate(ctx, text, language)
+			if err != nil || punctuated == "" {
+				log.Printf("标点模型处理失败，按规则加标点: %v", err)
+				punctuated = punctuateRule(text)
+			}
+			text = punctuated
+		case n.config.Punctuation != PunctuationNone:
+			text = punctuateRule(text)
+		}
+	}
+	if n.config.InverseNumbers && containsHan(text) {
+		text = inverseChinese(text)
+	}
+	return text
+}
@@ -1,3 +1,5 @@
+//go:build !no_tts_chattts
+
 package tts
 
 import (
@@ -11,21 +13,11 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 )
 
-// ChatTTSConfig ChatTTS特定配置
-type ChatTTSConfig struct {
-	ModelPath   string  `yaml:"model_path"`  // 模型路径
-	Device      string  `yaml:"device"`      // cpu|cuda
-	Temperature float32 `yaml:"temperature"` // 温度参数
-	TopP        float32 `yaml:"top_p"`       // Top-p参数
-	TopK        int     `yaml:"top_k"`       // Top-k参数
-	SpeakerID   int     `yaml:"speaker_id"`  // 说话人ID
-	NumThreads  int     `yaml:"num_threads"` // 线程数
-}
-
 // ChatTTS ChatTTS实现
 type ChatTTS struct {
 	config TTSConfig
@@ -259,6 +251,16 @@ func (c *ChatTTS) runChatTTS(ctx context.Context, text string) ([]byte, error) {
 
 // buildPythonScript 构建Python脚本
 func (c *ChatTTS) buildPythonScript(text string) string {
+	temperature, topP, topK := c.config.ChatTTSConfig.Temperature, c.config.ChatTTSConfig.TopP, c.config.ChatTTSConfig.TopK
+	if temperature <= 0 {
+		temperature = 0.3
+	}
+	if topP <= 0 {
+		topP = 0.7
+	}
+	if topK <= 0 {
+		topK = 20
+	}
 	return fmt.Sprintf(`
 import json
 import sys
@@ -274,12 +276,13 @@ try:
     chat = ChatTTS.Chat()
     chat.load_models(compile=False)
     
-    # 设置说话人
+    # 设置说话人（同一说话人ID使用相同的随机种子，音色固定）
+    torch.manual_seed(%d)
     spk = chat.sample_random_speaker()
     
     # 合成语音
     texts = ["%s"]
-    wavs = chat.infer(texts, spk_emb=spk, temperature=0.3, top_P=0.7, top_K=20)
+    wavs = chat.infer(texts, spk_emb=spk, temperature=%g, top_P=%g, top_K=%d)
     
     # 保存到临时文件
     temp_file = tempfile.NamedTemporaryFile(suffix='.wav', delete=False)
@@ -309,12 +312,22 @@ except Exception as e:
     }
     print(json.dumps(error_result))
 `,
+		c.speakerSeed(),
 		strings.ReplaceAll(text, `"`, `\"`), // 转义引号
+		temperature, topP, topK,
 		c.config.SampleRate,
 		c.config.SampleRate,
 	)
 }
 
+// speakerSeed 说话人对应的随机种子（声音ID为数字时使用声音ID，否则使用配置的说话人ID）
+func (c *ChatTTS) speakerSeed() int {
+	if id, err := strconv.Atoi(c.config.Voice); err == nil {
+		return id
+	}
+	return c.config.ChatTTSConfig.SpeakerID
+}
+
 // createTempScript 创建临时脚本文件
 func (c *ChatTTS) createTempScript(script string) (string, error) {
 	tempDir := os.TempDir()
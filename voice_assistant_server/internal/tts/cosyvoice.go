@@ -0,0 +1,414 @@
+//go:build !no_tts_cosyvoice
+
+package tts
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"voice_assistant/voice_assistant_server/internal/errs"
+
+	vaaudio "voice_assistant/pkg/audio"
+)
+
+// cosyVoiceSpeakers CosyVoice-300M-SFT内置说话人及其语言
+var cosyVoiceSpeakers = map[string]string{
+	"中文女": "zh-CN",
+	"中文男": "zh-CN",
+	"英文女": "en-US",
+	"英文男": "en-US",
+	"日语男": "ja-JP",
+	"粤语女": "zh-HK",
+	"韩语女": "ko-KR",
+}
+
+// defaultCosyVoiceSpeakers 默认内置说话人顺序
+var defaultCosyVoiceSpeakers = []string{"中文女", "中文男", "英文女", "英文男", "日语男", "粤语女", "韩语女"}
+
+// CosyVoiceTTS CosyVoice实现（通过CosyVoice FastAPI服务合成，支持参考音频克隆声音）
+type CosyVoiceTTS struct {
+	config TTSConfig
+	client *http.Client
+	bank   *VoiceBank
+	mu     sync.RWMutex
+
+	// 状态
+	isInitialized bool
+
+	// 统计信息
+	totalRequests   int64
+	totalCharacters int64
+}
+
+// NewCosyVoiceTTS 创建CosyVoice实例
+func NewCosyVoiceTTS(config TTSConfig) *CosyVoiceTTS {
+	return &CosyVoiceTTS{
+		config: config,
+	}
+}
+
+// Initialize 初始化TTS引擎
+func (c *CosyVoiceTTS) Initialize(config TTSConfig) error {
+	log.Println("初始化CosyVoice引擎...")
+
+	if config.CosyVoiceConfig.URL == "" {
+		config.CosyVoiceConfig.URL = "http://localhost:50000"
+	}
+	if config.CosyVoiceConfig.SampleRate <= 0 {
+		config.CosyVoiceConfig.SampleRate = 22050
+	}
+	if len(config.CosyVoiceConfig.Speakers) == 0 {
+		config.CosyVoiceConfig.Speakers = defaultCosyVoiceSpeakers
+	}
+	if config.Voice == "" {
+		config.Voice = config.CosyVoiceConfig.Speakers[0]
+	}
+	timeout := time.Duration(config.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = 60 * time.Second
+	}
+
+	bank, err := NewVoiceBank(config.CosyVoiceConfig.VoicesDir)
+	if err != nil {
+		return fmt.Errorf("打开克隆声音库失败: %w", err)
+	}
+
+	c.mu.Lock()
+	c.config = config
+	c.client = &http.Client{Timeout: timeout}
+	c.bank = bank
+	c.isInitialized = true
+	c.mu.Unlock()
+
+	log.Printf("CosyVoice引擎初始化成功: %s（已登记%d个克隆声音）", config.CosyVoiceConfig.URL, len(bank.List()))
+	return nil
+}
+
+// SynthesizeText 合成语音
+func (c *CosyVoiceTTS) SynthesizeText(ctx context.Context, text string) (TTSResult, error) {
+	c.mu.RLock()
+	config := c.config
+	initialized := c.isInitialized
+	c.mu.RUnlock()
+
+	if !initialized {
+		return TTSResult{}, ErrTTSNotInitialized
+	}
+	if strings.TrimSpace(text) == "" {
+		return TTSResult{}, ErrInvalidText
+	}
+
+	startTime := time.Now()
+	voiceID := c.resolveVoice(ctx)
+
+	pcm, err := c.inference(ctx, text, voiceID)
+	if err != nil {
+		return TTSResult{}, fmt.Errorf("CosyVoice合成失败: %w", err)
+	}
+
+	c.mu.Lock()
+	c.totalRequests++
+	c.totalCharacters += int64(len([]rune(text)))
+	c.mu.Unlock()
+
+	sampleRate := config.CosyVoiceConfig.SampleRate
+	return TTSResult{
+		AudioData:   vaaudio.EncodeWAV(pcm, sampleRate, 1),
+		Format:      "wav",
+		SampleRate:  sampleRate,
+		Channels:    1,
+		Duration:    int64(len(pcm)) * 1000 / int64(sampleRate*2),
+		Text:        text,
+		Voice:       voiceID,
+		Language:    config.Language,
+		IsComplete:  true,
+		ProcessTime: time.Since(startTime).Milliseconds(),
+		Timestamp:   time.Now().UnixMilli(),
+	}, nil
+}
+
+// SynthesizeTextStream 流式合成语音
+func (c *CosyVoiceTTS) SynthesizeTextStream(ctx context.Context, text string) (<-chan TTSResult, error) {
+	resultChan := make(chan TTSResult, 1)
+
+	go func() {
+		defer close(resultChan)
+
+		result, err := c.SynthesizeText(ctx, text)
+		if err != nil {
+			result.Error = err
+		}
+
+		resultChan <- result
+	}()
+
+	return resultChan, nil
+}
+
+// SynthesizeToFile 合成到文件
+func (c *CosyVoiceTTS) SynthesizeToFile(ctx context.Context, text string, filePath string) error {
+	result, err := c.SynthesizeText(ctx, text)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filePath, result.AudioData, 0644)
+}
+
+// SynthesizeToStream 合成到流
+func (c *CosyVoiceTTS) SynthesizeToStream(ctx context.Context, text string, stream io.Writer) error {
+	result, err := c.SynthesizeText(ctx, text)
+	if err != nil {
+		return err
+	}
+
+	_, err = stream.Write(result.AudioData)
+	return err
+}
+
+// GetSupportedVoices 获取可用声音列表（内置说话人和已登记的克隆声音）
+func (c *CosyVoiceTTS) GetSupportedVoices() []Voice {
+	c.mu.RLock()
+	speakers := c.config.CosyVoiceConfig.Speakers
+	sampleRate := c.config.CosyVoiceConfig.SampleRate
+	bank := c.bank
+	c.mu.RUnlock()
+
+	if len(speakers) == 0 {
+		speakers = defaultCosyVoiceSpeakers
+	}
+
+	voices := make([]Voice, 0, len(speakers))
+	for _, speaker := range speakers {
+		voices = append(voices, Voice{
+			ID:          speaker,
+			Name:        speaker,
+			DisplayName: speaker,
+			Language:    cosyVoiceSpeakers[speaker],
+			Locale:      cosyVoiceSpeakers[speaker],
+			SampleRate:  sampleRate,
+			Provider:    "cosyvoice",
+			Description: "内置说话人",
+		})
+	}
+	if bank != nil {
+		voices = append(voices, bank.List()...)
+	}
+	return voices
+}
+
+// SetVoice 设置声音
+func (c *CosyVoiceTTS) SetVoice(voiceID string) error {
+	if !c.hasVoice(voiceID) {
+		return fmt.Errorf("%w: %s", ErrVoiceNotFound, voiceID)
+	}
+
+	c.mu.Lock()
+	c.config.Voice = voiceID
+	c.mu.Unlock()
+	return nil
+}
+
+// GetSupportedLanguages 获取支持的语言列表
+func (c *CosyVoiceTTS) GetSupportedLanguages() []string {
+	return []string{"zh-CN", "en-US", "ja-JP", "zh-HK", "ko-KR"}
+}
+
+// SetLanguage 设置语言
+func (c *CosyVoiceTTS) SetLanguage(language string) error {
+	c.mu.Lock()
+	c.config.Language = language
+	c.mu.Unlock()
+	return nil
+}
+
+// GetModelInfo 获取模型信息
+func (c *CosyVoiceTTS) GetModelInfo() ModelInfo {
+	c.mu.RLock()
+	sampleRate := c.config.CosyVoiceConfig.SampleRate
+	c.mu.RUnlock()
+
+	return ModelInfo{
+		Name:        "CosyVoice",
+		Version:     "1.0.0",
+		Type:        "neural",
+		Provider:    "cosyvoice",
+		Languages:   c.GetSupportedLanguages(),
+		Voices:      c.GetSupportedVoices(),
+		SampleRates: []int{sampleRate},
+		Formats:     []string{"wav"},
+	}
+}
+
+// Close 关闭TTS引擎
+func (c *CosyVoiceTTS) Close() error {
+	c.mu.Lock()
+	c.isInitialized = false
+	c.mu.Unlock()
+	log.Println("CosyVoice引擎已关闭")
+	return nil
+}
+
+// CheckHealth 检查CosyVoice服务是否可达（收到任何HTTP响应即视为可达）
+func (c *CosyVoiceTTS) CheckHealth(ctx context.Context) error {
+	c.mu.RLock()
+	baseURL := strings.TrimRight(c.config.CosyVoiceConfig.URL, "/")
+	client := c.client
+	c.mu.RUnlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("CosyVoice服务不可达: %w", err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// EnrollVoice 登记参考音频（克隆声音）
+func (c *CosyVoiceTTS) EnrollVoice(ctx context.Context, sample VoiceSample) (Voice, error) {
+	c.mu.RLock()
+	bank := c.bank
+	c.mu.RUnlock()
+
+	if bank == nil {
+		return Voice{}, ErrTTSNotInitialized
+	}
+	if _, builtin := cosyVoiceSpeakers[sample.ID]; builtin {
+		return Voice{}, fmt.Errorf("%w: 不能覆盖内置说话人 %s", ErrInvalidVoice, sample.ID)
+	}
+
+	voice, err := bank.Save(sample, "cosyvoice")
+	if err != nil {
+		return Voice{}, err
+	}
+	log.Printf("CosyVoice登记克隆声音: %s", voice.ID)
+	return voice, nil
+}
+
+// DeleteVoice 删除登记的克隆声音
+func (c *CosyVoiceTTS) DeleteVoice(ctx context.Context, voiceID string) error {
+	c.mu.RLock()
+	bank := c.bank
+	c.mu.RUnlock()
+
+	if bank == nil {
+		return ErrTTSNotInitialized
+	}
+	return bank.Delete(voiceID)
+}
+
+// resolveVoice 获取本次请求使用的声音（请求级设置优先）
+func (c *CosyVoiceTTS) resolveVoice(ctx context.Context) string {
+	if opts := RequestOptionsFromContext(ctx); opts.Voice != "" && c.hasVoice(opts.Voice) {
+		return opts.Voice
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.config.Voice
+}
+
+// hasVoice 判断声音是否为内置说话人或已登记的克隆声音
+func (c *CosyVoiceTTS) hasVoice(voiceID string) bool {
+	c.mu.RLock()
+	speakers := c.config.CosyVoiceConfig.Speakers
+	bank := c.bank
+	c.mu.RUnlock()
+
+	for _, speaker := range speakers {
+		if speaker == voiceID {
+			return true
+		}
+	}
+	return bank != nil && bank.Has(voiceID)
+}
+
+// inference 调用CosyVoice服务：克隆声音使用zero-shot接口，内置说话人使用sft接口，返回16位PCM
+func (c *CosyVoiceTTS) inference(ctx context.Context, text, voiceID string) ([]byte, error) {
+	c.mu.RLock()
+	baseURL := strings.TrimRight(c.config.CosyVoiceConfig.URL, "/")
+	client := c.client
+	bank := c.bank
+	c.mu.RUnlock()
+
+	var req *http.Request
+	entry, reference, cloned, err := bank.Get(voiceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if cloned {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		writer.WriteField("tts_text", text)
+		writer.WriteField("prompt_text", entry.PromptText)
+		part, err := writer.CreateFormFile("prompt_wav", voiceID+".wav")
+		if err != nil {
+			return nil, err
+		}
+		if _, err := part.Write(reference); err != nil {
+			return nil, err
+		}
+		if err := writer.Close(); err != nil {
+			return nil, err
+		}
+
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, baseURL+"/inference_zero_shot", &body)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+	} else {
+		form := url.Values{"tts_text": {text}, "spk_id": {voiceID}}
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, baseURL+"/inference_sft", strings.NewReader(form.Encode()))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrConnectionFailed, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		err := errs.FromHTTPStatus(resp.StatusCode, strings.TrimSpace(string(message)))
+		if _, classified := errs.KindOf(err); !classified {
+			err = fmt.Errorf("%w: %v", ErrSynthesisFailed, err)
+		}
+		return nil, err
+	}
+
+	pcm, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取合成结果失败: %w", err)
+	}
+	if len(pcm) == 0 {
+		return nil, fmt.Errorf("%w: 服务返回空音频", ErrSynthesisFailed)
+	}
+	return pcm[:len(pcm)/2*2], nil
+}
+
+// 注册CosyVoice
+func init() {
+	RegisterTTS("cosyvoice", func(config TTSConfig) (TTSService, error) {
+		return NewCosyVoiceTTS(config), nil
+	})
+}
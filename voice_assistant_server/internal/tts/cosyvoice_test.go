@@ -0,0 +1,95 @@
+//go:build !no_tts_cosyvoice
+
+package tts
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	vaaudio "voice_assistant/pkg/audio"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCosyVoiceSynthesize(t *testing.T) {
+	var paths, voices []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		switch r.URL.Path {
+		case "/inference_sft":
+			voices = append(voices, r.FormValue("spk_id"))
+		case "/inference_zero_shot":
+			require.NoError(t, r.ParseMultipartForm(1<<20))
+			file, _, err := r.FormFile("prompt_wav")
+			require.NoError(t, err)
+			reference, _ := io.ReadAll(file)
+			assert.Equal(t, "RIFF", string(reference[:4]))
+			assert.Equal(t, "参考文本", r.FormValue("prompt_text"))
+			voices = append(voices, "cloned")
+		}
+		assert.Equal(t, "你好", r.FormValue("tts_text"))
+		w.Write([]byte{1, 0, 2, 0, 3})
+	}))
+	defer server.Close()
+
+	c := NewCosyVoiceTTS(TTSConfig{})
+	require.NoError(t, c.Initialize(TTSConfig{
+		CosyVoiceConfig: CosyVoiceConfig{URL: server.URL, SampleRate: 16000, VoicesDir: t.TempDir()},
+	}))
+
+	// 默认使用第一个内置说话人，输出补上WAV头
+	result, err := c.SynthesizeText(context.Background(), "你好")
+	require.NoError(t, err)
+	assert.Equal(t, "中文女", result.Voice)
+	assert.Equal(t, "RIFF", string(result.AudioData[:4]))
+	assert.Len(t, result.AudioData, 44+4)
+
+	// 登记后的克隆声音走zero-shot接口
+	ctx := context.Background()
+	_, err = c.EnrollVoice(ctx, VoiceSample{ID: "alice", PromptText: "参考文本", Audio: vaaudio.EncodeWAV([]byte{0, 0}, 16000, 1)})
+	require.NoError(t, err)
+	result, err = c.SynthesizeText(WithRequestOptions(ctx, RequestOptions{Voice: "alice"}), "你好")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", result.Voice)
+
+	assert.Equal(t, []string{"/inference_sft", "/inference_zero_shot"}, paths)
+	assert.Equal(t, []string{"中文女", "cloned"}, voices)
+}
+
+func TestCosyVoiceEnrollVoice(t *testing.T) {
+	dir := t.TempDir()
+	c := NewCosyVoiceTTS(TTSConfig{})
+	require.NoError(t, c.Initialize(TTSConfig{CosyVoiceConfig: CosyVoiceConfig{VoicesDir: dir}}))
+
+	ctx := context.Background()
+	reference := vaaudio.EncodeWAV([]byte{0, 0}, 16000, 1)
+	for _, sample := range []VoiceSample{
+		{ID: "../alice", PromptText: "参考文本", Audio: reference},
+		{ID: "alice", Audio: reference},
+		{ID: "alice", PromptText: "参考文本", Audio: []byte("not a wav")},
+		{ID: "中文女", PromptText: "参考文本", Audio: reference},
+	} {
+		_, err := c.EnrollVoice(ctx, sample)
+		assert.Error(t, err, sample.ID)
+	}
+
+	voice, err := c.EnrollVoice(ctx, VoiceSample{ID: "alice", Name: "Alice", Language: "zh-CN", PromptText: "参考文本", Audio: reference})
+	require.NoError(t, err)
+	assert.Equal(t, 16000, voice.SampleRate)
+	require.NoError(t, c.SetVoice("alice"))
+
+	// 重新打开后仍可使用
+	reopened := NewCosyVoiceTTS(TTSConfig{})
+	require.NoError(t, reopened.Initialize(TTSConfig{CosyVoiceConfig: CosyVoiceConfig{VoicesDir: dir}}))
+	voices := reopened.GetSupportedVoices()
+	assert.Equal(t, "alice", voices[len(voices)-1].ID)
+	assert.Equal(t, "zh-CN", voices[len(voices)-1].Language)
+
+	require.NoError(t, reopened.DeleteVoice(ctx, "alice"))
+	assert.ErrorIs(t, reopened.DeleteVoice(ctx, "alice"), ErrVoiceNotFound)
+	assert.Error(t, reopened.SetVoice("alice"))
+}
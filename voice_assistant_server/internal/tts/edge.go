@@ -1,3 +1,5 @@
+//go:build !no_tts_edge
+
 package tts
 
 import (
@@ -127,6 +129,12 @@ func (e *EdgeTTS) SynthesizeText(ctx context.Context, text string) (TTSResult, e
 
 	processTime := time.Since(startTime)
 
+	voice := e.resolveVoice(ctx)
+	language := e.config.Language
+	if opts := RequestOptionsFromContext(ctx); opts.Language != "" {
+		language = opts.Language
+	}
+
 	result := TTSResult{
 		AudioData:   audioData,
 		Format:      "mp3",
@@ -134,8 +142,8 @@ func (e *EdgeTTS) SynthesizeText(ctx context.Context, text string) (TTSResult, e
 		Channels:    1,
 		Duration:    int64(len(audioData) * 1000 / (24000 * 1 * 2)),
 		Text:        text,
-		Voice:       e.currentVoice,
-		Language:    e.config.Language,
+		Voice:       voice,
+		Language:    language,
 		IsComplete:  true,
 		ProcessTime: processTime.Milliseconds(),
 		ModelInfo:   "Edge-TTS",
@@ -300,13 +308,15 @@ func (e *EdgeTTS) synthesize(ctx context.Context, text string) ([]byte, error) {
 	}
 
 	// 发送SSML消息
-	ssmlMsg := e.buildSSMLMessage(text)
+	speed, pitch, volume := resolveProsody(ctx, e.config)
+	ssmlMsg := e.buildSSMLMessage(text, e.resolveVoice(ctx), speed, pitch, volume)
 	if err := e.conn.WriteMessage(websocket.TextMessage, []byte(ssmlMsg)); err != nil {
 		return nil, err
 	}
 
 	// 接收音频数据
 	var audioData []byte
+receive:
 	for {
 		select {
 		case <-ctx.Done():
@@ -326,7 +336,7 @@ func (e *EdgeTTS) synthesize(ctx context.Context, text string) ([]byte, error) {
 				}
 			} else if messageType == websocket.TextMessage {
 				if strings.Contains(string(data), "turn.end") {
-					break
+					break receive
 				}
 			}
 		}
@@ -347,7 +357,7 @@ Path:speech.config
 }
 
 // buildSSMLMessage 构建SSML消息
-func (e *EdgeTTS) buildSSMLMessage(text string) string {
+func (e *EdgeTTS) buildSSMLMessage(text, voice string, speed, pitch, volume float32) string {
 	timestamp := time.Now().Format("Mon Jan 02 2006 15:04:05 GMT-0700 (MST)")
 	requestId := e.generateRequestID()
 
@@ -358,11 +368,11 @@ func (e *EdgeTTS) buildSSMLMessage(text string) string {
 </prosody>
 </voice>
 </speak>`,
-		e.getLanguageFromVoice(),
-		e.currentVoice,
-		e.formatRate(),
-		e.formatPitch(),
-		e.formatVolume(),
+		e.getLanguageFromVoice(voice),
+		voice,
+		formatRate(speed),
+		formatPitch(pitch),
+		formatVolume(volume),
 		text)
 
 	return fmt.Sprintf(`X-RequestId:%s
@@ -383,37 +393,45 @@ func (e *EdgeTTS) generateRequestID() string {
 	return fmt.Sprintf("%d", time.Now().UnixNano())
 }
 
-// formatRate 格式化语速
-func (e *EdgeTTS) formatRate() string {
-	if e.config.Speed == 0 {
+// formatRate 格式化语速（倍率转换为相对百分比）
+func formatRate(speed float32) string {
+	if speed == 0 {
 		return "+0%"
 	}
-	return fmt.Sprintf("%+.0f%%", (e.config.Speed-1)*100)
+	return fmt.Sprintf("%+.0f%%", (speed-1)*100)
 }
 
-// formatPitch 格式化音调
-func (e *EdgeTTS) formatPitch() string {
-	if e.config.Pitch == 0 {
-		return "+0Hz"
+// formatPitch 格式化音调（倍率转换为相对百分比，1.0为原始音调）
+func formatPitch(pitch float32) string {
+	if pitch == 0 {
+		return "+0%"
 	}
-	return fmt.Sprintf("%+.0fHz", e.config.Pitch*100)
+	return fmt.Sprintf("%+.0f%%", (pitch-1)*100)
 }
 
-// formatVolume 格式化音量
-func (e *EdgeTTS) formatVolume() string {
-	if e.config.Volume == 0 {
+// formatVolume 格式化音量（倍率转换为相对百分比）
+func formatVolume(volume float32) string {
+	if volume == 0 {
 		return "+0%"
 	}
-	return fmt.Sprintf("%+.0f%%", (e.config.Volume-1)*100)
+	return fmt.Sprintf("%+.0f%%", (volume-1)*100)
+}
+
+// resolveVoice 获取本次请求使用的声音（请求级声音优先）
+func (e *EdgeTTS) resolveVoice(ctx context.Context) string {
+	if opts := RequestOptionsFromContext(ctx); opts.Voice != "" {
+		return opts.Voice
+	}
+	return e.currentVoice
 }
 
 // getLanguageFromVoice 从声音获取语言
-func (e *EdgeTTS) getLanguageFromVoice() string {
-	if strings.HasPrefix(e.currentVoice, "zh-CN") {
+func (e *EdgeTTS) getLanguageFromVoice(voice string) string {
+	if strings.HasPrefix(voice, "zh-CN") {
 		return "zh-CN"
-	} else if strings.HasPrefix(e.currentVoice, "en-US") {
+	} else if strings.HasPrefix(voice, "en-US") {
 		return "en-US"
-	} else if strings.HasPrefix(e.currentVoice, "ja-JP") {
+	} else if strings.HasPrefix(voice, "ja-JP") {
 		return "ja-JP"
 	}
 	return "zh-CN"
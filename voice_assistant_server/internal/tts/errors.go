@@ -1,31 +1,35 @@
 package tts
 
-import "errors"
+import (
+	"errors"
 
-// TTS相关错误定义
+	"voice_assistant/voice_assistant_server/internal/errs"
+)
+
+// TTS相关错误定义（与ASR、LLM共有的错误为errs中的同一值，按errs的类别对应协议错误代码）
 var (
-	ErrUnsupportedTTSType   = errors.New("unsupported TTS type")
-	ErrTTSNotInitialized    = errors.New("TTS service not initialized")
-	ErrInvalidVoice         = errors.New("invalid voice")
-	ErrVoiceNotFound        = errors.New("voice not found")
-	ErrModelNotFound        = errors.New("TTS model not found")
-	ErrModelLoadFailed      = errors.New("failed to load TTS model")
+	ErrUnsupportedTTSType   = errs.New(errs.KindMisconfigured, "unsupported TTS type")
+	ErrTTSNotInitialized    = errs.New(errs.KindUnavailable, "TTS service not initialized")
+	ErrInvalidVoice         = errs.New(errs.KindInvalidInput, "invalid voice")
+	ErrVoiceNotFound        = errs.New(errs.KindInvalidInput, "voice not found")
+	ErrModelNotFound        = errs.ErrModelNotFound
+	ErrModelLoadFailed      = errs.ErrModelLoadFailed
 	ErrSynthesisFailed      = errors.New("text synthesis failed")
-	ErrInvalidText          = errors.New("invalid text for synthesis")
-	ErrTextTooLong          = errors.New("text too long for synthesis")
-	ErrInvalidConfig        = errors.New("invalid TTS configuration")
-	ErrConnectionFailed     = errors.New("failed to connect to TTS service")
-	ErrTimeout              = errors.New("TTS processing timeout")
-	ErrAPIKeyMissing        = errors.New("API key is missing")
-	ErrAPIKeyInvalid        = errors.New("API key is invalid")
-	ErrRateLimitExceeded    = errors.New("rate limit exceeded")
-	ErrQuotaExceeded        = errors.New("quota exceeded")
-	ErrLanguageNotSupported = errors.New("language not supported")
-	ErrFormatNotSupported   = errors.New("audio format not supported")
-	ErrInvalidSampleRate    = errors.New("invalid sample rate")
-	ErrInvalidChannels      = errors.New("invalid number of channels")
-	ErrInsufficientMemory   = errors.New("insufficient memory for TTS model")
-	ErrGPUNotAvailable      = errors.New("GPU not available for TTS")
+	ErrInvalidText          = errs.New(errs.KindInvalidInput, "invalid text for synthesis")
+	ErrTextTooLong          = errs.New(errs.KindInvalidInput, "text too long for synthesis")
+	ErrInvalidConfig        = errs.ErrInvalidConfig
+	ErrConnectionFailed     = errs.ErrConnectionFailed
+	ErrTimeout              = errs.ErrTimeout
+	ErrAPIKeyMissing        = errs.ErrAPIKeyMissing
+	ErrAPIKeyInvalid        = errs.ErrAPIKeyInvalid
+	ErrRateLimitExceeded    = errs.ErrRateLimitExceeded
+	ErrQuotaExceeded        = errs.ErrQuotaExceeded
+	ErrLanguageNotSupported = errs.ErrLanguageNotSupported
+	ErrFormatNotSupported   = errs.New(errs.KindInvalidInput, "audio format not supported")
+	ErrInvalidSampleRate    = errs.New(errs.KindMisconfigured, "invalid sample rate")
+	ErrInvalidChannels      = errs.New(errs.KindMisconfigured, "invalid number of channels")
+	ErrInsufficientMemory   = errs.ErrInsufficientMemory
+	ErrGPUNotAvailable      = errs.ErrGPUNotAvailable
 	ErrFileWriteFailed      = errors.New("failed to write audio file")
 	ErrStreamWriteFailed    = errors.New("failed to write to audio stream")
 )
@@ -2,7 +2,9 @@ package tts
 
 import (
 	"context"
+	"fmt"
 	"io"
+	"sort"
 )
 
 // TTSService TTS服务接口
@@ -48,7 +50,7 @@ type TTSConfig struct {
 	Language   string  `yaml:"language"`    // 语言代码
 	SampleRate int     `yaml:"sample_rate"` // 采样率
 	Channels   int     `yaml:"channels"`    // 声道数
-	Format     string  `yaml:"format"`      // 音频格式 wav|mp3|ogg|flac
+	Format     string  `yaml:"format"`      // 音频格式 wav|pcm|mp3|ogg|flac
 	Quality    string  `yaml:"quality"`     // 音质 low|medium|high
 	Speed      float32 `yaml:"speed"`       // 语速
 	Pitch      float32 `yaml:"pitch"`       // 音调
@@ -65,6 +67,12 @@ type TTSConfig struct {
 
 	// PaddleSpeech特定配置
 	PaddleConfig PaddleConfig `yaml:"paddle"`
+
+	// CosyVoice特定配置
+	CosyVoiceConfig CosyVoiceConfig `yaml:"cosyvoice"`
+
+	// ChatTTS特定配置
+	ChatTTSConfig ChatTTSConfig `yaml:"chattts"`
 }
 
 // EdgeConfig Edge-TTS配置
@@ -96,6 +104,25 @@ type PaddleConfig struct {
 	EnableMKLDNN bool   `yaml:"enable_mkldnn"` // 启用MKLDNN
 }
 
+// ChatTTSConfig ChatTTS特定配置
+type ChatTTSConfig struct {
+	ModelPath   string  `yaml:"model_path"`  // 模型路径
+	Device      string  `yaml:"device"`      // cpu|cuda
+	Temperature float32 `yaml:"temperature"` // 温度参数
+	TopP        float32 `yaml:"top_p"`       // Top-p参数
+	TopK        int     `yaml:"top_k"`       // Top-k参数
+	SpeakerID   int     `yaml:"speaker_id"`  // 说话人ID
+	NumThreads  int     `yaml:"num_threads"` // 线程数
+}
+
+// CosyVoiceConfig CosyVoice服务配置
+type CosyVoiceConfig struct {
+	URL        string   `yaml:"url"`         // 服务地址（CosyVoice FastAPI服务）
+	SampleRate int      `yaml:"sample_rate"` // 输出采样率（CosyVoice 22050，CosyVoice2 24000）
+	Speakers   []string `yaml:"speakers"`    // 内置说话人（为空时使用默认列表）
+	VoicesDir  string   `yaml:"voices_dir"`  // 克隆声音的参考音频目录
+}
+
 // Voice 声音信息
 type Voice struct {
 	ID          string   `json:"id"`           // 声音ID
@@ -164,6 +191,69 @@ type AudioFormat struct {
 	Quality    string `json:"quality"`     // 音质
 }
 
+// VoiceCloner 支持参考音频克隆声音的TTS服务（可选实现）
+type VoiceCloner interface {
+	// EnrollVoice 登记参考音频，之后可以用返回的声音ID合成
+	EnrollVoice(ctx context.Context, sample VoiceSample) (Voice, error)
+
+	// DeleteVoice 删除登记的声音
+	DeleteVoice(ctx context.Context, voiceID string) error
+}
+
+// HealthChecker 可主动探测后端状态的TTS服务（可选实现，供就绪检查使用）
+type HealthChecker interface {
+	// CheckHealth 检查模型文件或合成服务是否可用
+	CheckHealth(ctx context.Context) error
+}
+
+// VoiceSample 克隆声音的参考样本
+type VoiceSample struct {
+	ID         string // 声音ID（字母、数字、下划线和连字符）
+	Name       string // 显示名称
+	Language   string // 语言代码
+	PromptText string // 参考音频的文字内容
+	Audio      []byte // 参考音频（WAV，建议3-10秒的清晰人声）
+}
+
+// RequestOptions 单次请求选项（通过context传递，覆盖服务级配置）
+type RequestOptions struct {
+	Voice    string  // 声音ID
+	Language string  // 语言代码
+	Speed    float32 // 语速倍率（0表示使用服务配置）
+	Pitch    float32 // 音调倍率（0表示使用服务配置）
+	Volume   float32 // 音量倍率（0表示使用服务配置）
+}
+
+// requestOptionsKey context键
+type requestOptionsKey struct{}
+
+// WithRequestOptions 将请求选项附加到context
+func WithRequestOptions(ctx context.Context, opts RequestOptions) context.Context {
+	return context.WithValue(ctx, requestOptionsKey{}, opts)
+}
+
+// RequestOptionsFromContext 从context获取请求选项
+func RequestOptionsFromContext(ctx context.Context) RequestOptions {
+	opts, _ := ctx.Value(requestOptionsKey{}).(RequestOptions)
+	return opts
+}
+
+// resolveProsody 获取本次请求的语速、音调、音量（请求级设置优先）
+func resolveProsody(ctx context.Context, config TTSConfig) (speed, pitch, volume float32) {
+	speed, pitch, volume = config.Speed, config.Pitch, config.Volume
+	opts := RequestOptionsFromContext(ctx)
+	if opts.Speed > 0 {
+		speed = opts.Speed
+	}
+	if opts.Pitch > 0 {
+		pitch = opts.Pitch
+	}
+	if opts.Volume > 0 {
+		volume = opts.Volume
+	}
+	return speed, pitch, volume
+}
+
 // TTSFactory TTS工厂函数类型
 type TTSFactory func(config TTSConfig) (TTSService, error)
 
@@ -179,16 +269,17 @@ func RegisterTTS(name string, factory TTSFactory) {
 func CreateTTS(config TTSConfig) (TTSService, error) {
 	factory, exists := ttsFactories[config.Type]
 	if !exists {
-		return nil, ErrUnsupportedTTSType
+		return nil, fmt.Errorf("%w: %s（当前二进制已编译: %v）", ErrUnsupportedTTSType, config.Type, GetAvailableTTSTypes())
 	}
 	return factory(config)
 }
 
-// GetAvailableTTSTypes 获取可用的TTS类型
+// GetAvailableTTSTypes 获取可用（已编译进当前二进制）的TTS类型
 func GetAvailableTTSTypes() []string {
 	types := make([]string, 0, len(ttsFactories))
 	for t := range ttsFactories {
 		types = append(types, t)
 	}
+	sort.Strings(types)
 	return types
 }
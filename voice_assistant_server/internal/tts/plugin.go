@@ -0,0 +1,302 @@
+package tts
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// PluginCaller 外部插件进程的调用接口（由 plugins 包实现）
+type PluginCaller interface {
+	Call(ctx context.Context, method string, params, result interface{}) error
+}
+
+// PluginTTS 由外部插件进程提供的TTS服务（如单独发布的 sherpa、piper 合成程序）
+type PluginTTS struct {
+	caller          PluginCaller
+	provider        string
+	config          TTSConfig
+	isInitialized   bool
+	mu              sync.RWMutex
+	modelInfo       ModelInfo
+	supportedVoices []Voice
+	currentVoice    string
+}
+
+// pluginTTSSettings 初始化时传给插件的配置
+type pluginTTSSettings struct {
+	Provider   string `json:"provider"`
+	Voice      string `json:"voice,omitempty"`
+	Language   string `json:"language,omitempty"`
+	SampleRate int    `json:"sample_rate,omitempty"`
+	Format     string `json:"format,omitempty"`
+	APIKey     string `json:"api_key,omitempty"`
+	APIUrl     string `json:"api_url,omitempty"`
+}
+
+// pluginTTSInfo 插件返回的模型信息
+type pluginTTSInfo struct {
+	Model     string   `json:"model"`
+	Version   string   `json:"version"`
+	Languages []string `json:"languages"`
+	Voices    []Voice  `json:"voices"`
+}
+
+// pluginSynthesizeRequest 合成请求
+type pluginSynthesizeRequest struct {
+	Provider string  `json:"provider"`
+	Text     string  `json:"text"`
+	Voice    string  `json:"voice,omitempty"`
+	Language string  `json:"language,omitempty"`
+	Speed    float32 `json:"speed,omitempty"`
+	Pitch    float32 `json:"pitch,omitempty"`
+	Volume   float32 `json:"volume,omitempty"`
+}
+
+// pluginSynthesizeResult 合成结果
+type pluginSynthesizeResult struct {
+	Audio      []byte `json:"audio"`  // base64
+	Format     string `json:"format"` // 默认pcm
+	SampleRate int    `json:"sample_rate"`
+	Channels   int    `json:"channels"`
+	Duration   int64  `json:"duration"` // 毫秒（为0时按16位PCM估算）
+}
+
+// pluginHealthRequest 健康检查请求
+type pluginHealthRequest struct {
+	Provider string `json:"provider"`
+}
+
+// NewPluginTTS 创建插件TTS实例
+func NewPluginTTS(caller PluginCaller, provider string, config TTSConfig) *PluginTTS {
+	return &PluginTTS{caller: caller, provider: provider, config: config}
+}
+
+// Initialize 初始化插件TTS（插件加载模型）
+func (p *PluginTTS) Initialize(config TTSConfig) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	log.Printf("PluginTTS: 初始化中 (%s)...", p.provider)
+
+	var info pluginTTSInfo
+	err := p.caller.Call(context.Background(), "tts.initialize", pluginTTSSettings{
+		Provider:   p.provider,
+		Voice:      config.Voice,
+		Language:   config.Language,
+		SampleRate: config.SampleRate,
+		Format:     config.Format,
+		APIKey:     config.APIKey,
+		APIUrl:     config.APIUrl,
+	}, &info)
+	if err != nil {
+		return fmt.Errorf("插件TTS初始化失败: %w", err)
+	}
+
+	name := info.Model
+	if name == "" {
+		name = p.provider
+	}
+	for i := range info.Voices {
+		if info.Voices[i].Provider == "" {
+			info.Voices[i].Provider = p.provider
+		}
+	}
+	p.supportedVoices = info.Voices
+	p.currentVoice = config.Voice
+	p.modelInfo = ModelInfo{
+		Name:      name,
+		Version:   info.Version,
+		Type:      "text-to-speech",
+		Provider:  p.provider,
+		Languages: info.Languages,
+		Voices:    info.Voices,
+		LoadTime:  time.Now().UnixMilli(),
+	}
+
+	p.config = config
+	p.isInitialized = true
+
+	log.Printf("PluginTTS: 初始化成功 (%s, 声音: %d)", name, len(info.Voices))
+	return nil
+}
+
+// SynthesizeText 合成文本
+func (p *PluginTTS) SynthesizeText(ctx context.Context, text string) (TTSResult, error) {
+	p.mu.RLock()
+	initialized, config, voice := p.isInitialized, p.config, p.currentVoice
+	p.mu.RUnlock()
+
+	if !initialized {
+		return TTSResult{}, ErrTTSNotInitialized
+	}
+
+	startTime := time.Now()
+	opts := RequestOptionsFromContext(ctx)
+	if opts.Voice != "" {
+		voice = opts.Voice
+	}
+	language := config.Language
+	if opts.Language != "" {
+		language = opts.Language
+	}
+	speed, pitch, volume := resolveProsody(ctx, config)
+
+	var result pluginSynthesizeResult
+	err := p.caller.Call(ctx, "tts.synthesize", pluginSynthesizeRequest{
+		Provider: p.provider,
+		Text:     text,
+		Voice:    voice,
+		Language: language,
+		Speed:    speed,
+		Pitch:    pitch,
+		Volume:   volume,
+	}, &result)
+	if err != nil {
+		return TTSResult{}, fmt.Errorf("%w: %v", ErrSynthesisFailed, err)
+	}
+
+	if result.Format == "" {
+		result.Format = "pcm"
+	}
+	if result.SampleRate == 0 {
+		result.SampleRate = config.SampleRate
+	}
+	if result.Channels == 0 {
+		result.Channels = 1
+	}
+	if result.Duration == 0 && result.SampleRate > 0 {
+		result.Duration = int64(len(result.Audio) * 1000 / (result.SampleRate * result.Channels * 2))
+	}
+
+	return TTSResult{
+		AudioData:   result.Audio,
+		Format:      result.Format,
+		SampleRate:  result.SampleRate,
+		Channels:    result.Channels,
+		Duration:    result.Duration,
+		Text:        text,
+		Voice:       voice,
+		Language:    language,
+		IsComplete:  true,
+		ProcessTime: time.Since(startTime).Milliseconds(),
+		ModelInfo:   p.modelInfo.Name,
+		Timestamp:   time.Now().UnixMilli(),
+	}, nil
+}
+
+// SynthesizeTextStream 流式合成文本（插件一次返回完整音频）
+func (p *PluginTTS) SynthesizeTextStream(ctx context.Context, text string) (<-chan TTSResult, error) {
+	resultChan := make(chan TTSResult, 1)
+
+	go func() {
+		defer close(resultChan)
+
+		result, err := p.SynthesizeText(ctx, text)
+		if err != nil {
+			result.Error = err
+		}
+
+		resultChan <- result
+	}()
+
+	return resultChan, nil
+}
+
+// SynthesizeToFile 合成到文件
+func (p *PluginTTS) SynthesizeToFile(ctx context.Context, text string, filePath string) error {
+	result, err := p.SynthesizeText(ctx, text)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(filePath, result.AudioData, 0644); err != nil {
+		return ErrFileWriteFailed
+	}
+	return nil
+}
+
+// SynthesizeToStream 合成到流
+func (p *PluginTTS) SynthesizeToStream(ctx context.Context, text string, stream io.Writer) error {
+	result, err := p.SynthesizeText(ctx, text)
+	if err != nil {
+		return err
+	}
+
+	if _, err := stream.Write(result.AudioData); err != nil {
+		return ErrStreamWriteFailed
+	}
+	return nil
+}
+
+// GetSupportedVoices 获取支持的声音列表
+func (p *PluginTTS) GetSupportedVoices() []Voice {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.supportedVoices
+}
+
+// SetVoice 设置声音（插件未声明声音列表时不检查）
+func (p *PluginTTS) SetVoice(voiceID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.supportedVoices) == 0 {
+		p.currentVoice = voiceID
+		return nil
+	}
+	for _, voice := range p.supportedVoices {
+		if voice.ID == voiceID {
+			p.currentVoice = voiceID
+			return nil
+		}
+	}
+	return ErrVoiceNotFound
+}
+
+// GetSupportedLanguages 获取支持的语言列表
+func (p *PluginTTS) GetSupportedLanguages() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.modelInfo.Languages
+}
+
+// SetLanguage 设置语言
+func (p *PluginTTS) SetLanguage(language string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, lang := range p.modelInfo.Languages {
+		if lang == language {
+			p.config.Language = language
+			return nil
+		}
+	}
+	return ErrLanguageNotSupported
+}
+
+// GetModelInfo 获取模型信息
+func (p *PluginTTS) GetModelInfo() ModelInfo {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.modelInfo
+}
+
+// Close 关闭插件TTS（插件进程由插件管理器停止）
+func (p *PluginTTS) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.isInitialized = false
+	log.Printf("PluginTTS: 已关闭 (%s)", p.provider)
+	return nil
+}
+
+// CheckHealth 检查插件进程是否可用
+func (p *PluginTTS) CheckHealth(ctx context.Context) error {
+	return p.caller.Call(ctx, "health", pluginHealthRequest{Provider: p.provider}, nil)
+}
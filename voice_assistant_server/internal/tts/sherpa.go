@@ -1,3 +1,5 @@
+//go:build !no_tts_sherpa
+
 package tts
 
 import (
@@ -64,7 +66,7 @@ func (s *SherpaTTS) SynthesizeText(ctx context.Context, text string) (TTSResult,
 	log.Printf("Sherpa-ONNX合成语音: %s", text)
 
 	// 构建命令行参数
-	args := s.buildCommandArgs(text)
+	args := s.buildCommandArgs(ctx, text)
 
 	// 执行TTS命令
 	cmd := exec.CommandContext(ctx, "sherpa-onnx-offline-tts", args...)
@@ -208,6 +210,11 @@ func (s *SherpaTTS) Close() error {
 	return nil
 }
 
+// CheckHealth 检查模型文件是否仍然存在
+func (s *SherpaTTS) CheckHealth(ctx context.Context) error {
+	return s.validateModelFiles()
+}
+
 // validateModelFiles 验证模型文件
 func (s *SherpaTTS) validateModelFiles() error {
 	requiredFiles := []string{
@@ -235,13 +242,14 @@ func (s *SherpaTTS) validateModelFiles() error {
 }
 
 // buildCommandArgs 构建命令行参数
-func (s *SherpaTTS) buildCommandArgs(text string) []string {
+func (s *SherpaTTS) buildCommandArgs(ctx context.Context, text string) []string {
+	speed, _, _ := resolveProsody(ctx, s.config)
 	args := []string{
 		"--model", s.config.SherpaConfig.ModelPath,
 		"--lexicon", s.config.SherpaConfig.LexiconPath,
 		"--tokens", s.config.SherpaConfig.TokensPath,
 		"--text", text,
-		"--speed", fmt.Sprintf("%.2f", s.config.Speed),
+		"--speed", fmt.Sprintf("%.2f", speed),
 		"--num-threads", strconv.Itoa(s.config.SherpaConfig.NumThreads),
 	}
 
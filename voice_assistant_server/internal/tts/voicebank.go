@@ -0,0 +1,176 @@
+package tts
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	vaaudio "voice_assistant/pkg/audio"
+)
+
+// voiceIDPattern 克隆声音ID（同时用作文件名）
+var voiceIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// VoiceBank 克隆声音的参考样本库：每个声音一个WAV文件和一个描述文件
+type VoiceBank struct {
+	dir    string
+	voices map[string]bankedVoice
+	mu     sync.RWMutex
+}
+
+// bankedVoice 登记的声音
+type bankedVoice struct {
+	Voice      Voice     `json:"voice"`
+	PromptText string    `json:"prompt_text"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// NewVoiceBank 打开参考样本库（目录不存在时创建）
+func NewVoiceBank(dir string) (*VoiceBank, error) {
+	if dir == "" {
+		dir = "data/voices"
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("创建声音目录失败: %w", err)
+	}
+
+	bank := &VoiceBank{dir: dir, voices: make(map[string]bankedVoice)}
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("读取声音描述失败: %w", err)
+		}
+		var voice bankedVoice
+		if err := json.Unmarshal(data, &voice); err != nil {
+			return nil, fmt.Errorf("解析声音描述失败(%s): %w", file, err)
+		}
+		bank.voices[voice.Voice.ID] = voice
+	}
+	return bank, nil
+}
+
+// Save 保存参考样本（同ID覆盖）
+func (b *VoiceBank) Save(sample VoiceSample, provider string) (Voice, error) {
+	if !voiceIDPattern.MatchString(sample.ID) {
+		return Voice{}, fmt.Errorf("%w: 声音ID只能包含字母、数字、下划线和连字符", ErrInvalidVoice)
+	}
+	if strings.TrimSpace(sample.PromptText) == "" {
+		return Voice{}, fmt.Errorf("%w: 缺少参考音频的文字内容", ErrInvalidVoice)
+	}
+	wav, err := vaaudio.ParseWAV(sample.Audio)
+	if err != nil {
+		return Voice{}, fmt.Errorf("%w: 参考音频必须是WAV格式", ErrFormatNotSupported)
+	}
+
+	name := sample.Name
+	if name == "" {
+		name = sample.ID
+	}
+	entry := bankedVoice{
+		Voice: Voice{
+			ID:          sample.ID,
+			Name:        name,
+			DisplayName: name,
+			Language:    sample.Language,
+			Locale:      sample.Language,
+			Style:       []string{"cloned"},
+			SampleRate:  wav.SampleRate,
+			Provider:    provider,
+			Description: "参考音频克隆的声音",
+		},
+		PromptText: sample.PromptText,
+		CreatedAt:  time.Now(),
+	}
+	meta, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return Voice{}, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := os.WriteFile(b.audioPath(sample.ID), sample.Audio, 0644); err != nil {
+		return Voice{}, fmt.Errorf("%w: %v", ErrFileWriteFailed, err)
+	}
+	if err := os.WriteFile(b.metaPath(sample.ID), meta, 0644); err != nil {
+		return Voice{}, fmt.Errorf("%w: %v", ErrFileWriteFailed, err)
+	}
+	b.voices[sample.ID] = entry
+	return entry.Voice, nil
+}
+
+// Get 获取登记的声音及其参考音频
+func (b *VoiceBank) Get(voiceID string) (bankedVoice, []byte, bool, error) {
+	b.mu.RLock()
+	entry, exists := b.voices[voiceID]
+	b.mu.RUnlock()
+	if !exists {
+		return bankedVoice{}, nil, false, nil
+	}
+
+	audio, err := os.ReadFile(b.audioPath(voiceID))
+	if err != nil {
+		return bankedVoice{}, nil, true, fmt.Errorf("读取参考音频失败: %w", err)
+	}
+	return entry, audio, true, nil
+}
+
+// Has 判断声音是否已登记
+func (b *VoiceBank) Has(voiceID string) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	_, exists := b.voices[voiceID]
+	return exists
+}
+
+// Delete 删除登记的声音
+func (b *VoiceBank) Delete(voiceID string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, exists := b.voices[voiceID]; !exists {
+		return ErrVoiceNotFound
+	}
+	for _, path := range []string{b.metaPath(voiceID), b.audioPath(voiceID)} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("删除声音失败: %w", err)
+		}
+	}
+	delete(b.voices, voiceID)
+	return nil
+}
+
+// List 登记的声音（按ID排序）
+func (b *VoiceBank) List() []Voice {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	voices := make([]Voice, 0, len(b.voices))
+	for _, entry := range b.voices {
+		voices = append(voices, entry.Voice)
+	}
+	sort.Slice(voices, func(i, j int) bool {
+		return voices[i].ID < voices[j].ID
+	})
+	return voices
+}
+
+// audioPath 参考音频路径
+func (b *VoiceBank) audioPath(voiceID string) string {
+	return filepath.Join(b.dir, voiceID+".wav")
+}
+
+// metaPath 描述文件路径
+func (b *VoiceBank) metaPath(voiceID string) string {
+	return filepath.Join(b.dir, voiceID+".json")
+}
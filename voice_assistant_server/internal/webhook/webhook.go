@@ -0,0 +1,267 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 事件类型
+const (
+	EventTurnCompleted = "turn.completed" // 一轮对话完成（识别文本和回复）
+)
+
+// 请求头
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderID        = "X-Webhook-Id"        // 事件ID，重试时不变，接收方可据此去重
+	HeaderTimestamp = "X-Webhook-Timestamp" // 发送时间（Unix秒）
+	HeaderSignature = "X-Webhook-Signature" // sha256=<HMAC-SHA256(secret, timestamp + "." + body) 的十六进制>
+)
+
+// ErrDispatcherClosed 分发器已关闭
+var ErrDispatcherClosed = errors.New("webhook dispatcher closed")
+
+// Config Webhook配置
+type Config struct {
+	Enabled        bool             `yaml:"enabled"`
+	Endpoints      []EndpointConfig `yaml:"endpoints"`
+	Timeout        time.Duration    `yaml:"timeout"`         // 单次请求超时
+	MaxRetries     int              `yaml:"max_retries"`     // 失败后的最多重试次数
+	RetryInterval  time.Duration    `yaml:"retry_interval"`  // 首次重试的等待时间，之后每次翻倍
+	QueueSize      int              `yaml:"queue_size"`      // 待发送事件的队列长度，队列满时丢弃新事件
+	RequireConsent bool             `yaml:"require_consent"` // 仅发送明确同意数据采集的会话
+}
+
+// EndpointConfig 接收事件的地址
+type EndpointConfig struct {
+	URL     string            `yaml:"url"`
+	Secret  string            `yaml:"secret"`  // 签名密钥（为空时不签名）
+	Headers map[string]string `yaml:"headers"` // 附加请求头（如认证令牌）
+}
+
+// Event 推送的事件
+type Event struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"event"`
+	Timestamp int64       `json:"timestamp"` // 毫秒
+	Data      interface{} `json:"data"`
+}
+
+// Turn 一轮对话（turn.completed 事件的数据）
+type Turn struct {
+	SessionID  string  `json:"session_id"`
+	UserID     string  `json:"user_id,omitempty"`
+	TenantID   string  `json:"tenant_id,omitempty"`
+	Language   string  `json:"language,omitempty"`
+	Persona    string  `json:"persona,omitempty"`
+	Speaker    string  `json:"speaker,omitempty"`
+	Transcript string  `json:"transcript"`
+	Confidence float64 `json:"confidence"`
+	Reply      string  `json:"reply"`
+	Model      string  `json:"model,omitempty"`
+	Tokens     int     `json:"tokens,omitempty"`
+	Moderated  bool    `json:"moderated,omitempty"` // 回复未通过内容审核，reply为提示语
+}
+
+// delivery 发往一个地址的事件
+type delivery struct {
+	endpoint EndpointConfig
+	event    string
+	id       string
+	body     []byte
+}
+
+// 未配置时使用的默认值
+const (
+	defaultTimeout       = 10 * time.Second
+	defaultRetryInterval = time.Second
+	defaultQueueSize     = 100
+	dispatchWorkers      = 2
+)
+
+// Dispatcher 异步推送事件：每个事件发往所有地址，失败时按指数退避重试，不阻塞对话处理
+type Dispatcher struct {
+	config Config
+	client *http.Client
+
+	queue chan delivery
+	stop  chan struct{} // 关闭时停止等待重试
+	wg    sync.WaitGroup
+
+	closed bool
+	mu     sync.RWMutex
+}
+
+// NewDispatcher 创建事件分发器并启动发送协程
+func NewDispatcher(config Config) (*Dispatcher, error) {
+	if len(config.Endpoints) == 0 {
+		return nil, fmt.Errorf("未配置Webhook地址")
+	}
+	for _, endpoint := range config.Endpoints {
+		if err := ValidateURL(endpoint.URL); err != nil {
+			return nil, err
+		}
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultTimeout
+	}
+	if config.RetryInterval <= 0 {
+		config.RetryInterval = defaultRetryInterval
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = defaultQueueSize
+	}
+
+	d := &Dispatcher{
+		config: config,
+		client: &http.Client{Timeout: config.Timeout},
+		queue:  make(chan delivery, config.QueueSize),
+		stop:   make(chan struct{}),
+	}
+	d.wg.Add(dispatchWorkers)
+	for i := 0; i < dispatchWorkers; i++ {
+		go d.worker()
+	}
+	return d, nil
+}
+
+// ValidateURL 检查Webhook地址（必须是http或https）
+func ValidateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("无效的Webhook地址: %q", raw)
+	}
+	return nil
+}
+
+// Send 推送事件（加入发送队列后立即返回，队列满时丢弃并返回错误）
+func (d *Dispatcher) Send(eventType string, data interface{}) error {
+	event := Event{ID: newEventID(), Type: eventType, Timestamp: time.Now().UnixMilli(), Data: data}
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("序列化Webhook事件失败: %w", err)
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.closed {
+		return ErrDispatcherClosed
+	}
+	for _, endpoint := range d.config.Endpoints {
+		select {
+		case d.queue <- delivery{endpoint: endpoint, event: eventType, id: event.ID, body: body}:
+		default:
+			return fmt.Errorf("Webhook发送队列已满，丢弃事件 %s", eventType)
+		}
+	}
+	return nil
+}
+
+// Close 停止接收事件，等待队列中的事件发送完（不再等待重试）
+func (d *Dispatcher) Close() {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return
+	}
+	d.closed = true
+	close(d.queue)
+	close(d.stop)
+	d.mu.Unlock()
+
+	d.wg.Wait()
+}
+
+// worker 发送协程
+func (d *Dispatcher) worker() {
+	defer d.wg.Done()
+	for item := range d.queue {
+		d.deliver(item)
+	}
+}
+
+// deliver 发送一个事件，网络错误、429和5xx响应按指数退避重试
+func (d *Dispatcher) deliver(item delivery) {
+	wait := d.config.RetryInterval
+	for attempt := 0; ; attempt++ {
+		retry, err := d.post(item)
+		if err == nil {
+			return
+		}
+		if !retry || attempt >= d.config.MaxRetries {
+			log.Printf("Webhook推送失败 (%s, %s, 已尝试%d次): %v", item.endpoint.URL, item.event, attempt+1, err)
+			return
+		}
+
+		select {
+		case <-d.stop:
+			log.Printf("Webhook推送失败，服务关闭不再重试 (%s, %s): %v", item.endpoint.URL, item.event, err)
+			return
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+// post 发送一次请求，返回失败时是否值得重试
+func (d *Dispatcher) post(item delivery) (bool, error) {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, item.endpoint.URL, bytes.NewReader(item.body))
+	if err != nil {
+		return false, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, item.event)
+	req.Header.Set(HeaderID, item.id)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	if item.endpoint.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(item.endpoint.Secret, timestamp, item.body))
+	}
+	for key, value := range item.endpoint.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("HTTP %d", resp.StatusCode)
+}
+
+// Sign 计算请求签名：sha256=<HMAC-SHA256(secret, timestamp + "." + body)>
+// 接收方用同一密钥重新计算并比较，同时检查时间戳防止重放。
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// newEventID 随机事件ID
+func newEventID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,73 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDispatcherSignsAndRetries(t *testing.T) {
+	var attempts atomic.Int32
+	received := make(chan Event, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, Sign("secret", r.Header.Get(HeaderTimestamp), body), r.Header.Get(HeaderSignature))
+		assert.Equal(t, EventTurnCompleted, r.Header.Get(HeaderEvent))
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+
+		// 第一次返回503，重试后成功
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var event Event
+		assert.NoError(t, json.Unmarshal(body, &event))
+		received <- event
+	}))
+	defer server.Close()
+
+	d, err := NewDispatcher(Config{
+		Endpoints:     []EndpointConfig{{URL: server.URL, Secret: "secret", Headers: map[string]string{"Authorization": "Bearer token"}}},
+		MaxRetries:    2,
+		RetryInterval: 10 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	defer d.Close()
+
+	require.NoError(t, d.Send(EventTurnCompleted, Turn{SessionID: "s1", Transcript: "开灯", Reply: "好的，已为你打开客厅的灯。"}))
+	select {
+	case event := <-received:
+		assert.Equal(t, EventTurnCompleted, event.Type)
+		assert.Equal(t, "开灯", event.Data.(map[string]interface{})["transcript"])
+	case <-time.After(2 * time.Second):
+		t.Fatal("未收到Webhook事件")
+	}
+	assert.Equal(t, int32(2), attempts.Load())
+}
+
+func TestDispatcherNoRetryOnClientError(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	d, err := NewDispatcher(Config{Endpoints: []EndpointConfig{{URL: server.URL}}, MaxRetries: 3, RetryInterval: time.Millisecond})
+	require.NoError(t, err)
+	require.NoError(t, d.Send(EventTurnCompleted, Turn{SessionID: "s1"}))
+	d.Close()
+
+	assert.Equal(t, int32(1), attempts.Load())
+	assert.ErrorIs(t, d.Send(EventTurnCompleted, Turn{}), ErrDispatcherClosed)
+
+	_, err = NewDispatcher(Config{Endpoints: []EndpointConfig{{URL: "ftp://example.com"}}})
+	assert.Error(t, err)
+}